@@ -1,13 +1,10 @@
 package com.blerpc.android
 
-import android.Manifest
 import android.content.Intent
-import android.content.pm.PackageManager
 import android.os.Bundle
 import android.util.Log
 import androidx.activity.ComponentActivity
 import androidx.activity.compose.setContent
-import androidx.activity.result.contract.ActivityResultContracts
 import androidx.compose.material3.MaterialTheme
 import androidx.compose.runtime.LaunchedEffect
 import androidx.compose.runtime.collectAsState
@@ -15,8 +12,8 @@ import androidx.compose.runtime.getValue
 import androidx.compose.runtime.mutableStateOf
 import androidx.compose.runtime.remember
 import androidx.compose.runtime.setValue
-import androidx.core.content.ContextCompat
 import com.blerpc.android.ble.ScannedDevice
+import com.blerpc.android.client.BlePermissions
 import com.blerpc.android.client.BlerpcClient
 import com.blerpc.android.test.TestRunner
 import com.blerpc.android.ui.LogScreen
@@ -30,15 +27,15 @@ class MainActivity : ComponentActivity() {
     private var autoRunPending = false
     private var autoRunIterations = 1
 
-    private val permissionLauncher =
-        registerForActivityResult(
-            ActivityResultContracts.RequestMultiplePermissions(),
-        ) { /* permissions granted or denied */ }
+    // The manifest declares BLUETOOTH_SCAN without neverForLocation, so scans
+    // also need location access.
+    private val permissionRequest =
+        BlePermissions.register(this, this, includeLocation = true) { /* permissions granted or denied */ }
 
     override fun onCreate(savedInstanceState: Bundle?) {
         super.onCreate(savedInstanceState)
         testRunner = TestRunner(applicationContext)
-        requestPermissions()
+        permissionRequest.launch()
 
         // Check if launched with --es action run_tests --ei iterations N
         if (intent?.getStringExtra("action") == "run_tests") {
@@ -113,18 +110,4 @@ class MainActivity : ComponentActivity() {
             autoRunIterations = intent.getIntExtra("iterations", 1)
         }
     }
-
-    private fun requestPermissions() {
-        val needed =
-            arrayOf(
-                Manifest.permission.BLUETOOTH_SCAN,
-                Manifest.permission.BLUETOOTH_CONNECT,
-                Manifest.permission.ACCESS_FINE_LOCATION,
-            ).filter {
-                ContextCompat.checkSelfPermission(this, it) != PackageManager.PERMISSION_GRANTED
-            }
-        if (needed.isNotEmpty()) {
-            permissionLauncher.launch(needed.toTypedArray())
-        }
-    }
 }
@@ -15,6 +15,8 @@ import android.bluetooth.le.ScanSettings
 import android.content.Context
 import android.os.Build
 import android.util.Log
+import com.blerpc.android.client.GeneratedUuids.CHAR_UUID
+import com.blerpc.android.client.GeneratedUuids.SERVICE_UUID
 import kotlinx.coroutines.channels.Channel
 import kotlinx.coroutines.delay
 import kotlinx.coroutines.suspendCancellableCoroutine
@@ -25,8 +27,6 @@ import kotlin.coroutines.resumeWithException
 
 private const val TAG = "BleTransport"
 
-val SERVICE_UUID: UUID = UUID.fromString("12340001-0000-1000-8000-00805f9b34fb")
-private val CHAR_UUID = UUID.fromString("12340002-0000-1000-8000-00805f9b34fb")
 private val CCCD_UUID = UUID.fromString("00002902-0000-1000-8000-00805f9b34fb")
 
 data class ScannedDevice(
@@ -41,7 +41,7 @@ data class ScannedDevice(
 )
 
 @SuppressLint("MissingPermission")
-class BleTransport(private val context: Context) : Transport {
+class BleTransport(internal val context: Context) : Transport {
     private var gatt: BluetoothGatt? = null
     private var writeChar: BluetoothGattCharacteristic? = null
     private val notifyChannel = Channel<ByteArray>(Channel.UNLIMITED)
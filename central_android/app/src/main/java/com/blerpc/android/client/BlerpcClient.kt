@@ -3,7 +3,6 @@ package com.blerpc.android.client
 import android.content.Context
 import android.util.Log
 import com.blerpc.android.ble.BleTransport
-import com.blerpc.android.ble.SERVICE_UUID
 import com.blerpc.android.ble.ScannedDevice
 import com.blerpc.android.ble.Transport
 import com.blerpc.protocol.BLERPC_ERROR_RESPONSE_TOO_LARGE
@@ -22,9 +21,16 @@ import com.blerpc.protocol.makeKeyExchange
 import com.blerpc.protocol.makeStreamEndC2P
 import com.blerpc.protocol.makeTimeoutRequest
 import kotlinx.coroutines.TimeoutCancellationException
+import kotlinx.coroutines.channels.Channel
+import kotlinx.coroutines.flow.Flow
+import kotlinx.coroutines.flow.asFlow
+import kotlinx.coroutines.flow.flow
+import kotlinx.coroutines.flow.toList
 import java.nio.ByteBuffer
 import java.nio.ByteOrder
 import java.util.UUID
+import java.util.concurrent.ConcurrentHashMap
+import java.util.concurrent.CopyOnWriteArrayList
 
 class PayloadTooLargeError(actual: Int, limit: Int) :
     Exception("Request payload ($actual bytes) exceeds peripheral limit ($limit bytes)")
@@ -50,12 +56,17 @@ class BlerpcClient(
     private var timeoutMs: Long = 100
     private var maxRequestPayloadSize: Int? = null
     private var maxResponsePayloadSize: Int? = null
+    private var peripheralFlags = 0
 
     // Encryption state
     private var session: BlerpcCryptoSession? = null
 
+    // Event subscriptions: channels per event name
+    private val eventChannels = ConcurrentHashMap<String, MutableList<Channel<ByteArray>>>()
+
     val mtu: Int get() = transport.mtu
     val isEncrypted: Boolean get() = session != null
+    override val capabilityFlags: Int get() = peripheralFlags
 
     internal fun initForTest(mtu: Int = 247) {
         splitter = ContainerSplitter(mtu = mtu)
@@ -63,13 +74,17 @@ class BlerpcClient(
 
     suspend fun scan(
         timeout: Long = 5000,
-        serviceUuid: UUID? = SERVICE_UUID,
+        serviceUuid: UUID? = GeneratedUuids.SERVICE_UUID,
     ): List<ScannedDevice> {
-        return (transport as BleTransport).scan(timeout, serviceUuid)
+        val ble = transport as BleTransport
+        BlePermissions.check(ble.context)
+        return ble.scan(timeout, serviceUuid)
     }
 
     suspend fun connect(device: ScannedDevice) {
-        (transport as BleTransport).connect(device)
+        val ble = transport as BleTransport
+        BlePermissions.check(ble.context)
+        ble.connect(device)
         splitter = ContainerSplitter(mtu = transport.mtu)
 
         try {
@@ -130,6 +145,7 @@ class BlerpcClient(
             }
             maxRequestPayloadSize = maxReq
             maxResponsePayloadSize = maxResp
+            peripheralFlags = flags
             Log.d(
                 TAG,
                 "Peripheral capabilities: max_request=$maxReq, max_response=$maxResp, flags=0x${flags.toString(16).padStart(4, '0')}",
@@ -240,6 +256,7 @@ class BlerpcClient(
                 // Decrypt if active
                 val decrypted = decryptPayload(result)
                 val resp = CommandPacket.deserialize(decrypted)
+                if (dispatchEvent(resp)) continue
                 if (resp.cmdType != CommandType.RESPONSE) {
                     throw ProtocolException("Expected response, got type=${resp.cmdType}")
                 }
@@ -254,75 +271,86 @@ class BlerpcClient(
     override suspend fun streamReceive(
         cmdName: String,
         requestData: ByteArray,
-    ): List<ByteArray> {
-        val s = splitter ?: throw IllegalStateException("Not connected")
+    ): List<ByteArray> = streamReceiveFlow(cmdName, requestData).toList()
 
-        val cmd =
-            CommandPacket(
-                cmdType = CommandType.REQUEST,
-                cmdName = cmdName,
-                data = requestData,
-            )
-        val payload = cmd.serialize()
+    override fun streamReceiveFlow(
+        cmdName: String,
+        requestData: ByteArray,
+    ): Flow<ByteArray> =
+        flow {
+            val s = splitter ?: throw IllegalStateException("Not connected")
 
-        maxRequestPayloadSize?.let { limit ->
-            if (payload.size > limit) throw PayloadTooLargeError(payload.size, limit)
-        }
+            val cmd =
+                CommandPacket(
+                    cmdType = CommandType.REQUEST,
+                    cmdName = cmdName,
+                    data = requestData,
+                )
+            val payload = cmd.serialize()
 
-        // Encrypt if active, then split into containers and send
-        val sendPayload = encryptPayload(payload)
-        val containers = s.split(sendPayload)
-        for (c in containers) {
-            transport.write(c.serialize())
-        }
+            maxRequestPayloadSize?.let { limit ->
+                if (payload.size > limit) throw PayloadTooLargeError(payload.size, limit)
+            }
 
-        val results = mutableListOf<ByteArray>()
-        assembler.reset()
-        var firstRead = true
-        while (true) {
-            // First read uses longer timeout (peripheral processing time)
-            val t = if (firstRead) maxOf(timeoutMs, 2000) else timeoutMs
-            firstRead = false
-            val notifyData = transport.readNotify(t)
-            val container = Container.deserialize(notifyData)
+            // Encrypt if active, then split into containers and send
+            val sendPayload = encryptPayload(payload)
+            val containers = s.split(sendPayload)
+            for (c in containers) {
+                transport.write(c.serialize())
+            }
 
-            if (container.containerType == ContainerType.CONTROL) {
-                if (container.controlCmd == ControlCmd.STREAM_END_P2C) {
-                    break
-                }
-                if (container.controlCmd == ControlCmd.ERROR && container.payload.isNotEmpty()) {
-                    val errorCode = container.payload[0]
-                    if (errorCode == BLERPC_ERROR_RESPONSE_TOO_LARGE) {
-                        throw ResponseTooLargeError("Response exceeds peripheral's max_response_payload_size")
+            assembler.reset()
+            var firstRead = true
+            while (true) {
+                // First read uses longer timeout (peripheral processing time)
+                val t = if (firstRead) maxOf(timeoutMs, 2000) else timeoutMs
+                firstRead = false
+                val notifyData = transport.readNotify(t)
+                val container = Container.deserialize(notifyData)
+
+                if (container.containerType == ContainerType.CONTROL) {
+                    if (container.controlCmd == ControlCmd.STREAM_END_P2C) {
+                        break
                     }
-                    throw PeripheralErrorException(errorCode)
+                    if (container.controlCmd == ControlCmd.ERROR && container.payload.isNotEmpty()) {
+                        val errorCode = container.payload[0]
+                        if (errorCode == BLERPC_ERROR_RESPONSE_TOO_LARGE) {
+                            throw ResponseTooLargeError("Response exceeds peripheral's max_response_payload_size")
+                        }
+                        throw PeripheralErrorException(errorCode)
+                    }
+                    continue
                 }
-                continue
-            }
 
-            val result = assembler.feed(container)
-            if (result != null) {
-                // Decrypt each received response
-                val decrypted = decryptPayload(result)
-                val resp = CommandPacket.deserialize(decrypted)
-                if (resp.cmdType != CommandType.RESPONSE) {
-                    throw ProtocolException("Expected response, got type=${resp.cmdType}")
+                val result = assembler.feed(container)
+                if (result != null) {
+                    // Decrypt each received response
+                    val decrypted = decryptPayload(result)
+                    val resp = CommandPacket.deserialize(decrypted)
+                    if (dispatchEvent(resp)) continue
+                    if (resp.cmdType != CommandType.RESPONSE) {
+                        throw ProtocolException("Expected response, got type=${resp.cmdType}")
+                    }
+                    emit(resp.data)
                 }
-                results.add(resp.data)
             }
         }
-        return results
-    }
 
     override suspend fun streamSend(
         cmdName: String,
         messages: List<ByteArray>,
         finalCmdName: String,
+    ): ByteArray = streamSendFlow(cmdName, messages.asFlow(), finalCmdName)
+
+    override suspend fun streamSendFlow(
+        cmdName: String,
+        messages: Flow<ByteArray>,
+        finalCmdName: String,
     ): ByteArray {
         val s = splitter ?: throw IllegalStateException("Not connected")
 
-        // Encrypt each message
-        for (msgData in messages) {
+        // Encrypt each message as it is produced
+        messages.collect { msgData ->
             val cmd =
                 CommandPacket(
                     cmdType = CommandType.REQUEST,
@@ -367,6 +395,7 @@ class BlerpcClient(
                 // Decrypt final response
                 val decrypted = decryptPayload(result)
                 val resp = CommandPacket.deserialize(decrypted)
+                if (dispatchEvent(resp)) continue
                 if (resp.cmdType != CommandType.RESPONSE) {
                     throw ProtocolException("Expected response, got type=${resp.cmdType}")
                 }
@@ -378,6 +407,61 @@ class BlerpcClient(
         }
     }
 
+    /**
+     * The data of each [eventName] event the peripheral notifies. RPCs set
+     * aside the events arriving while they wait for a response; between RPCs
+     * the collector reads notifications itself. Stop collecting to
+     * unsubscribe.
+     */
+    fun events(eventName: String): Flow<ByteArray> =
+        flow {
+            if (splitter == null) throw IllegalStateException("Not connected")
+
+            val channel = Channel<ByteArray>(Channel.UNLIMITED)
+            val channels = eventChannels.getOrPut(eventName) { CopyOnWriteArrayList() }
+            channels.add(channel)
+            try {
+                while (true) {
+                    var data = channel.tryReceive().getOrNull()
+                    while (data == null) {
+                        exclusive { readEvent() }
+                        data = channel.tryReceive().getOrNull()
+                    }
+                    emit(data)
+                }
+            } finally {
+                channels.remove(channel)
+            }
+        }
+
+    /** Reads one notification outside an RPC and dispatches it if an event. */
+    private suspend fun readEvent() {
+        val notifyData =
+            try {
+                transport.readNotify(timeoutMs)
+            } catch (_: TimeoutCancellationException) {
+                return
+            }
+        val container = Container.deserialize(notifyData)
+        if (container.containerType == ContainerType.CONTROL) return
+        val result = assembler.feed(container) ?: return
+        dispatchEvent(CommandPacket.deserialize(decryptPayload(result)))
+    }
+
+    /**
+     * Passes [resp] to the subscribers of its name; false if it is no event.
+     * Names stay known after their last subscriber leaves, so late events
+     * are dropped rather than mistaken for RPC responses.
+     */
+    private fun dispatchEvent(resp: CommandPacket): Boolean {
+        val channels = eventChannels[resp.cmdName]
+        if (resp.cmdType != CommandType.RESPONSE || channels == null) return false
+        for (channel in channels) {
+            channel.trySend(resp.data)
+        }
+        return true
+    }
+
     fun disconnect() {
         transport.disconnect()
     }
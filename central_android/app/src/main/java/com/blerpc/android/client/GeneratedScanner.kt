@@ -0,0 +1,29 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.blerpc.android.ble.ScannedDevice
+
+/** A blerpc peripheral found by a scan; pass [device] to connect. */
+data class DiscoveredDevice(
+    val device: ScannedDevice,
+) {
+    val name: String? get() = device.name
+    val address: String get() = device.address
+    val rssi: Int get() = device.rssi
+}
+
+/** Picks the blerpc peripherals out of scan results. */
+object GeneratedScanner {
+    /** Service UUID advertised by blerpc peripherals. */
+    const val SERVICE_UUID = "12340001-0000-1000-8000-00805f9b34fb"
+
+    /** Returns [device] as a [DiscoveredDevice], or null if it is not a blerpc peripheral. */
+    fun discover(device: ScannedDevice): DiscoveredDevice? {
+        if (device.serviceUuids.none { it.equals(SERVICE_UUID, ignoreCase = true) }) return null
+        return DiscoveredDevice(device)
+    }
+
+    /** Returns the blerpc peripherals among [devices], strongest first. */
+    fun discoverAll(devices: List<ScannedDevice>): List<DiscoveredDevice> =
+        devices.mapNotNull { discover(it) }.sortedByDescending { it.rssi }
+}
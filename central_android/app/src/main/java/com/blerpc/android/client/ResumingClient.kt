@@ -0,0 +1,86 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import kotlinx.coroutines.CancellationException
+import kotlinx.coroutines.TimeoutCancellationException
+
+/** Commands that are safe to run twice; retried after a reconnect. */
+val IDEMPOTENT_COMMANDS: Set<String> =
+    setOf(
+        "echo",
+        "flash_read",
+    )
+
+/**
+ * The connection dropped while a non-idempotent call was in flight. The
+ * peripheral may or may not have run the command, so it is not retried.
+ */
+class CallInterruptedException(val command: String, cause: Throwable) :
+    TransportException("$command: interrupted by disconnect", cause)
+
+/** App-level policy of [ResumingClient]. Subclass to override. */
+open class ResumePolicy(val maxRetries: Int = 1) {
+    /** Whether to reconnect and retry [command] after its [attempt]-th failure. */
+    open fun shouldRetry(
+        command: String,
+        attempt: Int,
+        error: Throwable,
+    ): Boolean = command in IDEMPOTENT_COMMANDS && attempt <= maxRetries
+}
+
+/**
+ * Recovers calls of [client] cut off by a dropped connection.
+ *
+ * Calls made while disconnected run [reconnect] first. Interrupted idempotent
+ * calls are retried as [policy] allows; other interrupted calls throw
+ * [CallInterruptedException]. A failure while [isConnected] still holds is
+ * thrown unchanged.
+ */
+class ResumingClient(
+    private val client: GeneratedClient,
+    private val isConnected: () -> Boolean,
+    private val reconnect: suspend () -> Unit,
+    private val policy: ResumePolicy = ResumePolicy(),
+) : GeneratedClient() {
+    override suspend fun call(
+        cmdName: String,
+        requestData: ByteArray,
+    ): ByteArray = resume(cmdName) { client.call(cmdName, requestData) }
+
+    override suspend fun streamReceive(
+        cmdName: String,
+        requestData: ByteArray,
+    ): List<ByteArray> = resume(cmdName) { client.streamReceive(cmdName, requestData) }
+
+    override suspend fun streamSend(
+        cmdName: String,
+        messages: List<ByteArray>,
+        finalCmdName: String,
+    ): ByteArray = resume(cmdName) { client.streamSend(cmdName, messages, finalCmdName) }
+
+    override val capabilityFlags: Int get() = client.capabilityFlags
+
+    private suspend fun <T> resume(
+        command: String,
+        block: suspend () -> T,
+    ): T {
+        var attempt = 0
+        while (true) {
+            if (!isConnected()) {
+                reconnect()
+            }
+            try {
+                return client.exclusive { block() }
+            } catch (e: Exception) {
+                // A read timeout is a TimeoutCancellationException; only real
+                // cancellation ends the call right away.
+                if (e is CancellationException && e !is TimeoutCancellationException) throw e
+                if (isConnected()) throw e
+                attempt++
+                if (!policy.shouldRetry(command, attempt, e)) {
+                    throw if (command in IDEMPOTENT_COMMANDS) e else CallInterruptedException(command, e)
+                }
+            }
+        }
+    }
+}
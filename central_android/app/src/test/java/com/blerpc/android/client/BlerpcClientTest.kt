@@ -9,6 +9,7 @@ import com.blerpc.protocol.ContainerSplitter
 import com.blerpc.protocol.ContainerType
 import com.blerpc.protocol.ControlCmd
 import com.blerpc.protocol.makeStreamEndP2C
+import kotlinx.coroutines.flow.first
 import kotlinx.coroutines.test.runTest
 import org.junit.Assert.assertArrayEquals
 import org.junit.Assert.assertEquals
@@ -267,6 +268,19 @@ class BlerpcClientTest {
             }
         }
 
+    @Test
+    fun eventsReadBetweenCallsAndSetAsideByCalls() =
+        runTest {
+            val (client, transport) = createClient()
+            transport.enqueueRead(buildResponse("button_event", byteArrayOf(0x08, 0x02)))
+            assertArrayEquals(byteArrayOf(0x08, 0x02), client.events("button_event").first())
+
+            // A later event of the same name does not end a call
+            transport.enqueueRead(buildResponse("button_event", byteArrayOf(0x08, 0x03)))
+            transport.enqueueRead(buildResponse("echo", byteArrayOf(0x0a)))
+            assertArrayEquals(byteArrayOf(0x0a), client.call("echo", ByteArray(0)))
+        }
+
     @Test
     fun exceptionClasses() {
         val payloadErr = PayloadTooLargeError(100, 50)
@@ -5,8 +5,9 @@ import 'dart:io' show Platform;
 import 'package:flutter/foundation.dart';
 import 'package:flutter_blue_plus/flutter_blue_plus.dart';
 
-const String serviceUuid = '12340001-0000-1000-8000-00805f9b34fb';
-const String charUuid = '12340002-0000-1000-8000-00805f9b34fb';
+import '../client/generated_uuids.dart';
+
+export '../client/generated_uuids.dart';
 
 class ScannedDevice {
   final BluetoothDevice device;
@@ -1,9 +1,6 @@
 import CoreBluetooth
 import Foundation
 
-let serviceUUID = CBUUID(string: "12340001-0000-1000-8000-00805f9b34fb")
-private let charUUID = CBUUID(string: "12340002-0000-1000-8000-00805f9b34fb")
-
 enum BleTransportError: Error {
     case scanTimeout
     case connectionFailed
@@ -79,7 +76,7 @@ final class BleTransport: NSObject, CBCentralManagerDelegate, CBPeripheralDelega
 
     func scan(
         timeout: TimeInterval = 5,
-        serviceUUID filterUUID: CBUUID? = serviceUUID
+        serviceUUID filterUUID: CBUUID? = GeneratedUUIDs.service
     ) async throws -> [ScannedDevice] {
         let cm = try await ensureCentralManager()
 
@@ -113,20 +110,20 @@ final class BleTransport: NSObject, CBCentralManagerDelegate, CBPeripheralDelega
         // Discover services
         try await withCheckedThrowingContinuation { (cont: CheckedContinuation<Void, any Error>) in
             self.connectContinuation = cont
-            foundPeripheral.discoverServices([serviceUUID])
+            foundPeripheral.discoverServices([GeneratedUUIDs.service])
         }
 
-        guard let service = foundPeripheral.services?.first(where: { $0.uuid == serviceUUID }) else {
+        guard let service = foundPeripheral.services?.first(where: { $0.uuid == GeneratedUUIDs.service }) else {
             throw BleTransportError.serviceNotFound
         }
 
         // Discover characteristics
         try await withCheckedThrowingContinuation { (cont: CheckedContinuation<Void, any Error>) in
             self.connectContinuation = cont
-            foundPeripheral.discoverCharacteristics([charUUID], for: service)
+            foundPeripheral.discoverCharacteristics([GeneratedUUIDs.characteristic], for: service)
         }
 
-        guard let char = service.characteristics?.first(where: { $0.uuid == charUUID }) else {
+        guard let char = service.characteristics?.first(where: { $0.uuid == GeneratedUUIDs.characteristic }) else {
             throw BleTransportError.characteristicNotFound
         }
         self.writeChar = char
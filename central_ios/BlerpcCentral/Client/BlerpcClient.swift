@@ -18,28 +18,36 @@ enum BlerpcClientError: Error {
 
 private let logger = Logger(subsystem: "com.blerpc", category: "BlerpcClient")
 
-final class BlerpcClient: GeneratedClientProtocol {
+final class BlerpcClient: GeneratedClientProtocol, @unchecked Sendable {
     let transport = BleTransport()
+    let callSerializer = CallSerializer()
     private var splitter: ContainerSplitter?
     private let assembler = ContainerAssembler()
     private var timeoutMs: Int = 100
     private var maxRequestPayloadSize: Int?
     private var maxResponsePayloadSize: Int?
+    private(set) var capabilityFlags = 0
 
     private var session: BlerpcCryptoSession?
     var requireEncryption: Bool = true
 
+    // Event subscriptions: continuations per event name
+    private let eventLock = NSLock()
+    private var eventContinuations: [String: [UUID: AsyncThrowingStream<Data, Error>.Continuation]] = [:]
+
     var mtu: Int { transport.mtu }
     var isEncrypted: Bool { session != nil }
 
     func scan(
         timeout: TimeInterval = 5,
-        serviceUUID filterUUID: CBUUID? = serviceUUID
+        serviceUUID filterUUID: CBUUID? = GeneratedUUIDs.service
     ) async throws -> [ScannedDevice] {
+        try await BleAuthorization.shared.waitUntilAvailable()
         return try await transport.scan(timeout: timeout, serviceUUID: filterUUID)
     }
 
     func connect(device: ScannedDevice) async throws {
+        try await BleAuthorization.shared.waitUntilAvailable()
         try await transport.connect(device: device)
         let mtuVal = transport.mtu
         splitter = ContainerSplitter(mtu: mtuVal)
@@ -96,6 +104,7 @@ final class BlerpcClient: GeneratedClientProtocol {
             }
             maxRequestPayloadSize = maxReq
             maxResponsePayloadSize = maxResp
+            capabilityFlags = flags
             // swiftlint:disable:next line_length
             logger.info("Peripheral capabilities: max_request=\(maxReq), max_response=\(maxResp), flags=0x\(String(flags, radix: 16, uppercase: false))")
 
@@ -151,6 +160,7 @@ final class BlerpcClient: GeneratedClientProtocol {
     }
 
     func call(cmdName: String, requestData: Data) async throws -> Data {
+        try BleAuthorization.shared.check()
         guard let s = splitter else { throw BlerpcClientError.notConnected }
 
         let cmd = CommandPacket(cmdType: .request, cmdName: cmdName, data: requestData)
@@ -188,6 +198,7 @@ final class BlerpcClient: GeneratedClientProtocol {
             if let result = assembler.feed(container) {
                 let decrypted = try decryptPayload(result)
                 let resp = try CommandPacket.deserialize(decrypted)
+                if dispatchEvent(resp) { continue }
                 guard resp.cmdType == .response else {
                     throw BlerpcClientError.unexpectedResponseType(resp.cmdType.rawValue)
                 }
@@ -202,6 +213,35 @@ final class BlerpcClient: GeneratedClientProtocol {
     }
 
     func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        var results: [Data] = []
+        try await receiveStream(cmdName: cmdName, requestData: requestData) { results.append($0) }
+        return results
+    }
+
+    func streamReceiveStream(cmdName: String, requestData: Data) -> AsyncThrowingStream<Data, Error> {
+        AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    try await self.receiveStream(cmdName: cmdName, requestData: requestData) {
+                        continuation.yield($0)
+                    }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+
+    /// Sends a P→C stream request and passes each response to `onResponse`
+    /// as it arrives, until the peripheral ends the stream.
+    private func receiveStream(
+        cmdName: String,
+        requestData: Data,
+        onResponse: (Data) -> Void
+    ) async throws {
+        try BleAuthorization.shared.check()
         guard let s = splitter else { throw BlerpcClientError.notConnected }
 
         let cmd = CommandPacket(cmdType: .request, cmdName: cmdName, data: requestData)
@@ -217,7 +257,6 @@ final class BlerpcClient: GeneratedClientProtocol {
             try transport.write(c.serialize())
         }
 
-        var results: [Data] = []
         assembler.reset()
         var firstRead = true
         while true {
@@ -243,13 +282,32 @@ final class BlerpcClient: GeneratedClientProtocol {
             if let result = assembler.feed(container) {
                 let decrypted = try decryptPayload(result)
                 let resp = try CommandPacket.deserialize(decrypted)
+                if dispatchEvent(resp) { continue }
                 guard resp.cmdType == .response else {
                     throw BlerpcClientError.unexpectedResponseType(resp.cmdType.rawValue)
                 }
-                results.append(resp.data)
+                onResponse(resp.data)
             }
         }
-        return results
+    }
+
+    /// Stops the P→C stream in progress and drains it up to its end, so the
+    /// next call does not read responses already in flight.
+    func streamCancel() async {
+        guard let s = splitter else { return }
+        do {
+            try transport.write(cancelContainer(transactionId: s.nextTransactionId()))
+            while true {
+                let container = try Container.deserialize(try await transport.readNotify(timeoutMs: timeoutMs))
+                if container.containerType == .control,
+                   container.controlCmd == .streamEndP2C || container.controlCmd == .error {
+                    break
+                }
+            }
+        } catch {
+            logger.warning("Cancelled stream did not end: \(error.localizedDescription)")
+        }
+        assembler.reset()
     }
 
     func streamSend(
@@ -257,17 +315,42 @@ final class BlerpcClient: GeneratedClientProtocol {
         messages: [Data],
         finalCmdName: String
     ) async throws -> Data {
+        try BleAuthorization.shared.check()
         guard let s = splitter else { throw BlerpcClientError.notConnected }
 
         for msgData in messages {
-            let cmd = CommandPacket(cmdType: .request, cmdName: cmdName, data: msgData)
-            let payload = try cmd.serialize()
-            let sendPayload = try encryptPayload(payload)
-            let containers = try s.split(sendPayload)
-            for c in containers {
-                try transport.write(c.serialize())
-            }
+            try sendStreamMessage(cmdName: cmdName, data: msgData, splitter: s)
+        }
+        return try await finishStreamSend(finalCmdName: finalCmdName, splitter: s)
+    }
+
+    func streamSend<S: AsyncSequence>(
+        cmdName: String,
+        messages: S,
+        finalCmdName: String
+    ) async throws -> Data where S.Element == Data {
+        try BleAuthorization.shared.check()
+        guard let s = splitter else { throw BlerpcClientError.notConnected }
+
+        // Send each message as it is produced
+        for try await msgData in messages {
+            try sendStreamMessage(cmdName: cmdName, data: msgData, splitter: s)
+        }
+        return try await finishStreamSend(finalCmdName: finalCmdName, splitter: s)
+    }
+
+    private func sendStreamMessage(cmdName: String, data: Data, splitter s: ContainerSplitter) throws {
+        let cmd = CommandPacket(cmdType: .request, cmdName: cmdName, data: data)
+        let payload = try cmd.serialize()
+        let sendPayload = try encryptPayload(payload)
+        let containers = try s.split(sendPayload)
+        for c in containers {
+            try transport.write(c.serialize())
         }
+    }
+
+    /// Sends STREAM_END_C2P and waits for the response of a C→P stream.
+    private func finishStreamSend(finalCmdName: String, splitter s: ContainerSplitter) async throws -> Data {
 
         // Send STREAM_END_C2P
         let tid = s.nextTransactionId()
@@ -297,6 +380,7 @@ final class BlerpcClient: GeneratedClientProtocol {
             if let result = assembler.feed(container) {
                 let decrypted = try decryptPayload(result)
                 let resp = try CommandPacket.deserialize(decrypted)
+                if dispatchEvent(resp) { continue }
                 guard resp.cmdType == .response else {
                     throw BlerpcClientError.unexpectedResponseType(resp.cmdType.rawValue)
                 }
@@ -310,6 +394,66 @@ final class BlerpcClient: GeneratedClientProtocol {
         }
     }
 
+    /// The data of each `eventName` event the peripheral notifies. RPCs set
+    /// aside the events arriving while they wait for a response; between RPCs
+    /// the subscription reads notifications itself. Stop iterating to
+    /// unsubscribe.
+    func events(eventName: String) -> AsyncThrowingStream<Data, Error> {
+        AsyncThrowingStream { continuation in
+            let id = UUID()
+            eventLock.lock()
+            eventContinuations[eventName, default: [:]][id] = continuation
+            eventLock.unlock()
+            let task = Task {
+                do {
+                    while !Task.isCancelled {
+                        try await self.exclusive { try await self.readEvent() }
+                    }
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in
+                task.cancel()
+                self.eventLock.lock()
+                self.eventContinuations[eventName]?[id] = nil
+                self.eventLock.unlock()
+            }
+        }
+    }
+
+    /// Reads one notification outside an RPC and dispatches it if an event.
+    private func readEvent() async throws {
+        guard splitter != nil else { throw BlerpcClientError.notConnected }
+        let notifyData: Data
+        do {
+            notifyData = try await transport.readNotify(timeoutMs: timeoutMs)
+        } catch BleTransportError.readTimeout {
+            return
+        }
+        let container = try Container.deserialize(notifyData)
+        if container.containerType == .control { return }
+        if let result = assembler.feed(container) {
+            let resp = try CommandPacket.deserialize(try decryptPayload(result))
+            _ = dispatchEvent(resp)
+        }
+    }
+
+    /// Passes `resp` to the subscribers of its name; false if it is no event.
+    /// Names stay known after their last subscriber leaves, so late events
+    /// are dropped rather than mistaken for RPC responses.
+    private func dispatchEvent(_ resp: CommandPacket) -> Bool {
+        eventLock.lock()
+        defer { eventLock.unlock() }
+        guard resp.cmdType == .response, let continuations = eventContinuations[resp.cmdName] else {
+            return false
+        }
+        for continuation in continuations.values {
+            continuation.yield(resp.data)
+        }
+        return true
+    }
+
     func disconnect() {
         transport.disconnect()
     }
@@ -4,7 +4,7 @@ from __future__ import annotations
 
 import asyncio
 import logging
-from collections.abc import AsyncIterator
+from collections.abc import AsyncIterable, AsyncIterator, Iterable
 
 from blerpc_protocol.command import CommandPacket, CommandType
 from blerpc_protocol.container import (
@@ -22,7 +22,7 @@ from blerpc_protocol.container import (
 )
 from blerpc_protocol.crypto import BlerpcCryptoSession, central_perform_key_exchange
 
-from .generated.generated_client import GeneratedClientMixin
+from .generated.generated_client import GeneratedClientMixin, _rpc_lock, make_cancel
 from .transport import SERVICE_UUID, BleTransport, ScannedDevice
 
 logger = logging.getLogger(__name__)
@@ -57,12 +57,16 @@ class BlerpcClient(GeneratedClientMixin):
         self._timeout_s = 0.1  # Default 100ms
         self._max_request_payload_size: int | None = None
         self._max_response_payload_size: int | None = None
+        self._capability_flags = 0
 
         # Encryption state
         self._session: BlerpcCryptoSession | None = None
         self._known_keys_path = known_keys_path
         self._require_encryption = require_encryption
 
+        # Event subscriptions: queues per event name
+        self._event_queues: dict[str, list[asyncio.Queue[bytes]]] = {}
+
     @property
     def mtu(self) -> int:
         return self._transport.mtu
@@ -75,10 +79,19 @@ class BlerpcClient(GeneratedClientMixin):
     def max_response_payload_size(self) -> int | None:
         return self._max_response_payload_size
 
+    @property
+    def capability_flags(self) -> int:
+        """Flags of the peripheral's CAPABILITIES response; 0 until received."""
+        return self._capability_flags
+
     @property
     def is_encrypted(self) -> bool:
         return self._session is not None
 
+    @property
+    def is_connected(self) -> bool:
+        return self._transport.is_connected
+
     async def scan(
         self,
         timeout: float = 5.0,
@@ -158,6 +171,7 @@ class BlerpcClient(GeneratedClientMixin):
                 )
             self._max_request_payload_size = max_req
             self._max_response_payload_size = max_resp
+            self._capability_flags = flags
             logger.info(
                 "Peripheral capabilities: max_request=%d, "
                 "max_response=%d, flags=0x%04x",
@@ -277,14 +291,14 @@ class BlerpcClient(GeneratedClientMixin):
                 continue  # Skip other control containers
 
             result = self._assembler.feed(container)
-            if result is not None:
-                break
+            if result is None:
+                continue
 
-        # Decrypt if active
-        result = self._decrypt_payload(result)
+            # Decrypt if active, then decode command response
+            resp = CommandPacket.deserialize(self._decrypt_payload(result))
+            if not self._dispatch_event(resp):
+                break
 
-        # Decode command response
-        resp = CommandPacket.deserialize(result)
         if resp.cmd_type != CommandType.RESPONSE:
             raise RuntimeError(f"Expected response, got type={resp.cmd_type}")
         if resp.cmd_name != cmd_name:
@@ -349,19 +363,46 @@ class BlerpcClient(GeneratedClientMixin):
             if result is not None:
                 result = self._decrypt_payload(result)
                 resp = CommandPacket.deserialize(result)
+                if self._dispatch_event(resp):
+                    continue
                 if resp.cmd_type != CommandType.RESPONSE:
                     raise RuntimeError(f"Expected response, got type={resp.cmd_type}")
                 yield resp.data
 
+    async def stream_cancel(self) -> None:
+        """Stop the P->C stream in progress and drain it up to its end.
+
+        The generated iter_* methods call this when their consumer stops
+        early. Responses already in flight are discarded, so the next call
+        does not read them.
+        """
+        if self._splitter is None or not self._transport.is_connected:
+            return
+        tid = self._splitter.next_transaction_id()
+        await self._transport.write(make_cancel(tid))
+        while True:
+            try:
+                notify_data = await self._transport.read_notify(timeout=self._timeout_s)
+            except TimeoutError:
+                logger.warning("Cancelled stream did not end")
+                return
+            container = Container.deserialize(notify_data)
+            if container.container_type != ContainerType.CONTROL:
+                continue
+            if container.control_cmd in (ControlCmd.STREAM_END_P2C, ControlCmd.ERROR):
+                self._assembler.reset()
+                return
+
     async def stream_send(
         self,
         cmd_name: str,
-        messages: list[bytes],
+        messages: Iterable[bytes] | AsyncIterable[bytes],
         final_cmd_name: str,
     ) -> bytes:
         """C->P stream: send requests, STREAM_END_C2P, return response.
 
-        Each item in messages is protobuf-encoded request data.
+        Each item in messages is protobuf-encoded request data; messages may be
+        an async iterable, and each item is sent as it is produced.
         After sending all messages + STREAM_END_C2P, waits for a final response
         with cmd_name=final_cmd_name and returns its data.
         """
@@ -369,17 +410,12 @@ class BlerpcClient(GeneratedClientMixin):
             raise RuntimeError("Not connected: call connect() first")
 
         # Send each message as an independent request
-        for msg_data in messages:
-            cmd = CommandPacket(
-                cmd_type=CommandType.REQUEST,
-                cmd_name=cmd_name,
-                data=msg_data,
-            )
-            payload = cmd.serialize()
-            send_payload = self._encrypt_payload(payload)
-            containers = self._splitter.split(send_payload)
-            for c in containers:
-                await self._transport.write(c.serialize())
+        if isinstance(messages, AsyncIterable):
+            async for msg_data in messages:
+                await self._send_stream_message(cmd_name, msg_data)
+        else:
+            for msg_data in messages:
+                await self._send_stream_message(cmd_name, msg_data)
 
         # Send STREAM_END_C2P
         tid = self._splitter.next_transaction_id()
@@ -406,11 +442,13 @@ class BlerpcClient(GeneratedClientMixin):
                 continue
 
             result = self._assembler.feed(container)
-            if result is not None:
+            if result is None:
+                continue
+
+            resp = CommandPacket.deserialize(self._decrypt_payload(result))
+            if not self._dispatch_event(resp):
                 break
 
-        result = self._decrypt_payload(result)
-        resp = CommandPacket.deserialize(result)
         if resp.cmd_type != CommandType.RESPONSE:
             raise RuntimeError(f"Expected response, got type={resp.cmd_type}")
         if resp.cmd_name != final_cmd_name:
@@ -420,6 +458,67 @@ class BlerpcClient(GeneratedClientMixin):
             )
         return resp.data
 
+    async def _send_stream_message(self, cmd_name: str, msg_data: bytes) -> None:
+        cmd = CommandPacket(
+            cmd_type=CommandType.REQUEST,
+            cmd_name=cmd_name,
+            data=msg_data,
+        )
+        payload = cmd.serialize()
+        send_payload = self._encrypt_payload(payload)
+        containers = self._splitter.split(send_payload)
+        for c in containers:
+            await self._transport.write(c.serialize())
+
+    async def events(self, event_name: str) -> AsyncIterator[bytes]:
+        """Yield the data of each event_name event the peripheral notifies.
+
+        RPCs set aside the events arriving while they wait for a response;
+        between RPCs the subscription reads notifications itself. Leave the
+        loop to unsubscribe.
+        """
+        if self._splitter is None:
+            raise RuntimeError("Not connected: call connect() first")
+
+        queue: asyncio.Queue[bytes] = asyncio.Queue()
+        queues = self._event_queues.setdefault(event_name, [])
+        queues.append(queue)
+        try:
+            while True:
+                while queue.empty():
+                    async with _rpc_lock(self):
+                        await self._read_event()
+                yield queue.get_nowait()
+        finally:
+            queues.remove(queue)
+
+    async def _read_event(self) -> None:
+        """Read one notification outside an RPC and dispatch it if an event."""
+        try:
+            notify_data = await self._transport.read_notify(timeout=self._timeout_s)
+        except asyncio.TimeoutError:
+            return
+        container = Container.deserialize(notify_data)
+        if container.container_type == ContainerType.CONTROL:
+            return
+        result = self._assembler.feed(container)
+        if result is not None:
+            resp = CommandPacket.deserialize(self._decrypt_payload(result))
+            self._dispatch_event(resp)
+
+    def _dispatch_event(self, resp: CommandPacket) -> bool:
+        """Queue resp for the subscribers of its name; False if not an event.
+
+        Names stay known after their last subscriber leaves, so late events
+        are dropped rather than mistaken for RPC responses.
+        """
+        queues = self._event_queues.get(resp.cmd_name)
+        if resp.cmd_type != CommandType.RESPONSE or queues is None:
+            return False
+        for queue in queues:
+            queue.put_nowait(resp.data)
+        return True
+
     async def disconnect(self) -> None:
         """Disconnect from the peripheral."""
         await self._transport.disconnect()
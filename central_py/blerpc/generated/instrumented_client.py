@@ -0,0 +1,189 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import dataclasses
+import logging
+import time
+from collections.abc import AsyncIterable, AsyncIterator, Iterable
+from typing import Any
+
+from .generated_client import GeneratedClientMixin
+
+logger = logging.getLogger(__name__)
+
+# Commands by the name the generated methods send for them, where it is
+# not their own.
+INTERCEPTED_COMMANDS: dict[str, str] = {}
+
+
+class Interceptor:
+    """Observes the calls of an InstrumentedClient. Override what you need.
+
+    command is the command's name as in the schema. Sizes are payload bytes,
+    summed over the messages of a stream; durations are in seconds. An
+    exception an interceptor raises is logged and does not fail the call.
+    """
+
+    def on_request(self, command: str, request_size: int) -> None:
+        """Called before the request is sent.
+
+        For a stream the client sends, request_size is 0: the messages are
+        not known yet.
+        """
+
+    def on_response(
+        self, command: str, request_size: int, response_size: int, duration: float
+    ) -> None:
+        """Called when the peripheral answered the call."""
+
+    def on_error(
+        self, command: str, request_size: int, error: Exception, duration: float
+    ) -> None:
+        """Called when the call failed, with the error it raised."""
+
+
+@dataclasses.dataclass
+class CommandMetrics:
+    """Counters of one command, as MetricsCollector keeps them."""
+
+    calls: int = 0
+    errors: int = 0
+    request_bytes: int = 0
+    response_bytes: int = 0
+    total_duration: float = 0.0
+    max_duration: float = 0.0
+
+    @property
+    def mean_duration(self) -> float:
+        return self.total_duration / self.calls if self.calls else 0.0
+
+
+class MetricsCollector(Interceptor):
+    """Counts calls, errors, bytes and durations per command.
+
+    Read the counters with snapshot(), e.g. periodically for a dashboard.
+    """
+
+    def __init__(self) -> None:
+        self._metrics: dict[str, CommandMetrics] = {}
+
+    def _record(
+        self, command: str, request_size: int, response_size: int, duration: float
+    ) -> CommandMetrics:
+        m = self._metrics.setdefault(command, CommandMetrics())
+        m.calls += 1
+        m.request_bytes += request_size
+        m.response_bytes += response_size
+        m.total_duration += duration
+        m.max_duration = max(m.max_duration, duration)
+        return m
+
+    def on_response(
+        self, command: str, request_size: int, response_size: int, duration: float
+    ) -> None:
+        self._record(command, request_size, response_size, duration)
+
+    def on_error(
+        self, command: str, request_size: int, error: Exception, duration: float
+    ) -> None:
+        self._record(command, request_size, 0, duration).errors += 1
+
+    def snapshot(self) -> dict[str, CommandMetrics]:
+        """Return a copy of the counters by command."""
+        return {k: dataclasses.replace(m) for k, m in self._metrics.items()}
+
+    def reset(self) -> None:
+        """Clear the counters."""
+        self._metrics.clear()
+
+
+class InstrumentedClient(GeneratedClientMixin):
+    """Reports every call made through it to interceptors.
+
+    Interceptors see the exchanges with the peripheral: a response with an
+    error status, or one that fails to decode, counts as a response, as the
+    generated method raises its error after the exchange. Make every call
+    through the wrapper: it forwards other attributes to client, but calls
+    made on client directly are not reported.
+    """
+
+    def __init__(self, client: Any, interceptors: Iterable[Interceptor]) -> None:
+        self._client = client
+        self._interceptors = list(interceptors)
+
+    def __getattr__(self, name: str) -> Any:
+        return getattr(self._client, name)
+
+    def _notify(self, event: str, *args: Any) -> None:
+        for interceptor in self._interceptors:
+            try:
+                getattr(interceptor, event)(*args)
+            except Exception:
+                logger.exception("%s.%s failed", type(interceptor).__name__, event)
+
+    async def _call(self, cmd_name: str, request_data: bytes) -> bytes:
+        command = INTERCEPTED_COMMANDS.get(cmd_name, cmd_name)
+        size = len(request_data)
+        self._notify("on_request", command, size)
+        start = time.monotonic()
+        try:
+            resp = await self._client._call(cmd_name, request_data)
+        except Exception as e:
+            self._notify("on_error", command, size, e, time.monotonic() - start)
+            raise
+        self._notify("on_response", command, size, len(resp), time.monotonic() - start)
+        return resp
+
+    async def stream_receive(
+        self, cmd_name: str, request_data: bytes
+    ) -> AsyncIterator[bytes]:
+        command = INTERCEPTED_COMMANDS.get(cmd_name, cmd_name)
+        size = len(request_data)
+        self._notify("on_request", command, size)
+        start = time.monotonic()
+        received = 0
+        error = None
+        try:
+            async for data in self._client.stream_receive(cmd_name, request_data):
+                received += len(data)
+                yield data
+        except Exception as e:
+            error = e
+            raise
+        finally:
+            duration = time.monotonic() - start
+            if error is None:
+                self._notify("on_response", command, size, received, duration)
+            else:
+                self._notify("on_error", command, size, error, duration)
+
+    async def stream_send(
+        self,
+        cmd_name: str,
+        messages: Iterable[bytes] | AsyncIterable[bytes],
+        final_cmd_name: str,
+    ) -> bytes:
+        command = INTERCEPTED_COMMANDS.get(cmd_name, cmd_name)
+        self._notify("on_request", command, 0)
+        start = time.monotonic()
+        sent = 0
+
+        async def count() -> AsyncIterator[bytes]:
+            nonlocal sent
+            if isinstance(messages, AsyncIterable):
+                async for data in messages:
+                    sent += len(data)
+                    yield data
+            else:
+                for data in messages:
+                    sent += len(data)
+                    yield data
+
+        try:
+            resp = await self._client.stream_send(cmd_name, count(), final_cmd_name)
+        except Exception as e:
+            self._notify("on_error", command, sent, e, time.monotonic() - start)
+            raise
+        self._notify("on_response", command, sent, len(resp), time.monotonic() - start)
+        return resp
@@ -0,0 +1,81 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from collections.abc import AsyncIterable
+from typing import NamedTuple
+
+from .generated_client import COMMAND_MESSAGES, GeneratedClientMixin
+
+
+class MockCall(NamedTuple):
+    """A call recorded by MockGeneratedClient.
+
+    requests holds the decoded request, or each message of a C2P stream.
+    """
+
+    command: str
+    requests: list
+
+
+class MockGeneratedClient(GeneratedClientMixin):
+    """Stand-in for BlerpcClient in app unit tests, with no peripheral.
+
+    Records every call in calls and answers it with the responses set for its
+    command. Commands without any get an empty response, and P2C streams no
+    responses.
+    """
+
+    is_connected = True
+
+    def __init__(self):
+        self.calls = []
+        self._answers = {}
+
+    def respond(self, command, *responses):
+        """Answer command with responses.
+
+        A unary or C2P call gets the first, a P2C stream each of them.
+        """
+        self._answers[command] = lambda requests: list(responses)
+
+    def respond_with(self, command, answer):
+        """Answer command with the responses answer(requests) returns."""
+        self._answers[command] = answer
+
+    def fail(self, command, error):
+        """Raise error, e.g. a NotFoundError, from calls of command."""
+
+        def answer(requests):
+            raise error
+
+        self._answers[command] = answer
+
+    def _answer(self, cmd_name, requests):
+        # Records the call and returns its command with the responses set
+        # for it, None if there are none.
+        command = cmd_name
+        req_cls, _ = COMMAND_MESSAGES[command]
+        decoded = [req_cls.FromString(data) for data in requests]
+        self.calls.append(MockCall(command, decoded))
+        answer = self._answers.get(command)
+        return command, None if answer is None else answer(decoded)
+
+    def _first(self, command, responses):
+        if responses:
+            return responses[0].SerializeToString()
+        _, resp_cls = COMMAND_MESSAGES[command]
+        return resp_cls().SerializeToString()
+
+    async def _call(self, cmd_name, request_data):
+        return self._first(*self._answer(cmd_name, [request_data]))
+
+    async def stream_receive(self, cmd_name, request_data):
+        _, responses = self._answer(cmd_name, [request_data])
+        for resp in responses or []:
+            yield resp.SerializeToString()
+
+    async def stream_send(self, cmd_name, messages, final_cmd_name):
+        if isinstance(messages, AsyncIterable):
+            messages = [m async for m in messages]
+        return self._first(*self._answer(cmd_name, list(messages)))
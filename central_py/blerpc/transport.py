@@ -9,10 +9,10 @@ from dataclasses import dataclass, field
 from bleak import BleakClient, BleakScanner
 from bleak.backends.device import BLEDevice
 
+from .generated.generated_uuids import CHAR_UUID, SERVICE_UUID
+
 logger = logging.getLogger(__name__)
 
-SERVICE_UUID = "12340001-0000-1000-8000-00805f9b34fb"
-CHAR_UUID = "12340002-0000-1000-8000-00805f9b34fb"
 DEFAULT_TIMEOUT_S = 0.1  # 100ms
 
 
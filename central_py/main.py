@@ -5,6 +5,7 @@ import logging
 import time
 
 from blerpc.client import BlerpcClient
+from blerpc.generated.generated_scanner import scan
 
 logging.basicConfig(
     level=logging.INFO, format="%(asctime)s %(levelname)s %(name)s: %(message)s"
@@ -16,7 +17,7 @@ async def main():
     client = BlerpcClient()
 
     try:
-        devices = await client.scan()
+        devices = await scan(client)
         if not devices:
             raise ConnectionError("No blerpc devices found")
         logger.info(
@@ -25,7 +26,7 @@ async def main():
             devices[0].name,
             devices[0].rssi,
         )
-        await client.connect(devices[0])
+        await client.connect(devices[0].device)
         logger.info("MTU: %d", client.mtu)
 
         # Echo test
@@ -9,6 +9,7 @@ import asyncio
 import pytest
 from blerpc.client import BlerpcClient, PayloadTooLargeError, ResponseTooLargeError
 from blerpc.generated import blerpc_pb2
+from blerpc.generated.generated_client import make_cancel
 from blerpc_protocol.command import CommandPacket, CommandType
 from blerpc_protocol.container import (
     BLERPC_ERROR_RESPONSE_TOO_LARGE,
@@ -370,6 +371,29 @@ async def test_counter_stream_empty():
     assert results == []
 
 
+@pytest.mark.asyncio
+async def test_counter_stream_cancel():
+    """Closing iter_counter_stream early sends Cancel and drains the stream."""
+    transport = MockTransport()
+    client = make_client(transport)
+
+    for i in range(3):
+        resp = blerpc_pb2.CounterStreamResponse(seq=i, value=i * 10)
+        transport.inject_response(
+            "counter_stream", resp.SerializeToString(), transaction_id=i + 10
+        )
+    inject_stream_end_p2c(transport, transaction_id=100)
+
+    stream = client.iter_counter_stream(count=3)
+    first = await anext(stream)
+    await stream.aclose()
+
+    assert first.seq == 0
+    cancel = transport._written[-1]
+    assert cancel == make_cancel(cancel[0])
+    assert transport._notify_queue.empty()
+
+
 @pytest.mark.asyncio
 async def test_counter_upload():
     """Test C->P stream: send N requests, STREAM_END_C2P, get response."""
@@ -596,3 +620,36 @@ async def test_payload_too_large_attributes():
         await client.echo(message="A" * 256)
     assert exc_info.value.limit == 10
     assert exc_info.value.actual > 10
+
+
+# ── Event tests ──────────────────────────────────────────────────────────
+
+
+@pytest.mark.asyncio
+async def test_events_read_between_calls():
+    """A subscription reads the events notified while no RPC is in flight."""
+    transport = MockTransport()
+    client = make_client(transport)
+    transport.inject_response("button_event", b"\x08\x02", transaction_id=5)
+
+    events = client.events("button_event")
+    assert await events.__anext__() == b"\x08\x02"
+    await events.aclose()
+    assert client._event_queues["button_event"] == []
+
+
+@pytest.mark.asyncio
+async def test_event_during_call_is_dispatched():
+    """An event arriving before the response goes to its subscribers."""
+    transport = MockTransport()
+    client = make_client(transport)
+    queue: asyncio.Queue[bytes] = asyncio.Queue()
+    client._event_queues["button_event"] = [queue]
+
+    transport.inject_response("button_event", b"\x08\x01", transaction_id=5)
+    resp = blerpc_pb2.EchoResponse(message="hello")
+    transport.inject_response("echo", resp.SerializeToString(), transaction_id=0)
+
+    result = await client.echo(message="hello")
+    assert result.message == "hello"
+    assert queue.get_nowait() == b"\x08\x01"
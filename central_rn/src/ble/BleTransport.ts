@@ -1,8 +1,8 @@
 import { BleManager, Device, Characteristic, Subscription } from 'react-native-ble-plx';
 import { Buffer } from 'buffer';
+import { CHAR_UUID, SERVICE_UUID } from '../client/GeneratedUuids';
 
-export const SERVICE_UUID = '12340001-0000-1000-8000-00805f9b34fb';
-export const CHAR_UUID = '12340002-0000-1000-8000-00805f9b34fb';
+export { CHAR_UUID, SERVICE_UUID };
 
 export interface ScannedDevice {
   device: Device;
@@ -0,0 +1,3 @@
+module github.com/tdaira/blerpc/go
+
+go 1.23
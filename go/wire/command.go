@@ -0,0 +1,87 @@
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// CommandType occupies bit 7 of the first command byte.
+type CommandType uint8
+
+const (
+	CommandRequest  CommandType = 0
+	CommandResponse CommandType = 1
+)
+
+func (t CommandType) String() string {
+	switch t {
+	case CommandRequest:
+		return "REQUEST"
+	case CommandResponse:
+		return "RESPONSE"
+	}
+	return fmt.Sprintf("CommandType(%d)", uint8(t))
+}
+
+// Command header: type byte, name length byte, name, data length (LE16).
+const (
+	MaxCommandNameLen = 0xFF
+	MaxCommandDataLen = 0xFFFF
+)
+
+// ErrShortCommand is returned when data ends inside a command packet.
+var ErrShortCommand = errors.New("wire: command packet too short")
+
+// Command is an RPC request or response identified by its wire name.
+type Command struct {
+	Type CommandType
+	Name string
+	Data []byte
+}
+
+// MarshalBinary encodes the command packet.
+func (c *Command) MarshalBinary() ([]byte, error) {
+	if err := validateCommandName(c.Name); err != nil {
+		return nil, err
+	}
+	if len(c.Data) > MaxCommandDataLen {
+		return nil, fmt.Errorf("wire: command data of %d bytes exceeds %d", len(c.Data), MaxCommandDataLen)
+	}
+	buf := make([]byte, 0, 4+len(c.Name)+len(c.Data))
+	buf = append(buf, byte(c.Type&0x01)<<7, byte(len(c.Name)))
+	buf = append(buf, c.Name...)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(c.Data)))
+	return append(buf, c.Data...), nil
+}
+
+// ParseCommand decodes a command packet. Data aliases data.
+func ParseCommand(data []byte) (*Command, error) {
+	if len(data) < 2 {
+		return nil, ErrShortCommand
+	}
+	c := &Command{Type: CommandType(data[0] >> 7)}
+	nameLen := int(data[1])
+	rest := data[2:]
+	if len(rest) < nameLen+2 {
+		return nil, ErrShortCommand
+	}
+	c.Name = string(rest[:nameLen])
+	dataLen := int(binary.LittleEndian.Uint16(rest[nameLen:]))
+	rest = rest[nameLen+2:]
+	if len(rest) < dataLen {
+		return nil, ErrShortCommand
+	}
+	c.Data = rest[:dataLen]
+	return c, nil
+}
+
+func validateCommandName(name string) error {
+	if name == "" {
+		return errors.New("wire: empty command name")
+	}
+	if len(name) > MaxCommandNameLen {
+		return fmt.Errorf("wire: command name %q is %d bytes, over the %d-byte limit", name, len(name), MaxCommandNameLen)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand_Vectors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Command
+	}{
+		{
+			name: "request",
+			data: []byte{0x00, 0x04, 'e', 'c', 'h', 'o', 0x02, 0x00, 0x01, 0x02},
+			want: Command{Type: CommandRequest, Name: "echo", Data: []byte{0x01, 0x02}},
+		},
+		{
+			name: "response",
+			data: []byte{0x80, 0x04, 'e', 'c', 'h', 'o', 0x01, 0x00, 0xFF},
+			want: Command{Type: CommandResponse, Name: "echo", Data: []byte{0xFF}},
+		},
+		{
+			name: "empty data",
+			data: []byte{0x00, 0x01, 'x', 0x00, 0x00},
+			want: Command{Type: CommandRequest, Name: "x", Data: []byte{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCommand(tt.data)
+			if err != nil {
+				t.Fatalf("ParseCommand: %v", err)
+			}
+			if got.Type != tt.want.Type || got.Name != tt.want.Name || !bytes.Equal(got.Data, tt.want.Data) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommand_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"no name length", []byte{0x00}},
+		{"name truncated", []byte{0x00, 0x04, 'e', 'c'}},
+		{"data length missing", []byte{0x00, 0x01, 'x', 0x01}},
+		{"data truncated", []byte{0x00, 0x01, 'x', 0x03, 0x00, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCommand(tt.data); !errors.Is(err, ErrShortCommand) {
+				t.Errorf("expected ErrShortCommand, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCommand_MarshalRoundtrip(t *testing.T) {
+	tests := []Command{
+		{Type: CommandRequest, Name: "flash_read", Data: []byte{0xAA, 0xBB, 0xCC}},
+		{Type: CommandResponse, Name: "echo", Data: []byte{}},
+		{Type: CommandRequest, Name: strings.Repeat("n", MaxCommandNameLen), Data: make([]byte, MaxCommandDataLen)},
+	}
+	for _, c := range tests {
+		data, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary: %v", err)
+		}
+		if want := 4 + len(c.Name) + len(c.Data); len(data) != want {
+			t.Errorf("encoded %d bytes, want %d", len(data), want)
+		}
+		got, err := ParseCommand(data)
+		if err != nil {
+			t.Fatalf("ParseCommand: %v", err)
+		}
+		if got.Type != c.Type || got.Name != c.Name || !bytes.Equal(got.Data, c.Data) {
+			t.Errorf("roundtrip mismatch for %q", c.Name)
+		}
+	}
+}
+
+func TestCommand_ResponseTypeBit(t *testing.T) {
+	data, err := (&Command{Type: CommandResponse, Name: "echo"}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != 0x80 {
+		t.Errorf("type byte = %#x, want 0x80", data[0])
+	}
+}
+
+func TestCommand_MarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+	}{
+		{"empty name", Command{Name: ""}},
+		{"name too long", Command{Name: strings.Repeat("n", MaxCommandNameLen+1)}},
+		{"data too long", Command{Name: "x", Data: make([]byte, MaxCommandDataLen+1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cmd.MarshalBinary(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Package wire implements the bleRPC container and command framing for Go
+// tools, matching the blerpc-protocol libraries byte for byte.
+//
+// A command packet (type, command name, protobuf data) is split into
+// containers that each fit one ATT write or notification. A FIRST container
+// carries the total length, SUBSEQUENT containers continue it, and CONTROL
+// containers carry timeouts, stream ends, capabilities, errors and key
+// exchange.
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Header sizes in bytes.
+const (
+	ATTOverhead          = 3 // ATT opcode + handle, subtracted from the MTU
+	FirstHeaderSize      = 6 // txn, seq, flags, total_length (LE16), payload_len
+	SubsequentHeaderSize = 4 // txn, seq, flags, payload_len
+	ControlHeaderSize    = 4 // same layout as SUBSEQUENT
+)
+
+// MaxContainers is the number of containers one transaction can span; the
+// sequence number is a single byte.
+const MaxContainers = 256
+
+// ContainerType occupies bits 7-6 of the flags byte.
+type ContainerType uint8
+
+const (
+	TypeFirst      ContainerType = 0b00
+	TypeSubsequent ContainerType = 0b01
+	TypeControl    ContainerType = 0b11
+)
+
+func (t ContainerType) String() string {
+	switch t {
+	case TypeFirst:
+		return "FIRST"
+	case TypeSubsequent:
+		return "SUBSEQUENT"
+	case TypeControl:
+		return "CONTROL"
+	}
+	return fmt.Sprintf("ContainerType(%d)", uint8(t))
+}
+
+// ErrShortContainer is returned when data is smaller than its header.
+var ErrShortContainer = errors.New("wire: container too short")
+
+// Container is one framed BLE write or notification.
+type Container struct {
+	TransactionID  uint8
+	SequenceNumber uint8
+	Type           ContainerType
+	ControlCmd     ControlCmd // CONTROL containers only
+	TotalLength    uint16     // FIRST containers only
+	Payload        []byte
+}
+
+func (c *Container) headerSize() int {
+	if c.Type == TypeFirst {
+		return FirstHeaderSize
+	}
+	return SubsequentHeaderSize
+}
+
+// MarshalBinary encodes the container.
+func (c *Container) MarshalBinary() ([]byte, error) {
+	if len(c.Payload) > 0xFF {
+		return nil, fmt.Errorf("wire: container payload of %d bytes exceeds 255", len(c.Payload))
+	}
+	buf := make([]byte, 0, c.headerSize()+len(c.Payload))
+	flags := byte(c.Type&0x03)<<6 | byte(c.ControlCmd&0x0F)<<2
+	buf = append(buf, c.TransactionID, c.SequenceNumber, flags)
+	if c.Type == TypeFirst {
+		buf = binary.LittleEndian.AppendUint16(buf, c.TotalLength)
+	}
+	buf = append(buf, byte(len(c.Payload)))
+	return append(buf, c.Payload...), nil
+}
+
+// ParseContainer decodes one container. The payload aliases data.
+func ParseContainer(data []byte) (*Container, error) {
+	if len(data) < SubsequentHeaderSize {
+		return nil, ErrShortContainer
+	}
+	c := &Container{
+		TransactionID:  data[0],
+		SequenceNumber: data[1],
+		Type:           ContainerType(data[2] >> 6),
+		ControlCmd:     ControlCmd(data[2] >> 2 & 0x0F),
+	}
+	rest := data[3:]
+	if c.Type == TypeFirst {
+		if len(data) < FirstHeaderSize {
+			return nil, ErrShortContainer
+		}
+		c.TotalLength = binary.LittleEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+	n := int(rest[0])
+	if len(rest)-1 < n {
+		return nil, fmt.Errorf("wire: container payload_len %d exceeds %d remaining bytes", n, len(rest)-1)
+	}
+	c.Payload = rest[1 : 1+n]
+	return c, nil
+}
+
+// Splitter fragments payloads into containers for a given ATT MTU and
+// numbers transactions.
+type Splitter struct {
+	mtu  int
+	next uint8
+}
+
+// NewSplitter returns a splitter for the negotiated ATT MTU.
+func NewSplitter(mtu int) *Splitter {
+	return &Splitter{mtu: mtu}
+}
+
+// MTU returns the ATT MTU the splitter fragments for.
+func (s *Splitter) MTU() int { return s.mtu }
+
+// NextTransactionID allocates a transaction ID, wrapping after 255.
+func (s *Splitter) NextTransactionID() uint8 {
+	id := s.next
+	s.next++
+	return id
+}
+
+// Split fragments payload under a newly allocated transaction ID.
+func (s *Splitter) Split(payload []byte) ([]Container, error) {
+	return s.SplitTransaction(payload, s.NextTransactionID())
+}
+
+// SplitTransaction fragments payload under the given transaction ID.
+func (s *Splitter) SplitTransaction(payload []byte, txn uint8) ([]Container, error) {
+	if len(payload) > 0xFFFF {
+		return nil, fmt.Errorf("wire: payload of %d bytes exceeds total_length limit 65535", len(payload))
+	}
+	effective := s.mtu - ATTOverhead
+	firstMax := min(effective-FirstHeaderSize, 0xFF)
+	nextMax := min(effective-SubsequentHeaderSize, 0xFF)
+	if firstMax <= 0 || nextMax <= 0 {
+		return nil, fmt.Errorf("wire: MTU %d too small", s.mtu)
+	}
+
+	n := min(len(payload), firstMax)
+	out := []Container{{
+		TransactionID: txn,
+		Type:          TypeFirst,
+		TotalLength:   uint16(len(payload)),
+		Payload:       payload[:n],
+	}}
+	for off := n; off < len(payload); off += n {
+		if len(out) == MaxContainers {
+			return nil, fmt.Errorf("wire: payload of %d bytes needs more than %d containers (sequence_number overflow)", len(payload), MaxContainers)
+		}
+		n = min(len(payload)-off, nextMax)
+		out = append(out, Container{
+			TransactionID:  txn,
+			SequenceNumber: uint8(len(out)),
+			Type:           TypeSubsequent,
+			Payload:        payload[off : off+n],
+		})
+	}
+	return out, nil
+}
+
+// Assembler reassembles payloads from FIRST and SUBSEQUENT containers,
+// tracking each transaction independently.
+type Assembler struct {
+	pending map[uint8]*partial
+}
+
+type partial struct {
+	total   int
+	nextSeq uint8
+	buf     []byte
+}
+
+// NewAssembler returns an empty assembler.
+func NewAssembler() *Assembler {
+	return &Assembler{pending: make(map[uint8]*partial)}
+}
+
+// Feed adds a container and returns the payload once its transaction is
+// complete. CONTROL containers, SUBSEQUENT containers without a FIRST, and
+// out-of-order containers (which discard the transaction) return false.
+func (a *Assembler) Feed(c *Container) ([]byte, bool) {
+	switch c.Type {
+	case TypeFirst:
+		p := &partial{total: int(c.TotalLength), nextSeq: 1, buf: make([]byte, 0, c.TotalLength)}
+		p.buf = append(p.buf, c.Payload...)
+		a.pending[c.TransactionID] = p
+	case TypeSubsequent:
+		p, ok := a.pending[c.TransactionID]
+		if !ok {
+			return nil, false
+		}
+		if c.SequenceNumber != p.nextSeq {
+			delete(a.pending, c.TransactionID)
+			return nil, false
+		}
+		p.nextSeq++
+		p.buf = append(p.buf, c.Payload...)
+	default:
+		return nil, false
+	}
+	p := a.pending[c.TransactionID]
+	if len(p.buf) < p.total {
+		return nil, false
+	}
+	delete(a.pending, c.TransactionID)
+	return p.buf[:p.total], true
+}
+
+// Pending reports whether a transaction is partially assembled.
+func (a *Assembler) Pending(txn uint8) bool {
+	_, ok := a.pending[txn]
+	return ok
+}
+
+// Reset drops all partially assembled transactions.
+func (a *Assembler) Reset() {
+	clear(a.pending)
+}
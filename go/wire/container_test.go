@@ -0,0 +1,329 @@
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseContainer_Vectors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Container
+	}{
+		{
+			name: "first",
+			data: []byte{0x01, 0x00, 0x00, 0x05, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o'},
+			want: Container{TransactionID: 1, Type: TypeFirst, TotalLength: 5, Payload: []byte("hello")},
+		},
+		{
+			name: "subsequent",
+			data: []byte{0x02, 0x01, 0x40, 0x03, 'a', 'b', 'c'},
+			want: Container{TransactionID: 2, SequenceNumber: 1, Type: TypeSubsequent, Payload: []byte("abc")},
+		},
+		{
+			name: "control timeout",
+			data: []byte{0x05, 0x00, 0xC4, 0x02, 0xC8, 0x00},
+			want: Container{TransactionID: 5, Type: TypeControl, ControlCmd: ControlTimeout, Payload: []byte{0xC8, 0x00}},
+		},
+		{
+			name: "trailing bytes ignored",
+			data: []byte{0x00, 0x00, 0x40, 0x01, 'x', 'y'},
+			want: Container{Type: TypeSubsequent, Payload: []byte("x")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseContainer(tt.data)
+			if err != nil {
+				t.Fatalf("ParseContainer: %v", err)
+			}
+			if got.TransactionID != tt.want.TransactionID || got.SequenceNumber != tt.want.SequenceNumber ||
+				got.Type != tt.want.Type || got.ControlCmd != tt.want.ControlCmd ||
+				got.TotalLength != tt.want.TotalLength || !bytes.Equal(got.Payload, tt.want.Payload) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContainer_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"too short", []byte{0x00, 0x01}},
+		{"first header truncated", []byte{0x00, 0x00, 0x00, 0x05, 0x00}},
+		{"payload truncated", []byte{0x00, 0x01, 0x40, 0x04, 'a', 'b'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseContainer(tt.data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+	if _, err := ParseContainer([]byte{0x00, 0x01}); !errors.Is(err, ErrShortContainer) {
+		t.Errorf("expected ErrShortContainer, got %v", err)
+	}
+}
+
+func TestContainer_MarshalRoundtrip(t *testing.T) {
+	tests := []Container{
+		{TransactionID: 42, Type: TypeFirst, TotalLength: 100, Payload: []byte{1, 2, 3}},
+		{TransactionID: 7, SequenceNumber: 3, Type: TypeSubsequent, Payload: []byte{0xAA, 0xBB}},
+		{TransactionID: 1, Type: TypeControl, ControlCmd: ControlTimeout, Payload: []byte{0xF4, 0x01}},
+		{Type: TypeFirst, Payload: []byte{}},
+		{Type: TypeSubsequent, Payload: bytes.Repeat([]byte{0x55}, 255)},
+	}
+	for _, c := range tests {
+		data, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(%+v): %v", c, err)
+		}
+		if want := c.headerSize() + len(c.Payload); len(data) != want {
+			t.Errorf("%v: encoded %d bytes, want %d", c.Type, len(data), want)
+		}
+		got, err := ParseContainer(data)
+		if err != nil {
+			t.Fatalf("ParseContainer: %v", err)
+		}
+		if got.TransactionID != c.TransactionID || got.SequenceNumber != c.SequenceNumber ||
+			got.Type != c.Type || got.ControlCmd != c.ControlCmd ||
+			got.TotalLength != c.TotalLength || !bytes.Equal(got.Payload, c.Payload) {
+			t.Errorf("roundtrip: got %+v, want %+v", got, c)
+		}
+	}
+}
+
+func TestContainer_FlagsByte(t *testing.T) {
+	c := Container{Type: TypeControl, ControlCmd: ControlStreamEndC2P}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// type 0b11 in bits 7-6, control_cmd 0x2 in bits 5-2
+	if data[2] != 0xC8 {
+		t.Errorf("flags = %#x, want 0xc8", data[2])
+	}
+}
+
+func TestContainer_MarshalPayloadTooLarge(t *testing.T) {
+	c := Container{Type: TypeSubsequent, Payload: make([]byte, 256)}
+	if _, err := c.MarshalBinary(); err == nil {
+		t.Error("expected error for 256-byte payload")
+	}
+}
+
+func TestSplitter_SmallPayload(t *testing.T) {
+	cs, err := NewSplitter(247).SplitTransaction([]byte("hello"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 1 || cs[0].Type != TypeFirst || cs[0].TotalLength != 5 || string(cs[0].Payload) != "hello" {
+		t.Errorf("got %+v", cs)
+	}
+}
+
+func TestSplitter_LargePayload(t *testing.T) {
+	const mtu = 27
+	effective := mtu - ATTOverhead
+	payload := bytes.Repeat(seq256(), 2)
+
+	cs, err := NewSplitter(mtu).SplitTransaction(payload, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs[0].Type != TypeFirst || cs[0].TotalLength != 512 || len(cs[0].Payload) != effective-FirstHeaderSize {
+		t.Errorf("first container = %+v", cs[0])
+	}
+	var joined []byte
+	for i, c := range cs {
+		if c.TransactionID != 5 || int(c.SequenceNumber) != i {
+			t.Errorf("container %d: txn=%d seq=%d", i, c.TransactionID, c.SequenceNumber)
+		}
+		if i > 0 && (c.Type != TypeSubsequent || len(c.Payload) > effective-SubsequentHeaderSize) {
+			t.Errorf("container %d = %+v", i, c)
+		}
+		data, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > effective {
+			t.Errorf("container %d is %d bytes, over the %d-byte ATT payload", i, len(data), effective)
+		}
+		joined = append(joined, c.Payload...)
+	}
+	if !bytes.Equal(joined, payload) {
+		t.Error("containers do not reassemble to the payload")
+	}
+}
+
+func TestSplitter_Boundaries(t *testing.T) {
+	const mtu = 30
+	firstMax := mtu - ATTOverhead - FirstHeaderSize
+	tests := []struct {
+		size  int
+		count int
+	}{
+		{0, 1},
+		{firstMax, 1},
+		{firstMax + 1, 2},
+	}
+	for _, tt := range tests {
+		cs, err := NewSplitter(mtu).SplitTransaction(make([]byte, tt.size), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cs) != tt.count {
+			t.Errorf("size %d: %d containers, want %d", tt.size, len(cs), tt.count)
+		}
+	}
+}
+
+func TestSplitter_LargeMTUCapsPayloadLen(t *testing.T) {
+	cs, err := NewSplitter(512).SplitTransaction(make([]byte, 600), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range cs {
+		if len(c.Payload) > 255 {
+			t.Errorf("container %d carries %d bytes", i, len(c.Payload))
+		}
+	}
+}
+
+func TestSplitter_Errors(t *testing.T) {
+	if _, err := NewSplitter(27).SplitTransaction(make([]byte, 10000), 0); err == nil || !strings.Contains(err.Error(), "sequence_number") {
+		t.Errorf("expected sequence_number overflow, got %v", err)
+	}
+	if _, err := NewSplitter(247).SplitTransaction(make([]byte, 0x10000), 0); err == nil {
+		t.Error("expected total_length error")
+	}
+	if _, err := NewSplitter(ATTOverhead+FirstHeaderSize).SplitTransaction([]byte("x"), 0); err == nil {
+		t.Error("expected MTU error")
+	}
+}
+
+func TestSplitter_TransactionIDs(t *testing.T) {
+	s := NewSplitter(247)
+	c1, _ := s.Split([]byte("a"))
+	c2, _ := s.Split([]byte("b"))
+	if c1[0].TransactionID != 0 || c2[0].TransactionID != 1 {
+		t.Errorf("ids = %d, %d", c1[0].TransactionID, c2[0].TransactionID)
+	}
+	s.next = 255
+	c1, _ = s.Split([]byte("a"))
+	c2, _ = s.Split([]byte("b"))
+	if c1[0].TransactionID != 255 || c2[0].TransactionID != 0 {
+		t.Errorf("wrap ids = %d, %d", c1[0].TransactionID, c2[0].TransactionID)
+	}
+}
+
+func TestAssembler_SingleAndMulti(t *testing.T) {
+	a := NewAssembler()
+	got, ok := a.Feed(&Container{Type: TypeFirst, TotalLength: 5, Payload: []byte("hello")})
+	if !ok || string(got) != "hello" {
+		t.Errorf("single: got %q, %v", got, ok)
+	}
+
+	if _, ok := a.Feed(&Container{TransactionID: 1, Type: TypeFirst, TotalLength: 8, Payload: []byte("hell")}); ok {
+		t.Error("first of two should not complete")
+	}
+	if !a.Pending(1) {
+		t.Error("transaction 1 should be pending")
+	}
+	got, ok = a.Feed(&Container{TransactionID: 1, SequenceNumber: 1, Type: TypeSubsequent, Payload: []byte("o wo")})
+	if !ok || string(got) != "hello wo" {
+		t.Errorf("multi: got %q, %v", got, ok)
+	}
+	if a.Pending(1) {
+		t.Error("completed transaction should be dropped")
+	}
+}
+
+func TestAssembler_Interleaved(t *testing.T) {
+	a := NewAssembler()
+	a.Feed(&Container{TransactionID: 1, Type: TypeFirst, TotalLength: 4, Payload: []byte("ab")})
+	a.Feed(&Container{TransactionID: 2, Type: TypeFirst, TotalLength: 4, Payload: []byte("wx")})
+	got2, ok2 := a.Feed(&Container{TransactionID: 2, SequenceNumber: 1, Type: TypeSubsequent, Payload: []byte("yz")})
+	got1, ok1 := a.Feed(&Container{TransactionID: 1, SequenceNumber: 1, Type: TypeSubsequent, Payload: []byte("cd")})
+	if !ok1 || !ok2 || string(got1) != "abcd" || string(got2) != "wxyz" {
+		t.Errorf("got %q/%v and %q/%v", got1, ok1, got2, ok2)
+	}
+}
+
+func TestAssembler_Ignored(t *testing.T) {
+	a := NewAssembler()
+	if _, ok := a.Feed(TimeoutRequest(0)); ok {
+		t.Error("control container should be ignored")
+	}
+	if _, ok := a.Feed(&Container{TransactionID: 99, SequenceNumber: 1, Type: TypeSubsequent, Payload: []byte("orphan")}); ok {
+		t.Error("subsequent without first should be ignored")
+	}
+
+	a.Feed(&Container{TransactionID: 2, Type: TypeFirst, TotalLength: 10, Payload: []byte("abc")})
+	if _, ok := a.Feed(&Container{TransactionID: 2, SequenceNumber: 2, Type: TypeSubsequent, Payload: []byte("def")}); ok {
+		t.Error("sequence gap should not complete")
+	}
+	if a.Pending(2) {
+		t.Error("sequence gap should discard the transaction")
+	}
+
+	a.Feed(&Container{TransactionID: 3, Type: TypeFirst, TotalLength: 10, Payload: []byte("abc")})
+	a.Reset()
+	if a.Pending(3) {
+		t.Error("Reset should drop pending transactions")
+	}
+}
+
+func TestSplitAssembleRoundtrip(t *testing.T) {
+	tests := []struct {
+		mtu  int
+		size int
+	}{
+		{247, 0},
+		{247, 11},
+		{27, 1024},
+		{247, 60000},
+		{512, 65000},
+	}
+	for _, tt := range tests {
+		payload := make([]byte, tt.size)
+		for i := range payload {
+			payload[i] = byte(i * 7)
+		}
+		cs, err := NewSplitter(tt.mtu).Split(payload)
+		if err != nil {
+			t.Fatalf("mtu %d size %d: %v", tt.mtu, tt.size, err)
+		}
+		a := NewAssembler()
+		var got []byte
+		var done bool
+		for _, c := range cs {
+			data, err := c.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseContainer(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, done = a.Feed(parsed)
+		}
+		if !done || !bytes.Equal(got, payload) {
+			t.Errorf("mtu %d size %d: roundtrip mismatch (done=%v, %d bytes)", tt.mtu, tt.size, done, len(got))
+		}
+	}
+}
+
+func seq256() []byte {
+	b := make([]byte, 256)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
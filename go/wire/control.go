@@ -0,0 +1,135 @@
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ControlCmd occupies bits 5-2 of the flags byte of CONTROL containers.
+type ControlCmd uint8
+
+const (
+	ControlNone         ControlCmd = 0x0
+	ControlTimeout      ControlCmd = 0x1
+	ControlStreamEndC2P ControlCmd = 0x2
+	ControlStreamEndP2C ControlCmd = 0x3
+	ControlCapabilities ControlCmd = 0x4
+	ControlError        ControlCmd = 0x5
+	ControlKeyExchange  ControlCmd = 0x6
+)
+
+func (c ControlCmd) String() string {
+	switch c {
+	case ControlNone:
+		return "NONE"
+	case ControlTimeout:
+		return "TIMEOUT"
+	case ControlStreamEndC2P:
+		return "STREAM_END_C2P"
+	case ControlStreamEndP2C:
+		return "STREAM_END_P2C"
+	case ControlCapabilities:
+		return "CAPABILITIES"
+	case ControlError:
+		return "ERROR"
+	case ControlKeyExchange:
+		return "KEY_EXCHANGE"
+	}
+	return fmt.Sprintf("ControlCmd(%d)", uint8(c))
+}
+
+// Error codes carried by ERROR control containers.
+const (
+	ErrorResponseTooLarge uint8 = 0x01
+)
+
+// Capability flags advertised in CAPABILITIES responses.
+const (
+	CapabilityEncryptionSupported uint16 = 0x0001
+	CapabilityCompression         uint16 = 0x0002
+)
+
+// Capabilities is the payload of a CAPABILITIES response.
+type Capabilities struct {
+	MaxRequestPayloadSize  uint16
+	MaxResponsePayloadSize uint16
+	Flags                  uint16
+}
+
+func control(txn uint8, cmd ControlCmd, payload []byte) *Container {
+	if payload == nil {
+		payload = []byte{}
+	}
+	return &Container{TransactionID: txn, Type: TypeControl, ControlCmd: cmd, Payload: payload}
+}
+
+// TimeoutRequest asks the peripheral for its response timeout.
+func TimeoutRequest(txn uint8) *Container {
+	return control(txn, ControlTimeout, nil)
+}
+
+// TimeoutResponse answers a TIMEOUT request with the timeout in milliseconds.
+func TimeoutResponse(txn uint8, timeoutMs uint16) *Container {
+	return control(txn, ControlTimeout, binary.LittleEndian.AppendUint16(nil, timeoutMs))
+}
+
+// StreamEndC2P ends a central-to-peripheral stream.
+func StreamEndC2P(txn uint8) *Container {
+	return control(txn, ControlStreamEndC2P, nil)
+}
+
+// StreamEndP2C ends a peripheral-to-central stream.
+func StreamEndP2C(txn uint8) *Container {
+	return control(txn, ControlStreamEndP2C, nil)
+}
+
+// CapabilitiesRequest asks the peripheral for its capabilities. The request
+// carries an all-zero capabilities payload.
+func CapabilitiesRequest(txn uint8) *Container {
+	return CapabilitiesResponse(txn, Capabilities{})
+}
+
+// CapabilitiesResponse advertises the peripheral's payload limits and flags.
+func CapabilitiesResponse(txn uint8, caps Capabilities) *Container {
+	p := binary.LittleEndian.AppendUint16(nil, caps.MaxRequestPayloadSize)
+	p = binary.LittleEndian.AppendUint16(p, caps.MaxResponsePayloadSize)
+	p = binary.LittleEndian.AppendUint16(p, caps.Flags)
+	return control(txn, ControlCapabilities, p)
+}
+
+// ErrorResponse reports an error code for a transaction.
+func ErrorResponse(txn uint8, code uint8) *Container {
+	return control(txn, ControlError, []byte{code})
+}
+
+// KeyExchange wraps one key exchange step.
+func KeyExchange(txn uint8, payload []byte) *Container {
+	return control(txn, ControlKeyExchange, payload)
+}
+
+// ParseTimeout returns the timeout in milliseconds of a TIMEOUT response.
+func ParseTimeout(c *Container) (uint16, error) {
+	if c.Type != TypeControl || c.ControlCmd != ControlTimeout {
+		return 0, errors.New("wire: not a TIMEOUT container")
+	}
+	if len(c.Payload) < 2 {
+		return 0, ErrShortContainer
+	}
+	return binary.LittleEndian.Uint16(c.Payload), nil
+}
+
+// ParseCapabilities decodes a CAPABILITIES payload.
+func ParseCapabilities(c *Container) (Capabilities, error) {
+	if c.Type != TypeControl || c.ControlCmd != ControlCapabilities {
+		return Capabilities{}, errors.New("wire: not a CAPABILITIES container")
+	}
+	if len(c.Payload) < 6 {
+		return Capabilities{}, ErrShortContainer
+	}
+	return Capabilities{
+		MaxRequestPayloadSize:  binary.LittleEndian.Uint16(c.Payload),
+		MaxResponsePayloadSize: binary.LittleEndian.Uint16(c.Payload[2:]),
+		Flags:                  binary.LittleEndian.Uint16(c.Payload[4:]),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package wire
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestControlContainers(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Container
+		cmd     ControlCmd
+		payload []byte
+	}{
+		{"timeout request", TimeoutRequest(5), ControlTimeout, []byte{}},
+		{"timeout response", TimeoutResponse(5, 200), ControlTimeout, []byte{0xC8, 0x00}},
+		{"stream end c2p", StreamEndC2P(3), ControlStreamEndC2P, []byte{}},
+		{"stream end p2c", StreamEndP2C(3), ControlStreamEndP2C, []byte{}},
+		{"capabilities request", CapabilitiesRequest(7), ControlCapabilities, make([]byte, 6)},
+		{"error", ErrorResponse(10, ErrorResponseTooLarge), ControlError, []byte{0x01}},
+		{"key exchange", KeyExchange(1, []byte{0x01, 0x02}), ControlKeyExchange, []byte{0x01, 0x02}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.c.Type != TypeControl || tt.c.ControlCmd != tt.cmd || !bytes.Equal(tt.c.Payload, tt.payload) {
+				t.Errorf("got %+v", tt.c)
+			}
+			data, err := tt.c.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != ControlHeaderSize+len(tt.payload) {
+				t.Errorf("encoded %d bytes", len(data))
+			}
+			got, err := ParseContainer(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ControlCmd != tt.cmd || !bytes.Equal(got.Payload, tt.payload) {
+				t.Errorf("roundtrip: got %+v", got)
+			}
+		})
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	ms, err := ParseTimeout(TimeoutResponse(0, 500))
+	if err != nil || ms != 500 {
+		t.Errorf("got %d, %v", ms, err)
+	}
+	if _, err := ParseTimeout(TimeoutRequest(0)); err == nil {
+		t.Error("expected error for empty payload")
+	}
+	if _, err := ParseTimeout(StreamEndC2P(0)); err == nil {
+		t.Error("expected error for non-TIMEOUT container")
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	want := Capabilities{MaxRequestPayloadSize: 256, MaxResponsePayloadSize: 65535, Flags: CapabilityEncryptionSupported}
+	c := CapabilitiesResponse(7, want)
+	if !bytes.Equal(c.Payload, []byte{0x00, 0x01, 0xFF, 0xFF, 0x01, 0x00}) {
+		t.Errorf("payload = % x", c.Payload)
+	}
+	got, err := ParseCapabilities(c)
+	if err != nil || got != want {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	if _, err := ParseCapabilities(&Container{Type: TypeControl, ControlCmd: ControlCapabilities, Payload: []byte{1, 2, 3, 4}}); err == nil {
+		t.Error("expected error for short payload")
+	}
+	if _, err := ParseCapabilities(TimeoutRequest(0)); err == nil {
+		t.Error("expected error for non-CAPABILITIES container")
+	}
+}
+
+func TestControlCmd_String(t *testing.T) {
+	for cmd, want := range map[ControlCmd]string{
+		ControlNone:         "NONE",
+		ControlTimeout:      "TIMEOUT",
+		ControlStreamEndC2P: "STREAM_END_C2P",
+		ControlStreamEndP2C: "STREAM_END_P2C",
+		ControlCapabilities: "CAPABILITIES",
+		ControlError:        "ERROR",
+		ControlKeyExchange:  "KEY_EXCHANGE",
+		ControlCmd(15):      "ControlCmd(15)",
+	} {
+		if got := cmd.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", uint8(cmd), got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package wire
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StreamKind tells how a command exchanges messages.
+type StreamKind uint8
+
+const (
+	Unary     StreamKind = iota
+	StreamP2C            // one request, responses until STREAM_END_P2C
+	StreamC2P            // requests until STREAM_END_C2P, then one response
+)
+
+func (k StreamKind) String() string {
+	switch k {
+	case Unary:
+		return "unary"
+	case StreamP2C:
+		return "p2c"
+	case StreamC2P:
+		return "c2p"
+	}
+	return fmt.Sprintf("StreamKind(%d)", uint8(k))
+}
+
+// CommandSpec describes one command of a schema. generate-handlers emits
+// the specs of a .proto file with -out-go-wire.
+type CommandSpec struct {
+	Name     string // wire name sent in command packets
+	Request  string // request message name
+	Response string // response message name
+	Stream   StreamKind
+}
+
+// Registry resolves wire names to command specs.
+type Registry struct {
+	byName map[string]CommandSpec
+}
+
+// NewRegistry validates the specs and indexes them by wire name.
+func NewRegistry(specs []CommandSpec) (*Registry, error) {
+	r := &Registry{byName: make(map[string]CommandSpec, len(specs))}
+	for _, s := range specs {
+		if err := validateCommandName(s.Name); err != nil {
+			return nil, err
+		}
+		if _, dup := r.byName[s.Name]; dup {
+			return nil, fmt.Errorf("wire: duplicate command name %q", s.Name)
+		}
+		r.byName[s.Name] = s
+	}
+	return r, nil
+}
+
+// Lookup returns the spec of a wire name.
+func (r *Registry) Lookup(name string) (CommandSpec, bool) {
+	s, ok := r.byName[name]
+	return s, ok
+}
+
+// Names returns the registered wire names in sorted order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for n := range r.byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
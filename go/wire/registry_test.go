@@ -0,0 +1,48 @@
+package wire
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	specs := []CommandSpec{
+		{Name: "echo", Request: "EchoRequest", Response: "EchoResponse"},
+		{Name: "counter_stream", Request: "CounterStreamRequest", Response: "CounterStreamResponse", Stream: StreamP2C},
+	}
+	r, err := NewRegistry(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := r.Lookup("counter_stream")
+	if !ok || got != specs[1] {
+		t.Errorf("Lookup = %+v, %v", got, ok)
+	}
+	if _, ok := r.Lookup("missing"); ok {
+		t.Error("unknown name should not resolve")
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"counter_stream", "echo"}) {
+		t.Errorf("Names = %v", names)
+	}
+}
+
+func TestNewRegistry_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []CommandSpec
+		want  string
+	}{
+		{"empty name", []CommandSpec{{Name: ""}}, "empty command name"},
+		{"name too long", []CommandSpec{{Name: strings.Repeat("n", 256)}}, "256 bytes"},
+		{"duplicate", []CommandSpec{{Name: "echo"}, {Name: "echo"}}, "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(tt.specs)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+# Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT
+#
+# Generated peripheral sources, include directories and Kconfig-driven
+# settings. Include it from the application CMakeLists.txt after
+# find_package(Zephyr):
+#
+#   include(${CMAKE_CURRENT_SOURCE_DIR}/generated_sources.cmake)
+
+target_sources(app PRIVATE
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_handlers.c
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_gatt_service.c
+)
+
+target_include_directories(app PRIVATE
+    ${CMAKE_CURRENT_LIST_DIR}/src
+)
+
+target_compile_definitions(app PRIVATE
+    BLERPC_CMDS_BLERPC=$<BOOL:${CONFIG_BLERPC_CMDS_BLERPC}>
+)
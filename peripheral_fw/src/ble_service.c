@@ -21,10 +21,15 @@ LOG_MODULE_REGISTER(ble_service, LOG_LEVEL_INF);
 /* type(1) + name_len(1) + name(max 16) + data_len(2) */
 #define CMD_HEADER_MAX_SIZE 20
 
-static struct bt_uuid_128 blerpc_svc_uuid = BT_UUID_INIT_128(BLERPC_SERVICE_UUID);
-static struct bt_uuid_128 blerpc_char_uuid = BT_UUID_INIT_128(BLERPC_CHAR_UUID);
-
 static struct bt_conn *current_conn;
+
+/* With -c-handler-ctx the handlers receive the connection of the request. */
+#ifdef BLERPC_HANDLER_CTX
+#define HANDLER_CTX_ARG , (void *)current_conn
+#else
+#define HANDLER_CTX_ARG
+#endif
+
 static struct container_assembler assembler;
 static ble_service_stream_end_cb_t stream_end_cb;
 static uint8_t transaction_counter;
@@ -230,6 +235,18 @@ static void send_busy_error(uint8_t transaction_id)
     }
 }
 
+#ifdef BLERPC_CONTROL_CMD_CANCEL
+/* Whether a request handler is running, so a Cancel has a stream to stop. */
+static bool request_in_progress(void)
+{
+#ifdef CONFIG_BLERPC_DOUBLE_BUFFER
+    return k_work_busy_get(&req_work_pool[0].work) || k_work_busy_get(&req_work_pool[1].work);
+#else
+    return k_work_busy_get(&req_work.work);
+#endif
+}
+#endif
+
 /* ── Request processing ──────────────────────────────────────────────── */
 
 static void process_request(const uint8_t *data, size_t len, uint8_t transaction_id)
@@ -247,7 +264,7 @@ static void process_request(const uint8_t *data, size_t len, uint8_t transaction
     }
 
     /* Look up handler */
-    command_handler_fn handler = handlers_lookup(cmd.cmd_name, cmd.cmd_name_len);
+    command_handler_fn handler = handlers_lookup(cmd.cmd_name, cmd.cmd_name_len HANDLER_CTX_ARG);
     if (!handler) {
         LOG_ERR("Unknown command: %.*s", cmd.cmd_name_len, cmd.cmd_name);
         return;
@@ -255,7 +272,16 @@ static void process_request(const uint8_t *data, size_t len, uint8_t transaction
 
     /* Pass 1: Calculate protobuf encoded size (sizing stream, no I/O) */
     pb_ostream_t sizing = PB_OSTREAM_SIZING;
-    int handler_rc = handler(cmd.data, cmd.data_len, &sizing);
+    int handler_rc = handler(cmd.data, cmd.data_len, &sizing HANDLER_CTX_ARG);
+    if (handler_rc == HANDLER_BUSY) {
+        LOG_WRN("Rate limited: %.*s", cmd.cmd_name_len, cmd.cmd_name);
+        send_busy_error(transaction_id);
+        return;
+    }
+    if (handler_rc == HANDLER_REPLAYED) {
+        LOG_WRN("Replayed request dropped: %.*s", cmd.cmd_name_len, cmd.cmd_name);
+        return;
+    }
     if (handler_rc == -2) {
         /* Handler manages its own response (e.g. stream handlers) */
         return;
@@ -317,7 +343,7 @@ static void process_request(const uint8_t *data, size_t len, uint8_t transaction
         /* Encode protobuf into the buffer after the command header */
         pb_ostream_t ostream = pb_ostream_from_buffer(cmd_plain_buf + cmd_hdr_size,
                                                       sizeof(cmd_plain_buf) - cmd_hdr_size);
-        if (handler(cmd.data, cmd.data_len, &ostream) != 0) {
+        if (handler(cmd.data, cmd.data_len, &ostream HANDLER_CTX_ARG) != 0) {
             LOG_ERR("Handler encode pass failed");
             return;
         }
@@ -359,7 +385,7 @@ static void process_request(const uint8_t *data, size_t len, uint8_t transaction
         .bytes_written = 0,
     };
 
-    if (handler(cmd.data, cmd.data_len, &ostream) != 0) {
+    if (handler(cmd.data, cmd.data_len, &ostream HANDLER_CTX_ARG) != 0) {
         LOG_ERR("Handler encode pass failed");
         return;
     }
@@ -380,19 +406,17 @@ static void request_work_handler(struct k_work *work)
 
 /* ── BLE service ─────────────────────────────────────────────────────── */
 
-static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, const void *buf,
-                        uint16_t len, uint16_t offset, uint8_t flags)
+/* Containers written to the RPC characteristic of the generated GATT service */
+void blerpc_gatt_on_write(struct bt_conn *conn, const uint8_t *buf, uint16_t len)
 {
-    (void)attr;
-    (void)offset;
-    (void)flags;
+    (void)conn;
 
     LOG_DBG("Write: %u bytes", len);
 
     struct container_header hdr;
     if (container_parse_header(buf, len, &hdr) != 0) {
         LOG_ERR("Container parse failed");
-        return len;
+        return;
     }
 
     /* Handle control containers inline (small, fast) */
@@ -419,6 +443,15 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
             if (stream_end_cb) {
                 stream_end_cb(hdr.transaction_id);
             }
+#ifdef BLERPC_CONTROL_CMD_CANCEL
+        } else if (hdr.control_cmd == BLERPC_CONTROL_CMD_CANCEL) {
+            /* A Cancel arriving after its stream ended must not stop the next
+             * one, which clears the flag only when it ends. */
+            if (request_in_progress()) {
+                LOG_INF("Stream cancelled by central");
+                blerpc_stream_cancel();
+            }
+#endif
         } else if (hdr.control_cmd == CONTROL_CMD_CAPABILITIES) {
             uint8_t ctrl_buf[12];
             struct container_header ctrl = {
@@ -434,6 +467,9 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
             uint16_t flags = 0;
 #ifdef CONFIG_BLERPC_ENCRYPTION
             flags |= CAPABILITY_FLAG_ENCRYPTION_SUPPORTED;
+#endif
+#ifdef BLERPC_CAPABILITY_FLAG_COMPRESSION
+            flags |= BLERPC_CAPABILITY_FLAG_COMPRESSION;
 #endif
             caps_payload[0] = (uint8_t)(max_req & 0xFF);
             caps_payload[1] = (uint8_t)(max_req >> 8);
@@ -451,7 +487,7 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
             /* Block KX re-initiation when encryption is already active */
             if (encryption_active) {
                 LOG_WRN("Key exchange rejected: encryption already active");
-                return len;
+                return;
             }
 
             uint8_t kx_out[BLERPC_STEP2_SIZE]; /* large enough for step 2 or 4 */
@@ -462,7 +498,7 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
                                                  kx_out, sizeof(kx_out), &kx_out_len,
                                                  &crypto_session, &session_established) != 0) {
                 LOG_ERR("Key exchange step processing failed");
-                return len;
+                return;
             }
 
             uint8_t resp_buf[BLERPC_STEP2_SIZE + CONTAINER_CONTROL_HEADER_SIZE];
@@ -485,7 +521,7 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
             }
 #endif /* CONFIG_BLERPC_ENCRYPTION */
         }
-        return len;
+        return;
     }
 
     /* Feed into assembler */
@@ -507,7 +543,7 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
             LOG_WRN("Both request work slots busy, sending BUSY error");
             send_busy_error(hdr.transaction_id);
             container_assembler_init(&assembler);
-            return len;
+            return;
         }
         atomic_set(&req_work_index, (slot + 1) & 1);
         struct request_work *rw = &req_work_pool[slot];
@@ -517,7 +553,7 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
             LOG_WRN("Request work busy, sending BUSY error");
             send_busy_error(hdr.transaction_id);
             container_assembler_init(&assembler);
-            return len;
+            return;
         }
         struct request_work *rw = &req_work;
 #endif
@@ -533,7 +569,7 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
                                               assembler.total_length) != 0) {
                 LOG_ERR("Decryption failed");
                 container_assembler_init(&assembler);
-                return len;
+                return;
             }
             rw->len = decrypted_len;
             memcpy(rw->data, decrypted, decrypted_len);
@@ -541,7 +577,7 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
             /* Reject unencrypted data when encryption is compiled in */
             LOG_WRN("Rejecting unencrypted payload (encryption enabled but not active)");
             container_assembler_init(&assembler);
-            return len;
+            return;
         }
 #else
         rw->len = assembler.total_length;
@@ -552,38 +588,16 @@ static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, c
     } else if (rc < 0) {
         container_assembler_init(&assembler);
     }
-
-    return len;
 }
 
-/* GATT service definition */
-BT_GATT_SERVICE_DEFINE(blerpc_svc, BT_GATT_PRIMARY_SERVICE(&blerpc_svc_uuid),
-                       BT_GATT_CHARACTERISTIC(&blerpc_char_uuid.uuid,
-                                              BT_GATT_CHRC_WRITE_WITHOUT_RESP | BT_GATT_CHRC_NOTIFY,
-                                              BT_GATT_PERM_WRITE, NULL, on_write, NULL),
-                       BT_GATT_CCC(NULL, BT_GATT_PERM_READ | BT_GATT_PERM_WRITE), );
-
 uint16_t ble_service_get_mtu(void)
 {
-    if (current_conn) {
-        return bt_gatt_get_mtu(current_conn);
-    }
-    return 23; /* Default minimum */
+    return blerpc_gatt_get_mtu(current_conn);
 }
 
 int ble_service_notify(const uint8_t *data, size_t len)
 {
-    if (!current_conn) {
-        return -ENOTCONN;
-    }
-
-    struct bt_gatt_notify_params params = {
-        .attr = &blerpc_svc.attrs[2],
-        .data = data,
-        .len = len,
-    };
-
-    return bt_gatt_notify_cb(current_conn, &params);
+    return blerpc_gatt_notify(current_conn, data, len);
 }
 
 static void connected(struct bt_conn *conn, uint8_t err)
@@ -645,6 +659,7 @@ BT_CONN_CB_DEFINE(conn_callbacks) = {
 
 void ble_service_init(void)
 {
+    blerpc_gatt_init();
     k_work_queue_init(&blerpc_work_q);
     k_work_queue_start(&blerpc_work_q, blerpc_work_stack, K_THREAD_STACK_SIZEOF(blerpc_work_stack),
                        K_PRIO_COOP(7), NULL);
@@ -3,17 +3,12 @@
 
 #include <zephyr/bluetooth/bluetooth.h>
 #include <zephyr/bluetooth/gatt.h>
+#include "generated_gatt_service.h"
 
 #ifdef __cplusplus
 extern "C" {
 #endif
 
-/* blerpc Service UUID: 12340001-0000-1000-8000-00805f9b34fb */
-#define BLERPC_SERVICE_UUID BT_UUID_128_ENCODE(0x12340001, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
-
-/* blerpc Characteristic UUID: 12340002-0000-1000-8000-00805f9b34fb */
-#define BLERPC_CHAR_UUID BT_UUID_128_ENCODE(0x12340002, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
-
 /**
  * Initialize the BLE service (work queue, assembler).
  * Call after bt_enable() but before starting advertising.
@@ -1,10 +1,33 @@
-/* Auto-generated by generate-handlers — DO NOT EDIT */
+/* Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT */
 #include "generated_handlers.h"
 #include "blerpc.pb.h"
 #include <pb_encode.h>
 #include <pb_decode.h>
 #include <string.h>
 
+/* Each command, header and name included, must fit the transport
+ * buffers at its largest. */
+#ifdef CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "echo requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(14 + BLERPC_FLASH_READ_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "flash_read requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_STREAM_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "counter_stream requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_UPLOAD_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "counter_upload requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+#endif
+#ifdef CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "echo responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(14 + BLERPC_DATA_WRITE_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "data_write responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_STREAM_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "counter_stream responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_UPLOAD_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "counter_upload responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+#endif
+
 /* Discard callback for FT_CALLBACK fields during decode */
 static bool discard_bytes_cb(pb_istream_t *stream, const pb_field_t *field,
                              void **arg)
@@ -21,6 +44,97 @@ static bool discard_bytes_cb(pb_istream_t *stream, const pb_field_t *field,
     return true;
 }
 
+__attribute__((weak))
+int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len)
+{
+    (void)cmd_name;
+    (void)data;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_stream_finish(void)
+{
+    return -1;
+}
+
+/* Set by a Cancel container, cleared when the stream ends. */
+static volatile bool stream_cancelled;
+
+void blerpc_stream_cancel(void)
+{
+    stream_cancelled = true;
+}
+
+bool blerpc_stream_should_stop(void)
+{
+    return stream_cancelled;
+}
+
+int blerpc_stream_end(void)
+{
+    stream_cancelled = false;
+    return blerpc_stream_finish() == 0 ? -2 : -1;
+}
+
+int blerpc_counter_stream_emit(const blerpc_CounterStreamResponse *resp)
+{
+    uint8_t buf[blerpc_CounterStreamResponse_size];
+    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+    if (!pb_encode(&out, blerpc_CounterStreamResponse_fields, resp)) return -1;
+    return blerpc_stream_write("counter_stream", buf, out.bytes_written);
+}
+
+/* Finalizes the client stream in progress; set by its requests. */
+static int (*stream_c2p_end)(void);
+
+__attribute__((weak))
+int blerpc_counter_upload_accumulate(const blerpc_CounterUploadRequest *req)
+{
+    (void)req;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_counter_upload_finalize(blerpc_CounterUploadResponse *resp)
+{
+    (void)resp;
+    return 0;
+}
+
+static int end_counter_upload(void)
+{
+    blerpc_CounterUploadResponse resp = blerpc_CounterUploadResponse_init_zero;
+    if (blerpc_counter_upload_finalize(&resp) != 0) return -1;
+    uint8_t buf[blerpc_CounterUploadResponse_size];
+    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+    if (!pb_encode(&out, blerpc_CounterUploadResponse_fields, &resp)) return -1;
+    return blerpc_stream_write("counter_upload", buf, out.bytes_written);
+}
+
+__attribute__((weak))
+int handle_counter_upload(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    (void)ostream; /* Not used — the response goes out from blerpc_stream_end_c2p */
+    blerpc_CounterUploadRequest req = blerpc_CounterUploadRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_CounterUploadRequest_fields, &req)) return -1;
+    if (blerpc_counter_upload_accumulate(&req) != 0) return -1;
+    stream_c2p_end = end_counter_upload;
+
+    /* Return -2: the requests of a stream get no response */
+    return -2;
+}
+
+int blerpc_stream_end_c2p(void)
+{
+    int (*end)(void) = stream_c2p_end;
+    stream_c2p_end = NULL;
+    return end != NULL ? end() : -1;
+}
+
 __attribute__((weak))
 int handle_echo(const uint8_t *req_data, size_t req_len,
                     pb_ostream_t *ostream)
@@ -34,6 +148,45 @@ int handle_echo(const uint8_t *req_data, size_t req_len,
     return 0;
 }
 
+__attribute__((weak))
+size_t blerpc_flash_read_length_data(const blerpc_FlashReadRequest *req)
+{
+    (void)req;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_flash_read_source_data(const blerpc_FlashReadRequest *req, uint8_t *chunk,
+                                  size_t len, size_t offset)
+{
+    (void)req;
+    (void)chunk;
+    (void)len;
+    (void)offset;
+    return -1;
+}
+
+static bool write_flash_read_data(pb_ostream_t *stream, const pb_field_t *field,
+                                  void *const *arg)
+{
+    const blerpc_FlashReadRequest *req = *arg;
+    size_t len = blerpc_flash_read_length_data(req);
+    if (len == 0) return true;
+    if (!pb_encode_tag_for_field(stream, field)) return false;
+    if (!pb_encode_varint(stream, len)) return false;
+    /* The sizing pass counts the bytes without reading them. */
+    if (stream->callback == NULL) return pb_write(stream, NULL, len);
+    uint8_t buf[64];
+    size_t offset = 0;
+    while (offset < len) {
+        size_t n = len - offset < sizeof(buf) ? len - offset : sizeof(buf);
+        if (blerpc_flash_read_source_data(req, buf, n, offset) != 0) return false;
+        if (!pb_write(stream, buf, n)) return false;
+        offset += n;
+    }
+    return true;
+}
+
 __attribute__((weak))
 int handle_flash_read(const uint8_t *req_data, size_t req_len,
                           pb_ostream_t *ostream)
@@ -43,16 +196,51 @@ int handle_flash_read(const uint8_t *req_data, size_t req_len,
     if (!pb_decode(&stream, blerpc_FlashReadRequest_fields, &req)) return -1;
 
     blerpc_FlashReadResponse resp = blerpc_FlashReadResponse_init_zero;
+    resp.data.funcs.encode = write_flash_read_data;
+    resp.data.arg = &req;
     if (!pb_encode(ostream, blerpc_FlashReadResponse_fields, &resp)) return -1;
     return 0;
 }
 
+__attribute__((weak))
+int blerpc_data_write_read_data(const blerpc_DataWriteRequest *req, const uint8_t *chunk,
+                                size_t len, size_t offset)
+{
+    (void)req;
+    (void)chunk;
+    (void)len;
+    (void)offset;
+    return 0;
+}
+
+static bool read_data_write_data(pb_istream_t *stream, const pb_field_t *field,
+                                 void **arg)
+{
+    (void)field;
+    const blerpc_DataWriteRequest *req = *arg;
+    uint8_t buf[64];
+    size_t offset = 0;
+    while (stream->bytes_left > 0) {
+        size_t n = stream->bytes_left < sizeof(buf) ? stream->bytes_left : sizeof(buf);
+        if (!pb_read(stream, buf, n)) return false;
+        if (blerpc_data_write_read_data(req, buf, n, offset) != 0) return false;
+        offset += n;
+    }
+    return true;
+}
+
 __attribute__((weak))
 int handle_data_write(const uint8_t *req_data, size_t req_len,
                           pb_ostream_t *ostream)
 {
     blerpc_DataWriteRequest req = blerpc_DataWriteRequest_init_zero;
-    req.data.funcs.decode = discard_bytes_cb;
+    /* Read the request's FT_CALLBACK fields once, on the sizing pass. */
+    if (ostream->callback == NULL) {
+        req.data.funcs.decode = read_data_write_data;
+        req.data.arg = &req;
+    } else {
+        req.data.funcs.decode = discard_bytes_cb;
+    }
     pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
     if (!pb_decode(&stream, blerpc_DataWriteRequest_fields, &req)) return -1;
 
@@ -65,34 +253,23 @@ __attribute__((weak))
 int handle_counter_stream(const uint8_t *req_data, size_t req_len,
                               pb_ostream_t *ostream)
 {
+    (void)ostream; /* Not used — responses go out through blerpc_counter_stream_emit */
     blerpc_CounterStreamRequest req = blerpc_CounterStreamRequest_init_zero;
     pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
     if (!pb_decode(&stream, blerpc_CounterStreamRequest_fields, &req)) return -1;
 
-    blerpc_CounterStreamResponse resp = blerpc_CounterStreamResponse_init_zero;
-    if (!pb_encode(ostream, blerpc_CounterStreamResponse_fields, &resp)) return -1;
-    return 0;
-}
-
-__attribute__((weak))
-int handle_counter_upload(const uint8_t *req_data, size_t req_len,
-                              pb_ostream_t *ostream)
-{
-    blerpc_CounterUploadRequest req = blerpc_CounterUploadRequest_init_zero;
-    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
-    if (!pb_decode(&stream, blerpc_CounterUploadRequest_fields, &req)) return -1;
-
-    blerpc_CounterUploadResponse resp = blerpc_CounterUploadResponse_init_zero;
-    if (!pb_encode(ostream, blerpc_CounterUploadResponse_fields, &resp)) return -1;
-    return 0;
+    /* Send each response with blerpc_counter_stream_emit() */
+    return blerpc_stream_end();
 }
 
 static const struct handler_entry handler_table[] = {
+#if BLERPC_CMDS_BLERPC
     {"echo", 4, handle_echo},
     {"flash_read", 10, handle_flash_read},
     {"data_write", 10, handle_data_write},
     {"counter_stream", 14, handle_counter_stream},
     {"counter_upload", 14, handle_counter_upload},
+#endif
 };
 
 command_handler_fn handlers_lookup(const char *name, uint8_t name_len)
@@ -168,24 +168,11 @@ static bool data_write_decode_cb(pb_istream_t *stream, const pb_field_t *field,
 
 /* ── counter_stream: P→C stream ───────────────────────────────────── */
 
-static int send_one_counter_stream_response(uint32_t seq, int32_t value)
+int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len)
 {
-    blerpc_CounterStreamResponse resp = blerpc_CounterStreamResponse_init_zero;
-    resp.seq = seq;
-    resp.value = value;
-
-    /* Encode protobuf to buffer */
-    uint8_t pb_buf[blerpc_CounterStreamResponse_size];
-    pb_ostream_t ostream = pb_ostream_from_buffer(pb_buf, sizeof(pb_buf));
-    if (!pb_encode(&ostream, blerpc_CounterStreamResponse_fields, &resp)) {
-        LOG_ERR("CounterStream encode failed");
-        return -1;
-    }
-
-    /* Build command response */
     static uint8_t cmd_buf[64];
-    int cmd_len = command_serialize(COMMAND_TYPE_RESPONSE, "counter_stream", 14, pb_buf,
-                                    (uint16_t)ostream.bytes_written, cmd_buf, sizeof(cmd_buf));
+    int cmd_len = command_serialize(COMMAND_TYPE_RESPONSE, cmd_name, (uint8_t)strlen(cmd_name),
+                                    data, (uint16_t)len, cmd_buf, sizeof(cmd_buf));
     if (cmd_len < 0) {
         return -1;
     }
@@ -195,9 +182,16 @@ static int send_one_counter_stream_response(uint32_t seq, int32_t value)
     return ble_service_send_command_response(tid, cmd_buf, (size_t)cmd_len);
 }
 
+int blerpc_stream_finish(void)
+{
+    uint8_t tid = ble_service_next_transaction_id();
+    ble_service_send_stream_end_p2c(tid);
+    return 0;
+}
+
 int handle_counter_stream(const uint8_t *req_data, size_t req_len, pb_ostream_t *ostream)
 {
-    (void)ostream; /* Not used — we send responses directly */
+    (void)ostream; /* Not used — responses go out through blerpc_counter_stream_emit */
 
     blerpc_CounterStreamRequest req = blerpc_CounterStreamRequest_init_zero;
     pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
@@ -216,19 +210,22 @@ int handle_counter_stream(const uint8_t *req_data, size_t req_len, pb_ostream_t
 
     /* Send N responses, each with its own transaction_id */
     for (uint32_t i = 0; i < req.count; i++) {
-        int rc = send_one_counter_stream_response(i, (int32_t)(i * 10));
+        if (blerpc_stream_should_stop()) {
+            LOG_INF("CounterStream: cancelled after %u responses", i);
+            break;
+        }
+        blerpc_CounterStreamResponse resp = blerpc_CounterStreamResponse_init_zero;
+        resp.seq = i;
+        resp.value = (int32_t)(i * 10);
+        int rc = blerpc_counter_stream_emit(&resp);
         if (rc != 0) {
             LOG_ERR("CounterStream send %u failed: %d", i, rc);
             return -1;
         }
     }
 
-    /* Send STREAM_END_P2C */
-    uint8_t tid = ble_service_next_transaction_id();
-    ble_service_send_stream_end_p2c(tid);
-
-    /* Return -2: process_request will skip normal response */
-    return -2;
+    /* Send STREAM_END_P2C; returns -2 so process_request skips the normal response */
+    return blerpc_stream_end();
 }
 
 /* ── counter_upload: C→P stream (accumulation) ────────────────────── */
@@ -245,57 +242,31 @@ static void on_stream_end_c2p(uint8_t transaction_id)
     ble_service_submit_work(&upload_response_work);
 }
 
-int handle_counter_upload(const uint8_t *req_data, size_t req_len, pb_ostream_t *ostream)
+int blerpc_counter_upload_accumulate(const blerpc_CounterUploadRequest *req)
 {
-    (void)ostream;
-
-    blerpc_CounterUploadRequest req = blerpc_CounterUploadRequest_init_zero;
-    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
-
-    if (!pb_decode(&stream, blerpc_CounterUploadRequest_fields, &req)) {
-        LOG_ERR("CounterUpload decode failed: %s", PB_GET_ERROR(&stream));
-        return -1;
-    }
-
     atomic_inc(&upload_count);
-    LOG_DBG("CounterUpload: seq=%u value=%d (total=%ld)", req.seq, req.value,
+    LOG_DBG("CounterUpload: seq=%u value=%d (total=%ld)", req->seq, req->value,
             atomic_get(&upload_count));
-
-    /* Return -2: no response for individual stream messages */
-    return -2;
+    return 0;
 }
 
-static void send_upload_response(struct k_work *work)
+int blerpc_counter_upload_finalize(blerpc_CounterUploadResponse *resp)
 {
-    (void)work;
-
     atomic_val_t count = atomic_set(&upload_count, 0);
 
     LOG_INF("CounterUpload: sending response, received_count=%ld", count);
+    resp->received_count = (uint32_t)count;
+    return 0;
+}
 
-    /* Encode CounterUploadResponse */
-    blerpc_CounterUploadResponse resp = blerpc_CounterUploadResponse_init_zero;
-    resp.received_count = (uint32_t)count;
-
-    uint8_t pb_buf[blerpc_CounterUploadResponse_size];
-    pb_ostream_t ostream = pb_ostream_from_buffer(pb_buf, sizeof(pb_buf));
-    if (!pb_encode(&ostream, blerpc_CounterUploadResponse_fields, &resp)) {
-        LOG_ERR("CounterUploadResponse encode failed");
-        return;
-    }
+static void send_upload_response(struct k_work *work)
+{
+    (void)work;
 
-    /* Build command response */
-    static uint8_t cmd_buf[64];
-    int cmd_len = command_serialize(COMMAND_TYPE_RESPONSE, "counter_upload", 14, pb_buf,
-                                    (uint16_t)ostream.bytes_written, cmd_buf, sizeof(cmd_buf));
-    if (cmd_len < 0) {
-        LOG_ERR("Command serialize failed");
-        return;
+    /* Finalizes the upload and sends its response via blerpc_stream_write */
+    if (blerpc_stream_end_c2p() != 0) {
+        LOG_ERR("CounterUpload: response failed");
     }
-
-    /* Send via ble_service helper (handles encryption if active) */
-    uint8_t tid = ble_service_next_transaction_id();
-    ble_service_send_command_response(tid, cmd_buf, (size_t)cmd_len);
 }
 
 void handlers_stream_init(void)
@@ -0,0 +1,308 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT.
+
+BLE peripheral (GATT server) built on bless: it exposes the RPC service and
+characteristic, answers the control containers, reassembles requests,
+decrypts them once a session is established and dispatches them through a
+HandlerRegistry of generated_handlers.py.
+
+Each response of a peripheral-to-central stream goes out as it is yielded,
+followed by STREAM_END_P2C. When the central ends a central-to-peripheral
+stream, the registry's finish makes the response.
+"""
+
+import asyncio
+import logging
+import os
+import struct
+import threading
+import time
+
+from blerpc_protocol.command import CommandPacket, CommandType
+from blerpc_protocol.container import (
+    BLERPC_ERROR_BUSY,
+    BLERPC_ERROR_RESPONSE_TOO_LARGE,
+    CAPABILITY_FLAG_ENCRYPTION_SUPPORTED,
+    Container,
+    ContainerAssembler,
+    ContainerSplitter,
+    ContainerType,
+    ControlCmd,
+    make_stream_end_p2c,
+)
+from blerpc_protocol.crypto import (
+    BlerpcCrypto,
+    BlerpcCryptoSession,
+    PeripheralKeyExchange,
+)
+from bless import (
+    BlessGATTCharacteristic,
+    BlessServer,
+    GATTAttributePermissions,
+    GATTCharacteristicProperties,
+)
+from generated_handlers import HandlerRegistry
+
+logger = logging.getLogger("blerpc-peripheral")
+
+SERVICE_UUID = "12340001-0000-1000-8000-00805f9b34fb"
+CHAR_UUID = "12340002-0000-1000-8000-00805f9b34fb"
+TIMEOUT_MS = 100
+MTU = 247
+MAX_REQUEST_PAYLOAD_SIZE = 65535
+MAX_RESPONSE_PAYLOAD_SIZE = 65535
+NOTIFY_MAX_RETRIES = 50
+NOTIFY_RETRY_DELAY_S = 0.005
+
+
+class BlerpcPeripheral:
+    def __init__(
+        self,
+        registry: HandlerRegistry | None = None,
+        name: str = "blerpc",
+        ed25519_private_key_hex: str | None = None,
+    ):
+        self.registry = HandlerRegistry() if registry is None else registry
+        self.name = name
+        self.server: BlessServer | None = None
+        self.assembler = ContainerAssembler()
+        self.splitter = ContainerSplitter(mtu=MTU)
+        self._loop: asyncio.AbstractEventLoop | None = None
+        self._state_lock = threading.Lock()
+        self._upload: str | None = None
+
+        # Encryption state
+        self._encryption_supported = False
+        self._session: BlerpcCryptoSession | None = None
+        self._kx: PeripheralKeyExchange | None = None
+        self._ed25519_privkey = None  # Kept to make a new KX per connection
+        self._connected = False
+
+        if ed25519_private_key_hex:
+            self._ed25519_privkey = BlerpcCrypto.ed25519_private_from_bytes(
+                bytes.fromhex(ed25519_private_key_hex)
+            )
+            self._kx = PeripheralKeyExchange(self._ed25519_privkey)
+            self._encryption_supported = True
+            logger.info("Encryption key loaded (X25519 generated per session)")
+
+    async def start(self):
+        self._loop = asyncio.get_running_loop()
+        self.server = BlessServer(name=self.name, loop=self._loop)
+        self.server.write_request_func = self._on_write
+
+        await self.server.add_new_service(SERVICE_UUID)
+        char_flags = (
+            GATTCharacteristicProperties.write_without_response
+            | GATTCharacteristicProperties.notify
+        )
+        permissions = (
+            GATTAttributePermissions.readable | GATTAttributePermissions.writeable
+        )
+        await self.server.add_new_characteristic(
+            SERVICE_UUID, CHAR_UUID, char_flags, None, permissions
+        )
+
+        await self.server.start()
+        logger.info("Advertising as '%s' — waiting for connections...", self.name)
+
+    async def stop(self):
+        if self.server:
+            await self.server.stop()
+
+    def _reset_connection_state(self):
+        logger.info("Resetting connection state")
+        with self._state_lock:
+            self._session = None
+            self._upload = None
+            self.assembler = ContainerAssembler()
+            if self._ed25519_privkey is not None:
+                self._kx = PeripheralKeyExchange(self._ed25519_privkey)
+
+    def _on_write(
+        self, characteristic: BlessGATTCharacteristic, value: bytearray, **kwargs
+    ):
+        container = Container.deserialize(bytes(value))
+        # bless reports no disconnects, so a CAPABILITIES request, always the
+        # first thing a central sends, marks a new connection.
+        if (
+            container.container_type == ContainerType.CONTROL
+            and container.control_cmd == ControlCmd.CAPABILITIES
+            and self._connected
+        ):
+            self._reset_connection_state()
+        self._connected = True
+
+        if container.container_type == ContainerType.CONTROL:
+            self._handle_control(container)
+            return
+
+        payload = self.assembler.feed(container)
+        if payload is not None:
+            # Handlers run off the BLE callback thread, in a loop of their own.
+            threading.Thread(
+                target=self._process_request_thread,
+                args=(payload, container.transaction_id),
+                daemon=True,
+            ).start()
+
+    def _handle_control(self, container: Container):
+        tid = container.transaction_id
+        if container.control_cmd == ControlCmd.TIMEOUT:
+            self._send_control(tid, ControlCmd.TIMEOUT, struct.pack("<H", TIMEOUT_MS))
+        elif container.control_cmd == ControlCmd.CAPABILITIES:
+            flags = 0
+            if self._encryption_supported:
+                flags |= CAPABILITY_FLAG_ENCRYPTION_SUPPORTED
+            payload = struct.pack(
+                "<HHH", MAX_REQUEST_PAYLOAD_SIZE, MAX_RESPONSE_PAYLOAD_SIZE, flags
+            )
+            self._send_control(tid, ControlCmd.CAPABILITIES, payload)
+        elif container.control_cmd == ControlCmd.KEY_EXCHANGE:
+            self._handle_key_exchange(container)
+        elif container.control_cmd == ControlCmd.STREAM_END_C2P:
+            threading.Thread(
+                target=lambda: asyncio.run(self._finish_upload()), daemon=True
+            ).start()
+
+    def _handle_key_exchange(self, container: Container):
+        if not self._encryption_supported or self._kx is None:
+            logger.warning("KEY_EXCHANGE received but encryption not supported")
+            return
+        if self._session is not None:
+            logger.warning("KEY_EXCHANGE rejected: encryption already active")
+            return
+        try:
+            response, session = self._kx.handle_step(container.payload)
+        except ValueError as e:
+            logger.error("Key exchange failed: %s", e)
+            return
+        self._send_control(container.transaction_id, ControlCmd.KEY_EXCHANGE, response)
+        if session is not None:
+            with self._state_lock:
+                self._session = session
+            logger.info("E2E encryption established")
+
+    def _process_request_thread(self, payload: bytes, transaction_id: int):
+        try:
+            asyncio.run(self._process_request(payload, transaction_id))
+        except Exception:
+            logger.exception("Error processing request")
+
+    async def _process_request(self, payload: bytes, transaction_id: int):
+        with self._state_lock:
+            session = self._session
+        if session is not None:
+            try:
+                payload = session.decrypt(payload)
+            except RuntimeError as e:
+                logger.error("Decryption/replay error: %s", e)
+                self._send_control(
+                    transaction_id, ControlCmd.ERROR, bytes([BLERPC_ERROR_BUSY])
+                )
+                return
+        elif self._encryption_supported:
+            logger.warning("Rejecting unencrypted payload")
+            return
+
+        cmd = CommandPacket.deserialize(payload)
+        if cmd.cmd_type != CommandType.REQUEST:
+            logger.error("Expected request, got type=%d", cmd.cmd_type)
+            return
+
+        if cmd.cmd_name not in self.registry:
+            logger.error("Unknown command: '%s'", cmd.cmd_name)
+            return
+
+        direction = self.registry.direction(cmd.cmd_name)
+        if direction == "p2c":
+            async for resp_data in self.registry.stream(cmd.cmd_name, cmd.data):
+                self._send_response(cmd.cmd_name, resp_data, None)
+            with self._state_lock:
+                tid = self.splitter.next_transaction_id()
+            self._send_container_sync(make_stream_end_p2c(transaction_id=tid))
+            return
+        resp_data = await self.registry.dispatch(cmd.cmd_name, cmd.data)
+        if direction == "c2p":
+            with self._state_lock:
+                self._upload = cmd.cmd_name
+            return
+        self._send_response(cmd.cmd_name, resp_data, transaction_id)
+
+    async def _finish_upload(self):
+        with self._state_lock:
+            name, self._upload = self._upload, None
+        if name is None:
+            logger.warning("STREAM_END_C2P without a stream")
+            return
+        try:
+            resp_data = await self.registry.finish(name)
+        except Exception:
+            logger.exception("Error finalizing %s", name)
+            return
+        self._send_response(name, resp_data, None)
+
+    def _send_response(
+        self, cmd_name: str, resp_data: bytes, transaction_id: int | None
+    ):
+        """Send a response; stream responses (transaction_id None) get a new ID."""
+        resp_payload = CommandPacket(
+            cmd_type=CommandType.RESPONSE, cmd_name=cmd_name, data=resp_data
+        ).serialize()
+        if len(resp_payload) > MAX_RESPONSE_PAYLOAD_SIZE:
+            logger.warning(
+                "Response too large: %d > %d",
+                len(resp_payload),
+                MAX_RESPONSE_PAYLOAD_SIZE,
+            )
+            if transaction_id is not None:
+                error = bytes([BLERPC_ERROR_RESPONSE_TOO_LARGE])
+                self._send_control(transaction_id, ControlCmd.ERROR, error)
+            return
+
+        with self._state_lock:
+            if self._session is not None:
+                resp_payload = self._session.encrypt(resp_payload)
+            if transaction_id is None:
+                transaction_id = self.splitter.next_transaction_id()
+            containers = self.splitter.split(
+                resp_payload, transaction_id=transaction_id
+            )
+        for c in containers:
+            self._send_container_sync(c)
+
+    def _send_control(self, transaction_id: int, cmd: ControlCmd, payload: bytes):
+        self._send_container_sync(
+            Container(
+                transaction_id=transaction_id,
+                sequence_number=0,
+                container_type=ContainerType.CONTROL,
+                control_cmd=cmd,
+                payload=payload,
+            )
+        )
+
+    def _send_container_sync(self, container: Container):
+        char = self.server.get_characteristic(CHAR_UUID)
+        char.value = container.serialize()
+        for _ in range(NOTIFY_MAX_RETRIES):
+            if self.server.update_value(SERVICE_UUID, CHAR_UUID):
+                return
+            time.sleep(NOTIFY_RETRY_DELAY_S)
+        logger.error("update_value failed after %d retries", NOTIFY_MAX_RETRIES)
+
+
+async def main(registry: HandlerRegistry | None = None):
+    """Serve until interrupted; BLERPC_ED25519_KEY enables encryption."""
+    logging.basicConfig(level=logging.INFO)
+    peripheral = BlerpcPeripheral(
+        registry, ed25519_private_key_hex=os.environ.get("BLERPC_ED25519_KEY")
+    )
+    await peripheral.start()
+    try:
+        await asyncio.Event().wait()
+    finally:
+        await peripheral.stop()
+
+
+if __name__ == "__main__":
+    asyncio.run(main())
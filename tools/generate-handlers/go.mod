@@ -2,4 +2,8 @@ module github.com/tdaira/blerpc/tools/generate-handlers
 
 go 1.23
 
-require github.com/yoheimuta/go-protoparser/v4 v4.11.0
+require (
+	github.com/yoheimuta/go-protoparser/v4 v4.11.0
+	google.golang.org/protobuf v1.36.12
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,9 @@
+package gen
+
+// Advertisement is a message broadcast as manufacturer specific data.
+type Advertisement struct {
+	Message Message
+	Snake   string
+	Type    int // advertisement type byte following the company identifier
+	MaxSize int // manufacturer data length bound after the company identifier
+}
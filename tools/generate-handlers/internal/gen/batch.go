@@ -0,0 +1,37 @@
+package gen
+
+// With batch: true in blerpc.yaml a client can send several unary calls in
+// one request, for sequences of small calls such as provisioning where the
+// connection interval, not the calls, sets the time. The batch travels as
+// the request of the reserved command _batch, so transports, encryption and
+// framing carry it like any other:
+//
+//	request data:  per call   name length (1) | name | data length (uint16 LE) | data
+//	response data: per call   data length (uint16 LE) | data
+//
+// handlers_lookup answers _batch with <pkg>_batch_handler (generated_batch.c),
+// which looks each call up through handlers_lookup, so roles, rate limits and
+// command groups apply, runs it and collects the responses in order. A call
+// that cannot run is answered with an error response: UNIMPLEMENTED for an
+// unknown command or one a batch cannot carry, RESOURCE_EXHAUSTED when it is
+// rate limited or its response does not fit <PKG>_BATCH_BUF_SIZE, INTERNAL
+// when its handler fails. Streaming commands, and commands whose requests
+// carry a replay counter or a session token, cannot be batched. The Python,
+// Kotlin and Swift clients get a Batch builder with a method per batchable
+// command returning a BatchCall, which holds the response once the batch is
+// sent.
+
+// BatchCommandName is the reserved command name of the batch envelope.
+const BatchCommandName = "_batch"
+
+// BatchCommands returns the commands a batch can carry.
+func BatchCommands(commands []Command, streaming map[string]string) []Command {
+	var batchable []Command
+	for _, cmd := range commands {
+		if _, ok := streaming[cmd.Snake]; ok || cmd.ReplayProtected || cmd.SessionProtected {
+			continue
+		}
+		batchable = append(batchable, cmd)
+	}
+	return batchable
+}
@@ -0,0 +1,23 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestBatchCommands(t *testing.T) {
+	replay := gentest.EchoCommand()
+	replay.Snake = "unlock"
+	replay.ReplayProtected = true
+	session := gentest.EchoCommand()
+	session.Snake = "wipe"
+	session.SessionProtected = true
+	commands := []Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand(), replay, session}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+
+	got := BatchCommands(commands, streaming)
+	if len(got) != 1 || got[0].Snake != "echo" {
+		t.Errorf("batchCommands = %v, want only echo", got)
+	}
+}
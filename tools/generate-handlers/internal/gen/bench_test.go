@@ -0,0 +1,20 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func BenchmarkParseProto1000(b *testing.B) {
+	src := gentest.SyntheticProto(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := protomodel.ParseReader(strings.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package gen
+
+// The blerpc_info built-in lets a client check on connect that the
+// peripheral was built from the same schema. get_blerpc_info returns the
+// schema hash and generator version compiled into the firmware; the clients'
+// verify_schema helpers compare the hash with their own and raise
+// SchemaMismatchError when they differ, before a call fails on a message
+// that changed shape.
+
+// GeneratorVersion is reported by get_blerpc_info next to the schema hash.
+// It follows the project version in pyproject.toml.
+const GeneratorVersion = "0.1.0"
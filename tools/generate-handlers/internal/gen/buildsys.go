@@ -0,0 +1,9 @@
+package gen
+
+// BuildTarget is a firmware app described by the build fragments.
+type BuildTarget struct {
+	Name     string // variable prefix, e.g. "blerpc_handlers"
+	Dir      string // fragment directory
+	Sources  []string
+	Includes []string
+}
@@ -0,0 +1,11 @@
+package gen
+
+// BuiltinCommand returns the command of the named built-in, if present.
+func BuiltinCommand(commands []Command, name string) (Command, bool) {
+	for _, cmd := range commands {
+		if cmd.Builtin == name {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
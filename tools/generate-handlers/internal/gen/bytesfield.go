@@ -0,0 +1,20 @@
+package gen
+
+// BoundedBytes reports whether f is a bytes field whose length clients check
+// against its max_size. Fields with a type mapping are left to the mapping.
+func BoundedBytes(f Field) bool {
+	return f.MaxSize > 0 && len(f.TypeOverrides) == 0
+}
+
+// HasBoundedBytesRequests reports whether any command takes a bounded bytes
+// field, which makes the clients declare PayloadTooLargeError.
+func HasBoundedBytesRequests(commands []Command) bool {
+	for _, cmd := range commands {
+		for _, f := range cmd.RequestFields {
+			if BoundedBytes(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateAdvHeader(advs []gen.Advertisement, pkg string, companyID uint) string {
+	up := strings.ToUpper(pkg)
+	guard := up + "_GENERATED_ADVERTISING_H"
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#ifndef " + guard + "\n")
+	b.WriteString("#define " + guard + "\n")
+	b.WriteByte('\n')
+	b.WriteString("#include <stddef.h>\n")
+	b.WriteString("#include <stdint.h>\n")
+	b.WriteString("#include \"" + pkg + ".pb.h\"\n")
+	b.WriteByte('\n')
+	b.WriteString("#ifdef __cplusplus\n")
+	b.WriteString("extern \"C\" {\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Bluetooth SIG company identifier leading the manufacturer data. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_ADV_COMPANY_ID 0x%04x\n", up, companyID))
+	b.WriteByte('\n')
+	b.WriteString("/* Advertisement types, and the largest manufacturer data of each,\n")
+	b.WriteString(" * company identifier included. */\n")
+	for _, adv := range advs {
+		name := up + "_ADV_" + strings.ToUpper(adv.Snake)
+		b.WriteString(fmt.Sprintf("#define %s_TYPE %d\n", name, adv.Type))
+		b.WriteString(fmt.Sprintf("#define %s_MAX_SIZE %d\n", name, adv.MaxSize+2))
+	}
+	b.WriteByte('\n')
+	b.WriteString("/*\n")
+	b.WriteString(" * Encode msg as manufacturer specific data: company identifier (little\n")
+	b.WriteString(" * endian), advertisement type, protobuf-encoded message. Return the data\n")
+	b.WriteString(" * length, or -1 if buf is too small. Pass the data to\n")
+	b.WriteString(" * BT_DATA(BT_DATA_MANUFACTURER_DATA, buf, len).\n")
+	b.WriteString(" */\n")
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("int %s_adv_encode_%s(const %s_%s *msg, uint8_t *buf, size_t buf_size);\n",
+			pkg, adv.Snake, pkg, adv.Message.Name))
+	}
+	b.WriteByte('\n')
+	b.WriteString("#ifdef __cplusplus\n")
+	b.WriteString("}\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("#endif /* " + guard + " */\n")
+	return b.String()
+}
+
+func GenerateAdvSource(advs []gen.Advertisement, pkg string) string {
+	up := strings.ToUpper(pkg)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_advertising.h\"\n")
+	b.WriteString("#include <pb_encode.h>\n")
+	b.WriteByte('\n')
+	b.WriteString("static int adv_encode(uint8_t type, const pb_msgdesc_t *fields, const void *msg,\n")
+	b.WriteString("                      uint8_t *buf, size_t buf_size)\n")
+	b.WriteString("{\n")
+	b.WriteString("    if (buf_size < 3) return -1;\n")
+	b.WriteString(fmt.Sprintf("    buf[0] = %s_ADV_COMPANY_ID & 0xff;\n", up))
+	b.WriteString(fmt.Sprintf("    buf[1] = %s_ADV_COMPANY_ID >> 8;\n", up))
+	b.WriteString("    buf[2] = type;\n")
+	b.WriteString("    pb_ostream_t stream = pb_ostream_from_buffer(buf + 3, buf_size - 3);\n")
+	b.WriteString("    if (!pb_encode(&stream, fields, msg)) return -1;\n")
+	b.WriteString("    return (int)(3 + stream.bytes_written);\n")
+	b.WriteString("}\n")
+	for _, adv := range advs {
+		msg := pkg + "_" + adv.Message.Name
+		name := up + "_ADV_" + strings.ToUpper(adv.Snake)
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("int %s_adv_encode_%s(const %s *msg, uint8_t *buf, size_t buf_size)\n", pkg, adv.Snake, msg))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    return adv_encode(%s_TYPE, %s_fields, msg, buf, buf_size);\n", name, msg))
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
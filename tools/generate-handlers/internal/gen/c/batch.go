@@ -0,0 +1,195 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// defaultBatchBufSize is the default <PKG>_BATCH_BUF_SIZE.
+const defaultBatchBufSize = 1024
+
+// writeCBatchDecl emits the batch envelope name, buffer size and handler
+// into generated_handlers.h.
+func writeCBatchDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	up := strings.ToUpper(pkg)
+	b.WriteString("/* Command name of the batch envelope, a request carrying several unary\n")
+	b.WriteString(fmt.Sprintf(" * requests that %s_batch_handler runs in order, answered by one\n", pkg))
+	b.WriteString(" * response carrying their responses. " + gctx.CSymbol("handlers_lookup") + " returns the handler\n")
+	b.WriteString(" * for it. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_BATCH_CMD_NAME \"%s\"\n", up, gen.BatchCommandName))
+	b.WriteByte('\n')
+	b.WriteString("/* Bytes of responses one batch collects; a response that does not fit is\n")
+	b.WriteString(" * answered with RESOURCE_EXHAUSTED. Define it to override. */\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_BATCH_BUF_SIZE\n", up))
+	b.WriteString(fmt.Sprintf("#define %s_BATCH_BUF_SIZE %d\n", up, defaultBatchBufSize))
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	pad := strings.Repeat(" ", len("int "+pkg+"_batch_handler("))
+	b.WriteString(fmt.Sprintf("int %s_batch_handler(const uint8_t *req_data, size_t req_len,\n", pkg))
+	b.WriteString(pad + cHandlerOutParam(gctx, "pb_ostream_t *ostream") + ");\n")
+	b.WriteByte('\n')
+}
+
+// writeCBatchLookup emits the handlers_lookup check for the batch envelope.
+func writeCBatchLookup(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if !gctx.CBatch {
+		return
+	}
+	name := strings.ToUpper(pkg) + "_BATCH_CMD_NAME"
+	b.WriteString(fmt.Sprintf("    if (name_len == sizeof(%s) - 1 &&\n", name))
+	b.WriteString(fmt.Sprintf("        memcmp(name, %s, name_len) == 0) {\n", name))
+	b.WriteString(fmt.Sprintf("        return %s_batch_handler;\n", pkg))
+	b.WriteString("    }\n")
+}
+
+// GenerateBatchSource returns generated_batch.c, the batch handler of the
+// peripheral.
+func GenerateBatchSource(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	up := strings.ToUpper(pkg)
+	batchable := gen.BatchCommands(commands, streaming)
+	ids := gen.HasCommandIDs(commands)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_handlers.h\"\n")
+	b.WriteString("#include <pb_encode.h>\n")
+	b.WriteString("#include <stdbool.h>\n")
+	b.WriteString("#include <string.h>\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Commands a batch may carry: the unary commands whose requests carry no\n")
+	b.WriteString(" * replay counter or session token. */\n")
+	b.WriteString("static bool batchable(const char *name, uint8_t name_len)\n")
+	b.WriteString("{\n")
+	if len(batchable) == 0 {
+		b.WriteString("    (void)name;\n")
+		b.WriteString("    (void)name_len;\n")
+		b.WriteString("    return false;\n")
+	} else {
+		b.WriteString("    static const struct " + gctx.CSymbol("handler_entry") + " commands[] = {\n")
+		for _, cmd := range batchable {
+			b.WriteString(fmt.Sprintf("        {\"%s\", %d, NULL},\n", cmd.Wire(), len(cmd.Wire())))
+		}
+		b.WriteString("    };\n")
+		if ids {
+			b.WriteString("    static const uint8_t command_ids[] = {\n")
+			for _, cmd := range batchable {
+				id := "0"
+				if cmd.ID != 0 {
+					id = cCommandIDConst(cmd, pkg)
+				}
+				b.WriteString("        " + id + ",\n")
+			}
+			b.WriteString("    };\n")
+		}
+		b.WriteString("    size_t i;\n")
+		b.WriteString("    for (i = 0; i < sizeof(commands) / sizeof(commands[0]); i++) {\n")
+		if ids {
+			b.WriteString("        /* A one-byte name carries the command ID. */\n")
+			b.WriteString("        if ((name_len == 1 && (uint8_t)name[0] == command_ids[i]) ||\n")
+			b.WriteString("            (commands[i].name_len == name_len &&\n")
+			b.WriteString("             memcmp(commands[i].name, name, name_len) == 0)) {\n")
+		} else {
+			b.WriteString("        if (commands[i].name_len == name_len &&\n")
+			b.WriteString("            memcmp(commands[i].name, name, name_len) == 0) {\n")
+		}
+		b.WriteString("            return true;\n")
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return false;\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Responses of the last batch run, and its request. */\n")
+	b.WriteString(fmt.Sprintf("static uint8_t resp_buf[%s_BATCH_BUF_SIZE];\n", up))
+	b.WriteString("static size_t resp_len;\n")
+	b.WriteString("static const uint8_t *resp_req;\n")
+	b.WriteString("static size_t resp_req_len;\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Appends a response of len bytes, written after its length. */\n")
+	b.WriteString("static void put_response(size_t len)\n")
+	b.WriteString("{\n")
+	b.WriteString("    resp_buf[resp_len] = (uint8_t)(len & 0xFF);\n")
+	b.WriteString("    resp_buf[resp_len + 1] = (uint8_t)(len >> 8);\n")
+	b.WriteString("    resp_len += 2 + len;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Appends an error response; false when it does not fit. */\n")
+	b.WriteString("static bool put_error(uint32_t status)\n")
+	b.WriteString("{\n")
+	b.WriteString("    if (sizeof(resp_buf) - resp_len < 2) return false;\n")
+	b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(resp_buf + resp_len + 2,\n")
+	b.WriteString("                                              sizeof(resp_buf) - resp_len - 2);\n")
+	b.WriteString(fmt.Sprintf("    if (%s_return_error(&out, status) != 0) return false;\n", pkg))
+	b.WriteString("    put_response(out.bytes_written);\n")
+	b.WriteString("    return true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Runs the calls of a batch request into resp_buf. Like the dispatcher,\n")
+	b.WriteString(" * sizes each response before encoding it. Returns -1 for a malformed\n")
+	b.WriteString(" * request; calls left over when resp_buf is full get no response. */\n")
+	b.WriteString("static int run_batch(const uint8_t *req_data, size_t req_len" + gctx.CCtxSuffix() + ")\n")
+	b.WriteString("{\n")
+	b.WriteString("    size_t pos = 0;\n")
+	b.WriteString("    resp_len = 0;\n")
+	b.WriteString("    while (pos < req_len) {\n")
+	b.WriteString("        uint8_t name_len = req_data[pos];\n")
+	b.WriteString("        if (req_len - pos < 3u + name_len) return -1;\n")
+	b.WriteString("        const char *name = (const char *)req_data + pos + 1;\n")
+	b.WriteString("        size_t data_len = req_data[pos + 1 + name_len] |\n")
+	b.WriteString("                          (size_t)req_data[pos + 2 + name_len] << 8;\n")
+	b.WriteString("        pos += 3u + name_len;\n")
+	b.WriteString("        if (req_len - pos < data_len) return -1;\n")
+	b.WriteString("        const uint8_t *data = req_data + pos;\n")
+	b.WriteString("        pos += data_len;\n")
+	b.WriteByte('\n')
+	b.WriteString("        " + gctx.CSymbol("command_handler_fn") + " handler = NULL;\n")
+	b.WriteString("        if (batchable(name, name_len)) {\n")
+	b.WriteString(fmt.Sprintf("            handler = %s(name, name_len%s);\n", gctx.CSymbol("handlers_lookup"), cHandlerOutArg(gctx, "")))
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        uint32_t status = %s_STATUS_UNIMPLEMENTED;\n", up))
+	b.WriteString("        if (handler != NULL) {\n")
+	b.WriteString("            pb_ostream_t sizing = PB_OSTREAM_SIZING;\n")
+	b.WriteString(fmt.Sprintf("            int rc = handler(data, data_len, %s);\n", cHandlerOutArg(gctx, "&sizing")))
+	b.WriteString("            if (rc == 0 && sizeof(resp_buf) - resp_len >= 2 &&\n")
+	b.WriteString("                sizing.bytes_written <= sizeof(resp_buf) - resp_len - 2) {\n")
+	b.WriteString("                pb_ostream_t out = pb_ostream_from_buffer(resp_buf + resp_len + 2,\n")
+	b.WriteString("                                                          sizing.bytes_written);\n")
+	b.WriteString(fmt.Sprintf("                rc = handler(data, data_len, %s);\n", cHandlerOutArg(gctx, "&out")))
+	b.WriteString("                if (rc == 0) {\n")
+	b.WriteString("                    put_response(out.bytes_written);\n")
+	b.WriteString("                    continue;\n")
+	b.WriteString("                }\n")
+	b.WriteString("            }\n")
+	b.WriteString(fmt.Sprintf("            status = rc == 0 || rc == HANDLER_BUSY ? %s_STATUS_RESOURCE_EXHAUSTED\n", up))
+	b.WriteString(fmt.Sprintf("                                                   : %s_STATUS_INTERNAL;\n", up))
+	b.WriteString("        }\n")
+	b.WriteString("        if (!put_error(status)) break;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	pad := strings.Repeat(" ", len("int "+pkg+"_batch_handler("))
+	b.WriteString(fmt.Sprintf("int %s_batch_handler(const uint8_t *req_data, size_t req_len,\n", pkg))
+	b.WriteString(pad + cHandlerOutParam(gctx, "pb_ostream_t *ostream") + ")\n")
+	b.WriteString("{\n")
+	b.WriteString("    /* The dispatcher calls a handler twice, to size the response and to\n")
+	b.WriteString("     * encode it. The calls run on the first, sizing pass, and the second\n")
+	b.WriteString("     * writes the responses collected then. */\n")
+	b.WriteString("    bool sizing = ostream->callback == NULL;\n")
+	b.WriteString("    if (sizing || req_data != resp_req || req_len != resp_req_len) {\n")
+	b.WriteString(fmt.Sprintf("        if (run_batch(req_data, req_len%s) != 0) return -1;\n", cHandlerOutArg(gctx, "")))
+	b.WriteString("    }\n")
+	b.WriteString("    resp_req = sizing ? req_data : NULL;\n")
+	b.WriteString("    resp_req_len = sizing ? req_len : 0;\n")
+	b.WriteString("    return pb_write(ostream, resp_buf, resp_len) ? 0 : -1;\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateBatchCSource(t *testing.T) {
+	gctx := gen.NewContext()
+	commands := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	got := GenerateBatchSource(gctx, commands, streaming, "blerpc")
+	for _, want := range []string{
+		`{"echo", 4, NULL},`,
+		"static uint8_t resp_buf[BLERPC_BATCH_BUF_SIZE];",
+		"handler = handlers_lookup(name, name_len);",
+		"status = rc == 0 || rc == HANDLER_BUSY ? BLERPC_STATUS_RESOURCE_EXHAUSTED",
+		"int blerpc_batch_handler(const uint8_t *req_data, size_t req_len,",
+		"bool sizing = ostream->callback == NULL;",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Contains(got, "counter_stream") {
+		t.Error("streaming command is batchable")
+	}
+
+	var b strings.Builder
+	writeCBatchDecl(gctx, &b, "blerpc")
+	for _, want := range []string{
+		`#define BLERPC_BATCH_CMD_NAME "_batch"`,
+		"#define BLERPC_BATCH_BUF_SIZE 1024",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+}
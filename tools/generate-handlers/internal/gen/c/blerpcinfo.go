@@ -0,0 +1,51 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCBlerpcInfoDecl emits the identifiers get_blerpc_info reports.
+func writeCBlerpcInfoDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Reported by get_blerpc_info; the clients compare the schema hash with\n")
+	b.WriteString(" * their own on connect. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_SCHEMA_HASH \"%s\"\n", upper, gctx.SchemaHash))
+	b.WriteString(fmt.Sprintf("#define %s_GENERATOR_VERSION \"%s\"\n", upper, gen.GeneratorVersion))
+	b.WriteByte('\n')
+}
+
+// writeCBlerpcInfoHandler emits the get_blerpc_info handler, which encodes
+// the two string constants through callbacks, as the unbounded strings are
+// FT_CALLBACK fields.
+func writeCBlerpcInfoHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	upper := strings.ToUpper(pkg)
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("static bool encode_info_string(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                               void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    const char *s = *arg;\n")
+	b.WriteString("    return pb_encode_tag_for_field(stream, field) &&\n")
+	b.WriteString("           pb_encode_string(stream, (const pb_byte_t *)s, strlen(s));\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString("    (void)req_data; /* The request has no fields */\n")
+	b.WriteString("    (void)req_len;\n")
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    resp.schema_hash.funcs.encode = encode_info_string;\n")
+	b.WriteString(fmt.Sprintf("    resp.schema_hash.arg = (void *)%s_SCHEMA_HASH;\n", upper))
+	b.WriteString("    resp.generator_version.funcs.encode = encode_info_string;\n")
+	b.WriteString(fmt.Sprintf("    resp.generator_version.arg = (void *)%s_GENERATOR_VERSION;\n", upper))
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,135 @@
+package c
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// BuildSystems lists the accepted -build-system values. Each selected build
+// system gets a fragment listing the generated C sources and include
+// directories next to the peripheral and central firmware; the nanopb
+// runtime and the nanopb-generated <pkg>.pb.c are left to the project.
+var BuildSystems = []string{"zephyr", "make", "idf", "platformio"}
+
+// relPaths returns paths relative to dir in slash form, "" for dir itself.
+func relPaths(dir string, paths []string) []string {
+	var rel []string
+	for _, p := range paths {
+		r, err := filepath.Rel(dir, p)
+		if err != nil {
+			r = p
+		}
+		if r == "." {
+			r = ""
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	return rel
+}
+
+// joinBase joins base and a relative path from relPaths.
+func joinBase(base, rel string) string {
+	if rel == "" {
+		return base
+	}
+	return base + "/" + rel
+}
+
+func GenerateMakeFragment(t gen.BuildTarget) string {
+	up := strings.ToUpper(t.Name)
+	var b strings.Builder
+	b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteString("#\n")
+	b.WriteString("# Generated C sources for Make builds. Include it and add the variables to\n")
+	b.WriteString("# the build:\n")
+	b.WriteString("#\n")
+	b.WriteString("#   include path/to/generated.mk\n")
+	b.WriteString(fmt.Sprintf("#   SRCS += $(%s_SRCS)\n", up))
+	b.WriteString(fmt.Sprintf("#   CFLAGS += $(addprefix -I,$(%s_INC_DIRS))\n", up))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("%s_DIR := $(patsubst %%/,%%,$(dir $(lastword $(MAKEFILE_LIST))))\n", up))
+	for _, v := range []struct {
+		suffix string
+		paths  []string
+	}{{"SRCS", t.Sources}, {"INC_DIRS", t.Includes}} {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("%s_%s := \\\n", up, v.suffix))
+		rel := relPaths(t.Dir, v.paths)
+		for i, r := range rel {
+			line := "\t" + joinBase("$("+up+"_DIR)", r)
+			if i < len(rel)-1 {
+				line += " \\"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func GenerateIDFFragment(t gen.BuildTarget) string {
+	up := strings.ToUpper(t.Name)
+	var b strings.Builder
+	b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteString("#\n")
+	b.WriteString("# Generated C sources for an ESP-IDF component. Include it from the\n")
+	b.WriteString("# component CMakeLists.txt before registering the component:\n")
+	b.WriteString("#\n")
+	b.WriteString("#   include(path/to/generated_idf.cmake)\n")
+	b.WriteString(fmt.Sprintf("#   idf_component_register(SRCS main.c ${%s_SRCS}\n", up))
+	b.WriteString(fmt.Sprintf("#                          INCLUDE_DIRS . ${%s_INCLUDE_DIRS})\n", up))
+	for _, v := range []struct {
+		suffix string
+		paths  []string
+	}{{"SRCS", t.Sources}, {"INCLUDE_DIRS", t.Includes}} {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("set(%s_%s\n", up, v.suffix))
+		for _, r := range relPaths(t.Dir, v.paths) {
+			b.WriteString("    " + joinBase("${CMAKE_CURRENT_LIST_DIR}", r) + "\n")
+		}
+		b.WriteString(")\n")
+	}
+	return b.String()
+}
+
+// platformIOLibrary is the library.json manifest of the generated sources,
+// used from a project with lib_deps = symlink://path/to/dir.
+type platformIOLibrary struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Build       struct {
+		SrcDir    string   `json:"srcDir"`
+		SrcFilter []string `json:"srcFilter"`
+		Flags     []string `json:"flags"`
+	} `json:"build"`
+}
+
+func GeneratePlatformIOLibrary(t gen.BuildTarget) string {
+	lib := platformIOLibrary{
+		Name:        strings.ReplaceAll(t.Name, "_", "-"),
+		Version:     "0.0.0",
+		Description: "Auto-generated by generate-handlers — DO NOT EDIT",
+	}
+	lib.Build.SrcDir = "."
+	lib.Build.SrcFilter = []string{"-<*>"}
+	for _, r := range relPaths(t.Dir, t.Sources) {
+		lib.Build.SrcFilter = append(lib.Build.SrcFilter, "+<"+r+">")
+	}
+	for _, r := range relPaths(t.Dir, t.Includes) {
+		if r == "" {
+			r = "."
+		}
+		lib.Build.Flags = append(lib.Build.Flags, "-I"+r)
+	}
+	// srcFilter patterns hold < and >, which Marshal would escape.
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(lib)
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package c
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func peripheralBuildTarget() gen.BuildTarget {
+	dir := filepath.Join("root", "peripheral_fw")
+	return gen.BuildTarget{
+		Name: "blerpc_handlers",
+		Dir:  dir,
+		Sources: []string{
+			filepath.Join(dir, "src", "generated_handlers.c"),
+			filepath.Join(dir, "src", "generated_advertising.c"),
+		},
+		Includes: []string{filepath.Join(dir, "src"), dir},
+	}
+}
+
+func TestGenerateMakeFragment(t *testing.T) {
+	out := GenerateMakeFragment(peripheralBuildTarget())
+	for _, s := range []string{
+		"BLERPC_HANDLERS_DIR := $(patsubst %/,%,$(dir $(lastword $(MAKEFILE_LIST))))\n",
+		"BLERPC_HANDLERS_SRCS := \\\n" +
+			"\t$(BLERPC_HANDLERS_DIR)/src/generated_handlers.c \\\n" +
+			"\t$(BLERPC_HANDLERS_DIR)/src/generated_advertising.c\n",
+		"BLERPC_HANDLERS_INC_DIRS := \\\n" +
+			"\t$(BLERPC_HANDLERS_DIR)/src \\\n" +
+			"\t$(BLERPC_HANDLERS_DIR)\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateIDFFragment(t *testing.T) {
+	out := GenerateIDFFragment(peripheralBuildTarget())
+	for _, s := range []string{
+		"set(BLERPC_HANDLERS_SRCS\n    ${CMAKE_CURRENT_LIST_DIR}/src/generated_handlers.c\n",
+		"set(BLERPC_HANDLERS_INCLUDE_DIRS\n    ${CMAKE_CURRENT_LIST_DIR}/src\n    ${CMAKE_CURRENT_LIST_DIR}\n)\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePlatformIOLibrary(t *testing.T) {
+	out := GeneratePlatformIOLibrary(peripheralBuildTarget())
+	if strings.Contains(out, `\u003c`) {
+		t.Errorf("source filter should not be HTML-escaped:\n%s", out)
+	}
+	var lib platformIOLibrary
+	if err := json.Unmarshal([]byte(out), &lib); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if lib.Name != "blerpc-handlers" {
+		t.Errorf("name = %q", lib.Name)
+	}
+	if got := strings.Join(lib.Build.SrcFilter, " "); got != "-<*> +<src/generated_handlers.c> +<src/generated_advertising.c>" {
+		t.Errorf("srcFilter = %s", got)
+	}
+	if got := strings.Join(lib.Build.Flags, " "); got != "-Isrc -I." {
+		t.Errorf("flags = %s", got)
+	}
+}
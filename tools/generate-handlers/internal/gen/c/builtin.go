@@ -0,0 +1,167 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCBuiltinDecls emits the header declarations of the enabled built-ins.
+func writeCBuiltinDecls(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	if _, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writeCBlerpcInfoDecl(gctx, b, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "capabilities"); ok {
+		writeCCapabilitiesDecl(gctx, b, commands, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "conn_params"); ok {
+		writeCConnParamsDecl(b, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "dfu"); ok {
+		writeCDfuDecl(b, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "file_transfer"); ok {
+		writeCFileTransferDecl(b, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "log_stream"); ok {
+		writeCLogStreamDecl(b, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "ping"); ok {
+		writeCPingDecl(b, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "rpc_stats"); ok {
+		writeCRPCStatsDecl(b, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "session"); ok {
+		writeCSessionDecl(b, pkg)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "settings"); ok {
+		writeCSettingsDecl(b, cmd, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "time_sync"); ok {
+		writeCTimeSyncDecl(b, pkg)
+	}
+}
+
+// writeCBuiltinHandler emits the nanopb handler of a built-in command in
+// place of the empty weak stub, reporting whether cmd is one.
+func writeCBuiltinHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) bool {
+	switch cmd.Builtin {
+	case "blerpc_info":
+		writeCBlerpcInfoHandler(gctx, b, cmd, pkg)
+	case "capabilities":
+		writeCCapabilitiesHandler(gctx, b, cmd, pkg)
+	case "conn_params":
+		writeCConnParamsHandler(gctx, b, cmd, pkg)
+	case "dfu":
+		writeCDfuHandler(gctx, b, cmd, pkg)
+	case "file_transfer":
+		writeCFileTransferHandler(gctx, b, cmd, pkg)
+	case "log_stream":
+		writeCLogStreamHandler(gctx, b, cmd, pkg)
+	case "ping":
+		writeCPingHandler(gctx, b, cmd, pkg)
+	case "rpc_stats":
+		writeCRPCStatsHandler(gctx, b, cmd, pkg)
+	case "session":
+		if cmd.RequestMsg == "StartSessionRequest" {
+			writeCStartSessionHandler(gctx, b, cmd, pkg)
+		} else {
+			writeCAuthenticateSessionHandler(gctx, b, cmd, pkg)
+		}
+	case "settings":
+		if cmd.RequestMsg == "GetSettingRequest" {
+			writeCGetSettingHandler(gctx, b, cmd, pkg)
+		} else {
+			writeCSetSettingHandler(gctx, b, cmd, pkg)
+		}
+	case "time_sync":
+		writeCTimeSyncHandler(gctx, b, cmd, pkg)
+	default:
+		return false
+	}
+	return true
+}
+
+// connParamsProfiles are the default connection parameters of each
+// ConnProfile value: interval min/max in 1.25 ms units, peripheral latency
+// and supervision timeout in 10 ms units. The firmware hook may adjust them.
+var connParamsProfiles = []struct {
+	value                             string
+	intervalMin, intervalMax, latency int
+	timeout                           int
+}{
+	{"CONN_PROFILE_FAST", 6, 12, 0, 400},
+	{"CONN_PROFILE_LOW_POWER", 80, 160, 4, 600},
+	{"CONN_PROFILE_BALANCED", 24, 40, 0, 400},
+}
+
+// writeCConnParamsDecl emits the parameter struct and the hook the
+// conn_params handler calls.
+func writeCConnParamsDecl(b *strings.Builder, pkg string) {
+	b.WriteString("/* Connection parameters requested by conn_params: intervals in 1.25 ms\n")
+	b.WriteString(" * units, supervision timeout in 10 ms units. */\n")
+	b.WriteString(fmt.Sprintf("struct %s_conn_params {\n", pkg))
+	b.WriteString("    uint16_t interval_min;\n")
+	b.WriteString("    uint16_t interval_max;\n")
+	b.WriteString("    uint16_t latency;\n")
+	b.WriteString("    uint16_t timeout;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Requests params for profile (a ConnProfile value) from the BLE stack,\n")
+	b.WriteString(" * e.g. with bt_conn_le_param_update(). params holds the profile defaults\n")
+	b.WriteString(" * and may be adjusted to what was requested. Returns 0 on success; the\n")
+	b.WriteString(" * weak default returns -1, so conn_params fails until it is overridden. */\n")
+	b.WriteString(fmt.Sprintf("int %s_conn_params_apply(uint8_t profile, struct %s_conn_params *params);\n", pkg, pkg))
+	b.WriteByte('\n')
+}
+
+// writeCConnParamsHandler emits the weak hook stub and the conn_params
+// handler, which fills in the profile defaults and calls the hook.
+func writeCConnParamsHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_conn_params_apply(uint8_t profile, struct %s_conn_params *params)\n", pkg, pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)profile;\n")
+	b.WriteString("    (void)params;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    struct %s_conn_params params;\n", pkg))
+	b.WriteString("    switch (req.profile) {\n")
+	for _, p := range connParamsProfiles {
+		if p.value == "CONN_PROFILE_BALANCED" {
+			b.WriteString("    default:\n")
+		} else {
+			b.WriteString(fmt.Sprintf("    case %s_ConnProfile_%s:\n", pkg, p.value))
+		}
+		b.WriteString(fmt.Sprintf("        params = (struct %s_conn_params){%d, %d, %d, %d};\n",
+			pkg, p.intervalMin, p.intervalMax, p.latency, p.timeout))
+		b.WriteString("        break;\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    if (%s_conn_params_apply((uint8_t)req.profile, &params) != 0) return -1;\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	for _, f := range []string{"interval_min", "interval_max", "latency", "timeout"} {
+		b.WriteString(fmt.Sprintf("    resp.%s = params.%s;\n", f, f))
+	}
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,65 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCSetBoundedBytes emits the statements copying the pointer and length
+// parameters of a bounded bytes field into req, failing the call when the
+// length exceeds the array.
+func writeCSetBoundedBytes(b *strings.Builder, f gen.Field) {
+	b.WriteString(fmt.Sprintf("    if (%s_len > sizeof(req.%s.bytes)) return -1;\n", f.Name, f.Name))
+	b.WriteString(fmt.Sprintf("    memcpy(req.%s.bytes, %s, %s_len);\n", f.Name, f.Name, f.Name))
+	b.WriteString(fmt.Sprintf("    req.%s.size = (pb_size_t)%s_len;\n", f.Name, f.Name))
+	if cHasFlag(f) {
+		b.WriteString(fmt.Sprintf("    req.has_%s = true;\n", f.Name))
+	}
+}
+
+// writeCBytesSetters declares, for the handlers, a setter per bounded bytes
+// field of a response, e.g. blerpc_flash_read_set_data.
+func writeCBytesSetters(b *strings.Builder, commands []gen.Command, pkg string) {
+	first := true
+	for _, cmd := range commands {
+		respMsg := pkg + "_" + cmd.ResponseMsg
+		for _, f := range cmd.ResponseFields {
+			if !gen.BoundedBytes(f) {
+				continue
+			}
+			if first {
+				b.WriteString("/* Setters of the bounded bytes fields of the responses: each copies len\n")
+				b.WriteString(" * bytes into the field and returns 0, or returns -1, leaving the field\n")
+				b.WriteString(" * as is, when they exceed its max_size. */\n")
+				first = false
+			}
+			name := fmt.Sprintf("%s_%s_set_%s", pkg, cmd.Snake, f.Name)
+			pad := strings.Repeat(" ", len("static inline int ")+len(name)+1)
+			b.WriteString(fmt.Sprintf("static inline int %s(%s *resp,\n", name, respMsg))
+			b.WriteString(fmt.Sprintf("%sconst uint8_t *data, size_t len)\n", pad))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    if (len > sizeof(resp->%s.bytes)) return -1;\n", f.Name))
+			b.WriteString(fmt.Sprintf("    memcpy(resp->%s.bytes, data, len);\n", f.Name))
+			b.WriteString(fmt.Sprintf("    resp->%s.size = (pb_size_t)len;\n", f.Name))
+			if cHasFlag(f) {
+				b.WriteString(fmt.Sprintf("    resp->has_%s = true;\n", f.Name))
+			}
+			b.WriteString("    return 0;\n")
+			b.WriteString("}\n\n")
+		}
+	}
+}
+
+// hasBoundedBytesResponses reports whether the C header declares setters.
+func hasBoundedBytesResponses(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		for _, f := range cmd.ResponseFields {
+			if gen.BoundedBytes(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
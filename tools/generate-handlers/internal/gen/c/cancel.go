@@ -0,0 +1,44 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCStreamCancelDecl emits the Cancel control command and the
+// cancellation hooks of server-streaming handlers.
+func writeCStreamCancelDecl(b *strings.Builder, pkg string) {
+	b.WriteString("/* Control command of the Cancel container a central sends to stop the\n")
+	b.WriteString(" * server stream in progress; it carries no payload. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_CONTROL_CMD_CANCEL 0x%x\n", strings.ToUpper(pkg), gen.ControlCmdCancel))
+	b.WriteByte('\n')
+	b.WriteString("/* Stops the server stream in progress at its handler's next\n")
+	b.WriteString(fmt.Sprintf(" * %s_stream_should_stop() check. The transport calls it when a Cancel\n", pkg))
+	b.WriteString(" * container arrives while a request runs; safe from any thread. */\n")
+	b.WriteString(fmt.Sprintf("void %s_stream_cancel(void);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Reports whether the central cancelled the server stream in progress.\n")
+	b.WriteString(" * Streaming handlers check it between responses and, once set, stop\n")
+	b.WriteString(fmt.Sprintf(" * emitting and return %s_stream_end(), which clears it. */\n", pkg))
+	b.WriteString(fmt.Sprintf("bool %s_stream_should_stop(void);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCStreamCancel emits the cancellation flag and its hooks.
+func writeCStreamCancel(b *strings.Builder, pkg string) {
+	b.WriteString("/* Set by a Cancel container, cleared when the stream ends. */\n")
+	b.WriteString("static volatile bool stream_cancelled;\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("void %s_stream_cancel(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    stream_cancelled = true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("bool %s_stream_should_stop(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    return stream_cancelled;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,143 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The capabilities built-in tells a client which commands the firmware
+// implements, so one app can drive a fleet on mixed firmware versions. A
+// handler marks itself implemented with <PKG>_IMPLEMENTS(cmd), which
+// overrides a weak false flag; commands left on their weak stubs report as
+// missing and built-ins as implemented. With command IDs the response is a
+// bitmap of IDs, which stay stable across schema versions, else the list of
+// wire names.
+
+// cImplementsFlag names the flag <PKG>_IMPLEMENTS sets for cmd.
+func cImplementsFlag(cmd gen.Command, pkg string) string {
+	return pkg + "_implements_" + cmd.Snake
+}
+
+// writeCCapabilitiesDecl emits the registration macro, the implemented
+// flags and the table accessor.
+func writeCCapabilitiesDecl(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString(fmt.Sprintf("/* Marks a command implemented for get_capabilities: put %s_IMPLEMENTS(echo);\n", upper))
+	b.WriteString(" * next to the " + gctx.CHandlerName("echo") + " overriding the weak stub. Commands left on their\n")
+	b.WriteString(" * stubs report as missing; built-ins always report as implemented. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_IMPLEMENTS(cmd) const bool %s_implements_##cmd = true\n", upper, pkg))
+	b.WriteByte('\n')
+	for _, cmd := range commands {
+		if cmd.Builtin == "" {
+			b.WriteString(fmt.Sprintf("extern const bool %s;\n", cImplementsFlag(cmd, pkg)))
+		}
+	}
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("struct %s_capability {\n", pkg))
+	b.WriteString("    const char *name;\n")
+	b.WriteString("    uint8_t name_len;\n")
+	b.WriteString("    uint8_t id;\n")
+	b.WriteString("    const bool *implemented;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Returns every command in the handler table and stores their number in\n")
+	b.WriteString(" * count. */\n")
+	b.WriteString(fmt.Sprintf("const struct %s_capability *%s_capabilities(size_t *count);\n", pkg, pkg))
+	b.WriteByte('\n')
+}
+
+// writeCCapabilitiesTable emits the weak implemented flags and the table of
+// the commands in the handler table.
+func writeCCapabilitiesTable(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString(fmt.Sprintf("/* get_capabilities flags, set by %s_IMPLEMENTS in the handler sources. */\n", strings.ToUpper(pkg)))
+	for _, cmd := range commands {
+		if cmd.Builtin == "" {
+			b.WriteString(fmt.Sprintf("__attribute__((weak)) const bool %s = false;\n", cImplementsFlag(cmd, pkg)))
+		}
+	}
+	b.WriteString("static const bool builtin_implemented = true;\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("static const struct %s_capability capabilities[] = {\n", pkg))
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		flag := "builtin_implemented"
+		if cmd.Builtin == "" {
+			flag = cImplementsFlag(cmd, pkg)
+		}
+		id := "0"
+		if cmd.ID != 0 {
+			id = cCommandIDConst(cmd, pkg)
+		}
+		return fmt.Sprintf("    {\"%s\", %d, %s, &%s},\n", cmd.Wire(), len(cmd.Wire()), id, flag)
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("const struct %s_capability *%s_capabilities(size_t *count)\n", pkg, pkg))
+	b.WriteString("{\n")
+	b.WriteString("    *count = sizeof(capabilities) / sizeof(capabilities[0]);\n")
+	b.WriteString("    return capabilities;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCCapabilitiesHandler emits the get_capabilities handler, which
+// encodes the implemented commands as a bitmap of their IDs when the
+// commands have IDs, else as their wire names.
+func writeCCapabilitiesHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	field, encoder := "names", "encode_capability_names"
+	if cmd.ID != 0 {
+		field, encoder = "command_ids", "encode_capability_ids"
+		b.WriteString("static bool encode_capability_ids(pb_ostream_t *stream, const pb_field_t *field,\n")
+		b.WriteString("                                  void *const *arg)\n")
+		b.WriteString("{\n")
+		b.WriteString("    (void)arg;\n")
+		b.WriteString(fmt.Sprintf("    uint8_t bitmap[%d / 8 + 1] = {0};\n", gen.MaxCommandID))
+		b.WriteString("    size_t count, i, len = 0;\n")
+		b.WriteString(fmt.Sprintf("    const struct %s_capability *caps = %s_capabilities(&count);\n", pkg, pkg))
+		b.WriteString("    for (i = 0; i < count; i++) {\n")
+		b.WriteString("        if (!*caps[i].implemented) continue;\n")
+		b.WriteString("        bitmap[caps[i].id / 8] |= (uint8_t)(1u << (caps[i].id % 8));\n")
+		b.WriteString("        if ((size_t)caps[i].id / 8 + 1 > len) len = (size_t)caps[i].id / 8 + 1;\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return pb_encode_tag_for_field(stream, field) &&\n")
+		b.WriteString("           pb_encode_string(stream, bitmap, len);\n")
+		b.WriteString("}\n")
+	} else {
+		b.WriteString("static bool encode_capability_names(pb_ostream_t *stream, const pb_field_t *field,\n")
+		b.WriteString("                                    void *const *arg)\n")
+		b.WriteString("{\n")
+		b.WriteString("    (void)arg;\n")
+		b.WriteString("    size_t count, i;\n")
+		b.WriteString(fmt.Sprintf("    const struct %s_capability *caps = %s_capabilities(&count);\n", pkg, pkg))
+		b.WriteString("    for (i = 0; i < count; i++) {\n")
+		b.WriteString("        if (!*caps[i].implemented) continue;\n")
+		b.WriteString("        if (!pb_encode_tag_for_field(stream, field) ||\n")
+		b.WriteString("            !pb_encode_string(stream, (const pb_byte_t *)caps[i].name, caps[i].name_len)) {\n")
+		b.WriteString("            return false;\n")
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return true;\n")
+		b.WriteString("}\n")
+	}
+	b.WriteByte('\n')
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString(fmt.Sprintf("    resp.%s.funcs.encode = %s;\n", field, encoder))
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -1,11 +1,13 @@
-package main
+package c
 
 import (
 	"fmt"
 	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
 )
 
-func generateCClientHeader(commands []Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
+func GenerateClientHeader(commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
 	var b strings.Builder
 
 	guard := strings.ToUpper(pkg) + "_GENERATED_CLIENT_H"
@@ -47,15 +49,20 @@ func generateCClientHeader(commands []Command, streaming map[string]string, call
 		"                              const char *final_cmd_name,",
 		"                              uint8_t *resp_data, size_t resp_size, size_t *resp_len);",
 		"",
-		"/* Generated typed RPC functions */",
 	}
 	for _, l := range lines {
 		b.WriteString(l)
 		b.WriteByte('\n')
 	}
+	if gen.HasCommandIDs(commands) {
+		writeCCommandIDs(&b, commands, pkg)
+	}
+	writeCWellKnownHelpers(&b, commands, pkg)
+	b.WriteString("/* Generated typed RPC functions */\n")
 
 	for _, cmd := range commands {
 		params := cClientParams(cmd, streaming, callbacks, pkg)
+		b.WriteString(Deprecation(cmd))
 		b.WriteString(fmt.Sprintf("int %s_%s(%s);\n", pkg, cmd.Snake, strings.Join(params, ", ")))
 	}
 
@@ -75,7 +82,7 @@ func generateCClientHeader(commands []Command, streaming map[string]string, call
 	return b.String()
 }
 
-func generateCClientSource(commands []Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
+func GenerateClientSource(commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
 	var b strings.Builder
 
 	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
@@ -183,11 +190,7 @@ func generateCClientSource(commands []Command, streaming map[string]string, call
 			b.WriteString("{\n")
 			b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
 			for _, f := range cmd.RequestFields {
-				if f.Type == "string" {
-					b.WriteString(fmt.Sprintf("    strncpy(req.%s, %s, sizeof(req.%s) - 1);\n", f.Name, f.Name, f.Name))
-				} else {
-					b.WriteString(fmt.Sprintf("    req.%s = %s;\n", f.Name, f.Name))
-				}
+				writeCSetRequestField(&b, f)
 			}
 			b.WriteByte('\n')
 			b.WriteString(fmt.Sprintf("    uint8_t req_buf[%s_size];\n", reqMsg))
@@ -197,7 +200,7 @@ func generateCClientSource(commands []Command, streaming map[string]string, call
 			b.WriteString(fmt.Sprintf("    struct _"+pkg+"_%s_ctx ctx = {\n", cmd.Snake))
 			b.WriteString("        .results = results, .max_results = max_results, .count = 0\n")
 			b.WriteString("    };\n")
-			b.WriteString(fmt.Sprintf("    if ("+pkg+"_stream_receive(\"%s\", req_buf, ostream.bytes_written,\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("    if ("+pkg+"_stream_receive(%s, req_buf, ostream.bytes_written,\n", gen.CallName(cmd, "c")))
 			b.WriteString(fmt.Sprintf("                              _"+pkg+"_%s_on_resp, &ctx) != 0) return -1;\n", cmd.Snake))
 			b.WriteByte('\n')
 			b.WriteString("    *result_count = ctx.count;\n")
@@ -226,9 +229,9 @@ func generateCClientSource(commands []Command, streaming map[string]string, call
 			b.WriteByte('\n')
 			b.WriteString(fmt.Sprintf("    uint8_t resp_buf[%s_size];\n", respMsg))
 			b.WriteString("    size_t resp_len;\n")
-			b.WriteString(fmt.Sprintf("    if ("+pkg+"_stream_send(\"%s\", msg_count,\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("    if ("+pkg+"_stream_send(%s, msg_count,\n", gen.CallName(cmd, "c")))
 			b.WriteString(fmt.Sprintf("                           _"+pkg+"_%s_next, &ctx,\n", cmd.Snake))
-			b.WriteString(fmt.Sprintf("                           \"%s\", resp_buf, sizeof(resp_buf),\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("                           %s, resp_buf, sizeof(resp_buf),\n", gen.CallName(cmd, "c")))
 			b.WriteString("                           &resp_len) != 0) return -1;\n")
 			b.WriteByte('\n')
 			b.WriteString(fmt.Sprintf("    *resp = (%s)%s_init_zero;\n", respMsg, respMsg))
@@ -276,10 +279,8 @@ func generateCClientSource(commands []Command, streaming map[string]string, call
 				if callbacks[key] {
 					b.WriteString(fmt.Sprintf("    req.%s.funcs.encode = _"+pkg+"_encode_bytes_cb;\n", f.Name))
 					b.WriteString(fmt.Sprintf("    req.%s.arg = &_%s_ctx;\n", f.Name, f.Name))
-				} else if f.Type == "string" {
-					b.WriteString(fmt.Sprintf("    strncpy(req.%s, %s, sizeof(req.%s) - 1);\n", f.Name, f.Name, f.Name))
 				} else {
-					b.WriteString(fmt.Sprintf("    req.%s = %s;\n", f.Name, f.Name))
+					writeCSetRequestField(&b, f)
 				}
 			}
 			b.WriteByte('\n')
@@ -306,13 +307,13 @@ func generateCClientSource(commands []Command, streaming map[string]string, call
 			}
 			if hasCbResp {
 				b.WriteString("    size_t resp_len;\n")
-				b.WriteString(fmt.Sprintf("    if ("+pkg+"_rpc_call(\"%s\", %s, ostream.bytes_written,\n", cmd.Snake, reqBufName))
+				b.WriteString(fmt.Sprintf("    if ("+pkg+"_rpc_call(%s, %s, ostream.bytes_written,\n", gen.CallName(cmd, "c"), reqBufName))
 				b.WriteString("                        _" + pkg + "_resp_buf, sizeof(_" + pkg + "_resp_buf),\n")
 				b.WriteString("                        &resp_len) != 0) return -1;\n")
 			} else {
 				b.WriteString(fmt.Sprintf("    uint8_t resp_buf[%s_size];\n", respMsg))
 				b.WriteString("    size_t resp_len;\n")
-				b.WriteString(fmt.Sprintf("    if ("+pkg+"_rpc_call(\"%s\", %s, ostream.bytes_written,\n", cmd.Snake, reqBufName))
+				b.WriteString(fmt.Sprintf("    if ("+pkg+"_rpc_call(%s, %s, ostream.bytes_written,\n", gen.CallName(cmd, "c"), reqBufName))
 				b.WriteString("                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;\n")
 			}
 			b.WriteByte('\n')
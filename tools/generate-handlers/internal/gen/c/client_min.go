@@ -0,0 +1,309 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Size-optimized C client (-c-client-mode=min).
+//
+// Intended for MCU-to-MCU links where a hub MCU acts as central. Requests and
+// responses are encoded in two static buffers shared by all commands (sized by
+// unions of the nanopb *_size macros), there is no command table, and each
+// command gets a request builder that callers can also use on their own
+// buffers. FT_CALLBACK fields are passed through as pb_callback_t so no
+// helper callbacks are linked in. The transport functions are the same as
+// for the full client.
+
+// cMinFieldParams returns the request field parameters of a builder.
+func cMinFieldParams(cmd gen.Command, callbacks map[string]bool, pkg string) []string {
+	var params []string
+	for _, f := range cmd.RequestFields {
+		if callbacks[cmd.RequestMsg+"."+f.Name] {
+			params = append(params, "pb_callback_t "+f.Name)
+		} else if gen.BoundedBytes(f) {
+			params = append(params, "const uint8_t *"+f.Name, "size_t "+f.Name+"_len")
+		} else {
+			params = append(params, cParamStr(cRequestParamType(f, pkg), f.Name))
+		}
+	}
+	return params
+}
+
+func cMinFieldArgs(cmd gen.Command) []string {
+	var args []string
+	for _, f := range cmd.RequestFields {
+		args = append(args, f.Name)
+		if gen.BoundedBytes(f) {
+			args = append(args, f.Name+"_len")
+		}
+	}
+	return args
+}
+
+func cMinBuilderParams(cmd gen.Command, callbacks map[string]bool, pkg string) []string {
+	return append(cMinFieldParams(cmd, callbacks, pkg), "uint8_t *buf", "size_t buf_size", "size_t *len")
+}
+
+func cMinCallParams(cmd gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) []string {
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	switch streaming[cmd.Snake] {
+	case "c2p":
+		return []string{"size_t msg_count", pkg + "_next_msg_t next_msg", "void *msg_ctx", respMsg + " *resp"}
+	case "p2c":
+		return append(cMinFieldParams(cmd, callbacks, pkg), fmt.Sprintf("%s_%s_on_resp_t on_resp", pkg, cmd.Snake), "void *ctx")
+	}
+	return append(cMinFieldParams(cmd, callbacks, pkg), respMsg+" *resp")
+}
+
+func hasCallbackField(msg string, fields []gen.Field, callbacks map[string]bool) bool {
+	for _, f := range fields {
+		if callbacks[msg+"."+f.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCMinBufUnion emits a static union large enough for every listed
+// message. Messages with FT_CALLBACK fields have no nanopb size bound and
+// fall back to <PKG>_CLIENT_CALLBACK_BUF_SIZE.
+func writeCMinBufUnion(b *strings.Builder, name string, msgs []string, unbounded bool, pkg string) {
+	if len(msgs) == 0 && !unbounded {
+		return
+	}
+	b.WriteString("static union {\n")
+	for _, m := range msgs {
+		b.WriteString(fmt.Sprintf("    uint8_t %s[%s_%s_size];\n", m, pkg, m))
+	}
+	if unbounded {
+		b.WriteString(fmt.Sprintf("    uint8_t unbounded[%s_CLIENT_CALLBACK_BUF_SIZE];\n", strings.ToUpper(pkg)))
+	}
+	b.WriteString(fmt.Sprintf("} %s;\n\n", name))
+}
+
+func GenerateClientMinHeader(commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
+	var b strings.Builder
+
+	guard := strings.ToUpper(pkg) + "_GENERATED_CLIENT_H"
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"/*",
+		" * Size-optimized client: requests and responses are encoded in static",
+		" * buffers shared by all commands, so calls must not run concurrently.",
+		" * FT_CALLBACK request fields are passed as pb_callback_t; for FT_CALLBACK",
+		" * response fields, set the decode callbacks in *resp before the call.",
+		" */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+		`#include "` + pkg + `.pb.h"`,
+		"#include <pb_encode.h>",
+		"#include <pb_decode.h>",
+		"#include <stdint.h>",
+		"#include <stddef.h>",
+		"#include <stdbool.h>",
+		"#include <string.h>",
+		"",
+		"#ifdef __cplusplus",
+		`extern "C" {`,
+		"#endif",
+		"",
+		"/* Callback for P2C streaming response payloads */",
+		"typedef int (*" + pkg + "_on_stream_resp_t)(const uint8_t *data, size_t len, void *ctx);",
+		"",
+		"/* Callback for C2P streaming message serialization */",
+		"typedef int (*" + pkg + "_next_msg_t)(size_t index, uint8_t *buf, size_t buf_size,",
+		"                                 size_t *len, void *ctx);",
+		"",
+		"/* User-provided RPC transport functions */",
+		"extern int " + pkg + "_rpc_call(const char *cmd_name,",
+		"                           const uint8_t *req_data, size_t req_len,",
+		"                           uint8_t *resp_data, size_t resp_size, size_t *resp_len);",
+		"",
+		"extern int " + pkg + "_stream_receive(const char *cmd_name,",
+		"                                 const uint8_t *req_data, size_t req_len,",
+		"                                 " + pkg + "_on_stream_resp_t on_resp, void *ctx);",
+		"",
+		"extern int " + pkg + "_stream_send(const char *cmd_name, size_t msg_count,",
+		"                              " + pkg + "_next_msg_t next_msg, void *msg_ctx,",
+		"                              const char *final_cmd_name,",
+		"                              uint8_t *resp_data, size_t resp_size, size_t *resp_len);",
+		"",
+	}
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	if gen.HasCommandIDs(commands) {
+		writeCCommandIDs(&b, commands, pkg)
+	}
+	writeCWellKnownHelpers(&b, commands, pkg)
+
+	hasP2C := false
+	for _, cmd := range commands {
+		if streaming[cmd.Snake] == "p2c" {
+			if !hasP2C {
+				b.WriteString("/* Per-command callbacks for decoded P2C streaming responses */\n")
+				hasP2C = true
+			}
+			b.WriteString(fmt.Sprintf("typedef int (*%s_%s_on_resp_t)(const %s_%s *resp, void *ctx);\n",
+				pkg, cmd.Snake, pkg, cmd.ResponseMsg))
+		}
+	}
+	if hasP2C {
+		b.WriteByte('\n')
+	}
+
+	b.WriteString("/* Request builders: encode a request into buf, returning 0 on success */\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("int %s_build_%s(%s);\n", pkg, cmd.Snake, strings.Join(cMinBuilderParams(cmd, callbacks, pkg), ", ")))
+	}
+	b.WriteByte('\n')
+
+	b.WriteString("/* Generated typed RPC functions */\n")
+	for _, cmd := range commands {
+		b.WriteString(Deprecation(cmd))
+		b.WriteString(fmt.Sprintf("int %s_%s(%s);\n", pkg, cmd.Snake, strings.Join(cMinCallParams(cmd, streaming, callbacks, pkg), ", ")))
+	}
+
+	tail := []string{
+		"",
+		"#ifdef __cplusplus",
+		"}",
+		"#endif",
+		"",
+		"#endif /* " + guard + " */",
+	}
+	for _, l := range tail {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+func GenerateClientMinSource(commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_client.h\"\n\n")
+
+	// Collect the messages that pass through the shared buffers. P2C responses
+	// are decoded straight from the transport and C2P requests are encoded by
+	// the caller's next_msg, so neither needs space here.
+	var reqMsgs, respMsgs []string
+	seenReq := make(map[string]bool)
+	seenResp := make(map[string]bool)
+	reqUnbounded, respUnbounded := false, false
+	for _, cmd := range commands {
+		dir := streaming[cmd.Snake]
+		if dir != "c2p" {
+			if hasCallbackField(cmd.RequestMsg, cmd.RequestFields, callbacks) {
+				reqUnbounded = true
+			} else if !seenReq[cmd.RequestMsg] {
+				seenReq[cmd.RequestMsg] = true
+				reqMsgs = append(reqMsgs, cmd.RequestMsg)
+			}
+		}
+		if dir != "p2c" {
+			if hasCallbackField(cmd.ResponseMsg, cmd.ResponseFields, callbacks) {
+				respUnbounded = true
+			} else if !seenResp[cmd.ResponseMsg] {
+				seenResp[cmd.ResponseMsg] = true
+				respMsgs = append(respMsgs, cmd.ResponseMsg)
+			}
+		}
+	}
+
+	if reqUnbounded || respUnbounded {
+		macro := strings.ToUpper(pkg) + "_CLIENT_CALLBACK_BUF_SIZE"
+		b.WriteString("#ifndef " + macro + "\n")
+		b.WriteString("#define " + macro + " 256\n")
+		b.WriteString("#endif\n\n")
+	}
+	writeCMinBufUnion(&b, "_"+pkg+"_req_buf", reqMsgs, reqUnbounded, pkg)
+	writeCMinBufUnion(&b, "_"+pkg+"_resp_buf", respMsgs, respUnbounded, pkg)
+
+	for _, cmd := range commands {
+		reqMsg := pkg + "_" + cmd.RequestMsg
+		respMsg := pkg + "_" + cmd.ResponseMsg
+
+		// Request builder
+		b.WriteString(fmt.Sprintf("int %s_build_%s(%s)\n", pkg, cmd.Snake, strings.Join(cMinBuilderParams(cmd, callbacks, pkg), ", ")))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+		for _, f := range cmd.RequestFields {
+			if callbacks[cmd.RequestMsg+"."+f.Name] {
+				b.WriteString(fmt.Sprintf("    req.%s = %s;\n", f.Name, f.Name))
+			} else {
+				writeCSetRequestField(&b, f)
+			}
+		}
+		b.WriteString("    pb_ostream_t ostream = pb_ostream_from_buffer(buf, buf_size);\n")
+		b.WriteString(fmt.Sprintf("    if (!pb_encode(&ostream, %s_fields, &req)) return -1;\n", reqMsg))
+		b.WriteString("    *len = ostream.bytes_written;\n")
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n\n")
+
+		args := strings.Join(append(cMinFieldArgs(cmd), ""), ", ")
+		params := strings.Join(cMinCallParams(cmd, streaming, callbacks, pkg), ", ")
+
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("struct _%s_%s_ctx {\n", pkg, cmd.Snake))
+			b.WriteString(fmt.Sprintf("    %s_%s_on_resp_t on_resp;\n", pkg, cmd.Snake))
+			b.WriteString("    void *ctx;\n")
+			b.WriteString("};\n\n")
+
+			b.WriteString(fmt.Sprintf("static int _%s_%s_on_resp(const uint8_t *data, size_t len,\n", pkg, cmd.Snake))
+			b.WriteString(fmt.Sprintf("                              %svoid *ctx)\n", strings.Repeat(" ", len(cmd.Snake))))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    struct _%s_%s_ctx *c = (struct _%s_%s_ctx *)ctx;\n", pkg, cmd.Snake, pkg, cmd.Snake))
+			b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+			b.WriteString("    pb_istream_t istream = pb_istream_from_buffer(data, len);\n")
+			b.WriteString(fmt.Sprintf("    if (!pb_decode(&istream, %s_fields, &resp)) return -1;\n", respMsg))
+			b.WriteString("    return c->on_resp(&resp, c->ctx);\n")
+			b.WriteString("}\n\n")
+
+			b.WriteString(fmt.Sprintf("int %s_%s(%s)\n", pkg, cmd.Snake, params))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    struct _%s_%s_ctx c = { .on_resp = on_resp, .ctx = ctx };\n", pkg, cmd.Snake))
+			b.WriteString(fmt.Sprintf("    uint8_t *req_buf = (uint8_t *)&_%s_req_buf;\n", pkg))
+			b.WriteString("    size_t req_len;\n")
+			b.WriteString(fmt.Sprintf("    if (%s_build_%s(%sreq_buf, sizeof(_%s_req_buf), &req_len) != 0) return -1;\n", pkg, cmd.Snake, args, pkg))
+			b.WriteString(fmt.Sprintf("    return %s_stream_receive(%s, req_buf, req_len,\n", pkg, gen.CallName(cmd, "c")))
+			b.WriteString(fmt.Sprintf("                             _%s_%s_on_resp, &c);\n", pkg, cmd.Snake))
+			b.WriteString("}\n\n")
+
+		case "c2p":
+			b.WriteString(fmt.Sprintf("int %s_%s(%s)\n", pkg, cmd.Snake, params))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    uint8_t *resp_buf = (uint8_t *)&_%s_resp_buf;\n", pkg))
+			b.WriteString("    size_t resp_len;\n")
+			b.WriteString(fmt.Sprintf("    if (%s_stream_send(%s, msg_count, next_msg, msg_ctx,\n", pkg, gen.CallName(cmd, "c")))
+			b.WriteString(fmt.Sprintf("                           %s, resp_buf, sizeof(_%s_resp_buf),\n", gen.CallName(cmd, "c"), pkg))
+			b.WriteString("                           &resp_len) != 0) return -1;\n")
+			b.WriteString("    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);\n")
+			b.WriteString(fmt.Sprintf("    return pb_decode(&istream, %s_fields, resp) ? 0 : -1;\n", respMsg))
+			b.WriteString("}\n\n")
+
+		default:
+			b.WriteString(fmt.Sprintf("int %s_%s(%s)\n", pkg, cmd.Snake, params))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    uint8_t *req_buf = (uint8_t *)&_%s_req_buf;\n", pkg))
+			b.WriteString(fmt.Sprintf("    uint8_t *resp_buf = (uint8_t *)&_%s_resp_buf;\n", pkg))
+			b.WriteString("    size_t req_len, resp_len;\n")
+			b.WriteString(fmt.Sprintf("    if (%s_build_%s(%sreq_buf, sizeof(_%s_req_buf), &req_len) != 0) return -1;\n", pkg, cmd.Snake, args, pkg))
+			b.WriteString(fmt.Sprintf("    if (%s_rpc_call(%s, req_buf, req_len,\n", pkg, gen.CallName(cmd, "c")))
+			b.WriteString(fmt.Sprintf("                        resp_buf, sizeof(_%s_resp_buf), &resp_len) != 0) return -1;\n", pkg))
+			b.WriteString("    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);\n")
+			b.WriteString(fmt.Sprintf("    return pb_decode(&istream, %s_fields, resp) ? 0 : -1;\n", respMsg))
+			b.WriteString("}\n\n")
+		}
+	}
+
+	return b.String()
+}
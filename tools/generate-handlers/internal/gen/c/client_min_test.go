@@ -0,0 +1,79 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateCClientMinHeader(t *testing.T) {
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateClientMinHeader(cmds, streaming, nil, "blerpc")
+
+	mustContain := []string{
+		"#ifndef BLERPC_GENERATED_CLIENT_H",
+		"int blerpc_build_echo(const char *message, uint8_t *buf, size_t buf_size, size_t *len);",
+		"int blerpc_echo(const char *message, blerpc_EchoResponse *resp);",
+		"typedef int (*blerpc_counter_stream_on_resp_t)(const blerpc_CounterStreamResponse *resp, void *ctx);",
+		"blerpc_counter_stream_on_resp_t on_resp, void *ctx);",
+		"int blerpc_counter_upload(size_t msg_count, blerpc_next_msg_t next_msg, void *msg_ctx, blerpc_CounterUploadResponse *resp);",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("min C client header missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateCClientMinSource(t *testing.T) {
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateClientMinSource(cmds, streaming, nil, "blerpc")
+
+	mustContain := []string{
+		"uint8_t EchoRequest[blerpc_EchoRequest_size];",
+		"uint8_t CounterStreamRequest[blerpc_CounterStreamRequest_size];",
+		"} _blerpc_req_buf;",
+		"uint8_t CounterUploadResponse[blerpc_CounterUploadResponse_size];",
+		"} _blerpc_resp_buf;",
+		"blerpc_build_echo(message, req_buf, sizeof(_blerpc_req_buf), &req_len)",
+		`blerpc_rpc_call("echo", req_buf, req_len,`,
+		"return c->on_resp(&resp, c->ctx);",
+		`blerpc_stream_send("counter_upload", msg_count, next_msg, msg_ctx,`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("min C client source missing %q\nGot:\n%s", s, out)
+		}
+	}
+	// Streamed payloads never pass through the shared buffers.
+	for _, s := range []string{"CounterStreamResponse[", "CounterUploadRequest[", "_bytes_encode_ctx", "CALLBACK_BUF_SIZE"} {
+		if strings.Contains(out, s) {
+			t.Errorf("min C client source should not contain %q", s)
+		}
+	}
+}
+
+func TestGenerateCClientMinSource_Callback(t *testing.T) {
+	cmds := []gen.Command{gentest.CallbackCommand()}
+	callbacks := map[string]bool{"DataWriteRequest.data": true}
+	out := GenerateClientMinSource(cmds, nil, callbacks, "blerpc")
+
+	mustContain := []string{
+		"#define BLERPC_CLIENT_CALLBACK_BUF_SIZE 256",
+		"uint8_t unbounded[BLERPC_CLIENT_CALLBACK_BUF_SIZE];",
+		"int blerpc_build_data_write(uint32_t address, pb_callback_t data,",
+		"req.data = data;",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("min C client source missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "_encode_bytes_cb") {
+		t.Error("min C client should pass FT_CALLBACK fields through without helpers")
+	}
+}
@@ -1,13 +1,16 @@
-package main
+package c
 
 import (
 	"strings"
 	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
 )
 
 func TestGenerateCClientHeader_Echo(t *testing.T) {
-	cmds := []Command{echoCommand()}
-	out := generateCClientHeader(cmds, nil, nil, "blerpc")
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClientHeader(cmds, nil, nil, "blerpc")
 
 	mustContain := []string{
 		"#ifndef BLERPC_GENERATED_CLIENT_H",
@@ -24,8 +27,8 @@ func TestGenerateCClientHeader_Echo(t *testing.T) {
 }
 
 func TestGenerateCClientSource_Echo(t *testing.T) {
-	cmds := []Command{echoCommand()}
-	out := generateCClientSource(cmds, nil, nil, "blerpc")
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClientSource(cmds, nil, nil, "blerpc")
 
 	mustContain := []string{
 		`#include "generated_client.h"`,
@@ -43,9 +46,9 @@ func TestGenerateCClientSource_Echo(t *testing.T) {
 }
 
 func TestGenerateCClientHeader_StreamP2C(t *testing.T) {
-	cmds := []Command{streamP2CCommand()}
+	cmds := []gen.Command{gentest.StreamP2CCommand()}
 	streaming := map[string]string{"counter_stream": "p2c"}
-	out := generateCClientHeader(cmds, streaming, nil, "blerpc")
+	out := GenerateClientHeader(cmds, streaming, nil, "blerpc")
 
 	mustContain := []string{
 		"int blerpc_counter_stream(",
@@ -61,9 +64,9 @@ func TestGenerateCClientHeader_StreamP2C(t *testing.T) {
 }
 
 func TestGenerateCClientSource_StreamP2C(t *testing.T) {
-	cmds := []Command{streamP2CCommand()}
+	cmds := []gen.Command{gentest.StreamP2CCommand()}
 	streaming := map[string]string{"counter_stream": "p2c"}
-	out := generateCClientSource(cmds, streaming, nil, "blerpc")
+	out := GenerateClientSource(cmds, streaming, nil, "blerpc")
 
 	mustContain := []string{
 		"struct _blerpc_counter_stream_ctx",
@@ -81,9 +84,9 @@ func TestGenerateCClientSource_StreamP2C(t *testing.T) {
 }
 
 func TestGenerateCClientHeader_StreamC2P(t *testing.T) {
-	cmds := []Command{streamC2PCommand()}
+	cmds := []gen.Command{gentest.StreamC2PCommand()}
 	streaming := map[string]string{"counter_upload": "c2p"}
-	out := generateCClientHeader(cmds, streaming, nil, "blerpc")
+	out := GenerateClientHeader(cmds, streaming, nil, "blerpc")
 
 	mustContain := []string{
 		"int blerpc_counter_upload(",
@@ -99,9 +102,9 @@ func TestGenerateCClientHeader_StreamC2P(t *testing.T) {
 }
 
 func TestGenerateCClientSource_StreamC2P(t *testing.T) {
-	cmds := []Command{streamC2PCommand()}
+	cmds := []gen.Command{gentest.StreamC2PCommand()}
 	streaming := map[string]string{"counter_upload": "c2p"}
-	out := generateCClientSource(cmds, streaming, nil, "blerpc")
+	out := GenerateClientSource(cmds, streaming, nil, "blerpc")
 
 	mustContain := []string{
 		"struct _blerpc_counter_upload_ctx",
@@ -117,11 +120,11 @@ func TestGenerateCClientSource_StreamC2P(t *testing.T) {
 }
 
 func TestGenerateCClientSource_Callback(t *testing.T) {
-	cmds := []Command{callbackCommand()}
+	cmds := []gen.Command{gentest.CallbackCommand()}
 	callbacks := map[string]bool{
 		"DataWriteRequest.data": true,
 	}
-	out := generateCClientSource(cmds, nil, callbacks, "blerpc")
+	out := GenerateClientSource(cmds, nil, callbacks, "blerpc")
 
 	mustContain := []string{
 		"_blerpc_encode_bytes_cb",
@@ -137,8 +140,8 @@ func TestGenerateCClientSource_Callback(t *testing.T) {
 }
 
 func TestGenerateCClientHeader_CustomPkg(t *testing.T) {
-	cmds := []Command{echoCommand()}
-	out := generateCClientHeader(cmds, nil, nil, "myapp")
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClientHeader(cmds, nil, nil, "myapp")
 
 	mustContain := []string{
 		"#ifndef MYAPP_GENERATED_CLIENT_H",
@@ -157,12 +160,14 @@ func TestGenerateCClientHeader_CustomPkg(t *testing.T) {
 }
 
 func TestGenerateCClientSource_MultiField(t *testing.T) {
-	cmds := []Command{messageFieldCommand()}
-	out := generateCClientSource(cmds, nil, nil, "blerpc")
+	cmds := []gen.Command{gentest.MessageFieldCommand()}
+	out := GenerateClientSource(cmds, nil, nil, "blerpc")
 
 	mustContain := []string{
 		"int blerpc_update_address(",
 		"blerpc_UpdateAddressRequest req = blerpc_UpdateAddressRequest_init_zero",
+		"blerpc_Address address",
+		"req.has_address = true;",
 	}
 	for _, s := range mustContain {
 		if !strings.Contains(out, s) {
@@ -170,3 +175,30 @@ func TestGenerateCClientSource_MultiField(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateCClientSource_Proto2Optional(t *testing.T) {
+	cmds := []gen.Command{gentest.Proto2Command()}
+	out := GenerateClientSource(cmds, nil, nil, "blerpc")
+
+	if !strings.Contains(out, "req.has_label = true;") {
+		t.Errorf("C client should set has_ flag for optional fields\nGot:\n%s", out)
+	}
+	if strings.Contains(out, "req.has_address") {
+		t.Error("C client should not set has_ flag for required fields")
+	}
+}
+
+func TestGenerateCClientSource_Proto3Optional(t *testing.T) {
+	out := GenerateClientSource([]gen.Command{gentest.OptionalCommand()}, nil, nil, "blerpc")
+
+	mustContain := []string{
+		"const uint32_t *limit, uint32_t offset",
+		"    if (limit != NULL) {\n        req.limit = *limit;\n        req.has_limit = true;\n    }\n",
+		"    req.offset = offset;\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C client optional field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// cCommandIDConst names the C enum constant of a command's ID.
+func cCommandIDConst(cmd gen.Command, pkg string) string {
+	return strings.ToUpper(pkg) + "_CMD_ID_" + strings.ToUpper(cmd.Snake)
+}
+
+// writeCCommandIDs emits the ID enum into a C header.
+func writeCCommandIDs(b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString("/* Numeric command IDs. Sent as a one-byte command name, an ID dispatches\n")
+	b.WriteString(" * like the full name. */\n")
+	b.WriteString(fmt.Sprintf("enum %s_command_id {\n", pkg))
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    %s = %d,\n", cCommandIDConst(cmd, pkg), cmd.ID))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+}
+
+// writeCCommandIDTable emits the IDs in handler table order.
+func writeCCommandIDTable(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString("/* ID of each command, in handler table order. */\n")
+	b.WriteString("static const uint8_t command_ids[] = {\n")
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		return fmt.Sprintf("    %s,\n", cCommandIDConst(cmd, pkg))
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,235 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// defaultCompressionBufSize is the default <PKG>_COMPRESSION_BUF_SIZE.
+const defaultCompressionBufSize = 1024
+
+// cCompressionConst names the C enum constant of an algorithm.
+func cCompressionConst(algorithm, pkg string) string {
+	return strings.ToUpper(pkg) + "_COMPRESSION_" + strings.ToUpper(algorithm)
+}
+
+// writeCCompressionDecl emits the algorithms, capability flag, envelope and
+// codec hooks into generated_handlers.h.
+func writeCCompressionDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	up := strings.ToUpper(pkg)
+	b.WriteString("/* Algorithms of compressed commands. */\n")
+	b.WriteString(fmt.Sprintf("enum %s_compression {\n", pkg))
+	b.WriteString(fmt.Sprintf("    %s_COMPRESSION_NONE = 0,\n", up))
+	for i, alg := range gen.CompressionAlgorithms {
+		b.WriteString(fmt.Sprintf("    %s = %d,\n", cCompressionConst(alg, pkg), i+1))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/* CAPABILITIES flag telling centrals they may call compressed commands in\n")
+	b.WriteString(fmt.Sprintf(" * the envelope named %s_COMPRESSED_CMD_NAME, which %s_compressed_handler\n", up, pkg))
+	b.WriteString(" * answers; " + gctx.CSymbol("handlers_lookup") + " returns the handler for it. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_CAPABILITY_FLAG_COMPRESSION 0x%04x\n", up, gen.CapabilityFlagCompression))
+	b.WriteString(fmt.Sprintf("#define %s_COMPRESSED_CMD_NAME \"%s\"\n", up, gen.CompressedCommandName))
+	b.WriteByte('\n')
+	b.WriteString("/* Bytes of a decompressed request, and of a response before and after\n")
+	b.WriteString(" * compression. Define it to override. */\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_COMPRESSION_BUF_SIZE\n", up))
+	b.WriteString(fmt.Sprintf("#define %s_COMPRESSION_BUF_SIZE %d\n", up, defaultCompressionBufSize))
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Codecs of the compressed commands, which the firmware must define, e.g.\n")
+	b.WriteString(" * with miniz for raw DEFLATE (RFC 1951) or heatshrink with a window of 8\n")
+	b.WriteString(" * and a lookahead of 4 bits. Each writes at most out_size bytes to out and\n")
+	b.WriteString(" * sets *out_len; return 0, or -1 on an error or when the result does not\n")
+	b.WriteString(" * fit. */\n")
+	for _, fn := range []string{"compress", "decompress"} {
+		decl := fmt.Sprintf("int %s_%s(", pkg, fn)
+		pad := strings.Repeat(" ", len(decl))
+		b.WriteString(fmt.Sprintf("%senum %s_compression algorithm,\n", decl, pkg))
+		b.WriteString(pad + "const uint8_t *in, size_t in_len,\n")
+		b.WriteString(pad + "uint8_t *out, size_t out_size, size_t *out_len);\n")
+	}
+	b.WriteByte('\n')
+	pad := strings.Repeat(" ", len("int "+pkg+"_compressed_handler("))
+	b.WriteString(fmt.Sprintf("int %s_compressed_handler(const uint8_t *req_data, size_t req_len,\n", pkg))
+	b.WriteString(pad + cHandlerOutParam(gctx, "pb_ostream_t *ostream") + ");\n")
+	b.WriteByte('\n')
+}
+
+// writeCCompressionLookup emits the handlers_lookup check for the
+// compression envelope.
+func writeCCompressionLookup(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if !gctx.CCompression {
+		return
+	}
+	name := strings.ToUpper(pkg) + "_COMPRESSED_CMD_NAME"
+	b.WriteString(fmt.Sprintf("    if (name_len == sizeof(%s) - 1 &&\n", name))
+	b.WriteString(fmt.Sprintf("        memcmp(name, %s, name_len) == 0) {\n", name))
+	b.WriteString(fmt.Sprintf("        return %s_compressed_handler;\n", pkg))
+	b.WriteString("    }\n")
+}
+
+// GenerateCompressionSource returns generated_compression.c, the handler of
+// the compression envelope.
+func GenerateCompressionSource(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	up := strings.ToUpper(pkg)
+	ids := gen.HasCommandIDs(commands)
+	var compressed []gen.Command
+	for _, cmd := range commands {
+		if cmd.Compression != "" {
+			compressed = append(compressed, cmd)
+		}
+	}
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_handlers.h\"\n")
+	b.WriteString("#include <pb_encode.h>\n")
+	b.WriteString("#include <stdbool.h>\n")
+	b.WriteString("#include <string.h>\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Flags of the envelope: the data is compressed; the response may be. */\n")
+	b.WriteString("#define FLAG_COMPRESSED 0x01\n")
+	b.WriteString("#define FLAG_ACCEPT_COMPRESSED 0x02\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Algorithm of a compressed command; NONE for any other. */\n")
+	b.WriteString(fmt.Sprintf("static enum %s_compression compression_of(const char *name, uint8_t name_len)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    static const struct " + gctx.CSymbol("handler_entry") + " commands[] = {\n")
+	for _, cmd := range compressed {
+		b.WriteString(fmt.Sprintf("        {\"%s\", %d, NULL},\n", cmd.Wire(), len(cmd.Wire())))
+	}
+	b.WriteString("    };\n")
+	b.WriteString(fmt.Sprintf("    static const enum %s_compression algorithms[] = {\n", pkg))
+	for _, cmd := range compressed {
+		b.WriteString("        " + cCompressionConst(cmd.Compression, pkg) + ",\n")
+	}
+	b.WriteString("    };\n")
+	if ids {
+		b.WriteString("    static const uint8_t command_ids[] = {\n")
+		for _, cmd := range compressed {
+			id := "0"
+			if cmd.ID != 0 {
+				id = cCommandIDConst(cmd, pkg)
+			}
+			b.WriteString("        " + id + ",\n")
+		}
+		b.WriteString("    };\n")
+	}
+	b.WriteString("    size_t i;\n")
+	b.WriteString("    for (i = 0; i < sizeof(commands) / sizeof(commands[0]); i++) {\n")
+	if ids {
+		b.WriteString("        /* A one-byte name carries the command ID. */\n")
+		b.WriteString("        if ((name_len == 1 && (uint8_t)name[0] == command_ids[i]) ||\n")
+		b.WriteString("            (commands[i].name_len == name_len &&\n")
+		b.WriteString("             memcmp(commands[i].name, name, name_len) == 0)) {\n")
+	} else {
+		b.WriteString("        if (commands[i].name_len == name_len &&\n")
+		b.WriteString("            memcmp(commands[i].name, name, name_len) == 0) {\n")
+	}
+	b.WriteString("            return algorithms[i];\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    return %s_COMPRESSION_NONE;\n", up))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("static uint8_t req_buf[%s_COMPRESSION_BUF_SIZE];\n", up))
+	b.WriteString(fmt.Sprintf("static uint8_t plain_buf[%s_COMPRESSION_BUF_SIZE];\n", up))
+	b.WriteString(fmt.Sprintf("static uint8_t packed_buf[%s_COMPRESSION_BUF_SIZE];\n", up))
+	b.WriteByte('\n')
+	b.WriteString("/* Response of the last call run, and its request. */\n")
+	b.WriteString("static uint8_t resp_flags;\n")
+	b.WriteString("static const uint8_t *resp_data;\n")
+	b.WriteString("static size_t resp_len;\n")
+	b.WriteString("static const uint8_t *resp_req;\n")
+	b.WriteString("static size_t resp_req_len;\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Sets the response to an error carrying status. */\n")
+	b.WriteString("static int set_error(uint32_t status)\n")
+	b.WriteString("{\n")
+	b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(plain_buf, sizeof(plain_buf));\n")
+	b.WriteString(fmt.Sprintf("    if (%s_return_error(&out, status) != 0) return -1;\n", pkg))
+	b.WriteString("    resp_flags = 0;\n")
+	b.WriteString("    resp_data = plain_buf;\n")
+	b.WriteString("    resp_len = out.bytes_written;\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Runs the call of an envelope into resp_data. Returns -1 for a malformed\n")
+	b.WriteString(" * envelope, or what the handler returns when it fails, for the dispatcher\n")
+	b.WriteString(" * to answer as it would a plain call. */\n")
+	b.WriteString("static int run_compressed(const uint8_t *req_data, size_t req_len" + gctx.CCtxSuffix() + ")\n")
+	b.WriteString("{\n")
+	b.WriteString("    if (req_len < 2 || req_len - 2 < req_data[0]) return -1;\n")
+	b.WriteString("    uint8_t name_len = req_data[0];\n")
+	b.WriteString("    const char *name = (const char *)req_data + 1;\n")
+	b.WriteString("    uint8_t flags = req_data[1 + name_len];\n")
+	b.WriteString("    const uint8_t *data = req_data + 2 + name_len;\n")
+	b.WriteString("    size_t data_len = req_len - 2 - name_len;\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    enum %s_compression algorithm = compression_of(name, name_len);\n", pkg))
+	b.WriteString("    " + gctx.CSymbol("command_handler_fn") + " handler = NULL;\n")
+	b.WriteString(fmt.Sprintf("    if (algorithm != %s_COMPRESSION_NONE) {\n", up))
+	b.WriteString(fmt.Sprintf("        handler = %s(name, name_len%s);\n", gctx.CSymbol("handlers_lookup"), cHandlerOutArg(gctx, "")))
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    if (handler == NULL) return set_error(%s_STATUS_UNIMPLEMENTED);\n", up))
+	b.WriteString("    if (flags & FLAG_COMPRESSED) {\n")
+	b.WriteString(fmt.Sprintf("        if (%s_decompress(algorithm, data, data_len, req_buf, sizeof(req_buf),\n", pkg))
+	b.WriteString(fmt.Sprintf("            %s             &data_len) != 0) {\n", strings.Repeat(" ", len(pkg))))
+	b.WriteString(fmt.Sprintf("            return set_error(%s_STATUS_INVALID_ARGUMENT);\n", up))
+	b.WriteString("        }\n")
+	b.WriteString("        data = req_buf;\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    pb_ostream_t sizing = PB_OSTREAM_SIZING;\n")
+	b.WriteString(fmt.Sprintf("    int rc = handler(data, data_len, %s);\n", cHandlerOutArg(gctx, "&sizing")))
+	b.WriteString("    if (rc != 0) return rc;\n")
+	b.WriteString(fmt.Sprintf("    if (sizing.bytes_written > sizeof(plain_buf)) return set_error(%s_STATUS_RESOURCE_EXHAUSTED);\n", up))
+	b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(plain_buf, sizing.bytes_written);\n")
+	b.WriteString(fmt.Sprintf("    rc = handler(data, data_len, %s);\n", cHandlerOutArg(gctx, "&out")))
+	b.WriteString("    if (rc != 0) return rc;\n")
+	b.WriteString("    resp_flags = 0;\n")
+	b.WriteString("    resp_data = plain_buf;\n")
+	b.WriteString("    resp_len = out.bytes_written;\n")
+	b.WriteByte('\n')
+	b.WriteString("    size_t packed_len;\n")
+	b.WriteString("    if ((flags & FLAG_ACCEPT_COMPRESSED) &&\n")
+	b.WriteString(fmt.Sprintf("        %s_compress(algorithm, plain_buf, resp_len, packed_buf, sizeof(packed_buf),\n", pkg))
+	b.WriteString(fmt.Sprintf("        %s           &packed_len) == 0 &&\n", strings.Repeat(" ", len(pkg))))
+	b.WriteString("        packed_len < resp_len) {\n")
+	b.WriteString("        resp_flags = FLAG_COMPRESSED;\n")
+	b.WriteString("        resp_data = packed_buf;\n")
+	b.WriteString("        resp_len = packed_len;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	pad := strings.Repeat(" ", len("int "+pkg+"_compressed_handler("))
+	b.WriteString(fmt.Sprintf("int %s_compressed_handler(const uint8_t *req_data, size_t req_len,\n", pkg))
+	b.WriteString(pad + cHandlerOutParam(gctx, "pb_ostream_t *ostream") + ")\n")
+	b.WriteString("{\n")
+	b.WriteString("    /* The dispatcher calls a handler twice, to size the response and to\n")
+	b.WriteString("     * encode it. The call runs on the first, sizing pass, and the second\n")
+	b.WriteString("     * writes the response kept then. */\n")
+	b.WriteString("    bool sizing = ostream->callback == NULL;\n")
+	b.WriteString("    if (sizing || req_data != resp_req || req_len != resp_req_len) {\n")
+	b.WriteString(fmt.Sprintf("        int rc = run_compressed(req_data, req_len%s);\n", cHandlerOutArg(gctx, "")))
+	b.WriteString("        if (rc != 0) {\n")
+	b.WriteString("            resp_req = NULL;\n")
+	b.WriteString("            return rc;\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    resp_req = sizing ? req_data : NULL;\n")
+	b.WriteString("    resp_req_len = sizing ? req_len : 0;\n")
+	b.WriteString("    if (!pb_write(ostream, &resp_flags, 1)) return -1;\n")
+	b.WriteString("    return pb_write(ostream, resp_data, resp_len) ? 0 : -1;\n")
+	b.WriteString("}\n")
+	return b.String()
+}
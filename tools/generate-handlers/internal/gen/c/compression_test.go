@@ -0,0 +1,40 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateCompressionCSource(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.Compression = "heatshrink"
+	got := GenerateCompressionSource(gctx, []gen.Command{cmd}, "blerpc")
+	for _, want := range []string{
+		`{"echo", 4, NULL},`,
+		"BLERPC_COMPRESSION_HEATSHRINK,",
+		"if (blerpc_decompress(algorithm, data, data_len, req_buf, sizeof(req_buf),",
+		"packed_len < resp_len) {",
+		"int blerpc_compressed_handler(const uint8_t *req_data, size_t req_len,",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	var b strings.Builder
+	writeCCompressionDecl(gctx, &b, "blerpc")
+	for _, want := range []string{
+		"BLERPC_COMPRESSION_DEFLATE = 1,",
+		"#define BLERPC_CAPABILITY_FLAG_COMPRESSION 0x0002",
+		`#define BLERPC_COMPRESSED_CMD_NAME "_compressed"`,
+		"#define BLERPC_COMPRESSION_BUF_SIZE 1024",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package c
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// StringLiteral quotes a command name, escaping a one-byte ID. IDs are
+// the whole name, so no hex digit follows the escape.
+func StringLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		if c < 0x20 || c >= 0x7F {
+			fmt.Fprintf(&b, `\x%02x`, c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// cConformanceBytes renders bytes as a pointer and length initializer.
+func cConformanceBytes(h string) string {
+	data, _ := hex.DecodeString(h)
+	if len(data) == 0 {
+		return "NULL, 0"
+	}
+	parts := make([]string, len(data))
+	for i, c := range data {
+		parts[i] = fmt.Sprintf("0x%02x", c)
+	}
+	return fmt.Sprintf("(const uint8_t[]){%s}, %d", strings.Join(parts, ", "), len(data))
+}
+
+// GenerateLoopback returns loopback.c, the C client's transport functions
+// over the vectors and a main running them.
+func GenerateLoopback(gctx *gen.Context, commands []gen.Command, suite gen.ConformanceSuite, pkg string) string {
+	var b strings.Builder
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString(gctx.RenderTemplate("loopback.c.tmpl", nil))
+	b.WriteByte('\n')
+	for _, cmd := range gen.ConformanceCommands(commands, suite) {
+		msg := pkg + "_" + cmd.ResponseMsg
+		b.WriteString(fmt.Sprintf("static bool decode_%s(const uint8_t *data, size_t len)\n", cmd.Snake))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    %s msg = %s_init_zero;\n", msg, msg))
+		b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(data, len);\n")
+		b.WriteString(fmt.Sprintf("    return pb_decode(&stream, %s_fields, &msg);\n", msg))
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+	b.WriteString("/* The vectors of conformance/vectors.json. */\n")
+	b.WriteString("static const struct conformance_vector vectors[] = {\n")
+	for _, v := range suite.Vectors {
+		stream := "STREAM_NONE"
+		switch v.Stream {
+		case "p2c":
+			stream = "STREAM_P2C"
+		case "c2p":
+			stream = "STREAM_C2P"
+		}
+		b.WriteString("    {\n")
+		b.WriteString(fmt.Sprintf("        %q, %s, %s, %d,\n", v.Command, StringLiteral(v.WireName), stream, v.MTU))
+		b.WriteString(fmt.Sprintf("        %s,\n", cConformanceBytes(v.Request)))
+		b.WriteString(fmt.Sprintf("        %s,\n", cConformanceBytes(v.Response)))
+		b.WriteString(fmt.Sprintf("        %s,\n", cConformanceBytes(strings.Join(v.RequestContainers, ""))))
+		b.WriteString("        (const struct conformance_bytes[]){\n")
+		for _, ct := range v.ResponseContainers {
+			b.WriteString(fmt.Sprintf("            {%s},\n", cConformanceBytes(ct)))
+		}
+		b.WriteString("        },\n")
+		b.WriteString(fmt.Sprintf("        %d,\n", len(v.ResponseContainers)))
+		b.WriteString(fmt.Sprintf("        decode_%s,\n", v.Command))
+		b.WriteString("    },\n")
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("int main(void)\n")
+	b.WriteString("{\n")
+	b.WriteString("    int failed = 0;\n")
+	b.WriteString("    for (size_t i = 0; i < sizeof(vectors) / sizeof(vectors[0]); i++) {\n")
+	b.WriteString("        failed += check_vector(&vectors[i]);\n")
+	b.WriteString("    }\n")
+	b.WriteString("    printf(\"%d of %zu vectors failed\\n\", failed, sizeof(vectors) / sizeof(vectors[0]));\n")
+	b.WriteString("    return failed != 0;\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,23 @@
+package c
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Deprecation returns the comment of a handler or client function of cmd,
+// or "".
+func Deprecation(cmd gen.Command) string {
+	var notes []string
+	if cmd.Deprecated {
+		notes = append(notes, "Deprecated.")
+	}
+	if fields := gen.DeprecatedFields(cmd); len(fields) > 0 {
+		notes = append(notes, "Deprecated request fields: "+strings.Join(fields, ", ")+".")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return "/* " + strings.Join(notes, " ") + " */\n"
+}
@@ -0,0 +1,179 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCDfuDecl emits the chunk size and the update slot hooks the dfu
+// handlers call.
+func writeCDfuDecl(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Most bytes dfu_chunk accepts at once; dfu_begin reports it to the\n")
+	b.WriteString(" * clients. */\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_DFU_CHUNK_SIZE\n", upper))
+	b.WriteString(fmt.Sprintf("#define %s_DFU_CHUNK_SIZE 128\n", upper))
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Update slot of dfu, e.g. MCUboot's secondary slot through Zephyr's\n")
+	b.WriteString(" * flash_img_*() API. begin prepares the slot for an image of size bytes,\n")
+	b.WriteString(" * e.g. by erasing it. write stores len bytes at offset; the image arrives in\n")
+	b.WriteString(" * order, from 0 or from where an interrupted update stopped. finalize marks\n")
+	b.WriteString(" * the image, whose CRC-32 has been checked, to boot, and with reboot\n")
+	b.WriteString(" * restarts into it once the response has gone out, e.g. from a delayed\n")
+	b.WriteString(" * work item. Each returns 0, or a negative value on error; the weak\n")
+	b.WriteString(" * defaults return -1, so dfu fails until they are overridden. */\n")
+	b.WriteString(fmt.Sprintf("int %s_dfu_begin(uint32_t size);\n", pkg))
+	b.WriteString(fmt.Sprintf("int %s_dfu_write(uint32_t offset, const uint8_t *data, size_t len);\n", pkg))
+	b.WriteString(fmt.Sprintf("int %s_dfu_finalize(bool reboot);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCDfuSupport emits the weak hook stubs and the state machine the dfu
+// handlers share, ahead of the handlers.
+func writeCDfuSupport(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_dfu_begin(uint32_t size)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)size;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_dfu_write(uint32_t offset, const uint8_t *data, size_t len)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)offset;\n")
+	b.WriteString("    (void)data;\n")
+	b.WriteString("    (void)len;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_dfu_finalize(bool reboot)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)reboot;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/* dfu_begin moves to DFU_RECEIVING and dfu_finalize back to DFU_IDLE. An\n")
+	b.WriteString(" * update left in DFU_RECEIVING, e.g. by a dropped link, continues from\n")
+	b.WriteString(" * offset when dfu_begin comes again with the same size and CRC-32. The\n")
+	b.WriteString(" * handlers change the state on the sizing pass, which comes first. */\n")
+	b.WriteString("enum dfu_state {\n")
+	b.WriteString("    DFU_IDLE,\n")
+	b.WriteString("    DFU_RECEIVING,\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("static struct {\n")
+	b.WriteString("    enum dfu_state state;\n")
+	b.WriteString("    uint32_t size;\n")
+	b.WriteString("    uint32_t crc32;  /* of the whole image, from dfu_begin */\n")
+	b.WriteString("    uint32_t offset; /* bytes written */\n")
+	b.WriteString("    uint32_t crc;    /* running CRC-32 of the bytes written, uninverted */\n")
+	b.WriteString("} dfu;\n")
+	b.WriteByte('\n')
+	b.WriteString("/* CRC-32 (IEEE 802.3) of data, continued from c. */\n")
+	b.WriteString("static uint32_t dfu_crc_update(uint32_t c, const uint8_t *data, size_t len)\n")
+	b.WriteString("{\n")
+	b.WriteString("    for (size_t i = 0; i < len; i++) {\n")
+	b.WriteString("        c ^= data[i];\n")
+	b.WriteString("        for (int k = 0; k < 8; k++) {\n")
+	b.WriteString("            c = (c >> 1) ^ (0xEDB88320 & (0 - (c & 1)));\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return c;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/* The data of the dfu_chunk request being handled. */\n")
+	b.WriteString("static struct {\n")
+	b.WriteString(fmt.Sprintf("    uint8_t data[%s_DFU_CHUNK_SIZE];\n", upper))
+	b.WriteString("    size_t len;\n")
+	b.WriteString("} dfu_chunk;\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool decode_dfu_data(pb_istream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                            void **arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString("    (void)arg;\n")
+	b.WriteString("    size_t len = stream->bytes_left;\n")
+	b.WriteString("    if (len > sizeof(dfu_chunk.data)) return false;\n")
+	b.WriteString("    if (!pb_read(stream, dfu_chunk.data, len)) return false;\n")
+	b.WriteString("    dfu_chunk.len = len;\n")
+	b.WriteString("    return true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCDfuHandler emits the nanopb handler of one dfu command.
+func writeCDfuHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	if cmd.RequestMsg == "DfuChunkRequest" {
+		b.WriteString("    dfu_chunk.len = 0;\n")
+		b.WriteString("    req.data.funcs.decode = decode_dfu_data;\n")
+	}
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	switch cmd.RequestMsg {
+	case "DfuBeginRequest":
+		b.WriteString("    if (ostream->callback == NULL) {\n")
+		b.WriteString("        bool resume = dfu.state == DFU_RECEIVING && dfu.size == req.size &&\n")
+		b.WriteString("                      dfu.crc32 == req.crc32;\n")
+		b.WriteString("        if (!resume) {\n")
+		b.WriteString("            dfu.state = DFU_IDLE;\n")
+		b.WriteString(fmt.Sprintf("            if (%s_dfu_begin(req.size) != 0) return -1;\n", pkg))
+		b.WriteString("            dfu.state = DFU_RECEIVING;\n")
+		b.WriteString("            dfu.size = req.size;\n")
+		b.WriteString("            dfu.crc32 = req.crc32;\n")
+		b.WriteString("            dfu.offset = 0;\n")
+		b.WriteString("            dfu.crc = 0xFFFFFFFF;\n")
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+	case "DfuChunkRequest":
+		b.WriteString("    if (ostream->callback == NULL) {\n")
+		b.WriteString("        if (dfu.state != DFU_RECEIVING) return -1;\n")
+		b.WriteString("        /* A chunk at another offset, e.g. one resent after its response was\n")
+		b.WriteString("         * lost, is not written; the response says where to continue. */\n")
+		b.WriteString("        if (req.offset == dfu.offset && dfu_chunk.len > 0) {\n")
+		b.WriteString("            if (dfu_chunk.len > dfu.size - dfu.offset) return -1;\n")
+		b.WriteString(fmt.Sprintf("            if (%s_dfu_write(dfu.offset, dfu_chunk.data, dfu_chunk.len) != 0) {\n", pkg))
+		b.WriteString("                return -1;\n")
+		b.WriteString("            }\n")
+		b.WriteString("            dfu.crc = dfu_crc_update(dfu.crc, dfu_chunk.data, dfu_chunk.len);\n")
+		b.WriteString("            dfu.offset += (uint32_t)dfu_chunk.len;\n")
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+	case "DfuFinalizeRequest":
+		b.WriteString("    if (ostream->callback == NULL) {\n")
+		b.WriteString("        if (dfu.state != DFU_RECEIVING) return -1;\n")
+		b.WriteString("        dfu.state = DFU_IDLE;\n")
+		b.WriteString("        if (dfu.offset != dfu.size || ~dfu.crc != dfu.crc32) return -1;\n")
+		b.WriteString(fmt.Sprintf("        if (%s_dfu_finalize(req.reboot) != 0) return -1;\n", pkg))
+		b.WriteString("    }\n")
+	}
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	switch cmd.RequestMsg {
+	case "DfuBeginRequest":
+		b.WriteString("    resp.offset = dfu.offset;\n")
+		b.WriteString(fmt.Sprintf("    resp.max_chunk = %s_DFU_CHUNK_SIZE;\n", strings.ToUpper(pkg)))
+	case "DfuChunkRequest":
+		b.WriteString("    resp.offset = dfu.offset;\n")
+	case "DfuFinalizeRequest":
+		b.WriteString("    resp.crc32 = ~dfu.crc;\n")
+	}
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
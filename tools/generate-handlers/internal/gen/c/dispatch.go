@@ -0,0 +1,262 @@
+package c
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// -c-dispatch picks how handlers_lookup finds a command in the C handler
+// table. linear compares the name with every entry in schema order. binary
+// sorts the table by name and bisects it. hash keeps the schema order and
+// finds the entry through a perfect hash of the name computed by the
+// generator: names fall into buckets, and each bucket has a seed that sends
+// its names to free slots of hash_slots, so a lookup hashes twice and
+// compares one name. With command IDs both look up a one-byte name in
+// id_slots first.
+//
+// The arrays kept in handler table order (rpc_stats, rate_limits, roles,
+// command_ids) follow the order of cTableRuns, and the commands of a group
+// stay under its macro wherever the sort places them.
+
+// DispatchModes lists the values of -c-dispatch.
+var DispatchModes = []string{"linear", "binary", "hash"}
+
+// fnvOffset and fnvPrime are the 32-bit FNV-1a parameters of name_hash.
+const (
+	fnvOffset = 2166136261
+	fnvPrime  = 16777619
+)
+
+// cTableRuns returns the commands in handler table order, split into runs
+// of consecutive commands of the same group. Outside binary dispatch the
+// runs are the groups in schema order.
+func cTableRuns(gctx *gen.Context, commands []gen.Command, pkg string) []gen.CommandGroup {
+	groups := gen.GroupCommands(commands, "per-group", pkg)
+	if gctx.CDispatch != "binary" {
+		return groups
+	}
+	groupOf := make(map[string]string)
+	var sorted []gen.Command
+	for _, g := range groups {
+		for _, cmd := range g.Commands {
+			groupOf[cmd.Wire()] = g.Name
+			sorted = append(sorted, cmd)
+		}
+	}
+	// Go compares strings bytewise, shorter first on a common prefix, like
+	// compare_name.
+	slices.SortFunc(sorted, func(a, b gen.Command) int { return cmp.Compare(a.Wire(), b.Wire()) })
+	var runs []gen.CommandGroup
+	for _, cmd := range sorted {
+		name := groupOf[cmd.Wire()]
+		if len(runs) == 0 || runs[len(runs)-1].Name != name {
+			runs = append(runs, gen.CommandGroup{Name: name})
+		}
+		runs[len(runs)-1].Commands = append(runs[len(runs)-1].Commands, cmd)
+	}
+	return runs
+}
+
+// cTableIndex returns the constant of the position of cmd in the handler
+// table.
+func cTableIndex(cmd gen.Command) string {
+	return "TABLE_" + strings.ToUpper(cmd.Snake)
+}
+
+// cSlotType returns the smallest unsigned type holding a table position
+// plus one for n commands.
+func cSlotType(n int) string {
+	if n < 255 {
+		return "uint8_t"
+	}
+	return "uint16_t"
+}
+
+// writeCTableRuns writes line(cmd) for every command in table order, each
+// run under the macro of its group.
+func writeCTableRuns(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string, line func(cmd gen.Command) string) {
+	for _, r := range cTableRuns(gctx, commands, pkg) {
+		b.WriteString("#if " + cGroupMacro(pkg, r.Name) + "\n")
+		for _, cmd := range r.Commands {
+			b.WriteString(line(cmd))
+		}
+		b.WriteString("#endif\n")
+	}
+}
+
+// writeCTableIndexes emits the position of every command in the handler
+// table, which depends on the groups compiled in.
+func writeCTableIndexes(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString("/* Positions in the handler table. */\n")
+	b.WriteString("enum {\n")
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string { return "    " + cTableIndex(cmd) + ",\n" })
+	b.WriteString("    TABLE_COUNT\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+}
+
+// writeCIDSlots emits the table position plus one of every command ID.
+func writeCIDSlots(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString("/* Table position + 1 of each command ID; 0 for unused IDs. */\n")
+	b.WriteString(fmt.Sprintf("static const %s id_slots[128] = {\n", cSlotType(len(commands))))
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		return fmt.Sprintf("    [%s] = %s + 1,\n", cCommandIDConst(cmd, pkg), cTableIndex(cmd))
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+}
+
+// nameHash is name_hash of the generated C.
+func nameHash(seed uint32, name string) uint32 {
+	h := uint32(fnvOffset) ^ seed
+	for i := 0; i < len(name); i++ {
+		h = (h ^ uint32(name[i])) * fnvPrime
+	}
+	return h
+}
+
+// perfectHash finds a seed for each of the buckets, chosen by name_hash with
+// seed 0, such that name_hash with the seed of its bucket sends every name
+// to its own slot. It grows the slots until the seeds 0-255 suffice.
+func perfectHash(names []string) (seeds []uint8, slots map[string]int, size int) {
+	buckets := max(1, (len(names)+1)/2)
+	for size = max(1, len(names)+len(names)/4); ; size++ {
+		members := make([][]string, buckets)
+		for _, name := range names {
+			i := nameHash(0, name) % uint32(buckets)
+			members[i] = append(members[i], name)
+		}
+		order := make([]int, buckets)
+		for i := range order {
+			order[i] = i
+		}
+		// Place the largest buckets first, while most slots are free.
+		slices.SortStableFunc(order, func(a, b int) int { return len(members[b]) - len(members[a]) })
+
+		seeds = make([]uint8, buckets)
+		slots = make(map[string]int, len(names))
+		taken := make([]bool, size)
+		placed := true
+		for _, bucket := range order {
+			found := false
+			for seed := 0; seed < 256 && !found; seed++ {
+				var want []int
+				for _, name := range members[bucket] {
+					slot := int(nameHash(uint32(seed), name) % uint32(size))
+					if taken[slot] || slices.Contains(want, slot) {
+						break
+					}
+					want = append(want, slot)
+				}
+				if len(want) < len(members[bucket]) {
+					continue
+				}
+				found = true
+				seeds[bucket] = uint8(seed)
+				for i, name := range members[bucket] {
+					slots[name] = want[i]
+					taken[want[i]] = true
+				}
+			}
+			if !found {
+				placed = false
+				break
+			}
+		}
+		if placed {
+			return seeds, slots, size
+		}
+	}
+}
+
+// writeCHashTable emits the perfect hash of the command names.
+func writeCHashTable(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.Wire()
+	}
+	seeds, slots, size := perfectHash(names)
+
+	b.WriteString("/* Perfect hash of the command names: the seed of a name's bucket sends\n")
+	b.WriteString(" * it to its own slot, which holds its table position + 1. */\n")
+	b.WriteString(fmt.Sprintf("#define HASH_BUCKETS %d\n", len(seeds)))
+	b.WriteString(fmt.Sprintf("#define HASH_SLOTS %d\n", size))
+	b.WriteByte('\n')
+	b.WriteString("static const uint8_t hash_seeds[HASH_BUCKETS] = {")
+	for i, seed := range seeds {
+		if i%12 == 0 {
+			b.WriteString("\n   ")
+		}
+		b.WriteString(fmt.Sprintf(" %d,", seed))
+	}
+	b.WriteString("\n};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("static const %s hash_slots[HASH_SLOTS] = {\n", cSlotType(len(commands))))
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		return fmt.Sprintf("    [%d] = %s + 1,\n", slots[cmd.Wire()], cTableIndex(cmd))
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("static uint32_t name_hash(uint32_t seed, const char *name, uint8_t name_len)\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    uint32_t h = %du ^ seed;\n", uint32(fnvOffset)))
+	b.WriteString("    uint8_t i;\n")
+	b.WriteString("    for (i = 0; i < name_len; i++) {\n")
+	b.WriteString(fmt.Sprintf("        h = (h ^ (uint8_t)name[i]) * %du;\n", fnvPrime))
+	b.WriteString("    }\n")
+	b.WriteString("    return h;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCHandlerIndex emits handler_index, which returns the table position
+// of a command name, or -1, for binary and hash dispatch.
+func writeCHandlerIndex(gctx *gen.Context, b *strings.Builder, ids bool) {
+	if gctx.CDispatch == "binary" {
+		b.WriteString("static int compare_name(const char *name, uint8_t name_len,\n")
+		b.WriteString("                        const struct " + gctx.CSymbol("handler_entry") + " *entry)\n")
+		b.WriteString("{\n")
+		b.WriteString("    uint8_t n = name_len < entry->name_len ? name_len : entry->name_len;\n")
+		b.WriteString("    int c = memcmp(name, entry->name, n);\n")
+		b.WriteString("    return c != 0 ? c : (int)name_len - (int)entry->name_len;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+	b.WriteString("static int handler_index(const char *name, uint8_t name_len)\n")
+	b.WriteString("{\n")
+	if ids {
+		b.WriteString("    /* A one-byte name carries the command ID. */\n")
+		b.WriteString("    if (name_len == 1 && (uint8_t)name[0] < 128 && id_slots[(uint8_t)name[0]] != 0) {\n")
+		b.WriteString("        return id_slots[(uint8_t)name[0]] - 1;\n")
+		b.WriteString("    }\n")
+	}
+	if gctx.CDispatch == "binary" {
+		b.WriteString("    size_t lo = 0;\n")
+		b.WriteString("    size_t hi = sizeof(handler_table) / sizeof(handler_table[0]);\n")
+		b.WriteString("    while (lo < hi) {\n")
+		b.WriteString("        size_t mid = lo + (hi - lo) / 2;\n")
+		b.WriteString("        int c = compare_name(name, name_len, &handler_table[mid]);\n")
+		b.WriteString("        if (c == 0) return (int)mid;\n")
+		b.WriteString("        if (c < 0) {\n")
+		b.WriteString("            hi = mid;\n")
+		b.WriteString("        } else {\n")
+		b.WriteString("            lo = mid + 1;\n")
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return -1;\n")
+	} else {
+		b.WriteString("    uint8_t seed = hash_seeds[name_hash(0, name, name_len) % HASH_BUCKETS];\n")
+		b.WriteString("    int i = (int)hash_slots[name_hash(seed, name, name_len) % HASH_SLOTS] - 1;\n")
+		b.WriteString("    if (i < 0 || handler_table[i].name_len != name_len ||\n")
+		b.WriteString("        memcmp(handler_table[i].name, name, name_len) != 0) {\n")
+		b.WriteString("        return -1;\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return i;\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,107 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestPerfectHash(t *testing.T) {
+	for _, n := range []int{1, 2, 7, 60, 255} {
+		names := make([]string, n)
+		for i := range names {
+			names[i] = fmt.Sprintf("command_%d", i)
+		}
+		seeds, slots, size := perfectHash(names)
+		used := make(map[int]string)
+		for _, name := range names {
+			seed := seeds[nameHash(0, name)%uint32(len(seeds))]
+			slot := int(nameHash(uint32(seed), name) % uint32(size))
+			if slot != slots[name] {
+				t.Fatalf("n=%d: %s hashes to %d, want its slot %d", n, name, slot, slots[name])
+			}
+			if other, ok := used[slot]; ok {
+				t.Fatalf("n=%d: %s and %s share slot %d", n, name, other, slot)
+			}
+			used[slot] = name
+		}
+		if size > 2*n+1 {
+			t.Errorf("n=%d: %d slots", n, size)
+		}
+	}
+}
+
+func TestCTableRuns_Binary(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.CDispatch = "binary"
+	cmd := func(snake, service string) gen.Command {
+		c := gentest.EchoCommand()
+		c.Snake, c.Service = snake, service
+		return c
+	}
+	runs := cTableRuns(gctx, []gen.Command{cmd("zeta", "A"), cmd("beta", "B"), cmd("alpha", "A"), cmd("alphabet", "A")}, "blerpc")
+	var got []string
+	for _, r := range runs {
+		var names []string
+		for _, c := range r.Commands {
+			names = append(names, c.Snake)
+		}
+		got = append(got, r.Name+":"+strings.Join(names, ","))
+	}
+	if want := "A:alpha,alphabet B:beta A:zeta"; strings.Join(got, " ") != want {
+		t.Errorf("runs = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestGenerateDispatch(t *testing.T) {
+	gctx := gen.NewContext()
+	limited := gentest.EchoCommand()
+	limited.RateLimit = 2
+	limited.ID = 2
+	other := gentest.EnumCommand()
+	other.ID = 1
+	other.Role = "installer"
+	commands := []gen.Command{other, limited}
+
+	gctx.CDispatch = "binary"
+	src := GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"    {\"echo\", 4, handle_echo},\n    {\"get_status\", 10, handle_get_status},",
+		"    2, /* echo */\n    0, /* get_status */",
+		"    BLERPC_ROLE_USER, /* echo */\n    BLERPC_ROLE_INSTALLER, /* get_status */",
+		"    TABLE_ECHO,\n    TABLE_GET_STATUS,\n#endif\n    TABLE_COUNT",
+		"static const uint8_t id_slots[128] = {",
+		"    [BLERPC_CMD_ID_ECHO] = TABLE_ECHO + 1,",
+		"static int compare_name(const char *name, uint8_t name_len,",
+		"        int c = compare_name(name, name_len, &handler_table[mid]);",
+		"    int i = handler_index(name, name_len);",
+		"    if (roles[i] > blerpc_current_role()) return NULL;",
+		"    if (!rate_limit_take((size_t)i)) {\n        blerpc_on_rate_limited(name, name_len);\n        return reject_rate_limited;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("binary source missing %q", want)
+		}
+	}
+	if strings.Contains(src, "command_ids[]") {
+		t.Error("binary dispatch should look IDs up in id_slots")
+	}
+
+	gctx.CDispatch = "hash"
+	src = GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"    {\"get_status\", 10, handle_get_status},\n    {\"echo\", 4, handle_echo},",
+		"#define HASH_BUCKETS 1",
+		"static const uint8_t hash_seeds[HASH_BUCKETS] = {",
+		"static const uint8_t hash_slots[HASH_SLOTS] = {",
+		"= TABLE_ECHO + 1,",
+		"static uint32_t name_hash(uint32_t seed, const char *name, uint8_t name_len)",
+		"    uint8_t seed = hash_seeds[name_hash(0, name, name_len) % HASH_BUCKETS];",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("hash source missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateEventsHeader(events []gen.Event, pkg string) string {
+	guard := strings.ToUpper(pkg) + "_GENERATED_EVENTS_H"
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#ifndef " + guard + "\n")
+	b.WriteString("#define " + guard + "\n")
+	b.WriteByte('\n')
+	b.WriteString("#include <stddef.h>\n")
+	b.WriteString("#include <stdint.h>\n")
+	b.WriteString("#include \"" + pkg + ".pb.h\"\n")
+	b.WriteByte('\n')
+	b.WriteString("#ifdef __cplusplus\n")
+	b.WriteString("extern \"C\" {\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("/*\n")
+	b.WriteString(" * Notify an encoded event to the central as a response named name. The\n")
+	b.WriteString(" * weak default returns -1; the firmware provides it, writing to the notify\n")
+	b.WriteString(" * characteristic like a stream response. Return 0 on success.\n")
+	b.WriteString(" */\n")
+	b.WriteString(fmt.Sprintf("int %s_event_write(const char *name, const uint8_t *data, size_t len);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Encode an event and notify it. Return 0, or -1 if encoding or the write\n")
+	b.WriteString(" * fails. */\n")
+	for _, ev := range events {
+		b.WriteString(fmt.Sprintf("int %s_notify_%s(const %s_%s *event);\n", pkg, ev.Snake, pkg, ev.Message.Name))
+	}
+	b.WriteByte('\n')
+	b.WriteString("#ifdef __cplusplus\n")
+	b.WriteString("}\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("#endif /* " + guard + " */\n")
+	return b.String()
+}
+
+// GenerateEventsSource emits the notify helpers. Events with FT_CALLBACK
+// fields have no nanopb size bound and are encoded into <PKG>_EVENT_BUF_SIZE
+// bytes.
+func GenerateEventsSource(events []gen.Event, callbacks map[string]bool, pkg string) string {
+	bufMacro := strings.ToUpper(pkg) + "_EVENT_BUF_SIZE"
+	eventBuf := func(ev gen.Event) string {
+		if hasCallbackField(ev.Message.Name, ev.Message.Fields, callbacks) {
+			return bufMacro
+		}
+		return pkg + "_" + ev.Message.Name + "_size"
+	}
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_events.h\"\n")
+	b.WriteString("#include <pb_encode.h>\n")
+	b.WriteByte('\n')
+	for _, ev := range events {
+		if eventBuf(ev) == bufMacro {
+			b.WriteString("#ifndef " + bufMacro + "\n")
+			b.WriteString("#define " + bufMacro + " 256\n")
+			b.WriteString("#endif\n")
+			b.WriteByte('\n')
+			break
+		}
+	}
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_event_write(const char *name, const uint8_t *data, size_t len)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)name;\n")
+	b.WriteString("    (void)data;\n")
+	b.WriteString("    (void)len;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	for _, ev := range events {
+		msg := pkg + "_" + ev.Message.Name
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("int %s_notify_%s(const %s *event)\n", pkg, ev.Snake, msg))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    uint8_t buf[%s];\n", eventBuf(ev)))
+		b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));\n")
+		b.WriteString(fmt.Sprintf("    if (!pb_encode(&out, %s_fields, event)) return -1;\n", msg))
+		b.WriteString(fmt.Sprintf("    return %s_event_write(\"%s\", buf, out.bytes_written);\n", pkg, ev.Snake))
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,231 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCFileTransferDecl emits the buffer sizes and the file system hooks
+// the file_transfer handlers call.
+func writeCFileTransferDecl(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Longest path file_open accepts, and most bytes file_read returns at once;\n")
+	b.WriteString(" * the chunk is also the buffer of the CRC-32 read-back. */\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_FILE_PATH_SIZE\n", upper))
+	b.WriteString(fmt.Sprintf("#define %s_FILE_PATH_SIZE 64\n", upper))
+	b.WriteString("#endif\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_FILE_CHUNK_SIZE\n", upper))
+	b.WriteString(fmt.Sprintf("#define %s_FILE_CHUNK_SIZE 128\n", upper))
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("/* File system of file_transfer, e.g. over Zephyr's fs_*() API. open returns\n")
+	b.WriteString(" * a handle >= 0 for path, opened for writing, truncated unless resume is set,\n")
+	b.WriteString(" * or for reading. read returns the bytes read at offset, 0 at the end of the\n")
+	b.WriteString(" * file, and must work on handles opened for writing too, so uploads can be\n")
+	b.WriteString(" * verified. write and close return 0. Each returns a negative value on\n")
+	b.WriteString(" * error; the weak defaults return -1, so file_transfer fails until they are\n")
+	b.WriteString(" * overridden. */\n")
+	b.WriteString(fmt.Sprintf("int %s_file_open(const char *path, bool write, bool resume);\n", pkg))
+	b.WriteString(fmt.Sprintf("int %s_file_read(uint32_t handle, uint32_t offset, uint8_t *buf, size_t len);\n", pkg))
+	b.WriteString(fmt.Sprintf("int %s_file_write(uint32_t handle, uint32_t offset, const uint8_t *data,\n", pkg))
+	b.WriteString(fmt.Sprintf("    %s           size_t len);\n", strings.Repeat(" ", len(pkg))))
+	b.WriteString(fmt.Sprintf("int %s_file_close(uint32_t handle);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCFileTransferSupport emits the weak hook stubs and the helpers the
+// file_transfer handlers share, ahead of the handlers.
+func writeCFileTransferSupport(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_file_open(const char *path, bool write, bool resume)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)path;\n")
+	b.WriteString("    (void)write;\n")
+	b.WriteString("    (void)resume;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_file_read(uint32_t handle, uint32_t offset, uint8_t *buf, size_t len)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)handle;\n")
+	b.WriteString("    (void)offset;\n")
+	b.WriteString("    (void)buf;\n")
+	b.WriteString("    (void)len;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_file_write(uint32_t handle, uint32_t offset, const uint8_t *data,\n", pkg))
+	b.WriteString(fmt.Sprintf("    %s           size_t len)\n", strings.Repeat(" ", len(pkg))))
+	b.WriteString("{\n")
+	b.WriteString("    (void)handle;\n")
+	b.WriteString("    (void)offset;\n")
+	b.WriteString("    (void)data;\n")
+	b.WriteString("    (void)len;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_file_close(uint32_t handle)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)handle;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Size and CRC-32 (IEEE 802.3) of the file behind handle, read back one\n")
+	b.WriteString(" * chunk at a time. */\n")
+	b.WriteString("static int file_checksum(uint32_t handle, uint32_t *size, uint32_t *crc)\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    uint8_t buf[%s_FILE_CHUNK_SIZE];\n", upper))
+	b.WriteString("    uint32_t c = 0xFFFFFFFF;\n")
+	b.WriteString("    *size = 0;\n")
+	b.WriteString("    for (;;) {\n")
+	b.WriteString(fmt.Sprintf("        int n = %s_file_read(handle, *size, buf, sizeof(buf));\n", pkg))
+	b.WriteString("        if (n < 0) return -1;\n")
+	b.WriteString("        if (n == 0) break;\n")
+	b.WriteString("        for (size_t i = 0; i < (size_t)n; i++) {\n")
+	b.WriteString("            c ^= buf[i];\n")
+	b.WriteString("            for (int k = 0; k < 8; k++) {\n")
+	b.WriteString("                c = (c >> 1) ^ (0xEDB88320 & (0 - (c & 1)));\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("        *size += (uint32_t)n;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    *crc = ~c;\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/* file_open and file_close act on the sizing pass, which comes first, and\n")
+	b.WriteString(" * keep the result here for the writing pass. */\n")
+	b.WriteString("static int file_handle;\n")
+	b.WriteString("static uint32_t file_size;\n")
+	b.WriteString("static uint32_t file_crc;\n")
+	b.WriteByte('\n')
+	b.WriteString("/* The chunk file_read read on the sizing pass. */\n")
+	b.WriteString("static struct {\n")
+	b.WriteString(fmt.Sprintf("    uint8_t data[%s_FILE_CHUNK_SIZE];\n", upper))
+	b.WriteString("    size_t len;\n")
+	b.WriteString("} file_chunk;\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool decode_file_path(pb_istream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                             void **arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString("    char *path = *arg;\n")
+	b.WriteString("    size_t len = stream->bytes_left;\n")
+	b.WriteString(fmt.Sprintf("    if (len >= %s_FILE_PATH_SIZE) return false;\n", upper))
+	b.WriteString("    if (!pb_read(stream, (pb_byte_t *)path, len)) return false;\n")
+	b.WriteString("    path[len] = '\\0';\n")
+	b.WriteString("    return true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool encode_file_chunk(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                              void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)arg;\n")
+	b.WriteString("    return pb_encode_tag_for_field(stream, field) &&\n")
+	b.WriteString("           pb_encode_string(stream, file_chunk.data, file_chunk.len);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Where a file_write request's data goes. Encoders emit fields in number\n")
+	b.WriteString(" * order, so handle and offset are decoded by the time data is. */\n")
+	b.WriteString("struct file_write_target {\n")
+	b.WriteString("    const uint32_t *handle;\n")
+	b.WriteString("    const uint32_t *offset;\n")
+	b.WriteString("    bool write;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool decode_file_data(pb_istream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                             void **arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString("    const struct file_write_target *target = *arg;\n")
+	b.WriteString("    uint32_t offset = *target->offset;\n")
+	b.WriteString("    uint8_t buf[64];\n")
+	b.WriteString("    while (stream->bytes_left > 0) {\n")
+	b.WriteString("        size_t n = stream->bytes_left < sizeof(buf) ? stream->bytes_left : sizeof(buf);\n")
+	b.WriteString("        if (!pb_read(stream, buf, n)) return false;\n")
+	b.WriteString("        if (target->write &&\n")
+	b.WriteString(fmt.Sprintf("            %s_file_write(*target->handle, offset, buf, n) != 0) {\n", pkg))
+	b.WriteString("            return false;\n")
+	b.WriteString("        }\n")
+	b.WriteString("        offset += (uint32_t)n;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCFileTransferHandler emits the nanopb handler of one file_transfer
+// command.
+func writeCFileTransferHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	switch cmd.RequestMsg {
+	case "FileOpenRequest":
+		b.WriteString(fmt.Sprintf("    char path[%s_FILE_PATH_SIZE] = \"\";\n", strings.ToUpper(pkg)))
+		b.WriteString("    req.path.funcs.decode = decode_file_path;\n")
+		b.WriteString("    req.path.arg = path;\n")
+	case "FileWriteRequest":
+		b.WriteString("    struct file_write_target target = {\n")
+		b.WriteString("        &req.handle, &req.offset, ostream->callback == NULL,\n")
+		b.WriteString("    };\n")
+		b.WriteString("    /* Write the data once, on the sizing pass. */\n")
+		b.WriteString("    req.data.funcs.decode = decode_file_data;\n")
+		b.WriteString("    req.data.arg = &target;\n")
+	}
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	switch cmd.RequestMsg {
+	case "FileOpenRequest":
+		b.WriteString("    if (ostream->callback == NULL) {\n")
+		b.WriteString(fmt.Sprintf("        file_handle = %s_file_open(path, req.write, req.resume);\n", pkg))
+		b.WriteString("        if (file_handle < 0) return -1;\n")
+		b.WriteString("        if (file_checksum((uint32_t)file_handle, &file_size, &file_crc) != 0) {\n")
+		b.WriteString(fmt.Sprintf("            %s_file_close((uint32_t)file_handle);\n", pkg))
+		b.WriteString("            return -1;\n")
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+		b.WriteString("    resp.handle = (uint32_t)file_handle;\n")
+		b.WriteString("    resp.size = file_size;\n")
+		b.WriteString("    resp.crc32 = file_crc;\n")
+	case "FileReadRequest":
+		b.WriteString("    if (ostream->callback == NULL) {\n")
+		b.WriteString("        size_t len = req.length < sizeof(file_chunk.data) ? req.length\n")
+		b.WriteString("                                                          : sizeof(file_chunk.data);\n")
+		b.WriteString(fmt.Sprintf("        int n = %s_file_read(req.handle, req.offset, file_chunk.data, len);\n", pkg))
+		b.WriteString("        if (n < 0) return -1;\n")
+		b.WriteString("        file_chunk.len = (size_t)n;\n")
+		b.WriteString("    }\n")
+		b.WriteString("    resp.data.funcs.encode = encode_file_chunk;\n")
+	case "FileCloseRequest":
+		b.WriteString("    if (ostream->callback == NULL) {\n")
+		b.WriteString("        file_size = 0;\n")
+		b.WriteString("        file_crc = 0;\n")
+		b.WriteString("        if (req.verify && file_checksum(req.handle, &file_size, &file_crc) != 0) {\n")
+		b.WriteString(fmt.Sprintf("            %s_file_close(req.handle);\n", pkg))
+		b.WriteString("            return -1;\n")
+		b.WriteString("        }\n")
+		b.WriteString(fmt.Sprintf("        if (%s_file_close(req.handle) != 0) return -1;\n", pkg))
+		b.WriteString("    }\n")
+		b.WriteString("    resp.size = file_size;\n")
+		b.WriteString("    resp.crc32 = file_crc;\n")
+	}
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,236 @@
+package c
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// -report prints what each command costs the peripheral: the size of its
+// nanopb request and response structs, which a unary handler keeps on the
+// stack, its largest encodings, and its share of the handler table in
+// flash. Struct sizes are those of a 32-bit target such as a Cortex-M, with
+// nanopb's default 16-bit pb_size_t.
+
+// cLayout is the size and alignment of a C type.
+type cLayout struct {
+	size, align int
+}
+
+var (
+	callbackLayout  = cLayout{8, 4}  // pb_callback_t: a function and an argument pointer
+	sizeLayout      = cLayout{2, 2}  // pb_size_t: the count of a repeated field, the which_ of a oneof
+	wellKnownLayout = cLayout{16, 8} // Timestamp and Duration: int64 seconds, int32 nanos
+)
+
+// scalarLayouts holds the C layouts of the proto scalar types.
+var scalarLayouts = map[string]cLayout{
+	"bool":     {1, 1},
+	"int32":    {4, 4},
+	"uint32":   {4, 4},
+	"sint32":   {4, 4},
+	"fixed32":  {4, 4},
+	"sfixed32": {4, 4},
+	"float":    {4, 4},
+	"int64":    {8, 8},
+	"uint64":   {8, 8},
+	"sint64":   {8, 8},
+	"fixed64":  {8, 8},
+	"sfixed64": {8, 8},
+	"double":   {8, 8},
+}
+
+// handlerEntrySize is sizeof(struct handler_entry): the name pointer, the
+// name length padded to 4 and the handler pointer.
+const handlerEntrySize = 12
+
+func alignUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+// add lays out a member of type m after the members already in l.
+func (l *cLayout) add(m cLayout) {
+	l.size = alignUp(l.size, m.align) + m.size
+	l.align = max(l.align, m.align)
+}
+
+// close pads l to its alignment. nanopb gives a message without fields a
+// dummy char.
+func (l cLayout) close() cLayout {
+	if l.size == 0 {
+		return cLayout{1, 1}
+	}
+	return cLayout{alignUp(l.size, l.align), l.align}
+}
+
+// structSizer computes the nanopb struct sizes of the messages of a schema.
+type structSizer struct {
+	messages  map[string]gen.Message
+	limits    map[string]protomodel.FieldLimits
+	callbacks map[string]bool
+	visiting  map[string]bool
+}
+
+// structLayout returns the layout of the struct nanopb generates for the
+// fields of message msg. A oneof is its which_ member and a union placed at
+// its first field.
+func (s *structSizer) structLayout(msg string, fields []gen.Field) cLayout {
+	if s.visiting[msg] {
+		return callbackLayout // recursive messages need FT_CALLBACK
+	}
+	s.visiting[msg] = true
+	defer delete(s.visiting, msg)
+	var l cLayout
+	done := make(map[string]bool)
+	for _, f := range fields {
+		if f.Oneof == "" {
+			s.addField(&l, msg, f)
+			continue
+		}
+		if done[f.Oneof] {
+			continue
+		}
+		done[f.Oneof] = true
+		var union cLayout
+		for _, m := range fields {
+			if m.Oneof == f.Oneof {
+				v := s.valueLayout(msg+"."+m.Name, m)
+				union = cLayout{max(union.size, v.size), max(union.align, v.align)}
+			}
+		}
+		l.add(sizeLayout)
+		l.add(union.close())
+	}
+	return l.close()
+}
+
+// addField lays out the members of field f of msg in l: its has_ flag or
+// count, and its value or array.
+func (s *structSizer) addField(l *cLayout, msg string, f gen.Field) {
+	key := msg + "." + f.Name
+	if s.callbacks[key] {
+		l.add(callbackLayout)
+		return
+	}
+	switch {
+	case f.IsMap || f.IsRepeated:
+		count := s.limits[key].MaxCount
+		if count == 0 {
+			l.add(callbackLayout)
+			return
+		}
+		elem := s.valueLayout(key, f)
+		if f.IsMap {
+			var entry cLayout
+			entry.add(s.valueLayout(key, gen.Field{Type: f.KeyType}))
+			value := gen.Field{Type: f.ValueType, IsMessage: f.ValueIsMessage}
+			if cHasFlag(value) {
+				entry.add(scalarLayouts["bool"])
+			}
+			entry.add(s.valueLayout(key, value))
+			elem = entry.close()
+		}
+		l.add(sizeLayout)
+		l.add(cLayout{count * elem.size, elem.align})
+	default:
+		if cHasFlag(f) {
+			l.add(scalarLayouts["bool"])
+		}
+		l.add(s.valueLayout(key, f))
+	}
+}
+
+// valueLayout returns the layout of one value of f, key naming the field in
+// the .options file.
+func (s *structSizer) valueLayout(key string, f gen.Field) cLayout {
+	switch {
+	case protomodel.IsWellKnownType(f.Type):
+		return wellKnownLayout
+	case f.IsMessage:
+		m, ok := s.messages[f.Type]
+		if !ok {
+			return callbackLayout
+		}
+		return s.structLayout(m.Name, m.Fields)
+	case f.IsEnum:
+		return cLayout{4, 4}
+	case f.Type == "string":
+		if n := s.limits[key].MaxSize; n > 0 {
+			return cLayout{n, 1}
+		}
+		return callbackLayout
+	case f.Type == "bytes":
+		if n := s.limits[key].MaxSize; n > 0 {
+			return cLayout{alignUp(sizeLayout.size+n, sizeLayout.align), sizeLayout.align}
+		}
+		return callbackLayout
+	}
+	if l, ok := scalarLayouts[f.Type]; ok {
+		return l
+	}
+	return callbackLayout
+}
+
+// handlerTableFlash returns the flash of the handler table and the lookup
+// arrays beside it: the entries, the names and, per -c-dispatch and command
+// IDs, the hash and ID slots.
+func handlerTableFlash(gctx *gen.Context, commands []gen.Command) int {
+	total := 0
+	for _, cmd := range commands {
+		total += handlerEntrySize + len(cmd.Wire()) + 1
+	}
+	slot := 1
+	if cSlotType(len(commands)) == "uint16_t" {
+		slot = 2
+	}
+	if gctx.CDispatch == "hash" && len(commands) > 0 {
+		names := make([]string, len(commands))
+		for i, cmd := range commands {
+			names[i] = cmd.Wire()
+		}
+		seeds, _, size := perfectHash(names)
+		total += len(seeds) + size*slot
+	}
+	if gen.HasCommandIDs(commands) {
+		total += 128 * slot
+	}
+	return total
+}
+
+// WriteFootprintReport writes the footprint of every command and the totals
+// to w.
+func WriteFootprintReport(gctx *gen.Context, w io.Writer, commands []gen.Command, messages map[string]gen.Message, limits map[string]protomodel.FieldLimits, callbacks map[string]bool) {
+	s := &structSizer{messages, limits, callbacks, make(map[string]bool)}
+	encoded := func(n int) string {
+		if n == gen.UnboundedSize {
+			return "unbounded"
+		}
+		return strconv.Itoa(n)
+	}
+
+	width := len("command")
+	for _, cmd := range commands {
+		width = max(width, len(cmd.Snake))
+	}
+	fmt.Fprintf(w, "Footprint of %d commands (nanopb structs on a 32-bit target, -c-dispatch %s)\n\n", len(commands), gctx.CDispatch)
+	fmt.Fprintf(w, "%-*s  %11s  %11s  %11s  %11s  %11s\n", width, "command", "req struct", "resp struct", "max req", "max resp", "table")
+	stack, largest := 0, ""
+	for _, cmd := range commands {
+		req := s.structLayout(cmd.RequestMsg, cmd.RequestFields).size
+		resp := s.structLayout(cmd.ResponseMsg, cmd.ResponseFields).size
+		table := handlerEntrySize + len(cmd.Wire()) + 1
+		fmt.Fprintf(w, "%-*s  %11d  %11d  %11s  %11s  %11d\n", width, cmd.Snake, req, resp,
+			encoded(cmd.MaxRequestSize), encoded(cmd.MaxResponseSize), table)
+		if req+resp > stack {
+			stack, largest = req+resp, cmd.Snake
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Handler table: %d bytes of flash\n", handlerTableFlash(gctx, commands))
+	if largest != "" {
+		fmt.Fprintf(w, "Largest handler structs: %d bytes of stack (%s)\n", stack, largest)
+	}
+}
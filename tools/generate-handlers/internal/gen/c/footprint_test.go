@@ -0,0 +1,52 @@
+package c
+
+import (
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestStructLayout(t *testing.T) {
+	limits := map[string]protomodel.FieldLimits{
+		"EchoRequest.message": {MaxSize: 257},
+		"BatchRequest.names":  {MaxSize: 16, MaxCount: 4},
+		"BatchRequest.ids":    {MaxCount: 4},
+		"SetKeyRequest.key":   {MaxSize: 15},
+		"SearchRequest.text":  {MaxSize: 32},
+		"DrawRequest.path":    {MaxCount: 2},
+	}
+	messages := map[string]gen.Message{
+		"Point": {Name: "Point", Fields: []gen.Field{{Type: "int32", Name: "x"}, {Type: "int64", Name: "y"}}},
+	}
+	callbacks := map[string]bool{"DataWriteRequest.data": true}
+	s := &structSizer{messages, limits, callbacks, make(map[string]bool)}
+
+	for _, tt := range []struct {
+		name   string
+		msg    string
+		fields []gen.Field
+		want   int
+	}{
+		{"string", "EchoRequest", gentest.EchoCommand().RequestFields, 257},
+		// pb_size_t names_count, char names[4][16], pb_size_t ids_count and
+		// uint32_t ids[4].
+		{"repeated", "BatchRequest", gentest.RepeatedCommand().RequestFields, 2 + 64 + 2 + 16},
+		// PB_BYTES_ARRAY_T(15), padded to 18, then uint32_t slot.
+		{"bytes", "SetKeyRequest", []gen.Field{{Type: "bytes", Name: "key"}, {Type: "uint32", Name: "slot"}}, 24},
+		// uint32_t limit, pb_size_t which_query, union of char[32] and
+		// uint32_t.
+		{"oneof", "SearchRequest", gentest.OneofCommand().RequestFields, 4 + 4 + 32},
+		// uint32_t address, pb_callback_t data.
+		{"callback", "DataWriteRequest", gentest.CallbackCommand().RequestFields, 12},
+		// pb_size_t path_count, Point path[2] of 16 bytes each.
+		{"submessage", "DrawRequest", []gen.Field{{Type: "Point", Name: "path", IsMessage: true, IsRepeated: true}}, 8 + 32},
+		{"unbounded map", "SetLabelsRequest", gentest.MapCommand().RequestFields[:1], 8},
+		{"empty", "PingRequest", nil, 1},
+	} {
+		if got := s.structLayout(tt.msg, tt.fields).size; got != tt.want {
+			t.Errorf("%s: struct size = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
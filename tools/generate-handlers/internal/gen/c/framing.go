@@ -0,0 +1,13 @@
+package c
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateFramingHeader(gctx *gen.Context, commands []gen.Command, pkg string, inFlight int, crc bool) string {
+	return gctx.RenderTemplate("framing.h.tmpl", gctx.NewFramingData(commands, pkg, inFlight, crc))
+}
+
+func GenerateFramingSource(gctx *gen.Context, commands []gen.Command, pkg string, inFlight int, crc bool) string {
+	return gctx.RenderTemplate("framing.c.tmpl", gctx.NewFramingData(commands, pkg, inFlight, crc))
+}
@@ -0,0 +1,251 @@
+package c
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// fieldWireType returns the wire type of a field as it appears on the wire.
+// Repeated numeric fields are packed in proto3 and therefore length-delimited.
+func fieldWireType(f gen.Field) int {
+	if f.IsMap || f.IsMessage || f.IsRepeated {
+		return gen.WireBytes
+	}
+	if f.IsEnum {
+		return gen.WireVarint
+	}
+	return gen.WireTypeOf(f.Type)
+}
+
+// dictEscape renders bytes as an AFL/libFuzzer dictionary string literal.
+func dictEscape(data []byte) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range data {
+		if c >= 0x20 && c < 0x7f && c != '"' && c != '\\' {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(fmt.Sprintf("\\x%02x", c))
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func writeDictMessage(b *strings.Builder, msg gen.Message, seen map[string]bool) {
+	if seen[msg.Name] {
+		return
+	}
+	seen[msg.Name] = true
+	for _, f := range msg.Fields {
+		tag := gen.AppendTag(nil, f.Number, fieldWireType(f))
+		b.WriteString(fmt.Sprintf("tag_%s_%s=%s\n", msg.Name, f.Name, dictEscape(tag)))
+	}
+}
+
+// GenerateFuzzDict renders an AFL/libFuzzer dictionary with the command names,
+// the field tags of every request/response message and the enum values.
+func GenerateFuzzDict(commands []gen.Command, msgByName map[string]gen.Message, enums []gen.Enum) string {
+	var b strings.Builder
+	b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteByte('\n')
+	b.WriteString("# Command names\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("cmd_%s=%s\n", cmd.Snake, dictEscape([]byte(cmd.Wire()))))
+	}
+
+	b.WriteByte('\n')
+	b.WriteString("# Field tags\n")
+	seen := make(map[string]bool)
+	for _, cmd := range commands {
+		writeDictMessage(&b, gen.Message{Name: cmd.RequestMsg, Fields: cmd.RequestFields}, seen)
+		writeDictMessage(&b, gen.Message{Name: cmd.ResponseMsg, Fields: cmd.ResponseFields}, seen)
+	}
+	// Nested message types referenced from commands, in name order.
+	var nested []string
+	for _, cmd := range commands {
+		for f := range cmd.Fields() {
+			name := f.Type
+			if f.IsMap {
+				name = f.ValueType
+			}
+			if _, ok := msgByName[name]; ok && !seen[name] {
+				nested = append(nested, name)
+			}
+		}
+	}
+	sort.Strings(nested)
+	for _, name := range nested {
+		writeDictMessage(&b, msgByName[name], seen)
+	}
+
+	if len(enums) > 0 {
+		b.WriteByte('\n')
+		b.WriteString("# Enum values\n")
+		for _, en := range enums {
+			for _, v := range en.Values {
+				val := binary.AppendUvarint(nil, uint64(int64(v.Number)))
+				b.WriteString(fmt.Sprintf("enum_%s_%s=%s\n", en.Name, v.Name, dictEscape(val)))
+			}
+		}
+	}
+	return b.String()
+}
+
+// GenerateFuzzCorpus renders minimal valid corpus entries per command: an
+// empty request (all defaults) and the encoded sample request. Paths are
+// relative to the corpus directory.
+func GenerateFuzzCorpus(commands []gen.Command, msgByName map[string]gen.Message, enumByName map[string]gen.Enum) []gen.Output {
+	var outs []gen.Output
+	for _, cmd := range commands {
+		outs = append(outs,
+			gen.Output{Path: cmd.Snake + "/empty", Content: ""},
+			gen.Output{Path: cmd.Snake + "/sample", Content: string(gen.EncodeSampleFields(cmd.RequestFields, 0, msgByName, enumByName))},
+		)
+	}
+	return outs
+}
+
+// GenerateFuzzCommandSeeds renders a request command per command, type,
+// name, length and the encoded sample request, as the harness takes them.
+// Replay-protected commands get a counter of 1 and a zero MAC, which the
+// replay guard rejects, and session-protected ones a zero token, which the
+// session guard rejects.
+func GenerateFuzzCommandSeeds(commands []gen.Command, msgByName map[string]gen.Message, enumByName map[string]gen.Enum) []gen.Output {
+	var outs []gen.Output
+	for _, cmd := range commands {
+		data := make([]byte, gen.MockRequestPrefix(cmd))
+		if cmd.ReplayProtected {
+			data[len(data)-gen.ReplayPrefixSize] = 1
+		}
+		data = append(data, gen.EncodeSampleFields(cmd.RequestFields, 0, msgByName, enumByName)...)
+		seed := []byte{0, byte(len(cmd.Wire()))}
+		seed = append(seed, cmd.Wire()...)
+		seed = binary.LittleEndian.AppendUint16(seed, uint16(len(data)))
+		outs = append(outs, gen.Output{Path: cmd.Snake + "/command", Content: string(append(seed, data...))})
+	}
+	return outs
+}
+
+// GenerateFuzzHarness renders a libFuzzer target of the code parsing what the
+// radio delivers: it parses the input as a request command, runs the handler
+// handlers_lookup finds for it through both passes of the dispatcher, and
+// decodes the payload as the request of every command of that name. With
+// framing (inFlight > 0 with correlation IDs) it also feeds the input to the
+// reassembler as frames, each after a length byte, and dispatches every
+// message that completes.
+func GenerateFuzzHarness(gctx *gen.Context, commands []gen.Command, pkg string, framing bool, inFlight int) string {
+	ctxArg := ""
+	if gctx.CHandlerCtx {
+		ctxArg = ", NULL"
+	}
+	var b strings.Builder
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("/*\n")
+	b.WriteString(" * libFuzzer target of the request path: build it with\n")
+	b.WriteString(" * -fsanitize=fuzzer,address, generated_handlers.c, the handler\n")
+	if framing {
+		b.WriteString(" * implementations, generated_framing.c, nanopb and blerpc_protocol, and run\n")
+	} else {
+		b.WriteString(" * implementations, nanopb and blerpc_protocol, and run it\n")
+	}
+	b.WriteString(fmt.Sprintf(" * with -dict=%s.dict on the corpus directory.\n", pkg))
+	b.WriteString(" */\n")
+	b.WriteString("#include \"generated_handlers.h\"\n")
+	b.WriteString("#include \"" + pkg + ".pb.h\"\n")
+	if framing {
+		b.WriteString("#include \"generated_framing.h\"\n")
+	}
+	b.WriteString("#include <blerpc_protocol/command.h>\n")
+	b.WriteString("#include <pb_decode.h>\n")
+	b.WriteString("#include <pb_encode.h>\n")
+	b.WriteString("#include <string.h>\n")
+	b.WriteByte('\n')
+	b.WriteString("#ifndef FUZZ_RESP_BUF_SIZE\n")
+	b.WriteString("#define FUZZ_RESP_BUF_SIZE 1024\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Room for the decoded request of any command. */\n")
+	b.WriteString("union fuzz_request {\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    %s_%s %s;\n", pkg, cmd.RequestMsg, cmd.Snake))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("static const struct {\n")
+	b.WriteString("    const char *name;\n")
+	b.WriteString("    uint8_t name_len;\n")
+	b.WriteString("    const pb_msgdesc_t *fields;\n")
+	b.WriteString("} requests[] = {\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    {\"%s\", %d, %s_%s_fields},\n", cmd.Wire(), len(cmd.Wire()), pkg, cmd.RequestMsg))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+
+	b.WriteString("static void dispatch(const uint8_t *data, size_t len)\n")
+	b.WriteString("{\n")
+	b.WriteString("    static uint8_t resp_buf[FUZZ_RESP_BUF_SIZE];\n")
+	b.WriteString("    struct command_packet cmd;\n")
+	b.WriteString("    if (command_parse(data, len, &cmd) != 0 || cmd.cmd_type != COMMAND_TYPE_REQUEST) {\n")
+	b.WriteString("        return;\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    for (size_t i = 0; i < sizeof(requests) / sizeof(requests[0]); i++) {\n")
+	b.WriteString("        if (requests[i].name_len != cmd.cmd_name_len ||\n")
+	b.WriteString("            memcmp(requests[i].name, cmd.cmd_name, cmd.cmd_name_len) != 0) {\n")
+	b.WriteString("            continue;\n")
+	b.WriteString("        }\n")
+	b.WriteString("        union fuzz_request req;\n")
+	b.WriteString("        memset(&req, 0, sizeof(req));\n")
+	b.WriteString("        pb_istream_t stream = pb_istream_from_buffer(cmd.data, cmd.data_len);\n")
+	b.WriteString("        pb_decode(&stream, requests[i].fields, &req);\n")
+	b.WriteString("#ifdef PB_ENABLE_MALLOC\n")
+	b.WriteString("        pb_release(requests[i].fields, &req);\n")
+	b.WriteString("#endif\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s handler = %s(cmd.cmd_name, cmd.cmd_name_len%s);\n", gctx.CSymbol("command_handler_fn"), gctx.CSymbol("handlers_lookup"), ctxArg))
+	b.WriteString("    if (handler == NULL) return;\n")
+	b.WriteString("    pb_ostream_t sizing = PB_OSTREAM_SIZING;\n")
+	b.WriteString(fmt.Sprintf("    if (handler(cmd.data, cmd.data_len, &sizing%s) != 0 ||\n", ctxArg))
+	b.WriteString("        sizing.bytes_written > sizeof(resp_buf)) {\n")
+	b.WriteString("        return;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(resp_buf, sizeof(resp_buf));\n")
+	b.WriteString(fmt.Sprintf("    handler(cmd.data, cmd.data_len, &out%s);\n", ctxArg))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size)\n")
+	b.WriteString("{\n")
+	b.WriteString("    dispatch(data, size);\n")
+	if framing {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    static struct %s_reassembler r;\n", pkg))
+		b.WriteString(fmt.Sprintf("    %s_reassembler_reset(&r);\n", pkg))
+		b.WriteString("    while (size > 0) {\n")
+		b.WriteString("        size_t n = data[0] < size - 1 ? data[0] : size - 1;\n")
+		if inFlight > 0 {
+			b.WriteString(fmt.Sprintf("        struct %s_frame_message msg;\n", pkg))
+			b.WriteString(fmt.Sprintf("        if (%s_reassembler_feed(&r, data + 1, n, &msg) > 0) {\n", pkg))
+			b.WriteString("            dispatch(msg.data, msg.len);\n")
+		} else {
+			b.WriteString(fmt.Sprintf("        int len = %s_reassembler_feed(&r, data + 1, n);\n", pkg))
+			b.WriteString("        if (len > 0) {\n")
+			b.WriteString("            dispatch(r.buf, (size_t)len);\n")
+		}
+		b.WriteString("        }\n")
+		b.WriteString("        data += 1 + n;\n")
+		b.WriteString("        size -= 1 + n;\n")
+		b.WriteString("    }\n")
+	}
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	return b.String()
+}
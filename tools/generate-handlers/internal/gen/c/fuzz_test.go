@@ -0,0 +1,86 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateFuzzDict(t *testing.T) {
+	enums := []gen.Enum{{Name: "Status", Values: []gen.EnumValue{{Name: "STATUS_OK", Number: 0}, {Name: "STATUS_ERR", Number: 1}}}}
+	out := GenerateFuzzDict([]gen.Command{gentest.EchoCommand(), gentest.CallbackCommand()}, nil, enums)
+
+	mustContain := []string{
+		`cmd_echo="echo"`,
+		`cmd_data_write="data_write"`,
+		`tag_EchoRequest_message="\x0a"`,
+		`tag_DataWriteRequest_address="\x08"`,
+		`tag_DataWriteRequest_data="\x12"`,
+		`enum_Status_STATUS_ERR="\x01"`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("fuzz dict missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateFuzzCorpus(t *testing.T) {
+	outs := GenerateFuzzCorpus([]gen.Command{gentest.EchoCommand()}, nil, nil)
+	if len(outs) != 2 {
+		t.Fatalf("expected 2 seeds, got %d", len(outs))
+	}
+	if outs[0].Path != "echo/empty" || outs[0].Content != "" {
+		t.Errorf("unexpected empty seed: %+v", outs[0])
+	}
+	if outs[1].Path != "echo/sample" || outs[1].Content != "\x0a\x07message" {
+		t.Errorf("unexpected sample seed: %q", outs[1].Content)
+	}
+}
+
+func TestGenerateFuzzCommandSeeds(t *testing.T) {
+	guarded := gentest.EchoCommand()
+	guarded.ReplayProtected = true
+	outs := GenerateFuzzCommandSeeds([]gen.Command{guarded}, nil, nil)
+	want := "\x00\x04echo\x21\x00\x01" + strings.Repeat("\x00", 23) + "\x0a\x07message"
+	if len(outs) != 1 || outs[0].Path != "echo/command" || outs[0].Content != want {
+		t.Errorf("unexpected command seeds: %+v", outs)
+	}
+}
+
+func TestGenerateFuzzHarness(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateFuzzHarness(gctx, cmds, "blerpc", false, 0)
+	for _, s := range []string{
+		"#include <blerpc_protocol/command.h>",
+		"    blerpc_EchoRequest echo;\n",
+		"    {\"echo\", 4, blerpc_EchoRequest_fields},\n",
+		"command_handler_fn handler = handlers_lookup(cmd.cmd_name, cmd.cmd_name_len);\n",
+		"int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size)\n{\n    dispatch(data, size);\n    return 0;\n}\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("harness missing %q", s)
+		}
+	}
+	if strings.Contains(out, "reassembler") {
+		t.Error("harness feeds the reassembler without framing")
+	}
+
+	tests := []struct {
+		name     string
+		inFlight int
+		want     string
+	}{
+		{"framing", 0, "        int len = blerpc_reassembler_feed(&r, data + 1, n);\n"},
+		{"correlation", 4, "        if (blerpc_reassembler_feed(&r, data + 1, n, &msg) > 0) {\n            dispatch(msg.data, msg.len);\n"},
+	}
+	for _, tt := range tests {
+		out := GenerateFuzzHarness(gctx, cmds, "blerpc", true, tt.inFlight)
+		if !strings.Contains(out, "#include \"generated_framing.h\"") || !strings.Contains(out, tt.want) {
+			t.Errorf("%s: harness missing framing\nGot:\n%s", tt.name, out)
+		}
+	}
+}
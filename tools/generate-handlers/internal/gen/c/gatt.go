@@ -0,0 +1,155 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// In the characteristic-per-command GATT mode (-gatt per-command) every
+// command gets its own characteristic in a dedicated command service instead
+// of sharing the multiplexed RPC characteristic. A write to a command
+// characteristic carries the usual containers, but the assembled payload is
+// the bare request message: the characteristic identifies the command, so no
+// command header (type, name, data length) is sent. Responses and stream
+// messages are notified on the same characteristic.
+//
+// The service UUID is -gatt-uuid-base. Each characteristic UUID replaces the
+// first 32-bit field of the base with the FNV-1a hash of the command's wire
+// name, so UUIDs stay stable when commands are added, removed or reordered.
+
+// DefaultGattUUIDBase is the default command service UUID; "blerpc" in ASCII
+// follows the zero first field.
+const DefaultGattUUIDBase = "00000000-626c-6572-7063-000000000000"
+
+// zephyrUUIDEncode renders a UUID as a Zephyr BT_UUID_128_ENCODE invocation.
+func zephyrUUIDEncode(uuid string) string {
+	p := strings.Split(uuid, "-")
+	return fmt.Sprintf("BT_UUID_128_ENCODE(0x%s, 0x%s, 0x%s, 0x%s, 0x%s)", p[0], p[1], p[2], p[3], p[4])
+}
+
+func GenerateGattHeader(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	up := strings.ToUpper(pkg)
+	guard := up + "_GENERATED_GATT_H"
+	var b strings.Builder
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+		"#include <stddef.h>",
+		"#include <stdint.h>",
+		"#include <zephyr/bluetooth/conn.h>",
+		"#include <zephyr/bluetooth/gatt.h>",
+		"#include \"generated_handlers.h\"",
+		"",
+		"#ifdef __cplusplus",
+		`extern "C" {`,
+		"#endif",
+		"",
+		"/* Command service UUID: " + gen.GATTServiceUUID(commands) + " */",
+		"#define " + up + "_CMD_SERVICE_UUID " + zephyrUUIDEncode(gen.GATTServiceUUID(commands)),
+		"",
+	}
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("/* %s: %s */\n", cmd.Wire(), cmd.CharUUID))
+		b.WriteString(fmt.Sprintf("#define %s_CMD_%s_UUID %s\n", up, strings.ToUpper(cmd.Snake), zephyrUUIDEncode(cmd.CharUUID)))
+	}
+	b.WriteByte('\n')
+
+	tail := []string{
+		fmt.Sprintf("#define %s_GATT_COMMAND_COUNT %d", up, len(commands)),
+		"",
+		"/* A command characteristic of the command service. */",
+		"struct " + pkg + "_gatt_command {",
+		"    const char *name;",
+		"    uint8_t name_len;",
+		"    uint8_t index;",
+		"    " + gctx.CSymbol("command_handler_fn") + " handler;",
+		"};",
+		"",
+		"/* Commands in characteristic order. */",
+		"extern const struct " + pkg + "_gatt_command " + pkg + "_gatt_commands[" + up + "_GATT_COMMAND_COUNT];",
+		"",
+		"/* Characteristic value attribute of a command, for notifications. */",
+		"const struct bt_gatt_attr *" + pkg + "_gatt_command_attr(const struct " + pkg + "_gatt_command *cmd);",
+		"",
+		"/**",
+		" * Implemented by the BLE layer: a container was written to the",
+		" * characteristic of cmd. The assembled payload is the bare request",
+		" * message, without a command header.",
+		" */",
+		"void " + pkg + "_gatt_on_command_write(const struct " + pkg + "_gatt_command *cmd,",
+		strings.Repeat(" ", len(pkg)+28) + "struct bt_conn *conn, const uint8_t *data, uint16_t len);",
+		"",
+		"#ifdef __cplusplus",
+		"}",
+		"#endif",
+		"",
+		"#endif /* " + guard + " */",
+	}
+	for _, l := range tail {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func GenerateGattSource(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	up := strings.ToUpper(pkg)
+	var b strings.Builder
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_gatt.h\"\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("const struct %s_gatt_command %s_gatt_commands[%s_GATT_COMMAND_COUNT] = {\n", pkg, pkg, up))
+	for i, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    {\"%s\", %d, %d, %s},\n", cmd.Wire(), len(cmd.Wire()), i, cHandlerFn(gctx, cmd)))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("static struct bt_uuid_128 cmd_svc_uuid = BT_UUID_INIT_128(%s_CMD_SERVICE_UUID);\n", up))
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("static struct bt_uuid_128 cmd_%s_uuid = BT_UUID_INIT_128(%s_CMD_%s_UUID);\n",
+			cmd.Snake, up, strings.ToUpper(cmd.Snake)))
+	}
+	b.WriteByte('\n')
+
+	for i, cmd := range commands {
+		pad := strings.Repeat(" ", len(cmd.Snake))
+		b.WriteString(fmt.Sprintf("static ssize_t on_write_%s(struct bt_conn *conn, const struct bt_gatt_attr *attr,\n", cmd.Snake))
+		b.WriteString(fmt.Sprintf("                         %sconst void *buf, uint16_t len, uint16_t offset, uint8_t flags)\n", pad))
+		b.WriteString("{\n")
+		b.WriteString("    (void)attr;\n")
+		b.WriteString("    (void)offset;\n")
+		b.WriteString("    (void)flags;\n")
+		b.WriteString(fmt.Sprintf("    %s_gatt_on_command_write(&%s_gatt_commands[%d], conn, buf, len);\n", pkg, pkg, i))
+		b.WriteString("    return len;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+
+	// Each characteristic takes three attributes (declaration, value, CCC)
+	// after the primary service attribute.
+	b.WriteString(fmt.Sprintf("BT_GATT_SERVICE_DEFINE(%s_cmd_svc, BT_GATT_PRIMARY_SERVICE(&cmd_svc_uuid),\n", pkg))
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    BT_GATT_CHARACTERISTIC(&cmd_%s_uuid.uuid,\n", cmd.Snake))
+		b.WriteString("                           BT_GATT_CHRC_WRITE_WITHOUT_RESP | BT_GATT_CHRC_NOTIFY,\n")
+		b.WriteString(fmt.Sprintf("                           BT_GATT_PERM_WRITE, NULL, on_write_%s, NULL),\n", cmd.Snake))
+		b.WriteString("    BT_GATT_CCC(NULL, BT_GATT_PERM_READ | BT_GATT_PERM_WRITE),\n")
+	}
+	b.WriteString(");\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("const struct bt_gatt_attr *%s_gatt_command_attr(const struct %s_gatt_command *cmd)\n", pkg, pkg))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    return &%s_cmd_svc.attrs[2 + 3 * cmd->index];\n", pkg))
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,259 @@
+package c
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+var ReGattGroupName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// writeCGattGroupDecl emits the service enum and
+// <pkg>_service_handlers_lookup.
+func writeCGattGroupDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* GATT services the commands are grouped into (gatt.services in\n")
+	b.WriteString(" * blerpc.yaml), each with a characteristic of its own. */\n")
+	b.WriteString(fmt.Sprintf("enum %s_gatt_service {\n", pkg))
+	b.WriteString(fmt.Sprintf("    %s_GATT_SERVICE_RPC = 0, /* the multiplexed RPC service */\n", upper))
+	for i, g := range gctx.GATTGroups {
+		b.WriteString(fmt.Sprintf("    %s_GATT_SERVICE_%s = %d,\n", upper, strings.ToUpper(g.Name), i+1))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("#define %s_GATT_SERVICE_COUNT %d\n", upper, len(gctx.GATTGroups)+1))
+	b.WriteByte('\n')
+	b.WriteString("/* " + gctx.CSymbol("handlers_lookup") + " for a container written to the characteristic of\n")
+	b.WriteString(" * service: the commands of the other services look unknown. */\n")
+	fn := fmt.Sprintf("%s %s_service_handlers_lookup(", gctx.CSymbol("command_handler_fn"), pkg)
+	b.WriteString(fmt.Sprintf("%senum %s_gatt_service service, const char *name,\n", fn, pkg))
+	b.WriteString(fmt.Sprintf("%suint8_t name_len%s);\n", strings.Repeat(" ", len(fn)), gctx.CCtxSuffix()))
+	b.WriteByte('\n')
+}
+
+// writeCGattGroupLookup emits the command table of each service and
+// <pkg>_service_handlers_lookup, which checks the command is in the table
+// of service before handing it to handlers_lookup.
+func writeCGattGroupLookup(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	upper := strings.ToUpper(pkg)
+	ids := gen.HasCommandIDs(commands)
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("/* Commands of each GATT service, for %s_service_handlers_lookup. */\n", pkg))
+	b.WriteString("struct service_command {\n")
+	b.WriteString("    const char *name;\n")
+	b.WriteString("    uint8_t name_len;\n")
+	if ids {
+		b.WriteString("    uint8_t id;\n")
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+
+	var rpc []gen.Command
+	for _, cmd := range commands {
+		if cmd.GattService == "" {
+			rpc = append(rpc, cmd)
+		}
+	}
+	tables := []gen.GATTGroup{{Name: "rpc", Commands: rpc}}
+	tables = append(tables, gctx.GATTGroups...)
+	for _, t := range tables {
+		if len(t.Commands) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("static const struct service_command %s_service_commands[] = {\n", t.Name))
+		for _, cmd := range t.Commands {
+			if ids {
+				b.WriteString(fmt.Sprintf("    {\"%s\", %d, %d},\n", cmd.Wire(), len(cmd.Wire()), cmd.ID))
+			} else {
+				b.WriteString(fmt.Sprintf("    {\"%s\", %d},\n", cmd.Wire(), len(cmd.Wire())))
+			}
+		}
+		b.WriteString("};\n")
+		b.WriteByte('\n')
+	}
+	b.WriteString("static const struct {\n")
+	b.WriteString("    const struct service_command *commands;\n")
+	b.WriteString("    size_t count;\n")
+	b.WriteString(fmt.Sprintf("} service_tables[%s_GATT_SERVICE_COUNT] = {\n", upper))
+	for _, t := range tables {
+		if len(t.Commands) == 0 {
+			b.WriteString("    {NULL, 0},\n")
+			continue
+		}
+		table := t.Name + "_service_commands"
+		b.WriteString(fmt.Sprintf("    {%s, sizeof(%s) / sizeof(%s[0])},\n", table, table, table))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+
+	fn := fmt.Sprintf("%s %s_service_handlers_lookup(", gctx.CSymbol("command_handler_fn"), pkg)
+	b.WriteString(fmt.Sprintf("%senum %s_gatt_service service, const char *name,\n", fn, pkg))
+	b.WriteString(fmt.Sprintf("%suint8_t name_len%s)\n", strings.Repeat(" ", len(fn)), gctx.CCtxSuffix()))
+	b.WriteString("{\n")
+	b.WriteString("    size_t i;\n")
+	b.WriteString(fmt.Sprintf("    if ((unsigned)service >= %s_GATT_SERVICE_COUNT) return NULL;\n", upper))
+	b.WriteString("    for (i = 0; i < service_tables[service].count; i++) {\n")
+	b.WriteString("        const struct service_command *cmd = &service_tables[service].commands[i];\n")
+	if ids {
+		b.WriteString("        /* A one-byte name carries the command ID. */\n")
+		b.WriteString("        if ((name_len == 1 && (uint8_t)name[0] == cmd->id) ||\n")
+		b.WriteString("            (cmd->name_len == name_len && memcmp(cmd->name, name, name_len) == 0)) {\n")
+	} else {
+		b.WriteString("        if (cmd->name_len == name_len && memcmp(cmd->name, name, name_len) == 0) {\n")
+	}
+	ctx := ""
+	if gctx.CHandlerCtx {
+		ctx = ", ctx"
+	}
+	b.WriteString(fmt.Sprintf("            return %s(name, name_len%s);\n", gctx.CSymbol("handlers_lookup"), ctx))
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return NULL;\n")
+	b.WriteString("}\n")
+}
+
+// writeGattGroupServiceDecl emits the UUIDs, the write hook and the notify
+// function of the groups to the Zephyr GATT service header.
+func writeGattGroupServiceDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	up := strings.ToUpper(prefix)
+	b.WriteString("/* GATT services grouping commands apart from the RPC service\n")
+	b.WriteString(" * (gatt.services in blerpc.yaml), each with one characteristic. */\n")
+	for _, g := range gctx.GATTGroups {
+		name := strings.ToUpper(g.Name)
+		b.WriteString(fmt.Sprintf("/* %s Service UUID: %s */\n", g.Name, g.ServiceUUID))
+		b.WriteString(fmt.Sprintf("#define %s_%s_SERVICE_UUID %s\n", up, name, zephyrUUIDEncode(g.ServiceUUID)))
+		b.WriteString(fmt.Sprintf("/* %s Characteristic UUID: %s */\n", g.Name, g.CharUUID))
+		b.WriteString(fmt.Sprintf("#define %s_%s_CHAR_UUID %s\n", up, name, zephyrUUIDEncode(g.CharUUID)))
+		b.WriteByte('\n')
+	}
+	lines := []string{
+		"/**",
+		" * Implemented by the BLE layer: a container was written to the",
+		" * characteristic of a grouped service. Look its command up with",
+		" * " + prefix + "_service_handlers_lookup(service, ...), and that of a",
+		" * container written to the RPC characteristic with " + up + "_GATT_SERVICE_RPC,",
+		" * so each characteristic only runs the commands of its service.",
+		" */",
+		"void " + prefix + "_gatt_on_service_write(enum " + prefix + "_gatt_service service, struct bt_conn *conn,",
+		strings.Repeat(" ", len(prefix)+28) + "const uint8_t *data, uint16_t len);",
+		"",
+		"/**",
+		" * Send a notification on the characteristic of service; " + up + "_GATT_SERVICE_RPC",
+		" * is the RPC characteristic.",
+		" * @return 0 on success, negative on error",
+		" */",
+		"int " + prefix + "_gatt_service_notify(enum " + prefix + "_gatt_service service, struct bt_conn *conn,",
+		strings.Repeat(" ", len(prefix)+25) + "const uint8_t *data, size_t len);",
+		"",
+	}
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+}
+
+// writeGattGroupServices emits a Zephyr GATT service per group and
+// <prefix>_gatt_service_notify.
+func writeGattGroupServices(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	up := strings.ToUpper(prefix)
+	b.WriteByte('\n')
+	for _, g := range gctx.GATTGroups {
+		name := strings.ToUpper(g.Name)
+		b.WriteString(fmt.Sprintf("static struct bt_uuid_128 %s_svc_uuid = BT_UUID_INIT_128(%s_%s_SERVICE_UUID);\n", g.Name, up, name))
+		b.WriteString(fmt.Sprintf("static struct bt_uuid_128 %s_char_uuid = BT_UUID_INIT_128(%s_%s_CHAR_UUID);\n", g.Name, up, name))
+		b.WriteByte('\n')
+		pad := strings.Repeat(" ", len(g.Name))
+		b.WriteString(fmt.Sprintf("static ssize_t on_write_%s(struct bt_conn *conn, const struct bt_gatt_attr *attr,\n", g.Name))
+		b.WriteString(fmt.Sprintf("                         %sconst void *buf, uint16_t len, uint16_t offset, uint8_t flags)\n", pad))
+		b.WriteString("{\n")
+		b.WriteString("    (void)attr;\n")
+		b.WriteString("    (void)offset;\n")
+		b.WriteString("    (void)flags;\n")
+		b.WriteString(fmt.Sprintf("    %s_gatt_on_service_write(%s_GATT_SERVICE_%s, conn, buf, len);\n", prefix, up, name))
+		b.WriteString("    return len;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+		def := fmt.Sprintf("BT_GATT_SERVICE_DEFINE(%s_%s_svc, ", prefix, g.Name)
+		indent := strings.Repeat(" ", len("BT_GATT_SERVICE_DEFINE("))
+		b.WriteString(fmt.Sprintf("%sBT_GATT_PRIMARY_SERVICE(&%s_svc_uuid),\n", def, g.Name))
+		b.WriteString(fmt.Sprintf("%sBT_GATT_CHARACTERISTIC(&%s_char_uuid.uuid,\n", indent, g.Name))
+		b.WriteString(fmt.Sprintf("%s                       BT_GATT_CHRC_WRITE_WITHOUT_RESP | BT_GATT_CHRC_NOTIFY,\n", indent))
+		b.WriteString(fmt.Sprintf("%s                       BT_GATT_PERM_WRITE, NULL, on_write_%s, NULL),\n", indent, g.Name))
+		b.WriteString(fmt.Sprintf("%sBT_GATT_CCC(NULL, BT_GATT_PERM_READ | BT_GATT_PERM_WRITE), );\n", indent))
+		b.WriteByte('\n')
+	}
+
+	b.WriteString("/* Characteristic value attribute of each service, for notifications. */\n")
+	b.WriteString(fmt.Sprintf("static const struct bt_gatt_attr *const service_attrs[%s_GATT_SERVICE_COUNT] = {\n", up))
+	b.WriteString(fmt.Sprintf("    &%s_svc.attrs[2],\n", prefix))
+	for _, g := range gctx.GATTGroups {
+		b.WriteString(fmt.Sprintf("    &%s_%s_svc.attrs[2],\n", prefix, g.Name))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("int %s_gatt_service_notify(enum %s_gatt_service service, struct bt_conn *conn,\n", prefix, prefix))
+	b.WriteString(fmt.Sprintf("%sconst uint8_t *data, size_t len)\n", strings.Repeat(" ", len(prefix)+25)))
+	b.WriteString("{\n")
+	b.WriteString("    if (!conn) {\n")
+	b.WriteString("        return -ENOTCONN;\n")
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    if ((unsigned)service >= %s_GATT_SERVICE_COUNT) {\n", up))
+	b.WriteString("        return -EINVAL;\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    struct bt_gatt_notify_params params = {\n")
+	b.WriteString("        .attr = service_attrs[service],\n")
+	b.WriteString("        .data = data,\n")
+	b.WriteString("        .len = len,\n")
+	b.WriteString("    };\n")
+	b.WriteByte('\n')
+	b.WriteString("    return bt_gatt_notify_cb(conn, &params);\n")
+	b.WriteString("}\n")
+}
+
+// cGattGroupUUIDLines returns the group UUIDs of the C client's
+// generated_uuids.h, and for each grouped command the characteristic its
+// calls are written to.
+func cGattGroupUUIDLines(gctx *gen.Context, prefix string) []string {
+	up := strings.ToUpper(prefix)
+	var lines []string
+	for _, g := range gctx.GATTGroups {
+		name := up + "_" + strings.ToUpper(g.Name)
+		lines = append(lines,
+			"/* "+g.Name+" Service UUID: "+g.ServiceUUID+" */",
+			"#define "+name+"_SERVICE_UUID "+zephyrUUIDEncode(g.ServiceUUID),
+			"#define "+name+"_SERVICE_UUID_STR \""+g.ServiceUUID+"\"",
+			"",
+			"/* "+g.Name+" Characteristic UUID: "+g.CharUUID+" */",
+			"#define "+name+"_CHAR_UUID "+zephyrUUIDEncode(g.CharUUID),
+			"#define "+name+"_CHAR_UUID_STR \""+g.CharUUID+"\"",
+			"",
+		)
+	}
+	if len(gctx.GATTGroups) > 0 {
+		lines = append(lines, "/* Characteristic each grouped command is written to. */")
+		for _, g := range gctx.GATTGroups {
+			for _, cmd := range g.Commands {
+				lines = append(lines, fmt.Sprintf("#define %s_CMD_%s_CHAR_UUID %s_%s_CHAR_UUID", up, strings.ToUpper(cmd.Snake), up, strings.ToUpper(g.Name)))
+			}
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
@@ -0,0 +1,233 @@
+package c
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Platforms lists the accepted -platform values. With zephyr the generator
+// writes the GATT service of the multiplexed RPC characteristic: the
+// service definition, the write and CCC callbacks, notification and the
+// MTU hooks. Container assembly, encryption and the handlers_lookup
+// dispatch stay in the BLE layer, which implements <pkg>_gatt_on_write.
+// esp-idf writes the NimBLE equivalent (see gatt_service_nimble.go).
+var Platforms = []string{"zephyr", "esp-idf", "none"}
+
+func GenerateGattServiceHeader(gctx *gen.Context, pkg string) string {
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	up := strings.ToUpper(prefix)
+	guard := up + "_GENERATED_GATT_SERVICE_H"
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+		"#include <stdbool.h>",
+		"#include <stddef.h>",
+		"#include <stdint.h>",
+		"#include <zephyr/bluetooth/conn.h>",
+		"#include <zephyr/bluetooth/gatt.h>",
+		"",
+		"#ifdef __cplusplus",
+		`extern "C" {`,
+		"#endif",
+		"",
+		"/* " + prefix + " Service UUID: " + gctx.ServiceUUID + " */",
+		"#define " + up + "_SERVICE_UUID " + zephyrUUIDEncode(gctx.ServiceUUID),
+		"",
+		"/* " + prefix + " Characteristic UUID: " + gctx.CharUUID + " */",
+		"#define " + up + "_CHAR_UUID " + zephyrUUIDEncode(gctx.CharUUID),
+		"",
+		"/* ATT MTU before the MTU exchange, and without a connection. */",
+		"#define " + up + "_GATT_DEFAULT_MTU 23",
+		"",
+		"/**",
+		" * Register the GATT callbacks (MTU updates).",
+		" * Call once after bt_enable().",
+		" */",
+		"void " + prefix + "_gatt_init(void);",
+		"",
+		"/**",
+		" * Implemented by the BLE layer: a container was written to the RPC",
+		" * characteristic. Called on the Bluetooth RX thread.",
+		" */",
+		"void " + prefix + "_gatt_on_write(struct bt_conn *conn, const uint8_t *data, uint16_t len);",
+		"",
+		"/**",
+		" * Called when the ATT MTU of conn changes. Weak; the default does nothing.",
+		" */",
+		"void " + prefix + "_gatt_on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx);",
+		"",
+		"/**",
+		" * Called when the Central enables or disables notifications. Weak; the",
+		" * default does nothing.",
+		" */",
+		"void " + prefix + "_gatt_on_notify_changed(bool enabled);",
+		"",
+		"/**",
+		" * Whether the Central has enabled notifications on the RPC characteristic.",
+		" */",
+		"bool " + prefix + "_gatt_notify_enabled(void);",
+		"",
+		"/**",
+		" * Get the ATT MTU of conn, or " + up + "_GATT_DEFAULT_MTU when conn is NULL.",
+		" */",
+		"uint16_t " + prefix + "_gatt_get_mtu(struct bt_conn *conn);",
+		"",
+		"/**",
+		" * Ask the Central for the largest ATT MTU the stack supports.",
+		" * Needs CONFIG_BT_GATT_CLIENT; returns -ENOTSUP without it.",
+		" */",
+		"int " + prefix + "_gatt_exchange_mtu(struct bt_conn *conn);",
+		"",
+		"/**",
+		" * Send a notification on the RPC characteristic.",
+		" * @return 0 on success, negative on error",
+		" */",
+		"int " + prefix + "_gatt_notify(struct bt_conn *conn, const uint8_t *data, size_t len);",
+		"",
+	}
+	if len(gctx.GATTGroups) > 0 {
+		// The group hooks take the service enum of the handlers header.
+		lines = slices.Insert(lines, slices.Index(lines, "#include <zephyr/bluetooth/gatt.h>")+1, `#include "generated_handlers.h"`)
+	}
+	var b strings.Builder
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+	writeGattGroupServiceDecl(gctx, &b, pkg)
+	for _, l := range []string{"#ifdef __cplusplus", "}", "#endif", "", "#endif /* " + guard + " */"} {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func GenerateGattServiceSource(gctx *gen.Context, pkg string) string {
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	up := strings.ToUpper(prefix)
+	var b strings.Builder
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_gatt_service.h\"\n")
+	b.WriteString("#include <errno.h>\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("static struct bt_uuid_128 rpc_svc_uuid = BT_UUID_INIT_128(%s_SERVICE_UUID);\n", up))
+	b.WriteString(fmt.Sprintf("static struct bt_uuid_128 rpc_char_uuid = BT_UUID_INIT_128(%s_CHAR_UUID);\n", up))
+	b.WriteByte('\n')
+	b.WriteString("static bool notify_enabled;\n")
+	b.WriteByte('\n')
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("void %s_gatt_on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    (void)conn;\n")
+	b.WriteString("    (void)tx;\n")
+	b.WriteString("    (void)rx;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("void %s_gatt_on_notify_changed(bool enabled)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    (void)enabled;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, const void *buf,\n")
+	b.WriteString("                        uint16_t len, uint16_t offset, uint8_t flags)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)attr;\n")
+	b.WriteString("    (void)offset;\n")
+	b.WriteString("    (void)flags;\n")
+	b.WriteString(fmt.Sprintf("    %s_gatt_on_write(conn, buf, len);\n", prefix))
+	b.WriteString("    return len;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static void on_ccc_changed(const struct bt_gatt_attr *attr, uint16_t value)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)attr;\n")
+	b.WriteString("    notify_enabled = value == BT_GATT_CCC_NOTIFY;\n")
+	b.WriteString(fmt.Sprintf("    %s_gatt_on_notify_changed(notify_enabled);\n", prefix))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/* Attributes: primary service, characteristic declaration, value, CCC */\n")
+	b.WriteString(fmt.Sprintf("BT_GATT_SERVICE_DEFINE(%s_svc, BT_GATT_PRIMARY_SERVICE(&rpc_svc_uuid),\n", prefix))
+	pad := strings.Repeat(" ", len("BT_GATT_SERVICE_DEFINE("))
+	b.WriteString(fmt.Sprintf("%sBT_GATT_CHARACTERISTIC(&rpc_char_uuid.uuid,\n", pad))
+	b.WriteString(fmt.Sprintf("%s                       BT_GATT_CHRC_WRITE_WITHOUT_RESP | BT_GATT_CHRC_NOTIFY,\n", pad))
+	b.WriteString(fmt.Sprintf("%s                       BT_GATT_PERM_WRITE, NULL, on_write, NULL),\n", pad))
+	b.WriteString(fmt.Sprintf("%sBT_GATT_CCC(on_ccc_changed, BT_GATT_PERM_READ | BT_GATT_PERM_WRITE), );\n", pad))
+	b.WriteByte('\n')
+
+	b.WriteString("static void on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx)\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s_gatt_on_mtu_updated(conn, tx, rx);\n", prefix))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static struct bt_gatt_cb gatt_callbacks = {\n")
+	b.WriteString("    .att_mtu_updated = on_mtu_updated,\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("void %s_gatt_init(void)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    bt_gatt_cb_register(&gatt_callbacks);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("bool %s_gatt_notify_enabled(void)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    return notify_enabled;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("uint16_t %s_gatt_get_mtu(struct bt_conn *conn)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    if (!conn) {\n")
+	b.WriteString(fmt.Sprintf("        return %s_GATT_DEFAULT_MTU;\n", up))
+	b.WriteString("    }\n")
+	b.WriteString("    return bt_gatt_get_mtu(conn);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("#ifdef CONFIG_BT_GATT_CLIENT\n")
+	b.WriteString("static struct bt_gatt_exchange_params mtu_exchange_params;\n")
+	b.WriteByte('\n')
+	b.WriteString("static void on_mtu_exchanged(struct bt_conn *conn, uint8_t err,\n")
+	b.WriteString("                             struct bt_gatt_exchange_params *params)\n")
+	b.WriteString("{\n")
+	b.WriteString("    /* The new MTU is reported through att_mtu_updated */\n")
+	b.WriteString("    (void)conn;\n")
+	b.WriteString("    (void)err;\n")
+	b.WriteString("    (void)params;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("int %s_gatt_exchange_mtu(struct bt_conn *conn)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    mtu_exchange_params.func = on_mtu_exchanged;\n")
+	b.WriteString("    return bt_gatt_exchange_mtu(conn, &mtu_exchange_params);\n")
+	b.WriteString("}\n")
+	b.WriteString("#else\n")
+	b.WriteString(fmt.Sprintf("int %s_gatt_exchange_mtu(struct bt_conn *conn)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    (void)conn;\n")
+	b.WriteString("    return -ENOTSUP;\n")
+	b.WriteString("}\n")
+	b.WriteString("#endif /* CONFIG_BT_GATT_CLIENT */\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("int %s_gatt_notify(struct bt_conn *conn, const uint8_t *data, size_t len)\n", prefix))
+	b.WriteString("{\n")
+	b.WriteString("    if (!conn) {\n")
+	b.WriteString("        return -ENOTCONN;\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    struct bt_gatt_notify_params params = {\n")
+	b.WriteString(fmt.Sprintf("        .attr = &%s_svc.attrs[2],\n", prefix))
+	b.WriteString("        .data = data,\n")
+	b.WriteString("        .len = len,\n")
+	b.WriteString("    };\n")
+	b.WriteByte('\n')
+	b.WriteString("    return bt_gatt_notify_cb(conn, &params);\n")
+	b.WriteString("}\n")
+	writeGattGroupServices(gctx, &b, pkg)
+	return b.String()
+}
@@ -0,0 +1,161 @@
+package c
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// With -platform esp-idf the GATT service targets the NimBLE host of
+// ESP-IDF. Unlike Zephyr, where ble_service.c assembles the containers, the
+// generated source also carries the glue from characteristic writes to
+// handlers_lookup: container assembly, the TIMEOUT and CAPABILITIES
+// control commands, and the two-pass nanopb encoding of the response, so the
+// handlers of generated_handlers.c run unchanged on both platforms.
+// Encryption is not supported on this platform yet.
+
+// nimbleUUIDInit renders a UUID as a NimBLE BLE_UUID128_INIT invocation,
+// whose bytes are little-endian.
+func nimbleUUIDInit(uuid string) string {
+	hex := strings.ReplaceAll(uuid, "-", "")
+	bytes := make([]string, 16)
+	for i := range 16 {
+		bytes[15-i] = "0x" + hex[2*i:2*i+2]
+	}
+	return "BLE_UUID128_INIT(" + strings.Join(bytes, ", ") + ")"
+}
+
+func GenerateNimBLEGattServiceHeader(gctx *gen.Context, pkg string) string {
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	up := strings.ToUpper(prefix)
+	guard := up + "_GENERATED_GATT_SERVICE_H"
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+		"#include <stddef.h>",
+		"#include <stdint.h>",
+		"#include \"host/ble_gap.h\"",
+		"#include \"host/ble_uuid.h\"",
+		"",
+		"#ifdef __cplusplus",
+		`extern "C" {`,
+		"#endif",
+		"",
+		"/* " + prefix + " Service UUID: " + gctx.ServiceUUID + " */",
+		"#define " + up + "_SERVICE_UUID " + nimbleUUIDInit(gctx.ServiceUUID),
+		"",
+		"/* " + prefix + " Characteristic UUID: " + gctx.CharUUID + " */",
+		"#define " + up + "_CHAR_UUID " + nimbleUUIDInit(gctx.CharUUID),
+		"",
+		"/* Settings shared with the Zephyr Kconfig options; override with -D. */",
+		"#ifndef CONFIG_BLERPC_TIMEOUT_MS",
+		"#define CONFIG_BLERPC_TIMEOUT_MS 100",
+		"#endif",
+		"#ifndef CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE",
+		"#define CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE 65535",
+		"#endif",
+		"#ifndef CONFIG_BLERPC_WORK_STACK_SIZE",
+		"#define CONFIG_BLERPC_WORK_STACK_SIZE 4096",
+		"#endif",
+		"#ifndef CONFIG_BLERPC_PREFERRED_MTU",
+		"#define CONFIG_BLERPC_PREFERRED_MTU 247",
+		"#endif",
+		"",
+		"/**",
+		" * Register the RPC service and start the request task.",
+		" * Call after nimble_port_init() and before starting the host task.",
+		" * @return 0 on success, non-zero on error",
+		" */",
+		"int " + prefix + "_gatt_init(void);",
+		"",
+		"/**",
+		" * Feed every GAP event of the application's event handler, so the",
+		" * service tracks the connection and its MTU.",
+		" */",
+		"void " + prefix + "_gatt_on_gap_event(const struct ble_gap_event *event);",
+		"",
+		"/**",
+		" * Called when the ATT MTU of the connection changes. Weak; the default",
+		" * does nothing.",
+		" */",
+		"void " + prefix + "_gatt_on_mtu_updated(uint16_t conn_handle, uint16_t mtu);",
+		"",
+	}
+	if gctx.CHandlerCtx {
+		lines = append(lines,
+			"/**",
+			" * Context passed to the handlers of requests from conn_handle. Weak;",
+			" * the default returns NULL.",
+			" */",
+			"void *"+prefix+"_gatt_handler_ctx(uint16_t conn_handle);",
+			"",
+		)
+	}
+	rest := []string{
+		"/**",
+		" * Get the current connection's MTU.",
+		" */",
+		"uint16_t " + prefix + "_gatt_get_mtu(void);",
+		"",
+		"/**",
+		" * Ask the Central for CONFIG_BLERPC_PREFERRED_MTU.",
+		" * @return 0 on success, non-zero on error",
+		" */",
+		"int " + prefix + "_gatt_exchange_mtu(void);",
+		"",
+		"/**",
+		" * Send a notification to the connected Central.",
+		" * @return 0 on success, negative on error",
+		" */",
+		"int " + prefix + "_gatt_notify(const uint8_t *data, size_t len);",
+		"",
+		"/**",
+		" * Send a STREAM_END_P2C control container.",
+		" * @return 0 on success, negative on error",
+		" */",
+		"int " + prefix + "_gatt_send_stream_end_p2c(uint8_t transaction_id);",
+		"",
+		"/**",
+		" * Callback type for stream end notification (C->P).",
+		" * Called when STREAM_END_C2P is received from Central.",
+		" */",
+		"typedef void (*" + prefix + "_gatt_stream_end_cb_t)(uint8_t transaction_id);",
+		"",
+		"/**",
+		" * Register a callback for STREAM_END_C2P reception.",
+		" */",
+		"void " + prefix + "_gatt_set_stream_end_cb(" + prefix + "_gatt_stream_end_cb_t cb);",
+		"",
+		"/**",
+		" * Get the next transaction ID (incrementing counter).",
+		" */",
+		"uint8_t " + prefix + "_gatt_next_transaction_id(void);",
+		"",
+		"/**",
+		" * Split a serialized command payload into containers and send them.",
+		" * @return 0 on success, negative on error",
+		" */",
+		"int " + prefix + "_gatt_send_command_response(uint8_t transaction_id, const uint8_t *cmd_data,",
+		strings.Repeat(" ", len("int "+prefix+"_gatt_send_command_response(")) + "size_t cmd_len);",
+		"",
+		"#ifdef __cplusplus",
+		"}",
+		"#endif",
+		"",
+		"#endif /* " + guard + " */",
+	}
+	return strings.Join(append(lines, rest...), "\n") + "\n"
+}
+
+func GenerateNimBLEGattServiceSource(gctx *gen.Context, pkg string) string {
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	up := strings.ToUpper(prefix)
+	return gctx.RenderTemplate("nimble_gatt_service.c.tmpl", struct {
+		Prefix, Upper, Pad, CPrefix string
+		Ctx                         bool
+	}{
+		prefix, up, strings.Repeat(" ", len("int "+prefix+"_gatt_send_command_response(")), gctx.Names.CPrefix, gctx.CHandlerCtx,
+	})
+}
@@ -0,0 +1,92 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestGenerateGattServiceHeader(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateGattServiceHeader(gctx, "acme.sensor")
+	for _, s := range []string{
+		"#ifndef ACME_SENSOR_GENERATED_GATT_SERVICE_H",
+		"#define ACME_SENSOR_SERVICE_UUID BT_UUID_128_ENCODE(0x12340001, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)",
+		"#define ACME_SENSOR_CHAR_UUID BT_UUID_128_ENCODE(0x12340002, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)",
+		"void acme_sensor_gatt_on_write(struct bt_conn *conn, const uint8_t *data, uint16_t len);",
+		"void acme_sensor_gatt_on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx);",
+		"int acme_sensor_gatt_exchange_mtu(struct bt_conn *conn);",
+		"int acme_sensor_gatt_notify(struct bt_conn *conn, const uint8_t *data, size_t len);",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("header missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateGattServiceSource(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateGattServiceSource(gctx, "blerpc")
+	for _, s := range []string{
+		"static struct bt_uuid_128 rpc_svc_uuid = BT_UUID_INIT_128(BLERPC_SERVICE_UUID);",
+		"__attribute__((weak))\nvoid blerpc_gatt_on_mtu_updated(",
+		"    blerpc_gatt_on_write(conn, buf, len);\n    return len;\n",
+		"BT_GATT_SERVICE_DEFINE(blerpc_svc, BT_GATT_PRIMARY_SERVICE(&rpc_svc_uuid),\n" +
+			"                       BT_GATT_CHARACTERISTIC(&rpc_char_uuid.uuid,",
+		"BT_GATT_CCC(on_ccc_changed, BT_GATT_PERM_READ | BT_GATT_PERM_WRITE), );",
+		"    .att_mtu_updated = on_mtu_updated,",
+		"#ifdef CONFIG_BT_GATT_CLIENT",
+		"    return -ENOTSUP;",
+		// Attribute 2 is the characteristic value.
+		"        .attr = &blerpc_svc.attrs[2],",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("source missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestNimbleUUIDInit(t *testing.T) {
+	got := nimbleUUIDInit("12340001-0000-1000-8000-00805f9b34fb")
+	want := "BLE_UUID128_INIT(0xfb, 0x34, 0x9b, 0x5f, 0x80, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00, 0x00, 0x01, 0x00, 0x34, 0x12)"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestGenerateNimBLEGattService(t *testing.T) {
+	gctx := gen.NewContext()
+	header := GenerateNimBLEGattServiceHeader(gctx, "blerpc")
+	for _, s := range []string{
+		"#include \"host/ble_gap.h\"",
+		"#define BLERPC_SERVICE_UUID BLE_UUID128_INIT(0xfb, ",
+		"#ifndef CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE",
+		"void blerpc_gatt_on_gap_event(const struct ble_gap_event *event);",
+		"int blerpc_gatt_send_command_response(uint8_t transaction_id, const uint8_t *cmd_data,\n" +
+			"                                      size_t cmd_len);",
+	} {
+		if !strings.Contains(header, s) {
+			t.Errorf("header missing %q\nGot:\n%s", s, header)
+		}
+	}
+
+	source := GenerateNimBLEGattServiceSource(gctx, "blerpc")
+	for _, s := range []string{
+		"#include \"generated_handlers.h\"",
+		"static const ble_uuid128_t rpc_svc_uuid = BLERPC_SERVICE_UUID;",
+		"    command_handler_fn handler = handlers_lookup(cmd.cmd_name, cmd.cmd_name_len);",
+		"    if (handler_rc == HANDLER_BUSY) {",
+		"                    .flags = BLE_GATT_CHR_F_WRITE_NO_RSP | BLE_GATT_CHR_F_NOTIFY,",
+		"    int rc = ble_gatts_notify_custom(conn_handle, rpc_val_handle, om);",
+		"    case BLE_GAP_EVENT_MTU:\n        blerpc_gatt_on_mtu_updated(event->mtu.conn_handle, event->mtu.value);",
+		"    if (xTaskCreate(request_task, \"blerpc_req\", CONFIG_BLERPC_WORK_STACK_SIZE, NULL, 5,",
+	} {
+		if !strings.Contains(source, s) {
+			t.Errorf("source missing %q\nGot:\n%s", s, source)
+		}
+	}
+	if strings.Contains(source, "@@") {
+		t.Error("unreplaced placeholder in source")
+	}
+}
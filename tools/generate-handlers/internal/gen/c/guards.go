@@ -0,0 +1,122 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// hasRateLimiting reports whether handlers_lookup rejects calls over a rate,
+// per command or in the token bucket.
+func hasRateLimiting(gctx *gen.Context, commands []gen.Command) bool {
+	return hasRateLimits(commands) || gctx.CGuards.RateLimit != nil
+}
+
+// sizeGuarded reports whether the handler table points at the size guard of
+// cmd.
+func sizeGuarded(gctx *gen.Context, cmd gen.Command) bool {
+	return gctx.CGuards.MaxRequestSize && cmd.MaxRequestSize != gen.UnboundedSize
+}
+
+// writeCTokenBucketDecl emits the rate and burst of the token bucket, which a
+// build may override.
+func writeCTokenBucketDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if gctx.CGuards.RateLimit == nil {
+		return
+	}
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Calls per second the token bucket of all commands refills with, and the\n")
+	b.WriteString(" * calls it holds; define them to override blerpc.yaml. */\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_RATE_LIMIT_PER_SECOND\n", upper))
+	b.WriteString(fmt.Sprintf("#define %s_RATE_LIMIT_PER_SECOND %d\n", upper, gctx.CGuards.RateLimit.PerSecond))
+	b.WriteString("#endif\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_RATE_LIMIT_BURST\n", upper))
+	b.WriteString(fmt.Sprintf("#define %s_RATE_LIMIT_BURST %d\n", upper, gctx.CGuards.RateLimit.Burst))
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+}
+
+// writeCTokenBucket emits the token bucket handlers_lookup takes a token of
+// for every call. Tokens are counted in thousandths, so that low rates refill
+// a little every millisecond.
+func writeCTokenBucket(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if gctx.CGuards.RateLimit == nil {
+		return
+	}
+	upper := strings.ToUpper(pkg)
+	b.WriteString(fmt.Sprintf("#define BUCKET_FULL ((uint32_t)%s_RATE_LIMIT_BURST * 1000u)\n", upper))
+	b.WriteByte('\n')
+	b.WriteString("static uint32_t bucket_tokens = BUCKET_FULL;\n")
+	b.WriteString("static uint32_t bucket_ms;\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Refills the token bucket for the time since the last call and takes a\n")
+	b.WriteString(" * token from it, reporting whether there was one. */\n")
+	b.WriteString("static int bucket_take(void)\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    uint32_t now = %s_rate_limit_now_ms();\n", pkg))
+	b.WriteString(fmt.Sprintf("    uint64_t tokens = bucket_tokens + (uint64_t)(now - bucket_ms) * %s_RATE_LIMIT_PER_SECOND;\n", upper))
+	b.WriteString("    bucket_ms = now;\n")
+	b.WriteString("    if (tokens > BUCKET_FULL) tokens = BUCKET_FULL;\n")
+	b.WriteString("    if (tokens < 1000) {\n")
+	b.WriteString("        bucket_tokens = (uint32_t)tokens;\n")
+	b.WriteString("        return 0;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    bucket_tokens = (uint32_t)(tokens - 1000);\n")
+	b.WriteString("    return 1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCSizeGuards emits the size guards of the bounded commands, which the
+// handler table points at in place of guardedFn's function. A guard gets the
+// request as received, so it allows for the session token and the replay
+// counter leading it. outParam lists the parameters of a handler in the C
+// runtime after req_len, out names the first.
+func writeCSizeGuards(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg, outParam, out string, guardedFn func(gen.Command) string) {
+	upper := strings.ToUpper(pkg)
+	for _, g := range gen.GroupCommands(commands, "per-group", pkg) {
+		var guarded []gen.Command
+		for _, cmd := range g.Commands {
+			if sizeGuarded(gctx, cmd) {
+				guarded = append(guarded, cmd)
+			}
+		}
+		if len(guarded) == 0 {
+			continue
+		}
+		b.WriteString("#if " + cGroupMacro(pkg, g.Name) + "\n")
+		for i, cmd := range guarded {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			limit := cMaxSizeMacro(cmd, pkg, "REQ")
+			if cmd.SessionProtected {
+				limit += " + " + upper + "_SESSION_TOKEN_SIZE"
+			}
+			if cmd.ReplayProtected {
+				limit += fmt.Sprintf(" + %d", gen.ReplayPrefixSize)
+			}
+			pad := strings.Repeat(" ", len(cmd.Snake))
+			b.WriteString(fmt.Sprintf("static int sized_%s(const uint8_t *req_data, size_t req_len,\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("                  %s%s)\n", pad, outParam))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    if (req_len > %s) {\n", limit))
+			b.WriteString(fmt.Sprintf("        return %s_return_error(%s, %s_STATUS_INVALID_ARGUMENT);\n", pkg, out, upper))
+			b.WriteString("    }\n")
+			b.WriteString(fmt.Sprintf("    return %s(req_data, req_len, %s);\n", guardedFn(cmd), cHandlerOutArg(gctx, out)))
+			b.WriteString("}\n")
+		}
+		b.WriteString("#endif\n")
+		b.WriteByte('\n')
+	}
+}
+
+// cTableHandler returns the function the handler table points at for a
+// command: its size guard, then the guards of cGuardedHandler.
+func cTableHandler(gctx *gen.Context, cmd gen.Command, handlerFn func(gen.Command) string) string {
+	if sizeGuarded(gctx, cmd) {
+		return "sized_" + cmd.Snake
+	}
+	return cGuardedHandler(cmd, handlerFn)
+}
@@ -0,0 +1,106 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateSizeGuards(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.CGuards = gen.GuardsConfig{MaxRequestSize: true}
+	echo := gentest.EchoCommand()
+	echo.MaxRequestSize = 66
+	guarded := gentest.EnumCommand()
+	guarded.MaxRequestSize = 2
+	guarded.ReplayProtected = true
+	guarded.SessionProtected = true
+	unbounded := gentest.OneofCommand()
+	unbounded.MaxRequestSize = gen.UnboundedSize
+	commands := []gen.Command{echo, guarded, unbounded}
+
+	src := GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static int sized_echo(const uint8_t *req_data, size_t req_len,\n                      pb_ostream_t *ostream)",
+		"    if (req_len > BLERPC_ECHO_MAX_REQ_SIZE) {\n        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);",
+		"    return handle_echo(req_data, req_len, ostream);",
+		"    if (req_len > BLERPC_GET_STATUS_MAX_REQ_SIZE + BLERPC_SESSION_TOKEN_SIZE + 24) {",
+		"    return authed_get_status(req_data, req_len, ostream);",
+		"    {\"echo\", 4, sized_echo},",
+		"    {\"get_status\", 10, sized_get_status},",
+		"    {\"search\", 6, handle_search},",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+	if strings.Contains(src, "sized_search") {
+		t.Error("commands with an unbounded request should not be size guarded")
+	}
+
+	gctx.CHandlerCtx = true
+	pbc := GenerateSourceProtobufC(gctx, []gen.Command{echo}, "blerpc")
+	for _, want := range []string{
+		"static int sized_echo(const uint8_t *req_data, size_t req_len,\n                      ProtobufCBuffer *out, void *ctx)",
+		"        return blerpc_return_error(out, BLERPC_STATUS_INVALID_ARGUMENT);",
+		"    return handle_echo(req_data, req_len, out, ctx);",
+	} {
+		if !strings.Contains(pbc, want) {
+			t.Errorf("protobuf-c source missing %q", want)
+		}
+	}
+
+	gctx.CGuards = gen.GuardsConfig{}
+	if plain := GenerateSource(gctx, []gen.Command{echo}, nil, nil, "blerpc"); strings.Contains(plain, "sized_") {
+		t.Error("size guards should be off without guards.max_request_size")
+	}
+}
+
+func TestGenerateTokenBucket(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.CGuards = gen.GuardsConfig{RateLimit: &gen.TokenBucketConfig{PerSecond: 20, Burst: 40}}
+	commands := []gen.Command{gentest.EchoCommand()}
+
+	header := GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"uint32_t blerpc_rate_limit_now_ms(void);",
+		"void blerpc_on_rate_limited(const char *name, uint8_t name_len);",
+		"#ifndef BLERPC_RATE_LIMIT_PER_SECOND\n#define BLERPC_RATE_LIMIT_PER_SECOND 20\n#endif",
+		"#ifndef BLERPC_RATE_LIMIT_BURST\n#define BLERPC_RATE_LIMIT_BURST 40\n#endif",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static int bucket_take(void)",
+		"uint64_t tokens = bucket_tokens + (uint64_t)(now - bucket_ms) * BLERPC_RATE_LIMIT_PER_SECOND;",
+		"__attribute__((weak))\nvoid blerpc_on_rate_limited(const char *name, uint8_t name_len)",
+		"            if (!bucket_take()) {\n                blerpc_on_rate_limited(name, name_len);\n                return reject_rate_limited;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+	if strings.Contains(src, "rate_limits[]") {
+		t.Error("the bucket alone should not emit per-command limits")
+	}
+
+	gctx.CHandlerCtx = true
+	gctx.CDispatch = "binary"
+	limited := gentest.EchoCommand()
+	limited.RateLimit = 2
+	src = GenerateSource(gctx, []gen.Command{limited}, nil, nil, "blerpc")
+	for _, want := range []string{
+		"void blerpc_on_rate_limited(const char *name, uint8_t name_len, void *ctx)\n{\n    (void)name;\n    (void)name_len;\n    (void)ctx;",
+		"    if (!rate_limit_take((size_t)i) || !bucket_take()) {\n        blerpc_on_rate_limited(name, name_len, ctx);",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source with context missing %q", want)
+		}
+	}
+}
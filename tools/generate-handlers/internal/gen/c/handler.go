@@ -0,0 +1,382 @@
+// Package c generates the C targets: the peripheral handlers, GATT
+// services and build fragments, and the C central client.
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// headerData fills generated_handlers.h.tmpl. The declarations of the
+// features, such as rate limits, sessions and the built-ins, are written
+// in Go.
+type headerData struct {
+	Pkg, Guard              string
+	PbH, StringH            bool
+	HandlerFn, HandlerEntry string
+	Lookup, CtxSuffix       string
+	OutParam, TypedefPad    string
+	Decls                   string // the declarations before the handlers
+	Handlers                []handlerDecl
+	StreamDecls             string // the stream and built-in declarations
+}
+
+// handlerDecl is the declaration of the handler of a command. TypedDecl
+// holds the declarations of a typed handler in its place.
+type handlerDecl struct {
+	Name, Pad   string
+	Deprecation string
+	TypedDecl   string
+}
+
+// GenerateHeader returns generated_handlers.h: the handler table types and
+// the declaration of every handler.
+func GenerateHeader(gctx *gen.Context, commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
+	handlerFn := gctx.CSymbol("command_handler_fn")
+	pbH := HasTypedHandlers(commands) || hasBoundedBytesResponses(commands) || len(commandsWithReadHooks(commands, callbacks)) > 0 ||
+		len(commandsWithSourceHooks(commands, streaming, callbacks)) > 0
+	d := headerData{
+		Pkg:          pkg,
+		Guard:        strings.ToUpper(pkg) + "_GENERATED_HANDLERS_H",
+		PbH:          pbH,
+		StringH:      hasBoundedBytesResponses(commands),
+		HandlerFn:    handlerFn,
+		HandlerEntry: gctx.CSymbol("handler_entry"),
+		Lookup:       gctx.CSymbol("handlers_lookup"),
+		CtxSuffix:    gctx.CCtxSuffix(),
+		OutParam:     cHandlerOutParam(gctx, "pb_ostream_t *ostream"),
+		TypedefPad:   strings.Repeat(" ", len("typedef int (*"+handlerFn+")(")),
+	}
+
+	var b strings.Builder
+	writeCHandlerRejections(&b)
+	writeCHandlerCtxMacro(gctx, &b, pkg)
+	writeCStatusCodes(&b, pkg, "nanopb")
+	if hasRateLimiting(gctx, commands) {
+		writeCRateLimitDecl(gctx, &b, pkg)
+	}
+	if len(gen.PrivilegedCommands(commands)) > 0 {
+		writeCRoleDecl(gctx, &b, pkg)
+	}
+	if gen.HasReplayProtected(commands) {
+		writeCReplayDecl(&b, pkg)
+	}
+	if gen.HasCommandIDs(commands) {
+		writeCCommandIDs(&b, commands, pkg)
+	}
+	writeCGattGroupDecl(gctx, &b, pkg)
+	writeCGroupMacros(&b, commands, pkg)
+	writeCMaxSizes(&b, commands, pkg)
+	writeCBytesSetters(&b, commands, pkg)
+	writeCReadHookDecls(&b, commands, callbacks, pkg)
+	writeCSourceHookDecls(&b, commands, streaming, callbacks, pkg)
+	if gctx.CBatch {
+		writeCBatchDecl(gctx, &b, pkg)
+	}
+	if gctx.CCompression {
+		writeCCompressionDecl(gctx, &b, pkg)
+	}
+	d.Decls = b.String()
+
+	for _, cmd := range commands {
+		h := handlerDecl{Name: gctx.CHandlerName(cmd.Snake), Pad: gctx.CHandlerPad(cmd.Snake), Deprecation: Deprecation(cmd)}
+		if cmd.TypedHandler {
+			b.Reset()
+			writeCTypedHandlerDecls(gctx, &b, cmd, pkg)
+			h.TypedDecl = b.String()
+		}
+		d.Handlers = append(d.Handlers, h)
+	}
+
+	b.Reset()
+	writeCStreamDecls(&b, commands, streaming, pkg)
+	writeCBuiltinDecls(gctx, &b, commands, pkg)
+	d.StreamDecls = b.String()
+
+	return gctx.RenderTemplate("generated_handlers.h.tmpl", d)
+}
+
+// sourceData fills generated_handlers.c.tmpl.
+type sourceData struct {
+	Pkg     string
+	Support string // the size asserts, the discard callback and the stream support
+	Stubs   stubsData
+	Table   string // the handler table and handlers_lookup
+}
+
+// GenerateSource returns generated_handlers.c: the weak handler stubs and
+// the handler table.
+func GenerateSource(gctx *gen.Context, commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) string {
+	d := sourceData{Pkg: pkg, Stubs: newStubsData(gctx, commands, streaming, callbacks, pkg)}
+	var b strings.Builder
+	writeCMaxSizeAsserts(&b, commands, pkg)
+	writeCDiscardCallback(&b)
+	writeCStreamSupport(gctx, &b, commands, streaming, callbacks, pkg)
+	d.Support = b.String()
+
+	b.Reset()
+	writeCHandlerTable(gctx, &b, commands, pkg, "nanopb")
+	writeCGattGroupLookup(gctx, &b, commands, pkg)
+	d.Table = b.String()
+
+	return gctx.RenderTemplate("generated_handlers.c.tmpl", d)
+}
+
+// cDiscardCallback is the decode callback installed on FT_CALLBACK request
+// fields on the writing pass of the weak stubs, whose read hooks had the
+// data on the sizing pass (see readhooks.go).
+var cDiscardCallback = []string{
+	"/* Discard callback for FT_CALLBACK fields during decode */",
+	"static bool discard_bytes_cb(pb_istream_t *stream, const pb_field_t *field,",
+	"                             void **arg)",
+	"{",
+	"    (void)field;",
+	"    (void)arg;",
+	"    uint8_t buf[64];",
+	"    size_t left = stream->bytes_left;",
+	"    while (left > 0) {",
+	"        size_t n = left < sizeof(buf) ? left : sizeof(buf);",
+	"        if (!pb_read(stream, buf, n)) return false;",
+	"        left -= n;",
+	"    }",
+	"    return true;",
+	"}",
+	"",
+}
+
+func writeCDiscardCallback(b *strings.Builder) {
+	for _, l := range cDiscardCallback {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+}
+
+// stubsData fills handler_stubs.c.tmpl: the weak nanopb handler stubs,
+// which decode the request and reply with an empty response, or end a
+// stream without responses, until the user overrides them.
+type stubsData struct {
+	Support string // the support code of the file transfer, DFU and session built-ins
+	Stubs   []handlerStub
+}
+
+// handlerStub is the weak handler of a command. Code holds the handler of
+// a built-in or typed command in its place. The hooks are whole lines.
+type handlerStub struct {
+	Code                   string
+	Name, Pad, OutParam    string
+	Pkg, Emit              string
+	Request, Response      string
+	P2C                    bool
+	ReadHooks, SourceHooks string
+	InstallReadHooks       string
+	InstallSourceHooks     string
+}
+
+// newStubsData fills the stubs of the commands but those of client
+// streams, which are written with the stream support.
+func newStubsData(gctx *gen.Context, commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) stubsData {
+	var d stubsData
+	var b strings.Builder
+	if _, ok := gen.FirmwareUpdateCommands(commands); ok {
+		writeCDfuSupport(&b, pkg)
+	}
+	if _, ok := gen.FileTransferCommands(commands); ok {
+		writeCFileTransferSupport(&b, pkg)
+	}
+	if _, _, ok := gen.SessionCommands(commands); ok {
+		writeCSessionSupport(&b, pkg)
+	}
+	d.Support = b.String()
+	for _, cmd := range commands {
+		b.Reset()
+		if writeCBuiltinHandler(gctx, &b, cmd, pkg) {
+			d.Stubs = append(d.Stubs, handlerStub{Code: b.String()})
+			continue
+		}
+		if streaming[cmd.Snake] == "c2p" {
+			// Written with the stream support.
+			continue
+		}
+		if cmd.TypedHandler {
+			writeCTypedHandler(gctx, &b, cmd, pkg)
+			d.Stubs = append(d.Stubs, handlerStub{Code: b.String()})
+			continue
+		}
+		s := handlerStub{
+			Name:     gctx.CHandlerName(cmd.Snake),
+			Pad:      gctx.CHandlerPad(cmd.Snake),
+			OutParam: cHandlerOutParam(gctx, "pb_ostream_t *ostream"),
+			Pkg:      pkg,
+			Emit:     pkg + "_" + cmd.Snake + "_emit",
+			Request:  pkg + "_" + cmd.RequestMsg,
+			Response: pkg + "_" + cmd.ResponseMsg,
+			P2C:      streaming[cmd.Snake] == "p2c",
+		}
+		writeCReadHooks(&b, cmd, callbacks, pkg)
+		s.ReadHooks = b.String()
+		b.Reset()
+		// Read hooks for FT_CALLBACK request fields; p2c handlers run once.
+		writeCInstallReadHooks(&b, cmd, callbacks, !s.P2C)
+		s.InstallReadHooks = b.String()
+		if !s.P2C {
+			b.Reset()
+			writeCSourceHooks(&b, cmd, callbacks, pkg)
+			s.SourceHooks = b.String()
+			b.Reset()
+			writeCInstallSourceHooks(&b, cmd, callbacks)
+			s.InstallSourceHooks = b.String()
+		}
+		d.Stubs = append(d.Stubs, s)
+	}
+	return d
+}
+
+// cGroupMacro returns the macro that includes a command group in the
+// handler table.
+func cGroupMacro(pkg, group string) string {
+	return strings.ToUpper(pkg) + "_CMDS_" + strings.ToUpper(protomodel.CamelToSnake(group))
+}
+
+// writeCGroupMacros emits a default of 1 for the macro of every command
+// group (proto service). Zephyr builds set them from Kconfig.generated.
+func writeCGroupMacros(b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString("/* Command groups in the handler table; define one to 0 to leave its\n")
+	b.WriteString(" * commands out. Zephyr builds set them from Kconfig.generated. */\n")
+	for _, g := range gen.GroupCommands(commands, "per-group", pkg) {
+		macro := cGroupMacro(pkg, g.Name)
+		b.WriteString("#ifndef " + macro + "\n")
+		b.WriteString("#define " + macro + " 1\n")
+		b.WriteString("#endif\n")
+	}
+	b.WriteByte('\n')
+}
+
+// writeCHandlerTable emits the name -> handler table and handlers_lookup.
+// With the rpc_stats built-in the table points at the counting wrappers,
+// replay-protected commands go through their guards first, bounded ones
+// through their size guards with guards.max_request_size, and with rate
+// limits, the token bucket or roles handlers_lookup enforces them. With command IDs a one-byte
+// name is matched against the ID as well. -c-dispatch picks the search (see
+// dispatch.go).
+func writeCHandlerTable(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg, runtime string) {
+	handlerFn := func(cmd gen.Command) string { return cHandlerFn(gctx, cmd) }
+	if _, ok := gen.BuiltinCommand(commands, "capabilities"); ok {
+		writeCCapabilitiesTable(gctx, b, commands, pkg)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "rpc_stats"); ok {
+		writeCRPCStatsTable(gctx, b, commands, pkg)
+		handlerFn = func(cmd gen.Command) string { return "counted_" + cmd.Snake }
+	}
+	if gen.HasReplayProtected(commands) {
+		writeCReplayGuards(gctx, b, commands, pkg, handlerFn)
+	}
+	if gen.HasSessionProtected(commands) {
+		writeCSessionGuards(gctx, b, commands, pkg, func(cmd gen.Command) string {
+			if cmd.ReplayProtected {
+				return "guarded_" + cmd.Snake
+			}
+			return handlerFn(cmd)
+		})
+	}
+	out, outParam := "ostream", cHandlerOutParam(gctx, "pb_ostream_t *ostream")
+	if runtime == "protobuf-c" {
+		out, outParam = "out", cHandlerOutParam(gctx, "ProtobufCBuffer *out")
+	}
+	if gctx.CGuards.MaxRequestSize {
+		writeCSizeGuards(gctx, b, commands, pkg, outParam, out, func(cmd gen.Command) string {
+			return cGuardedHandler(cmd, handlerFn)
+		})
+	}
+	restricted := len(gen.PrivilegedCommands(commands)) > 0
+	if restricted {
+		writeCRoles(gctx, b, commands, pkg)
+	}
+	rateLimited := hasRateLimiting(gctx, commands)
+	if rateLimited {
+		writeCRateLimits(gctx, b, commands, pkg, outParam)
+	}
+	var rateCheck string
+	switch {
+	case hasRateLimits(commands) && gctx.CGuards.RateLimit != nil:
+		rateCheck = "!rate_limit_take((size_t)i) || !bucket_take()"
+	case hasRateLimits(commands):
+		rateCheck = "!rate_limit_take((size_t)i)"
+	default:
+		rateCheck = "!bucket_take()"
+	}
+	ids := gen.HasCommandIDs(commands)
+	linear := gctx.CDispatch == "linear"
+	if !linear {
+		writeCTableIndexes(gctx, b, commands, pkg)
+	}
+	if ids && linear {
+		writeCCommandIDTable(gctx, b, commands, pkg)
+	} else if ids {
+		writeCIDSlots(gctx, b, commands, pkg)
+	}
+	if gctx.CDispatch == "hash" {
+		writeCHashTable(gctx, b, commands, pkg)
+	}
+	b.WriteString("static const struct " + gctx.CSymbol("handler_entry") + " handler_table[] = {\n")
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		return fmt.Sprintf("    {\"%s\", %d, %s},\n", cmd.Wire(), len(cmd.Wire()), cTableHandler(gctx, cmd, handlerFn))
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+
+	if !linear {
+		writeCHandlerIndex(gctx, b, ids)
+		b.WriteString(gctx.CSymbol("command_handler_fn") + " " + gctx.CSymbol("handlers_lookup") + "(const char *name, uint8_t name_len" + gctx.CCtxSuffix() + ")\n")
+		b.WriteString("{\n")
+		writeCBatchLookup(gctx, b, pkg)
+		writeCCompressionLookup(gctx, b, pkg)
+		b.WriteString("    int i = handler_index(name, name_len);\n")
+		b.WriteString("    if (i < 0) return NULL;\n")
+		if restricted {
+			b.WriteString("    /* Commands above the current role look unknown. */\n")
+			b.WriteString(fmt.Sprintf("    if (roles[i] > %s_current_role(%s)) return NULL;\n", pkg, cCtxArg(gctx)))
+		}
+		if rateLimited {
+			b.WriteString(fmt.Sprintf("    if (%s) {\n", rateCheck))
+			b.WriteString(fmt.Sprintf("        %s_on_rate_limited(name, %s);\n", pkg, cHandlerOutArg(gctx, "name_len")))
+			b.WriteString("        return reject_rate_limited;\n")
+			b.WriteString("    }\n")
+		}
+		b.WriteString("    return handler_table[i].handler;\n")
+		b.WriteString("}\n")
+		return
+	}
+	b.WriteString(gctx.CSymbol("command_handler_fn") + " " + gctx.CSymbol("handlers_lookup") + "(const char *name, uint8_t name_len" + gctx.CCtxSuffix() + ")\n")
+	b.WriteString("{\n")
+	b.WriteString("    size_t i;\n")
+	writeCBatchLookup(gctx, b, pkg)
+	writeCCompressionLookup(gctx, b, pkg)
+	b.WriteString("    for (i = 0; i < sizeof(handler_table) / sizeof(handler_table[0]); i++) {\n")
+	if ids {
+		b.WriteString("        /* A one-byte name carries the command ID. */\n")
+		b.WriteString("        if ((name_len == 1 && (uint8_t)name[0] == command_ids[i]) ||\n")
+		b.WriteString("            (handler_table[i].name_len == name_len &&\n")
+		b.WriteString("             memcmp(handler_table[i].name, name, name_len) == 0)) {\n")
+	} else {
+		b.WriteString("        if (handler_table[i].name_len == name_len &&\n")
+		b.WriteString("            memcmp(handler_table[i].name, name, name_len) == 0) {\n")
+	}
+	if restricted {
+		b.WriteString("            /* Commands above the current role look unknown. */\n")
+		b.WriteString(fmt.Sprintf("            if (roles[i] > %s_current_role(%s)) return NULL;\n", pkg, cCtxArg(gctx)))
+	}
+	if rateLimited {
+		b.WriteString(fmt.Sprintf("            if (%s) {\n", strings.ReplaceAll(rateCheck, "(size_t)i", "i")))
+		b.WriteString(fmt.Sprintf("                %s_on_rate_limited(name, %s);\n", pkg, cHandlerOutArg(gctx, "name_len")))
+		b.WriteString("                return reject_rate_limited;\n")
+		b.WriteString("            }\n")
+	}
+	b.WriteString("            return handler_table[i].handler;\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return NULL;\n")
+	b.WriteString("}\n")
+}
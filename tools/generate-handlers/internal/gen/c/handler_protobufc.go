@@ -0,0 +1,156 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// protobufCLower converts a message name the way protoc-c does for function
+// names: an underscore before every inner upper-case letter, all lower-case.
+func protobufCLower(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// protobufCNames returns the protoc-c type name, function prefix and INIT
+// macro of a message in pkg.
+func protobufCNames(pkg, msg string) (typ, fn, init string) {
+	var typParts, fnParts []string
+	for _, p := range strings.Split(pkg, ".") {
+		typParts = append(typParts, strings.ToUpper(p[:1])+p[1:])
+		fnParts = append(fnParts, protobufCLower(p))
+	}
+	typ = strings.Join(typParts, "__") + "__" + msg
+	fn = strings.Join(fnParts, "__") + "__" + protobufCLower(msg)
+	init = strings.ToUpper(fn) + "__INIT"
+	return typ, fn, init
+}
+
+func GenerateHeaderProtobufC(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	guard := strings.ToUpper(pkg) + "_GENERATED_HANDLERS_H"
+	var b strings.Builder
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+		"#include <stdint.h>",
+		"#include <stddef.h>",
+		"#include <protobuf-c/protobuf-c.h>",
+		"",
+		"#ifdef __cplusplus",
+		`extern "C" {`,
+		"#endif",
+		"",
+		"typedef int (*" + gctx.CSymbol("command_handler_fn") + ")(const uint8_t *req_data, size_t req_len,",
+		strings.Repeat(" ", len("typedef int (*"+gctx.CSymbol("command_handler_fn")+")(")) + cHandlerOutParam(gctx, "ProtobufCBuffer *out") + ");",
+		"",
+		"struct " + gctx.CSymbol("handler_entry") + " {",
+		"    const char *name;",
+		"    uint8_t name_len;",
+		"    " + gctx.CSymbol("command_handler_fn") + " handler;",
+		"};",
+		"",
+		gctx.CSymbol("command_handler_fn") + " " + gctx.CSymbol("handlers_lookup") + "(const char *name, uint8_t name_len" + gctx.CCtxSuffix() + ");",
+		"",
+	}
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	writeCHandlerRejections(&b)
+	writeCHandlerCtxMacro(gctx, &b, pkg)
+	writeCStatusCodes(&b, pkg, "protobuf-c")
+	if hasRateLimiting(gctx, commands) {
+		writeCRateLimitDecl(gctx, &b, pkg)
+	}
+	if len(gen.PrivilegedCommands(commands)) > 0 {
+		writeCRoleDecl(gctx, &b, pkg)
+	}
+	if gen.HasCommandIDs(commands) {
+		writeCCommandIDs(&b, commands, pkg)
+	}
+	writeCGattGroupDecl(gctx, &b, pkg)
+	writeCGroupMacros(&b, commands, pkg)
+
+	for _, cmd := range commands {
+		pad := gctx.CHandlerPad(cmd.Snake)
+		b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+		b.WriteString(fmt.Sprintf("                %s%s);\n", pad, cHandlerOutParam(gctx, "ProtobufCBuffer *out")))
+		b.WriteByte('\n')
+	}
+
+	tail := []string{
+		"#ifdef __cplusplus",
+		"}",
+		"#endif",
+		"",
+		"#endif /* " + guard + " */",
+	}
+	for _, l := range tail {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func GenerateSourceProtobufC(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	var b strings.Builder
+
+	header := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		`#include "generated_handlers.h"`,
+		`#include "` + pkg + `.pb-c.h"`,
+		"#include <string.h>",
+		"",
+	}
+	for _, l := range header {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+
+	writeCHandlerStubsProtobufC(gctx, &b, commands, pkg)
+	writeCHandlerTable(gctx, &b, commands, pkg, "protobuf-c")
+	writeCGattGroupLookup(gctx, &b, commands, pkg)
+
+	return b.String()
+}
+
+// writeCHandlerStubsProtobufC emits the weak protobuf-c handler stubs.
+func writeCHandlerStubsProtobufC(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	for _, cmd := range commands {
+		reqType, reqFn, _ := protobufCNames(pkg, cmd.RequestMsg)
+		respType, respFn, respInit := protobufCNames(pkg, cmd.ResponseMsg)
+		pad := gctx.CHandlerPad(cmd.Snake)
+
+		b.WriteString("__attribute__((weak))\n")
+		b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+		b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "ProtobufCBuffer *out")))
+		b.WriteString("{\n")
+
+		// Decode request
+		b.WriteString(fmt.Sprintf("    %s *req = %s__unpack(NULL, req_len, req_data);\n", reqType, reqFn))
+		b.WriteString("    if (req == NULL) return -1;\n")
+		b.WriteString(fmt.Sprintf("    %s__free_unpacked(req, NULL);\n", reqFn))
+		b.WriteByte('\n')
+
+		// Encode response
+		b.WriteString(fmt.Sprintf("    %s resp = %s;\n", respType, respInit))
+		b.WriteString(fmt.Sprintf("    %s__pack_to_buffer(&resp, out);\n", respFn))
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+}
@@ -0,0 +1,70 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestProtobufCNames(t *testing.T) {
+	tests := []struct {
+		pkg, msg           string
+		typ, fn, initMacro string
+	}{
+		{"blerpc", "EchoRequest", "Blerpc__EchoRequest", "blerpc__echo_request", "BLERPC__ECHO_REQUEST__INIT"},
+		{"acme.sensor", "ReadADC", "Acme__Sensor__ReadADC", "acme__sensor__read_a_d_c", "ACME__SENSOR__READ_A_D_C__INIT"},
+	}
+	for _, tt := range tests {
+		typ, fn, init := protobufCNames(tt.pkg, tt.msg)
+		if typ != tt.typ || fn != tt.fn || init != tt.initMacro {
+			t.Errorf("protobufCNames(%q, %q) = %q, %q, %q", tt.pkg, tt.msg, typ, fn, init)
+		}
+	}
+}
+
+func TestGenerateCHeaderProtobufC(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateHeaderProtobufC(gctx, []gen.Command{gentest.EchoCommand()}, "blerpc")
+
+	mustContain := []string{
+		"#include <protobuf-c/protobuf-c.h>",
+		"typedef int (*command_handler_fn)(const uint8_t *req_data, size_t req_len,\n                                  ProtobufCBuffer *out);",
+		"int handle_echo(const uint8_t *req_data, size_t req_len,\n                    ProtobufCBuffer *out);",
+		"command_handler_fn handlers_lookup(const char *name, uint8_t name_len);",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("header missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "pb_") {
+		t.Error("protobuf-c header should not reference nanopb")
+	}
+}
+
+func TestGenerateCSourceProtobufC(t *testing.T) {
+	gctx := gen.NewContext()
+	wired := gentest.CallbackCommand()
+	wired.WireName = "dw"
+	out := GenerateSourceProtobufC(gctx, []gen.Command{gentest.EchoCommand(), wired}, "blerpc")
+
+	mustContain := []string{
+		"#include \"blerpc.pb-c.h\"",
+		"__attribute__((weak))\nint handle_echo(const uint8_t *req_data, size_t req_len,\n                    ProtobufCBuffer *out)",
+		"    Blerpc__EchoRequest *req = blerpc__echo_request__unpack(NULL, req_len, req_data);\n    if (req == NULL) return -1;\n",
+		"    blerpc__echo_request__free_unpacked(req, NULL);\n",
+		"    Blerpc__EchoResponse resp = BLERPC__ECHO_RESPONSE__INIT;\n    blerpc__echo_response__pack_to_buffer(&resp, out);\n",
+		"    {\"echo\", 4, handle_echo},",
+		"    {\"dw\", 2, handle_data_write},",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("source missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "discard_bytes_cb") || strings.Contains(out, "pb_decode") {
+		t.Error("protobuf-c source should not reference nanopb")
+	}
+}
@@ -0,0 +1,139 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateCHeader_Echo(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateHeader(gctx, cmds, nil, nil, "blerpc")
+
+	mustContain := []string{
+		"#ifndef BLERPC_GENERATED_HANDLERS_H",
+		"int handle_echo(const uint8_t *req_data, size_t req_len,",
+		"pb_ostream_t *ostream);",
+		"handlers_lookup",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C header missing %q", s)
+		}
+	}
+}
+
+func TestGenerateCHeader_CustomPkg(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateHeader(gctx, cmds, nil, nil, "myapp")
+
+	mustContain := []string{
+		"#ifndef MYAPP_GENERATED_HANDLERS_H",
+		"#define MYAPP_GENERATED_HANDLERS_H",
+		"MYAPP_GENERATED_HANDLERS_H",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C header custom pkg missing %q\nGot:\n%s", s, out)
+		}
+	}
+	mustNotContain := []string{
+		"BLERPC_",
+		"blerpc.pb.h",
+	}
+	for _, s := range mustNotContain {
+		if strings.Contains(out, s) {
+			t.Errorf("C header custom pkg should not contain %q", s)
+		}
+	}
+}
+
+func TestGenerateCHeader_MultipleCommands(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.EnumCommand()}
+	out := GenerateHeader(gctx, cmds, nil, nil, "blerpc")
+
+	mustContain := []string{
+		"int handle_echo(",
+		"int handle_get_status(",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C header multiple commands missing %q", s)
+		}
+	}
+}
+
+func TestGenerateCSource_Echo(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateSource(gctx, cmds, nil, nil, "blerpc")
+
+	mustContain := []string{
+		"__attribute__((weak))",
+		"int handle_echo(",
+		"blerpc_EchoRequest req = blerpc_EchoRequest_init_zero;",
+		"blerpc_EchoResponse resp = blerpc_EchoResponse_init_zero;",
+		`{"echo", 4, handle_echo}`,
+		"handlers_lookup",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C source missing %q", s)
+		}
+	}
+}
+
+func TestGenerateCSource_Callback(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.CallbackCommand()}
+	callbacks := map[string]bool{
+		"DataWriteRequest.data": true,
+	}
+	out := GenerateSource(gctx, cmds, nil, callbacks, "blerpc")
+
+	mustContain := []string{
+		"req.data.funcs.decode = discard_bytes_cb;",
+		"handle_data_write",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C source callback missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateCSource_CustomPkg(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateSource(gctx, cmds, nil, nil, "myapp")
+
+	mustContain := []string{
+		"myapp.pb.h",
+		"myapp_EchoRequest req = myapp_EchoRequest_init_zero;",
+		"myapp_EchoResponse resp = myapp_EchoResponse_init_zero;",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C source custom pkg missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "blerpc_") {
+		t.Error("C source custom pkg should not contain 'blerpc_'")
+	}
+}
+
+func TestGenerateCSource_WireName(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.WireName = "ec"
+	out := GenerateSource(gctx, []gen.Command{cmd}, nil, nil, "blerpc")
+
+	if !strings.Contains(out, `{"ec", 2, handle_echo}`) {
+		t.Errorf("handler table should use the wire name\nGot:\n%s", out)
+	}
+}
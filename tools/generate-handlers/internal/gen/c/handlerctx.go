@@ -0,0 +1,53 @@
+package c
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// cHandlerOutParam returns the last parameter of a C handler, out, followed
+// by the context parameter when enabled.
+func cHandlerOutParam(gctx *gen.Context, out string) string {
+	if gctx.CHandlerCtx {
+		return out + ", void *ctx"
+	}
+	return out
+}
+
+// cHandlerOutArg returns the last argument of a call to a C handler, out,
+// followed by the context when enabled.
+func cHandlerOutArg(gctx *gen.Context, out string) string {
+	if gctx.CHandlerCtx {
+		return out + ", ctx"
+	}
+	return out
+}
+
+// cCtxParam returns the parameter list of a hook that receives the context:
+// void *ctx when enabled, else void.
+func cCtxParam(gctx *gen.Context) string {
+	if gctx.CHandlerCtx {
+		return "void *ctx"
+	}
+	return "void"
+}
+
+// cCtxArg returns the argument list of a call to such a hook.
+func cCtxArg(gctx *gen.Context) string {
+	if gctx.CHandlerCtx {
+		return "ctx"
+	}
+	return ""
+}
+
+// writeCHandlerCtxMacro emits <PKG>_HANDLER_CTX when the context is enabled,
+// so a dispatcher shared by firmware builds with and without it can tell.
+func writeCHandlerCtxMacro(gctx *gen.Context, b *strings.Builder, pkg string) {
+	if !gctx.CHandlerCtx {
+		return
+	}
+	b.WriteString("/* Handlers and " + gctx.CSymbol("handlers_lookup") + " take a trailing void *ctx. */\n")
+	b.WriteString("#define " + strings.ToUpper(pkg) + "_HANDLER_CTX 1\n")
+	b.WriteByte('\n')
+}
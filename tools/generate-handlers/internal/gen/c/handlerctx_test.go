@@ -0,0 +1,96 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateHandlerCtx(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.CHandlerCtx = true
+	limited := gentest.EchoCommand()
+	limited.RateLimit = 2
+	limited.Role = "installer"
+	guarded := gentest.EnumCommand()
+	guarded.ReplayProtected = true
+	stats := gentest.EchoCommand()
+	stats.Snake = "get_rpc_stats"
+	stats.Builtin = "rpc_stats"
+	stats.RequestMsg = "GetRpcStatsRequest"
+	stats.ResponseMsg = "GetRpcStatsResponse"
+	commands := []gen.Command{limited, guarded, stats}
+
+	header := GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_HANDLER_CTX 1",
+		"typedef int (*command_handler_fn)(const uint8_t *req_data, size_t req_len,\n                                  pb_ostream_t *ostream, void *ctx);",
+		"command_handler_fn handlers_lookup(const char *name, uint8_t name_len, void *ctx);",
+		"enum blerpc_role blerpc_current_role(void *ctx);",
+		"int handle_echo(const uint8_t *req_data, size_t req_len,\n                    pb_ostream_t *ostream, void *ctx);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"int handle_echo(const uint8_t *req_data, size_t req_len,\n                    pb_ostream_t *ostream, void *ctx)\n{",
+		"    int rc = handler(req_data, req_len, ostream, ctx);",
+		"                       req_data, req_len, ostream, ctx);",
+		"    return counted_get_status(req_data + 24, req_len - 24, ostream, ctx);",
+		"    (void)ostream;\n    (void)ctx;\n    return HANDLER_BUSY;",
+		"command_handler_fn handlers_lookup(const char *name, uint8_t name_len, void *ctx)",
+		"if (roles[i] > blerpc_current_role(ctx)) return NULL;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	pbc := GenerateHeaderProtobufC(gctx, []gen.Command{gentest.EchoCommand()}, "blerpc")
+	if !strings.Contains(pbc, "ProtobufCBuffer *out, void *ctx);") {
+		t.Error("protobuf-c header missing the ctx parameter")
+	}
+
+	typed := gentest.EchoCommand()
+	typed.TypedHandler = true
+	src = GenerateSource(gctx, []gen.Command{typed}, nil, nil, "blerpc")
+	for _, want := range []string{
+		"int handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp, void *ctx)",
+		"    int rc = handle_echo(&req, &resp, ctx);",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("typed source missing %q", want)
+		}
+	}
+
+	nimble := GenerateNimBLEGattServiceSource(gctx, "blerpc")
+	for _, want := range []string{
+		"void *blerpc_gatt_handler_ctx(uint16_t conn_handle)",
+		"    void *ctx = blerpc_gatt_handler_ctx(conn_handle);\n\n    command_handler_fn handler = handlers_lookup(cmd.cmd_name, cmd.cmd_name_len, ctx);",
+		"    int handler_rc = handler(cmd.data, cmd.data_len, &sizing, ctx);",
+		"    if (handler(cmd.data, cmd.data_len, &ostream, ctx) != 0) {",
+	} {
+		if !strings.Contains(nimble, want) {
+			t.Errorf("NimBLE source missing %q", want)
+		}
+	}
+	if !strings.Contains(GenerateNimBLEGattServiceHeader(gctx, "blerpc"), "void *blerpc_gatt_handler_ctx(uint16_t conn_handle);") {
+		t.Error("NimBLE header missing the ctx hook")
+	}
+}
+
+func TestGenerateHandlerCtx_Off(t *testing.T) {
+	gctx := gen.NewContext()
+	header := GenerateHeader(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc")
+	if strings.Contains(header, "ctx") {
+		t.Error("header should not mention ctx without -c-handler-ctx")
+	}
+	if strings.Contains(GenerateNimBLEGattServiceSource(gctx, "blerpc"), "handler_ctx") {
+		t.Error("NimBLE source should not ask for a ctx without -c-handler-ctx")
+	}
+}
@@ -0,0 +1,131 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// cNullableField reports whether the C client takes a request field as a
+// pointer that may be NULL. Static bytes arrays keep their parameter.
+func cNullableField(f gen.Field) bool {
+	return gen.NullableField(f) && f.Type != "bytes"
+}
+
+// writeCSetRequestField emits the statements copying the C client parameter
+// of a request field into req, setting the has_ flag of fields with one.
+// Nullable fields are set, and flagged, only when the pointer is not NULL;
+// bounded bytes fields are checked against their array.
+func writeCSetRequestField(b *strings.Builder, f gen.Field) {
+	if gen.BoundedBytes(f) {
+		writeCSetBoundedBytes(b, f)
+		return
+	}
+	value, indent := f.Name, "    "
+	if cNullableField(f) {
+		if f.Type != "string" {
+			value = "*" + f.Name
+		}
+		indent = "        "
+		b.WriteString(fmt.Sprintf("    if (%s != NULL) {\n", f.Name))
+	}
+	if f.Type == "string" {
+		b.WriteString(fmt.Sprintf("%sstrncpy(req.%s, %s, sizeof(req.%s) - 1);\n", indent, f.Name, value, f.Name))
+	} else {
+		b.WriteString(fmt.Sprintf("%sreq.%s = %s;\n", indent, f.Name, value))
+	}
+	if cHasFlag(f) {
+		b.WriteString(fmt.Sprintf("%sreq.has_%s = true;\n", indent, f.Name))
+	}
+	if cNullableField(f) {
+		b.WriteString("    }\n")
+	}
+}
+
+// cHasFlag reports whether nanopb emits a has_ flag for a request field:
+// explicit optional fields and singular submessages outside a oneof.
+func cHasFlag(f gen.Field) bool {
+	if f.IsOptional {
+		return true
+	}
+	return f.IsMessage && !f.IsRepeated && !f.IsMap && !f.IsRequired && f.Oneof == ""
+}
+
+// cParamStr formats a C type and parameter name, handling pointer types.
+func cParamStr(cType, name string) string {
+	if strings.HasSuffix(cType, "*") {
+		return cType + name
+	}
+	return cType + " " + name
+}
+
+// cRequestParamType returns the C client parameter type of a request field:
+// nullable fields are passed by pointer.
+func cRequestParamType(f gen.Field, pkg string) string {
+	cType := ResolveType(f, pkg)
+	if cNullableField(f) && !strings.HasSuffix(cType, "*") {
+		return "const " + cType + " *"
+	}
+	return cType
+}
+
+// cClientParams builds the parameter list for a C client function.
+func cClientParams(cmd gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) []string {
+	dir, isStreaming := streaming[cmd.Snake]
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+
+	if isStreaming && dir == "c2p" {
+		return []string{
+			fmt.Sprintf("const %s *messages", reqMsg),
+			"size_t msg_count",
+			fmt.Sprintf("%s *resp", respMsg),
+		}
+	}
+
+	var params []string
+
+	for _, f := range cmd.RequestFields {
+		key := cmd.RequestMsg + "." + f.Name
+		if callbacks[key] {
+			params = append(params, fmt.Sprintf("const uint8_t *%s", f.Name))
+			params = append(params, fmt.Sprintf("size_t %s_len", f.Name))
+		} else if gen.BoundedBytes(f) {
+			params = append(params, fmt.Sprintf("const uint8_t *%s", f.Name), fmt.Sprintf("size_t %s_len", f.Name))
+		} else {
+			params = append(params, cParamStr(cRequestParamType(f, pkg), f.Name))
+		}
+	}
+
+	hasCbReq := false
+	for _, f := range cmd.RequestFields {
+		if callbacks[cmd.RequestMsg+"."+f.Name] {
+			hasCbReq = true
+			break
+		}
+	}
+	if hasCbReq {
+		params = append(params, "uint8_t *work_buf", "size_t work_buf_size")
+	}
+
+	if isStreaming && dir == "p2c" {
+		params = append(params,
+			fmt.Sprintf("%s *results", respMsg),
+			"size_t max_results",
+			"size_t *result_count",
+		)
+	} else {
+		params = append(params, fmt.Sprintf("%s *resp", respMsg))
+		for _, f := range cmd.ResponseFields {
+			key := cmd.ResponseMsg + "." + f.Name
+			if callbacks[key] {
+				params = append(params, fmt.Sprintf("uint8_t *%s_buf", f.Name))
+				params = append(params, fmt.Sprintf("size_t %s_buf_size", f.Name))
+				params = append(params, fmt.Sprintf("size_t *%s_len", f.Name))
+			}
+		}
+	}
+
+	return params
+}
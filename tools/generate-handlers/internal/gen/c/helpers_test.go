@@ -0,0 +1,25 @@
+package c
+
+import (
+	"testing"
+)
+
+func TestCParamStr(t *testing.T) {
+	tests := []struct {
+		cType string
+		name  string
+		want  string
+	}{
+		{"uint32_t", "count", "uint32_t count"},
+		{"const char *", "name", "const char *name"},
+		{"const uint8_t *", "data", "const uint8_t *data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cParamStr(tt.cType, tt.name)
+			if got != tt.want {
+				t.Errorf("cParamStr(%q, %q) = %q, want %q", tt.cType, tt.name, got, tt.want)
+			}
+		})
+	}
+}
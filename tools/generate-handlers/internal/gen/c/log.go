@@ -0,0 +1,192 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCLogStreamDecl emits the buffer sizes, the log writer and the hooks
+// of the log_stream handler.
+func writeCLogStreamDecl(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Entries kept by the log_stream ring buffer, and the bytes of a module\n")
+	b.WriteString(" * name and of a message fragment in each, including the terminator. */\n")
+	for _, d := range []struct {
+		name  string
+		value int
+	}{{"LOG_ENTRIES", 32}, {"LOG_MODULE_SIZE", 16}, {"LOG_MESSAGE_SIZE", 64}} {
+		b.WriteString(fmt.Sprintf("#ifndef %s_%s\n", upper, d.name))
+		b.WriteString(fmt.Sprintf("#define %s_%s %d\n", upper, d.name, d.value))
+		b.WriteString("#endif\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/* Appends message to the buffer drained by log_stream, split over several\n")
+	b.WriteString(" * entries when longer than a fragment; the oldest entries are overwritten\n")
+	b.WriteString(" * when it is full. level is a LogLevel value. On Zephyr it may be called\n")
+	b.WriteString(" * from any thread or ISR. */\n")
+	b.WriteString(fmt.Sprintf("void %s_log_write(uint8_t level, const char *module, const char *message);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Millisecond clock stamping the entries; only differences are used, so it\n")
+	b.WriteString(" * may wrap. The weak default reads the Zephyr uptime and returns 0\n")
+	b.WriteString(" * elsewhere. */\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_log_now_ms(void);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Send one encoded LogStreamResponse of log_stream, and end the stream,\n")
+	b.WriteString(" * e.g. with command_serialize(), ble_service_send_command_response() and\n")
+	b.WriteString(" * ble_service_send_stream_end_p2c(). Return 0 on success; the weak defaults\n")
+	b.WriteString(" * return -1. */\n")
+	b.WriteString(fmt.Sprintf("int %s_log_stream_send(const uint8_t *data, size_t len);\n", pkg))
+	b.WriteString(fmt.Sprintf("int %s_log_stream_end(void);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCLogStreamHandler emits the ring buffer, the weak hooks and the
+// log_stream handler, which sends the entries itself and returns -2 so the
+// dispatcher sends no response.
+func writeCLogStreamHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	upper := strings.ToUpper(pkg)
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("struct log_entry {\n")
+	b.WriteString("    uint32_t seq;\n")
+	b.WriteString("    uint32_t uptime_ms;\n")
+	b.WriteString("    uint8_t level;\n")
+	b.WriteString("    bool more;\n")
+	b.WriteString(fmt.Sprintf("    char module[%s_LOG_MODULE_SIZE];\n", upper))
+	b.WriteString(fmt.Sprintf("    char message[%s_LOG_MESSAGE_SIZE];\n", upper))
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("static struct log_entry log_ring[%s_LOG_ENTRIES];\n", upper))
+	b.WriteString("static uint32_t log_head; /* seq of the next entry */\n")
+	b.WriteString("static uint32_t log_tail; /* seq of the oldest entry kept */\n")
+	b.WriteByte('\n')
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("#include <zephyr/kernel.h>\n")
+	b.WriteByte('\n')
+	b.WriteString("static struct k_spinlock log_lock;\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_log_now_ms(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("    return k_uptime_get_32();\n")
+	b.WriteString("#else\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("#endif\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("void %s_log_write(uint8_t level, const char *module, const char *message)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    uint32_t now = %s_log_now_ms();\n", pkg))
+	b.WriteString("    size_t len = strlen(message);\n")
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("    k_spinlock_key_t key = k_spin_lock(&log_lock);\n")
+	b.WriteString("#endif\n")
+	b.WriteString("    do {\n")
+	b.WriteString(fmt.Sprintf("        struct log_entry *e = &log_ring[log_head %% %s_LOG_ENTRIES];\n", upper))
+	b.WriteString(fmt.Sprintf("        size_t n = len < %s_LOG_MESSAGE_SIZE - 1 ? len : %s_LOG_MESSAGE_SIZE - 1;\n", upper, upper))
+	b.WriteString("        size_t cut = n;\n")
+	b.WriteString("        /* Split between UTF-8 characters where there is one to split at. */\n")
+	b.WriteString("        while (cut > 0 && cut < len && ((uint8_t)message[cut] & 0xC0) == 0x80) cut--;\n")
+	b.WriteString("        if (cut > 0) n = cut;\n")
+	b.WriteString("        e->seq = log_head++;\n")
+	b.WriteString("        e->uptime_ms = now;\n")
+	b.WriteString("        e->level = level;\n")
+	b.WriteString("        strncpy(e->module, module, sizeof(e->module) - 1);\n")
+	b.WriteString("        e->module[sizeof(e->module) - 1] = '\\0';\n")
+	b.WriteString("        memcpy(e->message, message, n);\n")
+	b.WriteString("        e->message[n] = '\\0';\n")
+	b.WriteString("        message += n;\n")
+	b.WriteString("        len -= n;\n")
+	b.WriteString("        e->more = len > 0;\n")
+	b.WriteString("    } while (len > 0);\n")
+	b.WriteString(fmt.Sprintf("    if (log_head - log_tail > %s_LOG_ENTRIES) {\n", upper))
+	b.WriteString(fmt.Sprintf("        log_tail = log_head - %s_LOG_ENTRIES;\n", upper))
+	b.WriteString("    }\n")
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("    k_spin_unlock(&log_lock, key);\n")
+	b.WriteString("#endif\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Removes the oldest entry from the buffer into out. */\n")
+	b.WriteString("static bool log_pop(struct log_entry *out)\n")
+	b.WriteString("{\n")
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("    k_spinlock_key_t key = k_spin_lock(&log_lock);\n")
+	b.WriteString("#endif\n")
+	b.WriteString("    bool ok = log_tail != log_head;\n")
+	b.WriteString("    if (ok) {\n")
+	b.WriteString(fmt.Sprintf("        *out = log_ring[log_tail %% %s_LOG_ENTRIES];\n", upper))
+	b.WriteString("        log_tail++;\n")
+	b.WriteString("    }\n")
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("    k_spin_unlock(&log_lock, key);\n")
+	b.WriteString("#endif\n")
+	b.WriteString("    return ok;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool encode_log_string(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                              void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    const char *s = *arg;\n")
+	b.WriteString("    return pb_encode_tag_for_field(stream, field) &&\n")
+	b.WriteString("           pb_encode_string(stream, (const pb_byte_t *)s, strlen(s));\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_log_stream_send(const uint8_t *data, size_t len)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)data;\n")
+	b.WriteString("    (void)len;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_log_stream_end(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    (void)ostream; /* Not used — entries go out through %s_log_stream_send */\n", pkg))
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString("    struct log_entry entry;\n")
+	b.WriteString("    uint32_t sent = 0;\n")
+	b.WriteString("    while ((req.max_entries == 0 || sent < req.max_entries) && log_pop(&entry)) {\n")
+	b.WriteString("        if (entry.level < (uint8_t)req.min_level) continue;\n")
+	b.WriteString(fmt.Sprintf("        %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("        resp.seq = entry.seq;\n")
+	b.WriteString(fmt.Sprintf("        resp.level = (%s_LogLevel)entry.level;\n", pkg))
+	b.WriteString("        resp.uptime_ms = entry.uptime_ms;\n")
+	b.WriteString(fmt.Sprintf("        resp.now_ms = %s_log_now_ms();\n", pkg))
+	b.WriteString("        resp.more = entry.more;\n")
+	b.WriteString("        resp.module.funcs.encode = encode_log_string;\n")
+	b.WriteString("        resp.module.arg = entry.module;\n")
+	b.WriteString("        resp.message.funcs.encode = encode_log_string;\n")
+	b.WriteString("        resp.message.arg = entry.message;\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("        uint8_t buf[%s_LOG_MODULE_SIZE + %s_LOG_MESSAGE_SIZE + 32];\n", upper, upper))
+	b.WriteString("        pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));\n")
+	b.WriteString(fmt.Sprintf("        if (!pb_encode(&out, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString(fmt.Sprintf("        if (%s_log_stream_send(buf, out.bytes_written) != 0) return -1;\n", pkg))
+	b.WriteString("        /* max_entries counts messages, not their fragments. */\n")
+	b.WriteString("        if (!entry.more) sent++;\n")
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    if (%s_log_stream_end() != 0) return -1;\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("    /* Return -2: the dispatcher sends no response */\n")
+	b.WriteString("    return -2;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
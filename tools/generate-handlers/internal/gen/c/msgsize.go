@@ -0,0 +1,91 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// cMaxSizeMacro names the max request ("REQ") or response ("RESP") size
+// constant of cmd.
+func cMaxSizeMacro(cmd gen.Command, pkg, kind string) string {
+	return fmt.Sprintf("%s_%s_MAX_%s_SIZE", strings.ToUpper(pkg), strings.ToUpper(cmd.Snake), kind)
+}
+
+// writeCMaxSizes defines the max size constants of the bounded messages.
+func writeCMaxSizes(b *strings.Builder, commands []gen.Command, pkg string) {
+	var lines []string
+	for _, cmd := range commands {
+		if cmd.MaxRequestSize != gen.UnboundedSize {
+			lines = append(lines, fmt.Sprintf("#define %s %d", cMaxSizeMacro(cmd, pkg, "REQ"), cmd.MaxRequestSize))
+		}
+		if cmd.MaxResponseSize != gen.UnboundedSize {
+			lines = append(lines, fmt.Sprintf("#define %s %d", cMaxSizeMacro(cmd, pkg, "RESP"), cmd.MaxResponseSize))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	b.WriteString("/* Largest encoded request and response of each command, from the\n")
+	b.WriteString(" * max_size and max_count bounds in the .options file. Messages with an\n")
+	b.WriteString(" * unbounded field (FT_CALLBACK, or no bound) have no constant. */\n")
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+}
+
+// writeCMaxSizeAsserts checks the max sizes against the Zephyr transport
+// buffers: the assembler buffer holds a whole request command and the
+// response payload limit bounds a whole response command, each a 4-byte
+// header and the command name ahead of the message, and for replay-protected
+// requests the counter and MAC.
+func writeCMaxSizeAsserts(b *strings.Builder, commands []gen.Command, pkg string) {
+	checks := []struct {
+		kind, limit, what string
+		size              func(gen.Command) int
+		overhead          func(gen.Command) int
+	}{
+		{"REQ", "CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE", "requests",
+			func(c gen.Command) int { return c.MaxRequestSize },
+			func(c gen.Command) int {
+				if c.ReplayProtected {
+					return 4 + len(c.Wire()) + gen.ReplayPrefixSize
+				}
+				return 4 + len(c.Wire())
+			}},
+		{"RESP", "CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE", "responses",
+			func(c gen.Command) int { return c.MaxResponseSize },
+			func(c gen.Command) int { return 4 + len(c.Wire()) }},
+	}
+	var body strings.Builder
+	for _, c := range checks {
+		var asserts []string
+		for _, cmd := range commands {
+			if c.size(cmd) == gen.UnboundedSize {
+				continue
+			}
+			asserts = append(asserts,
+				fmt.Sprintf("_Static_assert(%d + %s <= %s,", c.overhead(cmd), cMaxSizeMacro(cmd, pkg, c.kind), c.limit),
+				fmt.Sprintf("               \"%s %s can exceed %s\");", cmd.Wire(), c.what, c.limit))
+		}
+		if len(asserts) == 0 {
+			continue
+		}
+		body.WriteString("#ifdef " + c.limit + "\n")
+		for _, l := range asserts {
+			body.WriteString(l)
+			body.WriteByte('\n')
+		}
+		body.WriteString("#endif\n")
+	}
+	if body.Len() == 0 {
+		return
+	}
+	b.WriteString("/* Each command, header and name included, must fit the transport\n")
+	b.WriteString(" * buffers at its largest. */\n")
+	b.WriteString(body.String())
+	b.WriteByte('\n')
+}
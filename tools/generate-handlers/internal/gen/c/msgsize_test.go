@@ -0,0 +1,40 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestCMaxSizeOutput(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), {Camel: "Unlock", Snake: "unlock", ReplayProtected: true, MaxRequestSize: 6, MaxResponseSize: gen.UnboundedSize}}
+	cmds[0].MaxRequestSize, cmds[0].MaxResponseSize = 259, 259
+
+	header := GenerateHeader(gctx, cmds, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_ECHO_MAX_REQ_SIZE 259\n",
+		"#define BLERPC_ECHO_MAX_RESP_SIZE 259\n",
+		"#define BLERPC_UNLOCK_MAX_REQ_SIZE 6\n",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+	if strings.Contains(header, "BLERPC_UNLOCK_MAX_RESP_SIZE") {
+		t.Error("unbounded response got a size constant")
+	}
+
+	source := GenerateSource(gctx, cmds, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#ifdef CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE\n_Static_assert(8 + BLERPC_ECHO_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,",
+		"_Static_assert(34 + BLERPC_UNLOCK_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,",
+		"\"echo responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE\");\n#endif\n",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCOneofSwitches writes, for a scaffold stub, a switch over the which_
+// member of every oneof in the request, reached through req ("req." or
+// "req->").
+func writeCOneofSwitches(b *strings.Builder, cmd gen.Command, req, pkg string) {
+	for _, oneof := range gen.OneofNames(cmd.RequestFields) {
+		b.WriteString(fmt.Sprintf("    switch (%swhich_%s) {\n", req, oneof))
+		for _, m := range gen.OneofMembers(cmd.RequestFields, oneof) {
+			b.WriteString(fmt.Sprintf("    case %s_%s_%s_tag:\n", pkg, cmd.RequestMsg, m.Name))
+			b.WriteString(fmt.Sprintf("        /* TODO: handle %s%s.%s */\n", req, oneof, m.Name))
+			b.WriteString("        break;\n")
+		}
+		b.WriteString("    default:\n")
+		b.WriteString(fmt.Sprintf("        /* %s not set */\n", oneof))
+		b.WriteString("        break;\n")
+		b.WriteString("    }\n")
+		b.WriteByte('\n')
+	}
+}
@@ -0,0 +1,116 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The ping built-in checks that the link is alive and measures it. The
+// peripheral echoes the request payload with its uptime and the link RSSI
+// from <pkg>_ping_rssi(); the clients' measure_latency helpers time one ping
+// and their keepalive helpers ping at an interval until cancelled, failing as
+// soon as a ping does, so an app notices a dead connection before a long call
+// times out.
+
+// defaultPingPayloadSize is the default <PKG>_PING_PAYLOAD_SIZE.
+const defaultPingPayloadSize = 64
+
+// writeCPingDecl emits the payload size and the hooks of the ping handler.
+func writeCPingDecl(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Bytes of payload ping echoes; a longer payload fails the call. Define\n")
+	b.WriteString(" * it to override. */\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s_PING_PAYLOAD_SIZE\n", upper))
+	b.WriteString(fmt.Sprintf("#define %s_PING_PAYLOAD_SIZE %d\n", upper, defaultPingPayloadSize))
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Uptime and link RSSI, in dBm, reported by ping. The weak uptime reads\n")
+	b.WriteString(" * the Zephyr uptime and returns 0 elsewhere; the weak RSSI returns 0,\n")
+	b.WriteString(" * unknown, until it is overridden, e.g. with an HCI Read RSSI command. */\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_ping_uptime_ms(void);\n", pkg))
+	b.WriteString(fmt.Sprintf("int8_t %s_ping_rssi(void);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCPingHandler emits the weak hooks and the ping handler, which reads
+// the uptime and RSSI on the sizing pass, so both passes encode the same
+// response.
+func writeCPingHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	upper := strings.ToUpper(pkg)
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("#include <zephyr/kernel.h>\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_ping_uptime_ms(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("    return k_uptime_get_32();\n")
+	b.WriteString("#else\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("#endif\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int8_t %s_ping_rssi(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/* The payload of the ping being answered, and what the sizing pass read. */\n")
+	b.WriteString("static struct {\n")
+	b.WriteString(fmt.Sprintf("    uint8_t data[%s_PING_PAYLOAD_SIZE];\n", upper))
+	b.WriteString("    size_t len;\n")
+	b.WriteString("    uint32_t uptime_ms;\n")
+	b.WriteString("    int8_t rssi;\n")
+	b.WriteString("} ping_state;\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool decode_ping_payload(pb_istream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                                void **arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString("    (void)arg;\n")
+	b.WriteString("    size_t len = stream->bytes_left;\n")
+	b.WriteString("    if (len > sizeof(ping_state.data)) return false;\n")
+	b.WriteString("    ping_state.len = len;\n")
+	b.WriteString("    return pb_read(stream, ping_state.data, len);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool encode_ping_payload(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                                void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)arg;\n")
+	b.WriteString("    if (ping_state.len == 0) return true;\n")
+	b.WriteString("    return pb_encode_tag_for_field(stream, field) &&\n")
+	b.WriteString("           pb_encode_string(stream, ping_state.data, ping_state.len);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    req.payload.funcs.decode = decode_ping_payload;\n")
+	b.WriteString("    ping_state.len = 0;\n")
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteString("    if (ostream->callback == NULL) {\n")
+	b.WriteString(fmt.Sprintf("        ping_state.uptime_ms = %s_ping_uptime_ms();\n", pkg))
+	b.WriteString(fmt.Sprintf("        ping_state.rssi = %s_ping_rssi();\n", pkg))
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    resp.payload.funcs.encode = encode_ping_payload;\n")
+	b.WriteString("    resp.uptime_ms = ping_state.uptime_ms;\n")
+	b.WriteString("    resp.rssi = ping_state.rssi;\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
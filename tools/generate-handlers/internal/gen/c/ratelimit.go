@@ -0,0 +1,122 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func hasRateLimits(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		if cmd.RateLimit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCHandlerRejections emits the return values of requests rejected
+// before their handler runs: HANDLER_BUSY, which the dispatcher answers with
+// a BUSY error, and HANDLER_REPLAYED, which it drops.
+func writeCHandlerRejections(b *strings.Builder) {
+	b.WriteString("/* Returned for a request over its command's rate limit; the dispatcher\n")
+	b.WriteString(" * answers it with a BUSY error. */\n")
+	b.WriteString("#define HANDLER_BUSY (-3)\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Returned for a replay-protected request whose MAC is wrong or whose\n")
+	b.WriteString(" * counter is not newer than the last one accepted; the dispatcher drops\n")
+	b.WriteString(" * it. */\n")
+	b.WriteString("#define HANDLER_REPLAYED (-4)\n")
+	b.WriteByte('\n')
+}
+
+// writeCRateLimitDecl emits the clock hook of the rate limits and the hook
+// told of rejected calls.
+func writeCRateLimitDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	b.WriteString("/* Millisecond clock of the rate limit windows; only differences are used,\n")
+	b.WriteString(" * so it may wrap. Zephyr builds get a weak default reading the uptime;\n")
+	b.WriteString(" * other platforms must define it. */\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_rate_limit_now_ms(void);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Called for each call rejected over a rate limit, with the command name\n")
+	b.WriteString(" * as received, before it is answered with a BUSY error. The weak default\n")
+	b.WriteString(" * does nothing. */\n")
+	b.WriteString(fmt.Sprintf("void %s_on_rate_limited(const char *name, uint8_t name_len%s);\n", pkg, gctx.CCtxSuffix()))
+	b.WriteByte('\n')
+	writeCTokenBucketDecl(gctx, b, pkg)
+}
+
+// writeCRateLimits emits the limits, in handler table order, the window
+// bookkeeping and the token bucket handlers_lookup uses. outParam lists the
+// parameters of a handler in the C runtime after req_len.
+func writeCRateLimits(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg, outParam string) {
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("void %s_on_rate_limited(const char *name, uint8_t name_len%s)\n", pkg, gctx.CCtxSuffix()))
+	b.WriteString("{\n")
+	b.WriteString("    (void)name;\n")
+	b.WriteString("    (void)name_len;\n")
+	if gctx.CHandlerCtx {
+		b.WriteString("    (void)ctx;\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("#include <zephyr/kernel.h>\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_rate_limit_now_ms(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    return k_uptime_get_32();\n")
+	b.WriteString("}\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	if hasRateLimits(commands) {
+		writeCCommandRateLimits(gctx, b, commands, pkg)
+	}
+	writeCTokenBucket(gctx, b, pkg)
+	b.WriteString("static int reject_rate_limited(const uint8_t *req_data, size_t req_len,\n")
+	b.WriteString(fmt.Sprintf("                               %s)\n", outParam))
+	b.WriteString("{\n")
+	b.WriteString("    (void)req_data;\n")
+	b.WriteString("    (void)req_len;\n")
+	for _, param := range strings.Split(outParam, ", ") {
+		b.WriteString(fmt.Sprintf("    (void)%s;\n", param[strings.LastIndex(param, "*")+1:]))
+	}
+	b.WriteString("    return HANDLER_BUSY;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCCommandRateLimits emits the per-command limits, in handler table
+// order, and their windows.
+func writeCCommandRateLimits(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString("/* Calls per second accepted by each command (0: unlimited), in handler\n")
+	b.WriteString(" * table order. */\n")
+	b.WriteString("static const uint16_t rate_limits[] = {\n")
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		return fmt.Sprintf("    %d, /* %s */\n", cmd.RateLimit, cmd.Wire())
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("static struct {\n")
+	b.WriteString("    uint32_t start_ms;\n")
+	b.WriteString("    uint16_t calls;\n")
+	b.WriteString("} rate_windows[sizeof(rate_limits) / sizeof(rate_limits[0])];\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Counts a call against the current one-second window of command i and\n")
+	b.WriteString(" * reports whether it is within the limit. */\n")
+	b.WriteString("static int rate_limit_take(size_t i)\n")
+	b.WriteString("{\n")
+	b.WriteString("    if (rate_limits[i] == 0) return 1;\n")
+	b.WriteString(fmt.Sprintf("    uint32_t now = %s_rate_limit_now_ms();\n", pkg))
+	b.WriteString("    if (now - rate_windows[i].start_ms >= 1000 || rate_windows[i].calls == 0) {\n")
+	b.WriteString("        rate_windows[i].start_ms = now;\n")
+	b.WriteString("        rate_windows[i].calls = 0;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    if (rate_windows[i].calls >= rate_limits[i]) return 0;\n")
+	b.WriteString("    rate_windows[i].calls++;\n")
+	b.WriteString("    return 1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,54 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateRateLimits(t *testing.T) {
+	gctx := gen.NewContext()
+	limited := gentest.EchoCommand()
+	limited.RateLimit = 2
+
+	header := GenerateHeader(gctx, []gen.Command{limited}, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define HANDLER_BUSY (-3)",
+		"uint32_t blerpc_rate_limit_now_ms(void);",
+		"void blerpc_on_rate_limited(const char *name, uint8_t name_len);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := GenerateSource(gctx, []gen.Command{limited}, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static const uint16_t rate_limits[] = {",
+		"    2, /* echo */",
+		"static int rate_limit_take(size_t i)",
+		"return HANDLER_BUSY;",
+		"if (!rate_limit_take(i)) {\n                blerpc_on_rate_limited(name, name_len);\n                return reject_rate_limited;",
+		"__attribute__((weak))\nvoid blerpc_on_rate_limited(const char *name, uint8_t name_len)\n{",
+		"pb_ostream_t *ostream)",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	pbc := GenerateSourceProtobufC(gctx, []gen.Command{limited}, "blerpc")
+	if !strings.Contains(pbc, "ProtobufCBuffer *out)") || !strings.Contains(pbc, "(void)out;") {
+		t.Error("protobuf-c source missing reject_rate_limited with its out parameter")
+	}
+
+	plain := GenerateSource(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc")
+	if strings.Contains(plain, "rate_limit") {
+		t.Error("unlimited commands should not emit rate limit code")
+	}
+	if !strings.Contains(GenerateHeader(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc"), "HANDLER_BUSY") {
+		t.Error("HANDLER_BUSY should be defined without rate limits")
+	}
+}
@@ -0,0 +1,146 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// An FT_CALLBACK bytes field of a request, e.g. the image of a firmware
+// update, has no storage in the nanopb struct. The weak handler stubs hand
+// it to a read hook, <pkg>_<cmd>_read_<field>, a chunk at a time as the
+// request is decoded. The weak hooks discard the data, so an app overrides
+// the hook of the field it needs and keeps the stub, or overrides the
+// handler and installs a decode callback of its own. A unary handler runs
+// twice, a sizing pass and a writing pass, and the hook is called on the
+// sizing pass only; the writing pass discards the field.
+
+// commandsWithReadHooks returns the commands whose handler stubs call read
+// hooks: those with FT_CALLBACK request fields, except built-ins, which
+// decode their own fields, and typed handlers, which take none.
+func commandsWithReadHooks(commands []gen.Command, callbacks map[string]bool) []gen.Command {
+	var hooked []gen.Command
+	for _, cmd := range commands {
+		if cmd.Builtin == "" && !cmd.TypedHandler && hasCallbackField(cmd.RequestMsg, cmd.RequestFields, callbacks) {
+			hooked = append(hooked, cmd)
+		}
+	}
+	return hooked
+}
+
+// discardsCallbackFields reports whether the handler stubs of commands
+// discard FT_CALLBACK request fields on the writing pass, which only unary
+// handlers have.
+func discardsCallbackFields(commands []gen.Command, streaming map[string]string, callbacks map[string]bool) bool {
+	for _, cmd := range commandsWithReadHooks(commands, callbacks) {
+		if _, ok := streaming[cmd.Snake]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// cReadHookName names the read hook of field f of the request of cmd.
+func cReadHookName(cmd gen.Command, f gen.Field, pkg string) string {
+	return fmt.Sprintf("%s_%s_read_%s", pkg, cmd.Snake, f.Name)
+}
+
+// cReadHookParams returns the parameter list of a read hook, wrapped after
+// the request.
+func cReadHookParams(cmd gen.Command, f gen.Field, pkg string) string {
+	pad := strings.Repeat(" ", len("int ")+len(cReadHookName(cmd, f, pkg))+1)
+	return fmt.Sprintf("(const %s_%s *req, const uint8_t *chunk,\n%ssize_t len, size_t offset)", pkg, cmd.RequestMsg, pad)
+}
+
+// writeCReadHookDecls declares the read hooks in the handler header.
+func writeCReadHookDecls(b *strings.Builder, commands []gen.Command, callbacks map[string]bool, pkg string) {
+	hooked := commandsWithReadHooks(commands, callbacks)
+	if len(hooked) == 0 {
+		return
+	}
+	b.WriteString("/* Read hooks of the FT_CALLBACK bytes fields of the requests, called with\n")
+	b.WriteString(" * each chunk of the field as the request is decoded, once per request. The\n")
+	b.WriteString(" * fields of req numbered before it are decoded already; offset is the\n")
+	b.WriteString(" * position of chunk in the field. Return 0 to go on, or a negative value\n")
+	b.WriteString(" * to fail the request. The weak defaults discard the data. */\n")
+	for _, cmd := range hooked {
+		for _, f := range cmd.RequestFields {
+			if callbacks[cmd.RequestMsg+"."+f.Name] {
+				b.WriteString(fmt.Sprintf("int %s%s;\n", cReadHookName(cmd, f, pkg), cReadHookParams(cmd, f, pkg)))
+			}
+		}
+	}
+	b.WriteByte('\n')
+}
+
+// writeCReadHooks emits the weak read hooks of the FT_CALLBACK request
+// fields of cmd and the decode callbacks that feed them.
+func writeCReadHooks(b *strings.Builder, cmd gen.Command, callbacks map[string]bool, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	for _, f := range cmd.RequestFields {
+		if !callbacks[cmd.RequestMsg+"."+f.Name] {
+			continue
+		}
+		hook := cReadHookName(cmd, f, pkg)
+		b.WriteString("__attribute__((weak))\n")
+		b.WriteString(fmt.Sprintf("int %s%s\n", hook, cReadHookParams(cmd, f, pkg)))
+		b.WriteString("{\n")
+		b.WriteString("    (void)req;\n")
+		b.WriteString("    (void)chunk;\n")
+		b.WriteString("    (void)len;\n")
+		b.WriteString("    (void)offset;\n")
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("static bool read_%s_%s(pb_istream_t *stream, const pb_field_t *field,\n", cmd.Snake, f.Name))
+		b.WriteString(fmt.Sprintf("%svoid **arg)\n", strings.Repeat(" ", len("static bool read_")+len(cmd.Snake)+len(f.Name)+2)))
+		b.WriteString("{\n")
+		b.WriteString("    (void)field;\n")
+		b.WriteString(fmt.Sprintf("    const %s *req = *arg;\n", reqMsg))
+		b.WriteString("    uint8_t buf[64];\n")
+		b.WriteString("    size_t offset = 0;\n")
+		b.WriteString("    while (stream->bytes_left > 0) {\n")
+		b.WriteString("        size_t n = stream->bytes_left < sizeof(buf) ? stream->bytes_left : sizeof(buf);\n")
+		b.WriteString("        if (!pb_read(stream, buf, n)) return false;\n")
+		b.WriteString(fmt.Sprintf("        if (%s(req, buf, n, offset) != 0) return false;\n", hook))
+		b.WriteString("        offset += n;\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return true;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+}
+
+// writeCInstallReadHooks emits the statements installing the decode
+// callbacks of the FT_CALLBACK request fields of cmd on req. With sizingOnly
+// set, for handlers run twice, the hooks get the sizing pass and the writing
+// pass discards the fields.
+func writeCInstallReadHooks(b *strings.Builder, cmd gen.Command, callbacks map[string]bool, sizingOnly bool) {
+	var fields []gen.Field
+	for _, f := range cmd.RequestFields {
+		if callbacks[cmd.RequestMsg+"."+f.Name] {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	indent := "    "
+	if sizingOnly {
+		b.WriteString("    /* Read the request's FT_CALLBACK fields once, on the sizing pass. */\n")
+		b.WriteString("    if (ostream->callback == NULL) {\n")
+		indent = "        "
+	}
+	for _, f := range fields {
+		b.WriteString(fmt.Sprintf("%sreq.%s.funcs.decode = read_%s_%s;\n", indent, f.Name, cmd.Snake, f.Name))
+		b.WriteString(fmt.Sprintf("%sreq.%s.arg = &req;\n", indent, f.Name))
+	}
+	if sizingOnly {
+		b.WriteString("    } else {\n")
+		for _, f := range fields {
+			b.WriteString(fmt.Sprintf("        req.%s.funcs.decode = discard_bytes_cb;\n", f.Name))
+		}
+		b.WriteString("    }\n")
+	}
+}
@@ -0,0 +1,61 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestCReadHooks(t *testing.T) {
+	gctx := gen.NewContext()
+	callbacks := map[string]bool{"DataWriteRequest.data": true}
+	cmds := []gen.Command{gentest.CallbackCommand()}
+
+	header := GenerateHeader(gctx, cmds, nil, callbacks, "blerpc")
+	for _, s := range []string{
+		`#include "blerpc.pb.h"`,
+		"int blerpc_data_write_read_data(const blerpc_DataWriteRequest *req, const uint8_t *chunk,\n" +
+			"                                size_t len, size_t offset);",
+	} {
+		if !strings.Contains(header, s) {
+			t.Errorf("C header missing %q\nGot:\n%s", s, header)
+		}
+	}
+
+	src := GenerateSource(gctx, cmds, nil, callbacks, "blerpc")
+	for _, s := range []string{
+		"__attribute__((weak))\nint blerpc_data_write_read_data(",
+		"static bool read_data_write_data(pb_istream_t *stream, const pb_field_t *field,",
+		"        if (blerpc_data_write_read_data(req, buf, n, offset) != 0) return false;",
+		"    if (ostream->callback == NULL) {\n" +
+			"        req.data.funcs.decode = read_data_write_data;\n" +
+			"        req.data.arg = &req;\n" +
+			"    } else {\n" +
+			"        req.data.funcs.decode = discard_bytes_cb;\n" +
+			"    }\n",
+	} {
+		if !strings.Contains(src, s) {
+			t.Errorf("C source missing %q\nGot:\n%s", s, src)
+		}
+	}
+}
+
+func TestCReadHooks_Streams(t *testing.T) {
+	gctx := gen.NewContext()
+	upload := gentest.StreamC2PCommand()
+	upload.RequestFields = append(upload.RequestFields, gen.Field{Type: "bytes", Name: "blob", Number: 9})
+	callbacks := map[string]bool{upload.RequestMsg + ".blob": true}
+	streaming := map[string]string{upload.Snake: "c2p"}
+
+	src := GenerateSource(gctx, []gen.Command{upload}, streaming, callbacks, "blerpc")
+	hook := "blerpc_" + upload.Snake + "_read_blob"
+	want := "    req.blob.funcs.decode = read_" + upload.Snake + "_blob;\n    req.blob.arg = &req;\n    pb_istream_t"
+	if !strings.Contains(src, "int "+hook+"(") || !strings.Contains(src, want) {
+		t.Errorf("C source should feed c2p requests to %s on every call\nGot:\n%s", hook, src)
+	}
+	if strings.Contains(src, "ostream->callback == NULL") {
+		t.Error("stream handlers run once and should not wait for the sizing pass")
+	}
+}
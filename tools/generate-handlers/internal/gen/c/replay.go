@@ -0,0 +1,134 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCReplayDecl emits the MAC size and the MAC and persistence hooks of
+// the replay counter.
+func writeCReplayDecl(b *strings.Builder, pkg string) {
+	b.WriteString("/* Length of the MAC after the replay counter: the first bytes of an\n")
+	b.WriteString(" * HMAC-SHA256 under the key shared with the central. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_REPLAY_MAC_SIZE %d\n", strings.ToUpper(pkg), gen.ReplayMACSize))
+	b.WriteByte('\n')
+	b.WriteString("/* Writes to mac, 32 bytes, the HMAC-SHA256 under the key shared with the\n")
+	b.WriteString(" * central of command with its NUL, the 8 counter bytes leading a\n")
+	b.WriteString(" * replay-protected request, then its payload. Returns 0 on success; the weak\n")
+	b.WriteString(" * default returns -1, so every replay-protected request is dropped until it\n")
+	b.WriteString(" * is overridden. */\n")
+	writeCReplayMACSignature(b, pkg, ";\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Last replay counter accepted, kept across reboots so a recorded request\n")
+	b.WriteString(" * cannot be replayed after one. The weak defaults keep it in RAM only. */\n")
+	b.WriteString(fmt.Sprintf("uint64_t %s_replay_counter_load(void);\n", pkg))
+	b.WriteString(fmt.Sprintf("void %s_replay_counter_store(uint64_t counter);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCReplayMACSignature emits the signature of the <pkg>_replay_mac hook,
+// then end.
+func writeCReplayMACSignature(b *strings.Builder, pkg, end string) {
+	pad := strings.Repeat(" ", len(pkg))
+	b.WriteString(fmt.Sprintf("int %s_replay_mac(const char *command, const uint8_t *counter,\n", pkg))
+	b.WriteString(fmt.Sprintf("    %s            const uint8_t *payload, size_t payload_len, uint8_t *mac)%s", pad, end))
+}
+
+// writeCReplayGuards emits the counter and MAC check and a guarded_<cmd>
+// wrapper of every replay-protected command, calling the function handlerFn
+// names.
+func writeCReplayGuards(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string, handlerFn func(gen.Command) string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("__attribute__((weak))\n")
+	writeCReplayMACSignature(b, pkg, "\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)command;\n")
+	b.WriteString("    (void)counter;\n")
+	b.WriteString("    (void)payload;\n")
+	b.WriteString("    (void)payload_len;\n")
+	b.WriteString("    (void)mac;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("uint64_t %s_replay_counter_load(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("void %s_replay_counter_store(uint64_t counter)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)counter;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static uint64_t replay_last;\n")
+	b.WriteString("static bool replay_loaded;\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Checks the MAC of a replay-protected request to command and its counter\n")
+	b.WriteString(" * against the last one accepted. The sizing pass only checks; the writing\n")
+	b.WriteString(" * pass accepts the counter. */\n")
+	b.WriteString("static bool replay_fresh(const char *command, const uint8_t *req_data, size_t req_len,\n")
+	b.WriteString("                         bool accept)\n")
+	b.WriteString("{\n")
+	b.WriteString("    uint64_t counter = 0;\n")
+	b.WriteString("    uint8_t mac[32];\n")
+	b.WriteString("    uint8_t diff = 0;\n")
+	b.WriteString("    size_t i;\n")
+	b.WriteString(fmt.Sprintf("    if (req_len < %d + %s_REPLAY_MAC_SIZE) return false;\n", gen.ReplayCounterSize, upper))
+	b.WriteString("    if (!replay_loaded) {\n")
+	b.WriteString(fmt.Sprintf("        replay_last = %s_replay_counter_load();\n", pkg))
+	b.WriteString("        replay_loaded = true;\n")
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    for (i = 0; i < %d; i++) {\n", gen.ReplayCounterSize))
+	b.WriteString("        counter |= (uint64_t)req_data[i] << (8 * i);\n")
+	b.WriteString("    }\n")
+	b.WriteString("    if (counter <= replay_last) return false;\n")
+	b.WriteString(fmt.Sprintf("    if (%s_replay_mac(command, req_data, req_data + %d + %s_REPLAY_MAC_SIZE,\n", pkg, gen.ReplayCounterSize, upper))
+	b.WriteString(fmt.Sprintf("        %s            req_len - %d - %s_REPLAY_MAC_SIZE, mac) != 0) {\n", strings.Repeat(" ", len(pkg)), gen.ReplayCounterSize, upper))
+	b.WriteString("        return false;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    /* Look at every byte, so the time taken tells nothing of the MAC. */\n")
+	b.WriteString(fmt.Sprintf("    for (i = 0; i < %s_REPLAY_MAC_SIZE; i++) {\n", upper))
+	b.WriteString(fmt.Sprintf("        diff |= mac[i] ^ req_data[%d + i];\n", gen.ReplayCounterSize))
+	b.WriteString("    }\n")
+	b.WriteString("    if (diff != 0) return false;\n")
+	b.WriteString("    if (accept) {\n")
+	b.WriteString("        replay_last = counter;\n")
+	b.WriteString(fmt.Sprintf("        %s_replay_counter_store(counter);\n", pkg))
+	b.WriteString("    }\n")
+	b.WriteString("    return true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	for _, g := range gen.GroupCommands(commands, "per-group", pkg) {
+		var guarded []gen.Command
+		for _, cmd := range g.Commands {
+			if cmd.ReplayProtected {
+				guarded = append(guarded, cmd)
+			}
+		}
+		if len(guarded) == 0 {
+			continue
+		}
+		b.WriteString("#if " + cGroupMacro(pkg, g.Name) + "\n")
+		for i, cmd := range guarded {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			pad := strings.Repeat(" ", len(cmd.Snake))
+			b.WriteString(fmt.Sprintf("static int guarded_%s(const uint8_t *req_data, size_t req_len,\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("                    %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    if (!replay_fresh(\"%s\", req_data, req_len, ostream->callback != NULL)) {\n", cmd.Wire()))
+			b.WriteString("        return HANDLER_REPLAYED;\n")
+			b.WriteString("    }\n")
+			b.WriteString(fmt.Sprintf("    return %s(req_data + %d, req_len - %d, %s);\n",
+				handlerFn(cmd), gen.ReplayPrefixSize, gen.ReplayPrefixSize, cHandlerOutArg(gctx, "ostream")))
+			b.WriteString("}\n")
+		}
+		b.WriteString("#endif\n")
+		b.WriteByte('\n')
+	}
+}
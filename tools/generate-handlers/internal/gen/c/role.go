@@ -0,0 +1,47 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCRoleDecl emits the role enum and the current role hook.
+func writeCRoleDecl(gctx *gen.Context, b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Roles a command may require; each includes the ones before it. */\n")
+	b.WriteString(fmt.Sprintf("enum %s_role {\n", pkg))
+	for i, name := range gen.RoleNames {
+		b.WriteString(fmt.Sprintf("    %s_ROLE_%s = %d,\n", upper, strings.ToUpper(name), i))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Role of the current connection, e.g. raised by an unlock command or a\n")
+	b.WriteString(fmt.Sprintf(" * factory jumper. The weak default returns %s_ROLE_USER. */\n", upper))
+	b.WriteString(fmt.Sprintf("enum %s_role %s_current_role(%s);\n", pkg, pkg, cCtxParam(gctx)))
+	b.WriteByte('\n')
+}
+
+// writeCRoles emits the role of each command, in handler table order, and
+// the weak current role hook.
+func writeCRoles(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Role each command requires, in handler table order. */\n")
+	b.WriteString("static const uint8_t roles[] = {\n")
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		level, _ := gen.RoleLevel(cmd.Role)
+		return fmt.Sprintf("    %s_ROLE_%s, /* %s */\n", upper, strings.ToUpper(gen.RoleNames[level]), cmd.Wire())
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("enum %s_role %s_current_role(%s)\n", pkg, pkg, cCtxParam(gctx)))
+	b.WriteString("{\n")
+	if gctx.CHandlerCtx {
+		b.WriteString("    (void)ctx;\n")
+	}
+	b.WriteString(fmt.Sprintf("    return %s_ROLE_USER;\n", upper))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,84 @@
+package c
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// HandlerDefRe matches the definitions of C handlers, named by
+// CHandlerName.
+func HandlerDefRe(gctx *gen.Context) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^int\s+` + regexp.QuoteMeta(gctx.CHandlerName("")) + `(\w+)\s*\(`)
+}
+
+// ScaffoldHandlers returns the content of the user C handler file with
+// non-weak stubs appended for every command not in implemented. existing is
+// the current file content ("" when the file does not exist yet). It returns
+// the added command names; when none, the file should be left untouched.
+func ScaffoldHandlers(gctx *gen.Context, commands []gen.Command, pkg, existing string, implemented map[string]bool) (string, []string) {
+	var b strings.Builder
+	b.WriteString(existing)
+	if existing == "" {
+		b.WriteString("/*\n")
+		b.WriteString(" * User handler implementations.\n")
+		b.WriteString(" *\n")
+		b.WriteString(" * Created by generate-handlers. This file is yours to edit: the generator\n")
+		b.WriteString(" * never rewrites it, -scaffold only appends stubs for new commands.\n")
+		b.WriteString(" * These definitions override the weak defaults in generated_handlers.c.\n")
+		b.WriteString(" */\n")
+		b.WriteString("#include \"generated_handlers.h\"\n")
+		b.WriteString("#include \"" + pkg + ".pb.h\"\n")
+		b.WriteString("#include <pb_encode.h>\n")
+		b.WriteString("#include <pb_decode.h>\n")
+	}
+
+	var added []string
+	for _, cmd := range commands {
+		if implemented[cmd.Snake] {
+			continue
+		}
+		added = append(added, cmd.Snake)
+		reqMsg := pkg + "_" + cmd.RequestMsg
+		respMsg := pkg + "_" + cmd.ResponseMsg
+		pad := gctx.CHandlerPad(cmd.Snake)
+
+		b.WriteByte('\n')
+		if cmd.TypedHandler {
+			b.WriteString(cTypedHandlerSignature(gctx, cmd, pkg) + "\n")
+			b.WriteString("{\n")
+			writeCOneofSwitches(&b, cmd, "req->", pkg)
+			b.WriteString(fmt.Sprintf("    /* TODO: implement %s */\n", cmd.Snake))
+			for _, oneof := range gen.OneofNames(cmd.ResponseFields) {
+				b.WriteString(fmt.Sprintf("    /* To set %s, fill a member of resp->%s and set resp->which_%s to its tag. */\n", oneof, oneof, oneof))
+			}
+			b.WriteString("    (void)req;\n")
+			b.WriteString("    (void)resp;\n")
+			if gctx.CHandlerCtx {
+				b.WriteString("    (void)ctx;\n")
+			}
+			b.WriteString("    return 0;\n")
+			b.WriteString("}\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+		b.WriteString(fmt.Sprintf("            %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+		b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+		b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+		b.WriteByte('\n')
+		writeCOneofSwitches(&b, cmd, "req.", pkg)
+		b.WriteString(fmt.Sprintf("    /* TODO: implement %s */\n", cmd.Snake))
+		b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+		for _, oneof := range gen.OneofNames(cmd.ResponseFields) {
+			b.WriteString(fmt.Sprintf("    /* To set %s, fill a member of resp.%s and set resp.which_%s to its tag. */\n", oneof, oneof, oneof))
+		}
+		b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+	}
+	return b.String(), added
+}
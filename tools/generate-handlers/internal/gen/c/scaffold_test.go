@@ -0,0 +1,69 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestScaffoldCHandlers_New(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.EnumCommand()}
+	out, added := ScaffoldHandlers(gctx, cmds, "blerpc", "", map[string]bool{"echo": true})
+
+	if len(added) != 1 || added[0] != "get_status" {
+		t.Fatalf("expected only get_status added, got %v", added)
+	}
+	mustContain := []string{
+		`#include "generated_handlers.h"`,
+		"int handle_get_status(const uint8_t *req_data, size_t req_len,",
+		"/* TODO: implement get_status */",
+		"blerpc_GetStatusResponse resp = blerpc_GetStatusResponse_init_zero;",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C scaffold missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "handle_echo") {
+		t.Error("C scaffold should skip implemented commands")
+	}
+	if strings.Contains(out, "__attribute__((weak))") {
+		t.Error("C scaffold stubs must not be weak")
+	}
+}
+
+func TestScaffoldCHandlers_Oneof(t *testing.T) {
+	gctx := gen.NewContext()
+	out, _ := ScaffoldHandlers(gctx, []gen.Command{gentest.OneofCommand()}, "blerpc", "", nil)
+
+	mustContain := []string{
+		"    switch (req.which_query) {\n    case blerpc_SearchRequest_text_tag:\n        /* TODO: handle req.query.text */\n",
+		"    case blerpc_SearchRequest_user_id_tag:\n",
+		"    default:\n        /* query not set */\n",
+		"fill a member of resp.result and set resp.which_result to its tag.",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C scaffold oneof missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestScaffoldCHandlers_AppendKeepsExisting(t *testing.T) {
+	gctx := gen.NewContext()
+	existing := "/* mine */\nint handle_echo(void) { return 42; }\n"
+	out, added := ScaffoldHandlers(gctx, []gen.Command{gentest.EchoCommand(), gentest.EnumCommand()}, "blerpc", existing, map[string]bool{"echo": true})
+
+	if !strings.HasPrefix(out, existing) {
+		t.Errorf("C scaffold must keep existing content verbatim\nGot:\n%s", out)
+	}
+	if strings.Count(out, "#include") != 0 {
+		t.Error("C scaffold should not repeat the file header when appending")
+	}
+	if len(added) != 1 {
+		t.Errorf("expected 1 added stub, got %v", added)
+	}
+}
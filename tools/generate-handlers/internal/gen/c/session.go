@@ -0,0 +1,244 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCSessionDecl emits the sizes, hooks and session functions of the
+// session built-in.
+func writeCSessionDecl(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Lengths of the session challenge and token, and of the proof: an\n")
+	b.WriteString(" * HMAC-SHA256 of the challenge under the key shared with the central. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_SESSION_CHALLENGE_SIZE %d\n", upper, gen.SessionChallengeSize))
+	b.WriteString(fmt.Sprintf("#define %s_SESSION_TOKEN_SIZE %d\n", upper, gen.SessionTokenSize))
+	b.WriteString(fmt.Sprintf("#define %s_SESSION_PROOF_SIZE %d\n", upper, gen.SessionProofSize))
+	b.WriteByte('\n')
+	b.WriteString("/* Fills buf with len bytes from a cryptographic random source, e.g.\n")
+	b.WriteString(" * sys_csrand_get() or psa_generate_random(). Returns 0 on success; the weak\n")
+	b.WriteString(" * default returns -1, so no session starts until it is overridden. */\n")
+	b.WriteString(fmt.Sprintf("int %s_session_random(uint8_t *buf, size_t len);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Reports whether proof is the HMAC-SHA256 of challenge under the key shared\n")
+	b.WriteString(" * with the central; compare in constant time. The weak default rejects\n")
+	b.WriteString(" * every proof. */\n")
+	b.WriteString(fmt.Sprintf("bool %s_session_verify(const uint8_t *challenge, const uint8_t *proof,\n", pkg))
+	b.WriteString(fmt.Sprintf("     %s                 size_t proof_len);\n", strings.Repeat(" ", len(pkg))))
+	b.WriteByte('\n')
+	b.WriteString("/* Reports whether token, the first SESSION_TOKEN_SIZE bytes of a request,\n")
+	b.WriteString(" * is the token of the open session. The guards of session-protected\n")
+	b.WriteString(" * commands call it. */\n")
+	b.WriteString(fmt.Sprintf("bool %s_session_check(const uint8_t *token);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Ends the open session, e.g. on disconnect; protected commands fail with\n")
+	b.WriteString(fmt.Sprintf(" * %s_STATUS_UNAUTHENTICATED until the central authenticates again. */\n", upper))
+	b.WriteString(fmt.Sprintf("void %s_session_end(void);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCSessionSupport emits the weak hook stubs, the session state and the
+// callbacks the session handlers share, ahead of the handlers.
+func writeCSessionSupport(b *strings.Builder, pkg string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_session_random(uint8_t *buf, size_t len)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)buf;\n")
+	b.WriteString("    (void)len;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("bool %s_session_verify(const uint8_t *challenge, const uint8_t *proof,\n", pkg))
+	b.WriteString(fmt.Sprintf("     %s                 size_t proof_len)\n", strings.Repeat(" ", len(pkg))))
+	b.WriteString("{\n")
+	b.WriteString("    (void)challenge;\n")
+	b.WriteString("    (void)proof;\n")
+	b.WriteString("    (void)proof_len;\n")
+	b.WriteString("    return false;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("static uint8_t session_challenge[%s_SESSION_CHALLENGE_SIZE];\n", upper))
+	b.WriteString("static bool session_challenge_valid;\n")
+	b.WriteString(fmt.Sprintf("static uint8_t session_token[%s_SESSION_TOKEN_SIZE];\n", upper))
+	b.WriteString("static bool session_open;\n")
+	b.WriteString("/* Outcome of the authenticate_session request in progress. */\n")
+	b.WriteString(fmt.Sprintf("static enum %s_status session_status;\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("bool %s_session_check(const uint8_t *token)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    uint8_t diff = 0;\n")
+	b.WriteString("    size_t i;\n")
+	b.WriteString("    if (!session_open) return false;\n")
+	b.WriteString("    /* Look at every byte, so the time taken tells nothing of the token. */\n")
+	b.WriteString(fmt.Sprintf("    for (i = 0; i < %s_SESSION_TOKEN_SIZE; i++) {\n", upper))
+	b.WriteString("        diff |= token[i] ^ session_token[i];\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return diff == 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("void %s_session_end(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    session_open = false;\n")
+	b.WriteString("    session_challenge_valid = false;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("struct session_bytes {\n")
+	b.WriteString("    const uint8_t *data;\n")
+	b.WriteString("    size_t len;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool encode_session_bytes(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                                 void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    const struct session_bytes *bytes = *arg;\n")
+	b.WriteString("    return pb_encode_tag_for_field(stream, field) &&\n")
+	b.WriteString("           pb_encode_string(stream, bytes->data, bytes->len);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("struct session_proof {\n")
+	b.WriteString(fmt.Sprintf("    uint8_t data[%s_SESSION_PROOF_SIZE];\n", upper))
+	b.WriteString("    size_t len;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool decode_session_proof(pb_istream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                                 void **arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    struct session_proof *proof = *arg;\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString("    if (stream->bytes_left > sizeof(proof->data)) return false;\n")
+	b.WriteString("    proof->len = stream->bytes_left;\n")
+	b.WriteString("    return pb_read(stream, proof->data, proof->len);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCStartSessionHandler emits the start_session handler. The challenge
+// is drawn on the sizing pass, which comes first, and sent by both.
+func writeCStartSessionHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	upper := strings.ToUpper(pkg)
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString("    (void)req_data; /* The request has no fields */\n")
+	b.WriteString("    (void)req_len;\n")
+	b.WriteString("    if (ostream->callback == NULL) {\n")
+	b.WriteString(fmt.Sprintf("        %s_session_end();\n", pkg))
+	b.WriteString("        session_challenge_valid =\n")
+	b.WriteString(fmt.Sprintf("            %s_session_random(session_challenge, sizeof(session_challenge)) == 0;\n", pkg))
+	b.WriteString("    }\n")
+	b.WriteString("    if (!session_challenge_valid) {\n")
+	b.WriteString(fmt.Sprintf("        return %s_return_error(ostream, %s_STATUS_UNAVAILABLE);\n", pkg, upper))
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    struct session_bytes challenge = {session_challenge, sizeof(session_challenge)};\n")
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    resp.challenge.funcs.encode = encode_session_bytes;\n")
+	b.WriteString("    resp.challenge.arg = &challenge;\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCAuthenticateSessionHandler emits the authenticate_session handler.
+// The sizing pass checks the proof and draws the token; the writing pass
+// spends the challenge, so it answers one proof, and opens the session.
+func writeCAuthenticateSessionHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	upper := strings.ToUpper(pkg)
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString("    if (ostream->callback == NULL) {\n")
+	b.WriteString("        struct session_proof proof = {{0}, 0};\n")
+	b.WriteString(fmt.Sprintf("        %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("        req.proof.funcs.decode = decode_session_proof;\n")
+	b.WriteString("        req.proof.arg = &proof;\n")
+	b.WriteString("        pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("        if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteString("        if (!session_challenge_valid ||\n")
+	b.WriteString(fmt.Sprintf("            !%s_session_verify(session_challenge, proof.data, proof.len)) {\n", pkg))
+	b.WriteString(fmt.Sprintf("            session_status = %s_STATUS_UNAUTHENTICATED;\n", upper))
+	b.WriteString(fmt.Sprintf("        } else if (%s_session_random(session_token, sizeof(session_token)) != 0) {\n", pkg))
+	b.WriteString(fmt.Sprintf("            session_status = %s_STATUS_UNAVAILABLE;\n", upper))
+	b.WriteString("        } else {\n")
+	b.WriteString(fmt.Sprintf("            session_status = %s_STATUS_OK;\n", upper))
+	b.WriteString("        }\n")
+	b.WriteString("    } else {\n")
+	b.WriteString("        session_challenge_valid = false;\n")
+	b.WriteString(fmt.Sprintf("        session_open = session_status == %s_STATUS_OK;\n", upper))
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    if (session_status != %s_STATUS_OK) {\n", upper))
+	b.WriteString(fmt.Sprintf("        return %s_return_error(ostream, session_status);\n", pkg))
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    struct session_bytes token = {session_token, sizeof(session_token)};\n")
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    resp.token.funcs.encode = encode_session_bytes;\n")
+	b.WriteString("    resp.token.arg = &token;\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCSessionGuards emits an authed_<cmd> wrapper of every
+// session-protected command, calling the function handlerFn names.
+func writeCSessionGuards(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string, handlerFn func(gen.Command) string) {
+	upper := strings.ToUpper(pkg)
+	for _, g := range gen.GroupCommands(commands, "per-group", pkg) {
+		var guarded []gen.Command
+		for _, cmd := range g.Commands {
+			if cmd.SessionProtected {
+				guarded = append(guarded, cmd)
+			}
+		}
+		if len(guarded) == 0 {
+			continue
+		}
+		b.WriteString("#if " + cGroupMacro(pkg, g.Name) + "\n")
+		for i, cmd := range guarded {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			pad := strings.Repeat(" ", len(cmd.Snake))
+			b.WriteString(fmt.Sprintf("static int authed_%s(const uint8_t *req_data, size_t req_len,\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("                   %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    if (req_len < %s_SESSION_TOKEN_SIZE || !%s_session_check(req_data)) {\n", upper, pkg))
+			b.WriteString(fmt.Sprintf("        return %s_return_error(ostream, %s_STATUS_UNAUTHENTICATED);\n", pkg, upper))
+			b.WriteString("    }\n")
+			inner := handlerFn(cmd)
+			b.WriteString(fmt.Sprintf("    return %s(req_data + %s_SESSION_TOKEN_SIZE,\n", inner, upper))
+			b.WriteString(fmt.Sprintf("            %sreq_len - %s_SESSION_TOKEN_SIZE, %s);\n",
+				strings.Repeat(" ", len(inner)), upper, cHandlerOutArg(gctx, "ostream")))
+			b.WriteString("}\n")
+		}
+		b.WriteString("#endif\n")
+		b.WriteByte('\n')
+	}
+}
+
+// cGuardedHandler returns the function the handler table points at for a
+// command: its session guard, then its replay guard, then handlerFn's.
+func cGuardedHandler(cmd gen.Command, handlerFn func(gen.Command) string) string {
+	switch {
+	case cmd.SessionProtected:
+		return "authed_" + cmd.Snake
+	case cmd.ReplayProtected:
+		return "guarded_" + cmd.Snake
+	}
+	return handlerFn(cmd)
+}
@@ -0,0 +1,125 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCSettingsDecl emits the storage hooks of get_setting and set_setting.
+func writeCSettingsDecl(b *strings.Builder, cmd gen.Command, pkg string) {
+	msg := pkg + "_" + cmd.Settings.Name
+	b.WriteString(fmt.Sprintf("#include \"%s.pb.h\"\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("/* Storage of the %s fields read by get_setting and written by\n", cmd.Settings.Name))
+	b.WriteString(fmt.Sprintf(" * set_setting. field is a %s_<field>_tag value; load\n", msg))
+	b.WriteString(" * fills in that field of settings, store persists it, e.g. with the Zephyr\n")
+	b.WriteString(" * settings subsystem. Return 0 on success; the weak defaults return -1. */\n")
+	b.WriteString(fmt.Sprintf("int %s_setting_load(uint32_t field, %s *settings);\n", pkg, msg))
+	b.WriteString(fmt.Sprintf("int %s_setting_store(uint32_t field, const %s *settings);\n", pkg, msg))
+	b.WriteByte('\n')
+}
+
+// writeCSettingsFieldCheck emits the rejection of field numbers the
+// settings message does not define.
+func writeCSettingsFieldCheck(b *strings.Builder, settings *gen.Message, pkg string) {
+	b.WriteString("    switch (req.field) {\n")
+	for _, f := range settings.Fields {
+		b.WriteString(fmt.Sprintf("    case %s_%s_%s_tag:\n", pkg, settings.Name, f.Name))
+	}
+	b.WriteString("        break;\n")
+	b.WriteString("    default:\n")
+	b.WriteString("        return -1;\n")
+	b.WriteString("    }\n")
+}
+
+// writeCGetSettingHandler emits the weak load hook and the get_setting
+// handler, which encodes the loaded settings into the response value.
+func writeCGetSettingHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	msg := pkg + "_" + cmd.Settings.Name
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_setting_load(uint32_t field, %s *settings)\n", pkg, msg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString("    (void)settings;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool encode_setting_value(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                                 void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    if (!pb_encode_tag_for_field(stream, field)) return false;\n")
+	b.WriteString(fmt.Sprintf("    return pb_encode_submessage(stream, %s_fields, *arg);\n", msg))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	writeCSettingsFieldCheck(b, cmd.Settings, pkg)
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s settings = %s_init_zero;\n", msg, msg))
+	b.WriteString(fmt.Sprintf("    if (%s_setting_load(req.field, &settings) != 0) return -1;\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    resp.value.funcs.encode = encode_setting_value;\n")
+	b.WriteString("    resp.value.arg = &settings;\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCSetSettingHandler emits the weak store hook and the set_setting
+// handler. The store runs on the sizing pass, which comes first, so a failed
+// store ends the request before a response is sent.
+func writeCSetSettingHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	msg := pkg + "_" + cmd.Settings.Name
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_setting_store(uint32_t field, const %s *settings)\n", pkg, msg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString("    (void)settings;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool decode_setting_value(pb_istream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                                 void **arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)field;\n")
+	b.WriteString(fmt.Sprintf("    return pb_decode(stream, %s_fields, *arg);\n", msg))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s settings = %s_init_zero;\n", msg, msg))
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    req.value.funcs.decode = decode_setting_value;\n")
+	b.WriteString("    req.value.arg = &settings;\n")
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	writeCSettingsFieldCheck(b, cmd.Settings, pkg)
+	b.WriteString(fmt.Sprintf("    if (ostream->callback == NULL && %s_setting_store(req.field, &settings) != 0) {\n", pkg))
+	b.WriteString("        return -1;\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,139 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// An FT_CALLBACK bytes field of a response, e.g. a log dump, has no storage
+// in the nanopb struct either, so it can be larger than the RAM of the
+// peripheral. The weak handler stubs of unary commands encode it from a pair
+// of source hooks: <pkg>_<cmd>_length_<field> returns its length for the
+// request, and <pkg>_<cmd>_source_<field> fills it a chunk at a time as the
+// response is encoded. The sizing pass only asks for the length; on the
+// writing pass the chunks go straight to the container stream, so an app
+// reading flash or a file never holds more than a chunk. The weak length
+// hook returns 0, which leaves the field out, and the weak source hook
+// fails. The response is an ordinary message on the wire, which clients
+// reassemble like any other; the C central client decodes the field into
+// the buffer its caller passes.
+
+// commandsWithSourceHooks returns the commands whose handler stubs call
+// source hooks: unary commands with FT_CALLBACK response fields, except
+// built-ins, which encode their own fields, and typed handlers, which set
+// the callbacks of the response themselves.
+func commandsWithSourceHooks(commands []gen.Command, streaming map[string]string, callbacks map[string]bool) []gen.Command {
+	var hooked []gen.Command
+	for _, cmd := range commands {
+		if _, ok := streaming[cmd.Snake]; ok || cmd.Builtin != "" || cmd.TypedHandler {
+			continue
+		}
+		if hasCallbackField(cmd.ResponseMsg, cmd.ResponseFields, callbacks) {
+			hooked = append(hooked, cmd)
+		}
+	}
+	return hooked
+}
+
+// cLengthHookName and cSourceHookName name the source hooks of field f of
+// the response of cmd.
+func cLengthHookName(cmd gen.Command, f gen.Field, pkg string) string {
+	return fmt.Sprintf("%s_%s_length_%s", pkg, cmd.Snake, f.Name)
+}
+
+func cSourceHookName(cmd gen.Command, f gen.Field, pkg string) string {
+	return fmt.Sprintf("%s_%s_source_%s", pkg, cmd.Snake, f.Name)
+}
+
+// cSourceHookParams returns the parameter list of a source hook, wrapped
+// after the request like the read hooks.
+func cSourceHookParams(cmd gen.Command, f gen.Field, pkg string) string {
+	pad := strings.Repeat(" ", len("int ")+len(cSourceHookName(cmd, f, pkg))+1)
+	return fmt.Sprintf("(const %s_%s *req, uint8_t *chunk,\n%ssize_t len, size_t offset)", pkg, cmd.RequestMsg, pad)
+}
+
+// writeCSourceHookDecls declares the source hooks in the handler header.
+func writeCSourceHookDecls(b *strings.Builder, commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) {
+	hooked := commandsWithSourceHooks(commands, streaming, callbacks)
+	if len(hooked) == 0 {
+		return
+	}
+	b.WriteString("/* Source hooks of the FT_CALLBACK bytes fields of the responses. The\n")
+	b.WriteString(" * length hook returns the length of the field for req, 0 to leave it out;\n")
+	b.WriteString(" * the source hook fills chunk with the len bytes of the field at offset\n")
+	b.WriteString(" * and returns 0, or a negative value to fail the request. The chunks go\n")
+	b.WriteString(" * out as they are filled, so the field may exceed the RAM of the device.\n")
+	b.WriteString(" * The weak length hooks return 0 and the weak source hooks fail. */\n")
+	for _, cmd := range hooked {
+		for _, f := range cmd.ResponseFields {
+			if callbacks[cmd.ResponseMsg+"."+f.Name] {
+				b.WriteString(fmt.Sprintf("size_t %s(const %s_%s *req);\n", cLengthHookName(cmd, f, pkg), pkg, cmd.RequestMsg))
+				b.WriteString(fmt.Sprintf("int %s%s;\n", cSourceHookName(cmd, f, pkg), cSourceHookParams(cmd, f, pkg)))
+			}
+		}
+	}
+	b.WriteByte('\n')
+}
+
+// writeCSourceHooks emits the weak source hooks of the FT_CALLBACK response
+// fields of cmd and the encode callbacks that pull from them.
+func writeCSourceHooks(b *strings.Builder, cmd gen.Command, callbacks map[string]bool, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	for _, f := range cmd.ResponseFields {
+		if !callbacks[cmd.ResponseMsg+"."+f.Name] {
+			continue
+		}
+		length, source := cLengthHookName(cmd, f, pkg), cSourceHookName(cmd, f, pkg)
+		b.WriteString("__attribute__((weak))\n")
+		b.WriteString(fmt.Sprintf("size_t %s(const %s *req)\n", length, reqMsg))
+		b.WriteString("{\n")
+		b.WriteString("    (void)req;\n")
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+		b.WriteString("__attribute__((weak))\n")
+		b.WriteString(fmt.Sprintf("int %s%s\n", source, cSourceHookParams(cmd, f, pkg)))
+		b.WriteString("{\n")
+		b.WriteString("    (void)req;\n")
+		b.WriteString("    (void)chunk;\n")
+		b.WriteString("    (void)len;\n")
+		b.WriteString("    (void)offset;\n")
+		b.WriteString("    return -1;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("static bool write_%s_%s(pb_ostream_t *stream, const pb_field_t *field,\n", cmd.Snake, f.Name))
+		b.WriteString(fmt.Sprintf("%svoid *const *arg)\n", strings.Repeat(" ", len("static bool write_")+len(cmd.Snake)+len(f.Name)+2)))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    const %s *req = *arg;\n", reqMsg))
+		b.WriteString(fmt.Sprintf("    size_t len = %s(req);\n", length))
+		b.WriteString("    if (len == 0) return true;\n")
+		b.WriteString("    if (!pb_encode_tag_for_field(stream, field)) return false;\n")
+		b.WriteString("    if (!pb_encode_varint(stream, len)) return false;\n")
+		b.WriteString("    /* The sizing pass counts the bytes without reading them. */\n")
+		b.WriteString("    if (stream->callback == NULL) return pb_write(stream, NULL, len);\n")
+		b.WriteString("    uint8_t buf[64];\n")
+		b.WriteString("    size_t offset = 0;\n")
+		b.WriteString("    while (offset < len) {\n")
+		b.WriteString("        size_t n = len - offset < sizeof(buf) ? len - offset : sizeof(buf);\n")
+		b.WriteString(fmt.Sprintf("        if (%s(req, buf, n, offset) != 0) return false;\n", source))
+		b.WriteString("        if (!pb_write(stream, buf, n)) return false;\n")
+		b.WriteString("        offset += n;\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return true;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+}
+
+// writeCInstallSourceHooks emits the statements installing the encode
+// callbacks of the FT_CALLBACK response fields of cmd on resp.
+func writeCInstallSourceHooks(b *strings.Builder, cmd gen.Command, callbacks map[string]bool) {
+	for _, f := range cmd.ResponseFields {
+		if callbacks[cmd.ResponseMsg+"."+f.Name] {
+			b.WriteString(fmt.Sprintf("    resp.%s.funcs.encode = write_%s_%s;\n", f.Name, cmd.Snake, f.Name))
+			b.WriteString(fmt.Sprintf("    resp.%s.arg = &req;\n", f.Name))
+		}
+	}
+}
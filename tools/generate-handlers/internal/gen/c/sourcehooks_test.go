@@ -0,0 +1,50 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestCSourceHooks(t *testing.T) {
+	gctx := gen.NewContext()
+	read := gentest.EchoCommand()
+	read.Camel, read.Snake, read.ResponseMsg = "LogDump", "log_dump", "LogDumpResponse"
+	read.ResponseFields = []gen.Field{{Type: "bytes", Name: "log", Number: 1}}
+	callbacks := map[string]bool{"LogDumpResponse.log": true}
+	cmds := []gen.Command{read}
+
+	header := GenerateHeader(gctx, cmds, nil, callbacks, "blerpc")
+	for _, s := range []string{
+		`#include "blerpc.pb.h"`,
+		"size_t blerpc_log_dump_length_log(const blerpc_EchoRequest *req);",
+		"int blerpc_log_dump_source_log(const blerpc_EchoRequest *req, uint8_t *chunk,\n" +
+			"                               size_t len, size_t offset);",
+	} {
+		if !strings.Contains(header, s) {
+			t.Errorf("C header missing %q\nGot:\n%s", s, header)
+		}
+	}
+
+	src := GenerateSource(gctx, cmds, nil, callbacks, "blerpc")
+	for _, s := range []string{
+		"__attribute__((weak))\nsize_t blerpc_log_dump_length_log(const blerpc_EchoRequest *req)\n",
+		"static bool write_log_dump_log(pb_ostream_t *stream, const pb_field_t *field,\n" +
+			"                               void *const *arg)",
+		"    if (stream->callback == NULL) return pb_write(stream, NULL, len);\n",
+		"        if (blerpc_log_dump_source_log(req, buf, n, offset) != 0) return false;\n",
+		"    resp.log.funcs.encode = write_log_dump_log;\n    resp.log.arg = &req;\n    if (!pb_encode(",
+	} {
+		if !strings.Contains(src, s) {
+			t.Errorf("C source missing %q\nGot:\n%s", s, src)
+		}
+	}
+
+	// Stream responses go out through the emit functions.
+	src = GenerateSource(gctx, cmds, map[string]string{"log_dump": "p2c"}, callbacks, "blerpc")
+	if strings.Contains(src, "blerpc_log_dump_source_log") {
+		t.Error("p2c handlers should not get source hooks")
+	}
+}
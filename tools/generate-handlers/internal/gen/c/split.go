@@ -0,0 +1,64 @@
+package c
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// SplitSource returns the handler table source at path and one source of
+// weak handler stubs per group next to it.
+func SplitSource(gctx *gen.Context, groups []gen.CommandGroup, commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg, runtime, path string) []gen.Output {
+	// The stream support, including the client-streaming handlers, shares
+	// state and stays in the index.
+	streams := runtime != "protobuf-c" && hasCStreams(commands, streaming)
+	uploads := cStreamCommands(commands, streaming, "c2p")
+	var index strings.Builder
+	index.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	index.WriteString("#include \"generated_handlers.h\"\n")
+	if streams {
+		index.WriteString("#include \"" + pkg + ".pb.h\"\n")
+		index.WriteString("#include <pb_encode.h>\n")
+		if len(uploads) > 0 {
+			index.WriteString("#include <pb_decode.h>\n")
+		}
+	}
+	index.WriteString("#include <string.h>\n")
+	index.WriteByte('\n')
+	if runtime != "protobuf-c" {
+		writeCMaxSizeAsserts(&index, commands, pkg)
+	}
+	if streams {
+		writeCStreamSupport(gctx, &index, commands, streaming, callbacks, pkg)
+	}
+	writeCHandlerTable(gctx, &index, commands, pkg, runtime)
+	writeCGattGroupLookup(gctx, &index, commands, pkg)
+	outputs := []gen.Output{{Path: path, Content: index.String()}}
+
+	for _, g := range groups {
+		var b strings.Builder
+		b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+		b.WriteString("#include \"generated_handlers.h\"\n")
+		if runtime == "protobuf-c" {
+			b.WriteString("#include \"" + pkg + ".pb-c.h\"\n")
+			b.WriteByte('\n')
+			writeCHandlerStubsProtobufC(gctx, &b, g.Commands, pkg)
+		} else {
+			b.WriteString("#include \"" + pkg + ".pb.h\"\n")
+			b.WriteString("#include <pb_encode.h>\n")
+			b.WriteString("#include <pb_decode.h>\n")
+			if _, ok := gen.BuiltinCommand(g.Commands, "log_stream"); ok {
+				b.WriteString("#include <string.h>\n")
+			}
+			b.WriteByte('\n')
+			if discardsCallbackFields(g.Commands, streaming, callbacks) {
+				writeCDiscardCallback(&b)
+			}
+			b.WriteString(gctx.RenderTemplate("handler_stubs.c.tmpl", newStubsData(gctx, g.Commands, streaming, callbacks, pkg)))
+		}
+		content := strings.TrimSuffix(b.String(), "\n")
+		outputs = append(outputs, gen.Output{Path: gen.SplitPath(path, "_", protomodel.CamelToSnake(g.Name)), Content: content})
+	}
+	return outputs
+}
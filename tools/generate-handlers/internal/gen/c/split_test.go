@@ -0,0 +1,58 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func outputsByPath(outputs []gen.Output) map[string]string {
+	byPath := make(map[string]string)
+	for _, out := range outputs {
+		byPath[out.Path] = out.Content
+	}
+	return byPath
+}
+
+func TestSplitCSource(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.CallbackCommand()}
+	callbacks := map[string]bool{"DataWriteRequest.data": true}
+	groups := gen.GroupCommands(cmds, "per-command", "blerpc")
+	files := outputsByPath(SplitSource(gctx, groups, cmds, nil, callbacks, "blerpc", "nanopb", "src/generated_handlers.c"))
+
+	index := files["src/generated_handlers.c"]
+	for _, s := range []string{
+		"{\"echo\", 4, handle_echo},",
+		"{\"data_write\", 10, handle_data_write},",
+		"command_handler_fn handlers_lookup(const char *name, uint8_t name_len)",
+	} {
+		if !strings.Contains(index, s) {
+			t.Errorf("index missing %q\nGot:\n%s", s, index)
+		}
+	}
+	if strings.Contains(index, "__attribute__((weak))") {
+		t.Error("index should not hold handler stubs")
+	}
+
+	echo := files["src/generated_handlers_echo.c"]
+	if !strings.Contains(echo, "int handle_echo(") || strings.Contains(echo, "discard_bytes_cb") {
+		t.Errorf("unexpected echo stubs:\n%s", echo)
+	}
+	if strings.HasSuffix(echo, "\n\n") {
+		t.Error("stub file should end with a single newline")
+	}
+	dataWrite := files["src/generated_handlers_data_write.c"]
+	if !strings.Contains(dataWrite, "static bool discard_bytes_cb(") ||
+		!strings.Contains(dataWrite, "req.data.funcs.decode = discard_bytes_cb;") {
+		t.Errorf("data_write stubs missing discard callback:\n%s", dataWrite)
+	}
+
+	pbc := outputsByPath(SplitSource(gctx, groups, cmds, nil, nil, "blerpc", "protobuf-c", "generated_handlers.c"))
+	if s := pbc["generated_handlers_echo.c"]; !strings.Contains(s, "#include \"blerpc.pb-c.h\"") ||
+		!strings.Contains(s, "ProtobufCBuffer *out)") {
+		t.Errorf("unexpected protobuf-c stubs:\n%s", s)
+	}
+}
@@ -0,0 +1,184 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The rpc_stats built-in counts calls, errors and the longest duration of
+// every command in the nanopb handler table. The table points at counting
+// wrappers instead of the handlers, so the dispatcher needs no changes, and
+// get_rpc_stats reports the counters to the client.
+
+// writeCRPCStatsDecl emits the counter struct and accessors.
+func writeCRPCStatsDecl(b *strings.Builder, pkg string) {
+	b.WriteString("/* Per-command counters kept by the handler table for get_rpc_stats. */\n")
+	b.WriteString(fmt.Sprintf("struct %s_rpc_stat {\n", pkg))
+	b.WriteString("    const char *name;\n")
+	b.WriteString("    uint8_t name_len;\n")
+	b.WriteString("    uint32_t calls;\n")
+	b.WriteString("    uint32_t errors;\n")
+	b.WriteString("    uint32_t max_duration_us;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Returns the counters of every command in the handler table and stores\n")
+	b.WriteString(" * their number in count. */\n")
+	b.WriteString(fmt.Sprintf("struct %s_rpc_stat *%s_rpc_stats(size_t *count);\n", pkg, pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Microsecond clock that times the handlers; only differences are used, so\n")
+	b.WriteString(" * it may wrap. The weak default reads the Zephyr uptime and returns 0 (no\n")
+	b.WriteString(" * durations) elsewhere. */\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_rpc_stats_now_us(void);\n", pkg))
+	b.WriteByte('\n')
+}
+
+func rpcStatIndex(cmd gen.Command) string {
+	return "RPC_STAT_" + strings.ToUpper(cmd.Snake)
+}
+
+// writeCRPCStatsTable emits the counters and the counting wrappers the
+// handler table points at. A handler runs twice per request, a sizing pass
+// and a writing pass; a request is counted once, on the writing pass or on a
+// sizing pass that ends it (an error, or a stream handler that sends its own
+// responses).
+func writeCRPCStatsTable(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	groups := cTableRuns(gctx, commands, pkg)
+
+	b.WriteString("/* get_rpc_stats counters, in handler table order. */\n")
+	b.WriteString("enum {\n")
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string { return "    " + rpcStatIndex(cmd) + ",\n" })
+	b.WriteString("    RPC_STAT_COUNT\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("static struct %s_rpc_stat rpc_stats[RPC_STAT_COUNT] = {\n", pkg))
+	writeCTableRuns(gctx, b, commands, pkg, func(cmd gen.Command) string {
+		return fmt.Sprintf("    {\"%s\", %d, 0, 0, 0},\n", cmd.Wire(), len(cmd.Wire()))
+	})
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("struct %s_rpc_stat *%s_rpc_stats(size_t *count)\n", pkg, pkg))
+	b.WriteString("{\n")
+	b.WriteString("    *count = RPC_STAT_COUNT;\n")
+	b.WriteString("    return rpc_stats;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("#include <zephyr/kernel.h>\n")
+	b.WriteString("#endif\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("uint32_t %s_rpc_stats_now_us(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("#ifdef __ZEPHYR__\n")
+	b.WriteString("    return k_ticks_to_us_floor32(k_uptime_ticks());\n")
+	b.WriteString("#else\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("#endif\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static int run_counted(size_t index, " + gctx.CSymbol("command_handler_fn") + " handler,\n")
+	b.WriteString("                       const uint8_t *req_data, size_t req_len,\n")
+	b.WriteString("                       " + cHandlerOutParam(gctx, "pb_ostream_t *ostream") + ")\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    uint32_t start = %s_rpc_stats_now_us();\n", pkg))
+	b.WriteString("    int rc = handler(req_data, req_len, " + cHandlerOutArg(gctx, "ostream") + ");\n")
+	b.WriteString("    /* The sizing pass has no callback; count it only if it ends the request. */\n")
+	b.WriteString("    if (ostream->callback != NULL || rc != 0) {\n")
+	b.WriteString(fmt.Sprintf("        struct %s_rpc_stat *stat = &rpc_stats[index];\n", pkg))
+	b.WriteString(fmt.Sprintf("        uint32_t elapsed = %s_rpc_stats_now_us() - start;\n", pkg))
+	b.WriteString("        stat->calls++;\n")
+	b.WriteString("        if (rc != 0 && rc != -2) {\n")
+	b.WriteString("            stat->errors++;\n")
+	b.WriteString("        }\n")
+	b.WriteString("        if (elapsed > stat->max_duration_us) {\n")
+	b.WriteString("            stat->max_duration_us = elapsed;\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return rc;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	for _, g := range groups {
+		b.WriteString("#if " + cGroupMacro(pkg, g.Name) + "\n")
+		for i, cmd := range g.Commands {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			pad := strings.Repeat(" ", len(cmd.Snake))
+			b.WriteString(fmt.Sprintf("static int counted_%s(const uint8_t *req_data, size_t req_len,\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("                    %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+			b.WriteString("{\n")
+			b.WriteString(fmt.Sprintf("    return run_counted(%s, %s,\n", rpcStatIndex(cmd), cHandlerFn(gctx, cmd)))
+			b.WriteString("                       req_data, req_len, " + cHandlerOutArg(gctx, "ostream") + ");\n")
+			b.WriteString("}\n")
+		}
+		b.WriteString("#endif\n")
+	}
+	b.WriteByte('\n')
+}
+
+// writeCRPCStatsHandler emits the get_rpc_stats handler, which encodes the
+// counters as repeated RpcStat callbacks.
+func writeCRPCStatsHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	statMsg := pkg + "_RpcStat"
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("static bool encode_rpc_stat_name(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                                 void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    const struct %s_rpc_stat *stat = *arg;\n", pkg))
+	b.WriteString("    return pb_encode_tag_for_field(stream, field) &&\n")
+	b.WriteString("           pb_encode_string(stream, (const pb_byte_t *)stat->name, stat->name_len);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("static bool encode_rpc_stats(pb_ostream_t *stream, const pb_field_t *field,\n")
+	b.WriteString("                             void *const *arg)\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)arg;\n")
+	b.WriteString("    size_t count, i;\n")
+	b.WriteString(fmt.Sprintf("    struct %s_rpc_stat *stats = %s_rpc_stats(&count);\n", pkg, pkg))
+	b.WriteString("    for (i = 0; i < count; i++) {\n")
+	b.WriteString(fmt.Sprintf("        %s msg = %s_init_zero;\n", statMsg, statMsg))
+	b.WriteString("        msg.name.funcs.encode = encode_rpc_stat_name;\n")
+	b.WriteString("        msg.name.arg = &stats[i];\n")
+	b.WriteString("        msg.calls = stats[i].calls;\n")
+	b.WriteString("        msg.errors = stats[i].errors;\n")
+	b.WriteString("        msg.max_duration_us = stats[i].max_duration_us;\n")
+	b.WriteString("        if (!pb_encode_tag_for_field(stream, field) ||\n")
+	b.WriteString(fmt.Sprintf("            !pb_encode_submessage(stream, %s_fields, &msg)) {\n", statMsg))
+	b.WriteString("            return false;\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    resp.stats.funcs.encode = encode_rpc_stats;\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteByte('\n')
+	b.WriteString("    /* Clear after the writing pass, so both passes encode the same counts. */\n")
+	b.WriteString("    if (req.reset && ostream->callback != NULL) {\n")
+	b.WriteString("        size_t count, i;\n")
+	b.WriteString(fmt.Sprintf("        struct %s_rpc_stat *stats = %s_rpc_stats(&count);\n", pkg, pkg))
+	b.WriteString("        for (i = 0; i < count; i++) {\n")
+	b.WriteString("            stats[i].calls = 0;\n")
+	b.WriteString("            stats[i].errors = 0;\n")
+	b.WriteString("            stats[i].max_duration_us = 0;\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,59 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCStatusCodes emits the status enum and the <pkg>_return_error()
+// helper handlers return an error response with. runtime is "nanopb" or
+// "protobuf-c".
+func writeCStatusCodes(b *strings.Builder, pkg, runtime string) {
+	upper := strings.ToUpper(pkg)
+	b.WriteString("/* Status codes of error responses. Codes from 128 up are free for\n")
+	b.WriteString(" * application use. */\n")
+	b.WriteString(fmt.Sprintf("enum %s_status {\n", pkg))
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("    %s_STATUS_%s = %d,\n", upper, sc.Name, i))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/* Field number of the status in an error response; no message uses it. */\n")
+	b.WriteString(fmt.Sprintf("#define %s_STATUS_FIELD %d\n", upper, gen.StatusField))
+	b.WriteByte('\n')
+	b.WriteString("/*\n")
+	b.WriteString(" * Write an error response carrying status in place of the response\n")
+	b.WriteString(" * message, e.g.\n")
+	b.WriteString(" *\n")
+	b.WriteString(fmt.Sprintf(" *     return %s_return_error(ostream, %s_STATUS_NOT_FOUND);\n", pkg, upper))
+	b.WriteString(" *\n")
+	b.WriteString(" * Return 0, or -1 if the status does not fit.\n")
+	b.WriteString(" */\n")
+	if runtime == "protobuf-c" {
+		b.WriteString(fmt.Sprintf("static inline int %s_return_error(ProtobufCBuffer *out, uint32_t status)\n", pkg))
+		b.WriteString("{\n")
+		tag := make([]string, len(gen.StatusTag))
+		for i, c := range gen.StatusTag {
+			tag[i] = fmt.Sprintf("0x%02x", c)
+		}
+		b.WriteString(fmt.Sprintf("    uint8_t buf[10] = {%s};\n", strings.Join(tag, ", ")))
+		b.WriteString(fmt.Sprintf("    size_t n = %d;\n", len(gen.StatusTag)))
+		b.WriteString("    while (status >= 0x80) {\n")
+		b.WriteString("        buf[n++] = (uint8_t)(status | 0x80);\n")
+		b.WriteString("        status >>= 7;\n")
+		b.WriteString("    }\n")
+		b.WriteString("    buf[n++] = (uint8_t)status;\n")
+		b.WriteString("    out->append(out, n, buf);\n")
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+	} else {
+		b.WriteString(fmt.Sprintf("static inline int %s_return_error(pb_ostream_t *ostream, uint32_t status)\n", pkg))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    if (!pb_encode_tag(ostream, PB_WT_VARINT, %s_STATUS_FIELD)) return -1;\n", upper))
+		b.WriteString("    return pb_encode_varint(ostream, status) ? 0 : -1;\n")
+		b.WriteString("}\n")
+	}
+	b.WriteByte('\n')
+}
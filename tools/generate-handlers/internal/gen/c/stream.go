@@ -0,0 +1,227 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Peripheral-to-central streams are consumed as they arrive: Python gets an
+// iter_<cmd> async generator, Kotlin a <cmd>Flow method returning a Flow and
+// Swift a <cmd>Responses method returning an AsyncThrowingStream, next to
+// the methods collecting the whole stream into a list. Each holds the RPC
+// lock until the stream ends or the consumer stops.
+//
+// Central-to-peripheral streams are produced as they are sent: the client
+// methods take an iterable or async iterable in Python, a Flow in Kotlin and
+// an AsyncSequence in Swift besides a list, so an upload need not be held in
+// memory at once.
+//
+// On the peripheral a server-streaming handler sends each response with the
+// generated <pkg>_<cmd>_emit(), which encodes it and passes it to the
+// <pkg>_stream_write() hook, and returns <pkg>_stream_end(). The handler of a
+// client stream is generated: it passes each request to
+// <pkg>_<cmd>_accumulate(), and <pkg>_stream_end_c2p() sends the response
+// <pkg>_<cmd>_finalize() fills.
+
+// cStreamCommands returns the streams in direction dir whose handlers the
+// user writes; built-in streams send their responses themselves.
+func cStreamCommands(commands []gen.Command, streaming map[string]string, dir string) []gen.Command {
+	var out []gen.Command
+	for _, cmd := range commands {
+		if streaming[cmd.Snake] == dir && cmd.Builtin == "" {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
+
+// hasCStreams reports whether the C handlers need the stream support.
+func hasCStreams(commands []gen.Command, streaming map[string]string) bool {
+	return len(cStreamCommands(commands, streaming, "p2c")) > 0 || len(cStreamCommands(commands, streaming, "c2p")) > 0
+}
+
+// writeCStreamDecls emits the stream hooks, the emit function of every
+// peripheral-to-central stream and the accumulate and finalize hooks of
+// every central-to-peripheral stream.
+func writeCStreamDecls(b *strings.Builder, commands []gen.Command, streaming map[string]string, pkg string) {
+	if !hasCStreams(commands, streaming) {
+		return
+	}
+	b.WriteString("/* Server-streaming handlers send each response with <pkg>_<cmd>_emit() and\n")
+	b.WriteString(" * finish with return <pkg>_stream_end(), so the dispatcher sends no response\n")
+	b.WriteString(" * of its own. The responses leave through two hooks returning 0 on success,\n")
+	b.WriteString(" * whose weak defaults return -1: stream_write sends one encoded response of\n")
+	b.WriteString(" * cmd_name, e.g. with command_serialize() and\n")
+	b.WriteString(" * ble_service_send_command_response(), and stream_finish ends the stream,\n")
+	b.WriteString(" * e.g. with ble_service_send_stream_end_p2c(). */\n")
+	b.WriteString(fmt.Sprintf("int %s_stream_write(const char *cmd_name, const uint8_t *data, size_t len);\n", pkg))
+	b.WriteString(fmt.Sprintf("int %s_stream_finish(void);\n", pkg))
+	b.WriteByte('\n')
+	b.WriteString("/* Ends the stream; returns -2, or -1 when it could not be ended. */\n")
+	b.WriteString(fmt.Sprintf("int %s_stream_end(void);\n", pkg))
+	b.WriteByte('\n')
+	if len(cStreamCommands(commands, streaming, "p2c")) > 0 {
+		writeCStreamCancelDecl(b, pkg)
+	}
+	for _, cmd := range cStreamCommands(commands, streaming, "p2c") {
+		b.WriteString(fmt.Sprintf("struct _%s_%s;\n", pkg, cmd.ResponseMsg))
+		b.WriteString(fmt.Sprintf("int %s_%s_emit(const struct _%s_%s *resp);\n", pkg, cmd.Snake, pkg, cmd.ResponseMsg))
+		b.WriteByte('\n')
+	}
+
+	uploads := cStreamCommands(commands, streaming, "c2p")
+	if len(uploads) == 0 {
+		return
+	}
+	b.WriteString("/* Client-streaming handlers pass each request of the stream to\n")
+	b.WriteString(" * <pkg>_<cmd>_accumulate(). When the central ends the stream, call\n")
+	b.WriteString(" * <pkg>_stream_end_c2p(), e.g. from a work item submitted by the\n")
+	b.WriteString(" * STREAM_END_C2P callback: it has <pkg>_<cmd>_finalize() fill the response\n")
+	b.WriteString(" * and sends it through <pkg>_stream_write(). The weak defaults discard the\n")
+	b.WriteString(" * requests and leave the response empty. All return 0 on success. */\n")
+	b.WriteString(fmt.Sprintf("int %s_stream_end_c2p(void);\n", pkg))
+	b.WriteByte('\n')
+	for _, cmd := range uploads {
+		b.WriteString(fmt.Sprintf("struct _%s_%s;\n", pkg, cmd.RequestMsg))
+		b.WriteString(fmt.Sprintf("struct _%s_%s;\n", pkg, cmd.ResponseMsg))
+		b.WriteString(fmt.Sprintf("int %s_%s_accumulate(const struct _%s_%s *req);\n", pkg, cmd.Snake, pkg, cmd.RequestMsg))
+		b.WriteString(fmt.Sprintf("int %s_%s_finalize(struct _%s_%s *resp);\n", pkg, cmd.Snake, pkg, cmd.ResponseMsg))
+		b.WriteByte('\n')
+	}
+}
+
+// writeCStreamSupport emits the weak stream hooks, <pkg>_stream_end, the
+// emit functions and the client-streaming handlers. Responses with
+// FT_CALLBACK fields have no nanopb size bound and are encoded into
+// <PKG>_STREAM_BUF_SIZE bytes.
+func writeCStreamSupport(gctx *gen.Context, b *strings.Builder, commands []gen.Command, streaming map[string]string, callbacks map[string]bool, pkg string) {
+	if !hasCStreams(commands, streaming) {
+		return
+	}
+	bufMacro := strings.ToUpper(pkg) + "_STREAM_BUF_SIZE"
+	respBuf := func(cmd gen.Command) string {
+		if hasCallbackField(cmd.ResponseMsg, cmd.ResponseFields, callbacks) {
+			return bufMacro
+		}
+		return pkg + "_" + cmd.ResponseMsg + "_size"
+	}
+	streams := append(cStreamCommands(commands, streaming, "p2c"), cStreamCommands(commands, streaming, "c2p")...)
+	for _, cmd := range streams {
+		if respBuf(cmd) == bufMacro {
+			b.WriteString("#ifndef " + bufMacro + "\n")
+			b.WriteString("#define " + bufMacro + " 256\n")
+			b.WriteString("#endif\n")
+			b.WriteByte('\n')
+			break
+		}
+	}
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_stream_write(const char *cmd_name, const uint8_t *data, size_t len)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)cmd_name;\n")
+	b.WriteString("    (void)data;\n")
+	b.WriteString("    (void)len;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_stream_finish(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	cancellable := len(cStreamCommands(commands, streaming, "p2c")) > 0
+	if cancellable {
+		writeCStreamCancel(b, pkg)
+	}
+	b.WriteString(fmt.Sprintf("int %s_stream_end(void)\n", pkg))
+	b.WriteString("{\n")
+	if cancellable {
+		b.WriteString("    stream_cancelled = false;\n")
+	}
+	b.WriteString(fmt.Sprintf("    return %s_stream_finish() == 0 ? -2 : -1;\n", pkg))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	for _, cmd := range cStreamCommands(commands, streaming, "p2c") {
+		respMsg := pkg + "_" + cmd.ResponseMsg
+		b.WriteString(fmt.Sprintf("int %s_%s_emit(const %s *resp)\n", pkg, cmd.Snake, respMsg))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    uint8_t buf[%s];\n", respBuf(cmd)))
+		b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));\n")
+		b.WriteString(fmt.Sprintf("    if (!pb_encode(&out, %s_fields, resp)) return -1;\n", respMsg))
+		b.WriteString(fmt.Sprintf("    return %s_stream_write(\"%s\", buf, out.bytes_written);\n", pkg, cmd.Wire()))
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+
+	uploads := cStreamCommands(commands, streaming, "c2p")
+	if len(uploads) == 0 {
+		return
+	}
+	b.WriteString("/* Finalizes the client stream in progress; set by its requests. */\n")
+	b.WriteString("static int (*stream_c2p_end)(void);\n")
+	b.WriteByte('\n')
+	for _, cmd := range uploads {
+		writeCClientStreamHandler(gctx, b, cmd, callbacks, pkg, respBuf(cmd))
+	}
+	b.WriteString(fmt.Sprintf("int %s_stream_end_c2p(void)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    int (*end)(void) = stream_c2p_end;\n")
+	b.WriteString("    stream_c2p_end = NULL;\n")
+	b.WriteString("    return end != NULL ? end() : -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCClientStreamHandler emits the weak accumulate and finalize hooks of
+// a central-to-peripheral stream, the function sending its response, and its
+// handler, which replies to no request of the stream.
+func writeCClientStreamHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, callbacks map[string]bool, pkg, respBuf string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	writeCReadHooks(b, cmd, callbacks, pkg)
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_%s_accumulate(const %s *req)\n", pkg, cmd.Snake, reqMsg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)req;\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_%s_finalize(%s *resp)\n", pkg, cmd.Snake, respMsg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)resp;\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("static int end_%s(void)\n", cmd.Snake))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString(fmt.Sprintf("    if (%s_%s_finalize(&resp) != 0) return -1;\n", pkg, cmd.Snake))
+	b.WriteString(fmt.Sprintf("    uint8_t buf[%s];\n", respBuf))
+	b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(&out, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString(fmt.Sprintf("    return %s_stream_write(\"%s\", buf, out.bytes_written);\n", pkg, cmd.Wire()))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    (void)ostream; /* Not used — the response goes out from %s_stream_end_c2p */\n", pkg))
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	writeCInstallReadHooks(b, cmd, callbacks, false)
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteString(fmt.Sprintf("    if (%s_%s_accumulate(&req) != 0) return -1;\n", pkg, cmd.Snake))
+	b.WriteString(fmt.Sprintf("    stream_c2p_end = end_%s;\n", cmd.Snake))
+	b.WriteByte('\n')
+	b.WriteString("    /* Return -2: the requests of a stream get no response */\n")
+	b.WriteString("    return -2;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,58 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The time_sync built-in sets the peripheral's wall clock from the central.
+// The request carries the central's clock in microseconds since the Unix
+// epoch plus an offset the peripheral adds before handing it to the
+// <pkg>_time_set() hook. The clients' sync_time helpers send an offset of 0,
+// or, compensated, half the round trip of a first exchange: the estimated
+// delay before the peripheral applies the time.
+
+// writeCTimeSyncDecl emits the hook the time_sync handler calls.
+func writeCTimeSyncDecl(b *strings.Builder, pkg string) {
+	b.WriteString("/* Sets the wall clock to unix_time_us, microseconds since the Unix epoch,\n")
+	b.WriteString(" * for time_sync, e.g. with clock_settime(). Returns 0 on success; the weak\n")
+	b.WriteString(" * default returns -1, so time_sync fails until it is overridden. */\n")
+	b.WriteString(fmt.Sprintf("int %s_time_set(int64_t unix_time_us);\n", pkg))
+	b.WriteByte('\n')
+}
+
+// writeCTimeSyncHandler emits the weak hook stub and the time_sync handler,
+// which applies the compensated time once and echoes it.
+func writeCTimeSyncHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := gctx.CHandlerPad(cmd.Snake)
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s_time_set(int64_t unix_time_us)\n", pkg))
+	b.WriteString("{\n")
+	b.WriteString("    (void)unix_time_us;\n")
+	b.WriteString("    return -1;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", gctx.CHandlerName(cmd.Snake)))
+	b.WriteString(fmt.Sprintf("                %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    resp.applied_time_us = req.unix_time_us + (int64_t)req.offset_us;\n")
+	b.WriteString("    /* Set the clock once, on the sizing pass, which comes first. */\n")
+	b.WriteString(fmt.Sprintf("    if (ostream->callback == NULL && %s_time_set(resp.applied_time_us) != 0) {\n", pkg))
+	b.WriteString("        return -1;\n")
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,75 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func HasTypedHandlers(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		if cmd.TypedHandler {
+			return true
+		}
+	}
+	return false
+}
+
+// cHandlerFn returns the function with the raw handler signature that the
+// handler table calls for cmd.
+func cHandlerFn(gctx *gen.Context, cmd gen.Command) string {
+	if cmd.TypedHandler {
+		return gctx.CSymbol("dispatch_" + cmd.Snake)
+	}
+	return gctx.CHandlerName(cmd.Snake)
+}
+
+// cTypedHandlerSignature returns the prototype of the typed handler of cmd,
+// without the trailing semicolon or body.
+func cTypedHandlerSignature(gctx *gen.Context, cmd gen.Command, pkg string) string {
+	return fmt.Sprintf("int %s(const %s_%s *req, %s)", gctx.CHandlerName(cmd.Snake), pkg, cmd.RequestMsg, cHandlerOutParam(gctx, pkg+"_"+cmd.ResponseMsg+" *resp"))
+}
+
+// writeCTypedHandlerDecls emits the typed handler and its dispatcher.
+func writeCTypedHandlerDecls(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	pad := strings.Repeat(" ", len(cHandlerFn(gctx, cmd)))
+	b.WriteString(cTypedHandlerSignature(gctx, cmd, pkg) + ";\n")
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", cHandlerFn(gctx, cmd)))
+	b.WriteString(fmt.Sprintf("     %s%s);\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+}
+
+// writeCTypedHandler emits the weak typed handler stub, which replies with an
+// empty response, and the dispatcher that decodes and encodes around it.
+func writeCTypedHandler(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	pad := strings.Repeat(" ", len(cHandlerFn(gctx, cmd)))
+
+	b.WriteString("__attribute__((weak))\n")
+	b.WriteString(cTypedHandlerSignature(gctx, cmd, pkg) + "\n")
+	b.WriteString("{\n")
+	b.WriteString("    (void)req;\n")
+	b.WriteString("    (void)resp;\n")
+	if gctx.CHandlerCtx {
+		b.WriteString("    (void)ctx;\n")
+	}
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("int %s(const uint8_t *req_data, size_t req_len,\n", cHandlerFn(gctx, cmd)))
+	b.WriteString(fmt.Sprintf("     %s%s)\n", pad, cHandlerOutParam(gctx, "pb_ostream_t *ostream")))
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+	b.WriteString("    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_decode(&stream, %s_fields, &req)) return -1;\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("    int rc = %s(&req, %s);\n", gctx.CHandlerName(cmd.Snake), cHandlerOutArg(gctx, "&resp")))
+	b.WriteString("    if (rc != 0) return rc;\n")
+	b.WriteString(fmt.Sprintf("    if (!pb_encode(ostream, %s_fields, &resp)) return -1;\n", respMsg))
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,68 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateTypedHandlers(t *testing.T) {
+	gctx := gen.NewContext()
+	typed := gentest.EchoCommand()
+	typed.TypedHandler = true
+	commands := []gen.Command{typed, gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+
+	header := GenerateHeader(gctx, commands, streaming, nil, "blerpc")
+	for _, want := range []string{
+		`#include "blerpc.pb.h"`,
+		"int handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp);",
+		"int dispatch_echo(const uint8_t *req_data, size_t req_len,",
+		"int handle_counter_stream(const uint8_t *req_data, size_t req_len,",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+	if strings.Contains(header, "int handle_echo(const uint8_t") {
+		t.Error("header should not declare a raw echo handler")
+	}
+
+	src := GenerateSource(gctx, commands, streaming, nil, "blerpc")
+	for _, want := range []string{
+		"__attribute__((weak))\nint handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp)\n",
+		"    if (!pb_decode(&stream, blerpc_EchoRequest_fields, &req)) return -1;",
+		"    int rc = handle_echo(&req, &resp);\n    if (rc != 0) return rc;",
+		"    if (!pb_encode(ostream, blerpc_EchoResponse_fields, &resp)) return -1;",
+		`{"echo", 4, dispatch_echo},`,
+		`{"counter_stream", 14, handle_counter_stream},`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	typed.ReplayProtected = true
+	src = GenerateSource(gctx, []gen.Command{typed}, nil, nil, "blerpc")
+	if !strings.Contains(src, "return dispatch_echo(req_data + 24, req_len - 24, ostream);") {
+		t.Error("replay guard should call the dispatcher")
+	}
+}
+
+func TestScaffoldCHandlers_Typed(t *testing.T) {
+	gctx := gen.NewContext()
+	typed := gentest.EchoCommand()
+	typed.TypedHandler = true
+	got, added := ScaffoldHandlers(gctx, []gen.Command{typed}, "blerpc", "", nil)
+	if len(added) != 1 {
+		t.Fatalf("added = %v", added)
+	}
+	if !strings.Contains(got, "int handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp)\n{\n") {
+		t.Errorf("scaffold missing typed stub:\n%s", got)
+	}
+	if strings.Contains(got, "pb_decode(") {
+		t.Error("typed stub should not decode the request")
+	}
+}
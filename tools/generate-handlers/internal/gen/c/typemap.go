@@ -0,0 +1,41 @@
+package c
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// cTypes maps proto field types to C types (for function parameters).
+var cTypes = map[string]string{
+	"string":   "const char *",
+	"bytes":    "const uint8_t *",
+	"uint32":   "uint32_t",
+	"int32":    "int32_t",
+	"uint64":   "uint64_t",
+	"int64":    "int64_t",
+	"sint32":   "int32_t",
+	"sint64":   "int64_t",
+	"fixed32":  "uint32_t",
+	"fixed64":  "uint64_t",
+	"sfixed32": "int32_t",
+	"sfixed64": "int64_t",
+	"float":    "float",
+	"double":   "double",
+	"bool":     "bool",
+}
+
+func ResolveType(f gen.Field, pkg string) string {
+	if f.IsEnum {
+		return "int32_t"
+	}
+	if protomodel.IsWellKnownType(f.Type) {
+		return wellKnownCType(f.Type)
+	}
+	if f.IsMessage {
+		return gen.MessageTypeName(f.Type, f.TypeFile, "c", pkg)
+	}
+	if t, ok := cTypes[f.Type]; ok {
+		return t
+	}
+	return "uint32_t"
+}
@@ -0,0 +1,211 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// With -scaffold and -out-c-tests, every command gets a Unity test file of
+// its C handler: test_<cmd>.c encodes a request with pb_encode, runs the
+// handler found by handlers_lookup the way the dispatcher does (a sizing
+// pass, then the writing pass) and decodes the response, leaving the firmware
+// team to fill in the request and the assertions. Like the handler scaffold
+// the files are the user's: one that exists is never rewritten, so commands
+// added to the schema later only gain new files.
+//
+// Streaming handlers answer through <pkg>_stream_write(), so their tests
+// define the stream hooks to capture the responses in place of the BLE
+// transport.
+
+// unityDefaultBufSize sizes the buffers of messages without a max size.
+const unityDefaultBufSize = 256
+
+// unityMaxStreamed bounds the responses a server-streaming test captures.
+const unityMaxStreamed = 8
+
+// unityBufSize renders the buffer size of the request ("REQ") or response
+// ("RESP") of cmd: its max size constant when the message is bounded.
+func unityBufSize(cmd gen.Command, pkg, kind string) string {
+	size := cmd.MaxRequestSize
+	if kind == "RESP" {
+		size = cmd.MaxResponseSize
+	}
+	if size == gen.UnboundedSize {
+		return fmt.Sprint(unityDefaultBufSize)
+	}
+	return cMaxSizeMacro(cmd, pkg, kind)
+}
+
+// GenerateUnityTest returns the Unity test file of the handler of cmd.
+// stream is the streaming direction of cmd ("p2c", "c2p" or "").
+func GenerateUnityTest(gctx *gen.Context, cmd gen.Command, stream, pkg string) string {
+	reqMsg := pkg + "_" + cmd.RequestMsg
+	respMsg := pkg + "_" + cmd.ResponseMsg
+	ctxArg := ""
+	if gctx.CHandlerCtx {
+		ctxArg = ", NULL"
+	}
+
+	var b strings.Builder
+	b.WriteString("/*\n")
+	b.WriteString(fmt.Sprintf(" * Unity tests of the %s handler.\n", cmd.Snake))
+	b.WriteString(" *\n")
+	b.WriteString(" * Created by generate-handlers -scaffold. This file is yours to edit: the\n")
+	b.WriteString(" * generator never rewrites it. Build it with Unity, nanopb, the generated\n")
+	b.WriteString(" * handlers and your handler implementations.\n")
+	b.WriteString(" */\n")
+	b.WriteString("#include \"generated_handlers.h\"\n")
+	b.WriteString("#include \"" + pkg + ".pb.h\"\n")
+	b.WriteString("#include <pb_encode.h>\n")
+	b.WriteString("#include <pb_decode.h>\n")
+	b.WriteString("#include <string.h>\n")
+	b.WriteString("#include <unity.h>\n")
+	b.WriteByte('\n')
+
+	// The session guard strips the token first, then the replay guard the
+	// counter and MAC, so the token leads.
+	var prefix []string
+	if cmd.SessionProtected {
+		prefix = append(prefix, fmt.Sprintf("%s_SESSION_TOKEN_SIZE", strings.ToUpper(pkg)))
+	}
+	if cmd.ReplayProtected {
+		prefix = append(prefix, fmt.Sprint(gen.ReplayPrefixSize))
+	}
+	b.WriteString(fmt.Sprintf("#define REQ_BUF_SIZE %s\n", unityBufSize(cmd, pkg, "REQ")))
+	b.WriteString(fmt.Sprintf("#define RESP_BUF_SIZE %s\n", unityBufSize(cmd, pkg, "RESP")))
+	if len(prefix) > 0 {
+		b.WriteString(fmt.Sprintf("#define REQ_PREFIX_SIZE (%s)\n", strings.Join(prefix, " + ")))
+	}
+	b.WriteByte('\n')
+
+	if stream != "" {
+		b.WriteString("/* Responses sent through the stream hooks, captured in place of the BLE\n")
+		b.WriteString(" * transport. These definitions override the weak defaults in\n")
+		b.WriteString(" * generated_handlers.c: leave the firmware's own out of the test build. */\n")
+		b.WriteString(fmt.Sprintf("#define MAX_STREAMED %d\n", unityMaxStreamed))
+		b.WriteString("static uint8_t streamed[MAX_STREAMED][RESP_BUF_SIZE];\n")
+		b.WriteString("static size_t streamed_len[MAX_STREAMED];\n")
+		b.WriteString("static size_t streamed_count;\n")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("int %s_stream_write(const char *cmd_name, const uint8_t *data, size_t len)\n", pkg))
+		b.WriteString("{\n")
+		b.WriteString("    (void)cmd_name;\n")
+		b.WriteString("    TEST_ASSERT_LESS_THAN(MAX_STREAMED, streamed_count);\n")
+		b.WriteString("    TEST_ASSERT_LESS_OR_EQUAL(RESP_BUF_SIZE, len);\n")
+		b.WriteString("    memcpy(streamed[streamed_count], data, len);\n")
+		b.WriteString("    streamed_len[streamed_count++] = len;\n")
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("int %s_stream_finish(void)\n", pkg))
+		b.WriteString("{\n")
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+
+	b.WriteString("void setUp(void)\n")
+	b.WriteString("{\n")
+	if stream != "" {
+		b.WriteString("    streamed_count = 0;\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("void tearDown(void)\n")
+	b.WriteString("{\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("/* Runs the %s handler as the dispatcher does: a sizing pass, then,\n", cmd.Snake))
+	b.WriteString(" * when that returns 0, the pass writing the response to resp_buf. */\n")
+	b.WriteString("static int call_handler(const uint8_t *req, size_t req_len,\n")
+	b.WriteString("                        uint8_t *resp_buf, size_t *resp_len)\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    %s handler = %s(\"%s\", %d%s);\n", gctx.CSymbol("command_handler_fn"), gctx.CSymbol("handlers_lookup"), cmd.Wire(), len(cmd.Wire()), ctxArg))
+	b.WriteString("    TEST_ASSERT_NOT_NULL(handler);\n")
+	b.WriteByte('\n')
+	b.WriteString("    pb_ostream_t sizing = PB_OSTREAM_SIZING;\n")
+	b.WriteString(fmt.Sprintf("    int rc = handler(req, req_len, &sizing%s);\n", ctxArg))
+	b.WriteString("    if (rc != 0) return rc;\n")
+	b.WriteString("    TEST_ASSERT_LESS_OR_EQUAL(RESP_BUF_SIZE, sizing.bytes_written);\n")
+	b.WriteByte('\n')
+	b.WriteString("    pb_ostream_t out = pb_ostream_from_buffer(resp_buf, RESP_BUF_SIZE);\n")
+	b.WriteString(fmt.Sprintf("    rc = handler(req, req_len, &out%s);\n", ctxArg))
+	b.WriteString("    *resp_len = out.bytes_written;\n")
+	b.WriteString("    return rc;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString(fmt.Sprintf("static void test_%s(void)\n", cmd.Snake))
+	b.WriteString("{\n")
+	reqBuf, reqLen := "req_buf", "ostream.bytes_written"
+	if len(prefix) > 0 {
+		b.WriteString("    uint8_t req_buf[REQ_PREFIX_SIZE + REQ_BUF_SIZE] = {0};\n")
+		if cmd.SessionProtected {
+			b.WriteString("    /* TODO: put the token of an open session in front of the request:\n")
+			b.WriteString(fmt.Sprintf("     * without one the handler answers UNAUTHENTICATED (see %s_session_check). */\n", pkg))
+		}
+		if cmd.ReplayProtected {
+			offset := "0"
+			if cmd.SessionProtected {
+				offset = fmt.Sprintf("%s_SESSION_TOKEN_SIZE", strings.ToUpper(pkg))
+			}
+			b.WriteString("    /* Replay counter, little endian, above the last one accepted. */\n")
+			b.WriteString(fmt.Sprintf("    req_buf[%s] = 1;\n", offset))
+			b.WriteString("    /* TODO: put the MAC of the counter and request after the counter:\n")
+			b.WriteString(fmt.Sprintf("     * without one the handler drops the request (see %s_replay_mac). */\n", pkg))
+		}
+		reqBuf, reqLen = "req_buf + REQ_PREFIX_SIZE", "REQ_PREFIX_SIZE + ostream.bytes_written"
+	} else {
+		b.WriteString("    uint8_t req_buf[REQ_BUF_SIZE];\n")
+	}
+	b.WriteString(fmt.Sprintf("    %s req = %s_init_zero;\n", reqMsg, reqMsg))
+	b.WriteString("    /* TODO: fill in the request */\n")
+	b.WriteString(fmt.Sprintf("    pb_ostream_t ostream = pb_ostream_from_buffer(%s, REQ_BUF_SIZE);\n", reqBuf))
+	b.WriteString(fmt.Sprintf("    TEST_ASSERT_TRUE(pb_encode(&ostream, %s_fields, &req));\n", reqMsg))
+	b.WriteByte('\n')
+	b.WriteString("    uint8_t resp_buf[RESP_BUF_SIZE];\n")
+	b.WriteString("    size_t resp_len = 0;\n")
+	switch stream {
+	case "p2c":
+		b.WriteString("    /* A stream handler emits its responses and returns -2. */\n")
+		b.WriteString(fmt.Sprintf("    TEST_ASSERT_EQUAL_INT(-2, call_handler(req_buf, %s, resp_buf, &resp_len));\n", reqLen))
+		b.WriteByte('\n')
+		b.WriteString("    for (size_t i = 0; i < streamed_count; i++) {\n")
+		b.WriteString(fmt.Sprintf("        %s resp = %s_init_zero;\n", respMsg, respMsg))
+		b.WriteString("        pb_istream_t istream = pb_istream_from_buffer(streamed[i], streamed_len[i]);\n")
+		b.WriteString(fmt.Sprintf("        TEST_ASSERT_TRUE(pb_decode(&istream, %s_fields, &resp));\n", respMsg))
+		b.WriteString("        /* TODO: check each response */\n")
+		b.WriteString("    }\n")
+	case "c2p":
+		b.WriteString("    /* Each request of the stream returns -2; ending the stream sends the\n")
+		b.WriteString("     * response. Encode and send more requests to test accumulation. */\n")
+		b.WriteString(fmt.Sprintf("    TEST_ASSERT_EQUAL_INT(-2, call_handler(req_buf, %s, resp_buf, &resp_len));\n", reqLen))
+		b.WriteString(fmt.Sprintf("    TEST_ASSERT_EQUAL_INT(0, %s_stream_end_c2p());\n", pkg))
+		b.WriteString("    TEST_ASSERT_EQUAL_size_t(1, streamed_count);\n")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+		b.WriteString("    pb_istream_t istream = pb_istream_from_buffer(streamed[0], streamed_len[0]);\n")
+		b.WriteString(fmt.Sprintf("    TEST_ASSERT_TRUE(pb_decode(&istream, %s_fields, &resp));\n", respMsg))
+		b.WriteString("    /* TODO: check the response */\n")
+	default:
+		b.WriteString(fmt.Sprintf("    TEST_ASSERT_EQUAL_INT(0, call_handler(req_buf, %s, resp_buf, &resp_len));\n", reqLen))
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    %s resp = %s_init_zero;\n", respMsg, respMsg))
+		b.WriteString("    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);\n")
+		b.WriteString(fmt.Sprintf("    TEST_ASSERT_TRUE(pb_decode(&istream, %s_fields, &resp));\n", respMsg))
+		b.WriteString("    /* TODO: check the response */\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("int main(void)\n")
+	b.WriteString("{\n")
+	b.WriteString("    UNITY_BEGIN();\n")
+	b.WriteString(fmt.Sprintf("    RUN_TEST(test_%s);\n", cmd.Snake))
+	b.WriteString("    return UNITY_END();\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package c
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateCUnityTest(t *testing.T) {
+	gctx := gen.NewContext()
+	bounded := gentest.EchoCommand()
+	bounded.MaxRequestSize, bounded.MaxResponseSize = 12, gen.UnboundedSize
+	guarded := gentest.EchoCommand()
+	guarded.ReplayProtected = true
+	guarded.SessionProtected = true
+	guarded.MaxRequestSize, guarded.MaxResponseSize = gen.UnboundedSize, gen.UnboundedSize
+	streamCmd := gentest.StreamP2CCommand()
+	streamCmd.MaxRequestSize, streamCmd.MaxResponseSize = gen.UnboundedSize, gen.UnboundedSize
+	tests := []struct {
+		name   string
+		out    string
+		want   []string
+		absent []string
+	}{
+		{"unary", GenerateUnityTest(gctx, bounded, "", "blerpc"), []string{
+			"#define REQ_BUF_SIZE BLERPC_ECHO_MAX_REQ_SIZE\n#define RESP_BUF_SIZE 256\n",
+			"command_handler_fn handler = handlers_lookup(\"echo\", 4);\n",
+			"    pb_ostream_t sizing = PB_OSTREAM_SIZING;\n    int rc = handler(req, req_len, &sizing);\n",
+			"    TEST_ASSERT_TRUE(pb_encode(&ostream, blerpc_EchoRequest_fields, &req));\n",
+			"    TEST_ASSERT_EQUAL_INT(0, call_handler(req_buf, ostream.bytes_written, resp_buf, &resp_len));\n",
+			"    TEST_ASSERT_TRUE(pb_decode(&istream, blerpc_EchoResponse_fields, &resp));\n",
+			"    RUN_TEST(test_echo);\n",
+		}, []string{"stream_write", "REQ_PREFIX_SIZE"}},
+		{"guarded", GenerateUnityTest(gctx, guarded, "", "blerpc"), []string{
+			"#define REQ_PREFIX_SIZE (BLERPC_SESSION_TOKEN_SIZE + 24)\n",
+			"(see blerpc_session_check)",
+			"    req_buf[BLERPC_SESSION_TOKEN_SIZE] = 1;\n",
+			"pb_ostream_from_buffer(req_buf + REQ_PREFIX_SIZE, REQ_BUF_SIZE);\n",
+			"call_handler(req_buf, REQ_PREFIX_SIZE + ostream.bytes_written, resp_buf, &resp_len)",
+		}, nil},
+		{"p2c", GenerateUnityTest(gctx, streamCmd, "p2c", "blerpc"), []string{
+			"int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len)\n",
+			"int blerpc_stream_finish(void)\n",
+			"    streamed_count = 0;\n",
+			"TEST_ASSERT_EQUAL_INT(-2, call_handler(",
+			"pb_istream_from_buffer(streamed[i], streamed_len[i]);\n",
+		}, []string{"stream_end_c2p"}},
+		{"c2p", GenerateUnityTest(gctx, streamCmd, "c2p", "blerpc"), []string{
+			"    TEST_ASSERT_EQUAL_INT(0, blerpc_stream_end_c2p());\n",
+			"pb_istream_from_buffer(streamed[0], streamed_len[0]);\n",
+		}, nil},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+		for _, s := range tt.absent {
+			if strings.Contains(tt.out, s) {
+				t.Errorf("%s: unexpected %q", tt.name, s)
+			}
+		}
+	}
+}
+
+func TestGenerateCUnityTest_HandlerCtx(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.CHandlerCtx = true
+	out := GenerateUnityTest(gctx, gentest.EchoCommand(), "", "blerpc")
+	for _, want := range []string{
+		"handlers_lookup(\"echo\", 4, NULL);\n",
+		"handler(req, req_len, &out, NULL);\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
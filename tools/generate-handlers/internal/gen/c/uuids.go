@@ -0,0 +1,33 @@
+package c
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateUUIDsHeader returns generated_uuids.h of the C client. The
+// UUIDs expand to BT_UUID_128_ENCODE, so the includer needs
+// <zephyr/bluetooth/uuid.h> where it uses them.
+func GenerateUUIDsHeader(gctx *gen.Context, pkg string) string {
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	up := strings.ToUpper(prefix)
+	guard := up + "_GENERATED_UUIDS_H"
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+		"/* " + prefix + " Service UUID: " + gctx.ServiceUUID + " */",
+		"#define " + up + "_SERVICE_UUID " + zephyrUUIDEncode(gctx.ServiceUUID),
+		"#define " + up + "_SERVICE_UUID_STR \"" + gctx.ServiceUUID + "\"",
+		"",
+		"/* " + prefix + " Characteristic UUID: " + gctx.CharUUID + " */",
+		"#define " + up + "_CHAR_UUID " + zephyrUUIDEncode(gctx.CharUUID),
+		"#define " + up + "_CHAR_UUID_STR \"" + gctx.CharUUID + "\"",
+		"",
+	}
+	lines = append(lines, cGattGroupUUIDLines(gctx, prefix)...)
+	lines = append(lines, "#endif /* "+guard+" */")
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,51 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// wellKnownCType returns the nanopb struct name of a well-known type.
+func wellKnownCType(protoType string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(protoType, "."), ".", "_")
+}
+
+// writeCWellKnownHelpers emits inline epoch conversion helpers for the
+// nanopb well-known type structs used by the commands.
+func writeCWellKnownHelpers(b *strings.Builder, commands []gen.Command, pkg string) {
+	if gen.UsesWellKnownType(commands, protomodel.TimestampType) {
+		b.WriteString("/* google.protobuf.Timestamp <-> Unix epoch milliseconds */\n")
+		b.WriteString(fmt.Sprintf("static inline google_protobuf_Timestamp %s_timestamp_from_epoch_ms(int64_t ms)\n", pkg))
+		b.WriteString("{\n")
+		b.WriteString("    google_protobuf_Timestamp ts = google_protobuf_Timestamp_init_zero;\n")
+		b.WriteString("    ts.seconds = ms / 1000;\n")
+		b.WriteString("    ts.nanos = (int32_t)(ms % 1000) * 1000000;\n")
+		b.WriteString("    if (ts.nanos < 0) {\n")
+		b.WriteString("        ts.seconds -= 1;\n")
+		b.WriteString("        ts.nanos += 1000000000;\n")
+		b.WriteString("    }\n")
+		b.WriteString("    return ts;\n")
+		b.WriteString("}\n\n")
+		b.WriteString(fmt.Sprintf("static inline int64_t %s_timestamp_to_epoch_ms(const google_protobuf_Timestamp *ts)\n", pkg))
+		b.WriteString("{\n")
+		b.WriteString("    return ts->seconds * 1000 + ts->nanos / 1000000;\n")
+		b.WriteString("}\n\n")
+	}
+	if gen.UsesWellKnownType(commands, protomodel.DurationType) {
+		b.WriteString("/* google.protobuf.Duration <-> milliseconds */\n")
+		b.WriteString(fmt.Sprintf("static inline google_protobuf_Duration %s_duration_from_ms(int64_t ms)\n", pkg))
+		b.WriteString("{\n")
+		b.WriteString("    google_protobuf_Duration d = google_protobuf_Duration_init_zero;\n")
+		b.WriteString("    d.seconds = ms / 1000;\n")
+		b.WriteString("    d.nanos = (int32_t)(ms % 1000) * 1000000;\n")
+		b.WriteString("    return d;\n")
+		b.WriteString("}\n\n")
+		b.WriteString(fmt.Sprintf("static inline int64_t %s_duration_to_ms(const google_protobuf_Duration *d)\n", pkg))
+		b.WriteString("{\n")
+		b.WriteString("    return d->seconds * 1000 + d->nanos / 1000000;\n")
+		b.WriteString("}\n\n")
+	}
+}
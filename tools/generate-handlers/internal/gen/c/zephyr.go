@@ -0,0 +1,150 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Zephyr fragments let the firmware apps pick up generated sources and
+// configuration without editing their build files when the schema changes:
+// generated_sources.cmake adds the sources and their include directories and
+// maps Kconfig symbols to the macros the generated C reads, and
+// Kconfig.generated declares the symbols.
+// Include them once from the app:
+//
+//	include(${CMAKE_CURRENT_SOURCE_DIR}/generated_sources.cmake)
+//	rsource "Kconfig.generated"
+
+// kconfigHelp wraps text into Kconfig help lines.
+func kconfigHelp(text string) string {
+	var b strings.Builder
+	b.WriteString("\thelp\n")
+	line := ""
+	for _, w := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(w) > 70 {
+			b.WriteString("\t  " + line + "\n")
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	if line != "" {
+		b.WriteString("\t  " + line + "\n")
+	}
+	return b.String()
+}
+
+func writeCMakeHeader(b *strings.Builder, what string) {
+	b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteString("#\n")
+	b.WriteString("# Generated " + what + " sources, include directories and Kconfig-driven\n")
+	b.WriteString("# settings. Include it from the application CMakeLists.txt after\n")
+	b.WriteString("# find_package(Zephyr):\n")
+	b.WriteString("#\n")
+	b.WriteString("#   include(${CMAKE_CURRENT_SOURCE_DIR}/generated_sources.cmake)\n")
+	b.WriteByte('\n')
+}
+
+func writeCMakeSources(b *strings.Builder, t gen.BuildTarget) {
+	for _, v := range []struct {
+		command string
+		paths   []string
+	}{{"target_sources", t.Sources}, {"target_include_directories", t.Includes}} {
+		if len(v.paths) == 0 {
+			continue
+		}
+		if v.command != "target_sources" {
+			b.WriteByte('\n')
+		}
+		b.WriteString(v.command + "(app PRIVATE\n")
+		for _, r := range relPaths(t.Dir, v.paths) {
+			b.WriteString("    " + joinBase("${CMAKE_CURRENT_LIST_DIR}", r) + "\n")
+		}
+		b.WriteString(")\n")
+	}
+}
+
+// GenerateZephyrKconfig returns the peripheral Kconfig fragment: one option
+// per command group (proto service) to include its commands in the handler
+// table.
+func GenerateZephyrKconfig(commands []gen.Command, pkg string) string {
+	var b strings.Builder
+	b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("menu \"%s generated commands\"\n", pkg))
+	for _, g := range gen.GroupCommands(commands, "per-group", pkg) {
+		names := make([]string, len(g.Commands))
+		for i, cmd := range g.Commands {
+			names[i] = cmd.Wire()
+		}
+		b.WriteByte('\n')
+		b.WriteString("config " + cGroupMacro(pkg, g.Name) + "\n")
+		b.WriteString(fmt.Sprintf("\tbool \"%s commands\"\n", g.Name))
+		b.WriteString("\tdefault y\n")
+		b.WriteString(kconfigHelp(fmt.Sprintf("Include the commands of %s in the handler table: %s. The peripheral drops requests for a left-out command as unknown.",
+			g.Name, strings.Join(names, ", "))))
+	}
+	b.WriteByte('\n')
+	b.WriteString("endmenu\n")
+	return b.String()
+}
+
+// GenerateZephyrCMake returns the peripheral CMake fragment of t, adding its
+// sources and passing the command group options to the handler table.
+func GenerateZephyrCMake(commands []gen.Command, pkg string, t gen.BuildTarget) string {
+	var b strings.Builder
+	writeCMakeHeader(&b, "peripheral")
+	writeCMakeSources(&b, t)
+	b.WriteByte('\n')
+	b.WriteString("target_compile_definitions(app PRIVATE\n")
+	for _, g := range gen.GroupCommands(commands, "per-group", pkg) {
+		macro := cGroupMacro(pkg, g.Name)
+		b.WriteString(fmt.Sprintf("    %s=$<BOOL:${CONFIG_%s}>\n", macro, macro))
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// clientBufMacro returns the buffer size macro of the C client flavor.
+func clientBufMacro(pkg, mode string) string {
+	if mode == "min" {
+		return strings.ToUpper(pkg) + "_CLIENT_CALLBACK_BUF_SIZE"
+	}
+	return strings.ToUpper(pkg) + "_GENERATED_RESP_BUF_SIZE"
+}
+
+// GenerateZephyrClientKconfig returns the central Kconfig fragment holding
+// the buffer size of the C client.
+func GenerateZephyrClientKconfig(pkg, mode string) string {
+	var b strings.Builder
+	b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteByte('\n')
+	b.WriteString("config " + clientBufMacro(pkg, mode) + "\n")
+	if mode == "min" {
+		b.WriteString("\tint \"Generated client buffer for unbounded fields\"\n")
+		b.WriteString("\tdefault 256\n")
+		b.WriteString(kconfigHelp("Size of the static buffer the size-optimized client uses for request and response fields without a max_size in the .options file."))
+	} else {
+		b.WriteString("\tint \"Generated client response buffer size\"\n")
+		b.WriteString("\tdefault BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE\n")
+		b.WriteString(kconfigHelp("Size of the buffer the generated client decodes FT_CALLBACK response fields into. Defaults to the assembler buffer, which bounds every response."))
+	}
+	return b.String()
+}
+
+// GenerateZephyrClientCMake returns the central CMake fragment of t.
+func GenerateZephyrClientCMake(pkg, mode string, t gen.BuildTarget) string {
+	var b strings.Builder
+	writeCMakeHeader(&b, "client")
+	writeCMakeSources(&b, t)
+	b.WriteByte('\n')
+	macro := clientBufMacro(pkg, mode)
+	b.WriteString("target_compile_definitions(app PRIVATE\n")
+	b.WriteString(fmt.Sprintf("    %s=${CONFIG_%s}\n", macro, macro))
+	b.WriteString(")\n")
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package c
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func groupedCommands() []gen.Command {
+	echo, stream, upload := gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()
+	echo.Service, stream.Service, upload.Service = "EchoService", "CounterService", "CounterService"
+	return []gen.Command{echo, stream, upload}
+}
+
+func TestCHandlerTableGroups(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := groupedCommands()
+	header := GenerateHeader(gctx, cmds, nil, nil, "blerpc")
+	for _, s := range []string{
+		"#ifndef BLERPC_CMDS_ECHO_SERVICE\n#define BLERPC_CMDS_ECHO_SERVICE 1\n#endif\n",
+		"#ifndef BLERPC_CMDS_COUNTER_SERVICE\n#define BLERPC_CMDS_COUNTER_SERVICE 1\n#endif\n",
+	} {
+		if !strings.Contains(header, s) {
+			t.Errorf("header missing %q\nGot:\n%s", s, header)
+		}
+	}
+	source := GenerateSource(gctx, cmds, nil, nil, "blerpc")
+	want := "#if BLERPC_CMDS_COUNTER_SERVICE\n" +
+		"    {\"counter_stream\", 14, handle_counter_stream},\n" +
+		"    {\"counter_upload\", 14, handle_counter_upload},\n" +
+		"#endif\n"
+	if !strings.Contains(source, want) {
+		t.Errorf("source missing %q\nGot:\n%s", want, source)
+	}
+	if !strings.Contains(GenerateHeaderProtobufC(gctx, cmds, "blerpc"), "#define BLERPC_CMDS_ECHO_SERVICE 1\n") {
+		t.Error("protobuf-c header missing group macros")
+	}
+}
+
+func TestGenerateZephyrKconfig(t *testing.T) {
+	out := GenerateZephyrKconfig(groupedCommands(), "blerpc")
+	for _, s := range []string{
+		"menu \"blerpc generated commands\"\n",
+		"config BLERPC_CMDS_ECHO_SERVICE\n\tbool \"EchoService commands\"\n\tdefault y\n\thelp\n",
+		"\t  Include the commands of CounterService in the handler table:\n\t  counter_stream, counter_upload.",
+		"endmenu\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 80 {
+			t.Errorf("line too long: %q", line)
+		}
+	}
+}
+
+func TestGenerateZephyrCMake(t *testing.T) {
+	dir := filepath.Join("root", "peripheral_fw")
+	sources := []string{
+		filepath.Join(dir, "src", "generated_handlers.c"),
+		filepath.Join(dir, "src", "generated_gatt.c"),
+	}
+	out := GenerateZephyrCMake(groupedCommands(), "blerpc", gen.BuildTarget{Dir: dir, Sources: sources, Includes: []string{filepath.Join(dir, "src")}})
+	for _, s := range []string{
+		"target_sources(app PRIVATE\n" +
+			"    ${CMAKE_CURRENT_LIST_DIR}/src/generated_handlers.c\n" +
+			"    ${CMAKE_CURRENT_LIST_DIR}/src/generated_gatt.c\n)\n",
+		"target_include_directories(app PRIVATE\n    ${CMAKE_CURRENT_LIST_DIR}/src\n)\n",
+		"    BLERPC_CMDS_COUNTER_SERVICE=$<BOOL:${CONFIG_BLERPC_CMDS_COUNTER_SERVICE}>\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateZephyrClientFragments(t *testing.T) {
+	tests := []struct {
+		mode, macro, def string
+	}{
+		{"full", "BLERPC_GENERATED_RESP_BUF_SIZE", "\tdefault BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE\n"},
+		{"min", "BLERPC_CLIENT_CALLBACK_BUF_SIZE", "\tdefault 256\n"},
+	}
+	for _, tt := range tests {
+		kconfig := GenerateZephyrClientKconfig("blerpc", tt.mode)
+		if !strings.Contains(kconfig, "config "+tt.macro+"\n") || !strings.Contains(kconfig, tt.def) {
+			t.Errorf("%s: unexpected Kconfig:\n%s", tt.mode, kconfig)
+		}
+		cmake := GenerateZephyrClientCMake("blerpc", tt.mode, gen.BuildTarget{Dir: "central_fw", Sources: []string{filepath.Join("central_fw", "src", "generated_client.c")}})
+		for _, s := range []string{
+			"    ${CMAKE_CURRENT_LIST_DIR}/src/generated_client.c\n",
+			"    " + tt.macro + "=${CONFIG_" + tt.macro + "}\n",
+		} {
+			if !strings.Contains(cmake, s) {
+				t.Errorf("%s: CMake fragment missing %q\nGot:\n%s", tt.mode, s, cmake)
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package gen
+
+import (
+	"fmt"
+)
+
+func CacheableCommands(commands []Command) []Command {
+	var out []Command
+	for _, cmd := range commands {
+		if cmd.CacheTTLMs > 0 {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
+
+// CacheCallNames returns the names the clients' call functions receive for
+// cmd, as expressions of lang: the quoted wire name, and with command IDs
+// the one-character name the generated methods send.
+func CacheCallNames(cmd Command, lang string) []string {
+	names := []string{fmt.Sprintf("%q", cmd.Wire())}
+	if cmd.ID != 0 {
+		names = append(names, CallName(cmd, lang))
+	}
+	return names
+}
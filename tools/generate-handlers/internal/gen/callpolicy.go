@@ -0,0 +1,23 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+)
+
+func HasCallPolicies(commands []Command) bool {
+	for _, cmd := range commands {
+		if cmd.TimeoutMs > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PyTimeoutSeconds renders milliseconds as a Python float of seconds.
+func PyTimeoutSeconds(ms int) string {
+	if ms%1000 == 0 {
+		return fmt.Sprintf("%d.0", ms/1000)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%03d", ms/1000, ms%1000), "0")
+}
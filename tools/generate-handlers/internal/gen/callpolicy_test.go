@@ -0,0 +1,13 @@
+package gen
+
+import (
+	"testing"
+)
+
+func TestPyTimeoutSeconds(t *testing.T) {
+	for ms, want := range map[int]string{500: "0.5", 30000: "30.0", 1250: "1.25", 1: "0.001"} {
+		if got := PyTimeoutSeconds(ms); got != want {
+			t.Errorf("pyTimeoutSeconds(%d) = %q, want %q", ms, got, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package gen
+
+// A consumer that stops reading a peripheral-to-central stream early, by
+// breaking out of Python's iter_<cmd>, cancelling the scope collecting
+// Kotlin's <cmd>Flow or the task iterating Swift's <cmd>Responses, has the
+// client's stream_cancel/streamCancel send a Cancel control container. The
+// protocol library has no constant for it, so the clients get one and a
+// function serializing the container. On the peripheral the transport calls
+// <pkg>_stream_cancel() when the container arrives, and streaming handlers
+// poll <pkg>_stream_should_stop() between responses and end the stream
+// early; the central drains the stream up to its STREAM_END_P2C, keeping the
+// link in step for the next call.
+
+// ControlCmdCancel is the control command of the Cancel container, next
+// after KEY_EXCHANGE in the protocol's numbering.
+const ControlCmdCancel = 0x7
+
+// HasServerStreams reports whether any command is a peripheral-to-central
+// stream.
+func HasServerStreams(commands []Command, streaming map[string]string) bool {
+	for _, cmd := range commands {
+		if streaming[cmd.Snake] == "p2c" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package gen
+
+// Traffic capture (capture: true in blerpc.yaml) records the calls of a
+// client in the field and replays them against a later firmware release.
+// The Python RecordingClient (capture.py) and the Go Recorder (capture.go,
+// in the Go client package) wrap the raw transport calls of a client and
+// write a line of JSON per call: the command, the name it was sent by, the
+// stream kind, the start time, the duration and the request and response
+// payloads in hex, so both read each other's captures. Replay sends the
+// captured requests through any client, or a transport of the simulator,
+// and reports the responses that differ. Session- and replay-protected
+// commands are recorded but not replayed, as their requests lead with a
+// token or counter the peripheral accepts once. Sensitive fields are
+// stripped from the payloads (see redact.go). The fixed code is
+// py_capture.py.tmpl and go_capture.go.tmpl.
+
+// CaptureUnreplayable returns the snake names of the commands replay skips.
+func CaptureUnreplayable(commands []Command) []string {
+	var names []string
+	for _, cmd := range commands {
+		if MockRequestPrefix(cmd) > 0 {
+			names = append(names, cmd.Snake)
+		}
+	}
+	return names
+}
@@ -0,0 +1,23 @@
+package gen
+
+import (
+	"testing"
+)
+
+func TestKotlinBuilderSetter(t *testing.T) {
+	tests := []struct {
+		f    Field
+		want string
+	}{
+		{Field{Name: "user_id", Type: "string"}, "setUserId"},
+		{Field{Name: "ids", Type: "uint32", IsRepeated: true}, "addAllIds"},
+		{Field{Name: "levels", Type: "Level", IsRepeated: true, IsEnum: true}, "addAllLevelsValue"},
+		{Field{Name: "level", Type: "Level", IsEnum: true}, "setLevelValue"},
+		{Field{Name: "labels", Type: "map", IsMap: true}, "putAllLabels"},
+	}
+	for _, tt := range tests {
+		if got := KotlinBuilderSetter(tt.f); got != tt.want {
+			t.Errorf("kotlinBuilderSetter(%s) = %q, want %q", tt.f.Name, got, tt.want)
+		}
+	}
+}
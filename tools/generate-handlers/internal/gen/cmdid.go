@@ -0,0 +1,49 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Command names cost 10-20 bytes of every request on a link whose packets
+// carry 20. With command_ids: true in blerpc.yaml every command gets a
+// numeric ID, and the clients send it as a one-byte command name: the ID as
+// a single-byte varint. handlers_lookup matches such a name against the ID
+// before comparing full names, so clients that still send names keep
+// working.
+//
+// IDs are kept in a lock file next to the proto, keyed by wire name. Once
+// assigned an ID never changes, and the IDs of removed commands stay
+// reserved, so firmware and apps built from different schema versions agree
+// on every ID they share. Commit the lock file with the proto.
+
+// MaxCommandID is the largest ID a one-byte name can carry: the protocol
+// libraries pass names as UTF-8 strings, in which a lone byte must be below
+// 0x80, the range of a single-byte varint.
+const MaxCommandID = 127
+
+func HasCommandIDs(commands []Command) bool {
+	for _, cmd := range commands {
+		if cmd.ID != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CallName returns the expression a client passes as the command name: the
+// CommandId member when the command has an ID, else the quoted wire name.
+func CallName(cmd Command, lang string) string {
+	if cmd.ID == 0 {
+		return "\"" + cmd.Wire() + "\""
+	}
+	switch lang {
+	case "python":
+		return "CommandId." + strings.ToUpper(cmd.Snake) + ".wire_name"
+	case "kotlin":
+		return "CommandId." + strings.ToUpper(cmd.Snake) + ".wireName"
+	case "swift":
+		return "CommandId." + SwiftPropertyName(cmd.Snake) + ".wireName"
+	}
+	return fmt.Sprintf("\"\\x%02x\"", cmd.ID)
+}
@@ -0,0 +1,22 @@
+package gen
+
+// CompressionAlgorithms lists the algorithms; the index plus one is the
+// algorithm's value in C.
+var CompressionAlgorithms = []string{"deflate", "heatshrink"}
+
+// CompressedCommandName is the reserved command name of the compression
+// envelope.
+const CompressedCommandName = "_compressed"
+
+// CapabilityFlagCompression is the CAPABILITIES flag of a peripheral that
+// answers compressed calls; 0x0001 is the encryption flag of the protocol.
+const CapabilityFlagCompression = 0x0002
+
+func HasCompressed(commands []Command) bool {
+	for _, cmd := range commands {
+		if cmd.Compression != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package gen
+
+import (
+	"sort"
+	"strings"
+)
+
+// FieldTypeOverride returns the override of f for lang, if any.
+func FieldTypeOverride(f Field, lang string) (TypeOverride, bool) {
+	o, ok := f.TypeOverrides[lang]
+	return o, ok
+}
+
+func ApplyConverter(expr, value string) string {
+	if expr == "" {
+		return value
+	}
+	return strings.ReplaceAll(expr, "{}", value)
+}
+
+// EncodeValue converts a parameter to the proto field value for lang.
+func EncodeValue(f Field, lang, value string) string {
+	if o, ok := FieldTypeOverride(f, lang); ok {
+		return ApplyConverter(o.Encode, value)
+	}
+	return value
+}
+
+// OverrideImports returns the sorted, deduplicated import lines that the
+// type mappings used by commands need in lang.
+func OverrideImports(commands []Command, lang string) []string {
+	seen := make(map[string]bool)
+	var imports []string
+	for _, cmd := range commands {
+		for f := range cmd.Fields() {
+			if o, ok := FieldTypeOverride(f, lang); ok && o.Import != "" && !seen[o.Import] {
+				seen[o.Import] = true
+				imports = append(imports, o.Import)
+			}
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// MappedResponseFields returns, per response message in command order, the
+// fields with a decode converter for lang. Used to emit typed accessors.
+func MappedResponseFields(commands []Command, lang string) ([]string, map[string][]Field) {
+	var order []string
+	byMsg := make(map[string][]Field)
+	for _, cmd := range commands {
+		if _, done := byMsg[cmd.ResponseMsg]; done {
+			continue
+		}
+		var fields []Field
+		for _, f := range cmd.ResponseFields {
+			if o, ok := FieldTypeOverride(f, lang); ok && o.Decode != "" {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) > 0 {
+			order = append(order, cmd.ResponseMsg)
+			byMsg[cmd.ResponseMsg] = fields
+		}
+	}
+	return order, byMsg
+}
@@ -0,0 +1,78 @@
+package gen
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// ConformanceVector is one entry of vectors.json. Bytes are lowercase hex.
+type ConformanceVector struct {
+	Command            string   `json:"command"`
+	WireName           string   `json:"wire_name"`
+	Stream             string   `json:"stream"`
+	MTU                int      `json:"mtu"`
+	RequestMessage     string   `json:"request_message"`
+	ResponseMessage    string   `json:"response_message"`
+	Request            string   `json:"request"`
+	Response           string   `json:"response"`
+	RequestContainers  []string `json:"request_containers"`
+	ResponseContainers []string `json:"response_containers"`
+}
+
+// ConformanceSkip records a command without vectors.
+type ConformanceSkip struct {
+	Command string `json:"command"`
+	Reason  string `json:"reason"`
+}
+
+// ConformanceSuite is vectors.json.
+type ConformanceSuite struct {
+	Vectors []ConformanceVector `json:"vectors"`
+	Skipped []ConformanceSkip   `json:"skipped,omitempty"`
+}
+
+// ConformanceCommandPacket encodes a command packet: type bit, name length,
+// name, data length (uint16 LE) and data.
+func ConformanceCommandPacket(response bool, name string, data []byte) []byte {
+	var typ byte
+	if response {
+		typ = 0x80
+	}
+	buf := []byte{typ, byte(len(name))}
+	buf = append(buf, name...)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(data)))
+	return append(buf, data...)
+}
+
+// ConformanceWireName returns the command name the generated methods send
+// for cmd: its ID as a one-byte name, or its wire name.
+func ConformanceWireName(cmd Command) string {
+	if cmd.ID != 0 {
+		return string([]byte{byte(cmd.ID)})
+	}
+	return cmd.Wire()
+}
+
+// ConformanceCommands returns the commands the vectors cover, in order.
+func ConformanceCommands(commands []Command, suite ConformanceSuite) []Command {
+	covered := make(map[string]bool)
+	for _, v := range suite.Vectors {
+		covered[v.Command] = true
+	}
+	var out []Command
+	for _, cmd := range commands {
+		if covered[cmd.Snake] {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
+
+// ConformanceStringList renders hex strings as a list of string literals.
+func ConformanceStringList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = `"` + s + `"`
+	}
+	return strings.Join(quoted, ", ")
+}
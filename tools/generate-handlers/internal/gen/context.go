@@ -0,0 +1,53 @@
+// Package gen holds what the target generators share: the schema types,
+// the Context of one generation, the templates and the helpers used by
+// more than one target.
+package gen
+
+import (
+	"sync"
+	"text/template"
+)
+
+// Context is what the target generators of one generation read besides the
+// schema: the names, GATT UUIDs and services of blerpc.yaml, the settings of
+// the C handlers and the templates. The generator fills one in per
+// generation and passes it down, so generations share no state.
+type Context struct {
+	// Names holds the names: section of the loaded config.
+	Names NamesConfig
+	// ServiceUUID is the service UUID of the multiplexed RPC
+	// characteristic, advertised by every blerpc peripheral; CharUUID is the
+	// characteristic.
+	ServiceUUID, CharUUID string
+	// GATTGroups holds the GATT service groups, once the commands have
+	// their groups.
+	GATTGroups []GATTGroup
+	// SchemaHash is the hash of the schema, for the blerpc_info built-in.
+	SchemaHash string
+	// CDispatch is -c-dispatch: linear, binary or hash.
+	CDispatch string
+	// CHandlerCtx is -c-handler-ctx.
+	CHandlerCtx bool
+	// CBatch is batch in blerpc.yaml.
+	CBatch bool
+	// CCompression is set when a command is compressed.
+	CCompression bool
+	// CGuards is guards in blerpc.yaml.
+	CGuards GuardsConfig
+	// SwiftActorClient, set from blerpc.yaml's swift_actor_client, adds
+	// ActorClient, a default implementation of GeneratedClientProtocol, to
+	// the Swift client.
+	SwiftActorClient bool
+
+	templates   *template.Template
+	templateMu  sync.Mutex
+	templateErr error
+}
+
+// NewContext returns the context of an empty blerpc.yaml: the default
+// names and UUIDs, linear dispatch and the embedded templates.
+func NewContext() *Context {
+	gctx := &Context{ServiceUUID: DefaultServiceUUID, CharUUID: DefaultCharUUID, CDispatch: "linear"}
+	gctx.templates = gctx.bind(builtinTemplates)
+	return gctx
+}
@@ -0,0 +1,332 @@
+// Package cpp generates the C++ central client.
+package cpp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The C++ client targets Linux gateways using the protobuf C++ runtime. Like
+// the Swift protocol, GeneratedClient leaves the transport to the caller:
+// subclasses implement call, streamReceive and streamSend, e.g. over BlueZ
+// D-Bus, and inherit the typed command methods. The methods take and return
+// the generated messages rather than flattened fields.
+
+// cppMessageName returns the protobuf C++ class of a message, e.g.
+// pb::Outer_Inner for the nested Outer.Inner.
+func cppMessageName(msg string) string {
+	return "pb::" + strings.ReplaceAll(msg, ".", "_")
+}
+
+// cppStatusCheck returns the statements throwing CommandStatusError for
+// resp, or "" when the command is not checked.
+func cppStatusCheck(cmd gen.Command, indent string) string {
+	if cmd.StatusField == "" {
+		return ""
+	}
+	getter := fmt.Sprintf("static_cast<int>(resp.%s())", strings.ToLower(cmd.StatusField))
+	return fmt.Sprintf("%sif (%s != %d) {\n%s    throw CommandStatusError(\"%s\", \"%s\", %s);\n%s}\n",
+		indent, getter, cmd.StatusOK, indent, cmd.Snake, cmd.StatusField, getter, indent)
+}
+
+// GenerateClient returns generated_client.hpp, a header-only C++17 client
+// of the commands. pbHeader is the protoc-generated header of the schema.
+func GenerateClient(commands []gen.Command, streaming map[string]string, pkg, pbHeader string) string {
+	prefix := strings.ReplaceAll(pkg, ".", "_")
+	guard := strings.ToUpper(prefix) + "_GENERATED_CLIENT_HPP"
+	replay := gen.HasReplayProtected(commands)
+	start, auth, sessions := gen.SessionCommands(commands)
+	var b strings.Builder
+
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+	}
+	if replay {
+		lines = append(lines, "#include <algorithm>", "#include <chrono>")
+	}
+	lines = append(lines,
+		"#include <cstdint>",
+		"#include <mutex>",
+		"#include <stdexcept>",
+		"#include <string>",
+		"#include <utility>",
+		"#include <vector>",
+		"",
+		`#include "`+pbHeader+`"`,
+		"",
+		"namespace "+prefix+"_client {",
+		"",
+		"namespace pb = ::"+strings.ReplaceAll(pkg, ".", "::")+";",
+		"",
+	)
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+
+	writeCppErrors(&b, commands)
+
+	b.WriteString("/**\n")
+	b.WriteString(" * Auto-generated RPC client. Derive from it and implement\n")
+	b.WriteString(" * call/streamReceive/streamSend over the transport, e.g. BlueZ on D-Bus.\n")
+	if sessions {
+		b.WriteString(" * The session handshake also needs sessionProof, the HMAC of the key.\n")
+	}
+	b.WriteString(" * The command methods run one RPC at a time; the transport methods are\n")
+	b.WriteString(" * called with the client's lock held.\n")
+	b.WriteString(" */\n")
+	b.WriteString("class GeneratedClient {\n")
+	b.WriteString("public:\n")
+	b.WriteString("    virtual ~GeneratedClient() = default;\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Send one request and return the response payload. */\n")
+	b.WriteString("    virtual std::string call(const std::string &cmd_name, const std::string &request_data) = 0;\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Send one request and return every response of a P→C stream. */\n")
+	b.WriteString("    virtual std::vector<std::string> streamReceive(const std::string &cmd_name,\n")
+	b.WriteString("                                                   const std::string &request_data) = 0;\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Send the messages of a C→P stream and return the final response payload. */\n")
+	b.WriteString("    virtual std::string streamSend(const std::string &cmd_name, const std::vector<std::string> &messages,\n")
+	b.WriteString("                                   const std::string &final_cmd_name) = 0;\n")
+	if sessions {
+		writeCppSessionMethods(&b)
+	}
+	writeCppMethods(&b, commands, streaming)
+	b.WriteByte('\n')
+	b.WriteString("protected:\n")
+	b.WriteString("    /** Decode a response, throwing the error an error response reports. */\n")
+	b.WriteString("    template <typename T>\n")
+	b.WriteString("    static T decode(const char *command, const std::string &data)\n")
+	b.WriteString("    {\n")
+	b.WriteString("        throwStatusError(command, data);\n")
+	b.WriteString("        T resp;\n")
+	b.WriteString("        if (!resp.ParseFromString(data)) {\n")
+	b.WriteString("            throw DecodeError(command);\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return resp;\n")
+	b.WriteString("    }\n")
+	if replay {
+		b.WriteByte('\n')
+		b.WriteString("    /**\n")
+		b.WriteString("     * Lead a replay-protected request with a counter, 8 bytes little endian,\n")
+		b.WriteString(fmt.Sprintf("     * and a MAC: the first %d bytes of sessionProof() of the command name,\n", gen.ReplayMACSize))
+		b.WriteString("     * a NUL, the counter and the request. The peripheral drops requests whose\n")
+		b.WriteString("     * MAC is wrong or whose counter is not above the last one it accepted;\n")
+		b.WriteString("     * microseconds since the epoch keep it increasing across restarts. Call\n")
+		b.WriteString("     * with call_mutex_ held.\n")
+		b.WriteString("     */\n")
+		b.WriteString("    std::string withReplayCounter(const std::string &command, const std::string &request_data)\n")
+		b.WriteString("    {\n")
+		b.WriteString("        auto now = std::chrono::duration_cast<std::chrono::microseconds>(\n")
+		b.WriteString("            std::chrono::system_clock::now().time_since_epoch());\n")
+		b.WriteString("        last_replay_counter_ = std::max(last_replay_counter_ + 1, static_cast<uint64_t>(now.count()));\n")
+		b.WriteString("        std::string counter;\n")
+		b.WriteString("        for (int i = 0; i < 8; i++) {\n")
+		b.WriteString("            counter.push_back(static_cast<char>(last_replay_counter_ >> (8 * i)));\n")
+		b.WriteString("        }\n")
+		b.WriteString("        std::string mac = sessionProof(command + std::string(1, '\\0') + counter + request_data);\n")
+		b.WriteString(fmt.Sprintf("        return counter + mac.substr(0, %d) + request_data;\n", gen.ReplayMACSize))
+		b.WriteString("    }\n")
+	}
+	if sessions {
+		writeCppSessionHelpers(&b, start, auth)
+	}
+	b.WriteByte('\n')
+	b.WriteString("    std::mutex call_mutex_;\n")
+	if replay {
+		b.WriteString("    uint64_t last_replay_counter_ = 0;\n")
+	}
+	if sessions {
+		b.WriteString("    std::string session_token_;\n")
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("} // namespace " + prefix + "_client\n")
+	b.WriteByte('\n')
+	b.WriteString("#endif /* " + guard + " */\n")
+	return b.String()
+}
+
+// writeCppErrors emits the exception hierarchy and the status envelope check.
+func writeCppErrors(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("/** Base of every exception thrown by generated client methods. */\n")
+	b.WriteString("class BlerpcError : public std::runtime_error {\n")
+	b.WriteString("public:\n")
+	b.WriteString("    using std::runtime_error::runtime_error;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The request could not be delivered or the response was lost. */\n")
+	b.WriteString("class TransportError : public BlerpcError {\n")
+	b.WriteString("public:\n")
+	b.WriteString("    using BlerpcError::BlerpcError;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The peripheral did not respond in time. */\n")
+	b.WriteString("class TimeoutError : public BlerpcError {\n")
+	b.WriteString("public:\n")
+	b.WriteString("    explicit TimeoutError(const std::string &command) : BlerpcError(command + \": timed out\"), command(command) {}\n")
+	b.WriteString("    std::string command;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The response payload is not a valid message. */\n")
+	b.WriteString("class DecodeError : public BlerpcError {\n")
+	b.WriteString("public:\n")
+	b.WriteString("    explicit DecodeError(const std::string &command) : BlerpcError(command + \": invalid response\"), command(command) {}\n")
+	b.WriteString("    std::string command;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Status codes of error responses; 128 and up are application codes. */\n")
+	b.WriteString("enum class StatusCode : int {\n")
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("    %s = %d,\n", gen.StatusErrorName(sc.Name), i))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The peripheral reported a non-OK status. */\n")
+	b.WriteString("class RemoteError : public BlerpcError {\n")
+	b.WriteString("public:\n")
+	b.WriteString("    RemoteError(const std::string &command, int status)\n")
+	b.WriteString("        : BlerpcError(command + \" failed: status \" + std::to_string(status)), command(command), status(status)\n")
+	b.WriteString("    {\n")
+	b.WriteString("    }\n")
+	b.WriteString("    std::string command;\n")
+	b.WriteString("    int status;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	if gen.HasStatusChecks(commands) {
+		b.WriteString("/** A checked command responded with a non-OK status. */\n")
+		b.WriteString("class CommandStatusError : public RemoteError {\n")
+		b.WriteString("public:\n")
+		b.WriteString("    CommandStatusError(const std::string &command, const std::string &field, int status)\n")
+		b.WriteString("        : RemoteError(command, status), field(field)\n")
+		b.WriteString("    {\n")
+		b.WriteString("    }\n")
+		b.WriteString("    std::string field;\n")
+		b.WriteString("};\n")
+		b.WriteByte('\n')
+	}
+	tag := make([]string, len(gen.StatusTag))
+	for i, c := range gen.StatusTag {
+		tag[i] = fmt.Sprintf("'\\x%02X'", c)
+	}
+	b.WriteString("/**\n")
+	b.WriteString(" * Throw the RemoteError an error response reports; return for other\n")
+	b.WriteString(" * responses. Error responses hold only a status, in a field no message uses.\n")
+	b.WriteString(" */\n")
+	b.WriteString("inline void throwStatusError(const char *command, const std::string &data)\n")
+	b.WriteString("{\n")
+	b.WriteString(fmt.Sprintf("    static const std::string tag = {%s};\n", strings.Join(tag, ", ")))
+	b.WriteString("    if (data.size() <= tag.size() || data.compare(0, tag.size(), tag) != 0) {\n")
+	b.WriteString("        return;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    int status = 0;\n")
+	b.WriteString("    int shift = 0;\n")
+	b.WriteString("    for (size_t i = tag.size(); i < data.size(); i++) {\n")
+	b.WriteString("        auto byte = static_cast<uint8_t>(data[i]);\n")
+	b.WriteString("        status |= (byte & 0x7F) << shift;\n")
+	b.WriteString("        shift += 7;\n")
+	b.WriteString("        if (byte < 0x80) {\n")
+	b.WriteString("            break;\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    throw RemoteError(command, status);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeCppMethods emits the command methods of GeneratedClient, including
+// deprecated aliases.
+func writeCppMethods(b *strings.Builder, commands []gen.Command, streaming map[string]string) {
+	for _, cmd := range commands {
+		reqCls := cppMessageName(cmd.RequestMsg)
+		respCls := cppMessageName(cmd.ResponseMsg)
+		name := gen.CallName(cmd, "cpp")
+
+		b.WriteByte('\n')
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("    std::vector<%s> %s(const %s &req)\n", respCls, gen.ToLowerCamel(cmd.Camel), reqCls))
+			b.WriteString("    {\n")
+			b.WriteString("        std::lock_guard<std::mutex> lock(call_mutex_);\n")
+			b.WriteString(fmt.Sprintf("        std::vector<%s> responses;\n", respCls))
+			b.WriteString(fmt.Sprintf("        for (const std::string &data : streamReceive(%s, req.SerializeAsString())) {\n", name))
+			if cmd.StatusField == "" {
+				b.WriteString(fmt.Sprintf("            responses.push_back(decode<%s>(\"%s\", data));\n", respCls, cmd.Snake))
+			} else {
+				b.WriteString(fmt.Sprintf("            auto resp = decode<%s>(\"%s\", data);\n", respCls, cmd.Snake))
+				b.WriteString(cppStatusCheck(cmd, "            "))
+				b.WriteString("            responses.push_back(std::move(resp));\n")
+			}
+			b.WriteString("        }\n")
+			b.WriteString("        return responses;\n")
+			b.WriteString("    }\n")
+		case "c2p":
+			b.WriteString(fmt.Sprintf("    %s %s(const std::vector<%s> &messages)\n", respCls, gen.ToLowerCamel(cmd.Camel), reqCls))
+			b.WriteString("    {\n")
+			b.WriteString("        std::vector<std::string> raw;\n")
+			b.WriteString("        raw.reserve(messages.size());\n")
+			b.WriteString(fmt.Sprintf("        for (const %s &msg : messages) {\n", reqCls))
+			b.WriteString("            raw.push_back(msg.SerializeAsString());\n")
+			b.WriteString("        }\n")
+			b.WriteString("        std::lock_guard<std::mutex> lock(call_mutex_);\n")
+			b.WriteString(fmt.Sprintf("        std::string resp_data = streamSend(%s, raw, %s);\n", name, name))
+			writeCppParseResp(b, cmd, respCls)
+			b.WriteString("    }\n")
+		default:
+			reqData := "req.SerializeAsString()"
+			if cmd.ReplayProtected {
+				reqData = fmt.Sprintf("withReplayCounter(%q, %s)", cmd.Wire(), reqData)
+			}
+			b.WriteString(fmt.Sprintf("    %s %s(const %s &req)\n", respCls, gen.ToLowerCamel(cmd.Camel), reqCls))
+			b.WriteString("    {\n")
+			b.WriteString("        std::lock_guard<std::mutex> lock(call_mutex_);\n")
+			fn := "call"
+			if cmd.SessionProtected {
+				fn = "sessionCall"
+			}
+			b.WriteString(fmt.Sprintf("        std::string resp_data = %s(%s, %s);\n", fn, name, reqData))
+			writeCppParseResp(b, cmd, respCls)
+			b.WriteString("    }\n")
+		}
+	}
+
+	// Deprecated aliases for renamed commands
+	for _, cmd := range commands {
+		if cmd.RenamedFrom == "" {
+			continue
+		}
+		param, ret := "const "+cppMessageName(cmd.RequestMsg)+" &req", cppMessageName(cmd.ResponseMsg)
+		arg := "req"
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			ret = "std::vector<" + ret + ">"
+		case "c2p":
+			param = "const std::vector<" + cppMessageName(cmd.RequestMsg) + "> &messages"
+			arg = "messages"
+		}
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    [[deprecated(\"use %s\")]]\n", gen.ToLowerCamel(cmd.Camel)))
+		b.WriteString(fmt.Sprintf("    %s %s(%s)\n", ret, gen.ToLowerCamel(cmd.RenamedFrom), param))
+		b.WriteString("    {\n")
+		b.WriteString(fmt.Sprintf("        return %s(%s);\n", gen.ToLowerCamel(cmd.Camel), arg))
+		b.WriteString("    }\n")
+	}
+}
+
+// writeCppParseResp emits the decoding and return of resp_data, checking the
+// status field first when the command opted in.
+func writeCppParseResp(b *strings.Builder, cmd gen.Command, respCls string) {
+	if cmd.StatusField == "" {
+		b.WriteString(fmt.Sprintf("        return decode<%s>(\"%s\", resp_data);\n", respCls, cmd.Snake))
+		return
+	}
+	b.WriteString(fmt.Sprintf("        auto resp = decode<%s>(\"%s\", resp_data);\n", respCls, cmd.Snake))
+	b.WriteString(cppStatusCheck(cmd, "        "))
+	b.WriteString("        return resp;\n")
+}
@@ -0,0 +1,53 @@
+package cpp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestCppMessageName(t *testing.T) {
+	if got := cppMessageName("SensorReading.Sample"); got != "pb::SensorReading_Sample" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGenerateCppClient(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.ReplayProtected = true
+	echo.StatusField, echo.StatusOK = "status", 0
+	upload := gentest.StreamC2PCommand()
+	upload.RenamedFrom = "CounterPush"
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), upload}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateClient(cmds, streaming, "acme.sensor", "sensor.pb.h")
+
+	for _, want := range []string{
+		"#ifndef ACME_SENSOR_GENERATED_CLIENT_HPP",
+		"#include \"sensor.pb.h\"",
+		"namespace acme_sensor_client {\n\nnamespace pb = ::acme::sensor;",
+		"    NotFound = 2,\n",
+		"    static const std::string tag = {'\\xF8', '\\xFF', '\\xFF', '\\xFF', '\\x0F'};",
+		"    virtual std::string call(const std::string &cmd_name, const std::string &request_data) = 0;",
+		"    pb::EchoResponse echo(const pb::EchoRequest &req)\n    {\n        std::lock_guard<std::mutex> lock(call_mutex_);\n" +
+			"        std::string resp_data = call(\"echo\", withReplayCounter(\"echo\", req.SerializeAsString()));\n",
+		"        if (static_cast<int>(resp.status()) != 0) {\n            throw CommandStatusError(\"echo\", \"status\", static_cast<int>(resp.status()));\n",
+		"    std::vector<pb::CounterStreamResponse> counterStream(const pb::CounterStreamRequest &req)",
+		"streamSend(\"counter_upload\", raw, \"counter_upload\");",
+		"    [[deprecated(\"use counterUpload\")]]\n    pb::CounterUploadResponse counterPush(const std::vector<pb::CounterUploadRequest> &messages)",
+		"    uint64_t last_replay_counter_ = 0;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("client missing %q\nGot:\n%s", want, out)
+		}
+	}
+
+	plain := GenerateClient([]gen.Command{gentest.EchoCommand()}, nil, "blerpc", "blerpc.pb.h")
+	for _, s := range []string{"withReplayCounter", "CommandStatusError", "#include <chrono>"} {
+		if strings.Contains(plain, s) {
+			t.Errorf("%s emitted without replay-protected or checked commands", s)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package cpp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// EmbeddedProto turns every string, bytes and repeated field into a class
+// template parameter. The handler target instantiates the request and
+// response classes with the nanopb max_size/max_count options, falling back
+// to overridable default macros.
+
+// embeddedProtoArgs returns the template arguments of msg in EmbeddedProto
+// order: per field, the repeated count, then the element length, then the
+// parameters of a nested message.
+func embeddedProtoArgs(msg string, msgByName map[string]gen.Message, limits map[string]protomodel.FieldLimits, pkg string) []string {
+	m, ok := msgByName[msg]
+	if !ok {
+		return nil
+	}
+	prefix := strings.ToUpper(strings.ReplaceAll(pkg, ".", "_"))
+	length := func(n int) string {
+		if n > 0 {
+			return fmt.Sprint(n)
+		}
+		return prefix + "_EP_DEFAULT_LENGTH"
+	}
+	var args []string
+	for _, f := range m.Fields {
+		l := limits[msg+"."+f.Name]
+		if f.IsRepeated {
+			if l.MaxCount > 0 {
+				args = append(args, fmt.Sprint(l.MaxCount))
+			} else {
+				args = append(args, prefix+"_EP_DEFAULT_REP_LENGTH")
+			}
+		}
+		switch {
+		case f.Type == "string" || f.Type == "bytes":
+			args = append(args, length(l.MaxSize))
+		case f.IsMessage && !protomodel.IsWellKnownType(f.Type):
+			args = append(args, embeddedProtoArgs(strings.TrimPrefix(f.Type, "."), msgByName, limits, pkg)...)
+		}
+	}
+	return args
+}
+
+// ValidateEmbeddedProto rejects schema constructs EmbeddedProto cannot
+// represent.
+func ValidateEmbeddedProto(commands []gen.Command) error {
+	for _, cmd := range commands {
+		for f := range cmd.Fields() {
+			if f.IsMap {
+				return fmt.Errorf("%s: map field %q is not supported by EmbeddedProto", cmd.Snake, f.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func embeddedProtoNamespace(pkg string) string {
+	return strings.ReplaceAll(pkg, ".", "::")
+}
+
+func GenerateHeader(gctx *gen.Context, commands []gen.Command, msgByName map[string]gen.Message, limits map[string]protomodel.FieldLimits, pkg string) string {
+	prefix := strings.ToUpper(strings.ReplaceAll(pkg, ".", "_"))
+	guard := prefix + "_GENERATED_HANDLERS_HPP"
+	ns := strings.ReplaceAll(pkg, ".", "_") + "_handlers"
+	var b strings.Builder
+
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#ifndef " + guard,
+		"#define " + guard,
+		"",
+		"#include <cstddef>",
+		"#include <cstdint>",
+		"#include <ReadBufferInterface.h>",
+		"#include <WriteBufferInterface.h>",
+		`#include "` + pkg + `.h"`,
+		"",
+		"/* Lengths for string, bytes and repeated fields without nanopb size options */",
+		"#ifndef " + prefix + "_EP_DEFAULT_LENGTH",
+		"#define " + prefix + "_EP_DEFAULT_LENGTH 64",
+		"#endif",
+		"#ifndef " + prefix + "_EP_DEFAULT_REP_LENGTH",
+		"#define " + prefix + "_EP_DEFAULT_REP_LENGTH 8",
+		"#endif",
+		"",
+		"namespace " + ns + " {",
+		"",
+	}
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+
+	// Message instantiations, once per message
+	seen := make(map[string]bool)
+	for _, cmd := range commands {
+		for _, msg := range []string{cmd.RequestMsg, cmd.ResponseMsg} {
+			if seen[msg] {
+				continue
+			}
+			seen[msg] = true
+			typ := "::" + embeddedProtoNamespace(pkg) + "::" + msg
+			if args := embeddedProtoArgs(msg, msgByName, limits, pkg); len(args) > 0 {
+				typ += "<" + strings.Join(args, ", ") + ">"
+			}
+			b.WriteString(fmt.Sprintf("using %s = %s;\n", msg, typ))
+		}
+	}
+	b.WriteByte('\n')
+
+	b.WriteString("using " + gctx.CSymbol("command_handler_fn") + " = int (*)(::EmbeddedProto::ReadBufferInterface &req_buf,\n")
+	b.WriteString(strings.Repeat(" ", len("using "+gctx.CSymbol("command_handler_fn")+" = int (*)(")) + "::EmbeddedProto::WriteBufferInterface &resp_buf);\n")
+	b.WriteByte('\n')
+	b.WriteString("struct " + gctx.CSymbol("handler_entry") + " {\n")
+	b.WriteString("    const char *name;\n")
+	b.WriteString("    uint8_t name_len;\n")
+	b.WriteString("    " + gctx.CSymbol("command_handler_fn") + " handler;\n")
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString(gctx.CSymbol("command_handler_fn") + " " + gctx.CSymbol("handlers_lookup") + "(const char *name, uint8_t name_len);\n")
+	b.WriteByte('\n')
+
+	for _, cmd := range commands {
+		pad := gctx.CHandlerPad(cmd.Snake)
+		b.WriteString(fmt.Sprintf("int %s(::EmbeddedProto::ReadBufferInterface &req_buf,\n", gctx.CHandlerName(cmd.Snake)))
+		b.WriteString(fmt.Sprintf("            %s::EmbeddedProto::WriteBufferInterface &resp_buf);\n", pad))
+		b.WriteByte('\n')
+	}
+
+	b.WriteString("} // namespace " + ns + "\n")
+	b.WriteByte('\n')
+	b.WriteString("#endif /* " + guard + " */\n")
+	return b.String()
+}
+
+func GenerateSource(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	ns := strings.ReplaceAll(pkg, ".", "_") + "_handlers"
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#include \"generated_handlers.hpp\"\n")
+	b.WriteString("#include <cstring>\n")
+	b.WriteByte('\n')
+	b.WriteString("namespace " + ns + " {\n")
+	b.WriteByte('\n')
+
+	// Weak handler stubs
+	for _, cmd := range commands {
+		pad := gctx.CHandlerPad(cmd.Snake)
+		b.WriteString("__attribute__((weak))\n")
+		b.WriteString(fmt.Sprintf("int %s(::EmbeddedProto::ReadBufferInterface &req_buf,\n", gctx.CHandlerName(cmd.Snake)))
+		b.WriteString(fmt.Sprintf("            %s::EmbeddedProto::WriteBufferInterface &resp_buf)\n", pad))
+		b.WriteString("{\n")
+		b.WriteString(fmt.Sprintf("    %s req;\n", cmd.RequestMsg))
+		b.WriteString("    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;\n")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    %s resp;\n", cmd.ResponseMsg))
+		b.WriteString("    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;\n")
+		b.WriteString("    return 0;\n")
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+
+	// Handler table
+	b.WriteString("static const " + gctx.CSymbol("handler_entry") + " handler_table[] = {\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    {\"%s\", %d, %s},\n", cmd.Wire(), len(cmd.Wire()), gctx.CHandlerName(cmd.Snake)))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	ids := gen.HasCommandIDs(commands)
+	if ids {
+		b.WriteString("/* ID of each command, in handler table order. */\n")
+		b.WriteString("static const uint8_t command_ids[] = {\n")
+		for _, cmd := range commands {
+			b.WriteString(fmt.Sprintf("    %d, /* %s */\n", cmd.ID, cmd.Wire()))
+		}
+		b.WriteString("};\n")
+		b.WriteByte('\n')
+	}
+
+	// Lookup function
+	b.WriteString(gctx.CSymbol("command_handler_fn") + " " + gctx.CSymbol("handlers_lookup") + "(const char *name, uint8_t name_len)\n")
+	b.WriteString("{\n")
+	if ids {
+		b.WriteString("    for (size_t i = 0; i < sizeof(handler_table) / sizeof(handler_table[0]); i++) {\n")
+		b.WriteString("        const " + gctx.CSymbol("handler_entry") + " &entry = handler_table[i];\n")
+		b.WriteString("        /* A one-byte name carries the command ID. */\n")
+		b.WriteString("        if ((name_len == 1 && static_cast<uint8_t>(name[0]) == command_ids[i]) ||\n")
+		b.WriteString("            (entry.name_len == name_len && std::memcmp(entry.name, name, name_len) == 0)) {\n")
+	} else {
+		b.WriteString("    for (const " + gctx.CSymbol("handler_entry") + " &entry : handler_table) {\n")
+		b.WriteString("        if (entry.name_len == name_len && std::memcmp(entry.name, name, name_len) == 0) {\n")
+	}
+	b.WriteString("            return entry.handler;\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return nullptr;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("} // namespace " + ns + "\n")
+
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package cpp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestEmbeddedProtoArgs(t *testing.T) {
+	msgByName := map[string]gen.Message{
+		"Item": {Name: "Item", Fields: []gen.Field{
+			{Name: "id", Type: "uint32"},
+			{Name: "label", Type: "string"},
+		}},
+		"ListResponse": {Name: "ListResponse", Fields: []gen.Field{
+			{Name: "count", Type: "uint32"},
+			{Name: "items", Type: "Item", IsMessage: true, IsRepeated: true},
+			{Name: "tags", Type: "string", IsRepeated: true},
+			{Name: "blob", Type: "bytes"},
+			{Name: "at", Type: "google.protobuf.Timestamp", IsMessage: true},
+		}},
+	}
+	limits := map[string]protomodel.FieldLimits{
+		"Item.label":         {MaxSize: 16},
+		"ListResponse.items": {MaxCount: 4},
+		"ListResponse.tags":  {MaxCount: 2, MaxSize: 8},
+	}
+	got := embeddedProtoArgs("ListResponse", msgByName, limits, "blerpc")
+	want := []string{"4", "16", "2", "8", "BLERPC_EP_DEFAULT_LENGTH"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if args := embeddedProtoArgs("Item", msgByName, nil, "acme.sensor"); !reflect.DeepEqual(args, []string{"ACME_SENSOR_EP_DEFAULT_LENGTH"}) {
+		t.Errorf("defaults: got %v", args)
+	}
+}
+
+func TestValidateEmbeddedProto(t *testing.T) {
+	if err := ValidateEmbeddedProto([]gen.Command{gentest.EchoCommand()}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateEmbeddedProto([]gen.Command{gentest.MapCommand()}); err == nil || !strings.Contains(err.Error(), "map field") {
+		t.Errorf("expected map field error, got %v", err)
+	}
+}
+
+func TestGenerateCppHeader(t *testing.T) {
+	gctx := gen.NewContext()
+	msgByName := map[string]gen.Message{
+		"EchoRequest":  {Name: "EchoRequest", Fields: gentest.EchoCommand().RequestFields},
+		"EchoResponse": {Name: "EchoResponse", Fields: gentest.EchoCommand().ResponseFields},
+	}
+	limits := map[string]protomodel.FieldLimits{"EchoRequest.message": {MaxSize: 257}}
+	out := GenerateHeader(gctx, []gen.Command{gentest.EchoCommand()}, msgByName, limits, "blerpc")
+
+	mustContain := []string{
+		"#ifndef BLERPC_GENERATED_HANDLERS_HPP",
+		"#include <ReadBufferInterface.h>",
+		"#include \"blerpc.h\"",
+		"#define BLERPC_EP_DEFAULT_LENGTH 64",
+		"namespace blerpc_handlers {",
+		"using EchoRequest = ::blerpc::EchoRequest<257>;",
+		"using EchoResponse = ::blerpc::EchoResponse<BLERPC_EP_DEFAULT_LENGTH>;",
+		"using command_handler_fn = int (*)(::EmbeddedProto::ReadBufferInterface &req_buf,",
+		"int handle_echo(::EmbeddedProto::ReadBufferInterface &req_buf,\n                ::EmbeddedProto::WriteBufferInterface &resp_buf);",
+		"} // namespace blerpc_handlers",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("header missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateCppSource(t *testing.T) {
+	gctx := gen.NewContext()
+	wired := gentest.EchoCommand()
+	wired.WireName = "e"
+	out := GenerateSource(gctx, []gen.Command{wired}, "blerpc")
+
+	mustContain := []string{
+		"#include \"generated_handlers.hpp\"",
+		"__attribute__((weak))\nint handle_echo(",
+		"    EchoRequest req;\n    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;\n",
+		"    EchoResponse resp;\n    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;\n",
+		"    {\"e\", 1, handle_echo},",
+		"command_handler_fn handlers_lookup(const char *name, uint8_t name_len)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("source missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
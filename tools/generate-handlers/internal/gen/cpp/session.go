@@ -0,0 +1,74 @@
+package cpp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCppSessionMethods emits the public session methods of the C++
+// client. The client leaves the HMAC to the subclass, like the transport.
+func writeCppSessionMethods(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Return the HMAC-SHA256 of data under the key shared with the\n")
+	b.WriteString("     * peripheral, e.g. with OpenSSL's HMAC(). The session handshake sends it\n")
+	b.WriteString("     * over the challenge to prove knowledge of the key, and replay-protected\n")
+	b.WriteString("     * requests carry it over their counter.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    virtual std::string sessionProof(const std::string &data) = 0;\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Authenticate to the peripheral, unlocking its session-protected\n")
+	b.WriteString("     * commands. Protected calls open a session as needed; call this to\n")
+	b.WriteString("     * authenticate up front.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    void openSession()\n")
+	b.WriteString("    {\n")
+	b.WriteString("        std::lock_guard<std::mutex> lock(call_mutex_);\n")
+	b.WriteString("        handshake();\n")
+	b.WriteString("    }\n")
+}
+
+// writeCppSessionHelpers emits the handshake of the C++ client.
+func writeCppSessionHelpers(b *strings.Builder, start, auth gen.Command) {
+	tag := make([]string, 0, len(gen.StatusTag)+1)
+	for _, c := range gen.StatusTag {
+		tag = append(tag, fmt.Sprintf("'\\x%02X'", c))
+	}
+	tag = append(tag, fmt.Sprintf("'\\x%02X'", gen.StatusCodeValue("UNAUTHENTICATED")))
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Run the session handshake with call_mutex_ held and keep the token:\n")
+	b.WriteString("     * the proof is sessionProof() of the challenge.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    std::string handshake()\n")
+	b.WriteString("    {\n")
+	b.WriteString(fmt.Sprintf("        std::string start_data = call(%s, %s().SerializeAsString());\n", gen.CallName(start, "cpp"), cppMessageName(start.RequestMsg)))
+	b.WriteString(fmt.Sprintf("        %s req;\n", cppMessageName(auth.RequestMsg)))
+	b.WriteString(fmt.Sprintf("        req.set_proof(sessionProof(decode<%s>(\"%s\", start_data).challenge()));\n", cppMessageName(start.ResponseMsg), start.Snake))
+	b.WriteString(fmt.Sprintf("        std::string auth_data = call(%s, req.SerializeAsString());\n", gen.CallName(auth, "cpp")))
+	b.WriteString(fmt.Sprintf("        session_token_ = decode<%s>(\"%s\", auth_data).token();\n", cppMessageName(auth.ResponseMsg), auth.Snake))
+	b.WriteString("        return session_token_;\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Send a session-protected request led by the session token, opening a\n")
+	b.WriteString("     * session first when there is none. A peripheral that lost the session,\n")
+	b.WriteString("     * e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent\n")
+	b.WriteString("     * once more in a new session. Call with call_mutex_ held.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    std::string sessionCall(const std::string &cmd_name, const std::string &request_data)\n")
+	b.WriteString("    {\n")
+	b.WriteString(fmt.Sprintf("        static const std::string unauthenticated = {%s};\n", strings.Join(tag, ", ")))
+	b.WriteString("        if (session_token_.empty()) {\n")
+	b.WriteString("            handshake();\n")
+	b.WriteString("        }\n")
+	b.WriteString("        std::string resp_data = call(cmd_name, session_token_ + request_data);\n")
+	b.WriteString("        if (resp_data != unauthenticated) {\n")
+	b.WriteString("            return resp_data;\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return call(cmd_name, handshake() + request_data);\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,363 @@
+// Package csharp generates the C# central client.
+package csharp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The C# client targets Windows tooling on .NET with Google.Protobuf. Like
+// the C++ client, GeneratedClient leaves the transport to the caller:
+// subclasses implement CallAsync, StreamReceiveAsync and StreamSendAsync,
+// e.g. over Windows.Devices.Bluetooth, and inherit a Task-returning method
+// per command. The methods take and return the generated messages.
+
+// csharpNamespace returns the namespace protoc puts the messages of pkg in,
+// e.g. Acme.Sensor for acme.sensor.
+func csharpNamespace(pkg string) string {
+	parts := strings.Split(pkg, ".")
+	for i, p := range parts {
+		parts[i] = gen.UpperCamel(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// csharpMessageName returns the Google.Protobuf class of a message, e.g.
+// Pb.Outer.Types.Inner for the nested Outer.Inner.
+func csharpMessageName(msg string) string {
+	return "Pb." + strings.ReplaceAll(msg, ".", ".Types.")
+}
+
+// csharpStatusCheck returns the statements throwing CommandStatusException
+// for resp, or "" when the command is not checked.
+func csharpStatusCheck(cmd gen.Command, indent string) string {
+	if cmd.StatusField == "" {
+		return ""
+	}
+	getter := fmt.Sprintf("(int)resp.%s", gen.UpperCamel(cmd.StatusField))
+	return fmt.Sprintf("%sif (%s != %d)\n%s{\n%s    throw new CommandStatusException(\"%s\", \"%s\", %s);\n%s}\n",
+		indent, getter, cmd.StatusOK, indent, indent, cmd.Snake, cmd.StatusField, getter, indent)
+}
+
+// GenerateClient returns GeneratedClient.cs, a .NET client of the
+// commands.
+func GenerateClient(commands []gen.Command, streaming map[string]string, pkg string) string {
+	ns := csharpNamespace(pkg)
+	replay := gen.HasReplayProtected(commands)
+	start, auth, sessions := gen.SessionCommands(commands)
+	var b strings.Builder
+
+	lines := []string{
+		"/* Auto-generated by generate-handlers — DO NOT EDIT */",
+		"#nullable enable",
+		"",
+		"using System;",
+		"using System.Collections.Generic;",
+	}
+	if sessions {
+		lines = append(lines, "using System.Security.Cryptography;")
+	}
+	if replay {
+		lines = append(lines, "using System.Text;")
+	}
+	lines = append(lines,
+		"using System.Threading;",
+		"using System.Threading.Tasks;",
+		"using Google.Protobuf;",
+		"using Pb = global::"+ns+";",
+		"",
+		"namespace "+ns+".Client",
+		"{",
+	)
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+
+	writeCSharpErrors(&b, commands)
+
+	b.WriteString("    /// <summary>\n")
+	b.WriteString("    /// Auto-generated RPC client. Derive from it and implement CallAsync,\n")
+	b.WriteString("    /// StreamReceiveAsync and StreamSendAsync over the transport, e.g.\n")
+	b.WriteString("    /// Windows.Devices.Bluetooth. The command methods run one RPC at a time;\n")
+	b.WriteString("    /// the transport methods are called with the client's lock held.\n")
+	b.WriteString("    /// </summary>\n")
+	b.WriteString("    public abstract class GeneratedClient\n")
+	b.WriteString("    {\n")
+	b.WriteString("        private readonly SemaphoreSlim callLock = new SemaphoreSlim(1, 1);\n")
+	if replay {
+		b.WriteString("        private ulong lastReplayCounter;\n")
+	}
+	if sessions {
+		b.WriteString("        private byte[]? sessionToken;\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>Send one request and return the response payload.</summary>\n")
+	b.WriteString("        protected abstract Task<byte[]> CallAsync(string cmdName, byte[] requestData, CancellationToken cancellationToken);\n")
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>Send one request and return every response of a P→C stream.</summary>\n")
+	b.WriteString("        protected abstract Task<IReadOnlyList<byte[]>> StreamReceiveAsync(string cmdName, byte[] requestData, CancellationToken cancellationToken);\n")
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>Send the messages of a C→P stream and return the final response payload.</summary>\n")
+	b.WriteString("        protected abstract Task<byte[]> StreamSendAsync(string cmdName, IReadOnlyList<byte[]> messages, string finalCmdName, CancellationToken cancellationToken);\n")
+	if sessions {
+		writeCSharpSessionMethods(&b)
+	}
+	writeCSharpMethods(&b, commands, streaming)
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>Run a transport call with the client's lock held.</summary>\n")
+	b.WriteString("        private async Task<T> Exclusive<T>(Func<Task<T>> call, CancellationToken cancellationToken)\n")
+	b.WriteString("        {\n")
+	b.WriteString("            await callLock.WaitAsync(cancellationToken).ConfigureAwait(false);\n")
+	b.WriteString("            try\n")
+	b.WriteString("            {\n")
+	b.WriteString("                return await call().ConfigureAwait(false);\n")
+	b.WriteString("            }\n")
+	b.WriteString("            finally\n")
+	b.WriteString("            {\n")
+	b.WriteString("                callLock.Release();\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>Decode a response, throwing the error an error response reports.</summary>\n")
+	b.WriteString("        protected static T Decode<T>(string command, byte[] data, MessageParser<T> parser) where T : IMessage<T>\n")
+	b.WriteString("        {\n")
+	b.WriteString("            StatusErrors.ThrowIfError(command, data);\n")
+	b.WriteString("            try\n")
+	b.WriteString("            {\n")
+	b.WriteString("                return parser.ParseFrom(data);\n")
+	b.WriteString("            }\n")
+	b.WriteString("            catch (InvalidProtocolBufferException e)\n")
+	b.WriteString("            {\n")
+	b.WriteString("                throw new DecodeException(command, e);\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	if replay {
+		b.WriteByte('\n')
+		b.WriteString("        /// <summary>\n")
+		b.WriteString("        /// Lead a replay-protected request with a counter, 8 bytes little endian,\n")
+		b.WriteString(fmt.Sprintf("        /// and a MAC: the first %d bytes of an HMAC-SHA256 under SessionKey of\n", gen.ReplayMACSize))
+		b.WriteString("        /// the command name, a NUL, the counter and the request. The peripheral\n")
+		b.WriteString("        /// drops requests whose MAC is wrong or whose counter is not above the\n")
+		b.WriteString("        /// last one it accepted; microseconds since the epoch keep it increasing\n")
+		b.WriteString("        /// across restarts. Call with the client's lock held.\n")
+		b.WriteString("        /// </summary>\n")
+		b.WriteString("        private byte[] WithReplayCounter(string command, byte[] requestData)\n")
+		b.WriteString("        {\n")
+		b.WriteString("            byte[] key = SessionKey ?? throw new InvalidOperationException(\"SessionKey is not set\");\n")
+		b.WriteString("            ulong now = (ulong)(DateTimeOffset.UtcNow.ToUnixTimeMilliseconds() * 1000);\n")
+		b.WriteString("            lastReplayCounter = Math.Max(lastReplayCounter + 1, now);\n")
+		b.WriteString("            byte[] name = Encoding.UTF8.GetBytes(command);\n")
+		b.WriteString("            var signed = new byte[name.Length + 1 + 8 + requestData.Length];\n")
+		b.WriteString("            name.CopyTo(signed, 0);\n")
+		b.WriteString("            for (int i = 0; i < 8; i++)\n")
+		b.WriteString("            {\n")
+		b.WriteString("                signed[name.Length + 1 + i] = (byte)(lastReplayCounter >> (8 * i));\n")
+		b.WriteString("            }\n")
+		b.WriteString("            requestData.CopyTo(signed, name.Length + 9);\n")
+		b.WriteString("            using var hmac = new HMACSHA256(key);\n")
+		b.WriteString("            byte[] mac = hmac.ComputeHash(signed);\n")
+		b.WriteString(fmt.Sprintf("            var output = new byte[%d + requestData.Length];\n", gen.ReplayPrefixSize))
+		b.WriteString("            Array.Copy(signed, name.Length + 1, output, 0, 8);\n")
+		b.WriteString(fmt.Sprintf("            Array.Copy(mac, 0, output, 8, %d);\n", gen.ReplayMACSize))
+		b.WriteString(fmt.Sprintf("            requestData.CopyTo(output, %d);\n", gen.ReplayPrefixSize))
+		b.WriteString("            return output;\n")
+		b.WriteString("        }\n")
+	}
+	if sessions {
+		writeCSharpSessionHelpers(&b, start, auth)
+	}
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// writeCSharpException emits an exception class deriving from base.
+func writeCSharpException(b *strings.Builder, doc, name, base string, body []string) {
+	b.WriteString(fmt.Sprintf("    /// <summary>%s</summary>\n", doc))
+	b.WriteString(fmt.Sprintf("    public class %s : %s\n", name, base))
+	b.WriteString("    {\n")
+	for _, l := range body {
+		if l == "" {
+			b.WriteByte('\n')
+			continue
+		}
+		b.WriteString("        " + l + "\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+}
+
+// writeCSharpErrors emits the exception hierarchy and the status envelope
+// check.
+func writeCSharpErrors(b *strings.Builder, commands []gen.Command) {
+	writeCSharpException(b, "Base of every exception thrown by generated client methods.", "BlerpcException", "Exception", []string{
+		"public BlerpcException(string message, Exception? inner = null) : base(message, inner) { }",
+	})
+	writeCSharpException(b, "The request could not be delivered or the response was lost.", "TransportException", "BlerpcException", []string{
+		"public TransportException(string message, Exception? inner = null) : base(message, inner) { }",
+	})
+	writeCSharpException(b, "The peripheral did not respond in time.", "BlerpcTimeoutException", "BlerpcException", []string{
+		"public BlerpcTimeoutException(string command) : base(command + \": timed out\") => Command = command;",
+		"",
+		"public string Command { get; }",
+	})
+	writeCSharpException(b, "The response payload is not a valid message.", "DecodeException", "BlerpcException", []string{
+		"public DecodeException(string command, Exception? inner = null) : base(command + \": invalid response\", inner) => Command = command;",
+		"",
+		"public string Command { get; }",
+	})
+
+	b.WriteString("    /// <summary>Status codes of error responses; 128 and up are application codes.</summary>\n")
+	b.WriteString("    public enum StatusCode\n")
+	b.WriteString("    {\n")
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("        %s = %d,\n", gen.StatusErrorName(sc.Name), i))
+	}
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+
+	writeCSharpException(b, "The peripheral reported a non-OK status.", "RemoteException", "BlerpcException", []string{
+		"public RemoteException(string command, int status) : base(command + \" failed: status \" + status)",
+		"{",
+		"    Command = command;",
+		"    Status = status;",
+		"}",
+		"",
+		"public string Command { get; }",
+		"",
+		"public int Status { get; }",
+	})
+	if gen.HasStatusChecks(commands) {
+		writeCSharpException(b, "A checked command responded with a non-OK status.", "CommandStatusException", "RemoteException", []string{
+			"public CommandStatusException(string command, string field, int status) : base(command, status) => Field = field;",
+			"",
+			"public string Field { get; }",
+		})
+	}
+
+	tag := make([]string, len(gen.StatusTag))
+	for i, c := range gen.StatusTag {
+		tag[i] = fmt.Sprintf("0x%02X", c)
+	}
+	b.WriteString("    internal static class StatusErrors\n")
+	b.WriteString("    {\n")
+	b.WriteString(fmt.Sprintf("        private static readonly byte[] Tag = { %s };\n", strings.Join(tag, ", ")))
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>\n")
+	b.WriteString("        /// Throw the RemoteException an error response reports; return for other\n")
+	b.WriteString("        /// responses. Error responses hold only a status, in a field no message uses.\n")
+	b.WriteString("        /// </summary>\n")
+	b.WriteString("        internal static void ThrowIfError(string command, byte[] data)\n")
+	b.WriteString("        {\n")
+	b.WriteString("            if (data.Length <= Tag.Length || !data.AsSpan(0, Tag.Length).SequenceEqual(Tag))\n")
+	b.WriteString("            {\n")
+	b.WriteString("                return;\n")
+	b.WriteString("            }\n")
+	b.WriteString("            int status = 0;\n")
+	b.WriteString("            int shift = 0;\n")
+	b.WriteString("            for (int i = Tag.Length; i < data.Length; i++)\n")
+	b.WriteString("            {\n")
+	b.WriteString("                status |= (data[i] & 0x7F) << shift;\n")
+	b.WriteString("                shift += 7;\n")
+	b.WriteString("                if (data[i] < 0x80)\n")
+	b.WriteString("                {\n")
+	b.WriteString("                    break;\n")
+	b.WriteString("                }\n")
+	b.WriteString("            }\n")
+	b.WriteString("            throw new RemoteException(command, status);\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+}
+
+// writeCSharpMethods emits the command methods of GeneratedClient,
+// including deprecated aliases.
+func writeCSharpMethods(b *strings.Builder, commands []gen.Command, streaming map[string]string) {
+	for _, cmd := range commands {
+		reqCls := csharpMessageName(cmd.RequestMsg)
+		respCls := csharpMessageName(cmd.ResponseMsg)
+		name := gen.CallName(cmd, "csharp")
+		method := cmd.Camel + "Async"
+
+		b.WriteByte('\n')
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("        public async Task<IReadOnlyList<%s>> %s(%s req, CancellationToken cancellationToken = default)\n", respCls, method, reqCls))
+			b.WriteString("        {\n")
+			b.WriteString(fmt.Sprintf("            var raw = await Exclusive(() => StreamReceiveAsync(%s, req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);\n", name))
+			b.WriteString(fmt.Sprintf("            var responses = new List<%s>(raw.Count);\n", respCls))
+			b.WriteString("            foreach (byte[] data in raw)\n")
+			b.WriteString("            {\n")
+			if cmd.StatusField == "" {
+				b.WriteString(fmt.Sprintf("                responses.Add(Decode(\"%s\", data, %s.Parser));\n", cmd.Snake, respCls))
+			} else {
+				b.WriteString(fmt.Sprintf("                var resp = Decode(\"%s\", data, %s.Parser);\n", cmd.Snake, respCls))
+				b.WriteString(csharpStatusCheck(cmd, "                "))
+				b.WriteString("                responses.Add(resp);\n")
+			}
+			b.WriteString("            }\n")
+			b.WriteString("            return responses;\n")
+			b.WriteString("        }\n")
+		case "c2p":
+			b.WriteString(fmt.Sprintf("        public async Task<%s> %s(IEnumerable<%s> messages, CancellationToken cancellationToken = default)\n", respCls, method, reqCls))
+			b.WriteString("        {\n")
+			b.WriteString("            var raw = new List<byte[]>();\n")
+			b.WriteString(fmt.Sprintf("            foreach (%s msg in messages)\n", reqCls))
+			b.WriteString("            {\n")
+			b.WriteString("                raw.Add(msg.ToByteArray());\n")
+			b.WriteString("            }\n")
+			b.WriteString(fmt.Sprintf("            byte[] respData = await Exclusive(() => StreamSendAsync(%s, raw, %s, cancellationToken), cancellationToken).ConfigureAwait(false);\n", name, name))
+			writeCSharpParseResp(b, cmd, respCls)
+			b.WriteString("        }\n")
+		default:
+			reqData := "req.ToByteArray()"
+			if cmd.ReplayProtected {
+				reqData = fmt.Sprintf("WithReplayCounter(%q, %s)", cmd.Wire(), reqData)
+			}
+			b.WriteString(fmt.Sprintf("        public async Task<%s> %s(%s req, CancellationToken cancellationToken = default)\n", respCls, method, reqCls))
+			b.WriteString("        {\n")
+			fn := "CallAsync"
+			if cmd.SessionProtected {
+				fn = "SessionCallAsync"
+			}
+			b.WriteString(fmt.Sprintf("            byte[] respData = await Exclusive(() => %s(%s, %s, cancellationToken), cancellationToken).ConfigureAwait(false);\n", fn, name, reqData))
+			writeCSharpParseResp(b, cmd, respCls)
+			b.WriteString("        }\n")
+		}
+	}
+
+	// Deprecated aliases for renamed commands
+	for _, cmd := range commands {
+		if cmd.RenamedFrom == "" {
+			continue
+		}
+		param, ret := csharpMessageName(cmd.RequestMsg)+" req", "Task<"+csharpMessageName(cmd.ResponseMsg)+">"
+		arg := "req"
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			ret = "Task<IReadOnlyList<" + csharpMessageName(cmd.ResponseMsg) + ">>"
+		case "c2p":
+			param = "IEnumerable<" + csharpMessageName(cmd.RequestMsg) + "> messages"
+			arg = "messages"
+		}
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("        [Obsolete(\"use %sAsync\")]\n", cmd.Camel))
+		b.WriteString(fmt.Sprintf("        public %s %sAsync(%s, CancellationToken cancellationToken = default) =>\n", ret, gen.UpperCamel(cmd.RenamedFrom), param))
+		b.WriteString(fmt.Sprintf("            %sAsync(%s, cancellationToken);\n", cmd.Camel, arg))
+	}
+}
+
+// writeCSharpParseResp emits the decoding and return of respData, checking
+// the status field first when the command opted in.
+func writeCSharpParseResp(b *strings.Builder, cmd gen.Command, respCls string) {
+	if cmd.StatusField == "" {
+		b.WriteString(fmt.Sprintf("            return Decode(\"%s\", respData, %s.Parser);\n", cmd.Snake, respCls))
+		return
+	}
+	b.WriteString(fmt.Sprintf("            var resp = Decode(\"%s\", respData, %s.Parser);\n", cmd.Snake, respCls))
+	b.WriteString(csharpStatusCheck(cmd, "            "))
+	b.WriteString("            return resp;\n")
+}
@@ -0,0 +1,57 @@
+package csharp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestCSharpNames(t *testing.T) {
+	if got := csharpNamespace("acme.sensor_hub"); got != "Acme.SensorHub" {
+		t.Errorf("csharpNamespace = %q", got)
+	}
+	if got := csharpMessageName("SensorReading.Sample"); got != "Pb.SensorReading.Types.Sample" {
+		t.Errorf("csharpMessageName = %q", got)
+	}
+}
+
+func TestGenerateCSharpClient(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.ReplayProtected = true
+	echo.StatusField, echo.StatusOK = "status", 0
+	upload := gentest.StreamC2PCommand()
+	upload.RenamedFrom = "CounterPush"
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), upload}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateClient(cmds, streaming, "acme.sensor")
+
+	for _, want := range []string{
+		"using Pb = global::Acme.Sensor;\n\nnamespace Acme.Sensor.Client\n{\n",
+		"        NotFound = 2,\n",
+		"        private static readonly byte[] Tag = { 0xF8, 0xFF, 0xFF, 0xFF, 0x0F };\n",
+		"        protected abstract Task<byte[]> CallAsync(string cmdName, byte[] requestData, CancellationToken cancellationToken);\n",
+		"        public async Task<Pb.EchoResponse> EchoAsync(Pb.EchoRequest req, CancellationToken cancellationToken = default)\n" +
+			"        {\n" +
+			"            byte[] respData = await Exclusive(() => CallAsync(\"echo\", WithReplayCounter(\"echo\", req.ToByteArray()), cancellationToken), cancellationToken).ConfigureAwait(false);\n",
+		"            if ((int)resp.Status != 0)\n            {\n                throw new CommandStatusException(\"echo\", \"status\", (int)resp.Status);\n",
+		"        public async Task<IReadOnlyList<Pb.CounterStreamResponse>> CounterStreamAsync(Pb.CounterStreamRequest req, CancellationToken cancellationToken = default)\n",
+		"StreamSendAsync(\"counter_upload\", raw, \"counter_upload\", cancellationToken)",
+		"        [Obsolete(\"use CounterUploadAsync\")]\n" +
+			"        public Task<Pb.CounterUploadResponse> CounterPushAsync(IEnumerable<Pb.CounterUploadRequest> messages, CancellationToken cancellationToken = default) =>\n" +
+			"            CounterUploadAsync(messages, cancellationToken);\n",
+		"        private ulong lastReplayCounter;\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("client missing %q\nGot:\n%s", want, out)
+		}
+	}
+
+	plain := GenerateClient([]gen.Command{gentest.EchoCommand()}, nil, "blerpc")
+	for _, s := range []string{"WithReplayCounter", "CommandStatusException"} {
+		if strings.Contains(plain, s) {
+			t.Errorf("%s emitted without replay-protected or checked commands", s)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package csharp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeCSharpSessionMethods emits the public session members of the C#
+// client.
+func writeCSharpSessionMethods(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>\n")
+	b.WriteString("        /// Key shared with the peripheral, which the session handshake proves\n")
+	b.WriteString("        /// knowledge of. Set it before calling a session- or replay-protected\n")
+	b.WriteString("        /// command.\n")
+	b.WriteString("        /// </summary>\n")
+	b.WriteString("        public byte[]? SessionKey { get; set; }\n")
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>\n")
+	b.WriteString("        /// Authenticate to the peripheral, unlocking its session-protected\n")
+	b.WriteString("        /// commands. Protected calls open a session as needed; call this to\n")
+	b.WriteString("        /// authenticate up front.\n")
+	b.WriteString("        /// </summary>\n")
+	b.WriteString("        public Task OpenSessionAsync(CancellationToken cancellationToken = default) =>\n")
+	b.WriteString("            Exclusive(() => HandshakeAsync(cancellationToken), cancellationToken);\n")
+}
+
+// writeCSharpSessionHelpers emits the handshake of the C# client.
+func writeCSharpSessionHelpers(b *strings.Builder, start, auth gen.Command) {
+	tag := make([]string, 0, len(gen.StatusTag)+1)
+	for _, c := range gen.StatusTag {
+		tag = append(tag, fmt.Sprintf("0x%02X", c))
+	}
+	tag = append(tag, fmt.Sprintf("0x%02X", gen.StatusCodeValue("UNAUTHENTICATED")))
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>Error response of a peripheral that has no session with the token.</summary>\n")
+	b.WriteString(fmt.Sprintf("        private static readonly byte[] Unauthenticated = { %s };\n", strings.Join(tag, ", ")))
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>\n")
+	b.WriteString("        /// Run the session handshake with the client's lock held and keep the\n")
+	b.WriteString("        /// token: the proof is an HMAC-SHA256 of the challenge under SessionKey.\n")
+	b.WriteString("        /// </summary>\n")
+	b.WriteString("        private async Task<byte[]> HandshakeAsync(CancellationToken cancellationToken)\n")
+	b.WriteString("        {\n")
+	b.WriteString("            byte[] key = SessionKey ?? throw new InvalidOperationException(\"SessionKey is not set\");\n")
+	b.WriteString(fmt.Sprintf("            byte[] startData = await CallAsync(%s, new %s().ToByteArray(), cancellationToken).ConfigureAwait(false);\n", gen.CallName(start, "csharp"), csharpMessageName(start.RequestMsg)))
+	b.WriteString(fmt.Sprintf("            var challenge = Decode(\"%s\", startData, %s.Parser).Challenge;\n", start.Snake, csharpMessageName(start.ResponseMsg)))
+	b.WriteString("            using var hmac = new HMACSHA256(key);\n")
+	b.WriteString(fmt.Sprintf("            var req = new %s { Proof = ByteString.CopyFrom(hmac.ComputeHash(challenge.ToByteArray())) };\n", csharpMessageName(auth.RequestMsg)))
+	b.WriteString(fmt.Sprintf("            byte[] authData = await CallAsync(%s, req.ToByteArray(), cancellationToken).ConfigureAwait(false);\n", gen.CallName(auth, "csharp")))
+	b.WriteString(fmt.Sprintf("            byte[] token = Decode(\"%s\", authData, %s.Parser).Token.ToByteArray();\n", auth.Snake, csharpMessageName(auth.ResponseMsg)))
+	b.WriteString("            sessionToken = token;\n")
+	b.WriteString("            return token;\n")
+	b.WriteString("        }\n")
+	b.WriteByte('\n')
+	b.WriteString("        /// <summary>\n")
+	b.WriteString("        /// Send a session-protected request led by the session token, opening a\n")
+	b.WriteString("        /// session first when there is none. A peripheral that lost the session,\n")
+	b.WriteString("        /// e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent\n")
+	b.WriteString("        /// once more in a new session. Call with the client's lock held.\n")
+	b.WriteString("        /// </summary>\n")
+	b.WriteString("        private async Task<byte[]> SessionCallAsync(string cmdName, byte[] requestData, CancellationToken cancellationToken)\n")
+	b.WriteString("        {\n")
+	b.WriteString("            byte[] token = sessionToken ?? await HandshakeAsync(cancellationToken).ConfigureAwait(false);\n")
+	b.WriteString("            byte[] respData = await CallAsync(cmdName, WithToken(token, requestData), cancellationToken).ConfigureAwait(false);\n")
+	b.WriteString("            if (!respData.AsSpan().SequenceEqual(Unauthenticated))\n")
+	b.WriteString("            {\n")
+	b.WriteString("                return respData;\n")
+	b.WriteString("            }\n")
+	b.WriteString("            token = await HandshakeAsync(cancellationToken).ConfigureAwait(false);\n")
+	b.WriteString("            return await CallAsync(cmdName, WithToken(token, requestData), cancellationToken).ConfigureAwait(false);\n")
+	b.WriteString("        }\n")
+	b.WriteByte('\n')
+	b.WriteString("        private static byte[] WithToken(byte[] token, byte[] requestData)\n")
+	b.WriteString("        {\n")
+	b.WriteString("            var output = new byte[token.Length + requestData.Length];\n")
+	b.WriteString("            token.CopyTo(output, 0);\n")
+	b.WriteString("            requestData.CopyTo(output, token.Length);\n")
+	b.WriteString("            return output;\n")
+	b.WriteString("        }\n")
+}
@@ -0,0 +1,52 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeDartBlerpcInfoHelpers emits the schema check of the Dart client
+// mixin.
+func writeDartBlerpcInfoHelpers(b *strings.Builder, cmd gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("  /// Checks that the peripheral was built from this client's schema. Call\n")
+	b.WriteString("  /// once on connect; throws [SchemaMismatchError] when the schema hashes\n")
+	b.WriteString("  /// differ.\n")
+	b.WriteString(fmt.Sprintf("  Future<%s> verifySchema() async {\n", cmd.ResponseMsg))
+	b.WriteString(fmt.Sprintf("    final info = await %s();\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("    if (info.schemaHash != blerpcSchemaHash) {\n")
+	b.WriteString("      throw SchemaMismatchError(\n")
+	b.WriteString("          blerpcSchemaHash, info.schemaHash, info.generatorVersion);\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return info;\n")
+	b.WriteString("  }\n")
+}
+
+// writeDartSchemaMismatchError emits the schema constants and the error
+// verifySchema throws, after the client mixin.
+func writeDartSchemaMismatchError(gctx *gen.Context, b *strings.Builder, commands []gen.Command) {
+	if _, ok := gen.BuiltinCommand(commands, "blerpc_info"); !ok {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/// The schema this client was generated from, which verifySchema compares\n")
+	b.WriteString("/// with the peripheral's.\n")
+	b.WriteString(fmt.Sprintf("const blerpcSchemaHash = '%s';\n", gctx.SchemaHash))
+	b.WriteString(fmt.Sprintf("const blerpcGeneratorVersion = '%s';\n", gen.GeneratorVersion))
+	b.WriteByte('\n')
+	b.WriteString("/// The peripheral was built from a different schema than this client.\n")
+	b.WriteString("class SchemaMismatchError implements Exception {\n")
+	b.WriteString("  final String expected;\n")
+	b.WriteString("  final String actual;\n")
+	b.WriteString("  final String generatorVersion;\n")
+	b.WriteByte('\n')
+	b.WriteString("  SchemaMismatchError(this.expected, this.actual, this.generatorVersion);\n")
+	b.WriteByte('\n')
+	b.WriteString("  @override\n")
+	b.WriteString("  String toString() =>\n")
+	b.WriteString("      'SchemaMismatchError: peripheral schema $actual (generator $generatorVersion) '\n")
+	b.WriteString("      \"does not match this client's $expected (generator $blerpcGeneratorVersion)\";\n")
+	b.WriteString("}\n")
+}
@@ -0,0 +1,23 @@
+package dart
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeDartBuiltinHelpers(b *strings.Builder, commands []gen.Command) {
+	if cmd, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writeDartBlerpcInfoHelpers(b, cmd)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "log_stream"); ok {
+		writeDartLogStreamHelpers(b, cmd)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "ping"); ok {
+		writeDartPingHelpers(b, cmd)
+	}
+	writeDartSettingsHelpers(b, commands)
+	if cmd, ok := gen.BuiltinCommand(commands, "time_sync"); ok {
+		writeDartTimeSyncHelpers(b, cmd)
+	}
+}
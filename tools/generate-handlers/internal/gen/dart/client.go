@@ -0,0 +1,155 @@
+// Package dart generates the Dart central client.
+package dart
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// dartParam renders a named parameter. proto2 required fields become
+// `required` named parameters without a default.
+func dartParam(f gen.Field) string {
+	propName := dartPropertyName(f.Name)
+	if f.IsRequired {
+		return fmt.Sprintf("required %s %s", ResolveType(f), propName)
+	}
+	if dartNullable(f) {
+		// Message constructors are not const, and oneof members must stay
+		// unset unless given, so these default to null.
+		return fmt.Sprintf("%s? %s", ResolveType(f), propName)
+	}
+	return fmt.Sprintf("%s %s = %s", ResolveType(f), propName, ResolveDefault(f))
+}
+
+// dartNullable reports whether a request field is a nullable parameter: a
+// singular message that is not proto2 required, or a oneof member.
+func dartNullable(f gen.Field) bool {
+	return f.Oneof != "" || f.IsMessage && !f.IsRepeated && !f.IsMap && !f.IsRequired
+}
+
+// dartRequest is the construction of req from the method parameters.
+// Scalars are set in a cascade, single field on one line and multiple
+// fields multiline; repeated and map fields have no setter and are filled
+// through addAll; nullable parameters are set when given.
+type dartRequest struct {
+	Cascade  []string
+	Nullable []string
+}
+
+func newDartRequest(fields []gen.Field) dartRequest {
+	var r dartRequest
+	for _, f := range fields {
+		propName := dartPropertyName(f.Name)
+		switch {
+		case dartNullable(f):
+			r.Nullable = append(r.Nullable, propName)
+		case f.IsRepeated || f.IsMap:
+			r.Cascade = append(r.Cascade, fmt.Sprintf("..%s.addAll(%s)", propName, propName))
+		default:
+			r.Cascade = append(r.Cascade, fmt.Sprintf("..%s = %s", propName, propName))
+		}
+	}
+	return r
+}
+
+// dartProtoFiles returns the proto files whose generated Dart libraries the
+// client imports, sorted: the main file and the imported files defining
+// message types of request fields.
+func dartProtoFiles(commands []gen.Command, pkg string) []string {
+	files := []string{pkg}
+	for _, cmd := range commands {
+		for _, f := range cmd.RequestFields {
+			if f.TypeFile != "" && !slices.Contains(files, f.TypeFile) {
+				files = append(files, f.TypeFile)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// clientData fills generated_client.dart.tmpl. The sections of the
+// features, such as sessions and the built-ins, are written in Go.
+type clientData struct {
+	Pkg              string
+	ProtoFiles       []string
+	Replay, Sessions bool
+	Preamble         string // top-level declarations before the mixin
+	SessionHelpers   string
+	Methods          []clientMethod
+	Helpers          string // the built-in helpers, inside the mixin
+	Trailer          string // the declarations after the mixin
+}
+
+// clientMethod is the method of a command. Stream is "p2c", "c2p" or
+// empty for a unary command.
+type clientMethod struct {
+	Name, Request, Response, Params, Wire string
+	Stream                                string
+	SessionProtected, ReplayProtected     bool
+	Build                                 dartRequest
+}
+
+// GenerateClient returns generated_client.dart: GeneratedClientMixin with
+// a method per command.
+func GenerateClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	start, auth, sessions := gen.SessionCommands(commands)
+	d := clientData{Pkg: pkg, ProtoFiles: dartProtoFiles(commands, pkg), Replay: gen.HasReplayProtected(commands), Sessions: sessions}
+
+	var b strings.Builder
+	if d.Replay {
+		writeDartReplayCounter(&b)
+	}
+	if sessions {
+		writeDartUnauthenticated(&b)
+	}
+	d.Preamble = b.String()
+	if sessions {
+		b.Reset()
+		writeDartSessionHelpers(&b, start, auth)
+		d.SessionHelpers = b.String()
+	}
+
+	// Unary methods come first, then the streaming ones.
+	for _, stream := range []bool{false, true} {
+		for _, cmd := range commands {
+			dir, ok := streaming[cmd.Snake]
+			if ok != stream {
+				continue
+			}
+			m := clientMethod{
+				Name:             gen.ToLowerCamel(cmd.Camel),
+				Request:          cmd.RequestMsg,
+				Response:         cmd.ResponseMsg,
+				Wire:             cmd.Wire(),
+				Stream:           dir,
+				SessionProtected: cmd.SessionProtected,
+				ReplayProtected:  cmd.ReplayProtected,
+				Build:            newDartRequest(cmd.RequestFields),
+			}
+			var params []string
+			for _, f := range cmd.RequestFields {
+				params = append(params, dartParam(f))
+			}
+			if len(params) > 0 {
+				m.Params = "{" + strings.Join(params, ", ") + "}"
+			}
+			d.Methods = append(d.Methods, m)
+		}
+	}
+
+	b.Reset()
+	writeDartBuiltinHelpers(&b, commands)
+	d.Helpers = b.String()
+	b.Reset()
+	writeDartCharacteristics(&b, commands)
+	writeDartRoles(&b, commands)
+	writeDartSchemaMismatchError(gctx, &b, commands)
+	d.Trailer = b.String()
+
+	return gctx.RenderTemplate("generated_client.dart.tmpl", d)
+}
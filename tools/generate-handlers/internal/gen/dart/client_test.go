@@ -0,0 +1,197 @@
+package dart
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateDartClient_Echo(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"mixin GeneratedClientMixin",
+		"Future<EchoResponse> echo(",
+		"String message = ''",
+		"final req = EchoRequest()..message = message;",
+		"await exclusive(\n        () => call('echo'",
+		"  Future<void> _rpcTail = Future.value();\n",
+		"EchoResponse.fromBuffer(respData)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client missing %q", s)
+		}
+	}
+}
+
+func TestGenerateDartClient_CustomPkg(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "myapp")
+
+	mustContain := []string{
+		"import 'package:myapp_central/proto/myapp.pb.dart'",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client custom pkg missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "blerpc") {
+		t.Errorf("Dart client custom pkg should not contain 'blerpc'")
+	}
+}
+
+func TestGenerateDartClient_Repeated(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.RepeatedCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"List<String> names = const []",
+		"List<int> ids = const []",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client repeated missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateDartClient_MessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MessageFieldCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"Address? address",
+		"if (address != null) req.address = address;",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client message field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateDartClient_NestedMessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.NestedMessageCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"Outer_Inner? tag, List<Point> path = const [], Map<String, Point> named = const {}",
+		"    final req = DrawRequest()\n      ..path.addAll(path)\n      ..named.addAll(named);\n",
+		"    if (tag != null) req.tag = tag;\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client nested message field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateDartClient_ImportedMessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.MessageFieldCommand()
+	cmd.RequestFields[1].TypeFile = "common/types"
+	out := GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc")
+
+	want := "import 'package:blerpc_central/proto/blerpc.pb.dart';\nimport 'package:blerpc_central/proto/common/types.pb.dart';\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("Dart client missing imports %q\nGot:\n%s", want, out)
+	}
+}
+
+func TestGenerateDartClient_Oneof(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.OneofCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"search({int limit = 0, String? text, int? userId})",
+		"    final req = SearchRequest()..limit = limit;\n    if (text != null) req.text = text;\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client oneof missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateDartClient_Map(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MapCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"Map<String, String> labels = const {}",
+		"Map<String, int> counts = const {}",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client map missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateDartClient_Enum(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EnumCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	if !strings.Contains(out, "Future<GetStatusResponse> getStatus(") {
+		t.Errorf("Dart client enum missing getStatus method\nGot:\n%s", out)
+	}
+}
+
+func TestGenerateDartClient_StreamP2C(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"Future<List<CounterStreamResponse>> counterStream(",
+		"await exclusive(() => streamReceive(",
+		"CounterStreamResponse.fromBuffer(data)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client p2c missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateDartClient_StreamC2P(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_upload": "c2p"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"Future<CounterUploadResponse> counterUpload(",
+		"List<CounterUploadRequest> messages",
+		"await exclusive(\n        () => streamSend(",
+		"CounterUploadResponse.fromBuffer(respData)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Dart client c2p missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateDartClient_Proto2(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.Proto2Command()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	want := "configure({required int address, String label = 'dev'}) async {"
+	if !strings.Contains(out, want) {
+		t.Errorf("Dart client proto2 missing %q\nGot:\n%s", want, out)
+	}
+}
@@ -0,0 +1,38 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeDartGattGroups(gctx *gen.Context, b *strings.Builder) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/// Services grouping commands apart from the RPC service, by name.\n")
+	b.WriteString("const gattServices = <String, ({String service, String characteristic})>{\n")
+	for _, g := range gctx.GATTGroups {
+		b.WriteString(fmt.Sprintf("  '%s': (\n", g.Name))
+		b.WriteString(fmt.Sprintf("    service: '%s',\n", g.ServiceUUID))
+		b.WriteString(fmt.Sprintf("    characteristic: '%s',\n", g.CharUUID))
+		b.WriteString("  ),\n")
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Service of each grouped command, by wire name; the others use\n")
+	b.WriteString("/// [serviceUuid].\n")
+	b.WriteString("const commandServices = <String, String>{\n")
+	for _, c := range gctx.GATTGroupCommands() {
+		b.WriteString(fmt.Sprintf("  '%s': '%s',\n", c[0], c[1]))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The characteristic [command] is written to.\n")
+	b.WriteString("String characteristicFor(String command) {\n")
+	b.WriteString("  final service = commandServices[command];\n")
+	b.WriteString("  return service == null ? charUuid : gattServices[service]!.characteristic;\n")
+	b.WriteString("}\n")
+}
@@ -0,0 +1,10 @@
+package dart
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// dartPropertyName converts a snake_case field name to lowerCamelCase (same as Swift).
+func dartPropertyName(fieldName string) string {
+	return gen.SwiftPropertyName(fieldName)
+}
@@ -0,0 +1,35 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeDartLogStreamHelpers emits the log reader of the Dart client mixin.
+func writeDartLogStreamHelpers(b *strings.Builder, cmd gen.Command) {
+	respCls := cmd.ResponseMsg
+	b.WriteByte('\n')
+	b.WriteString("  /// Drains the firmware log into (timestamp, entry) pairs, oldest first. The\n")
+	b.WriteString("  /// message of each entry joins the fragments of a long message, and the\n")
+	b.WriteString("  /// timestamp is when it was logged.\n")
+	b.WriteString(fmt.Sprintf("  Future<List<(DateTime, %s)>> readLogs({int minLevel = 0, int maxEntries = 0}) async {\n", respCls))
+	b.WriteString(fmt.Sprintf("    final responses = await %s(minLevel: minLevel, maxEntries: maxEntries);\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("    final received = DateTime.now();\n")
+	b.WriteString(fmt.Sprintf("    final logs = <(DateTime, %s)>[];\n", respCls))
+	b.WriteString(fmt.Sprintf("    %s? first;\n", respCls))
+	b.WriteString("    for (final resp in responses) {\n")
+	b.WriteString("      if (first == null) {\n")
+	b.WriteString("        first = resp;\n")
+	b.WriteString("      } else {\n")
+	b.WriteString("        first.message += resp.message;\n")
+	b.WriteString("      }\n")
+	b.WriteString("      if (resp.more) continue;\n")
+	b.WriteString("      final ageMs = (first.nowMs - first.uptimeMs) & 0xFFFFFFFF;\n")
+	b.WriteString("      logs.add((received.subtract(Duration(milliseconds: ageMs)), first));\n")
+	b.WriteString("      first = null;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return logs;\n")
+	b.WriteString("  }\n")
+}
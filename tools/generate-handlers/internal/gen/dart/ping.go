@@ -0,0 +1,36 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeDartPingHelpers emits the latency and keepalive helpers of the Dart
+// client mixin.
+func writeDartPingHelpers(b *strings.Builder, cmd gen.Command) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("  /// Pings the peripheral and returns the round trip.\n")
+	b.WriteString("  Future<Duration> measureLatency() async {\n")
+	b.WriteString("    final roundTrip = Stopwatch()..start();\n")
+	b.WriteString(fmt.Sprintf("    await %s();\n", method))
+	b.WriteString("    return roundTrip.elapsed;\n")
+	b.WriteString("  }\n")
+	b.WriteByte('\n')
+	b.WriteString("  /// Pings the peripheral every [interval], passing each round trip to\n")
+	b.WriteString("  /// [onLatency], until [isActive] returns false. It throws as soon as a ping\n")
+	b.WriteString("  /// fails, so a dead connection shows before a long call times out.\n")
+	b.WriteString("  Future<void> keepalive({\n")
+	b.WriteString("    Duration interval = const Duration(seconds: 5),\n")
+	b.WriteString("    void Function(Duration latency)? onLatency,\n")
+	b.WriteString("    bool Function()? isActive,\n")
+	b.WriteString("  }) async {\n")
+	b.WriteString("    while (isActive?.call() ?? true) {\n")
+	b.WriteString("      final latency = await measureLatency();\n")
+	b.WriteString("      onLatency?.call(latency);\n")
+	b.WriteString("      await Future<void>.delayed(interval);\n")
+	b.WriteString("    }\n")
+	b.WriteString("  }\n")
+}
@@ -0,0 +1,32 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeDartReplayCounter emits the counter of replay-protected requests.
+func writeDartReplayCounter(b *strings.Builder) {
+	b.WriteString("int _lastReplayCounter = 0;\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Leads replay-protected requests with a counter, 8 bytes little endian,\n")
+	b.WriteString("/// and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of\n")
+	b.WriteString("/// the command name, a NUL, the counter and the request. The peripheral drops\n")
+	b.WriteString("/// requests whose MAC is wrong or whose counter is not above the last one it\n")
+	b.WriteString("/// accepted; microseconds since the epoch keep it increasing across restarts.\n")
+	b.WriteString("Uint8List _withReplayCounter(\n")
+	b.WriteString("    List<int>? key, String command, List<int> requestData) {\n")
+	b.WriteString("  if (key == null) throw StateError('sessionKey is not set');\n")
+	b.WriteString("  final now = DateTime.now().microsecondsSinceEpoch;\n")
+	b.WriteString("  _lastReplayCounter = now > _lastReplayCounter ? now : _lastReplayCounter + 1;\n")
+	b.WriteString("  final counter = (ByteData(8)..setUint64(0, _lastReplayCounter, Endian.little))\n")
+	b.WriteString("      .buffer\n")
+	b.WriteString("      .asUint8List();\n")
+	b.WriteString("  final mac = Hmac(sha256, key)\n")
+	b.WriteString("      .convert([...utf8.encode(command), 0, ...counter, ...requestData]).bytes;\n")
+	b.WriteString(fmt.Sprintf("  return Uint8List.fromList([...counter, ...mac.sublist(0, %d), ...requestData]);\n", gen.ReplayMACSize))
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,22 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeDartRoles(b *strings.Builder, commands []gen.Command) {
+	privileged := gen.PrivilegedCommands(commands)
+	if len(privileged) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/// Role each command requires on the peripheral, keyed by wire name; others need 'user'.\n")
+	b.WriteString("const commandRoles = <String, String>{\n")
+	for _, cmd := range privileged {
+		b.WriteString(fmt.Sprintf("  '%s': '%s',\n", cmd.Wire(), cmd.Role))
+	}
+	b.WriteString("};\n")
+}
@@ -0,0 +1,73 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeDartUnauthenticated emits the check for the error response of a
+// peripheral that has no session with the token a request led with.
+func writeDartUnauthenticated(b *strings.Builder) {
+	tag := make([]string, len(gen.StatusTag))
+	for i, c := range gen.StatusTag {
+		tag[i] = fmt.Sprintf("0x%02x", c)
+	}
+	b.WriteString(fmt.Sprintf("const _unauthenticated = [%s, %d];\n", strings.Join(tag, ", "), gen.StatusCodeValue("UNAUTHENTICATED")))
+	b.WriteByte('\n')
+	b.WriteString("bool _isUnauthenticated(List<int> data) {\n")
+	b.WriteString("  if (data.length != _unauthenticated.length) return false;\n")
+	b.WriteString("  for (var i = 0; i < data.length; i++) {\n")
+	b.WriteString("    if (data[i] != _unauthenticated[i]) return false;\n")
+	b.WriteString("  }\n")
+	b.WriteString("  return true;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeDartSessionHelpers emits the session key and handshake of the Dart
+// client mixin. The HMAC comes from package:crypto, which the app depends
+// on when it enables the session built-in.
+func writeDartSessionHelpers(b *strings.Builder, start, auth gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("  /// Key shared with the peripheral, which the session handshake proves\n")
+	b.WriteString("  /// knowledge of. Set it before calling a session- or replay-protected\n")
+	b.WriteString("  /// command.\n")
+	b.WriteString("  List<int>? sessionKey;\n")
+	b.WriteByte('\n')
+	b.WriteString("  List<int>? _sessionToken;\n")
+	b.WriteByte('\n')
+	b.WriteString("  /// Authenticates to the peripheral, unlocking its session-protected\n")
+	b.WriteString("  /// commands. Protected calls open a session as needed; call this to\n")
+	b.WriteString("  /// authenticate up front.\n")
+	b.WriteString("  Future<void> openSession() => exclusive(_handshake);\n")
+	b.WriteByte('\n')
+	b.WriteString("  /// Runs the session handshake inside the caller's [exclusive] call and\n")
+	b.WriteString("  /// keeps the token: the proof is an HMAC-SHA256 of the challenge under\n")
+	b.WriteString("  /// [sessionKey].\n")
+	b.WriteString("  Future<List<int>> _handshake() async {\n")
+	b.WriteString("    final key = sessionKey;\n")
+	b.WriteString("    if (key == null) throw StateError('sessionKey is not set');\n")
+	b.WriteString(fmt.Sprintf("    final startData = await call(\n        '%s', Uint8List.fromList(%s().writeToBuffer()));\n", start.Wire(), start.RequestMsg))
+	b.WriteString(fmt.Sprintf("    final challenge = %s.fromBuffer(startData).challenge;\n", start.ResponseMsg))
+	b.WriteString(fmt.Sprintf("    final req = %s()\n", auth.RequestMsg))
+	b.WriteString("      ..proof = Hmac(sha256, key).convert(challenge).bytes;\n")
+	b.WriteString(fmt.Sprintf("    final authData =\n        await call('%s', Uint8List.fromList(req.writeToBuffer()));\n", auth.Wire()))
+	b.WriteString(fmt.Sprintf("    final token = %s.fromBuffer(authData).token;\n", auth.ResponseMsg))
+	b.WriteString("    _sessionToken = token;\n")
+	b.WriteString("    return token;\n")
+	b.WriteString("  }\n")
+	b.WriteByte('\n')
+	b.WriteString("  /// Sends a session-protected request led by the session token, opening a\n")
+	b.WriteString("  /// session first when there is none. A peripheral that lost the session,\n")
+	b.WriteString("  /// e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent\n")
+	b.WriteString("  /// once more in a new session.\n")
+	b.WriteString("  Future<Uint8List> _sessionCall(String cmdName, List<int> requestData) async {\n")
+	b.WriteString("    final token = _sessionToken ?? await _handshake();\n")
+	b.WriteString("    final respData =\n        await call(cmdName, Uint8List.fromList([...token, ...requestData]));\n")
+	b.WriteString("    if (!_isUnauthenticated(respData)) return respData;\n")
+	b.WriteString("    final fresh = await _handshake();\n")
+	b.WriteString("    return call(cmdName, Uint8List.fromList([...fresh, ...requestData]));\n")
+	b.WriteString("  }\n")
+}
@@ -0,0 +1,34 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeDartSettingsHelpers emits the typed settings accessors of the Dart
+// client mixin.
+func writeDartSettingsHelpers(b *strings.Builder, commands []gen.Command) {
+	get, set := gen.SettingsCommands(commands)
+	if get != nil {
+		for _, f := range get.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("  /// Reads the %s setting.\n", f.Name))
+			b.WriteString(fmt.Sprintf("  Future<%s> get%sSetting() async {\n", ResolveType(f), gen.SettingLabel(f)))
+			b.WriteString(fmt.Sprintf("    final resp = await %s(field: %d);\n", gen.ToLowerCamel(get.Camel), f.Number))
+			b.WriteString(fmt.Sprintf("    return %s.fromBuffer(resp.value).%s;\n", get.Settings.Name, dartPropertyName(f.Name)))
+			b.WriteString("  }\n")
+		}
+	}
+	if set != nil {
+		for _, f := range set.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("  /// Writes the %s setting.\n", f.Name))
+			b.WriteString(fmt.Sprintf("  Future<void> set%sSetting(%s value) async {\n", gen.SettingLabel(f), ResolveType(f)))
+			b.WriteString(fmt.Sprintf("    final settings = %s()..%s = value;\n", set.Settings.Name, dartPropertyName(f.Name)))
+			b.WriteString(fmt.Sprintf("    await %s(field: %d, value: settings.writeToBuffer());\n", gen.ToLowerCamel(set.Camel), f.Number))
+			b.WriteString("  }\n")
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeDartTimeSyncHelpers emits the clock helper of the Dart client mixin.
+func writeDartTimeSyncHelpers(b *strings.Builder, cmd gen.Command) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("  /// Sets the peripheral's wall clock to this device's. With [compensate], a\n")
+	b.WriteString("  /// first exchange measures the round trip and the second adds half of it,\n")
+	b.WriteString("  /// the estimated delivery delay.\n")
+	b.WriteString(fmt.Sprintf("  Future<%s> syncTime({bool compensate = false}) async {\n", cmd.ResponseMsg))
+	b.WriteString("    var offsetUs = 0;\n")
+	b.WriteString("    if (compensate) {\n")
+	b.WriteString("      final roundTrip = Stopwatch()..start();\n")
+	b.WriteString(fmt.Sprintf("      await %s(unixTimeUs: DateTime.now().microsecondsSinceEpoch);\n", method))
+	b.WriteString("      offsetUs = roundTrip.elapsedMicroseconds ~/ 2;\n")
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    return %s(unixTimeUs: DateTime.now().microsecondsSinceEpoch, offsetUs: offsetUs);\n", method))
+	b.WriteString("  }\n")
+}
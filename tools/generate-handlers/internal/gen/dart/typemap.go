@@ -0,0 +1,76 @@
+package dart
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// dartDefaults maps proto field types to Dart default values.
+var dartDefaults = map[string]string{
+	"string":   "''",
+	"bytes":    "const <int>[]",
+	"uint32":   "0",
+	"int32":    "0",
+	"uint64":   "0",
+	"int64":    "0",
+	"sint32":   "0",
+	"sint64":   "0",
+	"fixed32":  "0",
+	"fixed64":  "0",
+	"sfixed32": "0",
+	"sfixed64": "0",
+	"float":    "0.0",
+	"double":   "0.0",
+	"bool":     "false",
+}
+
+func scalarDartType(f gen.Field) string {
+	if f.IsEnum {
+		return "int"
+	}
+	if f.IsMessage && !protomodel.IsWellKnownType(f.Type) {
+		return gen.MessageTypeName(f.Type, f.TypeFile, "dart", "")
+	}
+	if f.IsMessage {
+		return f.Type
+	}
+	if t, ok := gen.DartTypes[f.Type]; ok {
+		return t
+	}
+	return "dynamic"
+}
+
+func ResolveType(f gen.Field) string {
+	if f.IsMap {
+		k := gen.LookupScalar(gen.DartTypes, f.KeyType, "dynamic")
+		v := gen.LookupScalar(gen.DartTypes, f.ValueType, f.ValueType)
+		if f.ValueIsMessage {
+			v = scalarDartType(gen.MapValueField(f))
+		}
+		return "Map<" + k + ", " + v + ">"
+	}
+	base := scalarDartType(f)
+	if f.IsRepeated {
+		return "List<" + base + ">"
+	}
+	return base
+}
+
+func ResolveDefault(f gen.Field) string {
+	if d, ok := gen.DefaultLiteral(f, "dart"); ok {
+		return d
+	}
+	if f.IsMap {
+		return "const {}"
+	}
+	if f.IsRepeated {
+		return "const []"
+	}
+	if f.IsEnum {
+		return "0"
+	}
+	if d, ok := dartDefaults[f.Type]; ok {
+		return d
+	}
+	return "null"
+}
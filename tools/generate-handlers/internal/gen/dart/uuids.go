@@ -0,0 +1,21 @@
+package dart
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateUUIDs returns generated_uuids.dart.
+func GenerateUUIDs(gctx *gen.Context) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteByte('\n')
+	b.WriteString("// GATT UUIDs of the blerpc service, from blerpc.yaml.\n")
+	b.WriteString(fmt.Sprintf("const String serviceUuid = '%s';\n", gctx.ServiceUUID))
+	b.WriteString(fmt.Sprintf("const String charUuid = '%s';\n", gctx.CharUUID))
+	writeDartGattGroups(gctx, &b)
+	return b.String()
+}
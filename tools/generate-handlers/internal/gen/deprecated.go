@@ -0,0 +1,38 @@
+package gen
+
+import (
+	"strings"
+)
+
+// Deprecations carry deprecated = true from the schema into the clients, so
+// app code gets a compiler warning, or a DeprecationWarning in Python, while
+// it migrates. A command is deprecated by
+//
+//	rpc Echo(EchoRequest) returns (EchoResponse) { option deprecated = true; }
+//
+// or, for schemas discovered by message naming, by option deprecated = true
+// on its request message. A deprecated request field is a parameter of the
+// client methods, which Kotlin and Swift cannot mark: their methods document
+// it instead, and Python warns when the call sets it. The C headers get a
+// comment. Deprecated response fields need nothing here, as protoc already
+// marks them in the message classes.
+
+// DeprecatedFields returns the names of the deprecated request fields of cmd.
+func DeprecatedFields(cmd Command) []string {
+	var names []string
+	for _, f := range cmd.RequestFields {
+		if f.Deprecated {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// CodeList renders names as a comma-separated list of code spans.
+func CodeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
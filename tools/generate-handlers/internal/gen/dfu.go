@@ -0,0 +1,38 @@
+package gen
+
+// The dfu built-in writes a firmware image to the peripheral's update slot:
+// dfu_begin announces the size and CRC-32 of the image, dfu_chunk writes it
+// in order, one chunk per request, and dfu_finalize checks the CRC-32 of
+// what was written before the firmware marks the image to boot. The
+// firmware supplies the slot through the <pkg>_dfu_*() hooks. dfu_begin for
+// the image of an interrupted update answers with the bytes already
+// written, so the clients' update_firmware helpers continue from there.
+
+// DFUCommands are the commands of the dfu built-in.
+type DFUCommands struct {
+	Begin, Chunk, Finalize Command
+}
+
+// FirmwareUpdateCommands returns the dfu commands present in commands,
+// reporting whether all three are.
+func FirmwareUpdateCommands(commands []Command) (DFUCommands, bool) {
+	var dfu DFUCommands
+	found := 0
+	for _, cmd := range commands {
+		if cmd.Builtin != "dfu" {
+			continue
+		}
+		switch cmd.RequestMsg {
+		case "DfuBeginRequest":
+			dfu.Begin = cmd
+		case "DfuChunkRequest":
+			dfu.Chunk = cmd
+		case "DfuFinalizeRequest":
+			dfu.Finalize = cmd
+		default:
+			continue
+		}
+		found++
+	}
+	return dfu, found == 3
+}
@@ -0,0 +1,238 @@
+// Package docs generates the schema documentation: Markdown, the HTML
+// explorer and OpenRPC.
+package docs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The docs target (docs/api.md) is a markdown reference of the commands for
+// the teams that call them without reading the proto file: a section per
+// command with its call behavior and field tables, then the enums and the
+// other messages the fields use. Descriptions are the proto comments.
+
+// docsText flattens a proto comment into one markdown table cell.
+func docsText(comment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(comment, "\n", " "), "|", `\|`)
+}
+
+// docsAnchor returns the GitHub anchor of a heading of a single word.
+func docsAnchor(name string) string {
+	return "#" + strings.ToLower(name)
+}
+
+// docsFieldType renders the type of f, linking enums and messages of the
+// main file to their sections.
+func docsFieldType(f gen.Field, msgByName map[string]gen.Message, enumByName map[string]gen.Enum) string {
+	if f.IsMap {
+		return fmt.Sprintf("`map<%s, %s>`", f.KeyType, f.ValueType)
+	}
+	typ := "`" + f.Type + "`"
+	if _, ok := enumByName[f.Type]; ok {
+		typ = fmt.Sprintf("[`%s`](%s)", f.Type, docsAnchor(f.Type))
+	} else if _, ok := msgByName[f.Type]; ok && f.IsMessage {
+		typ = fmt.Sprintf("[`%s`](%s)", f.Type, docsAnchor(f.Type))
+	}
+	switch {
+	case f.IsRepeated:
+		return "repeated " + typ
+	case f.IsRequired:
+		return "required " + typ
+	case f.IsOptional:
+		return "optional " + typ
+	}
+	return typ
+}
+
+// writeDocsFields emits the field table of a message, or a note that it has
+// no fields.
+func writeDocsFields(b *strings.Builder, fields []gen.Field, msgByName map[string]gen.Message, enumByName map[string]gen.Enum) {
+	if len(fields) == 0 {
+		b.WriteString("No fields.\n")
+		return
+	}
+	b.WriteString("| # | Field | Type | Description |\n")
+	b.WriteString("|---|-------|------|-------------|\n")
+	for _, f := range fields {
+		desc := docsText(f.Comment)
+		if f.Oneof != "" {
+			desc = strings.TrimSpace(fmt.Sprintf("One of `%s`. %s", f.Oneof, desc))
+		}
+		fmt.Fprintf(b, "| %d | `%s` | %s | %s |\n", f.Number, f.Name, docsFieldType(f, msgByName, enumByName), desc)
+	}
+}
+
+// docsSize renders a maximum encoded size from the .options bounds.
+func docsSize(size int) string {
+	if size < 0 {
+		return "unbounded"
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
+
+// writeDocsCommand emits the section of cmd.
+func writeDocsCommand(b *strings.Builder, cmd gen.Command, mode string, msgByName map[string]gen.Message, enumByName map[string]gen.Enum) {
+	fmt.Fprintf(b, "\n## %s\n\n", cmd.Snake)
+	if cmd.Comment != "" {
+		b.WriteString(cmd.Comment + "\n\n")
+	}
+	switch mode {
+	case "p2c":
+		b.WriteString("- Streaming: peripheral to central (P2C); one request, a response per item\n")
+	case "c2p":
+		b.WriteString("- Streaming: central to peripheral (C2P); a request per item, one response\n")
+	default:
+		b.WriteString("- Streaming: none\n")
+	}
+	fmt.Fprintf(b, "- Wire name: `%s`", cmd.Wire())
+	if cmd.ID != 0 {
+		fmt.Fprintf(b, ", ID %d", cmd.ID)
+	}
+	b.WriteByte('\n')
+	if cmd.RenamedFrom != "" {
+		fmt.Fprintf(b, "- Renamed from `%s`; clients keep a deprecated alias\n", cmd.RenamedFrom)
+	}
+	if cmd.Builtin != "" {
+		fmt.Fprintf(b, "- Built-in: `%s`\n", cmd.Builtin)
+	}
+	if cmd.TimeoutMs > 0 {
+		fmt.Fprintf(b, "- Timeout: %d ms per attempt\n", cmd.TimeoutMs)
+	} else {
+		b.WriteString("- Timeout: transport default\n")
+	}
+	if cmd.Retries > 0 {
+		fmt.Fprintf(b, "- Retries: %d\n", cmd.Retries)
+	}
+	if cmd.CacheTTLMs > 0 {
+		fmt.Fprintf(b, "- Cached: caching clients reuse a response for %d ms\n", cmd.CacheTTLMs)
+	}
+	if cmd.Priority != "" && cmd.Priority != "normal" {
+		fmt.Fprintf(b, "- Priority: %s\n", cmd.Priority)
+	}
+	if cmd.Idempotent {
+		b.WriteString("- Idempotent: resuming clients retry it after a reconnect\n")
+	}
+	if cmd.Role != "" && cmd.Role != "user" {
+		fmt.Fprintf(b, "- Role: %s\n", cmd.Role)
+	}
+	if cmd.ReplayProtected {
+		b.WriteString("- Replay protected: requests lead with a counter and its MAC\n")
+	}
+	if cmd.SessionProtected {
+		b.WriteString("- Session protected: requests lead with a session token\n")
+	}
+	if cmd.Compression != "" {
+		fmt.Fprintf(b, "- Compression: %s\n", cmd.Compression)
+	}
+	if cmd.GattService != "" {
+		fmt.Fprintf(b, "- GATT service: %s\n", cmd.GattService)
+	}
+	if cmd.RateLimit > 0 {
+		fmt.Fprintf(b, "- Rate limit: %d calls per second\n", cmd.RateLimit)
+	}
+	if cmd.QueueTTL > 0 {
+		fmt.Fprintf(b, "- Offline queue: calls wait up to %d s\n", cmd.QueueTTL)
+	}
+	if cmd.Deprecated {
+		b.WriteString("- Deprecated\n")
+	}
+	if len(cmd.ExcludeTargets) > 0 {
+		fmt.Fprintf(b, "- Not generated for: %s\n", strings.Join(cmd.ExcludeTargets, ", "))
+	}
+	fmt.Fprintf(b, "- Max request size: %s\n", docsSize(cmd.MaxRequestSize))
+	fmt.Fprintf(b, "- Max response size: %s\n", docsSize(cmd.MaxResponseSize))
+	fmt.Fprintf(b, "\n### Request: `%s`\n\n", cmd.RequestMsg)
+	writeDocsFields(b, cmd.RequestFields, msgByName, enumByName)
+	fmt.Fprintf(b, "\n### Response: `%s`\n\n", cmd.ResponseMsg)
+	writeDocsFields(b, cmd.ResponseFields, msgByName, enumByName)
+}
+
+// docsTypes returns the enums and the messages other than the requests and
+// responses that the command fields use, directly or through other messages,
+// each in order of first use.
+func docsTypes(commands []gen.Command, msgByName map[string]gen.Message, enumByName map[string]gen.Enum) (enums []gen.Enum, msgs []gen.Message) {
+	top := make(map[string]bool)
+	for _, cmd := range commands {
+		top[cmd.RequestMsg] = true
+		top[cmd.ResponseMsg] = true
+	}
+	seen := make(map[string]bool)
+	var visit func(fields []gen.Field)
+	use := func(name string) {
+		if seen[name] {
+			return
+		}
+		if e, ok := enumByName[name]; ok {
+			seen[name] = true
+			enums = append(enums, e)
+		} else if m, ok := msgByName[name]; ok && !top[name] {
+			seen[name] = true
+			msgs = append(msgs, m)
+			visit(m.Fields)
+		}
+	}
+	visit = func(fields []gen.Field) {
+		for _, f := range fields {
+			if f.IsMap {
+				use(f.ValueType)
+				continue
+			}
+			use(f.Type)
+		}
+	}
+	for _, cmd := range commands {
+		visit(cmd.RequestFields)
+		visit(cmd.ResponseFields)
+	}
+	return enums, msgs
+}
+
+// GenerateDocs returns docs/api.md.
+func GenerateDocs(commands []gen.Command, streaming map[string]string, msgByName map[string]gen.Message, enumByName map[string]gen.Enum, pkg string) string {
+	var b strings.Builder
+	b.WriteString("<!-- Auto-generated by generate-handlers — DO NOT EDIT. -->\n\n")
+	fmt.Fprintf(&b, "# %s API reference\n\n", pkg)
+	b.WriteString("Every command of the peripheral with its request and response fields.\n")
+	b.WriteString("Field descriptions are the comments in the proto file.\n\n")
+	b.WriteString("| Command | Request | Response | Streaming |\n")
+	b.WriteString("|---------|---------|----------|-----------|\n")
+	for _, cmd := range commands {
+		mode := "none"
+		if m := streaming[cmd.Snake]; m != "" {
+			mode = strings.ToUpper(m)
+		}
+		fmt.Fprintf(&b, "| [`%s`](%s) | `%s` | `%s` | %s |\n", cmd.Snake, docsAnchor(cmd.Snake), cmd.RequestMsg, cmd.ResponseMsg, mode)
+	}
+	for _, cmd := range commands {
+		writeDocsCommand(&b, cmd, streaming[cmd.Snake], msgByName, enumByName)
+	}
+	enums, msgs := docsTypes(commands, msgByName, enumByName)
+	if len(enums) > 0 {
+		b.WriteString("\n## Enums\n")
+		for _, e := range enums {
+			fmt.Fprintf(&b, "\n### %s\n\n", e.Name)
+			if e.Comment != "" {
+				b.WriteString(e.Comment + "\n\n")
+			}
+			b.WriteString("| Value | Name | Description |\n")
+			b.WriteString("|-------|------|-------------|\n")
+			for _, v := range e.Values {
+				fmt.Fprintf(&b, "| %d | `%s` | %s |\n", v.Number, v.Name, docsText(v.Comment))
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		b.WriteString("\n## Messages\n")
+		for _, m := range msgs {
+			fmt.Fprintf(&b, "\n### %s\n\n", m.Name)
+			if m.Comment != "" {
+				b.WriteString(m.Comment + "\n\n")
+			}
+			writeDocsFields(&b, m.Fields, msgByName, enumByName)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.Comment = "Echo — loopback test.\nReturns the message."
+	echo.RequestFields[0].Comment = "text to echo | up to 256 bytes"
+	echo.TimeoutMs, echo.Retries, echo.Idempotent = 500, 2, true
+	echo.MaxRequestSize, echo.MaxResponseSize = 259, -1
+	echo.ExcludeTargets = []string{"kotlin", "swift"}
+	status := gentest.EnumCommand()
+	status.ResponseFields = append(status.ResponseFields,
+		gen.Field{Type: "Entry", Name: "entries", Number: 2, IsMessage: true, IsRepeated: true},
+		gen.Field{Name: "tags", Number: 3, IsMap: true, KeyType: "string", ValueType: "uint32"})
+	msgByName := map[string]gen.Message{
+		"Entry": {Name: "Entry", Comment: "One log entry.", Fields: []gen.Field{{Type: "Status", Name: "status", Number: 1, IsEnum: true}}},
+	}
+	enumByName := map[string]gen.Enum{
+		"Status": {Name: "Status", Values: []gen.EnumValue{{Name: "STATUS_OK", Number: 0, Comment: "all good"}}},
+	}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateDocs([]gen.Command{echo, status, gentest.StreamP2CCommand()}, streaming, msgByName, enumByName, "blerpc")
+	for _, want := range []string{
+		"# blerpc API reference\n",
+		"| [`echo`](#echo) | `EchoRequest` | `EchoResponse` | none |\n",
+		"| [`counter_stream`](#counter_stream) | `CounterStreamRequest` | `CounterStreamResponse` | P2C |\n",
+		"## echo\n\nEcho — loopback test.\nReturns the message.\n\n- Streaming: none\n",
+		"- Timeout: 500 ms per attempt\n- Retries: 2\n- Idempotent",
+		"- Not generated for: kotlin, swift\n- Max request size: 259 bytes\n- Max response size: unbounded\n",
+		"| 1 | `message` | `string` | text to echo \\| up to 256 bytes |\n",
+		"| 1 | `status` | [`Status`](#status) |  |\n",
+		"| 2 | `entries` | repeated [`Entry`](#entry) |  |\n",
+		"| 3 | `tags` | `map<string, uint32>` |  |\n",
+		"- Streaming: peripheral to central (P2C)",
+		"## Enums\n\n### Status\n\n",
+		"| 0 | `STATUS_OK` | all good |\n",
+		"## Messages\n\n### Entry\n\nOne log entry.\n\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Count(out, "### Status\n") != 1 {
+		t.Error("Status is documented more than once")
+	}
+}
@@ -0,0 +1,286 @@
+package docs
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The docs_html target (docs/api.html) is an API explorer for the teams
+// that call the peripheral: one static page with a searchable list of the
+// commands, each with its call behavior, field tables, the sample request
+// and response of the fixtures as encoded on the air, and a call of the
+// generated method in every client language, ready to paste. The page
+// renders in the browser from the -emit-model document embedded in it, next
+// to the examples, so it opens from a release archive without a server. The
+// markup, style and script are api.html.tmpl.
+
+// explorerDocument is the data embedded in the page.
+type explorerDocument struct {
+	Model    gen.ModelDocument `json:"model"`
+	Examples []explorerExample `json:"examples"` // in the order of Model.Commands
+}
+
+// explorerExample holds the samples of a command. Bytes are lowercase hex.
+type explorerExample struct {
+	Command       string            `json:"command"`
+	RequestText   string            `json:"request_text"` // textproto of the sample request
+	Request       string            `json:"request"`
+	RequestPacket string            `json:"request_packet"` // command packet carrying the request
+	ResponseText  string            `json:"response_text"`
+	Response      string            `json:"response"`
+	Snippets      []explorerSnippet `json:"snippets"`
+}
+
+// explorerSnippet is the call of a command in one client language.
+type explorerSnippet struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// explorerLanguages are the client targets the page shows calls for, with
+// the names of their tabs.
+var explorerLanguages = []struct{ target, name string }{
+	{"python", "Python"},
+	{"kotlin", "Kotlin"},
+	{"swift", "Swift"},
+	{"typescript", "TypeScript"},
+	{"dart", "Dart"},
+}
+
+// explorerFields returns the sample fields a snippet passes: the scalars and
+// enums, single or repeated. Messages, maps and oneofs, which each client
+// takes in a shape of its own, are left to the defaults, as are fields with
+// a type mapping, whose types the generator does not know.
+func explorerFields(fields []gen.Field) []gen.Field {
+	var out []gen.Field
+	for _, f := range gen.SampleFields(fields) {
+		if !f.IsMessage && !f.IsMap && f.Oneof == "" && len(f.TypeOverrides) == 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// explorerScalar renders the sample value of f, the one
+// gen.WriteTextprotoFields renders, as a literal of target.
+func explorerScalar(f gen.Field, target string, enumByName map[string]gen.Enum) string {
+	if f.IsEnum {
+		// Every client takes enums as their number.
+		en, ok := enumByName[f.Type]
+		return fmt.Sprint(gen.SampleEnumValue(en, ok).Number)
+	}
+	quote := func(s string) string {
+		if target == "typescript" || target == "dart" {
+			return "'" + s + "'"
+		}
+		return fmt.Sprintf("%q", s)
+	}
+	switch lit := gen.TextprotoScalar(f.Type, f.Name); f.Type {
+	case "string":
+		return quote(f.Name)
+	case "bytes":
+		switch target {
+		case "python":
+			return `b"\x01\x02\x03\x04"`
+		case "kotlin":
+			return "com.google.protobuf.ByteString.copyFrom(byteArrayOf(1, 2, 3, 4))"
+		case "swift":
+			return "Data([1, 2, 3, 4])"
+		case "typescript":
+			return "Uint8Array.of(1, 2, 3, 4)"
+		}
+		return "[1, 2, 3, 4]"
+	case "bool":
+		if target == "python" {
+			return "True"
+		}
+		return lit
+	case "float":
+		if target == "kotlin" {
+			return lit + "f"
+		}
+		return lit
+	default:
+		if target == "kotlin" && gen.KotlinTypes[f.Type] == "Long" {
+			return lit + "L"
+		}
+		return lit
+	}
+}
+
+// explorerValue renders the sample value of f, a list of one for repeated
+// fields, as a literal of target. The methods take enums as numbers, but
+// the Swift and Dart messages built for a C→P stream hold enum values.
+func explorerValue(f gen.Field, target string, message bool, enumByName map[string]gen.Enum) string {
+	v := explorerScalar(f, target, enumByName)
+	if f.IsEnum && message {
+		switch target {
+		case "swift":
+			v = ".init(rawValue: " + v + ")!"
+		case "dart":
+			v = gen.MessageTypeName(f.Type, f.TypeFile, "dart", "") + ".valueOf(" + v + ")!"
+		}
+	}
+	if !f.IsRepeated {
+		return v
+	}
+	if target == "kotlin" {
+		return "listOf(" + v + ")"
+	}
+	return "[" + v + "]"
+}
+
+// explorerArgs renders the arguments of a call of the generated method that
+// sets fields.
+func explorerArgs(fields []gen.Field, target string, enumByName map[string]gen.Enum) string {
+	args := make([]string, len(fields))
+	for i, f := range fields {
+		v := explorerValue(f, target, false, enumByName)
+		switch target {
+		case "python":
+			args[i] = f.Name + "=" + v
+		case "kotlin":
+			args[i] = f.Name + " = " + v
+		default:
+			args[i] = gen.SwiftPropertyName(f.Name) + ": " + v
+		}
+	}
+	if target == "typescript" && len(args) > 0 {
+		return "{ " + strings.Join(args, ", ") + " }"
+	}
+	return strings.Join(args, ", ")
+}
+
+// explorerMessage renders a request message of a C→P stream, built with
+// fields set, as an expression of target.
+func explorerMessage(gctx *gen.Context, cmd gen.Command, fields []gen.Field, target, pkg string, enumByName map[string]gen.Enum) string {
+	var parts []string
+	for _, f := range fields {
+		v := explorerValue(f, target, true, enumByName)
+		prop := gen.SwiftPropertyName(f.Name)
+		switch target {
+		case "python":
+			parts = append(parts, f.Name+"="+v)
+		case "kotlin":
+			parts = append(parts, "."+gen.KotlinBuilderSetter(f)+"("+v+")")
+		case "swift":
+			parts = append(parts, "$0."+prop+" = "+v)
+		case "typescript":
+			parts = append(parts, prop+": "+v)
+		case "dart":
+			if f.IsRepeated {
+				parts = append(parts, ".."+prop+".addAll("+v+")")
+			} else {
+				parts = append(parts, ".."+prop+" = "+v)
+			}
+		}
+	}
+	switch target {
+	case "python":
+		return pkg + "_pb2." + cmd.RequestMsg + "(" + strings.Join(parts, ", ") + ")"
+	case "kotlin":
+		return gen.MessageTypeName(cmd.RequestMsg, "", "kotlin", pkg) + ".newBuilder()" + strings.Join(parts, "") + ".build()"
+	case "swift":
+		cls := gctx.SwiftPrefix(pkg) + cmd.RequestMsg
+		if len(parts) == 0 {
+			return cls + "()"
+		}
+		return cls + ".with { " + strings.Join(parts, "; ") + " }"
+	case "typescript":
+		if len(parts) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(parts, ", ") + " }"
+	}
+	return cmd.RequestMsg + "()" + strings.Join(parts, "")
+}
+
+// explorerSnippets returns the calls of cmd in the client languages of
+// enabled targets that generate it. client is a connected client of the
+// language.
+func explorerSnippets(gctx *gen.Context, cmd gen.Command, mode string, enabled func(string) bool, pkg string, enumByName map[string]gen.Enum) []explorerSnippet {
+	fields := explorerFields(cmd.RequestFields)
+	out := []explorerSnippet{}
+	for _, lang := range explorerLanguages {
+		if !enabled(lang.target) || slices.Contains(cmd.ExcludeTargets, lang.target) {
+			continue
+		}
+		method := gen.ToLowerCamel(cmd.Camel)
+		if lang.target == "python" {
+			method = gctx.PyMethodName(cmd.Snake)
+		}
+		args := explorerArgs(fields, lang.target, enumByName)
+		if mode == "c2p" {
+			msg := explorerMessage(gctx, cmd, fields, lang.target, pkg, enumByName)
+			switch lang.target {
+			case "kotlin":
+				args = "listOf(" + msg + ")"
+			case "swift":
+				args = "messages: [" + msg + "]"
+			default:
+				args = "[" + msg + "]"
+			}
+		}
+		result := "resp"
+		if mode == "p2c" {
+			result = "resps"
+		}
+		var code string
+		switch lang.target {
+		case "python":
+			code = fmt.Sprintf("%s = await client.%s(%s)", result, method, args)
+		case "kotlin":
+			code = fmt.Sprintf("val %s = client.%s(%s)", result, method, args)
+		case "swift":
+			code = fmt.Sprintf("let %s = try await client.%s(%s)", result, method, args)
+		case "typescript":
+			code = fmt.Sprintf("const %s = await client.%s(%s);", result, method, args)
+		case "dart":
+			code = fmt.Sprintf("final %s = await client.%s(%s);", result, method, args)
+		}
+		out = append(out, explorerSnippet{lang.name, code})
+	}
+	return out
+}
+
+// explorerExamples returns the samples of every command.
+func explorerExamples(gctx *gen.Context, commands []gen.Command, streaming map[string]string, enabled func(string) bool, msgByName map[string]gen.Message, enumByName map[string]gen.Enum, pkg string) []explorerExample {
+	examples := make([]explorerExample, 0, len(commands))
+	for _, cmd := range commands {
+		var reqText, respText strings.Builder
+		gen.WriteTextprotoFields(&reqText, cmd.RequestFields, "", 0, msgByName, enumByName)
+		gen.WriteTextprotoFields(&respText, cmd.ResponseFields, "", 0, msgByName, enumByName)
+		req := gen.EncodeSampleFields(cmd.RequestFields, 0, msgByName, enumByName)
+		examples = append(examples, explorerExample{
+			Command:       cmd.Snake,
+			RequestText:   reqText.String(),
+			Request:       hex.EncodeToString(req),
+			RequestPacket: hex.EncodeToString(gen.ConformanceCommandPacket(false, gen.ConformanceWireName(cmd), req)),
+			ResponseText:  respText.String(),
+			Response:      hex.EncodeToString(gen.EncodeSampleFields(cmd.ResponseFields, 0, msgByName, enumByName)),
+			Snippets:      explorerSnippets(gctx, cmd, streaming[cmd.Snake], enabled, pkg, enumByName),
+		})
+	}
+	return examples
+}
+
+// GenerateDocsHTML returns docs/api.html. enabled reports whether a client
+// target is generated.
+func GenerateDocsHTML(gctx *gen.Context, commands []gen.Command, streaming map[string]string, protoFile *gen.ProtoFile, enabled func(string) bool, msgByName map[string]gen.Message, enumByName map[string]gen.Enum, pkg string) string {
+	doc := explorerDocument{
+		Model:    gen.BuildModel(commands, streaming, protoFile, pkg),
+		Examples: explorerExamples(gctx, commands, streaming, enabled, msgByName, enumByName, pkg),
+	}
+	// Marshal escapes <, > and &, so no comment can close the script
+	// element holding the document.
+	data, _ := json.Marshal(doc)
+	return gctx.RenderTemplate("api.html.tmpl", struct {
+		Package string
+		Data    string
+	}{pkg, string(data)})
+}
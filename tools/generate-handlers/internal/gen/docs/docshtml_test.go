@@ -0,0 +1,82 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestExplorerSnippets(t *testing.T) {
+	gctx := gen.NewContext()
+	all := func(string) bool { return true }
+	cmd := gentest.StreamP2CCommand()
+	cmd.RequestFields = append(cmd.RequestFields,
+		gen.Field{Type: "int64", Name: "since_us", Number: 2},
+		gen.Field{Type: "bytes", Name: "tags", Number: 3, IsRepeated: true},
+		gen.Field{Type: "Point", Name: "origin", Number: 4, IsMessage: true})
+	var got []string
+	for _, s := range explorerSnippets(gctx, cmd, "p2c", all, "blerpc", nil) {
+		got = append(got, s.Language+": "+s.Code)
+	}
+	want := []string{
+		`Python: resps = await client.counter_stream(start=1, since_us=-1, tags=[b"\x01\x02\x03\x04"])`,
+		"Kotlin: val resps = client.counterStream(start = 1, since_us = -1L, tags = listOf(com.google.protobuf.ByteString.copyFrom(byteArrayOf(1, 2, 3, 4))))",
+		"Swift: let resps = try await client.counterStream(start: 1, sinceUs: -1, tags: [Data([1, 2, 3, 4])])",
+		"TypeScript: const resps = await client.counterStream({ start: 1, sinceUs: -1, tags: [Uint8Array.of(1, 2, 3, 4)] });",
+		"Dart: final resps = await client.counterStream(start: 1, sinceUs: -1, tags: [[1, 2, 3, 4]]);",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("snippets:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// C→P streams take the request messages; enums in them are values.
+	upload := gentest.StreamC2PCommand()
+	upload.RequestFields = append(upload.RequestFields, gen.Field{Type: "Status", Name: "status", Number: 2, IsEnum: true})
+	upload.ExcludeTargets = []string{"typescript"}
+	enumByName := map[string]gen.Enum{"Status": {Name: "Status", Values: []gen.EnumValue{{Name: "STATUS_OK"}, {Name: "STATUS_BUSY", Number: 3}}}}
+	enabled := func(target string) bool { return target != "dart" }
+	got = nil
+	for _, s := range explorerSnippets(gctx, upload, "c2p", enabled, "blerpc", enumByName) {
+		got = append(got, s.Language+": "+s.Code)
+	}
+	want = []string{
+		"Python: resp = await client.counter_upload([blerpc_pb2.CounterUploadRequest(value=1, status=3)])",
+		"Kotlin: val resp = client.counterUpload(listOf(blerpc.Blerpc.CounterUploadRequest.newBuilder().setValue(1).setStatusValue(3).build()))",
+		"Swift: let resp = try await client.counterUpload(messages: [Blerpc_CounterUploadRequest.with { $0.value = 1; $0.status = .init(rawValue: 3)! }])",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("C→P snippets:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	msg := explorerMessage(gctx, upload, explorerFields(upload.RequestFields), "dart", "blerpc", enumByName)
+	if msg != "CounterUploadRequest()..value = 1..status = Status.valueOf(3)!" {
+		t.Errorf("Dart message = %s", msg)
+	}
+}
+
+func TestGenerateDocsHTML(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.ID = 1
+	echo.Comment = "Echo </script><b>bold</b>"
+	pf := &gen.ProtoFile{Syntax: "proto3", Messages: []gen.Message{
+		{Name: "EchoRequest", Fields: echo.RequestFields},
+		{Name: "EchoResponse", Fields: echo.ResponseFields},
+	}}
+	out := GenerateDocsHTML(gctx, []gen.Command{echo}, nil, pf, func(string) bool { return true }, nil, nil, "blerpc")
+	for _, want := range []string{
+		"<title>blerpc API explorer</title>",
+		`<script type="application/json" id="blerpc-model">{"model":{"version":1,"package":"blerpc"`,
+		`"comment":"Echo \u003c/script\u003e\u003cb\u003ebold\u003c/b\u003e"`,
+		`"request_text":"message: \"message\"\n","request":"0a076d657373616765","request_packet":"00010109000a076d657373616765"`,
+		`{"language":"Python","code":"resp = await client.echo(message=\"message\")"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<b>bold") {
+		t.Error("a comment reaches the page as markup")
+	}
+}
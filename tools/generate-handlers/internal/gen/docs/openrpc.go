@@ -0,0 +1,219 @@
+package docs
+
+import (
+	"cmp"
+	"encoding/json"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// The docs target also writes docs/openrpc.json, an OpenRPC 1.2 description
+// of the commands for tools that render or validate the API without the
+// proto file, such as a docs site or a cloud device twin. Each command is a
+// method named by its wire name whose params are the request fields, by
+// name; its result and every message and enum the fields use are JSON
+// Schemas under components.schemas, following the proto3 JSON mapping:
+// 64-bit integers are strings and bytes base64 strings. What OpenRPC has no
+// words for, such as the streaming mode and the nanopb size bounds, is in
+// x-blerpc-* extensions.
+
+const openRPCVersion = "1.2.6"
+
+type openRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       openRPCInfo       `json:"info"`
+	Methods    []openRPCMethod   `json:"methods"`
+	Components openRPCComponents `json:"components"`
+}
+
+type openRPCInfo struct {
+	Title            string `json:"title"`
+	Version          string `json:"version"` // the schema hash
+	GeneratorVersion string `json:"x-blerpc-generator-version"`
+}
+
+type openRPCMethod struct {
+	Name            string               `json:"name"`
+	Description     string               `json:"description,omitempty"`
+	ParamStructure  string               `json:"paramStructure"`
+	Params          []openRPCContentDesc `json:"params"`
+	Result          openRPCContentDesc   `json:"result"`
+	Deprecated      bool                 `json:"deprecated,omitempty"`
+	Stream          string               `json:"x-blerpc-stream"` // unary, p2c or c2p
+	Request         string               `json:"x-blerpc-request"`
+	Service         string               `json:"x-blerpc-service,omitempty"`
+	ID              int                  `json:"x-blerpc-id,omitempty"`
+	Idempotent      bool                 `json:"x-blerpc-idempotent,omitempty"`
+	TimeoutMs       int                  `json:"x-blerpc-timeout-ms,omitempty"`
+	MaxRequestSize  int                  `json:"x-blerpc-max-request-size"`  // -1 when unbounded
+	MaxResponseSize int                  `json:"x-blerpc-max-response-size"` // -1 when unbounded
+}
+
+type openRPCContentDesc struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Deprecated  bool           `json:"deprecated,omitempty"`
+	Schema      map[string]any `json:"schema"`
+}
+
+type openRPCComponents struct {
+	Schemas map[string]map[string]any `json:"schemas"`
+}
+
+// openRPCRef returns the schema referring to the component of type name.
+func openRPCRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// openRPCScalarSchema returns the JSON Schema of a scalar proto type, or
+// nil if typ is an enum or message.
+func openRPCScalarSchema(typ string) map[string]any {
+	switch typ {
+	case "int32", "sint32", "sfixed32":
+		return map[string]any{"type": "integer", "minimum": -2147483648, "maximum": 2147483647}
+	case "uint32", "fixed32":
+		return map[string]any{"type": "integer", "minimum": 0, "maximum": int64(4294967295)}
+	case "int64", "sint64", "sfixed64":
+		return map[string]any{"type": "string", "pattern": "^-?[0-9]+$"}
+	case "uint64", "fixed64":
+		return map[string]any{"type": "string", "pattern": "^[0-9]+$"}
+	case "float", "double":
+		return map[string]any{"type": "number"}
+	case "bool":
+		return map[string]any{"type": "boolean"}
+	case "string":
+		return map[string]any{"type": "string"}
+	case "bytes":
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case protomodel.TimestampType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case protomodel.DurationType:
+		return map[string]any{"type": "string", "pattern": "^-?[0-9]+(\\.[0-9]+)?s$"}
+	}
+	return nil
+}
+
+// openRPCTypeSchema returns the schema of a single value of typ, referring
+// to the components of enums and messages.
+func openRPCTypeSchema(typ string) map[string]any {
+	if s := openRPCScalarSchema(typ); s != nil {
+		return s
+	}
+	return openRPCRef(strings.TrimPrefix(typ, "."))
+}
+
+// openRPCFieldSchema returns the schema of the value of f.
+func openRPCFieldSchema(f gen.Field) map[string]any {
+	if f.IsMap {
+		return map[string]any{"type": "object", "additionalProperties": openRPCTypeSchema(f.ValueType)}
+	}
+	s := openRPCTypeSchema(f.Type)
+	if f.MaxSize > 0 {
+		s["x-blerpc-max-size"] = f.MaxSize
+	}
+	if f.IsRepeated {
+		s = map[string]any{"type": "array", "items": s}
+	}
+	return s
+}
+
+// openRPCMessageSchema returns the object schema of message m.
+func openRPCMessageSchema(m gen.Message) map[string]any {
+	props := make(map[string]any, len(m.Fields))
+	var required []string
+	for _, f := range m.Fields {
+		prop := openRPCFieldSchema(f)
+		if f.Deprecated {
+			prop["deprecated"] = true
+		}
+		if f.Comment != "" {
+			prop["description"] = f.Comment
+		}
+		props[f.Name] = prop
+		if f.IsRequired {
+			required = append(required, f.Name)
+		}
+	}
+	s := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	if m.Comment != "" {
+		s["description"] = m.Comment
+	}
+	return s
+}
+
+// openRPCEnumSchema returns the schema of enum e, whose JSON values are the
+// value names.
+func openRPCEnumSchema(e gen.Enum) map[string]any {
+	values := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = v.Name
+	}
+	s := map[string]any{"type": "string", "enum": values}
+	if e.Comment != "" {
+		s["description"] = e.Comment
+	}
+	return s
+}
+
+// GenerateOpenRPC returns docs/openrpc.json.
+func GenerateOpenRPC(gctx *gen.Context, commands []gen.Command, streaming map[string]string, msgByName map[string]gen.Message, enumByName map[string]gen.Enum, pkg string) string {
+	doc := openRPCDocument{
+		OpenRPC:    openRPCVersion,
+		Info:       openRPCInfo{Title: pkg + " BLE RPC API", Version: cmp.Or(gctx.SchemaHash, "0.0.0"), GeneratorVersion: gen.GeneratorVersion},
+		Methods:    make([]openRPCMethod, 0, len(commands)),
+		Components: openRPCComponents{Schemas: make(map[string]map[string]any)},
+	}
+	var use func(name string)
+	use = func(name string) {
+		name = strings.TrimPrefix(name, ".")
+		if _, done := doc.Components.Schemas[name]; done {
+			return
+		}
+		if e, ok := enumByName[name]; ok {
+			doc.Components.Schemas[name] = openRPCEnumSchema(e)
+		} else if m, ok := msgByName[name]; ok {
+			doc.Components.Schemas[name] = openRPCMessageSchema(m)
+			for _, f := range m.Fields {
+				use(cmp.Or(f.ValueType, f.Type))
+			}
+		}
+	}
+	for _, cmd := range commands {
+		m := openRPCMethod{
+			Name:            cmd.Wire(),
+			Description:     cmd.Comment,
+			ParamStructure:  "by-name",
+			Params:          make([]openRPCContentDesc, 0, len(cmd.RequestFields)),
+			Result:          openRPCContentDesc{Name: cmd.ResponseMsg, Schema: openRPCRef(cmd.ResponseMsg)},
+			Deprecated:      cmd.Deprecated,
+			Stream:          cmp.Or(streaming[cmd.Snake], "unary"),
+			Request:         cmd.RequestMsg,
+			Service:         cmd.Service,
+			ID:              cmd.ID,
+			Idempotent:      cmd.Idempotent,
+			TimeoutMs:       cmd.TimeoutMs,
+			MaxRequestSize:  cmd.MaxRequestSize,
+			MaxResponseSize: cmd.MaxResponseSize,
+		}
+		for _, f := range cmd.RequestFields {
+			m.Params = append(m.Params, openRPCContentDesc{
+				Name:        f.Name,
+				Description: f.Comment,
+				Required:    f.IsRequired,
+				Deprecated:  f.Deprecated,
+				Schema:      openRPCFieldSchema(f),
+			})
+			use(cmp.Or(f.ValueType, f.Type))
+		}
+		use(cmd.ResponseMsg)
+		doc.Methods = append(doc.Methods, m)
+	}
+	data, _ := json.MarshalIndent(doc, "", "  ")
+	return string(data) + "\n"
+}
@@ -0,0 +1,84 @@
+package docs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateOpenRPC(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.Comment = "Echo — loopback test."
+	echo.RequestFields = append(echo.RequestFields,
+		gen.Field{Type: "bytes", Name: "payload", Number: 2, MaxSize: 64},
+		gen.Field{Type: "int64", Name: "stamp", Number: 3, IsRepeated: true})
+	echo.MaxRequestSize, echo.MaxResponseSize = 259, -1
+	status := gentest.EnumCommand()
+	status.ResponseFields = append(status.ResponseFields, gen.Field{Type: "Entry", Name: "entries", Number: 2, IsMessage: true, IsRepeated: true})
+	msgByName := map[string]gen.Message{
+		"EchoResponse":      {Name: "EchoResponse", Fields: echo.ResponseFields},
+		"GetStatusResponse": {Name: "GetStatusResponse", Fields: status.ResponseFields},
+		"Entry":             {Name: "Entry", Fields: []gen.Field{{Type: "Status", Name: "status", Number: 1, IsEnum: true, Comment: "entry status"}}},
+		"Unused":            {Name: "Unused"},
+	}
+	enumByName := map[string]gen.Enum{
+		"Status": {Name: "Status", Values: []gen.EnumValue{{Name: "STATUS_OK", Number: 0}, {Name: "STATUS_BUSY", Number: 1}}},
+	}
+	out := GenerateOpenRPC(gctx, []gen.Command{echo, status}, map[string]string{"get_status": "p2c"}, msgByName, enumByName, "blerpc")
+
+	var doc struct {
+		OpenRPC string `json:"openrpc"`
+		Methods []struct {
+			Name   string `json:"name"`
+			Params []struct {
+				Name   string         `json:"name"`
+				Schema map[string]any `json:"schema"`
+			} `json:"params"`
+			Result struct {
+				Schema map[string]any `json:"schema"`
+			} `json:"result"`
+			Stream         string `json:"x-blerpc-stream"`
+			MaxRequestSize int    `json:"x-blerpc-max-request-size"`
+		} `json:"methods"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("openrpc.json is not JSON: %v\n%s", err, out)
+	}
+	if doc.OpenRPC != openRPCVersion || len(doc.Methods) != 2 {
+		t.Fatalf("openrpc %q with %d methods", doc.OpenRPC, len(doc.Methods))
+	}
+	m := doc.Methods[0]
+	if m.Name != "echo" || m.Stream != "unary" || m.MaxRequestSize != 259 || len(m.Params) != 3 {
+		t.Errorf("echo method = %+v", m)
+	}
+	if s := m.Params[1].Schema; s["contentEncoding"] != "base64" || s["x-blerpc-max-size"] != float64(64) {
+		t.Errorf("bytes param schema = %v", s)
+	}
+	if s := m.Params[2].Schema; s["type"] != "array" || s["items"].(map[string]any)["type"] != "string" {
+		t.Errorf("repeated int64 param schema = %v", s)
+	}
+	if doc.Methods[1].Stream != "p2c" || doc.Methods[1].Result.Schema["$ref"] != "#/components/schemas/GetStatusResponse" {
+		t.Errorf("get_status method = %+v", doc.Methods[1])
+	}
+	for _, name := range []string{"EchoResponse", "GetStatusResponse", "Entry", "Status"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("components.schemas misses %s", name)
+		}
+	}
+	if _, ok := doc.Components.Schemas["Unused"]; ok {
+		t.Errorf("components.schemas has Unused, which no command uses")
+	}
+	if enum := doc.Components.Schemas["Status"]["enum"].([]any); len(enum) != 2 || enum[1] != "STATUS_BUSY" {
+		t.Errorf("Status enum = %v", enum)
+	}
+	entry := doc.Components.Schemas["Entry"]["properties"].(map[string]any)["status"].(map[string]any)
+	if entry["$ref"] != "#/components/schemas/Status" || entry["description"] != "entry status" {
+		t.Errorf("Entry.status schema = %v", entry)
+	}
+}
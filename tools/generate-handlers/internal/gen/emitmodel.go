@@ -0,0 +1,164 @@
+package gen
+
+import (
+	"cmp"
+)
+
+// -emit-model writes the parsed schema, after blerpc.yaml is applied, as a
+// JSON document for tooling outside the generator, such as release scripts
+// diffing the command sets of two firmware versions. Unlike the plugin
+// request, which hands over the internal model as is, the document has its
+// own types with fixed snake_case keys, so it stays stable as the generator
+// changes; ModelVersion is bumped on incompatible changes.
+
+const ModelVersion = 1
+
+type ModelDocument struct {
+	Version  int            `json:"version"`
+	Package  string         `json:"package"`
+	Syntax   string         `json:"syntax"`
+	Commands []ModelCommand `json:"commands"`
+	Messages []ModelMessage `json:"messages"`
+	Enums    []ModelEnum    `json:"enums"`
+}
+
+type ModelCommand struct {
+	Name             string   `json:"name"`
+	Camel            string   `json:"camel"`
+	WireName         string   `json:"wire_name"`
+	ID               int      `json:"id,omitempty"`
+	Service          string   `json:"service,omitempty"`
+	Stream           string   `json:"stream"` // unary, p2c or c2p
+	Request          string   `json:"request"`
+	Response         string   `json:"response"`
+	Builtin          string   `json:"builtin,omitempty"`
+	RenamedFrom      string   `json:"renamed_from,omitempty"`
+	Deprecated       bool     `json:"deprecated,omitempty"`
+	Idempotent       bool     `json:"idempotent,omitempty"`
+	TimeoutMs        int      `json:"timeout_ms,omitempty"`
+	Retries          int      `json:"retries,omitempty"`
+	CacheTTLMs       int      `json:"cache_ttl_ms,omitempty"`
+	Priority         string   `json:"priority,omitempty"`
+	RateLimit        int      `json:"rate_limit,omitempty"`
+	QueueTTL         int      `json:"queue_ttl,omitempty"`
+	Role             string   `json:"role,omitempty"`
+	ReplayProtected  bool     `json:"replay_protected,omitempty"`
+	SessionProtected bool     `json:"session_protected,omitempty"`
+	Compression      string   `json:"compression,omitempty"`
+	GattService      string   `json:"gatt_service,omitempty"`
+	ExcludeTargets   []string `json:"exclude_targets,omitempty"`
+	MaxRequestSize   int      `json:"max_request_size"`  // -1 when unbounded
+	MaxResponseSize  int      `json:"max_response_size"` // -1 when unbounded
+	Comment          string   `json:"comment,omitempty"`
+}
+
+type ModelMessage struct {
+	Name    string       `json:"name"`
+	File    string       `json:"file,omitempty"`
+	Fields  []ModelField `json:"fields"`
+	Comment string       `json:"comment,omitempty"`
+}
+
+type ModelField struct {
+	Name       string `json:"name"`
+	Number     int    `json:"number"`
+	Type       string `json:"type"` // for maps, "map"
+	KeyType    string `json:"key_type,omitempty"`
+	ValueType  string `json:"value_type,omitempty"`
+	Repeated   bool   `json:"repeated,omitempty"`
+	Optional   bool   `json:"optional,omitempty"`
+	Required   bool   `json:"required,omitempty"`
+	Oneof      string `json:"oneof,omitempty"`
+	Default    string `json:"default,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	Sensitive  bool   `json:"sensitive,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+type ModelEnum struct {
+	Name    string           `json:"name"`
+	Values  []ModelEnumValue `json:"values"`
+	Comment string           `json:"comment,omitempty"`
+}
+
+type ModelEnumValue struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+}
+
+func BuildModel(commands []Command, streaming map[string]string, protoFile *ProtoFile, pkg string) ModelDocument {
+	doc := ModelDocument{
+		Version:  ModelVersion,
+		Package:  pkg,
+		Syntax:   protoFile.Syntax,
+		Commands: make([]ModelCommand, 0, len(commands)),
+		Messages: make([]ModelMessage, 0, len(protoFile.Messages)),
+		Enums:    make([]ModelEnum, 0, len(protoFile.Enums)),
+	}
+	for _, cmd := range commands {
+		doc.Commands = append(doc.Commands, ModelCommand{
+			Name:             cmd.Snake,
+			Camel:            cmd.Camel,
+			WireName:         cmd.Wire(),
+			ID:               cmd.ID,
+			Service:          cmd.Service,
+			Stream:           cmp.Or(streaming[cmd.Snake], "unary"),
+			Request:          cmd.RequestMsg,
+			Response:         cmd.ResponseMsg,
+			Builtin:          cmd.Builtin,
+			RenamedFrom:      cmd.RenamedFrom,
+			Deprecated:       cmd.Deprecated,
+			Idempotent:       cmd.Idempotent,
+			TimeoutMs:        cmd.TimeoutMs,
+			Retries:          cmd.Retries,
+			CacheTTLMs:       cmd.CacheTTLMs,
+			Priority:         cmd.Priority,
+			RateLimit:        cmd.RateLimit,
+			QueueTTL:         cmd.QueueTTL,
+			Role:             cmd.Role,
+			ReplayProtected:  cmd.ReplayProtected,
+			SessionProtected: cmd.SessionProtected,
+			Compression:      cmd.Compression,
+			GattService:      cmd.GattService,
+			ExcludeTargets:   cmd.ExcludeTargets,
+			MaxRequestSize:   cmd.MaxRequestSize,
+			MaxResponseSize:  cmd.MaxResponseSize,
+			Comment:          cmd.Comment,
+		})
+	}
+	for _, m := range protoFile.Messages {
+		msg := ModelMessage{Name: m.Name, File: m.File, Fields: make([]ModelField, 0, len(m.Fields)), Comment: m.Comment}
+		for _, f := range m.Fields {
+			msg.Fields = append(msg.Fields, modelFieldOf(f))
+		}
+		doc.Messages = append(doc.Messages, msg)
+	}
+	for _, e := range protoFile.Enums {
+		enum := ModelEnum{Name: e.Name, Values: make([]ModelEnumValue, 0, len(e.Values)), Comment: e.Comment}
+		for _, v := range e.Values {
+			enum.Values = append(enum.Values, ModelEnumValue{v.Name, v.Number})
+		}
+		doc.Enums = append(doc.Enums, enum)
+	}
+	return doc
+}
+
+func modelFieldOf(f Field) ModelField {
+	mf := ModelField{
+		Name:       f.Name,
+		Number:     f.Number,
+		Type:       f.Type,
+		Repeated:   f.IsRepeated,
+		Optional:   f.IsOptional,
+		Required:   f.IsRequired,
+		Oneof:      f.Oneof,
+		Default:    f.Default,
+		Deprecated: f.Deprecated,
+		Sensitive:  f.Sensitive,
+		Comment:    f.Comment,
+	}
+	if f.IsMap {
+		mf.Type, mf.KeyType, mf.ValueType = "map", f.KeyType, f.ValueType
+	}
+	return mf
+}
@@ -0,0 +1,15 @@
+package gen
+
+// Messages named *Event, or annotated with option (blerpc.event) = true, are
+// events the peripheral notifies without a request. An event travels like a
+// response whose command name is the snake_case message name, so the
+// clients tell it apart from the responses of RPCs in flight. The peripheral
+// gets nanopb <pkg>_notify_<event>() helpers that encode an event and pass it
+// to the <pkg>_event_write() hook; the Python, Kotlin and Swift clients get a
+// subscription per event that decodes them as they arrive.
+
+// Event is a message the peripheral notifies unsolicited.
+type Event struct {
+	Message Message
+	Snake   string // command name the event is notified under
+}
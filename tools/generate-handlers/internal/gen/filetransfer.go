@@ -0,0 +1,41 @@
+package gen
+
+// The file_transfer built-in reads and writes files on the peripheral in
+// chunks small enough for one request: file_open returns a handle with the
+// size and CRC-32 of the file, file_read and file_write move one chunk at an
+// offset, and file_close, asked to verify, reads the file back for its size
+// and CRC-32. The firmware supplies the file system through the
+// <pkg>_file_*() hooks. The clients' upload and download helpers check the
+// CRC-32 of the whole file and resume an interrupted transfer from the bytes
+// already on either side.
+
+// FileCommands are the commands of the file_transfer built-in.
+type FileCommands struct {
+	Open, Read, Write, Close Command
+}
+
+// FileTransferCommands returns the file_transfer commands present in
+// commands, reporting whether all four are.
+func FileTransferCommands(commands []Command) (FileCommands, bool) {
+	var files FileCommands
+	found := 0
+	for _, cmd := range commands {
+		if cmd.Builtin != "file_transfer" {
+			continue
+		}
+		switch cmd.RequestMsg {
+		case "FileOpenRequest":
+			files.Open = cmd
+		case "FileReadRequest":
+			files.Read = cmd
+		case "FileWriteRequest":
+			files.Write = cmd
+		case "FileCloseRequest":
+			files.Close = cmd
+		default:
+			continue
+		}
+		found++
+	}
+	return files, found == 4
+}
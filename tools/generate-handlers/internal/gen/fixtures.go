@@ -0,0 +1,91 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxFixtureDepth bounds nested message expansion so recursive messages
+// still produce a finite sample.
+const maxFixtureDepth = 3
+
+// TextprotoScalar returns a plausible non-default textproto value for a scalar type.
+func TextprotoScalar(protoType, fieldName string) string {
+	switch protoType {
+	case "string":
+		return fmt.Sprintf("%q", fieldName)
+	case "bytes":
+		return `"\x01\x02\x03\x04"`
+	case "bool":
+		return "true"
+	case "float", "double":
+		return "1.5"
+	case "int32", "int64", "sint32", "sint64", "sfixed32", "sfixed64":
+		return "-1"
+	}
+	return "1"
+}
+
+// SampleEnumValue picks the first non-zero enum value so samples differ from
+// the default. Unknown enums fall back to the numeric value 1.
+func SampleEnumValue(en Enum, ok bool) EnumValue {
+	if !ok {
+		return EnumValue{Name: "1", Number: 1}
+	}
+	for _, v := range en.Values {
+		if v.Number != 0 {
+			return v
+		}
+	}
+	if len(en.Values) > 0 {
+		return en.Values[0]
+	}
+	return EnumValue{Name: "0"}
+}
+
+func textprotoEnum(en Enum, ok bool) string {
+	return SampleEnumValue(en, ok).Name
+}
+
+func WriteTextprotoFields(b *strings.Builder, fields []Field, indent string, depth int,
+	msgByName map[string]Message, enumByName map[string]Enum) {
+	seenOneof := make(map[string]bool)
+	for _, f := range fields {
+		if f.Oneof != "" {
+			// Only one member of a oneof may be set.
+			if seenOneof[f.Oneof] {
+				continue
+			}
+			seenOneof[f.Oneof] = true
+		}
+		switch {
+		case f.IsMap:
+			b.WriteString(fmt.Sprintf("%s%s {\n", indent, f.Name))
+			b.WriteString(fmt.Sprintf("%s  key: %s\n", indent, TextprotoScalar(f.KeyType, "key")))
+			if m, ok := msgByName[f.ValueType]; ok {
+				if depth < maxFixtureDepth {
+					b.WriteString(fmt.Sprintf("%s  value {\n", indent))
+					WriteTextprotoFields(b, m.Fields, indent+"    ", depth+1, msgByName, enumByName)
+					b.WriteString(fmt.Sprintf("%s  }\n", indent))
+				}
+			} else if en, ok := enumByName[f.ValueType]; ok {
+				b.WriteString(fmt.Sprintf("%s  value: %s\n", indent, textprotoEnum(en, true)))
+			} else {
+				b.WriteString(fmt.Sprintf("%s  value: %s\n", indent, TextprotoScalar(f.ValueType, "value")))
+			}
+			b.WriteString(fmt.Sprintf("%s}\n", indent))
+		case f.IsMessage:
+			if depth >= maxFixtureDepth {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("%s%s {\n", indent, f.Name))
+			WriteTextprotoFields(b, msgByName[f.Type].Fields, indent+"  ", depth+1, msgByName, enumByName)
+			b.WriteString(fmt.Sprintf("%s}\n", indent))
+		case f.IsEnum:
+			en, ok := enumByName[f.Type]
+			b.WriteString(fmt.Sprintf("%s%s: %s\n", indent, f.Name, textprotoEnum(en, ok)))
+		default:
+			b.WriteString(fmt.Sprintf("%s%s: %s\n", indent, f.Name, TextprotoScalar(f.Type, f.Name)))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package gen
+
+import (
+	"strings"
+)
+
+// With framing: true in blerpc.yaml the generator writes a small framing
+// layer that splits a message larger than the negotiated ATT MTU into
+// frames and puts them back together, for the C peripheral and the Python,
+// Kotlin and Swift clients. Every frame starts with a flags byte and a
+// sequence number counting the frames of the message from 0; the first
+// frame also carries the total length of the message:
+//
+//	flags (FIRST 0x01, LAST 0x02) | seq | [length, uint16 LE, FIRST only] | data
+//
+// A frame with FIRST drops a partly reassembled message, so a sender that
+// gave up on a message can start the next one. The peripheral reassembles
+// requests into a static buffer sized for the largest request command, 4-byte
+// header and name included, from the max_size and max_count bounds of the
+// .options file; with an unbounded request it falls back to
+// defaultFramingReassemblySize. The firmware may define
+// <PKG>_FRAMING_REASSEMBLY_SIZE to override either.
+
+// With correlation_ids: true as well, every frame carries after the flags a
+// correlation ID byte the client picks for a request and the peripheral
+// copies into its responses, so several calls can be in flight and their
+// frames interleaved. The peripheral reassembles up to max_in_flight requests
+// at once, one per ID, and a MORE flag marks the responses of a stream but
+// the last. The clients get a pipeline that allocates the IDs, holds back
+// calls beyond max_in_flight and routes responses to their calls.
+
+// With frame_crc: true every message travels with its CRC-32 after its data,
+// in its last frame or straddling the last two; the length in the first frame
+// leaves it out. The reassemblers check and strip it, failing a corrupted
+// message with a distinct integrity error: <PKG>_FRAMING_ERR_INTEGRITY on the
+// peripheral, which the firmware answers so the central can retry, and
+// IntegrityError/IntegrityException in the clients. The peripheral's
+// reassembly buffers grow by the 4 bytes of the CRC.
+
+// defaultFramingReassemblySize is the peripheral reassembly buffer when a
+// request has no bound.
+const defaultFramingReassemblySize = 1024
+
+// framingData is the data of the framing templates.
+type framingData struct {
+	Prefix, Upper  string
+	ReassemblySize int
+	Bounded        bool // ReassemblySize was computed from the requests
+	KotlinPackage  string
+	FeedPad        string // aligns the parameters of <prefix>_reassembler_feed
+	FragmentPad    string // aligns the parameters of <prefix>_fragment
+	FindPad        string // aligns the parameters of find_slot
+	Correlation    bool   // frames carry a correlation ID
+	MaxInFlight    int    // calls in flight at once with correlation IDs
+	CRC            bool   // messages carry a CRC-32
+}
+
+// framedRequestSize returns the largest request command, header, name and
+// replay counter and MAC included, or UnboundedSize.
+func framedRequestSize(commands []Command) int {
+	size := 0
+	for _, cmd := range commands {
+		if cmd.MaxRequestSize == UnboundedSize {
+			return UnboundedSize
+		}
+		n := 4 + len(cmd.Wire()) + cmd.MaxRequestSize
+		if cmd.ReplayProtected {
+			n += ReplayPrefixSize
+		}
+		size = max(size, n)
+	}
+	return size
+}
+
+// NewFramingData returns the template data; inFlight is the max_in_flight of
+// correlation IDs, or 0 without them, and crc is frame_crc.
+func (gctx *Context) NewFramingData(commands []Command, pkg string, inFlight int, crc bool) framingData {
+	d := framingData{Prefix: pkg, Upper: strings.ToUpper(pkg), ReassemblySize: defaultFramingReassemblySize, CRC: crc}
+	d.Correlation, d.MaxInFlight = inFlight > 0, inFlight
+	if n := framedRequestSize(commands); n != UnboundedSize {
+		d.ReassemblySize, d.Bounded = n, true
+	}
+	d.KotlinPackage = gctx.KotlinPackage(pkg)
+	d.FeedPad = strings.Repeat(" ", len("int "+pkg+"_reassembler_feed("))
+	d.FragmentPad = strings.Repeat(" ", len("int "+pkg+"_fragment("))
+	d.FindPad = strings.Repeat(" ", len("static struct "+pkg+"_reassembly_slot *find_slot("))
+	return d
+}
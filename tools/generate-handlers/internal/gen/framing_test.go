@@ -0,0 +1,23 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestFramedRequestSize(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.MaxRequestSize = 100
+	status := gentest.EnumCommand()
+	status.MaxRequestSize = 90
+	status.ReplayProtected = true
+	// get_status: 4 + 10 + 90 + 24 beats echo: 4 + 4 + 100.
+	if got := framedRequestSize([]Command{echo, status}); got != 128 {
+		t.Errorf("framedRequestSize = %d, want 128", got)
+	}
+	status.MaxRequestSize = UnboundedSize
+	if got := framedRequestSize([]Command{echo, status}); got != UnboundedSize {
+		t.Errorf("framedRequestSize = %d, want unbounded", got)
+	}
+}
@@ -0,0 +1,138 @@
+package gen
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// Protobuf wire types.
+const (
+	WireVarint  = 0
+	wireFixed64 = 1
+	WireBytes   = 2
+	wireFixed32 = 5
+)
+
+// WireTypeOf returns the wire type a scalar proto type is encoded with.
+func WireTypeOf(protoType string) int {
+	switch protoType {
+	case "string", "bytes":
+		return WireBytes
+	case "double", "fixed64", "sfixed64":
+		return wireFixed64
+	case "float", "fixed32", "sfixed32":
+		return wireFixed32
+	}
+	return WireVarint
+}
+
+func AppendTag(buf []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+func appendLenPrefixed(buf, data []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// appendSampleScalar encodes the same plausible value TextprotoScalar renders.
+func appendSampleScalar(buf []byte, protoType, fieldName string) []byte {
+	switch protoType {
+	case "string":
+		return appendLenPrefixed(buf, []byte(fieldName))
+	case "bytes":
+		return appendLenPrefixed(buf, []byte{1, 2, 3, 4})
+	case "bool":
+		return append(buf, 1)
+	case "float":
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(1.5))
+	case "double":
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(1.5))
+	case "int32", "int64":
+		return binary.AppendUvarint(buf, math.MaxUint64) // -1 sign-extended
+	case "sint32", "sint64":
+		return append(buf, 1) // zigzag(-1)
+	case "sfixed32":
+		return binary.LittleEndian.AppendUint32(buf, math.MaxUint32)
+	case "sfixed64":
+		return binary.LittleEndian.AppendUint64(buf, math.MaxUint64)
+	case "fixed32":
+		return binary.LittleEndian.AppendUint32(buf, 1)
+	case "fixed64":
+		return binary.LittleEndian.AppendUint64(buf, 1)
+	}
+	return append(buf, 1)
+}
+
+// SampleFields returns the fields a sample sets, the first member of each
+// oneof as in WriteTextprotoFields, in field number order: the order every
+// protobuf runtime serializes them in.
+func SampleFields(fields []Field) []Field {
+	var out []Field
+	seenOneof := make(map[string]bool)
+	for _, f := range fields {
+		if f.Oneof != "" {
+			if seenOneof[f.Oneof] {
+				continue
+			}
+			seenOneof[f.Oneof] = true
+		}
+		out = append(out, f)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Number < out[j].Number })
+	return out
+}
+
+// EncodeSampleFields produces the canonical binary encoding of the sample
+// values that WriteTextprotoFields renders, so fixtures, corpus seeds and
+// conformance vectors agree.
+func EncodeSampleFields(fields []Field, depth int, msgByName map[string]Message, enumByName map[string]Enum) []byte {
+	var buf []byte
+	for _, f := range SampleFields(fields) {
+		switch {
+		case f.IsMap:
+			var entry []byte
+			entry = AppendTag(entry, 1, WireTypeOf(f.KeyType))
+			entry = appendSampleScalar(entry, f.KeyType, "key")
+			if m, ok := msgByName[f.ValueType]; ok {
+				if depth < maxFixtureDepth {
+					entry = AppendTag(entry, 2, WireBytes)
+					entry = appendLenPrefixed(entry, EncodeSampleFields(m.Fields, depth+1, msgByName, enumByName))
+				}
+			} else if en, ok := enumByName[f.ValueType]; ok {
+				entry = AppendTag(entry, 2, WireVarint)
+				entry = binary.AppendUvarint(entry, uint64(int64(SampleEnumValue(en, true).Number)))
+			} else {
+				entry = AppendTag(entry, 2, WireTypeOf(f.ValueType))
+				entry = appendSampleScalar(entry, f.ValueType, "value")
+			}
+			buf = AppendTag(buf, f.Number, WireBytes)
+			buf = appendLenPrefixed(buf, entry)
+		case f.IsMessage:
+			if depth >= maxFixtureDepth {
+				continue
+			}
+			buf = AppendTag(buf, f.Number, WireBytes)
+			buf = appendLenPrefixed(buf, EncodeSampleFields(msgByName[f.Type].Fields, depth+1, msgByName, enumByName))
+		case f.IsEnum:
+			en, ok := enumByName[f.Type]
+			var v []byte
+			v = binary.AppendUvarint(v, uint64(int64(SampleEnumValue(en, ok).Number)))
+			if f.IsRepeated {
+				buf = AppendTag(buf, f.Number, WireBytes)
+				buf = appendLenPrefixed(buf, v)
+			} else {
+				buf = AppendTag(buf, f.Number, WireVarint)
+				buf = append(buf, v...)
+			}
+		case f.IsRepeated && WireTypeOf(f.Type) != WireBytes:
+			buf = AppendTag(buf, f.Number, WireBytes)
+			buf = appendLenPrefixed(buf, appendSampleScalar(nil, f.Type, f.Name))
+		default:
+			buf = AppendTag(buf, f.Number, WireTypeOf(f.Type))
+			buf = appendSampleScalar(buf, f.Type, f.Name)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,60 @@
+package gen
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeSampleFields_Scalars(t *testing.T) {
+	fields := []Field{
+		{Type: "string", Name: "ab", Number: 1},
+		{Type: "uint32", Name: "count", Number: 2},
+		{Type: "sint32", Name: "delta", Number: 3},
+		{Type: "fixed32", Name: "id", Number: 4},
+		{Type: "bool", Name: "on", Number: 5},
+	}
+	got := EncodeSampleFields(fields, 0, nil, nil)
+	want := []byte{
+		0x0a, 0x02, 'a', 'b', // string
+		0x10, 0x01, // uint32 = 1
+		0x18, 0x01, // sint32 = zigzag(-1)
+		0x25, 0x01, 0x00, 0x00, 0x00, // fixed32 = 1
+		0x28, 0x01, // bool = true
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeSampleFields = % x, want % x", got, want)
+	}
+}
+
+func TestEncodeSampleFields_NestedAndRepeated(t *testing.T) {
+	msgByName := map[string]Message{
+		"Inner": {Name: "Inner", Fields: []Field{{Type: "uint32", Name: "v", Number: 1}}},
+	}
+	fields := []Field{
+		{Type: "Inner", Name: "inner", Number: 1, IsMessage: true},
+		{Type: "uint32", Name: "ids", Number: 2, IsRepeated: true},
+	}
+	got := EncodeSampleFields(fields, 0, msgByName, nil)
+	want := []byte{
+		0x0a, 0x02, 0x08, 0x01, // inner { v: 1 }
+		0x12, 0x01, 0x01, // packed ids [1]
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeSampleFields = % x, want % x", got, want)
+	}
+}
+
+func TestEncodeSampleFields_FieldNumberOrder(t *testing.T) {
+	fields := []Field{
+		{Type: "uint32", Name: "b", Number: 2},
+		{Type: "uint32", Name: "a", Number: 1},
+		{Type: "uint32", Name: "x", Number: 4, Oneof: "choice"},
+		{Type: "uint32", Name: "y", Number: 3, Oneof: "choice"},
+	}
+	got := EncodeSampleFields(fields, 0, nil, nil)
+	// Canonical order, with the first declared member of the oneof.
+	want := []byte{0x08, 0x01, 0x10, 0x01, 0x20, 0x01}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeSampleFields = % x, want % x", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+package gen
+
+// GATTServiceUUID returns the command service UUID, or "" when the commands
+// have no characteristic UUIDs.
+func GATTServiceUUID(commands []Command) string {
+	if len(commands) == 0 || commands[0].CharUUID == "" {
+		return ""
+	}
+	return "00000000" + commands[0].CharUUID[8:]
+}
@@ -0,0 +1,21 @@
+package gen
+
+// GATTGroup is a declared GATT service group and the commands in it.
+type GATTGroup struct {
+	Name        string
+	ServiceUUID string
+	CharUUID    string
+	Commands    []Command
+}
+
+// GATTGroupCommands returns the wire names of the grouped commands with
+// the names of their groups, in group order.
+func (gctx *Context) GATTGroupCommands() [][2]string {
+	var out [][2]string
+	for _, g := range gctx.GATTGroups {
+		for _, cmd := range g.Commands {
+			out = append(out, [2]string{cmd.Wire(), g.Name})
+		}
+	}
+	return out
+}
@@ -0,0 +1,248 @@
+// Package gentest holds the schema fixtures shared by the tests of the
+// generators.
+package gentest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// SyntheticProto returns a schema with n commands served by one service.
+// Every command carries scalar, bytes, repeated, enum and nested message
+// fields, and every tenth command streams in either direction.
+func SyntheticProto(n int) string {
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n\npackage blerpc;\n\n")
+	b.WriteString("enum Mode {\n  MODE_UNKNOWN = 0;\n  MODE_FAST = 1;\n}\n\n")
+	b.WriteString("message Point {\n  int32 x = 1;\n  int32 y = 2;\n}\n\n")
+	var rpcs strings.Builder
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("Command%04d", i)
+		fmt.Fprintf(&b, "message %sRequest {\n  uint32 id = 1;\n  string label = 2;\n  bytes payload = 3;\n"+
+			"  repeated uint32 values = 4;\n  Mode mode = 5;\n  Point point = 6;\n}\n\n", name)
+		fmt.Fprintf(&b, "message %sResponse {\n  bool ok = 1;\n  string message = 2;\n  repeated Point points = 3;\n}\n\n", name)
+		req, resp := name+"Request", name+"Response"
+		switch i % 10 {
+		case 3:
+			resp = "stream " + resp
+		case 7:
+			req = "stream " + req
+		}
+		fmt.Fprintf(&rpcs, "  rpc %s(%s) returns (%s);\n", name, req, resp)
+	}
+	b.WriteString("service BlerpcService {\n")
+	b.WriteString(rpcs.String())
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func EchoCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "Echo",
+		Snake:       "echo",
+		RequestMsg:  "EchoRequest",
+		ResponseMsg: "EchoResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "string", Name: "message", Number: 1},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "string", Name: "message", Number: 1},
+		},
+	}
+}
+
+func OneofCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "Search",
+		Snake:       "search",
+		RequestMsg:  "SearchRequest",
+		ResponseMsg: "SearchResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "uint32", Name: "limit", Number: 1},
+			{Type: "string", Name: "text", Number: 2, Oneof: "query"},
+			{Type: "uint32", Name: "user_id", Number: 3, Oneof: "query"},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "string", Name: "name", Number: 1, Oneof: "result"},
+			{Type: "Level", Name: "level", Number: 2, IsEnum: true, Oneof: "result"},
+		},
+	}
+}
+
+func NestedMessageCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "Draw",
+		Snake:       "draw",
+		RequestMsg:  "DrawRequest",
+		ResponseMsg: "DrawResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "Outer.Inner", Name: "tag", Number: 1, IsMessage: true},
+			{Type: "Point", Name: "path", Number: 2, IsMessage: true, IsRepeated: true},
+			{Type: "map", Name: "named", Number: 3, IsMap: true, KeyType: "string", ValueType: "Point", ValueIsMessage: true},
+		},
+	}
+}
+
+func MessageFieldCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "UpdateAddress",
+		Snake:       "update_address",
+		RequestMsg:  "UpdateAddressRequest",
+		ResponseMsg: "UpdateAddressResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "string", Name: "user_id", Number: 1},
+			{Type: "Address", Name: "address", Number: 2, IsMessage: true},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "bool", Name: "ok", Number: 1},
+		},
+	}
+}
+
+func MapCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "SetLabels",
+		Snake:       "set_labels",
+		RequestMsg:  "SetLabelsRequest",
+		ResponseMsg: "SetLabelsResponse",
+		RequestFields: []protomodel.Field{
+			{Name: "labels", Number: 1, IsMap: true, KeyType: "string", ValueType: "string"},
+			{Name: "counts", Number: 2, IsMap: true, KeyType: "string", ValueType: "uint32"},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "bool", Name: "ok", Number: 1},
+		},
+	}
+}
+
+func RepeatedCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "Batch",
+		Snake:       "batch",
+		RequestMsg:  "BatchRequest",
+		ResponseMsg: "BatchResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "string", Name: "names", Number: 1, IsRepeated: true},
+			{Type: "uint32", Name: "ids", Number: 2, IsRepeated: true},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "string", Name: "results", Number: 1, IsRepeated: true},
+		},
+	}
+}
+
+func EnumCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "GetStatus",
+		Snake:       "get_status",
+		RequestMsg:  "GetStatusRequest",
+		ResponseMsg: "GetStatusResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "string", Name: "name", Number: 1},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "Status", Name: "status", Number: 1, IsEnum: true},
+		},
+	}
+}
+
+func StreamP2CCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "CounterStream",
+		Snake:       "counter_stream",
+		RequestMsg:  "CounterStreamRequest",
+		ResponseMsg: "CounterStreamResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "uint32", Name: "start", Number: 1},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "uint32", Name: "count", Number: 1},
+		},
+	}
+}
+
+func StreamC2PCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "CounterUpload",
+		Snake:       "counter_upload",
+		RequestMsg:  "CounterUploadRequest",
+		ResponseMsg: "CounterUploadResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "uint32", Name: "value", Number: 1},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "uint32", Name: "total", Number: 1},
+		},
+	}
+}
+
+func CallbackCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "DataWrite",
+		Snake:       "data_write",
+		RequestMsg:  "DataWriteRequest",
+		ResponseMsg: "DataWriteResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "uint32", Name: "address", Number: 1},
+			{Type: "bytes", Name: "data", Number: 2},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "bool", Name: "ok", Number: 1},
+		},
+	}
+}
+
+func Proto2Command() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "Configure",
+		Snake:       "configure",
+		RequestMsg:  "ConfigureRequest",
+		ResponseMsg: "ConfigureResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "uint32", Name: "address", Number: 1, IsRequired: true},
+			{Type: "string", Name: "label", Number: 2, IsOptional: true, Default: `"dev"`},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "bool", Name: "ok", Number: 1},
+		},
+	}
+}
+
+// OptionalCommand has a proto3 explicit optional field, without a default.
+func OptionalCommand() protomodel.Command {
+	return protomodel.Command{
+		Camel:       "List",
+		Snake:       "list",
+		RequestMsg:  "ListRequest",
+		ResponseMsg: "ListResponse",
+		RequestFields: []protomodel.Field{
+			{Type: "uint32", Name: "limit", Number: 1, IsOptional: true},
+			{Type: "uint32", Name: "offset", Number: 2},
+		},
+		ResponseFields: []protomodel.Field{
+			{Type: "uint32", Name: "count", Number: 1},
+		},
+	}
+}
+
+// RedactMessages returns a schema with a sensitive field in Credentials,
+// reached from LoginRequest directly and through a map, and an unrelated
+// message.
+func RedactMessages() map[string]protomodel.Message {
+	return map[string]protomodel.Message{
+		"Credentials": {Name: "Credentials", Fields: []protomodel.Field{
+			{Name: "user", Type: "string", Number: 1},
+			{Name: "pin", Type: "bytes", Number: 2, Sensitive: true},
+		}},
+		"LoginRequest": {Name: "LoginRequest", Fields: []protomodel.Field{
+			{Name: "credentials", Type: "Credentials", Number: 1, IsMessage: true},
+			{Name: "backup_keys", Number: 2, IsMap: true, KeyType: "string", ValueType: "Credentials", ValueIsMessage: true},
+			{Name: "note", Type: "string", Number: 3, IsOptional: true},
+		}},
+		"LoginResponse": {Name: "LoginResponse", Fields: []protomodel.Field{
+			{Name: "ok", Type: "bool", Number: 1},
+		}},
+	}
+}
@@ -0,0 +1,57 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateAdv(advs []gen.Advertisement, pkg, pbImport string, companyID uint) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("// Package " + pkg + "adv decodes " + pkg + " advertisements from scan results.\n")
+	b.WriteString("package " + pkg + "adv\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tpb \"" + pbImport + "\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+	b.WriteString("// CompanyID is the Bluetooth SIG company identifier of the manufacturer data.\n")
+	b.WriteString(fmt.Sprintf("const CompanyID = 0x%04x\n", companyID))
+	b.WriteByte('\n')
+	b.WriteString("// Advertisement types.\n")
+	b.WriteString("const (\n")
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("\tType%s = %d\n", adv.Message.Name, adv.Type))
+	}
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+	b.WriteString("// Parse decodes manufacturer data without the company identifier, as\n")
+	b.WriteString("// scanners report it per company. It returns false when the data holds no\n")
+	b.WriteString("// valid advertisement of this schema.\n")
+	b.WriteString("func Parse(data []byte) (proto.Message, bool) {\n")
+	b.WriteString("\tif len(data) == 0 {\n")
+	b.WriteString("\t\treturn nil, false\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tvar msg proto.Message\n")
+	b.WriteString("\tswitch data[0] {\n")
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("\tcase Type%s:\n", adv.Message.Name))
+		b.WriteString(fmt.Sprintf("\t\tmsg = &pb.%s{}\n", adv.Message.Name))
+	}
+	b.WriteString("\tdefault:\n")
+	b.WriteString("\t\treturn nil, false\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tif err := proto.Unmarshal(data[1:], msg); err != nil {\n")
+	b.WriteString("\t\treturn nil, false\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn msg, true\n")
+	b.WriteString("}\n")
+
+	return formatGo(b.String())
+}
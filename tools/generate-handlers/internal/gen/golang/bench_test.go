@@ -0,0 +1,37 @@
+package golang
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateGoBench(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.ReplayProtected, echo.ID = true, 1
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateBench(gctx, cmds, streaming, nil, nil, "example.com/sim", "example.com/wire")
+	if _, err := parser.ParseFile(token.NewFileSet(), "main.go", out, 0); err != nil {
+		t.Fatalf("generated benchmark does not parse: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"package main\n",
+		"\tsim \"example.com/sim\"\n",
+		"\t\"example.com/wire\"\n",
+		// The replay counter and MAC lead the sample request, zeroed.
+		"\t{\"echo\", \"\\x01\", wire.Unary, []byte(\"\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\x00\\n\\amessage\")},\n",
+		"\t{\"counter_stream\", \"counter_stream\", wire.StreamP2C, ",
+		"\t{\"counter_upload\", \"counter_upload\", wire.StreamC2P, ",
+		"func (r result) percentile(p int) time.Duration {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("benchmark missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateCapture returns capture.go, in the package of the Go client.
+func GenerateCapture(gctx *gen.Context, commands []gen.Command, messages map[string]gen.Message, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("package " + pkg + "client\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"bytes\"\n")
+	b.WriteString("\t\"cmp\"\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"encoding/binary\"\n")
+	b.WriteString("\t\"encoding/hex\"\n")
+	b.WriteString("\t\"encoding/json\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"fmt\"\n")
+	b.WriteString("\t\"io\"\n")
+	b.WriteString("\t\"iter\"\n")
+	b.WriteString("\t\"slices\"\n")
+	b.WriteString("\t\"sync\"\n")
+	b.WriteString("\t\"time\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// captureCommands maps the names the methods send commands by, where they\n")
+	b.WriteString("// are not their own, to the commands.\n")
+	b.WriteString("var captureCommands = map[string]string{\n")
+	for _, cmd := range gen.MockRenamedCommands(commands) {
+		b.WriteString(fmt.Sprintf("\t%s: %q,\n", gen.CallName(cmd, "go"), cmd.Snake))
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// unreplayable holds the commands whose requests lead with a session\n")
+	b.WriteString("// token or replay counter, which the peripheral accepts once.\n")
+	b.WriteString("var unreplayable = map[string]bool{\n")
+	for _, name := range gen.CaptureUnreplayable(commands) {
+		b.WriteString(fmt.Sprintf("\t%q: true,\n", name))
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	writeGoRedactTables(&b, commands, messages)
+
+	b.WriteString(gctx.RenderTemplate("go_capture.go.tmpl", nil))
+	return formatGo(b.String())
+}
@@ -0,0 +1,523 @@
+// Package golang generates the Go targets: the central client, the wire
+// package and the tools built on them, such as the simulator, the gateway
+// and the MQTT bridge.
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Go client lives in package <pkg>client next to the error types of
+// -out-go-errors. Its methods take and return the protoc-gen-go messages of
+// -go-pb-import and run over a Transport, which embeds the Caller the
+// file_transfer helper uses; *Client is a Caller itself, so a FileTransfer
+// built on it shares the client's one-RPC-at-a-time lock.
+
+// goMessageName returns the protoc-gen-go type of a schema message, e.g.
+// Outer_Inner for the nested Outer.Inner.
+func goMessageName(msg string) string {
+	return strings.ReplaceAll(msg, ".", "_")
+}
+
+// goStatusCheck returns the statements returning a RemoteError for resp, or
+// "" when the command is not checked.
+func goStatusCheck(cmd gen.Command, indent string) string {
+	if cmd.StatusField == "" {
+		return ""
+	}
+	getter := "resp.Get" + gen.UpperCamel(cmd.StatusField) + "()"
+	return fmt.Sprintf("%sif %s != %d {\n%s\treturn nil, &RemoteError{Command: %q, Field: %q, Status: int(%s)}\n%s}\n",
+		indent, getter, cmd.StatusOK, indent, cmd.Snake, cmd.StatusField, getter, indent)
+}
+
+// GenerateClient returns the typed Go client of the commands.
+func GenerateClient(commands []gen.Command, streaming map[string]string, pkg, pbImport string) string {
+	var b strings.Builder
+
+	hasReplay := gen.HasReplayProtected(commands)
+	start, auth, sessions := gen.SessionCommands(commands)
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("// Package " + pkg + "client is a typed client of the " + pkg + " commands.\n")
+	b.WriteString("package " + pkg + "client\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	if sessions {
+		b.WriteString("\t\"bytes\"\n")
+	}
+	b.WriteString("\t\"context\"\n")
+	if sessions {
+		b.WriteString("\t\"crypto/hmac\"\n")
+		b.WriteString("\t\"crypto/sha256\"\n")
+	}
+	if hasReplay {
+		b.WriteString("\t\"encoding/binary\"\n")
+	}
+	b.WriteString("\t\"errors\"\n")
+	if sessions {
+		b.WriteString("\t\"fmt\"\n")
+	}
+	b.WriteString("\t\"iter\"\n")
+	if sessions {
+		b.WriteString("\t\"slices\"\n")
+	}
+	b.WriteString("\t\"sync\"\n")
+	if hasReplay {
+		b.WriteString("\t\"time\"\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("\t\"google.golang.org/protobuf/encoding/protowire\"\n")
+	b.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tpb \"" + pbImport + "\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString(`// Transport carries raw command payloads to the peripheral, e.g. over a
+// BLE link framed with the wire package. Errors it returns are wrapped in
+// TransportError, or TimeoutError for context.DeadlineExceeded, unless they
+// match ErrBlerpc already.
+type Transport interface {
+	Caller
+	// StreamReceive sends a peripheral-to-central stream request and yields
+	// each response until the stream ends, an error is yielded or the
+	// consumer stops.
+	StreamReceive(ctx context.Context, cmdName string, requestData []byte) iter.Seq2[[]byte, error]
+	// StreamSend sends each request of a central-to-peripheral stream as it
+	// is produced, ends the stream with finalCmdName and returns the
+	// response.
+	StreamSend(ctx context.Context, cmdName string, messages iter.Seq[[]byte], finalCmdName string) ([]byte, error)
+}
+
+`)
+	b.WriteString("// Status codes of error responses, found in RemoteError.Status. Codes\n")
+	b.WriteString("// from 128 up are application defined.\n")
+	b.WriteString("const (\n")
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("\tStatus%s = %d // %s\n", gen.StatusErrorName(sc.Name), i, sc.Doc))
+	}
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString(`// Client calls the commands over a Transport, one at a time: the
+// peripheral runs a single command at once. It is safe for concurrent use.
+type Client struct {
+	Transport Transport
+`)
+	if sessions {
+		b.WriteString("\t// SessionKey is the key shared with the peripheral, which the session\n")
+		b.WriteString("\t// handshake proves knowledge of. Set it before calling a session- or\n")
+		b.WriteString("\t// replay-protected command.\n")
+		b.WriteString("\tSessionKey []byte\n")
+	}
+	b.WriteString("\n\tmu sync.Mutex\n")
+	if hasReplay {
+		b.WriteString("\tlastReplay uint64\n")
+	}
+	if sessions {
+		b.WriteString("\tsessionToken []byte\n")
+	}
+	b.WriteString(`}
+
+// New returns a client calling the commands over t.
+func New(t Transport) *Client {
+	return &Client{Transport: t}
+}
+
+// Call sends a raw request under the client's lock, so helpers taking a
+// Caller, such as FileTransfer, do not interleave with typed calls.
+func (c *Client) Call(ctx context.Context, cmdName string, requestData []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Transport.Call(ctx, cmdName, requestData)
+}
+
+// transportError wraps an error returned by the transport.
+func transportError(cmd string, err error) error {
+	switch {
+	case errors.Is(err, ErrBlerpc):
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		return &TimeoutError{Command: cmd}
+	}
+	return &TransportError{Command: cmd, Err: err}
+}
+
+`)
+	b.WriteString(fmt.Sprintf(`// decode checks data for an error response, which carries only a status in
+// field %d, and otherwise unmarshals it into resp.
+func decode(cmd string, data []byte, resp proto.Message) error {
+	if num, typ, n := protowire.ConsumeTag(data); n > 0 && num == %d && typ == protowire.VarintType {
+		status, m := protowire.ConsumeVarint(data[n:])
+		if m < 0 {
+			return &DecodeError{Command: cmd, Err: protowire.ParseError(m)}
+		}
+		return &RemoteError{Command: cmd, Status: int(status)}
+	}
+	if err := proto.Unmarshal(data, resp); err != nil {
+		return &DecodeError{Command: cmd, Err: err}
+	}
+	return nil
+}
+
+`, gen.StatusField, gen.StatusField))
+	if hasReplay {
+		b.WriteString(fmt.Sprintf(`// withReplayCounter leads the request of a replay-protected command with a
+// counter, 8 bytes little endian, and a MAC: the first %d bytes of an
+// HMAC-SHA256 under c.SessionKey of the command name, a NUL, the counter and
+// the request. The peripheral drops requests whose MAC is wrong or whose
+// counter is not above the last one it accepted; microseconds since the epoch
+// keep it increasing across restarts. Call it under c.mu so requests go out
+// in counter order.
+func (c *Client) withReplayCounter(cmdName string, reqData []byte) ([]byte, error) {
+	if c.SessionKey == nil {
+		return nil, ErrNoSessionKey
+	}
+	c.lastReplay = max(c.lastReplay+1, uint64(time.Now().UnixMicro()))
+	counter := binary.LittleEndian.AppendUint64(nil, c.lastReplay)
+	mac := hmac.New(sha256.New, c.SessionKey)
+	mac.Write(slices.Concat([]byte(cmdName), []byte{0}, counter, reqData))
+	return slices.Concat(counter, mac.Sum(nil)[:%d], reqData), nil
+}
+
+`, gen.ReplayMACSize, gen.ReplayMACSize))
+	}
+	if sessions {
+		writeGoSessionHelpers(&b, start, auth)
+	}
+
+	for _, cmd := range commands {
+		dir, isStream := streaming[cmd.Snake]
+		req, resp := "pb."+goMessageName(cmd.RequestMsg), "pb."+goMessageName(cmd.ResponseMsg)
+		wire := gen.CallName(cmd, "go")
+		switch {
+		case !isStream:
+			b.WriteString(fmt.Sprintf("// %s calls the %s command.\n", cmd.Camel, cmd.Snake))
+			b.WriteString(fmt.Sprintf("func (c *Client) %s(ctx context.Context, req *%s) (*%s, error) {\n", cmd.Camel, req, resp))
+			b.WriteString("\treqData, err := proto.Marshal(req)\n")
+			b.WriteString("\tif err != nil {\n")
+			b.WriteString("\t\treturn nil, err\n")
+			b.WriteString("\t}\n")
+			b.WriteString("\tc.mu.Lock()\n")
+			if cmd.ReplayProtected {
+				b.WriteString(fmt.Sprintf("\treqData, err = c.withReplayCounter(%q, reqData)\n", cmd.Wire()))
+				b.WriteString("\tif err != nil {\n")
+				b.WriteString("\t\tc.mu.Unlock()\n")
+				b.WriteString("\t\treturn nil, err\n")
+				b.WriteString("\t}\n")
+			}
+			if cmd.SessionProtected {
+				b.WriteString(fmt.Sprintf("\trespData, err := c.sessionCall(ctx, %s, reqData)\n", wire))
+			} else {
+				b.WriteString(fmt.Sprintf("\trespData, err := c.Transport.Call(ctx, %s, reqData)\n", wire))
+			}
+			b.WriteString("\tc.mu.Unlock()\n")
+			b.WriteString("\tif err != nil {\n")
+			b.WriteString(fmt.Sprintf("\t\treturn nil, transportError(%q, err)\n", cmd.Snake))
+			b.WriteString("\t}\n")
+			b.WriteString(fmt.Sprintf("\tresp := &%s{}\n", resp))
+			b.WriteString(fmt.Sprintf("\tif err := decode(%q, respData, resp); err != nil {\n", cmd.Snake))
+			b.WriteString("\t\treturn nil, err\n")
+			b.WriteString("\t}\n")
+			b.WriteString(goStatusCheck(cmd, "\t"))
+			b.WriteString("\treturn resp, nil\n")
+			b.WriteString("}\n")
+		case dir == "p2c":
+			b.WriteString(fmt.Sprintf("// %sSeq calls the %s stream and yields each response as it arrives.\n", cmd.Camel, cmd.Snake))
+			b.WriteString("// The client stays locked until the stream ends or the loop stops.\n")
+			b.WriteString(fmt.Sprintf("func (c *Client) %sSeq(ctx context.Context, req *%s) iter.Seq2[*%s, error] {\n", cmd.Camel, req, resp))
+			b.WriteString(fmt.Sprintf("\treturn func(yield func(*%s, error) bool) {\n", resp))
+			b.WriteString("\t\treqData, err := proto.Marshal(req)\n")
+			b.WriteString("\t\tif err != nil {\n")
+			b.WriteString("\t\t\tyield(nil, err)\n")
+			b.WriteString("\t\t\treturn\n")
+			b.WriteString("\t\t}\n")
+			b.WriteString("\t\tc.mu.Lock()\n")
+			b.WriteString("\t\tdefer c.mu.Unlock()\n")
+			b.WriteString(fmt.Sprintf("\t\tfor data, err := range c.Transport.StreamReceive(ctx, %s, reqData) {\n", wire))
+			b.WriteString("\t\t\tif err != nil {\n")
+			b.WriteString(fmt.Sprintf("\t\t\t\tyield(nil, transportError(%q, err))\n", cmd.Snake))
+			b.WriteString("\t\t\t\treturn\n")
+			b.WriteString("\t\t\t}\n")
+			b.WriteString(fmt.Sprintf("\t\t\tresp := &%s{}\n", resp))
+			b.WriteString(fmt.Sprintf("\t\t\tif err := decode(%q, data, resp); err != nil {\n", cmd.Snake))
+			b.WriteString("\t\t\t\tyield(nil, err)\n")
+			b.WriteString("\t\t\t\treturn\n")
+			b.WriteString("\t\t\t}\n")
+			if cmd.StatusField != "" {
+				getter := "resp.Get" + gen.UpperCamel(cmd.StatusField) + "()"
+				b.WriteString(fmt.Sprintf("\t\t\tif %s != %d {\n", getter, cmd.StatusOK))
+				b.WriteString(fmt.Sprintf("\t\t\t\tyield(nil, &RemoteError{Command: %q, Field: %q, Status: int(%s)})\n", cmd.Snake, cmd.StatusField, getter))
+				b.WriteString("\t\t\t\treturn\n")
+				b.WriteString("\t\t\t}\n")
+			}
+			b.WriteString("\t\t\tif !yield(resp, nil) {\n")
+			b.WriteString("\t\t\t\treturn\n")
+			b.WriteString("\t\t\t}\n")
+			b.WriteString("\t\t}\n")
+			b.WriteString("\t}\n")
+			b.WriteString("}\n")
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("// %s calls the %s stream and returns all responses.\n", cmd.Camel, cmd.Snake))
+			b.WriteString(fmt.Sprintf("func (c *Client) %s(ctx context.Context, req *%s) ([]*%s, error) {\n", cmd.Camel, req, resp))
+			b.WriteString(fmt.Sprintf("\tvar out []*%s\n", resp))
+			b.WriteString(fmt.Sprintf("\tfor resp, err := range c.%sSeq(ctx, req) {\n", cmd.Camel))
+			b.WriteString("\t\tif err != nil {\n")
+			b.WriteString("\t\t\treturn out, err\n")
+			b.WriteString("\t\t}\n")
+			b.WriteString("\t\tout = append(out, resp)\n")
+			b.WriteString("\t}\n")
+			b.WriteString("\treturn out, nil\n")
+			b.WriteString("}\n")
+		default:
+			b.WriteString(fmt.Sprintf("// %s sends each request of the %s stream as reqs produces it and\n", cmd.Camel, cmd.Snake))
+			b.WriteString("// returns the response; pass slices.Values(list) to send a slice.\n")
+			b.WriteString(fmt.Sprintf("func (c *Client) %s(ctx context.Context, reqs iter.Seq[*%s]) (*%s, error) {\n", cmd.Camel, req, resp))
+			b.WriteString("\tvar marshalErr error\n")
+			b.WriteString("\tmessages := func(yield func([]byte) bool) {\n")
+			b.WriteString("\t\tfor req := range reqs {\n")
+			b.WriteString("\t\t\tdata, err := proto.Marshal(req)\n")
+			b.WriteString("\t\t\tif err != nil {\n")
+			b.WriteString("\t\t\t\tmarshalErr = err\n")
+			b.WriteString("\t\t\t\treturn\n")
+			b.WriteString("\t\t\t}\n")
+			b.WriteString("\t\t\tif !yield(data) {\n")
+			b.WriteString("\t\t\t\treturn\n")
+			b.WriteString("\t\t\t}\n")
+			b.WriteString("\t\t}\n")
+			b.WriteString("\t}\n")
+			b.WriteString("\tc.mu.Lock()\n")
+			b.WriteString(fmt.Sprintf("\trespData, err := c.Transport.StreamSend(ctx, %s, messages, %s)\n", wire, wire))
+			b.WriteString("\tc.mu.Unlock()\n")
+			b.WriteString("\tif marshalErr != nil {\n")
+			b.WriteString("\t\treturn nil, marshalErr\n")
+			b.WriteString("\t}\n")
+			b.WriteString("\tif err != nil {\n")
+			b.WriteString(fmt.Sprintf("\t\treturn nil, transportError(%q, err)\n", cmd.Snake))
+			b.WriteString("\t}\n")
+			b.WriteString(fmt.Sprintf("\tresp := &%s{}\n", resp))
+			b.WriteString(fmt.Sprintf("\tif err := decode(%q, respData, resp); err != nil {\n", cmd.Snake))
+			b.WriteString("\t\treturn nil, err\n")
+			b.WriteString("\t}\n")
+			b.WriteString(goStatusCheck(cmd, "\t"))
+			b.WriteString("\treturn resp, nil\n")
+			b.WriteString("}\n")
+		}
+		b.WriteByte('\n')
+	}
+
+	return formatGo(b.String())
+}
+
+// GenerateDevices returns the DeviceManager of the Go client package,
+// with one <Command>All method per client method that calls it on every
+// connected device.
+func GenerateDevices(commands []gen.Command, streaming map[string]string, pkg, pbImport string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("package " + pkg + "client\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"io\"\n")
+	b.WriteString("\t\"iter\"\n")
+	b.WriteString("\t\"sync\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tpb \"" + pbImport + "\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+	b.WriteString(`// Result is the outcome of a call on one device.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// DeviceManager holds clients of several peripherals, keyed by address.
+// Every device gets a client of its own, so calls to different devices run
+// concurrently while each client still runs one RPC at a time.
+type DeviceManager struct {
+	// Dial connects to address and returns its transport. A transport
+	// implementing io.Closer is closed on Disconnect.
+	Dial func(ctx context.Context, address string) (Transport, error)
+
+	mu         sync.Mutex
+	clients    map[string]*Client
+	order      []string
+	connecting map[string]*dialing
+}
+
+type dialing struct {
+	done   chan struct{}
+	client *Client
+	err    error
+}
+
+// Connect returns the client of address, dialing it first if it is not
+// managed yet. Concurrent calls for one address share a single dial.
+func (m *DeviceManager) Connect(ctx context.Context, address string) (*Client, error) {
+	m.mu.Lock()
+	if c, ok := m.clients[address]; ok {
+		m.mu.Unlock()
+		return c, nil
+	}
+	d, ok := m.connecting[address]
+	if !ok {
+		d = &dialing{done: make(chan struct{})}
+		if m.connecting == nil {
+			m.connecting = make(map[string]*dialing)
+		}
+		m.connecting[address] = d
+		go m.dial(d, address)
+	}
+	m.mu.Unlock()
+	select {
+	case <-d.done:
+		return d.client, d.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dial runs without the caller's context, so a caller giving up does not
+// fail the dial other callers wait for.
+func (m *DeviceManager) dial(d *dialing, address string) {
+	t, err := m.Dial(context.Background(), address)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.connecting, address)
+	if err != nil {
+		d.err = &TransportError{Command: "connect", Err: err}
+	} else {
+		d.client = New(t)
+		if m.clients == nil {
+			m.clients = make(map[string]*Client)
+		}
+		m.clients[address] = d.client
+		m.order = append(m.order, address)
+	}
+	close(d.done)
+}
+
+// ConnectAll connects to every address concurrently.
+func (m *DeviceManager) ConnectAll(ctx context.Context, addresses ...string) map[string]Result[*Client] {
+	return broadcast(ctx, addresses, func(ctx context.Context, address string) (*Client, error) {
+		return m.Connect(ctx, address)
+	})
+}
+
+// Client returns the client of address, if it is managed.
+func (m *DeviceManager) Client(address string) (*Client, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.clients[address]
+	return c, ok
+}
+
+// Addresses returns the managed addresses in connection order.
+func (m *DeviceManager) Addresses() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.order...)
+}
+
+// Disconnect stops managing address and closes its transport.
+func (m *DeviceManager) Disconnect(address string) error {
+	m.mu.Lock()
+	c, ok := m.clients[address]
+	delete(m.clients, address)
+	for i, a := range m.order {
+		if a == address {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	if closer, ok := c.Transport.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// DisconnectAll disconnects every managed device.
+func (m *DeviceManager) DisconnectAll() error {
+	var errs []error
+	for _, address := range m.Addresses() {
+		errs = append(errs, m.Disconnect(address))
+	}
+	return errors.Join(errs...)
+}
+
+// Broadcast calls fn with the client of every managed device concurrently,
+// or of the given addresses. A device whose call failed maps to its error,
+// so one bad device does not hide the results of the others.
+func Broadcast[T any](ctx context.Context, m *DeviceManager, fn func(context.Context, *Client) (T, error), addresses ...string) map[string]Result[T] {
+	if len(addresses) == 0 {
+		addresses = m.Addresses()
+	}
+	return broadcast(ctx, addresses, func(ctx context.Context, address string) (T, error) {
+		c, ok := m.Client(address)
+		if !ok {
+			var zero T
+			return zero, &TransportError{Command: "broadcast", Err: errors.New(address + " is not connected")}
+		}
+		return fn(ctx, c)
+	})
+}
+
+func broadcast[T any](ctx context.Context, addresses []string, fn func(context.Context, string) (T, error)) map[string]Result[T] {
+	results := make([]Result[T], len(addresses))
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].Value, results[i].Err = fn(ctx, address)
+		}()
+	}
+	wg.Wait()
+	out := make(map[string]Result[T], len(addresses))
+	for i, address := range addresses {
+		out[address] = results[i]
+	}
+	return out
+}
+`)
+	usesIter := false
+	for _, cmd := range commands {
+		req, resp := "pb."+goMessageName(cmd.RequestMsg), "pb."+goMessageName(cmd.ResponseMsg)
+		param, arg := "req *"+req, "req"
+		result := "*" + resp
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			result = "[]*" + resp
+		case "c2p":
+			// Each device consumes reqs on its own, so it must be
+			// re-iterable, e.g. slices.Values.
+			param, arg = "reqs iter.Seq[*"+req+"]", "reqs"
+			usesIter = true
+		}
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("// %sAll calls %s on every managed device, or on the given addresses.\n", cmd.Camel, cmd.Camel))
+		b.WriteString(fmt.Sprintf("func (m *DeviceManager) %sAll(ctx context.Context, %s, addresses ...string) map[string]Result[%s] {\n", cmd.Camel, param, result))
+		b.WriteString(fmt.Sprintf("\treturn Broadcast(ctx, m, func(ctx context.Context, c *Client) (%s, error) {\n", result))
+		b.WriteString(fmt.Sprintf("\t\treturn c.%s(ctx, %s)\n", cmd.Camel, arg))
+		b.WriteString("\t}, addresses...)\n")
+		b.WriteString("}\n")
+	}
+
+	src := b.String()
+	if !usesIter {
+		src = strings.Replace(src, "\t\"iter\"\n", "", 1)
+	}
+	return formatGo(src)
+}
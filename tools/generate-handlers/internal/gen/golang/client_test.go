@@ -0,0 +1,67 @@
+package golang
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateGoClient(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.ReplayProtected = true
+	echo.StatusField, echo.StatusOK = "status", 0
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateClient(cmds, streaming, "blerpc", "example.com/pb")
+	if _, err := parser.ParseFile(token.NewFileSet(), "client.go", out, 0); err != nil {
+		t.Fatalf("generated client does not parse: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"package blerpcclient",
+		"\tCaller\n",
+		"StreamReceive(ctx context.Context, cmdName string, requestData []byte) iter.Seq2[[]byte, error]",
+		"\tStatusNotFound           = 2  //",
+		"num == 536870911 && typ == protowire.VarintType",
+		"func (c *Client) Echo(ctx context.Context, req *pb.EchoRequest) (*pb.EchoResponse, error) {",
+		"\treqData, err = c.withReplayCounter(\"echo\", reqData)\n",
+		"\tif resp.GetStatus() != 0 {\n",
+		"func (c *Client) CounterStreamSeq(ctx context.Context, req *pb.CounterStreamRequest) iter.Seq2[*pb.CounterStreamResponse, error] {",
+		"func (c *Client) CounterStream(ctx context.Context, req *pb.CounterStreamRequest) ([]*pb.CounterStreamResponse, error) {",
+		"func (c *Client) CounterUpload(ctx context.Context, reqs iter.Seq[*pb.CounterUploadRequest]) (*pb.CounterUploadResponse, error) {",
+		"c.Transport.StreamSend(ctx, \"counter_upload\", messages, \"counter_upload\")",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("client missing %q", want)
+		}
+	}
+	if plain := GenerateClient([]gen.Command{gentest.EchoCommand()}, nil, "blerpc", "example.com/pb"); strings.Contains(plain, "withReplayCounter") {
+		t.Error("replay counter emitted without replay-protected commands")
+	}
+}
+
+func TestGenerateGoDevices(t *testing.T) {
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateDevices(cmds, streaming, "blerpc", "example.com/pb")
+	if _, err := parser.ParseFile(token.NewFileSet(), "device_manager.go", out, 0); err != nil {
+		t.Fatalf("generated manager does not parse: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"type DeviceManager struct {",
+		"func (m *DeviceManager) Connect(ctx context.Context, address string) (*Client, error) {",
+		"func (m *DeviceManager) EchoAll(ctx context.Context, req *pb.EchoRequest, addresses ...string) map[string]Result[*pb.EchoResponse] {",
+		"func (m *DeviceManager) CounterStreamAll(ctx context.Context, req *pb.CounterStreamRequest, addresses ...string) map[string]Result[[]*pb.CounterStreamResponse] {",
+		"func (m *DeviceManager) CounterUploadAll(ctx context.Context, reqs iter.Seq[*pb.CounterUploadRequest], addresses ...string) map[string]Result[*pb.CounterUploadResponse] {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("manager missing %q", want)
+		}
+	}
+	if plain := GenerateDevices([]gen.Command{gentest.EchoCommand()}, nil, "blerpc", "example.com/pb"); strings.Contains(plain, "\"iter\"") {
+		t.Error("iter imported without client streams")
+	}
+}
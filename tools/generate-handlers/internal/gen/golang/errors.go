@@ -0,0 +1,76 @@
+package golang
+
+import (
+	"strings"
+)
+
+// GenerateErrors returns the error types of the Go client package and the
+// Caller interface its helpers share.
+func GenerateErrors(pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("package " + pkg + "client\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"fmt\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+	b.WriteString("// Caller carries raw command payloads to the peripheral.\n")
+	b.WriteString("type Caller interface {\n")
+	b.WriteString("\tCall(ctx context.Context, cmdName string, requestData []byte) ([]byte, error)\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("// ErrBlerpc matches every error returned by client methods with errors.Is.\n")
+	b.WriteString("var ErrBlerpc = errors.New(\"blerpc\")\n")
+	b.WriteByte('\n')
+	b.WriteString("// TransportError reports that the request could not be delivered or the\n")
+	b.WriteString("// response was lost.\n")
+	b.WriteString("type TransportError struct {\n")
+	b.WriteString("\tCommand string\n")
+	b.WriteString("\tErr     error\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("func (e *TransportError) Error() string { return fmt.Sprintf(\"%s: transport: %v\", e.Command, e.Err) }\n")
+	b.WriteString("func (e *TransportError) Unwrap() []error { return []error{ErrBlerpc, e.Err} }\n")
+	b.WriteByte('\n')
+	b.WriteString("// TimeoutError reports that the peripheral did not respond in time. It\n")
+	b.WriteString("// matches context.DeadlineExceeded.\n")
+	b.WriteString("type TimeoutError struct {\n")
+	b.WriteString("\tCommand string\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("func (e *TimeoutError) Error() string { return e.Command + \": timed out\" }\n")
+	b.WriteString("func (e *TimeoutError) Unwrap() []error {\n")
+	b.WriteString("\treturn []error{&TransportError{Command: e.Command, Err: context.DeadlineExceeded}}\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("// DecodeError reports that the response payload is not a valid message.\n")
+	b.WriteString("type DecodeError struct {\n")
+	b.WriteString("\tCommand string\n")
+	b.WriteString("\tErr     error\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("func (e *DecodeError) Error() string { return fmt.Sprintf(\"%s: invalid response: %v\", e.Command, e.Err) }\n")
+	b.WriteString("func (e *DecodeError) Unwrap() []error { return []error{ErrBlerpc, e.Err} }\n")
+	b.WriteByte('\n')
+	b.WriteString("// RemoteError reports that the peripheral answered with a non-OK status.\n")
+	b.WriteString("type RemoteError struct {\n")
+	b.WriteString("\tCommand string\n")
+	b.WriteString("\tField   string // status field name, if the status came from the response\n")
+	b.WriteString("\tStatus  int\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("func (e *RemoteError) Error() string {\n")
+	b.WriteString("\tif e.Field != \"\" {\n")
+	b.WriteString("\t\treturn fmt.Sprintf(\"%s failed: %s=%d\", e.Command, e.Field, e.Status)\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn fmt.Sprintf(\"%s failed: status=%d\", e.Command, e.Status)\n")
+	b.WriteString("}\n")
+	b.WriteString("func (e *RemoteError) Unwrap() error { return ErrBlerpc }\n")
+
+	return formatGo(b.String())
+}
@@ -0,0 +1,31 @@
+package golang
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGoErrors(t *testing.T) {
+	out := GenerateErrors("blerpc")
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "errors.go", out, 0); err != nil {
+		t.Fatalf("generated Go does not parse: %v\n%s", err, out)
+	}
+	mustContain := []string{
+		"// Code generated by generate-handlers. DO NOT EDIT.",
+		"package blerpcclient",
+		"var ErrBlerpc = errors.New(\"blerpc\")",
+		"type TransportError struct {",
+		"type TimeoutError struct {",
+		"Err: context.DeadlineExceeded",
+		"type DecodeError struct {",
+		"type RemoteError struct {",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Go errors missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateFaults returns faults.go, in the package of the Go client.
+func GenerateFaults(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("package " + pkg + "client\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"cmp\"\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"iter\"\n")
+	b.WriteString("\t\"math/rand/v2\"\n")
+	b.WriteString("\t\"slices\"\n")
+	b.WriteString("\t\"sync\"\n")
+	b.WriteString("\t\"time\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// faultCommands maps the names the methods send commands by, where they\n")
+	b.WriteString("// are not their own, to the commands.\n")
+	b.WriteString("var faultCommands = map[string]string{\n")
+	for _, cmd := range gen.MockRenamedCommands(commands) {
+		b.WriteString(fmt.Sprintf("\t%s: %q,\n", gen.CallName(cmd, "go"), cmd.Snake))
+	}
+	b.WriteString("}\n")
+
+	b.WriteString(gctx.RenderTemplate("go_faults.go.tmpl", nil))
+	return formatGo(b.String())
+}
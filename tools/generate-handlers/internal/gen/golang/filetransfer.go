@@ -0,0 +1,165 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateFileTransfer returns the file transfer helper of the Go client
+// package, which shares ErrBlerpc and the error types of -out-go-errors.
+func GenerateFileTransfer(files gen.FileCommands, pkg, pbImport string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("package " + pkg + "client\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"fmt\"\n")
+	b.WriteString("\t\"hash/crc32\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tpb \"" + pbImport + "\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+	b.WriteString(`// FileTransfer uploads and downloads files over the file_transfer built-in,
+// verifying each by CRC-32.
+type FileTransfer struct {
+	Caller Caller
+	// ChunkSize is the number of bytes per read or write; 0 means 128.
+	ChunkSize int
+	// Progress, if set, is called after each chunk with the bytes moved so
+	// far and the total.
+	Progress func(done, total int)
+}
+
+func (t *FileTransfer) chunkSize() int {
+	if t.ChunkSize > 0 {
+		return t.ChunkSize
+	}
+	return 128
+}
+
+func (t *FileTransfer) call(ctx context.Context, cmd string, req, resp proto.Message) error {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	respData, err := t.Caller.Call(ctx, cmd, reqData)
+	if err != nil {
+		if errors.Is(err, ErrBlerpc) {
+			return err
+		}
+		return &TransportError{Command: cmd, Err: err}
+	}
+	if err := proto.Unmarshal(respData, resp); err != nil {
+		return &DecodeError{Command: cmd, Err: err}
+	}
+	return nil
+}
+
+`)
+	b.WriteString(fmt.Sprintf(`func (t *FileTransfer) open(ctx context.Context, path string, write, resume bool) (*pb.%s, error) {
+	resp := &pb.%s{}
+	err := t.call(ctx, %q, &pb.%s{Path: path, Write: write, Resume: resume}, resp)
+	return resp, err
+}
+
+func (t *FileTransfer) close(ctx context.Context, handle uint32, verify bool) (*pb.%s, error) {
+	resp := &pb.%s{}
+	err := t.call(ctx, %q, &pb.%s{Handle: handle, Verify: verify}, resp)
+	return resp, err
+}
+
+`, files.Open.ResponseMsg, files.Open.ResponseMsg, files.Open.Wire(), files.Open.RequestMsg,
+		files.Close.ResponseMsg, files.Close.ResponseMsg, files.Close.Wire(), files.Close.RequestMsg))
+	b.WriteString(fmt.Sprintf(`// Upload writes data to path on the peripheral and verifies it by CRC-32.
+// With resume, an interrupted upload continues after the bytes already
+// written, if they match the start of data.
+func (t *FileTransfer) Upload(ctx context.Context, path string, data []byte, resume bool) error {
+	opened, err := t.open(ctx, path, true, resume)
+	if err != nil {
+		return err
+	}
+	offset := int(opened.GetSize())
+	if offset != 0 && (offset > len(data) || crc32.ChecksumIEEE(data[:offset]) != opened.GetCrc32()) {
+		// The partial file is not a prefix of data: start over.
+		if _, err := t.close(ctx, opened.GetHandle(), false); err != nil {
+			return err
+		}
+		if opened, err = t.open(ctx, path, true, false); err != nil {
+			return err
+		}
+		offset = 0
+	}
+	for offset < len(data) {
+		end := offset + t.chunkSize()
+		if end > len(data) {
+			end = len(data)
+		}
+		req := &pb.%s{Handle: opened.GetHandle(), Offset: uint32(offset), Data: data[offset:end]}
+		if err := t.call(ctx, %q, req, &pb.%s{}); err != nil {
+			_, _ = t.close(ctx, opened.GetHandle(), false)
+			return err
+		}
+		offset = end
+		if t.Progress != nil {
+			t.Progress(offset, len(data))
+		}
+	}
+	closed, err := t.close(ctx, opened.GetHandle(), true)
+	if err != nil {
+		return err
+	}
+	if int(closed.GetSize()) != len(data) || closed.GetCrc32() != crc32.ChecksumIEEE(data) {
+		return fmt.Errorf("%%s: upload failed verification: %%w", path, ErrBlerpc)
+	}
+	return nil
+}
+
+`, files.Write.RequestMsg, files.Write.Wire(), files.Write.ResponseMsg))
+	b.WriteString(fmt.Sprintf(`// Download reads path from the peripheral and verifies it by CRC-32. Pass
+// the bytes received before an interruption as partial to continue after
+// them.
+func (t *FileTransfer) Download(ctx context.Context, path string, partial []byte) ([]byte, error) {
+	opened, err := t.open(ctx, path, false, false)
+	if err != nil {
+		return nil, err
+	}
+	size := int(opened.GetSize())
+	data := append([]byte(nil), partial...)
+	if len(data) > size {
+		data = data[:size]
+	}
+	for len(data) < size {
+		req := &pb.%s{Handle: opened.GetHandle(), Offset: uint32(len(data)), Length: uint32(t.chunkSize())}
+		resp := &pb.%s{}
+		if err := t.call(ctx, %q, req, resp); err != nil {
+			_, _ = t.close(ctx, opened.GetHandle(), false)
+			return nil, err
+		}
+		if len(resp.GetData()) == 0 {
+			break
+		}
+		data = append(data, resp.GetData()...)
+		if t.Progress != nil {
+			t.Progress(len(data), size)
+		}
+	}
+	if _, err := t.close(ctx, opened.GetHandle(), false); err != nil {
+		return nil, err
+	}
+	if len(data) != size || crc32.ChecksumIEEE(data) != opened.GetCrc32() {
+		return nil, fmt.Errorf("%%s: download failed verification: %%w", path, ErrBlerpc)
+	}
+	return data, nil
+}
+`, files.Read.RequestMsg, files.Read.ResponseMsg, files.Read.Wire()))
+
+	return formatGo(b.String())
+}
@@ -0,0 +1,130 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Go gateway (-out-go-gateway) puts the commands behind a network API
+// for backend services and integration tests: Server registers a gRPC
+// service with a method per command and serves the same commands as JSON
+// over HTTP, forwarding every call through the typed client of
+// -go-client-import, so its BLE central transport, lock, replay counters
+// and error types apply unchanged. The service is described by hand-built
+// grpc.ServiceDesc values over the protoc-gen-go messages, so no .proto
+// service or protoc-gen-go-grpc run is needed. The generic method builders
+// and the error mapping are go_gateway.go.tmpl.
+
+// goGatewayData is the data of go_gateway.go.tmpl.
+type goGatewayData struct {
+	ServiceName string
+}
+
+// goGatewayMethod returns the client method the gateway forwards cmd to, as
+// a method expression.
+func goGatewayMethod(cmd gen.Command, mode string) string {
+	if mode == "p2c" {
+		return "(*client.Client)." + cmd.Camel + "Seq"
+	}
+	return "(*client.Client)." + cmd.Camel
+}
+
+// GenerateGateway returns the gRPC and JSON/HTTP gateway of the commands,
+// in package <pkg>gateway.
+func GenerateGateway(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg, clientImport string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("// Package " + pkg + "gateway serves the " + pkg + " commands to the network,\n")
+	b.WriteString("// forwarding every call to a peripheral through a " + pkg + "client.Client:\n")
+	b.WriteString("//\n")
+	b.WriteString("//\tgw := " + pkg + "gateway.New(" + pkg + "client.New(bleTransport))\n")
+	b.WriteString("//\ts := grpc.NewServer()\n")
+	b.WriteString("//\tgw.Register(s)\n")
+	b.WriteString("//\tgo s.Serve(grpcListener)\n")
+	b.WriteString("//\tgo http.Serve(httpListener, gw.HTTPHandler())\n")
+	b.WriteString("//\n")
+	b.WriteString("// The gRPC service " + pkg + ".Gateway has a method per command, named after\n")
+	b.WriteString("// it in upper camel case; streams to the central are server streams and\n")
+	b.WriteString("// streams from the central are client streams. Over HTTP each command is\n")
+	b.WriteString("// POST /<command> with the protojson request as body, an empty body being\n")
+	b.WriteString("// the empty request. Streams to the central answer newline-delimited JSON\n")
+	b.WriteString("// responses; streams from the central take their requests as a sequence\n")
+	b.WriteString("// of JSON objects in the body.\n")
+	b.WriteString("package " + pkg + "gateway\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"bytes\"\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"encoding/json\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"io\"\n")
+	b.WriteString("\t\"iter\"\n")
+	b.WriteString("\t\"net/http\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\t\"google.golang.org/grpc\"\n")
+	b.WriteString("\t\"google.golang.org/grpc/codes\"\n")
+	b.WriteString("\t\"google.golang.org/grpc/status\"\n")
+	b.WriteString("\t\"google.golang.org/protobuf/encoding/protojson\"\n")
+	b.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tclient \"" + clientImport + "\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// ServiceDesc describes the " + pkg + ".Gateway gRPC service. Register adds it\n")
+	b.WriteString("// to a server.\n")
+	b.WriteString("var ServiceDesc = grpc.ServiceDesc{\n")
+	b.WriteString("\tServiceName: ServiceName,\n")
+	b.WriteString("\tHandlerType: (*any)(nil),\n")
+	b.WriteString("\tMethods: []grpc.MethodDesc{\n")
+	for _, cmd := range commands {
+		if streaming[cmd.Snake] == "" {
+			b.WriteString(fmt.Sprintf("\t\tunaryMethod(%q, %s),\n", cmd.Camel, goGatewayMethod(cmd, "")))
+		}
+	}
+	b.WriteString("\t},\n")
+	b.WriteString("\tStreams: []grpc.StreamDesc{\n")
+	for _, cmd := range commands {
+		switch mode := streaming[cmd.Snake]; mode {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("\t\tserverStream(%q, %s),\n", cmd.Camel, goGatewayMethod(cmd, mode)))
+		case "c2p":
+			b.WriteString(fmt.Sprintf("\t\tclientStream(%q, %s),\n", cmd.Camel, goGatewayMethod(cmd, mode)))
+		}
+	}
+	b.WriteString("\t},\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// HTTPHandler returns the JSON/HTTP endpoints of the commands.\n")
+	b.WriteString("func (s *Server) HTTPHandler() http.Handler {\n")
+	b.WriteString("\tmux := http.NewServeMux()\n")
+	for _, cmd := range commands {
+		helper := "unaryHTTP"
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			helper = "serverStreamHTTP"
+		case "c2p":
+			helper = "clientStreamHTTP"
+		}
+		b.WriteString(fmt.Sprintf("\tmux.Handle(\"POST /%s\", %s(s, %s))\n", cmd.Snake, helper, goGatewayMethod(cmd, streaming[cmd.Snake])))
+	}
+	b.WriteString("\treturn mux\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// statusCodes maps the status codes of error responses to gRPC codes.\n")
+	b.WriteString("var statusCodes = map[int]codes.Code{\n")
+	for _, sc := range gen.StatusCodes[1:] {
+		name := gen.StatusErrorName(sc.Name)
+		b.WriteString(fmt.Sprintf("\tclient.Status%s: codes.%s,\n", name, name))
+	}
+	b.WriteString("}\n")
+
+	b.WriteString(gctx.RenderTemplate("go_gateway.go.tmpl", goGatewayData{ServiceName: pkg + ".Gateway"}))
+	return formatGo(b.String())
+}
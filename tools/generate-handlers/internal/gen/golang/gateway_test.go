@@ -0,0 +1,45 @@
+package golang
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateGoGateway(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateGateway(gctx, cmds, streaming, "blerpc", "example.com/client")
+	if _, err := parser.ParseFile(token.NewFileSet(), "gateway.go", out, 0); err != nil {
+		t.Fatalf("generated gateway does not parse: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"package blerpcgateway",
+		"\tclient \"example.com/client\"\n",
+		"const ServiceName = \"blerpc.Gateway\"\n",
+		"\t\tunaryMethod(\"Echo\", (*client.Client).Echo),\n",
+		"\t\tserverStream(\"CounterStream\", (*client.Client).CounterStreamSeq),\n",
+		"\t\tclientStream(\"CounterUpload\", (*client.Client).CounterUpload),\n",
+		"\tmux.Handle(\"POST /echo\", unaryHTTP(s, (*client.Client).Echo))\n",
+		"\tmux.Handle(\"POST /counter_stream\", serverStreamHTTP(s, (*client.Client).CounterStreamSeq))\n",
+		"\tmux.Handle(\"POST /counter_upload\", clientStreamHTTP(s, (*client.Client).CounterUpload))\n",
+		"\tclient.StatusNotFound:           codes.NotFound,\n",
+		"func grpcError(err error) error {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("gateway missing %q", want)
+		}
+	}
+	if strings.Contains(out, "client.StatusOk") {
+		t.Error("gateway maps the OK status")
+	}
+	// Streams are not unary methods.
+	if strings.Contains(out, "unaryMethod(\"CounterStream\"") {
+		t.Error("stream registered as a unary method")
+	}
+}
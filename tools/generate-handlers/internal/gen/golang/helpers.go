@@ -0,0 +1,15 @@
+package golang
+
+import (
+	"go/format"
+)
+
+// formatGo runs gofmt over generated Go source. If the source does not parse,
+// it is returned unchanged so the compiler error points at the real problem.
+func formatGo(src string) string {
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		return src
+	}
+	return string(out)
+}
@@ -0,0 +1,96 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Go MQTT bridge (-out-go-mqtt) gives remote access to a peripheral
+// through an edge gateway: Bridge answers requests published to
+// <topic>/rpc/<command>/request[/<id>] with a call through the typed client
+// of -go-client-import and publishes the protojson response to
+// <topic>/rpc/<command>/response[/<id>], or an error object to .../error.
+// Streams to the central publish a response per message and an empty
+// .../end; streams from the central take a JSON array of requests. The
+// events the transport delivers are published to <topic>/events/<event>.
+// The MQTT client stays the application's behind the Broker interface, so
+// the bridge pins no MQTT library. The generic parts are go_mqtt.go.tmpl.
+
+// goMQTTData is the data of go_mqtt.go.tmpl.
+type goMQTTData struct {
+	Pkg string
+}
+
+// GenerateMQTT returns the MQTT bridge of the commands and events, in
+// package <pkg>mqtt.
+func GenerateMQTT(gctx *gen.Context, commands []gen.Command, streaming map[string]string, events []gen.Event, pkg, clientImport, pbImport string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("// Package " + pkg + "mqtt bridges the " + pkg + " commands and events to MQTT,\n")
+	b.WriteString("// forwarding every request to a peripheral through a " + pkg + "client.Client:\n")
+	b.WriteString("//\n")
+	b.WriteString("//\tbr := " + pkg + "mqtt.New(" + pkg + "client.New(bleTransport), broker, \"site/gw1/" + pkg + "/dev42\")\n")
+	b.WriteString("//\terr := br.Run(ctx)\n")
+	b.WriteString("//\n")
+	b.WriteString("// A request is the protojson request message, published to\n")
+	b.WriteString("// <topic>/rpc/<command>/request, optionally followed by /<id> to tell\n")
+	b.WriteString("// concurrent callers apart; an empty payload is the empty request. The\n")
+	b.WriteString("// protojson response goes to <topic>/rpc/<command>/response and a failure\n")
+	b.WriteString("// to <topic>/rpc/<command>/error as {\"error\", \"status\", \"message\"}, both\n")
+	b.WriteString("// with the same /<id>. Streams to the central publish each response, then\n")
+	b.WriteString("// an empty message to <topic>/rpc/<command>/end; streams from the central\n")
+	b.WriteString("// take a JSON array of requests. Events are published as protojson to\n")
+	b.WriteString("// <topic>/events/<event>.\n")
+	b.WriteString("package " + pkg + "mqtt\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"bytes\"\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"encoding/json\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"fmt\"\n")
+	b.WriteString("\t\"iter\"\n")
+	b.WriteString("\t\"slices\"\n")
+	b.WriteString("\t\"strings\"\n")
+	b.WriteString("\t\"sync\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\t\"google.golang.org/protobuf/encoding/protojson\"\n")
+	b.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tclient \"" + clientImport + "\"\n")
+	if len(events) > 0 {
+		b.WriteString("\tpb \"" + pbImport + "\"\n")
+	}
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// handlers runs the requests of each command, by command name.\n")
+	b.WriteString("var handlers = map[string]handler{\n")
+	for _, cmd := range commands {
+		switch mode := streaming[cmd.Snake]; mode {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("\t%q: serverStream(%s),\n", cmd.Snake, goGatewayMethod(cmd, mode)))
+		case "c2p":
+			b.WriteString(fmt.Sprintf("\t%q: clientStream(%s),\n", cmd.Snake, goGatewayMethod(cmd, mode)))
+		default:
+			b.WriteString(fmt.Sprintf("\t%q: unary(%s),\n", cmd.Snake, goGatewayMethod(cmd, mode)))
+		}
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// events allocates the message of each event, by the command name it is\n")
+	b.WriteString("// notified under.\n")
+	b.WriteString("var events = map[string]func() proto.Message{\n")
+	for _, ev := range events {
+		b.WriteString(fmt.Sprintf("\t%q: func() proto.Message { return new(pb.%s) },\n", ev.Snake, goMessageName(ev.Message.Name)))
+	}
+	b.WriteString("}\n")
+
+	b.WriteString(gctx.RenderTemplate("go_mqtt.go.tmpl", goMQTTData{Pkg: pkg}))
+	return formatGo(b.String())
+}
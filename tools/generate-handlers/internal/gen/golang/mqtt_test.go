@@ -0,0 +1,42 @@
+package golang
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateGoMQTT(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	events := []gen.Event{{Message: gen.Message{Name: "ButtonEvent"}, Snake: "button_event"}}
+	out := GenerateMQTT(gctx, cmds, streaming, events, "blerpc", "example.com/client", "example.com/pb")
+	if _, err := parser.ParseFile(token.NewFileSet(), "mqtt.go", out, 0); err != nil {
+		t.Fatalf("generated bridge does not parse: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"package blerpcmqtt",
+		"\tclient \"example.com/client\"\n\tpb \"example.com/pb\"\n",
+		"\t\"echo\":           unary((*client.Client).Echo),\n",
+		"\t\"counter_stream\": serverStream((*client.Client).CounterStreamSeq),\n",
+		"\t\"counter_upload\": clientStream((*client.Client).CounterUpload),\n",
+		"\t\"button_event\": func() proto.Message { return new(pb.ButtonEvent) },\n",
+		"br.Broker.Subscribe(br.Topic+\"/rpc/+/request/#\"",
+		"e.g. site/gw1/blerpc/<device>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("bridge missing %q", want)
+		}
+	}
+
+	// Without events the message package is not imported.
+	out = GenerateMQTT(gctx, cmds, streaming, nil, "blerpc", "example.com/client", "example.com/pb")
+	if strings.Contains(out, "example.com/pb") {
+		t.Error("bridge without events imports the message package")
+	}
+}
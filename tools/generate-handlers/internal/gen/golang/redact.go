@@ -0,0 +1,42 @@
+package golang
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeGoRedactTables writes the redactedMessages and redactedCommands
+// tables of capture.go.
+func writeGoRedactTables(b *strings.Builder, commands []gen.Command, messages map[string]gen.Message) {
+	plans := gen.RedactPlans(messages)
+	b.WriteString("// redactedMessages holds the sensitive fields stripped from the recorded\n")
+	b.WriteString("// payloads: by message, the numbers of the fields to drop, mapped to \"\",\n")
+	b.WriteString("// and of the message fields holding some, mapped to their message.\n")
+	b.WriteString("var redactedMessages = map[string]map[uint64]string{\n")
+	for _, name := range slices.Sorted(maps.Keys(plans)) {
+		b.WriteString(fmt.Sprintf("\t%q: {", name))
+		for i, num := range slices.Sorted(maps.Keys(plans[name])) {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(fmt.Sprintf("%d: %q", num, plans[name][num]))
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("// redactedCommands holds the commands with sensitive fields.\n")
+	b.WriteString("var redactedCommands = map[string]redactedCall{\n")
+	for _, c := range gen.RedactedCalls(commands, plans) {
+		if c.Opaque {
+			b.WriteString(fmt.Sprintf("\t%q: {opaque: true},\n", c.Command))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\t%q: {prefix: %d, request: %q, response: %q},\n", c.Command, c.Prefix, c.Request, c.Response))
+	}
+	b.WriteString("}\n")
+}
@@ -0,0 +1,84 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeGoSessionHelpers emits the handshake of the Go client.
+func writeGoSessionHelpers(b *strings.Builder, start, auth gen.Command) {
+	b.WriteString("// ErrNoSessionKey is returned for a session- or replay-protected call on a\n")
+	b.WriteString("// client without a SessionKey.\n")
+	b.WriteString("var ErrNoSessionKey = fmt.Errorf(\"%w: SessionKey is not set\", ErrBlerpc)\n")
+	b.WriteByte('\n')
+	b.WriteString("// unauthenticated is the error response of a peripheral that has no\n")
+	b.WriteString("// session with the token a request led with.\n")
+	b.WriteString(fmt.Sprintf("var unauthenticated = protowire.AppendVarint(protowire.AppendTag(nil, %d, protowire.VarintType), StatusUnauthenticated)\n", gen.StatusField))
+	b.WriteByte('\n')
+	b.WriteString("// OpenSession authenticates to the peripheral, unlocking its\n")
+	b.WriteString("// session-protected commands. Protected calls open a session as needed;\n")
+	b.WriteString("// call it to authenticate up front.\n")
+	b.WriteString("func (c *Client) OpenSession(ctx context.Context) error {\n")
+	b.WriteString("\tc.mu.Lock()\n")
+	b.WriteString("\tdefer c.mu.Unlock()\n")
+	b.WriteString("\t_, err := c.handshake(ctx)\n")
+	b.WriteString("\treturn err\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("// handshake runs the session handshake under c.mu and keeps the token:\n")
+	b.WriteString("// the proof is an HMAC-SHA256 of the challenge under c.SessionKey.\n")
+	b.WriteString("func (c *Client) handshake(ctx context.Context) ([]byte, error) {\n")
+	b.WriteString("\tif c.SessionKey == nil {\n")
+	b.WriteString("\t\treturn nil, ErrNoSessionKey\n")
+	b.WriteString("\t}\n")
+	b.WriteString(fmt.Sprintf("\tstartData, err := c.Transport.Call(ctx, %s, nil)\n", gen.CallName(start, "go")))
+	b.WriteString("\tif err != nil {\n")
+	b.WriteString(fmt.Sprintf("\t\treturn nil, transportError(%q, err)\n", start.Snake))
+	b.WriteString("\t}\n")
+	b.WriteString(fmt.Sprintf("\tchallenge := &pb.%s{}\n", goMessageName(start.ResponseMsg)))
+	b.WriteString(fmt.Sprintf("\tif err := decode(%q, startData, challenge); err != nil {\n", start.Snake))
+	b.WriteString("\t\treturn nil, err\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tmac := hmac.New(sha256.New, c.SessionKey)\n")
+	b.WriteString("\tmac.Write(challenge.GetChallenge())\n")
+	b.WriteString(fmt.Sprintf("\treqData, err := proto.Marshal(&pb.%s{Proof: mac.Sum(nil)})\n", goMessageName(auth.RequestMsg)))
+	b.WriteString("\tif err != nil {\n")
+	b.WriteString("\t\treturn nil, err\n")
+	b.WriteString("\t}\n")
+	b.WriteString(fmt.Sprintf("\tauthData, err := c.Transport.Call(ctx, %s, reqData)\n", gen.CallName(auth, "go")))
+	b.WriteString("\tif err != nil {\n")
+	b.WriteString(fmt.Sprintf("\t\treturn nil, transportError(%q, err)\n", auth.Snake))
+	b.WriteString("\t}\n")
+	b.WriteString(fmt.Sprintf("\tsession := &pb.%s{}\n", goMessageName(auth.ResponseMsg)))
+	b.WriteString(fmt.Sprintf("\tif err := decode(%q, authData, session); err != nil {\n", auth.Snake))
+	b.WriteString("\t\treturn nil, err\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tc.sessionToken = session.GetToken()\n")
+	b.WriteString("\treturn c.sessionToken, nil\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("// sessionCall sends a session-protected request led by the session token,\n")
+	b.WriteString("// opening a session first when there is none. A peripheral that lost the\n")
+	b.WriteString("// session, e.g. on a reboot, answers UNAUTHENTICATED; the request is then\n")
+	b.WriteString("// sent once more in a new session. Call it under c.mu.\n")
+	b.WriteString("func (c *Client) sessionCall(ctx context.Context, cmdName string, requestData []byte) ([]byte, error) {\n")
+	b.WriteString("\ttoken := c.sessionToken\n")
+	b.WriteString("\tif token == nil {\n")
+	b.WriteString("\t\tvar err error\n")
+	b.WriteString("\t\tif token, err = c.handshake(ctx); err != nil {\n")
+	b.WriteString("\t\t\treturn nil, err\n")
+	b.WriteString("\t\t}\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\trespData, err := c.Transport.Call(ctx, cmdName, slices.Concat(token, requestData))\n")
+	b.WriteString("\tif err != nil || !bytes.Equal(respData, unauthenticated) {\n")
+	b.WriteString("\t\treturn respData, err\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tif token, err = c.handshake(ctx); err != nil {\n")
+	b.WriteString("\t\treturn nil, err\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn c.Transport.Call(ctx, cmdName, slices.Concat(token, requestData))\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
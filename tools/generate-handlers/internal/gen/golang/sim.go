@@ -0,0 +1,186 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Go simulator (-out-go-sim) is a virtual peripheral for integration
+// tests in CI: it answers the containers of the wire package as the firmware
+// does, but over a TCP or Unix socket instead of a GATT characteristic, each
+// container sent as one packet led by its length (uint16 LE). Handler has a
+// method per command and DefaultHandler echoes every request, so a test
+// overrides just the commands it checks. Like the mock clients, it drops the
+// session token and replay prefix leading protected requests unchecked, and
+// it does not simulate encryption. The server loop is go_sim.go.tmpl.
+
+// goSimData is the data of go_sim.go.tmpl.
+type goSimData struct {
+	StatusField int
+}
+
+// goSimMethod returns the Handler method of cmd, without the receiver.
+func goSimMethod(cmd gen.Command, mode string) string {
+	req, resp := "*pb."+goMessageName(cmd.RequestMsg), "*pb."+goMessageName(cmd.ResponseMsg)
+	switch mode {
+	case "p2c":
+		return fmt.Sprintf("%s(ctx context.Context, req %s, send func(%s) error) error", cmd.Camel, req, resp)
+	case "c2p":
+		return fmt.Sprintf("%s(ctx context.Context, reqs []%s) (%s, error)", cmd.Camel, req, resp)
+	}
+	return fmt.Sprintf("%s(ctx context.Context, req %s) (%s, error)", cmd.Camel, req, resp)
+}
+
+// writeGoSimRun emits run<Camel>, which decodes the requests of cmd, calls
+// its handler method and sends the responses.
+func writeGoSimRun(b *strings.Builder, cmd gen.Command, mode string) {
+	req, resp := "pb."+goMessageName(cmd.RequestMsg), "pb."+goMessageName(cmd.ResponseMsg)
+	b.WriteString(fmt.Sprintf("func run%s(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {\n", cmd.Camel))
+	switch mode {
+	case "c2p":
+		b.WriteString(fmt.Sprintf("\tmsgs := make([]*%s, len(reqs))\n", req))
+		b.WriteString("\tfor i, data := range reqs {\n")
+		b.WriteString(fmt.Sprintf("\t\tmsgs[i] = &%s{}\n", req))
+		b.WriteString("\t\tif err := proto.Unmarshal(data, msgs[i]); err != nil {\n")
+		b.WriteString("\t\t\treturn invalidRequest(err)\n")
+		b.WriteString("\t\t}\n")
+		b.WriteString("\t}\n")
+		b.WriteString(fmt.Sprintf("\tresp, err := h.%s(ctx, msgs)\n", cmd.Camel))
+	default:
+		b.WriteString(fmt.Sprintf("\treq := &%s{}\n", req))
+		b.WriteString("\tif err := proto.Unmarshal(reqs[0], req); err != nil {\n")
+		b.WriteString("\t\treturn invalidRequest(err)\n")
+		b.WriteString("\t}\n")
+		if mode == "p2c" {
+			b.WriteString(fmt.Sprintf("\treturn h.%s(ctx, req, func(resp *%s) error { return send(resp) })\n", cmd.Camel, resp))
+			b.WriteString("}\n")
+			return
+		}
+		b.WriteString(fmt.Sprintf("\tresp, err := h.%s(ctx, req)\n", cmd.Camel))
+	}
+	b.WriteString("\tif err != nil {\n")
+	b.WriteString("\t\treturn err\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn send(resp)\n")
+	b.WriteString("}\n")
+}
+
+// GenerateSim returns the simulated peripheral of the commands, in package
+// <pkg>sim.
+func GenerateSim(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg, pbImport, wireImport string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("// Package " + pkg + "sim is a simulated " + pkg + " peripheral for integration\n")
+	b.WriteString("// tests. It serves the commands over TCP or a Unix socket, sending every\n")
+	b.WriteString("// container of the wire package as one packet led by its length (uint16\n")
+	b.WriteString("// LE), so a test transport only has to replace the GATT characteristic:\n")
+	b.WriteString("//\n")
+	b.WriteString("//\tsim := " + pkg + "sim.New(myHandler{})\n")
+	b.WriteString("//\tgo sim.ListenAndServe(ctx, \"tcp\", \"127.0.0.1:7001\")\n")
+	b.WriteString("//\n")
+	b.WriteString("// Requests of session- and replay-protected commands are accepted without\n")
+	b.WriteString("// checking their token, counter or MAC, and encryption is not simulated.\n")
+	b.WriteString("package " + pkg + "sim\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"cmp\"\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"encoding/binary\"\n")
+	b.WriteString("\t\"errors\"\n")
+	b.WriteString("\t\"fmt\"\n")
+	b.WriteString("\t\"io\"\n")
+	b.WriteString("\t\"net\"\n")
+	b.WriteString("\t\"sync\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\t\"google.golang.org/protobuf/encoding/protowire\"\n")
+	b.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tpb \"" + pbImport + "\"\n")
+	b.WriteString("\t\"" + wireImport + "\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// Handler implements the commands of the simulated peripheral. Embed\n")
+	b.WriteString("// DefaultHandler to implement only the commands a test needs. A returned\n")
+	b.WriteString("// *StatusError becomes an error response of its status; other errors\n")
+	b.WriteString("// become StatusInternal.\n")
+	b.WriteString("type Handler interface {\n")
+	for _, cmd := range commands {
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("\t// %s answers the %s stream, calling send for each response.\n", cmd.Camel, cmd.Snake))
+		case "c2p":
+			b.WriteString(fmt.Sprintf("\t// %s answers the %s stream once the central ends it.\n", cmd.Camel, cmd.Snake))
+		default:
+			b.WriteString(fmt.Sprintf("\t// %s answers the %s command.\n", cmd.Camel, cmd.Snake))
+		}
+		b.WriteString("\t" + goSimMethod(cmd, streaming[cmd.Snake]) + "\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("// DefaultHandler answers every command with its request echoed into the\n")
+	b.WriteString("// response: the fields sharing a number and wire type are copied. Streams\n")
+	b.WriteString("// to the central send one such response; streams from the central echo\n")
+	b.WriteString("// their last request.\n")
+	b.WriteString("type DefaultHandler struct{}\n")
+	for _, cmd := range commands {
+		resp := "pb." + goMessageName(cmd.ResponseMsg)
+		b.WriteByte('\n')
+		b.WriteString("func (DefaultHandler) " + goSimMethod(cmd, streaming[cmd.Snake]) + " {\n")
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("\treturn send(echo(req, &%s{}))\n", resp))
+		case "c2p":
+			b.WriteString(fmt.Sprintf("\tresp := &%s{}\n", resp))
+			b.WriteString("\tif len(reqs) > 0 {\n")
+			b.WriteString("\t\techo(reqs[len(reqs)-1], resp)\n")
+			b.WriteString("\t}\n")
+			b.WriteString("\treturn resp, nil\n")
+		default:
+			b.WriteString(fmt.Sprintf("\treturn echo(req, &%s{}), nil\n", resp))
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteByte('\n')
+
+	b.WriteString("// Status codes of error responses. Codes from 128 up are application\n")
+	b.WriteString("// defined.\n")
+	b.WriteString("const (\n")
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("\tStatus%s = %d // %s\n", gen.StatusErrorName(sc.Name), i, sc.Doc))
+	}
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	for _, cmd := range commands {
+		writeGoSimRun(&b, cmd, streaming[cmd.Snake])
+		b.WriteByte('\n')
+	}
+
+	b.WriteString("// commands maps the wire names, and the IDs, of the commands to their\n")
+	b.WriteString("// stream kind, the bytes leading their requests and their run function.\n")
+	b.WriteString("var commands = map[string]command{\n")
+	for _, cmd := range commands {
+		stream := "wire.Unary"
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			stream = "wire.StreamP2C"
+		case "c2p":
+			stream = "wire.StreamC2P"
+		}
+		entry := fmt.Sprintf("{%s, %d, run%s}", stream, gen.MockRequestPrefix(cmd), cmd.Camel)
+		b.WriteString(fmt.Sprintf("\t%q: %s,\n", cmd.Wire(), entry))
+		if cmd.ID != 0 {
+			b.WriteString(fmt.Sprintf("\t%s: %s,\n", gen.CallName(cmd, "go"), entry))
+		}
+	}
+	b.WriteString("}\n")
+
+	b.WriteString(gctx.RenderTemplate("go_sim.go.tmpl", goSimData{StatusField: gen.StatusField}))
+	return formatGo(b.String())
+}
@@ -0,0 +1,44 @@
+package golang
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateGoSim(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.ReplayProtected, echo.ID = true, 1
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateSim(gctx, cmds, streaming, "blerpc", "example.com/pb", "example.com/wire")
+	if _, err := parser.ParseFile(token.NewFileSet(), "simulator.go", out, 0); err != nil {
+		t.Fatalf("generated simulator does not parse: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"package blerpcsim",
+		"\t\"example.com/wire\"\n",
+		"\tEcho(ctx context.Context, req *pb.EchoRequest) (*pb.EchoResponse, error)\n",
+		"\tCounterStream(ctx context.Context, req *pb.CounterStreamRequest, send func(*pb.CounterStreamResponse) error) error\n",
+		"\tCounterUpload(ctx context.Context, reqs []*pb.CounterUploadRequest) (*pb.CounterUploadResponse, error)\n",
+		"\treturn echo(req, &pb.EchoResponse{}), nil\n",
+		"\treturn send(echo(req, &pb.CounterStreamResponse{}))\n",
+		"\t\techo(reqs[len(reqs)-1], resp)\n",
+		"\treturn h.CounterStream(ctx, req, func(resp *pb.CounterStreamResponse) error { return send(resp) })\n",
+		"\t\"echo\":           {wire.Unary, 24, runEcho},\n",
+		"\t\"\\x01\":           {wire.Unary, 24, runEcho},\n",
+		"\t\"counter_upload\": {wire.StreamC2P, 0, runCounterUpload},\n",
+		"const statusField = 536870911\n",
+		"\tStatusUnimplemented      = 8  //",
+		"func (s *Simulator) Serve(ctx context.Context, l net.Listener) error {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("simulator missing %q", want)
+		}
+	}
+}
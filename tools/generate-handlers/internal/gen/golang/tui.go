@@ -0,0 +1,394 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// goTUIFieldKind classifies a request field for the TUI form so the generated
+// code knows how to turn the typed text into a protojson value.
+func goTUIFieldKind(f gen.Field) string {
+	if f.IsMap || f.IsRepeated || f.IsMessage {
+		return "kindJSON"
+	}
+	if f.IsEnum {
+		return "kindEnum"
+	}
+	switch f.Type {
+	case "string":
+		return "kindString"
+	case "bytes":
+		return "kindBytes"
+	case "bool":
+		return "kindBool"
+	}
+	return "kindNumber"
+}
+
+func GenerateTUI(commands []gen.Command, streaming map[string]string, pkg, pbImport string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("// Package " + pkg + "tui is an interactive terminal client for the " + pkg + " commands.\n")
+	b.WriteString("package " + pkg + "tui\n")
+	b.WriteByte('\n')
+	b.WriteString("import (\n")
+	b.WriteString("\t\"context\"\n")
+	b.WriteString("\t\"encoding/json\"\n")
+	b.WriteString("\t\"fmt\"\n")
+	b.WriteString("\t\"strconv\"\n")
+	b.WriteString("\t\"strings\"\n")
+	b.WriteString("\t\"time\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\t\"github.com/charmbracelet/bubbles/textinput\"\n")
+	b.WriteString("\ttea \"github.com/charmbracelet/bubbletea\"\n")
+	b.WriteString("\t\"google.golang.org/protobuf/encoding/protojson\"\n")
+	b.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	b.WriteByte('\n')
+	b.WriteString("\tpb \"" + pbImport + "\"\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+
+	b.WriteString(`// Transport carries raw command payloads to the peripheral.
+type Transport interface {
+	Call(ctx context.Context, cmdName string, requestData []byte) ([]byte, error)
+	StreamReceive(ctx context.Context, cmdName string, requestData []byte) ([][]byte, error)
+}
+
+// Notification is an unsolicited payload pushed by the peripheral.
+type Notification struct {
+	Source string
+	Data   []byte
+}
+
+// Notifier is optionally implemented by transports that deliver notifications.
+// They are shown in the notification pane as they arrive.
+type Notifier interface {
+	Notifications() <-chan Notification
+}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindBytes
+	kindBool
+	kindNumber
+	kindEnum
+	kindJSON
+)
+
+type fieldSpec struct {
+	name string
+	kind fieldKind
+}
+
+type commandSpec struct {
+	name        string
+	stream      bool
+	fields      []fieldSpec
+	newRequest  func() proto.Message
+	newResponse func() proto.Message
+}
+
+`)
+
+	// Command table. C2P commands take a sequence of request messages and
+	// have no sensible single-form representation, so they are left out.
+	b.WriteString("var commands = []commandSpec{\n")
+	for _, cmd := range commands {
+		dir, isStreaming := streaming[cmd.Snake]
+		if isStreaming && dir == "c2p" {
+			continue
+		}
+		b.WriteString("\t{\n")
+		b.WriteString(fmt.Sprintf("\t\tname: %q,\n", cmd.Wire()))
+		if isStreaming {
+			b.WriteString("\t\tstream: true,\n")
+		}
+		if len(cmd.RequestFields) > 0 {
+			b.WriteString("\t\tfields: []fieldSpec{\n")
+			for _, f := range cmd.RequestFields {
+				b.WriteString(fmt.Sprintf("\t\t\t{%q, %s},\n", f.Name, goTUIFieldKind(f)))
+			}
+			b.WriteString("\t\t},\n")
+		}
+		b.WriteString(fmt.Sprintf("\t\tnewRequest:  func() proto.Message { return &pb.%s{} },\n", cmd.RequestMsg))
+		b.WriteString(fmt.Sprintf("\t\tnewResponse: func() proto.Message { return &pb.%s{} },\n", cmd.ResponseMsg))
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString(`// fieldValue converts the text typed into a form input to a protojson value.
+func fieldValue(kind fieldKind, text string) (any, error) {
+	switch kind {
+	case kindString, kindBytes, kindNumber:
+		// protojson accepts quoted numbers and base64 strings for bytes.
+		return text, nil
+	case kindBool:
+		return strconv.ParseBool(text)
+	case kindEnum:
+		if n, err := strconv.ParseInt(text, 10, 32); err == nil {
+			return n, nil
+		}
+		return text, nil
+	default:
+		var v any
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+func buildRequest(spec commandSpec, values []string) (proto.Message, error) {
+	obj := make(map[string]any)
+	for i, f := range spec.fields {
+		if values[i] == "" {
+			continue
+		}
+		v, err := fieldValue(f.kind, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		obj[f.name] = v
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	req := spec.newRequest()
+	if err := protojson.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func formatResponse(spec commandSpec, data []byte) string {
+	resp := spec.newResponse()
+	if err := proto.Unmarshal(data, resp); err != nil {
+		return fmt.Sprintf("decode error: %v", err)
+	}
+	return protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Format(resp)
+}
+
+type mode int
+
+const (
+	modeList mode = iota
+	modeForm
+)
+
+const maxNotes = 8
+
+type resultMsg string
+
+type noteMsg Notification
+
+type model struct {
+	transport Transport
+	timeout   time.Duration
+	notify    <-chan Notification
+
+	mode   mode
+	cursor int
+	inputs []textinput.Model
+	focus  int
+	busy   bool
+	output string
+	notes  []string
+}
+
+// Run starts the interactive client on the terminal and blocks until the user quits.
+func Run(t Transport, timeout time.Duration) error {
+	m := model{transport: t, timeout: timeout}
+	if n, ok := t.(Notifier); ok {
+		m.notify = n.Notifications()
+	}
+	_, err := tea.NewProgram(m).Run()
+	return err
+}
+
+func (m model) Init() tea.Cmd {
+	return m.waitNote()
+}
+
+func (m model) waitNote() tea.Cmd {
+	if m.notify == nil {
+		return nil
+	}
+	ch := m.notify
+	return func() tea.Msg {
+		n, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return noteMsg(n)
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case resultMsg:
+		m.busy = false
+		m.output = string(msg)
+		return m, nil
+	case noteMsg:
+		line := fmt.Sprintf("%s %s (%d bytes)", time.Now().Format("15:04:05"), msg.Source, len(msg.Data))
+		m.notes = append(m.notes, line)
+		if len(m.notes) > maxNotes {
+			m.notes = m.notes[len(m.notes)-maxNotes:]
+		}
+		return m, m.waitNote()
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.mode == modeList {
+			return m.updateList(msg)
+		}
+		return m.updateForm(msg)
+	}
+	return m, nil
+}
+
+func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(commands)-1 {
+			m.cursor++
+		}
+	case "enter":
+		spec := commands[m.cursor]
+		if len(spec.fields) == 0 {
+			return m.submit()
+		}
+		m.inputs = make([]textinput.Model, len(spec.fields))
+		for i, f := range spec.fields {
+			in := textinput.New()
+			in.Prompt = f.name + ": "
+			m.inputs[i] = in
+		}
+		m.focus = 0
+		m.inputs[0].Focus()
+		m.mode = modeForm
+	}
+	return m, nil
+}
+
+func (m model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		return m, nil
+	case "tab", "down":
+		return m.moveFocus(1), nil
+	case "shift+tab", "up":
+		return m.moveFocus(-1), nil
+	case "enter":
+		if m.focus < len(m.inputs)-1 {
+			return m.moveFocus(1), nil
+		}
+		return m.submit()
+	}
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) moveFocus(delta int) model {
+	m.inputs[m.focus].Blur()
+	m.focus = (m.focus + delta + len(m.inputs)) % len(m.inputs)
+	m.inputs[m.focus].Focus()
+	return m
+}
+
+func (m model) submit() (tea.Model, tea.Cmd) {
+	if m.busy {
+		return m, nil
+	}
+	spec := commands[m.cursor]
+	values := make([]string, len(m.inputs))
+	for i, in := range m.inputs {
+		values[i] = strings.TrimSpace(in.Value())
+	}
+	req, err := buildRequest(spec, values)
+	if err != nil {
+		m.output = fmt.Sprintf("invalid request: %v", err)
+		return m, nil
+	}
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		m.output = fmt.Sprintf("encode error: %v", err)
+		return m, nil
+	}
+	m.busy = true
+	m.mode = modeList
+	m.output = "calling " + spec.name + "..."
+	t, timeout := m.transport, m.timeout
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if !spec.stream {
+			respData, err := t.Call(ctx, spec.name, reqData)
+			if err != nil {
+				return resultMsg(fmt.Sprintf("%s failed: %v", spec.name, err))
+			}
+			return resultMsg(formatResponse(spec, respData))
+		}
+		responses, err := t.StreamReceive(ctx, spec.name, reqData)
+		if err != nil {
+			return resultMsg(fmt.Sprintf("%s failed: %v", spec.name, err))
+		}
+		parts := make([]string, len(responses))
+		for i, data := range responses {
+			parts[i] = formatResponse(spec, data)
+		}
+		return resultMsg(strings.Join(parts, "\n"))
+	}
+}
+
+func (m model) View() string {
+	var b strings.Builder
+	if m.mode == modeList {
+		b.WriteString("Commands\n\n")
+		for i, spec := range commands {
+			cursor := "  "
+			if i == m.cursor {
+				cursor = "> "
+			}
+			b.WriteString(cursor + spec.name + "\n")
+		}
+	} else {
+		b.WriteString(commands[m.cursor].name + "\n\n")
+		for _, in := range m.inputs {
+			b.WriteString(in.View() + "\n")
+		}
+	}
+	b.WriteString("\n── Response ──\n")
+	b.WriteString(m.output + "\n")
+	b.WriteString("\n── Notifications ──\n")
+	for _, n := range m.notes {
+		b.WriteString(n + "\n")
+	}
+	if m.mode == modeList {
+		b.WriteString("\n↑/↓ select • enter open • q quit\n")
+	} else {
+		b.WriteString("\ntab next field • enter send • esc back\n")
+	}
+	return b.String()
+}
+`)
+
+	return formatGo(b.String())
+}
@@ -0,0 +1,70 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateGoTUI_Echo(t *testing.T) {
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateTUI(cmds, nil, "blerpc", "example.com/app/pb")
+
+	mustContain := []string{
+		"// Code generated by generate-handlers. DO NOT EDIT.",
+		"package blerpctui",
+		`pb "example.com/app/pb"`,
+		`name: "echo",`,
+		`{"message", kindString},`,
+		"newRequest:  func() proto.Message { return &pb.EchoRequest{} },",
+		"newResponse: func() proto.Message { return &pb.EchoResponse{} },",
+		"func Run(t Transport, timeout time.Duration) error {",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Go TUI missing %q", s)
+		}
+	}
+}
+
+func TestGenerateGoTUI_FieldKinds(t *testing.T) {
+	cmds := []gen.Command{gentest.CallbackCommand(), gentest.EnumCommand(), gentest.MessageFieldCommand(), gentest.RepeatedCommand()}
+	out := GenerateTUI(cmds, nil, "blerpc", "example.com/app/pb")
+
+	mustContain := []string{
+		`{"address", kindNumber},`,
+		`{"data", kindBytes},`,
+		`{"user_id", kindString},`,
+		`{"address", kindJSON},`,
+		`{"names", kindJSON},`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Go TUI field kinds missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateGoTUI_Streaming(t *testing.T) {
+	cmds := []gen.Command{gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateTUI(cmds, streaming, "blerpc", "example.com/app/pb")
+
+	if !strings.Contains(out, "stream: true,") {
+		t.Error("Go TUI should mark P2C command as streaming")
+	}
+	if strings.Contains(out, `name: "counter_upload"`) {
+		t.Error("Go TUI should not list C2P commands")
+	}
+}
+
+func TestGenerateGoTUI_CustomPkg(t *testing.T) {
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateTUI(cmds, nil, "myapp", "example.com/myapp/pb")
+
+	if !strings.Contains(out, "package myapptui") {
+		t.Errorf("Go TUI custom pkg missing package clause\nGot:\n%s", out)
+	}
+}
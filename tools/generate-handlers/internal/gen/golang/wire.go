@@ -0,0 +1,43 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateWire returns the command table of the schema for the shared Go
+// wire package, so hosted tools resolve wire names from one source.
+func GenerateWire(commands []gen.Command, streaming map[string]string, pkg, wireImport string) string {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by generate-handlers. DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("// Package " + pkg + "wire lists the " + pkg + " commands for the shared wire package.\n")
+	b.WriteString("package " + pkg + "wire\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("import \"%s\"\n", wireImport))
+	b.WriteByte('\n')
+	b.WriteString("// Commands describes every command in schema order.\n")
+	b.WriteString("var Commands = []wire.CommandSpec{\n")
+	for _, cmd := range commands {
+		stream := "wire.Unary"
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			stream = "wire.StreamP2C"
+		case "c2p":
+			stream = "wire.StreamC2P"
+		}
+		b.WriteString(fmt.Sprintf("\t{Name: %q, Request: %q, Response: %q, Stream: %s},\n",
+			cmd.Wire(), cmd.RequestMsg, cmd.ResponseMsg, stream))
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("// NewRegistry returns a registry of Commands.\n")
+	b.WriteString("func NewRegistry() (*wire.Registry, error) {\n")
+	b.WriteString("\treturn wire.NewRegistry(Commands)\n")
+	b.WriteString("}\n")
+
+	return formatGo(b.String())
+}
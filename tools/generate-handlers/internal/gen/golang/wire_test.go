@@ -0,0 +1,31 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateGoWire(t *testing.T) {
+	wired := gentest.EchoCommand()
+	wired.WireName = "e"
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateWire([]gen.Command{wired, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}, streaming, "blerpc", "github.com/tdaira/blerpc/go/wire")
+
+	mustContain := []string{
+		"// Code generated by generate-handlers. DO NOT EDIT.",
+		"package blerpcwire",
+		"import \"github.com/tdaira/blerpc/go/wire\"",
+		"{Name: \"e\", Request: \"EchoRequest\", Response: \"EchoResponse\", Stream: wire.Unary},",
+		"{Name: \"counter_stream\", Request: \"CounterStreamRequest\", Response: \"CounterStreamResponse\", Stream: wire.StreamP2C},",
+		"{Name: \"counter_upload\", Request: \"CounterUploadRequest\", Response: \"CounterUploadResponse\", Stream: wire.StreamC2P},",
+		"return wire.NewRegistry(Commands)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Go wire table missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
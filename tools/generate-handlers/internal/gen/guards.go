@@ -0,0 +1,32 @@
+package gen
+
+// The guards in blerpc.yaml protect constrained firmware from misbehaving or
+// malicious centrals before any handler runs:
+//
+//	guards:
+//	  max_request_size: true
+//	  rate_limit:
+//	    per_second: 20
+//	    burst: 40
+//
+// With max_request_size, a request longer than the largest encoding of its
+// command's request message, from the bounds in the .options file, is
+// answered with INVALID_ARGUMENT without decoding it. Commands with an
+// unbounded request are not checked. rate_limit adds a token bucket shared by
+// all commands to the per-command limits of ratelimit.go: each call takes a
+// token, the bucket refills per_second tokens a second and holds at most
+// burst, and calls that find it empty are answered with a BUSY error. Every
+// rejected call, by the bucket or a per-command limit, is reported to the
+// weak <pkg>_on_rate_limited() hook, e.g. to count it or drop the connection.
+
+// GuardsConfig configures the guard layer of the C dispatcher.
+type GuardsConfig struct {
+	MaxRequestSize bool               `yaml:"max_request_size"` // reject requests longer than their command allows
+	RateLimit      *TokenBucketConfig `yaml:"rate_limit"`       // token bucket shared by all commands
+}
+
+// TokenBucketConfig sets the token bucket of the guards.
+type TokenBucketConfig struct {
+	PerSecond int `yaml:"per_second"`
+	Burst     int `yaml:"burst"` // defaults to per_second
+}
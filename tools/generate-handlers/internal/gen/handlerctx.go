@@ -0,0 +1,10 @@
+package gen
+
+// CCtxSuffix returns the context parameter appended to an existing
+// parameter list, such as that of handlers_lookup.
+func (gctx *Context) CCtxSuffix() string {
+	if gctx.CHandlerCtx {
+		return ", void *ctx"
+	}
+	return ""
+}
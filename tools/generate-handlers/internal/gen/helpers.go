@@ -0,0 +1,71 @@
+package gen
+
+import (
+	"strings"
+)
+
+func ToLowerCamel(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// KotlinSetterName returns the protobuf-java setter for a field.
+// For snake_case fields like "received_count", the setter is "setReceivedCount".
+func KotlinSetterName(fieldName string) string {
+	parts := strings.Split(fieldName, "_")
+	var b strings.Builder
+	b.WriteString("set")
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	return b.String()
+}
+
+// KotlinBuilderSetter returns the builder method that fills a request field:
+// repeated fields take a list through addAll, maps through putAll. Enum
+// parameters are Ints and go through the ...Value variant.
+func KotlinBuilderSetter(f Field) string {
+	setter := KotlinSetterName(f.Name)
+	switch {
+	case f.IsMap:
+		return "putAll" + setter[len("set"):]
+	case f.IsRepeated:
+		setter = "addAll" + setter[len("set"):]
+	}
+	if f.IsEnum {
+		setter += "Value"
+	}
+	return setter
+}
+
+// NullableField reports whether a request field is an explicit optional
+// scalar, e.g. proto3 `optional uint32 limit = 1;`, which clients take as a
+// nullable parameter left unset when null, so the peripheral can tell "not
+// set" from zero. Fields with a proto2 default or a type mapping keep a plain
+// parameter.
+func NullableField(f Field) bool {
+	return f.IsOptional && !f.IsMessage && !f.IsRepeated && !f.IsMap && f.Oneof == "" &&
+		f.Default == "" && len(f.TypeOverrides) == 0
+}
+
+// SwiftPropertyName converts a snake_case field name to lowerCamelCase.
+func SwiftPropertyName(fieldName string) string {
+	parts := strings.Split(fieldName, "_")
+	if len(parts) == 0 {
+		return fieldName
+	}
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, p := range parts[1:] {
+		if p == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	return b.String()
+}
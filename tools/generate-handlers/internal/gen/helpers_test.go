@@ -0,0 +1,62 @@
+package gen
+
+import (
+	"testing"
+)
+
+func TestToLowerCamel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Echo", "echo"},
+		{"FlashRead", "flashRead"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ToLowerCamel(tt.input)
+			if got != tt.want {
+				t.Errorf("toLowerCamel(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKotlinSetterName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"message", "setMessage"},
+		{"received_count", "setReceivedCount"},
+		{"address", "setAddress"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := KotlinSetterName(tt.input)
+			if got != tt.want {
+				t.Errorf("kotlinSetterName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwiftPropertyName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"message", "message"},
+		{"received_count", "receivedCount"},
+		{"address", "address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := SwiftPropertyName(tt.input)
+			if got != tt.want {
+				t.Errorf("swiftPropertyName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateAdv(gctx *gen.Context, advs []gen.Advertisement, pkg string, companyID uint) string {
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import android.bluetooth.le.ScanRecord\n")
+	b.WriteString("import com.google.protobuf.InvalidProtocolBufferException\n")
+	b.WriteString("import com.google.protobuf.MessageLite\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Decodes advertisements broadcast as manufacturer specific data. */\n")
+	b.WriteString("object GeneratedAdvertising {\n")
+	b.WriteString("    /** Bluetooth SIG company identifier of the manufacturer data. */\n")
+	b.WriteString(fmt.Sprintf("    const val COMPANY_ID = 0x%04X\n", companyID))
+	b.WriteByte('\n')
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("    const val %s_TYPE = %d\n", strings.ToUpper(adv.Snake), adv.Type))
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /** Returns the advertisement in [record], or null if it holds none of this schema. */\n")
+	b.WriteString("    fun parse(record: ScanRecord): MessageLite? =\n")
+	b.WriteString("        record.getManufacturerSpecificData(COMPANY_ID)?.let { parse(it) }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Decodes manufacturer data without the company identifier. */\n")
+	b.WriteString("    fun parse(data: ByteArray): MessageLite? {\n")
+	b.WriteString("        if (data.isEmpty()) return null\n")
+	b.WriteString("        val payload = data.copyOfRange(1, data.size)\n")
+	b.WriteString("        return try {\n")
+	b.WriteString("            when (data[0].toInt() and 0xFF) {\n")
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("                %s_TYPE -> %s.%s.%s.parseFrom(payload)\n",
+			strings.ToUpper(adv.Snake), pkg, pkgCap, adv.Message.Name))
+	}
+	b.WriteString("                else -> null\n")
+	b.WriteString("            }\n")
+	b.WriteString("        } catch (e: InvalidProtocolBufferException) {\n")
+	b.WriteString("            null\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,158 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateBatch returns GeneratedBatch.kt, in the package of the
+// generated client.
+func GenerateBatch(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	batchable := gen.BatchCommands(commands, streaming)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import com.google.protobuf.ByteString\n")
+	b.WriteString("import com.google.protobuf.InvalidProtocolBufferException\n")
+	b.WriteString("import java.io.ByteArrayOutputStream\n")
+	for _, imp := range gen.OverrideImports(batchable, "kotlin") {
+		b.WriteString(imp + "\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** Command name of the batch envelope. */\n")
+	b.WriteString(fmt.Sprintf("const val BATCH_COMMAND = \"%s\"\n", gen.BatchCommandName))
+	b.WriteByte('\n')
+	b.WriteString("/** The batch response holds no complete response for a call. */\n")
+	b.WriteString("class BatchException(message: String) : BlerpcException(message)\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The response of a call added to a [Batch], once the batch is sent. */\n")
+	b.WriteString("class BatchCall<T> internal constructor(\n")
+	b.WriteString("    val command: String,\n")
+	b.WriteString("    private val parse: (ByteArray) -> T,\n")
+	b.WriteString(") {\n")
+	b.WriteString("    private var result: Result<T>? = null\n")
+	b.WriteByte('\n')
+	b.WriteString("    internal val error: Throwable? get() = result?.exceptionOrNull()\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Returns the response, or throws the error of the call. */\n")
+	b.WriteString("    fun get(): T = checkNotNull(result) { \"$command: the batch was not sent\" }.getOrThrow()\n")
+	b.WriteByte('\n')
+	b.WriteString("    internal fun complete(data: ByteArray) {\n")
+	b.WriteString("        result =\n")
+	b.WriteString("            try {\n")
+	b.WriteString("                statusException(command, data)?.let { throw it }\n")
+	b.WriteString("                Result.success(parse(data))\n")
+	b.WriteString("            } catch (e: InvalidProtocolBufferException) {\n")
+	b.WriteString("                Result.failure(DecodeException(command, e))\n")
+	b.WriteString("            } catch (e: BlerpcException) {\n")
+	b.WriteString("                Result.failure(e)\n")
+	b.WriteString("            }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    internal fun fail(error: Throwable) {\n")
+	b.WriteString("        result = Result.failure(error)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Unary calls sent to the peripheral in one request. The peripheral runs\n")
+	b.WriteString(" * them in order and answers them in one response, so a sequence of small\n")
+	b.WriteString(" * calls, such as provisioning, costs one round trip instead of one per call:\n")
+	b.WriteString(" *\n")
+	b.WriteString(" *     val batch = Batch(client)\n")
+	b.WriteString(" *     val echo = batch.echo(message = \"hi\")\n")
+	b.WriteString(" *     batch.send()\n")
+	b.WriteString(" *     println(echo.get().message)\n")
+	b.WriteString(" *\n")
+	b.WriteString(" * Streaming commands, and commands with a replay counter or a session\n")
+	b.WriteString(" * token, cannot be batched.\n")
+	b.WriteString(" */\n")
+	b.WriteString("class Batch(private val client: " + gctx.KotlinClientClass() + ") {\n")
+	b.WriteString("    private val entries = ByteArrayOutputStream()\n")
+	b.WriteString("    private val calls = mutableListOf<BatchCall<*>>()\n")
+
+	for _, cmd := range batchable {
+		reqCls := pkg + "." + pkgCap + "." + cmd.RequestMsg
+		respCls := pkg + "." + pkgCap + "." + cmd.ResponseMsg
+		params := strings.Join(kotlinParams(cmd.RequestFields, cmd.RequestMsg, pkg), ", ")
+		b.WriteByte('\n')
+		b.WriteString(Deprecation(cmd, true))
+		b.WriteString(fmt.Sprintf("    fun %s(%s): BatchCall<%s> {\n", gen.ToLowerCamel(cmd.Camel), params, respCls))
+		writeKotlinSizeChecks(&b, cmd)
+		b.WriteString(fmt.Sprintf("        val req = %s.newBuilder()\n", reqCls))
+		writeKotlinSetters(&b, cmd.RequestFields, cmd.RequestMsg)
+		b.WriteString("            .build()\n")
+		if cmd.StatusField == "" {
+			b.WriteString(fmt.Sprintf("        val call = BatchCall(\"%s\") { %s.parseFrom(it) }\n", cmd.Snake, respCls))
+		} else {
+			b.WriteString("        val call =\n")
+			b.WriteString(fmt.Sprintf("            BatchCall(\"%s\") {\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("                val resp = %s.parseFrom(it)\n", respCls))
+			b.WriteString(kotlinStatusCheck(cmd, "                "))
+			b.WriteString("                resp\n")
+			b.WriteString("            }\n")
+		}
+		b.WriteString(fmt.Sprintf("        return add(%s, req.toByteArray(), call)\n", gen.CallName(cmd, "kotlin")))
+		b.WriteString("    }\n")
+	}
+
+	b.WriteByte('\n')
+	b.WriteString("    private fun <T> add(\n")
+	b.WriteString("        cmdName: String,\n")
+	b.WriteString("        requestData: ByteArray,\n")
+	b.WriteString("        call: BatchCall<T>,\n")
+	b.WriteString("    ): BatchCall<T> {\n")
+	b.WriteString("        val name = cmdName.toByteArray()\n")
+	b.WriteString("        entries.write(name.size)\n")
+	b.WriteString("        entries.write(name)\n")
+	b.WriteString("        entries.write(requestData.size and 0xFF)\n")
+	b.WriteString("        entries.write(requestData.size shr 8)\n")
+	b.WriteString("        entries.write(requestData)\n")
+	b.WriteString("        calls.add(call)\n")
+	b.WriteString("        return call\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Sends the calls added since the last send and records their results.\n")
+	b.WriteString("     * Throws the error of the first failed call once every result is\n")
+	b.WriteString("     * recorded, or the transport error, which every call then throws too.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun send() {\n")
+	b.WriteString("        val sent = calls.toList()\n")
+	b.WriteString("        val payload = entries.toByteArray()\n")
+	b.WriteString("        calls.clear()\n")
+	b.WriteString("        entries.reset()\n")
+	b.WriteString("        if (sent.isEmpty()) return\n")
+	b.WriteString("        val respData =\n")
+	b.WriteString("            try {\n")
+	b.WriteString("                client.exclusive { client.call(BATCH_COMMAND, payload) }\n")
+	b.WriteString("            } catch (e: Exception) {\n")
+	b.WriteString("                sent.forEach { it.fail(e) }\n")
+	b.WriteString("                throw e\n")
+	b.WriteString("            }\n")
+	b.WriteString("        var pos = 0\n")
+	b.WriteString("        for (call in sent) {\n")
+	b.WriteString("            val size =\n")
+	b.WriteString("                if (respData.size - pos < 2) {\n")
+	b.WriteString("                    0\n")
+	b.WriteString("                } else {\n")
+	b.WriteString("                    (respData[pos].toInt() and 0xFF) or ((respData[pos + 1].toInt() and 0xFF) shl 8)\n")
+	b.WriteString("                }\n")
+	b.WriteString("            if (respData.size - pos < 2 + size) {\n")
+	b.WriteString("                call.fail(BatchException(\"${call.command}: no response in the batch\"))\n")
+	b.WriteString("                pos = respData.size\n")
+	b.WriteString("                continue\n")
+	b.WriteString("            }\n")
+	b.WriteString("            call.complete(respData.copyOfRange(pos + 2, pos + 2 + size))\n")
+	b.WriteString("            pos += 2 + size\n")
+	b.WriteString("        }\n")
+	b.WriteString("        sent.firstNotNullOfOrNull { it.error }?.let { throw it }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
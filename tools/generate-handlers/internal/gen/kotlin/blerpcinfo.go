@@ -0,0 +1,47 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinSchemaMismatchException emits the schema constants and the
+// exception verifySchema throws.
+func writeKotlinSchemaMismatchException(gctx *gen.Context, b *strings.Builder) {
+	b.WriteString("/**\n")
+	b.WriteString(" * The schema this client was generated from, which\n")
+	b.WriteString(" * [" + gctx.KotlinClientClass() + ".verifySchema] compares with the peripheral's.\n")
+	b.WriteString(" */\n")
+	b.WriteString(fmt.Sprintf("const val SCHEMA_HASH = \"%s\"\n", gctx.SchemaHash))
+	b.WriteString(fmt.Sprintf("const val GENERATOR_VERSION = \"%s\"\n", gen.GeneratorVersion))
+	b.WriteByte('\n')
+	b.WriteString("/** The peripheral was built from a different schema than this client. */\n")
+	b.WriteString("class SchemaMismatchException(\n")
+	b.WriteString("    val expected: String,\n")
+	b.WriteString("    val actual: String,\n")
+	b.WriteString("    val generatorVersion: String,\n")
+	b.WriteString(") : BlerpcException(\n")
+	b.WriteString("    \"peripheral schema $actual (generator $generatorVersion) does not match \" +\n")
+	b.WriteString("        \"this client's $expected (generator $GENERATOR_VERSION)\",\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+}
+
+// writeKotlinBlerpcInfoHelpers emits the schema check of the Kotlin client.
+func writeKotlinBlerpcInfoHelpers(b *strings.Builder, cmd gen.Command, pkg, pkgCap string) {
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Checks that the peripheral was built from this client's schema. Call\n")
+	b.WriteString("     * once on connect; throws [SchemaMismatchException] when the schema\n")
+	b.WriteString("     * hashes differ.\n")
+	b.WriteString("     */\n")
+	b.WriteString(fmt.Sprintf("    suspend fun verifySchema(): %s.%s.%s {\n", pkg, pkgCap, cmd.ResponseMsg))
+	b.WriteString(fmt.Sprintf("        val info = %s()\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("        if (info.schemaHash != SCHEMA_HASH) {\n")
+	b.WriteString("            throw SchemaMismatchException(SCHEMA_HASH, info.schemaHash, info.generatorVersion)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return info\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,66 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeKotlinBuiltinHelpers(b *strings.Builder, commands []gen.Command, pkg, pkgCap string) {
+	if cmd, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writeKotlinBlerpcInfoHelpers(b, cmd, pkg, pkgCap)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "capabilities"); ok {
+		writeKotlinCapabilitiesHelpers(b, cmd, commands)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "conn_params"); ok {
+		writeKotlinConnParamsHelpers(b, cmd, pkg, pkgCap)
+	}
+	if dfu, ok := gen.FirmwareUpdateCommands(commands); ok {
+		writeKotlinDfuHelpers(b, dfu)
+	}
+	if files, ok := gen.FileTransferCommands(commands); ok {
+		writeKotlinFileTransferHelpers(b, files)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "log_stream"); ok {
+		writeKotlinLogStreamHelpers(b, cmd, pkg, pkgCap)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "ping"); ok {
+		writeKotlinPingHelpers(b, cmd)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "rpc_stats"); ok {
+		writeKotlinRPCStatsHelpers(b, cmd, pkg, pkgCap)
+	}
+	if start, auth, ok := gen.SessionCommands(commands); ok {
+		writeKotlinSessionHelpers(b, start, auth, pkg, pkgCap)
+	}
+	writeKotlinSettingsHelpers(b, commands, pkg, pkgCap)
+	if cmd, ok := gen.BuiltinCommand(commands, "time_sync"); ok {
+		writeKotlinTimeSyncHelpers(b, cmd, pkg, pkgCap)
+	}
+}
+
+// writeKotlinConnParamsHelpers emits the connection profile helpers of the
+// Kotlin client.
+func writeKotlinConnParamsHelpers(b *strings.Builder, cmd gen.Command, pkg, pkgCap string) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	profile := pkg + "." + pkgCap + ".ConnProfile."
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Runs [block] on the fast connection profile, e.g. for a DFU, then\n")
+	b.WriteString("     * requests the balanced profile again.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun <T> withFastConnection(block: suspend () -> T): T {\n")
+	b.WriteString(fmt.Sprintf("        %s(profile = %sCONN_PROFILE_FAST_VALUE)\n", method, profile))
+	b.WriteString("        try {\n")
+	b.WriteString("            return block()\n")
+	b.WriteString("        } finally {\n")
+	b.WriteString(fmt.Sprintf("            %s(profile = %sCONN_PROFILE_BALANCED_VALUE)\n", method, profile))
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Requests the low-power profile for a connection that stays idle. */\n")
+	b.WriteString(fmt.Sprintf("    suspend fun useIdleConnection(): %s.%s.%s =\n", pkg, pkgCap, cmd.ResponseMsg))
+	b.WriteString(fmt.Sprintf("        %s(profile = %sCONN_PROFILE_LOW_POWER_VALUE)\n", method, profile))
+}
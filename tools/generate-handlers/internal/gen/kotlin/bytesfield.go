@@ -0,0 +1,41 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinPayloadTooLargeException emits the exception the Kotlin client
+// throws for a bounded bytes argument that is too long.
+func writeKotlinPayloadTooLargeException(b *strings.Builder) {
+	b.WriteString("/** A bytes argument exceeds the max_size the peripheral accepts. */\n")
+	b.WriteString("class PayloadTooLargeException(\n")
+	b.WriteString("    val command: String,\n")
+	b.WriteString("    val field: String,\n")
+	b.WriteString("    val size: Int,\n")
+	b.WriteString("    val maxSize: Int,\n")
+	b.WriteString(") : BlerpcException(\"$command: $field is $size bytes, over its max_size of $maxSize\")\n")
+	b.WriteByte('\n')
+}
+
+// writeKotlinSizeChecks emits the length checks of the bounded bytes and
+// string arguments of cmd.
+func writeKotlinSizeChecks(b *strings.Builder, cmd gen.Command) {
+	for _, f := range cmd.RequestFields {
+		if gen.BoundedString(f) {
+			writeKotlinStringCheck(b, cmd, f)
+		}
+		if !gen.BoundedBytes(f) {
+			continue
+		}
+		cond := fmt.Sprintf("%s.size() > %d", f.Name, f.MaxSize)
+		if gen.NullableField(f) {
+			cond = f.Name + " != null && " + cond
+		}
+		b.WriteString(fmt.Sprintf("        if (%s) {\n", cond))
+		b.WriteString(fmt.Sprintf("            throw PayloadTooLargeException(\"%s\", \"%s\", %s.size(), %d)\n", cmd.Snake, f.Name, f.Name, f.MaxSize))
+		b.WriteString("        }\n")
+	}
+}
@@ -0,0 +1,44 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateCache returns CachingClient.kt, placed next to the generated
+// client.
+func GenerateCache(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	cacheable := gen.CacheableCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import kotlinx.coroutines.CoroutineScope\n")
+	b.WriteString("import kotlinx.coroutines.Job\n")
+	b.WriteString("import kotlinx.coroutines.flow.StateFlow\n")
+	b.WriteString("import kotlinx.coroutines.launch\n")
+	b.WriteString("import java.nio.ByteBuffer\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Milliseconds a response of each cacheable command is served from memory,\n")
+	b.WriteString(" * by the names its calls are sent by.\n")
+	b.WriteString(" */\n")
+	if len(cacheable) == 0 {
+		b.WriteString("val CACHE_TTLS_MS: Map<String, Long> = emptyMap()\n")
+	} else {
+		b.WriteString("val CACHE_TTLS_MS: Map<String, Long> =\n")
+		b.WriteString("    mapOf(\n")
+		for _, cmd := range cacheable {
+			for _, name := range gen.CacheCallNames(cmd, "kotlin") {
+				b.WriteString(fmt.Sprintf("        %s to %dL,\n", name, cmd.CacheTTLMs))
+			}
+		}
+		b.WriteString("    )\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("CachingClient.kt.tmpl", nil))
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinCallPolicies emits the call policy class and table.
+func writeKotlinCallPolicies(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("/** Timeout of each attempt of a command and how often it is retried. */\n")
+	b.WriteString("class CallPolicy(val timeoutMs: Long, val retries: Int)\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Call policies by command. Commands without one wait as long as the\n")
+	b.WriteString(" * transport does and are not retried.\n")
+	b.WriteString(" */\n")
+	b.WriteString("val CALL_POLICIES: Map<String, CallPolicy> = mapOf(\n")
+	for _, cmd := range commands {
+		if cmd.TimeoutMs > 0 {
+			b.WriteString(fmt.Sprintf("    \"%s\" to CallPolicy(%d, %d),\n", cmd.Snake, cmd.TimeoutMs, cmd.Retries))
+		}
+	}
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+}
+
+// writeKotlinCallPolicyHelper emits the GeneratedClient member running a
+// call under a policy.
+func writeKotlinCallPolicyHelper(b *strings.Builder) {
+	b.WriteString("    /**\n")
+	b.WriteString("     * Runs [block] under the call policy of [command]: each attempt fails with\n")
+	b.WriteString("     * a [TimeoutException] after the timeout, and attempts that time out or\n")
+	b.WriteString("     * fail with a [TransportException] are made again up to the retries.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    protected suspend fun <T> withCallPolicy(command: String, block: suspend () -> T): T {\n")
+	b.WriteString("        val policy = CALL_POLICIES.getValue(command)\n")
+	b.WriteString("        var attempt = 0\n")
+	b.WriteString("        while (true) {\n")
+	b.WriteString("            try {\n")
+	b.WriteString("                return try {\n")
+	b.WriteString("                    withTimeout(policy.timeoutMs) { block() }\n")
+	b.WriteString("                } catch (e: TimeoutCancellationException) {\n")
+	b.WriteString("                    throw TimeoutException(\"$command: no response within ${policy.timeoutMs} ms\", e)\n")
+	b.WriteString("                }\n")
+	b.WriteString("            } catch (e: TransportException) {\n")
+	b.WriteString("                if (attempt++ == policy.retries) throw e\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+}
+
+// PolicyCall renders the expression calling a unary command, in a
+// session when it is session protected, in the compression envelope when it
+// is compressed, and under its call policy when it has one.
+func PolicyCall(cmd gen.Command, reqData string) string {
+	fn, args := "call", []string{gen.CallName(cmd, "kotlin"), reqData}
+	if cmd.SessionProtected {
+		fn = "sessionCall"
+	}
+	if cmd.Compression != "" {
+		fn, args = "compressedCall", append([]string{"\"" + cmd.Compression + "\""}, args...)
+	}
+	call := fmt.Sprintf("%s(%s)", fn, strings.Join(args, ", "))
+	if cmd.TimeoutMs == 0 {
+		return call
+	}
+	return fmt.Sprintf("withCallPolicy(\"%s\") { %s }", cmd.Snake, call)
+}
@@ -0,0 +1,34 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinCancelContainer emits the Cancel control command and the
+// function serializing its container.
+func writeKotlinCancelContainer(b *strings.Builder) {
+	b.WriteString("/**\n")
+	b.WriteString(" * Control command of the Cancel container, which stops the P→C stream in\n")
+	b.WriteString(" * progress on the peripheral. The protocol library has no constant for it.\n")
+	b.WriteString(" */\n")
+	b.WriteString(fmt.Sprintf("const val CONTROL_CMD_CANCEL = 0x%x\n", gen.ControlCmdCancel))
+	b.WriteByte('\n')
+	b.WriteString("/** Serializes a Cancel control container, which carries no payload. */\n")
+	b.WriteString("fun cancelContainer(transactionId: Int): ByteArray =\n")
+	b.WriteString("    byteArrayOf(transactionId.toByte(), 0, (0xC0 or (CONTROL_CMD_CANCEL shl 2)).toByte(), 0)\n")
+	b.WriteByte('\n')
+}
+
+// writeKotlinStreamCancel emits the default streamCancel of GeneratedClient.
+func writeKotlinStreamCancel(b *strings.Builder) {
+	b.WriteString("    /**\n")
+	b.WriteString("     * Stops the P→C stream in progress, whose collector stopped early. The\n")
+	b.WriteString("     * default does nothing and the peripheral runs the stream to its end;\n")
+	b.WriteString("     * override to send a [cancelContainer] and drain the stream.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    open suspend fun streamCancel() {}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,42 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinCapabilitiesHelpers emits the capability helpers of the Kotlin
+// client.
+func writeKotlinCapabilitiesHelpers(b *strings.Builder, cmd gen.Command, commands []gen.Command) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Returns the names of the commands the firmware implements; commands it\n")
+	b.WriteString("     * lacks, or leaves on their stubs, are missing. Query it once on connect\n")
+	b.WriteString("     * and check the set before calling commands some firmware versions lack.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun supportedCommands(): Set<String> {\n")
+	b.WriteString(fmt.Sprintf("        val resp = %s()\n", method))
+	if cmd.ID != 0 {
+		b.WriteString("        val bitmap = resp.commandIds.toByteArray()\n")
+		b.WriteString("        val ids = mapOf(\n")
+		for _, c := range commands {
+			b.WriteString(fmt.Sprintf("            \"%s\" to %d,\n", c.Snake, c.ID))
+		}
+		b.WriteString("        )\n")
+		b.WriteString("        return ids.filterValues { it / 8 < bitmap.size && (bitmap[it / 8].toInt() shr (it % 8)) and 1 != 0 }.keys\n")
+	} else {
+		b.WriteString("        val names = mapOf(\n")
+		for _, c := range commands {
+			b.WriteString(fmt.Sprintf("            \"%s\" to \"%s\",\n", c.Wire(), c.Snake))
+		}
+		b.WriteString("        )\n")
+		b.WriteString("        return resp.namesList.mapNotNull { names[it] }.toSet()\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Reports whether the firmware implements [command], e.g. \"echo\". */\n")
+	b.WriteString("    suspend fun supports(command: String): Boolean = command in supportedCommands()\n")
+}
@@ -0,0 +1,207 @@
+// Package kotlin generates the Kotlin Android client.
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// kotlinParam renders a method parameter. proto2 required fields have no
+// default so callers must pass them.
+func kotlinParam(f gen.Field, pkg string) string {
+	if o, ok := gen.FieldTypeOverride(f, "kotlin"); ok {
+		if o.Default == "" {
+			return fmt.Sprintf("%s: %s", f.Name, o.Type)
+		}
+		return fmt.Sprintf("%s: %s = %s", f.Name, o.Type, o.Default)
+	}
+	if f.IsRequired {
+		return fmt.Sprintf("%s: %s", f.Name, ResolveType(f, pkg))
+	}
+	if gen.NullableField(f) {
+		return fmt.Sprintf("%s: %s? = null", f.Name, ResolveType(f, pkg))
+	}
+	return fmt.Sprintf("%s: %s = %s", f.Name, ResolveType(f, pkg), ResolveDefault(f, pkg))
+}
+
+// clientData fills GeneratedClient.kt.tmpl. The sections of the features,
+// such as call policies, compression and the built-ins, are written in Go.
+type clientData struct {
+	Package                      string
+	ServerStreams, ClientStreams bool
+	CallPolicies, Ping           bool
+	Compressed, Replay, MAC      bool
+	Imports                      []string // the imports of the type overrides
+	Preamble                     string   // top-level declarations before the class
+	CallHelpers                  string   // the call policy and compression helpers
+	StreamCancel                 string
+	Methods                      []clientMethod
+	Aliases                      []clientAlias
+	Helpers                      string // the built-in helpers, inside the class
+	Trailer                      string // the declarations after the class
+}
+
+// clientMethod is the method of a command. Stream is "p2c", "c2p" or empty
+// for a unary command. Deprecation, SizeChecks and Setters are whole lines;
+// Call is the expression calling a unary command.
+type clientMethod struct {
+	Name, Snake, Request, Response, CallName string
+	Stream                                   string
+	Params                                   string
+	Deprecation, SizeChecks, Setters         string
+	Call                                     string
+	StatusCheck                              string
+}
+
+// clientAlias is the deprecated method under the old name of a renamed
+// command, calling Target.
+type clientAlias struct {
+	Name, Target, Params, Returns, Call string
+}
+
+// GenerateClient returns GeneratedClient.kt: the abstract client class with
+// a method per command.
+func GenerateClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	// Capitalize package name for Java outer class name
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	_, ping := gen.BuiltinCommand(commands, "ping")
+	_, _, sessions := gen.SessionCommands(commands)
+	d := clientData{
+		Package:       gctx.KotlinPackage(pkg),
+		ServerStreams: gen.HasServerStreams(commands, streaming),
+		ClientStreams: gen.HasClientStreams(commands, streaming),
+		CallPolicies:  gen.HasCallPolicies(commands),
+		Ping:          ping,
+		Compressed:    gen.HasCompressed(commands),
+		Replay:        gen.HasReplayProtected(commands),
+		MAC:           sessions || gen.HasReplayProtected(commands),
+		Imports:       gen.OverrideImports(commands, "kotlin"),
+	}
+
+	var b strings.Builder
+	writeKotlinErrors(gctx, &b, commands)
+	if d.Replay {
+		writeKotlinReplayCounter(&b)
+	}
+	if gen.HasCommandIDs(commands) {
+		writeKotlinCommandIDs(&b, commands)
+	}
+	if d.CallPolicies {
+		writeKotlinCallPolicies(&b, commands)
+	}
+	if d.Compressed {
+		WriteCompression(&b)
+	}
+	if d.ServerStreams {
+		writeKotlinCancelContainer(&b)
+	}
+	d.Preamble = b.String()
+
+	b.Reset()
+	if d.CallPolicies {
+		writeKotlinCallPolicyHelper(&b)
+	}
+	if d.Compressed {
+		WriteCompressedCall(&b)
+	}
+	d.CallHelpers = b.String()
+	if d.ServerStreams {
+		b.Reset()
+		writeKotlinStreamCancel(&b)
+		d.StreamCancel = b.String()
+	}
+
+	for _, stream := range []bool{false, true} {
+		for _, cmd := range commands {
+			dir, ok := streaming[cmd.Snake]
+			if ok != stream {
+				continue
+			}
+			m := clientMethod{
+				Name:        gen.ToLowerCamel(cmd.Camel),
+				Snake:       cmd.Snake,
+				Request:     pkg + "." + pkgCap + "." + cmd.RequestMsg,
+				Response:    pkg + "." + pkgCap + "." + cmd.ResponseMsg,
+				CallName:    gen.CallName(cmd, "kotlin"),
+				Stream:      dir,
+				Deprecation: Deprecation(cmd, dir != "c2p"),
+				StatusCheck: strings.TrimSpace(kotlinStatusCheck(cmd, "")),
+			}
+			if dir != "c2p" {
+				m.Params = strings.Join(kotlinParams(cmd.RequestFields, cmd.RequestMsg, pkg), ", ")
+				b.Reset()
+				writeKotlinSizeChecks(&b, cmd)
+				m.SizeChecks = b.String()
+				b.Reset()
+				writeKotlinSetters(&b, cmd.RequestFields, cmd.RequestMsg)
+				m.Setters = b.String()
+			}
+			if dir == "" {
+				reqData := "req.toByteArray()"
+				if cmd.ReplayProtected {
+					reqData = fmt.Sprintf("ReplayCounter.prefix(sessionKey, \"%s\", %s)", cmd.Wire(), reqData)
+				}
+				m.Call = PolicyCall(cmd, reqData)
+			}
+			d.Methods = append(d.Methods, m)
+		}
+	}
+
+	// Deprecated aliases for renamed commands
+	for _, cmd := range commands {
+		if cmd.RenamedFrom == "" {
+			continue
+		}
+		a := clientAlias{
+			Name:    gen.ToLowerCamel(cmd.RenamedFrom),
+			Target:  gen.ToLowerCamel(cmd.Camel),
+			Returns: pkg + "." + pkgCap + "." + cmd.ResponseMsg,
+		}
+		var params, args []string
+		switch streaming[cmd.Snake] {
+		case "c2p":
+			params = []string{fmt.Sprintf("messages: List<%s.%s.%s>", pkg, pkgCap, cmd.RequestMsg)}
+			args = []string{"messages = messages"}
+		case "p2c":
+			a.Returns = "List<" + a.Returns + ">"
+			fallthrough
+		default:
+			params = kotlinParams(cmd.RequestFields, cmd.RequestMsg, pkg)
+			for _, name := range gen.ParamNames(cmd.RequestFields) {
+				args = append(args, name+" = "+name)
+			}
+		}
+		a.Params = strings.Join(params, ", ")
+		a.Call = fmt.Sprintf("%s(%s)", a.Target, strings.Join(args, ", "))
+		d.Aliases = append(d.Aliases, a)
+	}
+
+	b.Reset()
+	writeKotlinUnwrapped(&b, commands, pkg)
+	writeKotlinProperties(&b, commands, pkg)
+	writeKotlinBuiltinHelpers(&b, commands, pkg, pkgCap)
+	d.Helpers = b.String()
+
+	b.Reset()
+	writeKotlinOneofs(&b, commands, streaming, pkg, pkgCap)
+
+	// Typed accessors for mapped response fields
+	order, byMsg := gen.MappedResponseFields(commands, "kotlin")
+	for _, msg := range order {
+		for _, f := range byMsg[msg] {
+			o, _ := gen.FieldTypeOverride(f, "kotlin")
+			prop := gen.SwiftPropertyName(f.Name)
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("val %s.%s.%s.%sTyped: %s\n", pkg, pkgCap, msg, prop, o.Type))
+			b.WriteString(fmt.Sprintf("    get() = %s\n", gen.ApplyConverter(o.Decode, prop)))
+		}
+	}
+	WriteRemovedFields(&b, commands, pkg, pkgCap)
+	writeKotlinCharacteristics(&b, commands)
+	writeKotlinRoles(&b, commands)
+	d.Trailer = b.String()
+
+	return gctx.RenderTemplate("GeneratedClient.kt.tmpl", d)
+}
@@ -0,0 +1,275 @@
+package kotlin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateKotlinClient_Echo(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"abstract class GeneratedClient",
+		`open suspend fun echo(message: String = "")`,
+		"blerpc.Blerpc.EchoRequest.newBuilder()",
+		".setMessage(message)",
+		`val respData = exclusive { call("echo"`,
+		"    private val rpcLock = Mutex()\n",
+		"blerpc.Blerpc.EchoResponse.parseFrom",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client missing %q", s)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_CustomPkg(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "myapp")
+
+	mustContain := []string{
+		"package com.myapp.android.client",
+		"myapp.Myapp.EchoRequest.newBuilder()",
+		"myapp.Myapp.EchoResponse.parseFrom",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client custom pkg missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "blerpc") {
+		t.Errorf("Kotlin client custom pkg should not contain 'blerpc'")
+	}
+}
+
+func TestGenerateKotlinClient_MessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MessageFieldCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"address: blerpc.Blerpc.Address = blerpc.Blerpc.Address.getDefaultInstance()",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client message field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_NestedMessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.NestedMessageCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"tag: blerpc.Blerpc.Outer.Inner = blerpc.Blerpc.Outer.Inner.getDefaultInstance()",
+		"path: List<blerpc.Blerpc.Point> = emptyList()",
+		"named: Map<String, blerpc.Blerpc.Point> = emptyMap()",
+		".addAllPath(path)",
+		".putAllNamed(named)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client nested message field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_Map(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MapCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"labels: Map<String, String> = emptyMap()",
+		"counts: Map<String, Int> = emptyMap()",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client map missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_Repeated(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.RepeatedCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"names: List<String> = emptyList()",
+		"ids: List<Int> = emptyList()",
+		".addAllNames(names)",
+		".addAllIds(ids)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client repeated missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_Oneof(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.OneofCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"open suspend fun search(limit: Int = 0, query: SearchRequestQuery? = null): blerpc.Blerpc.SearchResponse {",
+		"                    is SearchRequestQuery.UserId -> setUserId(query.value)\n                    null -> {}\n",
+		"sealed class SearchRequestQuery {\n    data class Text(val value: String) : SearchRequestQuery()\n",
+		"val blerpc.Blerpc.SearchResponse.resultOneOf: SearchResponseResult?\n    get() = when (resultCase) {\n",
+		"blerpc.Blerpc.SearchResponse.ResultCase.LEVEL -> SearchResponseResult.Level(levelValue)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client oneof missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "text: String") {
+		t.Error("oneof members should not be separate parameters")
+	}
+}
+
+func TestGenerateKotlinClient_ImportedMessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.MessageFieldCommand()
+	cmd.RequestFields[1].TypeFile = "common/shared_types"
+	out := GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc")
+
+	want := "address: blerpc.SharedTypes.Address = blerpc.SharedTypes.Address.getDefaultInstance()"
+	if !strings.Contains(out, want) {
+		t.Errorf("Kotlin client should name the outer class after the defining file, missing %q\nGot:\n%s", want, out)
+	}
+}
+
+func TestGenerateKotlinClient_Enum(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EnumCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	// enum → Int type, default 0
+	if !strings.Contains(out, "name: String") {
+		t.Errorf("Kotlin client enum missing name param\nGot:\n%s", out)
+	}
+}
+
+func TestGenerateKotlinClient_StreamP2C(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"open suspend fun counterStream(",
+		"List<blerpc.Blerpc.CounterStreamResponse>",
+		"streamReceive(",
+		".map {",
+		"parseFrom(data)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client p2c missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_StreamC2P(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_upload": "c2p"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"open suspend fun counterUpload(",
+		"messages: List<blerpc.Blerpc.CounterUploadRequest>",
+		"streamSend(",
+		"it.toByteArray()",
+		"decode(\"counter_upload\", respData)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client c2p missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_Proto2(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.Proto2Command()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	want := `open suspend fun configure(address: Int, label: String = "dev")`
+	if !strings.Contains(out, want) {
+		t.Errorf("Kotlin client proto2 missing %q\nGot:\n%s", want, out)
+	}
+}
+
+func TestGenerateKotlinClient_Proto3Optional(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.OptionalCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"open suspend fun list(limit: Int? = null, offset: Int = 0)",
+		".apply { if (limit != null) setLimit(limit) }",
+		".setOffset(offset)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client optional field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_RenamedFrom(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.RenamedFrom = "Say"
+	upload := gentest.StreamC2PCommand()
+	upload.RenamedFrom = "Upload"
+	streaming := map[string]string{"counter_upload": "c2p"}
+	out := GenerateClient(gctx, []gen.Command{echo, upload}, streaming, "blerpc")
+
+	mustContain := []string{
+		`@Deprecated("Renamed to echo", ReplaceWith("echo(message = message)"))`,
+		`suspend fun say(message: String = ""): blerpc.Blerpc.EchoResponse = echo(message = message)`,
+		`suspend fun upload(messages: List<blerpc.Blerpc.CounterUploadRequest>): blerpc.Blerpc.CounterUploadResponse = counterUpload(messages = messages)`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_TypeOverride(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	mac := map[string]gen.TypeOverride{"kotlin": {
+		Type:   "MacAddress",
+		Encode: "{}.toByteString()",
+		Decode: "MacAddress({})",
+		Import: "import com.example.MacAddress",
+	}}
+	cmd.RequestFields[0].TypeOverrides = mac
+	cmd.ResponseFields[0].TypeOverrides = mac
+	out := GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc")
+
+	mustContain := []string{
+		"import com.example.MacAddress\n",
+		"open suspend fun echo(message: MacAddress): blerpc.Blerpc.EchoResponse {",
+		".setMessage(message.toByteString())",
+		"val blerpc.Blerpc.EchoResponse.messageTyped: MacAddress\n    get() = MacAddress(message)\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
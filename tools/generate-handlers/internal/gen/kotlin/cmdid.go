@@ -0,0 +1,25 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeKotlinCommandIDs(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("/** Numeric command IDs, sent as one-character command names. */\n")
+	b.WriteString("enum class CommandId(val id: Int) {\n")
+	for i, cmd := range commands {
+		sep := ","
+		if i == len(commands)-1 {
+			sep = ";"
+		}
+		b.WriteString(fmt.Sprintf("    %s(%d)%s\n", strings.ToUpper(cmd.Snake), cmd.ID, sep))
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /** The command name carrying this ID. */\n")
+	b.WriteString("    val wireName: String get() = Char(id).toString()\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,106 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// WriteCompression emits the codecs of the Kotlin client.
+func WriteCompression(b *strings.Builder) {
+	b.WriteString("/** CAPABILITIES flag of a peripheral answering compressed calls. */\n")
+	b.WriteString(fmt.Sprintf("const val CAPABILITY_FLAG_COMPRESSION = 0x%04X\n", gen.CapabilityFlagCompression))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("private const val COMPRESSED_COMMAND = \"%s\"\n", gen.CompressedCommandName))
+	b.WriteString("private const val FLAG_COMPRESSED = 0x01\n")
+	b.WriteString("private const val FLAG_ACCEPT_COMPRESSED = 0x02\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Compresses and decompresses the data of compressed calls. */\n")
+	b.WriteString("interface CompressionCodec {\n")
+	b.WriteString("    fun compress(data: ByteArray): ByteArray\n")
+	b.WriteByte('\n')
+	b.WriteString("    fun decompress(data: ByteArray): ByteArray\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Raw DEFLATE (RFC 1951). */\n")
+	b.WriteString("object DeflateCodec : CompressionCodec {\n")
+	b.WriteString("    override fun compress(data: ByteArray): ByteArray {\n")
+	b.WriteString("        val deflater = Deflater(Deflater.BEST_COMPRESSION, true)\n")
+	b.WriteString("        try {\n")
+	b.WriteString("            deflater.setInput(data)\n")
+	b.WriteString("            deflater.finish()\n")
+	b.WriteString("            val out = ByteArrayOutputStream()\n")
+	b.WriteString("            val buf = ByteArray(512)\n")
+	b.WriteString("            while (!deflater.finished()) {\n")
+	b.WriteString("                out.write(buf, 0, deflater.deflate(buf))\n")
+	b.WriteString("            }\n")
+	b.WriteString("            return out.toByteArray()\n")
+	b.WriteString("        } finally {\n")
+	b.WriteString("            deflater.end()\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override fun decompress(data: ByteArray): ByteArray {\n")
+	b.WriteString("        val inflater = Inflater(true)\n")
+	b.WriteString("        try {\n")
+	b.WriteString("            inflater.setInput(data)\n")
+	b.WriteString("            val out = ByteArrayOutputStream()\n")
+	b.WriteString("            val buf = ByteArray(512)\n")
+	b.WriteString("            while (!inflater.finished()) {\n")
+	b.WriteString("                val n = inflater.inflate(buf)\n")
+	b.WriteString("                if (n == 0 && inflater.needsInput()) throw DataFormatException(\"truncated data\")\n")
+	b.WriteString("                out.write(buf, 0, n)\n")
+	b.WriteString("            }\n")
+	b.WriteString("            return out.toByteArray()\n")
+	b.WriteString("        } finally {\n")
+	b.WriteString("            inflater.end()\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Codecs by algorithm. DEFLATE is built in; register a heatshrink codec\n")
+	b.WriteString(" * (window 8, lookahead 4 bits) to compress the heatshrink commands too.\n")
+	b.WriteString(" * Calls of a command whose algorithm has no codec are sent uncompressed.\n")
+	b.WriteString(" */\n")
+	b.WriteString("object CompressionCodecs {\n")
+	b.WriteString("    val byAlgorithm: MutableMap<String, CompressionCodec> = ConcurrentHashMap(mapOf(\"deflate\" to DeflateCodec))\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// WriteCompressedCall emits the GeneratedClient member sending a
+// compressed call.
+func WriteCompressedCall(b *strings.Builder) {
+	b.WriteString("    /**\n")
+	b.WriteString("     * Sends a call of a compressed command in the compression envelope when\n")
+	b.WriteString("     * the peripheral advertises [CAPABILITY_FLAG_COMPRESSION] and\n")
+	b.WriteString("     * [CompressionCodecs] has a codec for [algorithm], compressing the request\n")
+	b.WriteString("     * when that makes it smaller, and returns the response decompressed;\n")
+	b.WriteString("     * sends a plain call otherwise.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    protected suspend fun compressedCall(algorithm: String, cmdName: String, requestData: ByteArray): ByteArray {\n")
+	b.WriteString("        val codec = CompressionCodecs.byAlgorithm[algorithm]\n")
+	b.WriteString("        if (codec == null || capabilityFlags and CAPABILITY_FLAG_COMPRESSION == 0) {\n")
+	b.WriteString("            return call(cmdName, requestData)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        val packed = codec.compress(requestData)\n")
+	b.WriteString("        val compressed = packed.size < requestData.size\n")
+	b.WriteString("        val flags = if (compressed) FLAG_COMPRESSED or FLAG_ACCEPT_COMPRESSED else FLAG_ACCEPT_COMPRESSED\n")
+	b.WriteString("        val name = cmdName.toByteArray()\n")
+	b.WriteString("        val envelope =\n")
+	b.WriteString("            byteArrayOf(name.size.toByte()) + name + byteArrayOf(flags.toByte()) +\n")
+	b.WriteString("                if (compressed) packed else requestData\n")
+	b.WriteString("        val respData = call(COMPRESSED_COMMAND, envelope)\n")
+	b.WriteString("        if (respData.isEmpty()) throw DecodeException(cmdName, DataFormatException(\"empty response\"))\n")
+	b.WriteString("        val data = respData.copyOfRange(1, respData.size)\n")
+	b.WriteString("        if (respData[0].toInt() and FLAG_COMPRESSED == 0) return data\n")
+	b.WriteString("        return try {\n")
+	b.WriteString("            codec.decompress(data)\n")
+	b.WriteString("        } catch (e: Exception) {\n")
+	b.WriteString("            throw DecodeException(cmdName, e)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,62 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// StringLiteral quotes a command name, escaping a one-byte ID.
+func StringLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		if c < 0x20 || c >= 0x7F {
+			fmt.Fprintf(&b, `\u%04x`, c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// GenerateLoopback returns LoopbackClient.kt with the vectors and the
+// message tables of the commands they cover.
+func GenerateLoopback(gctx *gen.Context, commands []gen.Command, suite gen.ConformanceSuite, pkg string) string {
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	var b strings.Builder
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteString(gctx.RenderTemplate("LoopbackClient.kt.tmpl", nil))
+	b.WriteByte('\n')
+	b.WriteString("/** The vectors of conformance/vectors.json. */\n")
+	b.WriteString("val conformanceVectors = listOf(\n")
+	for _, v := range suite.Vectors {
+		b.WriteString("    ConformanceVector(\n")
+		b.WriteString(fmt.Sprintf("        %q, %s, %q, %d,\n", v.Command, StringLiteral(v.WireName), v.Stream, v.MTU))
+		b.WriteString(fmt.Sprintf("        request = %q,\n", v.Request))
+		b.WriteString(fmt.Sprintf("        response = %q,\n", v.Response))
+		b.WriteString(fmt.Sprintf("        requestContainers = listOf(%s),\n", gen.ConformanceStringList(v.RequestContainers)))
+		b.WriteString(fmt.Sprintf("        responseContainers = listOf(%s),\n", gen.ConformanceStringList(v.ResponseContainers)))
+		b.WriteString("    ),\n")
+	}
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+	covered := gen.ConformanceCommands(commands, suite)
+	b.WriteString("private fun conformanceRequest(command: String, data: ByteArray): MessageLite = when (command) {\n")
+	for _, cmd := range covered {
+		b.WriteString(fmt.Sprintf("    \"%s\" -> %s.%s.%s.parseFrom(data)\n", cmd.Snake, pkg, pkgCap, cmd.RequestMsg))
+	}
+	b.WriteString("    else -> throw IllegalArgumentException(\"unknown command $command\")\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("private fun conformanceResponse(command: String, data: ByteArray): MessageLite = when (command) {\n")
+	for _, cmd := range covered {
+		b.WriteString(fmt.Sprintf("    \"%s\" -> %s.%s.%s.parseFrom(data)\n", cmd.Snake, pkg, pkgCap, cmd.ResponseMsg))
+	}
+	b.WriteString("    else -> throw IllegalArgumentException(\"unknown command $command\")\n")
+	b.WriteString("}\n")
+	return b.String()
+}
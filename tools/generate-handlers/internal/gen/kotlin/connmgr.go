@@ -0,0 +1,12 @@
+package kotlin
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateConnectionManager returns ConnectionManager.kt.
+func GenerateConnectionManager(gctx *gen.Context, pkg string) string {
+	return "/* Auto-generated by generate-handlers — DO NOT EDIT */\n" +
+		"package " + gctx.KotlinPackage(pkg) + "\n" +
+		gctx.RenderTemplate("ConnectionManager.kt.tmpl", nil)
+}
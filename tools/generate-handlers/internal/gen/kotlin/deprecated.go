@@ -0,0 +1,27 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Deprecation returns the annotations of a client method of cmd, with
+// params one taking the request fields. protoc deprecates the setters of
+// deprecated fields, and the classes of deprecated messages, in the Java
+// code too, so the method suppresses those warnings.
+func Deprecation(cmd gen.Command, params bool) string {
+	var b strings.Builder
+	fields := gen.DeprecatedFields(cmd)
+	if params && len(fields) > 0 {
+		fmt.Fprintf(&b, "    /** Deprecated request fields: %s. */\n", gen.CodeList(fields))
+	}
+	if cmd.Deprecated {
+		fmt.Fprintf(&b, "    @Deprecated(\"%s is deprecated in the schema\")\n", cmd.Snake)
+	}
+	if cmd.Deprecated || params && len(fields) > 0 {
+		b.WriteString("    @Suppress(\"DEPRECATION\")\n")
+	}
+	return b.String()
+}
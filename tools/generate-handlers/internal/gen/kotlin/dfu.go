@@ -0,0 +1,42 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinDfuHelpers emits the firmware update helper of the Kotlin
+// client.
+func writeKotlinDfuHelpers(b *strings.Builder, dfu gen.DFUCommands) {
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Writes the firmware image at [path] to the peripheral and applies it.\n")
+	b.WriteString("     * An update interrupted before it was applied continues after the bytes\n")
+	b.WriteString("     * the peripheral already has when called again with the same image.\n")
+	b.WriteString("     * [progress] is called with the bytes sent and the total after each\n")
+	b.WriteString("     * chunk. With [reboot], the peripheral restarts into the image once it is\n")
+	b.WriteString("     * verified.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun updateFirmware(\n")
+	b.WriteString("        path: String,\n")
+	b.WriteString("        progress: ((Int, Int) -> Unit)? = null,\n")
+	b.WriteString("        reboot: Boolean = true,\n")
+	b.WriteString("    ) {\n")
+	b.WriteString("        val image = java.io.File(path).readBytes()\n")
+	b.WriteString("        val crc = java.util.zip.CRC32().apply { update(image) }.value.toInt()\n")
+	b.WriteString(fmt.Sprintf("        val begun = %s(size = image.size, crc32 = crc)\n", gen.ToLowerCamel(dfu.Begin.Camel)))
+	b.WriteString("        var offset = begun.offset\n")
+	b.WriteString("        val chunkSize = if (begun.maxChunk > 0) begun.maxChunk else 128\n")
+	b.WriteString("        while (offset < image.size) {\n")
+	b.WriteString("            val end = minOf(offset + chunkSize, image.size)\n")
+	b.WriteString(fmt.Sprintf("            val resp = %s(offset = offset, data = ByteString.copyFrom(image, offset, end - offset))\n", gen.ToLowerCamel(dfu.Chunk.Camel)))
+	b.WriteString("            if (resp.offset == offset) throw BlerpcException(\"$path: update stalled at byte $offset\")\n")
+	b.WriteString("            offset = resp.offset\n")
+	b.WriteString("            progress?.invoke(offset, image.size)\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        val done = %s(reboot = reboot)\n", gen.ToLowerCamel(dfu.Finalize.Camel)))
+	b.WriteString("        if (done.crc32 != crc) throw BlerpcException(\"$path: update failed verification\")\n")
+	b.WriteString("    }\n")
+}
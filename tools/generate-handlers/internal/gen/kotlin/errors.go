@@ -0,0 +1,43 @@
+package kotlin
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeKotlinErrors(gctx *gen.Context, b *strings.Builder, commands []gen.Command) {
+	b.WriteString("/** Base class of errors thrown by generated client methods. */\n")
+	b.WriteString("open class BlerpcException(message: String, cause: Throwable? = null) : Exception(message, cause)\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The request could not be delivered or the response was lost. */\n")
+	b.WriteString("open class TransportException(message: String, cause: Throwable? = null) : BlerpcException(message, cause)\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The peripheral did not respond in time. */\n")
+	b.WriteString("class TimeoutException(message: String, cause: Throwable? = null) : TransportException(message, cause)\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The response payload is not a valid message. */\n")
+	b.WriteString("class DecodeException(val command: String, cause: Throwable) :\n")
+	b.WriteString("    BlerpcException(\"$command: invalid response\", cause)\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The peripheral reported a non-OK status. */\n")
+	b.WriteString("open class RemoteException(\n")
+	b.WriteString("    val command: String,\n")
+	b.WriteString("    val status: Int,\n")
+	b.WriteString("    message: String = \"$command failed: status=$status\",\n")
+	b.WriteString(") : BlerpcException(message)\n")
+	b.WriteByte('\n')
+	writeKotlinStatusCodes(b)
+	if gen.HasStatusChecks(commands) {
+		writeKotlinStatusException(b)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writeKotlinSchemaMismatchException(gctx, b)
+	}
+	if gen.HasBoundedBytesRequests(commands) {
+		writeKotlinPayloadTooLargeException(b)
+	}
+	if gen.HasBoundedStringRequests(commands) {
+		writeKotlinStringTooLongException(b)
+	}
+}
@@ -0,0 +1,31 @@
+package kotlin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateKotlinClient_Errors(t *testing.T) {
+	gctx := gen.NewContext()
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}, streaming, "blerpc")
+
+	mustContain := []string{
+		"open class BlerpcException(",
+		"open class TransportException(message: String, cause: Throwable? = null) : BlerpcException(message, cause)",
+		"class TimeoutException(message: String, cause: Throwable? = null) : TransportException(message, cause)",
+		"class DecodeException(val command: String, cause: Throwable) :",
+		"open class RemoteException(",
+		"        } catch (e: InvalidProtocolBufferException) {\n            throw DecodeException(command, e)\n",
+		"        return decode(\"echo\", respData) { blerpc.Blerpc.EchoResponse.parseFrom(it) }\n",
+		"responses.map { decode(\"counter_stream\", it) { data -> blerpc.Blerpc.CounterStreamResponse.parseFrom(data) } }",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
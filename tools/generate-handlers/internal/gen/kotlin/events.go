@@ -0,0 +1,34 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateEvents(gctx *gen.Context, events []gen.Event, pkg string) string {
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import com.google.protobuf.InvalidProtocolBufferException\n")
+	b.WriteString("import kotlinx.coroutines.flow.Flow\n")
+	b.WriteString("import kotlinx.coroutines.flow.map\n")
+	for _, ev := range events {
+		cls := fmt.Sprintf("%s.%s.%s", pkg, pkgCap, ev.Message.Name)
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("/** The [%s]s the peripheral notifies; collect to subscribe. */\n", cls))
+		b.WriteString(fmt.Sprintf("fun BlerpcClient.subscribe%s(): Flow<%s> =\n", ev.Message.Name, cls))
+		b.WriteString(fmt.Sprintf("    events(\"%s\").map { data ->\n", ev.Snake))
+		b.WriteString("        try {\n")
+		b.WriteString(fmt.Sprintf("            %s.parseFrom(data)\n", cls))
+		b.WriteString("        } catch (e: InvalidProtocolBufferException) {\n")
+		b.WriteString(fmt.Sprintf("            throw DecodeException(\"%s\", e)\n", ev.Snake))
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+	}
+	return b.String()
+}
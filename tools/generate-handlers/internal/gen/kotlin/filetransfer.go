@@ -0,0 +1,96 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinFileTransferHelpers emits the upload and download helpers of
+// the Kotlin client.
+func writeKotlinFileTransferHelpers(b *strings.Builder, files gen.FileCommands) {
+	open := gen.ToLowerCamel(files.Open.Camel)
+	read := gen.ToLowerCamel(files.Read.Camel)
+	write := gen.ToLowerCamel(files.Write.Camel)
+	closeFn := gen.ToLowerCamel(files.Close.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Writes [data] to [path] on the peripheral and verifies it by CRC-32. With\n")
+	b.WriteString("     * [resume], an interrupted upload continues after the bytes already\n")
+	b.WriteString("     * written, if they match the start of [data]. [progress] is called with\n")
+	b.WriteString("     * the bytes sent and the total after each chunk.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun uploadFile(\n")
+	b.WriteString("        path: String,\n")
+	b.WriteString("        data: ByteArray,\n")
+	b.WriteString("        resume: Boolean = false,\n")
+	b.WriteString("        chunkSize: Int = 128,\n")
+	b.WriteString("        progress: ((Int, Int) -> Unit)? = null,\n")
+	b.WriteString("    ) {\n")
+	b.WriteString(fmt.Sprintf("        var opened = %s(path = path, write = true, resume = resume)\n", open))
+	b.WriteString("        var offset = opened.size\n")
+	b.WriteString("        if (offset != 0 && (offset > data.size || fileCrc32(data, offset) != opened.crc32)) {\n")
+	b.WriteString("            // The partial file is not a prefix of data: start over.\n")
+	b.WriteString(fmt.Sprintf("            %s(handle = opened.handle)\n", closeFn))
+	b.WriteString(fmt.Sprintf("            opened = %s(path = path, write = true)\n", open))
+	b.WriteString("            offset = 0\n")
+	b.WriteString("        }\n")
+	b.WriteString("        try {\n")
+	b.WriteString("            while (offset < data.size) {\n")
+	b.WriteString("                val end = minOf(offset + chunkSize, data.size)\n")
+	b.WriteString(fmt.Sprintf("                %s(\n", write))
+	b.WriteString("                    handle = opened.handle,\n")
+	b.WriteString("                    offset = offset,\n")
+	b.WriteString("                    data = ByteString.copyFrom(data, offset, end - offset),\n")
+	b.WriteString("                )\n")
+	b.WriteString("                offset = end\n")
+	b.WriteString("                progress?.invoke(offset, data.size)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        } catch (e: Throwable) {\n")
+	b.WriteString(fmt.Sprintf("            runCatching { %s(handle = opened.handle) }\n", closeFn))
+	b.WriteString("            throw e\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        val closed = %s(handle = opened.handle, verify = true)\n", closeFn))
+	b.WriteString("        if (closed.size != data.size || closed.crc32 != fileCrc32(data, data.size)) {\n")
+	b.WriteString("            throw BlerpcException(\"$path: upload failed verification\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Reads [path] from the peripheral and verifies it by CRC-32. Pass the\n")
+	b.WriteString("     * bytes received before an interruption as [partial] to continue after\n")
+	b.WriteString("     * them. [progress] is called with the bytes received and the total after\n")
+	b.WriteString("     * each chunk.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun downloadFile(\n")
+	b.WriteString("        path: String,\n")
+	b.WriteString("        partial: ByteArray = ByteArray(0),\n")
+	b.WriteString("        chunkSize: Int = 128,\n")
+	b.WriteString("        progress: ((Int, Int) -> Unit)? = null,\n")
+	b.WriteString("    ): ByteArray {\n")
+	b.WriteString(fmt.Sprintf("        val opened = %s(path = path)\n", open))
+	b.WriteString("        val data = java.io.ByteArrayOutputStream()\n")
+	b.WriteString("        data.write(partial, 0, minOf(partial.size, opened.size))\n")
+	b.WriteString("        try {\n")
+	b.WriteString("            while (data.size() < opened.size) {\n")
+	b.WriteString(fmt.Sprintf("                val resp = %s(handle = opened.handle, offset = data.size(), length = chunkSize)\n", read))
+	b.WriteString("                if (resp.data.isEmpty) break\n")
+	b.WriteString("                resp.data.writeTo(data)\n")
+	b.WriteString("                progress?.invoke(data.size(), opened.size)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        } catch (e: Throwable) {\n")
+	b.WriteString(fmt.Sprintf("            runCatching { %s(handle = opened.handle) }\n", closeFn))
+	b.WriteString("            throw e\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        %s(handle = opened.handle)\n", closeFn))
+	b.WriteString("        val bytes = data.toByteArray()\n")
+	b.WriteString("        if (bytes.size != opened.size || fileCrc32(bytes, bytes.size) != opened.crc32) {\n")
+	b.WriteString("            throw BlerpcException(\"$path: download failed verification\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return bytes\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private fun fileCrc32(data: ByteArray, length: Int): Int =\n")
+	b.WriteString("        java.util.zip.CRC32().apply { update(data, 0, length) }.value.toInt()\n")
+}
@@ -0,0 +1,37 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeKotlinCharacteristics(b *strings.Builder, commands []gen.Command) {
+	if gen.GATTServiceUUID(commands) == "" {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** GATT service of the characteristic-per-command mode. */\n")
+	b.WriteString(fmt.Sprintf("const val COMMAND_SERVICE_UUID = \"%s\"\n", gen.GATTServiceUUID(commands)))
+	b.WriteByte('\n')
+	b.WriteString("/** GATT characteristic UUID per command, keyed by wire name. */\n")
+	b.WriteString("val COMMAND_CHARACTERISTICS: Map<String, String> =\n")
+	b.WriteString("    mapOf(\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("        \"%s\" to \"%s\",\n", cmd.Wire(), cmd.CharUUID))
+	}
+	b.WriteString("    )\n")
+}
+
+// GenerateGattClient returns GattClient.kt, placed next to the
+// generated client with kotlin_gatt_client: true in blerpc.yaml. GattClient
+// is a reference GeneratedClient over the Android GATT API: it connects,
+// requests a larger MTU, subscribes to the RPC characteristic and writes
+// the framed containers with response. It only supports the multiplexed
+// GATT layout.
+func GenerateGattClient(gctx *gen.Context, pkg string) string {
+	return "/* Auto-generated by generate-handlers — DO NOT EDIT */\n" +
+		"package " + gctx.KotlinPackage(pkg) + "\n" +
+		gctx.RenderTemplate("GattClient.kt.tmpl", nil)
+}
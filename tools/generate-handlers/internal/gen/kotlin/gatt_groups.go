@@ -0,0 +1,38 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeKotlinGattGroups(gctx *gen.Context, b *strings.Builder) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /** Services grouping commands apart from the RPC service: service and characteristic by name. */\n")
+	b.WriteString("    val SERVICES: Map<String, Pair<UUID, UUID>> =\n")
+	b.WriteString("        mapOf(\n")
+	for _, g := range gctx.GATTGroups {
+		b.WriteString(fmt.Sprintf("            \"%s\" to\n", g.Name))
+		b.WriteString("                Pair(\n")
+		b.WriteString(fmt.Sprintf("                    UUID.fromString(\"%s\"),\n", g.ServiceUUID))
+		b.WriteString(fmt.Sprintf("                    UUID.fromString(\"%s\"),\n", g.CharUUID))
+		b.WriteString("                ),\n")
+	}
+	b.WriteString("        )\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Service of each grouped command, by wire name; the others use [SERVICE_UUID]. */\n")
+	b.WriteString("    val COMMAND_SERVICES: Map<String, String> =\n")
+	b.WriteString("        mapOf(\n")
+	for _, c := range gctx.GATTGroupCommands() {
+		b.WriteString(fmt.Sprintf("            \"%s\" to \"%s\",\n", c[0], c[1]))
+	}
+	b.WriteString("        )\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** The characteristic [command] is written to. */\n")
+	b.WriteString("    fun characteristicFor(command: String): UUID =\n")
+	b.WriteString("        COMMAND_SERVICES[command]?.let { SERVICES.getValue(it).second } ?: CHAR_UUID\n")
+}
@@ -0,0 +1,26 @@
+package kotlin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestGenerateKotlinGattClient(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateGattClient(gctx, "blerpc")
+	for _, want := range []string{
+		"package com.blerpc.android.client\n\nimport android.annotation.SuppressLint\n",
+		"class GattClient(\n    private val context: Context,\n    private val requireEncryption: Boolean = true,\n) : GeneratedClient() {\n",
+		"override val capabilityFlags: Int get() = peripheralFlags\n",
+		"g.requestMtu(REQUESTED_MTU)",
+		"BluetoothGattCharacteristic.WRITE_TYPE_DEFAULT",
+		"g.getService(GeneratedUuids.SERVICE_UUID)?.getCharacteristic(GeneratedUuids.CHAR_UUID)",
+		"    override suspend fun streamSend(\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateInstrumented returns InstrumentedClient.kt, placed next to
+// the generated client.
+func GenerateInstrumented(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The schema name of the command the generated methods send as [cmdName]. */\n")
+	b.WriteString("private fun interceptedCommand(cmdName: String): String =\n")
+	renamed := gen.MockRenamedCommands(commands)
+	if len(renamed) == 0 {
+		b.WriteString("    cmdName\n")
+	} else {
+		b.WriteString("    when (cmdName) {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("        %s -> \"%s\"\n", gen.CallName(cmd, "kotlin"), cmd.Snake))
+		}
+		b.WriteString("        else -> cmdName\n")
+		b.WriteString("    }\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("InstrumentedClient.kt.tmpl", nil))
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinLogStreamHelpers emits the log reader of the Kotlin client.
+func writeKotlinLogStreamHelpers(b *strings.Builder, cmd gen.Command, pkg, pkgCap string) {
+	respCls := pkg + "." + pkgCap + "." + cmd.ResponseMsg
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Drains the firmware log into (timestamp, entry) pairs, oldest first. The\n")
+	b.WriteString("     * message of each entry joins the fragments of a long message, and the\n")
+	b.WriteString("     * timestamp is when it was logged.\n")
+	b.WriteString("     */\n")
+	b.WriteString(fmt.Sprintf("    suspend fun readLogs(minLevel: Int = 0, maxEntries: Int = 0): List<Pair<java.time.Instant, %s>> {\n", respCls))
+	b.WriteString(fmt.Sprintf("        val responses = %s(minLevel, maxEntries)\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("        val received = System.currentTimeMillis()\n")
+	b.WriteString(fmt.Sprintf("        val logs = mutableListOf<Pair<java.time.Instant, %s>>()\n", respCls))
+	b.WriteString("        val message = StringBuilder()\n")
+	b.WriteString(fmt.Sprintf("        var first: %s? = null\n", respCls))
+	b.WriteString("        for (resp in responses) {\n")
+	b.WriteString("            if (first == null) first = resp\n")
+	b.WriteString("            message.append(resp.message)\n")
+	b.WriteString("            if (resp.more) continue\n")
+	b.WriteString("            val ageMs = (first.nowMs - first.uptimeMs).toLong() and 0xFFFFFFFFL\n")
+	b.WriteString("            val entry = first.toBuilder().setMessage(message.toString()).build()\n")
+	b.WriteString("            logs.add(java.time.Instant.ofEpochMilli(received - ageMs) to entry)\n")
+	b.WriteString("            message.clear()\n")
+	b.WriteString("            first = null\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return logs\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,129 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateMock returns MockGeneratedClient.kt, placed next to the
+// generated client.
+func GenerateMock(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	var b strings.Builder
+	renamed := gen.MockRenamedCommands(commands)
+	_, auth, sessions := gen.SessionCommands(commands)
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	if sessions {
+		b.WriteString("import com.google.protobuf.ByteString\n")
+	}
+	b.WriteString("import com.google.protobuf.MessageLite\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * A call recorded by [Mock" + gctx.KotlinClientClass() + "]: the command and its decoded\n")
+	b.WriteString(" * requests, one per message of a C→P stream.\n")
+	b.WriteString(" */\n")
+	b.WriteString("data class MockCall(val command: String, val requests: List<MessageLite>)\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Stand-in for BlerpcClient in app unit tests, with no peripheral. Records\n")
+	b.WriteString(" * every call in [calls] and answers it with the responses set for its\n")
+	b.WriteString(" * command. Commands without any get an empty response, and P→C streams no\n")
+	b.WriteString(" * responses.\n")
+	b.WriteString(" */\n")
+	b.WriteString("class Mock" + gctx.KotlinClientClass() + " : " + gctx.KotlinClientClass() + "() {\n")
+	b.WriteString("    private val answers = mutableMapOf<String, (List<MessageLite>) -> List<MessageLite>>()\n")
+	b.WriteString("    private val recorded = mutableListOf<MockCall>()\n")
+	b.WriteByte('\n')
+	if sessions {
+		b.WriteString("    init {\n")
+		b.WriteString("        // Any key does: the mock answers the session handshake itself.\n")
+		b.WriteString(fmt.Sprintf("        sessionKey = ByteArray(%d)\n", gen.SessionProofSize))
+		b.WriteString("    }\n")
+		b.WriteByte('\n')
+	}
+	b.WriteString("    /** The calls made so far, in order. */\n")
+	b.WriteString("    val calls: List<MockCall>\n")
+	b.WriteString("        get() = synchronized(recorded) { recorded.toList() }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Answers [command] with [responses]: a unary or C→P call gets the first,\n")
+	b.WriteString("     * a P→C stream each of them.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    fun respond(command: String, vararg responses: MessageLite) {\n")
+	b.WriteString("        respond(command) { responses.toList() }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Answers [command] with the responses [answer] returns for its requests. */\n")
+	b.WriteString("    fun respond(command: String, answer: (List<MessageLite>) -> List<MessageLite>) {\n")
+	b.WriteString("        synchronized(answers) { answers[command] = answer }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Fails calls of [command] with [error], e.g. a [NotFoundException]. */\n")
+	b.WriteString("    fun fail(command: String, error: Throwable) {\n")
+	b.WriteString("        respond(command) { throw error }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override suspend fun call(cmdName: String, requestData: ByteArray): ByteArray {\n")
+	b.WriteString("        val (command, responses) = answer(cmdName, listOf(requestData))\n")
+	b.WriteString("        return (responses?.firstOrNull() ?: emptyResponse(command)).toByteArray()\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override suspend fun streamReceive(cmdName: String, requestData: ByteArray): List<ByteArray> =\n")
+	b.WriteString("        answer(cmdName, listOf(requestData)).second.orEmpty().map { it.toByteArray() }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override suspend fun streamSend(cmdName: String, messages: List<ByteArray>, finalCmdName: String): ByteArray {\n")
+	b.WriteString("        val (command, responses) = answer(cmdName, messages)\n")
+	b.WriteString("        return (responses?.firstOrNull() ?: emptyResponse(command)).toByteArray()\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Records a call and returns its command with the responses set for it. */\n")
+	b.WriteString("    private fun answer(cmdName: String, requests: List<ByteArray>): Pair<String, List<MessageLite>?> {\n")
+	if len(renamed) > 0 {
+		b.WriteString("        val command = when (cmdName) {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("            %s -> \"%s\"\n", gen.CallName(cmd, "kotlin"), cmd.Snake))
+		}
+		b.WriteString("            else -> cmdName\n")
+		b.WriteString("        }\n")
+	} else {
+		b.WriteString("        val command = cmdName\n")
+	}
+	b.WriteString("        val decoded = requests.map { decodeRequest(command, it) }\n")
+	b.WriteString("        synchronized(recorded) { recorded.add(MockCall(command, decoded)) }\n")
+	b.WriteString("        return command to synchronized(answers) { answers[command] }?.invoke(decoded)\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private fun decodeRequest(command: String, data: ByteArray): MessageLite = when (command) {\n")
+	for _, cmd := range commands {
+		reqCls := pkg + "." + pkgCap + "." + cmd.RequestMsg
+		data := "data"
+		if n := gen.MockRequestPrefix(cmd); n > 0 {
+			data = fmt.Sprintf("data.copyOfRange(%d, data.size)", n)
+		}
+		b.WriteString(fmt.Sprintf("        \"%s\" -> %s.parseFrom(%s)\n", cmd.Snake, reqCls, data))
+	}
+	b.WriteString("        else -> throw IllegalArgumentException(\"unknown command $command\")\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private fun emptyResponse(command: String): MessageLite = when (command) {\n")
+	for _, cmd := range commands {
+		respCls := pkg + "." + pkgCap + "." + cmd.ResponseMsg
+		if sessions && cmd.Snake == auth.Snake {
+			b.WriteString("        // A token of the size peripherals issue opens the session.\n")
+			b.WriteString(fmt.Sprintf("        \"%s\" -> %s.newBuilder()\n", cmd.Snake, respCls))
+			b.WriteString(fmt.Sprintf("            .setToken(ByteString.copyFrom(ByteArray(%d)))\n", gen.SessionTokenSize))
+			b.WriteString("            .build()\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("        \"%s\" -> %s.getDefaultInstance()\n", cmd.Snake, respCls))
+	}
+	b.WriteString("        else -> throw IllegalArgumentException(\"unknown command $command\")\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
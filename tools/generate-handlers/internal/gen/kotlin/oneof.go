@@ -0,0 +1,117 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// kotlinOneofClass names the sealed class of a oneof, e.g. SearchRequestQuery.
+func kotlinOneofClass(msg, oneof string) string {
+	return msg + gen.UpperCamel(oneof)
+}
+
+// kotlinOneofType returns the type a sealed class member wraps.
+func kotlinOneofType(f gen.Field, pkg string) string {
+	if o, ok := gen.FieldTypeOverride(f, "kotlin"); ok {
+		return o.Type
+	}
+	return scalarKotlinType(f, pkg)
+}
+
+// kotlinParams renders the parameters of a method taking the fields of msg.
+func kotlinParams(fields []gen.Field, msg, pkg string) []string {
+	var params []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		switch {
+		case f.Oneof == "":
+			params = append(params, kotlinParam(f, pkg))
+		case !seen[f.Oneof]:
+			seen[f.Oneof] = true
+			params = append(params, fmt.Sprintf("%s: %s? = null", f.Oneof, kotlinOneofClass(msg, f.Oneof)))
+		}
+	}
+	return params
+}
+
+// writeKotlinSetters writes the builder calls that fill the fields of msg
+// from the method parameters.
+func writeKotlinSetters(b *strings.Builder, fields []gen.Field, msg string) {
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if gen.NullableField(f) {
+			b.WriteString(fmt.Sprintf("            .apply { if (%s != null) %s(%s) }\n", f.Name, gen.KotlinBuilderSetter(f), f.Name))
+			continue
+		}
+		if f.Oneof == "" {
+			b.WriteString(fmt.Sprintf("            .%s(%s)\n", gen.KotlinBuilderSetter(f), gen.EncodeValue(f, "kotlin", f.Name)))
+			continue
+		}
+		if seen[f.Oneof] {
+			continue
+		}
+		seen[f.Oneof] = true
+		cls := kotlinOneofClass(msg, f.Oneof)
+		b.WriteString("            .apply {\n")
+		b.WriteString(fmt.Sprintf("                when (%s) {\n", f.Oneof))
+		for _, m := range gen.OneofMembers(fields, f.Oneof) {
+			b.WriteString(fmt.Sprintf("                    is %s.%s -> %s(%s)\n",
+				cls, gen.UpperCamel(m.Name), gen.KotlinBuilderSetter(m), gen.EncodeValue(m, "kotlin", f.Oneof+".value")))
+		}
+		b.WriteString("                    null -> {}\n")
+		b.WriteString("                }\n")
+		b.WriteString("            }\n")
+	}
+}
+
+// writeKotlinOneofs emits the sealed class of every oneof in the request and
+// response messages, and for responses an extension property returning the
+// member that is set, or null.
+func writeKotlinOneofs(b *strings.Builder, commands []gen.Command, streaming map[string]string, pkg, pkgCap string) {
+	written := make(map[string]bool)
+	sealed := func(msg string, fields []gen.Field, response bool) {
+		for _, oneof := range gen.OneofNames(fields) {
+			if written[msg+"."+oneof] {
+				continue
+			}
+			written[msg+"."+oneof] = true
+			cls := kotlinOneofClass(msg, oneof)
+			members := gen.OneofMembers(fields, oneof)
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("sealed class %s {\n", cls))
+			for _, m := range members {
+				b.WriteString(fmt.Sprintf("    data class %s(val value: %s) : %s()\n", gen.UpperCamel(m.Name), kotlinOneofType(m, pkg), cls))
+			}
+			b.WriteString("}\n")
+			if !response {
+				continue
+			}
+			msgCls := pkg + "." + pkgCap + "." + msg
+			prop := gen.SwiftPropertyName(oneof)
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("val %s.%sOneOf: %s?\n", msgCls, prop, cls))
+			b.WriteString(fmt.Sprintf("    get() = when (%sCase) {\n", prop))
+			for _, m := range members {
+				getter := gen.SwiftPropertyName(m.Name)
+				if m.IsEnum {
+					getter += "Value"
+				}
+				if o, ok := gen.FieldTypeOverride(m, "kotlin"); ok {
+					getter = gen.ApplyConverter(o.Decode, getter)
+				}
+				b.WriteString(fmt.Sprintf("        %s.%sCase.%s -> %s.%s(%s)\n",
+					msgCls, gen.UpperCamel(oneof), strings.ToUpper(m.Name), cls, gen.UpperCamel(m.Name), getter))
+			}
+			b.WriteString("        else -> null\n")
+			b.WriteString("    }\n")
+		}
+	}
+	for _, cmd := range commands {
+		if streaming[cmd.Snake] != "c2p" {
+			sealed(cmd.RequestMsg, cmd.RequestFields, false)
+		}
+		sealed(cmd.ResponseMsg, cmd.ResponseFields, true)
+	}
+}
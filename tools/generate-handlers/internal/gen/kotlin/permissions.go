@@ -0,0 +1,116 @@
+package kotlin
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GeneratePermissions returns BlePermissions.kt, the runtime permission
+// flow the Android transport needs before it can scan or connect. The
+// permissions depend on the API level only, so the file is the same for
+// every schema apart from its package.
+func GeneratePermissions(gctx *gen.Context, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import android.Manifest\n")
+	b.WriteString("import android.content.Context\n")
+	b.WriteString("import android.content.pm.PackageManager\n")
+	b.WriteString("import android.os.Build\n")
+	b.WriteString("import androidx.activity.result.ActivityResultCaller\n")
+	b.WriteString("import androidx.activity.result.ActivityResultLauncher\n")
+	b.WriteString("import androidx.activity.result.contract.ActivityResultContracts\n")
+	b.WriteString("import androidx.core.content.ContextCompat\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Thrown when the app lacks runtime permissions the BLE transport needs. */\n")
+	b.WriteString("class MissingPermissionsException(val permissions: List<String>) :\n")
+	b.WriteString("    SecurityException(\"Missing Bluetooth permissions: ${permissions.joinToString()}\")\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Runtime permissions required before the BLE transport can scan and connect.\n")
+	b.WriteString(" *\n")
+	b.WriteString(" * Android 12 (API 31) and later grant BLUETOOTH_SCAN and BLUETOOTH_CONNECT at\n")
+	b.WriteString(" * runtime; earlier releases need location access for scans instead. Apps whose\n")
+	b.WriteString(" * BLUETOOTH_SCAN declaration lacks usesPermissionFlags=\"neverForLocation\" only\n")
+	b.WriteString(" * receive scan results with location access, so they pass includeLocation = true.\n")
+	b.WriteString(" */\n")
+	b.WriteString("object BlePermissions {\n")
+	b.WriteString("    /** Permissions to request on this device. */\n")
+	b.WriteString("    fun required(includeLocation: Boolean = false): List<String> =\n")
+	b.WriteString("        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.S) {\n")
+	b.WriteString("            listOfNotNull(\n")
+	b.WriteString("                Manifest.permission.BLUETOOTH_SCAN,\n")
+	b.WriteString("                Manifest.permission.BLUETOOTH_CONNECT,\n")
+	b.WriteString("                Manifest.permission.ACCESS_FINE_LOCATION.takeIf { includeLocation },\n")
+	b.WriteString("            )\n")
+	b.WriteString("        } else {\n")
+	b.WriteString("            listOf(Manifest.permission.ACCESS_FINE_LOCATION)\n")
+	b.WriteString("        }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Required permissions that are not granted yet. */\n")
+	b.WriteString("    fun missing(\n")
+	b.WriteString("        context: Context,\n")
+	b.WriteString("        includeLocation: Boolean = false,\n")
+	b.WriteString("    ): List<String> =\n")
+	b.WriteString("        required(includeLocation).filter {\n")
+	b.WriteString("            ContextCompat.checkSelfPermission(context, it) != PackageManager.PERMISSION_GRANTED\n")
+	b.WriteString("        }\n")
+	b.WriteByte('\n')
+	b.WriteString("    fun hasAll(\n")
+	b.WriteString("        context: Context,\n")
+	b.WriteString("        includeLocation: Boolean = false,\n")
+	b.WriteString("    ): Boolean = missing(context, includeLocation).isEmpty()\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Throws [MissingPermissionsException] unless every required permission is granted. */\n")
+	b.WriteString("    fun check(\n")
+	b.WriteString("        context: Context,\n")
+	b.WriteString("        includeLocation: Boolean = false,\n")
+	b.WriteString("    ) {\n")
+	b.WriteString("        val missing = missing(context, includeLocation)\n")
+	b.WriteString("        if (missing.isNotEmpty()) {\n")
+	b.WriteString("            throw MissingPermissionsException(missing)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Registers a permission request on [caller]. Like any activity result, it\n")
+	b.WriteString("     * must be registered before the activity or fragment is started. [onResult]\n")
+	b.WriteString("     * receives whether every required permission is granted, which is also\n")
+	b.WriteString("     * false when the user dismisses the dialog.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    fun register(\n")
+	b.WriteString("        caller: ActivityResultCaller,\n")
+	b.WriteString("        context: Context,\n")
+	b.WriteString("        includeLocation: Boolean = false,\n")
+	b.WriteString("        onResult: (Boolean) -> Unit,\n")
+	b.WriteString("    ): Request {\n")
+	b.WriteString("        val launcher =\n")
+	b.WriteString("            caller.registerForActivityResult(\n")
+	b.WriteString("                ActivityResultContracts.RequestMultiplePermissions(),\n")
+	b.WriteString("            ) { onResult(hasAll(context, includeLocation)) }\n")
+	b.WriteString("        return Request(context, includeLocation, launcher, onResult)\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** A permission request registered with [register]. */\n")
+	b.WriteString("    class Request internal constructor(\n")
+	b.WriteString("        private val context: Context,\n")
+	b.WriteString("        private val includeLocation: Boolean,\n")
+	b.WriteString("        private val launcher: ActivityResultLauncher<Array<String>>,\n")
+	b.WriteString("        private val onResult: (Boolean) -> Unit,\n")
+	b.WriteString("    ) {\n")
+	b.WriteString("        /** Asks for the missing permissions; reports success at once if none are missing. */\n")
+	b.WriteString("        fun launch() {\n")
+	b.WriteString("            val missing = missing(context, includeLocation)\n")
+	b.WriteString("            if (missing.isEmpty()) {\n")
+	b.WriteString("                onResult(true)\n")
+	b.WriteString("            } else {\n")
+	b.WriteString("                launcher.launch(missing.toTypedArray())\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package kotlin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestGenerateKotlinPermissions(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GeneratePermissions(gctx, "blerpc")
+	for _, s := range []string{
+		"package com.blerpc.android.client\n",
+		"object BlePermissions {\n",
+		"        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.S) {\n",
+		"                Manifest.permission.ACCESS_FINE_LOCATION.takeIf { includeLocation },\n",
+		"            listOf(Manifest.permission.ACCESS_FINE_LOCATION)\n",
+		"            throw MissingPermissionsException(missing)\n",
+		"            ) { onResult(hasAll(context, includeLocation)) }\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
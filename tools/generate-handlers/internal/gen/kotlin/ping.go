@@ -0,0 +1,34 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinPingHelpers emits the latency and keepalive helpers of the
+// Kotlin client.
+func writeKotlinPingHelpers(b *strings.Builder, cmd gen.Command) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /** Pings the peripheral and returns the round trip in milliseconds. */\n")
+	b.WriteString("    suspend fun measureLatency(): Long {\n")
+	b.WriteString("        val start = System.nanoTime()\n")
+	b.WriteString(fmt.Sprintf("        %s()\n", method))
+	b.WriteString("        return (System.nanoTime() - start) / 1_000_000\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Pings the peripheral every [intervalMs] until cancelled, passing each\n")
+	b.WriteString("     * round trip to [onLatency]. Launch it next to the calls: it throws as\n")
+	b.WriteString("     * soon as a ping fails, so a dead connection shows before a long call\n")
+	b.WriteString("     * times out.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun keepalive(intervalMs: Long = 5000, onLatency: (Long) -> Unit = {}) {\n")
+	b.WriteString("        while (true) {\n")
+	b.WriteString("            onLatency(measureLatency())\n")
+	b.WriteString("            delay(intervalMs)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+}
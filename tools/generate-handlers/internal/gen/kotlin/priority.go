@@ -0,0 +1,50 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GeneratePriority returns PriorityClient.kt, placed next to the
+// generated client.
+func GeneratePriority(gctx *gen.Context, commands []gen.Command, pkg string, streaming map[string]string) string {
+	prioritized := gen.PrioritizedCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import kotlinx.coroutines.CancellationException\n")
+	b.WriteString("import kotlinx.coroutines.CompletableDeferred\n")
+	b.WriteString("import kotlinx.coroutines.Job\n")
+	b.WriteString("import kotlinx.coroutines.async\n")
+	b.WriteString("import kotlinx.coroutines.coroutineScope\n")
+	b.WriteString("import kotlinx.coroutines.currentCoroutineContext\n")
+	b.WriteString("import kotlinx.coroutines.ensureActive\n")
+	b.WriteString("import java.util.PriorityQueue\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Order in which a [PriorityClient] runs waiting calls. */\n")
+	b.WriteString("enum class Priority { " + strings.ToUpper(strings.Join(gen.PriorityNames, ", ")) + " }\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Priority of the commands not run at [Priority.NORMAL], by the names their\n")
+	b.WriteString(" * calls are sent by.\n")
+	b.WriteString(" */\n")
+	if len(prioritized) == 0 {
+		b.WriteString("val COMMAND_PRIORITIES: Map<String, Priority> = emptyMap()\n")
+	} else {
+		b.WriteString("val COMMAND_PRIORITIES: Map<String, Priority> =\n")
+		b.WriteString("    mapOf(\n")
+		for _, cmd := range prioritized {
+			for _, name := range gen.CacheCallNames(cmd, "kotlin") {
+				b.WriteString(fmt.Sprintf("        %s to Priority.%s,\n", name, strings.ToUpper(cmd.Priority)))
+			}
+		}
+		b.WriteString("    )\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("PriorityClient.kt.tmpl", gen.PriorityData{ServerStreams: gen.HasServerStreams(commands, streaming)}))
+	return b.String()
+}
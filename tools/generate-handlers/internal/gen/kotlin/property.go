@@ -0,0 +1,29 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinProperties emits the property accessors of GeneratedClient.
+func writeKotlinProperties(b *strings.Builder, commands []gen.Command, pkg string) {
+	for _, get := range commands {
+		set, ok := gen.PropertySetter(get, commands)
+		if get.Property == "" || !ok {
+			continue
+		}
+		g, s := get.ResponseFields[0], set.RequestFields[0]
+		name := gen.SwiftPropertyName(get.Property)
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    /** Reads the %s with [%s]. */\n", g.Name, gen.ToLowerCamel(get.Camel)))
+		b.WriteString(fmt.Sprintf("    suspend fun %s(): %s =\n", name, ResolveType(g, pkg)))
+		b.WriteString(fmt.Sprintf("        %s().%s\n", gen.ToLowerCamel(get.Camel), gen.SwiftPropertyName(g.Name)))
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    /** Writes the %s with [%s]. */\n", s.Name, gen.ToLowerCamel(set.Camel)))
+		b.WriteString(fmt.Sprintf("    suspend fun %s(value: %s) {\n", name, ResolveType(s, pkg)))
+		b.WriteString(fmt.Sprintf("        %s(%s = value)\n", gen.ToLowerCamel(set.Camel), s.Name))
+		b.WriteString("    }\n")
+	}
+}
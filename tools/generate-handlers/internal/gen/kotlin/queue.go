@@ -0,0 +1,164 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateQueue returns OfflineQueue.kt, placed next to the generated
+// client.
+func GenerateQueue(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	queueable := gen.QueueableCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import kotlinx.coroutines.CancellationException\n")
+	b.WriteString("import kotlinx.coroutines.TimeoutCancellationException\n")
+	b.WriteString("import kotlinx.coroutines.sync.Mutex\n")
+	b.WriteString("import kotlinx.coroutines.sync.withLock\n")
+	b.WriteString("import java.io.DataInputStream\n")
+	b.WriteString("import java.io.DataOutputStream\n")
+	b.WriteString("import java.io.File\n")
+	b.WriteString("import java.io.IOException\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Time to live in seconds of each queueable command. */\n")
+	if len(queueable) == 0 {
+		b.WriteString("val QUEUEABLE_COMMANDS: Map<String, Long> = emptyMap()\n")
+	} else {
+		b.WriteString("val QUEUEABLE_COMMANDS: Map<String, Long> =\n")
+		b.WriteString("    mapOf(\n")
+		for _, cmd := range queueable {
+			b.WriteString(fmt.Sprintf("        %q to %dL,\n", cmd.Wire(), cmd.QueueTTL))
+		}
+		b.WriteString("    )\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** A call waiting in an [OfflineQueue]. */\n")
+	b.WriteString("class QueuedCall(\n")
+	b.WriteString("    val command: String,\n")
+	b.WriteString("    val request: ByteArray,\n")
+	b.WriteString("    val expiresAtMs: Long,\n")
+	b.WriteString(")\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The queued call expired before a connection came up. */\n")
+	b.WriteString("class QueuedCallExpiredException(val command: String) :\n")
+	b.WriteString("    BlerpcException(\"$command: expired in the offline queue\")\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Persists the calls of an [OfflineQueue]. */\n")
+	b.WriteString("interface QueueStore {\n")
+	b.WriteString("    fun load(): List<QueuedCall>\n")
+	b.WriteByte('\n')
+	b.WriteString("    fun save(calls: List<QueuedCall>)\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Keeps the queue in [file], e.g. File(context.filesDir, \"blerpc_queue.bin\"). */\n")
+	b.WriteString("class FileQueueStore(private val file: File) : QueueStore {\n")
+	b.WriteString("    override fun load(): List<QueuedCall> {\n")
+	b.WriteString("        if (!file.exists()) return emptyList()\n")
+	b.WriteString("        DataInputStream(file.inputStream().buffered()).use { input ->\n")
+	b.WriteString("            return List(input.readInt()) {\n")
+	b.WriteString("                val command = input.readUTF()\n")
+	b.WriteString("                val expiresAtMs = input.readLong()\n")
+	b.WriteString("                val request = ByteArray(input.readInt()).also { input.readFully(it) }\n")
+	b.WriteString("                QueuedCall(command, request, expiresAtMs)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override fun save(calls: List<QueuedCall>) {\n")
+	b.WriteString("        // Write a new file and rename it so a crash never leaves a torn queue.\n")
+	b.WriteString("        val tmp = File(file.path + \".tmp\")\n")
+	b.WriteString("        DataOutputStream(tmp.outputStream().buffered()).use { out ->\n")
+	b.WriteString("            out.writeInt(calls.size)\n")
+	b.WriteString("            for (call in calls) {\n")
+	b.WriteString("                out.writeUTF(call.command)\n")
+	b.WriteString("                out.writeLong(call.expiresAtMs)\n")
+	b.WriteString("                out.writeInt(call.request.size)\n")
+	b.WriteString("                out.write(call.request)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("        if (!tmp.renameTo(file)) {\n")
+	b.WriteString("            throw IOException(\"Cannot replace ${file.path}\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Persistent queue of calls made while the device is out of reach.\n")
+	b.WriteString(" *\n")
+	b.WriteString(" * Call [flush] after connecting. Calls are sent in the order they were queued\n")
+	b.WriteString(" * and never after they expire. A call failing without an answer from the\n")
+	b.WriteString(" * peripheral stops the flush and stays queued; one rejected with a\n")
+	b.WriteString(" * [RemoteException] is reported and dropped.\n")
+	b.WriteString(" */\n")
+	b.WriteString("class OfflineQueue(\n")
+	b.WriteString("    private val store: QueueStore,\n")
+	b.WriteString("    private val clock: () -> Long = System::currentTimeMillis,\n")
+	b.WriteString(") {\n")
+	b.WriteString("    private val lock = Mutex()\n")
+	b.WriteString("    private val flushLock = Mutex()\n")
+	b.WriteString("    private val calls = ArrayDeque(store.load())\n")
+	b.WriteByte('\n')
+	b.WriteString("    suspend fun size(): Int = lock.withLock { calls.size }\n")
+	for _, cmd := range queueable {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    suspend fun enqueue%s(request: %s.%s.%s) {\n", cmd.Camel, pkg, pkgCap, cmd.RequestMsg))
+		b.WriteString(fmt.Sprintf("        enqueue(%q, request.toByteArray())\n", cmd.Wire()))
+		b.WriteString("    }\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Sends the queued calls through [client] and passes each outcome to\n")
+	b.WriteString("     * [onResult]; expired calls fail with [QueuedCallExpiredException]. Returns\n")
+	b.WriteString("     * the number of calls left, right away if another flush is running.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun flush(\n")
+	b.WriteString("        client: " + gctx.KotlinClientClass() + ",\n")
+	b.WriteString("        onResult: (QueuedCall, Result<ByteArray>) -> Unit = { _, _ -> },\n")
+	b.WriteString("    ): Int {\n")
+	b.WriteString("        if (!flushLock.tryLock()) return size()\n")
+	b.WriteString("        try {\n")
+	b.WriteString("            while (true) {\n")
+	b.WriteString("                val call = lock.withLock { calls.firstOrNull() } ?: return 0\n")
+	b.WriteString("                val result: Result<ByteArray> =\n")
+	b.WriteString("                    if (clock() >= call.expiresAtMs) {\n")
+	b.WriteString("                        Result.failure(QueuedCallExpiredException(call.command))\n")
+	b.WriteString("                    } else {\n")
+	b.WriteString("                        try {\n")
+	b.WriteString("                            Result.success(client.exclusive { client.call(call.command, call.request) })\n")
+	b.WriteString("                        } catch (e: RemoteException) {\n")
+	b.WriteString("                            Result.failure(e)\n")
+	b.WriteString("                        } catch (e: Exception) {\n")
+	b.WriteString("                            if (e is CancellationException && e !is TimeoutCancellationException) throw e\n")
+	b.WriteString("                            return size()\n")
+	b.WriteString("                        }\n")
+	b.WriteString("                    }\n")
+	b.WriteString("                lock.withLock {\n")
+	b.WriteString("                    calls.removeFirst()\n")
+	b.WriteString("                    store.save(calls)\n")
+	b.WriteString("                }\n")
+	b.WriteString("                onResult(call, result)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        } finally {\n")
+	b.WriteString("            flushLock.unlock()\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private suspend fun enqueue(\n")
+	b.WriteString("        command: String,\n")
+	b.WriteString("        request: ByteArray,\n")
+	b.WriteString("    ) {\n")
+	b.WriteString("        val ttlMs = QUEUEABLE_COMMANDS.getValue(command) * 1000\n")
+	b.WriteString("        lock.withLock {\n")
+	b.WriteString("            calls.addLast(QueuedCall(command, request, clock() + ttlMs))\n")
+	b.WriteString("            store.save(calls)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
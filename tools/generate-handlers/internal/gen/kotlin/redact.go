@@ -0,0 +1,81 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// kotlinRedactedField returns the expression adding field f to the parts of
+// toRedactedString, reached through the receiver.
+func kotlinRedactedField(f gen.Field, msgCls string) string {
+	prop := gen.SwiftPropertyName(f.Name)
+	var value string
+	switch {
+	case f.Sensitive:
+		value = "$REDACTED"
+	case f.IsMap && f.ValueIsMessage:
+		value = fmt.Sprintf("${%sMap.mapValues { redacted(it.value) }}", prop)
+	case f.IsMap:
+		value = fmt.Sprintf("$%sMap", prop)
+	case f.IsRepeated && f.IsMessage:
+		value = fmt.Sprintf("${%sList.map { redacted(it) }}", prop)
+	case f.IsRepeated:
+		value = fmt.Sprintf("$%sList", prop)
+	case f.IsMessage:
+		value = fmt.Sprintf("${redacted(%s)}", prop)
+	default:
+		value = fmt.Sprintf("$%s", prop)
+	}
+	add := fmt.Sprintf("add(\"%s=%s\")", f.Name, value)
+	switch {
+	case f.Oneof != "":
+		return fmt.Sprintf("if (%sCase == %s.%sCase.%s) %s", gen.SwiftPropertyName(f.Oneof), msgCls, gen.UpperCamel(f.Oneof), strings.ToUpper(f.Name), add)
+	case f.IsOptional || (f.IsMessage && !f.IsRepeated && !f.IsMap):
+		return fmt.Sprintf("if (has%s()) %s", gen.UpperCamel(f.Name), add)
+	}
+	return add
+}
+
+// GenerateRedaction returns Redaction.kt, in the package of the
+// Kotlin client.
+func GenerateRedaction(gctx *gen.Context, messages map[string]gen.Message, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteString(gctx.RenderTemplate("Redaction.kt.tmpl", nil))
+
+	sensitive := gen.SensitiveMessages(messages)
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Returns [message] like a data class's toString, with the fields marked\n")
+	b.WriteString(" * (blerpc.sensitive) shown as [REDACTED], e.g.\n")
+	b.WriteString(" * `LoginRequest(user=ann, password=<redacted>)`. Messages without such\n")
+	b.WriteString(" * fields, directly or in a message field, keep their own toString.\n")
+	b.WriteString(" */\n")
+	if len(sensitive) == 0 {
+		b.WriteString("fun redacted(message: MessageLite): String = message.toString()\n")
+		return b.String()
+	}
+	b.WriteString("fun redacted(message: MessageLite): String =\n")
+	b.WriteString("    when (message) {\n")
+	for _, m := range sensitive {
+		b.WriteString(fmt.Sprintf("        is %s -> message.toRedactedString()\n", gen.MessageTypeName(m.Name, m.File, "kotlin", pkg)))
+	}
+	b.WriteString("        else -> message.toString()\n")
+	b.WriteString("    }\n")
+	for _, m := range sensitive {
+		msgCls := gen.MessageTypeName(m.Name, m.File, "kotlin", pkg)
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("/** This %s with its sensitive fields redacted. */\n", m.Name))
+		b.WriteString(fmt.Sprintf("fun %s.toRedactedString(): String =\n", msgCls))
+		b.WriteString("    buildList {\n")
+		for _, f := range m.Fields {
+			b.WriteString("        " + kotlinRedactedField(f, msgCls) + "\n")
+		}
+		b.WriteString(fmt.Sprintf("    }.joinToString(\", \", \"%s(\", \")\")\n", m.Name))
+	}
+	return b.String()
+}
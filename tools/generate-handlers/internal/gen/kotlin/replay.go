@@ -0,0 +1,36 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinReplayCounter emits the counter object of replay-protected
+// requests.
+func writeKotlinReplayCounter(b *strings.Builder) {
+	b.WriteString("/**\n")
+	b.WriteString(" * Leads replay-protected requests with a counter, 8 bytes little endian,\n")
+	b.WriteString(" * and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of\n")
+	b.WriteString(" * the command name, a NUL, the counter and the request. The peripheral drops\n")
+	b.WriteString(" * requests whose MAC is wrong or whose counter is not above the last one it\n")
+	b.WriteString(" * accepted; microseconds since the epoch keep it increasing across restarts.\n")
+	b.WriteString(" */\n")
+	b.WriteString("internal object ReplayCounter {\n")
+	b.WriteString("    private var last = 0L\n")
+	b.WriteByte('\n')
+	b.WriteString("    @Synchronized\n")
+	b.WriteString("    fun prefix(key: ByteArray?, command: String, requestData: ByteArray): ByteArray {\n")
+	b.WriteString("        key ?: throw BlerpcException(\"sessionKey is not set\")\n")
+	b.WriteString("        last = maxOf(last + 1, System.currentTimeMillis() * 1000)\n")
+	b.WriteString("        val counter = ByteBuffer.allocate(8).order(ByteOrder.LITTLE_ENDIAN).putLong(last).array()\n")
+	b.WriteString("        val mac = Mac.getInstance(\"HmacSHA256\").apply { init(SecretKeySpec(key, \"HmacSHA256\")) }\n")
+	b.WriteString("        mac.update(command.toByteArray())\n")
+	b.WriteString("        mac.update(0.toByte())\n")
+	b.WriteString("        mac.update(counter)\n")
+	b.WriteString(fmt.Sprintf("        return counter + mac.doFinal(requestData).copyOf(%d) + requestData\n", gen.ReplayMACSize))
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
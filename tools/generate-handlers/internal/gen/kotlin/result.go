@@ -0,0 +1,144 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Result client of Kotlin (ResultClient.kt, with kotlin_result_client:
+// true in blerpc.yaml) is for apps that handle errors as values.
+// ResultClient has a method per command of GeneratedClient, by the same name
+// and parameters, returning a Result whose failure is always a
+// BlerpcException; getOrThrow() gives the throwing surface back. Unary calls
+// run under withTimeout, of their call policy's attempts or of the client's
+// default. Streams are not timed, since their length is up to the
+// peripheral. connectionState is a StateFlow the link's owner reports to.
+
+// kotlinResultTimeoutMs returns the timeout of all attempts of cmd, or 0
+// when its call has no policy.
+func kotlinResultTimeoutMs(cmd gen.Command) int {
+	return cmd.TimeoutMs * (cmd.Retries + 1)
+}
+
+// GenerateResultClient returns ResultClient.kt, placed next to the
+// generated client.
+func GenerateResultClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	pkgCap := strings.ToUpper(pkg[:1]) + pkg[1:]
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import kotlinx.coroutines.CancellationException\n")
+	b.WriteString("import kotlinx.coroutines.TimeoutCancellationException\n")
+	b.WriteString("import kotlinx.coroutines.flow.MutableStateFlow\n")
+	b.WriteString("import kotlinx.coroutines.flow.StateFlow\n")
+	b.WriteString("import kotlinx.coroutines.flow.asStateFlow\n")
+	b.WriteString("import kotlinx.coroutines.withTimeout\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Connection state of the link of a [ResultClient]. */\n")
+	b.WriteString("enum class ConnectionState { DISCONNECTED, CONNECTING, CONNECTED }\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Timeouts of the unary commands with a call policy, covering all of its\n")
+	b.WriteString(" * attempts. Other unary commands get the default of the [ResultClient].\n")
+	b.WriteString(" */\n")
+	var timed []gen.Command
+	for _, cmd := range commands {
+		if _, ok := streaming[cmd.Snake]; !ok && cmd.TimeoutMs > 0 {
+			timed = append(timed, cmd)
+		}
+	}
+	if len(timed) == 0 {
+		b.WriteString("val RESULT_TIMEOUTS_MS: Map<String, Long> = emptyMap()\n")
+	} else {
+		b.WriteString("val RESULT_TIMEOUTS_MS: Map<String, Long> =\n")
+		b.WriteString("    mapOf(\n")
+		for _, cmd := range timed {
+			b.WriteString(fmt.Sprintf("        \"%s\" to %dL,\n", cmd.Snake, kotlinResultTimeoutMs(cmd)))
+		}
+		b.WriteString("    )\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * The commands of [client], returning a [Result] instead of throwing. A\n")
+	b.WriteString(" * failure is a [BlerpcException]: a [TimeoutException] once the timeout of\n")
+	b.WriteString(" * a unary call passes, and a [TransportException] wrapping any error that\n")
+	b.WriteString(" * is not a BlerpcException. Cancellation is not caught.\n")
+	b.WriteString(" *\n")
+	b.WriteString(" *     client.echo(message = \"hi\")\n")
+	b.WriteString(" *         .onSuccess { println(it.message) }\n")
+	b.WriteString(" *         .onFailure { log(it) }\n")
+	b.WriteString(" */\n")
+	b.WriteString("class ResultClient(\n")
+	b.WriteString("    private val client: " + gctx.KotlinClientClass() + ",\n")
+	b.WriteString("    private val defaultTimeoutMs: Long = 5000,\n")
+	b.WriteString(") {\n")
+	b.WriteString("    private val state = MutableStateFlow(ConnectionState.DISCONNECTED)\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** The state the link's owner last reported with [setConnectionState]. */\n")
+	b.WriteString("    val connectionState: StateFlow<ConnectionState> = state.asStateFlow()\n")
+	b.WriteByte('\n')
+	b.WriteString("    fun setConnectionState(value: ConnectionState) {\n")
+	b.WriteString("        state.value = value\n")
+	b.WriteString("    }\n")
+
+	for _, cmd := range commands {
+		dir, ok := streaming[cmd.Snake]
+		respCls := pkg + "." + pkgCap + "." + cmd.ResponseMsg
+		var params, args []string
+		switch dir {
+		case "c2p":
+			params = []string{fmt.Sprintf("messages: List<%s.%s.%s>", pkg, pkgCap, cmd.RequestMsg)}
+			args = []string{"messages"}
+		case "p2c":
+			respCls = "List<" + respCls + ">"
+			fallthrough
+		default:
+			params = kotlinParams(cmd.RequestFields, cmd.RequestMsg, pkg)
+			for _, name := range gen.ParamNames(cmd.RequestFields) {
+				args = append(args, name+" = "+name)
+			}
+		}
+		helper := "timed"
+		if ok {
+			helper = "untimed"
+		}
+		b.WriteByte('\n')
+		b.WriteString(Deprecation(cmd, dir != "c2p"))
+		b.WriteString(fmt.Sprintf("    suspend fun %s(%s): Result<%s> =\n", gen.ToLowerCamel(cmd.Camel), strings.Join(params, ", "), respCls))
+		b.WriteString(fmt.Sprintf("        %s(\"%s\") { it.%s(%s) }\n", helper, cmd.Snake, gen.ToLowerCamel(cmd.Camel), strings.Join(args, ", ")))
+	}
+
+	b.WriteByte('\n')
+	b.WriteString("    private suspend fun <T> timed(\n")
+	b.WriteString("        command: String,\n")
+	b.WriteString("        block: suspend (" + gctx.KotlinClientClass() + ") -> T,\n")
+	b.WriteString("    ): Result<T> {\n")
+	b.WriteString("        val timeoutMs = RESULT_TIMEOUTS_MS[command] ?: defaultTimeoutMs\n")
+	b.WriteString("        return try {\n")
+	b.WriteString("            untimed(command) { withTimeout(timeoutMs) { block(it) } }\n")
+	b.WriteString("        } catch (e: TimeoutCancellationException) {\n")
+	b.WriteString("            Result.failure(TimeoutException(\"$command: no response within $timeoutMs ms\", e))\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private suspend fun <T> untimed(\n")
+	b.WriteString("        command: String,\n")
+	b.WriteString("        block: suspend (" + gctx.KotlinClientClass() + ") -> T,\n")
+	b.WriteString("    ): Result<T> =\n")
+	b.WriteString("        try {\n")
+	b.WriteString("            Result.success(block(client))\n")
+	b.WriteString("        } catch (e: BlerpcException) {\n")
+	b.WriteString("            Result.failure(e)\n")
+	b.WriteString("        } catch (e: CancellationException) {\n")
+	b.WriteString("            // withTimeout of timed() expires as a TimeoutCancellationException.\n")
+	b.WriteString("            throw e\n")
+	b.WriteString("        } catch (e: Exception) {\n")
+	b.WriteString("            Result.failure(TransportException(\"$command: ${e.message}\", e))\n")
+	b.WriteString("        }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package kotlin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateKotlinResultClient(t *testing.T) {
+	gctx := gen.NewContext()
+	streaming := map[string]string{"counter_stream": "p2c"}
+	echo := gentest.EchoCommand()
+	echo.TimeoutMs, echo.Retries = 500, 2
+	out := GenerateResultClient(gctx, []gen.Command{echo, gentest.StreamP2CCommand()}, streaming, "blerpc")
+
+	for _, want := range []string{
+		"package com.blerpc.android.client\n",
+		"import kotlinx.coroutines.withTimeout\n",
+		"        \"echo\" to 1500L,\n",
+		"    val connectionState: StateFlow<ConnectionState> = state.asStateFlow()\n",
+		"    suspend fun echo(message: String = \"\"): Result<blerpc.Blerpc.EchoResponse> =\n        timed(\"echo\") { it.echo(message = message) }\n",
+		"Result<List<blerpc.Blerpc.CounterStreamResponse>> =\n        untimed(\"counter_stream\") { it.counterStream(",
+		"            Result.failure(TimeoutException(\"$command: no response within $timeoutMs ms\", e))\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q\nGot:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\"counter_stream\" to") {
+		t.Error("stream has a timeout")
+	}
+}
@@ -0,0 +1,108 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateResume returns ResumingClient.kt, placed next to the
+// generated client.
+func GenerateResume(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import kotlinx.coroutines.CancellationException\n")
+	b.WriteString("import kotlinx.coroutines.TimeoutCancellationException\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Commands that are safe to run twice; retried after a reconnect. */\n")
+	names := gen.IdempotentCallNames(commands, "kotlin")
+	if len(names) == 0 {
+		b.WriteString("val IDEMPOTENT_COMMANDS: Set<String> = emptySet()\n")
+	} else {
+		b.WriteString("val IDEMPOTENT_COMMANDS: Set<String> =\n")
+		b.WriteString("    setOf(\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("        %s,\n", name))
+		}
+		b.WriteString("    )\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * The connection dropped while a non-idempotent call was in flight. The\n")
+	b.WriteString(" * peripheral may or may not have run the command, so it is not retried.\n")
+	b.WriteString(" */\n")
+	b.WriteString("class CallInterruptedException(val command: String, cause: Throwable) :\n")
+	b.WriteString("    TransportException(\"$command: interrupted by disconnect\", cause)\n")
+	b.WriteByte('\n')
+	b.WriteString("/** App-level policy of [ResumingClient]. Subclass to override. */\n")
+	b.WriteString("open class ResumePolicy(val maxRetries: Int = 1) {\n")
+	b.WriteString("    /** Whether to reconnect and retry [command] after its [attempt]-th failure. */\n")
+	b.WriteString("    open fun shouldRetry(\n")
+	b.WriteString("        command: String,\n")
+	b.WriteString("        attempt: Int,\n")
+	b.WriteString("        error: Throwable,\n")
+	b.WriteString("    ): Boolean = command in IDEMPOTENT_COMMANDS && attempt <= maxRetries\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Recovers calls of [client] cut off by a dropped connection.\n")
+	b.WriteString(" *\n")
+	b.WriteString(" * Calls made while disconnected run [reconnect] first. Interrupted idempotent\n")
+	b.WriteString(" * calls are retried as [policy] allows; other interrupted calls throw\n")
+	b.WriteString(" * [CallInterruptedException]. A failure while [isConnected] still holds is\n")
+	b.WriteString(" * thrown unchanged.\n")
+	b.WriteString(" */\n")
+	b.WriteString("class ResumingClient(\n")
+	b.WriteString("    private val client: " + gctx.KotlinClientClass() + ",\n")
+	b.WriteString("    private val isConnected: () -> Boolean,\n")
+	b.WriteString("    private val reconnect: suspend () -> Unit,\n")
+	b.WriteString("    private val policy: ResumePolicy = ResumePolicy(),\n")
+	b.WriteString(") : " + gctx.KotlinClientClass() + "() {\n")
+	b.WriteString("    override suspend fun call(\n")
+	b.WriteString("        cmdName: String,\n")
+	b.WriteString("        requestData: ByteArray,\n")
+	b.WriteString("    ): ByteArray = resume(cmdName) { client.call(cmdName, requestData) }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override suspend fun streamReceive(\n")
+	b.WriteString("        cmdName: String,\n")
+	b.WriteString("        requestData: ByteArray,\n")
+	b.WriteString("    ): List<ByteArray> = resume(cmdName) { client.streamReceive(cmdName, requestData) }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override suspend fun streamSend(\n")
+	b.WriteString("        cmdName: String,\n")
+	b.WriteString("        messages: List<ByteArray>,\n")
+	b.WriteString("        finalCmdName: String,\n")
+	b.WriteString("    ): ByteArray = resume(cmdName) { client.streamSend(cmdName, messages, finalCmdName) }\n")
+	b.WriteByte('\n')
+	b.WriteString("    override val capabilityFlags: Int get() = client.capabilityFlags\n")
+	b.WriteByte('\n')
+	b.WriteString("    private suspend fun <T> resume(\n")
+	b.WriteString("        command: String,\n")
+	b.WriteString("        block: suspend () -> T,\n")
+	b.WriteString("    ): T {\n")
+	b.WriteString("        var attempt = 0\n")
+	b.WriteString("        while (true) {\n")
+	b.WriteString("            if (!isConnected()) {\n")
+	b.WriteString("                reconnect()\n")
+	b.WriteString("            }\n")
+	b.WriteString("            try {\n")
+	b.WriteString("                return client.exclusive { block() }\n")
+	b.WriteString("            } catch (e: Exception) {\n")
+	b.WriteString("                // A read timeout is a TimeoutCancellationException; only real\n")
+	b.WriteString("                // cancellation ends the call right away.\n")
+	b.WriteString("                if (e is CancellationException && e !is TimeoutCancellationException) throw e\n")
+	b.WriteString("                if (isConnected()) throw e\n")
+	b.WriteString("                attempt++\n")
+	b.WriteString("                if (!policy.shouldRetry(command, attempt, e)) {\n")
+	b.WriteString("                    throw if (command in IDEMPOTENT_COMMANDS) e else CallInterruptedException(command, e)\n")
+	b.WriteString("                }\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
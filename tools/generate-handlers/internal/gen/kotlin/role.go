@@ -0,0 +1,23 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeKotlinRoles(b *strings.Builder, commands []gen.Command) {
+	privileged := gen.PrivilegedCommands(commands)
+	if len(privileged) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** Role each command requires on the peripheral, keyed by wire name; others need \"user\". */\n")
+	b.WriteString("val COMMAND_ROLES: Map<String, String> =\n")
+	b.WriteString("    mapOf(\n")
+	for _, cmd := range privileged {
+		b.WriteString(fmt.Sprintf("        \"%s\" to \"%s\",\n", cmd.Wire(), cmd.Role))
+	}
+	b.WriteString("    )\n")
+}
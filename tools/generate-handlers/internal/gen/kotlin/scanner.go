@@ -0,0 +1,74 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateScanner returns GeneratedScanner.kt, placed next to the
+// generated client. It works on the ScannedDevice records of the app's
+// BleTransport.
+func GenerateScanner(gctx *gen.Context, hasAdvs bool, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import com." + pkg + ".android.ble.ScannedDevice\n")
+	if hasAdvs {
+		b.WriteString("import com.google.protobuf.MessageLite\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** A blerpc peripheral found by a scan; pass [device] to connect. */\n")
+	b.WriteString("data class DiscoveredDevice(\n")
+	b.WriteString("    val device: ScannedDevice,\n")
+	if hasAdvs {
+		b.WriteString("    /** The decoded (blerpc.advertising) message, if the device sent one. */\n")
+		b.WriteString("    val advertisement: MessageLite?,\n")
+	}
+	b.WriteString(") {\n")
+	b.WriteString("    val name: String? get() = device.name\n")
+	b.WriteString("    val address: String get() = device.address\n")
+	b.WriteString("    val rssi: Int get() = device.rssi\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Picks the blerpc peripherals out of scan results. */\n")
+	b.WriteString("object GeneratedScanner {\n")
+	b.WriteString("    /** Service UUID advertised by blerpc peripherals. */\n")
+	b.WriteString(fmt.Sprintf("    const val SERVICE_UUID = \"%s\"\n", gctx.ServiceUUID))
+	b.WriteByte('\n')
+	b.WriteString("    /** Returns [device] as a [DiscoveredDevice], or null if it is not a blerpc peripheral. */\n")
+	if hasAdvs {
+		b.WriteString("    fun discover(\n")
+		b.WriteString("        device: ScannedDevice,\n")
+		b.WriteString("        requireAdvertisement: Boolean = false,\n")
+		b.WriteString("    ): DiscoveredDevice? {\n")
+	} else {
+		b.WriteString("    fun discover(device: ScannedDevice): DiscoveredDevice? {\n")
+	}
+	b.WriteString("        if (device.serviceUuids.none { it.equals(SERVICE_UUID, ignoreCase = true) }) return null\n")
+	if hasAdvs {
+		b.WriteString("        val advertisement = device.manufacturerData[GeneratedAdvertising.COMPANY_ID]?.let { GeneratedAdvertising.parse(it) }\n")
+		b.WriteString("        if (requireAdvertisement && advertisement == null) return null\n")
+		b.WriteString("        return DiscoveredDevice(device, advertisement)\n")
+	} else {
+		b.WriteString("        return DiscoveredDevice(device)\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /** Returns the blerpc peripherals among [devices], strongest first. */\n")
+	if hasAdvs {
+		b.WriteString("    fun discoverAll(\n")
+		b.WriteString("        devices: List<ScannedDevice>,\n")
+		b.WriteString("        requireAdvertisement: Boolean = false,\n")
+		b.WriteString("    ): List<DiscoveredDevice> =\n")
+		b.WriteString("        devices.mapNotNull { discover(it, requireAdvertisement) }.sortedByDescending { it.rssi }\n")
+	} else {
+		b.WriteString("    fun discoverAll(devices: List<ScannedDevice>): List<DiscoveredDevice> =\n")
+		b.WriteString("        devices.mapNotNull { discover(it) }.sortedByDescending { it.rssi }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
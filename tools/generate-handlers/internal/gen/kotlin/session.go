@@ -0,0 +1,63 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinSessionHelpers emits the session key and handshake of the
+// Kotlin client.
+func writeKotlinSessionHelpers(b *strings.Builder, start, auth gen.Command, pkg, pkgCap string) {
+	msg := func(name string) string { return pkg + "." + pkgCap + "." + name }
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Key shared with the peripheral, which the session handshake proves\n")
+	b.WriteString("     * knowledge of. Set it before calling a session- or replay-protected\n")
+	b.WriteString("     * command.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    var sessionKey: ByteArray? = null\n")
+	b.WriteByte('\n')
+	b.WriteString("    private var sessionToken: ByteArray? = null\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Authenticates to the peripheral, unlocking its session-protected\n")
+	b.WriteString("     * commands. Protected calls open a session as needed; call this to\n")
+	b.WriteString("     * authenticate up front.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    suspend fun openSession() {\n")
+	b.WriteString("        exclusive { handshake() }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Runs the session handshake inside the caller's [exclusive] block and\n")
+	b.WriteString("     * keeps the token: the proof is an HMAC-SHA256 of the challenge under\n")
+	b.WriteString("     * [sessionKey].\n")
+	b.WriteString("     */\n")
+	b.WriteString("    private suspend fun handshake(): ByteArray {\n")
+	b.WriteString("        val key = sessionKey ?: throw BlerpcException(\"sessionKey is not set\")\n")
+	b.WriteString(fmt.Sprintf("        val startData = call(%s, %s.getDefaultInstance().toByteArray())\n", gen.CallName(start, "kotlin"), msg(start.RequestMsg)))
+	b.WriteString(fmt.Sprintf("        val challenge = decode(\"%s\", startData) { %s.parseFrom(it) }.challenge\n", start.Snake, msg(start.ResponseMsg)))
+	b.WriteString("        val mac = Mac.getInstance(\"HmacSHA256\").apply { init(SecretKeySpec(key, \"HmacSHA256\")) }\n")
+	b.WriteString(fmt.Sprintf("        val req = %s.newBuilder()\n", msg(auth.RequestMsg)))
+	b.WriteString("            .setProof(ByteString.copyFrom(mac.doFinal(challenge.toByteArray())))\n")
+	b.WriteString("            .build()\n")
+	b.WriteString(fmt.Sprintf("        val authData = call(%s, req.toByteArray())\n", gen.CallName(auth, "kotlin")))
+	b.WriteString(fmt.Sprintf("        val token = decode(\"%s\", authData) { %s.parseFrom(it) }.token.toByteArray()\n", auth.Snake, msg(auth.ResponseMsg)))
+	b.WriteString("        sessionToken = token\n")
+	b.WriteString("        return token\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Sends a session-protected request led by the session token, opening a\n")
+	b.WriteString("     * session first when there is none. A peripheral that lost the session,\n")
+	b.WriteString("     * e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent\n")
+	b.WriteString("     * once more in a new session.\n")
+	b.WriteString("     */\n")
+	b.WriteString("    protected suspend fun sessionCall(cmdName: String, requestData: ByteArray): ByteArray {\n")
+	b.WriteString("        val respData = call(cmdName, (sessionToken ?: handshake()) + requestData)\n")
+	b.WriteString("        if (statusException(cmdName, respData) !is UnauthenticatedException) return respData\n")
+	b.WriteString("        return call(cmdName, handshake() + requestData)\n")
+	b.WriteString("    }\n")
+}
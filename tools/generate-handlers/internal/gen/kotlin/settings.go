@@ -0,0 +1,36 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinSettingsHelpers emits the typed settings accessors of the
+// Kotlin client.
+func writeKotlinSettingsHelpers(b *strings.Builder, commands []gen.Command, pkg, pkgCap string) {
+	get, set := gen.SettingsCommands(commands)
+	if get != nil {
+		cls := pkg + "." + pkgCap + "." + get.Settings.Name
+		for _, f := range get.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("    /** Reads the %s setting. */\n", f.Name))
+			b.WriteString(fmt.Sprintf("    suspend fun get%sSetting(): %s {\n", gen.SettingLabel(f), ResolveType(f, pkg)))
+			b.WriteString(fmt.Sprintf("        val resp = %s(field = %d)\n", gen.ToLowerCamel(get.Camel), f.Number))
+			b.WriteString(fmt.Sprintf("        return decode(\"%s\", resp.value.toByteArray()) { %s.parseFrom(it) }.%s\n", get.Snake, cls, gen.SwiftPropertyName(f.Name)))
+			b.WriteString("    }\n")
+		}
+	}
+	if set != nil {
+		cls := pkg + "." + pkgCap + "." + set.Settings.Name
+		for _, f := range set.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("    /** Writes the %s setting. */\n", f.Name))
+			b.WriteString(fmt.Sprintf("    suspend fun set%sSetting(value: %s) {\n", gen.SettingLabel(f), ResolveType(f, pkg)))
+			b.WriteString(fmt.Sprintf("        val settings = %s.newBuilder().%s(value).build()\n", cls, gen.KotlinSetterName(f.Name)))
+			b.WriteString(fmt.Sprintf("        %s(field = %d, value = settings.toByteString())\n", gen.ToLowerCamel(set.Camel), f.Number))
+			b.WriteString("    }\n")
+		}
+	}
+}
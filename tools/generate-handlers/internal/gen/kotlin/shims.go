@@ -0,0 +1,22 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// WriteRemovedFields emits the extension properties of the removed
+// response fields.
+func WriteRemovedFields(b *strings.Builder, commands []gen.Command, pkg, pkgCap string) {
+	order, byMsg := gen.RemovedResponseFields(commands)
+	for _, msg := range order {
+		for _, f := range byMsg[msg] {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("@Deprecated(\"%s\")\n", gen.RemovedFieldNote(f)))
+			b.WriteString(fmt.Sprintf("val %s.%s.%s.%s: %s\n", pkg, pkgCap, msg, gen.SwiftPropertyName(f.Name), ResolveType(f, pkg)))
+			b.WriteString(fmt.Sprintf("    get() = %s\n", ResolveDefault(f, pkg)))
+		}
+	}
+}
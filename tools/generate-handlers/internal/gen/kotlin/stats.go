@@ -0,0 +1,16 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinRPCStatsHelpers emits the stats helper of the Kotlin client.
+func writeKotlinRPCStatsHelpers(b *strings.Builder, cmd gen.Command, pkg, pkgCap string) {
+	b.WriteByte('\n')
+	b.WriteString("    /** Returns the firmware's per-command counters keyed by command name. */\n")
+	b.WriteString(fmt.Sprintf("    suspend fun rpcStatsByCommand(reset: Boolean = false): Map<String, %s.%s.RpcStat> =\n", pkg, pkgCap))
+	b.WriteString(fmt.Sprintf("        %s(reset = reset).statsList.associateBy { it.name }\n", gen.ToLowerCamel(cmd.Camel)))
+}
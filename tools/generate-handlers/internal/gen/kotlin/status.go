@@ -0,0 +1,27 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func kotlinStatusCheck(cmd gen.Command, indent string) string {
+	if cmd.StatusField == "" {
+		return ""
+	}
+	prop := gen.SwiftPropertyName(cmd.StatusField) + "Value"
+	return fmt.Sprintf("%sif (resp.%s != %d) throw CommandStatusException(\"%s\", \"%s\", resp.%s)\n",
+		indent, prop, cmd.StatusOK, cmd.Snake, cmd.StatusField, prop)
+}
+
+func writeKotlinStatusException(b *strings.Builder) {
+	b.WriteString("/** Thrown when a checked command responds with a non-OK status. */\n")
+	b.WriteString("class CommandStatusException(\n")
+	b.WriteString("    command: String,\n")
+	b.WriteString("    val field: String,\n")
+	b.WriteString("    status: Int,\n")
+	b.WriteString(") : RemoteException(command, status, \"$command failed: $field=$status\")\n")
+	b.WriteByte('\n')
+}
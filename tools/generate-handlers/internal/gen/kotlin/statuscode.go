@@ -0,0 +1,52 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinStatusCodes emits StatusCode, a RemoteException subclass per
+// code and statusException, which decode calls first.
+func writeKotlinStatusCodes(b *strings.Builder) {
+	b.WriteString("/** Status codes of error responses; 128 and up are application codes. */\n")
+	b.WriteString("enum class StatusCode(val code: Int) {\n")
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("    %s(%d),\n", sc.Name, i))
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	for _, sc := range gen.StatusCodes[1:] {
+		b.WriteString(fmt.Sprintf("/** %s */\n", sc.Doc))
+		b.WriteString(fmt.Sprintf("class %sException(command: String) :\n", gen.StatusErrorName(sc.Name)))
+		b.WriteString(fmt.Sprintf("    RemoteException(command, StatusCode.%s.code, \"$command failed: %s\")\n", sc.Name, sc.Name))
+		b.WriteByte('\n')
+	}
+	var tag []string
+	for _, c := range gen.StatusTag {
+		tag = append(tag, fmt.Sprintf("0x%02X.toByte()", c))
+	}
+	b.WriteString("// Error responses hold only a status, in a field no message uses.\n")
+	b.WriteString(fmt.Sprintf("private val STATUS_TAG = byteArrayOf(%s)\n", strings.Join(tag, ", ")))
+	b.WriteByte('\n')
+	b.WriteString("/** Returns the error an error response reports, or null for other responses. */\n")
+	b.WriteString("fun statusException(command: String, data: ByteArray): RemoteException? {\n")
+	b.WriteString("    if (data.size <= STATUS_TAG.size || STATUS_TAG.indices.any { data[it] != STATUS_TAG[it] }) return null\n")
+	b.WriteString("    var status = 0\n")
+	b.WriteString("    var shift = 0\n")
+	b.WriteString("    for (i in STATUS_TAG.size until data.size) {\n")
+	b.WriteString("        val byte = data[i].toInt() and 0xFF\n")
+	b.WriteString("        status = status or ((byte and 0x7F) shl shift)\n")
+	b.WriteString("        shift += 7\n")
+	b.WriteString("        if (byte < 0x80) break\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return when (status) {\n")
+	for _, sc := range gen.StatusCodes[1:] {
+		b.WriteString(fmt.Sprintf("        StatusCode.%s.code -> %sException(command)\n", sc.Name, gen.StatusErrorName(sc.Name)))
+	}
+	b.WriteString("        else -> RemoteException(command, status)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
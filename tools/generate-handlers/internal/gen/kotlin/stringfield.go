@@ -0,0 +1,33 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinStringTooLongException emits the exception the Kotlin client
+// throws for a bounded string argument that is too long.
+func writeKotlinStringTooLongException(b *strings.Builder) {
+	b.WriteString("/** A string argument is longer in UTF-8 than its max_size allows. */\n")
+	b.WriteString("class StringTooLongException(\n")
+	b.WriteString("    val command: String,\n")
+	b.WriteString("    val field: String,\n")
+	b.WriteString("    val size: Int,\n")
+	b.WriteString("    val maxBytes: Int,\n")
+	b.WriteString(") : BlerpcException(\"$command: $field is $size bytes in UTF-8, over the $maxBytes its max_size allows\")\n")
+	b.WriteByte('\n')
+}
+
+// writeKotlinStringCheck emits the UTF-8 length check of string argument f.
+func writeKotlinStringCheck(b *strings.Builder, cmd gen.Command, f gen.Field) {
+	size := f.Name + ".encodeToByteArray().size"
+	cond := fmt.Sprintf("%s > %d", size, f.MaxBytes)
+	if gen.NullableField(f) {
+		cond = f.Name + " != null && " + cond
+	}
+	b.WriteString(fmt.Sprintf("        if (%s) {\n", cond))
+	b.WriteString(fmt.Sprintf("            throw StringTooLongException(\"%s\", \"%s\", %s, %d)\n", cmd.Snake, f.Name, size, f.MaxBytes))
+	b.WriteString("        }\n")
+}
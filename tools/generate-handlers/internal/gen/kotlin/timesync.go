@@ -0,0 +1,28 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinTimeSyncHelpers emits the clock helper of the Kotlin client.
+func writeKotlinTimeSyncHelpers(b *strings.Builder, cmd gen.Command, pkg, pkgCap string) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /**\n")
+	b.WriteString("     * Sets the peripheral's wall clock to this device's. With [compensate], a\n")
+	b.WriteString("     * first exchange measures the round trip and the second adds half of it,\n")
+	b.WriteString("     * the estimated delivery delay.\n")
+	b.WriteString("     */\n")
+	b.WriteString(fmt.Sprintf("    suspend fun syncTime(compensate: Boolean = false): %s.%s.%s {\n", pkg, pkgCap, cmd.ResponseMsg))
+	b.WriteString("        var offsetUs = 0\n")
+	b.WriteString("        if (compensate) {\n")
+	b.WriteString("            val start = System.nanoTime()\n")
+	b.WriteString(fmt.Sprintf("            %s(unix_time_us = System.currentTimeMillis() * 1000)\n", method))
+	b.WriteString("            offsetUs = ((System.nanoTime() - start) / 2000).toInt()\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        return %s(unix_time_us = System.currentTimeMillis() * 1000, offset_us = offsetUs)\n", method))
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,79 @@
+package kotlin
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// kotlinDefaults maps proto field types to Kotlin default values.
+var kotlinDefaults = map[string]string{
+	"string":   "\"\"",
+	"bytes":    "com.google.protobuf.ByteString.EMPTY",
+	"uint32":   "0",
+	"int32":    "0",
+	"uint64":   "0L",
+	"int64":    "0L",
+	"sint32":   "0",
+	"sint64":   "0L",
+	"fixed32":  "0",
+	"fixed64":  "0L",
+	"sfixed32": "0",
+	"sfixed64": "0L",
+	"float":    "0.0f",
+	"double":   "0.0",
+	"bool":     "false",
+}
+
+func scalarKotlinType(f gen.Field, pkg string) string {
+	if f.IsEnum {
+		return "Int"
+	}
+	if f.IsMessage && !protomodel.IsWellKnownType(f.Type) {
+		return gen.MessageTypeName(f.Type, f.TypeFile, "kotlin", pkg)
+	}
+	if f.IsMessage {
+		return f.Type
+	}
+	if t, ok := gen.KotlinTypes[f.Type]; ok {
+		return t
+	}
+	return "Any"
+}
+
+func ResolveType(f gen.Field, pkg string) string {
+	if f.IsMap {
+		k := gen.LookupScalar(gen.KotlinTypes, f.KeyType, "Any")
+		v := gen.LookupScalar(gen.KotlinTypes, f.ValueType, f.ValueType)
+		if f.ValueIsMessage {
+			v = scalarKotlinType(gen.MapValueField(f), pkg)
+		}
+		return "Map<" + k + ", " + v + ">"
+	}
+	base := scalarKotlinType(f, pkg)
+	if f.IsRepeated {
+		return "List<" + base + ">"
+	}
+	return base
+}
+
+func ResolveDefault(f gen.Field, pkg string) string {
+	if d, ok := gen.DefaultLiteral(f, "kotlin"); ok {
+		return d
+	}
+	if f.IsMap {
+		return "emptyMap()"
+	}
+	if f.IsRepeated {
+		return "emptyList()"
+	}
+	if f.IsEnum {
+		return "0"
+	}
+	if f.IsMessage {
+		return scalarKotlinType(f, pkg) + ".getDefaultInstance()"
+	}
+	if d, ok := kotlinDefaults[f.Type]; ok {
+		return d
+	}
+	return "0"
+}
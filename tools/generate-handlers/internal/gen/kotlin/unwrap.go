@@ -0,0 +1,28 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeKotlinUnwrapped emits the unwrapped methods of GeneratedClient.
+func writeKotlinUnwrapped(b *strings.Builder, commands []gen.Command, pkg string) {
+	for _, cmd := range commands {
+		if !cmd.Unwrap {
+			continue
+		}
+		f := cmd.ResponseFields[0]
+		var args []string
+		for _, name := range gen.ParamNames(cmd.RequestFields) {
+			args = append(args, name+" = "+name)
+		}
+		params := strings.Join(kotlinParams(cmd.RequestFields, cmd.RequestMsg, pkg), ", ")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    /** Calls [%s] and returns the %s of its response. */\n", gen.ToLowerCamel(cmd.Camel), f.Name))
+		b.WriteString(Deprecation(cmd, true))
+		b.WriteString(fmt.Sprintf("    suspend fun %s(%s): %s =\n", gen.UnwrapCamel(cmd), params, ResolveType(f, pkg)))
+		b.WriteString(fmt.Sprintf("        %s(%s).%s\n", gen.ToLowerCamel(cmd.Camel), strings.Join(args, ", "), gen.SwiftPropertyName(f.Name)))
+	}
+}
@@ -0,0 +1,29 @@
+package kotlin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateUUIDs returns GeneratedUuids.kt.
+func GenerateUUIDs(gctx *gen.Context, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("package " + gctx.KotlinPackage(pkg) + "\n")
+	b.WriteByte('\n')
+	b.WriteString("import java.util.UUID\n")
+	b.WriteByte('\n')
+	b.WriteString("/** GATT UUIDs of the blerpc service, from blerpc.yaml. */\n")
+	b.WriteString("object GeneratedUuids {\n")
+	b.WriteString("    /** Service advertised by blerpc peripherals. */\n")
+	b.WriteString(fmt.Sprintf("    val SERVICE_UUID: UUID = UUID.fromString(\"%s\")\n", gctx.ServiceUUID))
+	b.WriteByte('\n')
+	b.WriteString("    /** The multiplexed RPC characteristic. */\n")
+	b.WriteString(fmt.Sprintf("    val CHAR_UUID: UUID = UUID.fromString(\"%s\")\n", gctx.CharUUID))
+	writeKotlinGattGroups(gctx, &b)
+	b.WriteString("}\n")
+	return b.String()
+}
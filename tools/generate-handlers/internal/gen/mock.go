@@ -0,0 +1,35 @@
+package gen
+
+// Mock clients let apps unit test code built on the generated client without
+// a peripheral. MockGeneratedClient plugs into the generated methods where
+// BlerpcClient does, at call/stream_receive/stream_send, so every generated
+// method and helper works on it unchanged: it decodes and records each
+// request and answers with the responses a test set for the command, or with
+// an empty response. The replay prefix and session token leading protected
+// requests are dropped before decoding, and without canned responses the
+// session handshake succeeds, so protected commands need no setup.
+
+// MockRequestPrefix returns how many bytes lead the encoded request of cmd:
+// the session token, then the replay counter and MAC.
+func MockRequestPrefix(cmd Command) int {
+	n := 0
+	if cmd.SessionProtected {
+		n += SessionTokenSize
+	}
+	if cmd.ReplayProtected {
+		n += ReplayPrefixSize
+	}
+	return n
+}
+
+// MockRenamedCommands returns the commands the generated methods call by
+// another name than their own: an ID or a wire name kept from a rename.
+func MockRenamedCommands(commands []Command) []Command {
+	var renamed []Command
+	for _, cmd := range commands {
+		if cmd.ID != 0 || cmd.Wire() != cmd.Snake {
+			renamed = append(renamed, cmd)
+		}
+	}
+	return renamed
+}
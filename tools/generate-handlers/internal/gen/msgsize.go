@@ -0,0 +1,10 @@
+package gen
+
+// nanopb sizes its structs from the max_size and max_count bounds in the
+// .options file. The same bounds give the largest encoding of each request
+// and response, which the C handlers compare with the transport buffers at
+// compile time. FT_CALLBACK fields, and string, bytes and repeated fields
+// without a bound, leave a message unbounded.
+
+// UnboundedSize is the max size of a message with an unbounded field.
+const UnboundedSize = -1
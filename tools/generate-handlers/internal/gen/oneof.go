@@ -0,0 +1,62 @@
+package gen
+
+import (
+	"strings"
+)
+
+// The members of a request oneof share one client parameter, so callers set
+// at most one of them: Kotlin takes a generated sealed class, Swift the
+// OneOf_ enum SwiftProtobuf generates. Kotlin responses get an accessor
+// returning the sealed class; Swift responses already expose the enum.
+// Python, TypeScript and Dart keep one parameter per member that is left
+// unset by default. C handler scaffolds switch on the which_ member.
+
+// UpperCamel converts a snake_case name to UpperCamelCase.
+func UpperCamel(name string) string {
+	p := SwiftPropertyName(name)
+	if p == "" {
+		return p
+	}
+	return strings.ToUpper(p[:1]) + p[1:]
+}
+
+// OneofMembers returns the fields of the named oneof, in field order.
+func OneofMembers(fields []Field, oneof string) []Field {
+	var members []Field
+	for _, f := range fields {
+		if f.Oneof == oneof {
+			members = append(members, f)
+		}
+	}
+	return members
+}
+
+// OneofNames returns the oneofs among fields, in order of their first member.
+func OneofNames(fields []Field) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if f.Oneof != "" && !seen[f.Oneof] {
+			seen[f.Oneof] = true
+			names = append(names, f.Oneof)
+		}
+	}
+	return names
+}
+
+// ParamNames returns the client parameter names of request fields: the field
+// names, with each oneof in place of its members.
+func ParamNames(fields []Field) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		switch {
+		case f.Oneof == "":
+			names = append(names, f.Name)
+		case !seen[f.Oneof]:
+			seen[f.Oneof] = true
+			names = append(names, f.Oneof)
+		}
+	}
+	return names
+}
@@ -0,0 +1,9 @@
+package gen
+
+// Output is a generated file and its rendered content.
+type Output struct {
+	Path    string
+	Content string
+	Render  func() string // renders Content when set, see LazyOutput
+	User    bool          // written only while missing
+}
@@ -0,0 +1,21 @@
+package gen
+
+// PriorityNames are the priorities from lowest to highest.
+var PriorityNames = []string{"low", "normal", "high"}
+
+// PriorityData fills the PriorityClient templates, which forward the
+// stream cancellation of clients with P→C streams.
+type PriorityData struct {
+	ServerStreams bool
+}
+
+// PrioritizedCommands returns the commands of a priority other than normal.
+func PrioritizedCommands(commands []Command) []Command {
+	var out []Command
+	for _, cmd := range commands {
+		if cmd.Priority != "" && cmd.Priority != "normal" {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
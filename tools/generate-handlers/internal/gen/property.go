@@ -0,0 +1,17 @@
+package gen
+
+import (
+	"strings"
+)
+
+// PropertySetter returns the Set command of the property of get among
+// commands, which may leave it out for a target.
+func PropertySetter(get Command, commands []Command) (Command, bool) {
+	camel := "Set" + strings.TrimPrefix(get.Camel, "Get")
+	for _, cmd := range commands {
+		if cmd.Camel == camel {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
@@ -0,0 +1,51 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateAdv(gctx *gen.Context, advs []gen.Advertisement, pkg string, companyID uint) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("from google.protobuf import message\n")
+	b.WriteByte('\n')
+	b.WriteString(Pb2Import(gctx, pkg, ".") + "\n")
+	b.WriteByte('\n')
+	b.WriteString("# Bluetooth SIG company identifier of the manufacturer data.\n")
+	b.WriteString(fmt.Sprintf("COMPANY_ID = 0x%04X\n", companyID))
+	b.WriteByte('\n')
+	b.WriteString("# Message class per advertisement type.\n")
+	b.WriteString("ADVERTISEMENT_TYPES = {\n")
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("    %d: %s_pb2.%s,\n", adv.Type, pkg, adv.Message.Name))
+	}
+	b.WriteString("}\n")
+	b.WriteString("\n\n")
+	b.WriteString("def parse_manufacturer_data(manufacturer_data):\n")
+	b.WriteString("    \"\"\"Decode the advertisement in the manufacturer data of a scan result.\n")
+	b.WriteByte('\n')
+	b.WriteString("    manufacturer_data maps company identifiers to data without the\n")
+	b.WriteString("    identifier, like bleak's AdvertisementData.manufacturer_data. Returns\n")
+	b.WriteString("    None when it holds no valid advertisement of this schema.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteString("    data = manufacturer_data.get(COMPANY_ID)\n")
+	b.WriteString("    if not data:\n")
+	b.WriteString("        return None\n")
+	b.WriteString("    cls = ADVERTISEMENT_TYPES.get(data[0])\n")
+	b.WriteString("    if cls is None:\n")
+	b.WriteString("        return None\n")
+	b.WriteString("    msg = cls()\n")
+	b.WriteString("    try:\n")
+	b.WriteString("        msg.ParseFromString(bytes(data[1:]))\n")
+	b.WriteString("    except message.DecodeError:\n")
+	b.WriteString("        return None\n")
+	b.WriteString("    return msg\n")
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateBatch returns generated_batch.py, placed next to the generated
+// client module.
+func GenerateBatch(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	batchable := gen.BatchCommands(commands, streaming)
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	if pyRequestsUseDatetime(batchable) {
+		b.WriteString("import datetime\n")
+	}
+	if HasDeprecations(batchable) {
+		b.WriteString("import warnings\n")
+	}
+	b.WriteString("from collections.abc import Callable\n")
+	b.WriteString("from typing import Generic, TypeVar, cast\n")
+	imports := gen.OverrideImports(batchable, "python")
+	if pyRequestsUseImportedMessages(batchable) {
+		imports = append([]string{"from google.protobuf import message"}, imports...)
+	}
+	if len(imports) > 0 {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(imports, "\n") + "\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString(Pb2Import(gctx, pkg, ".") + "\n")
+	names := []string{"BlerpcError"}
+	if gen.HasCommandIDs(batchable) {
+		names = append(names, "CommandId")
+	}
+	if gen.HasStatusChecks(batchable) {
+		names = append(names, "CommandStatusError")
+	}
+	names = append(names, "GeneratedClientMixin")
+	if gen.HasBoundedBytesRequests(batchable) {
+		names = append(names, "PayloadTooLargeError")
+	}
+	if gen.HasBoundedStringRequests(batchable) {
+		names = append(names, "StringTooLongError")
+	}
+	names = append(names, "_decode", "_rpc_lock")
+	if line := "from .generated_client import " + strings.Join(names, ", "); len(line) <= 88 {
+		b.WriteString(line + "\n")
+	} else {
+		b.WriteString("from .generated_client import (\n")
+		for _, name := range names {
+			b.WriteString("    " + name + ",\n")
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Command name of the batch envelope.\n")
+	b.WriteString(fmt.Sprintf("BATCH_COMMAND = \"%s\"\n", gen.BatchCommandName))
+	b.WriteByte('\n')
+	b.WriteString("T = TypeVar(\"T\")\n")
+	b.WriteString("\n\n")
+	b.WriteString("class BatchError(BlerpcError):\n")
+	b.WriteString("    \"\"\"The batch response holds no complete response for a call.\"\"\"\n")
+	b.WriteString("\n\n")
+	b.WriteString("class BatchCall(Generic[T]):\n")
+	b.WriteString("    \"\"\"The response of a call added to a Batch, once the batch is sent.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, command: str, decode: Callable[[bytes], T]):\n")
+	b.WriteString("        self.command = command\n")
+	b.WriteString("        self._decode = decode\n")
+	b.WriteString("        self._result: T | None = None\n")
+	b.WriteString("        self._error: BaseException | None = None\n")
+	b.WriteString("        self._done = False\n")
+	b.WriteByte('\n')
+	b.WriteString("    def result(self) -> T:\n")
+	b.WriteString("        \"\"\"Return the response, or raise the error of the call.\"\"\"\n")
+	b.WriteString("        if not self._done:\n")
+	b.WriteString("            raise RuntimeError(f\"{self.command}: the batch was not sent\")\n")
+	b.WriteString("        if self._error is not None:\n")
+	b.WriteString("            raise self._error\n")
+	b.WriteString("        return cast(T, self._result)\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _set(self, data: bytes) -> None:\n")
+	b.WriteString("        try:\n")
+	b.WriteString("            self._result = self._decode(data)\n")
+	b.WriteString("        except BlerpcError as e:\n")
+	b.WriteString("            self._error = e\n")
+	b.WriteString("        self._done = True\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _fail(self, error: BaseException) -> None:\n")
+	b.WriteString("        self._error = error\n")
+	b.WriteString("        self._done = True\n")
+	b.WriteString("\n\n")
+	b.WriteString("class Batch:\n")
+	b.WriteString("    \"\"\"Unary calls sent to the peripheral in one request.\n")
+	b.WriteByte('\n')
+	b.WriteString("    The peripheral runs them in order and answers them in one response, so a\n")
+	b.WriteString("    sequence of small calls, such as provisioning, costs one round trip\n")
+	b.WriteString("    instead of one per call:\n")
+	b.WriteByte('\n')
+	b.WriteString("        batch = Batch(client)\n")
+	b.WriteString("        echo = batch.echo(message=\"hi\")\n")
+	b.WriteString("        await batch.send()\n")
+	b.WriteString("        print(echo.result().message)\n")
+	b.WriteByte('\n')
+	b.WriteString("    Streaming commands, and commands with a replay counter or a session\n")
+	b.WriteString("    token, cannot be batched.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, client: GeneratedClientMixin):\n")
+	b.WriteString("        self._client = client\n")
+	b.WriteString("        self._entries: list[bytes] = []\n")
+	b.WriteString("        self._calls: list[BatchCall] = []\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _add(self, name: str, data: bytes, call: BatchCall[T]) -> BatchCall[T]:\n")
+	b.WriteString("        encoded = name.encode()\n")
+	b.WriteString("        header = bytes([len(encoded)]) + encoded + len(data).to_bytes(2, \"little\")\n")
+	b.WriteString("        self._entries.append(header + data)\n")
+	b.WriteString("        self._calls.append(call)\n")
+	b.WriteString("        return call\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def send(self) -> None:\n")
+	b.WriteString("        \"\"\"Send the calls added since the last send and record their results.\n")
+	b.WriteByte('\n')
+	b.WriteString("        Raises the error of the first failed call once every result is\n")
+	b.WriteString("        recorded, or the transport error, which every call then raises too.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        calls, self._calls = self._calls, []\n")
+	b.WriteString("        payload, self._entries = b\"\".join(self._entries), []\n")
+	b.WriteString("        if not calls:\n")
+	b.WriteString("            return\n")
+	b.WriteString("        try:\n")
+	b.WriteString("            async with _rpc_lock(self._client):\n")
+	b.WriteString("                resp_data = await self._client._call(BATCH_COMMAND, payload)\n")
+	b.WriteString("        except Exception as e:\n")
+	b.WriteString("            for call in calls:\n")
+	b.WriteString("                call._fail(e)\n")
+	b.WriteString("            raise\n")
+	b.WriteString("        pos = 0\n")
+	b.WriteString("        for call in calls:\n")
+	b.WriteString("            size = int.from_bytes(resp_data[pos : pos + 2], \"little\")\n")
+	b.WriteString("            if len(resp_data) < pos + 2 + size:\n")
+	b.WriteString("                call._fail(BatchError(f\"{call.command}: no response in the batch\"))\n")
+	b.WriteString("                pos = len(resp_data)\n")
+	b.WriteString("                continue\n")
+	b.WriteString("            call._set(resp_data[pos + 2 : pos + 2 + size])\n")
+	b.WriteString("            pos += 2 + size\n")
+	b.WriteString("        for call in calls:\n")
+	b.WriteString("            if call._error is not None:\n")
+	b.WriteString("                raise call._error\n")
+
+	for _, cmd := range batchable {
+		reqCls := pkg + "_pb2." + cmd.RequestMsg
+		respCls := pkg + "_pb2." + cmd.ResponseMsg
+		var kwargs []string
+		for _, f := range cmd.RequestFields {
+			kwargs = append(kwargs, fmt.Sprintf("%s=%s", f.Name, gen.EncodeValue(f, "python", f.Name)))
+		}
+		b.WriteByte('\n')
+		b.WriteString(pyDef("    ", "def "+gctx.PyMethodName(cmd.Snake), pyMethodParams(cmd, pkg), "BatchCall["+respCls+"]"))
+		b.WriteString(fmt.Sprintf("        \"\"\"Add a call of the %s command.\"\"\"\n", cmd.Snake))
+		b.WriteString(Deprecation(cmd, "        ", true))
+		writePySizeChecks(&b, cmd, "        ")
+		b.WriteString(pyCall("        ", "req = "+reqCls, kwargs...))
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("        def decode(resp_data: bytes) -> %s:\n", respCls))
+		b.WriteString(pyDecodeResp("            ", respCls, "resp_data", cmd.Snake))
+		b.WriteString(pyStatusCheck(cmd, "            "))
+		b.WriteString("            return resp\n")
+		b.WriteByte('\n')
+		b.WriteString(pyCall("        ", "return self._add", gen.CallName(cmd, "python"), "req.SerializeToString()", fmt.Sprintf("BatchCall(\"%s\", decode)", cmd.Snake)))
+	}
+	return b.String()
+}
@@ -0,0 +1,20 @@
+package python
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// pyBleakData fills the template of the Python bleak client.
+type pyBleakData struct {
+	ServiceUUID string
+	CharUUID    string
+}
+
+// GenerateBleakClient returns bleak_client.py, placed next to the
+// generated client module: BleakRpcClient, a GeneratedClientMixin that
+// connects with bleak, discovers the MTU and frames the commands with
+// blerpc_protocol over the RPC characteristic, so a central needs no
+// hand-written transport. It only supports the multiplexed GATT layout.
+func GenerateBleakClient(gctx *gen.Context) string {
+	return gctx.RenderTemplate("py_bleak_client.py.tmpl", pyBleakData{gctx.ServiceUUID, gctx.CharUUID})
+}
@@ -0,0 +1,32 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestGeneratePyBleakClient(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateBleakClient(gctx)
+	for _, want := range []string{
+		"from .generated_client import (\n    GeneratedClientMixin,\n",
+		"SERVICE_UUID = \"12340001-0000-1000-8000-00805f9b34fb\"\n",
+		"CHAR_UUID = \"12340002-0000-1000-8000-00805f9b34fb\"\n",
+		"class BleakRpcClient(GeneratedClientMixin):\n",
+		"        await self._client.start_notify(CHAR_UUID, self._on_notify)\n        self._splitter = ContainerSplitter(mtu=self._client.mtu_size)\n",
+		"        await self._client.write_gatt_char(CHAR_UUID, data, response=False)\n",
+		"    async def _call(self, cmd_name: str, request_data: bytes) -> bytes:\n",
+		"    async def stream_cancel(self) -> None:\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	for i, line := range strings.Split(out, "\n") {
+		if len([]rune(line)) > 88 {
+			t.Errorf("line %d longer than 88 characters: %q", i+1, line)
+		}
+	}
+}
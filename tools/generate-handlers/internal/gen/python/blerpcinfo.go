@@ -0,0 +1,50 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePySchemaMismatchError emits the schema constants and the error
+// verify_schema raises.
+func writePySchemaMismatchError(gctx *gen.Context, b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("# The schema this client was generated from, which verify_schema compares\n")
+	b.WriteString("# with the peripheral's.\n")
+	b.WriteString(fmt.Sprintf("SCHEMA_HASH = \"%s\"\n", gctx.SchemaHash))
+	b.WriteString(fmt.Sprintf("GENERATOR_VERSION = \"%s\"\n", gen.GeneratorVersion))
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class SchemaMismatchError(BlerpcError):\n")
+	b.WriteString("    \"\"\"The peripheral was built from a different schema than this client.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, expected, actual, generator_version):\n")
+	b.WriteString("        super().__init__(\n")
+	b.WriteString("            f\"peripheral schema {actual} (generator {generator_version}) does not \"\n")
+	b.WriteString("            f\"match this client's {expected} (generator {GENERATOR_VERSION})\"\n")
+	b.WriteString("        )\n")
+	b.WriteString("        self.expected = expected\n")
+	b.WriteString("        self.actual = actual\n")
+	b.WriteString("        self.generator_version = generator_version\n")
+}
+
+// writePyBlerpcInfoHelpers emits the schema check of the Python client
+// mixin.
+func writePyBlerpcInfoHelpers(gctx *gen.Context, b *strings.Builder, cmd gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("    async def verify_schema(self):\n")
+	b.WriteString("        \"\"\"Check that the peripheral was built from this client's schema.\n")
+	b.WriteByte('\n')
+	b.WriteString("        Call once on connect. Raises SchemaMismatchError when the schema\n")
+	b.WriteString("        hashes differ; returns the peripheral's info otherwise.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString(fmt.Sprintf("        info = await self.%s()\n", gctx.PyMethodName(cmd.Snake)))
+	b.WriteString("        if info.schema_hash != SCHEMA_HASH:\n")
+	b.WriteString("            raise SchemaMismatchError(\n")
+	b.WriteString("                SCHEMA_HASH, info.schema_hash, info.generator_version\n")
+	b.WriteString("            )\n")
+	b.WriteString("        return info\n")
+}
@@ -0,0 +1,73 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyBuiltinHelpers emits the client helpers of the enabled built-ins.
+func writePyBuiltinHelpers(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	if cmd, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writePyBlerpcInfoHelpers(gctx, b, cmd)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "capabilities"); ok {
+		writePyCapabilitiesHelpers(gctx, b, cmd, commands)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "conn_params"); ok {
+		writePyConnParamsHelpers(gctx, b, cmd, pkg)
+	}
+	if dfu, ok := gen.FirmwareUpdateCommands(commands); ok {
+		writePyDfuHelpers(gctx, b, dfu)
+	}
+	if files, ok := gen.FileTransferCommands(commands); ok {
+		writePyFileTransferHelpers(gctx, b, files)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "log_stream"); ok {
+		writePyLogStreamHelpers(gctx, b, cmd)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "ping"); ok {
+		writePyPingHelpers(gctx, b, cmd)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "rpc_stats"); ok {
+		writePyRPCStatsHelpers(gctx, b, cmd)
+	}
+	if start, auth, ok := gen.SessionCommands(commands); ok {
+		writePySessionHelpers(b, start, auth, pkg)
+	}
+	writePySettingsHelpers(gctx, b, commands, pkg)
+	if cmd, ok := gen.BuiltinCommand(commands, "time_sync"); ok {
+		writePyTimeSyncHelpers(gctx, b, cmd)
+	}
+}
+
+// pyBuiltinsUseTime reports whether the Python helpers of the enabled
+// built-ins need the time module.
+func pyBuiltinsUseTime(commands []gen.Command) bool {
+	_, logStream := gen.BuiltinCommand(commands, "log_stream")
+	_, ping := gen.BuiltinCommand(commands, "ping")
+	_, timeSync := gen.BuiltinCommand(commands, "time_sync")
+	return logStream || ping || timeSync
+}
+
+// writePyConnParamsHelpers emits the connection profile helpers of the
+// Python client mixin.
+func writePyConnParamsHelpers(gctx *gen.Context, b *strings.Builder, cmd gen.Command, pkg string) {
+	b.WriteByte('\n')
+	b.WriteString("    @contextlib.asynccontextmanager\n")
+	b.WriteString("    async def fast_connection(self):\n")
+	b.WriteString("        \"\"\"Run the block on the fast connection profile, e.g. for a DFU.\n")
+	b.WriteByte('\n')
+	b.WriteString("        The balanced profile is requested again when the block exits.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString(fmt.Sprintf("        await self.%s(profile=%s_pb2.CONN_PROFILE_FAST)\n", gctx.PyMethodName(cmd.Snake), pkg))
+	b.WriteString("        try:\n")
+	b.WriteString("            yield\n")
+	b.WriteString("        finally:\n")
+	b.WriteString(fmt.Sprintf("            await self.%s(profile=%s_pb2.CONN_PROFILE_BALANCED)\n", gctx.PyMethodName(cmd.Snake), pkg))
+	b.WriteByte('\n')
+	b.WriteString("    async def use_idle_connection(self):\n")
+	b.WriteString("        \"\"\"Request the low-power profile for a connection that stays idle.\"\"\"\n")
+	b.WriteString(fmt.Sprintf("        return await self.%s(profile=%s_pb2.CONN_PROFILE_LOW_POWER)\n", gctx.PyMethodName(cmd.Snake), pkg))
+}
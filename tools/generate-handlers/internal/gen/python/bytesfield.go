@@ -0,0 +1,45 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyPayloadTooLargeError emits the error the Python client raises for a
+// bounded bytes argument that is too long.
+func writePyPayloadTooLargeError(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class PayloadTooLargeError(BlerpcError, ValueError):\n")
+	b.WriteString("    \"\"\"A bytes argument exceeds the max_size the peripheral accepts.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, command, field, size, max_size):\n")
+	b.WriteString("        super().__init__(\n")
+	b.WriteString("            f\"{command}: {field} is {size} bytes, over its max_size of {max_size}\"\n")
+	b.WriteString("        )\n")
+	b.WriteString("        self.command = command\n")
+	b.WriteString("        self.field = field\n")
+	b.WriteString("        self.size = size\n")
+	b.WriteString("        self.max_size = max_size\n")
+}
+
+// writePySizeChecks emits the length checks of the bounded bytes and string
+// arguments of cmd.
+func writePySizeChecks(b *strings.Builder, cmd gen.Command, indent string) {
+	for _, f := range cmd.RequestFields {
+		if gen.BoundedString(f) {
+			writePyStringCheck(b, cmd, f, indent)
+		}
+		if !gen.BoundedBytes(f) {
+			continue
+		}
+		cond := fmt.Sprintf("len(%s) > %d", f.Name, f.MaxSize)
+		if gen.NullableField(f) {
+			cond = f.Name + " is not None and " + cond
+		}
+		b.WriteString(fmt.Sprintf("%sif %s:\n", indent, cond))
+		b.WriteString(fmt.Sprintf("%s    raise PayloadTooLargeError(\"%s\", \"%s\", len(%s), %d)\n", indent, cmd.Snake, f.Name, f.Name, f.MaxSize))
+	}
+}
@@ -0,0 +1,46 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateCache returns caching_client.py, placed next to the generated
+// client module.
+func GenerateCache(gctx *gen.Context, commands []gen.Command) string {
+	cacheable := gen.CacheableCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("import time\n")
+	b.WriteString("from collections.abc import AsyncIterable, AsyncIterator, Callable, Iterable\n")
+	b.WriteString("from typing import Any\n")
+	b.WriteByte('\n')
+	if gen.HasCommandIDs(cacheable) {
+		b.WriteString("from .generated_client import CommandId, GeneratedClientMixin\n")
+	} else {
+		b.WriteString("from .generated_client import GeneratedClientMixin\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Seconds a response of each cacheable command is served from memory, by\n")
+	b.WriteString("# the names its calls are sent by.\n")
+	if len(cacheable) == 0 {
+		b.WriteString("CACHE_TTLS: dict[str, float] = {}\n")
+	} else {
+		b.WriteString("CACHE_TTLS = {\n")
+		for _, cmd := range cacheable {
+			for _, name := range gen.CacheCallNames(cmd, "python") {
+				b.WriteString(fmt.Sprintf("    %s: %s,\n", name, gen.PyTimeoutSeconds(cmd.CacheTTLMs)))
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("py_caching_client.py.tmpl", nil))
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyCallPolicies emits the call policy table and the helper running a
+// call under a policy.
+func writePyCallPolicies(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("\n\n")
+	b.WriteString("class CallPolicy(NamedTuple):\n")
+	b.WriteString("    \"\"\"Timeout of each attempt of a command and how often it is retried.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    timeout_s: float\n")
+	b.WriteString("    retries: int\n")
+	b.WriteString("\n\n")
+	b.WriteString("# Call policies by command. Commands without one wait as long as the\n")
+	b.WriteString("# transport does and are not retried.\n")
+	b.WriteString("CALL_POLICIES = {\n")
+	for _, cmd := range commands {
+		if cmd.TimeoutMs > 0 {
+			b.WriteString(fmt.Sprintf("    \"%s\": CallPolicy(timeout_s=%s, retries=%d),\n", cmd.Snake, gen.PyTimeoutSeconds(cmd.TimeoutMs), cmd.Retries))
+		}
+	}
+	b.WriteString("}\n")
+	b.WriteString("\n\n")
+	b.WriteString("async def _call_with_policy(command, call):\n")
+	b.WriteString("    # Awaits call() under the policy of command: each attempt fails with\n")
+	b.WriteString("    # TimeoutError after the timeout, and attempts that time out or fail with\n")
+	b.WriteString("    # a TransportError are made again up to the retries.\n")
+	b.WriteString("    policy = CALL_POLICIES[command]\n")
+	b.WriteString("    for attempt in range(policy.retries + 1):\n")
+	b.WriteString("        try:\n")
+	b.WriteString("            return await asyncio.wait_for(call(), policy.timeout_s)\n")
+	b.WriteString("        except TransportError:\n")
+	b.WriteString("            if attempt == policy.retries:\n")
+	b.WriteString("                raise\n")
+	b.WriteString("        except builtins.TimeoutError as e:\n")
+	b.WriteString("            if attempt == policy.retries:\n")
+	b.WriteString("                msg = f\"{command}: no response within {policy.timeout_s} s\"\n")
+	b.WriteString("                raise TimeoutError(msg) from e\n")
+}
+
+// pyPolicyCall renders the statement awaiting a unary call, in a session
+// when the command is session protected, in the compression envelope when it
+// is compressed, and under the call policy of the command when it has one.
+func pyPolicyCall(cmd gen.Command, indent, reqData string) string {
+	fn, args := "self._call", []string{gen.CallName(cmd, "python"), reqData}
+	if cmd.SessionProtected {
+		fn, args = "_session_call", append([]string{"self"}, args...)
+	}
+	if cmd.Compression != "" {
+		fn, args = "_compressed_call", append([]string{"self", "\"" + cmd.Compression + "\""}, args...)
+	}
+	if cmd.TimeoutMs == 0 {
+		return pyCall(indent, "resp_data = await "+fn, args...)
+	}
+	call := fmt.Sprintf("lambda: %s(%s)", fn, strings.Join(args, ", "))
+	return pyCall(indent, "resp_data = await _call_with_policy", "\""+cmd.Snake+"\"", call)
+}
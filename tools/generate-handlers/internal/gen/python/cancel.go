@@ -0,0 +1,32 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyCancelContainer emits the Cancel control command and the function
+// serializing its container.
+func writePyCancelContainer(b *strings.Builder) {
+	b.WriteString("\n\n")
+	b.WriteString("# Control command of the Cancel container, which stops the P2C stream in\n")
+	b.WriteString("# progress on the peripheral. blerpc_protocol has no constant for it.\n")
+	b.WriteString(fmt.Sprintf("CONTROL_CMD_CANCEL = 0x%x\n", gen.ControlCmdCancel))
+	b.WriteString("\n\n")
+	b.WriteString("def make_cancel(transaction_id):\n")
+	b.WriteString("    \"\"\"Serialize a Cancel control container, which carries no payload.\"\"\"\n")
+	b.WriteString("    return bytes([transaction_id & 0xFF, 0, 0xC0 | CONTROL_CMD_CANCEL << 2, 0])\n")
+}
+
+// writePyStreamCancel emits the default stream_cancel of the mixin.
+func writePyStreamCancel(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteString("    async def stream_cancel(self) -> None:\n")
+	b.WriteString("        \"\"\"Stop the P2C stream in progress, whose consumer stopped early.\n")
+	b.WriteByte('\n')
+	b.WriteString("        The default does nothing and the peripheral runs the stream to its\n")
+	b.WriteString("        end; BlerpcClient sends make_cancel() and drains the stream.\n")
+	b.WriteString("        \"\"\"\n")
+}
@@ -0,0 +1,46 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyCapabilitiesHelpers emits the capability helpers of the Python
+// client mixin.
+func writePyCapabilitiesHelpers(gctx *gen.Context, b *strings.Builder, cmd gen.Command, commands []gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("    async def supported_commands(self):\n")
+	b.WriteString("        \"\"\"Return the names of the commands the firmware implements.\n")
+	b.WriteByte('\n')
+	b.WriteString("        Commands the firmware lacks, or leaves on their stubs, are missing.\n")
+	b.WriteString("        Query it once on connect and check the set before calling commands\n")
+	b.WriteString("        some firmware versions lack.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString(fmt.Sprintf("        resp = await self.%s()\n", gctx.PyMethodName(cmd.Snake)))
+	if cmd.ID != 0 {
+		b.WriteString("        ids = {\n")
+		for _, c := range commands {
+			b.WriteString(fmt.Sprintf("            \"%s\": %d,\n", c.Snake, c.ID))
+		}
+		b.WriteString("        }\n")
+		b.WriteString("        bitmap = resp.command_ids\n")
+		b.WriteString("        return {\n")
+		b.WriteString("            name\n")
+		b.WriteString("            for name, i in ids.items()\n")
+		b.WriteString("            if i // 8 < len(bitmap) and bitmap[i // 8] >> (i % 8) & 1\n")
+		b.WriteString("        }\n")
+	} else {
+		b.WriteString("        names = {\n")
+		for _, c := range commands {
+			b.WriteString(fmt.Sprintf("            \"%s\": \"%s\",\n", c.Wire(), c.Snake))
+		}
+		b.WriteString("        }\n")
+		b.WriteString("        return {names[w] for w in resp.names if w in names}\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("    async def supports(self, command):\n")
+	b.WriteString("        \"\"\"Report whether the firmware implements command, e.g. \"echo\".\"\"\"\n")
+	b.WriteString("        return command in await self.supported_commands()\n")
+}
@@ -0,0 +1,66 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateCapture returns capture.py, placed next to the generated client
+// module.
+func GenerateCapture(gctx *gen.Context, commands []gen.Command, messages map[string]gen.Message) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("import argparse\n")
+	b.WriteString("import asyncio\n")
+	b.WriteString("import datetime\n")
+	b.WriteString("import json\n")
+	b.WriteString("import sys\n")
+	b.WriteString("import time\n")
+	b.WriteString("from collections.abc import AsyncIterable, AsyncIterator, Iterable\n")
+	b.WriteString("from typing import IO, Any\n")
+	b.WriteByte('\n')
+	b.WriteString("from ..client import BlerpcClient\n")
+	renamed := gen.MockRenamedCommands(commands)
+	if gen.HasCommandIDs(commands) {
+		b.WriteString("from .generated_client import BlerpcError, CommandId, GeneratedClientMixin\n")
+	} else {
+		b.WriteString("from .generated_client import BlerpcError, GeneratedClientMixin\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Commands by the name the generated methods send for them, where it is\n")
+	b.WriteString("# not their own.\n")
+	if len(renamed) == 0 {
+		b.WriteString("CAPTURE_COMMANDS: dict[str, str] = {}\n")
+	} else {
+		b.WriteString("CAPTURE_COMMANDS = {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("    %s: \"%s\",\n", gen.CallName(cmd, "python"), cmd.Snake))
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Commands whose requests lead with a session token or replay counter,\n")
+	b.WriteString("# which the peripheral accepts once; replay skips them.\n")
+	if skipped := gen.CaptureUnreplayable(commands); len(skipped) == 0 {
+		b.WriteString("UNREPLAYABLE_COMMANDS: frozenset[str] = frozenset()\n")
+	} else {
+		b.WriteString("UNREPLAYABLE_COMMANDS = frozenset(\n")
+		b.WriteString("    {\n")
+		for _, name := range skipped {
+			b.WriteString(fmt.Sprintf("        \"%s\",\n", name))
+		}
+		b.WriteString("    }\n")
+		b.WriteString(")\n")
+	}
+	b.WriteByte('\n')
+	writePyRedactTables(&b, commands, messages)
+
+	b.WriteString(gctx.RenderTemplate("py_capture.py.tmpl", nil))
+	return b.String()
+}
@@ -0,0 +1,23 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGeneratePyCapture_NoRenamedCommands(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateCapture(gctx, []gen.Command{gentest.EchoCommand()}, nil)
+	for _, want := range []string{
+		"from .generated_client import BlerpcError, GeneratedClientMixin\n",
+		"CAPTURE_COMMANDS: dict[str, str] = {}\n",
+		"UNREPLAYABLE_COMMANDS: frozenset[str] = frozenset()\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
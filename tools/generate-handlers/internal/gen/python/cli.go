@@ -0,0 +1,138 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Python CLI (cli.py, run as blerpc-cli or python -m
+// blerpc.generated.cli) is a debugging tool with a subcommand per command
+// and a flag per request field. It connects with BlerpcClient, calls the
+// generated client method of the command with the flags given, so protected
+// commands and call policies work as in an app, and prints each response as
+// protojson. The argument parser is generated; the rest is py_cli.py.tmpl.
+
+// pyCLIData is the data of py_cli.py.tmpl.
+type pyCLIData struct {
+	Imports   string
+	Pb2Import string
+	Parser    string
+	Sessions  bool // the session built-in is on: set session_key from --session-key
+}
+
+// CLIFlag returns the argparse type converter of the flag of f and the
+// help text naming its format.
+func CLIFlag(f gen.Field, pkg string) (conv, help string) {
+	if o, ok := gen.FieldTypeOverride(f, "python"); ok {
+		switch o.Type {
+		case "datetime.datetime":
+			return "datetime.datetime.fromisoformat", "ISO 8601 time"
+		case "datetime.timedelta":
+			return "_seconds", "seconds"
+		}
+		return o.Type, o.Type
+	}
+	switch {
+	case f.IsMap || f.IsRepeated || f.IsMessage:
+		return "_json", ResolveType(f, pkg) + " as JSON"
+	case f.IsEnum:
+		return fmt.Sprintf("_enum(%s_pb2.%s)", pkg, f.Type), f.Type + " name or number"
+	}
+	switch f.Type {
+	case "string":
+		return "str", "string"
+	case "bytes":
+		return "bytes.fromhex", "hex bytes"
+	case "bool":
+		return "_bool", "true or false"
+	case "float", "double":
+		return "float", f.Type
+	}
+	return "_int", f.Type
+}
+
+// writePyCLIParser emits _build_parser, which has the connection options and
+// a subcommand per command.
+func writePyCLIParser(gctx *gen.Context, b *strings.Builder, commands []gen.Command, streaming map[string]string, pkg string) {
+	b.WriteString("def _build_parser() -> argparse.ArgumentParser:\n")
+	b.WriteString("    parser = argparse.ArgumentParser(\n")
+	b.WriteString("        prog=\"blerpc-cli\", description=\"Call the commands of a blerpc peripheral.\"\n")
+	b.WriteString("    )\n")
+	b.WriteString("    parser.add_argument(\n")
+	b.WriteString("        \"--device\", help=\"name or address of the peripheral (default: the nearest)\"\n")
+	b.WriteString("    )\n")
+	b.WriteString("    parser.add_argument(\"--scan-timeout\", type=float, default=5.0, help=\"seconds\")\n")
+	b.WriteString("    parser.add_argument(\"--known-keys\", help=\"known peripheral keys file\")\n")
+	b.WriteString("    parser.add_argument(\n")
+	b.WriteString("        \"--no-encryption\", action=\"store_true\", help=\"allow unencrypted calls\"\n")
+	b.WriteString("    )\n")
+	if _, _, ok := gen.SessionCommands(commands); ok {
+		b.WriteString(pyCall("    ", "parser.add_argument", "\"--session-key\"", "type=bytes.fromhex", "help=\"session key shared with the peripheral, in hex\""))
+	}
+	b.WriteString("    commands = parser.add_subparsers(dest=\"command\", metavar=\"COMMAND\", required=True)\n")
+	for _, cmd := range commands {
+		name := strings.ReplaceAll(cmd.Snake, "_", "-")
+		b.WriteByte('\n')
+		switch streaming[cmd.Snake] {
+		case "c2p":
+			b.WriteString(pyCall("    ", "cmd = commands.add_parser", fmt.Sprintf("%q", name), fmt.Sprintf("help=\"C2P stream %s\"", cmd.Snake)))
+			b.WriteString("    cmd.add_argument(\n")
+			b.WriteString("        \"--input\",\n")
+			b.WriteString("        type=argparse.FileType(),\n")
+			b.WriteString("        default=sys.stdin,\n")
+			b.WriteString(fmt.Sprintf("        help=\"%s per line as JSON (default: stdin)\",\n", cmd.RequestMsg))
+			b.WriteString("    )\n")
+			b.WriteString(pyCall("    ", "cmd.set_defaults", fmt.Sprintf("method=%q", cmd.Snake), "stream=\"c2p\"", fmt.Sprintf("request=%s_pb2.%s", pkg, cmd.RequestMsg)))
+			continue
+		case "p2c":
+			b.WriteString(pyCall("    ", "cmd = commands.add_parser", fmt.Sprintf("%q", name), fmt.Sprintf("help=\"P2C stream %s\"", cmd.Snake)))
+		default:
+			b.WriteString(pyCall("    ", "cmd = commands.add_parser", fmt.Sprintf("%q", name), fmt.Sprintf("help=\"call %s\"", cmd.Snake)))
+		}
+		var fields []string
+		for _, f := range cmd.RequestFields {
+			conv, help := CLIFlag(f, pkg)
+			args := []string{
+				fmt.Sprintf("%q", "--"+strings.ReplaceAll(f.Name, "_", "-")),
+				fmt.Sprintf("dest=\"field_%s\"", f.Name),
+				"type=" + conv,
+			}
+			if f.IsRequired {
+				args = append(args, "required=True")
+			}
+			args = append(args, fmt.Sprintf("help=%q", help))
+			b.WriteString(pyCall("    ", "cmd.add_argument", args...))
+			fields = append(fields, fmt.Sprintf("%q", f.Name))
+		}
+		method, stream := gctx.PyMethodName(cmd.Snake), "\"\""
+		if streaming[cmd.Snake] == "p2c" {
+			method, stream = gctx.PyMethodName("iter_"+cmd.Snake), "\"p2c\""
+		}
+		tuple := "()"
+		switch len(fields) {
+		case 0:
+		case 1:
+			tuple = "(" + fields[0] + ",)"
+		default:
+			tuple = "(" + strings.Join(fields, ", ") + ")"
+		}
+		b.WriteString(pyCall("    ", "cmd.set_defaults", fmt.Sprintf("method=%q", method), "stream="+stream, "fields="+tuple))
+	}
+	b.WriteByte('\n')
+	b.WriteString("    return parser\n")
+}
+
+// GenerateCLI returns cli.py, placed next to the generated client module.
+func GenerateCLI(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	var parser strings.Builder
+	writePyCLIParser(gctx, &parser, commands, streaming, pkg)
+	_, _, sessions := gen.SessionCommands(commands)
+	return gctx.RenderTemplate("py_cli.py.tmpl", pyCLIData{
+		Imports:   strings.Join(gen.OverrideImports(commands, "python"), "\n"),
+		Pb2Import: Pb2Import(gctx, pkg, "."),
+		Parser:    parser.String(),
+		Sessions:  sessions,
+	})
+}
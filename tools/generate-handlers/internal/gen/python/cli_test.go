@@ -0,0 +1,36 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGeneratePyCLI(t *testing.T) {
+	gctx := gen.NewContext()
+	enum := gentest.EnumCommand()
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateCLI(gctx, []gen.Command{gentest.EchoCommand(), enum, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}, streaming, "blerpc")
+	for _, want := range []string{
+		"from ..client import BlerpcClient\n",
+		"from . import blerpc_pb2\n",
+		"        prog=\"blerpc-cli\", description=\"Call the commands of a blerpc peripheral.\"\n",
+		"    cmd = commands.add_parser(\"echo\", help=\"call echo\")\n" +
+			"    cmd.add_argument(\"--message\", dest=\"field_message\", type=str, help=\"string\")\n" +
+			"    cmd.set_defaults(method=\"echo\", stream=\"\", fields=(\"message\",))\n",
+		"    cmd = commands.add_parser(\"counter-stream\", help=\"P2C stream counter_stream\")\n",
+		"method=\"iter_counter_stream\", stream=\"p2c\"",
+		"        help=\"CounterUploadRequest per line as JSON (default: stdin)\",\n",
+		"request=blerpc_pb2.CounterUploadRequest",
+		"    return parser\n\n\nasync def _connect(",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Contains(out, "session_key") {
+		t.Error("--session-key emitted without the session built-in")
+	}
+}
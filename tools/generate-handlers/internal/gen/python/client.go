@@ -0,0 +1,441 @@
+// Package python generates the Python client, its CLI and the Python
+// peripheral server.
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// pyParam renders a keyword-only parameter. proto2 required fields have no
+// default so callers must pass them.
+func pyParam(f gen.Field) string {
+	if o, ok := gen.FieldTypeOverride(f, "python"); ok {
+		if o.Default == "" {
+			return f.Name
+		}
+		return fmt.Sprintf("%s=%s", f.Name, o.Default)
+	}
+	if f.IsRequired {
+		return f.Name
+	}
+	return fmt.Sprintf("%s=%s", f.Name, ResolveDefault(f))
+}
+
+// pyTypedParam is pyParam annotated with the field's Python type; a None
+// default makes the annotation optional.
+func pyTypedParam(f gen.Field, pkg string) string {
+	typ := ResolveType(f, pkg)
+	def := ResolveDefault(f)
+	if o, ok := gen.FieldTypeOverride(f, "python"); ok {
+		typ, def = o.Type, o.Default
+	} else if f.IsRequired {
+		def = ""
+	}
+	switch def {
+	case "":
+		return fmt.Sprintf("%s: %s", f.Name, typ)
+	case "None":
+		return fmt.Sprintf("%s: %s | None = None", f.Name, typ)
+	}
+	return fmt.Sprintf("%s: %s = %s", f.Name, typ, def)
+}
+
+// pyDecodeResp renders the response decoding statement, wrapped the way ruff
+// formats calls that exceed the line length.
+func pyDecodeResp(indent, respCls, data, cmdName string) string {
+	args := fmt.Sprintf("%s(), %s, \"%s\"", respCls, data, cmdName)
+	line := fmt.Sprintf("%sresp = _decode(%s)", indent, args)
+	if len(line) <= 88 {
+		return line + "\n"
+	}
+	if len(indent)+4+len(args) <= 88 {
+		return fmt.Sprintf("%sresp = _decode(\n%s    %s\n%s)\n", indent, indent, args, indent)
+	}
+	return fmt.Sprintf("%sresp = _decode(\n%s    %s(),\n%s    %s,\n%s    \"%s\",\n%s)\n",
+		indent, indent, respCls, indent, data, indent, cmdName, indent)
+}
+
+// pyCall renders a statement ending in a call, wrapped the way ruff formats
+// calls that exceed the line length.
+func pyCall(indent, prefix string, args ...string) string {
+	joined := strings.Join(args, ", ")
+	if line := fmt.Sprintf("%s%s(%s)", indent, prefix, joined); len(line) <= 88 {
+		return line + "\n"
+	}
+	if len(indent)+4+len(joined) <= 88 {
+		return fmt.Sprintf("%s%s(\n%s    %s\n%s)\n", indent, prefix, indent, joined, indent)
+	}
+	var b strings.Builder
+	b.WriteString(indent + prefix + "(\n")
+	for _, a := range args {
+		b.WriteString(indent + "    " + a + ",\n")
+	}
+	b.WriteString(indent + ")\n")
+	return b.String()
+}
+
+// pyDef renders a def line, wrapped the way ruff formats signatures that
+// exceed the line length (a sole parameter gets a trailing comma, and a union
+// annotation too long for its line is parenthesized, a member per line). ret
+// is the return annotation ("" for none).
+func pyDef(indent, prefix string, params []string, ret string) string {
+	tail := ":"
+	if ret != "" {
+		tail = " -> " + ret + ":"
+	}
+	joined := strings.Join(params, ", ")
+	if line := fmt.Sprintf("%s%s(%s)%s", indent, prefix, joined, tail); len(line) <= 88 {
+		return line + "\n"
+	}
+	if len(params) > 1 && len(indent)+4+len(joined) <= 88 {
+		return fmt.Sprintf("%s%s(\n%s    %s\n%s)%s\n", indent, prefix, indent, joined, indent, tail)
+	}
+	var b strings.Builder
+	b.WriteString(indent + prefix + "(\n")
+	for _, p := range params {
+		name, annotation, _ := strings.Cut(p, ": ")
+		if len(indent)+4+len(p)+1 <= 88 || !strings.Contains(annotation, " | ") || strings.Contains(annotation, " = ") {
+			b.WriteString(indent + "    " + p + ",\n")
+			continue
+		}
+		b.WriteString(indent + "    " + name + ": (\n")
+		b.WriteString(indent + "        " + strings.ReplaceAll(annotation, " | ", "\n"+indent+"        | ") + "\n")
+		b.WriteString(indent + "    ),\n")
+	}
+	b.WriteString(indent + ")" + tail + "\n")
+	return b.String()
+}
+
+// pyHandlersData is the data of py_generated_handlers.py.tmpl.
+type pyHandlersData struct {
+	Pb2Import  string
+	Commands   []pyHandler
+	CommandIDs bool
+}
+
+// pyHandler is the BlerpcHandlers method and the COMMANDS entry of a
+// command. Def and FinishDef are the def lines; Wrap breaks the entry over
+// lines as ruff would.
+type pyHandler struct {
+	Snake, Wire, ID, Stream string
+	Request, Response       string
+	Def, FinishDef          string
+	Wrap                    bool
+}
+
+// GenerateHandlers returns generated_handlers.py: the BlerpcHandlers base
+// class with an async method per command, typed by its messages, and the
+// HandlerRegistry that decodes requests by command name or ID, runs the
+// method (or a function registered with the handler/finisher decorators in
+// its place) and encodes the responses.
+func GenerateHandlers(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	d := pyHandlersData{Pb2Import: Pb2Import(gctx, pkg, pkg+".generated"), CommandIDs: gen.HasCommandIDs(commands)}
+	for _, cmd := range commands {
+		h := pyHandler{
+			Snake:    cmd.Snake,
+			Wire:     cmd.Wire(),
+			ID:       idName(cmd),
+			Stream:   streaming[cmd.Snake],
+			Request:  pkg + "_pb2." + cmd.RequestMsg,
+			Response: pkg + "_pb2." + cmd.ResponseMsg,
+		}
+		params := []string{"self", "req: " + h.Request}
+		switch h.Stream {
+		case "p2c":
+			h.Def = pyDef("    ", "async def "+cmd.Snake, params, "AsyncIterator["+h.Response+"]")
+		case "c2p":
+			h.Def = pyDef("    ", "async def "+cmd.Snake, params, "None")
+			h.FinishDef = pyDef("    ", "async def finish_"+cmd.Snake, []string{"self"}, h.Response)
+		default:
+			h.Def = pyDef("    ", "async def "+cmd.Snake, params, h.Response)
+		}
+		entry := fmt.Sprintf("\"%s\", %s, \"%s\"", h.Snake, h.Request, h.Stream)
+		h.Wrap = len(fmt.Sprintf("    \"%s\": (%s),", h.Wire, entry)) > 88
+		d.Commands = append(d.Commands, h)
+	}
+	return gctx.RenderTemplate("py_generated_handlers.py.tmpl", d)
+}
+
+// pyClientData is the data of py_generated_client.py.tmpl. The sections of
+// the features, such as sessions, call policies and the built-ins, are
+// written in Go.
+type pyClientData struct {
+	ConnParams, Datetime, DFU, Time bool
+	HMAC, Warnings, Zlib            bool
+	Properties, Heatshrink          bool
+	Typing                          []string
+	Protobuf                        string   // the google.protobuf modules imported
+	Imports                         []string // the imports of the type overrides
+	Pb2Import                       string
+	Preamble                        string // the module-level helpers before RpcTransport
+	pyClientMethods
+	Trailer string // stream_cancel and the declarations after the mixin
+}
+
+// pyClientMethods is the data of py_client_methods.py.tmpl: the members
+// of a client mixin.
+type pyClientMethods struct {
+	Methods []pyClientMethod
+	Aliases []pyClientAlias
+	Helpers string // the built-in helpers
+}
+
+// pyClientMethod is the method of a command. Stream is "p2c", "c2p" or
+// empty for a unary command. The def lines, Call, Decode and IterDecode
+// are whole lines wrapped as ruff would, at the depth they are placed at;
+// StatusCheck is unindented. A p2c stream has IterDef, the iter_ method
+// yielding responses, and Iter, the loop over it in the list method.
+type pyClientMethod struct {
+	Snake, CallName, Stream        string
+	Request, RequestMsg, Kwargs    string
+	Def, IterDef                   string
+	Deprecation, SizeChecks        string
+	ReplayWire                     string
+	Call, Decode, IterDecode, Iter string
+	StatusCheck                    string
+}
+
+// pyClientAlias is the deprecated method under the old name of a renamed
+// command, calling Target.
+type pyClientAlias struct {
+	Name, Target string
+}
+
+// GenerateClient returns generated_client.py: GeneratedClientMixin with a
+// method per command, the error types and the JSON helpers.
+func GenerateClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	_, connParams := gen.BuiltinCommand(commands, "conn_params")
+	_, dfu := gen.FirmwareUpdateCommands(commands)
+	_, fileTransfer := gen.FileTransferCommands(commands)
+	_, _, sessions := gen.SessionCommands(commands)
+	d := pyClientData{
+		ConnParams: connParams,
+		Datetime:   pyRequestsUseDatetime(commands),
+		DFU:        dfu,
+		Time:       pyBuiltinsUseTime(commands) || gen.HasReplayProtected(commands),
+		HMAC:       sessions || gen.HasReplayProtected(commands),
+		Warnings:   hasRenamedCommands(commands) || HasDeprecations(commands),
+		Zlib:       fileTransfer || dfu || gen.HasCompressed(commands),
+		Properties: hasProperties(commands),
+		Heatshrink: pyUsesHeatshrink(commands),
+		Typing:     pyTypingNames(commands),
+		Protobuf:   pyProtobufImports(commands, "json_format", "message"),
+		Imports:    gen.OverrideImports(commands, "python"),
+		Pb2Import:  Pb2Import(gctx, pkg, "."),
+	}
+
+	var b strings.Builder
+	writePyWellKnownHelpers(&b, commands)
+	writePyErrors(gctx, &b, commands)
+	writePyRPCLock(&b)
+	if gen.HasCallPolicies(commands) {
+		writePyCallPolicies(&b, commands)
+	}
+	if gen.HasClientStreams(commands, streaming) {
+		writePySerializeEach(&b)
+	}
+	if gen.HasReplayProtected(commands) {
+		writePyReplayCounter(&b)
+	}
+	if sessions {
+		writePySessionCall(&b)
+	}
+	if gen.HasCompressed(commands) {
+		WriteCompression(&b, commands)
+	}
+	if gen.HasServerStreams(commands, streaming) {
+		writePyCancelContainer(&b)
+	}
+	if gen.HasCommandIDs(commands) {
+		writePyCommandIDs(&b, commands)
+	}
+	if d.Properties {
+		writePyAsyncProperty(&b)
+	}
+	d.Preamble = b.String()
+
+	d.pyClientMethods = newPyClientMethods(gctx, commands, streaming, pkg)
+
+	b.Reset()
+	if gen.HasServerStreams(commands, streaming) {
+		writePyStreamCancel(&b)
+	}
+	writePyJSONHelpers(&b, commands, pkg)
+	writePyCharacteristics(&b, commands)
+	writePyRoles(&b, commands)
+	d.Trailer = b.String()
+
+	return gctx.RenderTemplate("py_generated_client.py.tmpl", d)
+}
+
+// pyTypingNames returns the typing names a Python client module imports.
+func pyTypingNames(commands []gen.Command) []string {
+	var names []string
+	if hasProperties(commands) {
+		names = append(names, "Any", "Generic")
+	}
+	if gen.HasCallPolicies(commands) {
+		names = append(names, "NamedTuple")
+	}
+	names = append(names, "Protocol")
+	if hasProperties(commands) {
+		names = append(names, "TypeVar")
+	}
+	return names
+}
+
+// pyRequestsUseDatetime reports whether a request field of the commands is
+// annotated with a datetime type (the well-known time types).
+func pyRequestsUseDatetime(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		for _, f := range cmd.RequestFields {
+			if o, ok := gen.FieldTypeOverride(f, "python"); ok && strings.HasPrefix(o.Type, "datetime.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pyRequestsUseImportedMessages reports whether a request field of the
+// commands is annotated as message.Message (see scalarPythonType).
+func pyRequestsUseImportedMessages(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		for _, f := range cmd.RequestFields {
+			if _, ok := gen.FieldTypeOverride(f, "python"); !ok && f.TypeFile != "" && (f.IsMessage || f.ValueIsMessage) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pyMethodParams returns the parameters of the client method of cmd: self,
+// then its request fields as typed keyword-only parameters.
+func pyMethodParams(cmd gen.Command, pkg string) []string {
+	params := []string{"self"}
+	if len(cmd.RequestFields) > 0 {
+		params = append(params, "*")
+	}
+	for _, f := range cmd.RequestFields {
+		params = append(params, pyTypedParam(f, pkg))
+	}
+	return params
+}
+
+// newPyClientMethods fills the methods of the commands, including
+// deprecated aliases, for a client mixin.
+func newPyClientMethods(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) pyClientMethods {
+	var d pyClientMethods
+	for _, stream := range []bool{false, true} {
+		for _, cmd := range commands {
+			dir, ok := streaming[cmd.Snake]
+			if ok != stream {
+				continue
+			}
+			m := pyClientMethod{
+				Snake:       cmd.Snake,
+				CallName:    gen.CallName(cmd, "python"),
+				Stream:      dir,
+				Request:     pkg + "_pb2." + cmd.RequestMsg,
+				RequestMsg:  cmd.RequestMsg,
+				Deprecation: Deprecation(cmd, "        ", dir != "c2p"),
+				StatusCheck: pyStatusCheck(cmd, ""),
+			}
+			respCls := pkg + "_pb2." + cmd.ResponseMsg
+			name := "async def " + gctx.PyMethodName(cmd.Snake)
+			params := pyMethodParams(cmd, pkg)
+			var kwargs, args []string
+			for _, f := range cmd.RequestFields {
+				kwargs = append(kwargs, fmt.Sprintf("%s=%s", f.Name, gen.EncodeValue(f, "python", f.Name)))
+				args = append(args, f.Name+"="+f.Name)
+			}
+			m.Kwargs = strings.Join(kwargs, ", ")
+			var b strings.Builder
+			writePySizeChecks(&b, cmd, "        ")
+			m.SizeChecks = b.String()
+
+			switch dir {
+			case "p2c":
+				m.IterDef = pyDef("    ", "async def "+gctx.PyMethodName("iter_"+cmd.Snake), params, "AsyncIterator["+respCls+"]")
+				m.IterDecode = pyDecodeResp("                    ", respCls, "data", cmd.Snake)
+				m.Def = pyDef("    ", name, params, "list["+respCls+"]")
+				m.Iter = strings.TrimSuffix(pyCall("        ", "async for resp in self."+gctx.PyMethodName("iter_"+cmd.Snake), args...), "\n")
+			case "c2p":
+				// c2p: takes an iterable or async iterable of typed request messages
+				messages := fmt.Sprintf("messages: Iterable[%s] | AsyncIterable[%s]", m.Request, m.Request)
+				m.Def = pyDef("    ", name, []string{"self", messages}, respCls)
+				m.Call = pyCall("            ", "resp_data = await self.stream_send", m.CallName, "raw", m.CallName)
+				m.Decode = pyDecodeResp("        ", respCls, "resp_data", cmd.Snake)
+			default:
+				m.Def = pyDef("    ", name, params, respCls)
+				reqData := "req.SerializeToString()"
+				if cmd.ReplayProtected {
+					m.ReplayWire, reqData = cmd.Wire(), "req_data"
+				}
+				m.Call = pyPolicyCall(cmd, "            ", reqData)
+				m.Decode = pyDecodeResp("        ", respCls, "resp_data", cmd.Snake)
+			}
+			d.Methods = append(d.Methods, m)
+		}
+	}
+
+	// Deprecated aliases for renamed commands
+	for _, cmd := range commands {
+		if cmd.RenamedFrom == "" {
+			continue
+		}
+		d.Aliases = append(d.Aliases, pyClientAlias{
+			Name:   gctx.PyMethodName(protomodel.CamelToSnake(cmd.RenamedFrom)),
+			Target: gctx.PyMethodName(cmd.Snake),
+		})
+	}
+
+	var b strings.Builder
+	writePyUnwrapped(gctx, &b, commands, pkg)
+	writePyProperties(gctx, &b, commands, pkg)
+	writePyBuiltinHelpers(gctx, &b, commands, pkg)
+	d.Helpers = b.String()
+	return d
+}
+
+// writePyJSONHelpers emits protojson conversion helpers keyed by command name,
+// so logs and test fixtures can be human-readable while staying schema-checked.
+func writePyJSONHelpers(b *strings.Builder, commands []gen.Command, pkg string) {
+	b.WriteString("\n\n")
+	b.WriteString("# Request/response message classes per command, for JSON conversion.\n")
+	b.WriteString("COMMAND_MESSAGES = {\n")
+	for _, cmd := range commands {
+		reqCls := pkg + "_pb2." + cmd.RequestMsg
+		respCls := pkg + "_pb2." + cmd.ResponseMsg
+		line := fmt.Sprintf("    \"%s\": (%s, %s),", cmd.Snake, reqCls, respCls)
+		if len(line) <= 88 {
+			b.WriteString(line + "\n")
+		} else {
+			// ruff-compatible: one class per line
+			b.WriteString(fmt.Sprintf("    \"%s\": (\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("        %s,\n", reqCls))
+			b.WriteString(fmt.Sprintf("        %s,\n", respCls))
+			b.WriteString("    ),\n")
+		}
+	}
+	b.WriteString("}\n")
+	b.WriteString("\n\n")
+	b.WriteString("def to_json(message):\n")
+	b.WriteString("    \"\"\"Serialize a request or response message to protojson text.\"\"\"\n")
+	b.WriteString("    return json_format.MessageToJson(message, preserving_proto_field_name=True)\n")
+	b.WriteString("\n\n")
+	b.WriteString("def request_from_json(cmd_name, text):\n")
+	b.WriteString("    \"\"\"Parse protojson text into the request message of cmd_name.\"\"\"\n")
+	b.WriteString("    req_cls, _ = COMMAND_MESSAGES[cmd_name]\n")
+	b.WriteString("    return json_format.Parse(text, req_cls())\n")
+	b.WriteString("\n\n")
+	b.WriteString("def response_from_json(cmd_name, text):\n")
+	b.WriteString("    \"\"\"Parse protojson text into the response message of cmd_name.\"\"\"\n")
+	b.WriteString("    _, resp_cls = COMMAND_MESSAGES[cmd_name]\n")
+	b.WriteString("    return json_format.Parse(text, resp_cls())\n")
+}
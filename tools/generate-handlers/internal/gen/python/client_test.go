@@ -0,0 +1,384 @@
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestGeneratePyHandlers_Echo(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateHandlers(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"class BlerpcHandlers:",
+		"    async def echo(self, req: blerpc_pb2.EchoRequest) -> blerpc_pb2.EchoResponse:\n",
+		"        return blerpc_pb2.EchoResponse()\n",
+		`    "echo": ("echo", blerpc_pb2.EchoRequest, ""),`,
+		"class HandlerRegistry:",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python handlers missing %q", s)
+		}
+	}
+}
+
+func TestGeneratePyHandlers_MultipleCommands(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.EnumCommand()}
+	out := GenerateHandlers(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"    async def echo(",
+		"    async def get_status(",
+		`    "echo": ("echo", blerpc_pb2.EchoRequest, ""),`,
+		`    "get_status": (`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python handlers multiple missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePyHandlers_CustomPkg(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateHandlers(gctx, cmds, nil, "myapp")
+
+	mustContain := []string{
+		"req: myapp_pb2.EchoRequest",
+		"        return myapp_pb2.EchoResponse()\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python handlers custom pkg missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "blerpc") {
+		t.Error("Python handlers custom pkg should not contain 'blerpc'")
+	}
+}
+
+func TestGeneratePyHandlers_Streams(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateHandlers(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"    async def counter_stream(\n        self, req: blerpc_pb2.CounterStreamRequest\n" +
+			"    ) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:\n" +
+			"        yield blerpc_pb2.CounterStreamResponse()\n",
+		"    async def counter_upload(self, req: blerpc_pb2.CounterUploadRequest) -> None:\n        pass\n",
+		"    async def finish_counter_upload(self) -> blerpc_pb2.CounterUploadResponse:\n",
+		`    "counter_stream": ("counter_stream", blerpc_pb2.CounterStreamRequest, "p2c"),`,
+		`    "counter_upload": ("counter_upload", blerpc_pb2.CounterUploadRequest, "c2p"),`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python handlers streams missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestPyDef(t *testing.T) {
+	tests := []struct {
+		params []string
+		ret    string
+		want   string
+	}{
+		{[]string{"self"}, "", "    def f(self):\n"},
+		{[]string{"self", "req: " + strings.Repeat("x", 60)}, "None",
+			"    def f(\n        self, req: " + strings.Repeat("x", 60) + "\n    ) -> None:\n"},
+		{[]string{"req: " + strings.Repeat("x", 80)}, "None",
+			"    def f(\n        req: " + strings.Repeat("x", 80) + ",\n    ) -> None:\n"},
+		{[]string{"self", "m: " + strings.Repeat("x", 50) + " | " + strings.Repeat("y", 50)}, "",
+			"    def f(\n        self,\n        m: (\n            " + strings.Repeat("x", 50) + "\n            | " + strings.Repeat("y", 50) + "\n        ),\n    ):\n"},
+		{[]string{"self", "a: " + strings.Repeat("x", 50), "b: " + strings.Repeat("y", 50)}, "",
+			"    def f(\n        self,\n        a: " + strings.Repeat("x", 50) + ",\n        b: " + strings.Repeat("y", 50) + ",\n    ):\n"},
+	}
+	for _, tt := range tests {
+		if got := pyDef("    ", "def f", tt.params, tt.ret); got != tt.want {
+			t.Errorf("pyDef(%v, %q) = %q, want %q", tt.params, tt.ret, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratePyClient_Echo(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"class GeneratedClientMixin(RpcTransport):",
+		`    async def echo(self, *, message: str = "") -> blerpc_pb2.EchoResponse:`,
+		"class RpcTransport(Protocol):",
+		"    async def _call(self, cmd_name: str, request_data: bytes) -> bytes: ...\n",
+		"blerpc_pb2.EchoRequest(message=message)",
+		"        async with _rpc_lock(self):\n            resp_data = await self._call(\"echo\"",
+		`return vars(client).setdefault("_rpc_call_lock", asyncio.Lock())`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client missing %q", s)
+		}
+	}
+}
+
+func TestGeneratePyClient_CustomPkg(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "myapp")
+
+	mustContain := []string{
+		"from . import myapp_pb2",
+		"myapp_pb2.EchoRequest(",
+		"myapp_pb2.EchoResponse()",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client custom pkg missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "blerpc") {
+		t.Errorf("Python client custom pkg should not contain 'blerpc'")
+	}
+}
+
+func TestGeneratePyClient_Repeated(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.RepeatedCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"names: list[str] | None = None",
+		"ids: list[int] | None = None",
+		"req = blerpc_pb2.BatchRequest(names=names, ids=ids)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client repeated missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePyClient_Oneof(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.OneofCommand()}, nil, "blerpc")
+
+	if !strings.Contains(out, "self, *, limit: int = 0, text: str | None = None, user_id: int | None = None\n") {
+		t.Errorf("Python client oneof members should default to None\nGot:\n%s", out)
+	}
+}
+
+func TestGeneratePyClient_Enum(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EnumCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	if !strings.Contains(out, "async def get_status(") {
+		t.Errorf("Python client enum missing get_status method\nGot:\n%s", out)
+	}
+}
+
+func TestGeneratePyClient_StreamP2C(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"    ) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:\n",
+		"    ) -> list[blerpc_pb2.CounterStreamResponse]:\n",
+		"P2C stream:",
+		"async for data in self.stream_receive(",
+		"ParseFromString(data)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client p2c missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePyClient_StreamC2P(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_upload": "c2p"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"    async def counter_upload(\n        self,\n        messages: (\n" +
+			"            Iterable[blerpc_pb2.CounterUploadRequest]\n" +
+			"            | AsyncIterable[blerpc_pb2.CounterUploadRequest]\n        ),\n" +
+			"    ) -> blerpc_pb2.CounterUploadResponse:\n",
+		"C2P stream:",
+		"self.stream_send(",
+		"SerializeToString()",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client c2p missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePyClient_Map(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MapCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"labels: dict[str, str] | None = None",
+		"counts: dict[str, int] | None = None",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client map missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePyClient_JSONHelpers(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"from google.protobuf import json_format",
+		`"echo": (blerpc_pb2.EchoRequest, blerpc_pb2.EchoResponse),`,
+		"\"counter_stream\": (\n        blerpc_pb2.CounterStreamRequest,\n        blerpc_pb2.CounterStreamResponse,\n    ),",
+		"def to_json(message):",
+		"def request_from_json(cmd_name, text):",
+		"def response_from_json(cmd_name, text):",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client JSON helpers missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePyClient_Proto2(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.Proto2Command()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	want := `self, *, address: int, label: str = "dev"`
+	if !strings.Contains(out, want) {
+		t.Errorf("Python client proto2 missing %q\nGot:\n%s", want, out)
+	}
+}
+
+func TestGeneratePyClient_Proto3Optional(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.OptionalCommand()}, nil, "blerpc")
+
+	want := "self, *, limit: int | None = None, offset: int = 0"
+	if !strings.Contains(out, want) {
+		t.Errorf("Python client optional field missing %q\nGot:\n%s", want, out)
+	}
+}
+
+func TestGeneratePy_WireName(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.WireName = "ec"
+	cmds := []gen.Command{cmd}
+
+	handlers := GenerateHandlers(gctx, cmds, nil, "blerpc")
+	if !strings.Contains(handlers, `"ec": ("echo", blerpc_pb2.EchoRequest, ""),`) {
+		t.Errorf("Python handlers should dispatch on the wire name\nGot:\n%s", handlers)
+	}
+	client := GenerateClient(gctx, cmds, nil, "blerpc")
+	for _, s := range []string{"async def echo(", `self._call("ec",`} {
+		if !strings.Contains(client, s) {
+			t.Errorf("Python client missing %q", s)
+		}
+	}
+}
+
+func TestGeneratePyClient_RenamedFrom(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.RenamedFrom = "Say"
+	out := GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc")
+
+	mustContain := []string{
+		"import warnings\n",
+		"    async def say(self, *args, **kwargs):\n",
+		`"say() is deprecated, use echo()",`,
+		"DeprecationWarning,",
+		"return await self.echo(*args, **kwargs)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"), "import warnings") {
+		t.Error("warnings should only be imported when a command was renamed")
+	}
+}
+
+func TestGeneratePyClient_TypeOverride(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.RequestFields[0].TypeOverrides = map[string]gen.TypeOverride{"python": {
+		Type: "Label", Encode: "str({})", Import: "from device import Label",
+	}}
+	out := GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc")
+
+	mustContain := []string{
+		"from device import Label\n\nfrom . import blerpc_pb2",
+		"async def echo(self, *, message: Label)",
+		"blerpc_pb2.EchoRequest(message=str(message))",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGeneratePyClient_TemplateOverride(t *testing.T) {
+	gctx := gen.NewContext()
+	dir := t.TempDir()
+	tmpl := "{{range .Methods}}    # {{.Snake}} via {{.CallName}}\n{{end}}"
+	if err := os.WriteFile(filepath.Join(dir, "py_client_methods.py.tmpl"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gctx.LoadTemplates(protomodel.OS, dir); err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+	cmds := []gen.Command{gentest.EchoCommand()}
+
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+	if !strings.Contains(out, "    \"\"\"\n\n    # echo via \"echo\"\n") {
+		t.Errorf("client methods not rendered from the replacement\nGot:\n%s", out)
+	}
+	if strings.Contains(out, "async def echo(") {
+		t.Error("built-in method template still in use")
+	}
+	// The modules of a split client render their methods the same way.
+	groups := []gen.CommandGroup{{Name: "Echo", Commands: cmds}}
+	for _, o := range SplitClient(gctx, groups, cmds, nil, "blerpc", "generated_client.py") {
+		if filepath.Base(o.Path) != "echo.py" {
+			continue
+		}
+		if !strings.HasSuffix(o.Content, "    # echo via \"echo\"\n") {
+			t.Errorf("split module not rendered from the replacement\nGot:\n%s", o.Content)
+		}
+		return
+	}
+	t.Error("split client has no echo.py")
+}
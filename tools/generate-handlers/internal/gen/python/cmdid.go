@@ -0,0 +1,28 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// idName returns the one-byte command name carrying the command's ID.
+func idName(cmd gen.Command) string {
+	return string(rune(cmd.ID))
+}
+
+func writePyCommandIDs(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("\n\n")
+	b.WriteString("class CommandId(enum.IntEnum):\n")
+	b.WriteString("    \"\"\"Numeric command IDs, sent as one-character command names.\"\"\"\n")
+	b.WriteByte('\n')
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    %s = %d\n", strings.ToUpper(cmd.Snake), cmd.ID))
+	}
+	b.WriteByte('\n')
+	b.WriteString("    @property\n")
+	b.WriteString("    def wire_name(self) -> str:\n")
+	b.WriteString("        \"\"\"The command name carrying this ID.\"\"\"\n")
+	b.WriteString("        return chr(self)\n")
+}
@@ -0,0 +1,78 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// WriteCompression emits the codecs and the helper sending a compressed
+// call of the Python client.
+func WriteCompression(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("\n\n")
+	b.WriteString("# CAPABILITIES flag of a peripheral answering calls in the _compressed\n")
+	b.WriteString("# envelope.\n")
+	b.WriteString(fmt.Sprintf("CAPABILITY_FLAG_COMPRESSION = 0x%04X\n", gen.CapabilityFlagCompression))
+	b.WriteString(fmt.Sprintf("_COMPRESSED_COMMAND = \"%s\"\n", gen.CompressedCommandName))
+	b.WriteString("# Flags of the envelope: the data is compressed; the response may be.\n")
+	b.WriteString("_FLAG_COMPRESSED = 0x01\n")
+	b.WriteString("_FLAG_ACCEPT_COMPRESSED = 0x02\n")
+	b.WriteString("\n\n")
+	b.WriteString("def _deflate(data):\n")
+	b.WriteString("    compressor = zlib.compressobj(wbits=-15)\n")
+	b.WriteString("    return compressor.compress(data) + compressor.flush()\n")
+	b.WriteString("\n\n")
+	b.WriteString("# Compress and decompress functions by algorithm: raw DEFLATE (RFC 1951),\n")
+	b.WriteString("# and heatshrink (window 8, lookahead 4 bits) when heatshrink2 is installed.\n")
+	b.WriteString("# Calls of a command whose algorithm has no codec are sent uncompressed.\n")
+	b.WriteString("COMPRESSION_CODECS = {\n")
+	b.WriteString("    \"deflate\": (_deflate, lambda data: zlib.decompress(data, wbits=-15)),\n")
+	b.WriteString("}\n")
+	if pyUsesHeatshrink(commands) {
+		b.WriteString("if heatshrink2 is not None:\n")
+		b.WriteString("    COMPRESSION_CODECS[\"heatshrink\"] = (\n")
+		b.WriteString("        lambda data: heatshrink2.compress(data, window_sz2=8, lookahead_sz2=4),\n")
+		b.WriteString("        lambda data: heatshrink2.decompress(data, window_sz2=8, lookahead_sz2=4),\n")
+		b.WriteString("    )\n")
+	}
+	b.WriteString("\n\n")
+	b.WriteString("async def _compressed_call(client, algorithm, command, req_data):\n")
+	b.WriteString("    # Sends a call of a compressed command in the _compressed envelope when\n")
+	b.WriteString("    # the peripheral advertises CAPABILITY_FLAG_COMPRESSION and the algorithm\n")
+	b.WriteString("    # has a codec, compressing the request when that makes it smaller, and\n")
+	b.WriteString("    # returns the response decompressed; sends a plain call otherwise. Call\n")
+	b.WriteString("    # under _rpc_lock.\n")
+	b.WriteString("    codec = COMPRESSION_CODECS.get(algorithm)\n")
+	b.WriteString("    capabilities = getattr(client, \"capability_flags\", 0)\n")
+	b.WriteString("    if codec is None or not capabilities & CAPABILITY_FLAG_COMPRESSION:\n")
+	b.WriteString("        return await client._call(command, req_data)\n")
+	b.WriteString("    compress, decompress = codec\n")
+	b.WriteString("    flags = _FLAG_ACCEPT_COMPRESSED\n")
+	b.WriteString("    packed = compress(req_data)\n")
+	b.WriteString("    if len(packed) < len(req_data):\n")
+	b.WriteString("        flags |= _FLAG_COMPRESSED\n")
+	b.WriteString("        req_data = packed\n")
+	b.WriteString("    name = command.encode()\n")
+	b.WriteString("    envelope = bytes([len(name)]) + name + bytes([flags]) + req_data\n")
+	b.WriteString("    resp_data = await client._call(_COMPRESSED_COMMAND, envelope)\n")
+	b.WriteString("    if not resp_data:\n")
+	b.WriteString("        raise DecodeError(command, \"empty response\")\n")
+	b.WriteString("    if not resp_data[0] & _FLAG_COMPRESSED:\n")
+	b.WriteString("        return resp_data[1:]\n")
+	b.WriteString("    try:\n")
+	b.WriteString("        return decompress(resp_data[1:])\n")
+	b.WriteString("    except Exception as e:\n")
+	b.WriteString("        raise DecodeError(command, e) from e\n")
+}
+
+// pyUsesHeatshrink reports whether a command is compressed with heatshrink,
+// which the Python client gets from the optional heatshrink2 package.
+func pyUsesHeatshrink(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		if cmd.Compression == "heatshrink" {
+			return true
+		}
+	}
+	return false
+}
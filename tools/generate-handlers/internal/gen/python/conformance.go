@@ -0,0 +1,11 @@
+package python
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateLoopback returns loopback_client.py, placed next to the mock
+// client. It reads vectors.json, so it is fixed text.
+func GenerateLoopback(gctx *gen.Context) string {
+	return gctx.RenderTemplate("loopback_client.py.tmpl", nil)
+}
@@ -0,0 +1,23 @@
+package python
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Connection managers own the link of a generated client, the state
+// machine every app otherwise rebuilds around ResumingClient:
+//
+//	DISCONNECTED → CONNECTING → READY ⇄ DEGRADED
+//
+// connect retries with exponential backoff and jitter, a reported link loss
+// reconnects in the background, and calls cut off by a dropped link are
+// replayed for the idempotent commands, as resume.go defines them. A call
+// failing on a live link, other than with an error or undecodable response,
+// marks it DEGRADED until a call succeeds again. The managers share
+// IDEMPOTENT_COMMANDS and CallInterruptedError with the resuming clients
+// and are written next to them.
+
+// GenerateConnectionManager returns connection_manager.py.
+func GenerateConnectionManager(gctx *gen.Context) string {
+	return gctx.RenderTemplate("py_connection_manager.py.tmpl", nil)
+}
@@ -0,0 +1,59 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// HasDeprecations reports whether a command or a request field is deprecated.
+func HasDeprecations(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		if cmd.Deprecated || len(gen.DeprecatedFields(cmd)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Deprecation returns the statements warning about a call of cmd, and,
+// with params, about the deprecated request fields it sets.
+func Deprecation(cmd gen.Command, indent string, params bool) string {
+	var b strings.Builder
+	warn := func(in, msg string) {
+		b.WriteString(in + "warnings.warn(\n")
+		fmt.Fprintf(&b, "%s    \"%s\",\n", in, msg)
+		b.WriteString(in + "    DeprecationWarning,\n")
+		b.WriteString(in + "    stacklevel=2,\n")
+		b.WriteString(in + ")\n")
+	}
+	if cmd.Deprecated {
+		warn(indent, cmd.Snake+"() is deprecated")
+	}
+	if !params {
+		return b.String()
+	}
+	for _, f := range cmd.RequestFields {
+		if !f.Deprecated {
+			continue
+		}
+		def := ResolveDefault(f)
+		if o, ok := gen.FieldTypeOverride(f, "python"); ok {
+			def = o.Default
+		} else if f.IsRequired {
+			def = ""
+		}
+		switch def {
+		case "":
+			warn(indent, fmt.Sprintf("%s(): %s is deprecated", cmd.Snake, f.Name))
+			continue
+		case "None":
+			fmt.Fprintf(&b, "%sif %s is not None:\n", indent, f.Name)
+		default:
+			fmt.Fprintf(&b, "%sif %s != %s:\n", indent, f.Name, def)
+		}
+		warn(indent+"    ", fmt.Sprintf("%s(): %s is deprecated", cmd.Snake, f.Name))
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateDevices returns device_manager.py, placed next to the generated
+// client module. Besides connection bookkeeping it has one <command>_all
+// method per client method that calls it on every connected device.
+func GenerateDevices(gctx *gen.Context, commands []gen.Command, streaming map[string]string) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("import asyncio\n")
+	if imports := gen.OverrideImports(commands, "python"); len(imports) > 0 {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(imports, "\n") + "\n")
+	}
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class DeviceManager:\n")
+	b.WriteString("    \"\"\"Connections to several peripherals, keyed by address.\n")
+	b.WriteByte('\n')
+	b.WriteString("    factory returns a new, unconnected client, e.g. BlerpcClient. Every device\n")
+	b.WriteString("    gets a client of its own, so calls to different devices run concurrently\n")
+	b.WriteString("    while each client still runs one RPC at a time.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, factory):\n")
+	b.WriteString("        self._factory = factory\n")
+	b.WriteString("        self._clients = {}\n")
+	b.WriteString("        self._connecting = {}\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __contains__(self, address):\n")
+	b.WriteString("        return address in self._clients\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __getitem__(self, address):\n")
+	b.WriteString("        \"\"\"The client of address; KeyError if it was never connected.\"\"\"\n")
+	b.WriteString("        return self._clients[address]\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __len__(self):\n")
+	b.WriteString("        return len(self._clients)\n")
+	b.WriteByte('\n')
+	b.WriteString("    @property\n")
+	b.WriteString("    def addresses(self):\n")
+	b.WriteString("        \"\"\"Addresses of the managed devices, in connection order.\"\"\"\n")
+	b.WriteString("        return list(self._clients)\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def __aenter__(self):\n")
+	b.WriteString("        return self\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def __aexit__(self, *exc):\n")
+	b.WriteString("        await self.disconnect_all()\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def connect(self, device):\n")
+	b.WriteString("        \"\"\"Connect to a scanned device and return its client.\n")
+	b.WriteByte('\n')
+	b.WriteString("        A live connection to the same address is reused; concurrent calls for\n")
+	b.WriteString("        one address share a single connection attempt.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        address = device.address\n")
+	b.WriteString("        client = self._clients.get(address)\n")
+	b.WriteString("        if client is not None and client.is_connected:\n")
+	b.WriteString("            return client\n")
+	b.WriteString("        task = self._connecting.get(address)\n")
+	b.WriteString("        if task is None:\n")
+	b.WriteString("            task = asyncio.ensure_future(self._connect(device))\n")
+	b.WriteString("            self._connecting[address] = task\n")
+	b.WriteString("            task.add_done_callback(lambda _: self._connecting.pop(address, None))\n")
+	b.WriteString("        return await asyncio.shield(task)\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def _connect(self, device):\n")
+	b.WriteString("        client = self._factory()\n")
+	b.WriteString("        await client.connect(device)\n")
+	b.WriteString("        self._clients[device.address] = client\n")
+	b.WriteString("        return client\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def connect_all(self, devices):\n")
+	b.WriteString("        \"\"\"Connect to every device concurrently.\n")
+	b.WriteByte('\n')
+	b.WriteString("        Returns {address: client}, with the exception in place of the client\n")
+	b.WriteString("        for devices that could not be connected.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        devices = list(devices)\n")
+	b.WriteString("        results = await asyncio.gather(\n")
+	b.WriteString("            *(self.connect(d) for d in devices), return_exceptions=True\n")
+	b.WriteString("        )\n")
+	b.WriteString("        return {d.address: r for d, r in zip(devices, results)}\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def disconnect(self, address):\n")
+	b.WriteString("        \"\"\"Disconnect address and stop managing it.\"\"\"\n")
+	b.WriteString("        client = self._clients.pop(address, None)\n")
+	b.WriteString("        if client is not None:\n")
+	b.WriteString("            await client.disconnect()\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def disconnect_all(self):\n")
+	b.WriteString("        clients = list(self._clients.values())\n")
+	b.WriteString("        self._clients.clear()\n")
+	b.WriteString("        await asyncio.gather(\n")
+	b.WriteString("            *(c.disconnect() for c in clients), return_exceptions=True\n")
+	b.WriteString("        )\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def broadcast(self, fn, addresses=None):\n")
+	b.WriteString("        \"\"\"Await fn(client) for every connected device concurrently.\n")
+	b.WriteByte('\n')
+	b.WriteString("        addresses limits the call to those devices. Returns {address: result};\n")
+	b.WriteString("        a device whose call failed maps to the exception, so one bad device\n")
+	b.WriteString("        does not hide the results of the others.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        if addresses is None:\n")
+	b.WriteString("            addresses = [a for a, c in self._clients.items() if c.is_connected]\n")
+	b.WriteString("        addresses = list(addresses)\n")
+	b.WriteString("        results = await asyncio.gather(\n")
+	b.WriteString("            *(fn(self._clients[a]) for a in addresses), return_exceptions=True\n")
+	b.WriteString("        )\n")
+	b.WriteString("        return dict(zip(addresses, results))\n")
+
+	for _, cmd := range commands {
+		dir, isStream := streaming[cmd.Snake]
+		params := []string{"self", "*"}
+		var args []string
+		if isStream && dir == "c2p" {
+			params = []string{"self", "messages", "*"}
+			args = []string{"messages"}
+		} else {
+			for _, f := range cmd.RequestFields {
+				params = append(params, pyParam(f))
+				args = append(args, fmt.Sprintf("%s=%s", f.Name, f.Name))
+			}
+		}
+		params = append(params, "addresses=None")
+
+		b.WriteByte('\n')
+		method := gctx.PyMethodName(cmd.Snake + "_all")
+		def := fmt.Sprintf("    async def %s(%s):", method, strings.Join(params, ", "))
+		if len(def) <= 88 {
+			b.WriteString(def + "\n")
+		} else {
+			b.WriteString(fmt.Sprintf("    async def %s(\n", method))
+			for _, p := range params {
+				b.WriteString("        " + p + ",\n")
+			}
+			b.WriteString("    ):\n")
+		}
+		b.WriteString(fmt.Sprintf("        \"\"\"Call %s on every connected device.\"\"\"\n", cmd.Snake))
+		call := fmt.Sprintf("lambda c: c.%s(%s)", gctx.PyMethodName(cmd.Snake), strings.Join(args, ", "))
+		line := fmt.Sprintf("        return await self.broadcast(%s, addresses)", call)
+		if len(line) <= 88 {
+			b.WriteString(line + "\n")
+		} else {
+			b.WriteString("        return await self.broadcast(\n")
+			b.WriteString(fmt.Sprintf("            %s, addresses\n", call))
+			b.WriteString("        )\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGeneratePyDevices(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_upload": "c2p"}
+	out := GenerateDevices(gctx, cmds, streaming)
+	for _, s := range []string{
+		"class DeviceManager:\n",
+		"            task = asyncio.ensure_future(self._connect(device))\n",
+		"    async def echo_all(self, *, message=\"\", addresses=None):\n",
+		"        return await self.broadcast(lambda c: c.echo(message=message), addresses)\n",
+		"    async def counter_upload_all(self, messages, *, addresses=None):\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
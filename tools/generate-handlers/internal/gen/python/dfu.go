@@ -0,0 +1,38 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyDfuHelpers emits the firmware update helper of the Python client
+// mixin.
+func writePyDfuHelpers(gctx *gen.Context, b *strings.Builder, dfu gen.DFUCommands) {
+	b.WriteByte('\n')
+	b.WriteString("    async def update_firmware(self, path, progress=None, *, reboot=True):\n")
+	b.WriteString("        \"\"\"Write the firmware image at path to the peripheral and apply it.\n")
+	b.WriteByte('\n')
+	b.WriteString("        An update interrupted before it was applied continues after the\n")
+	b.WriteString("        bytes the peripheral already has when called again with the same\n")
+	b.WriteString("        image. progress is called with (sent, total) after each chunk. With\n")
+	b.WriteString("        reboot, the peripheral restarts into the image once it is verified.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        image = pathlib.Path(path).read_bytes()\n")
+	b.WriteString("        crc = zlib.crc32(image)\n")
+	b.WriteString(fmt.Sprintf("        begun = await self.%s(size=len(image), crc32=crc)\n", gctx.PyMethodName(dfu.Begin.Snake)))
+	b.WriteString("        offset = begun.offset\n")
+	b.WriteString("        chunk_size = begun.max_chunk or 128\n")
+	b.WriteString("        while offset < len(image):\n")
+	b.WriteString("            chunk = image[offset : offset + chunk_size]\n")
+	b.WriteString(fmt.Sprintf("            resp = await self.%s(offset=offset, data=chunk)\n", gctx.PyMethodName(dfu.Chunk.Snake)))
+	b.WriteString("            if resp.offset == offset:\n")
+	b.WriteString("                raise BlerpcError(f\"{path}: update stalled at byte {offset}\")\n")
+	b.WriteString("            offset = resp.offset\n")
+	b.WriteString("            if progress:\n")
+	b.WriteString("                progress(offset, len(image))\n")
+	b.WriteString(fmt.Sprintf("        done = await self.%s(reboot=reboot)\n", gctx.PyMethodName(dfu.Finalize.Snake)))
+	b.WriteString("        if done.crc32 != crc:\n")
+	b.WriteString("            raise BlerpcError(f\"{path}: update failed verification\")\n")
+}
@@ -0,0 +1,89 @@
+package python
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Every generated client shares one error hierarchy so error handling can be
+// documented once for all targets:
+//
+//	BlerpcError
+//	├── TransportError   link failure; TimeoutError when no response arrived
+//	├── DecodeError      the response payload is not a valid message
+//	└── RemoteError      the peripheral reported a non-OK status
+//
+// The generated methods raise DecodeError and RemoteError themselves;
+// transport implementations raise TransportError and TimeoutError. Error
+// responses raise the RemoteError subclass of their status code (see
+// statuscode.go). In Swift the errors conform to BlerpcErrorProtocol and
+// BlerpcError is an enum of the kinds above, since Swift 5.9 has no typed
+// throws: generated methods throw the structs and BlerpcError(error) sorts
+// them for an exhaustive switch.
+
+func writePyErrors(gctx *gen.Context, b *strings.Builder, commands []gen.Command) {
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class BlerpcError(Exception):\n")
+	b.WriteString("    \"\"\"Base class of errors raised by generated client methods.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class TransportError(BlerpcError):\n")
+	b.WriteString("    \"\"\"The request could not be delivered or the response was lost.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class TimeoutError(TransportError, builtins.TimeoutError):\n")
+	b.WriteString("    \"\"\"The peripheral did not respond in time.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class DecodeError(BlerpcError):\n")
+	b.WriteString("    \"\"\"The response payload is not a valid message.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, command, cause):\n")
+	b.WriteString("        super().__init__(f\"{command}: invalid response: {cause}\")\n")
+	b.WriteString("        self.command = command\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class RemoteError(BlerpcError):\n")
+	b.WriteString("    \"\"\"The peripheral reported a non-OK status.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, command, status, message=None):\n")
+	b.WriteString("        super().__init__(message or f\"{command} failed: status={status}\")\n")
+	b.WriteString("        self.command = command\n")
+	b.WriteString("        self.status = status\n")
+	if gen.HasStatusChecks(commands) {
+		writePyStatusError(b)
+	}
+	writePyStatusCodes(b)
+	if _, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writePySchemaMismatchError(gctx, b)
+	}
+	if gen.HasBoundedBytesRequests(commands) {
+		writePyPayloadTooLargeError(b)
+	}
+	if gen.HasBoundedStringRequests(commands) {
+		writePyStringTooLongError(b)
+	}
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("def _decode(resp, data, command):\n")
+	b.WriteString("    _check_status(data, command)\n")
+	b.WriteString("    try:\n")
+	b.WriteString("        resp.ParseFromString(data)\n")
+	b.WriteString("    except message.DecodeError as e:\n")
+	b.WriteString("        raise DecodeError(command, e) from e\n")
+	b.WriteString("    return resp\n")
+}
+
+// writePyRPCLock emits _rpc_lock, which the generated methods hold around
+// each exchange so a client never has two RPCs in flight.
+func writePyRPCLock(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("def _rpc_lock(client):\n")
+	b.WriteString("    # The peripheral handles one RPC at a time, so concurrent calls on one\n")
+	b.WriteString("    # client would interleave their packets. Created on first use, as the\n")
+	b.WriteString("    # mixin has no __init__.\n")
+	b.WriteString("    return vars(client).setdefault(\"_rpc_call_lock\", asyncio.Lock())\n")
+}
@@ -0,0 +1,43 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGeneratePyClient_Errors(t *testing.T) {
+	gctx := gen.NewContext()
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}, streaming, "blerpc")
+
+	mustContain := []string{
+		"import builtins\n",
+		"from google.protobuf import json_format, message\n",
+		"class BlerpcError(Exception):",
+		"class TransportError(BlerpcError):",
+		"class TimeoutError(TransportError, builtins.TimeoutError):",
+		"class DecodeError(BlerpcError):",
+		"class RemoteError(BlerpcError):",
+		"    except message.DecodeError as e:\n        raise DecodeError(command, e) from e\n",
+		"        resp = _decode(blerpc_pb2.EchoResponse(), resp_data, \"echo\")\n",
+		"                    resp = _decode(\n                        blerpc_pb2.CounterStreamResponse(), data, \"counter_stream\"\n                    )\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestPyDecodeResp_Wraps(t *testing.T) {
+	got := pyDecodeResp("            ", "blerpc_pb2.AVeryLongResponseMessageName", "data", "a_long_command")
+	want := "            resp = _decode(\n" +
+		"                blerpc_pb2.AVeryLongResponseMessageName(), data, \"a_long_command\"\n" +
+		"            )\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
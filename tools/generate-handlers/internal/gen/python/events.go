@@ -0,0 +1,31 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateEvents(gctx *gen.Context, events []gen.Event, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString(Pb2Import(gctx, pkg, ".") + "\n")
+	b.WriteString("from .generated_client import _decode\n")
+	for _, ev := range events {
+		cls := pkg + "_pb2." + ev.Message.Name
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("async def subscribe_%s(client):\n", ev.Snake))
+		b.WriteString(fmt.Sprintf("    \"\"\"Yield each %s the peripheral notifies.\n", ev.Message.Name))
+		b.WriteByte('\n')
+		b.WriteString("    client is a connected BlerpcClient; leave the loop to unsubscribe.\n")
+		b.WriteString("    \"\"\"\n")
+		b.WriteString(fmt.Sprintf("    async for data in client.events(\"%s\"):\n", ev.Snake))
+		b.WriteString(pyCall("        ", "yield _decode", cls+"()", "data", "\""+ev.Snake+"\""))
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Fault injection (fault_injection: true in blerpc.yaml) tests the
+// timeouts, retries and error handling of an application against a
+// misbehaving link, deterministically in CI. The Python FaultyClient
+// (faults.py) and the Go FaultyTransport (faults.go, in the Go client
+// package) wrap the raw transport calls of a client, like the capture
+// recorders, and drop, delay, duplicate or corrupt the request and response
+// frames at the rates of a FaultProfile. The faults are drawn from a random
+// source seeded by the profile, so a test sees the same faults on every run.
+// A lost frame fails the call with the TimeoutError of the client once the
+// profile's timeout passes. The fixed code is py_faults.py.tmpl and
+// go_faults.go.tmpl.
+
+// GenerateFaults returns faults.py, placed next to the generated client
+// module.
+func GenerateFaults(gctx *gen.Context, commands []gen.Command) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("import asyncio\n")
+	b.WriteString("import random\n")
+	b.WriteString("from collections.abc import AsyncIterable, AsyncIterator, Collection, Iterable\n")
+	b.WriteString("from dataclasses import dataclass, field\n")
+	b.WriteString("from typing import Any, NoReturn\n")
+	b.WriteByte('\n')
+	renamed := gen.MockRenamedCommands(commands)
+	if gen.HasCommandIDs(commands) {
+		b.WriteString("from .generated_client import CommandId, GeneratedClientMixin, TimeoutError\n")
+	} else {
+		b.WriteString("from .generated_client import GeneratedClientMixin, TimeoutError\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Commands by the name the generated methods send for them, where it is\n")
+	b.WriteString("# not their own.\n")
+	if len(renamed) == 0 {
+		b.WriteString("FAULT_COMMANDS: dict[str, str] = {}\n")
+	} else {
+		b.WriteString("FAULT_COMMANDS = {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("    %s: \"%s\",\n", gen.CallName(cmd, "python"), cmd.Snake))
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("py_faults.py.tmpl", nil))
+	return b.String()
+}
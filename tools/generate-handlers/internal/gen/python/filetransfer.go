@@ -0,0 +1,82 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyFileTransferHelpers emits the upload and download helpers of the
+// Python client mixin.
+func writePyFileTransferHelpers(gctx *gen.Context, b *strings.Builder, files gen.FileCommands) {
+	b.WriteByte('\n')
+	b.WriteString("    async def upload_file(\n")
+	b.WriteString("        self, path, data, *, resume=False, chunk_size=128, progress=None\n")
+	b.WriteString("    ):\n")
+	b.WriteString("        \"\"\"Write data to path on the peripheral and verify it by CRC-32.\n")
+	b.WriteByte('\n')
+	b.WriteString("        With resume, an interrupted upload continues after the bytes already\n")
+	b.WriteString("        written, if they match the start of data. progress is called with\n")
+	b.WriteString("        (sent, total) after each chunk.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString(fmt.Sprintf("        opened = await self.%s(path=path, write=True, resume=resume)\n", gctx.PyMethodName(files.Open.Snake)))
+	b.WriteString("        offset = opened.size\n")
+	b.WriteString("        if offset and (\n")
+	b.WriteString("            offset > len(data) or zlib.crc32(data[:offset]) != opened.crc32\n")
+	b.WriteString("        ):\n")
+	b.WriteString("            # The partial file is not a prefix of data: start over.\n")
+	b.WriteString(fmt.Sprintf("            await self.%s(handle=opened.handle)\n", gctx.PyMethodName(files.Close.Snake)))
+	b.WriteString(fmt.Sprintf("            opened = await self.%s(path=path, write=True)\n", gctx.PyMethodName(files.Open.Snake)))
+	b.WriteString("            offset = 0\n")
+	b.WriteString("        try:\n")
+	b.WriteString("            while offset < len(data):\n")
+	b.WriteString("                chunk = data[offset : offset + chunk_size]\n")
+	b.WriteString(fmt.Sprintf("                await self.%s(\n", gctx.PyMethodName(files.Write.Snake)))
+	b.WriteString("                    handle=opened.handle, offset=offset, data=chunk\n")
+	b.WriteString("                )\n")
+	b.WriteString("                offset += len(chunk)\n")
+	b.WriteString("                if progress:\n")
+	b.WriteString("                    progress(offset, len(data))\n")
+	b.WriteString("        except BaseException:\n")
+	b.WriteString("            try:\n")
+	b.WriteString(fmt.Sprintf("                await self.%s(handle=opened.handle)\n", gctx.PyMethodName(files.Close.Snake)))
+	b.WriteString("            except BlerpcError:\n")
+	b.WriteString("                pass\n")
+	b.WriteString("            raise\n")
+	b.WriteString(fmt.Sprintf("        closed = await self.%s(handle=opened.handle, verify=True)\n", gctx.PyMethodName(files.Close.Snake)))
+	b.WriteString("        if closed.size != len(data) or closed.crc32 != zlib.crc32(data):\n")
+	b.WriteString("            raise BlerpcError(f\"{path}: upload failed verification\")\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def download_file(\n")
+	b.WriteString("        self, path, *, partial=b\"\", chunk_size=128, progress=None\n")
+	b.WriteString("    ):\n")
+	b.WriteString("        \"\"\"Read path from the peripheral and verify it by CRC-32.\n")
+	b.WriteByte('\n')
+	b.WriteString("        Pass the bytes received before an interruption as partial to\n")
+	b.WriteString("        continue after them. progress is called with (received, total)\n")
+	b.WriteString("        after each chunk.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString(fmt.Sprintf("        opened = await self.%s(path=path)\n", gctx.PyMethodName(files.Open.Snake)))
+	b.WriteString("        data = bytearray(partial[: opened.size])\n")
+	b.WriteString("        try:\n")
+	b.WriteString("            while len(data) < opened.size:\n")
+	b.WriteString(fmt.Sprintf("                resp = await self.%s(\n", gctx.PyMethodName(files.Read.Snake)))
+	b.WriteString("                    handle=opened.handle, offset=len(data), length=chunk_size\n")
+	b.WriteString("                )\n")
+	b.WriteString("                if not resp.data:\n")
+	b.WriteString("                    break\n")
+	b.WriteString("                data += resp.data\n")
+	b.WriteString("                if progress:\n")
+	b.WriteString("                    progress(len(data), opened.size)\n")
+	b.WriteString("        except BaseException:\n")
+	b.WriteString("            try:\n")
+	b.WriteString(fmt.Sprintf("                await self.%s(handle=opened.handle)\n", gctx.PyMethodName(files.Close.Snake)))
+	b.WriteString("            except BlerpcError:\n")
+	b.WriteString("                pass\n")
+	b.WriteString("            raise\n")
+	b.WriteString(fmt.Sprintf("        await self.%s(handle=opened.handle)\n", gctx.PyMethodName(files.Close.Snake)))
+	b.WriteString("        if len(data) != opened.size or zlib.crc32(data) != opened.crc32:\n")
+	b.WriteString("            raise BlerpcError(f\"{path}: download failed verification\")\n")
+	b.WriteString("        return bytes(data)\n")
+}
@@ -0,0 +1,22 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writePyCharacteristics(b *strings.Builder, commands []gen.Command) {
+	if gen.GATTServiceUUID(commands) == "" {
+		return
+	}
+	b.WriteString("\n\n")
+	b.WriteString("# GATT UUIDs of the characteristic-per-command mode, keyed by wire name.\n")
+	b.WriteString(fmt.Sprintf("COMMAND_SERVICE_UUID = \"%s\"\n", gen.GATTServiceUUID(commands)))
+	b.WriteString("COMMAND_CHARACTERISTICS = {\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    \"%s\": \"%s\",\n", cmd.Wire(), cmd.CharUUID))
+	}
+	b.WriteString("}\n")
+}
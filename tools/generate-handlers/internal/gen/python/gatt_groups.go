@@ -0,0 +1,38 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writePyGattGroups(gctx *gen.Context, b *strings.Builder) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("# GATT services grouping commands apart from the RPC service, from\n")
+	b.WriteString("# blerpc.yaml: (service UUID, characteristic UUID) by name.\n")
+	b.WriteString("SERVICES = {\n")
+	for _, g := range gctx.GATTGroups {
+		b.WriteString(fmt.Sprintf("    \"%s\": (\n", g.Name))
+		b.WriteString(fmt.Sprintf("        \"%s\",\n", g.ServiceUUID))
+		b.WriteString(fmt.Sprintf("        \"%s\",\n", g.CharUUID))
+		b.WriteString("    ),\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("# Service of each grouped command, by wire name; the others use\n")
+	b.WriteString("# SERVICE_UUID and CHAR_UUID.\n")
+	b.WriteString("COMMAND_SERVICES = {\n")
+	for _, c := range gctx.GATTGroupCommands() {
+		b.WriteString(fmt.Sprintf("    \"%s\": \"%s\",\n", c[0], c[1]))
+	}
+	b.WriteString("}\n")
+	b.WriteString("\n\n")
+	b.WriteString("def characteristic_for(command: str) -> str:\n")
+	b.WriteString("    \"\"\"Return the UUID of the characteristic command is written to.\"\"\"\n")
+	b.WriteString("    service = COMMAND_SERVICES.get(command)\n")
+	b.WriteString("    return SERVICES[service][1] if service else CHAR_UUID\n")
+}
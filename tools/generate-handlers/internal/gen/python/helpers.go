@@ -0,0 +1,15 @@
+package python
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// hasRenamedCommands reports whether any command records a previous name.
+func hasRenamedCommands(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		if cmd.RenamedFrom != "" {
+			return true
+		}
+	}
+	return false
+}
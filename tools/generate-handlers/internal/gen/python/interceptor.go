@@ -0,0 +1,56 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Instrumented clients wrap a generated client and report each exchange
+// with the peripheral to interceptors: the command, the request and
+// response sizes and the duration, or the error. MetricsCollector, the
+// default interceptor, keeps per-command counters for analytics and
+// latency dashboards. Interceptors see commands by their schema names,
+// also when the generated methods send them by command ID. The fixed code
+// is in the *InstrumentedClient*.tmpl templates.
+
+// GenerateInstrumented returns instrumented_client.py, placed next to the
+// generated client module.
+func GenerateInstrumented(gctx *gen.Context, commands []gen.Command) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("import dataclasses\n")
+	b.WriteString("import logging\n")
+	b.WriteString("import time\n")
+	b.WriteString("from collections.abc import AsyncIterable, AsyncIterator, Iterable\n")
+	b.WriteString("from typing import Any\n")
+	b.WriteByte('\n')
+	if gen.HasCommandIDs(commands) {
+		b.WriteString("from .generated_client import CommandId, GeneratedClientMixin\n")
+	} else {
+		b.WriteString("from .generated_client import GeneratedClientMixin\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("logger = logging.getLogger(__name__)\n")
+	b.WriteByte('\n')
+	b.WriteString("# Commands by the name the generated methods send for them, where it is\n")
+	b.WriteString("# not their own.\n")
+	renamed := gen.MockRenamedCommands(commands)
+	if len(renamed) == 0 {
+		b.WriteString("INTERCEPTED_COMMANDS: dict[str, str] = {}\n")
+	} else {
+		b.WriteString("INTERCEPTED_COMMANDS = {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("    %s: \"%s\",\n", gen.CallName(cmd, "python"), cmd.Snake))
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("py_instrumented_client.py.tmpl", nil))
+	return b.String()
+}
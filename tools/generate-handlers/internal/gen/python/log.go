@@ -0,0 +1,34 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyLogStreamHelpers emits the log reader of the Python client mixin.
+func writePyLogStreamHelpers(gctx *gen.Context, b *strings.Builder, cmd gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("    async def read_logs(self, *, min_level=0, max_entries=0):\n")
+	b.WriteString("        \"\"\"Drain the firmware log into (timestamp, entry) pairs, oldest first.\n")
+	b.WriteByte('\n')
+	b.WriteString("        The message of each entry joins the fragments of a long message, and\n")
+	b.WriteString("        the timestamp (seconds since the epoch) is when it was logged.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString(fmt.Sprintf("        responses = await self.%s(min_level=min_level, max_entries=max_entries)\n", gctx.PyMethodName(cmd.Snake)))
+	b.WriteString("        received = time.time()\n")
+	b.WriteString("        logs = []\n")
+	b.WriteString("        first = None\n")
+	b.WriteString("        for resp in responses:\n")
+	b.WriteString("            if first is None:\n")
+	b.WriteString("                first = resp\n")
+	b.WriteString("            else:\n")
+	b.WriteString("                first.message += resp.message\n")
+	b.WriteString("            if resp.more:\n")
+	b.WriteString("                continue\n")
+	b.WriteString("            age_ms = (first.now_ms - first.uptime_ms) & 0xFFFFFFFF\n")
+	b.WriteString("            logs.append((received - age_ms / 1000, first))\n")
+	b.WriteString("            first = None\n")
+	b.WriteString("        return logs\n")
+}
@@ -0,0 +1,140 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateMock returns mock_client.py, placed next to the generated client
+// module.
+func GenerateMock(commands []gen.Command) string {
+	var b strings.Builder
+	renamed := gen.MockRenamedCommands(commands)
+	_, _, sessions := gen.SessionCommands(commands)
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("from collections.abc import AsyncIterable\n")
+	b.WriteString("from typing import NamedTuple\n")
+	b.WriteByte('\n')
+	if gen.HasCommandIDs(commands) {
+		b.WriteString("from .generated_client import COMMAND_MESSAGES, CommandId, GeneratedClientMixin\n")
+	} else {
+		b.WriteString("from .generated_client import COMMAND_MESSAGES, GeneratedClientMixin\n")
+	}
+	if len(renamed) > 0 {
+		b.WriteByte('\n')
+		b.WriteString("# Commands by the name the generated methods send for them.\n")
+		b.WriteString("_COMMAND_NAMES = {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("    %s: \"%s\",\n", gen.CallName(cmd, "python"), cmd.Snake))
+		}
+		b.WriteString("}\n")
+	}
+	prefixed := gen.HasReplayProtected(commands) || gen.HasSessionProtected(commands)
+	if prefixed {
+		b.WriteByte('\n')
+		b.WriteString("# Bytes of session token and replay prefix leading protected requests.\n")
+		b.WriteString("_REQUEST_PREFIXES = {\n")
+		for _, cmd := range commands {
+			if n := gen.MockRequestPrefix(cmd); n > 0 {
+				b.WriteString(fmt.Sprintf("    \"%s\": %d,\n", cmd.Snake, n))
+			}
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("\n\n")
+	b.WriteString("class MockCall(NamedTuple):\n")
+	b.WriteString("    \"\"\"A call recorded by MockGeneratedClient.\n")
+	b.WriteByte('\n')
+	b.WriteString("    requests holds the decoded request, or each message of a C2P stream.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    command: str\n")
+	b.WriteString("    requests: list\n")
+	b.WriteString("\n\n")
+	b.WriteString("class MockGeneratedClient(GeneratedClientMixin):\n")
+	b.WriteString("    \"\"\"Stand-in for BlerpcClient in app unit tests, with no peripheral.\n")
+	b.WriteByte('\n')
+	b.WriteString("    Records every call in calls and answers it with the responses set for its\n")
+	b.WriteString("    command. Commands without any get an empty response, and P2C streams no\n")
+	b.WriteString("    responses.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    is_connected = True\n")
+	if sessions {
+		b.WriteString("    # Any key does: the mock answers the session handshake itself.\n")
+		b.WriteString(fmt.Sprintf("    session_key = bytes(%d)\n", gen.SessionProofSize))
+	}
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self):\n")
+	b.WriteString("        self.calls = []\n")
+	b.WriteString("        self._answers = {}\n")
+	b.WriteByte('\n')
+	b.WriteString("    def respond(self, command, *responses):\n")
+	b.WriteString("        \"\"\"Answer command with responses.\n")
+	b.WriteByte('\n')
+	b.WriteString("        A unary or C2P call gets the first, a P2C stream each of them.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        self._answers[command] = lambda requests: list(responses)\n")
+	b.WriteByte('\n')
+	b.WriteString("    def respond_with(self, command, answer):\n")
+	b.WriteString("        \"\"\"Answer command with the responses answer(requests) returns.\"\"\"\n")
+	b.WriteString("        self._answers[command] = answer\n")
+	b.WriteByte('\n')
+	b.WriteString("    def fail(self, command, error):\n")
+	b.WriteString("        \"\"\"Raise error, e.g. a NotFoundError, from calls of command.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("        def answer(requests):\n")
+	b.WriteString("            raise error\n")
+	b.WriteByte('\n')
+	b.WriteString("        self._answers[command] = answer\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _answer(self, cmd_name, requests):\n")
+	b.WriteString("        # Records the call and returns its command with the responses set\n")
+	b.WriteString("        # for it, None if there are none.\n")
+	if len(renamed) > 0 {
+		b.WriteString("        command = _COMMAND_NAMES.get(cmd_name, cmd_name)\n")
+	} else {
+		b.WriteString("        command = cmd_name\n")
+	}
+	b.WriteString("        req_cls, _ = COMMAND_MESSAGES[command]\n")
+	if prefixed {
+		b.WriteString("        skip = _REQUEST_PREFIXES.get(command, 0)\n")
+		b.WriteString("        decoded = [req_cls.FromString(data[skip:]) for data in requests]\n")
+	} else {
+		b.WriteString("        decoded = [req_cls.FromString(data) for data in requests]\n")
+	}
+	b.WriteString("        self.calls.append(MockCall(command, decoded))\n")
+	b.WriteString("        answer = self._answers.get(command)\n")
+	b.WriteString("        return command, None if answer is None else answer(decoded)\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _first(self, command, responses):\n")
+	b.WriteString("        if responses:\n")
+	b.WriteString("            return responses[0].SerializeToString()\n")
+	b.WriteString("        _, resp_cls = COMMAND_MESSAGES[command]\n")
+	if sessions {
+		b.WriteString("        if command == \"authenticate_session\":\n")
+		b.WriteString("            # A token of the size peripherals issue opens the session.\n")
+		b.WriteString(fmt.Sprintf("            return resp_cls(token=bytes(%d)).SerializeToString()\n", gen.SessionTokenSize))
+	}
+	b.WriteString("        return resp_cls().SerializeToString()\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def _call(self, cmd_name, request_data):\n")
+	b.WriteString("        return self._first(*self._answer(cmd_name, [request_data]))\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def stream_receive(self, cmd_name, request_data):\n")
+	b.WriteString("        _, responses = self._answer(cmd_name, [request_data])\n")
+	b.WriteString("        for resp in responses or []:\n")
+	b.WriteString("            yield resp.SerializeToString()\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def stream_send(self, cmd_name, messages, final_cmd_name):\n")
+	b.WriteString("        if isinstance(messages, AsyncIterable):\n")
+	b.WriteString("            messages = [m async for m in messages]\n")
+	b.WriteString("        return self._first(*self._answer(cmd_name, list(messages)))\n")
+	return b.String()
+}
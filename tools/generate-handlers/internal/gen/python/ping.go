@@ -0,0 +1,32 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyPingHelpers emits the latency and keepalive helpers of the Python
+// client mixin.
+func writePyPingHelpers(gctx *gen.Context, b *strings.Builder, cmd gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("    async def measure_latency(self):\n")
+	b.WriteString("        \"\"\"Ping the peripheral and return the round trip in seconds.\"\"\"\n")
+	b.WriteString("        start = time.monotonic()\n")
+	b.WriteString(fmt.Sprintf("        await self.%s()\n", gctx.PyMethodName(cmd.Snake)))
+	b.WriteString("        return time.monotonic() - start\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def keepalive(self, interval=5.0, *, on_latency=None):\n")
+	b.WriteString("        \"\"\"Ping the peripheral every interval seconds until cancelled.\n")
+	b.WriteByte('\n')
+	b.WriteString("        Run it as a task next to the calls: it raises as soon as a ping\n")
+	b.WriteString("        fails, so a dead connection shows before a long call times out.\n")
+	b.WriteString("        on_latency, if given, is called with each round trip in seconds.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        while True:\n")
+	b.WriteString("            latency = await self.measure_latency()\n")
+	b.WriteString("            if on_latency is not None:\n")
+	b.WriteString("                on_latency(latency)\n")
+	b.WriteString("            await asyncio.sleep(interval)\n")
+}
@@ -0,0 +1,61 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GeneratePriority returns priority_client.py, placed next to the
+// generated client module.
+func GeneratePriority(gctx *gen.Context, commands []gen.Command, streaming map[string]string) string {
+	prioritized := gen.PrioritizedCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("import asyncio\n")
+	b.WriteString("import contextlib\n")
+	b.WriteString("import enum\n")
+	b.WriteString("import heapq\n")
+	b.WriteString("import itertools\n")
+	b.WriteString("from collections.abc import AsyncIterable, AsyncIterator, Awaitable, Iterable\n")
+	b.WriteString("from typing import Any, TypeVar\n")
+	b.WriteByte('\n')
+	if gen.HasCommandIDs(prioritized) {
+		b.WriteString("from .generated_client import CommandId, GeneratedClientMixin, TransportError\n")
+	} else {
+		b.WriteString("from .generated_client import GeneratedClientMixin, TransportError\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("_T = TypeVar(\"_T\")\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class Priority(enum.IntEnum):\n")
+	b.WriteString("    \"\"\"Order in which a PriorityClient runs waiting calls.\"\"\"\n")
+	b.WriteByte('\n')
+	for i, name := range gen.PriorityNames {
+		b.WriteString(fmt.Sprintf("    %s = %d\n", strings.ToUpper(name), i))
+	}
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("# Priority of the commands not run at NORMAL, by the names their calls are\n")
+	b.WriteString("# sent by.\n")
+	if len(prioritized) == 0 {
+		b.WriteString("COMMAND_PRIORITIES: dict[str, Priority] = {}\n")
+	} else {
+		b.WriteString("COMMAND_PRIORITIES = {\n")
+		for _, cmd := range prioritized {
+			for _, name := range gen.CacheCallNames(cmd, "python") {
+				b.WriteString(fmt.Sprintf("    %s: Priority.%s,\n", name, strings.ToUpper(cmd.Priority)))
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("py_priority_client.py.tmpl", gen.PriorityData{ServerStreams: gen.HasServerStreams(commands, streaming)}))
+	return b.String()
+}
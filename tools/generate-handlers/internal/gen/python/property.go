@@ -0,0 +1,75 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// hasProperties reports whether a command of commands has a property.
+func hasProperties(commands []gen.Command) bool {
+	for _, cmd := range commands {
+		if _, ok := gen.PropertySetter(cmd, commands); ok && cmd.Property != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// writePyAsyncProperty emits AsyncProperty, the accessor the properties of
+// the Python client return.
+func writePyAsyncProperty(b *strings.Builder) {
+	b.WriteString("\n\n")
+	b.WriteString("_T = TypeVar(\"_T\")\n")
+	b.WriteString("\n\n")
+	b.WriteString("class AsyncProperty(Generic[_T]):\n")
+	b.WriteString("    \"\"\"A value of the peripheral read and written by a get/set command pair.\n")
+	b.WriteByte('\n')
+	b.WriteString("    ``await client.brightness`` reads it and\n")
+	b.WriteString("    ``await client.brightness.set(value)`` writes it.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(\n")
+	b.WriteString("        self,\n")
+	b.WriteString("        read: Callable[[], Awaitable[_T]],\n")
+	b.WriteString("        write: Callable[[_T], Awaitable[None]],\n")
+	b.WriteString("    ) -> None:\n")
+	b.WriteString("        self._read = read\n")
+	b.WriteString("        self._write = write\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __await__(self) -> Generator[Any, None, _T]:\n")
+	b.WriteString("        return self._read().__await__()\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def get(self) -> _T:\n")
+	b.WriteString("        \"\"\"Read the value.\"\"\"\n")
+	b.WriteString("        return await self._read()\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def set(self, value: _T) -> None:\n")
+	b.WriteString("        \"\"\"Write the value.\"\"\"\n")
+	b.WriteString("        await self._write(value)\n")
+}
+
+// writePyProperties emits the properties of the Python client mixin.
+func writePyProperties(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	for _, get := range commands {
+		set, ok := gen.PropertySetter(get, commands)
+		if get.Property == "" || !ok {
+			continue
+		}
+		g, s := get.ResponseFields[0], set.RequestFields[0]
+		getMethod, setMethod := gctx.PyMethodName(get.Snake), gctx.PyMethodName(set.Snake)
+		b.WriteByte('\n')
+		b.WriteString("    @property\n")
+		b.WriteString(fmt.Sprintf("    def %s(self) -> AsyncProperty[%s]:\n", gctx.PyMethodName(get.Property), ResolveType(g, pkg)))
+		b.WriteString(fmt.Sprintf("        \"\"\"The %s of %s and %s, as an AsyncProperty.\"\"\"\n", g.Name, getMethod, setMethod))
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("        async def read() -> %s:\n", ResolveType(g, pkg)))
+		b.WriteString(fmt.Sprintf("            return (await self.%s()).%s\n", getMethod, g.Name))
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("        async def write(value: %s) -> None:\n", ResolveType(s, pkg)))
+		b.WriteString(fmt.Sprintf("            await self.%s(%s=value)\n", setMethod, s.Name))
+		b.WriteByte('\n')
+		b.WriteString("        return AsyncProperty(read, write)\n")
+	}
+}
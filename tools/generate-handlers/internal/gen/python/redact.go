@@ -0,0 +1,124 @@
+package python
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Fields annotated [(blerpc.sensitive) = true] hold secrets or personal data
+// that must not reach logs. The Python, Kotlin and Swift clients get a
+// redacted() helper (redaction.py, Redaction.kt, Redaction.swift) printing a
+// message with those fields as <redacted>, walking into message fields;
+// messages without any, directly or in a message field, print as usual.
+// The capture recorders strip the fields from the payloads they write, at
+// the wire level: each command with sensitive fields has a plan naming the
+// field numbers to drop and the message fields to walk into, map values
+// going through their entry message. Requests lose their fields for good,
+// so replay skips their entries; replayed responses are stripped the same
+// way before they are compared. Payloads of calls in the compression
+// envelope are not recorded at all once a compressed command has sensitive
+// fields. The fixed code is py_redaction.py.tmpl, Redaction.kt.tmpl and
+// Redaction.swift.tmpl.
+
+// redactedPlaceholder stands in for the value of a sensitive field.
+const redactedPlaceholder = "<redacted>"
+
+// writePyRedactTables writes the REDACTED_MESSAGES and REDACTED_COMMANDS
+// tables of capture.py.
+func writePyRedactTables(b *strings.Builder, commands []gen.Command, messages map[string]gen.Message) {
+	plans := gen.RedactPlans(messages)
+	b.WriteString("# Sensitive fields, stripped from the recorded payloads: by message, the\n")
+	b.WriteString("# numbers of the fields to drop, mapped to None, and of the message\n")
+	b.WriteString("# fields holding some, mapped to their message.\n")
+	if len(plans) == 0 {
+		b.WriteString("REDACTED_MESSAGES: dict[str, dict[int, str | None]] = {}\n")
+	} else {
+		b.WriteString("REDACTED_MESSAGES: dict[str, dict[int, str | None]] = {\n")
+		for _, name := range slices.Sorted(maps.Keys(plans)) {
+			b.WriteString(fmt.Sprintf("    \"%s\": {", name))
+			for i, num := range slices.Sorted(maps.Keys(plans[name])) {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				ref := "None"
+				if plans[name][num] != "" {
+					ref = "\"" + plans[name][num] + "\""
+				}
+				b.WriteString(fmt.Sprintf("%d: %s", num, ref))
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Commands with sensitive fields: the bytes leading each request, and the\n")
+	b.WriteString("# messages of REDACTED_MESSAGES the requests and responses are, or None.\n")
+	b.WriteString("# Payloads of commands mapped to None are not recorded at all.\n")
+	calls := gen.RedactedCalls(commands, plans)
+	if len(calls) == 0 {
+		b.WriteString("REDACTED_COMMANDS: dict[str, tuple[int, str | None, str | None] | None] = {}\n")
+		return
+	}
+	b.WriteString("REDACTED_COMMANDS: dict[str, tuple[int, str | None, str | None] | None] = {\n")
+	pyName := func(s string) string {
+		if s == "" {
+			return "None"
+		}
+		return "\"" + s + "\""
+	}
+	for _, c := range calls {
+		if c.Opaque {
+			b.WriteString(fmt.Sprintf("    \"%s\": None,\n", c.Command))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("    \"%s\": (%d, %s, %s),\n", c.Command, c.Prefix, pyName(c.Request), pyName(c.Response)))
+	}
+	b.WriteString("}\n")
+}
+
+// GenerateRedaction returns redaction.py, placed next to the generated
+// client module.
+func GenerateRedaction(gctx *gen.Context, messages map[string]gen.Message, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("from typing import Any\n")
+	b.WriteByte('\n')
+	b.WriteString("from google.protobuf.descriptor import FieldDescriptor\n")
+	b.WriteString("from google.protobuf.message import Message\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("REDACTED = %q\n", redactedPlaceholder))
+	b.WriteByte('\n')
+	b.WriteString("# Fields marked (blerpc.sensitive), by the full name of their message.\n")
+	var direct []gen.Message
+	for _, m := range gen.SensitiveMessages(messages) {
+		if slices.ContainsFunc(m.Fields, func(f gen.Field) bool { return f.Sensitive }) {
+			direct = append(direct, m)
+		}
+	}
+	if len(direct) == 0 {
+		b.WriteString("SENSITIVE_FIELDS: dict[str, frozenset[str]] = {}\n")
+	} else {
+		b.WriteString("SENSITIVE_FIELDS: dict[str, frozenset[str]] = {\n")
+		for _, m := range direct {
+			var names []string
+			for _, f := range m.Fields {
+				if f.Sensitive {
+					names = append(names, "\""+f.Name+"\"")
+				}
+			}
+			b.WriteString(fmt.Sprintf("    \"%s.%s\": frozenset({%s}),\n", pkg, m.Name, strings.Join(names, ", ")))
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("py_redaction.py.tmpl", nil))
+	return b.String()
+}
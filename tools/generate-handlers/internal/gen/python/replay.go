@@ -0,0 +1,32 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyReplayCounter emits the module-level counter of replay-protected
+// requests and the helper leading them with it and their MAC.
+func writePyReplayCounter(b *strings.Builder) {
+	b.WriteString("\n\n")
+	b.WriteString("_last_replay_counter = 0\n")
+	b.WriteString("\n\n")
+	b.WriteString("def _with_replay_counter(client, command, req_data):\n")
+	b.WriteString("    # Leads a replay-protected request with a counter, 8 bytes little endian,\n")
+	b.WriteString("    # and a MAC: the first 16 bytes of an HMAC-SHA256 under session_key of\n")
+	b.WriteString("    # the command name, a NUL, the counter and the request. The peripheral\n")
+	b.WriteString("    # drops requests whose MAC is wrong or whose counter is not above the last\n")
+	b.WriteString("    # one it accepted; microseconds since the epoch keep it increasing across\n")
+	b.WriteString("    # restarts. Call under _rpc_lock so requests go out in counter order.\n")
+	b.WriteString("    global _last_replay_counter\n")
+	b.WriteString("    key = getattr(client, \"session_key\", None)\n")
+	b.WriteString("    if key is None:\n")
+	b.WriteString("        msg = \"set session_key before calling a replay-protected command\"\n")
+	b.WriteString("        raise BlerpcError(msg)\n")
+	b.WriteString("    _last_replay_counter = max(_last_replay_counter + 1, time.time_ns() // 1000)\n")
+	b.WriteString("    counter = _last_replay_counter.to_bytes(8, \"little\")\n")
+	b.WriteString("    mac = hmac.digest(key, command.encode() + b\"\\0\" + counter + req_data, \"sha256\")\n")
+	b.WriteString(fmt.Sprintf("    return counter + mac[:%d] + req_data\n", gen.ReplayMACSize))
+}
@@ -0,0 +1,149 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateResume returns resuming_client.py, placed next to the generated
+// client module.
+func GenerateResume(commands []gen.Command) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("from collections.abc import AsyncIterable\n")
+	b.WriteByte('\n')
+	if gen.HasCommandIDs(commands) {
+		b.WriteString("from .generated_client import CommandId, GeneratedClientMixin, TransportError, _rpc_lock\n")
+	} else {
+		b.WriteString("from .generated_client import GeneratedClientMixin, TransportError, _rpc_lock\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Commands that are safe to run twice; retried after a reconnect.\n")
+	names := gen.IdempotentCallNames(commands, "python")
+	if len(names) == 0 {
+		b.WriteString("IDEMPOTENT_COMMANDS = frozenset()\n")
+	} else {
+		b.WriteString("IDEMPOTENT_COMMANDS = frozenset(\n")
+		b.WriteString("    {\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("        %s,\n", name))
+		}
+		b.WriteString("    }\n")
+		b.WriteString(")\n")
+	}
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class CallInterruptedError(TransportError):\n")
+	b.WriteString("    \"\"\"The connection dropped while a non-idempotent call was in flight.\n")
+	b.WriteByte('\n')
+	b.WriteString("    The peripheral may or may not have run the command, so it is not retried.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, command, cause):\n")
+	b.WriteString("        super().__init__(f\"{command}: interrupted by disconnect: {cause}\")\n")
+	b.WriteString("        self.command = command\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class ResumePolicy:\n")
+	b.WriteString("    \"\"\"App-level policy of ResumingClient. Subclass to override.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    max_retries = 1\n")
+	b.WriteByte('\n')
+	b.WriteString("    def should_retry(self, command, attempt, error):\n")
+	b.WriteString("        \"\"\"Whether to reconnect and retry command after its attempt-th failure.\"\"\"\n")
+	b.WriteString("        return command in IDEMPOTENT_COMMANDS and attempt <= self.max_retries\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class ResumingClient(GeneratedClientMixin):\n")
+	b.WriteString("    \"\"\"Recovers calls cut off by a dropped connection.\n")
+	b.WriteByte('\n')
+	b.WriteString("    Calls made while disconnected reconnect first. Interrupted idempotent calls\n")
+	b.WriteString("    are retried as the policy allows; other interrupted calls raise\n")
+	b.WriteString("    CallInterruptedError. reconnect is an async callable restoring the\n")
+	b.WriteString("    connection, e.g. lambda: client.connect(device). Other attributes are\n")
+	b.WriteString("    forwarded to client.\n")
+	b.WriteString("    \"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, client, reconnect, policy=None):\n")
+	b.WriteString("        self._client = client\n")
+	b.WriteString("        self._reconnect = reconnect\n")
+	b.WriteString("        self._policy = policy or ResumePolicy()\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __getattr__(self, name):\n")
+	b.WriteString("        return getattr(self._client, name)\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def _ensure_connected(self):\n")
+	b.WriteString("        if not self._client.is_connected:\n")
+	b.WriteString("            await self._reconnect()\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _failure(self, command, attempt, error, replayable=True):\n")
+	b.WriteString("        \"\"\"Return the error to raise for a failed attempt, or None to retry.\"\"\"\n")
+	b.WriteString("        if self._client.is_connected:\n")
+	b.WriteString("            return error\n")
+	b.WriteString("        if replayable and self._policy.should_retry(command, attempt, error):\n")
+	b.WriteString("            return None\n")
+	b.WriteString("        if command in IDEMPOTENT_COMMANDS and replayable:\n")
+	b.WriteString("            return error\n")
+	b.WriteString("        return CallInterruptedError(command, error)\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def _resume(self, command, attempt_call):\n")
+	b.WriteString("        attempt = 0\n")
+	b.WriteString("        while True:\n")
+	b.WriteString("            await self._ensure_connected()\n")
+	b.WriteString("            try:\n")
+	b.WriteString("                async with _rpc_lock(self._client):\n")
+	b.WriteString("                    return await attempt_call()\n")
+	b.WriteString("            except Exception as e:\n")
+	b.WriteString("                attempt += 1\n")
+	b.WriteString("                err = self._failure(command, attempt, e)\n")
+	b.WriteString("                if err is e:\n")
+	b.WriteString("                    raise\n")
+	b.WriteString("                if err is not None:\n")
+	b.WriteString("                    raise err from e\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def _call(self, cmd_name, request_data):\n")
+	b.WriteString("        return await self._resume(\n")
+	b.WriteString("            cmd_name, lambda: self._client._call(cmd_name, request_data)\n")
+	b.WriteString("        )\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def stream_send(self, cmd_name, messages, final_cmd_name):\n")
+	b.WriteString("        # A retry sends the stream again, so it is collected first.\n")
+	b.WriteString("        if isinstance(messages, AsyncIterable):\n")
+	b.WriteString("            messages = [m async for m in messages]\n")
+	b.WriteString("        else:\n")
+	b.WriteString("            messages = list(messages)\n")
+	b.WriteString("        return await self._resume(\n")
+	b.WriteString("            cmd_name,\n")
+	b.WriteString("            lambda: self._client.stream_send(cmd_name, messages, final_cmd_name),\n")
+	b.WriteString("        )\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def stream_receive(self, cmd_name, request_data):\n")
+	b.WriteString("        # Responses already handed to the caller cannot be taken back, so a\n")
+	b.WriteString("        # stream is only retried if it broke before the first response.\n")
+	b.WriteString("        attempt = 0\n")
+	b.WriteString("        while True:\n")
+	b.WriteString("            await self._ensure_connected()\n")
+	b.WriteString("            received = False\n")
+	b.WriteString("            try:\n")
+	b.WriteString("                async with _rpc_lock(self._client):\n")
+	b.WriteString("                    async for data in self._client.stream_receive(\n")
+	b.WriteString("                        cmd_name, request_data\n")
+	b.WriteString("                    ):\n")
+	b.WriteString("                        received = True\n")
+	b.WriteString("                        yield data\n")
+	b.WriteString("                return\n")
+	b.WriteString("            except Exception as e:\n")
+	b.WriteString("                attempt += 1\n")
+	b.WriteString("                err = self._failure(cmd_name, attempt, e, replayable=not received)\n")
+	b.WriteString("                if err is e:\n")
+	b.WriteString("                    raise\n")
+	b.WriteString("                if err is not None:\n")
+	b.WriteString("                    raise err from e\n")
+	return b.String()
+}
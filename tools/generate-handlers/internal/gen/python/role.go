@@ -0,0 +1,24 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writePyRoles(b *strings.Builder, commands []gen.Command) {
+	privileged := gen.PrivilegedCommands(commands)
+	if len(privileged) == 0 {
+		return
+	}
+	b.WriteString("\n\n")
+	b.WriteString("# Role each command requires on the peripheral, keyed by wire name; commands\n")
+	b.WriteString("# not listed need only \"user\". The peripheral treats commands above its\n")
+	b.WriteString("# current role as unknown.\n")
+	b.WriteString("COMMAND_ROLES = {\n")
+	for _, cmd := range privileged {
+		b.WriteString(fmt.Sprintf("    \"%s\": \"%s\",\n", cmd.Wire(), cmd.Role))
+	}
+	b.WriteString("}\n")
+}
@@ -0,0 +1,67 @@
+package python
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+var ReHandlerDef = regexp.MustCompile(`(?m)^(?:async\s+)?def\s+handle_(\w+)\s*\(`)
+
+// ScaffoldHandlers is the Python counterpart of c.ScaffoldHandlers. Each stub
+// registers itself on the file's HandlerRegistry with a decorator, so
+// appended stubs are self-contained.
+func ScaffoldHandlers(commands []gen.Command, streaming map[string]string, pkg, existing string, implemented map[string]bool) (string, []string) {
+	var b strings.Builder
+	b.WriteString(existing)
+	if existing == "" {
+		b.WriteString("\"\"\"User handler implementations.\n")
+		b.WriteByte('\n')
+		b.WriteString("Created by generate-handlers. This file is yours to edit: the generator\n")
+		b.WriteString("never rewrites it, -scaffold only appends stubs for new commands.\n")
+		b.WriteString("Pass registry to generated_server.main() so the handlers take effect.\n")
+		b.WriteString("\"\"\"\n")
+		b.WriteByte('\n')
+		if gen.HasServerStreams(commands, streaming) {
+			b.WriteString("from collections.abc import AsyncIterator\n")
+			b.WriteByte('\n')
+		}
+		b.WriteString("from generated_handlers import HandlerRegistry, " + pkg + "_pb2\n")
+		b.WriteByte('\n')
+		b.WriteString("registry = HandlerRegistry()\n")
+	}
+
+	var added []string
+	for _, cmd := range commands {
+		if implemented[cmd.Snake] {
+			continue
+		}
+		added = append(added, cmd.Snake)
+		reqCls := pkg + "_pb2." + cmd.RequestMsg
+		respCls := pkg + "_pb2." + cmd.ResponseMsg
+		params := []string{"req: " + reqCls}
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("@registry.handler(\"%s\")\n", cmd.Snake))
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			b.WriteString(pyDef("", "async def handle_"+cmd.Snake, params, "AsyncIterator["+respCls+"]"))
+			b.WriteString(fmt.Sprintf("    # TODO: implement %s\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("    yield %s()\n", respCls))
+		case "c2p":
+			b.WriteString(pyDef("", "async def handle_"+cmd.Snake, params, "None"))
+			b.WriteString(fmt.Sprintf("    # TODO: implement %s (called per request)\n", cmd.Snake))
+			b.WriteString("\n\n")
+			b.WriteString(fmt.Sprintf("@registry.finisher(\"%s\")\n", cmd.Snake))
+			b.WriteString(pyDef("", "async def finish_"+cmd.Snake, nil, respCls))
+			b.WriteString(fmt.Sprintf("    # TODO: respond when the central ends the %s stream\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("    return %s()\n", respCls))
+		default:
+			b.WriteString(pyDef("", "async def handle_"+cmd.Snake, params, respCls))
+			b.WriteString(fmt.Sprintf("    # TODO: implement %s\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("    return %s()\n", respCls))
+		}
+	}
+	return b.String(), added
+}
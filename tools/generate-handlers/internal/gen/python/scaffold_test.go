@@ -0,0 +1,37 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestScaffoldPyHandlers(t *testing.T) {
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out, added := ScaffoldHandlers(cmds, streaming, "blerpc", "", map[string]bool{"echo": true})
+
+	if len(added) != 2 {
+		t.Fatalf("expected 2 added stubs, got %v", added)
+	}
+	mustContain := []string{
+		"from collections.abc import AsyncIterator\n\nfrom generated_handlers import HandlerRegistry, blerpc_pb2\n",
+		"registry = HandlerRegistry()\n",
+		"@registry.handler(\"counter_stream\")\nasync def handle_counter_stream(\n" +
+			"    req: blerpc_pb2.CounterStreamRequest,\n) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:\n",
+		"    yield blerpc_pb2.CounterStreamResponse()\n",
+		"async def handle_counter_upload(req: blerpc_pb2.CounterUploadRequest) -> None:\n",
+		"@registry.finisher(\"counter_upload\")\n" +
+			"async def finish_counter_upload() -> blerpc_pb2.CounterUploadResponse:\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python scaffold missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "handle_echo") {
+		t.Error("Python scaffold should skip implemented commands")
+	}
+}
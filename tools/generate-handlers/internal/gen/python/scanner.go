@@ -0,0 +1,97 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Scan helpers pick blerpc peripherals out of the scan results of each
+// central's transport. A peripheral is recognised by the RPC service UUID in
+// its advertisement, which it advertises in both GATT modes. When the schema
+// has (blerpc.advertising) messages the helpers also decode the manufacturer
+// data with the generated advertising parser, which must then be generated
+// into the same package.
+
+// GenerateScanner returns generated_scanner.py, placed next to the
+// generated client module.
+func GenerateScanner(gctx *gen.Context, hasAdvs bool) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("from dataclasses import dataclass\n")
+	if hasAdvs {
+		b.WriteByte('\n')
+		b.WriteString("from .generated_advertising import parse_manufacturer_data\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("# Service UUID advertised by blerpc peripherals.\n")
+	b.WriteString(fmt.Sprintf("SERVICE_UUID = \"%s\"\n", gctx.ServiceUUID))
+	b.WriteString("\n\n")
+	b.WriteString("@dataclass\n")
+	b.WriteString("class DiscoveredDevice:\n")
+	b.WriteString("    \"\"\"A blerpc peripheral found by a scan; pass device to connect().\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    name: str | None\n")
+	b.WriteString("    address: str\n")
+	b.WriteString("    rssi: int\n")
+	b.WriteString("    device: object\n")
+	if hasAdvs {
+		b.WriteString("    # The decoded (blerpc.advertising) message, if the device sent one.\n")
+		b.WriteString("    advertisement: object | None = None\n")
+	}
+	b.WriteString("\n\n")
+	if hasAdvs {
+		b.WriteString("def discover(device, require_advertisement=False):\n")
+	} else {
+		b.WriteString("def discover(device):\n")
+	}
+	b.WriteString("    \"\"\"Return a scan result as a DiscoveredDevice, or None if it is not blerpc.\"\"\"\n")
+	b.WriteString("    if SERVICE_UUID not in (u.lower() for u in device.service_uuids):\n")
+	b.WriteString("        return None\n")
+	if hasAdvs {
+		b.WriteString("    advertisement = parse_manufacturer_data(device.manufacturer_data)\n")
+		b.WriteString("    if require_advertisement and advertisement is None:\n")
+		b.WriteString("        return None\n")
+	}
+	b.WriteString("    return DiscoveredDevice(\n")
+	b.WriteString("        name=device.name,\n")
+	b.WriteString("        address=device.address,\n")
+	b.WriteString("        rssi=device.rssi,\n")
+	b.WriteString("        device=device,\n")
+	if hasAdvs {
+		b.WriteString("        advertisement=advertisement,\n")
+	}
+	b.WriteString("    )\n")
+	b.WriteString("\n\n")
+	if hasAdvs {
+		b.WriteString("def discover_all(devices, require_advertisement=False):\n")
+		b.WriteString("    \"\"\"Return the blerpc peripherals among scan results, strongest first.\"\"\"\n")
+		b.WriteString("    found = (discover(d, require_advertisement) for d in devices)\n")
+	} else {
+		b.WriteString("def discover_all(devices):\n")
+		b.WriteString("    \"\"\"Return the blerpc peripherals among scan results, strongest first.\"\"\"\n")
+		b.WriteString("    found = (discover(d) for d in devices)\n")
+	}
+	b.WriteString("    return sorted(\n")
+	b.WriteString("        (d for d in found if d is not None), key=lambda d: d.rssi, reverse=True\n")
+	b.WriteString("    )\n")
+	b.WriteString("\n\n")
+	if hasAdvs {
+		b.WriteString("async def scan(client, timeout=5.0, require_advertisement=False):\n")
+	} else {
+		b.WriteString("async def scan(client, timeout=5.0):\n")
+	}
+	b.WriteString("    \"\"\"Scan with client, e.g. BlerpcClient, and return the blerpc peripherals.\"\"\"\n")
+	b.WriteString("    devices = await client.scan(timeout=timeout, service_uuid=SERVICE_UUID)\n")
+	if hasAdvs {
+		b.WriteString("    return discover_all(devices, require_advertisement)\n")
+	} else {
+		b.WriteString("    return discover_all(devices)\n")
+	}
+	return b.String()
+}
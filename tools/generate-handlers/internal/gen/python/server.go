@@ -0,0 +1,21 @@
+package python
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Python peripheral server (generated_server.py) is a bless GATT server
+// of the RPC service: it answers the control containers, reassembles and
+// decrypts requests, dispatches them through the HandlerRegistry of
+// generated_handlers.py and sends the responses as notifications, so the
+// Python peripheral simulator only supplies handlers. The registry knows each
+// command's streaming direction, so the server itself is schema-independent.
+
+// pyServerData is the data of py_server.py.tmpl.
+type pyServerData struct {
+	ServiceUUID, CharUUID string
+}
+
+func GenerateServer(gctx *gen.Context) string {
+	return gctx.RenderTemplate("py_server.py.tmpl", pyServerData{ServiceUUID: gctx.ServiceUUID, CharUUID: gctx.CharUUID})
+}
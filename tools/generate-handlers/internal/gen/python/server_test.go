@@ -0,0 +1,25 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestGeneratePyServer(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateServer(gctx)
+	for _, want := range []string{
+		"from generated_handlers import HandlerRegistry\n",
+		"SERVICE_UUID = \"" + gctx.ServiceUUID + "\"\n",
+		"CHAR_UUID = \"" + gctx.CharUUID + "\"\n",
+		"        self.registry = HandlerRegistry() if registry is None else registry\n",
+		"            async for resp_data in self.registry.stream(cmd.cmd_name, cmd.data):\n",
+		"            resp_data = await self.registry.finish(name)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
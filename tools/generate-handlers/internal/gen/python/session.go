@@ -0,0 +1,53 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePySessionCall emits the helper sending a session-protected request.
+func writePySessionCall(b *strings.Builder) {
+	b.WriteString("\n\n")
+	b.WriteString("async def _session_call(client, command, req_data):\n")
+	b.WriteString("    # Sends a session-protected request led by the session token, opening a\n")
+	b.WriteString("    # session first when the client has none. A peripheral that lost the\n")
+	b.WriteString("    # session, e.g. on a reboot, answers UNAUTHENTICATED; the request is then\n")
+	b.WriteString("    # sent once more in a new session. Call under _rpc_lock.\n")
+	b.WriteString("    token = vars(client).get(\"_session_token\") or await client._open_session()\n")
+	b.WriteString("    resp_data = await client._call(command, token + req_data)\n")
+	b.WriteString("    if resp_data == _STATUS_TAG + bytes([StatusCode.UNAUTHENTICATED]):\n")
+	b.WriteString("        token = await client._open_session()\n")
+	b.WriteString("        resp_data = await client._call(command, token + req_data)\n")
+	b.WriteString("    return resp_data\n")
+}
+
+// writePySessionHelpers emits the handshake of the Python client mixin.
+func writePySessionHelpers(b *strings.Builder, start, auth gen.Command, pkg string) {
+	b.WriteByte('\n')
+	b.WriteString("    async def open_session(self):\n")
+	b.WriteString("        \"\"\"Authenticate to the peripheral, unlocking its protected commands.\n")
+	b.WriteByte('\n')
+	b.WriteString("        Protected calls open a session as needed; call it to authenticate up\n")
+	b.WriteString("        front. Set session_key to the key shared with the peripheral first.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        async with _rpc_lock(self):\n")
+	b.WriteString("            await self._open_session()\n")
+	b.WriteByte('\n')
+	b.WriteString("    async def _open_session(self):\n")
+	b.WriteString("        # Runs the handshake under the caller's _rpc_lock and keeps the token:\n")
+	b.WriteString("        # the proof is an HMAC-SHA256 of the challenge under session_key.\n")
+	b.WriteString("        key = getattr(self, \"session_key\", None)\n")
+	b.WriteString("        if key is None:\n")
+	b.WriteString("            msg = \"set session_key before opening a session\"\n")
+	b.WriteString("            raise BlerpcError(msg)\n")
+	b.WriteString(fmt.Sprintf("        req = %s_pb2.%s()\n", pkg, start.RequestMsg))
+	b.WriteString(pyCall("        ", "resp_data = await self._call", gen.CallName(start, "python"), "req.SerializeToString()"))
+	b.WriteString(pyDecodeResp("        ", pkg+"_pb2."+start.ResponseMsg, "resp_data", start.Snake))
+	b.WriteString(pyCall("        ", fmt.Sprintf("req = %s_pb2.%s", pkg, auth.RequestMsg), "proof=hmac.digest(key, resp.challenge, \"sha256\")"))
+	b.WriteString(pyCall("        ", "resp_data = await self._call", gen.CallName(auth, "python"), "req.SerializeToString()"))
+	b.WriteString(pyDecodeResp("        ", pkg+"_pb2."+auth.ResponseMsg, "resp_data", auth.Snake))
+	b.WriteString("        vars(self)[\"_session_token\"] = resp.token\n")
+	b.WriteString("        return resp.token\n")
+}
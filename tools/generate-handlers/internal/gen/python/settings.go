@@ -0,0 +1,33 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePySettingsHelpers emits the typed settings accessors of the Python
+// client mixin.
+func writePySettingsHelpers(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	get, set := gen.SettingsCommands(commands)
+	if get != nil {
+		for _, f := range get.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("    async def get_%s_setting(self):\n", f.Name))
+			b.WriteString(fmt.Sprintf("        \"\"\"Read the %s setting.\"\"\"\n", f.Name))
+			b.WriteString(fmt.Sprintf("        resp = await self.%s(field=%d)\n", gctx.PyMethodName(get.Snake), f.Number))
+			b.WriteString(fmt.Sprintf("        settings = _decode(%s_pb2.%s(), resp.value, \"%s\")\n", pkg, get.Settings.Name, get.Snake))
+			b.WriteString(fmt.Sprintf("        return settings.%s\n", f.Name))
+		}
+	}
+	if set != nil {
+		for _, f := range set.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("    async def set_%s_setting(self, value):\n", f.Name))
+			b.WriteString(fmt.Sprintf("        \"\"\"Write the %s setting.\"\"\"\n", f.Name))
+			b.WriteString(fmt.Sprintf("        settings = %s_pb2.%s(%s=value)\n", pkg, set.Settings.Name, f.Name))
+			b.WriteString(fmt.Sprintf("        await self.%s(field=%d, value=settings.SerializeToString())\n", gctx.PyMethodName(set.Snake), f.Number))
+		}
+	}
+}
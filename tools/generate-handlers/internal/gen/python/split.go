@@ -0,0 +1,275 @@
+package python
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// SplitClient turns the Python client module at path into a package of
+// the same name: _base.py holds the error types and helpers, each group
+// module holds a mixin, and __init__.py combines the mixins into
+// GeneratedClientMixin and re-exports the module-level API.
+func SplitClient(gctx *gen.Context, groups []gen.CommandGroup, commands []gen.Command, streaming map[string]string, pkg, path string) []gen.Output {
+	dir := strings.TrimSuffix(path, ".py")
+	header := "\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n\nfrom __future__ import annotations\n"
+
+	var base strings.Builder
+	base.WriteString(header)
+	base.WriteByte('\n')
+	base.WriteString("import asyncio\n")
+	base.WriteString("import builtins\n")
+	base.WriteString("import enum\n")
+	if gen.HasReplayProtected(commands) {
+		base.WriteString("import hmac\n")
+		base.WriteString("import time\n")
+	}
+	base.WriteString("from collections.abc import AsyncIterable, AsyncIterator, Iterable\n")
+	base.WriteString("from typing import " + strings.Join(pyTypingNames(commands), ", ") + "\n")
+	base.WriteByte('\n')
+	base.WriteString("from google.protobuf import " + pyProtobufImports(commands, "message") + "\n")
+	writePyWellKnownHelpers(&base, commands)
+	writePyErrors(gctx, &base, commands)
+	writePyRPCLock(&base)
+	if gen.HasCallPolicies(commands) {
+		writePyCallPolicies(&base, commands)
+	}
+	if gen.HasClientStreams(commands, streaming) {
+		writePySerializeEach(&base)
+	}
+	if gen.HasReplayProtected(commands) {
+		writePyReplayCounter(&base)
+	}
+	if _, _, ok := gen.SessionCommands(commands); ok {
+		writePySessionCall(&base)
+	}
+	if gen.HasServerStreams(commands, streaming) {
+		writePyCancelContainer(&base)
+	}
+	if gen.HasCommandIDs(commands) {
+		writePyCommandIDs(&base, commands)
+	}
+	base.WriteString(gctx.RenderTemplate("py_rpc_transport.py.tmpl", nil))
+	outputs := []gen.Output{{Path: filepath.Join(dir, "_base.py"), Content: base.String()}}
+
+	var mixins, modules []string
+	for _, g := range groups {
+		mixin := g.Name + "Mixin"
+		module := protomodel.CamelToSnake(g.Name)
+		mixins = append(mixins, mixin)
+		modules = append(modules, fmt.Sprintf("from .%s import %s\n", module, mixin))
+
+		var b strings.Builder
+		b.WriteString(header)
+		_, connParams := gen.BuiltinCommand(g.Commands, "conn_params")
+		_, ping := gen.BuiltinCommand(g.Commands, "ping")
+		usesTime := pyBuiltinsUseTime(g.Commands)
+		_, fileTransfer := gen.FileTransferCommands(g.Commands)
+		_, dfu := gen.FirmwareUpdateCommands(g.Commands)
+		_, _, sessions := gen.SessionCommands(g.Commands)
+		serverStreams := gen.HasServerStreams(g.Commands, streaming)
+		clientStreams := gen.HasClientStreams(g.Commands, streaming)
+		usesDatetime := pyRequestsUseDatetime(g.Commands)
+		if serverStreams || clientStreams || connParams || ping || usesDatetime || usesTime || sessions || hasRenamedCommands(g.Commands) || HasDeprecations(g.Commands) || fileTransfer || dfu {
+			b.WriteByte('\n')
+		}
+		if serverStreams || ping {
+			b.WriteString("import asyncio\n")
+		}
+		if connParams {
+			b.WriteString("import contextlib\n")
+		}
+		if usesDatetime {
+			b.WriteString("import datetime\n")
+		}
+		if sessions {
+			b.WriteString("import hmac\n")
+		}
+		if dfu {
+			b.WriteString("import pathlib\n")
+		}
+		if usesTime {
+			b.WriteString("import time\n")
+		}
+		if hasRenamedCommands(g.Commands) || HasDeprecations(g.Commands) {
+			b.WriteString("import warnings\n")
+		}
+		if fileTransfer || dfu {
+			b.WriteString("import zlib\n")
+		}
+		if abcs := pyMethodABCs(g.Commands, streaming); len(abcs) > 0 {
+			b.WriteString("from collections.abc import " + strings.Join(abcs, ", ") + "\n")
+		}
+		imports := gen.OverrideImports(g.Commands, "python")
+		if pyRequestsUseImportedMessages(g.Commands) {
+			imports = append([]string{"from google.protobuf import message"}, imports...)
+		}
+		if len(imports) > 0 {
+			b.WriteByte('\n')
+			b.WriteString(strings.Join(imports, "\n") + "\n")
+		}
+		b.WriteByte('\n')
+		b.WriteString(Pb2Import(gctx, pkg, "..") + "\n")
+		b.WriteString(pyImportLine("._base", pyBaseNames(g.Commands, streaming)))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("class %s(RpcTransport):\n", mixin))
+		b.WriteString(fmt.Sprintf("    \"\"\"Auto-generated RPC methods of %s.\"\"\"\n", g.Name))
+		b.WriteByte('\n')
+		b.WriteString(gctx.RenderTemplate("py_client_methods.py.tmpl", newPyClientMethods(gctx, g.Commands, streaming, pkg)))
+		outputs = append(outputs, gen.Output{Path: filepath.Join(dir, module+".py"), Content: b.String()})
+	}
+
+	exported := []string{"BlerpcError", "DecodeError", "RemoteError", "RpcTransport", "TimeoutError", "TransportError"}
+	exported = append(exported, pyStatusNames()...)
+	if gen.HasStatusChecks(commands) {
+		exported = append(exported, "CommandStatusError")
+	}
+	if gen.HasCommandIDs(commands) {
+		exported = append(exported, "CommandId")
+	}
+	sort.Strings(exported)
+	sort.Strings(modules)
+
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteByte('\n')
+	b.WriteString("from google.protobuf import json_format\n")
+	b.WriteByte('\n')
+	b.WriteString(Pb2Import(gctx, pkg, "..") + "\n")
+	// _rpc_lock is re-exported for the sibling modules that call the client's
+	// primitives directly.
+	imports := append(append([]string{}, exported...), "_rpc_lock")
+	if gen.HasServerStreams(commands, streaming) {
+		imports = append(append([]string{"CONTROL_CMD_CANCEL"}, imports...), "make_cancel")
+		exported = append(exported, "CONTROL_CMD_CANCEL", "make_cancel")
+	}
+	b.WriteString(pyImportLine("._base", imports))
+	b.WriteString(strings.Join(modules, ""))
+	b.WriteByte('\n')
+	b.WriteString("__all__ = [\n")
+	public := append(append([]string{}, exported...), "COMMAND_MESSAGES", "GeneratedClientMixin",
+		"request_from_json", "response_from_json", "to_json")
+	if gen.GATTServiceUUID(commands) != "" {
+		public = append(public, "COMMAND_CHARACTERISTICS", "COMMAND_SERVICE_UUID")
+	}
+	if len(gen.PrivilegedCommands(commands)) > 0 {
+		public = append(public, "COMMAND_ROLES")
+	}
+	sort.Strings(public)
+	for _, name := range public {
+		b.WriteString(fmt.Sprintf("    \"%s\",\n", name))
+	}
+	b.WriteString("]\n")
+	b.WriteString("\n\n")
+	line := fmt.Sprintf("class GeneratedClientMixin(%s):", strings.Join(mixins, ", "))
+	if len(line) <= 88 {
+		b.WriteString(line + "\n")
+	} else {
+		b.WriteString("class GeneratedClientMixin(\n")
+		for _, m := range mixins {
+			b.WriteString("    " + m + ",\n")
+		}
+		b.WriteString("):\n")
+	}
+	b.WriteString("    \"\"\"Auto-generated RPC methods (unary and streaming).\n")
+	b.WriteByte('\n')
+	b.WriteString("    Requires the RpcTransport primitives, which BlerpcClient provides.\n")
+	b.WriteString("    \"\"\"\n")
+	if gen.HasServerStreams(commands, streaming) {
+		writePyStreamCancel(&b)
+	}
+	writePyJSONHelpers(&b, commands, pkg)
+	writePyCharacteristics(&b, commands)
+	writePyRoles(&b, commands)
+	outputs = append(outputs, gen.Output{Path: filepath.Join(dir, "__init__.py"), Content: b.String()})
+	return outputs
+}
+
+// pyMethodABCs returns the collections.abc names the client methods of the
+// commands are annotated with.
+func pyMethodABCs(commands []gen.Command, streaming map[string]string) []string {
+	var names []string
+	if gen.HasClientStreams(commands, streaming) {
+		names = append(names, "AsyncIterable")
+	}
+	if gen.HasServerStreams(commands, streaming) {
+		names = append(names, "AsyncIterator")
+	}
+	if gen.HasClientStreams(commands, streaming) {
+		names = append(names, "Iterable")
+	}
+	return names
+}
+
+// pyBaseNames returns the _base names referenced by the methods of the
+// commands, in isort order (constants, then classes, then functions).
+func pyBaseNames(commands []gen.Command, streaming map[string]string) []string {
+	var names []string
+	_, blerpcInfo := gen.BuiltinCommand(commands, "blerpc_info")
+	if blerpcInfo {
+		names = append(names, "SCHEMA_HASH")
+	}
+	_, fileTransfer := gen.FileTransferCommands(commands)
+	_, dfu := gen.FirmwareUpdateCommands(commands)
+	_, _, sessions := gen.SessionCommands(commands)
+	if fileTransfer || dfu || sessions {
+		names = append(names, "BlerpcError")
+	}
+	if gen.HasCommandIDs(commands) {
+		names = append(names, "CommandId")
+	}
+	if gen.HasStatusChecks(commands) {
+		names = append(names, "CommandStatusError")
+	}
+	if gen.HasBoundedBytesRequests(commands) {
+		names = append(names, "PayloadTooLargeError")
+	}
+	if gen.HasBoundedStringRequests(commands) {
+		names = append(names, "StringTooLongError")
+	}
+	names = append(names, "RpcTransport")
+	if blerpcInfo {
+		names = append(names, "SchemaMismatchError")
+	}
+	if gen.HasCallPolicies(commands) {
+		names = append(names, "_call_with_policy")
+	}
+	names = append(names, "_decode")
+	if gen.UsesWellKnownType(commands, protomodel.DurationType) {
+		names = append(names, "_duration")
+	}
+	names = append(names, "_rpc_lock")
+	if gen.HasClientStreams(commands, streaming) {
+		names = append(names, "_serialize_each")
+	}
+	if gen.HasSessionProtected(commands) {
+		names = append(names, "_session_call")
+	}
+	if gen.UsesWellKnownType(commands, protomodel.TimestampType) {
+		names = append(names, "_timestamp")
+	}
+	if gen.HasReplayProtected(commands) {
+		names = append(names, "_with_replay_counter")
+	}
+	return names
+}
+
+// pyImportLine renders a from-import, wrapped one name per line the way ruff
+// formats imports that exceed the line length.
+func pyImportLine(module string, names []string) string {
+	line := fmt.Sprintf("from %s import %s", module, strings.Join(names, ", "))
+	if len(line) <= 88 {
+		return line + "\n"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("from %s import (\n", module))
+	for _, n := range names {
+		b.WriteString("    " + n + ",\n")
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyRPCStatsHelpers emits the stats helper of the Python client mixin.
+func writePyRPCStatsHelpers(gctx *gen.Context, b *strings.Builder, cmd gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("    async def rpc_stats_by_command(self, *, reset=False):\n")
+	b.WriteString("        \"\"\"Return the firmware's per-command counters keyed by command name.\"\"\"\n")
+	b.WriteString(fmt.Sprintf("        resp = await self.%s(reset=reset)\n", gctx.PyMethodName(cmd.Snake)))
+	b.WriteString("        return {s.name: s for s in resp.stats}\n")
+}
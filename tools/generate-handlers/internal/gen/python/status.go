@@ -0,0 +1,29 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// pyStatusCheck returns the statements raising CommandStatusError for resp,
+// or "" when the command is not checked.
+func pyStatusCheck(cmd gen.Command, indent string) string {
+	if cmd.StatusField == "" {
+		return ""
+	}
+	return fmt.Sprintf("%sif resp.%s != %d:\n%s    raise CommandStatusError(\"%s\", \"%s\", resp.%s)\n",
+		indent, cmd.StatusField, cmd.StatusOK, indent, cmd.Snake, cmd.StatusField, cmd.StatusField)
+}
+
+func writePyStatusError(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class CommandStatusError(RemoteError):\n")
+	b.WriteString("    \"\"\"Raised when a checked command responds with a non-OK status.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, command, field, status):\n")
+	b.WriteString("        super().__init__(command, status, f\"{command} failed: {field}={status}\")\n")
+	b.WriteString("        self.field = field\n")
+}
@@ -0,0 +1,65 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// pyStatusNames returns the status names the split Python client exports.
+func pyStatusNames() []string {
+	names := []string{"StatusCode"}
+	for _, sc := range gen.StatusCodes[1:] {
+		names = append(names, gen.StatusErrorName(sc.Name)+"Error")
+	}
+	return names
+}
+
+// writePyStatusCodes emits StatusCode, a RemoteError subclass per code and
+// _check_status, which _decode calls first.
+func writePyStatusCodes(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class StatusCode(enum.IntEnum):\n")
+	b.WriteString("    \"\"\"Status codes of error responses; 128 and up are application codes.\"\"\"\n")
+	b.WriteByte('\n')
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("    %s = %d\n", sc.Name, i))
+	}
+	for _, sc := range gen.StatusCodes[1:] {
+		b.WriteByte('\n')
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("class %sError(RemoteError):\n", gen.StatusErrorName(sc.Name)))
+		b.WriteString(fmt.Sprintf("    \"\"\"%s\"\"\"\n", sc.Doc))
+	}
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("# Error responses hold only a status, in a field no message uses.\n")
+	var tag strings.Builder
+	for _, c := range gen.StatusTag {
+		tag.WriteString(fmt.Sprintf("\\x%02x", c))
+	}
+	b.WriteString(fmt.Sprintf("_STATUS_TAG = b\"%s\"\n", tag.String()))
+	b.WriteByte('\n')
+	b.WriteString("_STATUS_ERRORS = {\n")
+	for _, sc := range gen.StatusCodes[1:] {
+		b.WriteString(fmt.Sprintf("    StatusCode.%s: %sError,\n", sc.Name, gen.StatusErrorName(sc.Name)))
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("def _check_status(data, command):\n")
+	b.WriteString("    if not data.startswith(_STATUS_TAG):\n")
+	b.WriteString("        return\n")
+	b.WriteString("    status = shift = 0\n")
+	b.WriteString("    for byte in data[len(_STATUS_TAG) :]:\n")
+	b.WriteString("        status |= (byte & 0x7F) << shift\n")
+	b.WriteString("        shift += 7\n")
+	b.WriteString("        if byte < 0x80:\n")
+	b.WriteString("            break\n")
+	b.WriteString("    error = _STATUS_ERRORS.get(status)\n")
+	b.WriteString("    if error is None:\n")
+	b.WriteString("        raise RemoteError(command, status)\n")
+	b.WriteString("    raise error(command, status, f\"{command} failed: {StatusCode(status).name}\")\n")
+}
@@ -0,0 +1,14 @@
+package python
+
+import "strings"
+
+// writePySerializeEach emits the helper encoding the requests of a client
+// stream as they are consumed.
+func writePySerializeEach(b *strings.Builder) {
+	b.WriteString("\n\n")
+	b.WriteString("def _serialize_each(messages):\n")
+	b.WriteString("    # Encode lazily, so a generator can produce the stream as it is sent.\n")
+	b.WriteString("    if isinstance(messages, AsyncIterable):\n")
+	b.WriteString("        return (m.SerializeToString() async for m in messages)\n")
+	b.WriteString("    return (m.SerializeToString() for m in messages)\n")
+}
@@ -0,0 +1,38 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyStringTooLongError emits the error the Python client raises for a
+// bounded string argument that is too long.
+func writePyStringTooLongError(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteByte('\n')
+	b.WriteString("class StringTooLongError(BlerpcError, ValueError):\n")
+	b.WriteString("    \"\"\"A string argument is longer in UTF-8 than its max_size allows.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    def __init__(self, command, field, size, max_bytes):\n")
+	b.WriteString("        super().__init__(\n")
+	b.WriteString("            f\"{command}: {field} is {size} bytes in UTF-8, over the {max_bytes}\"\n")
+	b.WriteString("            \" its max_size allows\"\n")
+	b.WriteString("        )\n")
+	b.WriteString("        self.command = command\n")
+	b.WriteString("        self.field = field\n")
+	b.WriteString("        self.size = size\n")
+	b.WriteString("        self.max_bytes = max_bytes\n")
+}
+
+// writePyStringCheck emits the UTF-8 length check of string argument f.
+func writePyStringCheck(b *strings.Builder, cmd gen.Command, f gen.Field, indent string) {
+	size := fmt.Sprintf("len(%s.encode())", f.Name)
+	cond := fmt.Sprintf("%s > %d", size, f.MaxBytes)
+	if gen.NullableField(f) {
+		cond = f.Name + " is not None and " + cond
+	}
+	b.WriteString(fmt.Sprintf("%sif %s:\n", indent, cond))
+	b.WriteString(fmt.Sprintf("%s    raise StringTooLongError(\"%s\", \"%s\", %s, %d)\n", indent, cmd.Snake, f.Name, size, f.MaxBytes))
+}
@@ -0,0 +1,156 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// The synchronous Python client (sync_client.py, with python_sync: true in
+// blerpc.yaml) is for scripts that have no event loop.
+// GeneratedSyncClientMixin has a blocking method per generated client method,
+// by the same name and parameters, that hands the call of the async method to
+// the _call_sync hook (and P2C iterators to _iter_sync), so call policies,
+// sessions and the other features of the async client apply unchanged.
+// SyncClient implements the hooks by running an async client on an event loop
+// thread.
+
+// pySyncReturn renders the statement returning
+// hook(self.async_client.method(args)), wrapped the way ruff formats calls that exceed the line length.
+func pySyncReturn(indent, hook, method string, args []string) string {
+	inner := strings.TrimSuffix(pyCall(indent+"    ", "self.async_client."+method, args...), "\n")
+	if line := fmt.Sprintf("%sreturn %s(%s)", indent, hook, strings.TrimSpace(inner)); !strings.Contains(inner, "\n") && len(line) <= 88 {
+		return line + "\n"
+	}
+	return fmt.Sprintf("%sreturn %s(\n%s\n%s)\n", indent, hook, inner, indent)
+}
+
+// pySyncArgs returns the keyword arguments passing the request fields of cmd
+// through to the async method.
+func pySyncArgs(cmd gen.Command) []string {
+	var args []string
+	for _, f := range cmd.RequestFields {
+		args = append(args, f.Name+"="+f.Name)
+	}
+	return args
+}
+
+// GenerateSyncClient returns sync_client.py, placed next to the generated
+// client module.
+func GenerateSyncClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("from __future__ import annotations\n")
+	b.WriteByte('\n')
+	b.WriteString("import asyncio\n")
+	if pyRequestsUseDatetime(commands) {
+		b.WriteString("import datetime\n")
+	}
+	b.WriteString("import threading\n")
+	if hasRenamedCommands(commands) {
+		b.WriteString("import warnings\n")
+	}
+	b.WriteString("from collections.abc import AsyncIterator, Awaitable, Iterable, Iterator\n")
+	b.WriteString("from typing import Protocol, TypeVar\n")
+	imports := gen.OverrideImports(commands, "python")
+	if pyRequestsUseImportedMessages(commands) {
+		imports = append([]string{"from google.protobuf import message"}, imports...)
+	}
+	if len(imports) > 0 {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(imports, "\n") + "\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString(Pb2Import(gctx, pkg, ".") + "\n")
+	b.WriteString("from .generated_client import GeneratedClientMixin\n")
+	b.WriteByte('\n')
+	b.WriteString("T = TypeVar(\"T\")\n")
+	b.WriteString("\n\n")
+	b.WriteString("class SyncRpcTransport(Protocol):\n")
+	b.WriteString("    \"\"\"Blocking hooks the generated sync methods call; SyncClient implements them.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("    async_client: GeneratedClientMixin\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _call_sync(self, call: Awaitable[T]) -> T: ...\n")
+	b.WriteByte('\n')
+	b.WriteString("    def _iter_sync(self, stream: AsyncIterator[T]) -> Iterator[T]: ...\n")
+	b.WriteString("\n\n")
+	b.WriteString("class GeneratedSyncClientMixin(SyncRpcTransport):\n")
+	b.WriteString("    \"\"\"Blocking variants of the GeneratedClientMixin methods, by the same names.\n")
+	b.WriteByte('\n')
+	b.WriteString("    Each runs the method of async_client through _call_sync and returns its\n")
+	b.WriteString("    result, or raises its error.\n")
+	b.WriteString("    \"\"\"\n")
+
+	for _, cmd := range commands {
+		if _, ok := streaming[cmd.Snake]; ok {
+			continue
+		}
+		b.WriteByte('\n')
+		b.WriteString(pyDef("    ", "def "+gctx.PyMethodName(cmd.Snake), pyMethodParams(cmd, pkg), pkg+"_pb2."+cmd.ResponseMsg))
+		b.WriteString(fmt.Sprintf("        \"\"\"Call the %s command, blocking until it responds.\"\"\"\n", cmd.Snake))
+		b.WriteString(pySyncReturn("        ", "self._call_sync", gctx.PyMethodName(cmd.Snake), pySyncArgs(cmd)))
+	}
+	for _, cmd := range commands {
+		dir, ok := streaming[cmd.Snake]
+		if !ok {
+			continue
+		}
+		reqCls := pkg + "_pb2." + cmd.RequestMsg
+		respCls := pkg + "_pb2." + cmd.ResponseMsg
+		b.WriteByte('\n')
+		if dir == "p2c" {
+			params := pyMethodParams(cmd, pkg)
+			b.WriteString(pyDef("    ", "def "+gctx.PyMethodName("iter_"+cmd.Snake), params, "Iterator["+respCls+"]"))
+			b.WriteString(fmt.Sprintf("        \"\"\"P2C stream: %s, yielding each response as it arrives.\n", cmd.Snake))
+			b.WriteByte('\n')
+			b.WriteString("        Closing the iterator before the stream ends cancels the stream.\n")
+			b.WriteString("        \"\"\"\n")
+			b.WriteString(pySyncReturn("        ", "self._iter_sync", gctx.PyMethodName("iter_"+cmd.Snake), pySyncArgs(cmd)))
+			b.WriteByte('\n')
+			b.WriteString(pyDef("    ", "def "+gctx.PyMethodName(cmd.Snake), params, "list["+respCls+"]"))
+			b.WriteString(fmt.Sprintf("        \"\"\"P2C stream: %s.\"\"\"\n", cmd.Snake))
+			b.WriteString(pySyncReturn("        ", "self._call_sync", gctx.PyMethodName(cmd.Snake), pySyncArgs(cmd)))
+		} else {
+			b.WriteString(pyDef("    ", "def "+gctx.PyMethodName(cmd.Snake), []string{"self", "messages: Iterable[" + reqCls + "]"}, respCls))
+			b.WriteString(fmt.Sprintf("        \"\"\"C2P stream: %s.\n", cmd.Snake))
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("        messages is an iterable of %s, each sent as it is\n", cmd.RequestMsg))
+			b.WriteString("        produced (on the event loop of async_client).\n")
+			b.WriteString("        \"\"\"\n")
+			b.WriteString(pySyncReturn("        ", "self._call_sync", gctx.PyMethodName(cmd.Snake), []string{"messages"}))
+		}
+	}
+	for _, cmd := range commands {
+		if !cmd.Unwrap {
+			continue
+		}
+		f := cmd.ResponseFields[0]
+		b.WriteByte('\n')
+		b.WriteString(pyDef("    ", "def "+gctx.PyMethodName(gen.UnwrapName(cmd)), pyMethodParams(cmd, pkg), ResolveType(f, pkg)))
+		b.WriteString(fmt.Sprintf("        \"\"\"Return the %s of the %s response.\"\"\"\n", f.Name, cmd.Snake))
+		b.WriteString(pySyncReturn("        ", "self._call_sync", gctx.PyMethodName(gen.UnwrapName(cmd)), pySyncArgs(cmd)))
+	}
+	for _, cmd := range commands {
+		if cmd.RenamedFrom == "" {
+			continue
+		}
+		old, method := gctx.PyMethodName(protomodel.CamelToSnake(cmd.RenamedFrom)), gctx.PyMethodName(cmd.Snake)
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    def %s(self, *args, **kwargs):\n", old))
+		b.WriteString(fmt.Sprintf("        \"\"\"Deprecated: renamed to %s.\"\"\"\n", method))
+		b.WriteString("        warnings.warn(\n")
+		b.WriteString(fmt.Sprintf("            \"%s() is deprecated, use %s()\",\n", old, method))
+		b.WriteString("            DeprecationWarning,\n")
+		b.WriteString("            stacklevel=2,\n")
+		b.WriteString("        )\n")
+		b.WriteString(fmt.Sprintf("        return self.%s(*args, **kwargs)\n", method))
+	}
+
+	b.WriteString(gctx.RenderTemplate("py_sync_client.py.tmpl", nil))
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGeneratePySyncClient(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.RenamedFrom = "Say"
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	out := GenerateSyncClient(gctx, []gen.Command{echo, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}, streaming, "blerpc")
+	for _, want := range []string{
+		"import warnings\n",
+		"from .generated_client import GeneratedClientMixin\n",
+		"class SyncRpcTransport(Protocol):\n",
+		"    def _call_sync(self, call: Awaitable[T]) -> T: ...\n",
+		"class GeneratedSyncClientMixin(SyncRpcTransport):\n",
+		"    def echo(self, *, message: str = \"\") -> blerpc_pb2.EchoResponse:\n" +
+			"        \"\"\"Call the echo command, blocking until it responds.\"\"\"\n" +
+			"        return self._call_sync(self.async_client.echo(message=message))\n",
+		"        return self._iter_sync(self.async_client.iter_counter_stream(start=start))\n",
+		"    ) -> list[blerpc_pb2.CounterStreamResponse]:\n",
+		"        self, messages: Iterable[blerpc_pb2.CounterUploadRequest]\n",
+		"        return self._call_sync(self.async_client.counter_upload(messages))\n",
+		"    def say(self, *args, **kwargs):\n",
+		"        return self.echo(*args, **kwargs)\n",
+		"class SyncClient(GeneratedSyncClientMixin):\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestPySyncReturn(t *testing.T) {
+	tests := []struct {
+		method string
+		args   []string
+		want   string
+	}{
+		{"echo", []string{"message=message"}, "        return self._call_sync(self.async_client.echo(message=message))\n"},
+		{"flash_read", []string{"address=address", "length=length"},
+			"        return self._call_sync(\n" +
+				"            self.async_client.flash_read(address=address, length=length)\n" +
+				"        )\n"},
+		{"iter_log_stream", []string{"min_level=min_level", "max_entries=max_entries", "since_boot_ms=since_boot_ms"},
+			"        return self._call_sync(\n" +
+				"            self.async_client.iter_log_stream(\n" +
+				"                min_level=min_level,\n" +
+				"                max_entries=max_entries,\n" +
+				"                since_boot_ms=since_boot_ms,\n" +
+				"            )\n" +
+				"        )\n"},
+	}
+	for _, tt := range tests {
+		if got := pySyncReturn("        ", "self._call_sync", tt.method, tt.args); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.method, got, tt.want)
+		}
+	}
+}
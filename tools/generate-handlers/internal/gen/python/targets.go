@@ -0,0 +1,29 @@
+package python
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Pb2Import returns the import of the protoc Python module of pkg, bound
+// to <pkg>_pb2. from is the package holding it by default, relative to the
+// importing module.
+func Pb2Import(gctx *gen.Context, pkg, from string) string {
+	mod := pkg + "_pb2"
+	if gctx.Names.PythonPb2Module == "" {
+		return "from " + from + " import " + mod
+	}
+	parent, name := "", gctx.Names.PythonPb2Module
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		parent, name = name[:i], name[i+1:]
+	}
+	line := "import " + name
+	if parent != "" {
+		line = "from " + parent + " import " + name
+	}
+	if name != mod {
+		line += " as " + mod
+	}
+	return line
+}
@@ -0,0 +1,279 @@
+package python
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// -out-py-tests generates a baseline end-to-end pytest suite: a test per
+// command, parametrized over the smallest and the largest values of its
+// request fields, calls the method of the generated Python client against
+// the generated Python handlers and checks that the response decodes and
+// round-trips. The handlers run behind LoopbackPeripheral, an in-process
+// link framing the containers as the firmware does at the smallest ATT MTU,
+// with EchoHandlers answering every command with its request echoed into the
+// response, like DefaultHandler of the Go simulator; the tests also check
+// the fields echoed back. Commands whose requests carry a session token or
+// replay counter, compressed commands and built-ins are left out, as the
+// handlers do not implement them. The fixed code is py_sim_tests.py.tmpl.
+
+// pyTestCommands returns the commands the suite calls.
+func pyTestCommands(commands []gen.Command) []gen.Command {
+	var tested []gen.Command
+	for _, cmd := range commands {
+		if cmd.Builtin == "" && !cmd.SessionProtected && !cmd.ReplayProtected && cmd.Compression == "" {
+			tested = append(tested, cmd)
+		}
+	}
+	return tested
+}
+
+// pyScalarBounds are the smallest and largest values of the scalar types.
+// The float bounds are those of float32, which survive the round trip.
+var pyScalarBounds = map[string][2]string{
+	"uint32":   {"0", "4294967295"},
+	"fixed32":  {"0", "4294967295"},
+	"int32":    {"-2147483648", "2147483647"},
+	"sint32":   {"-2147483648", "2147483647"},
+	"sfixed32": {"-2147483648", "2147483647"},
+	"uint64":   {"0", "18446744073709551615"},
+	"fixed64":  {"0", "18446744073709551615"},
+	"int64":    {"-9223372036854775808", "9223372036854775807"},
+	"sint64":   {"-9223372036854775808", "9223372036854775807"},
+	"sfixed64": {"-9223372036854775808", "9223372036854775807"},
+	"float":    {"-3.4028234663852886e38", "3.4028234663852886e38"},
+	"double":   {"-1.7976931348623157e308", "1.7976931348623157e308"},
+	"bool":     {"False", "True"},
+	"string":   {`""`, `"blerpc ✓"`},
+}
+
+// pyDefaultBytesLen is the length of the largest value of bytes fields
+// without a max size.
+const pyDefaultBytesLen = 64
+
+// pyBoundaryValues returns the smallest and the largest value of f as Python
+// literals, or ok false for the fields the suite leaves unset: messages,
+// maps and fields with a Python type mapping.
+func pyBoundaryValues(f gen.Field, enumByName map[string]gen.Enum) (lo, hi string, ok bool) {
+	if _, mapped := gen.FieldTypeOverride(f, "python"); mapped || f.IsMessage || f.IsMap {
+		return "", "", false
+	}
+	switch {
+	case f.IsEnum:
+		// Closed proto2 enums only take the values they define.
+		lo, hi = "0", "0"
+		if en, found := enumByName[f.Type]; found && len(en.Values) > 0 {
+			numbers := make([]int, len(en.Values))
+			for i, v := range en.Values {
+				numbers[i] = v.Number
+			}
+			lo, hi = fmt.Sprint(slices.Min(numbers)), fmt.Sprint(slices.Max(numbers))
+		}
+	case f.Type == "bytes":
+		n := pyDefaultBytesLen
+		if f.MaxSize > 0 {
+			n = f.MaxSize
+		}
+		lo, hi = `b""`, fmt.Sprintf("bytes(range(%d))", n)
+		if n > 256 {
+			hi = fmt.Sprintf("bytes(i %% 256 for i in range(%d))", n)
+		}
+	default:
+		bounds, found := pyScalarBounds[f.Type]
+		if !found {
+			return "", "", false
+		}
+		lo, hi = bounds[0], bounds[1]
+	}
+	if f.IsRepeated {
+		return "[]", "[" + hi + ", " + lo + "]", true
+	}
+	return lo, hi, true
+}
+
+// pyEchoedFields returns the fields of set, the request fields a test sets,
+// that EchoHandlers copies into the response of cmd: those with a response
+// field of the same name and type.
+func pyEchoedFields(cmd gen.Command, set []gen.Field) []gen.Field {
+	var echoed []gen.Field
+	for _, f := range set {
+		for _, g := range cmd.ResponseFields {
+			if g.Name == f.Name && g.Type == f.Type && g.IsRepeated == f.IsRepeated && !g.IsMessage && !g.IsMap {
+				echoed = append(echoed, f)
+			}
+		}
+	}
+	return echoed
+}
+
+// pyFieldsDict renders the fields of a test case as a dict item of the
+// parametrize list, wrapped the way ruff formats it.
+func pyFieldsDict(names, values []string) string {
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = fmt.Sprintf("%q: %s", name, values[i])
+	}
+	if line := "        {" + strings.Join(items, ", ") + "},"; len(line) <= 88 {
+		return line + "\n"
+	}
+	var b strings.Builder
+	b.WriteString("        {\n")
+	for _, item := range items {
+		b.WriteString("            " + item + ",\n")
+	}
+	b.WriteString("        },\n")
+	return b.String()
+}
+
+// pySysPath renders the os.path.join of the directory dir relative to the
+// directory of the test module at testPath.
+func pySysPath(testPath, dir string) string {
+	args := []string{"os.path.dirname(__file__)"}
+	rel, err := filepath.Rel(filepath.Dir(testPath), dir)
+	if err != nil {
+		abs, _ := filepath.Abs(dir)
+		return fmt.Sprintf("%q", filepath.ToSlash(abs))
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(rel), "/") {
+		args = append(args, fmt.Sprintf("%q", elem))
+	}
+	return "os.path.join(" + strings.Join(args, ", ") + ")"
+}
+
+// writePyEchoHandlers emits EchoHandlers, overriding the handler of every
+// tested command.
+func writePyEchoHandlers(b *strings.Builder, tested []gen.Command, streaming map[string]string, pkg string) {
+	b.WriteString("class EchoHandlers(BlerpcHandlers):\n")
+	b.WriteString("    \"\"\"Answers every command with its request echoed into the response.\"\"\"\n")
+	uploads := slices.ContainsFunc(tested, func(cmd gen.Command) bool { return streaming[cmd.Snake] == "c2p" })
+	if uploads {
+		b.WriteByte('\n')
+		b.WriteString("    def __init__(self) -> None:\n")
+		b.WriteString("        # The last request of each stream from the central.\n")
+		b.WriteString("        self.uploads: dict[str, Message] = {}\n")
+	}
+	for _, cmd := range tested {
+		// The status field of the response reads OK, so the client returns it.
+		resp := fmt.Sprintf("%s_pb2.%s()", pkg, cmd.ResponseMsg)
+		if cmd.StatusField != "" {
+			resp = fmt.Sprintf("%s_pb2.%s(%s=%d)", pkg, cmd.ResponseMsg, cmd.StatusField, cmd.StatusOK)
+		}
+		b.WriteByte('\n')
+		switch streaming[cmd.Snake] {
+		case "p2c":
+			b.WriteString(fmt.Sprintf("    async def %s(self, req):\n", cmd.Snake))
+			b.WriteString(pyCall("        ", "yield _echo", "req", resp))
+		case "c2p":
+			b.WriteString(fmt.Sprintf("    async def %s(self, req):\n", cmd.Snake))
+			b.WriteString(fmt.Sprintf("        self.uploads[%q] = req\n", cmd.Snake))
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("    async def finish_%s(self):\n", cmd.Snake))
+			b.WriteString(pyCall("        ", "return _echo", fmt.Sprintf("self.uploads.pop(%q)", cmd.Snake), resp))
+		default:
+			b.WriteString(fmt.Sprintf("    async def %s(self, req):\n", cmd.Snake))
+			b.WriteString(pyCall("        ", "return _echo", "req", resp))
+		}
+	}
+}
+
+// writePyCommandTest emits the test of cmd.
+func writePyCommandTest(gctx *gen.Context, b *strings.Builder, cmd gen.Command, stream, pkg string, enumByName map[string]gen.Enum) {
+	var set []gen.Field
+	var names, lows, highs []string
+	for _, f := range gen.SampleFields(cmd.RequestFields) {
+		if lo, hi, ok := pyBoundaryValues(f, enumByName); ok {
+			set = append(set, f)
+			names = append(names, f.Name)
+			lows, highs = append(lows, lo), append(highs, hi)
+		}
+	}
+
+	b.WriteString("\n\n@pytest.mark.asyncio\n")
+	params := []string{"client: SimulatorClient"}
+	args := ""
+	if len(set) > 0 {
+		b.WriteString("@pytest.mark.parametrize(\n")
+		b.WriteString("    \"fields\",\n")
+		b.WriteString("    [\n")
+		b.WriteString(pyFieldsDict(names, lows))
+		b.WriteString(pyFieldsDict(names, highs))
+		b.WriteString("    ],\n")
+		b.WriteString("    ids=[\"min\", \"max\"],\n")
+		b.WriteString(")\n")
+		params = append(params, "fields: dict[str, Any]")
+		args = "**fields"
+	}
+	b.WriteString(pyDef("", "async def test_"+cmd.Snake, params, "None"))
+	method := "client." + gctx.PyMethodName(cmd.Snake)
+	switch stream {
+	case "p2c":
+		b.WriteString(pyCall("    ", "resps = await "+method, args))
+		b.WriteString("    assert len(resps) == 1\n")
+		b.WriteString("    resp = resps[0]\n")
+	case "c2p":
+		b.WriteString(pyCall("    ", "resp = await "+method, fmt.Sprintf("[%s_pb2.%s(%s)]", pkg, cmd.RequestMsg, args)))
+	default:
+		b.WriteString(pyCall("    ", "resp = await "+method, args))
+	}
+	b.WriteString("    _assert_round_trips(resp)\n")
+	for _, f := range pyEchoedFields(cmd, set) {
+		if f.IsRepeated {
+			b.WriteString(fmt.Sprintf("    assert list(resp.%s) == fields[%q]\n", f.Name, f.Name))
+		} else {
+			b.WriteString(fmt.Sprintf("    assert resp.%s == fields[%q]\n", f.Name, f.Name))
+		}
+	}
+}
+
+// GenerateTests returns the pytest module at testPath, calling the client
+// at clientPath against the handlers at handlersPath.
+func GenerateTests(gctx *gen.Context, commands []gen.Command, streaming map[string]string, enumByName map[string]gen.Enum, pkg, testPath, clientPath, handlersPath string) string {
+	tested := pyTestCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\n")
+	b.WriteByte('\n')
+	b.WriteString("End-to-end tests of the generated client against the generated handlers,\n")
+	b.WriteString("linked in process: each command is called with the smallest and the largest\n")
+	b.WriteString("values of its request fields, and its response, the request echoed back,\n")
+	b.WriteString("must decode and round-trip.\n")
+	b.WriteString("\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("import os\n")
+	b.WriteString("import sys\n")
+	b.WriteString("from collections.abc import AsyncIterable, AsyncIterator, Iterable, Iterator\n")
+	b.WriteString("from typing import Any, TypeVar\n")
+	b.WriteByte('\n')
+	b.WriteString("import pytest\n")
+	b.WriteString("from blerpc_protocol.command import CommandPacket, CommandType\n")
+	b.WriteString("from blerpc_protocol.container import (\n")
+	b.WriteString("    Container,\n")
+	b.WriteString("    ContainerAssembler,\n")
+	b.WriteString("    ContainerSplitter,\n")
+	b.WriteString("    ContainerType,\n")
+	b.WriteString("    ControlCmd,\n")
+	b.WriteString("    make_stream_end_c2p,\n")
+	b.WriteString("    make_stream_end_p2c,\n")
+	b.WriteString(")\n")
+	b.WriteString("from google.protobuf.message import Message\n")
+	b.WriteByte('\n')
+	centralRoot := filepath.Dir(filepath.Dir(filepath.Dir(clientPath)))
+	b.WriteString(pyCall("", "sys.path.insert", "0", pySysPath(testPath, centralRoot)))
+	b.WriteString(pyCall("", "sys.path.insert", "0", pySysPath(testPath, filepath.Dir(handlersPath))))
+	b.WriteString(Pb2Import(gctx, pkg, pkg+".generated") + "\n")
+	clientModule := strings.TrimSuffix(filepath.Base(clientPath), ".py")
+	b.WriteString(fmt.Sprintf("from %s.generated.%s import GeneratedClientMixin\n", pkg, clientModule))
+	handlersModule := strings.TrimSuffix(filepath.Base(handlersPath), ".py")
+	b.WriteString(fmt.Sprintf("from %s import BlerpcHandlers, HandlerRegistry\n", handlersModule))
+	b.WriteString(gctx.RenderTemplate("py_sim_tests.py.tmpl", nil))
+	b.WriteString("\n\n")
+	writePyEchoHandlers(&b, tested, streaming, pkg)
+	for _, cmd := range tested {
+		writePyCommandTest(gctx, &b, cmd, streaming[cmd.Snake], pkg, enumByName)
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestPyBoundaryValues(t *testing.T) {
+	enums := map[string]gen.Enum{"Mode": {Name: "Mode", Values: []gen.EnumValue{{Name: "MODE_OFF", Number: 1}, {Name: "MODE_ON", Number: 4}}}}
+	for _, tc := range []struct {
+		f      gen.Field
+		lo, hi string
+	}{
+		{gen.Field{Type: "int32"}, "-2147483648", "2147483647"},
+		{gen.Field{Type: "uint64"}, "0", "18446744073709551615"},
+		{gen.Field{Type: "bytes", MaxSize: 16}, `b""`, "bytes(range(16))"},
+		{gen.Field{Type: "bytes", MaxSize: 300}, `b""`, "bytes(i % 256 for i in range(300))"},
+		{gen.Field{Type: "Mode", IsEnum: true}, "1", "4"},
+		{gen.Field{Type: "bool", IsRepeated: true}, "[]", "[True, False]"},
+	} {
+		lo, hi, ok := pyBoundaryValues(tc.f, enums)
+		if !ok || lo != tc.lo || hi != tc.hi {
+			t.Errorf("%s: got %s, %s, %v; want %s, %s", tc.f.Type, lo, hi, ok, tc.lo, tc.hi)
+		}
+	}
+	for _, f := range []gen.Field{
+		{Type: "Point", IsMessage: true},
+		{Type: "map", IsMap: true, KeyType: "string", ValueType: "int32"},
+		{Type: "int64", TypeOverrides: map[string]gen.TypeOverride{"python": {Type: "datetime"}}},
+	} {
+		if _, _, ok := pyBoundaryValues(f, nil); ok {
+			t.Errorf("%s: want the field left unset", f.Type)
+		}
+	}
+}
+
+func TestGeneratePyTests(t *testing.T) {
+	gctx := gen.NewContext()
+	protected := gentest.EchoCommand()
+	protected.Camel, protected.Snake, protected.SessionProtected = "Secret", "secret", true
+	out := GenerateTests(gctx, []gen.Command{gentest.EchoCommand(), protected}, nil, nil, "blerpc",
+		"central_py/tests/test_generated.py", "central_py/blerpc/generated/generated_client.py", "peripheral_py/generated_handlers.py")
+	for _, want := range []string{
+		`sys.path.insert(0, os.path.join(os.path.dirname(__file__), ".."))`,
+		`sys.path.insert(0, os.path.join(os.path.dirname(__file__), "..", "..", "peripheral_py"))`,
+		"from generated_handlers import BlerpcHandlers, HandlerRegistry\n",
+		"    async def echo(self, req):\n        return _echo(req, blerpc_pb2.EchoResponse())\n",
+		"        {\"message\": \"\"},\n        {\"message\": \"blerpc ✓\"},\n",
+		"async def test_echo(client: SimulatorClient, fields: dict[str, Any]) -> None:\n",
+		"    resp = await client.echo(**fields)\n",
+		"    assert resp.message == fields[\"message\"]\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Error("session-protected command is tested")
+	}
+}
@@ -0,0 +1,26 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyTimeSyncHelpers emits the clock helper of the Python client mixin.
+func writePyTimeSyncHelpers(gctx *gen.Context, b *strings.Builder, cmd gen.Command) {
+	b.WriteByte('\n')
+	b.WriteString("    async def sync_time(self, *, compensate=False):\n")
+	b.WriteString("        \"\"\"Set the peripheral's wall clock to this host's.\n")
+	b.WriteByte('\n')
+	b.WriteString("        With compensate, a first exchange measures the round trip and the\n")
+	b.WriteString("        second adds half of it, the estimated delivery delay.\n")
+	b.WriteString("        \"\"\"\n")
+	b.WriteString("        offset_us = 0\n")
+	b.WriteString("        if compensate:\n")
+	b.WriteString("            start = time.monotonic_ns()\n")
+	b.WriteString(fmt.Sprintf("            await self.%s(unix_time_us=time.time_ns() // 1000)\n", gctx.PyMethodName(cmd.Snake)))
+	b.WriteString("            offset_us = (time.monotonic_ns() - start) // 2000\n")
+	b.WriteString("        now_us = time.time_ns() // 1000\n")
+	b.WriteString(fmt.Sprintf("        return await self.%s(unix_time_us=now_us, offset_us=offset_us)\n", gctx.PyMethodName(cmd.Snake)))
+}
@@ -0,0 +1,64 @@
+package python
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// scalarPythonType annotates a value of f's type. Messages of the main file
+// are classes of <pkg>_pb2; those of imported files, whose modules the client
+// does not import, are annotated as message.Message.
+func scalarPythonType(f gen.Field, pkg string) string {
+	if f.IsEnum {
+		return "int"
+	}
+	if f.IsMessage {
+		if f.TypeFile != "" {
+			return "message.Message"
+		}
+		return pkg + "_pb2." + f.Type
+	}
+	return gen.LookupScalar(gen.PythonTypes, f.Type, "object")
+}
+
+func ResolveType(f gen.Field, pkg string) string {
+	if f.IsMap {
+		k := gen.LookupScalar(gen.PythonTypes, f.KeyType, "object")
+		v := gen.LookupScalar(gen.PythonTypes, f.ValueType, "object")
+		if f.ValueIsMessage {
+			v = scalarPythonType(gen.MapValueField(f), pkg)
+		}
+		return "dict[" + k + ", " + v + "]"
+	}
+	base := scalarPythonType(f, pkg)
+	if f.IsRepeated {
+		return "list[" + base + "]"
+	}
+	return base
+}
+
+func ResolveDefault(f gen.Field) string {
+	if f.Oneof != "" || gen.NullableField(f) {
+		// Members passed as None stay unset, so at most the given one is set;
+		// optional fields passed as None stay unset too.
+		return "None"
+	}
+	if d, ok := gen.DefaultLiteral(f, "python"); ok {
+		return d
+	}
+	if f.IsMap {
+		return "None"
+	}
+	if f.IsRepeated {
+		return "None"
+	}
+	if f.IsEnum {
+		return "0"
+	}
+	if f.IsMessage {
+		return "None"
+	}
+	if d, ok := gen.PythonDefaults[f.Type]; ok {
+		return d
+	}
+	return "None"
+}
@@ -0,0 +1,23 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writePyUnwrapped emits the unwrapped methods of the Python client mixin.
+func writePyUnwrapped(gctx *gen.Context, b *strings.Builder, commands []gen.Command, pkg string) {
+	for _, cmd := range commands {
+		if !cmd.Unwrap {
+			continue
+		}
+		f := cmd.ResponseFields[0]
+		b.WriteByte('\n')
+		b.WriteString(pyDef("    ", "async def "+gctx.PyMethodName(gen.UnwrapName(cmd)), pyMethodParams(cmd, pkg), ResolveType(f, pkg)))
+		b.WriteString(fmt.Sprintf("        \"\"\"Return the %s of the %s response.\"\"\"\n", f.Name, cmd.Snake))
+		b.WriteString(pyCall("        ", "resp = await self."+gctx.PyMethodName(cmd.Snake), pySyncArgs(cmd)...))
+		b.WriteString(fmt.Sprintf("        return resp.%s\n", f.Name))
+	}
+}
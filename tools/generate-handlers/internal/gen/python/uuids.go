@@ -0,0 +1,22 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateUUIDs returns generated_uuids.py, placed next to the generated
+// client module.
+func GenerateUUIDs(gctx *gen.Context) string {
+	var b strings.Builder
+
+	b.WriteString("\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n")
+	b.WriteByte('\n')
+	b.WriteString("# GATT UUIDs of the blerpc service, from blerpc.yaml.\n")
+	b.WriteString(fmt.Sprintf("SERVICE_UUID = \"%s\"\n", gctx.ServiceUUID))
+	b.WriteString(fmt.Sprintf("CHAR_UUID = \"%s\"\n", gctx.CharUUID))
+	writePyGattGroups(gctx, &b)
+	return b.String()
+}
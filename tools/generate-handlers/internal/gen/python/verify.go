@@ -0,0 +1,24 @@
+package python
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CompileFile compiles the Python file named by its argument without
+// writing bytecode, which py_compile would leave in a __pycache__ directory
+// next to the generated file.
+const CompileFile = "import sys; compile(open(sys.argv[1], encoding='utf-8').read(), sys.argv[1], 'exec')"
+
+// CheckSyntax compiles Python source with the given interpreter
+// without writing bytecode.
+func CheckSyntax(ctx context.Context, python, name, content string) error {
+	cmd := exec.CommandContext(ctx, python, "-c", "import sys; compile(sys.stdin.read(), sys.argv[1], 'exec')", name)
+	cmd.Stdin = strings.NewReader(content)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v\n%s", name, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package python
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// writePyWellKnownHelpers emits the request-side converters referenced by
+// the Python well-known type mappings. None leaves the field unset.
+func writePyWellKnownHelpers(b *strings.Builder, commands []gen.Command) {
+	helpers := []struct {
+		protoType, name, module, cls, from string
+	}{
+		{protomodel.TimestampType, "_timestamp", "timestamp_pb2", "Timestamp", "FromDatetime"},
+		{protomodel.DurationType, "_duration", "duration_pb2", "Duration", "FromTimedelta"},
+	}
+	for _, h := range helpers {
+		if !gen.UsesWellKnownType(commands, h.protoType) {
+			continue
+		}
+		b.WriteByte('\n')
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("def %s(value):\n", h.name))
+		b.WriteString("    if value is None:\n")
+		b.WriteString("        return None\n")
+		b.WriteString(fmt.Sprintf("    msg = %s.%s()\n", h.module, h.cls))
+		b.WriteString(fmt.Sprintf("    msg.%s(value)\n", h.from))
+		b.WriteString("    return msg\n")
+	}
+}
+
+// pyProtobufImports returns the google.protobuf modules a Python client
+// module imports: mods plus the well-known type modules in use, sorted.
+func pyProtobufImports(commands []gen.Command, mods ...string) string {
+	if gen.UsesWellKnownType(commands, protomodel.DurationType) {
+		mods = append(mods, "duration_pb2")
+	}
+	if gen.UsesWellKnownType(commands, protomodel.TimestampType) {
+		mods = append(mods, "timestamp_pb2")
+	}
+	sort.Strings(mods)
+	return strings.Join(mods, ", ")
+}
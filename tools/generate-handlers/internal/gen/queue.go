@@ -0,0 +1,11 @@
+package gen
+
+func QueueableCommands(commands []Command) []Command {
+	var out []Command
+	for _, cmd := range commands {
+		if cmd.QueueTTL > 0 {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package gen
+
+import (
+	"maps"
+	"slices"
+)
+
+// messageRef returns the message a field holds, or its map values hold, or
+// "" for scalars and well-known types.
+func messageRef(f Field, messages map[string]Message) string {
+	name := f.Type
+	if f.IsMap {
+		name = f.ValueType
+	}
+	if _, ok := messages[name]; !ok || (!f.IsMessage && !f.ValueIsMessage) {
+		return ""
+	}
+	return name
+}
+
+// SensitiveMessages returns, sorted by name, the messages with a sensitive
+// field, directly or in a message they contain.
+func SensitiveMessages(messages map[string]Message) []Message {
+	holds := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for name, m := range messages {
+			if holds[name] {
+				continue
+			}
+			for _, f := range m.Fields {
+				if f.Sensitive || holds[messageRef(f, messages)] {
+					holds[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	var out []Message
+	for _, name := range slices.Sorted(maps.Keys(holds)) {
+		out = append(out, messages[name])
+	}
+	return out
+}
+
+// RedactPlans returns the capture stripping plan of every message holding
+// sensitive fields: by field number, "" to drop the field, or the message to
+// strip inside it. Map fields whose values hold sensitive fields walk into
+// their entry message, e.g. Outer.TagsEntry, whose field 2 is the value.
+func RedactPlans(messages map[string]Message) map[string]map[int]string {
+	plans := make(map[string]map[int]string)
+	holds := make(map[string]bool)
+	for _, m := range SensitiveMessages(messages) {
+		holds[m.Name] = true
+	}
+	for name := range holds {
+		plan := make(map[int]string)
+		for _, f := range messages[name].Fields {
+			ref := messageRef(f, messages)
+			switch {
+			case f.Sensitive:
+				plan[f.Number] = ""
+			case !holds[ref]:
+			case f.IsMap:
+				entry := name + "." + UpperCamel(f.Name) + "Entry"
+				plans[entry] = map[int]string{2: ref}
+				plan[f.Number] = entry
+			default:
+				plan[f.Number] = ref
+			}
+		}
+		plans[name] = plan
+	}
+	return plans
+}
+
+// redactedCall is how a recorder strips the payloads of one command.
+type redactedCall struct {
+	Command  string
+	Prefix   int    // bytes leading each request: session token, replay counter
+	Request  string // plan of the requests, "" when they hold no sensitive fields
+	Response string // plan of the responses, likewise
+	Opaque   bool   // payloads are not recorded at all
+}
+
+// RedactedCalls returns the commands whose payloads hold sensitive fields,
+// in command order, followed by the compression envelope when a compressed
+// command is one of them.
+func RedactedCalls(commands []Command, plans map[string]map[int]string) []redactedCall {
+	var calls []redactedCall
+	compressed := false
+	for _, cmd := range commands {
+		c := redactedCall{Command: cmd.Snake, Prefix: MockRequestPrefix(cmd)}
+		if _, ok := plans[cmd.RequestMsg]; ok {
+			c.Request = cmd.RequestMsg
+		}
+		if _, ok := plans[cmd.ResponseMsg]; ok {
+			c.Response = cmd.ResponseMsg
+		}
+		if c.Request == "" && c.Response == "" {
+			continue
+		}
+		calls = append(calls, c)
+		compressed = compressed || cmd.Compression != ""
+	}
+	if compressed {
+		calls = append(calls, redactedCall{Command: CompressedCommandName, Opaque: true})
+	}
+	return calls
+}
@@ -0,0 +1,37 @@
+package gen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestRedactPlans(t *testing.T) {
+	msgs := gentest.RedactMessages()
+	var names []string
+	for _, m := range SensitiveMessages(msgs) {
+		names = append(names, m.Name)
+	}
+	if want := []string{"Credentials", "LoginRequest"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sensitiveMessages = %v, want %v", names, want)
+	}
+	want := map[string]map[int]string{
+		"Credentials":                  {2: ""},
+		"LoginRequest":                 {1: "Credentials", 2: "LoginRequest.BackupKeysEntry"},
+		"LoginRequest.BackupKeysEntry": {2: "Credentials"},
+	}
+	if got := RedactPlans(msgs); !reflect.DeepEqual(got, want) {
+		t.Errorf("redactPlans = %v, want %v", got, want)
+	}
+
+	login := Command{Camel: "Login", Snake: "login", RequestMsg: "LoginRequest", ResponseMsg: "LoginResponse", SessionProtected: true, Compression: "deflate"}
+	calls := RedactedCalls([]Command{login, gentest.EchoCommand()}, want)
+	wantCalls := []redactedCall{
+		{Command: "login", Prefix: SessionTokenSize, Request: "LoginRequest"},
+		{Command: CompressedCommandName, Opaque: true},
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("redactedCalls = %+v, want %+v", calls, wantCalls)
+	}
+}
@@ -0,0 +1,15 @@
+package gen
+
+// A large schema takes a while to render for every target, so generate
+// queues most files with LazyOutput and renders them concurrently once the
+// list is complete. Renderers only read the commands, the config and the
+// options, and the files keep the order they were queued in, so the outputs
+// of a run do not depend on scheduling. Every file that has the generated
+// header is then stamped with the schema hash and generator version, so
+// identical inputs always give byte-identical files and a file shows which
+// schema it came from.
+
+// LazyOutput queues the file at path, rendered by render.
+func LazyOutput(path string, render func() string) Output {
+	return Output{Path: path, Render: render}
+}
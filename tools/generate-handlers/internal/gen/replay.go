@@ -0,0 +1,42 @@
+package gen
+
+// Replay protection keeps a recorded request, such as an unlock, from being
+// sent again by someone else. The clients start each replay-protected
+// request with a counter, 8 bytes little endian, that only goes up:
+// microseconds since the epoch, or one more than the last counter when the
+// clock has not moved on. The counter is followed by a MAC, the first 16
+// bytes of an HMAC-SHA256 under the session key of the command name, a NUL,
+// the counter and the request, so only a holder of the key can make a
+// request with a newer counter. A guard in front of the handler computes the
+// MAC with the firmware's <pkg>_replay_mac() hook and compares it in
+// constant time, checks the counter against the last one the peripheral
+// accepted, strips both, and drops the request with HANDLER_REPLAYED when
+// either check fails. The <pkg>_replay_counter_load()/_store() hooks let the
+// firmware keep the last counter across reboots. The key is the one the
+// session built-in authenticates centrals with, so replay protection needs
+// the built-in, though not a session.
+//
+// Commands are protected with
+//
+//	option (blerpc.replay_protected) = true;
+//
+// or, for schemas discovered by message naming, an entry under
+// replay_protected in blerpc.yaml.
+
+const (
+	// ReplayCounterSize is the length of the counter in front of the request.
+	ReplayCounterSize = 8
+	// ReplayMACSize is the length of the truncated HMAC after the counter.
+	ReplayMACSize = 16
+	// ReplayPrefixSize is the length of the counter and MAC together.
+	ReplayPrefixSize = ReplayCounterSize + ReplayMACSize
+)
+
+func HasReplayProtected(commands []Command) bool {
+	for _, cmd := range commands {
+		if cmd.ReplayProtected {
+			return true
+		}
+	}
+	return false
+}
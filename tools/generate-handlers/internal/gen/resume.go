@@ -0,0 +1,22 @@
+package gen
+
+import (
+	"fmt"
+)
+
+// IdempotentCallNames returns the names the clients' call functions receive
+// for the idempotent commands, as expressions of lang: the quoted wire name,
+// and with command IDs the one-character name the generated methods send.
+func IdempotentCallNames(commands []Command, lang string) []string {
+	var names []string
+	for _, cmd := range commands {
+		if !cmd.Idempotent {
+			continue
+		}
+		names = append(names, fmt.Sprintf("%q", cmd.Wire()))
+		if cmd.ID != 0 {
+			names = append(names, CallName(cmd, lang))
+		}
+	}
+	return names
+}
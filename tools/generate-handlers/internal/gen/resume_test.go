@@ -0,0 +1,33 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestParseIdempotencyLevel(t *testing.T) {
+	pf, err := protomodel.ParseReader(strings.NewReader(`syntax = "proto3";
+package blerpc;
+message EchoRequest { string message = 1; }
+message EchoResponse { string message = 1; }
+service Echo {
+  rpc Echo(EchoRequest) returns (EchoResponse) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+  rpc Send(EchoRequest) returns (EchoResponse);
+}
+`))
+	if err != nil {
+		t.Fatalf("protomodel.ParseReader: %v", err)
+	}
+	msgByName := make(map[string]Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	cmds := protomodel.DiscoverCommandsFromServices(pf.Services, msgByName)
+	if len(cmds) != 2 || !cmds[0].Idempotent || cmds[1].Idempotent {
+		t.Errorf("unexpected commands: %+v", cmds)
+	}
+}
@@ -0,0 +1,29 @@
+package gen
+
+// RoleNames lists the roles from least to most privileged; the index is the
+// role's value in C.
+var RoleNames = []string{"user", "installer", "factory"}
+
+// RoleLevel returns the C value of a role; "" is user.
+func RoleLevel(role string) (int, bool) {
+	if role == "" {
+		return 0, true
+	}
+	for i, name := range RoleNames {
+		if name == role {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// PrivilegedCommands returns the commands requiring more than the user role.
+func PrivilegedCommands(commands []Command) []Command {
+	var out []Command
+	for _, cmd := range commands {
+		if level, _ := RoleLevel(cmd.Role); level > 0 {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+// Package rust generates the Rust peripheral handlers.
+package rust
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// rustMessageName returns the prost path of msg below the messages module:
+// prost nests a message declared inside another in a snake_case module
+// named after the parent.
+func rustMessageName(msg string) string {
+	parts := strings.Split(msg, ".")
+	for i := range parts[:len(parts)-1] {
+		parts[i] = protomodel.CamelToSnake(parts[i])
+	}
+	return "pb::" + strings.Join(parts, "::")
+}
+
+// rustFnSignature renders a function signature up to its opening brace,
+// moving the parameters to their own lines when it would pass rustfmt's
+// 100-column limit.
+func rustFnSignature(indent, head string, params []string, ret string) string {
+	line := fmt.Sprintf("%s%s(%s) -> %s {\n", indent, head, strings.Join(params, ", "), ret)
+	if len(line) <= 101 {
+		return line
+	}
+	var b strings.Builder
+	b.WriteString(indent + head + "(\n")
+	for _, p := range params {
+		b.WriteString(indent + "    " + p + ",\n")
+	}
+	b.WriteString(indent + ") -> " + ret + " {\n")
+	return b.String()
+}
+
+// GenerateHandlers returns generated_handlers.rs for no_std Rust
+// peripherals. It mirrors the nanopb C handlers: a Handlers trait whose
+// default methods answer with an empty response (like the weak C stubs),
+// one decode/encode shim per command, a static handler table and a lookup
+// that also accepts one-byte command IDs. pbPath is the module holding the
+// prost messages, built with default-features = false.
+func GenerateHandlers(commands []gen.Command, pkg, pbPath string) string {
+	if pbPath == "" {
+		pbPath = "crate::" + strings.ReplaceAll(pkg, ".", "::")
+	}
+	var b strings.Builder
+
+	b.WriteString("// Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteString("//! Command handlers for no_std peripherals. Requests and responses are\n")
+	b.WriteString("//! the prost messages of the schema; only `alloc` is needed.\n")
+	b.WriteByte('\n')
+	b.WriteString("use prost::Message;\n")
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("use %s as pb;\n", pbPath))
+	b.WriteByte('\n')
+
+	b.WriteString("/// Why a command produced no response. The dispatcher answers with an\n")
+	b.WriteString("/// error container instead.\n")
+	b.WriteString("#[derive(Debug, Clone, Copy, PartialEq, Eq)]\n")
+	b.WriteString("pub enum HandlerError {\n")
+	b.WriteString("    /// The request did not decode.\n")
+	b.WriteString("    Decode,\n")
+	b.WriteString("    /// The response did not fit in the response buffer.\n")
+	b.WriteString("    Encode,\n")
+	b.WriteString("    /// The handler rejected the request.\n")
+	b.WriteString("    Failed,\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/// One method per command. The defaults answer with an empty response;\n")
+	b.WriteString("/// override the commands the peripheral implements.\n")
+	b.WriteString("pub trait Handlers {\n")
+	for i, cmd := range commands {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		req, resp := rustMessageName(cmd.RequestMsg), rustMessageName(cmd.ResponseMsg)
+		b.WriteString(rustFnSignature("    ", "fn "+cmd.Snake, []string{"&mut self", "req: " + req}, "Result<"+resp+", HandlerError>"))
+		b.WriteString("        let _ = req;\n")
+		b.WriteString(fmt.Sprintf("        Ok(%s::default())\n", resp))
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("/// Decodes a request, runs its handler and encodes the response into the\n")
+	b.WriteString("/// buffer, returning the response length.\n")
+	b.WriteString("pub type CommandHandler<H> = fn(&mut H, &[u8], &mut [u8]) -> Result<usize, HandlerError>;\n")
+	b.WriteByte('\n')
+	b.WriteString("pub struct HandlerEntry<H> {\n")
+	b.WriteString("    pub name: &'static [u8],\n")
+	if gen.HasCommandIDs(commands) {
+		b.WriteString("    /// Command ID from the lock file.\n")
+		b.WriteString("    pub id: u8,\n")
+	}
+	b.WriteString("    pub handler: CommandHandler<H>,\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("fn encode<M: Message>(resp: &M, mut out: &mut [u8]) -> Result<usize, HandlerError> {\n")
+	b.WriteString("    let len = resp.encoded_len();\n")
+	b.WriteString("    resp.encode(&mut out).map_err(|_| HandlerError::Encode)?;\n")
+	b.WriteString("    Ok(len)\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	for _, cmd := range commands {
+		b.WriteString(rustFnSignature("", "fn handle_"+cmd.Snake+"<H: Handlers>", []string{"h: &mut H", "req: &[u8]", "out: &mut [u8]"}, "Result<usize, HandlerError>"))
+		b.WriteString(fmt.Sprintf("    let req = %s::decode(req).map_err(|_| HandlerError::Decode)?;\n", rustMessageName(cmd.RequestMsg)))
+		b.WriteString(fmt.Sprintf("    encode(&h.%s(req)?, out)\n", cmd.Snake))
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+
+	b.WriteString("/// The handler table, in schema order.\n")
+	b.WriteString("pub trait HandlerTable: Handlers + Sized + 'static {\n")
+	b.WriteString("    const TABLE: &'static [HandlerEntry<Self>];\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("impl<H: Handlers + 'static> HandlerTable for H {\n")
+	b.WriteString("    const TABLE: &'static [HandlerEntry<H>] = &[\n")
+	for _, cmd := range commands {
+		b.WriteString("        HandlerEntry {\n")
+		b.WriteString(fmt.Sprintf("            name: b\"%s\",\n", cmd.Wire()))
+		if gen.HasCommandIDs(commands) {
+			b.WriteString(fmt.Sprintf("            id: %d,\n", cmd.ID))
+		}
+		b.WriteString(fmt.Sprintf("            handler: handle_%s::<H>,\n", cmd.Snake))
+		b.WriteString("        },\n")
+	}
+	b.WriteString("    ];\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+
+	b.WriteString("pub fn handlers_lookup<H: HandlerTable>(name: &[u8]) -> Option<CommandHandler<H>> {\n")
+	if gen.HasCommandIDs(commands) {
+		b.WriteString("    // A one-byte name carries the command ID.\n")
+		b.WriteString("    H::TABLE\n")
+		b.WriteString("        .iter()\n")
+		b.WriteString("        .find(|e| e.name == name || (name.len() == 1 && name[0] == e.id))\n")
+		b.WriteString("        .map(|e| e.handler)\n")
+	} else {
+		b.WriteString("    H::TABLE.iter().find(|e| e.name == name).map(|e| e.handler)\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
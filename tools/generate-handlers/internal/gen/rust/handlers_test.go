@@ -0,0 +1,67 @@
+package rust
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestRustMessageName(t *testing.T) {
+	cases := map[string]string{
+		"EchoRequest":            "pb::EchoRequest",
+		"SensorReading.Sample":   "pb::sensor_reading::Sample",
+		"DeviceInfo.Radio.Level": "pb::device_info::radio::Level",
+	}
+	for in, want := range cases {
+		if got := rustMessageName(in); got != want {
+			t.Errorf("rustMessageName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGenerateRustHandlers(t *testing.T) {
+	wired := gentest.EchoCommand()
+	wired.WireName = "e"
+	out := GenerateHandlers([]gen.Command{wired, gentest.StreamP2CCommand()}, "blerpc", "")
+
+	mustContain := []string{
+		"use prost::Message;",
+		"use crate::blerpc as pb;",
+		"pub trait Handlers {\n    fn echo(&mut self, req: pb::EchoRequest) -> Result<pb::EchoResponse, HandlerError> {\n        let _ = req;\n        Ok(pb::EchoResponse::default())\n    }",
+		// Too long for one line: rustfmt puts the parameters on their own lines.
+		"    fn counter_stream(\n        &mut self,\n        req: pb::CounterStreamRequest,\n    ) -> Result<pb::CounterStreamResponse, HandlerError> {",
+		"fn handle_echo<H: Handlers>(h: &mut H, req: &[u8], out: &mut [u8]) -> Result<usize, HandlerError> {\n    let req = pb::EchoRequest::decode(req).map_err(|_| HandlerError::Decode)?;\n    encode(&h.echo(req)?, out)\n}",
+		"        HandlerEntry {\n            name: b\"e\",\n            handler: handle_echo::<H>,\n        },",
+		"    H::TABLE.iter().find(|e| e.name == name).map(|e| e.handler)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "pub id: u8") {
+		t.Error("command IDs emitted without IDs")
+	}
+
+	if out := GenerateHandlers([]gen.Command{gentest.EchoCommand()}, "acme.sensor", "sensor_pb"); !strings.Contains(out, "use sensor_pb as pb;") {
+		t.Errorf("-rs-pb-path not used\nGot:\n%s", out)
+	}
+}
+
+func TestGenerateRustHandlersCommandIDs(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.ID = 1
+	out := GenerateHandlers([]gen.Command{echo}, "blerpc", "")
+
+	for _, s := range []string{
+		"    pub id: u8,",
+		"            name: b\"echo\",\n            id: 1,\n",
+		".find(|e| e.name == name || (name.len() == 1 && name[0] == e.id))",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package gen
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// The schema model and the proto parser live in internal/protomodel, shared
+// with the pkg/blerpcgen API.
+type (
+	ProtoFile    = protomodel.ProtoFile
+	Message      = protomodel.Message
+	Field        = protomodel.Field
+	OneofGroup   = protomodel.OneofGroup
+	Enum         = protomodel.Enum
+	EnumValue    = protomodel.EnumValue
+	Service      = protomodel.Service
+	ServiceRPC   = protomodel.ServiceRPC
+	Command      = protomodel.Command
+	TypeOverride = protomodel.TypeOverride
+)
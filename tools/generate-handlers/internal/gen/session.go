@@ -0,0 +1,59 @@
+package gen
+
+// The session built-in keeps commands such as an unlock or a factory reset
+// away from centrals that do not hold a key shared with the peripheral.
+// start_session returns a random challenge; authenticate_session takes an
+// HMAC-SHA256 of it under the key, checked by the firmware's
+// <pkg>_session_verify() hook, and returns a session token. Every request of
+// a session-protected command then leads with the token: a guard in front of
+// the handler checks and strips it, and answers a request without the token
+// of the open session with an UNAUTHENTICATED error. The clients run the
+// handshake before the first protected call and again when the peripheral
+// has lost the session, e.g. after a reboot. Python, Kotlin, Swift, Go, Dart,
+// TypeScript and C# compute the proof from the session key they are given;
+// the C++ client asks its subclass for it, like for the transport. The C
+// client has no handshake, so protected commands must be excluded from it.
+//
+// Commands are protected with
+//
+//	option (blerpc.session_protected) = true;
+//
+// or, for schemas discovered by message naming, an entry under
+// session_protected in blerpc.yaml.
+
+const (
+	SessionChallengeSize = 16
+	SessionTokenSize     = 8
+	// SessionProofSize is the length of an HMAC-SHA256.
+	SessionProofSize = 32
+)
+
+// SessionCommands returns the start_session and authenticate_session
+// commands, reporting whether both are present.
+func SessionCommands(commands []Command) (start, auth Command, ok bool) {
+	found := 0
+	for _, cmd := range commands {
+		if cmd.Builtin != "session" {
+			continue
+		}
+		switch cmd.RequestMsg {
+		case "StartSessionRequest":
+			start = cmd
+		case "AuthenticateSessionRequest":
+			auth = cmd
+		default:
+			continue
+		}
+		found++
+	}
+	return start, auth, found == 2
+}
+
+func HasSessionProtected(commands []Command) bool {
+	for _, cmd := range commands {
+		if cmd.SessionProtected {
+			return true
+		}
+	}
+	return false
+}
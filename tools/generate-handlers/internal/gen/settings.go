@@ -0,0 +1,28 @@
+package gen
+
+import (
+	"strings"
+)
+
+// SettingsCommands returns the get_setting and set_setting commands present
+// in commands.
+func SettingsCommands(commands []Command) (get, set *Command) {
+	for i, cmd := range commands {
+		if cmd.Builtin != "settings" {
+			continue
+		}
+		if cmd.RequestMsg == "GetSettingRequest" {
+			get = &commands[i]
+		} else {
+			set = &commands[i]
+		}
+	}
+	return get, set
+}
+
+// SettingLabel returns the accessor infix of a settings field in camel case,
+// e.g. "LedColor" for led_color.
+func SettingLabel(f Field) string {
+	name := SwiftPropertyName(f.Name)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,25 @@
+package gen
+
+import (
+	"fmt"
+)
+
+// RemovedResponseFields returns the response messages with removed fields,
+// in command order, and their fields.
+func RemovedResponseFields(commands []Command) ([]string, map[string][]Field) {
+	var order []string
+	byMsg := make(map[string][]Field)
+	for _, cmd := range commands {
+		if _, done := byMsg[cmd.ResponseMsg]; done || len(cmd.RemovedFields) == 0 {
+			continue
+		}
+		order = append(order, cmd.ResponseMsg)
+		byMsg[cmd.ResponseMsg] = cmd.RemovedFields
+	}
+	return order, byMsg
+}
+
+// RemovedFieldNote is the deprecation message of a removed field.
+func RemovedFieldNote(f Field) string {
+	return fmt.Sprintf("Field %d was removed from the schema; reads the default", f.Number)
+}
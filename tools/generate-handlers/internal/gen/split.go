@@ -0,0 +1,44 @@
+package gen
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CommandGroup is a set of commands written to one split file.
+type CommandGroup struct {
+	Name     string // CamelCase; the file name uses the snake_case form
+	Commands []Command
+}
+
+// GroupCommands partitions commands for the split mode, keeping the command
+// order. per-group groups by proto service; commands discovered by naming
+// convention have no service and share one group named after the package.
+func GroupCommands(commands []Command, mode, pkg string) []CommandGroup {
+	var groups []CommandGroup
+	index := make(map[string]int)
+	for _, cmd := range commands {
+		name := cmd.Camel
+		if mode == "per-group" {
+			name = cmd.Service
+			if name == "" {
+				name = strings.ToUpper(pkg[:1]) + pkg[1:]
+			}
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, CommandGroup{Name: name})
+		}
+		groups[i].Commands = append(groups[i].Commands, cmd)
+	}
+	return groups
+}
+
+// SplitPath derives the path of a group file from the index file path, e.g.
+// generated_handlers.c -> generated_handlers_echo.c.
+func SplitPath(indexPath, sep, group string) string {
+	ext := filepath.Ext(indexPath)
+	return strings.TrimSuffix(indexPath, ext) + sep + group + ext
+}
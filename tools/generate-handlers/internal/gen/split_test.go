@@ -0,0 +1,28 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGroupCommands(t *testing.T) {
+	echo, stream, upload := gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()
+	echo.Service, stream.Service = "EchoService", "CounterService"
+	cmds := []Command{echo, stream, upload}
+
+	perCommand := GroupCommands(cmds, "per-command", "blerpc")
+	if len(perCommand) != 3 || perCommand[0].Name != "Echo" || perCommand[2].Name != "CounterUpload" {
+		t.Errorf("per-command groups: %+v", perCommand)
+	}
+
+	perGroup := GroupCommands(cmds, "per-group", "blerpc")
+	var names []string
+	for _, g := range perGroup {
+		names = append(names, g.Name)
+	}
+	if strings.Join(names, ",") != "EchoService,CounterService,Blerpc" {
+		t.Errorf("per-group names = %v", names)
+	}
+}
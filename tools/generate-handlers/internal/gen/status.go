@@ -0,0 +1,10 @@
+package gen
+
+func HasStatusChecks(commands []Command) bool {
+	for _, cmd := range commands {
+		if cmd.StatusField != "" {
+			return true
+		}
+	}
+	return false
+}
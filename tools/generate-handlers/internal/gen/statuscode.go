@@ -0,0 +1,47 @@
+package gen
+
+import (
+	"slices"
+	"strings"
+)
+
+// Handlers report failures with a status code from one enum shared by the
+// firmware and every client. An error response is an envelope holding only
+// the status, as a varint in field StatusField, which no schema can use for
+// anything else; the clients check each response for it before decoding and
+// raise the typed RemoteError of the code. Codes from 128 up are left to
+// applications and raise a plain RemoteError.
+
+// StatusField is the reserved field number of the error envelope, the
+// largest protobuf allows.
+const StatusField = 536870911
+
+// StatusTag is the varint-encoded tag of StatusField.
+var StatusTag = []byte{0xf8, 0xff, 0xff, 0xff, 0x0f}
+
+// StatusCodes are the standard status codes, indexed by value.
+var StatusCodes = []struct{ Name, Doc string }{
+	{"OK", "Success; never sent in an error response."},
+	{"INVALID_ARGUMENT", "The request is malformed or a field is out of bounds."},
+	{"NOT_FOUND", "The requested entity does not exist."},
+	{"ALREADY_EXISTS", "The entity to create exists already."},
+	{"PERMISSION_DENIED", "The caller may not run the command."},
+	{"RESOURCE_EXHAUSTED", "Memory, storage or another resource ran out."},
+	{"FAILED_PRECONDITION", "The device is not in a state to run the command."},
+	{"OUT_OF_RANGE", "An offset or value lies past the valid range."},
+	{"UNIMPLEMENTED", "The command is not supported by this firmware."},
+	{"INTERNAL", "The firmware hit an unexpected error."},
+	{"UNAVAILABLE", "The device cannot run the command now; retry later."},
+	{"UNAUTHENTICATED", "The command needs an authenticated session."},
+}
+
+// StatusCodeValue returns the value of the standard status code name.
+func StatusCodeValue(name string) int {
+	return slices.IndexFunc(StatusCodes, func(sc struct{ Name, Doc string }) bool { return sc.Name == name })
+}
+
+// StatusErrorName returns the typed error name of a status code, e.g.
+// NotFound for NOT_FOUND.
+func StatusErrorName(code string) string {
+	return UpperCamel(strings.ToLower(code))
+}
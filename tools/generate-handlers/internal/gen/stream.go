@@ -0,0 +1,12 @@
+package gen
+
+// HasClientStreams reports whether any command is a central-to-peripheral
+// stream.
+func HasClientStreams(commands []Command, streaming map[string]string) bool {
+	for _, cmd := range commands {
+		if streaming[cmd.Snake] == "c2p" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package gen
+
+// BoundedString reports whether f is a string field whose UTF-8 length
+// clients check. Fields with a type mapping are left to the mapping.
+func BoundedString(f Field) bool {
+	return f.MaxBytes > 0 && len(f.TypeOverrides) == 0
+}
+
+// HasBoundedStringRequests reports whether any command takes a bounded
+// string field, which makes the clients declare StringTooLongError.
+func HasBoundedStringRequests(commands []Command) bool {
+	for _, cmd := range commands {
+		for _, f := range cmd.RequestFields {
+			if BoundedString(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
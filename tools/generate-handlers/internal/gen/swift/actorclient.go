@@ -0,0 +1,147 @@
+package swift
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftActorClient emits PacketLink and ActorClient, which implements
+// the container layer of GeneratedClientProtocol over a PacketLink. Its
+// state is actor-isolated, so it is Sendable without a lock. It performs no
+// CAPABILITIES exchange and no encryption; BlerpcClient of the example app
+// shows both.
+func writeSwiftActorClient(gctx *gen.Context, b *strings.Builder, commands []gen.Command, streaming map[string]string) {
+	_, _, sessions := gen.SessionCommands(commands)
+	serverStreams := gen.HasServerStreams(commands, streaming)
+
+	b.WriteByte('\n')
+	b.WriteString("/// The link an ActorClient sends containers over, e.g. the write and notify\n")
+	b.WriteString("/// characteristics of a BLE connection.\n")
+	b.WriteString("protocol PacketLink: Sendable {\n")
+	b.WriteString("    /// Payload bytes of one write, which containers are split to.\n")
+	b.WriteString("    var mtu: Int { get }\n")
+	b.WriteString("    func write(_ data: Data) async throws\n")
+	b.WriteString("    /// The next notification; throws when none arrives in `timeoutMs`.\n")
+	b.WriteString("    func read(timeoutMs: Int) async throws -> Data\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Sends the generated commands over a PacketLink. The actor isolates the\n")
+	b.WriteString("/// splitter and assembler; callSerializer keeps the RPCs from interleaving\n")
+	b.WriteString("/// across the suspension points of the link. Encryption and the\n")
+	b.WriteString("/// CAPABILITIES exchange are left to the link's owner.\n")
+	b.WriteString("actor ActorClient: " + gctx.SwiftType("GeneratedClientProtocol") + " {\n")
+	b.WriteString("    nonisolated let callSerializer = CallSerializer()\n")
+	if sessions {
+		b.WriteString("    nonisolated let session: BlerpcSession\n")
+	}
+	b.WriteString("    private let link: any PacketLink\n")
+	b.WriteString("    private let splitter: ContainerSplitter\n")
+	b.WriteString("    private let assembler = ContainerAssembler()\n")
+	b.WriteString("    private let timeoutMs: Int\n")
+	b.WriteByte('\n')
+	if sessions {
+		b.WriteString("    init(link: any PacketLink, sessionKey: Data, timeoutMs: Int = 2000) {\n")
+		b.WriteString("        self.session = BlerpcSession(key: sessionKey)\n")
+	} else {
+		b.WriteString("    init(link: any PacketLink, timeoutMs: Int = 2000) {\n")
+	}
+	b.WriteString("        self.link = link\n")
+	b.WriteString("        self.splitter = ContainerSplitter(mtu: link.mtu)\n")
+	b.WriteString("        self.timeoutMs = timeoutMs\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func call(cmdName: String, requestData: Data) async throws -> Data {\n")
+	b.WriteString("        try await send(cmdName: cmdName, data: requestData)\n")
+	b.WriteString("        return try await receive(cmdName: cmdName)\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {\n")
+	b.WriteString("        try await send(cmdName: cmdName, data: requestData)\n")
+	b.WriteString("        var responses: [Data] = []\n")
+	b.WriteString("        while let data = try await receiveStreamed() {\n")
+	b.WriteString("            responses.append(data)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return responses\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {\n")
+	b.WriteString("        for message in messages {\n")
+	b.WriteString("            try await send(cmdName: cmdName, data: message)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        try await link.write(makeStreamEndC2P(transactionId: splitter.nextTransactionId()).serialize())\n")
+	b.WriteString("        return try await receive(cmdName: finalCmdName)\n")
+	b.WriteString("    }\n")
+	if serverStreams {
+		b.WriteByte('\n')
+		b.WriteString("    /// Stops the P→C stream in progress and drains it up to its end.\n")
+		b.WriteString("    func streamCancel() async {\n")
+		b.WriteString("        do {\n")
+		b.WriteString("            try await link.write(cancelContainer(transactionId: splitter.nextTransactionId()))\n")
+		b.WriteString("            while try await receiveStreamed() != nil {}\n")
+		b.WriteString("        } catch {\n")
+		b.WriteString("            // The stream ended some other way; the next call starts afresh.\n")
+		b.WriteString("        }\n")
+		b.WriteString("        assembler.reset()\n")
+		b.WriteString("    }\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("    private func send(cmdName: String, data: Data) async throws {\n")
+	b.WriteString("        let payload = try CommandPacket(cmdType: .request, cmdName: cmdName, data: data).serialize()\n")
+	b.WriteString("        for container in try splitter.split(payload) {\n")
+	b.WriteString("            try await link.write(container.serialize())\n")
+	b.WriteString("        }\n")
+	b.WriteString("        assembler.reset()\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// The response to `cmdName`.\n")
+	b.WriteString("    private func receive(cmdName: String) async throws -> Data {\n")
+	b.WriteString("        while true {\n")
+	b.WriteString("            let container = try Container.deserialize(try await link.read(timeoutMs: timeoutMs))\n")
+	b.WriteString("            if container.containerType == .control {\n")
+	b.WriteString("                try checkControl(container)\n")
+	b.WriteString("                continue\n")
+	b.WriteString("            }\n")
+	b.WriteString("            if let packet = try assembled(container) {\n")
+	b.WriteString("                guard packet.cmdName == cmdName else {\n")
+	b.WriteString("                    throw TransportError(message: \"expected a response to \\(cmdName), got \\(packet.cmdName)\")\n")
+	b.WriteString("                }\n")
+	b.WriteString("                return packet.data\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// The next response of a P→C stream, or nil at its end.\n")
+	b.WriteString("    private func receiveStreamed() async throws -> Data? {\n")
+	b.WriteString("        while true {\n")
+	b.WriteString("            let container = try Container.deserialize(try await link.read(timeoutMs: timeoutMs))\n")
+	b.WriteString("            if container.containerType == .control {\n")
+	b.WriteString("                if container.controlCmd == .streamEndP2C {\n")
+	b.WriteString("                    return nil\n")
+	b.WriteString("                }\n")
+	b.WriteString("                try checkControl(container)\n")
+	b.WriteString("                continue\n")
+	b.WriteString("            }\n")
+	b.WriteString("            if let packet = try assembled(container) {\n")
+	b.WriteString("                return packet.data\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Feeds `container` to the assembler; the response once complete.\n")
+	b.WriteString("    private func assembled(_ container: Container) throws -> CommandPacket? {\n")
+	b.WriteString("        guard let payload = assembler.feed(container) else { return nil }\n")
+	b.WriteString("        let packet = try CommandPacket.deserialize(payload)\n")
+	b.WriteString("        guard packet.cmdType == .response else {\n")
+	b.WriteString("            throw TransportError(message: \"unexpected packet type \\(packet.cmdType.rawValue)\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return packet\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private func checkControl(_ container: Container) throws {\n")
+	b.WriteString("        if container.controlCmd == .error, let code = container.payload.first {\n")
+	b.WriteString("            throw TransportError(message: \"peripheral error \\(code)\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+}
@@ -0,0 +1,38 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateSwiftActorClient(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.SwiftActorClient = true
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}, streaming, "blerpc")
+
+	for _, want := range []string{
+		"import BlerpcProtocol\n",
+		"protocol PacketLink: Sendable {",
+		"actor ActorClient: GeneratedClientProtocol {",
+		"    nonisolated let callSerializer = CallSerializer()\n",
+		"    init(link: any PacketLink, timeoutMs: Int = 2000) {\n",
+		"        try await link.write(makeStreamEndC2P(transactionId: splitter.nextTransactionId()).serialize())\n",
+		"    func streamCancel() async {\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if i, j := strings.Index(out, "extension GeneratedClientProtocol {"), strings.Index(out, "actor ActorClient"); i < 0 || j < i {
+		t.Error("ActorClient does not follow the protocol extension")
+	}
+
+	gctx.SwiftActorClient = false
+	if out := GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"); strings.Contains(out, "ActorClient") || strings.Contains(out, "import BlerpcProtocol") {
+		t.Error("ActorClient generated without swift_actor_client")
+	}
+}
@@ -0,0 +1,52 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateAdv(gctx *gen.Context, advs []gen.Advertisement, pkg string, companyID uint) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteString("import SwiftProtobuf\n")
+	b.WriteByte('\n')
+	b.WriteString("/// An advertisement broadcast as manufacturer specific data.\n")
+	b.WriteString("enum GeneratedAdvertisement {\n")
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("    case %s(%s%s)\n", gen.ToLowerCamel(adv.Message.Name), prefix, adv.Message.Name))
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /// Bluetooth SIG company identifier of the manufacturer data.\n")
+	b.WriteString(fmt.Sprintf("    static let companyID: UInt16 = 0x%04X\n", companyID))
+	b.WriteByte('\n')
+	b.WriteString("    /// Decodes the CBAdvertisementDataManufacturerDataKey value, which starts\n")
+	b.WriteString("    /// with the company identifier. Fails when the data holds no valid\n")
+	b.WriteString("    /// advertisement of this schema.\n")
+	b.WriteString("    init?(manufacturerData data: Data) {\n")
+	b.WriteString("        let bytes = [UInt8](data)\n")
+	b.WriteString("        guard bytes.count >= 3, UInt16(bytes[0]) | UInt16(bytes[1]) << 8 == Self.companyID else {\n")
+	b.WriteString("            return nil\n")
+	b.WriteString("        }\n")
+	b.WriteString("        let payload = Data(bytes[3...])\n")
+	b.WriteString("        do {\n")
+	b.WriteString("            switch bytes[2] {\n")
+	for _, adv := range advs {
+		b.WriteString(fmt.Sprintf("            case %d:\n", adv.Type))
+		b.WriteString(fmt.Sprintf("                self = .%s(try %s%s(serializedBytes: payload))\n",
+			gen.ToLowerCamel(adv.Message.Name), prefix, adv.Message.Name))
+	}
+	b.WriteString("            default:\n")
+	b.WriteString("                return nil\n")
+	b.WriteString("            }\n")
+	b.WriteString("        } catch {\n")
+	b.WriteString("            return nil\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package swift
+
+import (
+	"strings"
+)
+
+// GenerateAuthorization returns BleAuthorization.swift, which follows the
+// CoreBluetooth authorization and power state so the client can fail fast
+// with a typed error instead of waiting for a central manager that never
+// powers on.
+func GenerateAuthorization(pkg string) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import CoreBluetooth\n")
+	b.WriteString("import Foundation\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Why Bluetooth cannot be used.\n")
+	b.WriteString("enum BluetoothUnavailableError: Error, Equatable {\n")
+	b.WriteString("    /// The user denied Bluetooth access; it can only be granted again in Settings.\n")
+	b.WriteString("    case unauthorized\n")
+	b.WriteString("    /// Bluetooth access is restricted, e.g. by parental controls or a device profile.\n")
+	b.WriteString("    case restricted\n")
+	b.WriteString("    case poweredOff\n")
+	b.WriteString("    /// The device has no Bluetooth LE support.\n")
+	b.WriteString("    case unsupported\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Bluetooth availability derived from CBManager authorization and state.\n")
+	b.WriteString("enum BluetoothAvailability: Equatable {\n")
+	b.WriteString("    /// Not known yet, e.g. while the permission prompt is shown or Bluetooth resets.\n")
+	b.WriteString("    case unknown\n")
+	b.WriteString("    case available\n")
+	b.WriteString("    case unavailable(BluetoothUnavailableError)\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(state: CBManagerState, authorization: CBManagerAuthorization = CBManager.authorization) {\n")
+	b.WriteString("        switch authorization {\n")
+	b.WriteString("        case .denied:\n")
+	b.WriteString("            self = .unavailable(.unauthorized)\n")
+	b.WriteString("            return\n")
+	b.WriteString("        case .restricted:\n")
+	b.WriteString("            self = .unavailable(.restricted)\n")
+	b.WriteString("            return\n")
+	b.WriteString("        default:\n")
+	b.WriteString("            break\n")
+	b.WriteString("        }\n")
+	b.WriteString("        switch state {\n")
+	b.WriteString("        case .poweredOn:\n")
+	b.WriteString("            self = .available\n")
+	b.WriteString("        case .poweredOff:\n")
+	b.WriteString("            self = .unavailable(.poweredOff)\n")
+	b.WriteString("        case .unauthorized:\n")
+	b.WriteString("            self = .unavailable(.unauthorized)\n")
+	b.WriteString("        case .unsupported:\n")
+	b.WriteString("            self = .unavailable(.unsupported)\n")
+	b.WriteString("        default:\n")
+	b.WriteString("            self = .unknown\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Follows Bluetooth availability with a central manager of its own, leaving the\n")
+	b.WriteString("/// transport's manager and delegate alone. Creating the monitor shows the\n")
+	b.WriteString("/// permission prompt if the user has not answered it yet.\n")
+	b.WriteString("final class BleAuthorization: NSObject, CBCentralManagerDelegate {\n")
+	b.WriteString("    static let shared = BleAuthorization()\n")
+	b.WriteByte('\n')
+	b.WriteString("    private let queue = DispatchQueue(label: \"com." + pkg + ".ble.authorization\")\n")
+	b.WriteString("    private var manager: CBCentralManager?\n")
+	b.WriteString("    private var state: BluetoothAvailability = .unknown\n")
+	b.WriteString("    private var continuations: [UUID: AsyncStream<BluetoothAvailability>.Continuation] = [:]\n")
+	b.WriteByte('\n')
+	b.WriteString("    override init() {\n")
+	b.WriteString("        super.init()\n")
+	b.WriteString("        manager = CBCentralManager(\n")
+	b.WriteString("            delegate: self,\n")
+	b.WriteString("            queue: queue,\n")
+	b.WriteString("            options: [CBCentralManagerOptionShowPowerAlertKey: false]\n")
+	b.WriteString("        )\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// The latest availability.\n")
+	b.WriteString("    var current: BluetoothAvailability {\n")
+	b.WriteString("        queue.sync { state }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Yields the current availability, then every change.\n")
+	b.WriteString("    var updates: AsyncStream<BluetoothAvailability> {\n")
+	b.WriteString("        AsyncStream { continuation in\n")
+	b.WriteString("            let id = UUID()\n")
+	b.WriteString("            queue.sync {\n")
+	b.WriteString("                continuations[id] = continuation\n")
+	b.WriteString("                continuation.yield(state)\n")
+	b.WriteString("            }\n")
+	b.WriteString("            continuation.onTermination = { [weak self] _ in\n")
+	b.WriteString("                self?.queue.async { self?.continuations[id] = nil }\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Throws if Bluetooth is known to be unavailable.\n")
+	b.WriteString("    func check() throws {\n")
+	b.WriteString("        if case .unavailable(let error) = current {\n")
+	b.WriteString("            throw error\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Waits while the availability is unknown, then returns once Bluetooth is\n")
+	b.WriteString("    /// available or throws the reason it is not.\n")
+	b.WriteString("    func waitUntilAvailable() async throws {\n")
+	b.WriteString("        for await availability in updates {\n")
+	b.WriteString("            switch availability {\n")
+	b.WriteString("            case .available:\n")
+	b.WriteString("                return\n")
+	b.WriteString("            case .unavailable(let error):\n")
+	b.WriteString("                throw error\n")
+	b.WriteString("            case .unknown:\n")
+	b.WriteString("                continue\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("        throw CancellationError()\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func centralManagerDidUpdateState(_ central: CBCentralManager) {\n")
+	b.WriteString("        state = BluetoothAvailability(state: central.state)\n")
+	b.WriteString("        for continuation in continuations.values {\n")
+	b.WriteString("            continuation.yield(state)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
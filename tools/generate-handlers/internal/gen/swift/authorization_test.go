@@ -0,0 +1,21 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateSwiftAuthorization(t *testing.T) {
+	out := GenerateAuthorization("blerpc")
+	for _, s := range []string{
+		"enum BluetoothUnavailableError: Error, Equatable {\n",
+		"        case .denied:\n            self = .unavailable(.unauthorized)\n",
+		"    private let queue = DispatchQueue(label: \"com.blerpc.ble.authorization\")\n",
+		"    var updates: AsyncStream<BluetoothAvailability> {\n",
+		"    func waitUntilAvailable() async throws {\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
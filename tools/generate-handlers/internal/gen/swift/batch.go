@@ -0,0 +1,153 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateBatch returns GeneratedBatch.swift, next to the generated
+// client.
+func GenerateBatch(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	batchable := gen.BatchCommands(commands, streaming)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteString("import SwiftProtobuf\n")
+	for _, imp := range gen.OverrideImports(batchable, "swift") {
+		b.WriteString(imp + "\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/// Command name of the batch envelope.\n")
+	b.WriteString(fmt.Sprintf("let batchCommand = \"%s\"\n", gen.BatchCommandName))
+	b.WriteByte('\n')
+	b.WriteString("/// The batch response holds no complete response for a call.\n")
+	b.WriteString("struct BatchError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let message: String\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The response of a call added to a Batch, once the batch is sent.\n")
+	b.WriteString("final class BatchCall<T> {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    fileprivate var result: Result<T, Error>?\n")
+	b.WriteByte('\n')
+	b.WriteString("    fileprivate init(command: String) {\n")
+	b.WriteString("        self.command = command\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Returns the response, or throws the error of the call.\n")
+	b.WriteString("    func get() throws -> T {\n")
+	b.WriteString("        guard let result else { throw BatchError(message: \"\\(command): the batch was not sent\") }\n")
+	b.WriteString("        return try result.get()\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Unary calls sent to the peripheral in one request. The peripheral runs\n")
+	b.WriteString("/// them in order and answers them in one response, so a sequence of small\n")
+	b.WriteString("/// calls, such as provisioning, costs one round trip instead of one per call:\n")
+	b.WriteString("///\n")
+	b.WriteString("///     let batch = Batch(client: client)\n")
+	b.WriteString("///     let echo = try batch.echo(message: \"hi\")\n")
+	b.WriteString("///     try await batch.send()\n")
+	b.WriteString("///     print(try echo.get().message)\n")
+	b.WriteString("///\n")
+	b.WriteString("/// Streaming commands, and commands with a replay counter or a session\n")
+	b.WriteString("/// token, cannot be batched.\n")
+	b.WriteString("final class Batch<Client: " + gctx.SwiftType("GeneratedClientProtocol") + "> {\n")
+	b.WriteString("    private let client: Client\n")
+	b.WriteString("    private var payload = Data()\n")
+	b.WriteString("    /// Records the response data of each call, or its error, and returns\n")
+	b.WriteString("    /// the error of the call.\n")
+	b.WriteString("    private var pending: [(command: String, complete: (Result<Data, Error>) -> Error?)] = []\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(client: Client) {\n")
+	b.WriteString("        self.client = client\n")
+	b.WriteString("    }\n")
+
+	for _, cmd := range batchable {
+		reqCls := prefix + cmd.RequestMsg
+		respCls := prefix + cmd.ResponseMsg
+		params := strings.Join(swiftParams(cmd.RequestFields, cmd.RequestMsg, prefix), ", ")
+		b.WriteByte('\n')
+		b.WriteString(Deprecation(cmd, true))
+		b.WriteString(fmt.Sprintf("    func %s(%s) throws -> BatchCall<%s> {\n", gen.ToLowerCamel(cmd.Camel), params, respCls))
+		writeSwiftSizeChecks(&b, cmd, "        ")
+		b.WriteString(fmt.Sprintf("        var req = %s()\n", reqCls))
+		writeSwiftSetters(&b, cmd.RequestFields)
+		add := fmt.Sprintf("        return add(\"%s\", cmdName: %s, requestData: try req.serializedData())", cmd.Snake, gen.CallName(cmd, "swift"))
+		if cmd.StatusField == "" {
+			b.WriteString(fmt.Sprintf("%s { try %s(serializedBytes: $0) }\n", add, respCls))
+		} else {
+			b.WriteString(add + " { data in\n")
+			b.WriteString(fmt.Sprintf("            let resp = try %s(serializedBytes: data)\n", respCls))
+			b.WriteString(swiftStatusCheck(cmd, "            "))
+			b.WriteString("            return resp\n")
+			b.WriteString("        }\n")
+		}
+		b.WriteString("    }\n")
+	}
+
+	b.WriteByte('\n')
+	b.WriteString("    private func add<T>(\n")
+	b.WriteString("        _ command: String, cmdName: String, requestData: Data, parse: @escaping (Data) throws -> T\n")
+	b.WriteString("    ) -> BatchCall<T> {\n")
+	b.WriteString("        let call = BatchCall<T>(command: command)\n")
+	b.WriteString("        let name = Data(cmdName.utf8)\n")
+	b.WriteString("        payload.append(UInt8(name.count))\n")
+	b.WriteString("        payload.append(name)\n")
+	b.WriteString("        payload.append(UInt8(requestData.count & 0xFF))\n")
+	b.WriteString("        payload.append(UInt8(requestData.count >> 8))\n")
+	b.WriteString("        payload.append(requestData)\n")
+	b.WriteString("        let client = self.client\n")
+	b.WriteString("        pending.append((command, { data in\n")
+	b.WriteString("            let result = Result { try client.decode(command, try data.get(), parse) }\n")
+	b.WriteString("            call.result = result\n")
+	b.WriteString("            if case .failure(let error) = result { return error }\n")
+	b.WriteString("            return nil\n")
+	b.WriteString("        }))\n")
+	b.WriteString("        return call\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Sends the calls added since the last send and records their results.\n")
+	b.WriteString("    /// Throws the error of the first failed call once every result is\n")
+	b.WriteString("    /// recorded, or the transport error, which every call then throws too.\n")
+	b.WriteString("    func send() async throws {\n")
+	b.WriteString("        let sent = pending\n")
+	b.WriteString("        let requestData = payload\n")
+	b.WriteString("        pending = []\n")
+	b.WriteString("        payload = Data()\n")
+	b.WriteString("        guard !sent.isEmpty else { return }\n")
+	b.WriteString("        let respData: Data\n")
+	b.WriteString("        do {\n")
+	b.WriteString("            respData = try await client.exclusive { try await client.call(cmdName: batchCommand, requestData: requestData) }\n")
+	b.WriteString("        } catch {\n")
+	b.WriteString("            for entry in sent {\n")
+	b.WriteString("                _ = entry.complete(.failure(error))\n")
+	b.WriteString("            }\n")
+	b.WriteString("            throw error\n")
+	b.WriteString("        }\n")
+	b.WriteString("        let bytes = [UInt8](respData)\n")
+	b.WriteString("        var pos = 0\n")
+	b.WriteString("        var firstError: Error?\n")
+	b.WriteString("        for entry in sent {\n")
+	b.WriteString("            let size = bytes.count - pos < 2 ? 0 : Int(bytes[pos]) | Int(bytes[pos + 1]) << 8\n")
+	b.WriteString("            let data: Result<Data, Error>\n")
+	b.WriteString("            if bytes.count - pos < 2 + size {\n")
+	b.WriteString("                data = .failure(BatchError(message: \"\\(entry.command): no response in the batch\"))\n")
+	b.WriteString("                pos = bytes.count\n")
+	b.WriteString("            } else {\n")
+	b.WriteString("                data = .success(Data(bytes[(pos + 2)..<(pos + 2 + size)]))\n")
+	b.WriteString("                pos += 2 + size\n")
+	b.WriteString("            }\n")
+	b.WriteString("            if let error = entry.complete(data), firstError == nil {\n")
+	b.WriteString("                firstError = error\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("        if let firstError { throw firstError }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftSchemaMismatchError emits the schema constants and the error
+// verifySchema throws.
+func writeSwiftSchemaMismatchError(gctx *gen.Context, b *strings.Builder) {
+	b.WriteString("/// The schema this client was generated from, which verifySchema compares\n")
+	b.WriteString("/// with the peripheral's.\n")
+	b.WriteString(fmt.Sprintf("let blerpcSchemaHash = \"%s\"\n", gctx.SchemaHash))
+	b.WriteString(fmt.Sprintf("let blerpcGeneratorVersion = \"%s\"\n", gen.GeneratorVersion))
+	b.WriteByte('\n')
+	b.WriteString("/// The peripheral was built from a different schema than this client.\n")
+	b.WriteString("struct SchemaMismatchError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let expected: String\n")
+	b.WriteString("    let actual: String\n")
+	b.WriteString("    let generatorVersion: String\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeSwiftBlerpcInfoHelpers emits the schema check of the Swift client
+// protocol extension.
+func writeSwiftBlerpcInfoHelpers(b *strings.Builder, cmd gen.Command, prefix string) {
+	b.WriteByte('\n')
+	b.WriteString("    /// Checks that the peripheral was built from this client's schema. Call\n")
+	b.WriteString("    /// once on connect; throws SchemaMismatchError when the schema hashes\n")
+	b.WriteString("    /// differ.\n")
+	b.WriteString("    @discardableResult\n")
+	b.WriteString(fmt.Sprintf("    func verifySchema() async throws -> %s%s {\n", prefix, cmd.ResponseMsg))
+	b.WriteString(fmt.Sprintf("        let info = try await %s()\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("        guard info.schemaHash == blerpcSchemaHash else {\n")
+	b.WriteString("            throw SchemaMismatchError(\n")
+	b.WriteString("                expected: blerpcSchemaHash, actual: info.schemaHash, generatorVersion: info.generatorVersion)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return info\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,71 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeSwiftBuiltinHelpers(b *strings.Builder, commands []gen.Command, prefix string) {
+	if cmd, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writeSwiftBlerpcInfoHelpers(b, cmd, prefix)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "capabilities"); ok {
+		writeSwiftCapabilitiesHelpers(b, cmd, commands)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "conn_params"); ok {
+		writeSwiftConnParamsHelpers(b, cmd, prefix)
+	}
+	if dfu, ok := gen.FirmwareUpdateCommands(commands); ok {
+		writeSwiftDfuHelpers(b, dfu)
+	}
+	if files, ok := gen.FileTransferCommands(commands); ok {
+		writeSwiftFileTransferHelpers(b, files)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "log_stream"); ok {
+		writeSwiftLogStreamHelpers(b, cmd, prefix)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "ping"); ok {
+		writeSwiftPingHelpers(b, cmd)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "rpc_stats"); ok {
+		writeSwiftRPCStatsHelpers(b, cmd, prefix)
+	}
+	if start, auth, ok := gen.SessionCommands(commands); ok {
+		writeSwiftSessionHelpers(b, start, auth, prefix)
+	}
+	writeSwiftSettingsHelpers(b, commands, prefix)
+	if cmd, ok := gen.BuiltinCommand(commands, "time_sync"); ok {
+		writeSwiftTimeSyncHelpers(b, cmd, prefix)
+	}
+}
+
+// writeSwiftConnParamsHelpers emits the connection profile helpers of the
+// Swift client protocol extension.
+func writeSwiftConnParamsHelpers(b *strings.Builder, cmd gen.Command, prefix string) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	profile := func(c string) string {
+		return fmt.Sprintf("%s(profile: Int32(%sConnProfile.%s.rawValue))", method, prefix, c)
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /// Runs `body` on the fast connection profile, e.g. for a DFU, then\n")
+	b.WriteString("    /// requests the balanced profile again.\n")
+	b.WriteString("    func withFastConnection<T>(_ body: () async throws -> T) async throws -> T {\n")
+	b.WriteString("        _ = try await " + profile("fast") + "\n")
+	b.WriteString("        do {\n")
+	b.WriteString("            let result = try await body()\n")
+	b.WriteString("            _ = try await " + profile("balanced") + "\n")
+	b.WriteString("            return result\n")
+	b.WriteString("        } catch {\n")
+	b.WriteString("            _ = try? await " + profile("balanced") + "\n")
+	b.WriteString("            throw error\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Requests the low-power profile for a connection that stays idle.\n")
+	b.WriteString("    @discardableResult\n")
+	b.WriteString(fmt.Sprintf("    func useIdleConnection() async throws -> %s%s {\n", prefix, cmd.ResponseMsg))
+	b.WriteString("        try await " + profile("lowPower") + "\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,42 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftPayloadTooLargeError emits the error the Swift client throws for
+// a bounded bytes argument that is too long.
+func writeSwiftPayloadTooLargeError(b *strings.Builder) {
+	b.WriteString("/// A bytes argument exceeds the max_size the peripheral accepts.\n")
+	b.WriteString("struct PayloadTooLargeError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let field: String\n")
+	b.WriteString("    let size: Int\n")
+	b.WriteString("    let maxSize: Int\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeSwiftSizeChecks emits the length checks of the bounded bytes and
+// string arguments of cmd.
+func writeSwiftSizeChecks(b *strings.Builder, cmd gen.Command, indent string) {
+	for _, f := range cmd.RequestFields {
+		if gen.BoundedString(f) {
+			writeSwiftStringCheck(b, cmd, f, indent)
+		}
+		if !gen.BoundedBytes(f) {
+			continue
+		}
+		propName := gen.SwiftPropertyName(f.Name)
+		cond := fmt.Sprintf("%s.count > %d", propName, f.MaxSize)
+		if gen.NullableField(f) {
+			cond = "let " + propName + ", " + cond
+		}
+		b.WriteString(fmt.Sprintf("%sif %s {\n", indent, cond))
+		b.WriteString(fmt.Sprintf("%s    throw PayloadTooLargeError(command: \"%s\", field: \"%s\", size: %s.count, maxSize: %d)\n", indent, cmd.Snake, f.Name, propName, f.MaxSize))
+		b.WriteString(indent + "}\n")
+	}
+}
@@ -0,0 +1,35 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateCache returns CachingClient.swift, placed next to the
+// generated client.
+func GenerateCache(gctx *gen.Context, commands []gen.Command) string {
+	cacheable := gen.CacheableCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Seconds a response of each cacheable command is served from memory, by\n")
+	b.WriteString("/// the names its calls are sent by.\n")
+	if len(cacheable) == 0 {
+		b.WriteString("let cacheTTLs: [String: TimeInterval] = [:]\n")
+	} else {
+		b.WriteString("let cacheTTLs: [String: TimeInterval] = [\n")
+		for _, cmd := range cacheable {
+			for _, name := range gen.CacheCallNames(cmd, "swift") {
+				b.WriteString(fmt.Sprintf("    %s: %s,\n", name, gen.PyTimeoutSeconds(cmd.CacheTTLMs)))
+			}
+		}
+		b.WriteString("]\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("CachingClient.swift.tmpl", nil))
+	return b.String()
+}
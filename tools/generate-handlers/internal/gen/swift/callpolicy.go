@@ -0,0 +1,57 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftCallPolicies emits the call policy struct and table.
+func writeSwiftCallPolicies(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("/// Timeout of each attempt of a command and how often it is retried.\n")
+	b.WriteString("struct CallPolicy: Sendable {\n")
+	b.WriteString("    let timeoutMs: UInt64\n")
+	b.WriteString("    let retries: Int\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Call policies by command. Commands without one wait as long as the\n")
+	b.WriteString("/// transport does and are not retried.\n")
+	b.WriteString("let callPolicies: [String: CallPolicy] = [\n")
+	for _, cmd := range commands {
+		if cmd.TimeoutMs > 0 {
+			b.WriteString(fmt.Sprintf("    \"%s\": CallPolicy(timeoutMs: %d, retries: %d),\n", cmd.Snake, cmd.TimeoutMs, cmd.Retries))
+		}
+	}
+	b.WriteString("]\n")
+	b.WriteByte('\n')
+}
+
+// writeSwiftCallPolicyHelper emits the GeneratedClientProtocol extension
+// member running a call under a policy.
+func writeSwiftCallPolicyHelper(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteString("    /// Runs `body` under the call policy of `command`: each attempt fails with\n")
+	b.WriteString("    /// a TimeoutError after the timeout, and attempts that time out or fail\n")
+	b.WriteString("    /// with a TransportError are made again up to the retries. A timed-out\n")
+	b.WriteString("    /// attempt is cancelled, so call should honor cancellation.\n")
+	b.WriteString("    func withCallPolicy(_ command: String, _ body: @escaping @Sendable () async throws -> Data) async throws -> Data {\n")
+	b.WriteString("        let policy = callPolicies[command]!\n")
+	b.WriteString("        var attempt = 0\n")
+	b.WriteString("        while true {\n")
+	b.WriteString("            do {\n")
+	b.WriteString("                return try await withThrowingTaskGroup(of: Data.self) { group in\n")
+	b.WriteString("                    group.addTask { try await body() }\n")
+	b.WriteString("                    group.addTask {\n")
+	b.WriteString("                        try await Task.sleep(nanoseconds: policy.timeoutMs * 1_000_000)\n")
+	b.WriteString("                        throw TimeoutError(command: command)\n")
+	b.WriteString("                    }\n")
+	b.WriteString("                    defer { group.cancelAll() }\n")
+	b.WriteString("                    return try await group.next()!\n")
+	b.WriteString("                }\n")
+	b.WriteString("            } catch let error where attempt < policy.retries && (error is TimeoutError || error is TransportError) {\n")
+	b.WriteString("                attempt += 1\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,31 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftStreamCancelRequirement emits the streamCancel requirement of
+// GeneratedClientProtocol, whose default does nothing.
+func writeSwiftStreamCancelRequirement(b *strings.Builder) {
+	b.WriteString("    /// Stops the P→C stream in progress, whose consumer stopped early. The\n")
+	b.WriteString("    /// default does nothing and the peripheral runs the stream to its end;\n")
+	b.WriteString("    /// implement it to send a cancelContainer and drain the stream.\n")
+	b.WriteString("    func streamCancel() async\n")
+}
+
+// writeSwiftCancelContainer emits the Cancel control command and the
+// function serializing its container.
+func writeSwiftCancelContainer(b *strings.Builder) {
+	b.WriteString("/// Control command of the Cancel container, which stops the P→C stream in\n")
+	b.WriteString("/// progress on the peripheral. The protocol library has no constant for it.\n")
+	b.WriteString(fmt.Sprintf("let controlCmdCancel: UInt8 = 0x%x\n", gen.ControlCmdCancel))
+	b.WriteByte('\n')
+	b.WriteString("/// Serializes a Cancel control container, which carries no payload.\n")
+	b.WriteString("func cancelContainer(transactionId: UInt8) -> Data {\n")
+	b.WriteString("    Data([transactionId, 0, 0xC0 | controlCmdCancel << 2, 0])\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
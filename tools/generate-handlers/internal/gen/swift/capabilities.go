@@ -0,0 +1,42 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftCapabilitiesHelpers emits the capability helpers of the Swift
+// client protocol extension.
+func writeSwiftCapabilitiesHelpers(b *strings.Builder, cmd gen.Command, commands []gen.Command) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /// Returns the names of the commands the firmware implements; commands it\n")
+	b.WriteString("    /// lacks, or leaves on their stubs, are missing. Query it once on connect\n")
+	b.WriteString("    /// and check the set before calling commands some firmware versions lack.\n")
+	b.WriteString("    func supportedCommands() async throws -> Set<String> {\n")
+	b.WriteString(fmt.Sprintf("        let resp = try await %s()\n", method))
+	if cmd.ID != 0 {
+		b.WriteString("        let bitmap = [UInt8](resp.commandIds)\n")
+		b.WriteString("        let ids: [String: Int] = [\n")
+		for _, c := range commands {
+			b.WriteString(fmt.Sprintf("            \"%s\": %d,\n", c.Snake, c.ID))
+		}
+		b.WriteString("        ]\n")
+		b.WriteString("        return Set(ids.filter { $0.value / 8 < bitmap.count && (bitmap[$0.value / 8] >> ($0.value % 8)) & 1 != 0 }.keys)\n")
+	} else {
+		b.WriteString("        let names: [String: String] = [\n")
+		for _, c := range commands {
+			b.WriteString(fmt.Sprintf("            \"%s\": \"%s\",\n", c.Wire(), c.Snake))
+		}
+		b.WriteString("        ]\n")
+		b.WriteString("        return Set(resp.names.compactMap { names[$0] })\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Reports whether the firmware implements `command`, e.g. \"echo\".\n")
+	b.WriteString("    func supports(_ command: String) async throws -> Bool {\n")
+	b.WriteString("        try await supportedCommands().contains(command)\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,247 @@
+// Package swift generates the Swift client and its Swift package.
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// swiftParam renders a method parameter. proto2 required fields have no
+// default so callers must pass them.
+func swiftParam(f gen.Field, prefix string) string {
+	propName := gen.SwiftPropertyName(f.Name)
+	if o, ok := gen.FieldTypeOverride(f, "swift"); ok {
+		if o.Default == "" {
+			return fmt.Sprintf("%s: %s", propName, o.Type)
+		}
+		return fmt.Sprintf("%s: %s = %s", propName, o.Type, o.Default)
+	}
+	if f.IsRequired {
+		return fmt.Sprintf("%s: %s", propName, ResolveType(f, prefix))
+	}
+	if gen.NullableField(f) {
+		return fmt.Sprintf("%s: %s? = nil", propName, ResolveType(f, prefix))
+	}
+	return fmt.Sprintf("%s: %s = %s", propName, ResolveType(f, prefix), ResolveDefault(f, prefix))
+}
+
+// clientData fills GeneratedClient.swift.tmpl. The sections of the
+// features, such as sessions, call policies and the built-ins, are written
+// in Go. Index leaves out the methods, which the split client keeps in its
+// group files.
+type clientData struct {
+	ActorClient, MAC bool
+	Imports          []string // the imports of the type overrides
+	Errors           string
+	Preamble         string // declarations between CallSerializer and the protocol
+	Requirements     string // the protocol requirements of the features
+	ServerStreams    bool
+	CallHelpers      string // the call policy and compression helpers
+	Index            bool
+	clientMethods
+	Trailer string // the declarations after the extension
+}
+
+// clientMethods fills swift_client_methods.tmpl: the members of the
+// protocol extension.
+type clientMethods struct {
+	Methods []clientMethod
+	Aliases []clientAlias
+	Helpers string // the built-in helpers
+}
+
+// clientMethod is the method of a command. Stream is "p2c", "c2p" or empty
+// for a unary command. Deprecation, SizeChecks, Setters and StatusCheck
+// are whole lines; SizeChecks and StatusCheck are unindented. Call opens
+// the call of a unary command, which runs under withCallPolicy when Policy
+// is set.
+type clientMethod struct {
+	Name, Snake, Request, Response, CallName string
+	Stream                                   string
+	Params                                   string
+	Deprecation, SizeChecks, Setters         string
+	Call, RequestData                        string
+	Policy                                   bool
+	StatusCheck                              string
+}
+
+// clientAlias is the deprecated method under the old name of a renamed
+// command, calling Target.
+type clientAlias struct {
+	Name, Target, Params, Returns, Call string
+}
+
+// GenerateClient returns GeneratedClient.swift: the client protocol and
+// the extension with a method per command.
+func GenerateClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	d := newClientData(gctx, commands, streaming, prefix)
+	d.clientMethods = newClientMethods(commands, streaming, prefix)
+	return gctx.RenderTemplate("GeneratedClient.swift.tmpl", d)
+}
+
+// newClientData fills the client file but for its methods.
+func newClientData(gctx *gen.Context, commands []gen.Command, streaming map[string]string, prefix string) clientData {
+	_, _, sessions := gen.SessionCommands(commands)
+	d := clientData{
+		ActorClient:   gctx.SwiftActorClient,
+		MAC:           sessions || gen.HasReplayProtected(commands),
+		Imports:       gen.OverrideImports(commands, "swift"),
+		ServerStreams: gen.HasServerStreams(commands, streaming),
+	}
+	var b strings.Builder
+	writeSwiftErrors(gctx, &b, commands)
+	d.Errors = b.String()
+
+	b.Reset()
+	if gen.HasReplayProtected(commands) {
+		writeSwiftReplayCounter(&b)
+	}
+	if sessions {
+		writeSwiftSession(&b)
+	}
+	if gen.HasCommandIDs(commands) {
+		writeSwiftCommandIDs(&b, commands)
+	}
+	if gen.HasCallPolicies(commands) {
+		writeSwiftCallPolicies(&b, commands)
+	}
+	if gen.HasCompressed(commands) {
+		WriteCompression(&b)
+	}
+	if d.ServerStreams {
+		writeSwiftCancelContainer(&b)
+	}
+	d.Preamble = b.String()
+
+	b.Reset()
+	if d.ServerStreams {
+		writeSwiftStreamCancelRequirement(&b)
+	}
+	if sessions {
+		writeSwiftSessionRequirement(&b)
+	}
+	d.Requirements = b.String()
+
+	b.Reset()
+	if gen.HasCallPolicies(commands) {
+		writeSwiftCallPolicyHelper(&b)
+	}
+	if gen.HasCompressed(commands) {
+		WriteCompressedCall(&b)
+	}
+	d.CallHelpers = b.String()
+
+	b.Reset()
+	writeSwiftTypedAccessors(&b, commands, prefix)
+	WriteRemovedFields(&b, commands, prefix)
+	writeSwiftCharacteristics(&b, commands)
+	writeSwiftRoles(&b, commands)
+	if gctx.SwiftActorClient {
+		writeSwiftActorClient(gctx, &b, commands, streaming)
+	}
+	d.Trailer = b.String()
+	return d
+}
+
+// writeSwiftTypedAccessors emits typed accessors for mapped response fields.
+func writeSwiftTypedAccessors(b *strings.Builder, commands []gen.Command, prefix string) {
+	order, byMsg := gen.MappedResponseFields(commands, "swift")
+	for _, msg := range order {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("extension %s%s {\n", prefix, msg))
+		for _, f := range byMsg[msg] {
+			o, _ := gen.FieldTypeOverride(f, "swift")
+			prop := gen.SwiftPropertyName(f.Name)
+			b.WriteString(fmt.Sprintf("    var %sTyped: %s { %s }\n", prop, o.Type, gen.ApplyConverter(o.Decode, prop)))
+		}
+		b.WriteString("}\n")
+	}
+}
+
+// newClientMethods fills the methods of the commands, including deprecated
+// aliases, for the GeneratedClientProtocol extension.
+func newClientMethods(commands []gen.Command, streaming map[string]string, prefix string) clientMethods {
+	var d clientMethods
+	for _, stream := range []bool{false, true} {
+		for _, cmd := range commands {
+			dir, ok := streaming[cmd.Snake]
+			if ok != stream {
+				continue
+			}
+			m := clientMethod{
+				Name:        gen.ToLowerCamel(cmd.Camel),
+				Snake:       cmd.Snake,
+				Request:     prefix + cmd.RequestMsg,
+				Response:    prefix + cmd.ResponseMsg,
+				CallName:    gen.CallName(cmd, "swift"),
+				Stream:      dir,
+				Deprecation: Deprecation(cmd, dir != "c2p"),
+				StatusCheck: swiftStatusCheck(cmd, ""),
+			}
+			if dir != "c2p" {
+				var b strings.Builder
+				m.Params = strings.Join(swiftParams(cmd.RequestFields, cmd.RequestMsg, prefix), ", ")
+				writeSwiftSizeChecks(&b, cmd, "")
+				m.SizeChecks = b.String()
+				b.Reset()
+				writeSwiftSetters(&b, cmd.RequestFields)
+				m.Setters = b.String()
+			}
+			if dir == "" {
+				m.RequestData = "try req.serializedData()"
+				if cmd.ReplayProtected {
+					m.RequestData = fmt.Sprintf("ReplayCounter.prefix(key: session.key, command: \"%s\", %s)", cmd.Wire(), m.RequestData)
+				}
+				m.Call = "call("
+				if cmd.SessionProtected {
+					m.Call = "sessionCall("
+				}
+				if cmd.Compression != "" {
+					m.Call = fmt.Sprintf("compressedCall(\"%s\", ", cmd.Compression)
+				}
+				m.Policy = cmd.TimeoutMs > 0
+			}
+			d.Methods = append(d.Methods, m)
+		}
+	}
+
+	// Deprecated aliases for renamed commands
+	for _, cmd := range commands {
+		if cmd.RenamedFrom == "" {
+			continue
+		}
+		a := clientAlias{
+			Name:    gen.ToLowerCamel(cmd.RenamedFrom),
+			Target:  gen.ToLowerCamel(cmd.Camel),
+			Returns: prefix + cmd.ResponseMsg,
+		}
+		var params, args []string
+		switch streaming[cmd.Snake] {
+		case "c2p":
+			params = []string{fmt.Sprintf("messages: [%s%s]", prefix, cmd.RequestMsg)}
+			args = []string{"messages: messages"}
+		case "p2c":
+			a.Returns = "[" + a.Returns + "]"
+			fallthrough
+		default:
+			params = swiftParams(cmd.RequestFields, cmd.RequestMsg, prefix)
+			for _, name := range gen.ParamNames(cmd.RequestFields) {
+				propName := gen.SwiftPropertyName(name)
+				args = append(args, propName+": "+propName)
+			}
+		}
+		a.Params = strings.Join(params, ", ")
+		a.Call = fmt.Sprintf("%s(%s)", a.Target, strings.Join(args, ", "))
+		d.Aliases = append(d.Aliases, a)
+	}
+
+	var b strings.Builder
+	writeSwiftUnwrapped(&b, commands, prefix)
+	writeSwiftProperties(&b, commands, prefix)
+	writeSwiftBuiltinHelpers(&b, commands, prefix)
+	d.Helpers = b.String()
+	return d
+}
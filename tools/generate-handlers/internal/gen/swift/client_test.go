@@ -0,0 +1,266 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateSwiftClient_Echo(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"protocol GeneratedClientProtocol",
+		"extension GeneratedClientProtocol",
+		`func echo(message: String = "")`,
+		"Blerpc_EchoRequest()",
+		"req.message = message",
+		`call(cmdName: "echo"`,
+		"Blerpc_EchoResponse(serializedBytes:",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client missing %q", s)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_CustomPkg(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "myapp")
+
+	mustContain := []string{
+		"Myapp_EchoRequest()",
+		"Myapp_EchoResponse(serializedBytes:",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client custom pkg missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "Blerpc_") {
+		t.Errorf("Swift client custom pkg should not contain 'Blerpc_'")
+	}
+}
+
+func TestGenerateSwiftClient_Repeated(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.RepeatedCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"names: [String] = []",
+		"ids: [UInt32] = []",
+		"req.names = names",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client repeated missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_MessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MessageFieldCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"address: Blerpc_Address = Blerpc_Address()",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client message field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_Oneof(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.OneofCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"func search(limit: UInt32 = 0, query: Blerpc_SearchRequest.OneOf_Query? = nil) async throws -> Blerpc_SearchResponse {",
+		"        req.query = query\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client oneof missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_NestedMessageField(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.NestedMessageCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"tag: Blerpc_Outer.Inner = Blerpc_Outer.Inner()",
+		"path: [Blerpc_Point] = []",
+		"named: [String: Blerpc_Point] = [:]",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client nested message field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_Map(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MapCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	mustContain := []string{
+		"labels: [String: String] = [:]",
+		"counts: [String: UInt32] = [:]",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client map missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_Enum(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EnumCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	// Enum fields don't affect request params much (name is string),
+	// but method should be generated
+	if !strings.Contains(out, "func getStatus(") {
+		t.Errorf("Swift client enum missing getStatus method\nGot:\n%s", out)
+	}
+}
+
+func TestGenerateSwiftClient_StreamP2C(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"func counterStream(",
+		"[Blerpc_CounterStreamResponse]",
+		"streamReceive(",
+		"Blerpc_CounterStreamResponse(serializedBytes:",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client p2c missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_StreamC2P(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_upload": "c2p"}
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
+
+	mustContain := []string{
+		"func counterUpload(",
+		"messages: [Blerpc_CounterUploadRequest]",
+		"streamSend(",
+		"Blerpc_CounterUploadResponse(serializedBytes:",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client c2p missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_Proto2(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.Proto2Command()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	want := `func configure(address: UInt32, label: String = "dev") async throws`
+	if !strings.Contains(out, want) {
+		t.Errorf("Swift client proto2 missing %q\nGot:\n%s", want, out)
+	}
+}
+
+func TestGenerateSwiftClient_Proto3Optional(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateClient(gctx, []gen.Command{gentest.OptionalCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"func list(limit: UInt32? = nil, offset: UInt32 = 0) async throws",
+		"if let limit { req.limit = limit }",
+		"req.offset = offset",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client optional field missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_RenamedFrom(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.StreamP2CCommand()
+	cmd.RenamedFrom = "Count"
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, []gen.Command{cmd}, streaming, "blerpc")
+
+	mustContain := []string{
+		`@available(*, deprecated, renamed: "counterStream")`,
+		"func count(start: UInt32 = 0) async throws -> [Blerpc_CounterStreamResponse] {",
+		"try await counterStream(start: start)",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_TypeOverride(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.RequestFields[0].TypeOverrides = map[string]gen.TypeOverride{"swift": {
+		Type: "Label", Encode: "{}.rawValue", Default: "Label()", Import: "import DeviceKit",
+	}}
+	cmd.ResponseFields[0].TypeOverrides = map[string]gen.TypeOverride{"swift": {
+		Type: "Label", Decode: "Label(rawValue: {})",
+	}}
+	out := GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc")
+
+	mustContain := []string{
+		"import SwiftProtobuf\nimport DeviceKit\n",
+		"func echo(message: Label = Label()) async throws -> Blerpc_EchoResponse {",
+		"req.message = message.rawValue",
+		"extension Blerpc_EchoResponse {\n    var messageTyped: Label { Label(rawValue: message) }\n}",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_SerializesCalls(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, map[string]string{}, "blerpc")
+
+	for _, s := range []string{
+		"actor CallSerializer {",
+		"    var callSerializer: CallSerializer { get }\n",
+		"    func exclusive<T>(_ body: () async throws -> T) async throws -> T {\n",
+		"        await callSerializer.acquire()\n",
+		"let respData = try await exclusive { try await call(cmdName: \"echo\"",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
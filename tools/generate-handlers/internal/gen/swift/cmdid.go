@@ -0,0 +1,21 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeSwiftCommandIDs(b *strings.Builder, commands []gen.Command) {
+	b.WriteString("/// Numeric command IDs, sent as one-character command names.\n")
+	b.WriteString("enum CommandId: UInt8 {\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    case %s = %d\n", gen.SwiftPropertyName(cmd.Snake), cmd.ID))
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /// The command name carrying this ID.\n")
+	b.WriteString("    var wireName: String { String(UnicodeScalar(rawValue)) }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,91 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// WriteCompression emits the codecs of the Swift client.
+func WriteCompression(b *strings.Builder) {
+	b.WriteString("/// CAPABILITIES flag of a peripheral answering compressed calls.\n")
+	b.WriteString(fmt.Sprintf("let capabilityFlagCompression = 0x%04X\n", gen.CapabilityFlagCompression))
+	b.WriteByte('\n')
+	b.WriteString(fmt.Sprintf("private let compressedCommand = \"%s\"\n", gen.CompressedCommandName))
+	b.WriteString("private let flagCompressed: UInt8 = 0x01\n")
+	b.WriteString("private let flagAcceptCompressed: UInt8 = 0x02\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Compresses and decompresses the data of compressed calls.\n")
+	b.WriteString("struct CompressionCodec: Sendable {\n")
+	b.WriteString("    let compress: @Sendable (Data) throws -> Data\n")
+	b.WriteString("    let decompress: @Sendable (Data) throws -> Data\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Raw DEFLATE (RFC 1951).\n")
+	b.WriteString("    static let deflate = CompressionCodec(\n")
+	b.WriteString("        compress: { try ($0 as NSData).compressed(using: .zlib) as Data },\n")
+	b.WriteString("        decompress: { try ($0 as NSData).decompressed(using: .zlib) as Data }\n")
+	b.WriteString("    )\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Codecs by algorithm. DEFLATE is built in; register a heatshrink codec\n")
+	b.WriteString("/// (window 8, lookahead 4 bits) to compress the heatshrink commands too.\n")
+	b.WriteString("/// Calls of a command whose algorithm has no codec are sent uncompressed.\n")
+	b.WriteString("enum CompressionCodecs {\n")
+	b.WriteString("    private static let lock = NSLock()\n")
+	b.WriteString("    private static var codecs: [String: CompressionCodec] = [\"deflate\": .deflate]\n")
+	b.WriteByte('\n')
+	b.WriteString("    static subscript(algorithm: String) -> CompressionCodec? {\n")
+	b.WriteString("        get {\n")
+	b.WriteString("            lock.lock()\n")
+	b.WriteString("            defer { lock.unlock() }\n")
+	b.WriteString("            return codecs[algorithm]\n")
+	b.WriteString("        }\n")
+	b.WriteString("        set {\n")
+	b.WriteString("            lock.lock()\n")
+	b.WriteString("            defer { lock.unlock() }\n")
+	b.WriteString("            codecs[algorithm] = newValue\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// WriteCompressedCall emits the GeneratedClientProtocol extension
+// method sending a compressed call.
+func WriteCompressedCall(b *strings.Builder) {
+	b.WriteByte('\n')
+	b.WriteString("    /// Sends a call of a compressed command in the compression envelope when\n")
+	b.WriteString("    /// the peripheral advertises capabilityFlagCompression and\n")
+	b.WriteString("    /// CompressionCodecs has a codec for the algorithm, compressing the request\n")
+	b.WriteString("    /// when that makes it smaller, and returns the response decompressed;\n")
+	b.WriteString("    /// sends a plain call otherwise.\n")
+	b.WriteString("    func compressedCall(_ algorithm: String, cmdName: String, requestData: Data) async throws -> Data {\n")
+	b.WriteString("        guard let codec = CompressionCodecs[algorithm],\n")
+	b.WriteString("              capabilityFlags & capabilityFlagCompression != 0 else {\n")
+	b.WriteString("            return try await call(cmdName: cmdName, requestData: requestData)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        var flags = flagAcceptCompressed\n")
+	b.WriteString("        var data = requestData\n")
+	b.WriteString("        if let packed = try? codec.compress(requestData), packed.count < requestData.count {\n")
+	b.WriteString("            flags |= flagCompressed\n")
+	b.WriteString("            data = packed\n")
+	b.WriteString("        }\n")
+	b.WriteString("        let name = Data(cmdName.utf8)\n")
+	b.WriteString("        var envelope = Data([UInt8(name.count)])\n")
+	b.WriteString("        envelope.append(name)\n")
+	b.WriteString("        envelope.append(flags)\n")
+	b.WriteString("        envelope.append(data)\n")
+	b.WriteString("        let respData = try await call(cmdName: compressedCommand, requestData: envelope)\n")
+	b.WriteString("        guard let respFlags = respData.first else {\n")
+	b.WriteString("            throw DecodeError(command: cmdName, underlying: CocoaError(.coderReadCorrupt))\n")
+	b.WriteString("        }\n")
+	b.WriteString("        let body = Data(respData.dropFirst())\n")
+	b.WriteString("        guard respFlags & flagCompressed != 0 else { return body }\n")
+	b.WriteString("        do {\n")
+	b.WriteString("            return try codec.decompress(body)\n")
+	b.WriteString("        } catch {\n")
+	b.WriteString("            throw DecodeError(command: cmdName, underlying: error)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+}
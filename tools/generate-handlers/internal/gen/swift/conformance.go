@@ -0,0 +1,69 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// StringLiteral quotes a command name, escaping a one-byte ID.
+func StringLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		if c < 0x20 || c >= 0x7F {
+			fmt.Fprintf(&b, `\u{%02x}`, c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// GenerateLoopback returns LoopbackClient.swift with the vectors and the
+// message tables of the commands they cover.
+func GenerateLoopback(gctx *gen.Context, commands []gen.Command, suite gen.ConformanceSuite, pkg string) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	_, _, sessions := gen.SessionCommands(commands)
+	var b strings.Builder
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString(gctx.RenderTemplate("LoopbackClient.swift.tmpl", struct {
+		Sessions    bool
+		SessionSize int
+	}{sessions, gen.SessionProofSize}))
+	b.WriteByte('\n')
+	b.WriteString("/// The vectors of conformance/vectors.json.\n")
+	b.WriteString("let conformanceVectors: [ConformanceVector] = [\n")
+	for _, v := range suite.Vectors {
+		b.WriteString("    ConformanceVector(\n")
+		b.WriteString(fmt.Sprintf("        command: %q, wireName: %s, stream: %q, mtu: %d,\n", v.Command, StringLiteral(v.WireName), v.Stream, v.MTU))
+		b.WriteString(fmt.Sprintf("        request: %q,\n", v.Request))
+		b.WriteString(fmt.Sprintf("        response: %q,\n", v.Response))
+		b.WriteString(fmt.Sprintf("        requestContainers: [%s],\n", gen.ConformanceStringList(v.RequestContainers)))
+		b.WriteString(fmt.Sprintf("        responseContainers: [%s]\n", gen.ConformanceStringList(v.ResponseContainers)))
+		b.WriteString("    ),\n")
+	}
+	b.WriteString("]\n")
+	b.WriteByte('\n')
+	covered := gen.ConformanceCommands(commands, suite)
+	b.WriteString("private func conformanceRequest(_ command: String, _ data: Data) throws -> any SwiftProtobuf.Message {\n")
+	b.WriteString("    switch command {\n")
+	for _, cmd := range covered {
+		b.WriteString(fmt.Sprintf("    case \"%s\": return try %s%s(serializedBytes: data)\n", cmd.Snake, prefix, cmd.RequestMsg))
+	}
+	b.WriteString("    default: throw ConformanceError.unknownCommand(command)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("private func conformanceResponse(_ command: String, _ data: Data) throws -> any SwiftProtobuf.Message {\n")
+	b.WriteString("    switch command {\n")
+	for _, cmd := range covered {
+		b.WriteString(fmt.Sprintf("    case \"%s\": return try %s%s(serializedBytes: data)\n", cmd.Snake, prefix, cmd.ResponseMsg))
+	}
+	b.WriteString("    default: throw ConformanceError.unknownCommand(command)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,10 @@
+package swift
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateConnectionManager returns ConnectionManager.swift.
+func GenerateConnectionManager(gctx *gen.Context) string {
+	return gctx.RenderTemplate("ConnectionManager.swift.tmpl", nil)
+}
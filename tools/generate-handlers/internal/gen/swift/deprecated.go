@@ -0,0 +1,21 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Deprecation returns the documentation and attribute of a client
+// method of cmd, with params one taking the request fields.
+func Deprecation(cmd gen.Command, params bool) string {
+	var b strings.Builder
+	if fields := gen.DeprecatedFields(cmd); params && len(fields) > 0 {
+		fmt.Fprintf(&b, "    /// - Note: Deprecated request fields: %s.\n", gen.CodeList(fields))
+	}
+	if cmd.Deprecated {
+		fmt.Fprintf(&b, "    @available(*, deprecated, message: \"%s is deprecated in the schema\")\n", cmd.Snake)
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftDfuError emits the error the firmware update helper throws when
+// the peripheral stops taking the image or the image fails its CRC-32 check.
+func writeSwiftDfuError(b *strings.Builder) {
+	b.WriteString("/// updateFirmware could not write or verify the image at path.\n")
+	b.WriteString("struct FirmwareUpdateError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let path: String\n")
+	b.WriteString("    let reason: String\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeSwiftDfuHelpers emits the firmware update helper of the Swift client
+// protocol extension.
+func writeSwiftDfuHelpers(b *strings.Builder, dfu gen.DFUCommands) {
+	b.WriteByte('\n')
+	b.WriteString("    /// Writes the firmware image at `path` to the peripheral and applies it.\n")
+	b.WriteString("    /// An update interrupted before it was applied continues after the bytes\n")
+	b.WriteString("    /// the peripheral already has when called again with the same image.\n")
+	b.WriteString("    /// `progress` is called with the bytes sent and the total after each\n")
+	b.WriteString("    /// chunk. With `reboot`, the peripheral restarts into the image once it is\n")
+	b.WriteString("    /// verified.\n")
+	b.WriteString("    func updateFirmware(\n")
+	b.WriteString("        path: String,\n")
+	b.WriteString("        reboot: Bool = true,\n")
+	b.WriteString("        progress: ((Int, Int) -> Void)? = nil\n")
+	b.WriteString("    ) async throws {\n")
+	b.WriteString("        let image = [UInt8](try Data(contentsOf: URL(fileURLWithPath: path)))\n")
+	b.WriteString("        let crc = firmwareCRC32(image)\n")
+	b.WriteString(fmt.Sprintf("        let begun = try await %s(size: UInt32(image.count), crc32: crc)\n", gen.ToLowerCamel(dfu.Begin.Camel)))
+	b.WriteString("        var offset = Int(begun.offset)\n")
+	b.WriteString("        let chunkSize = begun.maxChunk > 0 ? Int(begun.maxChunk) : 128\n")
+	b.WriteString("        while offset < image.count {\n")
+	b.WriteString("            let end = min(offset + chunkSize, image.count)\n")
+	b.WriteString(fmt.Sprintf("            let resp = try await %s(offset: UInt32(offset), data: Data(image[offset..<end]))\n", gen.ToLowerCamel(dfu.Chunk.Camel)))
+	b.WriteString("            if Int(resp.offset) == offset {\n")
+	b.WriteString("                throw FirmwareUpdateError(path: path, reason: \"stalled at byte \\(offset)\")\n")
+	b.WriteString("            }\n")
+	b.WriteString("            offset = Int(resp.offset)\n")
+	b.WriteString("            progress?(offset, image.count)\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        let done = try await %s(reboot: reboot)\n", gen.ToLowerCamel(dfu.Finalize.Camel)))
+	b.WriteString("        if done.crc32 != crc {\n")
+	b.WriteString("            throw FirmwareUpdateError(path: path, reason: \"failed verification\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private func firmwareCRC32(_ bytes: [UInt8]) -> UInt32 {\n")
+	b.WriteString("        var crc: UInt32 = 0xFFFF_FFFF\n")
+	b.WriteString("        for byte in bytes {\n")
+	b.WriteString("            crc ^= UInt32(byte)\n")
+	b.WriteString("            for _ in 0..<8 {\n")
+	b.WriteString("                crc = (crc >> 1) ^ (crc & 1 == 0 ? 0 : 0xEDB8_8320)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return ~crc\n")
+	b.WriteString("    }\n")
+}
@@ -0,0 +1,87 @@
+package swift
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeSwiftErrors(gctx *gen.Context, b *strings.Builder, commands []gen.Command) {
+	b.WriteString("/// Implemented by every error thrown by generated client methods.\n")
+	b.WriteString("protocol BlerpcErrorProtocol: Error {}\n")
+	b.WriteByte('\n')
+	writeSwiftErrorEnum(b)
+	b.WriteString("/// The request could not be delivered or the response was lost.\n")
+	b.WriteString("struct TransportError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let message: String\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The peripheral did not respond in time.\n")
+	b.WriteString("struct TimeoutError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The response payload is not a valid message.\n")
+	b.WriteString("struct DecodeError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let underlying: Error\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The peripheral reported a non-OK status.\n")
+	b.WriteString("protocol RemoteError: BlerpcErrorProtocol {\n")
+	b.WriteString("    var command: String { get }\n")
+	b.WriteString("    var status: Int { get }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	writeSwiftStatusCodes(b)
+	if gen.HasStatusChecks(commands) {
+		writeSwiftStatusError(b)
+	}
+	if _, ok := gen.FirmwareUpdateCommands(commands); ok {
+		writeSwiftDfuError(b)
+	}
+	if _, ok := gen.FileTransferCommands(commands); ok {
+		writeSwiftFileTransferError(b)
+	}
+	if _, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writeSwiftSchemaMismatchError(gctx, b)
+	}
+	if gen.HasBoundedBytesRequests(commands) {
+		writeSwiftPayloadTooLargeError(b)
+	}
+	if gen.HasBoundedStringRequests(commands) {
+		writeSwiftStringTooLongError(b)
+	}
+}
+
+// writeSwiftErrorEnum emits BlerpcError, which sorts any error a generated
+// method throws into its kind, for a switch that covers every kind.
+func writeSwiftErrorEnum(b *strings.Builder) {
+	b.WriteString("/// An error of a generated client method by kind, for exhaustive handling:\n")
+	b.WriteString("///\n")
+	b.WriteString("///     do { ... } catch { switch BlerpcError(error) { ... } }\n")
+	b.WriteString("///\n")
+	b.WriteString("/// Each case carries the error as thrown. Errors of the transport that are\n")
+	b.WriteString("/// not blerpc errors, e.g. a lost BLE link, are transport errors.\n")
+	b.WriteString("enum BlerpcError: Error {\n")
+	b.WriteString("    case transport(any Error)\n")
+	b.WriteString("    case timeout(TimeoutError)\n")
+	b.WriteString("    case decode(DecodeError)\n")
+	b.WriteString("    case remote(any RemoteError)\n")
+	b.WriteString("    /// Any other error of the generated code, e.g. a failed verification.\n")
+	b.WriteString("    case other(any BlerpcErrorProtocol)\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(_ error: any Error) {\n")
+	b.WriteString("        switch error {\n")
+	b.WriteString("        case let error as BlerpcError: self = error\n")
+	b.WriteString("        case let error as TimeoutError: self = .timeout(error)\n")
+	b.WriteString("        case let error as DecodeError: self = .decode(error)\n")
+	b.WriteString("        case let error as any RemoteError: self = .remote(error)\n")
+	b.WriteString("        case let error as TransportError: self = .transport(error)\n")
+	b.WriteString("        case let error as any BlerpcErrorProtocol: self = .other(error)\n")
+	b.WriteString("        default: self = .transport(error)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,33 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateSwiftClient_Errors(t *testing.T) {
+	gctx := gen.NewContext()
+	streaming := map[string]string{"counter_stream": "p2c"}
+	out := GenerateClient(gctx, []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}, streaming, "blerpc")
+
+	mustContain := []string{
+		"protocol BlerpcErrorProtocol: Error {}",
+		"enum BlerpcError: Error {",
+		"        case let error as any RemoteError: self = .remote(error)\n",
+		"struct TransportError: BlerpcErrorProtocol {",
+		"struct TimeoutError: BlerpcErrorProtocol {",
+		"struct DecodeError: BlerpcErrorProtocol {",
+		"protocol RemoteError: BlerpcErrorProtocol {",
+		"            throw DecodeError(command: command, underlying: error)\n",
+		"        return try decode(\"echo\", respData) { try Blerpc_EchoResponse(serializedBytes: $0) }\n",
+		"responses.map { data in try decode(\"counter_stream\", data) { try Blerpc_CounterStreamResponse(serializedBytes: $0) } }",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
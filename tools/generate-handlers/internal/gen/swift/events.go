@@ -0,0 +1,45 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateEvents(gctx *gen.Context, events []gen.Event, pkg string) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteString("import SwiftProtobuf\n")
+	b.WriteByte('\n')
+	b.WriteString("extension BlerpcClient {\n")
+	for i, ev := range events {
+		cls := prefix + ev.Message.Name
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(fmt.Sprintf("    /// The %s events the peripheral notifies; iterate to subscribe.\n", ev.Message.Name))
+		b.WriteString(fmt.Sprintf("    func subscribe%s() -> AsyncThrowingStream<%s, Error> {\n", ev.Message.Name, cls))
+		b.WriteString(fmt.Sprintf("        let events = self.events(eventName: \"%s\")\n", ev.Snake))
+		b.WriteString("        return AsyncThrowingStream { continuation in\n")
+		b.WriteString("            let task = Task {\n")
+		b.WriteString("                do {\n")
+		b.WriteString("                    for try await data in events {\n")
+		b.WriteString(fmt.Sprintf("                        let event = try self.decode(\"%s\", data) { try %s(serializedBytes: $0) }\n", ev.Snake, cls))
+		b.WriteString("                        continuation.yield(event)\n")
+		b.WriteString("                    }\n")
+		b.WriteString("                    continuation.finish()\n")
+		b.WriteString("                } catch {\n")
+		b.WriteString("                    continuation.finish(throwing: error)\n")
+		b.WriteString("                }\n")
+		b.WriteString("            }\n")
+		b.WriteString("            continuation.onTermination = { _ in task.cancel() }\n")
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftFileTransferError emits the error the upload and download
+// helpers throw when the file fails its CRC-32 check.
+func writeSwiftFileTransferError(b *strings.Builder) {
+	b.WriteString("/// A file moved by uploadFile or downloadFile failed its size or CRC-32 check.\n")
+	b.WriteString("struct FileVerificationError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let path: String\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeSwiftFileTransferHelpers emits the upload and download helpers of the
+// Swift client protocol extension.
+func writeSwiftFileTransferHelpers(b *strings.Builder, files gen.FileCommands) {
+	open := gen.ToLowerCamel(files.Open.Camel)
+	read := gen.ToLowerCamel(files.Read.Camel)
+	write := gen.ToLowerCamel(files.Write.Camel)
+	closeFn := gen.ToLowerCamel(files.Close.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /// Writes `data` to `path` on the peripheral and verifies it by CRC-32.\n")
+	b.WriteString("    /// With `resume`, an interrupted upload continues after the bytes already\n")
+	b.WriteString("    /// written, if they match the start of `data`. `progress` is called with\n")
+	b.WriteString("    /// the bytes sent and the total after each chunk.\n")
+	b.WriteString("    func uploadFile(\n")
+	b.WriteString("        path: String,\n")
+	b.WriteString("        data: Data,\n")
+	b.WriteString("        resume: Bool = false,\n")
+	b.WriteString("        chunkSize: Int = 128,\n")
+	b.WriteString("        progress: ((Int, Int) -> Void)? = nil\n")
+	b.WriteString("    ) async throws {\n")
+	b.WriteString("        let bytes = [UInt8](data)\n")
+	b.WriteString(fmt.Sprintf("        var opened = try await %s(path: path, write: true, resume: resume)\n", open))
+	b.WriteString("        var offset = Int(opened.size)\n")
+	b.WriteString("        if offset != 0 && (offset > bytes.count || fileCRC32(bytes[..<offset]) != opened.crc32) {\n")
+	b.WriteString("            // The partial file is not a prefix of data: start over.\n")
+	b.WriteString(fmt.Sprintf("            _ = try await %s(handle: opened.handle)\n", closeFn))
+	b.WriteString(fmt.Sprintf("            opened = try await %s(path: path, write: true)\n", open))
+	b.WriteString("            offset = 0\n")
+	b.WriteString("        }\n")
+	b.WriteString("        do {\n")
+	b.WriteString("            while offset < bytes.count {\n")
+	b.WriteString("                let end = min(offset + chunkSize, bytes.count)\n")
+	b.WriteString(fmt.Sprintf("                _ = try await %s(\n", write))
+	b.WriteString("                    handle: opened.handle, offset: UInt32(offset), data: Data(bytes[offset..<end]))\n")
+	b.WriteString("                offset = end\n")
+	b.WriteString("                progress?(offset, bytes.count)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        } catch {\n")
+	b.WriteString(fmt.Sprintf("            _ = try? await %s(handle: opened.handle)\n", closeFn))
+	b.WriteString("            throw error\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        let closed = try await %s(handle: opened.handle, verify: true)\n", closeFn))
+	b.WriteString("        if Int(closed.size) != bytes.count || closed.crc32 != fileCRC32(bytes[...]) {\n")
+	b.WriteString("            throw FileVerificationError(path: path)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Reads `path` from the peripheral and verifies it by CRC-32. Pass the\n")
+	b.WriteString("    /// bytes received before an interruption as `partial` to continue after\n")
+	b.WriteString("    /// them. `progress` is called with the bytes received and the total after\n")
+	b.WriteString("    /// each chunk.\n")
+	b.WriteString("    func downloadFile(\n")
+	b.WriteString("        path: String,\n")
+	b.WriteString("        partial: Data = Data(),\n")
+	b.WriteString("        chunkSize: Int = 128,\n")
+	b.WriteString("        progress: ((Int, Int) -> Void)? = nil\n")
+	b.WriteString("    ) async throws -> Data {\n")
+	b.WriteString(fmt.Sprintf("        let opened = try await %s(path: path)\n", open))
+	b.WriteString("        let size = Int(opened.size)\n")
+	b.WriteString("        var bytes = [UInt8](partial.prefix(size))\n")
+	b.WriteString("        do {\n")
+	b.WriteString("            while bytes.count < size {\n")
+	b.WriteString(fmt.Sprintf("                let resp = try await %s(\n", read))
+	b.WriteString("                    handle: opened.handle, offset: UInt32(bytes.count), length: UInt32(chunkSize))\n")
+	b.WriteString("                if resp.data.isEmpty { break }\n")
+	b.WriteString("                bytes += resp.data\n")
+	b.WriteString("                progress?(bytes.count, size)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        } catch {\n")
+	b.WriteString(fmt.Sprintf("            _ = try? await %s(handle: opened.handle)\n", closeFn))
+	b.WriteString("            throw error\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        _ = try await %s(handle: opened.handle)\n", closeFn))
+	b.WriteString("        if bytes.count != size || fileCRC32(bytes[...]) != opened.crc32 {\n")
+	b.WriteString("            throw FileVerificationError(path: path)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return Data(bytes)\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private func fileCRC32(_ bytes: ArraySlice<UInt8>) -> UInt32 {\n")
+	b.WriteString("        var crc: UInt32 = 0xFFFF_FFFF\n")
+	b.WriteString("        for byte in bytes {\n")
+	b.WriteString("            crc ^= UInt32(byte)\n")
+	b.WriteString("            for _ in 0..<8 {\n")
+	b.WriteString("                crc = (crc >> 1) ^ (crc & 1 == 0 ? 0 : 0xEDB8_8320)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return ~crc\n")
+	b.WriteString("    }\n")
+}
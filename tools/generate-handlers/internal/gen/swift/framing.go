@@ -0,0 +1,9 @@
+package swift
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func GenerateFraming(gctx *gen.Context, commands []gen.Command, pkg string, inFlight int, crc bool) string {
+	return gctx.RenderTemplate("framing.swift.tmpl", gctx.NewFramingData(commands, pkg, inFlight, crc))
+}
@@ -0,0 +1,24 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeSwiftCharacteristics(b *strings.Builder, commands []gen.Command) {
+	if gen.GATTServiceUUID(commands) == "" {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/// GATT service of the characteristic-per-command mode.\n")
+	b.WriteString(fmt.Sprintf("let commandServiceUUID = \"%s\"\n", gen.GATTServiceUUID(commands)))
+	b.WriteByte('\n')
+	b.WriteString("/// GATT characteristic UUID per command, keyed by wire name.\n")
+	b.WriteString("let commandCharacteristics: [String: String] = [\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("    \"%s\": \"%s\",\n", cmd.Wire(), cmd.CharUUID))
+	}
+	b.WriteString("]\n")
+}
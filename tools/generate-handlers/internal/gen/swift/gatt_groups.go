@@ -0,0 +1,36 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeSwiftGattGroups(gctx *gen.Context, b *strings.Builder) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /// Services grouping commands apart from the RPC service, by name.\n")
+	b.WriteString("    static let services: [String: (service: CBUUID, characteristic: CBUUID)] = [\n")
+	for _, g := range gctx.GATTGroups {
+		b.WriteString(fmt.Sprintf("        \"%s\": (\n", g.Name))
+		b.WriteString(fmt.Sprintf("            CBUUID(string: \"%s\"),\n", g.ServiceUUID))
+		b.WriteString(fmt.Sprintf("            CBUUID(string: \"%s\")\n", g.CharUUID))
+		b.WriteString("        ),\n")
+	}
+	b.WriteString("    ]\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Service of each grouped command, by wire name; the others use `service`.\n")
+	b.WriteString("    static let commandServices: [String: String] = [\n")
+	for _, c := range gctx.GATTGroupCommands() {
+		b.WriteString(fmt.Sprintf("        \"%s\": \"%s\",\n", c[0], c[1]))
+	}
+	b.WriteString("    ]\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// The characteristic `command` is written to.\n")
+	b.WriteString("    static func routedCharacteristic(for command: String) -> CBUUID {\n")
+	b.WriteString("        commandServices[command].flatMap { services[$0]?.characteristic } ?? characteristic\n")
+	b.WriteString("    }\n")
+}
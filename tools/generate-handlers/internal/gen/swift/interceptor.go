@@ -0,0 +1,35 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateInstrumented returns InstrumentedClient.swift, placed next to
+// the generated client.
+func GenerateInstrumented(gctx *gen.Context, commands []gen.Command) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The schema name of the command the generated methods send as `cmdName`.\n")
+	b.WriteString("private func interceptedCommand(_ cmdName: String) -> String {\n")
+	renamed := gen.MockRenamedCommands(commands)
+	if len(renamed) == 0 {
+		b.WriteString("    cmdName\n")
+	} else {
+		b.WriteString("    switch cmdName {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("    case %s: return \"%s\"\n", gen.CallName(cmd, "swift"), cmd.Snake))
+		}
+		b.WriteString("    default: return cmdName\n")
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+
+	b.WriteString(gctx.RenderTemplate("InstrumentedClient.swift.tmpl", nil))
+	return b.String()
+}
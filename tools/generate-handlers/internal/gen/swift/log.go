@@ -0,0 +1,36 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftLogStreamHelpers emits the log reader of the Swift client
+// protocol extension.
+func writeSwiftLogStreamHelpers(b *strings.Builder, cmd gen.Command, prefix string) {
+	respCls := prefix + cmd.ResponseMsg
+	b.WriteByte('\n')
+	b.WriteString("    /// Drains the firmware log into (timestamp, entry) pairs, oldest first. The\n")
+	b.WriteString("    /// message of each entry joins the fragments of a long message, and the\n")
+	b.WriteString("    /// timestamp is when it was logged.\n")
+	b.WriteString(fmt.Sprintf("    func readLogs(minLevel: Int32 = 0, maxEntries: UInt32 = 0) async throws -> [(timestamp: Date, entry: %s)] {\n", respCls))
+	b.WriteString(fmt.Sprintf("        let responses = try await %s(minLevel: minLevel, maxEntries: maxEntries)\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("        let received = Date()\n")
+	b.WriteString(fmt.Sprintf("        var logs: [(timestamp: Date, entry: %s)] = []\n", respCls))
+	b.WriteString(fmt.Sprintf("        var first: %s?\n", respCls))
+	b.WriteString("        for resp in responses {\n")
+	b.WriteString("            if first == nil {\n")
+	b.WriteString("                first = resp\n")
+	b.WriteString("            } else {\n")
+	b.WriteString("                first!.message += resp.message\n")
+	b.WriteString("            }\n")
+	b.WriteString("            guard !resp.more, let entry = first else { continue }\n")
+	b.WriteString("            let ageMs = entry.nowMs &- entry.uptimeMs\n")
+	b.WriteString("            logs.append((received.addingTimeInterval(-Double(ageMs) / 1000), entry))\n")
+	b.WriteString("            first = nil\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return logs\n")
+	b.WriteString("    }\n")
+}
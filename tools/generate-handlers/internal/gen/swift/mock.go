@@ -0,0 +1,139 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateMock returns MockGeneratedClient.swift, placed next to the
+// generated client.
+func GenerateMock(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	var b strings.Builder
+	renamed := gen.MockRenamedCommands(commands)
+	_, auth, sessions := gen.SessionCommands(commands)
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteString("import SwiftProtobuf\n")
+	b.WriteByte('\n')
+	b.WriteString("/// A call recorded by " + gctx.SwiftType("MockGeneratedClient") + ": the command and its decoded\n")
+	b.WriteString("/// requests, one per message of a C→P stream.\n")
+	b.WriteString("struct MockCall {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let requests: [any SwiftProtobuf.Message]\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Stand-in for BlerpcClient in app unit tests, with no peripheral. Records\n")
+	b.WriteString("/// every call in `calls` and answers it with the responses set for its\n")
+	b.WriteString("/// command. Commands without any get an empty response, and P→C streams no\n")
+	b.WriteString("/// responses.\n")
+	b.WriteString("final class " + gctx.SwiftType("MockGeneratedClient") + ": " + gctx.SwiftType("GeneratedClientProtocol") + ", @unchecked Sendable {\n")
+	b.WriteString("    typealias Answer = ([any SwiftProtobuf.Message]) throws -> [any SwiftProtobuf.Message]\n")
+	b.WriteByte('\n')
+	b.WriteString("    let callSerializer = CallSerializer()\n")
+	if sessions {
+		b.WriteString("    /// Any key does: the mock answers the session handshake itself.\n")
+		b.WriteString(fmt.Sprintf("    let session = BlerpcSession(key: Data(count: %d))\n", gen.SessionProofSize))
+	}
+	b.WriteString("    private var answers: [String: Answer] = [:]\n")
+	b.WriteString("    private var recorded: [MockCall] = []\n")
+	b.WriteString("    private let lock = NSLock()\n")
+	b.WriteByte('\n')
+	b.WriteString("    init() {}\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// The calls made so far, in order.\n")
+	b.WriteString("    var calls: [MockCall] {\n")
+	b.WriteString("        lock.lock()\n")
+	b.WriteString("        defer { lock.unlock() }\n")
+	b.WriteString("        return recorded\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Answers `command` with `responses`: a unary or C→P call gets the first,\n")
+	b.WriteString("    /// a P→C stream each of them.\n")
+	b.WriteString("    func respond(_ command: String, with responses: any SwiftProtobuf.Message...) {\n")
+	b.WriteString("        respond(command) { _ in responses }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Answers `command` with the responses `answer` returns for its requests.\n")
+	b.WriteString("    func respond(_ command: String, answer: @escaping Answer) {\n")
+	b.WriteString("        lock.lock()\n")
+	b.WriteString("        defer { lock.unlock() }\n")
+	b.WriteString("        answers[command] = answer\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Fails calls of `command` with `error`, e.g. a NotFoundError.\n")
+	b.WriteString("    func fail(_ command: String, with error: Error) {\n")
+	b.WriteString("        respond(command) { _ in throw error }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func call(cmdName: String, requestData: Data) async throws -> Data {\n")
+	b.WriteString("        let (command, responses) = try answer(cmdName, [requestData])\n")
+	b.WriteString("        return try (responses?.first ?? emptyResponse(command)).serializedData()\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {\n")
+	b.WriteString("        let (_, responses) = try answer(cmdName, [requestData])\n")
+	b.WriteString("        return try (responses ?? []).map { try $0.serializedData() }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {\n")
+	b.WriteString("        let (command, responses) = try answer(cmdName, messages)\n")
+	b.WriteString("        return try (responses?.first ?? emptyResponse(command)).serializedData()\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Records a call and returns its command with the responses set for it.\n")
+	b.WriteString("    private func answer(_ cmdName: String, _ requests: [Data]) throws -> (String, [any SwiftProtobuf.Message]?) {\n")
+	if len(renamed) > 0 {
+		b.WriteString("        let command: String\n")
+		b.WriteString("        switch cmdName {\n")
+		for _, cmd := range renamed {
+			b.WriteString(fmt.Sprintf("        case %s: command = \"%s\"\n", gen.CallName(cmd, "swift"), cmd.Snake))
+		}
+		b.WriteString("        default: command = cmdName\n")
+		b.WriteString("        }\n")
+	} else {
+		b.WriteString("        let command = cmdName\n")
+	}
+	b.WriteString("        let decoded = try requests.map { try decodeRequest(command, $0) }\n")
+	b.WriteString("        lock.lock()\n")
+	b.WriteString("        recorded.append(MockCall(command: command, requests: decoded))\n")
+	b.WriteString("        let answer = answers[command]\n")
+	b.WriteString("        lock.unlock()\n")
+	b.WriteString("        return (command, try answer?(decoded))\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private func decodeRequest(_ command: String, _ data: Data) throws -> any SwiftProtobuf.Message {\n")
+	b.WriteString("        switch command {\n")
+	for _, cmd := range commands {
+		data := "data"
+		if n := gen.MockRequestPrefix(cmd); n > 0 {
+			data = fmt.Sprintf("data.dropFirst(%d)", n)
+		}
+		b.WriteString(fmt.Sprintf("        case \"%s\": return try %s%s(serializedBytes: %s)\n", cmd.Snake, prefix, cmd.RequestMsg, data))
+	}
+	b.WriteString("        default: throw TransportError(message: \"unknown command \\(command)\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private func emptyResponse(_ command: String) -> any SwiftProtobuf.Message {\n")
+	b.WriteString("        switch command {\n")
+	for _, cmd := range commands {
+		if sessions && cmd.Snake == auth.Snake {
+			b.WriteString(fmt.Sprintf("        case \"%s\":\n", cmd.Snake))
+			b.WriteString("            // A token of the size peripherals issue opens the session.\n")
+			b.WriteString(fmt.Sprintf("            var resp = %s%s()\n", prefix, cmd.ResponseMsg))
+			b.WriteString(fmt.Sprintf("            resp.token = Data(count: %d)\n", gen.SessionTokenSize))
+			b.WriteString("            return resp\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("        case \"%s\": return %s%s()\n", cmd.Snake, prefix, cmd.ResponseMsg))
+	}
+	b.WriteString("        default: preconditionFailure(\"unknown command \\(command)\")\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The Objective-C client of Swift (ObjCClient.swift, with swift_objc_client:
+// true in blerpc.yaml) is for app code that cannot call the async methods
+// of the GeneratedClientProtocol extension. ObjCClient wraps any client
+// and has a method per command taking the serialized request message and
+// completing with the serialized response or an NSError, since SwiftProtobuf
+// messages do not cross into Objective-C; callers encode and decode them
+// with a protobuf runtime of their own, e.g. GPBMessage. P→C streams
+// complete with the array of responses and C→P streams take an array of
+// requests. Deprecated aliases of renamed commands are left out.
+
+// swiftObjCArgs returns the arguments passing the fields of the parsed
+// request req to the Swift client method of cmd.
+func swiftObjCArgs(cmd gen.Command) []string {
+	var args []string
+	seen := make(map[string]bool)
+	for _, f := range cmd.RequestFields {
+		prop := gen.SwiftPropertyName(f.Name)
+		switch {
+		case f.Oneof != "":
+			if !seen[f.Oneof] {
+				seen[f.Oneof] = true
+				oneof := gen.SwiftPropertyName(f.Oneof)
+				args = append(args, fmt.Sprintf("%s: req.%s", oneof, oneof))
+			}
+		case gen.NullableField(f):
+			args = append(args, fmt.Sprintf("%s: req.has%s ? req.%s : nil", prop, gen.UpperCamel(prop), prop))
+		default:
+			value := "req." + prop
+			if o, ok := gen.FieldTypeOverride(f, "swift"); ok {
+				value = gen.ApplyConverter(o.Decode, value)
+			}
+			args = append(args, fmt.Sprintf("%s: %s", prop, value))
+		}
+	}
+	return args
+}
+
+// writeSwiftObjCParse emits the parsing of the serialized request of cmd.
+// A request without fields is still parsed, so malformed data fails the
+// same way.
+func writeSwiftObjCParse(b *strings.Builder, cmd gen.Command, reqCls string) {
+	if len(cmd.RequestFields) == 0 {
+		b.WriteString(fmt.Sprintf("            _ = try %s(serializedBytes: request)\n", reqCls))
+		return
+	}
+	b.WriteString(fmt.Sprintf("            let req = try %s(serializedBytes: request)\n", reqCls))
+}
+
+// GenerateObjCClient returns ObjCClient.swift, placed next to the
+// generated client.
+func GenerateObjCClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteString("import SwiftProtobuf\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The commands of a generated client for Objective-C. Each takes the\n")
+	b.WriteString("/// serialized request message and calls `completion`, on no particular\n")
+	b.WriteString("/// queue, with the serialized response or the error. Create it in Swift\n")
+	b.WriteString("/// around the client and hand it to Objective-C code, e.g.\n")
+	b.WriteString("///\n")
+	if len(commands) > 0 {
+		b.WriteString(fmt.Sprintf("///     [objcClient %s:[request data] completion:^(NSData *data, NSError *error) { ... }];\n", gen.ToLowerCamel(commands[0].Camel)))
+	}
+	b.WriteString(fmt.Sprintf("@objc(%sObjCClient)\n", gen.UpperCamel(pkg)))
+	b.WriteString("final class ObjCClient: NSObject {\n")
+	b.WriteString("    private let client: any " + gctx.SwiftType("GeneratedClientProtocol") + "\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(client: any " + gctx.SwiftType("GeneratedClientProtocol") + ") {\n")
+	b.WriteString("        self.client = client\n")
+	b.WriteString("    }\n")
+
+	for _, cmd := range commands {
+		dir := streaming[cmd.Snake]
+		reqCls := prefix + cmd.RequestMsg
+		method := gen.ToLowerCamel(cmd.Camel)
+		b.WriteByte('\n')
+		b.WriteString(Deprecation(cmd, dir != "c2p"))
+		switch dir {
+		case "c2p":
+			b.WriteString(fmt.Sprintf("    @objc func %s(_ requests: [Data], completion: @escaping @Sendable (Data?, Error?) -> Void) {\n", method))
+			b.WriteString("        run(completion) { [client] in\n")
+			b.WriteString(fmt.Sprintf("            let messages = try requests.map { try %s(serializedBytes: $0) }\n", reqCls))
+			b.WriteString(fmt.Sprintf("            return try await client.%s(messages: messages).serializedData()\n", method))
+		case "p2c":
+			b.WriteString(fmt.Sprintf("    @objc func %s(_ request: Data, completion: @escaping @Sendable ([Data]?, Error?) -> Void) {\n", method))
+			b.WriteString("        run(completion) { [client] in\n")
+			writeSwiftObjCParse(&b, cmd, reqCls)
+			b.WriteString(fmt.Sprintf("            return try await client.%s(%s).map { try $0.serializedData() }\n", method, strings.Join(swiftObjCArgs(cmd), ", ")))
+		default:
+			b.WriteString(fmt.Sprintf("    @objc func %s(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {\n", method))
+			b.WriteString("        run(completion) { [client] in\n")
+			writeSwiftObjCParse(&b, cmd, reqCls)
+			b.WriteString(fmt.Sprintf("            return try await client.%s(%s).serializedData()\n", method, strings.Join(swiftObjCArgs(cmd), ", ")))
+		}
+		b.WriteString("        }\n")
+		b.WriteString("    }\n")
+	}
+
+	b.WriteByte('\n')
+	b.WriteString("    /// Runs `body` in a task and completes with its result or error.\n")
+	b.WriteString("    private func run<T>(\n")
+	b.WriteString("        _ completion: @escaping @Sendable (T?, Error?) -> Void,\n")
+	b.WriteString("        _ body: @escaping @Sendable () async throws -> T\n")
+	b.WriteString("    ) {\n")
+	b.WriteString("        Task {\n")
+	b.WriteString("            do {\n")
+	b.WriteString("                completion(try await body(), nil)\n")
+	b.WriteString("            } catch {\n")
+	b.WriteString("                completion(nil, error)\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateSwiftObjCClient(t *testing.T) {
+	gctx := gen.NewContext()
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	empty := gentest.EchoCommand()
+	empty.Camel, empty.Snake, empty.RequestMsg, empty.RequestFields = "Reset", "reset", "ResetRequest", nil
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.OptionalCommand(), gentest.OneofCommand(), empty, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	out := GenerateObjCClient(gctx, cmds, streaming, "blerpc")
+
+	for _, want := range []string{
+		"@objc(BlerpcObjCClient)\nfinal class ObjCClient: NSObject {\n",
+		"    @objc func echo(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {\n" +
+			"        run(completion) { [client] in\n" +
+			"            let req = try Blerpc_EchoRequest(serializedBytes: request)\n" +
+			"            return try await client.echo(message: req.message).serializedData()\n",
+		"client.list(limit: req.hasLimit ? req.limit : nil, offset: req.offset)",
+		"client.search(limit: req.limit, query: req.query)",
+		"            _ = try Blerpc_ResetRequest(serializedBytes: request)\n            return try await client.reset().serializedData()\n",
+		"    @objc func counterStream(_ request: Data, completion: @escaping @Sendable ([Data]?, Error?) -> Void) {\n",
+		"client.counterStream(start: req.start).map { try $0.serializedData() }",
+		"    @objc func counterUpload(_ requests: [Data], completion: @escaping @Sendable (Data?, Error?) -> Void) {\n",
+		"            let messages = try requests.map { try Blerpc_CounterUploadRequest(serializedBytes: $0) }\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q\nGot:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// swiftParams renders the parameters of a method taking the fields of msg.
+func swiftParams(fields []gen.Field, msg, prefix string) []string {
+	var params []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		switch {
+		case f.Oneof == "":
+			params = append(params, swiftParam(f, prefix))
+		case !seen[f.Oneof]:
+			seen[f.Oneof] = true
+			params = append(params, fmt.Sprintf("%s: %s%s.OneOf_%s? = nil",
+				gen.SwiftPropertyName(f.Oneof), prefix, msg, gen.UpperCamel(f.Oneof)))
+		}
+	}
+	return params
+}
+
+// writeSwiftSetters writes the assignments that fill req from the method
+// parameters.
+func writeSwiftSetters(b *strings.Builder, fields []gen.Field) {
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if gen.NullableField(f) {
+			propName := gen.SwiftPropertyName(f.Name)
+			b.WriteString(fmt.Sprintf("        if let %s { req.%s = %s }\n", propName, propName, propName))
+		} else if f.Oneof == "" {
+			propName := gen.SwiftPropertyName(f.Name)
+			b.WriteString(fmt.Sprintf("        req.%s = %s\n", propName, gen.EncodeValue(f, "swift", propName)))
+		} else if !seen[f.Oneof] {
+			seen[f.Oneof] = true
+			propName := gen.SwiftPropertyName(f.Oneof)
+			b.WriteString(fmt.Sprintf("        req.%s = %s\n", propName, propName))
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftPingHelpers emits the latency and keepalive helpers of the Swift
+// client protocol extension.
+func writeSwiftPingHelpers(b *strings.Builder, cmd gen.Command) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /// Pings the peripheral and returns the round trip in seconds.\n")
+	b.WriteString("    func measureLatency() async throws -> TimeInterval {\n")
+	b.WriteString("        let start = DispatchTime.now().uptimeNanoseconds\n")
+	b.WriteString(fmt.Sprintf("        _ = try await %s()\n", method))
+	b.WriteString("        return TimeInterval(DispatchTime.now().uptimeNanoseconds - start) / 1_000_000_000\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Pings the peripheral every `interval` seconds until the task is\n")
+	b.WriteString("    /// cancelled, passing each round trip to `onLatency`. Run it in a task next\n")
+	b.WriteString("    /// to the calls: it throws as soon as a ping fails, so a dead connection\n")
+	b.WriteString("    /// shows before a long call times out.\n")
+	b.WriteString("    func keepalive(interval: TimeInterval = 5, onLatency: (TimeInterval) -> Void = { _ in }) async throws {\n")
+	b.WriteString("        while true {\n")
+	b.WriteString("            onLatency(try await measureLatency())\n")
+	b.WriteString("            try await Task.sleep(nanoseconds: UInt64(interval * 1_000_000_000))\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+}
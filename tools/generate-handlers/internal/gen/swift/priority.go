@@ -0,0 +1,42 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GeneratePriority returns PriorityClient.swift, placed next to the
+// generated client.
+func GeneratePriority(gctx *gen.Context, commands []gen.Command, streaming map[string]string) string {
+	prioritized := gen.PrioritizedCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Order in which a PriorityClient runs waiting calls.\n")
+	b.WriteString("enum Priority: Int, Comparable, Sendable {\n")
+	b.WriteString("    case " + strings.Join(gen.PriorityNames, ", ") + "\n")
+	b.WriteByte('\n')
+	b.WriteString("    static func < (a: Priority, b: Priority) -> Bool { a.rawValue < b.rawValue }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Priority of the commands not run at .normal, by the names their calls\n")
+	b.WriteString("/// are sent by.\n")
+	if len(prioritized) == 0 {
+		b.WriteString("let commandPriorities: [String: Priority] = [:]\n")
+	} else {
+		b.WriteString("let commandPriorities: [String: Priority] = [\n")
+		for _, cmd := range prioritized {
+			for _, name := range gen.CacheCallNames(cmd, "swift") {
+				b.WriteString(fmt.Sprintf("    %s: .%s,\n", name, cmd.Priority))
+			}
+		}
+		b.WriteString("]\n")
+	}
+
+	b.WriteString(gctx.RenderTemplate("PriorityClient.swift.tmpl", gen.PriorityData{ServerStreams: gen.HasServerStreams(commands, streaming)}))
+	return b.String()
+}
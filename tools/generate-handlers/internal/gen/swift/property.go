@@ -0,0 +1,30 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftProperties emits the property accessors of the
+// GeneratedClientProtocol extension.
+func writeSwiftProperties(b *strings.Builder, commands []gen.Command, prefix string) {
+	for _, get := range commands {
+		set, ok := gen.PropertySetter(get, commands)
+		if get.Property == "" || !ok {
+			continue
+		}
+		g, s := get.ResponseFields[0], set.RequestFields[0]
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    /// The %s read with `%s`; `%s(_:)` writes it.\n", g.Name, gen.ToLowerCamel(get.Camel), gen.ToLowerCamel(set.Camel)))
+		b.WriteString(fmt.Sprintf("    var %s: %s {\n", gen.SwiftPropertyName(get.Property), ResolveType(g, prefix)))
+		b.WriteString(fmt.Sprintf("        get async throws { try await %s().%s }\n", gen.ToLowerCamel(get.Camel), gen.SwiftPropertyName(g.Name)))
+		b.WriteString("    }\n")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    /// Writes the %s with `%s`.\n", s.Name, gen.ToLowerCamel(set.Camel)))
+		b.WriteString(fmt.Sprintf("    func %s(_ value: %s) async throws {\n", gen.ToLowerCamel(set.Camel), ResolveType(s, prefix)))
+		b.WriteString(fmt.Sprintf("        _ = try await %s(%s: value)\n", gen.ToLowerCamel(set.Camel), gen.SwiftPropertyName(s.Name)))
+		b.WriteString("    }\n")
+	}
+}
@@ -0,0 +1,133 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateQueue returns OfflineQueue.swift, placed next to the generated
+// client.
+func GenerateQueue(gctx *gen.Context, commands []gen.Command, pkg string) string {
+	prefix := gctx.SwiftPrefix(pkg)
+	queueable := gen.QueueableCommands(commands)
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Time to live in seconds of each queueable command.\n")
+	if len(queueable) == 0 {
+		b.WriteString("let queueableCommands: [String: TimeInterval] = [:]\n")
+	} else {
+		b.WriteString("let queueableCommands: [String: TimeInterval] = [\n")
+		for _, cmd := range queueable {
+			b.WriteString(fmt.Sprintf("    %q: %d,\n", cmd.Wire(), cmd.QueueTTL))
+		}
+		b.WriteString("]\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/// A call waiting in an OfflineQueue.\n")
+	b.WriteString("struct QueuedCall: Codable, Equatable {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let request: Data\n")
+	b.WriteString("    let expiresAt: Date\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The queued call expired before a connection came up.\n")
+	b.WriteString("struct QueuedCallExpiredError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Persists the calls of an OfflineQueue.\n")
+	b.WriteString("protocol QueueStore {\n")
+	b.WriteString("    func load() throws -> [QueuedCall]\n")
+	b.WriteString("    func save(_ calls: [QueuedCall]) throws\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Keeps the queue in a file, e.g. in the application support directory.\n")
+	b.WriteString("struct FileQueueStore: QueueStore {\n")
+	b.WriteString("    let url: URL\n")
+	b.WriteByte('\n')
+	b.WriteString("    func load() throws -> [QueuedCall] {\n")
+	b.WriteString("        guard FileManager.default.fileExists(atPath: url.path) else { return [] }\n")
+	b.WriteString("        return try JSONDecoder().decode([QueuedCall].self, from: Data(contentsOf: url))\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func save(_ calls: [QueuedCall]) throws {\n")
+	b.WriteString("        try JSONEncoder().encode(calls).write(to: url, options: .atomic)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Persistent queue of calls made while the device is out of reach.\n")
+	b.WriteString("///\n")
+	b.WriteString("/// Call flush after connecting. Calls are sent in the order they were queued\n")
+	b.WriteString("/// and never after they expire. A call failing without an answer from the\n")
+	b.WriteString("/// peripheral stops the flush and stays queued; one rejected with a\n")
+	b.WriteString("/// RemoteError is reported and dropped.\n")
+	b.WriteString("actor OfflineQueue {\n")
+	b.WriteString("    private let store: any QueueStore\n")
+	b.WriteString("    private let now: () -> Date\n")
+	b.WriteString("    private var calls: [QueuedCall]\n")
+	b.WriteString("    private var flushing = false\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(store: any QueueStore, now: @escaping () -> Date = Date.init) throws {\n")
+	b.WriteString("        self.store = store\n")
+	b.WriteString("        self.now = now\n")
+	b.WriteString("        calls = try store.load()\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    var count: Int { calls.count }\n")
+	for _, cmd := range queueable {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    func enqueue%s(_ request: %s%s) throws {\n", cmd.Camel, prefix, cmd.RequestMsg))
+		b.WriteString(fmt.Sprintf("        try enqueue(command: %q, request: request.serializedData())\n", cmd.Wire()))
+		b.WriteString("    }\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("    /// Sends the queued calls through `client` and passes each outcome to\n")
+	b.WriteString("    /// `onResult`; expired calls fail with QueuedCallExpiredError. Returns the\n")
+	b.WriteString("    /// number of calls left, right away if another flush is running.\n")
+	b.WriteString("    @discardableResult\n")
+	b.WriteString("    func flush(\n")
+	b.WriteString("        client: any " + gctx.SwiftType("GeneratedClientProtocol") + ",\n")
+	b.WriteString("        onResult: (QueuedCall, Result<Data, Error>) -> Void = { _, _ in }\n")
+	b.WriteString("    ) async throws -> Int {\n")
+	b.WriteString("        guard !flushing else { return calls.count }\n")
+	b.WriteString("        flushing = true\n")
+	b.WriteString("        defer { flushing = false }\n")
+	b.WriteString("        while let call = calls.first {\n")
+	b.WriteString("            let result: Result<Data, Error>\n")
+	b.WriteString("            if now() >= call.expiresAt {\n")
+	b.WriteString("                result = .failure(QueuedCallExpiredError(command: call.command))\n")
+	b.WriteString("            } else {\n")
+	b.WriteString("                do {\n")
+	b.WriteString("                    let data = try await client.exclusive {\n")
+	b.WriteString("                        try await client.call(cmdName: call.command, requestData: call.request)\n")
+	b.WriteString("                    }\n")
+	b.WriteString("                    result = .success(data)\n")
+	b.WriteString("                } catch let error as any RemoteError {\n")
+	b.WriteString("                    result = .failure(error)\n")
+	b.WriteString("                } catch {\n")
+	b.WriteString("                    if error is CancellationError {\n")
+	b.WriteString("                        throw error\n")
+	b.WriteString("                    }\n")
+	b.WriteString("                    return calls.count\n")
+	b.WriteString("                }\n")
+	b.WriteString("            }\n")
+	b.WriteString("            calls.removeFirst()\n")
+	b.WriteString("            try store.save(calls)\n")
+	b.WriteString("            onResult(call, result)\n")
+	b.WriteString("        }\n")
+	b.WriteString("        return 0\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private func enqueue(command: String, request: Data) throws {\n")
+	b.WriteString("        let ttl = queueableCommands[command] ?? 0\n")
+	b.WriteString("        calls.append(QueuedCall(command: command, request: request, expiresAt: now().addingTimeInterval(ttl)))\n")
+	b.WriteString("        try store.save(calls)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// swiftRedactedField returns the statements appending field f to the parts
+// of redactedDescription, reached through self.
+func swiftRedactedField(f gen.Field) string {
+	prop := gen.SwiftPropertyName(f.Name)
+	if f.Oneof != "" {
+		oneof := gen.SwiftPropertyName(f.Oneof)
+		if f.Sensitive {
+			return fmt.Sprintf("if case .%s? = %s { fields.append(\"%s: \\(redactedPlaceholder)\") }", prop, oneof, f.Name)
+		}
+		value := "\\(value)"
+		if f.IsMessage {
+			value = "\\(redacted(value))"
+		}
+		return fmt.Sprintf("if case let .%s(value)? = %s { fields.append(\"%s: %s\") }", prop, oneof, f.Name, value)
+	}
+	var value string
+	switch {
+	case f.Sensitive:
+		value = "\\(redactedPlaceholder)"
+	case f.IsMap && f.ValueIsMessage:
+		value = fmt.Sprintf("\\(%s.mapValues { redacted($0) })", prop)
+	case f.IsRepeated && f.IsMessage:
+		value = fmt.Sprintf("\\(%s.map { redacted($0) })", prop)
+	case f.IsMessage && !f.IsMap:
+		value = fmt.Sprintf("\\(redacted(%s))", prop)
+	default:
+		value = fmt.Sprintf("\\(%s)", prop)
+	}
+	add := fmt.Sprintf("fields.append(\"%s: %s\")", f.Name, value)
+	if f.IsOptional || (f.IsMessage && !f.IsRepeated && !f.IsMap) {
+		return fmt.Sprintf("if has%s { %s }", gen.UpperCamel(f.Name), add)
+	}
+	return add
+}
+
+// GenerateRedaction returns Redaction.swift, next to the Swift client.
+func GenerateRedaction(gctx *gen.Context, messages map[string]gen.Message, pkg string) string {
+	var b strings.Builder
+
+	b.WriteString(gctx.RenderTemplate("Redaction.swift.tmpl", nil))
+
+	prefix := gctx.SwiftPrefix(pkg)
+	sensitive := gen.SensitiveMessages(messages)
+	b.WriteByte('\n')
+	b.WriteString("/// Returns `message` with the fields marked (blerpc.sensitive) shown as\n")
+	b.WriteString("/// <redacted>, e.g. `LoginRequest(user: ann, password: <redacted>)`.\n")
+	b.WriteString("/// Messages without such fields, directly or in a message field, keep\n")
+	b.WriteString("/// their own description.\n")
+	b.WriteString("func redacted(_ message: any SwiftProtobuf.Message) -> String {\n")
+	if len(sensitive) == 0 {
+		b.WriteString("    String(describing: message)\n")
+		b.WriteString("}\n")
+		return b.String()
+	}
+	b.WriteString("    switch message {\n")
+	for _, m := range sensitive {
+		b.WriteString(fmt.Sprintf("    case let message as %s:\n", gen.MessageTypeName(m.Name, m.File, "swift", prefix)))
+		b.WriteString("        return message.redactedDescription\n")
+	}
+	b.WriteString("    default:\n")
+	b.WriteString("        return String(describing: message)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	for _, m := range sensitive {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("extension %s {\n", gen.MessageTypeName(m.Name, m.File, "swift", prefix)))
+		b.WriteString(fmt.Sprintf("    /// This %s with its sensitive fields redacted.\n", m.Name))
+		b.WriteString("    var redactedDescription: String {\n")
+		b.WriteString("        var fields: [String] = []\n")
+		for _, f := range m.Fields {
+			b.WriteString("        " + swiftRedactedField(f) + "\n")
+		}
+		b.WriteString(fmt.Sprintf("        return \"%s(\" + fields.joined(separator: \", \") + \")\"\n", m.Name))
+		b.WriteString("    }\n")
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
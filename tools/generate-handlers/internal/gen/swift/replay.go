@@ -0,0 +1,33 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftReplayCounter emits the counter of replay-protected requests.
+func writeSwiftReplayCounter(b *strings.Builder) {
+	b.WriteString("/// Leads replay-protected requests with a counter, 8 bytes little endian,\n")
+	b.WriteString("/// and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of\n")
+	b.WriteString("/// the command name, a NUL, the counter and the request. The peripheral drops\n")
+	b.WriteString("/// requests whose MAC is wrong or whose counter is not above the last one it\n")
+	b.WriteString("/// accepted; microseconds since the epoch keep it increasing across restarts.\n")
+	b.WriteString("enum ReplayCounter {\n")
+	b.WriteString("    private static var last: UInt64 = 0\n")
+	b.WriteString("    private static let lock = NSLock()\n")
+	b.WriteByte('\n')
+	b.WriteString("    static func prefix(key: Data, command: String, _ requestData: Data) -> Data {\n")
+	b.WriteString("        lock.lock()\n")
+	b.WriteString("        defer { lock.unlock() }\n")
+	b.WriteString("        last = max(last + 1, UInt64(Date().timeIntervalSince1970 * 1_000_000))\n")
+	b.WriteString("        var value = last.littleEndian\n")
+	b.WriteString("        let counter = Data(bytes: &value, count: 8)\n")
+	b.WriteString("        let mac = HMAC<SHA256>.authenticationCode(\n")
+	b.WriteString("            for: Data(command.utf8) + [0] + counter + requestData, using: SymmetricKey(data: key))\n")
+	b.WriteString(fmt.Sprintf("        return counter + Data(mac).prefix(%d) + requestData\n", gen.ReplayMACSize))
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,118 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateResume returns ResumingClient.swift, placed next to the
+// generated client.
+func GenerateResume(gctx *gen.Context, commands []gen.Command) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Commands that are safe to run twice; retried after a reconnect.\n")
+	names := gen.IdempotentCallNames(commands, "swift")
+	if len(names) == 0 {
+		b.WriteString("let idempotentCommands: Set<String> = []\n")
+	} else {
+		b.WriteString("let idempotentCommands: Set<String> = [\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("    %s,\n", name))
+		}
+		b.WriteString("]\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("/// The connection dropped while a non-idempotent call was in flight. The\n")
+	b.WriteString("/// peripheral may or may not have run the command, so it is not retried.\n")
+	b.WriteString("struct CallInterruptedError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let underlying: Error\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// App-level policy of ResumingClient. Subclass to override.\n")
+	b.WriteString("class ResumePolicy {\n")
+	b.WriteString("    let maxRetries: Int\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(maxRetries: Int = 1) {\n")
+	b.WriteString("        self.maxRetries = maxRetries\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Whether to reconnect and retry `command` after its `attempt`-th failure.\n")
+	b.WriteString("    func shouldRetry(command: String, attempt: Int, error: Error) -> Bool {\n")
+	b.WriteString("        idempotentCommands.contains(command) && attempt <= maxRetries\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Recovers calls of `client` cut off by a dropped connection.\n")
+	b.WriteString("///\n")
+	b.WriteString("/// Calls made while disconnected run `reconnect` first. Interrupted idempotent\n")
+	b.WriteString("/// calls are retried as `policy` allows; other interrupted calls throw\n")
+	b.WriteString("/// CallInterruptedError. A failure while `isConnected` still holds is thrown\n")
+	b.WriteString("/// unchanged.\n")
+	b.WriteString("final class ResumingClient: " + gctx.SwiftType("GeneratedClientProtocol") + ", @unchecked Sendable {\n")
+	b.WriteString("    private let client: any " + gctx.SwiftType("GeneratedClientProtocol") + "\n")
+	b.WriteString("    private let isConnected: () -> Bool\n")
+	b.WriteString("    private let reconnect: () async throws -> Void\n")
+	b.WriteString("    private let policy: ResumePolicy\n")
+	b.WriteString("    let callSerializer = CallSerializer()\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(\n")
+	b.WriteString("        client: any " + gctx.SwiftType("GeneratedClientProtocol") + ",\n")
+	b.WriteString("        isConnected: @escaping () -> Bool,\n")
+	b.WriteString("        reconnect: @escaping () async throws -> Void,\n")
+	b.WriteString("        policy: ResumePolicy = ResumePolicy()\n")
+	b.WriteString("    ) {\n")
+	b.WriteString("        self.client = client\n")
+	b.WriteString("        self.isConnected = isConnected\n")
+	b.WriteString("        self.reconnect = reconnect\n")
+	b.WriteString("        self.policy = policy\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func call(cmdName: String, requestData: Data) async throws -> Data {\n")
+	b.WriteString("        try await resume(cmdName) { try await self.client.call(cmdName: cmdName, requestData: requestData) }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {\n")
+	b.WriteString("        try await resume(cmdName) {\n")
+	b.WriteString("            try await self.client.streamReceive(cmdName: cmdName, requestData: requestData)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {\n")
+	b.WriteString("        try await resume(cmdName) {\n")
+	b.WriteString("            try await self.client.streamSend(cmdName: cmdName, messages: messages, finalCmdName: finalCmdName)\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    var capabilityFlags: Int { client.capabilityFlags }\n")
+	b.WriteByte('\n')
+	b.WriteString("    private func resume<T>(_ command: String, _ body: () async throws -> T) async throws -> T {\n")
+	b.WriteString("        var attempt = 0\n")
+	b.WriteString("        while true {\n")
+	b.WriteString("            if !isConnected() {\n")
+	b.WriteString("                try await reconnect()\n")
+	b.WriteString("            }\n")
+	b.WriteString("            do {\n")
+	b.WriteString("                return try await client.exclusive(body)\n")
+	b.WriteString("            } catch {\n")
+	b.WriteString("                if error is CancellationError || isConnected() {\n")
+	b.WriteString("                    throw error\n")
+	b.WriteString("                }\n")
+	b.WriteString("                attempt += 1\n")
+	b.WriteString("                if !policy.shouldRetry(command: command, attempt: attempt, error: error) {\n")
+	b.WriteString("                    if idempotentCommands.contains(command) {\n")
+	b.WriteString("                        throw error\n")
+	b.WriteString("                    }\n")
+	b.WriteString("                    throw CallInterruptedError(command: command, underlying: error)\n")
+	b.WriteString("                }\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
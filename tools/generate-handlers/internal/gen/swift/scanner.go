@@ -0,0 +1,68 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateScanner returns GeneratedScanner.swift, placed next to the
+// generated client. It works on the ScannedDevice records of the app's
+// BleTransport.
+func GenerateScanner(gctx *gen.Context, hasAdvs bool) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import CoreBluetooth\n")
+	b.WriteByte('\n')
+	b.WriteString("/// A blerpc peripheral found by a scan; pass `device` to connect.\n")
+	b.WriteString("struct DiscoveredDevice: Identifiable {\n")
+	b.WriteString("    let device: ScannedDevice\n")
+	if hasAdvs {
+		b.WriteString("    /// The decoded (blerpc.advertising) message, if the device sent one.\n")
+		b.WriteString("    let advertisement: GeneratedAdvertisement?\n")
+	}
+	b.WriteByte('\n')
+	b.WriteString("    var id: UUID { device.id }\n")
+	b.WriteString("    var name: String? { device.name }\n")
+	b.WriteString("    var rssi: Int { device.rssi }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Picks the blerpc peripherals out of scan results.\n")
+	b.WriteString("enum GeneratedScanner {\n")
+	b.WriteString("    /// Service UUID advertised by blerpc peripherals.\n")
+	b.WriteString(fmt.Sprintf("    static let serviceUUID = CBUUID(string: \"%s\")\n", gctx.ServiceUUID))
+	b.WriteByte('\n')
+	b.WriteString("    /// Returns `device` as a DiscoveredDevice, or nil if it is not a blerpc peripheral.\n")
+	if hasAdvs {
+		b.WriteString("    static func discover(_ device: ScannedDevice, requireAdvertisement: Bool = false) -> DiscoveredDevice? {\n")
+	} else {
+		b.WriteString("    static func discover(_ device: ScannedDevice) -> DiscoveredDevice? {\n")
+	}
+	b.WriteString("        guard device.serviceUUIDs.contains(serviceUUID) else {\n")
+	b.WriteString("            return nil\n")
+	b.WriteString("        }\n")
+	if hasAdvs {
+		b.WriteString("        let advertisement = device.manufacturerData.flatMap(GeneratedAdvertisement.init(manufacturerData:))\n")
+		b.WriteString("        if requireAdvertisement && advertisement == nil {\n")
+		b.WriteString("            return nil\n")
+		b.WriteString("        }\n")
+		b.WriteString("        return DiscoveredDevice(device: device, advertisement: advertisement)\n")
+	} else {
+		b.WriteString("        return DiscoveredDevice(device: device)\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Returns the blerpc peripherals among `devices`, strongest first.\n")
+	if hasAdvs {
+		b.WriteString("    static func discoverAll(_ devices: [ScannedDevice], requireAdvertisement: Bool = false) -> [DiscoveredDevice] {\n")
+		b.WriteString("        devices.compactMap { discover($0, requireAdvertisement: requireAdvertisement) }.sorted { $0.rssi > $1.rssi }\n")
+	} else {
+		b.WriteString("    static func discoverAll(_ devices: [ScannedDevice]) -> [DiscoveredDevice] {\n")
+		b.WriteString("        devices.compactMap { discover($0) }.sorted { $0.rssi > $1.rssi }\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftSession emits the session state a Swift client keeps.
+func writeSwiftSession(b *strings.Builder) {
+	b.WriteString("/// Session state of a client: the key shared with the peripheral, which the\n")
+	b.WriteString("/// session handshake proves knowledge of, and the token of the open session.\n")
+	b.WriteString("final class BlerpcSession: @unchecked Sendable {\n")
+	b.WriteString("    let key: Data\n")
+	b.WriteString("    private var openToken: Data?\n")
+	b.WriteString("    private let lock = NSLock()\n")
+	b.WriteByte('\n')
+	b.WriteString("    init(key: Data) {\n")
+	b.WriteString("        self.key = key\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    var token: Data? {\n")
+	b.WriteString("        get {\n")
+	b.WriteString("            lock.lock()\n")
+	b.WriteString("            defer { lock.unlock() }\n")
+	b.WriteString("            return openToken\n")
+	b.WriteString("        }\n")
+	b.WriteString("        set {\n")
+	b.WriteString("            lock.lock()\n")
+	b.WriteString("            defer { lock.unlock() }\n")
+	b.WriteString("            openToken = newValue\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeSwiftSessionRequirement emits the session requirement of
+// GeneratedClientProtocol.
+func writeSwiftSessionRequirement(b *strings.Builder) {
+	b.WriteString("    /// Key and token of the session handshake; one per client instance.\n")
+	b.WriteString("    var session: BlerpcSession { get }\n")
+}
+
+// writeSwiftSessionHelpers emits the handshake of the Swift client protocol
+// extension.
+func writeSwiftSessionHelpers(b *strings.Builder, start, auth gen.Command, prefix string) {
+	b.WriteByte('\n')
+	b.WriteString("    /// Authenticates to the peripheral, unlocking its session-protected\n")
+	b.WriteString("    /// commands. Protected calls open a session as needed; call this to\n")
+	b.WriteString("    /// authenticate up front.\n")
+	b.WriteString("    func openSession() async throws {\n")
+	b.WriteString("        _ = try await exclusive { try await handshake() }\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Runs the session handshake inside the caller's exclusive call and keeps\n")
+	b.WriteString("    /// the token: the proof is an HMAC-SHA256 of the challenge under the\n")
+	b.WriteString("    /// session key.\n")
+	b.WriteString("    private func handshake() async throws -> Data {\n")
+	b.WriteString(fmt.Sprintf("        let startData = try await call(cmdName: %s, requestData: try %s%s().serializedData())\n", gen.CallName(start, "swift"), prefix, start.RequestMsg))
+	b.WriteString(fmt.Sprintf("        let challenge = try decode(\"%s\", startData) { try %s%s(serializedBytes: $0) }.challenge\n", start.Snake, prefix, start.ResponseMsg))
+	b.WriteString(fmt.Sprintf("        var req = %s%s()\n", prefix, auth.RequestMsg))
+	b.WriteString("        req.proof = Data(HMAC<SHA256>.authenticationCode(for: challenge, using: SymmetricKey(data: session.key)))\n")
+	b.WriteString(fmt.Sprintf("        let authData = try await call(cmdName: %s, requestData: try req.serializedData())\n", gen.CallName(auth, "swift")))
+	b.WriteString(fmt.Sprintf("        let token = try decode(\"%s\", authData) { try %s%s(serializedBytes: $0) }.token\n", auth.Snake, prefix, auth.ResponseMsg))
+	b.WriteString("        session.token = token\n")
+	b.WriteString("        return token\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    /// Sends a session-protected request led by the session token, opening a\n")
+	b.WriteString("    /// session first when there is none. A peripheral that lost the session,\n")
+	b.WriteString("    /// e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent\n")
+	b.WriteString("    /// once more in a new session.\n")
+	b.WriteString("    func sessionCall(cmdName: String, requestData: Data) async throws -> Data {\n")
+	b.WriteString("        let token: Data\n")
+	b.WriteString("        if let open = session.token {\n")
+	b.WriteString("            token = open\n")
+	b.WriteString("        } else {\n")
+	b.WriteString("            token = try await handshake()\n")
+	b.WriteString("        }\n")
+	b.WriteString("        let respData = try await call(cmdName: cmdName, requestData: token + requestData)\n")
+	b.WriteString("        guard statusError(cmdName, respData) is UnauthenticatedError else { return respData }\n")
+	b.WriteString("        return try await call(cmdName: cmdName, requestData: handshake() + requestData)\n")
+	b.WriteString("    }\n")
+}
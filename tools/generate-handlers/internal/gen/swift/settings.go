@@ -0,0 +1,37 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftSettingsHelpers emits the typed settings accessors of the Swift
+// client protocol extension.
+func writeSwiftSettingsHelpers(b *strings.Builder, commands []gen.Command, prefix string) {
+	get, set := gen.SettingsCommands(commands)
+	if get != nil {
+		cls := prefix + get.Settings.Name
+		for _, f := range get.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("    /// Reads the %s setting.\n", f.Name))
+			b.WriteString(fmt.Sprintf("    func get%sSetting() async throws -> %s {\n", gen.SettingLabel(f), ResolveType(f, prefix)))
+			b.WriteString(fmt.Sprintf("        let resp = try await %s(field: %d)\n", gen.ToLowerCamel(get.Camel), f.Number))
+			b.WriteString(fmt.Sprintf("        return try decode(\"%s\", resp.value) { try %s(serializedBytes: $0) }.%s\n", get.Snake, cls, gen.SwiftPropertyName(f.Name)))
+			b.WriteString("    }\n")
+		}
+	}
+	if set != nil {
+		cls := prefix + set.Settings.Name
+		for _, f := range set.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("    /// Writes the %s setting.\n", f.Name))
+			b.WriteString(fmt.Sprintf("    func set%sSetting(_ value: %s) async throws {\n", gen.SettingLabel(f), ResolveType(f, prefix)))
+			b.WriteString(fmt.Sprintf("        var settings = %s()\n", cls))
+			b.WriteString(fmt.Sprintf("        settings.%s = value\n", gen.SwiftPropertyName(f.Name)))
+			b.WriteString(fmt.Sprintf("        _ = try await %s(field: %d, value: try settings.serializedData())\n", gen.ToLowerCamel(set.Camel), f.Number))
+			b.WriteString("    }\n")
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// WriteRemovedFields emits the extension properties of the removed
+// response fields.
+func WriteRemovedFields(b *strings.Builder, commands []gen.Command, prefix string) {
+	order, byMsg := gen.RemovedResponseFields(commands)
+	for _, msg := range order {
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("extension %s%s {\n", prefix, msg))
+		for _, f := range byMsg[msg] {
+			b.WriteString(fmt.Sprintf("    @available(*, deprecated, message: \"%s\")\n", gen.RemovedFieldNote(f)))
+			b.WriteString(fmt.Sprintf("    var %s: %s { %s }\n", gen.SwiftPropertyName(f.Name), ResolveType(f, prefix), ResolveDefault(f, prefix)))
+		}
+		b.WriteString("}\n")
+	}
+}
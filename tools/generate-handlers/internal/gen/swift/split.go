@@ -0,0 +1,26 @@
+package swift
+
+import "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+
+// groupData fills GeneratedClient+Group.swift.tmpl.
+type groupData struct {
+	Imports []string
+	clientMethods
+}
+
+// SplitClient returns the Swift client index at path, holding the error
+// types, the client protocol and the typed accessors, and one
+// GeneratedClient+<Group>.swift extension per group next to it.
+func SplitClient(gctx *gen.Context, groups []gen.CommandGroup, commands []gen.Command, streaming map[string]string, pkg, path string) []gen.Output {
+	prefix := gctx.SwiftPrefix(pkg)
+
+	index := newClientData(gctx, commands, streaming, prefix)
+	index.Index = true
+	outputs := []gen.Output{{Path: path, Content: gctx.RenderTemplate("GeneratedClient.swift.tmpl", index)}}
+
+	for _, g := range groups {
+		d := groupData{Imports: gen.OverrideImports(g.Commands, "swift"), clientMethods: newClientMethods(g.Commands, streaming, prefix)}
+		outputs = append(outputs, gen.Output{Path: gen.SplitPath(path, "+", g.Name), Content: gctx.RenderTemplate("GeneratedClient+Group.swift.tmpl", d)})
+	}
+	return outputs
+}
@@ -0,0 +1,42 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func outputsByPath(outputs []gen.Output) map[string]string {
+	byPath := make(map[string]string)
+	for _, out := range outputs {
+		byPath[out.Path] = out.Content
+	}
+	return byPath
+}
+
+func TestSplitSwiftClient(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_upload": "c2p"}
+	groups := gen.GroupCommands(cmds, "per-command", "blerpc")
+	files := outputsByPath(SplitClient(gctx, groups, cmds, streaming, "blerpc", "Client/GeneratedClient.swift"))
+
+	index := files["Client/GeneratedClient.swift"]
+	if !strings.Contains(index, "protocol GeneratedClientProtocol: Sendable {") || strings.Contains(index, "func echo(") {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+	echo := files["Client/GeneratedClient+Echo.swift"]
+	for _, s := range []string{
+		"import SwiftProtobuf\n\nextension GeneratedClientProtocol {\n    func echo(",
+		"let respData = try await exclusive { try await call(cmdName: \"echo\"",
+	} {
+		if !strings.Contains(echo, s) {
+			t.Errorf("GeneratedClient+Echo.swift missing %q\nGot:\n%s", s, echo)
+		}
+	}
+	if upload := files["Client/GeneratedClient+CounterUpload.swift"]; !strings.Contains(upload, "{\n    func counterUpload(messages:") {
+		t.Errorf("streaming method should open the extension:\n%s", upload)
+	}
+}
@@ -0,0 +1,19 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftRPCStatsHelpers emits the stats helper of the Swift client
+// protocol extension.
+func writeSwiftRPCStatsHelpers(b *strings.Builder, cmd gen.Command, prefix string) {
+	b.WriteByte('\n')
+	b.WriteString("    /// Returns the firmware's per-command counters keyed by command name.\n")
+	b.WriteString(fmt.Sprintf("    func rpcStatsByCommand(reset: Bool = false) async throws -> [String: %sRpcStat] {\n", prefix))
+	b.WriteString(fmt.Sprintf("        let resp = try await %s(reset: reset)\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("        return Dictionary(resp.stats.map { ($0.name, $0) }, uniquingKeysWith: { _, last in last })\n")
+	b.WriteString("    }\n")
+}
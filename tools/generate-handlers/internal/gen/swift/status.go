@@ -0,0 +1,27 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func swiftStatusCheck(cmd gen.Command, indent string) string {
+	if cmd.StatusField == "" {
+		return ""
+	}
+	prop := gen.SwiftPropertyName(cmd.StatusField) + ".rawValue"
+	return fmt.Sprintf("%sif resp.%s != %d {\n%s    throw CommandStatusError(command: \"%s\", field: \"%s\", status: resp.%s)\n%s}\n",
+		indent, prop, cmd.StatusOK, indent, cmd.Snake, cmd.StatusField, prop, indent)
+}
+
+func writeSwiftStatusError(b *strings.Builder) {
+	b.WriteString("/// Thrown when a checked command responds with a non-OK status.\n")
+	b.WriteString("struct CommandStatusError: RemoteError {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let field: String\n")
+	b.WriteString("    let status: Int\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
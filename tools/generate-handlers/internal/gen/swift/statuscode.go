@@ -0,0 +1,59 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftStatusCodes emits StatusCode, a RemoteError per code and
+// statusError, which decode calls first.
+func writeSwiftStatusCodes(b *strings.Builder) {
+	b.WriteString("/// Status codes of error responses; 128 and up are application codes.\n")
+	b.WriteString("enum StatusCode: Int {\n")
+	for i, sc := range gen.StatusCodes {
+		b.WriteString(fmt.Sprintf("    case %s = %d\n", gen.SwiftPropertyName(strings.ToLower(sc.Name)), i))
+	}
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	for _, sc := range gen.StatusCodes[1:] {
+		b.WriteString(fmt.Sprintf("/// %s\n", sc.Doc))
+		b.WriteString(fmt.Sprintf("struct %sError: RemoteError {\n", gen.StatusErrorName(sc.Name)))
+		b.WriteString("    let command: String\n")
+		b.WriteString(fmt.Sprintf("    var status: Int { StatusCode.%s.rawValue }\n", gen.SwiftPropertyName(strings.ToLower(sc.Name))))
+		b.WriteString("}\n")
+		b.WriteByte('\n')
+	}
+	b.WriteString("/// An error response with an application or unknown status code.\n")
+	b.WriteString("struct UnknownStatusError: RemoteError {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let status: Int\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	var tag []string
+	for _, c := range gen.StatusTag {
+		tag = append(tag, fmt.Sprintf("0x%02X", c))
+	}
+	b.WriteString("/// Returns the error an error response reports, or nil for other responses.\n")
+	b.WriteString("/// Error responses hold only a status, in a field no message uses.\n")
+	b.WriteString("func statusError(_ command: String, _ data: Data) -> RemoteError? {\n")
+	b.WriteString(fmt.Sprintf("    let tag: [UInt8] = [%s]\n", strings.Join(tag, ", ")))
+	b.WriteString("    let bytes = [UInt8](data)\n")
+	b.WriteString("    guard bytes.count > tag.count, Array(bytes[..<tag.count]) == tag else { return nil }\n")
+	b.WriteString("    var status = 0\n")
+	b.WriteString("    var shift = 0\n")
+	b.WriteString("    for byte in bytes[tag.count...] {\n")
+	b.WriteString("        status |= Int(byte & 0x7F) << shift\n")
+	b.WriteString("        shift += 7\n")
+	b.WriteString("        if byte < 0x80 { break }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    switch StatusCode(rawValue: status) {\n")
+	for _, sc := range gen.StatusCodes[1:] {
+		b.WriteString(fmt.Sprintf("    case .%s: return %sError(command: command)\n", gen.SwiftPropertyName(strings.ToLower(sc.Name)), gen.StatusErrorName(sc.Name)))
+	}
+	b.WriteString("    default: return UnknownStatusError(command: command, status: status)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,34 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftStringTooLongError emits the error the Swift client throws for a
+// bounded string argument that is too long.
+func writeSwiftStringTooLongError(b *strings.Builder) {
+	b.WriteString("/// A string argument is longer in UTF-8 than its max_size allows.\n")
+	b.WriteString("struct StringTooLongError: BlerpcErrorProtocol {\n")
+	b.WriteString("    let command: String\n")
+	b.WriteString("    let field: String\n")
+	b.WriteString("    let size: Int\n")
+	b.WriteString("    let maxBytes: Int\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeSwiftStringCheck emits the UTF-8 length check of string argument f.
+func writeSwiftStringCheck(b *strings.Builder, cmd gen.Command, f gen.Field, indent string) {
+	propName := gen.SwiftPropertyName(f.Name)
+	size := propName + ".utf8.count"
+	cond := fmt.Sprintf("%s > %d", size, f.MaxBytes)
+	if gen.NullableField(f) {
+		cond = "let " + propName + ", " + cond
+	}
+	b.WriteString(fmt.Sprintf("%sif %s {\n", indent, cond))
+	b.WriteString(fmt.Sprintf("%s    throw StringTooLongError(command: \"%s\", field: \"%s\", size: %s, maxBytes: %d)\n", indent, cmd.Snake, f.Name, size, f.MaxBytes))
+	b.WriteString(indent + "}\n")
+}
@@ -0,0 +1,309 @@
+package swift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// -out-swift-package writes the Swift client as a SwiftPM package other iOS
+// apps can depend on, besides the files written into the example app:
+//
+//	Package.swift
+//	Sources/<Pkg>Client/                 the Swift client files, with a public API
+//	Sources/<Pkg>Client/Transport.swift  BlerpcTransport and TransportClient
+//	Sources/<Pkg>Client/*.proto          compiled by the SwiftProtobuf plugin
+//
+// An app implements BlerpcTransport over its BLE stack, as the BlerpcClient
+// of the example app does, and calls the commands on a TransportClient; the
+// scanner and the event subscriptions, which extend that BLE stack, stay in
+// the app. The client files are written for the app target, whose
+// declarations are internal, so swiftPublicAPI makes them public for the
+// package.
+
+const (
+	swiftProtobufURL     = "https://github.com/apple/swift-protobuf.git"
+	swiftProtobufVersion = "1.28.0"
+	blerpcProtocolURL    = "https://github.com/tdaira/blerpc-protocol-swift"
+	blerpcProtocolVer    = "0.6.0"
+)
+
+// swiftPackageModule returns the module and library name of the package.
+func swiftPackageModule(pkg string) string {
+	return gen.UpperCamel(pkg) + "Client"
+}
+
+var swiftProtocolImportRe = regexp.MustCompile(`(?m)^import BlerpcProtocol$`)
+
+// PackageOutputs returns the files of the package in dir: the Swift
+// outputs under clientDir, the directory of the Swift client, other than
+// appOnly, and the protos.
+func PackageOutputs(gctx *gen.Context, outputs []gen.Output, clientDir string, appOnly []string, dir, pkg string, protos []gen.Output) []gen.Output {
+	module := swiftPackageModule(pkg)
+	src := filepath.Join(dir, "Sources", module)
+	var files []gen.Output
+	usesProtocol := false
+	for _, out := range outputs {
+		rel, err := filepath.Rel(clientDir, out.Path)
+		if err != nil || !filepath.IsLocal(rel) || filepath.Ext(rel) != ".swift" || slices.Contains(appOnly, out.Path) {
+			continue
+		}
+		usesProtocol = usesProtocol || swiftProtocolImportRe.MatchString(out.Content)
+		files = append(files, gen.Output{Path: filepath.Join(src, rel), Content: swiftPublicAPI(out.Content)})
+	}
+	files = append(files, gen.Output{Path: filepath.Join(src, "Transport.swift"), Content: generateSwiftTransport(gctx)})
+	var protoFiles []string
+	for _, p := range protos {
+		protoFiles = append(protoFiles, p.Path)
+		files = append(files, gen.Output{Path: filepath.Join(src, p.Path), Content: p.Content})
+	}
+	files = append(files, gen.Output{Path: filepath.Join(src, "swift-protobuf-config.json"), Content: generateSwiftProtobufConfig(protoFiles)})
+	return append([]gen.Output{{Path: filepath.Join(dir, "Package.swift"), Content: generateSwiftPackage(module, usesProtocol)}}, files...)
+}
+
+// generateSwiftPackage returns Package.swift. usesProtocol adds the
+// BlerpcProtocol library the session and framing code import.
+func generateSwiftPackage(module string, usesProtocol bool) string {
+	var b strings.Builder
+
+	// The tools version must be the first line.
+	b.WriteString("// swift-tools-version:5.9\n")
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import PackageDescription\n")
+	b.WriteByte('\n')
+	b.WriteString("let package = Package(\n")
+	b.WriteString(fmt.Sprintf("    name: \"%s\",\n", module))
+	b.WriteString("    platforms: [.iOS(.v16), .macOS(.v13)],\n")
+	b.WriteString("    products: [\n")
+	b.WriteString(fmt.Sprintf("        .library(name: \"%s\", targets: [\"%s\"]),\n", module, module))
+	b.WriteString("    ],\n")
+	b.WriteString("    dependencies: [\n")
+	b.WriteString(fmt.Sprintf("        .package(url: \"%s\", from: \"%s\"),\n", swiftProtobufURL, swiftProtobufVersion))
+	if usesProtocol {
+		b.WriteString(fmt.Sprintf("        .package(url: \"%s\", from: \"%s\"),\n", blerpcProtocolURL, blerpcProtocolVer))
+	}
+	b.WriteString("    ],\n")
+	b.WriteString("    targets: [\n")
+	b.WriteString("        .target(\n")
+	b.WriteString(fmt.Sprintf("            name: \"%s\",\n", module))
+	b.WriteString("            dependencies: [\n")
+	b.WriteString("                .product(name: \"SwiftProtobuf\", package: \"swift-protobuf\"),\n")
+	if usesProtocol {
+		b.WriteString("                .product(name: \"BlerpcProtocol\", package: \"blerpc-protocol-swift\"),\n")
+	}
+	b.WriteString("            ],\n")
+	b.WriteString("            plugins: [\n")
+	b.WriteString("                .plugin(name: \"SwiftProtobufPlugin\", package: \"swift-protobuf\"),\n")
+	b.WriteString("            ]\n")
+	b.WriteString("        ),\n")
+	b.WriteString("    ]\n")
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// generateSwiftProtobufConfig returns the swift-protobuf-config.json of the
+// target, generating public message types from protos.
+func generateSwiftProtobufConfig(protos []string) string {
+	type invocation struct {
+		ProtoFiles []string `json:"protoFiles"`
+		Visibility string   `json:"visibility"`
+	}
+	cfg := struct {
+		Invocations []invocation `json:"invocations"`
+	}{[]invocation{{protos, "public"}}}
+	data, _ := json.MarshalIndent(cfg, "", "  ")
+	return string(data) + "\n"
+}
+
+// generateSwiftTransport returns Transport.swift of the package.
+func generateSwiftTransport(gctx *gen.Context) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import Foundation\n")
+	b.WriteByte('\n')
+	b.WriteString("/// Carries the commands of a TransportClient to a peripheral: the container\n")
+	b.WriteString("/// layer, encryption and BLE link, as the BlerpcClient of the example app\n")
+	b.WriteString("/// implements them over CoreBluetooth.\n")
+	b.WriteString("public protocol BlerpcTransport: AnyObject, Sendable {\n")
+	b.WriteString("    func call(cmdName: String, requestData: Data) async throws -> Data\n")
+	b.WriteString("    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data]\n")
+	b.WriteString("    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data\n")
+	b.WriteString("    /// Stops the P→C stream in progress. The default does nothing.\n")
+	b.WriteString("    func streamCancel() async\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("public extension BlerpcTransport {\n")
+	b.WriteString("    func streamCancel() async {}\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("/// The generated commands, sent over a BlerpcTransport.\n")
+	b.WriteString("public final class TransportClient: " + gctx.SwiftType("GeneratedClientProtocol") + ", Sendable {\n")
+	b.WriteString("    public let transport: BlerpcTransport\n")
+	b.WriteString("    public let callSerializer = CallSerializer()\n")
+	b.WriteByte('\n')
+	b.WriteString("    public init(transport: BlerpcTransport) {\n")
+	b.WriteString("        self.transport = transport\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    public func call(cmdName: String, requestData: Data) async throws -> Data {\n")
+	b.WriteString("        try await transport.call(cmdName: cmdName, requestData: requestData)\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    public func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {\n")
+	b.WriteString("        try await transport.streamReceive(cmdName: cmdName, requestData: requestData)\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    public func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {\n")
+	b.WriteString("        try await transport.streamSend(cmdName: cmdName, messages: messages, finalCmdName: finalCmdName)\n")
+	b.WriteString("    }\n")
+	b.WriteByte('\n')
+	b.WriteString("    public func streamCancel() async {\n")
+	b.WriteString("        await transport.streamCancel()\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// swiftAccessWords are the access modifiers; private(set) and the like only
+// restrict the setter.
+var swiftAccessWords = []string{"public", "open", "internal", "fileprivate", "private"}
+
+// swiftModifierWords are the other modifiers that can lead a declaration.
+var swiftModifierWords = []string{"final", "static", "override", "convenience", "required", "nonisolated", "mutating", "lazy", "weak", "unowned", "indirect", "dynamic"}
+
+var swiftDeclWordRe = regexp.MustCompile(`^(struct|class|enum|actor|protocol|extension|func|init|var|let|typealias|subscript)\b`)
+
+// swiftDecl parses the declaration leading trimmed line t: its keyword, the
+// length of the leading attributes, and whether it has an access modifier
+// or is final. keyword is empty when t declares nothing.
+func swiftDecl(t string) (keyword string, attrLen int, access, final bool) {
+	words := strings.Fields(t)
+	i := 0
+	for ; i < len(words) && strings.HasPrefix(words[i], "@"); i++ {
+		attrLen += len(words[i]) + 1
+	}
+	for ; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case slices.Contains(swiftAccessWords, w):
+			access = true
+		case w == "final":
+			final = true
+		case slices.Contains(swiftModifierWords, w), strings.HasSuffix(w, "(set)"):
+		case w == "class" && i+1 < len(words) && swiftDeclWordRe.MatchString(words[i+1]):
+			// class func, class var
+		default:
+			return swiftDeclWordRe.FindString(w), attrLen, access, final
+		}
+	}
+	return "", attrLen, access, final
+}
+
+// swiftPublicAPI makes the declarations of a Swift file of the app target
+// public: the top-level ones, and the members of the types and of the
+// extensions declaring a conformance, which cannot be public themselves.
+// Classes not final become open, with open methods and properties, so apps
+// can subclass them as the example app does. Members of protocols and of
+// public extensions are public already, and private declarations stay so.
+// It relies on the four-space indentation of the generated code.
+func swiftPublicAPI(src string) string {
+	const (
+		keep   = iota // members stay as they are
+		public        // members become public
+		open          // members become open, or public where open is invalid
+	)
+	type container struct{ indent, members int }
+	var stack []container
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		t := strings.TrimLeft(line, " ")
+		if t == "" || strings.HasPrefix(t, "//") || strings.HasPrefix(t, "/*") || strings.HasPrefix(t, "*") {
+			continue
+		}
+		n := len(line) - len(t)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= n {
+			stack = stack[:len(stack)-1]
+		}
+		keyword, attrLen, access, final := swiftDecl(t)
+		if keyword == "" {
+			continue
+		}
+		parent := container{-4, public}
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		members := keep
+		if n == parent.indent+4 && parent.members != keep && !access {
+			modifier := "public "
+			switch {
+			case keyword == "class" && !final:
+				modifier, members = "open ", open
+			case keyword == "struct", keyword == "enum", keyword == "actor", keyword == "class":
+				members = public
+			case keyword == "extension" && strings.Contains(t, ":"):
+				modifier, members = "", public
+			case parent.members == open && (keyword == "func" || keyword == "var") && !strings.Contains(t, "static "):
+				modifier = "open "
+			}
+			lines[i] = line[:n] + t[:attrLen] + modifier + t[attrLen:]
+		} else if access && !strings.HasPrefix(t[attrLen:], "private") && !strings.HasPrefix(t[attrLen:], "fileprivate") && slices.Contains([]string{"struct", "enum", "actor", "class"}, keyword) {
+			members = public
+		}
+		stack = append(stack, container{n, members})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PackageProtos returns the proto at protoPath and the protos it
+// imports, found next to it or in importPaths, with paths relative to the
+// import root, read from fsys. The well-known google/protobuf protos come
+// with protoc.
+func PackageProtos(fsys fs.FS, protoPath string, importPaths []string) ([]gen.Output, error) {
+	importRe := regexp.MustCompile(`(?m)^\s*import\s+(?:public\s+|weak\s+)?"([^"]+)"\s*;`)
+	dirs := append([]string{filepath.Dir(protoPath)}, importPaths...)
+	var protos []gen.Output
+	seen := make(map[string]bool)
+	var add func(rel, path string) error
+	add = func(rel, path string) error {
+		if seen[rel] {
+			return nil
+		}
+		seen[rel] = true
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		protos = append(protos, gen.Output{Path: rel, Content: string(data)})
+		for _, m := range importRe.FindAllStringSubmatch(string(data), -1) {
+			imp := m[1]
+			if strings.HasPrefix(imp, "google/protobuf/") {
+				continue
+			}
+			found := ""
+			for _, dir := range dirs {
+				if _, err := fs.Stat(fsys, filepath.Join(dir, imp)); err == nil {
+					found = filepath.Join(dir, imp)
+					break
+				}
+			}
+			if found == "" {
+				return fmt.Errorf("import %q of %s not found", imp, rel)
+			}
+			if err := add(imp, found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(filepath.Base(protoPath), protoPath); err != nil {
+		return nil, err
+	}
+	return protos, nil
+}
@@ -0,0 +1,113 @@
+package swift
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestSwiftPublicAPI(t *testing.T) {
+	src := strings.Join([]string{
+		"import Foundation",
+		"",
+		"struct Point {",
+		"    let x: Int",
+		"    private var cache: Int = 0",
+		"    init(x: Int) { self.x = x }",
+		"}",
+		"",
+		"class Policy: NSObject {",
+		"    var attempts = 3",
+		"    static let shared = Policy()",
+		"    override init() {}",
+		"    func delay(_ attempt: Int) -> Double { 0 }",
+		"}",
+		"",
+		"final class Store {",
+		"    private(set) var items: [Int] = []",
+		"}",
+		"",
+		"protocol Client {",
+		"    func echo() async throws",
+		"}",
+		"",
+		"extension Client {",
+		"    func ping() async throws {}",
+		"}",
+		"",
+		"extension Point: Equatable {",
+		"    static func == (a: Point, b: Point) -> Bool { a.x == b.x }",
+		"}",
+		"",
+		"private func helper() {}",
+	}, "\n")
+	got := swiftPublicAPI(src)
+	for _, want := range []string{
+		"\npublic struct Point {\n    public let x: Int\n    private var cache: Int = 0\n    public init(x: Int)",
+		"\nopen class Policy: NSObject {\n    open var attempts = 3\n    public static let shared = Policy()\n    public override init() {}\n    open func delay(",
+		"\npublic final class Store {\n    public private(set) var items",
+		"\npublic protocol Client {\n    func echo() async throws\n}",
+		"\npublic extension Client {\n    func ping() async throws {}",
+		"\nextension Point: Equatable {\n    public static func ==",
+		"\nprivate func helper() {}",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestSwiftPackageOutputs(t *testing.T) {
+	gctx := gen.NewContext()
+	clientDir := filepath.Join("app", "Client")
+	outputs := []gen.Output{
+		{Path: filepath.Join(clientDir, "GeneratedClient.swift"), Content: "import Foundation\n\nstruct A {}\n"},
+		{Path: filepath.Join(clientDir, "GeneratedScanner.swift"), Content: "import CoreBluetooth\n"},
+		{Path: filepath.Join(clientDir, "Session.swift"), Content: "import BlerpcProtocol\n"},
+		{Path: filepath.Join("app", "Other.swift"), Content: "struct B {}\n"},
+	}
+	protos := []gen.Output{{Path: "blerpc.proto", Content: "syntax = \"proto3\";\n"}}
+	files := PackageOutputs(gctx, outputs, clientDir, []string{outputs[1].Path}, "pkg", "blerpc", protos)
+
+	got := make(map[string]string)
+	for _, f := range files {
+		got[f.Path] = f.Content
+	}
+	src := filepath.Join("pkg", "Sources", "BlerpcClient")
+	for _, path := range []string{
+		filepath.Join("pkg", "Package.swift"),
+		filepath.Join(src, "GeneratedClient.swift"),
+		filepath.Join(src, "Session.swift"),
+		filepath.Join(src, "Transport.swift"),
+		filepath.Join(src, "blerpc.proto"),
+		filepath.Join(src, "swift-protobuf-config.json"),
+	} {
+		if _, ok := got[path]; !ok {
+			t.Errorf("missing %s", path)
+		}
+	}
+	if len(got) != 6 {
+		t.Errorf("got %d files, want 6", len(got))
+	}
+	if !strings.Contains(got[filepath.Join(src, "GeneratedClient.swift")], "public struct A") {
+		t.Error("client declarations are not public")
+	}
+	pkg := got[filepath.Join("pkg", "Package.swift")]
+	if !strings.HasPrefix(pkg, "// swift-tools-version:5.9\n") {
+		t.Error("Package.swift does not start with the tools version")
+	}
+	for _, want := range []string{
+		`.library(name: "BlerpcClient", targets: ["BlerpcClient"])`,
+		`.product(name: "BlerpcProtocol", package: "blerpc-protocol-swift")`,
+		`.plugin(name: "SwiftProtobufPlugin", package: "swift-protobuf")`,
+	} {
+		if !strings.Contains(pkg, want) {
+			t.Errorf("Package.swift missing %q", want)
+		}
+	}
+	if !strings.Contains(got[filepath.Join(src, "swift-protobuf-config.json")], `"visibility": "public"`) {
+		t.Error("protos are not generated public")
+	}
+}
@@ -0,0 +1,28 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftTimeSyncHelpers emits the clock helper of the Swift client
+// protocol extension.
+func writeSwiftTimeSyncHelpers(b *strings.Builder, cmd gen.Command, prefix string) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("    /// Sets the peripheral's wall clock to this device's. With `compensate`, a\n")
+	b.WriteString("    /// first exchange measures the round trip and the second adds half of it,\n")
+	b.WriteString("    /// the estimated delivery delay.\n")
+	b.WriteString("    @discardableResult\n")
+	b.WriteString(fmt.Sprintf("    func syncTime(compensate: Bool = false) async throws -> %s%s {\n", prefix, cmd.ResponseMsg))
+	b.WriteString("        var offsetUs: UInt32 = 0\n")
+	b.WriteString("        if compensate {\n")
+	b.WriteString("            let start = DispatchTime.now().uptimeNanoseconds\n")
+	b.WriteString(fmt.Sprintf("            _ = try await %s(unixTimeUs: Int64(Date().timeIntervalSince1970 * 1_000_000))\n", method))
+	b.WriteString("            offsetUs = UInt32((DispatchTime.now().uptimeNanoseconds - start) / 2000)\n")
+	b.WriteString("        }\n")
+	b.WriteString(fmt.Sprintf("        return try await %s(unixTimeUs: Int64(Date().timeIntervalSince1970 * 1_000_000), offsetUs: offsetUs)\n", method))
+	b.WriteString("    }\n")
+}
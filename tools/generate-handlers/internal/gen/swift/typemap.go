@@ -0,0 +1,79 @@
+package swift
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// swiftDefaults maps proto field types to Swift default values.
+var swiftDefaults = map[string]string{
+	"string":   "\"\"",
+	"bytes":    "Data()",
+	"uint32":   "0",
+	"int32":    "0",
+	"uint64":   "0",
+	"int64":    "0",
+	"sint32":   "0",
+	"sint64":   "0",
+	"fixed32":  "0",
+	"fixed64":  "0",
+	"sfixed32": "0",
+	"sfixed64": "0",
+	"float":    "0.0",
+	"double":   "0.0",
+	"bool":     "false",
+}
+
+func scalarSwiftType(f gen.Field, prefix string) string {
+	if f.IsEnum {
+		return "Int32"
+	}
+	if f.IsMessage && !protomodel.IsWellKnownType(f.Type) {
+		return gen.MessageTypeName(f.Type, f.TypeFile, "swift", prefix)
+	}
+	if f.IsMessage {
+		return f.Type
+	}
+	if t, ok := gen.SwiftTypes[f.Type]; ok {
+		return t
+	}
+	return "Any"
+}
+
+func ResolveType(f gen.Field, prefix string) string {
+	if f.IsMap {
+		k := gen.LookupScalar(gen.SwiftTypes, f.KeyType, "Any")
+		v := gen.LookupScalar(gen.SwiftTypes, f.ValueType, f.ValueType)
+		if f.ValueIsMessage {
+			v = scalarSwiftType(gen.MapValueField(f), prefix)
+		}
+		return "[" + k + ": " + v + "]"
+	}
+	base := scalarSwiftType(f, prefix)
+	if f.IsRepeated {
+		return "[" + base + "]"
+	}
+	return base
+}
+
+func ResolveDefault(f gen.Field, prefix string) string {
+	if d, ok := gen.DefaultLiteral(f, "swift"); ok {
+		return d
+	}
+	if f.IsMap {
+		return "[:]"
+	}
+	if f.IsRepeated {
+		return "[]"
+	}
+	if f.IsEnum {
+		return "0"
+	}
+	if f.IsMessage {
+		return scalarSwiftType(f, prefix) + "()"
+	}
+	if d, ok := swiftDefaults[f.Type]; ok {
+		return d
+	}
+	return "nil"
+}
@@ -0,0 +1,31 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeSwiftUnwrapped emits the unwrapped methods of the
+// GeneratedClientProtocol extension.
+func writeSwiftUnwrapped(b *strings.Builder, commands []gen.Command, prefix string) {
+	for _, cmd := range commands {
+		if !cmd.Unwrap {
+			continue
+		}
+		f := cmd.ResponseFields[0]
+		var args []string
+		for _, name := range gen.ParamNames(cmd.RequestFields) {
+			propName := gen.SwiftPropertyName(name)
+			args = append(args, propName+": "+propName)
+		}
+		params := strings.Join(swiftParams(cmd.RequestFields, cmd.RequestMsg, prefix), ", ")
+		b.WriteByte('\n')
+		b.WriteString(fmt.Sprintf("    /// Calls `%s` and returns the %s of its response.\n", gen.ToLowerCamel(cmd.Camel), f.Name))
+		b.WriteString(Deprecation(cmd, true))
+		b.WriteString(fmt.Sprintf("    func %s(%s) async throws -> %s {\n", gen.UnwrapCamel(cmd), params, ResolveType(f, prefix)))
+		b.WriteString(fmt.Sprintf("        try await %s(%s).%s\n", gen.ToLowerCamel(cmd.Camel), strings.Join(args, ", "), gen.SwiftPropertyName(f.Name)))
+		b.WriteString("    }\n")
+	}
+}
@@ -0,0 +1,27 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateUUIDs returns GeneratedUUIDs.swift.
+func GenerateUUIDs(gctx *gen.Context) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("import CoreBluetooth\n")
+	b.WriteByte('\n')
+	b.WriteString("/// GATT UUIDs of the blerpc service, from blerpc.yaml.\n")
+	b.WriteString("enum GeneratedUUIDs {\n")
+	b.WriteString("    /// Service advertised by blerpc peripherals.\n")
+	b.WriteString(fmt.Sprintf("    static let service = CBUUID(string: \"%s\")\n", gctx.ServiceUUID))
+	b.WriteByte('\n')
+	b.WriteString("    /// The multiplexed RPC characteristic.\n")
+	b.WriteString(fmt.Sprintf("    static let characteristic = CBUUID(string: \"%s\")\n", gctx.CharUUID))
+	writeSwiftGattGroups(gctx, &b)
+	b.WriteString("}\n")
+	return b.String()
+}
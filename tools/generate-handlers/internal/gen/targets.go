@@ -0,0 +1,73 @@
+package gen
+
+import (
+	"cmp"
+	"strings"
+)
+
+// NamesConfig overrides the package and prefix names of the generated code,
+// so that the code of several schemas can share a firmware or an app.
+type NamesConfig struct {
+	KotlinPackage     string `yaml:"kotlin_package"`      // package of the Kotlin sources; default com.<pkg>.android.client
+	SwiftPrefix       string `yaml:"swift_prefix"`        // SwiftProtobuf message prefix; default <Pkg>_
+	PythonPb2Module   string `yaml:"python_pb2_module"`   // absolute module of the protoc Python output; default <pkg>_pb2 next to the client
+	CHandlerPrefix    string `yaml:"c_handler_prefix"`    // prefix of the C handler functions; default handle_
+	CPrefix           string `yaml:"c_prefix"`            // prefix of the C dispatch symbols such as handlers_lookup; default none
+	PythonMethodStyle string `yaml:"python_method_style"` // case of the Python client methods, snake (default) or camel
+	KotlinClientClass string `yaml:"kotlin_client_class"` // class of the Kotlin client; default GeneratedClient
+	SwiftTypePrefix   string `yaml:"swift_type_prefix"`   // prefix of the Swift client types; default none
+}
+
+// KotlinPackage returns the package of the generated Kotlin sources.
+func (gctx *Context) KotlinPackage(pkg string) string {
+	if gctx.Names.KotlinPackage != "" {
+		return gctx.Names.KotlinPackage
+	}
+	return "com." + pkg + ".android.client"
+}
+
+// SwiftPrefix returns the prefix SwiftProtobuf gives the messages of pkg.
+func (gctx *Context) SwiftPrefix(pkg string) string {
+	if gctx.Names.SwiftPrefix != "" {
+		return gctx.Names.SwiftPrefix
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + "_"
+}
+
+// CHandlerName returns the C handler function of the command snake, e.g.
+// handle_echo.
+func (gctx *Context) CHandlerName(snake string) string {
+	return cmp.Or(gctx.Names.CHandlerPrefix, "handle_") + snake
+}
+
+// CHandlerPad returns the spaces that align the continuation lines of a
+// handler prototype whose indent assumes the handle_ prefix.
+func (gctx *Context) CHandlerPad(snake string) string {
+	return strings.Repeat(" ", len(gctx.CHandlerName(snake))-len("handle_"))
+}
+
+// CSymbol returns the C dispatch symbol name, such as handlers_lookup,
+// with the c_prefix that keeps two schemas apart in one firmware.
+func (gctx *Context) CSymbol(name string) string {
+	return gctx.Names.CPrefix + name
+}
+
+// PyMethodName returns the Python client method of the command snake, e.g.
+// flash_read, or flashRead with python_method_style camel.
+func (gctx *Context) PyMethodName(snake string) string {
+	if gctx.Names.PythonMethodStyle == "camel" {
+		return SwiftPropertyName(snake)
+	}
+	return snake
+}
+
+// KotlinClientClass returns the class of the Kotlin client.
+func (gctx *Context) KotlinClientClass() string {
+	return cmp.Or(gctx.Names.KotlinClientClass, "GeneratedClient")
+}
+
+// SwiftType returns the Swift client type name with the swift_type_prefix,
+// e.g. GeneratedClient or SensorGeneratedClient.
+func (gctx *Context) SwiftType(name string) string {
+	return gctx.Names.SwiftTypePrefix + name
+}
@@ -0,0 +1,118 @@
+package gen
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Outputs render from text/template files embedded from templates/, named
+// after the file they produce. -template-dir names a directory of
+// replacements: a file there with the name of an embedded template is used
+// in its place, so the style of an output can be changed without forking
+// the tool. Templates include each other by name, e.g. both TypeScript BLE
+// clients include ts_client_session.tmpl. The templates of the C handlers
+// and the typed clients lay out the file and the method of each command;
+// the sections of optional features, such as sessions and the built-ins,
+// are written in Go and passed in as text.
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// builtinTemplates are the embedded templates, parsed once; each Context
+// renders a copy bound to its names.
+var builtinTemplates = template.Must(template.New("").Funcs(new(Context).templateFuncs()).ParseFS(embeddedTemplates, "templates/*.tmpl"))
+
+// templateFuncs are the helpers of the templates: join, indent, and the
+// names: helpers they name types with.
+func (gctx *Context) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"join":              strings.Join,
+		"indent":            indent,
+		"kotlinClientClass": gctx.KotlinClientClass,
+		"swiftType":         gctx.SwiftType,
+	}
+}
+
+// indent prefixes every line of s with prefix, for the sections written in
+// Go that a template places at more than one depth.
+func indent(prefix, s string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line != "" {
+			b.WriteString(prefix + line)
+		}
+	}
+	return b.String()
+}
+
+// bind returns a copy of t whose helpers read the names of gctx.
+func (gctx *Context) bind(t *template.Template) *template.Template {
+	return template.Must(t.Clone()).Funcs(gctx.templateFuncs())
+}
+
+// LoadTemplates replaces the embedded templates with the .tmpl files in dir
+// of fsys. Files that do not replace an embedded template are an error, so a
+// typo does not silently leave the built-in in use.
+func (gctx *Context) LoadTemplates(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	t := gctx.bind(builtinTemplates)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".tmpl" {
+			continue
+		}
+		if t.Lookup(name) == nil {
+			return fmt.Errorf("%s does not replace a built-in template (want one of %s)", name, strings.Join(templateNames(), ", "))
+		}
+		data, err := fs.ReadFile(fsys, filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if _, err := t.New(name).Parse(string(data)); err != nil {
+			return err
+		}
+	}
+	gctx.templates = t
+	return nil
+}
+
+// templateNames lists the embedded templates.
+func templateNames() []string {
+	entries, _ := embeddedTemplates.ReadDir("templates")
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+// RenderTemplate executes the named template. Only a replaced template can
+// fail, so a failure fails the generation like other invalid input: it is
+// kept for TemplateErr and the file is left empty.
+func (gctx *Context) RenderTemplate(name string, data any) string {
+	var b strings.Builder
+	if err := gctx.templates.ExecuteTemplate(&b, name, data); err != nil {
+		gctx.templateMu.Lock()
+		if gctx.templateErr == nil {
+			gctx.templateErr = err
+		}
+		gctx.templateMu.Unlock()
+		return ""
+	}
+	return b.String()
+}
+
+// TemplateErr returns the first failure to render a template, once the
+// outputs are rendered.
+func (gctx *Context) TemplateErr() error {
+	gctx.templateMu.Lock()
+	defer gctx.templateMu.Unlock()
+	return gctx.templateErr
+}
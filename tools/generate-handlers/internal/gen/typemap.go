@@ -0,0 +1,304 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// KotlinTypes maps proto field types to Kotlin types.
+var KotlinTypes = map[string]string{
+	"string":   "String",
+	"bytes":    "com.google.protobuf.ByteString",
+	"uint32":   "Int",
+	"int32":    "Int",
+	"uint64":   "Long",
+	"int64":    "Long",
+	"sint32":   "Int",
+	"sint64":   "Long",
+	"fixed32":  "Int",
+	"fixed64":  "Long",
+	"sfixed32": "Int",
+	"sfixed64": "Long",
+	"float":    "Float",
+	"double":   "Double",
+	"bool":     "Boolean",
+}
+
+// SwiftTypes maps proto field types to Swift types.
+var SwiftTypes = map[string]string{
+	"string":   "String",
+	"bytes":    "Data",
+	"uint32":   "UInt32",
+	"int32":    "Int32",
+	"uint64":   "UInt64",
+	"int64":    "Int64",
+	"sint32":   "Int32",
+	"sint64":   "Int64",
+	"fixed32":  "UInt32",
+	"fixed64":  "UInt64",
+	"sfixed32": "Int32",
+	"sfixed64": "Int64",
+	"float":    "Float",
+	"double":   "Double",
+	"bool":     "Bool",
+}
+
+// DartTypes maps proto field types to Dart types.
+var DartTypes = map[string]string{
+	"string":   "String",
+	"bytes":    "List<int>",
+	"uint32":   "int",
+	"int32":    "int",
+	"uint64":   "int",
+	"int64":    "int",
+	"sint32":   "int",
+	"sint64":   "int",
+	"fixed32":  "int",
+	"fixed64":  "int",
+	"sfixed32": "int",
+	"sfixed64": "int",
+	"float":    "double",
+	"double":   "double",
+	"bool":     "bool",
+}
+
+// TsTypes maps proto field types to TypeScript types.
+var TsTypes = map[string]string{
+	"string":   "string",
+	"bytes":    "Uint8Array",
+	"uint32":   "number",
+	"int32":    "number",
+	"uint64":   "number",
+	"int64":    "number",
+	"sint32":   "number",
+	"sint64":   "number",
+	"fixed32":  "number",
+	"fixed64":  "number",
+	"sfixed32": "number",
+	"sfixed64": "number",
+	"float":    "number",
+	"double":   "number",
+	"bool":     "boolean",
+}
+
+// PythonTypes maps proto field types to Python types.
+var PythonTypes = map[string]string{
+	"string":   "str",
+	"bytes":    "bytes",
+	"uint32":   "int",
+	"int32":    "int",
+	"uint64":   "int",
+	"int64":    "int",
+	"sint32":   "int",
+	"sint64":   "int",
+	"fixed32":  "int",
+	"fixed64":  "int",
+	"sfixed32": "int",
+	"sfixed64": "int",
+	"float":    "float",
+	"double":   "float",
+	"bool":     "bool",
+}
+
+// PythonDefaults maps proto field types to Python default values.
+var PythonDefaults = map[string]string{
+	"string":   `""`,
+	"bytes":    `b""`,
+	"uint32":   "0",
+	"int32":    "0",
+	"uint64":   "0",
+	"int64":    "0",
+	"sint32":   "0",
+	"sint64":   "0",
+	"fixed32":  "0",
+	"fixed64":  "0",
+	"sfixed32": "0",
+	"sfixed64": "0",
+	"float":    "0.0",
+	"double":   "0.0",
+	"bool":     "False",
+}
+
+// Type resolution helpers.
+// These handle scalar, enum, repeated, and map types for each target language.
+
+// MessageTypeName renders a message type of the schema, e.g. Point or the
+// nested Outer.Inner, as the class the protobuf plugin of lang generates.
+// file is the imported proto file defining it, empty for the main file.
+// For swift, pkg is the message prefix from SwiftPrefix.
+func MessageTypeName(protoType, file, lang, pkg string) string {
+	switch lang {
+	case "kotlin":
+		// The Java outer class is named after the defining file.
+		outer := strings.ToUpper(pkg[:1]) + pkg[1:]
+		if file != "" {
+			outer = UpperCamel(path.Base(file))
+		}
+		return pkg + "." + outer + "." + protoType
+	case "swift":
+		return pkg + protoType
+	case "dart":
+		return strings.ReplaceAll(protoType, ".", "_")
+	case "ts":
+		// protobufjs names the plain-object interface of Outer.Inner
+		// Outer.IInner.
+		i := strings.LastIndex(protoType, ".") + 1
+		return pkg + "." + protoType[:i] + "I" + protoType[i:]
+	case "c":
+		return pkg + "_" + strings.ReplaceAll(protoType, ".", "_")
+	}
+	return protoType
+}
+
+// MapValueField returns the value of a map field as a field of its own.
+func MapValueField(f Field) Field {
+	return Field{Type: f.ValueType, IsMessage: f.ValueIsMessage, TypeFile: f.TypeFile}
+}
+
+// Helper to resolve a scalar type name from a proto type for a given language map.
+func LookupScalar(typeMaps map[string]string, protoType, fallback string) string {
+	if t, ok := typeMaps[protoType]; ok {
+		return t
+	}
+	return fallback
+}
+
+// proto2 explicit defaults.
+// Field.Default holds the raw constant from `[default = ...]`; enum defaults
+// are resolved to their number by the parser.
+
+var longTypes = map[string]bool{
+	"int64": true, "uint64": true, "sint64": true, "fixed64": true, "sfixed64": true,
+}
+
+// quoteString renders s as a literal delimited by quote, using only the
+// escapes every target language understands. Control characters use \uXXXX,
+// or \u{X} when braced is set (Swift).
+func quoteString(s string, quote byte, braced bool, escapeDollar bool) string {
+	var b strings.Builder
+	b.WriteByte(quote)
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == rune(quote):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '$' && escapeDollar:
+			b.WriteString(`\$`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			if braced {
+				fmt.Fprintf(&b, `\u{%x}`, r)
+			} else {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(quote)
+	return b.String()
+}
+
+// bytesList renders data as a comma-separated list of byte values.
+func bytesList(data []byte, signed bool) string {
+	parts := make([]string, len(data))
+	for i, c := range data {
+		if signed {
+			parts[i] = strconv.Itoa(int(int8(c)))
+		} else {
+			parts[i] = fmt.Sprintf("0x%02x", c)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// numericDefault validates a numeric default constant. inf/nan and other
+// non-literal values are rejected so the caller falls back to the type default.
+func numericDefault(s string) (string, bool) {
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return s, true
+	}
+	if _, err := strconv.ParseUint(s, 0, 64); err == nil {
+		return s, true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "iInN") {
+		return s, true
+	}
+	return "", false
+}
+
+// DefaultLiteral converts a proto2 explicit default into a literal for lang
+// ("python", "kotlin", "swift", "dart" or "ts"). ok is false when the field
+// has no usable explicit default.
+func DefaultLiteral(f Field, lang string) (string, bool) {
+	if f.Default == "" || f.IsRepeated || f.IsMap || f.IsMessage {
+		return "", false
+	}
+	switch {
+	case f.Type == "string":
+		s := protomodel.UnquoteString(f.Default)
+		switch lang {
+		case "kotlin":
+			return quoteString(s, '"', false, true), true
+		case "swift":
+			return quoteString(s, '"', true, false), true
+		case "dart":
+			return quoteString(s, '\'', false, true), true
+		case "ts":
+			return quoteString(s, '\'', false, false), true
+		}
+		return strconv.Quote(s), true
+	case f.Type == "bytes":
+		data := []byte(protomodel.UnquoteString(f.Default))
+		switch lang {
+		case "kotlin":
+			return "com.google.protobuf.ByteString.copyFrom(byteArrayOf(" + bytesList(data, true) + "))", true
+		case "swift":
+			return "Data([" + bytesList(data, false) + "])", true
+		case "dart":
+			return "const <int>[" + bytesList(data, false) + "]", true
+		case "ts":
+			return "new Uint8Array([" + bytesList(data, false) + "])", true
+		}
+		var b strings.Builder
+		b.WriteString(`b"`)
+		for _, c := range data {
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+		b.WriteByte('"')
+		return b.String(), true
+	case f.Type == "bool":
+		if f.Default != "true" && f.Default != "false" {
+			return "", false
+		}
+		if lang == "python" {
+			return strings.ToUpper(f.Default[:1]) + f.Default[1:], true
+		}
+		return f.Default, true
+	}
+
+	n, ok := numericDefault(f.Default)
+	if !ok {
+		return "", false
+	}
+	if lang == "kotlin" && !f.IsEnum {
+		switch {
+		case longTypes[f.Type]:
+			n += "L"
+		case f.Type == "float":
+			n += "f"
+		case f.Type == "double" && !strings.ContainsAny(n, ".eE"):
+			n += ".0"
+		}
+	}
+	return n, true
+}
@@ -0,0 +1,51 @@
+package gen
+
+import (
+	"testing"
+)
+
+func TestDefaultLiteral(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+		lang  string
+		want  string
+	}{
+		{"python string", Field{Type: "string", Default: `"hi"`}, "python", `"hi"`},
+		{"kotlin string escapes dollar", Field{Type: "string", Default: `"$5"`}, "kotlin", `"\$5"`},
+		{"dart string", Field{Type: "string", Default: `"it's"`}, "dart", `'it\'s'`},
+		{"swift control char", Field{Type: "string", Default: `"a\001"`}, "swift", `"a\u{1}"`},
+		{"python bool", Field{Type: "bool", Default: "true"}, "python", "True"},
+		{"swift bool", Field{Type: "bool", Default: "false"}, "swift", "false"},
+		{"kotlin long", Field{Type: "int64", Default: "-7"}, "kotlin", "-7L"},
+		{"kotlin float", Field{Type: "float", Default: "1.5"}, "kotlin", "1.5f"},
+		{"kotlin double", Field{Type: "double", Default: "2"}, "kotlin", "2.0"},
+		{"ts uint32", Field{Type: "uint32", Default: "42"}, "ts", "42"},
+		{"enum number", Field{Type: "Mode", IsEnum: true, Default: "2"}, "kotlin", "2"},
+		{"python bytes", Field{Type: "bytes", Default: `"\001A"`}, "python", `b"\x01\x41"`},
+		{"kotlin bytes", Field{Type: "bytes", Default: `"\377"`}, "kotlin", "com.google.protobuf.ByteString.copyFrom(byteArrayOf(-1))"},
+		{"swift bytes", Field{Type: "bytes", Default: `"AB"`}, "swift", "Data([0x41, 0x42])"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DefaultLiteral(tt.field, tt.lang)
+			if !ok || got != tt.want {
+				t.Errorf("defaultLiteral(%+v, %s) = %q, %v; want %q", tt.field, tt.lang, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultLiteral_Unusable(t *testing.T) {
+	fields := []Field{
+		{Type: "uint32"},
+		{Type: "float", Default: "inf"},
+		{Type: "float", Default: "nan"},
+		{Type: "uint32", Default: "5", IsRepeated: true},
+	}
+	for _, f := range fields {
+		if got, ok := DefaultLiteral(f, "python"); ok {
+			t.Errorf("defaultLiteral(%+v) = %q, want no default", f, got)
+		}
+	}
+}
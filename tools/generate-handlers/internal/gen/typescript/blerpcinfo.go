@@ -0,0 +1,53 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeTsBlerpcInfoHelpers emits the schema check of the TypeScript client
+// class.
+func writeTsBlerpcInfoHelpers(b *strings.Builder, cmd gen.Command, pkg string) {
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Checks that the peripheral was built from this client's schema. Call\n")
+	b.WriteString("   * once on connect; throws `SchemaMismatchError` when the schema hashes\n")
+	b.WriteString("   * differ.\n")
+	b.WriteString("   */\n")
+	b.WriteString(fmt.Sprintf("  async verifySchema(): Promise<%s.%s> {\n", pkg, cmd.ResponseMsg))
+	b.WriteString(fmt.Sprintf("    const info = await this.%s();\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("    if (info.schemaHash !== SCHEMA_HASH) {\n")
+	b.WriteString("      throw new SchemaMismatchError(SCHEMA_HASH, info.schemaHash, info.generatorVersion);\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return info;\n")
+	b.WriteString("  }\n")
+}
+
+// writeTsSchemaMismatchError emits the schema constants and the error
+// verifySchema throws, after the client class.
+func writeTsSchemaMismatchError(gctx *gen.Context, b *strings.Builder, commands []gen.Command) {
+	if _, ok := gen.BuiltinCommand(commands, "blerpc_info"); !ok {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** The schema this client was generated from, which verifySchema compares with the peripheral's. */\n")
+	b.WriteString(fmt.Sprintf("export const SCHEMA_HASH = '%s';\n", gctx.SchemaHash))
+	b.WriteString(fmt.Sprintf("export const GENERATOR_VERSION = '%s';\n", gen.GeneratorVersion))
+	b.WriteByte('\n')
+	b.WriteString("/** The peripheral was built from a different schema than this client. */\n")
+	b.WriteString("export class SchemaMismatchError extends Error {\n")
+	b.WriteString("  constructor(\n")
+	b.WriteString("    readonly expected: string,\n")
+	b.WriteString("    readonly actual: string,\n")
+	b.WriteString("    readonly generatorVersion: string,\n")
+	b.WriteString("  ) {\n")
+	b.WriteString("    super(\n")
+	b.WriteString("      `peripheral schema ${actual} (generator ${generatorVersion}) does not ` +\n")
+	b.WriteString("        `match this client's ${expected} (generator ${GENERATOR_VERSION})`,\n")
+	b.WriteString("    );\n")
+	b.WriteString("    this.name = 'SchemaMismatchError';\n")
+	b.WriteString("  }\n")
+	b.WriteString("}\n")
+}
@@ -0,0 +1,23 @@
+package typescript
+
+import (
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeTsBuiltinHelpers(b *strings.Builder, commands []gen.Command, pkg string) {
+	if cmd, ok := gen.BuiltinCommand(commands, "blerpc_info"); ok {
+		writeTsBlerpcInfoHelpers(b, cmd, pkg)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "log_stream"); ok {
+		writeTsLogStreamHelpers(b, cmd, pkg)
+	}
+	if cmd, ok := gen.BuiltinCommand(commands, "ping"); ok {
+		writeTsPingHelpers(b, cmd)
+	}
+	writeTsSettingsHelpers(b, commands, pkg)
+	if cmd, ok := gen.BuiltinCommand(commands, "time_sync"); ok {
+		writeTsTimeSyncHelpers(b, cmd, pkg)
+	}
+}
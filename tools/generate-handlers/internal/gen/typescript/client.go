@@ -0,0 +1,123 @@
+// Package typescript generates the TypeScript clients for Web Bluetooth
+// and Node.
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// tsParams renders the destructured parameter list and its type literal.
+// proto2 required fields are non-optional, in which case the parameter
+// object itself can no longer default to {}.
+func tsParams(fields []gen.Field, pkg string) (params, typeFields []string, objDefault string) {
+	objDefault = " = {}"
+	for _, f := range fields {
+		propName := tsPropertyName(f.Name)
+		if f.IsRequired {
+			params = append(params, propName)
+			typeFields = append(typeFields, fmt.Sprintf("%s: %s", propName, ResolveType(f, pkg)))
+			objDefault = ""
+			continue
+		}
+		params = append(params, fmt.Sprintf("%s = %s", propName, ResolveDefault(f)))
+		typeFields = append(typeFields, fmt.Sprintf("%s?: %s", propName, ResolveType(f, pkg)))
+	}
+	return params, typeFields, objDefault
+}
+
+// clientData fills ts_generated_client.tmpl. The sections of the
+// features, such as sessions and the built-ins, are written in Go.
+type clientData struct {
+	Pkg            string
+	Preamble       string // top-level declarations before the class
+	SessionHelpers string
+	Methods        []clientMethod
+	Helpers        string // the built-in helpers, inside the class
+	Trailer        string // the declarations after the class
+}
+
+// clientMethod is the method of a command. Stream is "p2c", "c2p" or
+// empty for a unary command. Params, Types and Default are the destructured
+// parameters, their type literal and the default of the parameter object;
+// Wrap breaks the signature over lines as Prettier would.
+type clientMethod struct {
+	Name, Request, IRequest, Response, Wire string
+	Stream                                  string
+	SessionProtected, ReplayProtected       bool
+	Params                                  []string
+	Types, Default                          string
+	Fields                                  []string
+	Wrap                                    bool
+}
+
+// GenerateClient returns GeneratedClient.ts: the abstract GeneratedClient
+// class with a method per command.
+func GenerateClient(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg string) string {
+	start, auth, sessions := gen.SessionCommands(commands)
+	d := clientData{Pkg: pkg}
+
+	var b strings.Builder
+	if gen.HasReplayProtected(commands) {
+		writeTsReplayCounter(&b)
+	}
+	if sessions {
+		writeTsSessionPrefix(&b)
+	}
+	d.Preamble = b.String()
+	if sessions {
+		b.Reset()
+		writeTsSessionHelpers(&b, start, auth, pkg)
+		d.SessionHelpers = b.String()
+	}
+
+	// Unary methods come first, then the streaming ones.
+	for _, stream := range []bool{false, true} {
+		for _, cmd := range commands {
+			dir, ok := streaming[cmd.Snake]
+			if ok != stream {
+				continue
+			}
+			m := clientMethod{
+				Name:             gen.ToLowerCamel(cmd.Camel),
+				Request:          pkg + "." + cmd.RequestMsg,
+				IRequest:         pkg + ".I" + cmd.RequestMsg,
+				Response:         pkg + "." + cmd.ResponseMsg,
+				Wire:             cmd.Wire(),
+				Stream:           dir,
+				SessionProtected: cmd.SessionProtected,
+				ReplayProtected:  cmd.ReplayProtected,
+			}
+			var typeFields []string
+			m.Params, typeFields, m.Default = tsParams(cmd.RequestFields, pkg)
+			m.Types = strings.Join(typeFields, "; ")
+			for _, f := range cmd.RequestFields {
+				m.Fields = append(m.Fields, tsPropertyName(f.Name))
+			}
+			signature := fmt.Sprintf("  async %s({ %s }: { %s }%s): Promise<%s",
+				m.Name, strings.Join(m.Params, ", "), m.Types, m.Default, m.Response)
+			switch dir {
+			case "":
+				m.Wrap = len(signature+"> {") > 100
+			case "p2c":
+				m.Wrap = len(signature+"[]> {") > 80
+			case "c2p":
+				m.Wrap = len(fmt.Sprintf("  async %s(messages: %s[]): Promise<%s> {", m.Name, m.IRequest, m.Response)) > 80
+			}
+			d.Methods = append(d.Methods, m)
+		}
+	}
+
+	b.Reset()
+	writeTsBuiltinHelpers(&b, commands, pkg)
+	d.Helpers = b.String()
+	b.Reset()
+	writeTsCharacteristics(&b, commands)
+	writeTsRoles(&b, commands)
+	writeTsSchemaMismatchError(gctx, &b, commands)
+	d.Trailer = b.String()
+
+	return gctx.RenderTemplate("ts_generated_client.tmpl", d)
+}
@@ -1,13 +1,17 @@
-package main
+package typescript
 
 import (
 	"strings"
 	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
 )
 
 func TestGenerateTsClient_Echo(t *testing.T) {
-	cmds := []Command{echoCommand()}
-	out := generateTsClient(cmds, nil, "blerpc")
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
 
 	mustContain := []string{
 		"export abstract class GeneratedClient",
@@ -15,6 +19,8 @@ func TestGenerateTsClient_Echo(t *testing.T) {
 		"message = ''",
 		"blerpc.EchoRequest.create({ message })",
 		"blerpc.EchoRequest.encode(req).finish()",
+		"  private rpcTail: Promise<unknown> = Promise.resolve();\n",
+		"    const respData = await this.exclusive(() =>\n      this.call('echo',",
 		"blerpc.EchoResponse.decode(respData)",
 	}
 	for _, s := range mustContain {
@@ -25,8 +31,9 @@ func TestGenerateTsClient_Echo(t *testing.T) {
 }
 
 func TestGenerateTsClient_CustomPkg(t *testing.T) {
-	cmds := []Command{echoCommand()}
-	out := generateTsClient(cmds, nil, "myapp")
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	out := GenerateClient(gctx, cmds, nil, "myapp")
 
 	mustContain := []string{
 		"import { myapp } from '../proto/myapp'",
@@ -44,12 +51,13 @@ func TestGenerateTsClient_CustomPkg(t *testing.T) {
 }
 
 func TestGenerateTsClient_MessageField(t *testing.T) {
-	cmds := []Command{messageFieldCommand()}
-	out := generateTsClient(cmds, nil, "blerpc")
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MessageFieldCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
 
 	mustContain := []string{
-		"address?: Address",
-		"address = {}",
+		"address?: blerpc.IAddress",
+		"address = undefined",
 	}
 	for _, s := range mustContain {
 		if !strings.Contains(out, s) {
@@ -59,8 +67,9 @@ func TestGenerateTsClient_MessageField(t *testing.T) {
 }
 
 func TestGenerateTsClient_Map(t *testing.T) {
-	cmds := []Command{mapCommand()}
-	out := generateTsClient(cmds, nil, "blerpc")
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.MapCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
 
 	mustContain := []string{
 		"labels?: Record<string, string>",
@@ -76,8 +85,9 @@ func TestGenerateTsClient_Map(t *testing.T) {
 }
 
 func TestGenerateTsClient_Repeated(t *testing.T) {
-	cmds := []Command{repeatedCommand()}
-	out := generateTsClient(cmds, nil, "blerpc")
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.RepeatedCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
 
 	mustContain := []string{
 		"names?: string[]",
@@ -93,8 +103,9 @@ func TestGenerateTsClient_Repeated(t *testing.T) {
 }
 
 func TestGenerateTsClient_Enum(t *testing.T) {
-	cmds := []Command{enumCommand()}
-	out := generateTsClient(cmds, nil, "blerpc")
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EnumCommand()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
 
 	if !strings.Contains(out, "async getStatus(") {
 		t.Errorf("TS client enum missing getStatus method\nGot:\n%s", out)
@@ -102,9 +113,10 @@ func TestGenerateTsClient_Enum(t *testing.T) {
 }
 
 func TestGenerateTsClient_StreamP2C(t *testing.T) {
-	cmds := []Command{streamP2CCommand()}
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamP2CCommand()}
 	streaming := map[string]string{"counter_stream": "p2c"}
-	out := generateTsClient(cmds, streaming, "blerpc")
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
 
 	mustContain := []string{
 		"async counterStream(",
@@ -120,9 +132,10 @@ func TestGenerateTsClient_StreamP2C(t *testing.T) {
 }
 
 func TestGenerateTsClient_StreamC2P(t *testing.T) {
-	cmds := []Command{streamC2PCommand()}
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.StreamC2PCommand()}
 	streaming := map[string]string{"counter_upload": "c2p"}
-	out := generateTsClient(cmds, streaming, "blerpc")
+	out := GenerateClient(gctx, cmds, streaming, "blerpc")
 
 	mustContain := []string{
 		"async counterUpload(",
@@ -136,3 +149,14 @@ func TestGenerateTsClient_StreamC2P(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateTsClient_Proto2(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.Proto2Command()}
+	out := GenerateClient(gctx, cmds, nil, "blerpc")
+
+	want := "}: { address: number; label?: string }): Promise<blerpc.ConfigureResponse> {"
+	if !strings.Contains(out, want) {
+		t.Errorf("TypeScript client proto2 missing %q\nGot:\n%s", want, out)
+	}
+}
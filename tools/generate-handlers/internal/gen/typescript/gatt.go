@@ -0,0 +1,31 @@
+package typescript
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+var reTsIdent = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func writeTsCharacteristics(b *strings.Builder, commands []gen.Command) {
+	if gen.GATTServiceUUID(commands) == "" {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** GATT service of the characteristic-per-command mode. */\n")
+	b.WriteString(fmt.Sprintf("export const COMMAND_SERVICE_UUID = '%s';\n", gen.GATTServiceUUID(commands)))
+	b.WriteByte('\n')
+	b.WriteString("/** GATT characteristic UUID per command, keyed by wire name. */\n")
+	b.WriteString("export const COMMAND_CHARACTERISTICS: Readonly<Record<string, string>> = {\n")
+	for _, cmd := range commands {
+		key := cmd.Wire()
+		if !reTsIdent.MatchString(key) {
+			key = "'" + key + "'"
+		}
+		b.WriteString(fmt.Sprintf("  %s: '%s',\n", key, cmd.CharUUID))
+	}
+	b.WriteString("};\n")
+}
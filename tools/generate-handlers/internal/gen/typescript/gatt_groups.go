@@ -0,0 +1,43 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeTsGattGroups(gctx *gen.Context, b *strings.Builder) {
+	if len(gctx.GATTGroups) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** Services grouping commands apart from the RPC service, by name. */\n")
+	b.WriteString("export const SERVICES: Readonly<\n")
+	b.WriteString("  Record<string, { service: string; characteristic: string }>\n")
+	b.WriteString("> = {\n")
+	for _, g := range gctx.GATTGroups {
+		b.WriteString(fmt.Sprintf("  %s: {\n", g.Name))
+		b.WriteString(fmt.Sprintf("    service: '%s',\n", g.ServiceUUID))
+		b.WriteString(fmt.Sprintf("    characteristic: '%s',\n", g.CharUUID))
+		b.WriteString("  },\n")
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/** Service of each grouped command, by wire name; the others use SERVICE_UUID. */\n")
+	b.WriteString("export const COMMAND_SERVICES: Readonly<Record<string, string>> = {\n")
+	for _, c := range gctx.GATTGroupCommands() {
+		key := c[0]
+		if !reTsIdent.MatchString(key) {
+			key = "'" + key + "'"
+		}
+		b.WriteString(fmt.Sprintf("  %s: '%s',\n", key, c[1]))
+	}
+	b.WriteString("};\n")
+	b.WriteByte('\n')
+	b.WriteString("/** The characteristic `command` is written to. */\n")
+	b.WriteString("export function characteristicFor(command: string): string {\n")
+	b.WriteString("  const service = COMMAND_SERVICES[command];\n")
+	b.WriteString("  return service ? SERVICES[service].characteristic : CHAR_UUID;\n")
+	b.WriteString("}\n")
+}
@@ -0,0 +1,10 @@
+package typescript
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// tsPropertyName converts a snake_case field name to lowerCamelCase (same as Swift).
+func tsPropertyName(fieldName string) string {
+	return gen.SwiftPropertyName(fieldName)
+}
@@ -0,0 +1,40 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeTsLogStreamHelpers emits the log reader of the TypeScript client
+// class.
+func writeTsLogStreamHelpers(b *strings.Builder, cmd gen.Command, pkg string) {
+	respCls := pkg + "." + cmd.ResponseMsg
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Drains the firmware log into timestamped entries, oldest first. The\n")
+	b.WriteString("   * message of each entry joins the fragments of a long message, and the\n")
+	b.WriteString("   * timestamp is when it was logged.\n")
+	b.WriteString("   */\n")
+	b.WriteString("  async readLogs({ minLevel = 0, maxEntries = 0 }: { minLevel?: number; maxEntries?: number } = {}): Promise<\n")
+	b.WriteString(fmt.Sprintf("    { timestamp: Date; entry: %s }[]\n", respCls))
+	b.WriteString("  > {\n")
+	b.WriteString(fmt.Sprintf("    const responses = await this.%s({ minLevel, maxEntries });\n", gen.ToLowerCamel(cmd.Camel)))
+	b.WriteString("    const received = Date.now();\n")
+	b.WriteString(fmt.Sprintf("    const logs: { timestamp: Date; entry: %s }[] = [];\n", respCls))
+	b.WriteString(fmt.Sprintf("    let first: %s | undefined;\n", respCls))
+	b.WriteString("    for (const resp of responses) {\n")
+	b.WriteString("      if (first === undefined) {\n")
+	b.WriteString("        first = resp;\n")
+	b.WriteString("      } else {\n")
+	b.WriteString("        first.message += resp.message;\n")
+	b.WriteString("      }\n")
+	b.WriteString("      if (resp.more) continue;\n")
+	b.WriteString("      const ageMs = (first.nowMs - first.uptimeMs) >>> 0;\n")
+	b.WriteString("      logs.push({ timestamp: new Date(received - ageMs), entry: first });\n")
+	b.WriteString("      first = undefined;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return logs;\n")
+	b.WriteString("  }\n")
+}
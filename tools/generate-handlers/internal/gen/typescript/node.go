@@ -0,0 +1,13 @@
+package typescript
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateNodeClient returns NodeBleClient.ts, a GeneratedClient for Node
+// scripts such as test rigs that carries the commands over
+// @abandonware/noble. It shares the protocol session with the Web Bluetooth
+// client and sits next to the generated GeneratedClient.ts.
+func GenerateNodeClient(gctx *gen.Context, protocolImport string) string {
+	return gctx.RenderTemplate("ts_node_client.tmpl", tsClientData{protocolImport, gctx.ServiceUUID, gctx.CharUUID})
+}
@@ -0,0 +1,32 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestGenerateTsNodeClient(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateNodeClient(gctx, "@example/protocol")
+	for _, want := range []string{
+		"import noble, { Characteristic, Peripheral } from '@abandonware/noble';\n",
+		"} from '@example/protocol';\n",
+		"import { GeneratedClient } from './GeneratedClient';\n",
+		"export const CHAR_UUID = '12340002-0000-1000-8000-00805f9b34fb';\n",
+		"export class NodeBleClient extends GeneratedClient {\n",
+		"noble.startScanningAsync([nobleUuid(SERVICE_UUID)], false)",
+		"await this.char.writeAsync(Buffer.from(data), true);\n",
+		"this.splitter = new ContainerSplitter(this.peripheral.mtu ?? 23);\n",
+		// The protocol session is shared with the Web Bluetooth client.
+		"  protected async streamSend(\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Contains(out, "@@") {
+		t.Error("unreplaced placeholder")
+	}
+}
@@ -0,0 +1,37 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeTsPingHelpers emits the latency and keepalive helpers of the
+// TypeScript client class.
+func writeTsPingHelpers(b *strings.Builder, cmd gen.Command) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("  /** Pings the peripheral and returns the round trip in milliseconds. */\n")
+	b.WriteString("  async measureLatency(): Promise<number> {\n")
+	b.WriteString("    const start = Date.now();\n")
+	b.WriteString(fmt.Sprintf("    await this.%s();\n", method))
+	b.WriteString("    return Date.now() - start;\n")
+	b.WriteString("  }\n")
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Pings the peripheral every `intervalMs`, passing each round trip to\n")
+	b.WriteString("   * `onLatency`, until `signal` is aborted. It rejects as soon as a ping\n")
+	b.WriteString("   * fails, so a dead connection shows before a long call times out.\n")
+	b.WriteString("   */\n")
+	b.WriteString("  async keepalive({\n")
+	b.WriteString("    intervalMs = 5000,\n")
+	b.WriteString("    onLatency,\n")
+	b.WriteString("    signal,\n")
+	b.WriteString("  }: { intervalMs?: number; onLatency?: (latencyMs: number) => void; signal?: AbortSignal } = {}): Promise<void> {\n")
+	b.WriteString("    while (!signal?.aborted) {\n")
+	b.WriteString("      onLatency?.(await this.measureLatency());\n")
+	b.WriteString("      await new Promise((resolve) => setTimeout(resolve, intervalMs));\n")
+	b.WriteString("    }\n")
+	b.WriteString("  }\n")
+}
@@ -0,0 +1,53 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeTsReplayCounter emits the counter of replay-protected requests. The
+// HMAC comes from Web Crypto, so the helper is async; the callers await it
+// inside exclusive so requests go out in counter order.
+func writeTsReplayCounter(b *strings.Builder) {
+	b.WriteString("let lastReplayCounter = 0n;\n")
+	b.WriteByte('\n')
+	b.WriteString("/**\n")
+	b.WriteString(" * Leads replay-protected requests with a counter, 8 bytes little endian,\n")
+	b.WriteString(" * and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of\n")
+	b.WriteString(" * the command name, a NUL, the counter and the request. The peripheral drops\n")
+	b.WriteString(" * requests whose MAC is wrong or whose counter is not above the last one it\n")
+	b.WriteString(" * accepted; microseconds since the epoch keep it increasing across restarts.\n")
+	b.WriteString(" */\n")
+	b.WriteString("async function withReplayCounter(\n")
+	b.WriteString("  key: Uint8Array | undefined,\n")
+	b.WriteString("  command: string,\n")
+	b.WriteString("  requestData: Uint8Array,\n")
+	b.WriteString("): Promise<Uint8Array> {\n")
+	b.WriteString("  if (key === undefined) {\n")
+	b.WriteString("    throw new Error('sessionKey is not set');\n")
+	b.WriteString("  }\n")
+	b.WriteString("  const now = BigInt(Date.now()) * 1000n;\n")
+	b.WriteString("  lastReplayCounter = now > lastReplayCounter ? now : lastReplayCounter + 1n;\n")
+	b.WriteString("  const name = new TextEncoder().encode(command);\n")
+	b.WriteString("  const signed = new Uint8Array(name.length + 1 + 8 + requestData.length);\n")
+	b.WriteString("  signed.set(name);\n")
+	b.WriteString("  new DataView(signed.buffer).setBigUint64(name.length + 1, lastReplayCounter, true);\n")
+	b.WriteString("  signed.set(requestData, name.length + 9);\n")
+	b.WriteString("  const hmacKey = await crypto.subtle.importKey(\n")
+	b.WriteString("    'raw',\n")
+	b.WriteString("    key,\n")
+	b.WriteString("    { name: 'HMAC', hash: 'SHA-256' },\n")
+	b.WriteString("    false,\n")
+	b.WriteString("    ['sign'],\n")
+	b.WriteString("  );\n")
+	b.WriteString("  const mac = new Uint8Array(await crypto.subtle.sign('HMAC', hmacKey, signed));\n")
+	b.WriteString(fmt.Sprintf("  const out = new Uint8Array(%d + requestData.length);\n", gen.ReplayPrefixSize))
+	b.WriteString("  out.set(signed.subarray(name.length + 1, name.length + 9));\n")
+	b.WriteString(fmt.Sprintf("  out.set(mac.subarray(0, %d), 8);\n", gen.ReplayMACSize))
+	b.WriteString(fmt.Sprintf("  out.set(requestData, %d);\n", gen.ReplayPrefixSize))
+	b.WriteString("  return out;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
@@ -0,0 +1,26 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func writeTsRoles(b *strings.Builder, commands []gen.Command) {
+	privileged := gen.PrivilegedCommands(commands)
+	if len(privileged) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	b.WriteString("/** Role each command requires on the peripheral, keyed by wire name; others need 'user'. */\n")
+	b.WriteString("export const COMMAND_ROLES: Readonly<Record<string, string>> = {\n")
+	for _, cmd := range privileged {
+		key := cmd.Wire()
+		if !reTsIdent.MatchString(key) {
+			key = "'" + key + "'"
+		}
+		b.WriteString(fmt.Sprintf("  %s: '%s',\n", key, cmd.Role))
+	}
+	b.WriteString("};\n")
+}
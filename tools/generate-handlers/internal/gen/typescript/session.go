@@ -0,0 +1,102 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeTsSessionPrefix emits the helpers of the TypeScript client leading a
+// request with the session token.
+func writeTsSessionPrefix(b *strings.Builder) {
+	tag := make([]string, len(gen.StatusTag))
+	for i, c := range gen.StatusTag {
+		tag[i] = fmt.Sprintf("0x%02x", c)
+	}
+	b.WriteString("// Error response of a peripheral that has no session with the token.\n")
+	b.WriteString(fmt.Sprintf("const UNAUTHENTICATED = Uint8Array.of(%s, %d);\n", strings.Join(tag, ", "), gen.StatusCodeValue("UNAUTHENTICATED")))
+	b.WriteByte('\n')
+	b.WriteString("function isUnauthenticated(data: Uint8Array): boolean {\n")
+	b.WriteString("  return data.length === UNAUTHENTICATED.length && data.every((b, i) => b === UNAUTHENTICATED[i]);\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+	b.WriteString("function withToken(token: Uint8Array, requestData: Uint8Array): Uint8Array {\n")
+	b.WriteString("  const out = new Uint8Array(token.length + requestData.length);\n")
+	b.WriteString("  out.set(token);\n")
+	b.WriteString("  out.set(requestData, token.length);\n")
+	b.WriteString("  return out;\n")
+	b.WriteString("}\n")
+	b.WriteByte('\n')
+}
+
+// writeTsSessionHelpers emits the session key and handshake of the
+// TypeScript client. The HMAC comes from Web Crypto.
+func writeTsSessionHelpers(b *strings.Builder, start, auth gen.Command, pkg string) {
+	msg := func(name string) string { return pkg + "." + name }
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Key shared with the peripheral, which the session handshake proves\n")
+	b.WriteString("   * knowledge of. Set it before calling a session- or replay-protected\n")
+	b.WriteString("   * command. The proof is signed with Web Crypto; React Native needs a\n")
+	b.WriteString("   * polyfill of crypto.subtle.\n")
+	b.WriteString("   */\n")
+	b.WriteString("  sessionKey?: Uint8Array;\n")
+	b.WriteByte('\n')
+	b.WriteString("  private sessionToken?: Uint8Array;\n")
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Authenticates to the peripheral, unlocking its session-protected\n")
+	b.WriteString("   * commands. Protected calls open a session as needed; call this to\n")
+	b.WriteString("   * authenticate up front.\n")
+	b.WriteString("   */\n")
+	b.WriteString("  async openSession(): Promise<void> {\n")
+	b.WriteString("    await this.exclusive(() => this.handshake());\n")
+	b.WriteString("  }\n")
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Runs the session handshake inside the caller's exclusive call and keeps\n")
+	b.WriteString("   * the token: the proof is an HMAC-SHA256 of the challenge under\n")
+	b.WriteString("   * sessionKey.\n")
+	b.WriteString("   */\n")
+	b.WriteString("  private async handshake(): Promise<Uint8Array> {\n")
+	b.WriteString("    if (this.sessionKey === undefined) {\n")
+	b.WriteString("      throw new Error('sessionKey is not set');\n")
+	b.WriteString("    }\n")
+	b.WriteString("    const startData = await this.call(\n")
+	b.WriteString(fmt.Sprintf("      '%s',\n", start.Wire()))
+	b.WriteString(fmt.Sprintf("      %s.encode(%s.create({})).finish(),\n", msg(start.RequestMsg), msg(start.RequestMsg)))
+	b.WriteString("    );\n")
+	b.WriteString(fmt.Sprintf("    const { challenge } = %s.decode(startData);\n", msg(start.ResponseMsg)))
+	b.WriteString("    const key = await crypto.subtle.importKey(\n")
+	b.WriteString("      'raw',\n")
+	b.WriteString("      this.sessionKey,\n")
+	b.WriteString("      { name: 'HMAC', hash: 'SHA-256' },\n")
+	b.WriteString("      false,\n")
+	b.WriteString("      ['sign'],\n")
+	b.WriteString("    );\n")
+	b.WriteString("    const proof = new Uint8Array(await crypto.subtle.sign('HMAC', key, challenge));\n")
+	b.WriteString("    const authData = await this.call(\n")
+	b.WriteString(fmt.Sprintf("      '%s',\n", auth.Wire()))
+	b.WriteString(fmt.Sprintf("      %s.encode(%s.create({ proof })).finish(),\n", msg(auth.RequestMsg), msg(auth.RequestMsg)))
+	b.WriteString("    );\n")
+	b.WriteString(fmt.Sprintf("    const { token } = %s.decode(authData);\n", msg(auth.ResponseMsg)))
+	b.WriteString("    this.sessionToken = token;\n")
+	b.WriteString("    return token;\n")
+	b.WriteString("  }\n")
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Sends a session-protected request led by the session token, opening a\n")
+	b.WriteString("   * session first when there is none. A peripheral that lost the session,\n")
+	b.WriteString("   * e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent\n")
+	b.WriteString("   * once more in a new session.\n")
+	b.WriteString("   */\n")
+	b.WriteString("  private async sessionCall(cmdName: string, requestData: Uint8Array): Promise<Uint8Array> {\n")
+	b.WriteString("    const token = this.sessionToken ?? (await this.handshake());\n")
+	b.WriteString("    const respData = await this.call(cmdName, withToken(token, requestData));\n")
+	b.WriteString("    if (!isUnauthenticated(respData)) {\n")
+	b.WriteString("      return respData;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    return this.call(cmdName, withToken(await this.handshake(), requestData));\n")
+	b.WriteString("  }\n")
+}
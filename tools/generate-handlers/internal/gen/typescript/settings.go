@@ -0,0 +1,36 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeTsSettingsHelpers emits the typed settings accessors of the
+// TypeScript client class.
+func writeTsSettingsHelpers(b *strings.Builder, commands []gen.Command, pkg string) {
+	get, set := gen.SettingsCommands(commands)
+	if get != nil {
+		cls := pkg + "." + get.Settings.Name
+		for _, f := range get.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("  /** Reads the %s setting. */\n", f.Name))
+			b.WriteString(fmt.Sprintf("  async get%sSetting(): Promise<%s> {\n", gen.SettingLabel(f), ResolveType(f, pkg)))
+			b.WriteString(fmt.Sprintf("    const resp = await this.%s({ field: %d });\n", gen.ToLowerCamel(get.Camel), f.Number))
+			b.WriteString(fmt.Sprintf("    return %s.decode(resp.value).%s;\n", cls, tsPropertyName(f.Name)))
+			b.WriteString("  }\n")
+		}
+	}
+	if set != nil {
+		cls := pkg + "." + set.Settings.Name
+		for _, f := range set.Settings.Fields {
+			b.WriteByte('\n')
+			b.WriteString(fmt.Sprintf("  /** Writes the %s setting. */\n", f.Name))
+			b.WriteString(fmt.Sprintf("  async set%sSetting(value: %s): Promise<void> {\n", gen.SettingLabel(f), ResolveType(f, pkg)))
+			b.WriteString(fmt.Sprintf("    const settings = %s.encode({ %s: value }).finish();\n", cls, tsPropertyName(f.Name)))
+			b.WriteString(fmt.Sprintf("    await this.%s({ field: %d, value: settings });\n", gen.ToLowerCamel(set.Camel), f.Number))
+			b.WriteString("  }\n")
+		}
+	}
+}
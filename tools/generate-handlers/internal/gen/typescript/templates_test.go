@@ -0,0 +1,42 @@
+package typescript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	gctx := gen.NewContext()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ts_client_session.tmpl"), []byte("// session for {{.CharUUID}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Files other than templates are ignored.
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gctx.LoadTemplates(protomodel.OS, dir); err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+	for _, out := range []string{GenerateWebClient(gctx, "blerpc-protocol"), GenerateNodeClient(gctx, "blerpc-protocol")} {
+		if !strings.HasSuffix(out, "// session for "+gctx.CharUUID+"\n") {
+			t.Errorf("included template not replaced\nGot:\n%s", out)
+		}
+	}
+	if out := GenerateWebClient(gctx, "blerpc-protocol"); !strings.Contains(out, "} from 'blerpc-protocol';") {
+		t.Error("untouched template changed")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "ts_web.tmpl"), []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gctx.LoadTemplates(protomodel.OS, dir); err == nil || !strings.Contains(err.Error(), "ts_web.tmpl does not replace a built-in template") {
+		t.Errorf("expected unknown template error, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// writeTsTimeSyncHelpers emits the clock helper of the TypeScript client
+// class.
+func writeTsTimeSyncHelpers(b *strings.Builder, cmd gen.Command, pkg string) {
+	method := gen.ToLowerCamel(cmd.Camel)
+	b.WriteByte('\n')
+	b.WriteString("  /**\n")
+	b.WriteString("   * Sets the peripheral's wall clock to this device's. With `compensate`, a\n")
+	b.WriteString("   * first exchange measures the round trip and the second adds half of it,\n")
+	b.WriteString("   * the estimated delivery delay.\n")
+	b.WriteString("   */\n")
+	b.WriteString(fmt.Sprintf("  async syncTime({ compensate = false }: { compensate?: boolean } = {}): Promise<%s.%s> {\n", pkg, cmd.ResponseMsg))
+	b.WriteString("    let offsetUs = 0;\n")
+	b.WriteString("    if (compensate) {\n")
+	b.WriteString("      const start = Date.now();\n")
+	b.WriteString(fmt.Sprintf("      await this.%s({ unixTimeUs: Date.now() * 1000 });\n", method))
+	b.WriteString("      offsetUs = (Date.now() - start) * 500;\n")
+	b.WriteString("    }\n")
+	b.WriteString(fmt.Sprintf("    return this.%s({ unixTimeUs: Date.now() * 1000, offsetUs });\n", method))
+	b.WriteString("  }\n")
+}
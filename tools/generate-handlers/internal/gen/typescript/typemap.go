@@ -0,0 +1,84 @@
+package typescript
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// tsDefaults maps proto field types to TypeScript default values.
+var tsDefaults = map[string]string{
+	"string":   "''",
+	"bytes":    "new Uint8Array(0)",
+	"uint32":   "0",
+	"int32":    "0",
+	"uint64":   "0",
+	"int64":    "0",
+	"sint32":   "0",
+	"sint64":   "0",
+	"fixed32":  "0",
+	"fixed64":  "0",
+	"sfixed32": "0",
+	"sfixed64": "0",
+	"float":    "0",
+	"double":   "0",
+	"bool":     "false",
+}
+
+func scalarTsType(f gen.Field, pkg string) string {
+	if f.IsEnum {
+		return "number"
+	}
+	if f.IsMessage && !protomodel.IsWellKnownType(f.Type) {
+		return gen.MessageTypeName(f.Type, f.TypeFile, "ts", pkg)
+	}
+	if f.IsMessage {
+		return f.Type
+	}
+	if t, ok := gen.TsTypes[f.Type]; ok {
+		return t
+	}
+	return "unknown"
+}
+
+func ResolveType(f gen.Field, pkg string) string {
+	if f.IsMap {
+		k := gen.LookupScalar(gen.TsTypes, f.KeyType, "string")
+		v := gen.LookupScalar(gen.TsTypes, f.ValueType, f.ValueType)
+		if f.ValueIsMessage {
+			v = scalarTsType(gen.MapValueField(f), pkg)
+		}
+		return "Record<" + k + ", " + v + ">"
+	}
+	base := scalarTsType(f, pkg)
+	if f.IsRepeated {
+		return base + "[]"
+	}
+	return base
+}
+
+func ResolveDefault(f gen.Field) string {
+	if f.Oneof != "" {
+		// create() skips undefined members, so at most the given one is set.
+		return "undefined"
+	}
+	if d, ok := gen.DefaultLiteral(f, "ts"); ok {
+		return d
+	}
+	if f.IsMap {
+		return "{}"
+	}
+	if f.IsRepeated {
+		return "[]"
+	}
+	if f.IsEnum {
+		return "0"
+	}
+	if f.IsMessage {
+		// create() skips undefined fields, leaving the message unset.
+		return "undefined"
+	}
+	if d, ok := tsDefaults[f.Type]; ok {
+		return d
+	}
+	return "undefined"
+}
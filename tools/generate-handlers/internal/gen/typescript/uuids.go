@@ -0,0 +1,21 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// GenerateUUIDs returns GeneratedUuids.ts.
+func GenerateUUIDs(gctx *gen.Context) string {
+	var b strings.Builder
+
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteByte('\n')
+	b.WriteString("// GATT UUIDs of the blerpc service, from blerpc.yaml.\n")
+	b.WriteString(fmt.Sprintf("export const SERVICE_UUID = '%s';\n", gctx.ServiceUUID))
+	b.WriteString(fmt.Sprintf("export const CHAR_UUID = '%s';\n", gctx.CharUUID))
+	writeTsGattGroups(gctx, &b)
+	return b.String()
+}
@@ -0,0 +1,20 @@
+package typescript
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// tsClientData fills the templates of the TypeScript BLE clients.
+type tsClientData struct {
+	Protocol    string // module of the TypeScript protocol library, -ts-protocol-import
+	ServiceUUID string
+	CharUUID    string
+}
+
+// GenerateWebClient returns WebBluetoothClient.ts, a GeneratedClient for
+// browsers that frames the commands with the TypeScript protocol library
+// and carries them over the Web Bluetooth API. It sits next to the
+// generated GeneratedClient.ts and needs @types/web-bluetooth to compile.
+func GenerateWebClient(gctx *gen.Context, protocolImport string) string {
+	return gctx.RenderTemplate("ts_web_client.tmpl", tsClientData{protocolImport, gctx.ServiceUUID, gctx.CharUUID})
+}
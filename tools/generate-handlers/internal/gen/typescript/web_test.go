@@ -0,0 +1,31 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestGenerateTsWebClient(t *testing.T) {
+	gctx := gen.NewContext()
+	out := GenerateWebClient(gctx, "@example/protocol")
+	for _, want := range []string{
+		"} from '@example/protocol';\n",
+		"import { GeneratedClient } from './GeneratedClient';\n",
+		"export const SERVICE_UUID = '12340001-0000-1000-8000-00805f9b34fb';\n",
+		"export class WebBluetoothClient extends GeneratedClient {\n",
+		"navigator.bluetooth.requestDevice({ filters: [{ services: [SERVICE_UUID] }] })",
+		"await this.char.writeValueWithoutResponse(data);\n",
+		"  protected async call(cmdName: string, requestData: Uint8Array): Promise<Uint8Array> {\n",
+		"  protected async streamReceive(cmdName: string, requestData: Uint8Array): Promise<Uint8Array[]> {\n",
+		"    await this.write(makeStreamEndC2P(this.splitter!.nextTransactionId()).serialize());\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Contains(out, "@@") {
+		t.Error("unreplaced placeholder")
+	}
+}
@@ -0,0 +1,11 @@
+package gen
+
+// UnwrapName returns the snake_case name of the unwrapped method of cmd.
+func UnwrapName(cmd Command) string {
+	return cmd.Snake + "_" + cmd.ResponseFields[0].Name
+}
+
+// UnwrapCamel returns the lowerCamelCase name of the unwrapped method of cmd.
+func UnwrapCamel(cmd Command) string {
+	return ToLowerCamel(cmd.Camel) + UpperCamel(cmd.ResponseFields[0].Name)
+}
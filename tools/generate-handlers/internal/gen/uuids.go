@@ -0,0 +1,13 @@
+package gen
+
+// The service and characteristic UUIDs of the multiplexed RPC
+// characteristic come from the gatt: section of blerpc.yaml. Every
+// generated file naming them (the GATT service, the Python server, the
+// scanners and the TypeScript clients) uses the ServiceUUID and CharUUID of
+// the Context, and each central gets a constants file its hand-written
+// transport imports instead of declaring the UUIDs again.
+
+const (
+	DefaultServiceUUID = "12340001-0000-1000-8000-00805f9b34fb"
+	DefaultCharUUID    = "12340002-0000-1000-8000-00805f9b34fb"
+)
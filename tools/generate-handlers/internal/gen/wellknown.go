@@ -0,0 +1,18 @@
+package gen
+
+import (
+	"strings"
+)
+
+// UsesWellKnownType reports whether any request or response field of the
+// commands has the given well-known type.
+func UsesWellKnownType(commands []Command, protoType string) bool {
+	for _, cmd := range commands {
+		for f := range cmd.Fields() {
+			if strings.TrimPrefix(f.Type, ".") == protoType {
+				return true
+			}
+		}
+	}
+	return false
+}
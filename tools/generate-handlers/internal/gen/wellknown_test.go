@@ -0,0 +1,24 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestParseProtoReader_WellKnownTypes(t *testing.T) {
+	proto := `syntax = "proto3";
+import "google/protobuf/timestamp.proto";
+message TelemetryRequest {
+  google.protobuf.Timestamp since = 1;
+}`
+	pf, err := protomodel.ParseReader(strings.NewReader(proto))
+	if err != nil {
+		t.Fatalf("protomodel.ParseReader: %v", err)
+	}
+	f := pf.Messages[0].Fields[0]
+	if f.Type != "google.protobuf.Timestamp" || !f.IsMessage {
+		t.Errorf("expected Timestamp message field, got %+v", f)
+	}
+}
@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// Messages annotated with (blerpc.advertising) describe state broadcast
+// without a connection. Each is sent as manufacturer specific data: the
+// company identifier (little endian), the advertisement type from the
+// annotation, then the protobuf-encoded message. The peripheral gets nanopb
+// encoders; the Python, Kotlin, Swift and Go scanners get parsers that pick
+// the message class by type.
+
+// defaultAdvMaxSize is the default -adv-max-size: the manufacturer data a
+// legacy advertisement carries next to the flags AD structure (31 bytes
+// minus 3 for flags, 2 for the AD header and 2 for the company identifier).
+const defaultAdvMaxSize = 24
+
+// scalarMaxSize is the largest encoding of a scalar field value, tag
+// excluded.
+var scalarMaxSize = map[string]int{
+	"bool":   1,
+	"int32":  10, // negative values are sign-extended to 64 bits
+	"int64":  10,
+	"uint32": 5,
+	"uint64": 10,
+	"sint32": 5,
+	"sint64": 10,
+
+	"fixed32":  4,
+	"sfixed32": 4,
+	"float":    4,
+	"fixed64":  8,
+	"sfixed64": 8,
+	"double":   8,
+}
+
+// varintSize returns the encoded length of v as a protobuf varint.
+func varintSize(v int) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// advFieldMaxSize returns the largest encoding of a field, tag included, or
+// an error if the field has no size bound.
+func advFieldMaxSize(msg string, f gen.Field, limits map[string]protomodel.FieldLimits) (int, error) {
+	tag := varintSize(f.Number << 3)
+	switch {
+	case f.IsRepeated || f.IsMap:
+		return 0, fmt.Errorf("field %s.%s: repeated and map fields are not supported in advertisements", msg, f.Name)
+	case f.IsEnum:
+		return tag + 10, nil
+	case f.Type == "string" || f.Type == "bytes":
+		n := limits[msg+"."+f.Name].MaxSize
+		if n == 0 {
+			return 0, fmt.Errorf("field %s.%s: set max_size in the .options file to bound the advertisement", msg, f.Name)
+		}
+		return tag + varintSize(n) + n, nil
+	}
+	if n, ok := scalarMaxSize[f.Type]; ok {
+		return tag + n, nil
+	}
+	return 0, fmt.Errorf("field %s.%s: %s fields are not supported in advertisements", msg, f.Name, f.Type)
+}
+
+// discoverAdvertisements returns the messages annotated with
+// (blerpc.advertising), ordered by type, and checks that each fits maxSize
+// bytes of manufacturer data after the company identifier.
+func discoverAdvertisements(messages []gen.Message, limits map[string]protomodel.FieldLimits, maxSize int) ([]gen.Advertisement, error) {
+	var advs []gen.Advertisement
+	byType := make(map[int]string)
+	for _, m := range messages {
+		v, ok := m.Options["blerpc.advertising"]
+		if !ok {
+			continue
+		}
+		typ, err := strconv.ParseInt(v, 0, 0)
+		if err != nil || typ < 1 || typ > 255 {
+			return nil, fmt.Errorf("message %s: advertising type %q must be between 1 and 255", m.Name, v)
+		}
+		if other, ok := byType[int(typ)]; ok {
+			return nil, fmt.Errorf("messages %s and %s share advertising type %d", other, m.Name, typ)
+		}
+		byType[int(typ)] = m.Name
+
+		size := 1
+		for _, f := range m.Fields {
+			n, err := advFieldMaxSize(m.Name, f, limits)
+			if err != nil {
+				return nil, err
+			}
+			size += n
+		}
+		if size > maxSize {
+			return nil, fmt.Errorf("message %s: advertisement may take %d bytes, more than the %d allowed by -adv-max-size", m.Name, size, maxSize)
+		}
+		advs = append(advs, gen.Advertisement{Message: m, Snake: protomodel.CamelToSnake(m.Name), Type: int(typ), MaxSize: size})
+	}
+	sort.Slice(advs, func(i, j int) bool { return advs[i].Type < advs[j].Type })
+	return advs, nil
+}
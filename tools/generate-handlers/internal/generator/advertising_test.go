@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+const advertisingProto = `syntax = "proto3";
+
+package blerpc;
+
+import "blerpc_options.proto";
+
+enum Mode {
+  MODE_IDLE = 0;
+  MODE_ACTIVE = 1;
+}
+
+message SensorState {
+  option (blerpc.advertising) = 2;
+  sint32 temperature = 1;
+  uint32 battery = 2;
+  Mode mode = 3;
+}
+
+message DeviceName {
+  option (blerpc.advertising) = 0x01;
+  string name = 1;
+}
+
+message NotAdvertised {
+  string text = 1;
+}
+`
+
+func parseAdvertisements(t *testing.T, limits map[string]protomodel.FieldLimits) []gen.Advertisement {
+	t.Helper()
+	pf, err := protomodel.ParseReader(strings.NewReader(advertisingProto))
+	if err != nil {
+		t.Fatalf("protomodel.ParseReader: %v", err)
+	}
+	advs, err := discoverAdvertisements(pf.Messages, limits, defaultAdvMaxSize)
+	if err != nil {
+		t.Fatalf("discoverAdvertisements: %v", err)
+	}
+	return advs
+}
+
+func TestDiscoverAdvertisements(t *testing.T) {
+	advs := parseAdvertisements(t, map[string]protomodel.FieldLimits{"DeviceName.name": {MaxSize: 16}})
+	if len(advs) != 2 {
+		t.Fatalf("expected 2 advertisements, got %d", len(advs))
+	}
+	if advs[0].Message.Name != "DeviceName" || advs[0].Type != 1 || advs[0].MaxSize != 1+1+1+16 {
+		t.Errorf("unexpected first advertisement: %+v", advs[0])
+	}
+	// type + sint32 (1+5) + uint32 (1+5) + enum (1+10)
+	if advs[1].Snake != "sensor_state" || advs[1].Type != 2 || advs[1].MaxSize != 24 {
+		t.Errorf("unexpected second advertisement: %+v", advs[1])
+	}
+}
+
+func TestDiscoverAdvertisements_Errors(t *testing.T) {
+	adv := func(typ string, fields ...gen.Field) gen.Message {
+		return gen.Message{Name: "State", Fields: fields, Options: map[string]string{"blerpc.advertising": typ}}
+	}
+	tests := []struct {
+		name     string
+		messages []gen.Message
+		want     string
+	}{
+		{"zero type", []gen.Message{adv("0")}, "between 1 and 255"},
+		{"large type", []gen.Message{adv("256")}, "between 1 and 255"},
+		{"duplicate type", []gen.Message{adv("1"), {Name: "Other", Options: map[string]string{"blerpc.advertising": "1"}}},
+			"State and Other share advertising type 1"},
+		{"unbounded string", []gen.Message{adv("1", gen.Field{Type: "string", Name: "label", Number: 1})}, "set max_size"},
+		{"repeated", []gen.Message{adv("1", gen.Field{Type: "uint32", Name: "values", Number: 1, IsRepeated: true})}, "repeated"},
+		{"message field", []gen.Message{adv("1", gen.Field{Type: "Point", Name: "point", Number: 1, IsMessage: true})}, "Point fields"},
+		{"too large", []gen.Message{adv("1",
+			gen.Field{Type: "double", Name: "a", Number: 1},
+			gen.Field{Type: "double", Name: "b", Number: 2},
+			gen.Field{Type: "double", Name: "c", Number: 3},
+		)}, "may take 28 bytes, more than the 24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := discoverAdvertisements(tt.messages, nil, defaultAdvMaxSize)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGenerateAdvC(t *testing.T) {
+	advs := parseAdvertisements(t, map[string]protomodel.FieldLimits{"DeviceName.name": {MaxSize: 16}})
+	header := c.GenerateAdvHeader(advs, "blerpc", 0xffff)
+	for _, s := range []string{
+		"#define BLERPC_ADV_COMPANY_ID 0xffff",
+		"#define BLERPC_ADV_SENSOR_STATE_TYPE 2",
+		"#define BLERPC_ADV_SENSOR_STATE_MAX_SIZE 26",
+		"int blerpc_adv_encode_device_name(const blerpc_DeviceName *msg, uint8_t *buf, size_t buf_size);",
+	} {
+		if !strings.Contains(header, s) {
+			t.Errorf("header missing %q\nGot:\n%s", s, header)
+		}
+	}
+	source := c.GenerateAdvSource(advs, "blerpc")
+	for _, s := range []string{
+		"    buf[0] = BLERPC_ADV_COMPANY_ID & 0xff;",
+		"int blerpc_adv_encode_sensor_state(const blerpc_SensorState *msg, uint8_t *buf, size_t buf_size)",
+		"    return adv_encode(BLERPC_ADV_SENSOR_STATE_TYPE, blerpc_SensorState_fields, msg, buf, buf_size);",
+	} {
+		if !strings.Contains(source, s) {
+			t.Errorf("source missing %q\nGot:\n%s", s, source)
+		}
+	}
+}
+
+func TestGenerateAdvParsers(t *testing.T) {
+	gctx := gen.NewContext()
+	advs := parseAdvertisements(t, map[string]protomodel.FieldLimits{"DeviceName.name": {MaxSize: 16}})
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateAdv(gctx, advs, "blerpc", 0x0059), []string{
+			"from . import blerpc_pb2\n",
+			"COMPANY_ID = 0x0059\n",
+			"    2: blerpc_pb2.SensorState,\n",
+			"def parse_manufacturer_data(manufacturer_data):",
+		}},
+		{"kotlin", kotlin.GenerateAdv(gctx, advs, "blerpc", 0x0059), []string{
+			"    const val COMPANY_ID = 0x0059\n",
+			"    const val SENSOR_STATE_TYPE = 2\n",
+			"                SENSOR_STATE_TYPE -> blerpc.Blerpc.SensorState.parseFrom(payload)\n",
+		}},
+		{"swift", swift.GenerateAdv(gctx, advs, "blerpc", 0x0059), []string{
+			"    case deviceName(Blerpc_DeviceName)\n",
+			"    static let companyID: UInt16 = 0x0059\n",
+			"                self = .sensorState(try Blerpc_SensorState(serializedBytes: payload))\n",
+		}},
+		{"go", golang.GenerateAdv(advs, "blerpc", "example.com/pb", 0x0059), []string{
+			"package blerpcadv\n",
+			"const CompanyID = 0x0059\n",
+			"\tTypeSensorState = 2\n",
+			"\t\tmsg = &pb.SensorState{}\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.want {
+			if !strings.Contains(tt.out, s) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, s, tt.out)
+			}
+		}
+	}
+}
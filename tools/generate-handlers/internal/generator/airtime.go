@@ -0,0 +1,338 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The airtime report (airtime.md, next to the API reference) estimates what
+// each command costs the radio at its largest encoded request and response,
+// so a review shows how a proto change moves the battery budget. A call is
+// split into containers as the protocol libraries split it, each container
+// is one ATT write or notification and the link layer carries it in
+// packets of up to the data length. Airtime counts those packets on the
+// air; radio-on time adds the empty packet acknowledging each and the two
+// inter-frame spaces between them. A call takes at least one connection
+// event each way, and more when its packets exceed those one event carries.
+// Energy is the radio-on time at the radio current. The link parameters and
+// the typical app flows, whose calls the report sums, are set under airtime
+// in blerpc.yaml; the frames of framing: true and the MIC of an encrypted
+// link are not counted.
+
+// AirtimeConfig holds the link parameters and flows of the airtime report.
+// Zero values take the defaults below.
+type AirtimeConfig struct {
+	MTU                  int           `yaml:"mtu"`                    // negotiated ATT MTU
+	DataLength           int           `yaml:"data_length"`            // link-layer payload bytes per packet, 27 without data length extension
+	PHY                  string        `yaml:"phy"`                    // 1m or 2m
+	ConnectionIntervalMs float64       `yaml:"connection_interval_ms"` // time between connection events
+	PacketsPerEvent      int           `yaml:"packets_per_event"`      // data packets one connection event carries
+	RadioCurrentMA       float64       `yaml:"radio_current_ma"`       // current drawn while the radio is on
+	SupplyVoltage        float64       `yaml:"supply_voltage"`         // supply voltage, for the energy
+	Flows                []AirtimeFlow `yaml:"flows"`                  // typical app flows the report sums
+}
+
+// AirtimeFlow is a typical app flow: the calls it makes, in order.
+type AirtimeFlow struct {
+	Name  string        `yaml:"name"`
+	Calls []AirtimeCall `yaml:"calls"`
+}
+
+// AirtimeCall is a step of a flow: count calls of command, each sending or
+// receiving messages stream messages for a streaming command. The sizes
+// replace the largest encodings, e.g. for commands with unbounded fields.
+type AirtimeCall struct {
+	Command      string `yaml:"command"`
+	Count        int    `yaml:"count"`         // calls; 1 if unset
+	Messages     int    `yaml:"messages"`      // stream messages per call; 1 if unset
+	RequestSize  int    `yaml:"request_size"`  // encoded request bytes
+	ResponseSize int    `yaml:"response_size"` // encoded response bytes
+}
+
+// Defaults of the airtime report: the default MTU of the simulator, data
+// length extension, and a connection interval and packet count phones
+// commonly grant.
+const (
+	defaultAirtimeMTU             = 247
+	defaultAirtimeDataLength      = 251
+	defaultAirtimePHY             = "1m"
+	defaultAirtimeIntervalMs      = 30
+	defaultAirtimePacketsPerEvent = 4
+	defaultAirtimeCurrentMA       = 6
+	defaultAirtimeVoltage         = 3
+)
+
+// Link-layer constants of Bluetooth LE.
+const (
+	airtimeL2CAPHeader = 4   // length and channel ID
+	airtimePDUOverhead = 9   // access address, header and CRC; the preamble is added per PHY
+	airtimeIFSUs       = 150 // inter-frame space
+)
+
+func validateAirtime(c AirtimeConfig) error {
+	if c.PHY != "" && c.PHY != "1m" && c.PHY != "2m" {
+		return fmt.Errorf("airtime: unknown phy %q (want 1m or 2m)", c.PHY)
+	}
+	if c.MTU != 0 && (c.MTU < 23 || c.MTU > 517) {
+		return fmt.Errorf("airtime: mtu %d is not within 23-517", c.MTU)
+	}
+	if c.DataLength != 0 && (c.DataLength < 27 || c.DataLength > 251) {
+		return fmt.Errorf("airtime: data_length %d is not within 27-251", c.DataLength)
+	}
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"connection_interval_ms", c.ConnectionIntervalMs},
+		{"packets_per_event", float64(c.PacketsPerEvent)},
+		{"radio_current_ma", c.RadioCurrentMA},
+		{"supply_voltage", c.SupplyVoltage},
+	} {
+		if f.value < 0 {
+			return fmt.Errorf("airtime: %s must not be negative", f.name)
+		}
+	}
+	for i, flow := range c.Flows {
+		if flow.Name == "" {
+			return fmt.Errorf("airtime.flows[%d]: name is required", i)
+		}
+		if len(flow.Calls) == 0 {
+			return fmt.Errorf("airtime.flows[%d]: %s has no calls", i, flow.Name)
+		}
+		for j, call := range flow.Calls {
+			if call.Count < 0 || call.Messages < 0 || call.RequestSize < 0 || call.ResponseSize < 0 {
+				return fmt.Errorf("airtime.flows[%d].calls[%d]: counts and sizes must not be negative", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// checkAirtimeFlows checks that the flows call commands of the schema.
+func checkAirtimeFlows(commands []gen.Command, cfg *Config) error {
+	known := make(map[string]bool)
+	for _, cmd := range commands {
+		known[cmd.Snake] = true
+	}
+	for i, flow := range cfg.Airtime.Flows {
+		for j, call := range flow.Calls {
+			if !known[call.Command] {
+				return fmt.Errorf("airtime.flows[%d].calls[%d]: unknown command %q", i, j, call.Command)
+			}
+		}
+	}
+	return nil
+}
+
+// airtimeLink is the link of the report, its parameters defaulted.
+type airtimeLink struct {
+	mtu, dataLength, packetsPerEvent int
+	phy                              string
+	intervalMs, currentMA, voltage   float64
+}
+
+func newAirtimeLink(c AirtimeConfig) airtimeLink {
+	return airtimeLink{
+		mtu:             cmp.Or(c.MTU, defaultAirtimeMTU),
+		dataLength:      cmp.Or(c.DataLength, defaultAirtimeDataLength),
+		packetsPerEvent: cmp.Or(c.PacketsPerEvent, defaultAirtimePacketsPerEvent),
+		phy:             cmp.Or(c.PHY, defaultAirtimePHY),
+		intervalMs:      cmp.Or(c.ConnectionIntervalMs, defaultAirtimeIntervalMs),
+		currentMA:       cmp.Or(c.RadioCurrentMA, defaultAirtimeCurrentMA),
+		voltage:         cmp.Or(c.SupplyVoltage, defaultAirtimeVoltage),
+	}
+}
+
+// usPerByte returns the time one byte takes on the air, and preamble the
+// length of the preamble.
+func (l airtimeLink) usPerByte() (us float64, preamble int) {
+	if l.phy == "2m" {
+		return 4, 2
+	}
+	return 8, 1
+}
+
+// airtimeCost is the estimated cost of one or more messages.
+type airtimeCost struct {
+	packets, events int
+	airUs, radioUs  float64
+}
+
+func (c *airtimeCost) add(o airtimeCost) {
+	c.packets += o.packets
+	c.events += o.events
+	c.airUs += o.airUs
+	c.radioUs += o.radioUs
+}
+
+func (c airtimeCost) times(n int) airtimeCost {
+	return airtimeCost{c.packets * n, c.events * n, c.airUs * float64(n), c.radioUs * float64(n)}
+}
+
+// message returns the cost of sending a command packet of size bytes one
+// way: its containers, as conformanceSplit cuts them, in link-layer packets.
+func (l airtimeLink) message(size int) airtimeCost {
+	effective := l.mtu - conformanceATTOverhead
+	firstMax := min(effective-conformanceFirstHeader, 0xFF)
+	nextMax := min(effective-conformanceSubsequentHeader, 0xFF)
+	us, preamble := l.usPerByte()
+	empty := float64(preamble+airtimePDUOverhead) * us
+
+	var c airtimeCost
+	container := func(n int) {
+		att := airtimeL2CAPHeader + conformanceATTOverhead + n
+		for att > 0 {
+			payload := min(att, l.dataLength)
+			att -= payload
+			onAir := float64(preamble+airtimePDUOverhead+payload) * us
+			c.packets++
+			c.airUs += onAir
+			c.radioUs += onAir + airtimeIFSUs + empty + airtimeIFSUs
+		}
+	}
+	n := min(size, firstMax)
+	container(conformanceFirstHeader + n)
+	for off := n; off < size; off += n {
+		n = min(size-off, nextMax)
+		container(conformanceSubsequentHeader + n)
+	}
+	c.events = l.events(c.packets)
+	return c
+}
+
+// events returns the connection events carrying packets one way.
+func (l airtimeLink) events(packets int) int {
+	return (packets + l.packetsPerEvent - 1) / l.packetsPerEvent
+}
+
+// commandPacketSize returns the size of the command packet of cmd carrying
+// data bytes: type, name length, name, data length and data.
+func commandPacketSize(cmd gen.Command, data int) int {
+	return 4 + len(gen.ConformanceWireName(cmd)) + data
+}
+
+// callCost returns the cost of one call of cmd with a request and response
+// of the sizes given, moving messages stream messages, or false if a size is
+// unbounded. The messages of a stream share connection events, and every
+// call waits at least one event for its response.
+func (l airtimeLink) callCost(cmd gen.Command, mode string, reqSize, respSize, messages int) (airtimeCost, bool) {
+	if reqSize == gen.UnboundedSize || respSize == gen.UnboundedSize {
+		return airtimeCost{}, false
+	}
+	req := l.message(commandPacketSize(cmd, reqSize))
+	resp := l.message(commandPacketSize(cmd, respSize))
+	switch mode {
+	case "p2c":
+		resp = resp.times(messages)
+		resp.events = l.events(resp.packets)
+	case "c2p":
+		req = req.times(messages)
+		req.events = l.events(req.packets)
+	}
+	req.add(resp)
+	return req, true
+}
+
+func (l airtimeLink) latencyMs(c airtimeCost) float64 {
+	return float64(c.events) * l.intervalMs
+}
+
+func (l airtimeLink) energyUJ(c airtimeCost) float64 {
+	return c.radioUs * l.currentMA * l.voltage / 1000
+}
+
+// airtimeSize formats a largest encoding in bytes.
+func airtimeSize(size int) string {
+	if size == gen.UnboundedSize {
+		return "unbounded"
+	}
+	return strconv.Itoa(size)
+}
+
+// airtimeRow formats the cost columns of a report row.
+func (l airtimeLink) airtimeRow(c airtimeCost) string {
+	return fmt.Sprintf("%d | %.0f | %.0f | %d | %.0f | %.1f", c.packets, c.airUs, c.radioUs, c.events, l.latencyMs(c), l.energyUJ(c))
+}
+
+func generateAirtimeReport(commands []gen.Command, streaming map[string]string, cfg AirtimeConfig, pkg string) string {
+	l := newAirtimeLink(cfg)
+	us, _ := l.usPerByte()
+	var b strings.Builder
+	b.WriteString("<!-- Auto-generated by generate-handlers — DO NOT EDIT. -->\n\n")
+	fmt.Fprintf(&b, "# %s airtime budget\n\n", pkg)
+	b.WriteString("Estimated radio cost of each command at its largest encoded request and\n")
+	b.WriteString("response, on this link (set under `airtime` in blerpc.yaml):\n\n")
+	fmt.Fprintf(&b, "- ATT MTU: %d\n", l.mtu)
+	fmt.Fprintf(&b, "- Link-layer data length: %d bytes\n", l.dataLength)
+	fmt.Fprintf(&b, "- PHY: %s, %g µs per byte\n", strings.ToUpper(l.phy), us)
+	fmt.Fprintf(&b, "- Connection interval: %g ms, %d packets per event\n", l.intervalMs, l.packetsPerEvent)
+	fmt.Fprintf(&b, "- Radio current: %g mA at %g V\n\n", l.currentMA, l.voltage)
+	b.WriteString("Packets are the link-layer packets carrying the call both ways. Airtime\n")
+	b.WriteString("is their time on the air; radio-on time adds the empty packets\n")
+	b.WriteString("acknowledging them and the inter-frame spaces. Events are the connection\n")
+	b.WriteString("events the call spans, at least one each way, and latency their length.\n")
+	b.WriteString("Energy is the radio-on time at the radio current. Streaming commands are\n")
+	b.WriteString("costed per message. Flows sum their calls, at the sizes they set for\n")
+	b.WriteString("commands whose encodings are unbounded. Framing and link encryption are\n")
+	b.WriteString("not counted.\n\n")
+
+	b.WriteString("## Commands\n\n")
+	b.WriteString("| Command | Streaming | Request | Response | Packets | Airtime (µs) | Radio-on (µs) | Events | Latency (ms) | Energy (µJ) |\n")
+	b.WriteString("|---------|-----------|---------|----------|---------|--------------|---------------|--------|--------------|-------------|\n")
+	for _, cmd := range commands {
+		mode := "none"
+		if m := streaming[cmd.Snake]; m != "" {
+			mode = strings.ToUpper(m)
+		}
+		c, ok := l.callCost(cmd, streaming[cmd.Snake], cmd.MaxRequestSize, cmd.MaxResponseSize, 1)
+		if !ok {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | unbounded | | | | | |\n", cmd.Snake, mode,
+				airtimeSize(cmd.MaxRequestSize), airtimeSize(cmd.MaxResponseSize))
+			continue
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %d | %d | %s |\n", cmd.Snake, mode, cmd.MaxRequestSize, cmd.MaxResponseSize, l.airtimeRow(c))
+	}
+
+	if len(cfg.Flows) == 0 {
+		return b.String()
+	}
+	bySnake := make(map[string]gen.Command)
+	for _, cmd := range commands {
+		bySnake[cmd.Snake] = cmd
+	}
+	b.WriteString("\n## Flows\n\n")
+	b.WriteString("| Flow | Calls | Packets | Airtime (µs) | Radio-on (µs) | Events | Latency (ms) | Energy (µJ) |\n")
+	b.WriteString("|------|-------|---------|--------------|---------------|--------|--------------|-------------|\n")
+	for _, flow := range cfg.Flows {
+		var total airtimeCost
+		var calls []string
+		bounded := true
+		for _, call := range flow.Calls {
+			count, messages := max(call.Count, 1), max(call.Messages, 1)
+			step := "`" + call.Command + "`"
+			if messages > 1 {
+				step += fmt.Sprintf(" (%d messages)", messages)
+			}
+			if count > 1 {
+				step = fmt.Sprintf("%d × %s", count, step)
+			}
+			calls = append(calls, step)
+			cmd := bySnake[call.Command]
+			reqSize := cmp.Or(call.RequestSize, cmd.MaxRequestSize)
+			respSize := cmp.Or(call.ResponseSize, cmd.MaxResponseSize)
+			c, ok := l.callCost(cmd, streaming[call.Command], reqSize, respSize, messages)
+			bounded = bounded && ok
+			total.add(c.times(count))
+		}
+		if !bounded {
+			fmt.Fprintf(&b, "| %s | %s | unbounded | | | | | |\n", flow.Name, strings.Join(calls, ", "))
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", flow.Name, strings.Join(calls, ", "), l.airtimeRow(total))
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestAirtimeMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		link   AirtimeConfig
+		size   int
+		want   airtimeCost
+		events int
+	}{
+		// 24-byte container, 31 bytes with the ATT and L2CAP headers: one
+		// packet of 41 bytes on the air.
+		{"one packet", AirtimeConfig{}, 18, airtimeCost{packets: 1, events: 1, airUs: 328, radioUs: 708}, 1},
+		// Containers of 14, 16 and 10 bytes at the minimum MTU.
+		{"split", AirtimeConfig{MTU: 23, DataLength: 27, PacketsPerEvent: 2}, 40, airtimeCost{}, 2},
+		// One 206-byte container in packets of 27 bytes.
+		{"no data length extension", AirtimeConfig{DataLength: 27}, 200, airtimeCost{}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newAirtimeLink(tt.link).message(tt.size)
+			if tt.want.packets != 0 && got != tt.want {
+				t.Errorf("message(%d) = %+v, want %+v", tt.size, got, tt.want)
+			}
+			if got.events != tt.events {
+				t.Errorf("events = %d, want %d", got.events, tt.events)
+			}
+		})
+	}
+	if got := newAirtimeLink(AirtimeConfig{DataLength: 27}).message(200).packets; got != 8 {
+		t.Errorf("packets = %d, want 8", got)
+	}
+	if got := newAirtimeLink(AirtimeConfig{MTU: 23, DataLength: 27}).message(40).packets; got != 3 {
+		t.Errorf("packets = %d, want 3", got)
+	}
+}
+
+func TestGenerateAirtimeReport(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.MaxRequestSize, echo.MaxResponseSize = 10, 10
+	stream := gentest.EchoCommand()
+	stream.Camel, stream.Snake = "Stream", "stream"
+	stream.MaxRequestSize, stream.MaxResponseSize = 2, gen.UnboundedSize
+	cfg := AirtimeConfig{Flows: []AirtimeFlow{
+		{Name: "ping", Calls: []AirtimeCall{{Command: "echo", Count: 3}}},
+		{Name: "log", Calls: []AirtimeCall{{Command: "stream", Messages: 10}}},
+		{Name: "log lines", Calls: []AirtimeCall{{Command: "stream", Messages: 10, ResponseSize: 20}}},
+	}}
+
+	md := generateAirtimeReport([]gen.Command{echo, stream}, map[string]string{"stream": "p2c"}, cfg, "blerpc")
+	for _, want := range []string{
+		"# blerpc airtime budget\n",
+		"- PHY: 1M, 8 µs per byte\n",
+		"| `echo` | none | 10 | 10 | 2 | 656 | 1416 | 2 | 60 | 25.5 |\n",
+		"| `stream` | P2C | 2 | unbounded | unbounded | | | | | |\n",
+		"| ping | 3 × `echo` | 6 | 1968 | 4248 | 6 | 180 | 76.5 |\n",
+		"| log | `stream` (10 messages) | unbounded | | | | | |\n",
+		// The ten responses share three connection events.
+		"| log lines | `stream` (10 messages) | 11 | 4520 | 8700 | 4 | 120 | 156.6 |\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("airtime report missing %q:\n%s", want, md)
+		}
+	}
+
+	if err := checkAirtimeFlows([]gen.Command{echo}, &Config{Airtime: cfg}); err == nil || !strings.Contains(err.Error(), `airtime.flows[1].calls[0]: unknown command "stream"`) {
+		t.Errorf("expected unknown command error, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateBatchClients(t *testing.T) {
+	gctx := gen.NewContext()
+	commands := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	for _, tc := range []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"python", python.GenerateBatch(gctx, commands, streaming, "blerpc"), []string{
+			`BATCH_COMMAND = "_batch"`,
+			"def echo(self, *, message: str = \"\") -> BatchCall[blerpc_pb2.EchoResponse]:",
+			`len(data).to_bytes(2, "little")`,
+			"await self._client._call(BATCH_COMMAND, payload)",
+		}},
+		{"kotlin", kotlin.GenerateBatch(gctx, commands, streaming, "blerpc"), []string{
+			`const val BATCH_COMMAND = "_batch"`,
+			`fun echo(message: String = ""): BatchCall<blerpc.Blerpc.EchoResponse>`,
+			"entries.write(requestData.size shr 8)",
+			"client.exclusive { client.call(BATCH_COMMAND, payload) }",
+		}},
+		{"swift", swift.GenerateBatch(gctx, commands, streaming, "blerpc"), []string{
+			`let batchCommand = "_batch"`,
+			`func echo(message: String = "") throws -> BatchCall<Blerpc_EchoResponse>`,
+			"payload.append(UInt8(requestData.count >> 8))",
+			"try await client.call(cmdName: batchCommand, requestData: requestData)",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.got, want) {
+				t.Errorf("%s: missing %q", tc.name, want)
+			}
+		}
+		if strings.Contains(tc.got, "counterStream") || strings.Contains(tc.got, "counter_stream") {
+			t.Errorf("%s: streaming command is batchable", tc.name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gengen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+type benchSchema struct {
+	commands  []gengen.Command
+	streaming map[string]string
+	callbacks map[string]bool
+}
+
+func loadBenchSchema(b *testing.B, n int) benchSchema {
+	b.Helper()
+	pf, err := protomodel.ParseReader(strings.NewReader(gentest.SyntheticProto(n)))
+	if err != nil {
+		b.Fatalf("protomodel.ParseReader: %v", err)
+	}
+	msgByName := make(map[string]gengen.Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	s := benchSchema{
+		commands:  protomodel.DiscoverCommandsFromServices(pf.Services, msgByName),
+		streaming: protomodel.StreamingFromServices(pf.Services),
+		callbacks: make(map[string]bool),
+	}
+	if len(s.commands) != n {
+		b.Fatalf("expected %d commands, got %d", n, len(s.commands))
+	}
+	return s
+}
+
+func BenchmarkGenerate1000(b *testing.B) {
+	gctx := gengen.NewContext()
+	s := loadBenchSchema(b, 1000)
+	targets := []struct {
+		name string
+		gen  func() string
+	}{
+		{"c_header", func() string { return c.GenerateHeader(gctx, s.commands, s.streaming, nil, "blerpc") }},
+		{"c_source", func() string { return c.GenerateSource(gctx, s.commands, s.streaming, s.callbacks, "blerpc") }},
+		{"c_client", func() string {
+			return c.GenerateClientSource(s.commands, s.streaming, s.callbacks, "blerpc")
+		}},
+		{"py_handlers", func() string { return python.GenerateHandlers(gctx, s.commands, s.streaming, "blerpc") }},
+		{"py_client", func() string { return python.GenerateClient(gctx, s.commands, s.streaming, "blerpc") }},
+		{"kotlin", func() string { return kotlin.GenerateClient(gctx, s.commands, s.streaming, "blerpc") }},
+		{"swift", func() string { return swift.GenerateClient(gctx, s.commands, s.streaming, "blerpc") }},
+		{"dart", func() string { return dart.GenerateClient(gctx, s.commands, s.streaming, "blerpc") }},
+		{"ts", func() string { return typescript.GenerateClient(gctx, s.commands, s.streaming, "blerpc") }},
+	}
+	for _, tt := range targets {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.SetBytes(int64(len(tt.gen())))
+			}
+		})
+	}
+}
+
+func BenchmarkWriteOutputs1000(b *testing.B) {
+	gctx := gengen.NewContext()
+	s := loadBenchSchema(b, 1000)
+	dir := b.TempDir()
+	outputs := []gengen.Output{
+		{Path: filepath.Join(dir, "generated_handlers.c"), Content: c.GenerateSource(gctx, s.commands, s.streaming, s.callbacks, "blerpc")},
+		{Path: filepath.Join(dir, "generated_client.py"), Content: python.GenerateClient(gctx, s.commands, s.streaming, "blerpc")},
+		{Path: filepath.Join(dir, "GeneratedClient.swift"), Content: swift.GenerateClient(gctx, s.commands, s.streaming, "blerpc")},
+	}
+	var size int64
+	for _, out := range outputs {
+		size += int64(len(out.Content))
+	}
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, out := range outputs {
+			if err := writeFile(out.Path, out.Content); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// computeSchemaHash returns the first 8 bytes, in hex, of the SHA-256 of a
+// canonical rendering of what both ends of the link must agree on: the
+// commands with their wire names, IDs and stream directions, and every
+// message and enum. Comments, declaration order and source positions do not
+// change it.
+func computeSchemaHash(pf *gen.ProtoFile, commands []gen.Command, streaming map[string]string) string {
+	h := sha256.New()
+	for _, cmd := range slices.SortedFunc(slices.Values(commands), func(a, b gen.Command) int {
+		return cmp.Compare(a.Wire(), b.Wire())
+	}) {
+		fmt.Fprintf(h, "command %s %s %s id=%d stream=%s replay=%t session=%t\n",
+			cmd.Wire(), cmd.RequestMsg, cmd.ResponseMsg, cmd.ID, streaming[cmd.Snake], cmd.ReplayProtected, cmd.SessionProtected)
+	}
+	for _, m := range slices.SortedFunc(slices.Values(pf.Messages), func(a, b gen.Message) int {
+		return cmp.Compare(a.Name, b.Name)
+	}) {
+		fmt.Fprintf(h, "message %s\n", m.Name)
+		writeSchemaHashFields(h, m.Fields)
+	}
+	for _, e := range slices.SortedFunc(slices.Values(pf.Enums), func(a, b gen.Enum) int {
+		return cmp.Compare(a.Name, b.Name)
+	}) {
+		fmt.Fprintf(h, "enum %s\n", e.Name)
+		for _, v := range slices.SortedFunc(slices.Values(e.Values), func(a, b gen.EnumValue) int {
+			return cmp.Compare(a.Number, b.Number)
+		}) {
+			fmt.Fprintf(h, "  %d %s\n", v.Number, v.Name)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func writeSchemaHashFields(h hash.Hash, fields []gen.Field) {
+	for _, f := range slices.SortedFunc(slices.Values(fields), func(a, b gen.Field) int {
+		return cmp.Compare(a.Number, b.Number)
+	}) {
+		typ := f.Type
+		if f.IsMap {
+			typ = "map<" + f.KeyType + "," + f.ValueType + ">"
+		}
+		fmt.Fprintf(h, "  %d %s %s repeated=%t required=%t optional=%t oneof=%s\n",
+			f.Number, f.Name, typ, f.IsRepeated, f.IsRequired, f.IsOptional, f.Oneof)
+	}
+}
@@ -0,0 +1,125 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestComputeSchemaHash(t *testing.T) {
+	parse := func(proto string) (*gen.ProtoFile, []gen.Command) {
+		pf, err := protomodel.ParseReader(strings.NewReader(proto))
+		if err != nil {
+			t.Fatalf("protomodel.ParseReader: %v", err)
+		}
+		return pf, protomodel.DiscoverCommands(pf.Messages)
+	}
+	hash := func(proto string) string {
+		pf, commands := parse(proto)
+		return computeSchemaHash(pf, commands, nil)
+	}
+
+	base := hash("syntax = \"proto3\";\nmessage EchoRequest { string message = 1; }\nmessage EchoResponse { string message = 1; }\n")
+	if !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(base) {
+		t.Fatalf("hash = %q, want 16 hex digits", base)
+	}
+	if got := hash("syntax = \"proto3\";\n// Echo.\nmessage EchoResponse { string message = 1; }\n\nmessage EchoRequest { string message = 1; }\n"); got != base {
+		t.Errorf("comments and declaration order changed the hash: %s, want %s", got, base)
+	}
+	for _, changed := range []string{
+		"syntax = \"proto3\";\nmessage EchoRequest { string message = 2; }\nmessage EchoResponse { string message = 1; }\n",
+		"syntax = \"proto3\";\nmessage EchoRequest { bytes message = 1; }\nmessage EchoResponse { string message = 1; }\n",
+		"syntax = \"proto3\";\nmessage EchoRequest { repeated string message = 1; }\nmessage EchoResponse { string message = 1; }\n",
+		"syntax = \"proto3\";\nmessage EchoRequest { string text = 1; }\nmessage EchoResponse { string message = 1; }\n",
+	} {
+		if got := hash(changed); got == base {
+			t.Errorf("hash unchanged for\n%s", changed)
+		}
+	}
+
+	pf, commands := parse("syntax = \"proto3\";\nmessage EchoRequest { string message = 1; }\nmessage EchoResponse { string message = 1; }\n")
+	if got := computeSchemaHash(pf, commands, map[string]string{"echo": "p2c"}); got == base {
+		t.Error("a stream direction did not change the hash")
+	}
+	commands[0].ID = 1
+	if got := computeSchemaHash(pf, commands, nil); got == base {
+		t.Error("a command ID did not change the hash")
+	}
+}
+
+func TestBuiltinBlerpcInfo(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n", "blerpc_info")
+	if len(commands) != 1 || commands[0].Snake != "get_blerpc_info" {
+		t.Fatalf("got %+v", commands)
+	}
+	gctx.SchemaHash = "0123456789abcdef"
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_SCHEMA_HASH \"0123456789abcdef\"\n",
+		"#define BLERPC_GENERATOR_VERSION \"" + gen.GeneratorVersion + "\"\n",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static bool encode_info_string(pb_ostream_t *stream, const pb_field_t *field,",
+		"resp.schema_hash.arg = (void *)BLERPC_SCHEMA_HASH;",
+		"resp.generator_version.arg = (void *)BLERPC_GENERATOR_VERSION;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"SCHEMA_HASH = \"0123456789abcdef\"\n",
+			"class SchemaMismatchError(BlerpcError):",
+			"async def verify_schema(self):",
+			"SCHEMA_HASH, info.schema_hash, info.generator_version\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"const val SCHEMA_HASH = \"0123456789abcdef\"\n",
+			") : BlerpcException(\n",
+			"suspend fun verifySchema(): blerpc.Blerpc.GetBlerpcInfoResponse {",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"let blerpcSchemaHash = \"0123456789abcdef\"\n",
+			"struct SchemaMismatchError: BlerpcErrorProtocol {",
+			"func verifySchema() async throws -> Blerpc_GetBlerpcInfoResponse {",
+		}},
+		{"dart", dart.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"Future<GetBlerpcInfoResponse> verifySchema() async {",
+			"const blerpcSchemaHash = '0123456789abcdef';\n",
+			"class SchemaMismatchError implements Exception {",
+		}},
+		{"ts", typescript.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"async verifySchema(): Promise<blerpc.GetBlerpcInfoResponse> {",
+			"export const SCHEMA_HASH = '0123456789abcdef';\n",
+			"export class SchemaMismatchError extends Error {",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// Built-in commands are command sets the generator supplies itself, enabled
+// by name under builtins in blerpc.yaml. Their messages come from proto
+// templates merged into the parsed schema, so every target sees them as
+// ordinary commands. The templates are also written out as
+// blerpc_builtin.proto, which the schema imports so protoc and nanopb
+// generate the same messages; names the schema already defines are not
+// merged twice.
+
+// builtinSet is one built-in command set.
+type builtinSet struct {
+	proto string           // enums and messages, without syntax or package
+	rpcs  []gen.ServiceRPC // RPCs added for schemas that define services
+}
+
+// builtinService is the proto service holding the built-in RPCs of schemas
+// that define services.
+const builtinService = "Builtin"
+
+var builtinSets = map[string]builtinSet{
+	// blerpc_info reports the schema hash and generator version the firmware
+	// was built with, for the clients to check on connect.
+	"blerpc_info": {
+		proto: `message GetBlerpcInfoRequest {}
+
+message GetBlerpcInfoResponse {
+  // First 8 bytes of a SHA-256 over the commands, messages and enums, in hex.
+  string schema_hash = 1;
+  string generator_version = 2;
+}
+`,
+		rpcs: []gen.ServiceRPC{{Name: "GetBlerpcInfo", RequestType: "GetBlerpcInfoRequest", ResponseType: "GetBlerpcInfoResponse"}},
+	},
+	// capabilities reports which commands the firmware implements, for apps
+	// driving devices on different firmware versions.
+	"capabilities": {
+		proto: `message GetCapabilitiesRequest {}
+
+// The commands the firmware implements: built-ins, and commands whose
+// handlers are marked implemented.
+message GetCapabilitiesResponse {
+  // With command IDs: bit id % 8 of byte id / 8 is set for each command.
+  bytes command_ids = 1;
+  // Without command IDs: the wire names of the commands.
+  repeated string names = 2;
+}
+`,
+		rpcs: []gen.ServiceRPC{{Name: "GetCapabilities", RequestType: "GetCapabilitiesRequest", ResponseType: "GetCapabilitiesResponse"}},
+	},
+	// conn_params asks the peripheral for a connection interval/latency
+	// profile: fast for bulk transfers such as a DFU, low power for an idle
+	// link. The response carries the parameters requested from the stack.
+	"conn_params": {
+		proto: `enum ConnProfile {
+  CONN_PROFILE_BALANCED = 0;
+  CONN_PROFILE_FAST = 1;
+  CONN_PROFILE_LOW_POWER = 2;
+}
+
+message ConnParamsRequest {
+  ConnProfile profile = 1;
+}
+
+// Intervals in 1.25 ms units, supervision timeout in 10 ms units.
+message ConnParamsResponse {
+  uint32 interval_min = 1;
+  uint32 interval_max = 2;
+  uint32 latency = 3;
+  uint32 timeout = 4;
+}
+`,
+		rpcs: []gen.ServiceRPC{{Name: "ConnParams", RequestType: "ConnParamsRequest", ResponseType: "ConnParamsResponse"}},
+	},
+	// dfu writes a firmware image to the peripheral's update slot and applies
+	// it once its CRC-32 checks out.
+	"dfu": {
+		proto: `// Starts an update to an image of size bytes. For the image of an
+// interrupted update, the bytes already written are kept.
+message DfuBeginRequest {
+  uint32 size = 1;
+  // CRC-32 of the whole image.
+  uint32 crc32 = 2;
+}
+
+message DfuBeginResponse {
+  // Bytes of the image already written: where to continue.
+  uint32 offset = 1;
+  // Most bytes one chunk may carry.
+  uint32 max_chunk = 2;
+}
+
+// Writes data at offset. A chunk at another offset than the bytes written
+// so far is ignored.
+message DfuChunkRequest {
+  uint32 offset = 1;
+  bytes data = 2;
+}
+
+message DfuChunkResponse {
+  // Bytes of the image written so far.
+  uint32 offset = 1;
+}
+
+// Checks the image written against its size and CRC-32 and marks it to
+// boot.
+message DfuFinalizeRequest {
+  // Restart into the new image.
+  bool reboot = 1;
+}
+
+message DfuFinalizeResponse {
+  uint32 crc32 = 1;
+}
+`,
+		rpcs: []gen.ServiceRPC{
+			{Name: "DfuBegin", RequestType: "DfuBeginRequest", ResponseType: "DfuBeginResponse"},
+			{Name: "DfuChunk", RequestType: "DfuChunkRequest", ResponseType: "DfuChunkResponse"},
+			{Name: "DfuFinalize", RequestType: "DfuFinalizeRequest", ResponseType: "DfuFinalizeResponse"},
+		},
+	},
+	// file_transfer reads and writes files on the peripheral in chunks.
+	"file_transfer": {
+		proto: `message FileOpenRequest {
+  string path = 1;
+  // Open for writing, truncating the file unless resume is set.
+  bool write = 2;
+  // Keep the contents of a file opened for writing, to continue an
+  // interrupted upload after them.
+  bool resume = 3;
+}
+
+// The size and CRC-32 of the file as opened.
+message FileOpenResponse {
+  uint32 handle = 1;
+  uint32 size = 2;
+  uint32 crc32 = 3;
+}
+
+// Returns up to length bytes at offset; fewer at the end of the file.
+message FileReadRequest {
+  uint32 handle = 1;
+  uint32 offset = 2;
+  uint32 length = 3;
+}
+
+message FileReadResponse {
+  bytes data = 1;
+}
+
+message FileWriteRequest {
+  uint32 handle = 1;
+  uint32 offset = 2;
+  bytes data = 3;
+}
+
+message FileWriteResponse {}
+
+message FileCloseRequest {
+  uint32 handle = 1;
+  // Read the file back for size and crc32, e.g. to verify an upload.
+  bool verify = 2;
+}
+
+message FileCloseResponse {
+  uint32 size = 1;
+  uint32 crc32 = 2;
+}
+`,
+		rpcs: []gen.ServiceRPC{
+			{Name: "FileOpen", RequestType: "FileOpenRequest", ResponseType: "FileOpenResponse"},
+			{Name: "FileRead", RequestType: "FileReadRequest", ResponseType: "FileReadResponse"},
+			{Name: "FileWrite", RequestType: "FileWriteRequest", ResponseType: "FileWriteResponse"},
+			{Name: "FileClose", RequestType: "FileCloseRequest", ResponseType: "FileCloseResponse"},
+		},
+	},
+	// log_stream drains the firmware's log ring buffer as a stream.
+	"log_stream": {
+		proto: `enum LogLevel {
+  LOG_LEVEL_DEBUG = 0;
+  LOG_LEVEL_INFO = 1;
+  LOG_LEVEL_WARNING = 2;
+  LOG_LEVEL_ERROR = 3;
+}
+
+message LogStreamRequest {
+  // Entries below this level are dropped from the buffer unsent.
+  LogLevel min_level = 1;
+  // Stop after this many messages; 0 drains the buffer.
+  uint32 max_entries = 2;
+}
+
+// One buffered entry. A message longer than an entry is split over
+// consecutive entries, all but the last with more set.
+message LogStreamResponse {
+  // Gaps mark entries overwritten before they were drained.
+  uint32 seq = 1;
+  LogLevel level = 2;
+  // Device uptime when the entry was logged and when it was sent.
+  uint32 uptime_ms = 3;
+  uint32 now_ms = 4;
+  string module = 5;
+  string message = 6;
+  bool more = 7;
+}
+`,
+		rpcs: []gen.ServiceRPC{{Name: "LogStream", RequestType: "LogStreamRequest", ResponseType: "LogStreamResponse", ServerStream: true}},
+	},
+	// ping echoes a payload with the peripheral's uptime and link RSSI, for
+	// keepalives and latency measurement.
+	"ping": {
+		proto: `message PingRequest {
+  // Echoed back, e.g. to time a round trip of a given size.
+  bytes payload = 1;
+}
+
+message PingResponse {
+  bytes payload = 1;
+  uint32 uptime_ms = 2;
+  // RSSI of the link as the peripheral sees it, in dBm; 0 if unknown.
+  sint32 rssi = 3;
+}
+`,
+		rpcs: []gen.ServiceRPC{{Name: "Ping", RequestType: "PingRequest", ResponseType: "PingResponse"}},
+	},
+	// rpc_stats reads the per-command counters the handler table keeps.
+	"rpc_stats": {
+		proto: `message GetRpcStatsRequest {
+  // Clear the counters after reading them.
+  bool reset = 1;
+}
+
+message RpcStat {
+  string name = 1;
+  uint32 calls = 2;
+  uint32 errors = 3;
+  uint32 max_duration_us = 4;
+}
+
+// One entry per command in the firmware's handler table.
+message GetRpcStatsResponse {
+  repeated RpcStat stats = 1;
+}
+`,
+		rpcs: []gen.ServiceRPC{{Name: "GetRpcStats", RequestType: "GetRpcStatsRequest", ResponseType: "GetRpcStatsResponse"}},
+	},
+	// session authenticates the central with a key it shares with the
+	// peripheral, unlocking the commands marked session_protected.
+	"session": {
+		proto: `message StartSessionRequest {}
+
+// A fresh random challenge; starting a session ends the one open.
+message StartSessionResponse {
+  bytes challenge = 1;
+}
+
+// HMAC-SHA256 of the challenge under the shared key.
+message AuthenticateSessionRequest {
+  bytes proof = 1;
+}
+
+// Leads every session-protected request until the session ends.
+message AuthenticateSessionResponse {
+  bytes token = 1;
+}
+`,
+		rpcs: []gen.ServiceRPC{
+			{Name: "StartSession", RequestType: "StartSessionRequest", ResponseType: "StartSessionResponse"},
+			{Name: "AuthenticateSession", RequestType: "AuthenticateSessionRequest", ResponseType: "AuthenticateSessionResponse"},
+		},
+	},
+	// settings reads and writes one field of the (blerpc.settings) message;
+	// annotating a message enables it.
+	"settings": {
+		proto: `message GetSettingRequest {
+  // Field number in the settings message.
+  uint32 field = 1;
+}
+
+// The settings message, encoded, with the requested field filled in.
+message GetSettingResponse {
+  bytes value = 1;
+}
+
+// Writes the field of the settings message encoded in value.
+message SetSettingRequest {
+  uint32 field = 1;
+  bytes value = 2;
+}
+
+message SetSettingResponse {}
+`,
+		rpcs: []gen.ServiceRPC{
+			{Name: "GetSetting", RequestType: "GetSettingRequest", ResponseType: "GetSettingResponse"},
+			{Name: "SetSetting", RequestType: "SetSettingRequest", ResponseType: "SetSettingResponse"},
+		},
+	},
+	// time_sync sets the peripheral's wall clock from the central's.
+	"time_sync": {
+		proto: `message TimeSyncRequest {
+  // Central wall clock, microseconds since the Unix epoch.
+  int64 unix_time_us = 1;
+  // Added to unix_time_us before it is applied: the estimated delay from
+  // sending the request to applying it.
+  uint32 offset_us = 2;
+}
+
+message TimeSyncResponse {
+  // Time applied, microseconds since the Unix epoch.
+  int64 applied_time_us = 1;
+}
+`,
+		rpcs: []gen.ServiceRPC{{Name: "TimeSync", RequestType: "TimeSyncRequest", ResponseType: "TimeSyncResponse"}},
+	},
+}
+
+// builtinNames returns the known built-in command sets, sorted.
+func builtinNames() []string {
+	names := make([]string, 0, len(builtinSets))
+	for name := range builtinSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateBuiltins rejects unknown or repeated built-in names.
+func validateBuiltins(names []string) error {
+	for i, name := range names {
+		if _, ok := builtinSets[name]; !ok {
+			return fmt.Errorf("builtins[%d]: unknown built-in %q (want one of %s)", i, name, strings.Join(builtinNames(), ", "))
+		}
+		if slices.Index(names, name) != i {
+			return fmt.Errorf("builtins[%d]: %q listed twice", i, name)
+		}
+	}
+	return nil
+}
+
+// mergeBuiltins adds the enums and messages of the enabled built-ins to pf,
+// skipping names it already defines, and for schemas with services adds the
+// built-in RPCs the schema does not define itself.
+func mergeBuiltins(pf *gen.ProtoFile, cfg *Config) error {
+	for _, name := range cfg.Builtins {
+		set := builtinSets[name]
+		tmpl, err := protomodel.ParseReader(strings.NewReader("syntax = \"proto3\";\n\n" + set.proto))
+		if err != nil {
+			return fmt.Errorf("built-in %s: %w", name, err)
+		}
+		for _, e := range tmpl.Enums {
+			if !slices.ContainsFunc(pf.Enums, func(have gen.Enum) bool { return have.Name == e.Name }) {
+				pf.Enums = append(pf.Enums, e)
+			}
+		}
+		for _, m := range tmpl.Messages {
+			if !slices.ContainsFunc(pf.Messages, func(have gen.Message) bool { return have.Name == m.Name }) {
+				pf.Messages = append(pf.Messages, m)
+			}
+		}
+		if len(pf.Services) == 0 {
+			continue
+		}
+		for _, rpc := range set.rpcs {
+			if hasRPC(pf.Services, rpc.Name) {
+				continue
+			}
+			i := slices.IndexFunc(pf.Services, func(s gen.Service) bool { return s.Name == builtinService })
+			if i < 0 {
+				pf.Services = append(pf.Services, gen.Service{Name: builtinService})
+				i = len(pf.Services) - 1
+			}
+			pf.Services[i].RPCs = append(pf.Services[i].RPCs, rpc)
+		}
+	}
+	return nil
+}
+
+func hasRPC(services []gen.Service, name string) bool {
+	for _, svc := range services {
+		for _, rpc := range svc.RPCs {
+			if rpc.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyBuiltins marks the commands of the enabled built-ins, so the firmware
+// and client generators can add their built-in parts.
+func applyBuiltins(commands []gen.Command, cfg *Config) {
+	for _, name := range cfg.Builtins {
+		for _, rpc := range builtinSets[name].rpcs {
+			for i := range commands {
+				if commands[i].RequestMsg == rpc.RequestType && commands[i].ResponseMsg == rpc.ResponseType {
+					commands[i].Builtin = name
+				}
+			}
+		}
+	}
+}
+
+// generateBuiltinProto returns blerpc_builtin.proto with the messages of the
+// enabled built-ins, for the schema to import.
+func generateBuiltinProto(names []string, pkg string) string {
+	var b strings.Builder
+	b.WriteString("// Auto-generated by generate-handlers — DO NOT EDIT.\n")
+	b.WriteString("// Import this file from the schema so protoc and nanopb generate the\n")
+	b.WriteString("// messages of the built-in commands enabled in blerpc.yaml.\n")
+	b.WriteString("syntax = \"proto3\";\n")
+	b.WriteByte('\n')
+	b.WriteString("package " + pkg + ";\n")
+	for _, name := range names {
+		b.WriteByte('\n')
+		b.WriteString("// Built-in: " + name + "\n")
+		b.WriteByte('\n')
+		b.WriteString(builtinSets[name].proto)
+	}
+	return b.String()
+}
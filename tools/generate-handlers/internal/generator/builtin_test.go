@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func builtinSchema(t *testing.T, proto string, builtins ...string) ([]gen.Command, *gen.ProtoFile) {
+	t.Helper()
+	pf, err := protomodel.ParseReader(strings.NewReader(proto))
+	if err != nil {
+		t.Fatalf("protomodel.ParseReader: %v", err)
+	}
+	cfg := &Config{Builtins: builtins}
+	if err := mergeBuiltins(pf, cfg); err != nil {
+		t.Fatalf("mergeBuiltins: %v", err)
+	}
+	msgByName := make(map[string]gen.Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	var commands []gen.Command
+	if len(pf.Services) > 0 {
+		commands = protomodel.DiscoverCommandsFromServices(pf.Services, msgByName)
+	} else {
+		commands = protomodel.DiscoverCommands(pf.Messages)
+	}
+	applyBuiltins(commands, cfg)
+	return commands, pf
+}
+
+func TestMergeBuiltins(t *testing.T) {
+	const echo = "syntax = \"proto3\";\npackage blerpc;\n" +
+		"message EchoRequest { string message = 1; }\nmessage EchoResponse { string message = 1; }\n"
+
+	commands, _ := builtinSchema(t, echo, "conn_params")
+	if len(commands) != 2 || commands[1].Snake != "conn_params" || commands[1].Builtin != "conn_params" {
+		t.Fatalf("naming convention: got %+v", commands)
+	}
+	if commands[0].Builtin != "" {
+		t.Errorf("echo marked as built-in")
+	}
+
+	commands, pf := builtinSchema(t, echo+"service Tools { rpc Echo(EchoRequest) returns (EchoResponse); }\n", "conn_params")
+	if len(commands) != 2 || commands[1].Service != builtinService || commands[1].Builtin != "conn_params" {
+		t.Fatalf("services: got %+v", commands)
+	}
+
+	// A schema that imports blerpc_builtin.proto already has the messages.
+	before := len(pf.Messages)
+	if err := mergeBuiltins(pf, &Config{Builtins: []string{"conn_params"}}); err != nil {
+		t.Fatalf("mergeBuiltins: %v", err)
+	}
+	if len(pf.Messages) != before || len(pf.Services[1].RPCs) != 1 {
+		t.Errorf("merged twice: %d messages, %d built-in RPCs", len(pf.Messages), len(pf.Services[1].RPCs))
+	}
+}
+
+func TestBuiltinConnParams(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n", "conn_params")
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"proto", generateBuiltinProto([]string{"conn_params"}, "blerpc"), []string{
+			"package blerpc;\n",
+			"  CONN_PROFILE_FAST = 1;\n",
+			"message ConnParamsResponse {\n",
+		}},
+		{"c header", c.GenerateHeader(gctx, commands, nil, nil, "blerpc"), []string{
+			"struct blerpc_conn_params {\n",
+			"int blerpc_conn_params_apply(uint8_t profile, struct blerpc_conn_params *params);\n",
+		}},
+		{"c source", c.GenerateSource(gctx, commands, nil, nil, "blerpc"), []string{
+			"__attribute__((weak))\nint blerpc_conn_params_apply(uint8_t profile, struct blerpc_conn_params *params)\n{\n",
+			"    case blerpc_ConnProfile_CONN_PROFILE_FAST:\n        params = (struct blerpc_conn_params){6, 12, 0, 400};\n",
+			"    if (blerpc_conn_params_apply((uint8_t)req.profile, &params) != 0) return -1;\n",
+			"    resp.interval_max = params.interval_max;\n",
+		}},
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import contextlib\n",
+			"    @contextlib.asynccontextmanager\n    async def fast_connection(self):\n",
+			"            await self.conn_params(profile=blerpc_pb2.CONN_PROFILE_BALANCED)\n",
+			"        return await self.conn_params(profile=blerpc_pb2.CONN_PROFILE_LOW_POWER)\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    suspend fun <T> withFastConnection(block: suspend () -> T): T {\n",
+			"        connParams(profile = blerpc.Blerpc.ConnProfile.CONN_PROFILE_FAST_VALUE)\n",
+			"    suspend fun useIdleConnection(): blerpc.Blerpc.ConnParamsResponse =\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    func withFastConnection<T>(_ body: () async throws -> T) async throws -> T {\n",
+			"            _ = try? await connParams(profile: Int32(Blerpc_ConnProfile.balanced.rawValue))\n",
+			"        try await connParams(profile: Int32(Blerpc_ConnProfile.lowPower.rawValue))\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.want {
+			if !strings.Contains(tt.out, s) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, s, tt.out)
+			}
+		}
+	}
+
+	plain := []gen.Command{gentest.EchoCommand()}
+	for name, out := range map[string]string{
+		"c header": c.GenerateHeader(gctx, plain, nil, nil, "blerpc"),
+		"python":   python.GenerateClient(gctx, plain, nil, "blerpc"),
+		"kotlin":   kotlin.GenerateClient(gctx, plain, nil, "blerpc"),
+		"swift":    swift.GenerateClient(gctx, plain, nil, "blerpc"),
+	} {
+		if strings.Contains(out, "onnection") || strings.Contains(out, "conn_params") {
+			t.Errorf("%s: connection helpers without the conn_params built-in", name)
+		}
+	}
+}
+
+func TestBuiltinRPCStats(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n"+
+		"message EchoRequest { string message = 1; }\nmessage EchoResponse { string message = 1; }\n", "rpc_stats")
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c header", c.GenerateHeader(gctx, commands, nil, nil, "blerpc"), []string{
+			"struct blerpc_rpc_stat {\n",
+			"struct blerpc_rpc_stat *blerpc_rpc_stats(size_t *count);\n",
+			"uint32_t blerpc_rpc_stats_now_us(void);\n",
+		}},
+		{"c source", c.GenerateSource(gctx, commands, nil, nil, "blerpc"), []string{
+			"#if BLERPC_CMDS_BLERPC\n    RPC_STAT_ECHO,\n    RPC_STAT_GET_RPC_STATS,\n#endif\n    RPC_STAT_COUNT\n",
+			"    {\"echo\", 4, 0, 0, 0},\n",
+			"    if (ostream->callback != NULL || rc != 0) {\n",
+			"    return run_counted(RPC_STAT_ECHO, handle_echo,\n",
+			"    {\"echo\", 4, counted_echo},\n",
+			"    resp.stats.funcs.encode = encode_rpc_stats;\n",
+			"    if (req.reset && ostream->callback != NULL) {\n",
+		}},
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    async def rpc_stats_by_command(self, *, reset=False):\n",
+			"        return {s.name: s for s in resp.stats}\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    suspend fun rpcStatsByCommand(reset: Boolean = false): Map<String, blerpc.Blerpc.RpcStat> =\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    func rpcStatsByCommand(reset: Bool = false) async throws -> [String: Blerpc_RpcStat] {\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.want {
+			if !strings.Contains(tt.out, s) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, s, tt.out)
+			}
+		}
+	}
+
+	if out := c.GenerateSource(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc"); strings.Contains(out, "counted_") {
+		t.Errorf("counting wrappers without the rpc_stats built-in")
+	}
+}
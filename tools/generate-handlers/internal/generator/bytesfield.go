@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// A bytes field with a max_size in the .options file is a fixed array in the
+// nanopb struct, so a longer value cannot be sent: the C client would
+// overrun the array and the peripheral would fail to decode. The C client
+// takes such a field as a pointer and a length and returns -1 when the
+// length exceeds the array, and the C header gives handlers a setter per
+// bounded response field that checks the length before the copy. The
+// Python, Kotlin and Swift clients raise PayloadTooLargeError
+// (PayloadTooLargeException) before sending.
+
+// setBytesMaxSizes sets the MaxSize of the bounded bytes fields of every
+// command.
+func setBytesMaxSizes(commands []gen.Command, limits map[string]protomodel.FieldLimits, callbacks map[string]bool) {
+	set := func(msg string, fields []gen.Field) {
+		for i, f := range fields {
+			key := msg + "." + f.Name
+			if f.Type == "bytes" && !f.IsRepeated && !f.IsMap && !callbacks[key] {
+				fields[i].MaxSize = limits[key].MaxSize
+			}
+		}
+	}
+	for i := range commands {
+		set(commands[i].RequestMsg, commands[i].RequestFields)
+		set(commands[i].ResponseMsg, commands[i].ResponseFields)
+	}
+}
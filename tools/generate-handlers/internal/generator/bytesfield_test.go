@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// boundedBytesCommand has a bytes field with a max_size in the request and
+// in the response.
+func boundedBytesCommand() gen.Command {
+	cmd := gen.Command{
+		Camel:         "SetKey",
+		Snake:         "set_key",
+		RequestMsg:    "SetKeyRequest",
+		ResponseMsg:   "SetKeyResponse",
+		RequestFields: []gen.Field{{Type: "bytes", Name: "key", Number: 1}, {Type: "uint32", Name: "slot", Number: 2}},
+		ResponseFields: []gen.Field{
+			{Type: "bytes", Name: "nonce", Number: 1},
+		},
+	}
+	limits := map[string]protomodel.FieldLimits{
+		"SetKeyRequest.key":    {MaxSize: 16},
+		"SetKeyResponse.nonce": {MaxSize: 8},
+	}
+	commands := []gen.Command{cmd}
+	setBytesMaxSizes(commands, limits, nil)
+	return commands[0]
+}
+
+func TestSetBytesMaxSizes(t *testing.T) {
+	cmd := boundedBytesCommand()
+	if cmd.RequestFields[0].MaxSize != 16 || cmd.ResponseFields[0].MaxSize != 8 {
+		t.Errorf("MaxSize = %d, %d; want 16, 8", cmd.RequestFields[0].MaxSize, cmd.ResponseFields[0].MaxSize)
+	}
+	if cmd.RequestFields[1].MaxSize != 0 {
+		t.Errorf("MaxSize of a scalar = %d, want 0", cmd.RequestFields[1].MaxSize)
+	}
+
+	// FT_CALLBACK fields have no array to bound.
+	commands := []gen.Command{gentest.CallbackCommand()}
+	limits := map[string]protomodel.FieldLimits{"DataWriteRequest.data": {MaxSize: 64}}
+	setBytesMaxSizes(commands, limits, map[string]bool{"DataWriteRequest.data": true})
+	for _, f := range commands[0].RequestFields {
+		if f.MaxSize != 0 {
+			t.Errorf("MaxSize of callback field %s = %d, want 0", f.Name, f.MaxSize)
+		}
+	}
+}
+
+func TestBoundedBytesC(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{boundedBytesCommand()}
+
+	client := c.GenerateClientSource(cmds, nil, nil, "blerpc")
+	for _, s := range []string{
+		"int blerpc_set_key(const uint8_t *key, size_t key_len, uint32_t slot, blerpc_SetKeyResponse *resp)",
+		"    if (key_len > sizeof(req.key.bytes)) return -1;\n    memcpy(req.key.bytes, key, key_len);\n    req.key.size = (pb_size_t)key_len;\n",
+	} {
+		if !strings.Contains(client, s) {
+			t.Errorf("C client missing %q\nGot:\n%s", s, client)
+		}
+	}
+
+	minClient := c.GenerateClientMinSource(cmds, nil, nil, "blerpc")
+	for _, s := range []string{
+		"int blerpc_build_set_key(const uint8_t *key, size_t key_len, uint32_t slot,",
+		"blerpc_build_set_key(key, key_len, slot, ",
+		"if (key_len > sizeof(req.key.bytes)) return -1;",
+	} {
+		if !strings.Contains(minClient, s) {
+			t.Errorf("C min client missing %q\nGot:\n%s", s, minClient)
+		}
+	}
+
+	header := c.GenerateHeader(gctx, cmds, nil, nil, "blerpc")
+	for _, s := range []string{
+		`#include "blerpc.pb.h"`,
+		"#include <string.h>",
+		"static inline int blerpc_set_key_set_nonce(blerpc_SetKeyResponse *resp,",
+		"    if (len > sizeof(resp->nonce.bytes)) return -1;\n    memcpy(resp->nonce.bytes, data, len);\n    resp->nonce.size = (pb_size_t)len;\n    return 0;\n",
+	} {
+		if !strings.Contains(header, s) {
+			t.Errorf("C header missing %q\nGot:\n%s", s, header)
+		}
+	}
+	if strings.Contains(c.GenerateHeader(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc"), "<string.h>") {
+		t.Error("C header without bounded bytes fields should not include string.h")
+	}
+}
+
+func TestBoundedBytesClients(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{boundedBytesCommand()}
+
+	py := python.GenerateClient(gctx, cmds, nil, "blerpc")
+	for _, s := range []string{
+		"class PayloadTooLargeError(BlerpcError, ValueError):",
+		"        if len(key) > 16:\n            raise PayloadTooLargeError(\"set_key\", \"key\", len(key), 16)\n        req = ",
+	} {
+		if !strings.Contains(py, s) {
+			t.Errorf("Python client missing %q\nGot:\n%s", s, py)
+		}
+	}
+
+	kt := kotlin.GenerateClient(gctx, cmds, nil, "blerpc")
+	for _, s := range []string{
+		") : BlerpcException(\"$command: $field is $size bytes, over its max_size of $maxSize\")",
+		"        if (key.size() > 16) {\n            throw PayloadTooLargeException(\"set_key\", \"key\", key.size(), 16)\n        }\n        val req = ",
+	} {
+		if !strings.Contains(kt, s) {
+			t.Errorf("Kotlin client missing %q\nGot:\n%s", s, kt)
+		}
+	}
+
+	swift := swiftgen.GenerateClient(gctx, cmds, nil, "blerpc")
+	for _, s := range []string{
+		"struct PayloadTooLargeError: BlerpcErrorProtocol {",
+		"        if key.count > 16 {\n            throw PayloadTooLargeError(command: \"set_key\", field: \"key\", size: key.count, maxSize: 16)\n        }\n        var req = ",
+	} {
+		if !strings.Contains(swift, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, swift)
+		}
+	}
+
+	for name, out := range map[string]string{
+		"python": python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"),
+		"kotlin": kotlin.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"),
+		"swift":  swiftgen.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"),
+	} {
+		if strings.Contains(out, "PayloadTooLarge") {
+			t.Errorf("%s client without bounded bytes fields should not declare PayloadTooLarge", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Caching clients wrap a generated client and answer repeated calls of
+// cacheable commands from memory, so a UI polling GetDeviceInfo on every
+// screen does not keep the radio busy. A response is kept for the time to
+// live of its command and only reused for a call with the same request
+// bytes; failed calls are not cached. The cache is cleared on disconnect,
+// as the device may have changed while it was out of reach, and whenever
+// the app calls invalidate.
+//
+// Idempotent unary commands become cacheable with
+//
+//	option (blerpc.cache_ttl_ms) = 60000;
+//
+// or, for schemas discovered by message naming, an entry under cacheable in
+// blerpc.yaml. The fixed code is in the *CachingClient*.tmpl templates.
+
+// CacheableConfig sets the cache time to live of a command in blerpc.yaml.
+type CacheableConfig struct {
+	Command string `yaml:"command"`
+	TTLMs   int    `yaml:"ttl_ms"` // milliseconds a response is served from memory
+}
+
+// applyCacheable records the cacheable commands of blerpc.yaml and checks
+// that every cacheable command is unary and idempotent, as a cached call
+// does not reach the peripheral, and is called under its own name with
+// requests that can repeat.
+func applyCacheable(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, c := range cfg.Cacheable {
+		idx, ok := bySnake[c.Command]
+		if !ok {
+			return fmt.Errorf("cacheable[%d]: unknown command %q", i, c.Command)
+		}
+		if c.TTLMs <= 0 {
+			return fmt.Errorf("cacheable[%d]: %s needs a positive ttl_ms", i, c.Command)
+		}
+		commands[idx].CacheTTLMs = c.TTLMs
+	}
+	for _, cmd := range commands {
+		if cmd.CacheTTLMs == 0 {
+			continue
+		}
+		switch {
+		case streaming[cmd.Snake] != "":
+			return fmt.Errorf("%s: streaming commands cannot be cached", cmd.Snake)
+		case !cmd.Idempotent:
+			return fmt.Errorf("%s: only idempotent commands can be cached", cmd.Snake)
+		case cmd.ReplayProtected:
+			return fmt.Errorf("%s: replay-protected commands cannot be cached, as no two requests are alike", cmd.Snake)
+		case cmd.Compression != "":
+			return fmt.Errorf("%s: compressed commands cannot be cached, as they are called in the compression envelope", cmd.Snake)
+		}
+	}
+	return nil
+}
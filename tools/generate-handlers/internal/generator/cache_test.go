@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestApplyCacheable(t *testing.T) {
+	idempotent := gentest.EchoCommand()
+	idempotent.Idempotent = true
+	replayed := idempotent
+	replayed.ReplayProtected = true
+	compressed := idempotent
+	compressed.Compression = "deflate"
+	tests := []struct {
+		name      string
+		cmd       gen.Command
+		cacheable []CacheableConfig
+		streaming map[string]string
+		want      string
+	}{
+		{"unknown", idempotent, []CacheableConfig{{Command: "missing", TTLMs: 1000}}, nil, `unknown command "missing"`},
+		{"no ttl", idempotent, []CacheableConfig{{Command: "echo"}}, nil, "needs a positive ttl_ms"},
+		{"not idempotent", gentest.EchoCommand(), []CacheableConfig{{Command: "echo", TTLMs: 1000}}, nil, "only idempotent commands can be cached"},
+		{"streaming", idempotent, []CacheableConfig{{Command: "echo", TTLMs: 1000}}, map[string]string{"echo": "p2c"}, "streaming commands cannot be cached"},
+		{"replay protected", replayed, []CacheableConfig{{Command: "echo", TTLMs: 1000}}, nil, "replay-protected commands cannot be cached"},
+		{"compressed", compressed, []CacheableConfig{{Command: "echo", TTLMs: 1000}}, nil, "compressed commands cannot be cached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyCacheable([]gen.Command{tt.cmd}, &Config{Cacheable: tt.cacheable}, tt.streaming)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cmds := []gen.Command{idempotent}
+	if err := applyCacheable(cmds, &Config{Cacheable: []CacheableConfig{{Command: "echo", TTLMs: 1500}}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].CacheTTLMs != 1500 {
+		t.Errorf("CacheTTLMs = %d, want 1500", cmds[0].CacheTTLMs)
+	}
+}
+
+func TestGenerateCacheTables(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.CacheTTLMs = 1500
+	echo.ID = 3
+	plain := gentest.EchoCommand()
+	plain.Camel, plain.Snake = "Plain", "plain"
+
+	py := python.GenerateCache(gctx, []gen.Command{echo, plain})
+	for _, want := range []string{
+		"from .generated_client import CommandId, GeneratedClientMixin\n",
+		"    \"echo\": 1.5,\n    CommandId.ECHO.wire_name: 1.5,\n}\n",
+		"class CachingClient(GeneratedClientMixin):",
+	} {
+		if !strings.Contains(py, want) {
+			t.Errorf("Python cache missing %q", want)
+		}
+	}
+	if strings.Contains(py, "plain") {
+		t.Error("Python cache lists a command without a ttl")
+	}
+
+	kt := kotlin.GenerateCache(gctx, []gen.Command{echo}, "blerpc")
+	if !strings.Contains(kt, "        \"echo\" to 1500L,\n        CommandId.ECHO.wireName to 1500L,\n") {
+		t.Errorf("Kotlin cache table wrong:\n%s", kt)
+	}
+
+	swift := swiftgen.GenerateCache(gctx, nil)
+	if !strings.Contains(swift, "let cacheTTLs: [String: TimeInterval] = [:]\n") {
+		t.Errorf("Swift cache table not empty:\n%s", swift)
+	}
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Call policies bound how long the Python, Kotlin and Swift clients wait for
+// a command and how often they try it again, so a flash erase gets 30 s
+// while a ping fails after 500 ms. Each attempt of a call fails with the
+// client's timeout error once the timeout passes; idempotent commands are
+// called again after a timeout or transport error up to their retries.
+// Commands without a policy wait as long as the transport does.
+//
+// Commands get a policy with
+//
+//	option (blerpc.timeout_ms) = 500;
+//	option (blerpc.retries) = 2;  // needs idempotency_level
+//
+// or, for schemas discovered by message naming, an entry under call_policies
+// in blerpc.yaml.
+
+// CallPolicyConfig sets the timeout and retries of a command in blerpc.yaml.
+type CallPolicyConfig struct {
+	Command   string `yaml:"command"`
+	TimeoutMs int    `yaml:"timeout_ms"`
+	Retries   int    `yaml:"retries"`
+}
+
+// maxRetries bounds the attempts of a call, keeping a dead link from
+// stalling the client for long.
+const maxRetries = 10
+
+// applyCallPolicies records the call policies of blerpc.yaml and checks that
+// every command with a policy is unary and that only idempotent commands are
+// retried: a lost response may mean the call did run.
+func applyCallPolicies(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, p := range cfg.CallPolicies {
+		idx, ok := bySnake[p.Command]
+		if !ok {
+			return fmt.Errorf("call_policies[%d]: unknown command %q", i, p.Command)
+		}
+		if p.TimeoutMs <= 0 {
+			return fmt.Errorf("call_policies[%d]: %s needs a positive timeout_ms", i, p.Command)
+		}
+		commands[idx].TimeoutMs = p.TimeoutMs
+		commands[idx].Retries = p.Retries
+	}
+	for _, cmd := range commands {
+		if cmd.TimeoutMs == 0 && cmd.Retries == 0 {
+			continue
+		}
+		switch {
+		case streaming[cmd.Snake] != "":
+			return fmt.Errorf("%s: streaming commands cannot have a timeout or retries", cmd.Snake)
+		case cmd.TimeoutMs == 0:
+			return fmt.Errorf("%s: retries need a timeout_ms", cmd.Snake)
+		case cmd.Retries < 0 || cmd.Retries > maxRetries:
+			return fmt.Errorf("%s: retries must be between 0 and %d", cmd.Snake, maxRetries)
+		case cmd.Retries > 0 && !cmd.Idempotent:
+			return fmt.Errorf("%s: only idempotent commands can be retried", cmd.Snake)
+		}
+	}
+	return nil
+}
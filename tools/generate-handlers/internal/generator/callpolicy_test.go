@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestApplyCallPolicies(t *testing.T) {
+	idempotent := gentest.EchoCommand()
+	idempotent.Idempotent = true
+	tests := []struct {
+		name      string
+		cmd       gen.Command
+		policies  []CallPolicyConfig
+		streaming map[string]string
+		want      string
+	}{
+		{"unknown", gentest.EchoCommand(), []CallPolicyConfig{{Command: "missing", TimeoutMs: 500}}, nil, `unknown command "missing"`},
+		{"no timeout", idempotent, []CallPolicyConfig{{Command: "echo", Retries: 2}}, nil, "needs a positive timeout_ms"},
+		{"not idempotent", gentest.EchoCommand(), []CallPolicyConfig{{Command: "echo", TimeoutMs: 500, Retries: 2}}, nil, "only idempotent commands can be retried"},
+		{"too many retries", idempotent, []CallPolicyConfig{{Command: "echo", TimeoutMs: 500, Retries: 11}}, nil, "retries must be between 0 and 10"},
+		{"streaming", gentest.EchoCommand(), []CallPolicyConfig{{Command: "echo", TimeoutMs: 500}}, map[string]string{"echo": "p2c"}, "streaming commands cannot have a timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyCallPolicies([]gen.Command{tt.cmd}, &Config{CallPolicies: tt.policies}, tt.streaming)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Options set the policy without blerpc.yaml, and are checked as well.
+	optioned := gentest.EchoCommand()
+	optioned.Retries = 1
+	if err := applyCallPolicies([]gen.Command{optioned}, &Config{}, nil); err == nil || !strings.Contains(err.Error(), "retries need a timeout_ms") {
+		t.Errorf("expected a missing timeout error, got %v", err)
+	}
+
+	cmds := []gen.Command{idempotent}
+	if err := applyCallPolicies(cmds, &Config{CallPolicies: []CallPolicyConfig{{Command: "echo", TimeoutMs: 500, Retries: 2}}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].TimeoutMs != 500 || cmds[0].Retries != 2 {
+		t.Errorf("TimeoutMs = %d, Retries = %d", cmds[0].TimeoutMs, cmds[0].Retries)
+	}
+}
+
+func TestGenerateCallPolicies(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.Idempotent = true
+	echo.TimeoutMs = 500
+	echo.Retries = 2
+	commands := []gen.Command{echo}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"from typing import NamedTuple, Protocol\n",
+			"    \"echo\": CallPolicy(timeout_s=0.5, retries=2),\n",
+			"async def _call_with_policy(command, call):",
+			"            return await asyncio.wait_for(call(), policy.timeout_s)\n",
+			"resp_data = await _call_with_policy(\n",
+			"\"echo\", lambda: self._call(\"echo\", req.SerializeToString())\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import kotlinx.coroutines.withTimeout\n",
+			"    \"echo\" to CallPolicy(500, 2),\n",
+			"protected suspend fun <T> withCallPolicy(command: String, block: suspend () -> T): T {",
+			"val respData = exclusive { withCallPolicy(\"echo\") { call(\"echo\", req.toByteArray()) } }\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    \"echo\": CallPolicy(timeoutMs: 500, retries: 2),\n",
+			"func withCallPolicy(_ command: String, _ body: @escaping @Sendable () async throws -> Data) async throws -> Data {",
+			"        let reqData = try req.serializedData()\n",
+			"try await withCallPolicy(\"echo\") { try await self.call(cmdName: \"echo\", requestData: reqData) }\n",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+
+	// Without policies the clients call the transport directly.
+	if py := python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"); strings.Contains(py, "CALL_POLICIES") {
+		t.Error("python: call policies emitted without a policy")
+	}
+}
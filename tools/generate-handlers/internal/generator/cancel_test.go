@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateStreamCancel(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+
+	outputs := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c header", c.GenerateHeader(gctx, cmds, streaming, nil, "blerpc"), []string{
+			"#define BLERPC_CONTROL_CMD_CANCEL 0x7\n",
+			"void blerpc_stream_cancel(void);\n",
+			"bool blerpc_stream_should_stop(void);\n",
+		}},
+		{"c source", c.GenerateSource(gctx, cmds, streaming, nil, "blerpc"), []string{
+			"static volatile bool stream_cancelled;\n",
+			"bool blerpc_stream_should_stop(void)\n{\n    return stream_cancelled;\n}\n",
+			"int blerpc_stream_end(void)\n{\n    stream_cancelled = false;\n",
+		}},
+		{"python", python.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"CONTROL_CMD_CANCEL = 0x7\n",
+			"    return bytes([transaction_id & 0xFF, 0, 0xC0 | CONTROL_CMD_CANCEL << 2, 0])\n",
+			"            except (asyncio.CancelledError, GeneratorExit):\n",
+			"                await self.stream_cancel()\n                raise\n",
+			"    async def stream_cancel(self) -> None:\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"import kotlinx.coroutines.NonCancellable\n",
+			"fun cancelContainer(transactionId: Int): ByteArray =\n",
+			"                } catch (e: CancellationException) {\n",
+			"withContext(NonCancellable) { streamCancel() }\n",
+			"    open suspend fun streamCancel() {}\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"func cancelContainer(transactionId: UInt8) -> Data {\n",
+			"    func streamCancel() async\n",
+			"    func streamCancel() async {}\n",
+			"await Task { await self.streamCancel() }.value\n",
+		}},
+	}
+	for _, tt := range outputs {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+
+	// Without server streams there is nothing to cancel.
+	if h := c.GenerateHeader(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc"); strings.Contains(h, "stream_cancel") {
+		t.Error("c header: cancel hooks emitted without streams")
+	}
+	if py := python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"); strings.Contains(py, "make_cancel") {
+		t.Error("python: cancel container emitted without streams")
+	}
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestBuiltinCapabilities(t *testing.T) {
+	gctx := gen.NewContext()
+	const echo = "syntax = \"proto3\";\npackage blerpc;\n" +
+		"message EchoRequest { string message = 1; }\nmessage EchoResponse { string message = 1; }\n"
+	commands, _ := builtinSchema(t, echo, "capabilities")
+	if len(commands) != 2 || commands[1].Snake != "get_capabilities" {
+		t.Fatalf("got %+v", commands)
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_IMPLEMENTS(cmd) const bool blerpc_implements_##cmd = true\n",
+		"extern const bool blerpc_implements_echo;\n",
+		"const struct blerpc_capability *blerpc_capabilities(size_t *count);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+	if strings.Contains(header, "blerpc_implements_get_capabilities") {
+		t.Error("built-ins should not have an implemented flag")
+	}
+
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"__attribute__((weak)) const bool blerpc_implements_echo = false;\n",
+		"    {\"echo\", 4, 0, &blerpc_implements_echo},\n",
+		"    {\"get_capabilities\", 16, 0, &builtin_implemented},\n",
+		"resp.names.funcs.encode = encode_capability_names;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"async def supported_commands(self):",
+			"        return {names[w] for w in resp.names if w in names}\n",
+			"async def supports(self, command):",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"            \"echo\" to \"echo\",\n",
+			"suspend fun supports(command: String): Boolean = command in supportedCommands()",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"        return Set(resp.names.compactMap { names[$0] })\n",
+			"func supports(_ command: String) async throws -> Bool {",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
+
+func TestBuiltinCapabilities_CommandIDs(t *testing.T) {
+	gctx := gen.NewContext()
+	const echo = "syntax = \"proto3\";\npackage blerpc;\n" +
+		"message EchoRequest { string message = 1; }\nmessage EchoResponse { string message = 1; }\n"
+	commands, _ := builtinSchema(t, echo, "capabilities")
+	if err := assignCommandIDs(commands, map[string]int{}); err != nil {
+		t.Fatal(err)
+	}
+
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"    {\"echo\", 4, BLERPC_CMD_ID_ECHO, &blerpc_implements_echo},\n",
+		"        bitmap[caps[i].id / 8] |= (uint8_t)(1u << (caps[i].id % 8));\n",
+		"resp.command_ids.funcs.encode = encode_capability_ids;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+	py := python.GenerateClient(gctx, commands, nil, "blerpc")
+	for _, want := range []string{
+		"            \"echo\": 1,\n            \"get_capabilities\": 2,\n",
+		"            if i // 8 < len(bitmap) and bitmap[i // 8] >> (i % 8) & 1\n",
+	} {
+		if !strings.Contains(py, want) {
+			t.Errorf("python: missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+)
+
+func TestGenerateCapture(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.ID = 1
+	echo.ReplayProtected = true
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	goOut := golang.GenerateCapture(gctx, cmds, nil, "blerpc")
+	if _, err := parser.ParseFile(token.NewFileSet(), "capture.go", goOut, 0); err != nil {
+		t.Fatalf("generated capture.go does not parse: %v\n%s", err, goOut)
+	}
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateCapture(gctx, cmds, nil), []string{
+			"from .generated_client import BlerpcError, CommandId, GeneratedClientMixin\n",
+			"CAPTURE_COMMANDS = {\n    CommandId.ECHO.wire_name: \"echo\",\n}\n",
+			"UNREPLAYABLE_COMMANDS = frozenset(\n    {\n        \"echo\",\n    }\n)\n",
+			"class RecordingClient(GeneratedClientMixin):\n",
+			"async def replay(client: Any, entries: Iterable[dict[str, Any]]) -> list[str]:\n",
+		}},
+		{"go", goOut, []string{
+			"package blerpcclient\n",
+			"\t\"\\x01\": \"echo\",\n",
+			"var unreplayable = map[string]bool{\n\t\"echo\": true,\n}\n",
+			"func NewRecorder(t Transport, w io.Writer) *Recorder {",
+			"func Replay(ctx context.Context, t Transport, entries []CaptureEntry) []string {",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// diffContext is the number of unchanged lines around each change in the
+// -check diffs.
+const diffContext = 3
+
+// checkOutputs compares the rendered outputs with the files on disk and
+// writes a unified diff of each stale or missing file to w. It returns the
+// number of stale files and writes nothing to disk.
+func checkOutputs(outputs []gen.Output, root string, w io.Writer) (int, error) {
+	stale := 0
+	for _, out := range outputs {
+		data, err := os.ReadFile(out.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return stale, err
+		}
+		if err == nil && string(data) == out.Content {
+			continue
+		}
+		stale++
+		name := out.Path
+		if rel, err := filepath.Rel(root, out.Path); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		io.WriteString(w, unifiedDiff(name, string(data), out.Content))
+	}
+	return stale, nil
+}
+
+// dryRunOutputs writes to w the outputs that would be written, each with
+// its size and whether it is new, changed or unchanged, and the files -prune
+// would remove, followed by the diffs of the changed files. It writes
+// nothing to disk.
+func dryRunOutputs(outputs []gen.Output, prune []string, root string, w io.Writer) error {
+	var diffs strings.Builder
+	for _, out := range outputs {
+		data, err := os.ReadFile(out.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		name := relPath(root, out.Path)
+		state := "unchanged"
+		switch {
+		case err != nil:
+			state = "new"
+		case string(data) != out.Content:
+			state = "changed"
+			diffs.WriteString(unifiedDiff(name, string(data), out.Content))
+		}
+		fmt.Fprintf(w, "  %-9s %s (%d bytes)\n", state, name, len(out.Content))
+	}
+	for _, path := range prune {
+		fmt.Fprintf(w, "  %-9s %s\n", "removed", relPath(root, path))
+	}
+	if diffs.Len() > 0 {
+		fmt.Fprintln(w)
+		io.WriteString(w, diffs.String())
+	}
+	return nil
+}
+
+// printOutputs writes the content of the outputs to w. Like head, it puts
+// a ==> name <== line before each file when there is more than one.
+func printOutputs(outputs []gen.Output, root string, w io.Writer) {
+	for i, out := range outputs {
+		if len(outputs) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "==> %s <==\n", relPath(root, out.Path))
+		}
+		io.WriteString(w, out.Content)
+	}
+}
+
+// unifiedDiff renders the changes from old to new as a unified diff of
+// name; old is empty for a file that does not exist yet.
+func unifiedDiff(name, old, new string) string {
+	a, b := splitLines(old), splitLines(new)
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	from := "a/" + name
+	if old == "" {
+		from = "/dev/null"
+	}
+	fmt.Fprintf(&sb, "--- %s\n+++ b/%s\n", from, name)
+	for start := 0; start < len(ops); {
+		// Find the next change and the run of changes it starts, merging
+		// changes that are closer than two contexts apart.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+		lo, hi := max(start-diffContext, 0), min(end+diffContext, len(ops))
+
+		aStart, bStart := ops[lo].a, ops[lo].b
+		aLen, bLen := 0, 0
+		var body strings.Builder
+		for _, op := range ops[lo:hi] {
+			switch op.kind {
+			case ' ':
+				aLen++
+				bLen++
+				body.WriteString(" " + a[op.a])
+			case '-':
+				aLen++
+				body.WriteString("-" + a[op.a])
+			case '+':
+				bLen++
+				body.WriteString("+" + b[op.b])
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		sb.WriteString(body.String())
+		start = hi
+	}
+	return sb.String()
+}
+
+// hunkRange formats the 0-based start and length of a hunk side as the
+// 1-based range of a unified diff header.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// splitLines splits s after each newline, marking a missing final newline
+// the way diff does.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// diffOp is one line of an edit script: kept (' '), removed ('-') or
+// added ('+'), with its index in the old and new lines.
+type diffOp struct {
+	kind byte
+	a, b int
+}
+
+// diffLines returns the edit script turning a into b, keeping a longest
+// common subsequence of lines. The common head and tail are trimmed first,
+// so regenerating after a small schema change stays cheap.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	// lcs[i][j] is the LCS length of ma[i:] and mb[j:].
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := 0; i < pre; i++ {
+		ops = append(ops, diffOp{' ', i, i})
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', pre + i, pre + j})
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', pre + i, pre + j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', pre + i, pre + j})
+			j++
+		}
+	}
+	for k := 0; k < suf; k++ {
+		ops = append(ops, diffOp{' ', len(a) - suf + k, len(b) - suf + k})
+	}
+	return ops
+}
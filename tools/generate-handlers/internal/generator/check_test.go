@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nb\nc\nd\nE\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	want := `--- a/x.c
++++ b/x.c
+@@ -2,7 +2,7 @@
+ b
+ c
+ d
+-e
++E
+ f
+ g
+ h
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if got := unifiedDiff("x.c", old, new); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	got := unifiedDiff("new.py", "", "x = 1\n")
+	if !strings.Contains(got, "--- /dev/null\n+++ b/new.py\n@@ -0,0 +1 @@\n+x = 1\n") {
+		t.Errorf("unexpected diff for a new file:\n%s", got)
+	}
+	got = unifiedDiff("x.c", "a\nb", "a\nb\n")
+	if !strings.Contains(got, "-b\n\\ No newline at end of file\n+b\n") {
+		t.Errorf("missing final newline not marked:\n%s", got)
+	}
+}
+
+func TestCheckOutputs(t *testing.T) {
+	root := t.TempDir()
+	fresh, stale := filepath.Join(root, "fresh.h"), filepath.Join(root, "src", "stale.c")
+	if err := os.WriteFile(fresh, []byte("int x;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outputs := []gen.Output{{Path: fresh, Content: "int x;\n"}, {Path: stale, Content: "int y;\n"}}
+
+	var w bytes.Buffer
+	n, err := checkOutputs(outputs, root, &w)
+	if err != nil {
+		t.Fatalf("checkOutputs: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 stale file, got %d", n)
+	}
+	if !strings.Contains(w.String(), "+++ b/src/stale.c\n") || strings.Contains(w.String(), "fresh.h") {
+		t.Errorf("unexpected report:\n%s", w.String())
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("checkOutputs wrote a file")
+	}
+}
+
+func TestDryRunOutputs(t *testing.T) {
+	root := t.TempDir()
+	same, changed := filepath.Join(root, "same.h"), filepath.Join(root, "changed.c")
+	os.WriteFile(same, []byte("int x;\n"), 0o644)
+	os.WriteFile(changed, []byte("int y;\n"), 0o644)
+	outputs := []gen.Output{
+		{Path: same, Content: "int x;\n"},
+		{Path: changed, Content: "int z;\n"},
+		{Path: filepath.Join(root, "src", "new.c"), Content: "int w;\n"},
+	}
+	var w bytes.Buffer
+	if err := dryRunOutputs(outputs, []string{filepath.Join(root, "old.c")}, root, &w); err != nil {
+		t.Fatalf("dryRunOutputs: %v", err)
+	}
+	got := w.String()
+	for _, want := range []string{
+		"  unchanged same.h (7 bytes)\n",
+		"  changed   changed.c (7 bytes)\n",
+		"  new       src/new.c (7 bytes)\n",
+		"  removed   old.c\n",
+		"-int y;\n+int z;\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "+int w;") {
+		t.Errorf("new file diffed:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "src")); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote files")
+	}
+}
+
+func TestPrintOutputs(t *testing.T) {
+	root := t.TempDir()
+	var w bytes.Buffer
+	printOutputs([]gen.Output{{Path: filepath.Join(root, "a.h"), Content: "int a;\n"}}, root, &w)
+	if got := w.String(); got != "int a;\n" {
+		t.Errorf("single output printed as %q", got)
+	}
+	w.Reset()
+	printOutputs([]gen.Output{{Path: filepath.Join(root, "a.h"), Content: "int a;\n"}, {Path: filepath.Join(root, "b.c"), Content: "int b;\n"}}, root, &w)
+	if got, want := w.String(), "==> a.h <==\nint a;\n\n==> b.c <==\nint b;\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// parseCommandIDLock reads the command ID lock file, mapping wire names to
+// IDs. A missing file yields an empty lock.
+func parseCommandIDLock(fsys fs.FS, path string) (map[string]int, error) {
+	lock := make(map[string]int)
+	f, err := fsys.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return lock, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	owner := make(map[int]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid command ID line (expected 'name id'): %q", line)
+		}
+		id, err := strconv.Atoi(parts[1])
+		if err != nil || id < 1 || id > gen.MaxCommandID {
+			return nil, fmt.Errorf("invalid command ID %q for %s (must be 1-%d)", parts[1], parts[0], gen.MaxCommandID)
+		}
+		if other, ok := owner[id]; ok {
+			return nil, fmt.Errorf("command ID %d assigned to both %s and %s", id, other, parts[0])
+		}
+		if _, ok := lock[parts[0]]; ok {
+			return nil, fmt.Errorf("command %s listed twice", parts[0])
+		}
+		owner[id] = parts[0]
+		lock[parts[0]] = id
+	}
+	return lock, scanner.Err()
+}
+
+// assignCommandIDs sets the ID of every command from the lock, adding the
+// commands it lacks with IDs above every ID ever assigned.
+func assignCommandIDs(commands []gen.Command, lock map[string]int) error {
+	next := 1
+	for _, id := range lock {
+		next = max(next, id+1)
+	}
+	for i, cmd := range commands {
+		// A one-byte name would be read as an ID.
+		if len(cmd.Wire()) == 1 {
+			return fmt.Errorf("command %s: one-byte wire name %q is reserved for command IDs", cmd.Camel, cmd.Wire())
+		}
+		id, ok := lock[cmd.Wire()]
+		if !ok {
+			if next > gen.MaxCommandID {
+				return fmt.Errorf("command %s: all %d command IDs are taken; remove the entries of deleted commands from the lock file only if no deployed device or app uses them", cmd.Camel, gen.MaxCommandID)
+			}
+			id = next
+			next++
+			lock[cmd.Wire()] = id
+		}
+		commands[i].ID = id
+	}
+	return nil
+}
+
+// generateCommandIDLock renders the lock file, ordered by ID.
+func generateCommandIDLock(lock map[string]int) string {
+	names := make([]string, 0, len(lock))
+	for name := range lock {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return lock[names[i]] < lock[names[j]] })
+
+	var b strings.Builder
+	b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+	b.WriteString("# Numeric command IDs assigned by generate-handlers: <wire name> <id>.\n")
+	b.WriteString("# Keep this file under version control. IDs never change, and the IDs of\n")
+	b.WriteString("# removed commands stay reserved so they are not reused.\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("%s %d\n", name, lock[name]))
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestParseCommandIDLock(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := parseCommandIDLock(protomodel.OS, filepath.Join(dir, "missing.lock"))
+	if err != nil || len(lock) != 0 {
+		t.Fatalf("missing file: got %v, %v", lock, err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"fields", "echo\n", "expected 'name id'"},
+		{"zero", "echo 0\n", "must be 1-127"},
+		{"too large", "echo 128\n", "must be 1-127"},
+		{"shared id", "echo 1\nflash_read 1\n", "assigned to both echo and flash_read"},
+		{"twice", "echo 1\necho 2\n", "listed twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "command_ids.lock")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := parseCommandIDLock(protomodel.OS, path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAssignCommandIDs(t *testing.T) {
+	// counter_upload was removed; its ID stays reserved.
+	lock := map[string]int{"counter_stream": 1, "counter_upload": 2}
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	if err := assignCommandIDs(cmds, lock); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].ID != 3 || cmds[1].ID != 1 {
+		t.Errorf("got echo=%d, counter_stream=%d; want 3, 1", cmds[0].ID, cmds[1].ID)
+	}
+	if got := generateCommandIDLock(lock); !strings.Contains(got, "counter_stream 1\ncounter_upload 2\necho 3\n") {
+		t.Errorf("lock not ordered by ID:\n%s", got)
+	}
+
+	short := gentest.EchoCommand()
+	short.WireName = "e"
+	if err := assignCommandIDs([]gen.Command{short}, map[string]int{}); err == nil || !strings.Contains(err.Error(), "reserved for command IDs") {
+		t.Errorf("one-byte wire name: got %v", err)
+	}
+	if err := assignCommandIDs([]gen.Command{gentest.EchoCommand()}, map[string]int{"old": gen.MaxCommandID}); err == nil || !strings.Contains(err.Error(), "command IDs are taken") {
+		t.Errorf("exhausted IDs: got %v", err)
+	}
+}
+
+func TestGenerateCommandIDs(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.ID = 1
+	echo.Idempotent = true
+	stream := gentest.StreamP2CCommand()
+	stream.ID = 2
+	cmds := []gen.Command{echo, stream}
+	streaming := map[string]string{"counter_stream": "p2c"}
+
+	header := c.GenerateHeader(gctx, cmds, nil, nil, "blerpc")
+	if !strings.Contains(header, "enum blerpc_command_id {\n    BLERPC_CMD_ID_ECHO = 1,\n    BLERPC_CMD_ID_COUNTER_STREAM = 2,\n};") {
+		t.Errorf("header missing ID enum:\n%s", header)
+	}
+	src := c.GenerateSource(gctx, cmds, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static const uint8_t command_ids[] = {\n#if BLERPC_CMDS_BLERPC\n    BLERPC_CMD_ID_ECHO,\n",
+		"if ((name_len == 1 && (uint8_t)name[0] == command_ids[i]) ||",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+	if src := c.GenerateSource(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc"); strings.Contains(src, "command_ids") {
+		t.Error("command_ids emitted without IDs")
+	}
+
+	outputs := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c client", c.GenerateClientSource(cmds, streaming, nil, "blerpc"), []string{
+			`blerpc_rpc_call("\x01", req_buf, ostream.bytes_written,`,
+			`blerpc_stream_receive("\x02", req_buf, ostream.bytes_written,`,
+		}},
+		{"python", python.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"import enum\n",
+			"class CommandId(enum.IntEnum):",
+			"    COUNTER_STREAM = 2\n",
+			"resp_data = await self._call(\n                CommandId.ECHO.wire_name, req.SerializeToString()\n            )\n",
+			"CommandId.COUNTER_STREAM.wire_name, req.SerializeToString()",
+		}},
+		{"python handlers", python.GenerateHandlers(gctx, cmds, nil, "blerpc"), []string{
+			"COMMAND_IDS = {\n    \"\\x01\": \"echo\",\n",
+		}},
+		{"python resume", python.GenerateResume(cmds), []string{
+			"from .generated_client import CommandId, GeneratedClientMixin, TransportError, _rpc_lock",
+			"        \"echo\",\n        CommandId.ECHO.wire_name,\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"enum class CommandId(val id: Int) {\n    ECHO(1),\n    COUNTER_STREAM(2);\n",
+			"val wireName: String get() = Char(id).toString()",
+			"exclusive { call(CommandId.ECHO.wireName, req.toByteArray()) }",
+			"exclusive { streamReceive(CommandId.COUNTER_STREAM.wireName, req.toByteArray()) }",
+		}},
+		{"swift", swift.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"enum CommandId: UInt8 {\n    case echo = 1\n    case counterStream = 2\n",
+			"try await call(cmdName: CommandId.echo.wireName, requestData: try req.serializedData())",
+			"try await streamReceive(cmdName: CommandId.counterStream.wireName, requestData: try req.serializedData())",
+		}},
+		{"swift resume", swift.GenerateResume(gctx, cmds), []string{
+			"    \"echo\",\n    CommandId.echo.wireName,\n",
+		}},
+	}
+	for _, tt := range outputs {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// -compat-check compares the schema with a previous version, such as the
+// proto of the last release, and fails on changes that break peripherals and
+// apps already deployed with it: removed commands, changed wire names or
+// streaming directions, fields renamed, retyped or reusing the number of
+// another field, renumbered enum values and new required fields. Adding
+// commands, fields and enum values, and removing fields, stay compatible.
+
+// compatProblems returns the breaking changes from prev to cur.
+func compatProblems(prev, cur *gen.ProtoFile) ([]string, error) {
+	prevCmds, prevStreaming, err := protomodel.Discover(prev)
+	if err != nil {
+		return nil, fmt.Errorf("previous schema: %w", err)
+	}
+	curCmds, curStreaming, err := protomodel.Discover(cur)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	curByWire := make(map[string]gen.Command, len(curCmds))
+	for _, cmd := range curCmds {
+		curByWire[cmd.Wire()] = cmd
+	}
+	curMsgs := make(map[string]gen.Message, len(cur.Messages))
+	for _, m := range cur.Messages {
+		curMsgs[m.Name] = m
+	}
+	for _, old := range prevCmds {
+		cmd, ok := curByWire[old.Wire()]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("command %s removed", old.Wire()))
+			continue
+		}
+		if a, b := streamingName(prevStreaming[old.Snake]), streamingName(curStreaming[cmd.Snake]); a != b {
+			problems = append(problems, fmt.Sprintf("command %s changed from %s to %s", old.Wire(), a, b))
+		}
+		// Messages kept under their name are compared below.
+		if old.RequestMsg != cmd.RequestMsg {
+			problems = append(problems, compareFields("command "+old.Wire()+" request", old.RequestFields, cmd.RequestFields)...)
+		}
+		if old.ResponseMsg != cmd.ResponseMsg {
+			problems = append(problems, compareFields("command "+old.Wire()+" response", old.ResponseFields, cmd.ResponseFields)...)
+		}
+	}
+	for _, old := range prev.Messages {
+		if m, ok := curMsgs[old.Name]; ok {
+			problems = append(problems, compareFields("message "+old.Name, old.Fields, m.Fields)...)
+		}
+	}
+	curEnums := make(map[string]gen.Enum, len(cur.Enums))
+	for _, e := range cur.Enums {
+		curEnums[e.Name] = e
+	}
+	for _, old := range prev.Enums {
+		e, ok := curEnums[old.Name]
+		if !ok {
+			continue
+		}
+		for _, v := range old.Values {
+			i := slices.IndexFunc(e.Values, func(nv gen.EnumValue) bool { return nv.Name == v.Name })
+			if i >= 0 && e.Values[i].Number != v.Number {
+				problems = append(problems, fmt.Sprintf("enum %s: %s renumbered from %d to %d", old.Name, v.Name, v.Number, e.Values[i].Number))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// compareFields reports the breaking changes between the fields of one
+// message, matched by number.
+func compareFields(where string, prev, cur []gen.Field) []string {
+	var problems []string
+	for _, f := range cur {
+		if f.IsRequired && !slices.ContainsFunc(prev, func(old gen.Field) bool { return old.Number == f.Number }) {
+			problems = append(problems, fmt.Sprintf("%s: required field %s (%d) added", where, f.Name, f.Number))
+		}
+	}
+	for _, old := range prev {
+		i := slices.IndexFunc(cur, func(f gen.Field) bool { return f.Number == old.Number })
+		if i < 0 {
+			continue
+		}
+		f := cur[i]
+		renamed, retyped := f.Name != old.Name, fieldTypeName(f) != fieldTypeName(old)
+		switch {
+		case renamed && retyped:
+			problems = append(problems, fmt.Sprintf("%s: field number %d of %s %s reused by %s %s", where, old.Number, fieldTypeName(old), old.Name, fieldTypeName(f), f.Name))
+		case renamed:
+			problems = append(problems, fmt.Sprintf("%s: field %d renamed from %s to %s", where, old.Number, old.Name, f.Name))
+		case retyped:
+			problems = append(problems, fmt.Sprintf("%s: field %s (%d) changed from %s to %s", where, old.Name, old.Number, fieldTypeName(old), fieldTypeName(f)))
+		}
+	}
+	return problems
+}
+
+// fieldTypeName renders the type of f as the proto declares it.
+func fieldTypeName(f gen.Field) string {
+	switch {
+	case f.IsMap:
+		return "map<" + f.KeyType + ", " + f.ValueType + ">"
+	case f.IsRepeated:
+		return "repeated " + f.Type
+	}
+	return f.Type
+}
+
+// streamingName describes a streaming direction of the streaming map.
+func streamingName(dir string) string {
+	switch dir {
+	case "p2c":
+		return "peripheral-to-central streaming"
+	case "c2p":
+		return "central-to-peripheral streaming"
+	}
+	return "unary"
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+const compatPrevProto = `syntax = "proto3";
+package blerpc;
+
+enum Mode {
+  MODE_OFF = 0;
+  MODE_ON = 1;
+}
+
+message EchoRequest { string message = 1; }
+message EchoResponse { string message = 1; }
+
+message FlashReadRequest {
+  uint32 address = 1;
+  uint32 length = 2;
+  uint32 flags = 3;
+}
+message FlashReadResponse { bytes data = 1; }
+
+message ResetRequest { Mode mode = 1; }
+message ResetResponse {}
+`
+
+func TestCompatProblems(t *testing.T) {
+	parse := func(src string) *gen.ProtoFile {
+		t.Helper()
+		pf, err := protomodel.ParseReader(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pf
+	}
+	prev := parse(compatPrevProto)
+
+	problems, err := compatProblems(prev, parse(compatPrevProto+"message PingRequest {}\nmessage PingResponse {}\n"))
+	if err != nil || len(problems) != 0 {
+		t.Errorf("adding a command reported %v, %v", problems, err)
+	}
+
+	cur := parse(`syntax = "proto3";
+package blerpc;
+
+enum Mode {
+  MODE_OFF = 0;
+  MODE_ON = 2;
+}
+
+message EchoRequest { string message = 1; }
+message EchoResponse { string message = 1; }
+
+message FlashReadRequest {
+  uint32 address = 1;
+  uint64 length = 2;
+  string path = 3;
+  uint32 offset = 4;
+}
+message FlashReadResponse { bytes payload = 1; }
+`)
+	problems, err = compatProblems(prev, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"command reset removed",
+		"message FlashReadRequest: field length (2) changed from uint32 to uint64",
+		"message FlashReadRequest: field number 3 of uint32 flags reused by string path",
+		"message FlashReadResponse: field 1 renamed from data to payload",
+		"enum Mode: MODE_ON renumbered from 1 to 2",
+	}
+	if !slices.Equal(problems, want) {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompatStreaming(t *testing.T) {
+	prev, err := protomodel.ParseReader(strings.NewReader(`syntax = "proto3";
+package blerpc;
+message Req {}
+message Resp {}
+service Sensor {
+  rpc Read(Req) returns (Resp);
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := protomodel.ParseReader(strings.NewReader(`syntax = "proto3";
+package blerpc;
+message Req {}
+message Resp {}
+service Sensor {
+  rpc Read(Req) returns (stream Resp);
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := compatProblems(prev, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"command read changed from unary to peripheral-to-central streaming"}; !slices.Equal(problems, want) {
+		t.Errorf("problems = %v, want %v", problems, want)
+	}
+}
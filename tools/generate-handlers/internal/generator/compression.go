@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Compression cuts the transfer time of commands with large, redundant
+// payloads, such as log dumps and firmware manifests. A compressed command
+// keeps its plain wire format; clients call it in the reserved _compressed
+// envelope instead once the peripheral advertises
+// <PKG>_CAPABILITY_FLAG_COMPRESSION in its CAPABILITIES response and they
+// have a codec for the command's algorithm:
+//
+//	request data:  name length (1) | name | flags (1) | data
+//	response data: flags (1) | data
+//
+// Flag 0x01 marks compressed data; 0x02, in requests, lets the peripheral
+// compress the response. Each side compresses only when that makes the data
+// smaller. handlers_lookup answers _compressed with
+// <pkg>_compressed_handler (generated_compression.c), which decompresses the
+// request, runs the command's handler and compresses its response through
+// the <pkg>_compress()/_decompress() hooks the firmware implements, e.g. with
+// miniz or heatshrink. Clients that do not compress, and peripherals that do
+// not advertise the flag, keep calling the command plainly.
+//
+// Commands are compressed with
+//
+//	option (blerpc.compression) = "heatshrink";
+//
+// or, for schemas discovered by message naming, an entry under compression
+// in blerpc.yaml. The algorithms are raw DEFLATE (RFC 1951) and heatshrink
+// with a window of 8 and a lookahead of 4 bits.
+
+// CompressionConfig sets the algorithm a command is compressed with in
+// blerpc.yaml.
+type CompressionConfig struct {
+	Command   string `yaml:"command"`
+	Algorithm string `yaml:"algorithm"`
+}
+
+// applyCompression records the algorithms of blerpc.yaml and checks every
+// compressed command: it must be unary, and its request must not lead with a
+// replay counter or session token, which the envelope would compress.
+func applyCompression(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, c := range cfg.Compression {
+		idx, ok := bySnake[c.Command]
+		if !ok {
+			return fmt.Errorf("compression[%d]: unknown command %q", i, c.Command)
+		}
+		if c.Algorithm == "" {
+			return fmt.Errorf("compression[%d]: %s has no algorithm (want %s)", i, c.Command, strings.Join(gen.CompressionAlgorithms, ", "))
+		}
+		commands[idx].Compression = c.Algorithm
+	}
+	for _, cmd := range commands {
+		if cmd.Compression == "" {
+			continue
+		}
+		switch {
+		case !slices.Contains(gen.CompressionAlgorithms, cmd.Compression):
+			return fmt.Errorf("%s: unknown compression %q (want %s)", cmd.Snake, cmd.Compression, strings.Join(gen.CompressionAlgorithms, ", "))
+		case streaming[cmd.Snake] != "":
+			return fmt.Errorf("%s: streaming commands cannot be compressed", cmd.Snake)
+		case cmd.ReplayProtected || cmd.SessionProtected:
+			return fmt.Errorf("%s: replay- or session-protected commands cannot be compressed", cmd.Snake)
+		}
+	}
+	return nil
+}
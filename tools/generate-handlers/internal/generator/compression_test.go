@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestApplyCompression(t *testing.T) {
+	streaming := map[string]string{"counter_stream": "p2c"}
+	for _, tc := range []struct {
+		name    string
+		cfg     []CompressionConfig
+		mutate  func(*gen.Command)
+		wantErr string
+	}{
+		{"ok", []CompressionConfig{{"echo", "deflate"}}, nil, ""},
+		{"unknown command", []CompressionConfig{{"nope", "deflate"}}, nil, `unknown command "nope"`},
+		{"no algorithm", []CompressionConfig{{"echo", ""}}, nil, "echo has no algorithm"},
+		{"unknown algorithm", []CompressionConfig{{"echo", "lz4"}}, nil, `unknown compression "lz4"`},
+		{"streaming", []CompressionConfig{{"counter_stream", "deflate"}}, nil, "streaming commands cannot be compressed"},
+		{"session", []CompressionConfig{{"echo", "heatshrink"}}, func(c *gen.Command) { c.SessionProtected = true }, "session-protected"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			commands := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+			if tc.mutate != nil {
+				tc.mutate(&commands[0])
+			}
+			err := applyCompression(commands, &Config{Compression: tc.cfg}, streaming)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if commands[0].Compression != "deflate" {
+					t.Errorf("Compression = %q, want deflate", commands[0].Compression)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompressionClients(t *testing.T) {
+	var py, kt, sw strings.Builder
+	python.WriteCompression(&py, []gen.Command{gentest.EchoCommand()})
+	kotlin.WriteCompression(&kt)
+	kotlin.WriteCompressedCall(&kt)
+	swift.WriteCompression(&sw)
+	swift.WriteCompressedCall(&sw)
+	for _, tc := range []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"python", py.String(), []string{
+			"CAPABILITY_FLAG_COMPRESSION = 0x0002",
+			"async def _compressed_call(client, algorithm, command, req_data):",
+			"envelope = bytes([len(name)]) + name + bytes([flags]) + req_data",
+		}},
+		{"kotlin", kt.String(), []string{
+			"const val CAPABILITY_FLAG_COMPRESSION = 0x0002",
+			"object DeflateCodec : CompressionCodec {",
+			"protected suspend fun compressedCall(algorithm: String, cmdName: String, requestData: ByteArray): ByteArray {",
+		}},
+		{"swift", sw.String(), []string{
+			"let capabilityFlagCompression = 0x0002",
+			"static let deflate = CompressionCodec(",
+			"func compressedCall(_ algorithm: String, cmdName: String, requestData: Data) async throws -> Data {",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.got, want) {
+				t.Errorf("%s: missing %q", tc.name, want)
+			}
+		}
+	}
+
+	cmd := gentest.EchoCommand()
+	cmd.Compression = "deflate"
+	if got := kotlin.PolicyCall(cmd, "req.toByteArray()"); !strings.Contains(got, `compressedCall("deflate", `) {
+		t.Errorf("kotlinPolicyCall = %q, want a compressedCall", got)
+	}
+}
@@ -0,0 +1,182 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Config is the optional generator configuration read from blerpc.yaml.
+type Config struct {
+	TypeMappings     []TypeMapping       `yaml:"type_mappings"`
+	Status           *StatusConfig       `yaml:"status"`
+	Idempotent       []string            `yaml:"idempotent"`           // commands safe to retry, for schemas without RPCs
+	Queueable        []QueueableConfig   `yaml:"queueable"`            // commands the offline queue accepts
+	RateLimits       []RateLimitConfig   `yaml:"rate_limits"`          // calls per second the peripheral accepts per command
+	Guards           gen.GuardsConfig    `yaml:"guards"`               // request size checks and token bucket of the C dispatcher
+	Roles            []RoleConfig        `yaml:"roles"`                // role each command requires on the peripheral
+	Exclude          []ExcludeConfig     `yaml:"exclude"`              // client targets each command is left out of
+	ReplayProtected  []string            `yaml:"replay_protected"`     // commands whose requests carry a replay counter
+	SessionProtected []string            `yaml:"session_protected"`    // commands that need an authenticated session
+	Compression      []CompressionConfig `yaml:"compression"`          // algorithm each compressed command uses
+	CallPolicies     []CallPolicyConfig  `yaml:"call_policies"`        // client timeout and retries per command
+	Cacheable        []CacheableConfig   `yaml:"cacheable"`            // commands whose responses clients cache, with their time to live
+	Priorities       []PriorityConfig    `yaml:"priorities"`           // order in which priority clients run waiting calls
+	Builtins         []string            `yaml:"builtins"`             // built-in command sets to generate, e.g. conn_params
+	CommandIDs       bool                `yaml:"command_ids"`          // dispatch by numeric command IDs kept in a lock file
+	Framing          bool                `yaml:"framing"`              // generate the MTU framing layer of the peripheral and clients
+	CorrelationIDs   bool                `yaml:"correlation_ids"`      // frames carry a correlation ID so calls can be pipelined
+	MaxInFlight      int                 `yaml:"max_in_flight"`        // calls in flight at once with correlation IDs
+	FrameCRC         bool                `yaml:"frame_crc"`            // framed messages carry a CRC-32 the receiver checks
+	Batch            bool                `yaml:"batch"`                // generate the batch envelope of the peripheral and clients
+	UnwrapResponses  bool                `yaml:"unwrap_responses"`     // clients also return the field of single-scalar responses on its own
+	Properties       bool                `yaml:"property_accessors"`   // clients read and write Get/Set pairs of one scalar as properties
+	PythonSync       bool                `yaml:"python_sync"`          // generate the blocking Python client, sync_client.py
+	KotlinResult     bool                `yaml:"kotlin_result_client"` // generate the Kotlin client returning Result, ResultClient.kt
+	KotlinGatt       bool                `yaml:"kotlin_gatt_client"`   // generate the Android GATT transport, GattClient.kt
+	SwiftActorClient bool                `yaml:"swift_actor_client"`   // add ActorClient, an actor implementing the Swift client protocol
+	SwiftObjCClient  bool                `yaml:"swift_objc_client"`    // generate the Objective-C wrapper of the Swift client, ObjCClient.swift
+	Capture          bool                `yaml:"capture"`              // generate the Python and Go traffic recorders and replay
+	FaultInjection   bool                `yaml:"fault_injection"`      // generate the Python and Go fault-injection transports for tests
+	UserFiles        bool                `yaml:"user_files"`           // create the user handler files once, next to the generated handlers
+	Targets          map[string]bool     `yaml:"targets"`              // targets to generate; all are on unless turned off
+	Outputs          map[string]string   `yaml:"outputs"`              // output paths by -out-* flag name, relative to -root
+	Names            gen.NamesConfig     `yaml:"names"`                // per-language package and prefix names
+	GATT             GATTConfig          `yaml:"gatt"`                 // service and characteristic UUIDs
+	Airtime          AirtimeConfig       `yaml:"airtime"`              // link parameters and app flows of the airtime report
+	Plugins          map[string]string   `yaml:"plugins"`              // external generators by target; "" means blerpc-gen-<target> on PATH
+	Protos           []ProtoConfig       `yaml:"protos"`               // protos of the project, each generated with a namespace of its own
+	MergeHandlers    bool                `yaml:"merge_handlers"`       // serve the commands of every proto on one RPC characteristic
+}
+
+// StatusConfig designates a status enum. Clients of the listed commands
+// raise/throw a typed error when the response field of that enum type is not
+// the OK value.
+type StatusConfig struct {
+	Enum     string   `yaml:"enum"`
+	OK       string   `yaml:"ok"` // success value name; defaults to the zero value
+	Commands []string `yaml:"commands"`
+}
+
+// TypeMapping replaces the language type of matching fields. A mapping
+// matches either a single field ("Message.field") or every field of a proto
+// type; per-field mappings take precedence.
+type TypeMapping struct {
+	Field     string                      `yaml:"field"`
+	ProtoType string                      `yaml:"proto_type"`
+	Languages map[string]gen.TypeOverride `yaml:",inline"`
+}
+
+// typeMappingLanguages lists the targets that honor type mappings.
+var typeMappingLanguages = map[string]bool{"python": true, "kotlin": true, "swift": true}
+
+// loadConfig reads a blerpc.yaml file from fsys. A missing file yields an
+// empty config unless required is set (the path was given explicitly).
+func loadConfig(fsys fs.FS, path string, required bool) (*Config, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+	return parseConfig(data)
+}
+
+func parseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	for i, m := range cfg.TypeMappings {
+		if (m.Field == "") == (m.ProtoType == "") {
+			return nil, fmt.Errorf("type_mappings[%d]: set exactly one of field or proto_type", i)
+		}
+		if m.Field != "" && !strings.Contains(m.Field, ".") {
+			return nil, fmt.Errorf("type_mappings[%d]: field %q must be Message.field", i, m.Field)
+		}
+		for _, lang := range slices.Sorted(maps.Keys(m.Languages)) {
+			o := m.Languages[lang]
+			if !typeMappingLanguages[lang] {
+				return nil, fmt.Errorf("type_mappings[%d]: unsupported language %q", i, lang)
+			}
+			if o.Type == "" {
+				return nil, fmt.Errorf("type_mappings[%d].%s: type is required", i, lang)
+			}
+		}
+	}
+	if cfg.Status != nil && cfg.Status.Enum == "" {
+		return nil, fmt.Errorf("status: enum is required")
+	}
+	if cfg.CorrelationIDs && !cfg.Framing {
+		return nil, fmt.Errorf("correlation_ids: requires framing: true")
+	}
+	if cfg.FrameCRC && !cfg.Framing {
+		return nil, fmt.Errorf("frame_crc: requires framing: true")
+	}
+	if cfg.MaxInFlight != 0 && !cfg.CorrelationIDs {
+		return nil, fmt.Errorf("max_in_flight: requires correlation_ids: true")
+	}
+	if cfg.MaxInFlight < 0 || cfg.MaxInFlight > 255 {
+		return nil, fmt.Errorf("max_in_flight: %d is not within 1-255", cfg.MaxInFlight)
+	}
+	if err := validateBuiltins(cfg.Builtins); err != nil {
+		return nil, err
+	}
+	if err := validateTargets(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateGATT(cfg.GATT); err != nil {
+		return nil, err
+	}
+	if err := validateAirtime(cfg.Airtime); err != nil {
+		return nil, err
+	}
+	if err := validateProtos(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyTypeMappings records the configured overrides, and the built-in
+// well-known type mappings, on the request and response fields of every
+// command.
+func applyTypeMappings(commands []gen.Command, cfg *Config) {
+	byField := make(map[string]TypeMapping)
+	byType := make(map[string]TypeMapping)
+	for _, m := range append(append([]TypeMapping{}, wellKnownTypeMappings...), cfg.TypeMappings...) {
+		if m.Field != "" {
+			byField[m.Field] = m
+		} else {
+			byType[m.ProtoType] = m
+		}
+	}
+	annotate := func(msg string, fields []gen.Field) []gen.Field {
+		out := make([]gen.Field, len(fields))
+		for i, f := range fields {
+			m, ok := byField[msg+"."+f.Name]
+			if !ok {
+				m, ok = byType[strings.TrimPrefix(f.Type, ".")]
+			}
+			if ok && !f.IsMap && !f.IsRepeated {
+				f.TypeOverrides = m.Languages
+			}
+			out[i] = f
+		}
+		return out
+	}
+	for i := range commands {
+		commands[i].RequestFields = annotate(commands[i].RequestMsg, commands[i].RequestFields)
+		commands[i].ResponseFields = annotate(commands[i].ResponseMsg, commands[i].ResponseFields)
+	}
+}
@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+const typeMappingConfig = `
+type_mappings:
+  - field: DeviceInfoResponse.mac_address
+    kotlin:
+      type: MacAddress
+      decode: MacAddress({})
+      import: import com.example.MacAddress
+  - proto_type: bytes
+    python:
+      type: bytearray
+      encode: bytes({})
+      default: bytearray()
+`
+
+func TestParseConfig_TypeMappings(t *testing.T) {
+	cfg, err := parseConfig([]byte(typeMappingConfig))
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if len(cfg.TypeMappings) != 2 {
+		t.Fatalf("expected 2 type mappings, got %d", len(cfg.TypeMappings))
+	}
+	kt := cfg.TypeMappings[0].Languages["kotlin"]
+	if kt.Type != "MacAddress" || kt.Decode != "MacAddress({})" || kt.Import != "import com.example.MacAddress" {
+		t.Errorf("unexpected kotlin override: %+v", kt)
+	}
+	if py := cfg.TypeMappings[1].Languages["python"]; py.Encode != "bytes({})" || py.Default != "bytearray()" {
+		t.Errorf("unexpected python override: %+v", py)
+	}
+}
+
+func TestParseConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"no selector", "type_mappings:\n  - kotlin: {type: X}\n", "exactly one of field or proto_type"},
+		{"both selectors", "type_mappings:\n  - {field: A.b, proto_type: bytes}\n", "exactly one of field or proto_type"},
+		{"bad field", "type_mappings:\n  - {field: mac, kotlin: {type: X}}\n", "must be Message.field"},
+		{"unknown language", "type_mappings:\n  - {proto_type: bytes, rust: {type: X}}\n", `unsupported language "rust"`},
+		{"missing type", "type_mappings:\n  - {proto_type: bytes, swift: {decode: X}}\n", "type is required"},
+		{"unknown key", "typemappings: []\n", "parse config"},
+		{"unknown builtin", "builtins: [ota]\n", `unknown built-in "ota"`},
+		{"repeated builtin", "builtins: [conn_params, conn_params]\n", "listed twice"},
+		{"unknown target", "targets: {rust: true}\n", `unknown target "rust"`},
+		{"unknown output", "outputs: {kt-clinet: a.kt}\n", `unknown output "kt-clinet"`},
+		{"prefixed output", "outputs: {out-kt-client: a.kt}\n", `unknown output "out-kt-client"`},
+		{"correlation without framing", "correlation_ids: true\n", "requires framing: true"},
+		{"crc without framing", "frame_crc: true\n", "requires framing: true"},
+		{"in flight without correlation", "framing: true\nmax_in_flight: 2\n", "requires correlation_ids: true"},
+		{"too many in flight", "framing: true\ncorrelation_ids: true\nmax_in_flight: 256\n", "not within 1-255"},
+		{"relative pb2 module", "names: {python_pb2_module: .blerpc_pb2}\n", "absolute module name"},
+		{"bad c prefix", "names: {c_prefix: sensor-}\n", `c_prefix "sensor-" is not an identifier`},
+		{"bad method style", "names: {python_method_style: kebab}\n", `python_method_style "kebab"`},
+		{"bad service uuid", "gatt: {service_uuid: 12340001-0000-1000-8000-00805F9B34FB}\n", "not a lowercase UUID"},
+		{"same uuids", "gatt: {service_uuid: 0000aaaa-0000-1000-8000-00805f9b34fb, characteristic_uuid: 0000aaaa-0000-1000-8000-00805f9b34fb}\n", "are both"},
+		{"bad phy", "airtime: {phy: coded}\n", `unknown phy "coded"`},
+		{"small mtu", "airtime: {mtu: 20}\n", "mtu 20 is not within 23-517"},
+		{"unnamed flow", "airtime: {flows: [{calls: [{command: echo}]}]}\n", "name is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConfig([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blerpc.yaml")
+	cfg, err := loadConfig(protomodel.OS, path, false)
+	if err != nil || len(cfg.TypeMappings) != 0 {
+		t.Errorf("missing optional config should be empty, got %+v, %v", cfg, err)
+	}
+	if _, err := loadConfig(protomodel.OS, path, true); err == nil {
+		t.Error("missing explicit config should fail")
+	}
+	if err := os.WriteFile(path, []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(protomodel.OS, path, true); err != nil {
+		t.Errorf("empty config should load: %v", err)
+	}
+}
+
+func TestApplyTypeMappings(t *testing.T) {
+	cfg, err := parseConfig([]byte(typeMappingConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := []gen.Command{{
+		RequestMsg:  "DeviceInfoRequest",
+		ResponseMsg: "DeviceInfoResponse",
+		RequestFields: []gen.Field{
+			{Type: "bytes", Name: "token"},
+			{Type: "bytes", Name: "blobs", IsRepeated: true},
+		},
+		ResponseFields: []gen.Field{
+			{Type: "bytes", Name: "mac_address"},
+			{Type: "string", Name: "name"},
+		},
+	}}
+	applyTypeMappings(cmds, cfg)
+
+	if _, ok := gen.FieldTypeOverride(cmds[0].RequestFields[0], "python"); !ok {
+		t.Error("proto_type mapping should apply to bytes request field")
+	}
+	if cmds[0].RequestFields[1].TypeOverrides != nil {
+		t.Error("repeated fields should not be mapped")
+	}
+	// The field mapping wins over the proto_type mapping.
+	mac := cmds[0].ResponseFields[0]
+	if _, ok := gen.FieldTypeOverride(mac, "kotlin"); !ok {
+		t.Error("field mapping should apply to mac_address")
+	}
+	if _, ok := gen.FieldTypeOverride(mac, "python"); ok {
+		t.Error("field mapping should take precedence over proto_type mapping")
+	}
+	if cmds[0].ResponseFields[1].TypeOverrides != nil {
+		t.Error("unmatched field should not be mapped")
+	}
+}
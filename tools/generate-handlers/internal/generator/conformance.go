@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The conformance suite (-out-conformance) lets CI check that the C, Python,
+// Kotlin and Swift clients put the same bytes on the air. vectors.json holds
+// per command, at the smallest and the default ATT MTU, the canonical
+// encoding of a sample request and response, the containers the central
+// writes for the call and those the peripheral answers with. Each client gets
+// a loopback client next to its mock client that frames calls with its
+// protocol library where BlerpcClient does, records the containers instead of
+// writing them and answers from a vector. Its conformance check decodes the
+// samples with the generated messages, encodes them again, calls through the
+// loopback and compares every byte. The C loopback, loopback.c in the suite
+// directory, implements the transport functions of the C client and runs the
+// vectors on the host.
+//
+// Session- and replay-protected commands are left out, as their requests
+// lead with a token and counter that change per session. The frames of
+// framing: true travel inside the containers and are not covered.
+
+// conformanceMTUs are the ATT MTUs the vectors are recorded at: the BLE
+// minimum, which splits most requests, and the default of the simulator.
+var conformanceMTUs = []int{23, 247}
+
+// Wire constants of the blerpc protocol, as in the wire package.
+const (
+	conformanceATTOverhead      = 3
+	conformanceFirstHeader      = 6
+	conformanceSubsequentHeader = 4
+	conformanceStreamEndC2P     = 0x2
+	conformanceStreamEndP2C     = 0x3
+)
+
+// conformanceSplit splits payload into the containers of transaction txn for
+// mtu, as the Splitter of the protocol libraries does.
+func conformanceSplit(payload []byte, txn byte, mtu int) [][]byte {
+	effective := mtu - conformanceATTOverhead
+	firstMax := min(effective-conformanceFirstHeader, 0xFF)
+	nextMax := min(effective-conformanceSubsequentHeader, 0xFF)
+	n := min(len(payload), firstMax)
+	first := []byte{txn, 0, 0x00}
+	first = binary.LittleEndian.AppendUint16(first, uint16(len(payload)))
+	first = append(first, byte(n))
+	out := [][]byte{append(first, payload[:n]...)}
+	for off := n; off < len(payload); off += n {
+		n = min(len(payload)-off, nextMax)
+		ct := []byte{txn, byte(len(out)), 0x01 << 6, byte(n)}
+		out = append(out, append(ct, payload[off:off+n]...))
+	}
+	return out
+}
+
+// conformanceControl encodes a CONTROL container without payload.
+func conformanceControl(txn byte, cmd byte) []byte {
+	return []byte{txn, 0, 0x03<<6 | cmd<<2, 0}
+}
+
+// conformanceHex renders containers as hex.
+func conformanceHex(cts [][]byte) []string {
+	out := make([]string, len(cts))
+	for i, ct := range cts {
+		out[i] = hex.EncodeToString(ct)
+	}
+	return out
+}
+
+// conformanceVectors returns the vectors of the commands and the commands
+// left out. Every call starts a connection: the central numbers its
+// transactions from 0, and the peripheral answers a unary request under its
+// transaction and streams under its own, also from 0, as the simulator does.
+func conformanceVectors(commands []gen.Command, streaming map[string]string, msgByName map[string]gen.Message, enumByName map[string]gen.Enum) gen.ConformanceSuite {
+	var suite gen.ConformanceSuite
+	for _, cmd := range commands {
+		switch {
+		case cmd.SessionProtected:
+			suite.Skipped = append(suite.Skipped, gen.ConformanceSkip{Command: cmd.Snake, Reason: "session protected: requests lead with the session token"})
+			continue
+		case cmd.ReplayProtected:
+			suite.Skipped = append(suite.Skipped, gen.ConformanceSkip{Command: cmd.Snake, Reason: "replay protected: requests lead with the replay counter"})
+			continue
+		}
+		name := gen.ConformanceWireName(cmd)
+		req := gen.EncodeSampleFields(cmd.RequestFields, 0, msgByName, enumByName)
+		resp := gen.EncodeSampleFields(cmd.ResponseFields, 0, msgByName, enumByName)
+		mode := cmp.Or(streaming[cmd.Snake], "unary")
+		for _, mtu := range conformanceMTUs {
+			sent := conformanceSplit(gen.ConformanceCommandPacket(false, name, req), 0, mtu)
+			answers := conformanceSplit(gen.ConformanceCommandPacket(true, name, resp), 0, mtu)
+			switch mode {
+			case "p2c":
+				answers = append(answers, conformanceControl(1, conformanceStreamEndP2C))
+			case "c2p":
+				sent = append(sent, conformanceControl(1, conformanceStreamEndC2P))
+			}
+			suite.Vectors = append(suite.Vectors, gen.ConformanceVector{
+				Command:            cmd.Snake,
+				WireName:           name,
+				Stream:             mode,
+				MTU:                mtu,
+				RequestMessage:     cmd.RequestMsg,
+				ResponseMessage:    cmd.ResponseMsg,
+				Request:            hex.EncodeToString(req),
+				Response:           hex.EncodeToString(resp),
+				RequestContainers:  conformanceHex(sent),
+				ResponseContainers: conformanceHex(answers),
+			})
+		}
+	}
+	return suite
+}
+
+// generateConformanceVectors returns vectors.json.
+func generateConformanceVectors(suite gen.ConformanceSuite) string {
+	data, _ := json.MarshalIndent(suite, "", "  ")
+	return string(data) + "\n"
+}
@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestConformanceVectors(t *testing.T) {
+	flash := gentest.EchoCommand()
+	flash.Snake, flash.SessionProtected = "flash_read", true
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand(), flash}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	suite := conformanceVectors(cmds, streaming, nil, nil)
+	if len(suite.Vectors) != 3*len(conformanceMTUs) {
+		t.Fatalf("got %d vectors, want %d", len(suite.Vectors), 3*len(conformanceMTUs))
+	}
+
+	echo := suite.Vectors[0]
+	if echo.MTU != 23 || echo.Stream != "unary" || echo.Request != "0a076d657373616765" {
+		t.Errorf("echo vector = %+v", echo)
+	}
+	// The 17-byte command packet splits after 14 bytes at MTU 23.
+	wantSent := []string{"00000011000e00046563686f09000a076d657373", "00014003616765"}
+	if !reflect.DeepEqual(echo.RequestContainers, wantSent) {
+		t.Errorf("echo request containers = %q, want %q", echo.RequestContainers, wantSent)
+	}
+	wantAnswers := []string{"00000011000e80046563686f09000a076d657373", "00014003616765"}
+	if !reflect.DeepEqual(echo.ResponseContainers, wantAnswers) {
+		t.Errorf("echo response containers = %q, want %q", echo.ResponseContainers, wantAnswers)
+	}
+	if got := suite.Vectors[1].RequestContainers; len(got) != 1 {
+		t.Errorf("echo at MTU 247 sent %d containers, want 1", len(got))
+	}
+
+	p2c, c2p := suite.Vectors[2], suite.Vectors[4]
+	if last := p2c.ResponseContainers[len(p2c.ResponseContainers)-1]; last != "0100cc00" {
+		t.Errorf("P2C vector ends with %s, want STREAM_END_P2C 0100cc00", last)
+	}
+	if last := c2p.RequestContainers[len(c2p.RequestContainers)-1]; last != "0100c800" {
+		t.Errorf("C2P vector ends with %s, want STREAM_END_C2P 0100c800", last)
+	}
+	if len(suite.Skipped) != 1 || suite.Skipped[0].Command != "flash_read" {
+		t.Errorf("skipped = %+v, want flash_read", suite.Skipped)
+	}
+
+	var decoded gen.ConformanceSuite
+	if err := json.Unmarshal([]byte(generateConformanceVectors(suite)), &decoded); err != nil {
+		t.Fatalf("vectors.json does not parse: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, suite) {
+		t.Errorf("vectors.json does not round trip")
+	}
+}
+
+func TestConformanceVectors_CommandID(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.ID = 1
+	suite := conformanceVectors([]gen.Command{echo}, nil, nil, nil)
+	if got := suite.Vectors[0].WireName; got != "\x01" {
+		t.Errorf("wire name = %q, want the ID", got)
+	}
+	if got := suite.Vectors[0].RequestContainers[0]; !strings.HasPrefix(got, "0000000e000e000101") {
+		t.Errorf("request container = %s, want the one-byte name", got)
+	}
+	for _, tc := range []struct{ got, want string }{
+		{kotlin.StringLiteral("\x01"), `"\u0001"`},
+		{swift.StringLiteral("\x01"), `"\u{01}"`},
+		{c.StringLiteral("\x01"), `"\x01"`},
+		{c.StringLiteral("echo"), `"echo"`},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("literal = %s, want %s", tc.got, tc.want)
+		}
+	}
+}
+
+func TestGenerateConformanceLoopbacks(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	suite := conformanceVectors(cmds, streaming, nil, nil)
+	for _, tc := range []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateLoopback(gctx), []string{
+			"class LoopbackClient(GeneratedClientMixin):",
+			"req_cls, resp_cls = COMMAND_MESSAGES[vector[\"command\"]]",
+			"self.sent.append(make_stream_end_c2p(transaction_id=tid).serialize())",
+		}},
+		{"kotlin", kotlin.GenerateLoopback(gctx, cmds, suite, "blerpc"), []string{
+			"package com.blerpc.android.client\n",
+			"class LoopbackClient(private val vector: ConformanceVector) : GeneratedClient() {",
+			"        \"echo\", \"echo\", \"unary\", 23,\n",
+			"        requestContainers = listOf(\"00000011000e00046563686f09000a076d657373\", \"00014003616765\"),\n",
+			"    \"counter_upload\" -> blerpc.Blerpc.CounterUploadRequest.parseFrom(data)\n",
+			"    \"counter_stream\" -> blerpc.Blerpc.CounterStreamResponse.parseFrom(data)\n",
+		}},
+		{"swift", swift.GenerateLoopback(gctx, cmds, suite, "blerpc"), []string{
+			"final class LoopbackClient: GeneratedClientProtocol, @unchecked Sendable {",
+			"        command: \"counter_stream\", wireName: \"counter_stream\", stream: \"p2c\", mtu: 247,\n",
+			"    case \"echo\": return try Blerpc_EchoRequest(serializedBytes: data)\n",
+		}},
+		{"c", c.GenerateLoopback(gctx, cmds, suite, "blerpc"), []string{
+			"int blerpc_stream_send(const char *cmd_name, size_t msg_count, blerpc_next_msg_t next_msg,",
+			"static bool decode_counter_upload(const uint8_t *data, size_t len)\n",
+			"    return pb_decode(&stream, blerpc_EchoResponse_fields, &msg);\n",
+			"        \"counter_upload\", \"counter_upload\", STREAM_C2P, 23,\n",
+			"        decode_counter_stream,\n",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.out, want) {
+				t.Errorf("%s loopback missing %q", tc.name, want)
+			}
+		}
+	}
+	if strings.Contains(swift.GenerateLoopback(gctx, cmds, suite, "blerpc"), "BlerpcSession") {
+		t.Error("Swift loopback has a session without the session built-in")
+	}
+}
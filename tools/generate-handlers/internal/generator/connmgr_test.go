@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateConnectionManagers(t *testing.T) {
+	gctx := gen.NewContext()
+	for _, tc := range []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateConnectionManager(gctx), []string{
+			"from .resuming_client import IDEMPOTENT_COMMANDS, CallInterruptedError\n",
+			"class LinkState(enum.Enum):\n",
+			"class ConnectionManager(GeneratedClientMixin):\n",
+			"            if not isinstance(error, (RemoteError, DecodeError)):\n                self._set_state(LinkState.DEGRADED)\n",
+			"self._reconnect_task = asyncio.ensure_future(self._reconnect())",
+		}},
+		{"kotlin", kotlin.GenerateConnectionManager(gctx, "blerpc"), []string{
+			"package com.blerpc.android.client\n\nimport kotlinx.coroutines.CancellationException\n",
+			"enum class LinkState { DISCONNECTED, CONNECTING, READY, DEGRADED }\n",
+			"val state: StateFlow<LinkState> = mutableState.asStateFlow()\n",
+			"override val capabilityFlags: Int get() = client.capabilityFlags\n",
+			"if (policy.maxAttempts in 1..attempt) {",
+		}},
+		{"swift", swift.GenerateConnectionManager(gctx), []string{
+			"enum LinkState: Sendable {\n",
+			"final class ConnectionManager: GeneratedClientProtocol, @unchecked Sendable {\n",
+			"var capabilityFlags: Int { client.capabilityFlags }\n",
+			"throw CallInterruptedError(command: command, underlying: error)",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.out, want) {
+				t.Errorf("%s: missing %q", tc.name, want)
+			}
+		}
+	}
+	for i, line := range strings.Split(python.GenerateConnectionManager(gctx), "\n") {
+		if len([]rune(line)) > 88 {
+			t.Errorf("python line %d longer than 88 characters: %q", i+1, line)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestDeprecatedCommand(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.Deprecated = true
+	cmds := []gen.Command{cmd}
+
+	tests := []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, cmds, nil, "blerpc"), []string{
+			"import warnings\n",
+			"        \"\"\"Call the echo command.\"\"\"\n        warnings.warn(\n            \"echo() is deprecated\",\n            DeprecationWarning,\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, nil, "blerpc"), []string{
+			"    @Deprecated(\"echo is deprecated in the schema\")\n    @Suppress(\"DEPRECATION\")\n    open suspend fun echo(",
+		}},
+		{"swift", swift.GenerateClient(gctx, cmds, nil, "blerpc"), []string{
+			"    @available(*, deprecated, message: \"echo is deprecated in the schema\")\n    func echo(",
+		}},
+		{"c handler", c.GenerateHeader(gctx, cmds, nil, nil, "blerpc"), []string{
+			"/* Deprecated. */\nint handle_echo(",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range tt.want {
+				if !strings.Contains(tt.got, want) {
+					t.Errorf("missing %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeprecatedFields(t *testing.T) {
+	cmd := gentest.EchoCommand()
+	cmd.RequestFields[0].Deprecated = true
+
+	if got := c.Deprecation(cmd); got != "/* Deprecated request fields: message. */\n" {
+		t.Errorf("cDeprecation = %q", got)
+	}
+	if got := kotlin.Deprecation(cmd, false); got != "" {
+		t.Errorf("kotlinDeprecation without params = %q", got)
+	}
+	if got := kotlin.Deprecation(cmd, true); !strings.Contains(got, "/** Deprecated request fields: `message`. */\n    @Suppress(\"DEPRECATION\")\n") {
+		t.Errorf("kotlinDeprecation = %q", got)
+	}
+	if got := swift.Deprecation(cmd, true); got != "    /// - Note: Deprecated request fields: `message`.\n" {
+		t.Errorf("swiftDeprecation = %q", got)
+	}
+	py := python.Deprecation(cmd, "        ", true)
+	if !strings.Contains(py, "        if message != \"\":\n            warnings.warn(\n                \"echo(): message is deprecated\",\n") {
+		t.Errorf("pyDeprecation = %q", py)
+	}
+	if python.HasDeprecations([]gen.Command{gentest.EchoCommand()}) {
+		t.Error("echo has no deprecations")
+	}
+}
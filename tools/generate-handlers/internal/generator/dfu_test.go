@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestBuiltinDfu(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n", "dfu")
+	dfu, ok := gen.FirmwareUpdateCommands(commands)
+	if !ok {
+		t.Fatalf("got %+v", commands)
+	}
+	if dfu.Begin.Snake != "dfu_begin" || dfu.Finalize.Snake != "dfu_finalize" {
+		t.Errorf("begin %s, finalize %s", dfu.Begin.Snake, dfu.Finalize.Snake)
+	}
+	if _, ok := gen.FirmwareUpdateCommands(commands[:2]); ok {
+		t.Error("two commands reported complete")
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_DFU_CHUNK_SIZE 128",
+		"int blerpc_dfu_begin(uint32_t size);",
+		"int blerpc_dfu_finalize(bool reboot);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"enum dfu_state {\n    DFU_IDLE,\n    DFU_RECEIVING,\n};",
+		"if (blerpc_dfu_begin(req.size) != 0) return -1;",
+		"req.data.funcs.decode = decode_dfu_data;",
+		"if (req.offset == dfu.offset && dfu_chunk.len > 0) {",
+		"if (dfu.offset != dfu.size || ~dfu.crc != dfu.crc32) return -1;",
+		"resp.max_chunk = BLERPC_DFU_CHUNK_SIZE;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+	if strings.Count(src, "static uint32_t dfu_crc_update(") != 1 {
+		t.Error("shared helpers emitted more than once")
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import pathlib\n",
+			"import zlib\n",
+			"async def update_firmware(self, path, progress=None, *, reboot=True):",
+			"resp = await self.dfu_chunk(offset=offset, data=chunk)",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"suspend fun updateFirmware(",
+			"val begun = dfuBegin(size = image.size, crc32 = crc)",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"struct FirmwareUpdateError: BlerpcErrorProtocol {",
+			"let done = try await dfuFinalize(reboot: reboot)",
+		}},
+		{"go", golang.GenerateFirmwareUpdate(dfu, "blerpc", "example.com/pb"), []string{
+			"func (u *FirmwareUpdate) Update(ctx context.Context, path string) error {",
+			"if err := u.call(ctx, \"dfu_finalize\", &pb.DfuFinalizeRequest{Reboot: !u.NoReboot}, done); err != nil {",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
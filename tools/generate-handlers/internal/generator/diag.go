@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// Errors and warnings are diagnostics. They go to stderr as text, e.g.
+// "proto/blerpc.proto:12:3: EchoRequest.id: field declared twice", or with
+// -diagnostics-json as one JSON object per line for pre-commit hooks and CI
+// annotations:
+//
+//	{"severity":"error","file":"proto/blerpc.proto","line":12,"column":3,"message":"EchoRequest.id: field declared twice"}
+//
+// Progress, such as the Generated lines, goes to stdout at the normal
+// level. -quiet drops it, leaving only diagnostics, and -verbose adds the
+// inputs of the run and the time spent rendering to stderr.
+
+var (
+	quietFlag           = commandLine.Bool("quiet", false, "print only errors and warnings")
+	verboseFlag         = commandLine.Bool("verbose", false, "also print the inputs of the run and the time spent rendering")
+	diagnosticsJSONFlag = commandLine.Bool("diagnostics-json", false, "print errors and warnings to stderr as JSON lines with severity, file, line, column and message")
+)
+
+// diagnostic is an error or warning about the inputs of a run.
+type diagnostic struct {
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// diagOut receives the diagnostics; tests replace it.
+var diagOut io.Writer = os.Stderr
+
+// String returns d as "file:line:col: message", or the message alone when
+// d has no position.
+func (d diagnostic) String() string {
+	if d.File == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+}
+
+// parserPos matches the position go-protoparser puts in its syntax errors.
+var parserPos = regexp.MustCompile(`Pos=([^:()]+):(\d+):(\d+)`)
+
+// newDiagnostic returns a diagnostic with message msg, placed at the
+// position of the first of args that is an error with one: a schema error,
+// or a syntax error of the proto parser.
+func newDiagnostic(severity, msg string, args ...any) diagnostic {
+	d := diagnostic{Severity: severity, Message: msg}
+	for _, a := range args {
+		err, ok := a.(error)
+		if !ok {
+			continue
+		}
+		var se *protomodel.SchemaError
+		if errors.As(err, &se) && se.Pos.Filename != "" {
+			d.File, d.Line, d.Column = se.Pos.Filename, se.Pos.Line, se.Pos.Column
+			return d
+		}
+		if m := parserPos.FindStringSubmatch(err.Error()); m != nil {
+			d.File = m[1]
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Column, _ = strconv.Atoi(m[3])
+			return d
+		}
+	}
+	return d
+}
+
+// emit writes d to diagOut.
+func emit(d diagnostic) {
+	if *diagnosticsJSONFlag {
+		data, _ := json.Marshal(d)
+		fmt.Fprintf(diagOut, "%s\n", data)
+		return
+	}
+	prefix := ""
+	if d.Severity == "warning" {
+		prefix = "Warning: "
+	}
+	fmt.Fprintf(diagOut, "%s%s\n", prefix, d.Message)
+}
+
+// fatalf reports an error and exits with status 1. Like log.Fatalf, the
+// message is formatted from format and args; an error among args with a
+// position places the diagnostic.
+func fatalf(format string, args ...any) {
+	emit(newDiagnostic("error", fmt.Sprintf(format, args...), args...))
+	os.Exit(1)
+}
+
+// warnf reports a warning.
+func warnf(format string, args ...any) {
+	emit(newDiagnostic("warning", fmt.Sprintf(format, args...), args...))
+}
+
+// failProblems reports every problem in errs under title and exits with
+// status 1. As text, the problems are listed under the title; as JSON, each
+// is a diagnostic of its own, with its position when it has one.
+func failProblems(title string, errs []error) {
+	if !*diagnosticsJSONFlag {
+		fmt.Fprintf(diagOut, "%s: %d problem(s)\n", title, len(errs))
+	}
+	for _, err := range errs {
+		if *diagnosticsJSONFlag {
+			msg := err.Error()
+			var se *protomodel.SchemaError
+			if errors.As(err, &se) {
+				msg = se.Msg
+			}
+			emit(newDiagnostic("error", msg, err))
+		} else {
+			fmt.Fprintf(diagOut, "  %v\n", err)
+		}
+	}
+	os.Exit(1)
+}
+
+// verbosef prints a -verbose line to stderr.
+func verbosef(format string, args ...any) {
+	if *verboseFlag {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// problemsError is a failure with several problems, reported by fail with
+// failProblems.
+type problemsError struct {
+	title string
+	errs  []error
+}
+
+func (e *problemsError) Error() string {
+	return fmt.Sprintf("%s: %v", e.title, errors.Join(e.errs...))
+}
+
+// reportGeneration reports the warnings of a generation, then fails the run
+// with err if it is set.
+func reportGeneration(warnings []diagnostic, err error) {
+	for _, d := range warnings {
+		emit(d)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// fail reports err and exits with status 1.
+func fail(err error) {
+	if p, ok := err.(*problemsError); ok {
+		failProblems(p.title, p.errs)
+		return
+	}
+	fatalf("%v", err)
+}
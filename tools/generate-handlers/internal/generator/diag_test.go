@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestNewDiagnostic(t *testing.T) {
+	schemaErr := &protomodel.SchemaError{Pos: protomodel.Position{Filename: "proto/blerpc.proto", Line: 12, Column: 3}, Msg: "EchoRequest.id: field declared twice"}
+	parseErr := errors.New(`parse proto: found "\"x\"(Token=2, Pos=proto/blerpc.proto:17:21)" but expected [;]`)
+	for _, tt := range []struct {
+		name string
+		args []any
+		want diagnostic
+	}{
+		{"schema error", []any{"proto", fmt.Errorf("invalid: %w", schemaErr)}, diagnostic{"error", "proto/blerpc.proto", 12, 3, "m"}},
+		{"parse error", []any{parseErr}, diagnostic{"error", "proto/blerpc.proto", 17, 21, "m"}},
+		{"no position", []any{errors.New("boom"), 3}, diagnostic{"error", "", 0, 0, "m"}},
+	} {
+		if got := newDiagnostic("error", "m", tt.args...); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEmit(t *testing.T) {
+	var w bytes.Buffer
+	prev := diagOut
+	diagOut = &w
+	t.Cleanup(func() { diagOut, *diagnosticsJSONFlag = prev, false })
+	for _, json := range []bool{false, true} {
+		*diagnosticsJSONFlag = json
+		emit(diagnostic{Severity: "warning", Message: "streaming.txt is deprecated"})
+		emit(diagnostic{Severity: "error", File: "a.proto", Line: 2, Column: 1, Message: "bad"})
+	}
+	want := "Warning: streaming.txt is deprecated\n" +
+		"bad\n" +
+		`{"severity":"warning","message":"streaming.txt is deprecated"}` + "\n" +
+		`{"severity":"error","file":"a.proto","line":2,"column":1,"message":"bad"}` + "\n"
+	if w.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", w.String(), want)
+	}
+}
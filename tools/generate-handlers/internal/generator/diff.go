@@ -0,0 +1,318 @@
+package generator
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// "generate-handlers diff old new" reports how the command set changed
+// between two revisions of the schema, for release notes and compatibility
+// matrices. Each side is a proto file or a document saved by -emit-model.
+// Commands are matched by wire name, messages, enums, fields and enum values
+// by name. A proto alone does not carry what blerpc.yaml sets, such as
+// command IDs and timeouts, so those are only compared between two saved
+// documents. Unlike -compat-check, diff lists every change, breaking or not.
+
+// modelChange is one added, removed or changed command, message or enum.
+type modelChange struct {
+	Change  string   `json:"change"` // added, removed or changed
+	Name    string   `json:"name"`
+	Details []string `json:"details,omitempty"`
+}
+
+type modelDiff struct {
+	Commands []modelChange `json:"commands"`
+	Messages []modelChange `json:"messages"`
+	Enums    []modelChange `json:"enums"`
+}
+
+// configAttrs are the command attributes blerpc.yaml can set.
+var configAttrs = []string{
+	"id", "builtin", "idempotent", "timeout_ms", "retries", "cache_ttl_ms", "priority", "rate_limit", "queue_ttl", "role",
+	"replay_protected", "session_protected", "compression", "gatt_service", "exclude_targets", "max_request_size", "max_response_size",
+}
+
+// runDiff implements "generate-handlers diff", printing the changes from the
+// first argument to the second to w.
+func runDiff(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the changes as JSON instead of text")
+	protoPath := fs.String("proto-path", "", "comma-separated proto import search paths")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: generate-handlers diff [-json] [-proto-path dirs] old.proto|old.json new.proto|new.json")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("want the old and the new schema, got %d arguments", fs.NArg())
+	}
+	var importPaths []string
+	if *protoPath != "" {
+		importPaths = strings.Split(*protoPath, ",")
+	}
+	prev, prevSaved, err := loadModel(protomodel.OS, fs.Arg(0), importPaths)
+	if err != nil {
+		return err
+	}
+	cur, curSaved, err := loadModel(protomodel.OS, fs.Arg(1), importPaths)
+	if err != nil {
+		return err
+	}
+	d := diffModels(prev, cur, prevSaved && curSaved)
+	if *asJSON {
+		data, _ := json.MarshalIndent(d, "", "  ")
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+	_, err = io.WriteString(w, formatModelDiff(d))
+	return err
+}
+
+// loadModel reads a document saved by -emit-model, for a path ending in
+// .json, or builds one from a proto file, reading from fsys. saved reports
+// the former.
+func loadModel(fsys fs.FS, path string, importPaths []string) (doc gen.ModelDocument, saved bool, err error) {
+	if filepath.Ext(path) == ".json" {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return doc, false, err
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return doc, false, fmt.Errorf("%s: %w", path, err)
+		}
+		if doc.Version < 1 || doc.Version > gen.ModelVersion {
+			return doc, false, fmt.Errorf("%s: unsupported model version %d (want 1-%d)", path, doc.Version, gen.ModelVersion)
+		}
+		return doc, true, nil
+	}
+	pf, err := protomodel.ParseWithImportsFS(fsys, path, importPaths)
+	if err != nil {
+		return doc, false, err
+	}
+	commands, streaming, err := protomodel.Discover(pf)
+	if err != nil {
+		return doc, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return gen.BuildModel(commands, streaming, pf, cmp.Or(pf.Package, "blerpc")), false, nil
+}
+
+// diffModels returns the changes from prev to cur, comparing the
+// configAttrs only with config.
+func diffModels(prev, cur gen.ModelDocument, config bool) modelDiff {
+	d := modelDiff{Commands: []modelChange{}, Messages: []modelChange{}, Enums: []modelChange{}}
+
+	prevMsgs := make(map[string]gen.ModelMessage, len(prev.Messages))
+	for _, m := range prev.Messages {
+		prevMsgs[m.Name] = m
+	}
+	changedMsgs := make(map[string]bool)
+	for _, m := range cur.Messages {
+		old, ok := prevMsgs[m.Name]
+		if !ok {
+			d.Messages = append(d.Messages, modelChange{Change: "added", Name: m.Name})
+			continue
+		}
+		if details := fieldChanges(old.Fields, m.Fields); len(details) > 0 {
+			d.Messages = append(d.Messages, modelChange{Change: "changed", Name: m.Name, Details: details})
+			changedMsgs[m.Name] = true
+		}
+		delete(prevMsgs, m.Name)
+	}
+	for _, m := range prev.Messages {
+		if _, ok := prevMsgs[m.Name]; ok {
+			d.Messages = append(d.Messages, modelChange{Change: "removed", Name: m.Name})
+		}
+	}
+
+	skip := map[string]bool{"camel": true, "comment": true}
+	if !config {
+		for _, attr := range configAttrs {
+			skip[attr] = true
+		}
+	}
+	prevCmds := make(map[string]gen.ModelCommand, len(prev.Commands))
+	for _, c := range prev.Commands {
+		prevCmds[c.WireName] = c
+	}
+	for _, c := range cur.Commands {
+		old, ok := prevCmds[c.WireName]
+		if !ok {
+			d.Commands = append(d.Commands, modelChange{Change: "added", Name: c.WireName})
+			continue
+		}
+		details := attrChanges(old, c, skip)
+		if old.Request == c.Request && changedMsgs[c.Request] {
+			details = append(details, "request "+c.Request+" changed")
+		}
+		if old.Response == c.Response && changedMsgs[c.Response] {
+			details = append(details, "response "+c.Response+" changed")
+		}
+		if len(details) > 0 {
+			d.Commands = append(d.Commands, modelChange{Change: "changed", Name: c.WireName, Details: details})
+		}
+		delete(prevCmds, c.WireName)
+	}
+	for _, c := range prev.Commands {
+		if _, ok := prevCmds[c.WireName]; ok {
+			d.Commands = append(d.Commands, modelChange{Change: "removed", Name: c.WireName})
+		}
+	}
+
+	prevEnums := make(map[string]gen.ModelEnum, len(prev.Enums))
+	for _, e := range prev.Enums {
+		prevEnums[e.Name] = e
+	}
+	for _, e := range cur.Enums {
+		old, ok := prevEnums[e.Name]
+		if !ok {
+			d.Enums = append(d.Enums, modelChange{Change: "added", Name: e.Name})
+			continue
+		}
+		if details := enumValueChanges(old.Values, e.Values); len(details) > 0 {
+			d.Enums = append(d.Enums, modelChange{Change: "changed", Name: e.Name, Details: details})
+		}
+		delete(prevEnums, e.Name)
+	}
+	for _, e := range prev.Enums {
+		if _, ok := prevEnums[e.Name]; ok {
+			d.Enums = append(d.Enums, modelChange{Change: "removed", Name: e.Name})
+		}
+	}
+	return d
+}
+
+// fieldChanges describes the changes between the fields of one message.
+func fieldChanges(prev, cur []gen.ModelField) []string {
+	prevByName := make(map[string]gen.ModelField, len(prev))
+	for _, f := range prev {
+		prevByName[f.Name] = f
+	}
+	skip := map[string]bool{"name": true, "comment": true}
+	var details []string
+	for _, f := range cur {
+		old, ok := prevByName[f.Name]
+		if !ok {
+			details = append(details, fmt.Sprintf("field %s added (%s = %d)", f.Name, modelFieldType(f), f.Number))
+			continue
+		}
+		for _, c := range attrChanges(old, f, skip) {
+			details = append(details, "field "+f.Name+": "+c)
+		}
+		delete(prevByName, f.Name)
+	}
+	for _, f := range prev {
+		if _, ok := prevByName[f.Name]; ok {
+			details = append(details, fmt.Sprintf("field %s removed (%s = %d)", f.Name, modelFieldType(f), f.Number))
+		}
+	}
+	return details
+}
+
+// enumValueChanges describes the changes between the values of one enum.
+func enumValueChanges(prev, cur []gen.ModelEnumValue) []string {
+	prevByName := make(map[string]int, len(prev))
+	for _, v := range prev {
+		prevByName[v.Name] = v.Number
+	}
+	var details []string
+	for _, v := range cur {
+		old, ok := prevByName[v.Name]
+		switch {
+		case !ok:
+			details = append(details, fmt.Sprintf("value %s added (%d)", v.Name, v.Number))
+		case old != v.Number:
+			details = append(details, fmt.Sprintf("value %s: number %d -> %d", v.Name, old, v.Number))
+		}
+		delete(prevByName, v.Name)
+	}
+	for _, v := range prev {
+		if _, ok := prevByName[v.Name]; ok {
+			details = append(details, fmt.Sprintf("value %s removed (%d)", v.Name, v.Number))
+		}
+	}
+	return details
+}
+
+// attrChanges describes the fields of two values of one struct type that
+// differ, by their JSON keys, except those in skip.
+func attrChanges(prev, cur any, skip map[string]bool) []string {
+	pv, cv := reflect.ValueOf(prev), reflect.ValueOf(cur)
+	var changes []string
+	for i := 0; i < pv.NumField(); i++ {
+		key, _, _ := strings.Cut(pv.Type().Field(i).Tag.Get("json"), ",")
+		if skip[key] {
+			continue
+		}
+		a, b := pv.Field(i).Interface(), cv.Field(i).Interface()
+		if !reflect.DeepEqual(a, b) {
+			changes = append(changes, fmt.Sprintf("%s %s -> %s", key, attrValue(a), attrValue(b)))
+		}
+	}
+	return changes
+}
+
+func attrValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return cmp.Or(v, "none")
+	case []string:
+		if len(v) == 0 {
+			return "none"
+		}
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// modelFieldType renders the type of f as the proto declares it.
+func modelFieldType(f gen.ModelField) string {
+	switch {
+	case f.Type == "map":
+		return "map<" + f.KeyType + ", " + f.ValueType + ">"
+	case f.Repeated:
+		return "repeated " + f.Type
+	}
+	return f.Type
+}
+
+// formatModelDiff renders d as text: a section per kind, with a line per
+// added (+), removed (-) or changed (~) item and its details indented below.
+func formatModelDiff(d modelDiff) string {
+	var b strings.Builder
+	for _, s := range []struct {
+		title   string
+		changes []modelChange
+	}{
+		{"Commands", d.Commands},
+		{"Messages", d.Messages},
+		{"Enums", d.Enums},
+	} {
+		if len(s.changes) == 0 {
+			continue
+		}
+		b.WriteString(s.title + ":\n")
+		for _, c := range s.changes {
+			mark := map[string]string{"added": "+", "removed": "-", "changed": "~"}[c.Change]
+			fmt.Fprintf(&b, "  %s %s\n", mark, c.Name)
+			for _, detail := range c.Details {
+				fmt.Fprintf(&b, "      %s\n", detail)
+			}
+		}
+	}
+	if b.Len() == 0 {
+		return "No changes.\n"
+	}
+	return b.String()
+}
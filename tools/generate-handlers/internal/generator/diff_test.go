@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+const diffOldProto = `syntax = "proto3";
+package blerpc;
+
+enum Status {
+  STATUS_OK = 0;
+  STATUS_BUSY = 1;
+}
+
+message EchoRequest {
+  string message = 1;
+}
+
+message EchoResponse {
+  string message = 1;
+}
+
+message ResetRequest {}
+
+message ResetResponse {}
+`
+
+const diffNewProto = `syntax = "proto3";
+package blerpc;
+
+enum Status {
+  STATUS_OK = 0;
+  STATUS_BUSY = 2;
+}
+
+message EchoRequest {
+  string message = 1;
+  repeated uint32 tags = 2;
+}
+
+message EchoResponse {
+  string message = 1;
+}
+
+message PingRequest {}
+
+message PingResponse {}
+`
+
+func writeDiffProtos(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	prev, cur := filepath.Join(dir, "old.proto"), filepath.Join(dir, "new.proto")
+	if err := os.WriteFile(prev, []byte(diffOldProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cur, []byte(diffNewProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return prev, cur
+}
+
+func TestRunDiff_Text(t *testing.T) {
+	prev, cur := writeDiffProtos(t)
+	var out bytes.Buffer
+	if err := runDiff([]string{prev, cur}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Commands:\n  ~ echo\n      request EchoRequest changed\n  + ping\n  - reset\n",
+		"  ~ EchoRequest\n      field tags added (repeated uint32 = 2)\n",
+		"Enums:\n  ~ Status\n      value STATUS_BUSY: number 1 -> 2\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runDiff([]string{prev, prev}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "No changes.\n" {
+		t.Errorf("same schema: %q", out.String())
+	}
+}
+
+func TestRunDiff_SavedModel(t *testing.T) {
+	prev, cur := writeDiffProtos(t)
+	doc, _, err := loadModel(protomodel.OS, prev, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Commands[0].ID = 1
+	doc.Commands[0].TimeoutMs = 500
+	data, _ := json.Marshal(doc)
+	saved := filepath.Join(t.TempDir(), "model.json")
+	if err := os.WriteFile(saved, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runDiff([]string{"-json", saved, cur}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var d modelDiff
+	if err := json.Unmarshal(out.Bytes(), &d); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	// The proto carries no blerpc.yaml settings, so the timeout is not compared.
+	if len(d.Commands) != 3 || d.Commands[0].Name != "echo" || len(d.Commands[0].Details) != 1 {
+		t.Errorf("commands = %+v", d.Commands)
+	}
+
+	d = diffModels(doc, mustLoadModel(t, prev), true)
+	if len(d.Commands) != 1 || strings.Join(d.Commands[0].Details, "; ") != "id 1 -> 0; timeout_ms 500 -> 0" {
+		t.Errorf("with config: %+v", d.Commands)
+	}
+
+	doc.Version = gen.ModelVersion + 1
+	data, _ = json.Marshal(doc)
+	if err := os.WriteFile(saved, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runDiff([]string{saved, cur}, &out); err == nil || !strings.Contains(err.Error(), "unsupported model version") {
+		t.Errorf("expected version error, got %v", err)
+	}
+}
+
+func mustLoadModel(t *testing.T, path string) gen.ModelDocument {
+	t.Helper()
+	doc, _, err := loadModel(protomodel.OS, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
@@ -0,0 +1,737 @@
+package generator
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/cpp"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/csharp"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/docs"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/rust"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// generation is what the emitters of a run share: the options and the
+// files they are read from, the schema with blerpc.yaml applied, the context
+// of the target generators, and the paths of the main outputs, next to which
+// most others go.
+type generation struct {
+	opts         *Options
+	gctx         *gen.Context
+	fsys         fs.FS
+	warnings     []diagnostic
+	cfg          *Config
+	protoFile    *gen.ProtoFile
+	protoPath    string
+	pkg          string
+	commands     []gen.Command
+	streaming    map[string]string
+	callbacks    map[string]bool
+	msgByName    map[string]gen.Message
+	enumByName   map[string]gen.Enum
+	optionLimits map[string]protomodel.FieldLimits
+	advs         []gen.Advertisement
+	events       []gen.Event
+	commandIDs   map[string]int // nil unless command_ids is set
+	buildSystems []string
+
+	commandIDLock  string
+	cHeader        string
+	cSource        string
+	pyHandlers     string
+	pyClient       string
+	ktClient       string
+	swiftClient    string
+	swiftScanner   string
+	eventsSwift    string
+	dartClient     string
+	tsClient       string
+	cClientHeader  string
+	cClientSource  string
+	cClientUUIDs   string
+	cCMake         string
+	cKconfig       string
+	cClientCMake   string
+	cClientKconfig string
+}
+
+// inFlight returns the requests the framing layer keeps in flight: none
+// without correlation IDs.
+func (g *generation) inFlight() int {
+	if !g.cfg.CorrelationIDs {
+		return 0
+	}
+	return cmp.Or(g.cfg.MaxInFlight, defaultMaxInFlight)
+}
+
+// An emitter queues the outputs of a target, or of a feature spanning
+// targets, after those queued before it. Most queue them with
+// gen.LazyOutput, to be rendered once the list is complete.
+type emitter func(g *generation, outputs []gen.Output) ([]gen.Output, error)
+
+// emitters lists the emitters in the order the command writes the files.
+// The -split emitter replaces files queued before it, and the build
+// fragments list them.
+var emitters = []emitter{
+	emitC,
+	emitPythonHandlers,
+	emitPython,
+	emitKotlin,
+	emitSwift,
+	emitDart,
+	emitTypeScript,
+	emitDocs,
+	emitDocsHTML,
+	emitCClient,
+	emitSplit,
+	emitGATTService,
+	emitPerCommandGATT,
+	emitAdvertising,
+	emitEvents,
+	emitFraming,
+	emitBatch,
+	emitCompression,
+	emitUserFiles,
+	emitBuildFragments,
+	emitBuiltinProto,
+	emitCommandIDLock,
+	emitGoTUI,
+	emitCpp,
+	emitCppClient,
+	emitCSharp,
+	emitRust,
+	emitGoSim,
+	emitGoBench,
+	emitGoWire,
+	emitGoClient,
+	emitGoGateway,
+	emitGoMQTT,
+	emitGoErrors,
+	emitGoFiles,
+	emitGoDfu,
+	emitConformance,
+	emitFixtures,
+	emitFuzz,
+}
+
+func emitC(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("c") {
+		return outputs, nil
+	}
+	if g.opts.CRuntime == "protobuf-c" {
+		return append(outputs,
+			gen.LazyOutput(g.cHeader, func() string { return c.GenerateHeaderProtobufC(g.gctx, g.commands, g.pkg) }),
+			gen.LazyOutput(g.cSource, func() string { return c.GenerateSourceProtobufC(g.gctx, g.commands, g.pkg) }),
+		), nil
+	}
+	return append(outputs,
+		gen.LazyOutput(g.cHeader, func() string { return c.GenerateHeader(g.gctx, g.commands, g.streaming, g.callbacks, g.pkg) }),
+		gen.LazyOutput(g.cSource, func() string { return c.GenerateSource(g.gctx, g.commands, g.streaming, g.callbacks, g.pkg) }),
+	), nil
+}
+
+func emitPythonHandlers(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("python_handlers") {
+		return outputs, nil
+	}
+	return append(outputs,
+		gen.LazyOutput(g.pyHandlers, func() string { return python.GenerateHandlers(g.gctx, g.commands, g.streaming, g.pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-server"), filepath.Join(filepath.Dir(g.pyHandlers), "generated_server.py")), func() string { return python.GenerateServer(g.gctx) }),
+	), nil
+}
+
+func emitPython(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("python") {
+		return outputs, nil
+	}
+	commands, dir := targetCommands(g.commands, "python"), filepath.Dir(g.pyClient)
+	outputs = append(outputs,
+		gen.LazyOutput(g.pyClient, func() string { return python.GenerateClient(g.gctx, commands, g.streaming, g.pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-resume"), filepath.Join(dir, "resuming_client.py")), func() string { return python.GenerateResume(commands) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-connmgr"), filepath.Join(dir, "connection_manager.py")), func() string { return python.GenerateConnectionManager(g.gctx) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-metrics"), filepath.Join(dir, "instrumented_client.py")), func() string { return python.GenerateInstrumented(g.gctx, commands) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-cache"), filepath.Join(dir, "caching_client.py")), func() string { return python.GenerateCache(g.gctx, commands) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-priority"), filepath.Join(dir, "priority_client.py")), func() string { return python.GeneratePriority(g.gctx, commands, g.streaming) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-redaction"), filepath.Join(dir, "redaction.py")), func() string { return python.GenerateRedaction(g.gctx, g.msgByName, g.pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-devices"), filepath.Join(dir, "device_manager.py")), func() string { return python.GenerateDevices(g.gctx, commands, g.streaming) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-scanner"), filepath.Join(dir, "generated_scanner.py")), func() string { return python.GenerateScanner(g.gctx, len(g.advs) > 0) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("uuids-py"), filepath.Join(dir, "generated_uuids.py")), func() string { return python.GenerateUUIDs(g.gctx) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-mock"), filepath.Join(dir, "mock_client.py")), func() string { return python.GenerateMock(commands) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("py-cli"), filepath.Join(dir, "cli.py")), func() string { return python.GenerateCLI(g.gctx, commands, g.streaming, g.pkg) }),
+
+		gen.Output{Path: flagOrDefault(g.opts.out("py-typed"), filepath.Join(filepath.Dir(dir), "py.typed")), Content: ""},
+	)
+	if g.cfg.Capture {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("py-capture"), filepath.Join(dir, "capture.py")), func() string { return python.GenerateCapture(g.gctx, commands, g.msgByName) }))
+	}
+	if g.opts.out("py-tests") != "" {
+		outputs = append(outputs, gen.LazyOutput(g.opts.out("py-tests"), func() string {
+			return python.GenerateTests(g.gctx, commands, g.streaming, g.enumByName, g.pkg, g.opts.out("py-tests"), g.pyClient, g.pyHandlers)
+		}))
+	}
+	if g.cfg.FaultInjection {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("py-faults"), filepath.Join(dir, "faults.py")), func() string { return python.GenerateFaults(g.gctx, commands) }))
+	}
+	if g.opts.GATT == "multiplexed" {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("py-bleak"), filepath.Join(dir, "bleak_client.py")), func() string { return python.GenerateBleakClient(g.gctx) }))
+	}
+	if g.cfg.PythonSync {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("py-sync-client"), filepath.Join(dir, "sync_client.py")), func() string { return python.GenerateSyncClient(g.gctx, commands, g.streaming, g.pkg) }))
+	}
+	return outputs, nil
+}
+
+func emitKotlin(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("kotlin") {
+		return outputs, nil
+	}
+	commands, dir, pkg := targetCommands(g.commands, "kotlin"), filepath.Dir(g.ktClient), g.pkg
+	outputs = append(outputs,
+		gen.LazyOutput(g.ktClient, func() string { return kotlin.GenerateClient(g.gctx, commands, g.streaming, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-resume"), filepath.Join(dir, "ResumingClient.kt")), func() string { return kotlin.GenerateResume(g.gctx, commands, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-connmgr"), filepath.Join(dir, "ConnectionManager.kt")), func() string { return kotlin.GenerateConnectionManager(g.gctx, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-metrics"), filepath.Join(dir, "InstrumentedClient.kt")), func() string { return kotlin.GenerateInstrumented(g.gctx, commands, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-cache"), filepath.Join(dir, "CachingClient.kt")), func() string { return kotlin.GenerateCache(g.gctx, commands, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-priority"), filepath.Join(dir, "PriorityClient.kt")), func() string { return kotlin.GeneratePriority(g.gctx, commands, pkg, g.streaming) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-redaction"), filepath.Join(dir, "Redaction.kt")), func() string { return kotlin.GenerateRedaction(g.gctx, g.msgByName, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-queue"), filepath.Join(dir, "OfflineQueue.kt")), func() string { return kotlin.GenerateQueue(g.gctx, commands, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-permissions"), filepath.Join(dir, "BlePermissions.kt")), func() string { return kotlin.GeneratePermissions(g.gctx, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-scanner"), filepath.Join(dir, "GeneratedScanner.kt")), func() string { return kotlin.GenerateScanner(g.gctx, len(g.advs) > 0, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("uuids-kt"), filepath.Join(dir, "GeneratedUuids.kt")), func() string { return kotlin.GenerateUUIDs(g.gctx, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("kt-mock"), filepath.Join(dir, "MockGeneratedClient.kt")), func() string { return kotlin.GenerateMock(g.gctx, commands, pkg) }),
+	)
+	if g.cfg.KotlinResult {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("kt-result-client"), filepath.Join(dir, "ResultClient.kt")), func() string { return kotlin.GenerateResultClient(g.gctx, commands, g.streaming, pkg) }))
+	}
+	if g.cfg.KotlinGatt {
+		if g.opts.GATT == "per-command" {
+			return nil, errors.New("kotlin_gatt_client only supports -gatt multiplexed")
+		}
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("kt-gatt-client"), filepath.Join(dir, "GattClient.kt")), func() string { return kotlin.GenerateGattClient(g.gctx, pkg) }))
+	}
+	return outputs, nil
+}
+
+func emitSwift(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("swift") {
+		return outputs, nil
+	}
+	commands, dir, pkg := targetCommands(g.commands, "swift"), filepath.Dir(g.swiftClient), g.pkg
+	outputs = append(outputs,
+		gen.LazyOutput(g.swiftClient, func() string { return swiftgen.GenerateClient(g.gctx, commands, g.streaming, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-resume"), filepath.Join(dir, "ResumingClient.swift")), func() string { return swiftgen.GenerateResume(g.gctx, commands) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-connmgr"), filepath.Join(dir, "ConnectionManager.swift")), func() string { return swiftgen.GenerateConnectionManager(g.gctx) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-metrics"), filepath.Join(dir, "InstrumentedClient.swift")), func() string { return swiftgen.GenerateInstrumented(g.gctx, commands) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-cache"), filepath.Join(dir, "CachingClient.swift")), func() string { return swiftgen.GenerateCache(g.gctx, commands) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-priority"), filepath.Join(dir, "PriorityClient.swift")), func() string { return swiftgen.GeneratePriority(g.gctx, commands, g.streaming) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-redaction"), filepath.Join(dir, "Redaction.swift")), func() string { return swiftgen.GenerateRedaction(g.gctx, g.msgByName, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-queue"), filepath.Join(dir, "OfflineQueue.swift")), func() string { return swiftgen.GenerateQueue(g.gctx, commands, pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-authorization"), filepath.Join(dir, "BleAuthorization.swift")), func() string { return swiftgen.GenerateAuthorization(pkg) }),
+		gen.LazyOutput(g.swiftScanner, func() string { return swiftgen.GenerateScanner(g.gctx, len(g.advs) > 0) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("uuids-swift"), filepath.Join(dir, "GeneratedUUIDs.swift")), func() string { return swiftgen.GenerateUUIDs(g.gctx) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("swift-mock"), filepath.Join(dir, "MockGeneratedClient.swift")), func() string { return swiftgen.GenerateMock(g.gctx, commands, pkg) }),
+	)
+	if g.cfg.SwiftObjCClient {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("swift-objc-client"), filepath.Join(dir, "ObjCClient.swift")), func() string { return swiftgen.GenerateObjCClient(g.gctx, commands, g.streaming, pkg) }))
+	}
+	return outputs, nil
+}
+
+func emitDart(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("dart") {
+		return outputs, nil
+	}
+	commands := targetCommands(g.commands, "dart")
+	return append(outputs,
+		gen.LazyOutput(g.dartClient, func() string { return dart.GenerateClient(g.gctx, commands, g.streaming, g.pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("uuids-dart"), filepath.Join(filepath.Dir(g.dartClient), "generated_uuids.dart")), func() string { return dart.GenerateUUIDs(g.gctx) }),
+	), nil
+}
+
+func emitTypeScript(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("typescript") {
+		return outputs, nil
+	}
+	commands := targetCommands(g.commands, "typescript")
+	outputs = append(outputs,
+		gen.LazyOutput(g.tsClient, func() string { return typescript.GenerateClient(g.gctx, commands, g.streaming, g.pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("uuids-ts"), filepath.Join(filepath.Dir(g.tsClient), "GeneratedUuids.ts")), func() string { return typescript.GenerateUUIDs(g.gctx) }),
+	)
+	if g.opts.out("ts-web") != "" {
+		if g.opts.GATT == "per-command" {
+			return nil, errors.New("-out-ts-web only supports -gatt multiplexed")
+		}
+		outputs = append(outputs, gen.LazyOutput(g.opts.out("ts-web"), func() string { return typescript.GenerateWebClient(g.gctx, g.opts.TSProtocolImport) }))
+	}
+	if g.opts.out("ts-node") != "" {
+		if g.opts.GATT == "per-command" {
+			return nil, errors.New("-out-ts-node only supports -gatt multiplexed")
+		}
+		outputs = append(outputs, gen.LazyOutput(g.opts.out("ts-node"), func() string { return typescript.GenerateNodeClient(g.gctx, g.opts.TSProtocolImport) }))
+	}
+	return outputs, nil
+}
+
+func emitDocs(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("docs") {
+		return outputs, nil
+	}
+	outDocs := flagOrDefault(g.opts.out("docs"), g.opts.defaultPath("docs", "api.md"))
+	return append(outputs,
+		gen.LazyOutput(outDocs, func() string { return docs.GenerateDocs(g.commands, g.streaming, g.msgByName, g.enumByName, g.pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("openrpc"), filepath.Join(filepath.Dir(outDocs), "openrpc.json")), func() string {
+			return docs.GenerateOpenRPC(g.gctx, g.commands, g.streaming, g.msgByName, g.enumByName, g.pkg)
+		}),
+		gen.LazyOutput(flagOrDefault(g.opts.out("airtime"), filepath.Join(filepath.Dir(outDocs), "airtime.md")), func() string { return generateAirtimeReport(g.commands, g.streaming, g.cfg.Airtime, g.pkg) }),
+	), nil
+}
+
+func emitDocsHTML(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("docs_html") {
+		return outputs, nil
+	}
+	outDocs := flagOrDefault(g.opts.out("docs"), g.opts.defaultPath("docs", "api.md"))
+	return append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("docs-html"), filepath.Join(filepath.Dir(outDocs), "api.html")), func() string {
+		return docs.GenerateDocsHTML(g.gctx, g.commands, g.streaming, g.protoFile, g.cfg.targetEnabled, g.msgByName, g.enumByName, g.pkg)
+	})), nil
+}
+
+func emitCClient(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.targetEnabled("c_client") {
+		return outputs, nil
+	}
+	commands := targetCommands(g.commands, "c_client")
+	outputs = append(outputs, gen.LazyOutput(g.cClientUUIDs, func() string { return c.GenerateUUIDsHeader(g.gctx, g.pkg) }))
+	if g.opts.CClientMode == "min" {
+		return append(outputs,
+			gen.LazyOutput(g.cClientHeader, func() string { return c.GenerateClientMinHeader(commands, g.streaming, g.callbacks, g.pkg) }),
+			gen.LazyOutput(g.cClientSource, func() string { return c.GenerateClientMinSource(commands, g.streaming, g.callbacks, g.pkg) }),
+		), nil
+	}
+	return append(outputs,
+		gen.LazyOutput(g.cClientHeader, func() string { return c.GenerateClientHeader(commands, g.streaming, g.callbacks, g.pkg) }),
+		gen.LazyOutput(g.cClientSource, func() string { return c.GenerateClientSource(commands, g.streaming, g.callbacks, g.pkg) }),
+	), nil
+}
+
+// emitSplit replaces the C handler source, Python client and Swift client
+// with their -split files.
+func emitSplit(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.Split == "none" {
+		return outputs, nil
+	}
+	py, swift := targetCommands(g.commands, "python"), targetCommands(g.commands, "swift")
+	outputs = replaceOutput(outputs, g.cSource, c.SplitSource(g.gctx, gen.GroupCommands(g.commands, g.opts.Split, g.pkg), g.commands, g.streaming, g.callbacks, g.pkg, g.opts.CRuntime, g.cSource))
+	outputs = replaceOutput(outputs, g.pyClient, python.SplitClient(g.gctx, gen.GroupCommands(py, g.opts.Split, g.pkg), py, g.streaming, g.pkg, g.pyClient))
+	outputs = replaceOutput(outputs, g.swiftClient, swiftgen.SplitClient(g.gctx, gen.GroupCommands(swift, g.opts.Split, g.pkg), swift, g.streaming, g.pkg, g.swiftClient))
+	return outputs, nil
+}
+
+// emitGATTService writes the RPC GATT service of -platform.
+func emitGATTService(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.Platform == "none" || !g.cfg.targetEnabled("c") {
+		return outputs, nil
+	}
+	header, source := c.GenerateGattServiceHeader(g.gctx, g.pkg), c.GenerateGattServiceSource(g.gctx, g.pkg)
+	if g.opts.Platform == "esp-idf" {
+		header, source = c.GenerateNimBLEGattServiceHeader(g.gctx, g.pkg), c.GenerateNimBLEGattServiceSource(g.gctx, g.pkg)
+	}
+	return append(outputs,
+		gen.Output{Path: flagOrDefault(g.opts.out("gatt-service-header"), filepath.Join(filepath.Dir(g.cHeader), "generated_gatt_service.h")), Content: header},
+		gen.Output{Path: flagOrDefault(g.opts.out("gatt-service-source"), filepath.Join(filepath.Dir(g.cSource), "generated_gatt_service.c")), Content: source},
+	), nil
+}
+
+func emitPerCommandGATT(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.GATT != "per-command" || !g.cfg.targetEnabled("c") {
+		return outputs, nil
+	}
+	return append(outputs,
+		gen.LazyOutput(flagOrDefault(g.opts.out("gatt-header"), g.opts.defaultPath("peripheral_fw", "src", "generated_gatt.h")), func() string { return c.GenerateGattHeader(g.gctx, g.commands, g.pkg) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("gatt-source"), g.opts.defaultPath("peripheral_fw", "src", "generated_gatt.c")), func() string { return c.GenerateGattSource(g.gctx, g.commands, g.pkg) }),
+	), nil
+}
+
+func emitAdvertising(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if len(g.advs) == 0 {
+		return outputs, nil
+	}
+	advs, pkg, companyID := g.advs, g.pkg, g.opts.AdvCompanyID
+	if g.cfg.targetEnabled("c") {
+		outputs = append(outputs,
+			gen.LazyOutput(flagOrDefault(g.opts.out("adv-c-header"), filepath.Join(filepath.Dir(g.cHeader), "generated_advertising.h")), func() string { return c.GenerateAdvHeader(advs, pkg, companyID) }),
+			gen.LazyOutput(flagOrDefault(g.opts.out("adv-c-source"), filepath.Join(filepath.Dir(g.cSource), "generated_advertising.c")), func() string { return c.GenerateAdvSource(advs, pkg) }),
+		)
+	}
+	if g.cfg.targetEnabled("python") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("adv-py"), filepath.Join(filepath.Dir(g.pyClient), "generated_advertising.py")), func() string { return python.GenerateAdv(g.gctx, advs, pkg, companyID) }))
+	}
+	if g.cfg.targetEnabled("kotlin") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("adv-kt"), filepath.Join(filepath.Dir(g.ktClient), "GeneratedAdvertising.kt")), func() string { return kotlin.GenerateAdv(g.gctx, advs, pkg, companyID) }))
+	}
+	if g.cfg.targetEnabled("swift") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("adv-swift"), filepath.Join(filepath.Dir(g.swiftClient), "GeneratedAdvertising.swift")), func() string { return swiftgen.GenerateAdv(g.gctx, advs, pkg, companyID) }))
+	}
+	if g.opts.out("adv-go") != "" {
+		outputs = append(outputs, gen.LazyOutput(g.opts.out("adv-go"), func() string { return golang.GenerateAdv(advs, pkg, g.opts.GoPbImport, companyID) }))
+	}
+	return outputs, nil
+}
+
+func emitEvents(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if len(g.events) == 0 {
+		return outputs, nil
+	}
+	events, pkg := g.events, g.pkg
+	if g.cfg.targetEnabled("c") {
+		outputs = append(outputs,
+			gen.LazyOutput(flagOrDefault(g.opts.out("events-c-header"), filepath.Join(filepath.Dir(g.cHeader), "generated_events.h")), func() string { return c.GenerateEventsHeader(events, pkg) }),
+			gen.LazyOutput(flagOrDefault(g.opts.out("events-c-source"), filepath.Join(filepath.Dir(g.cSource), "generated_events.c")), func() string { return c.GenerateEventsSource(events, g.callbacks, pkg) }),
+		)
+	}
+	if g.cfg.targetEnabled("python") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("events-py"), filepath.Join(filepath.Dir(g.pyClient), "generated_events.py")), func() string { return python.GenerateEvents(g.gctx, events, pkg) }))
+	}
+	if g.cfg.targetEnabled("kotlin") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("events-kt"), filepath.Join(filepath.Dir(g.ktClient), "GeneratedEvents.kt")), func() string { return kotlin.GenerateEvents(g.gctx, events, pkg) }))
+	}
+	if g.cfg.targetEnabled("swift") {
+		outputs = append(outputs, gen.LazyOutput(g.eventsSwift, func() string { return swiftgen.GenerateEvents(g.gctx, events, pkg) }))
+	}
+	return outputs, nil
+}
+
+func emitFraming(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.Framing {
+		return outputs, nil
+	}
+	commands, pkg, inFlight, crc := g.commands, g.pkg, g.inFlight(), g.cfg.FrameCRC
+	if g.cfg.targetEnabled("c") {
+		outputs = append(outputs,
+			gen.LazyOutput(flagOrDefault(g.opts.out("framing-c-header"), filepath.Join(filepath.Dir(g.cHeader), "generated_framing.h")), func() string { return c.GenerateFramingHeader(g.gctx, commands, pkg, inFlight, crc) }),
+			gen.LazyOutput(flagOrDefault(g.opts.out("framing-c-source"), filepath.Join(filepath.Dir(g.cSource), "generated_framing.c")), func() string { return c.GenerateFramingSource(g.gctx, commands, pkg, inFlight, crc) }),
+		)
+	}
+	if g.cfg.targetEnabled("python") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("framing-py"), filepath.Join(filepath.Dir(g.pyClient), "generated_framing.py")), func() string { return python.GenerateFraming(g.gctx, commands, pkg, inFlight, crc) }))
+	}
+	if g.cfg.targetEnabled("kotlin") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("framing-kt"), filepath.Join(filepath.Dir(g.ktClient), "GeneratedFraming.kt")), func() string { return kotlin.GenerateFraming(g.gctx, commands, pkg, inFlight, crc) }))
+	}
+	if g.cfg.targetEnabled("swift") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("framing-swift"), filepath.Join(filepath.Dir(g.swiftClient), "GeneratedFraming.swift")), func() string { return swiftgen.GenerateFraming(g.gctx, commands, pkg, inFlight, crc) }))
+	}
+	return outputs, nil
+}
+
+func emitBatch(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.Batch {
+		return outputs, nil
+	}
+	if g.cfg.targetEnabled("c") {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("batch-c-source"), filepath.Join(filepath.Dir(g.cSource), "generated_batch.c")), func() string { return c.GenerateBatchSource(g.gctx, g.commands, g.streaming, g.pkg) }))
+	}
+	if g.cfg.targetEnabled("python") {
+		commands := targetCommands(g.commands, "python")
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("batch-py"), filepath.Join(filepath.Dir(g.pyClient), "generated_batch.py")), func() string { return python.GenerateBatch(g.gctx, commands, g.streaming, g.pkg) }))
+	}
+	if g.cfg.targetEnabled("kotlin") {
+		commands := targetCommands(g.commands, "kotlin")
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("batch-kt"), filepath.Join(filepath.Dir(g.ktClient), "GeneratedBatch.kt")), func() string { return kotlin.GenerateBatch(g.gctx, commands, g.streaming, g.pkg) }))
+	}
+	if g.cfg.targetEnabled("swift") {
+		commands := targetCommands(g.commands, "swift")
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("batch-swift"), filepath.Join(filepath.Dir(g.swiftClient), "GeneratedBatch.swift")), func() string { return swiftgen.GenerateBatch(g.gctx, commands, g.streaming, g.pkg) }))
+	}
+	return outputs, nil
+}
+
+func emitCompression(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.gctx.CCompression || !g.cfg.targetEnabled("c") {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("compression-c-source"), filepath.Join(filepath.Dir(g.cSource), "generated_compression.c")), func() string { return c.GenerateCompressionSource(g.gctx, g.commands, g.pkg) })), nil
+}
+
+// emitUserFiles writes the editable user handler files of user_files while
+// they are missing.
+func emitUserFiles(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if !g.cfg.UserFiles {
+		return outputs, nil
+	}
+	cUser, pyUser := "", ""
+	if g.cfg.targetEnabled("c") {
+		cUser = flagOrDefault(g.opts.out("c-user-handlers"), filepath.Join(filepath.Dir(g.cSource), "user_handlers.c"))
+	}
+	if g.cfg.targetEnabled("python_handlers") {
+		pyUser = flagOrDefault(g.opts.out("py-user-handlers"), filepath.Join(filepath.Dir(g.pyHandlers), "user_handlers.py"))
+	}
+	return append(outputs, userHandlerOutputs(g.gctx, g.fsys, g.commands, g.streaming, g.pkg, cUser, g.cSource, pyUser, g.pyHandlers)...), nil
+}
+
+// emitBuildFragments writes the source lists of -build-system. The
+// peripheral fragments list every C source queued so far but the client,
+// and the C user file.
+func emitBuildFragments(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	peripheral := gen.BuildTarget{Name: g.pkg + "_handlers", Dir: filepath.Dir(g.cCMake)}
+	for _, out := range outputs {
+		if out.Path == g.cClientSource || out.Path == g.cClientHeader || out.Path == g.cClientUUIDs {
+			continue
+		}
+		switch filepath.Ext(out.Path) {
+		case ".c":
+			peripheral.Sources = append(peripheral.Sources, out.Path)
+		case ".h":
+			if dir := filepath.Dir(out.Path); !slices.Contains(peripheral.Includes, dir) {
+				peripheral.Includes = append(peripheral.Includes, dir)
+			}
+		}
+	}
+	client := gen.BuildTarget{
+		Name:     g.pkg + "_client",
+		Dir:      filepath.Dir(g.cClientCMake),
+		Sources:  []string{g.cClientSource},
+		Includes: []string{filepath.Dir(g.cClientHeader)},
+	}
+	for _, bs := range g.buildSystems {
+		var peripheralFiles, clientFiles []gen.Output
+		switch bs {
+		case "zephyr":
+			peripheralFiles = []gen.Output{{Path: g.cCMake, Content: c.GenerateZephyrCMake(g.commands, g.pkg, peripheral)}, {Path: g.cKconfig, Content: c.GenerateZephyrKconfig(g.commands, g.pkg)}}
+
+			clientFiles = []gen.Output{{Path: g.cClientCMake, Content: c.GenerateZephyrClientCMake(g.pkg, g.opts.CClientMode, client)}, {Path: g.cClientKconfig, Content: c.GenerateZephyrClientKconfig(g.pkg, g.opts.CClientMode)}}
+
+		case "make":
+			peripheralFiles = []gen.Output{{Path: filepath.Join(peripheral.Dir, "generated.mk"), Content: c.GenerateMakeFragment(peripheral)}}
+			clientFiles = []gen.Output{{Path: filepath.Join(client.Dir, "generated.mk"), Content: c.GenerateMakeFragment(client)}}
+		case "idf":
+			peripheralFiles = []gen.Output{{Path: filepath.Join(peripheral.Dir, "generated_idf.cmake"), Content: c.GenerateIDFFragment(peripheral)}}
+			clientFiles = []gen.Output{{Path: filepath.Join(client.Dir, "generated_idf.cmake"), Content: c.GenerateIDFFragment(client)}}
+		case "platformio":
+			peripheralFiles = []gen.Output{{Path: filepath.Join(peripheral.Dir, "library.json"), Content: c.GeneratePlatformIOLibrary(peripheral)}}
+			clientFiles = []gen.Output{{Path: filepath.Join(client.Dir, "library.json"), Content: c.GeneratePlatformIOLibrary(client)}}
+		}
+		if g.cfg.targetEnabled("c") {
+			outputs = append(outputs, peripheralFiles...)
+		}
+		if g.cfg.targetEnabled("c_client") {
+			outputs = append(outputs, clientFiles...)
+		}
+	}
+	return outputs, nil
+}
+
+func emitBuiltinProto(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if len(g.cfg.Builtins) == 0 {
+		return outputs, nil
+	}
+	path := flagOrDefault(g.opts.out("builtin-proto"), filepath.Join(filepath.Dir(g.protoPath), g.opts.namespaced("blerpc_builtin.proto")))
+	return append(outputs, gen.LazyOutput(path, func() string { return generateBuiltinProto(g.cfg.Builtins, g.pkg) })), nil
+}
+
+func emitCommandIDLock(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.commandIDs == nil {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.commandIDLock, func() string { return generateCommandIDLock(g.commandIDs) })), nil
+}
+
+func emitGoTUI(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-tui") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-tui"), func() string { return golang.GenerateTUI(g.commands, g.streaming, g.pkg, g.opts.GoPbImport) })), nil
+}
+
+func emitCpp(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("cpp-header") == "" {
+		return outputs, nil
+	}
+	if err := cpp.ValidateEmbeddedProto(g.commands); err != nil {
+		return nil, fmt.Errorf("invalid commands for EmbeddedProto: %w", err)
+	}
+	source := flagOrDefault(g.opts.out("cpp-source"), filepath.Join(filepath.Dir(g.opts.out("cpp-header")), "generated_handlers.cpp"))
+	return append(outputs,
+		gen.LazyOutput(g.opts.out("cpp-header"), func() string { return cpp.GenerateHeader(g.gctx, g.commands, g.msgByName, g.optionLimits, g.pkg) }),
+		gen.LazyOutput(source, func() string { return cpp.GenerateSource(g.gctx, g.commands, g.pkg) }),
+	), nil
+}
+
+func emitCppClient(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("cpp-client") == "" {
+		return outputs, nil
+	}
+	pbHeader := strings.TrimSuffix(filepath.Base(g.protoPath), ".proto") + ".pb.h"
+	return append(outputs, gen.LazyOutput(g.opts.out("cpp-client"), func() string { return cpp.GenerateClient(g.commands, g.streaming, g.pkg, pbHeader) })), nil
+}
+
+func emitCSharp(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("csharp-client") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("csharp-client"), func() string { return csharp.GenerateClient(g.commands, g.streaming, g.pkg) })), nil
+}
+
+func emitRust(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("rs-handlers") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("rs-handlers"), func() string { return rust.GenerateHandlers(g.commands, g.pkg, g.opts.RSPbPath) })), nil
+}
+
+func emitGoSim(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-sim") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-sim"), func() string {
+		return golang.GenerateSim(g.gctx, g.commands, g.streaming, g.pkg, g.opts.GoPbImport, g.opts.GoWireImport)
+	})), nil
+}
+
+func emitGoBench(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-bench") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-bench"), func() string {
+		return golang.GenerateBench(g.gctx, g.commands, g.streaming, g.msgByName, g.enumByName, g.opts.GoSimImport, g.opts.GoWireImport)
+	})), nil
+}
+
+func emitGoWire(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-wire") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-wire"), func() string { return golang.GenerateWire(g.commands, g.streaming, g.pkg, g.opts.GoWireImport) })), nil
+}
+
+func emitGoClient(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-client") == "" {
+		return outputs, nil
+	}
+	dir := filepath.Dir(g.opts.out("go-client"))
+	outputs = append(outputs,
+		gen.LazyOutput(g.opts.out("go-client"), func() string { return golang.GenerateClient(g.commands, g.streaming, g.pkg, g.opts.GoPbImport) }),
+		gen.LazyOutput(flagOrDefault(g.opts.out("go-devices"), filepath.Join(dir, "device_manager.go")), func() string { return golang.GenerateDevices(g.commands, g.streaming, g.pkg, g.opts.GoPbImport) }),
+	)
+	if g.cfg.Capture {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("go-capture"), filepath.Join(dir, "capture.go")), func() string { return golang.GenerateCapture(g.gctx, g.commands, g.msgByName, g.pkg) }))
+	}
+	if g.cfg.FaultInjection {
+		outputs = append(outputs, gen.LazyOutput(flagOrDefault(g.opts.out("go-faults"), filepath.Join(dir, "faults.go")), func() string { return golang.GenerateFaults(g.gctx, g.commands, g.pkg) }))
+	}
+	return outputs, nil
+}
+
+func emitGoGateway(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-gateway") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-gateway"), func() string {
+		return golang.GenerateGateway(g.gctx, g.commands, g.streaming, g.pkg, g.opts.GoClientImport)
+	})), nil
+}
+
+func emitGoMQTT(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-mqtt") == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-mqtt"), func() string {
+		return golang.GenerateMQTT(g.gctx, g.commands, g.streaming, g.events, g.pkg, g.opts.GoClientImport, g.opts.GoPbImport)
+	})), nil
+}
+
+// emitGoErrors writes the error types of the Go client package, next to
+// -out-go-client unless -out-go-errors places them.
+func emitGoErrors(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	path := g.opts.out("go-errors")
+	if g.opts.out("go-client") != "" {
+		path = flagOrDefault(path, filepath.Join(filepath.Dir(g.opts.out("go-client")), "errors.go"))
+	}
+	if path == "" {
+		return outputs, nil
+	}
+	return append(outputs, gen.LazyOutput(path, func() string { return golang.GenerateErrors(g.pkg) })), nil
+}
+
+func emitGoFiles(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-files") == "" {
+		return outputs, nil
+	}
+	files, ok := gen.FileTransferCommands(g.commands)
+	if !ok {
+		return nil, errors.New("-out-go-files needs the file_transfer built-in")
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-files"), func() string { return golang.GenerateFileTransfer(files, g.pkg, g.opts.GoPbImport) })), nil
+}
+
+func emitGoDfu(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("go-dfu") == "" {
+		return outputs, nil
+	}
+	dfu, ok := gen.FirmwareUpdateCommands(g.commands)
+	if !ok {
+		return nil, errors.New("-out-go-dfu needs the dfu built-in")
+	}
+	return append(outputs, gen.LazyOutput(g.opts.out("go-dfu"), func() string { return golang.GenerateFirmwareUpdate(dfu, g.pkg, g.opts.GoPbImport) })), nil
+}
+
+func emitConformance(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("conformance") == "" {
+		return outputs, nil
+	}
+	suite := conformanceVectors(g.commands, g.streaming, g.msgByName, g.enumByName)
+	outputs = append(outputs, gen.LazyOutput(filepath.Join(g.opts.out("conformance"), "vectors.json"), func() string { return generateConformanceVectors(suite) }))
+	if g.cfg.targetEnabled("c_client") {
+		outputs = append(outputs, gen.LazyOutput(filepath.Join(g.opts.out("conformance"), "loopback.c"), func() string { return c.GenerateLoopback(g.gctx, g.commands, suite, g.pkg) }))
+	}
+	if g.cfg.targetEnabled("python") {
+		outputs = append(outputs, gen.LazyOutput(filepath.Join(filepath.Dir(g.pyClient), "loopback_client.py"), func() string { return python.GenerateLoopback(g.gctx) }))
+	}
+	if g.cfg.targetEnabled("kotlin") {
+		outputs = append(outputs, gen.LazyOutput(filepath.Join(filepath.Dir(g.ktClient), "LoopbackClient.kt"), func() string { return kotlin.GenerateLoopback(g.gctx, g.commands, suite, g.pkg) }))
+	}
+	if g.cfg.targetEnabled("swift") {
+		outputs = append(outputs, gen.LazyOutput(filepath.Join(filepath.Dir(g.swiftClient), "LoopbackClient.swift"), func() string { return swiftgen.GenerateLoopback(g.gctx, g.commands, suite, g.pkg) }))
+	}
+	return outputs, nil
+}
+
+func emitFixtures(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("fixtures") == "" {
+		return outputs, nil
+	}
+	for _, fx := range generateFixtures(g.commands, g.msgByName, g.enumByName, g.pkg) {
+		outputs = append(outputs, gen.Output{Path: filepath.Join(g.opts.out("fixtures"), fx.Path), Content: fx.Content})
+	}
+	return outputs, nil
+}
+
+func emitFuzz(g *generation, outputs []gen.Output) ([]gen.Output, error) {
+	if g.opts.out("fuzz") == "" {
+		return outputs, nil
+	}
+	outputs = append(outputs, gen.LazyOutput(filepath.Join(g.opts.out("fuzz"), g.pkg+".dict"), func() string { return c.GenerateFuzzDict(g.commands, g.msgByName, g.protoFile.Enums) }))
+	for _, seed := range c.GenerateFuzzCorpus(g.commands, g.msgByName, g.enumByName) {
+		outputs = append(outputs, gen.Output{Path: filepath.Join(g.opts.out("fuzz"), "corpus", seed.Path), Content: seed.Content})
+	}
+	if g.cfg.targetEnabled("c") && g.opts.CRuntime != "protobuf-c" {
+		outputs = append(outputs, gen.LazyOutput(filepath.Join(g.opts.out("fuzz"), "fuzz_handlers.c"), func() string { return c.GenerateFuzzHarness(g.gctx, g.commands, g.pkg, g.cfg.Framing, g.inFlight()) }))
+		for _, seed := range c.GenerateFuzzCommandSeeds(g.commands, g.msgByName, g.enumByName) {
+			outputs = append(outputs, gen.Output{Path: filepath.Join(g.opts.out("fuzz"), "corpus", seed.Path), Content: seed.Content})
+		}
+	}
+	return outputs, nil
+}
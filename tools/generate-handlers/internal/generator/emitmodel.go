@@ -0,0 +1,14 @@
+package generator
+
+import (
+	"encoding/json"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// generateModelJSON returns the -emit-model document of commands and the
+// messages and enums of protoFile.
+func generateModelJSON(commands []gen.Command, streaming map[string]string, protoFile *gen.ProtoFile, pkg string) string {
+	data, _ := json.MarshalIndent(gen.BuildModel(commands, streaming, protoFile, pkg), "", "  ")
+	return string(data) + "\n"
+}
@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateModelJSON(t *testing.T) {
+	echo := gentest.EchoCommand()
+	echo.Deprecated = true
+	echo.WireName = "e"
+	pf := &gen.ProtoFile{
+		Syntax: "proto3",
+		Messages: []gen.Message{
+			{Name: "EchoRequest", Fields: []gen.Field{{Name: "message", Number: 1, Type: "string"}}},
+			{Name: "Tags", Fields: []gen.Field{{Name: "tags", Number: 1, IsMap: true, KeyType: "string", ValueType: "uint32"}}},
+		},
+		Enums: []gen.Enum{{Name: "Status", Values: []gen.EnumValue{{Name: "OK", Number: 0}}}},
+	}
+	out := generateModelJSON([]gen.Command{echo, gentest.StreamP2CCommand()}, map[string]string{"counter_stream": "p2c"}, pf, "blerpc")
+
+	var doc struct {
+		Version  int `json:"version"`
+		Commands []struct {
+			Name       string `json:"name"`
+			WireName   string `json:"wire_name"`
+			Stream     string `json:"stream"`
+			Deprecated bool   `json:"deprecated"`
+		} `json:"commands"`
+		Messages []struct {
+			Fields []map[string]any `json:"fields"`
+		} `json:"messages"`
+		Enums []struct {
+			Values []map[string]any `json:"values"`
+		} `json:"enums"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if doc.Version != gen.ModelVersion || len(doc.Commands) != 2 {
+		t.Fatalf("unexpected document:\n%s", out)
+	}
+	if c := doc.Commands[0]; c.Name != "echo" || c.WireName != "e" || c.Stream != "unary" || !c.Deprecated {
+		t.Errorf("commands[0] = %+v", c)
+	}
+	if c := doc.Commands[1]; c.WireName != "counter_stream" || c.Stream != "p2c" {
+		t.Errorf("commands[1] = %+v", c)
+	}
+	if f := doc.Messages[1].Fields[0]; f["type"] != "map" || f["key_type"] != "string" || f["value_type"] != "uint32" {
+		t.Errorf("map field = %v", f)
+	}
+	if v := doc.Enums[0].Values[0]; v["name"] != "OK" || v["number"] != 0.0 {
+		t.Errorf("enum value = %v", v)
+	}
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateStatusCodes(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand()}
+	outputs := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c header", c.GenerateHeader(gctx, cmds, nil, nil, "blerpc"), []string{
+			"    BLERPC_STATUS_NOT_FOUND = 2,\n",
+			"#define BLERPC_STATUS_FIELD 536870911\n",
+			"static inline int blerpc_return_error(pb_ostream_t *ostream, uint32_t status)\n",
+		}},
+		{"protobuf-c header", c.GenerateHeaderProtobufC(gctx, cmds, "blerpc"), []string{
+			"static inline int blerpc_return_error(ProtobufCBuffer *out, uint32_t status)\n",
+			"    uint8_t buf[10] = {0xf8, 0xff, 0xff, 0xff, 0x0f};\n",
+		}},
+		{"python", python.GenerateClient(gctx, cmds, nil, "blerpc"), []string{
+			"import enum\n",
+			"class StatusCode(enum.IntEnum):",
+			"    UNAVAILABLE = 10\n",
+			"class NotFoundError(RemoteError):",
+			"_STATUS_TAG = b\"\\xf8\\xff\\xff\\xff\\x0f\"\n",
+			"    StatusCode.NOT_FOUND: NotFoundError,\n",
+			"def _decode(resp, data, command):\n    _check_status(data, command)\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, nil, "blerpc"), []string{
+			"enum class StatusCode(val code: Int) {",
+			"class NotFoundException(command: String) :\n    RemoteException(command, StatusCode.NOT_FOUND.code, \"$command failed: NOT_FOUND\")\n",
+			"        StatusCode.NOT_FOUND.code -> NotFoundException(command)\n",
+			"        statusException(command, data)?.let { throw it }\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, cmds, nil, "blerpc"), []string{
+			"enum StatusCode: Int {",
+			"    case notFound = 2\n",
+			"struct NotFoundError: RemoteError {",
+			"    case .notFound: return NotFoundError(command: command)\n",
+			"        if let error = statusError(command, data) { throw error }\n",
+		}},
+	}
+	for _, tt := range outputs {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, want, tt.out)
+			}
+		}
+	}
+}
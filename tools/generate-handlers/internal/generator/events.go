@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// discoverEvents returns the event messages of the main proto file, in
+// declaration order. Messages of commands are never events, and
+// option (blerpc.event) = false opts a *Event message out.
+func discoverEvents(messages []gen.Message, commands []gen.Command) ([]gen.Event, error) {
+	used := make(map[string]bool)
+	wire := make(map[string]string)
+	for _, cmd := range commands {
+		used[cmd.RequestMsg] = true
+		used[cmd.ResponseMsg] = true
+		wire[cmd.Wire()] = cmd.Camel
+	}
+	var events []gen.Event
+	for _, m := range messages {
+		opt, ok := m.Options["blerpc.event"]
+		if ok && opt != "true" || !ok && !strings.HasSuffix(m.Name, "Event") {
+			continue
+		}
+		if used[m.Name] {
+			if ok {
+				return nil, fmt.Errorf("message %s: a command request or response cannot be an event", m.Name)
+			}
+			continue
+		}
+		if m.File != "" {
+			return nil, fmt.Errorf("message %s: events must be defined in the main proto file", m.Name)
+		}
+		ev := gen.Event{Message: m, Snake: protomodel.CamelToSnake(m.Name)}
+		if cmd, ok := wire[ev.Snake]; ok {
+			return nil, fmt.Errorf("event %s: name %q is the wire name of command %s", m.Name, ev.Snake, cmd)
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
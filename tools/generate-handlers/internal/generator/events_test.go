@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+const eventsProto = `syntax = "proto3";
+
+package blerpc;
+
+import "blerpc_options.proto";
+
+message EchoRequest {
+  string message = 1;
+}
+
+message EchoResponse {
+  string message = 1;
+}
+
+message ButtonEvent {
+  uint32 button = 1;
+  bool pressed = 2;
+}
+
+message LowBattery {
+  option (blerpc.event) = true;
+  uint32 percent = 1;
+}
+
+message LogEvent {
+  option (blerpc.event) = false;
+  string line = 1;
+}
+`
+
+func parseEvents(t *testing.T) []gen.Event {
+	t.Helper()
+	pf, err := protomodel.ParseReader(strings.NewReader(eventsProto))
+	if err != nil {
+		t.Fatalf("protomodel.ParseReader: %v", err)
+	}
+	events, err := discoverEvents(pf.Messages, protomodel.DiscoverCommands(pf.Messages))
+	if err != nil {
+		t.Fatalf("discoverEvents: %v", err)
+	}
+	return events
+}
+
+func TestDiscoverEvents(t *testing.T) {
+	events := parseEvents(t)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Message.Name != "ButtonEvent" || events[0].Snake != "button_event" {
+		t.Errorf("unexpected first event: %+v", events[0])
+	}
+	if events[1].Message.Name != "LowBattery" || events[1].Snake != "low_battery" {
+		t.Errorf("unexpected second event: %+v", events[1])
+	}
+
+	event := map[string]string{"blerpc.event": "true"}
+	tests := []struct {
+		name     string
+		messages []gen.Message
+		commands []gen.Command
+		want     string
+	}{
+		{"command message", []gen.Message{{Name: "EchoResponse", Options: event}}, []gen.Command{gentest.EchoCommand()},
+			"a command request or response cannot be an event"},
+		{"imported", []gen.Message{{Name: "ClockEvent", File: "clock"}}, nil, "must be defined in the main proto file"},
+		{"wire name", []gen.Message{{Name: "Echo", Options: event}}, []gen.Command{gentest.EchoCommand()},
+			`name "echo" is the wire name of command Echo`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := discoverEvents(tt.messages, tt.commands)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGenerateEvents(t *testing.T) {
+	gctx := gen.NewContext()
+	events := parseEvents(t)
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c header", c.GenerateEventsHeader(events, "blerpc"), []string{
+			"int blerpc_event_write(const char *name, const uint8_t *data, size_t len);",
+			"int blerpc_notify_button_event(const blerpc_ButtonEvent *event);",
+		}},
+		{"c source", c.GenerateEventsSource(events, nil, "blerpc"), []string{
+			"__attribute__((weak))\nint blerpc_event_write(",
+			"    uint8_t buf[blerpc_LowBattery_size];\n",
+			"    return blerpc_event_write(\"button_event\", buf, out.bytes_written);\n",
+		}},
+		{"python", python.GenerateEvents(gctx, events, "blerpc"), []string{
+			"async def subscribe_button_event(client):\n",
+			"    async for data in client.events(\"low_battery\"):\n",
+			"        yield _decode(blerpc_pb2.ButtonEvent(), data, \"button_event\")\n",
+		}},
+		{"kotlin", kotlin.GenerateEvents(gctx, events, "blerpc"), []string{
+			"fun BlerpcClient.subscribeButtonEvent(): Flow<blerpc.Blerpc.ButtonEvent> =\n",
+			"            throw DecodeException(\"low_battery\", e)\n",
+		}},
+		{"swift", swift.GenerateEvents(gctx, events, "blerpc"), []string{
+			"    func subscribeLowBattery() -> AsyncThrowingStream<Blerpc_LowBattery, Error> {\n",
+			"        let events = self.events(eventName: \"button_event\")\n",
+			"try self.decode(\"button_event\", data) { try Blerpc_ButtonEvent(serializedBytes: $0) }",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, want, tt.out)
+			}
+		}
+	}
+
+	// Unbounded events are encoded into a configurable buffer.
+	callbacks := map[string]bool{"ButtonEvent.button": true}
+	if src := c.GenerateEventsSource(events, callbacks, "blerpc"); !strings.Contains(src, "#define BLERPC_EVENT_BUF_SIZE 256") ||
+		!strings.Contains(src, "uint8_t buf[BLERPC_EVENT_BUF_SIZE];") {
+		t.Errorf("unbounded event not sized by BLERPC_EVENT_BUF_SIZE:\n%s", src)
+	}
+}
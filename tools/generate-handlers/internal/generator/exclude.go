@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Exclusions keep commands out of chosen client targets, such as factory
+// commands that must never ship in the mobile apps while the Python tools
+// and the central firmware keep them. The peripheral targets implement every
+// command, so only the client targets can be excluded; the API reference
+// lists the targets each command is left out of.
+//
+// Commands are excluded with
+//
+//	option (blerpc.exclude_targets) = "kotlin,swift";
+//
+// or, for schemas discovered by message naming, an entry under exclude in
+// blerpc.yaml.
+
+// ExcludeConfig keeps a command out of targets in blerpc.yaml.
+type ExcludeConfig struct {
+	Command string   `yaml:"command"`
+	Targets []string `yaml:"targets"`
+}
+
+// excludableTargets lists the targets a command can be excluded from.
+var excludableTargets = []string{"c_client", "python", "kotlin", "swift", "dart", "typescript"}
+
+// applyExclusions records the exclusions of blerpc.yaml and checks those of
+// the (blerpc.exclude_targets) options.
+func applyExclusions(commands []gen.Command, cfg *Config) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, e := range cfg.Exclude {
+		idx, ok := bySnake[e.Command]
+		if !ok {
+			return fmt.Errorf("exclude[%d]: unknown command %q", i, e.Command)
+		}
+		if len(e.Targets) == 0 {
+			return fmt.Errorf("exclude[%d]: %s lists no targets", i, e.Command)
+		}
+		for _, t := range e.Targets {
+			if !slices.Contains(commands[idx].ExcludeTargets, t) {
+				commands[idx].ExcludeTargets = append(commands[idx].ExcludeTargets, t)
+			}
+		}
+	}
+	for _, cmd := range commands {
+		for _, t := range cmd.ExcludeTargets {
+			if !slices.Contains(excludableTargets, t) {
+				return fmt.Errorf("%s: cannot exclude target %q (want %s)", cmd.Snake, t, strings.Join(excludableTargets, ", "))
+			}
+		}
+	}
+	// Session- and replay-protected commands need the session commands, and
+	// so the session key, in the client.
+	for _, t := range excludableTargets {
+		kept := targetCommands(commands, t)
+		if _, _, sessions := gen.SessionCommands(kept); sessions || !gen.HasSessionProtected(kept) && !gen.HasReplayProtected(kept) {
+			continue
+		}
+		return fmt.Errorf("%s: the session commands cannot be excluded while session- or replay-protected commands are kept", t)
+	}
+	// The C client has no session handshake and no session key.
+	if cfg.targetEnabled("c_client") {
+		for _, cmd := range targetCommands(commands, "c_client") {
+			if cmd.SessionProtected {
+				return fmt.Errorf("%s: the C client cannot open a session; exclude the command from c_client", cmd.Snake)
+			}
+			if cmd.ReplayProtected {
+				return fmt.Errorf("%s: the C client cannot authenticate the replay counter; exclude the command from c_client", cmd.Snake)
+			}
+		}
+	}
+	return nil
+}
+
+// targetCommands returns the commands not excluded from target, keeping the
+// command order.
+func targetCommands(commands []gen.Command, target string) []gen.Command {
+	var out []gen.Command
+	for _, cmd := range commands {
+		if !slices.Contains(cmd.ExcludeTargets, target) {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
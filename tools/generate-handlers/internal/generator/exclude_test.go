@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestApplyExclusions(t *testing.T) {
+	tests := []struct {
+		name    string
+		exclude []ExcludeConfig
+		want    string
+	}{
+		{"unknown command", []ExcludeConfig{{Command: "missing", Targets: []string{"kotlin"}}}, `unknown command "missing"`},
+		{"no targets", []ExcludeConfig{{Command: "echo"}}, "lists no targets"},
+		{"peripheral target", []ExcludeConfig{{Command: "echo", Targets: []string{"c"}}}, `cannot exclude target "c"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyExclusions([]gen.Command{gentest.EchoCommand()}, &Config{Exclude: tt.exclude})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	option := gentest.EchoCommand()
+	option.ExcludeTargets = []string{"rust"}
+	if err := applyExclusions([]gen.Command{option}, &Config{}); err == nil || !strings.Contains(err.Error(), `cannot exclude target "rust"`) {
+		t.Errorf("expected option target error, got %v", err)
+	}
+
+	start, auth, protected := gentest.EchoCommand(), gentest.EchoCommand(), gentest.EchoCommand()
+	start.Snake, start.Builtin, start.RequestMsg = "start_session", "session", "StartSessionRequest"
+	auth.Snake, auth.Builtin, auth.RequestMsg = "authenticate_session", "session", "AuthenticateSessionRequest"
+	protected.SessionProtected = true
+	err := applyExclusions([]gen.Command{start, auth, protected}, &Config{Exclude: []ExcludeConfig{{Command: "start_session", Targets: []string{"swift"}}}})
+	if err == nil || !strings.Contains(err.Error(), "swift: the session commands cannot be excluded") {
+		t.Errorf("expected session error, got %v", err)
+	}
+	err = applyExclusions([]gen.Command{start, auth, protected}, &Config{})
+	if err == nil || !strings.Contains(err.Error(), "echo: the C client cannot open a session") {
+		t.Errorf("expected C client error, got %v", err)
+	}
+	if err := applyExclusions([]gen.Command{start, auth, protected}, &Config{Targets: map[string]bool{"c_client": false}}); err != nil {
+		t.Errorf("C client off: %v", err)
+	}
+
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	cmds[0].ExcludeTargets = []string{"kotlin"}
+	if err := applyExclusions(cmds, &Config{Exclude: []ExcludeConfig{{Command: "echo", Targets: []string{"kotlin", "swift"}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cmds[0].ExcludeTargets, []string{"kotlin", "swift"}) {
+		t.Errorf("ExcludeTargets = %q", cmds[0].ExcludeTargets)
+	}
+	for target, want := range map[string]int{"kotlin": 1, "swift": 1, "python": 2} {
+		if got := len(targetCommands(cmds, target)); got != want {
+			t.Errorf("%s: %d commands, want %d", target, got, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// generatorProtocolVersion is bumped on incompatible changes to the request.
+const generatorProtocolVersion = 1
+
+// generatorRequest is the model handed to a plugin: the commands as the
+// built-in targets see them, after blerpc.yaml is applied, and the schema
+// they were taken from.
+type generatorRequest struct {
+	Version   int               `json:"version"`
+	Target    string            `json:"target"`
+	Package   string            `json:"package"`
+	Syntax    string            `json:"syntax"`
+	Commands  []gen.Command     `json:"commands"`
+	Streaming map[string]string `json:"streaming"` // command snake name -> "p2c" or "c2p"
+	Messages  []gen.Message     `json:"messages"`
+	Enums     []gen.Enum        `json:"enums"`
+}
+
+// generatorResponse lists the files a plugin generated, with paths relative
+// to -root. A non-empty Error fails the run.
+type generatorResponse struct {
+	Files []struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	} `json:"files"`
+	Error string `json:"error"`
+}
+
+var pluginNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// externalPlugin is a plugin target and the path blerpc.yaml lists for it,
+// joined to the root when relative, or "" for blerpc-gen-<name> on PATH.
+type externalPlugin struct {
+	name string
+	path string
+}
+
+// externalPlugins returns the plugins to run: those listed in blerpc.yaml,
+// then those named by -plugins.
+func externalPlugins(cfg *Config, flagValue, root string) ([]externalPlugin, error) {
+	names := make([]string, 0, len(cfg.Plugins))
+	for name := range cfg.Plugins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range strings.Split(flagValue, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var plugins []externalPlugin
+	for _, name := range names {
+		if !pluginNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid plugin name %q", name)
+		}
+		path := cfg.Plugins[name]
+		if path != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		plugins = append(plugins, externalPlugin{name, path})
+	}
+	return plugins, nil
+}
+
+// PluginRunner runs the plugin name, at path or, when path is empty, the
+// blerpc-gen-<name> found on PATH. It writes request to the plugin's stdin
+// and the plugin's stderr to stderr, and returns its stdout.
+type PluginRunner func(ctx context.Context, name, path string, request []byte, stderr io.Writer) ([]byte, error)
+
+// ExecPlugin is the PluginRunner of the command: it executes the plugin as
+// a child process, killed if ctx is done first.
+func ExecPlugin(ctx context.Context, name, path string, request []byte, stderr io.Writer) ([]byte, error) {
+	if path == "" {
+		found, err := exec.LookPath("blerpc-gen-" + name)
+		if err != nil {
+			return nil, err
+		}
+		path = found
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// runExternalPlugin runs p on req with run and returns its files under
+// root. The plugin's stderr goes to stderr.
+func runExternalPlugin(ctx context.Context, run PluginRunner, p externalPlugin, req generatorRequest, root string, stderr io.Writer) ([]gen.Output, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := run(ctx, p.name, p.path, in, stderr)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	var resp generatorResponse
+	if err := json.Unmarshal(stdout, &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid response: %w", p.name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", p.name, resp.Error)
+	}
+	outputs := make([]gen.Output, 0, len(resp.Files))
+	for _, f := range resp.Files {
+		if f.Path == "" || !filepath.IsLocal(f.Path) {
+			return nil, fmt.Errorf("plugin %s: file path %q is not relative to the project root", p.name, f.Path)
+		}
+		outputs = append(outputs, gen.Output{Path: filepath.Join(root, f.Path), Content: f.Content})
+	}
+	return outputs, nil
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+// writePlugin writes an executable shell script plugin to dir.
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExternalPlugins(t *testing.T) {
+	cfg := &Config{Plugins: map[string]string{"qnx_hmi": "tools/gen-qnx", "abs": "/opt/gen"}}
+	plugins, err := externalPlugins(cfg, "docs, qnx_hmi", "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []externalPlugin{
+		{"abs", "/opt/gen"},
+		{"qnx_hmi", "/repo/tools/gen-qnx"},
+		{"docs", ""},
+	}
+	if len(plugins) != len(want) {
+		t.Fatalf("got %v, want %v", plugins, want)
+	}
+	for i := range want {
+		if plugins[i] != want[i] {
+			t.Errorf("plugin %d: got %v, want %v", i, plugins[i], want[i])
+		}
+	}
+
+	if _, err := externalPlugins(&Config{}, "../evil", "."); err == nil || !strings.Contains(err.Error(), "invalid plugin name") {
+		t.Errorf("expected name error, got %v", err)
+	}
+}
+
+func TestRunExternalPlugin(t *testing.T) {
+	dir := t.TempDir()
+	// Echoes the request back as the content of one file.
+	path := writePlugin(t, dir, "gen", `echo working >&2; printf '{"files":[{"path":"out/model.json","content":%s}]}' "$(cat | sed 's/\\/\\\\/g; s/"/\\"/g; s/^/"/; s/$/"/')"`+"\n")
+	p := externalPlugin{"qnx_hmi", path}
+	req := generatorRequest{
+		Version:   generatorProtocolVersion,
+		Target:    "qnx_hmi",
+		Package:   "blerpc",
+		Commands:  []gen.Command{gentest.EchoCommand()},
+		Streaming: map[string]string{},
+	}
+	var stderr strings.Builder
+	outputs, err := runExternalPlugin(context.Background(), ExecPlugin, p, req, "/repo", &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Path != "/repo/out/model.json" {
+		t.Fatalf("got %v", outputs)
+	}
+	var got generatorRequest
+	if err := json.Unmarshal([]byte(outputs[0].Content), &got); err != nil {
+		t.Fatalf("plugin did not receive JSON: %v\n%s", err, outputs[0].Content)
+	}
+	if got.Version != 1 || got.Target != "qnx_hmi" || len(got.Commands) != 1 || got.Commands[0].RequestMsg != "EchoRequest" {
+		t.Errorf("request not round-tripped: %+v", got)
+	}
+	if stderr.String() != "working\n" {
+		t.Errorf("stderr: got %q", stderr.String())
+	}
+
+	for script, want := range map[string]string{
+		`echo '{"error":"no HMI screens"}'` + "\n":               "plugin qnx_hmi: no HMI screens",
+		`echo '{"files":[{"path":"../x","content":""}]}'` + "\n": `file path "../x" is not relative`,
+		"echo not json\n": "invalid response",
+		"exit 3\n":        "exit status 3",
+	} {
+		p.path = writePlugin(t, dir, "gen", script)
+		if _, err := runExternalPlugin(context.Background(), ExecPlugin, p, req, "/repo", io.Discard); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("script %q: expected %q, got %v", script, want, err)
+		}
+	}
+
+	bin := t.TempDir()
+	writePlugin(t, bin, "blerpc-gen-docs", `echo '{"files":[]}'`+"\n")
+	t.Setenv("PATH", bin)
+	if _, err := runExternalPlugin(context.Background(), ExecPlugin, externalPlugin{"docs", ""}, req, "/repo", io.Discard); err != nil {
+		t.Errorf("plugin on PATH: %v", err)
+	}
+	if _, err := runExternalPlugin(context.Background(), ExecPlugin, externalPlugin{"missing", ""}, req, "/repo", io.Discard); err == nil || !strings.Contains(err.Error(), "plugin missing") {
+		t.Errorf("expected lookup error, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+)
+
+func TestGenerateFaults(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.ID = 1
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	goOut := golang.GenerateFaults(gctx, cmds, "blerpc")
+	if _, err := parser.ParseFile(token.NewFileSet(), "faults.go", goOut, 0); err != nil {
+		t.Fatalf("generated faults.go does not parse: %v\n%s", err, goOut)
+	}
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateFaults(gctx, cmds), []string{
+			"from .generated_client import CommandId, GeneratedClientMixin, TimeoutError\n",
+			"FAULT_COMMANDS = {\n    CommandId.ECHO.wire_name: \"echo\",\n}\n",
+			"class FaultProfile:\n",
+			"class FaultyClient(GeneratedClientMixin):\n",
+			"        self._random = random.Random(profile.seed)\n",
+		}},
+		{"python without IDs", python.GenerateFaults(gctx, []gen.Command{gentest.EchoCommand()}), []string{
+			"from .generated_client import GeneratedClientMixin, TimeoutError\n",
+			"FAULT_COMMANDS: dict[str, str] = {}\n",
+		}},
+		{"go", goOut, []string{
+			"package blerpcclient\n",
+			"\t\"\\x01\": \"echo\",\n",
+			"func NewFaultyTransport(t Transport, p FaultProfile) *FaultyTransport {",
+			"\treturn &TimeoutError{Command: cmdName}\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestBuiltinFileTransfer(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n", "file_transfer")
+	files, ok := gen.FileTransferCommands(commands)
+	if !ok {
+		t.Fatalf("got %+v", commands)
+	}
+	if files.Open.Snake != "file_open" || files.Close.Snake != "file_close" {
+		t.Errorf("open %s, close %s", files.Open.Snake, files.Close.Snake)
+	}
+	if _, ok := gen.FileTransferCommands(commands[:3]); ok {
+		t.Error("three commands reported complete")
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_FILE_CHUNK_SIZE 128",
+		"int blerpc_file_open(const char *path, bool write, bool resume);",
+		"int blerpc_file_close(uint32_t handle);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static int file_checksum(uint32_t handle, uint32_t *size, uint32_t *crc)",
+		"file_handle = blerpc_file_open(path, req.write, req.resume);",
+		"req.data.funcs.decode = decode_file_data;",
+		"resp.data.funcs.encode = encode_file_chunk;",
+		"if (req.verify && file_checksum(req.handle, &file_size, &file_crc) != 0) {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+	if strings.Count(src, "static int file_checksum(") != 1 {
+		t.Error("shared helpers emitted more than once")
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import zlib\n",
+			"self, path, data, *, resume=False, chunk_size=128, progress=None",
+			"closed = await self.file_close(handle=opened.handle, verify=True)",
+			"raise BlerpcError(f\"{path}: download failed verification\")",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"suspend fun uploadFile(",
+			"data = ByteString.copyFrom(data, offset, end - offset),",
+			"java.util.zip.CRC32().apply { update(data, 0, length) }.value.toInt()",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"struct FileVerificationError: BlerpcErrorProtocol {",
+			") async throws -> Data {",
+			"private func fileCRC32(_ bytes: ArraySlice<UInt8>) -> UInt32 {",
+		}},
+		{"go", golang.GenerateFileTransfer(files, "blerpc", "example.com/pb"), []string{
+			"package blerpcclient",
+			"func (t *FileTransfer) Upload(ctx context.Context, path string, data []byte, resume bool) error {",
+			"if err := t.call(ctx, \"file_write\", req, &pb.FileWriteResponse{}); err != nil {",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestWriteFootprintReport(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.CallbackCommand()}
+	limits := map[string]protomodel.FieldLimits{
+		"EchoRequest.message":  {MaxSize: 257},
+		"EchoResponse.message": {MaxSize: 257},
+	}
+	callbacks := map[string]bool{"DataWriteRequest.data": true}
+	setMaxSizes(cmds, nil, limits, callbacks)
+
+	var b strings.Builder
+	c.WriteFootprintReport(gctx, &b, cmds, nil, limits, callbacks)
+	out := b.String()
+	for _, s := range []string{
+		"Footprint of 2 commands (nanopb structs on a 32-bit target, -c-dispatch linear)",
+		"echo                257          257          259          259           17\n",
+		"data_write           12            1    unbounded            2           23\n",
+		"Handler table: 40 bytes of flash\n",
+		"Largest handler structs: 514 bytes of stack (echo)\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("report missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
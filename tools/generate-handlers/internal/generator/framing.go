@@ -0,0 +1,4 @@
+package generator
+
+// defaultMaxInFlight is the max_in_flight of correlation IDs when unset.
+const defaultMaxInFlight = 4
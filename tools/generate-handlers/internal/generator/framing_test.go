@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	cgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateFraming(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.MaxRequestSize = 100
+	commands := []gen.Command{echo}
+
+	header := cgen.GenerateFramingHeader(gctx, commands, "blerpc", 0, false)
+	for _, want := range []string{
+		"#ifndef BLERPC_GENERATED_FRAMING_H",
+		"#define BLERPC_FRAMING_REASSEMBLY_SIZE 108\n",
+		"The default is the largest\n * request command",
+		"struct blerpc_reassembler {\n    uint8_t buf[BLERPC_FRAMING_REASSEMBLY_SIZE];",
+		"int blerpc_reassembler_feed(struct blerpc_reassembler *r, const uint8_t *frame,\n                            size_t len);",
+		"int blerpc_fragment(const uint8_t *msg, size_t len, uint16_t mtu,\n                    blerpc_frame_write_fn write, void *user);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	echo.MaxRequestSize = gen.UnboundedSize
+	header = cgen.GenerateFramingHeader(gctx, []gen.Command{echo}, "blerpc", 0, false)
+	if !strings.Contains(header, "#define BLERPC_FRAMING_REASSEMBLY_SIZE 1024\n") {
+		t.Error("unbounded requests should fall back to the default reassembly size")
+	}
+
+	src := cgen.GenerateFramingSource(gctx, commands, "blerpc", 0, false)
+	for _, want := range []string{
+		"#include \"generated_framing.h\"",
+		"int blerpc_reassembler_feed(struct blerpc_reassembler *r, const uint8_t *frame,\n                            size_t len)\n{",
+		"            frame[0] = BLERPC_FRAME_FIRST;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	if py := python.GenerateFraming(gctx, commands, "blerpc", 0, false); !strings.Contains(py, "class FramingError(TransportError):") ||
+		!strings.Contains(py, "def fragment(message: bytes, mtu: int) -> list[bytes]:") {
+		t.Error("Python framing missing FramingError or fragment")
+	}
+	if kt := kotlin.GenerateFraming(gctx, commands, "blerpc", 0, false); !strings.Contains(kt, "package com.blerpc.android.client\n") ||
+		!strings.Contains(kt, "class FrameReassembler(private val maxSize: Int = Framing.MAX_MESSAGE_SIZE) {") {
+		t.Error("Kotlin framing missing package or FrameReassembler")
+	}
+	if swift := swiftgen.GenerateFraming(gctx, commands, "blerpc", 0, false); !strings.Contains(swift, "static func fragment(_ message: Data, mtu: Int) throws -> [Data] {") {
+		t.Error("Swift framing missing fragment")
+	}
+}
+
+func TestGenerateFraming_CorrelationIDs(t *testing.T) {
+	gctx := gen.NewContext()
+	commands := []gen.Command{gentest.EchoCommand()}
+
+	header := cgen.GenerateFramingHeader(gctx, commands, "blerpc", 3, false)
+	for _, want := range []string{
+		" *   flags | id | seq | [length, uint16 LE, FIRST only] | data",
+		"#define BLERPC_FRAME_MORE 0x04",
+		"#define BLERPC_FRAME_HEADER_SIZE 3",
+		"#define BLERPC_FRAMING_MAX_IN_FLIGHT 3\n",
+		"    BLERPC_FRAMING_ERR_BUSY = -5,",
+		"    struct blerpc_reassembly_slot slots[BLERPC_FRAMING_MAX_IN_FLIGHT];",
+		"                            size_t len, struct blerpc_frame_message *msg);",
+		"int blerpc_fragment(const uint8_t *msg, size_t len, uint16_t mtu, uint8_t id, bool more,",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := cgen.GenerateFramingSource(gctx, commands, "blerpc", 3, false)
+	for _, want := range []string{
+		"static struct blerpc_reassembly_slot *find_slot(struct blerpc_reassembler *r, uint8_t id,\n                                                bool active)",
+		"            return BLERPC_FRAMING_ERR_BUSY;",
+		"        frame[0] = more ? BLERPC_FRAME_MORE : 0;",
+		"        frame[1] = id;\n        frame[2] = seq++;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	for _, c := range []struct{ lang, out, want string }{
+		{"Python", python.GenerateFraming(gctx, commands, "blerpc", 3, false), "class Pipeline:"},
+		{"Python", python.GenerateFraming(gctx, commands, "blerpc", 3, false), "MAX_IN_FLIGHT = 3\n"},
+		{"Kotlin", kotlin.GenerateFraming(gctx, commands, "blerpc", 3, false), "class FramePipeline("},
+		{"Kotlin", kotlin.GenerateFraming(gctx, commands, "blerpc", 3, false), "fun fragment(message: ByteArray, mtu: Int, correlationId: Int): List<ByteArray> {"},
+		{"Swift", swiftgen.GenerateFraming(gctx, commands, "blerpc", 3, false), "actor FramePipeline {"},
+		{"Swift", swiftgen.GenerateFraming(gctx, commands, "blerpc", 3, false), "static let maxInFlight = 3\n"},
+	} {
+		if !strings.Contains(c.out, c.want) {
+			t.Errorf("%s framing missing %q", c.lang, c.want)
+		}
+	}
+	if strings.Contains(python.GenerateFraming(gctx, commands, "blerpc", 0, false), "Pipeline") {
+		t.Error("Python framing without correlation IDs should have no pipeline")
+	}
+}
+
+func TestGenerateFraming_CRC(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.MaxRequestSize = 100
+	commands := []gen.Command{echo}
+
+	for _, c := range []struct{ lang, out, want string }{
+		{"C header", cgen.GenerateFramingHeader(gctx, commands, "blerpc", 0, true), "#define BLERPC_FRAME_CRC_SIZE 4\n"},
+		{"C header", cgen.GenerateFramingHeader(gctx, commands, "blerpc", 0, true), "BLERPC_FRAMING_ERR_INTEGRITY = -6,"},
+		{"C header", cgen.GenerateFramingHeader(gctx, commands, "blerpc", 3, true), "uint8_t buf[BLERPC_FRAMING_REASSEMBLY_SIZE + BLERPC_FRAME_CRC_SIZE];"},
+		{"C source", cgen.GenerateFramingSource(gctx, commands, "blerpc", 0, true), "static uint32_t frame_crc32(const uint8_t *data, size_t len)"},
+		{"C source", cgen.GenerateFramingSource(gctx, commands, "blerpc", 0, true), "        return reassembly_fail(r, BLERPC_FRAMING_ERR_INTEGRITY);\n    }\n    r->total -= BLERPC_FRAME_CRC_SIZE;\n"},
+		{"C source", cgen.GenerateFramingSource(gctx, commands, "blerpc", 3, true), "        return reassembly_fail(s, BLERPC_FRAMING_ERR_INTEGRITY);\n"},
+		{"Python", python.GenerateFraming(gctx, commands, "blerpc", 0, true), "class IntegrityError(FramingError):"},
+		{"Python", python.GenerateFraming(gctx, commands, "blerpc", 0, true), "    payload = message + zlib.crc32(message).to_bytes(CRC_SIZE, \"little\")\n"},
+		{"Python", python.GenerateFraming(gctx, commands, "blerpc", 3, true), "import asyncio\nimport zlib\nfrom collections.abc"},
+		{"Kotlin", kotlin.GenerateFraming(gctx, commands, "blerpc", 0, true), "open class FramingException(message: String) : TransportException(message)"},
+		{"Kotlin", kotlin.GenerateFraming(gctx, commands, "blerpc", 3, true), "return FrameMessage(id, Framing.stripCrc(p.buf.toByteArray()),"},
+		{"Swift", swiftgen.GenerateFraming(gctx, commands, "blerpc", 0, true), "struct IntegrityError: BlerpcErrorProtocol {"},
+		{"Swift", swiftgen.GenerateFraming(gctx, commands, "blerpc", 3, true), "let message = try Framing.stripCRC(p.buf)\n"},
+	} {
+		if !strings.Contains(c.out, c.want) {
+			t.Errorf("%s framing missing %q", c.lang, c.want)
+		}
+	}
+	if strings.Contains(python.GenerateFraming(gctx, commands, "blerpc", 0, false), "zlib") {
+		t.Error("Python framing without frame_crc should not check a CRC")
+	}
+}
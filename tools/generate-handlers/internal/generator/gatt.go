@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+var reUUID = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// assignCharacteristicUUIDs sets CharUUID on every command. base must be a
+// lowercase UUID whose first field is zero, so the service UUID can be told
+// apart from the command characteristics and recovered from any of them.
+func assignCharacteristicUUIDs(commands []gen.Command, base string) error {
+	if !reUUID.MatchString(base) {
+		return fmt.Errorf("GATT UUID base %q is not a lowercase UUID", base)
+	}
+	if !strings.HasPrefix(base, "00000000-") {
+		return fmt.Errorf("GATT UUID base %q must start with 00000000-; that field is replaced per command", base)
+	}
+	owner := make(map[string]string)
+	for i := range commands {
+		h := fnv.New32a()
+		h.Write([]byte(commands[i].Wire()))
+		uuid := fmt.Sprintf("%08x", h.Sum32()) + base[8:]
+		if uuid == base {
+			return fmt.Errorf("command %s: characteristic UUID equals the service UUID; set a different (blerpc.wire_name)", commands[i].Camel)
+		}
+		if other, ok := owner[uuid]; ok {
+			return fmt.Errorf("commands %s and %s hash to the same characteristic UUID %s; set a different (blerpc.wire_name) on one of them",
+				other, commands[i].Camel, uuid)
+		}
+		owner[uuid] = commands[i].Camel
+		commands[i].CharUUID = uuid
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	cgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+)
+
+// GATT service groups keep commands such as diagnostics on a service and
+// characteristic pair of their own, apart from the multiplexed RPC
+// characteristic. Each group is declared with its UUIDs under gatt.services
+// in blerpc.yaml, e.g.
+//
+//	gatt:
+//	  services:
+//	    - name: diagnostics
+//	      service_uuid: 6e400101-b5a3-f393-e0a9-e50e24dcca9e
+//	      characteristic_uuid: 6e400102-b5a3-f393-e0a9-e50e24dcca9e
+//	      commands: [flash_read]
+//
+// and commands join one with its commands list or
+//
+//	option (blerpc.gatt_service) = "diagnostics";
+//
+// The others stay on the RPC service. The peripheral gets a table of the
+// commands of each service and <pkg>_service_handlers_lookup, which treats a
+// command written to another service's characteristic as unknown; the
+// Zephyr GATT service defines every group. Each client's UUID constants
+// file maps the grouped commands to their service for the transport to
+// route the calls by. The batch and compression envelopes carry calls to
+// commands of any service, so they cannot be combined with groups.
+
+// GATTServiceConfig declares a GATT service group in blerpc.yaml.
+type GATTServiceConfig struct {
+	Name               string   `yaml:"name"`
+	ServiceUUID        string   `yaml:"service_uuid"`
+	CharacteristicUUID string   `yaml:"characteristic_uuid"`
+	Commands           []string `yaml:"commands"`
+}
+
+// validateGATTServices checks the gatt.services declarations: names are
+// snake_case identifiers other than rpc, and no two services, including
+// the RPC service, share a UUID.
+func validateGATTServices(c GATTConfig) error {
+	owner := map[string]string{
+		cmp.Or(c.ServiceUUID, gen.DefaultServiceUUID):     "the RPC service",
+		cmp.Or(c.CharacteristicUUID, gen.DefaultCharUUID): "the RPC service",
+	}
+	names := make(map[string]bool)
+	for i, s := range c.Services {
+		if !cgen.ReGattGroupName.MatchString(s.Name) || s.Name == "rpc" {
+			return fmt.Errorf("gatt.services[%d]: name %q must be a snake_case identifier other than rpc", i, s.Name)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("gatt.services[%d]: %s is declared twice", i, s.Name)
+		}
+		names[s.Name] = true
+		for _, f := range []struct{ name, uuid string }{
+			{"service_uuid", s.ServiceUUID},
+			{"characteristic_uuid", s.CharacteristicUUID},
+		} {
+			if !reUUID.MatchString(f.uuid) {
+				return fmt.Errorf("gatt.services[%d]: %s %s %q is not a lowercase UUID", i, s.Name, f.name, f.uuid)
+			}
+			if other, ok := owner[f.uuid]; ok {
+				return fmt.Errorf("gatt.services[%d]: %s %s %s is already used by %s", i, s.Name, f.name, f.uuid, other)
+			}
+			owner[f.uuid] = s.Name
+		}
+	}
+	return nil
+}
+
+// applyGATTServices records the groups of the gatt.services command lists
+// and checks those of the (blerpc.gatt_service) options.
+func applyGATTServices(commands []gen.Command, cfg *Config) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	declared := make(map[string]bool)
+	for i, s := range cfg.GATT.Services {
+		declared[s.Name] = true
+		for _, name := range s.Commands {
+			idx, ok := bySnake[name]
+			if !ok {
+				return fmt.Errorf("gatt.services[%d]: unknown command %q", i, name)
+			}
+			if g := commands[idx].GattService; g != "" && g != s.Name {
+				return fmt.Errorf("gatt.services[%d]: %s is already in GATT service %s", i, name, g)
+			}
+			commands[idx].GattService = s.Name
+		}
+	}
+	used := make(map[string]bool)
+	for _, cmd := range commands {
+		if cmd.GattService == "" {
+			continue
+		}
+		if !declared[cmd.GattService] {
+			return fmt.Errorf("%s: unknown GATT service %q; declare it under gatt.services in blerpc.yaml", cmd.Snake, cmd.GattService)
+		}
+		used[cmd.GattService] = true
+	}
+	for i, s := range cfg.GATT.Services {
+		if !used[s.Name] {
+			return fmt.Errorf("gatt.services[%d]: %s has no commands", i, s.Name)
+		}
+	}
+	return nil
+}
+
+// newGATTGroups returns the groups of services in declaration order, with
+// their commands in schema order.
+func newGATTGroups(commands []gen.Command, services []GATTServiceConfig) []gen.GATTGroup {
+	var groups []gen.GATTGroup
+	for _, s := range services {
+		g := gen.GATTGroup{Name: s.Name, ServiceUUID: s.ServiceUUID, CharUUID: s.CharacteristicUUID}
+		for _, cmd := range commands {
+			if cmd.GattService == s.Name {
+				g.Commands = append(g.Commands, cmd)
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
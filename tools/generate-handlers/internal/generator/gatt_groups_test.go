@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func diagnosticsService() GATTServiceConfig {
+	return GATTServiceConfig{
+		Name:               "diagnostics",
+		ServiceUUID:        "6e400101-b5a3-f393-e0a9-e50e24dcca9e",
+		CharacteristicUUID: "6e400102-b5a3-f393-e0a9-e50e24dcca9e",
+		Commands:           []string{"echo"},
+	}
+}
+
+func TestValidateGATTServices(t *testing.T) {
+	rename := diagnosticsService()
+	rename.Name = "rpc"
+	clash := diagnosticsService()
+	clash.CharacteristicUUID = gen.DefaultServiceUUID
+	bad := diagnosticsService()
+	bad.ServiceUUID = "6E400101-B5A3-F393-E0A9-E50E24DCCA9E"
+	tests := []struct {
+		name     string
+		services []GATTServiceConfig
+		want     string
+	}{
+		{"reserved name", []GATTServiceConfig{rename}, `name "rpc" must be a snake_case identifier`},
+		{"twice", []GATTServiceConfig{diagnosticsService(), diagnosticsService()}, "diagnostics is declared twice"},
+		{"rpc uuid", []GATTServiceConfig{clash}, "is already used by the RPC service"},
+		{"uppercase", []GATTServiceConfig{bad}, "is not a lowercase UUID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGATT(GATTConfig{Services: tt.services})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+	if err := validateGATT(GATTConfig{Services: []GATTServiceConfig{diagnosticsService()}}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplyGATTServices(t *testing.T) {
+	unknown := diagnosticsService()
+	unknown.Commands = []string{"missing"}
+	empty := diagnosticsService()
+	empty.Commands = nil
+	option := gentest.EchoCommand()
+	option.GattService = "bulk"
+	tests := []struct {
+		name     string
+		cmd      gen.Command
+		services []GATTServiceConfig
+		want     string
+	}{
+		{"unknown command", gentest.EchoCommand(), []GATTServiceConfig{unknown}, `unknown command "missing"`},
+		{"no commands", gentest.EchoCommand(), []GATTServiceConfig{empty}, "diagnostics has no commands"},
+		{"undeclared option", option, nil, `unknown GATT service "bulk"`},
+		{"option and config", option, []GATTServiceConfig{diagnosticsService()}, "echo is already in GATT service bulk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyGATTServices([]gen.Command{tt.cmd}, &Config{GATT: GATTConfig{Services: tt.services}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	if err := applyGATTServices(cmds, &Config{GATT: GATTConfig{Services: []GATTServiceConfig{diagnosticsService()}}}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].GattService != "diagnostics" || cmds[1].GattService != "" {
+		t.Errorf("GattService = %q, %q", cmds[0].GattService, cmds[1].GattService)
+	}
+}
+
+func TestGenerateGATTGroups(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.GattService = "diagnostics"
+	echo.ID = 3
+	stream := gentest.StreamP2CCommand()
+	stream.ID = 4
+	cmds := []gen.Command{echo, stream}
+	gctx.GATTGroups = newGATTGroups(cmds, []GATTServiceConfig{diagnosticsService()})
+	gctx.CHandlerCtx = true
+
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c header", c.GenerateHeader(gctx, cmds, nil, nil, "blerpc"), []string{
+			"    BLERPC_GATT_SERVICE_DIAGNOSTICS = 1,\n",
+			"#define BLERPC_GATT_SERVICE_COUNT 2\n",
+			"uint8_t name_len, void *ctx);\n",
+		}},
+		{"c source", c.GenerateSource(gctx, cmds, nil, nil, "blerpc"), []string{
+			"static const struct service_command rpc_service_commands[] = {\n    {\"counter_stream\", 14, 4},\n};\n",
+			"static const struct service_command diagnostics_service_commands[] = {\n    {\"echo\", 4, 3},\n};\n",
+			"        if ((name_len == 1 && (uint8_t)name[0] == cmd->id) ||\n",
+			"            return handlers_lookup(name, name_len, ctx);\n",
+		}},
+		{"gatt service", c.GenerateGattServiceSource(gctx, "blerpc"), []string{
+			"    blerpc_gatt_on_service_write(BLERPC_GATT_SERVICE_DIAGNOSTICS, conn, buf, len);\n",
+			"BT_GATT_SERVICE_DEFINE(blerpc_diagnostics_svc, BT_GATT_PRIMARY_SERVICE(&diagnostics_svc_uuid),\n",
+			"    &blerpc_diagnostics_svc.attrs[2],\n",
+		}},
+		{"python", python.GenerateUUIDs(gctx), []string{"    \"echo\": \"diagnostics\",\n", "def characteristic_for(command: str) -> str:\n"}},
+		{"kotlin", kotlin.GenerateUUIDs(gctx, "blerpc"), []string{"            \"echo\" to \"diagnostics\",\n", "    fun characteristicFor(command: String): UUID =\n"}},
+		{"swift", swift.GenerateUUIDs(gctx), []string{"        \"echo\": \"diagnostics\",\n", "    static func routedCharacteristic(for command: String) -> CBUUID {\n"}},
+		{"dart", dart.GenerateUUIDs(gctx), []string{"  'echo': 'diagnostics',\n", "String characteristicFor(String command) {\n"}},
+		{"typescript", typescript.GenerateUUIDs(gctx), []string{"  echo: 'diagnostics',\n", "export function characteristicFor(command: string): string {\n"}},
+		{"c client", c.GenerateUUIDsHeader(gctx, "blerpc"), []string{"#define BLERPC_CMD_ECHO_CHAR_UUID BLERPC_DIAGNOSTICS_CHAR_UUID\n"}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
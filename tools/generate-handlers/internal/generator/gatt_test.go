@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func gattCommands(t *testing.T) []gen.Command {
+	t.Helper()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	if err := assignCharacteristicUUIDs(cmds, c.DefaultGattUUIDBase); err != nil {
+		t.Fatalf("assignCharacteristicUUIDs: %v", err)
+	}
+	return cmds
+}
+
+func TestAssignCharacteristicUUIDs(t *testing.T) {
+	cmds := gattCommands(t)
+	// FNV-1a of "echo"
+	if cmds[0].CharUUID != "d49dd484-626c-6572-7063-000000000000" {
+		t.Errorf("echo UUID = %s", cmds[0].CharUUID)
+	}
+	if cmds[0].CharUUID == cmds[1].CharUUID {
+		t.Error("commands share a characteristic UUID")
+	}
+	if got := gen.GATTServiceUUID(cmds); got != c.DefaultGattUUIDBase {
+		t.Errorf("service UUID = %s", got)
+	}
+
+	// The UUID follows the wire name, not the position or the RPC name.
+	renamed := []gen.Command{gentest.StreamP2CCommand(), gentest.EchoCommand()}
+	renamed[1].Camel, renamed[1].Snake, renamed[1].WireName = "Ping", "ping", "echo"
+	if err := assignCharacteristicUUIDs(renamed, c.DefaultGattUUIDBase); err != nil {
+		t.Fatal(err)
+	}
+	if renamed[1].CharUUID != cmds[0].CharUUID || renamed[0].CharUUID != cmds[1].CharUUID {
+		t.Error("UUIDs changed with command order or RPC name")
+	}
+
+	for _, base := range []string{"12340001-0000-1000-8000-00805f9b34fb", "00000000-626C-6572-7063-000000000000", "blerpc"} {
+		if err := assignCharacteristicUUIDs([]gen.Command{gentest.EchoCommand()}, base); err == nil {
+			t.Errorf("base %q: expected error", base)
+		}
+	}
+
+	dup := []gen.Command{gentest.EchoCommand(), gentest.EchoCommand()}
+	dup[1].Camel = "EchoAgain"
+	err := assignCharacteristicUUIDs(dup, c.DefaultGattUUIDBase)
+	if err == nil || !strings.Contains(err.Error(), "Echo and EchoAgain") {
+		t.Errorf("expected collision error, got %v", err)
+	}
+}
+
+func TestGenerateGattHeader(t *testing.T) {
+	gctx := gen.NewContext()
+	out := c.GenerateGattHeader(gctx, gattCommands(t), "blerpc")
+	for _, s := range []string{
+		"#define BLERPC_CMD_SERVICE_UUID BT_UUID_128_ENCODE(0x00000000, 0x626c, 0x6572, 0x7063, 0x000000000000)",
+		"/* echo: d49dd484-626c-6572-7063-000000000000 */",
+		"#define BLERPC_CMD_ECHO_UUID BT_UUID_128_ENCODE(0xd49dd484, 0x626c, 0x6572, 0x7063, 0x000000000000)",
+		"#define BLERPC_CMD_COUNTER_STREAM_UUID ",
+		"#define BLERPC_GATT_COMMAND_COUNT 2",
+		"extern const struct blerpc_gatt_command blerpc_gatt_commands[BLERPC_GATT_COMMAND_COUNT];",
+		"void blerpc_gatt_on_command_write(const struct blerpc_gatt_command *cmd,\n" +
+			"                                  struct bt_conn *conn,",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateGattSource(t *testing.T) {
+	gctx := gen.NewContext()
+	out := c.GenerateGattSource(gctx, gattCommands(t), "blerpc")
+	for _, s := range []string{
+		"{\"echo\", 4, 0, handle_echo},",
+		"{\"counter_stream\", 14, 1, handle_counter_stream},",
+		"static struct bt_uuid_128 cmd_echo_uuid = BT_UUID_INIT_128(BLERPC_CMD_ECHO_UUID);",
+		"static ssize_t on_write_echo(struct bt_conn *conn, const struct bt_gatt_attr *attr,\n" +
+			"                             const void *buf,",
+		"    blerpc_gatt_on_command_write(&blerpc_gatt_commands[1], conn, buf, len);",
+		"BT_GATT_SERVICE_DEFINE(blerpc_cmd_svc, BT_GATT_PRIMARY_SERVICE(&cmd_svc_uuid),",
+		"BT_GATT_PERM_WRITE, NULL, on_write_counter_stream, NULL),",
+		"    return &blerpc_cmd_svc.attrs[2 + 3 * cmd->index];",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestClientCharacteristicTables(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := gattCommands(t)
+	streaming := map[string]string{"counter_stream": "p2c"}
+	uuid := cmds[0].CharUUID
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"COMMAND_SERVICE_UUID = \"" + c.DefaultGattUUIDBase + "\"\n",
+			"    \"echo\": \"" + uuid + "\",\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"const val COMMAND_SERVICE_UUID = \"" + c.DefaultGattUUIDBase + "\"\n",
+			"        \"echo\" to \"" + uuid + "\",\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"let commandServiceUUID = \"" + c.DefaultGattUUIDBase + "\"\n",
+			"    \"echo\": \"" + uuid + "\",\n",
+		}},
+		{"dart", dart.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"const commandServiceUuid = '" + c.DefaultGattUUIDBase + "';\n",
+			"  'echo': '" + uuid + "',\n",
+		}},
+		{"ts", typescript.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"export const COMMAND_SERVICE_UUID = '" + c.DefaultGattUUIDBase + "';\n",
+			"  echo: '" + uuid + "',\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.want {
+			if !strings.Contains(tt.out, s) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, s, tt.out)
+			}
+		}
+	}
+
+	if out := python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"); strings.Contains(out, "COMMAND_CHARACTERISTICS") {
+		t.Error("multiplexed mode should not emit a routing table")
+	}
+}
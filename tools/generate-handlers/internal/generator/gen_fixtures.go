@@ -0,0 +1,24 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// generateFixtures renders one sample textproto request per command. The
+// returned paths are file names relative to the fixtures directory.
+func generateFixtures(commands []gen.Command, msgByName map[string]gen.Message, enumByName map[string]gen.Enum, pkg string) []gen.Output {
+	var outs []gen.Output
+	for _, cmd := range commands {
+		var b strings.Builder
+		b.WriteString("# Auto-generated by generate-handlers — DO NOT EDIT\n")
+		b.WriteString(fmt.Sprintf("# proto-file: %s.proto\n", pkg))
+		b.WriteString(fmt.Sprintf("# proto-message: %s.%s\n", pkg, cmd.RequestMsg))
+		b.WriteByte('\n')
+		gen.WriteTextprotoFields(&b, cmd.RequestFields, "", 0, msgByName, enumByName)
+		outs = append(outs, gen.Output{Path: cmd.Snake + ".textproto", Content: b.String()})
+	}
+	return outs
+}
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestGenerateFixtures_Echo(t *testing.T) {
+	outs := generateFixtures([]gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc")
+	if len(outs) != 1 {
+		t.Fatalf("expected 1 fixture, got %d", len(outs))
+	}
+	if outs[0].Path != "echo.textproto" {
+		t.Errorf("expected echo.textproto, got %s", outs[0].Path)
+	}
+	mustContain := []string{
+		"# proto-file: blerpc.proto",
+		"# proto-message: blerpc.EchoRequest",
+		`message: "message"`,
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(outs[0].Content, s) {
+			t.Errorf("fixture missing %q\nGot:\n%s", s, outs[0].Content)
+		}
+	}
+}
+
+func TestGenerateFixtures_NestedAndEnum(t *testing.T) {
+	msgByName := map[string]gen.Message{
+		"Address": {Name: "Address", Fields: []gen.Field{
+			{Type: "string", Name: "street", Number: 1},
+		}},
+	}
+	enumByName := map[string]gen.Enum{
+		"Status": {Name: "Status", Values: []gen.EnumValue{
+			{Name: "STATUS_UNKNOWN", Number: 0},
+			{Name: "STATUS_ACTIVE", Number: 1},
+		}},
+	}
+	cmd := gentest.MessageFieldCommand()
+	cmd.RequestFields = append(cmd.RequestFields, gen.Field{Type: "Status", Name: "status", Number: 3, IsEnum: true})
+	out := generateFixtures([]gen.Command{cmd}, msgByName, enumByName, "blerpc")[0].Content
+
+	mustContain := []string{
+		"address {\n  street: \"street\"\n}",
+		"status: STATUS_ACTIVE",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("fixture missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateFixtures_MapAndOneof(t *testing.T) {
+	oneof := gen.Command{
+		Camel:       "Search",
+		Snake:       "search",
+		RequestMsg:  "SearchRequest",
+		ResponseMsg: "SearchResponse",
+		RequestFields: []gen.Field{
+			{Type: "string", Name: "text", Number: 1, Oneof: "query"},
+			{Type: "uint32", Name: "id", Number: 2, Oneof: "query"},
+		},
+	}
+	outs := generateFixtures([]gen.Command{gentest.MapCommand(), oneof}, nil, nil, "blerpc")
+
+	if !strings.Contains(outs[0].Content, "counts {\n  key: \"key\"\n  value: 1\n}") {
+		t.Errorf("map fixture unexpected:\n%s", outs[0].Content)
+	}
+	if !strings.Contains(outs[1].Content, `text: "text"`) || strings.Contains(outs[1].Content, "id:") {
+		t.Errorf("oneof fixture should set only the first member:\n%s", outs[1].Content)
+	}
+}
@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// findImplementedHandlers scans the source files next to a scaffold file for
+// handler definitions, so stubs are only emitted for commands no one has
+// implemented yet. Generated files (the skip list, and files whose first line
+// carries the generated-file marker, such as -split stub files) are skipped
+// since they only hold defaults. The files are read from fsys.
+func findImplementedHandlers(fsys fs.FS, dir, ext string, re *regexp.Regexp, skip ...string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return found, nil
+		}
+		return nil, err
+	}
+	skipSet := make(map[string]bool)
+	for _, s := range skip {
+		skipSet[filepath.Base(s)] = true
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ext || skipSet[e.Name()] {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		firstLine, _, _ := strings.Cut(string(data), "\n")
+		if strings.Contains(firstLine, generatedMarker) {
+			continue
+		}
+		for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+			found[m[1]] = true
+		}
+	}
+	return found, nil
+}
+
+// userOutput queues the user file at path, rendered by render. Unlike the
+// generated files it is written only while missing: generate drops it once
+// it exists, and it stays out of the manifest so -prune never removes it.
+func userOutput(path string, render func() string) gen.Output {
+	return gen.Output{Path: path, Render: render, User: true}
+}
+
+// dropExistingUserFiles removes the user files that already exist in fsys
+// from outputs, leaving the edits in them alone.
+func dropExistingUserFiles(fsys fs.FS, outputs []gen.Output) []gen.Output {
+	return slices.DeleteFunc(outputs, func(out gen.Output) bool {
+		if !out.User {
+			return false
+		}
+		_, err := fs.Stat(fsys, out.Path)
+		return err == nil
+	})
+}
+
+// userHandlerOutputs returns the user handler files of the user_files
+// option: the C file overriding the weak handlers of generated_handlers.c
+// and the Python file registering overrides of the BlerpcHandlers defaults,
+// each with a stub for every command not implemented next to it in fsys yet.
+func userHandlerOutputs(gctx *gen.Context, fsys fs.FS, commands []gen.Command, streaming map[string]string, pkg, cPath, cGenerated, pyPath, pyGenerated string) []gen.Output {
+	var outputs []gen.Output
+	if cPath != "" {
+		outputs = append(outputs, userOutput(cPath, func() string {
+			implemented, _ := findImplementedHandlers(fsys, filepath.Dir(cPath), ".c", c.HandlerDefRe(gctx), cGenerated, cPath)
+			content, _ := c.ScaffoldHandlers(gctx, commands, pkg, "", implemented)
+			return content
+		}))
+	}
+	if pyPath != "" {
+		outputs = append(outputs, userOutput(pyPath, func() string {
+			implemented, _ := findImplementedHandlers(fsys, filepath.Dir(pyPath), ".py", python.ReHandlerDef, pyGenerated, pyPath)
+			content, _ := python.ScaffoldHandlers(commands, streaming, pkg, "", implemented)
+			return content
+		}))
+	}
+	return outputs
+}
+
+// runScaffold writes (or extends) the user handler files for C and Python,
+// reporting each to w. Existing handler definitions are never touched.
+func runScaffold(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg, cPath, cGenerated, pyPath, pyGenerated string, w io.Writer) error {
+	targets := []struct {
+		path      string
+		generated string
+		ext       string
+		re        *regexp.Regexp
+		render    func([]gen.Command, string, string, map[string]bool) (string, []string)
+	}{
+		{cPath, cGenerated, ".c", c.HandlerDefRe(gctx), func(commands []gen.Command, pkg, existing string, implemented map[string]bool) (string, []string) {
+			return c.ScaffoldHandlers(gctx, commands, pkg, existing, implemented)
+		}},
+		{pyPath, pyGenerated, ".py", python.ReHandlerDef, func(commands []gen.Command, pkg, existing string, implemented map[string]bool) (string, []string) {
+			return python.ScaffoldHandlers(commands, streaming, pkg, existing, implemented)
+		}},
+	}
+	for _, t := range targets {
+		implemented, err := findImplementedHandlers(protomodel.OS, filepath.Dir(t.path), t.ext, t.re, t.generated)
+		if err != nil {
+			return err
+		}
+		existing, err := os.ReadFile(t.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		content, added := t.render(commands, pkg, string(existing), implemented)
+		if len(added) == 0 {
+			fmt.Fprintf(w, "  %s: all commands implemented, left unchanged\n", t.path)
+			continue
+		}
+		if err := writeFile(t.path, content); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  %s: added stubs for %s\n", t.path, strings.Join(added, ", "))
+	}
+	return nil
+}
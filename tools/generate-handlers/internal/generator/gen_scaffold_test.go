@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestFindImplementedHandlers(t *testing.T) {
+	gctx := gen.NewContext()
+	dir := t.TempDir()
+	files := map[string]string{
+		"handlers.c":           "int handle_echo(const uint8_t *d, size_t n, pb_ostream_t *o)\n{\n}\n",
+		"generated_handlers.c": "__attribute__((weak))\nint handle_flash_read(const uint8_t *d,\n",
+		"notes.txt":            "int handle_data_write(\n",
+		"generated_handlers_counter_stream.c": "/* Auto-generated by generate-handlers — DO NOT EDIT */\n" +
+			"int handle_counter_stream(const uint8_t *d,\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found, err := findImplementedHandlers(protomodel.OS, dir, ".c", c.HandlerDefRe(gctx), "generated_handlers.c")
+	if err != nil {
+		t.Fatalf("findImplementedHandlers: %v", err)
+	}
+	if !found["echo"] || found["flash_read"] || found["data_write"] || found["counter_stream"] {
+		t.Errorf("unexpected implemented set: %v", found)
+	}
+}
+
+func TestUserHandlerOutputs(t *testing.T) {
+	gctx := gen.NewContext()
+	dir := t.TempDir()
+	cPath := filepath.Join(dir, "user_handlers.c")
+	pyPath := filepath.Join(dir, "user_handlers.py")
+	if err := os.WriteFile(filepath.Join(dir, "handlers.c"), []byte("int handle_echo(const uint8_t *req_data)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outputs := userHandlerOutputs(gctx, protomodel.OS, []gen.Command{gentest.EchoCommand(), gentest.EnumCommand()}, nil, "blerpc", cPath, "generated_handlers.c", pyPath, "generated_handlers.py")
+	renderOutputs(context.Background(), outputs)
+	if len(outputs) != 2 || !outputs[0].User || !outputs[1].User {
+		t.Fatalf("expected two user outputs, got %+v", outputs)
+	}
+	if strings.Contains(outputs[0].Content, "handle_echo") || !strings.Contains(outputs[0].Content, "handle_get_status") {
+		t.Errorf("C user file should only stub unimplemented handlers\nGot:\n%s", outputs[0].Content)
+	}
+	if !strings.Contains(outputs[1].Content, "@registry.handler(\"echo\")") {
+		t.Errorf("Python user file missing echo stub\nGot:\n%s", outputs[1].Content)
+	}
+
+	if err := os.WriteFile(cPath, []byte("/* edited */\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outputs = dropExistingUserFiles(protomodel.OS, append(outputs, gen.Output{Path: filepath.Join(dir, "generated_handlers.c")}))
+	if len(outputs) != 2 || outputs[0].Path != pyPath {
+		t.Errorf("expected the existing C user file dropped, got %+v", outputs)
+	}
+	m := buildManifest(outputs, dir, filepath.Join(dir, manifestFile))
+	if strings.Contains(m.Content, "user_handlers.py") {
+		t.Errorf("manifest must leave user files out\nGot:\n%s", m.Content)
+	}
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// Golden tests render every target for the schemas under testdata/golden and
+// compare the files with those checked in. Each case directory is a project
+// root holding proto/blerpc.proto and optionally blerpc.yaml, a flags file of
+// name=value lines (relative -out-* paths are taken from the case directory)
+// and want/, the expected outputs by path relative to the root. After an
+// intended change to the output, rewrite want/ with
+//
+//	go test -run TestGolden -update
+//
+// and review the diff.
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files under testdata/golden")
+
+func TestGolden(t *testing.T) {
+	cases, err := os.ReadDir(filepath.Join("testdata", "golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if !c.IsDir() {
+			continue
+		}
+		t.Run(c.Name(), func(t *testing.T) {
+			root := filepath.Join("testdata", "golden", c.Name())
+			outputs := renderGolden(t, root)
+			want := filepath.Join(root, "want")
+			if *updateGolden {
+				if err := os.RemoveAll(want); err != nil {
+					t.Fatal(err)
+				}
+				for _, out := range outputs {
+					if err := writeFile(filepath.Join(want, relPath(root, out.Path)), out.Content); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return
+			}
+			generated := make(map[string]bool)
+			for _, out := range outputs {
+				name := relPath(root, out.Path)
+				generated[name] = true
+				data, err := os.ReadFile(filepath.Join(want, name))
+				if err != nil {
+					t.Errorf("%s is not in want/ (run go test -run TestGolden -update)", name)
+					continue
+				}
+				if string(data) != out.Content {
+					t.Errorf("%s differs from the golden file:\n%s", name, unifiedDiff(name, string(data), out.Content))
+				}
+				if !out.User && !unstamped(name) && !strings.Contains(generatedHeader(out.Content), " (schema ") {
+					t.Errorf("%s has no stamped generated header", name)
+				}
+			}
+			filepath.WalkDir(want, func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() && !generated[relPath(want, path)] {
+					t.Errorf("%s is no longer generated", relPath(want, path))
+				}
+				return err
+			})
+		})
+	}
+}
+
+// unstamped reports whether the generated file name has no header to
+// stamp: JSON, which has no comments, the copies of the protos in the Swift
+// package, the py.typed marker PEP 561 reads and the fuzz corpus.
+func unstamped(name string) bool {
+	name = filepath.ToSlash(name)
+	switch {
+	case filepath.Ext(name) == ".json", filepath.Base(name) == "py.typed":
+		return true
+	case filepath.Ext(name) == ".proto" && strings.Contains(name, "/Sources/"):
+		return true
+	case strings.Contains(name, "fuzz/corpus/"):
+		return true
+	}
+	return false
+}
+
+// renderGolden generates the outputs of the case at root from the default
+// flags and those in its flags file.
+func renderGolden(t *testing.T, root string) []gen.Output {
+	t.Helper()
+	o := caseOptions(t, root)
+	o.Root = root
+	// Relative output paths are taken from the case directory.
+	for name, path := range o.Outputs {
+		if path != "" && !filepath.IsAbs(path) {
+			o.Outputs[name] = filepath.Join(root, path)
+		}
+	}
+	for _, path := range []*string{&o.EmitModel, &o.CompatShims} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(root, *path)
+		}
+	}
+
+	protoPath := filepath.Join(root, "proto", "blerpc.proto")
+	protoFile, err := protomodel.ParseWithImports(protoPath, nil)
+	if err != nil {
+		t.Fatalf("parse %s: %v", protoPath, err)
+	}
+	outputs, _, err := generate(context.Background(), o, protomodel.OS, protoFile, protoPath, io.Discard)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	return outputs
+}
+
+// caseOptions returns the default options, with Python unset, and the flags
+// in the flags file of the case at root.
+func caseOptions(t *testing.T, root string) *Options {
+	t.Helper()
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	o := registeredOptions(fs)
+	o.Python = ""
+	f, err := os.Open(filepath.Join(root, "flags"))
+	if os.IsNotExist(err) {
+		return o
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, "=")
+		if fs.Lookup(name) == nil {
+			t.Fatalf("flags: unknown flag %q", name)
+		}
+		if err := fs.Set(name, value); err != nil {
+			t.Fatalf("-%s: %v", name, err)
+		}
+	}
+	return o
+}
@@ -0,0 +1,24 @@
+package generator
+
+import (
+	"fmt"
+)
+
+// checkGuards validates the guards of blerpc.yaml and fills in the default
+// burst.
+func checkGuards(cfg *Config) error {
+	tb := cfg.Guards.RateLimit
+	if tb == nil {
+		return nil
+	}
+	if tb.PerSecond <= 0 || tb.PerSecond > maxRateLimit {
+		return fmt.Errorf("guards.rate_limit: per_second must be between 1 and %d", maxRateLimit)
+	}
+	if tb.Burst == 0 {
+		tb.Burst = tb.PerSecond
+	}
+	if tb.Burst < 0 || tb.Burst > maxRateLimit {
+		return fmt.Errorf("guards.rate_limit: burst must be between 1 and %d", maxRateLimit)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestCheckGuards(t *testing.T) {
+	tests := []struct {
+		name string
+		tb   gen.TokenBucketConfig
+		want string
+	}{
+		{"zero", gen.TokenBucketConfig{}, "per_second must be between 1 and 65535"},
+		{"too large", gen.TokenBucketConfig{PerSecond: 70000}, "per_second must be between 1 and 65535"},
+		{"negative burst", gen.TokenBucketConfig{PerSecond: 10, Burst: -1}, "burst must be between 1 and 65535"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGuards(&Config{Guards: gen.GuardsConfig{RateLimit: &tt.tb}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cfg := &Config{Guards: gen.GuardsConfig{RateLimit: &gen.TokenBucketConfig{PerSecond: 10}}}
+	if err := checkGuards(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guards.RateLimit.Burst != 10 {
+		t.Errorf("Burst = %d, want per_second", cfg.Guards.RateLimit.Burst)
+	}
+}
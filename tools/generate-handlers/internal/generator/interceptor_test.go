@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateInstrumented(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.ID = 1
+	cmds := []gen.Command{echo, gentest.StreamP2CCommand(), gentest.StreamC2PCommand()}
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateInstrumented(gctx, cmds), []string{
+			"from .generated_client import CommandId, GeneratedClientMixin\n",
+			"INTERCEPTED_COMMANDS = {\n    CommandId.ECHO.wire_name: \"echo\",\n}\n\n\nclass Interceptor:\n",
+			"class MetricsCollector(Interceptor):\n",
+			"class InstrumentedClient(GeneratedClientMixin):\n",
+			"self._notify(\"on_response\", command, size, received, duration)",
+		}},
+		{"kotlin", kotlin.GenerateInstrumented(gctx, cmds, "blerpc"), []string{
+			"package com.blerpc.android.client\n\n",
+			"    when (cmdName) {\n        CommandId.ECHO.wireName -> \"echo\"\n        else -> cmdName\n    }\n",
+			"class InstrumentedClient(\n    private val client: GeneratedClient,\n",
+			"override val capabilityFlags: Int get() = client.capabilityFlags\n",
+		}},
+		{"swift", swift.GenerateInstrumented(gctx, cmds), []string{
+			"    case CommandId.echo.wireName: return \"echo\"\n",
+			"protocol CallInterceptor: AnyObject {\n",
+			"final class InstrumentedClient: GeneratedClientProtocol, @unchecked Sendable {\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+
+	plain := python.GenerateInstrumented(gctx, []gen.Command{gentest.EchoCommand()})
+	if !strings.Contains(plain, "INTERCEPTED_COMMANDS: dict[str, str] = {}\n") {
+		t.Error("python: renamed commands listed without command IDs")
+	}
+	for i, line := range strings.Split(plain, "\n") {
+		if len([]rune(line)) > 88 {
+			t.Errorf("python line %d longer than 88 characters: %q", i+1, line)
+		}
+	}
+}
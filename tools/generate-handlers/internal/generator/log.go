@@ -0,0 +1,21 @@
+package generator
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The log_stream built-in gives every product remote logging. The firmware
+// appends entries to a ring buffer with <pkg>_log_write(); log_stream drains
+// it as a peripheral-to-central stream, one LogStreamResponse per entry,
+// dropping entries below the requested level. A message longer than an entry
+// is split over consecutive entries, which the clients' read_logs helpers
+// join again and timestamp from the device uptime at which it was logged.
+
+// applyLogStream marks the log_stream command as a peripheral-to-central
+// stream, which schemas discovered by message naming cannot declare in the
+// proto.
+func applyLogStream(commands []gen.Command, streaming map[string]string) {
+	if cmd, ok := gen.BuiltinCommand(commands, "log_stream"); ok {
+		streaming[cmd.Snake] = "p2c"
+	}
+}
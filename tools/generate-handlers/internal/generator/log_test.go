@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func TestBuiltinLogStream(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n", "log_stream")
+	streaming := map[string]string{}
+	applyLogStream(commands, streaming)
+	if len(commands) != 1 || commands[0].Snake != "log_stream" || streaming["log_stream"] != "p2c" {
+		t.Fatalf("got %+v, streaming %v", commands, streaming)
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_LOG_MESSAGE_SIZE 64",
+		"void blerpc_log_write(uint8_t level, const char *module, const char *message);",
+		"int blerpc_log_stream_send(const uint8_t *data, size_t len);",
+		"int blerpc_log_stream_end(void);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static struct log_entry log_ring[BLERPC_LOG_ENTRIES];",
+		"if (entry.level < (uint8_t)req.min_level) continue;",
+		"if (blerpc_log_stream_send(buf, out.bytes_written) != 0) return -1;",
+		"if (blerpc_log_stream_end() != 0) return -1;",
+		"return -2;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, streaming, "blerpc"), []string{
+			"import time\n",
+			"async def read_logs(self, *, min_level=0, max_entries=0):",
+			"responses = await self.log_stream(min_level=min_level, max_entries=max_entries)",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, streaming, "blerpc"), []string{
+			"suspend fun readLogs(minLevel: Int = 0, maxEntries: Int = 0): List<Pair<java.time.Instant, blerpc.Blerpc.LogStreamResponse>> {",
+			"val entry = first.toBuilder().setMessage(message.toString()).build()",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, streaming, "blerpc"), []string{
+			"func readLogs(minLevel: Int32 = 0, maxEntries: UInt32 = 0) async throws -> [(timestamp: Date, entry: Blerpc_LogStreamResponse)] {",
+		}},
+		{"dart", dart.GenerateClient(gctx, commands, streaming, "blerpc"), []string{
+			"Future<List<(DateTime, LogStreamResponse)>> readLogs({int minLevel = 0, int maxEntries = 0}) async {",
+		}},
+		{"ts", typescript.GenerateClient(gctx, commands, streaming, "blerpc"), []string{
+			"const responses = await this.logStream({ minLevel, maxEntries });",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,728 @@
+// Package generator is generate-handlers: it parses a proto file, applies
+// blerpc.yaml and runs the target generators of internal/gen. Main runs the
+// command; Run generates in memory for Go programs, through pkg/blerpcgen.
+package generator
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	cgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// writeFile writes content straight from the string, so large outputs are
+// not copied into a byte slice first.
+func writeFile(path, content string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// flagOrDefault returns the flag value if non-empty, otherwise the default.
+func flagOrDefault(flagVal, defaultVal string) string {
+	if flagVal != "" {
+		return flagVal
+	}
+	return defaultVal
+}
+
+// commandLine holds the flags, apart from flag.CommandLine so that programs
+// embedding the generator keep their own.
+var commandLine = flag.NewFlagSet("generate-handlers", flag.ExitOnError)
+
+// cliOptions are the options of the command line, which the protoc plugin
+// also reads from the plugin parameter.
+var cliOptions = func() *Options {
+	o := registeredOptions(commandLine)
+	o.RunPlugin = ExecPlugin
+	return o
+}()
+
+// The flags of the command that choose what it does with the generated
+// files rather than what it generates.
+var (
+	watchFlag    = commandLine.Bool("watch", false, "regenerate whenever the proto, .options file, streaming.txt or blerpc.yaml changes, writing only the files that change, until interrupted")
+	checkFlag    = commandLine.Bool("check", false, "compare the generated files with those on disk, print a unified diff of stale files and exit 1 if any differ, without writing")
+	dryRunFlag   = commandLine.Bool("dry-run", false, "list the files that would be generated with their sizes and whether they change, and print the diffs of changed files, without writing")
+	stdoutFlag   = commandLine.String("stdout", "", "print the files of one target (see -targets) to stdout instead of writing them")
+	manifestFlag = commandLine.String("manifest", "", "manifest of the generated files and their SHA-256, read by -prune (default: "+manifestFile+" in -root; none to disable)")
+	pruneFlag    = commandLine.Bool("prune", false, "delete the files the previous manifest lists that are no longer generated, unless edited since")
+)
+
+// Main runs the generate-handlers command with the arguments of the
+// process.
+func Main() {
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := runVerify(os.Args[2:]); err != nil {
+			fatalf("verify: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := runDiff(os.Args[2:], os.Stdout); err != nil {
+			fatalf("diff: %v", err)
+		}
+		return
+	}
+
+	if isProtocPlugin(os.Args) {
+		if err := runPlugin(os.Stdin, os.Stdout); err != nil {
+			fatalf("protoc-gen-blerpc: %v", err)
+		}
+		return
+	}
+
+	commandLine.Parse(os.Args[1:])
+	o := cliOptions
+
+	protoPath := flagOrDefault(o.Proto, filepath.Join(o.Root, "proto", "blerpc.proto"))
+
+	modes := 0
+	for _, on := range []bool{*checkFlag, o.scaffold, *dryRunFlag, o.report, *watchFlag, *stdoutFlag != ""} {
+		if on {
+			modes++
+		}
+	}
+	if modes > 1 {
+		fatalf("-check, -scaffold, -dry-run, -report, -watch and -stdout cannot be combined")
+	}
+	watching := os.Getenv(watchChildEnv) != ""
+	if *stdoutFlag != "" {
+		if o.Targets != "" {
+			fatalf("-stdout selects the target itself and cannot be combined with -targets")
+		}
+		o.Targets = *stdoutFlag
+	}
+	if *quietFlag && *verboseFlag {
+		fatalf("-quiet and -verbose cannot be combined")
+	}
+	progress := io.Writer(os.Stdout)
+	if *quietFlag {
+		progress = io.Discard
+	}
+	info := progress
+	if *checkFlag || o.report || watching || *stdoutFlag != "" {
+		info = io.Discard
+	}
+	if o.Namespace != "" && !reIdentifier.MatchString(o.Namespace) {
+		fatalf("Invalid -namespace %q (want an identifier)", o.Namespace)
+	}
+	if o.Proto == "" && o.Namespace == "" {
+		cfg, err := loadConfig(protomodel.OS, flagOrDefault(o.Config, filepath.Join(o.Root, "blerpc.yaml")), o.Config != "")
+		if err != nil {
+			fatalf("Failed to load config: %v", err)
+		}
+		if len(cfg.Protos) > 0 {
+			runProtos(cfg, os.Args[1:], info)
+			return
+		}
+	}
+	if *watchFlag {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		regenerate := watchChild(ctx, watchArgs(os.Args[1:]))
+		runWatch(ctx, watchInputs(protoPath), watchInterval, o.Root, regenerate, os.Stdout)
+		return
+	}
+
+	// A run limited to some targets would drop the files of the others from
+	// the manifest, so only full runs write it.
+	manifestPath := ""
+	if *manifestFlag != "none" && o.Targets == "" && !o.scaffold && !o.report {
+		manifestPath = flagOrDefault(*manifestFlag, filepath.Join(o.Root, o.namespaced(manifestFile)))
+	}
+	if *pruneFlag && manifestPath == "" {
+		fatalf("-prune needs the manifest of a full run and cannot be combined with -targets, -stdout, -scaffold, -report or -manifest none")
+	}
+	outputs, warnings, err := parseAndGenerate(context.Background(), o, protomodel.OS, protoPath, info)
+	reportGeneration(warnings, err)
+	if *stdoutFlag != "" {
+		printOutputs(outputs, o.Root, os.Stdout)
+		return
+	}
+	var prune []string
+	if *pruneFlag {
+		prev, err := readManifest(manifestPath)
+		if err != nil {
+			fatalf("Failed to read %s: %v", manifestPath, err)
+		}
+		var edited []string
+		prune, edited, err = staleFiles(prev, outputs, o.Root)
+		if err != nil {
+			fatalf("Failed to find stale generated files: %v", err)
+		}
+		for _, path := range edited {
+			warnf("keeping %s, which is no longer generated but was edited", relPath(o.Root, path))
+		}
+	}
+	if manifestPath != "" {
+		outputs = append(outputs, buildManifest(outputs, o.Root, manifestPath))
+	}
+	if *dryRunFlag {
+		if err := dryRunOutputs(outputs, prune, o.Root, os.Stdout); err != nil {
+			fatalf("Failed to compare generated files: %v", err)
+		}
+		return
+	}
+	if *checkFlag {
+		stale, err := checkOutputs(outputs, o.Root, os.Stdout)
+		if err != nil {
+			fatalf("Failed to check generated files: %v", err)
+		}
+		if stale > 0 {
+			fatalf("%d generated file(s) out of date; rerun generate-handlers", stale)
+		}
+		return
+	}
+	if watching {
+		if err := writeChangedOutputs(outputs, o.Root, progress); err != nil {
+			fatalf("Failed to write generated files: %v", err)
+		}
+	} else {
+		for _, out := range outputs {
+			if err := writeFile(out.Path, out.Content); err != nil {
+				fatalf("Failed to write %s: %v", out.Path, err)
+			}
+			rel, _ := filepath.Rel(o.Root, out.Path)
+			fmt.Fprintf(info, "  Generated %s\n", rel)
+		}
+	}
+	for _, path := range prune {
+		if err := os.Remove(path); err != nil {
+			fatalf("Failed to remove %s: %v", path, err)
+		}
+		fmt.Fprintf(info, "  Removed %s\n", relPath(o.Root, path))
+	}
+}
+
+// parseAndGenerate parses the proto at protoPath in fsys, checks it against
+// -compat-check and returns the files to write, as generate does.
+func parseAndGenerate(ctx context.Context, o *Options, fsys fs.FS, protoPath string, info io.Writer) ([]gen.Output, []diagnostic, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	protoFile, err := protomodel.ParseWithImportsFS(fsys, protoPath, o.ProtoPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse proto: %w", err)
+	}
+
+	if o.CompatCheck != "" {
+		prev, err := protomodel.ParseWithImportsFS(fsys, o.CompatCheck, o.ProtoPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse %s: %w", o.CompatCheck, err)
+		}
+		problems, err := compatProblems(prev, protoFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("compare with %s: %w", o.CompatCheck, err)
+		}
+		if len(problems) > 0 {
+			errs := make([]error, len(problems))
+			for i, p := range problems {
+				errs[i] = errors.New(p)
+			}
+			return nil, nil, &problemsError{"Breaking changes against " + o.CompatCheck, errs}
+		}
+	}
+	return generate(ctx, o, fsys, protoFile, protoPath, info)
+}
+
+// generate validates the options, config and schema and returns the files
+// to write, read from fsys, or none in -scaffold mode, which updates the
+// user handlers itself, and in -report mode, which prints the footprint
+// report to stdout. Progress goes to info. The warnings come back with the
+// outputs, even when the generation fails.
+func generate(ctx context.Context, o *Options, fsys fs.FS, protoFile *gen.ProtoFile, protoPath string, info io.Writer) ([]gen.Output, []diagnostic, error) {
+	g := &generation{opts: o.clone(), fsys: fsys, protoFile: protoFile, protoPath: protoPath}
+	outputs, err := g.run(ctx, info)
+	return outputs, g.warnings, err
+}
+
+// run generates the outputs of g.
+func (g *generation) run(ctx context.Context, info io.Writer) ([]gen.Output, error) {
+	if err := g.opts.check(); err != nil {
+		return nil, err
+	}
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	snakes := make([]string, len(g.commands))
+	for i, c := range g.commands {
+		snakes[i] = c.Snake
+	}
+	fmt.Fprintf(info, "Found %d commands: %s\n", len(g.commands), strings.Join(snakes, ", "))
+
+	if g.opts.report {
+		cgen.WriteFootprintReport(g.gctx, os.Stdout, g.commands, g.msgByName, g.optionLimits, g.callbacks)
+		return nil, nil
+	}
+	if g.opts.scaffold {
+		return nil, g.scaffold(info)
+	}
+
+	var outputs []gen.Output
+	for _, emit := range emitters {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var err error
+		if outputs, err = emit(g, outputs); err != nil {
+			return nil, err
+		}
+	}
+	return g.finish(ctx, outputs)
+}
+
+// check validates the options that choose between generator modes.
+func (o *Options) check() error {
+	if o.CClientMode != "full" && o.CClientMode != "min" {
+		return fmt.Errorf("invalid -c-client-mode %q (want full or min)", o.CClientMode)
+	}
+	if o.CRuntime != "nanopb" && o.CRuntime != "protobuf-c" {
+		return fmt.Errorf("invalid -c-runtime %q (want nanopb or protobuf-c)", o.CRuntime)
+	}
+	if o.CHandlerSignature != "raw" && o.CHandlerSignature != "typed" {
+		return fmt.Errorf("invalid -c-handler-signature %q (want raw or typed)", o.CHandlerSignature)
+	}
+	if o.CRuntime == "protobuf-c" && o.CHandlerSignature == "typed" {
+		return errors.New("-c-handler-signature typed only supports -c-runtime nanopb")
+	}
+	if !slices.Contains(cgen.DispatchModes, o.CDispatch) {
+		return fmt.Errorf("invalid -c-dispatch %q (want linear, binary or hash)", o.CDispatch)
+	}
+	if !slices.Contains(splitModes, o.Split) {
+		return fmt.Errorf("invalid -split %q (want none, per-command or per-group)", o.Split)
+	}
+	if o.GATT != "multiplexed" && o.GATT != "per-command" {
+		return fmt.Errorf("invalid -gatt %q (want multiplexed or per-command)", o.GATT)
+	}
+	if !slices.Contains(cgen.Platforms, o.Platform) {
+		return fmt.Errorf("invalid -platform %q (want zephyr, esp-idf or none)", o.Platform)
+	}
+	if o.Platform == "esp-idf" && o.GATT == "per-command" {
+		return errors.New("-gatt per-command only supports -platform zephyr")
+	}
+	if o.AdvCompanyID > 0xffff {
+		return fmt.Errorf("invalid -adv-company-id %#x (want a 16-bit company identifier)", o.AdvCompanyID)
+	}
+	for _, bs := range strings.Split(o.BuildSystem, ",") {
+		if !slices.Contains(cgen.BuildSystems, bs) {
+			return fmt.Errorf("invalid -build-system %q (want zephyr, make, idf or platformio)", bs)
+		}
+	}
+	if o.Namespace != "" && !reIdentifier.MatchString(o.Namespace) {
+		return fmt.Errorf("invalid -namespace %q (want an identifier)", o.Namespace)
+	}
+	if o.CRuntime == "protobuf-c" && o.scaffold {
+		return errors.New("-scaffold only supports -c-runtime nanopb")
+	}
+	return nil
+}
+
+// load loads blerpc.yaml, the templates and the sidecar files of the
+// proto, discovers its commands and applies the config to them. It also fills
+// in the context the target generators read, such as names and the GATT
+// UUIDs.
+func (g *generation) load() error {
+	o, fsys := g.opts, g.fsys
+	g.gctx = gen.NewContext()
+	g.gctx.CHandlerCtx = o.CHandlerCtx
+	g.gctx.CDispatch = o.CDispatch
+	if o.TemplateDir != "" {
+		if err := g.gctx.LoadTemplates(fsys, o.TemplateDir); err != nil {
+			return fmt.Errorf("load templates: %w", err)
+		}
+	}
+	cfg, err := loadConfig(fsys, flagOrDefault(o.Config, filepath.Join(o.Root, "blerpc.yaml")), o.Config != "")
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if o.Targets != "" {
+		if err := applyTargetsFlag(cfg, o.Targets); err != nil {
+			return fmt.Errorf("invalid -targets: %w", err)
+		}
+	}
+	if o.CRuntime == "protobuf-c" && cfg.UserFiles && cfg.targetEnabled("c") {
+		return errors.New("user_files only supports -c-runtime nanopb")
+	}
+	o.applyOutputPaths(cfg)
+	g.gctx.Names = namespaceNames(cfg.Names, o.Namespace)
+	g.gctx.ServiceUUID = cmp.Or(cfg.GATT.ServiceUUID, gen.DefaultServiceUUID)
+	g.gctx.CharUUID = cmp.Or(cfg.GATT.CharacteristicUUID, gen.DefaultCharUUID)
+
+	optionsFile := flagOrDefault(o.OptionsFile, filepath.Join(o.Root, "proto", "blerpc.options"))
+	streamingFile := flagOrDefault(o.Streaming, filepath.Join(o.Root, "proto", "streaming.txt"))
+	verbosef("Inputs: %s, %s, %s, %s", g.protoPath, optionsFile, streamingFile, flagOrDefault(o.Config, filepath.Join(o.Root, "blerpc.yaml")))
+
+	protoFile := g.protoFile
+	g.cfg = cfg
+	g.pkg = cmp.Or(protoFile.Package, "blerpc")
+	g.msgByName = make(map[string]gen.Message)
+	g.enumByName = make(map[string]gen.Enum)
+	g.buildSystems = strings.Split(o.BuildSystem, ",")
+	g.cHeader = flagOrDefault(o.out("c-header"), o.defaultPath("peripheral_fw", "src", "generated_handlers.h"))
+	g.cSource = flagOrDefault(o.out("c-source"), o.defaultPath("peripheral_fw", "src", "generated_handlers.c"))
+	g.pyHandlers = flagOrDefault(o.out("py-handlers"), o.defaultPath("peripheral_py", "generated_handlers.py"))
+	g.pyClient = flagOrDefault(o.out("py-client"), o.defaultPath("central_py", "blerpc", "generated", "generated_client.py"))
+	g.ktClient = flagOrDefault(o.out("kt-client"), o.defaultPath("central_android", "app", "src", "main", "java", "com", "blerpc", "android", "client", "GeneratedClient.kt"))
+	g.swiftClient = flagOrDefault(o.out("swift-client"), o.defaultPath("central_ios", "BlerpcCentral", "Client", "GeneratedClient.swift"))
+	g.swiftScanner = flagOrDefault(o.out("swift-scanner"), filepath.Join(filepath.Dir(g.swiftClient), "GeneratedScanner.swift"))
+	g.eventsSwift = flagOrDefault(o.out("events-swift"), filepath.Join(filepath.Dir(g.swiftClient), "GeneratedEvents.swift"))
+	g.dartClient = flagOrDefault(o.out("dart-client"), o.defaultPath("central_flutter", "lib", "client", "generated_client.dart"))
+	g.tsClient = flagOrDefault(o.out("ts-client"), o.defaultPath("central_rn", "src", "client", "GeneratedClient.ts"))
+	g.cClientHeader = flagOrDefault(o.out("c-client-header"), o.defaultPath("central_fw", "src", "generated_client.h"))
+	g.cClientSource = flagOrDefault(o.out("c-client-source"), o.defaultPath("central_fw", "src", "generated_client.c"))
+	g.cClientUUIDs = flagOrDefault(o.out("uuids-c"), filepath.Join(filepath.Dir(g.cClientHeader), "generated_uuids.h"))
+	g.cCMake = flagOrDefault(o.out("c-cmake"), o.defaultPath("peripheral_fw", "generated_sources.cmake"))
+	g.cKconfig = flagOrDefault(o.out("c-kconfig"), o.defaultPath("peripheral_fw", "Kconfig.generated"))
+	g.cClientCMake = flagOrDefault(o.out("c-client-cmake"), o.defaultPath("central_fw", "generated_sources.cmake"))
+	g.cClientKconfig = flagOrDefault(o.out("c-client-kconfig"), o.defaultPath("central_fw", "Kconfig.generated"))
+
+	settings, err := discoverSettings(protoFile.Messages)
+	if err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	if settings != nil && !slices.Contains(cfg.Builtins, "settings") {
+		cfg.Builtins = append(cfg.Builtins, "settings")
+	}
+	if settings == nil && slices.Contains(cfg.Builtins, "settings") {
+		return errors.New("the settings built-in needs a message annotated with (blerpc.settings)")
+	}
+	if err := mergeBuiltins(protoFile, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	for _, name := range []string{"blerpc_info", "capabilities", "dfu", "file_transfer", "log_stream", "rpc_stats", "session", "settings"} {
+		if o.CRuntime == "protobuf-c" && slices.Contains(cfg.Builtins, name) {
+			return fmt.Errorf("the %s built-in only supports -c-runtime nanopb", name)
+		}
+	}
+	// The dfu, file_transfer and session handlers and helpers share state,
+	// so their commands must stay in one file.
+	for _, name := range []string{"dfu", "file_transfer", "session"} {
+		if o.Split == "per-command" && slices.Contains(cfg.Builtins, name) {
+			return fmt.Errorf("the %s built-in does not support -split per-command", name)
+		}
+	}
+
+	if g.callbacks, err = protomodel.ParseOptionsFS(fsys, optionsFile); err != nil {
+		return fmt.Errorf("parse options: %w", err)
+	}
+	sidecarStreaming, err := protomodel.ParseStreamingCommandsFS(fsys, streamingFile)
+	if err != nil {
+		return fmt.Errorf("parse streaming commands: %w", err)
+	}
+
+	for _, m := range protoFile.Messages {
+		g.msgByName[m.Name] = m
+	}
+	for _, e := range protoFile.Enums {
+		g.enumByName[e.Name] = e
+	}
+
+	if errs := protomodel.Validate(protoFile); len(errs) > 0 {
+		return &problemsError{"Invalid schema", errs}
+	}
+	// Discover commands: prefer service definitions, fall back to naming convention
+	if g.commands, g.streaming, err = protomodel.Discover(protoFile); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	if len(g.commands) == 0 {
+		return errors.New("no commands found in proto file: define a service, or Request/Response message pairs")
+	}
+	// streaming.txt is a deprecated fallback for commands the proto leaves
+	// unary.
+	var sidecar []string
+	for k, v := range sidecarStreaming {
+		if _, exists := g.streaming[k]; !exists {
+			g.streaming[k] = v
+			sidecar = append(sidecar, k)
+		}
+	}
+	if len(sidecar) > 0 {
+		sort.Strings(sidecar)
+		g.warnf("%s is deprecated; set option (blerpc.streaming) on the request messages of %s instead", streamingFile, strings.Join(sidecar, ", "))
+	}
+	if err := protomodel.ValidateCommandCount(g.commands, o.MaxCommands); err != nil {
+		return fmt.Errorf("too many commands: %w", err)
+	}
+	g.commandIDLock = flagOrDefault(o.CommandIDsLock, filepath.Join(filepath.Dir(g.protoPath), o.namespaced("command_ids.lock")))
+	if cfg.CommandIDs {
+		if o.GATT == "per-command" {
+			return errors.New("command_ids has no effect with -gatt per-command, which sends no command names")
+		}
+		if g.commandIDs, err = parseCommandIDLock(fsys, g.commandIDLock); err != nil {
+			return fmt.Errorf("parse command IDs: %w", err)
+		}
+		if err := assignCommandIDs(g.commands, g.commandIDs); err != nil {
+			return fmt.Errorf("invalid command IDs: %w", err)
+		}
+	}
+
+	if err := g.applyConfig(settings); err != nil {
+		return err
+	}
+	if err := g.measure(optionsFile, settings); err != nil {
+		return err
+	}
+	return nil
+}
+
+// applyConfig applies the per-command settings of blerpc.yaml, and those of
+// the built-ins and the -compat-shims schema, to the commands.
+func (g *generation) applyConfig(settings *gen.Message) error {
+	o, cfg, commands, streaming := g.opts, g.cfg, g.commands, g.streaming
+	if cfg.Batch {
+		if o.GATT == "per-command" {
+			return errors.New("batch has no effect with -gatt per-command, which sends no command names")
+		}
+		if o.CRuntime == "protobuf-c" {
+			return errors.New("batch only supports -c-runtime nanopb")
+		}
+	}
+	g.gctx.CBatch = cfg.Batch
+	g.gctx.SwiftActorClient = cfg.SwiftActorClient
+
+	applyBuiltins(commands, cfg)
+	applySettings(commands, settings)
+	applyLogStream(commands, streaming)
+	applyTypeMappings(commands, cfg)
+	if err := applyStatusChecks(commands, cfg, g.enumByName); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyIdempotent(commands, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyQueueable(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyCallPolicies(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyUnwrap(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyProperties(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if o.CompatShims != "" {
+		prev, _, err := loadModel(g.fsys, o.CompatShims, o.ProtoPath)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", o.CompatShims, err)
+		}
+		applyCompatShims(commands, streaming, prev, g.protoFile)
+	}
+	if err := applyRateLimits(commands, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := checkGuards(cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	g.gctx.CGuards = cfg.Guards
+	if err := applyRoles(commands, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyReplayProtected(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if o.CRuntime == "protobuf-c" && gen.HasReplayProtected(commands) {
+		return errors.New("replay protection only supports -c-runtime nanopb")
+	}
+	if err := applySessionProtected(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyCompression(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	g.gctx.CCompression = gen.HasCompressed(commands)
+	if g.gctx.CCompression {
+		if o.GATT == "per-command" {
+			return errors.New("compression has no effect with -gatt per-command, which sends no command names")
+		}
+		if o.CRuntime == "protobuf-c" {
+			return errors.New("compression only supports -c-runtime nanopb")
+		}
+	}
+	if err := applyCacheable(commands, cfg, streaming); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyPriorities(commands, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := checkAirtimeFlows(commands, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyExclusions(commands, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyGATTServices(commands, cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	g.gctx.GATTGroups = newGATTGroups(commands, cfg.GATT.Services)
+	if len(g.gctx.GATTGroups) > 0 {
+		switch {
+		case o.GATT == "per-command":
+			return errors.New("gatt.services only supports -gatt multiplexed")
+		case o.Platform == "esp-idf":
+			return errors.New("gatt.services only supports -platform zephyr or none")
+		case cfg.Batch:
+			return errors.New("gatt.services cannot be combined with batch, whose envelope reaches the commands of every service")
+		case g.gctx.CCompression:
+			return errors.New("gatt.services cannot be combined with compression, whose envelope reaches the commands of every service")
+		}
+	}
+	if o.Strict {
+		if errs := strictProblems(commands, g.protoFile, cfg); len(errs) > 0 {
+			return &problemsError{"Unsupported schema constructs (-strict)", errs}
+		}
+	}
+	if err := applyTypedHandlers(commands, o.CHandlerSignature, streaming, g.callbacks); err != nil {
+		return fmt.Errorf("invalid handler signature: %w", err)
+	}
+	if o.GATT == "per-command" {
+		if err := assignCharacteristicUUIDs(commands, o.GATTUUIDBase); err != nil {
+			return fmt.Errorf("invalid GATT layout: %w", err)
+		}
+	}
+	return nil
+}
+
+// measure sets the buffer sizes of the commands from the .options file and
+// discovers the advertisements and events, whose sizes it also bounds.
+func (g *generation) measure(optionsFile string, settings *gen.Message) error {
+	var err error
+	if g.optionLimits, err = protomodel.ParseOptionLimitsFS(g.fsys, optionsFile); err != nil {
+		return fmt.Errorf("parse options: %w", err)
+	}
+	setMaxSizes(g.commands, g.msgByName, g.optionLimits, g.callbacks)
+	setBytesMaxSizes(g.commands, g.optionLimits, g.callbacks)
+	setStringMaxBytes(g.commands, g.optionLimits, g.callbacks)
+	g.gctx.SchemaHash = computeSchemaHash(g.protoFile, g.commands, g.streaming)
+	if settings != nil {
+		if err := checkSettingsFields(settings, g.optionLimits); err != nil {
+			return fmt.Errorf("invalid settings: %w", err)
+		}
+	}
+	if g.advs, err = discoverAdvertisements(g.protoFile.Messages, g.optionLimits, g.opts.AdvMaxSize); err != nil {
+		return fmt.Errorf("invalid advertisement: %w", err)
+	}
+	if g.events, err = discoverEvents(g.protoFile.Messages, g.commands); err != nil {
+		return fmt.Errorf("invalid event: %w", err)
+	}
+	return nil
+}
+
+// scaffold writes the user handler stubs of -scaffold, and the Unity tests
+// of -out-c-tests.
+func (g *generation) scaffold(info io.Writer) error {
+	o := g.opts
+	outCUserHandlers := flagOrDefault(o.out("c-user-handlers"), o.defaultPath("peripheral_fw", "src", "user_handlers.c"))
+	outPyUserHandlers := flagOrDefault(o.out("py-user-handlers"), o.defaultPath("peripheral_py", "user_handlers.py"))
+	if err := runScaffold(g.gctx, g.commands, g.streaming, g.pkg, outCUserHandlers, g.cSource, outPyUserHandlers, g.pyHandlers, info); err != nil {
+		return fmt.Errorf("scaffold user handlers: %w", err)
+	}
+	if o.out("c-tests") != "" {
+		if err := scaffoldCUnityTests(g.gctx, g.commands, g.streaming, g.pkg, o.out("c-tests"), info); err != nil {
+			return fmt.Errorf("scaffold C handler tests: %w", err)
+		}
+	}
+	return nil
+}
+
+// finish renders the queued outputs and adds those built from the rendered
+// files: the Swift package, the -emit-model file and the plugin outputs. It
+// stamps the result and checks its Python.
+func (g *generation) finish(ctx context.Context, outputs []gen.Output) ([]gen.Output, error) {
+	o := g.opts
+	outputs = dropExistingUserFiles(g.fsys, outputs)
+	start := time.Now()
+	if err := renderOutputs(ctx, outputs); err != nil {
+		return nil, err
+	}
+	if err := g.gctx.TemplateErr(); err != nil {
+		return nil, fmt.Errorf("render template: %w", err)
+	}
+	verbosef("Rendered %d files in %v", len(outputs), time.Since(start).Round(time.Millisecond))
+	if o.out("swift-package") != "" && g.cfg.targetEnabled("swift") {
+		protos, err := swift.PackageProtos(g.fsys, g.protoPath, o.ProtoPath)
+		if err != nil {
+			return nil, fmt.Errorf("copy protos into the Swift package: %w", err)
+		}
+		// The scanner and the events build on the BLE layer of the app.
+		appOnly := []string{g.swiftScanner, g.eventsSwift}
+		outputs = append(outputs, swift.PackageOutputs(g.gctx, outputs, filepath.Dir(g.swiftClient), appOnly, o.out("swift-package"), g.pkg, protos)...)
+	}
+	if o.EmitModel != "" {
+		outputs = append(outputs, gen.Output{Path: o.EmitModel, Content: generateModelJSON(g.commands, g.streaming, g.protoFile, g.pkg)})
+	}
+
+	plugins, err := externalPlugins(g.cfg, o.Plugins, o.Root)
+	if err != nil {
+		return nil, fmt.Errorf("find plugins: %w", err)
+	}
+	if len(plugins) > 0 && o.RunPlugin == nil {
+		return nil, fmt.Errorf("plugin %s: Options.RunPlugin is not set, and plugins are not run without it", plugins[0].name)
+	}
+	for _, p := range plugins {
+		req := generatorRequest{
+			Version:   generatorProtocolVersion,
+			Target:    p.name,
+			Package:   g.pkg,
+			Syntax:    g.protoFile.Syntax,
+			Commands:  g.commands,
+			Streaming: g.streaming,
+			Messages:  g.protoFile.Messages,
+			Enums:     g.protoFile.Enums,
+		}
+		var stderr bytes.Buffer
+		files, err := runExternalPlugin(ctx, o.RunPlugin, p, req, o.Root, &stderr)
+		for _, line := range strings.Split(stderr.String(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				g.warnf("plugin %s: %s", p.name, line)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, files...)
+	}
+
+	stampOutputs(outputs, g.gctx.SchemaHash)
+	if err := checkPythonOutputs(ctx, o.Python, outputs); err != nil {
+		return nil, fmt.Errorf("refusing to write invalid Python: %w", err)
+	}
+	return outputs, nil
+}
+
+// warnf records a warning of the generation.
+func (g *generation) warnf(format string, args ...any) {
+	g.warnings = append(g.warnings, newDiagnostic("warning", fmt.Sprintf(format, args...), args...))
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The manifest lists every file a run generated, relative to -root, with
+// the SHA-256 of its content. The next run with -prune deletes the files the
+// previous manifest lists but the run no longer generates, such as those of
+// a removed command or a disabled target. A listed file whose content no
+// longer matches its hash was edited by hand and is kept.
+
+// manifestFile is the default manifest path under -root.
+const manifestFile = "generated_manifest.json"
+
+type manifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// relPath returns path relative to root in slash form, or path itself if it
+// is not under root.
+func relPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// buildManifest returns the manifest of outputs, written to path. User
+// files are left out: they belong to the user once created.
+func buildManifest(outputs []gen.Output, root, path string) gen.Output {
+	m := manifest{Files: make([]manifestEntry, 0, len(outputs))}
+	for _, out := range outputs {
+		if out.User {
+			continue
+		}
+		m.Files = append(m.Files, manifestEntry{relPath(root, out.Path), sha256Hex([]byte(out.Content))})
+	}
+	data, _ := json.MarshalIndent(m, "", "  ")
+	return gen.Output{Path: path, Content: string(data) + "\n"}
+}
+
+// readManifest reads the manifest at path. A missing manifest is empty.
+func readManifest(path string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// staleFiles returns the files of prev, under root, that outputs no longer
+// include and that still exist on disk: remove holds those left as they were
+// generated, edited those changed since.
+func staleFiles(prev manifest, outputs []gen.Output, root string) (remove, edited []string, err error) {
+	current := make(map[string]bool, len(outputs))
+	for _, out := range outputs {
+		current[relPath(root, out.Path)] = true
+	}
+	for _, e := range prev.Files {
+		if current[e.Path] || !filepath.IsLocal(filepath.FromSlash(e.Path)) {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(e.Path))
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if sha256Hex(data) == e.SHA256 {
+			remove = append(remove, path)
+		} else {
+			edited = append(edited, path)
+		}
+	}
+	return remove, edited, nil
+}
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestBuildManifest(t *testing.T) {
+	root := t.TempDir()
+	out := buildManifest([]gen.Output{{Path: filepath.Join(root, "src", "a.c"), Content: "int a;\n"}}, root, filepath.Join(root, manifestFile))
+	var m manifest
+	if err := json.Unmarshal([]byte(out.Content), &m); err != nil {
+		t.Fatalf("manifest is not JSON: %v\n%s", err, out.Content)
+	}
+	want := manifestEntry{"src/a.c", sha256Hex([]byte("int a;\n"))}
+	if len(m.Files) != 1 || m.Files[0] != want {
+		t.Errorf("got %+v, want [%+v]", m.Files, want)
+	}
+}
+
+func TestStaleFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("kept.c", "int k;\n")
+	write("gone.c", "int g;\n")
+	write("edited.c", "int e; /* mine */\n")
+	prev := manifest{Files: []manifestEntry{
+		{"kept.c", sha256Hex([]byte("int k;\n"))},
+		{"gone.c", sha256Hex([]byte("int g;\n"))},
+		{"edited.c", sha256Hex([]byte("int e;\n"))},
+		{"deleted.c", sha256Hex([]byte("int d;\n"))},
+		{"../outside.c", sha256Hex([]byte("int o;\n"))},
+	}}
+	remove, edited, err := staleFiles(prev, []gen.Output{{Path: filepath.Join(root, "kept.c"), Content: "int k2;\n"}}, root)
+	if err != nil {
+		t.Fatalf("staleFiles: %v", err)
+	}
+	if want := []string{filepath.Join(root, "gone.c")}; !slices.Equal(remove, want) {
+		t.Errorf("remove = %v, want %v", remove, want)
+	}
+	if want := []string{filepath.Join(root, "edited.c")}; !slices.Equal(edited, want) {
+		t.Errorf("edited = %v, want %v", edited, want)
+	}
+}
+
+func TestReadManifestMissing(t *testing.T) {
+	m, err := readManifest(filepath.Join(t.TempDir(), manifestFile))
+	if err != nil || len(m.Files) != 0 {
+		t.Errorf("readManifest of a missing file = %+v, %v", m, err)
+	}
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateMock(t *testing.T) {
+	gctx := gen.NewContext()
+	guarded := gentest.EchoCommand()
+	guarded.ReplayProtected = true
+	cmds := []gen.Command{guarded, gentest.StreamP2CCommand()}
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateMock(cmds), []string{
+			"from .generated_client import COMMAND_MESSAGES, GeneratedClientMixin\n",
+			"_REQUEST_PREFIXES = {\n    \"echo\": 24,\n}\n",
+			"class MockGeneratedClient(GeneratedClientMixin):\n",
+			"        command = cmd_name\n",
+			"decoded = [req_cls.FromString(data[skip:]) for data in requests]",
+			"    async def stream_receive(self, cmd_name, request_data):\n",
+		}},
+		{"kotlin", kotlin.GenerateMock(gctx, cmds, "blerpc"), []string{
+			"class MockGeneratedClient : GeneratedClient() {\n",
+			"fun respond(command: String, vararg responses: MessageLite) {",
+			"\"echo\" -> blerpc.Blerpc.EchoRequest.parseFrom(data.copyOfRange(24, data.size))\n",
+			"\"counter_stream\" -> blerpc.Blerpc.CounterStreamRequest.parseFrom(data)\n",
+			"\"echo\" -> blerpc.Blerpc.EchoResponse.getDefaultInstance()\n",
+		}},
+		{"swift", swift.GenerateMock(gctx, cmds, "blerpc"), []string{
+			"final class MockGeneratedClient: GeneratedClientProtocol, @unchecked Sendable {\n",
+			"func fail(_ command: String, with error: Error) {",
+			"case \"echo\": return try Blerpc_EchoRequest(serializedBytes: data.dropFirst(24))\n",
+			"case \"echo\": return Blerpc_EchoResponse()\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+		if strings.Contains(tt.out, "authenticate_session") {
+			t.Errorf("%s: session handling emitted without the session built-in", tt.name)
+		}
+	}
+
+	// With command IDs the mock maps the ID back to the command.
+	withID := gentest.EchoCommand()
+	withID.ID = 1
+	for name, out := range map[string]string{
+		"python": python.GenerateMock([]gen.Command{withID}),
+		"kotlin": kotlin.GenerateMock(gctx, []gen.Command{withID}, "blerpc"),
+		"swift":  swift.GenerateMock(gctx, []gen.Command{withID}, "blerpc"),
+	} {
+		if !strings.Contains(out, "CommandId.") || !strings.Contains(out, "\"echo\"") {
+			t.Errorf("%s: command ID not mapped", name)
+		}
+	}
+}
+
+func TestGenerateMockSession(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, sessionSchema, "session")
+	if err := applySessionProtected(commands, &Config{SessionProtected: []string{"echo"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := gen.MockRequestPrefix(commands[0]); n != gen.SessionTokenSize {
+		t.Errorf("prefix of echo = %d, want %d", n, gen.SessionTokenSize)
+	}
+	for out, want := range map[string]string{
+		python.GenerateMock(commands):                 "return resp_cls(token=bytes(8)).SerializeToString()",
+		kotlin.GenerateMock(gctx, commands, "blerpc"): ".setToken(ByteString.copyFrom(ByteArray(8)))",
+		swift.GenerateMock(gctx, commands, "blerpc"):  "resp.token = Data(count: 8)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
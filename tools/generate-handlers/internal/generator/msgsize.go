@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// wellKnownMaxSize is the largest Timestamp or Duration: int64 seconds and
+// int32 nanos, each a tag and a 10-byte varint.
+const wellKnownMaxSize = 22
+
+// messageSizer computes max encoded sizes of the messages of a schema.
+type messageSizer struct {
+	messages  map[string]gen.Message
+	limits    map[string]protomodel.FieldLimits
+	callbacks map[string]bool
+	visiting  map[string]bool
+}
+
+// setMaxSizes sets the max request and response size of every command.
+func setMaxSizes(commands []gen.Command, messages map[string]gen.Message, limits map[string]protomodel.FieldLimits, callbacks map[string]bool) {
+	s := &messageSizer{messages, limits, callbacks, make(map[string]bool)}
+	for i := range commands {
+		cmd := &commands[i]
+		cmd.MaxRequestSize = s.fieldsSize(cmd.RequestMsg, cmd.RequestFields)
+		cmd.MaxResponseSize = s.fieldsSize(cmd.ResponseMsg, cmd.ResponseFields)
+	}
+}
+
+// fieldsSize returns the largest encoding of the fields of message msg. Of
+// the members of a oneof only the largest counts.
+func (s *messageSizer) fieldsSize(msg string, fields []gen.Field) int {
+	if s.visiting[msg] {
+		return gen.UnboundedSize // recursive messages have no bound
+	}
+	s.visiting[msg] = true
+	defer delete(s.visiting, msg)
+	total := 0
+	oneofs := make(map[string]int)
+	for _, f := range fields {
+		n := s.fieldSize(msg, f)
+		if n == gen.UnboundedSize {
+			return gen.UnboundedSize
+		}
+		if f.Oneof != "" {
+			oneofs[f.Oneof] = max(oneofs[f.Oneof], n)
+			continue
+		}
+		total += n
+	}
+	for _, n := range oneofs {
+		total += n
+	}
+	return total
+}
+
+// fieldSize returns the largest encoding of field f of msg, tags included.
+func (s *messageSizer) fieldSize(msg string, f gen.Field) int {
+	key := msg + "." + f.Name
+	if s.callbacks[key] {
+		return gen.UnboundedSize
+	}
+	tag := varintSize(f.Number << 3)
+	if f.IsMap {
+		count := s.limits[key].MaxCount
+		k, v := s.encodedSize(key, gen.Field{Type: f.KeyType}), s.encodedSize(key, gen.Field{Type: f.ValueType, IsMessage: f.ValueIsMessage})
+		if count == 0 || k == gen.UnboundedSize || v == gen.UnboundedSize {
+			return gen.UnboundedSize
+		}
+		entry := 1 + k + 1 + v
+		return count * (tag + varintSize(entry) + entry)
+	}
+	v := s.encodedSize(key, f)
+	if v == gen.UnboundedSize {
+		return gen.UnboundedSize
+	}
+	if !f.IsRepeated {
+		return tag + v
+	}
+	count := s.limits[key].MaxCount
+	if count == 0 {
+		return gen.UnboundedSize
+	}
+	if f.IsMessage || f.Type == "string" || f.Type == "bytes" {
+		return count * (tag + v)
+	}
+	// Scalars may come packed or unpacked; count the larger.
+	packed := tag + varintSize(count*v) + count*v
+	return max(packed, count*(tag+v))
+}
+
+// encodedSize returns the largest encoding of one value of f without tag,
+// length prefix included.
+func (s *messageSizer) encodedSize(key string, f gen.Field) int {
+	v := s.valueSize(key, f)
+	if v != gen.UnboundedSize && (f.IsMessage || f.Type == "string" || f.Type == "bytes") {
+		v += varintSize(v)
+	}
+	return v
+}
+
+// valueSize returns the largest encoding of one value of f, key naming the
+// field in the .options file, without tag or length prefix.
+func (s *messageSizer) valueSize(key string, f gen.Field) int {
+	switch {
+	case protomodel.IsWellKnownType(f.Type):
+		return wellKnownMaxSize
+	case f.IsMessage:
+		m, ok := s.messages[f.Type]
+		if !ok {
+			return gen.UnboundedSize
+		}
+		return s.fieldsSize(m.Name, m.Fields)
+	case f.IsEnum:
+		return 10
+	case f.Type == "string" || f.Type == "bytes":
+		n := s.limits[key].MaxSize
+		if n == 0 {
+			return gen.UnboundedSize
+		}
+		if f.Type == "string" {
+			n-- // max_size counts the terminating NUL
+		}
+		return n
+	}
+	if n, ok := scalarMaxSize[f.Type]; ok {
+		return n
+	}
+	return gen.UnboundedSize
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+func TestSetMaxSizes(t *testing.T) {
+	pf, err := protomodel.ParseReader(strings.NewReader(`syntax = "proto3";
+package blerpc;
+
+enum Mode { MODE_OFF = 0; }
+
+message Point { sint32 x = 1; sint32 y = 2; }
+message Node { Node next = 1; }
+
+message ShapeRequest {
+  string name = 1;
+  repeated Point points = 2;
+  repeated uint32 weights = 3;
+  Mode mode = 4;
+  oneof fill {
+    fixed32 color = 5;
+    bytes pattern = 6;
+  }
+  map<uint32, Point> labels = 7;
+}
+message ShapeResponse { bytes image = 1; }
+message ListRequest { Node head = 1; }
+message ListResponse { repeated uint32 ids = 1; }
+message PingRequest {}
+message PingResponse {}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := make(map[string]gen.Message)
+	for _, m := range pf.Messages {
+		msgs[m.Name] = m
+	}
+	limits := map[string]protomodel.FieldLimits{
+		"ShapeRequest.name":    {MaxSize: 17},
+		"ShapeRequest.points":  {MaxCount: 4},
+		"ShapeRequest.weights": {MaxCount: 3},
+		"ShapeRequest.pattern": {MaxSize: 8},
+		"ShapeRequest.labels":  {MaxCount: 2},
+		"ShapeResponse.image":  {MaxSize: 100},
+	}
+	commands := protomodel.DiscoverCommands(pf.Messages)
+	setMaxSizes(commands, msgs, limits, map[string]bool{"ShapeResponse.image": true})
+
+	// name 1+1+16, points 4*(1+1+12), weights unpacked 3*(1+5), mode 1+10,
+	// fill max(1+4, 1+1+8), labels 2*(1+1+(1+5+1+1+12)).
+	want := map[string][2]int{
+		"shape": {18 + 56 + 18 + 11 + 10 + 44, gen.UnboundedSize},
+		"list":  {gen.UnboundedSize, gen.UnboundedSize},
+		"ping":  {0, 0},
+	}
+	for _, cmd := range commands {
+		if got := [2]int{cmd.MaxRequestSize, cmd.MaxResponseSize}; got != want[cmd.Snake] {
+			t.Errorf("%s: max sizes %v, want %v", cmd.Snake, got, want[cmd.Snake])
+		}
+	}
+}
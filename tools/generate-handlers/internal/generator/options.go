@@ -0,0 +1,336 @@
+package generator
+
+import (
+	"flag"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	cgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// Options are the settings of a generation: the flags of the command but
+// those choosing what it does with the files, such as -check and -watch.
+// Each field is the flag it is named after, e.g. CHandlerCtx is
+// -c-handler-ctx; generate-handlers -help describes them. The paths of the
+// -out-* flags are in Outputs, keyed by flag name without out-, like the
+// outputs: section of blerpc.yaml. RunPlugin, which is no flag, runs the
+// external generators of the plugins: section and -plugins.
+type Options struct {
+	Root        string
+	Proto       string
+	OptionsFile string // -options
+	Streaming   string
+	Config      string
+	TemplateDir string
+	ProtoPath   []string
+
+	Split          string
+	MaxCommands    int
+	GATT           string
+	Platform       string
+	GATTUUIDBase   string
+	AdvCompanyID   uint
+	AdvMaxSize     int
+	Targets        string
+	Plugins        string
+	CompatCheck    string
+	CompatShims    string
+	EmitModel      string
+	Strict         bool
+	Python         string
+	Namespace      string
+	BuildSystem    string
+	CommandIDsLock string
+
+	CRuntime          string
+	CHandlerSignature string
+	CHandlerCtx       bool
+	CDispatch         string
+	CClientMode       string
+
+	TSProtocolImport string
+	RSPbPath         string
+	GoWireImport     string
+	GoPbImport       string
+	GoSimImport      string
+	GoClientImport   string
+
+	Outputs map[string]string
+
+	RunPlugin PluginRunner // nil fails generations that have plugins
+
+	scaffold bool // write the user handler stubs instead of generating
+	report   bool // print the footprint report instead of generating
+}
+
+// DefaultOptions returns the options of the command run without flags, but
+// with an empty Python: Run leaves the generated Python unchecked unless
+// Python names an interpreter.
+func DefaultOptions() *Options {
+	o := registeredOptions(flag.NewFlagSet("", flag.ContinueOnError))
+	o.Python = ""
+	return o
+}
+
+// registeredOptions returns options set by the flags it defines on fs.
+func registeredOptions(fs *flag.FlagSet) *Options {
+	o := new(Options)
+	o.register(fs)
+	return o
+}
+
+// register defines the flags of o on fs, setting o to their defaults.
+func (o *Options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.Root, "root", ".", "project root directory")
+
+	// Input flags
+	fs.StringVar(&o.Proto, "proto", "", "path to .proto file (default: <root>/proto/blerpc.proto)")
+	fs.StringVar(&o.OptionsFile, "options", "", "path to .options file (default: <root>/proto/blerpc.options)")
+	fs.StringVar(&o.Streaming, "streaming", "", "path to the deprecated streaming.txt (default: <root>/proto/streaming.txt)")
+	fs.StringVar(&o.Config, "config", "", "path to generator config (default: <root>/blerpc.yaml, optional)")
+	fs.StringVar(&o.TemplateDir, "template-dir", "", "directory of .tmpl files replacing the built-in templates of the same name (see tools/generate-handlers/internal/gen/templates)")
+	fs.Func("proto-path", "comma-separated proto import search paths", func(paths string) error {
+		o.ProtoPath = nil
+		if paths != "" {
+			o.ProtoPath = strings.Split(paths, ",")
+		}
+		return nil
+	})
+
+	// Mode flags
+	fs.BoolVar(&o.scaffold, "scaffold", false, "write editable user handler stubs for unimplemented commands instead of generating")
+	fs.BoolVar(&o.report, "report", false, "print the nanopb struct sizes, largest encodings and handler table flash of each command, with totals, instead of generating")
+	fs.StringVar(&o.Split, "split", "none", "write the C handler source, Python client and Swift client as one file per command (per-command) or proto service (per-group) plus an index file: none, per-command, or per-group")
+	fs.IntVar(&o.MaxCommands, "max-commands", protomodel.DefaultMaxCommands, "fail when the schema defines more commands than this (0 disables the check)")
+	fs.StringVar(&o.GATT, "gatt", "multiplexed", "GATT layout: multiplexed (all commands share one characteristic), or per-command (one characteristic per command)")
+	fs.StringVar(&o.Platform, "platform", "zephyr", "peripheral platform to write the RPC GATT service for: zephyr, esp-idf (NimBLE), or none")
+	fs.StringVar(&o.GATTUUIDBase, "gatt-uuid-base", cgen.DefaultGattUUIDBase, "command service UUID of -gatt per-command; characteristic UUIDs replace its first field with a hash of the command name")
+	fs.UintVar(&o.AdvCompanyID, "adv-company-id", 0xffff, "Bluetooth SIG company identifier of (blerpc.advertising) manufacturer data (default 0xffff, reserved for testing)")
+	fs.IntVar(&o.AdvMaxSize, "adv-max-size", defaultAdvMaxSize, "largest manufacturer data of an advertisement after the company identifier")
+	fs.StringVar(&o.Targets, "targets", "", "comma-separated targets to generate, replacing the targets: section of blerpc.yaml: "+strings.Join(targets, ", "))
+	fs.StringVar(&o.Plugins, "plugins", "", "comma-separated external targets to generate with blerpc-gen-<target> from PATH, besides the blerpc.yaml plugins")
+	fs.StringVar(&o.CompatCheck, "compat-check", "", "previous version of the proto, e.g. of the last release; fail before generating if the schema changes in a way that breaks peripherals and apps deployed with it")
+	fs.StringVar(&o.CompatShims, "compat-shims", "", "previous version of the schema, a proto or a document saved by -emit-model; keep deprecated aliases of the commands renamed since and default-filled accessors of the response fields removed since in the clients (disabled if empty)")
+	fs.StringVar(&o.EmitModel, "emit-model", "", "write the commands, messages and enums, after blerpc.yaml is applied, as a stable JSON document to this path, e.g. for release tooling diffing command sets (disabled if empty)")
+	fs.BoolVar(&o.Strict, "strict", false, "fail instead of falling back on command field types a client language has no type for, and on Request messages no RPC uses")
+	fs.StringVar(&o.Python, "python", "python3", "Python interpreter used to syntax-check generated Python before writing (empty to disable)")
+	fs.StringVar(&o.Namespace, "namespace", "", "namespace of the proto in a project of several (see protos in blerpc.yaml): defaults the C prefixes and the Swift type prefix, and puts the default output paths in a directory of its own")
+	fs.StringVar(&o.BuildSystem, "build-system", "zephyr", "comma-separated build systems to write source list fragments for: zephyr, make, idf, platformio")
+	fs.StringVar(&o.CommandIDsLock, "command-ids-lock", "", "lock file of the numeric command IDs enabled in blerpc.yaml (default: command_ids.lock next to -proto)")
+
+	// Output flags
+	o.Outputs = make(map[string]string)
+	for _, out := range outputFlags {
+		fs.Func("out-"+out.name, out.usage, func(path string) error {
+			o.Outputs[out.name] = path
+			return nil
+		})
+	}
+
+	// C handler flags
+	fs.StringVar(&o.CRuntime, "c-runtime", "nanopb", "protobuf runtime of the C handlers: nanopb, or protobuf-c")
+	fs.StringVar(&o.CHandlerSignature, "c-handler-signature", "raw", "signature of the nanopb handlers of unary commands: raw (request bytes and output stream), or typed (decoded request and response structs)")
+	fs.BoolVar(&o.CHandlerCtx, "c-handler-ctx", false, "add a trailing void *ctx, passed through by the firmware's dispatcher, to the C handlers and handlers_lookup")
+	fs.StringVar(&o.CDispatch, "c-dispatch", "linear", "how handlers_lookup finds a command: linear (scan in schema order), binary (search the table sorted by name), or hash (generated perfect hash)")
+
+	// C client flags
+	fs.StringVar(&o.CClientMode, "c-client-mode", "full", "C client flavor: full, or min for a size-optimized client with static buffers")
+
+	// TypeScript target flags
+	fs.StringVar(&o.TSProtocolImport, "ts-protocol-import", "@blerpc/protocol-rn", "module of the TypeScript protocol library imported by -out-ts-web and -out-ts-node")
+
+	// Rust target flags
+	fs.StringVar(&o.RSPbPath, "rs-pb-path", "", "Rust module path of the prost messages used by -out-rs-handlers (default: crate::<proto package>)")
+
+	// Go target flags
+	fs.StringVar(&o.GoWireImport, "go-wire-import", "github.com/tdaira/blerpc/go/wire", "import path of the shared Go wire package")
+	fs.StringVar(&o.GoPbImport, "go-pb-import", "github.com/tdaira/blerpc/central_go/proto", "import path of the protoc-gen-go message package")
+	fs.StringVar(&o.GoSimImport, "go-sim-import", "github.com/tdaira/blerpc/peripheral_go/sim", "import path of the package of -out-go-sim, imported by -out-go-bench")
+	fs.StringVar(&o.GoClientImport, "go-client-import", "github.com/tdaira/blerpc/central_go/client", "import path of the package of -out-go-client, imported by -out-go-gateway and -out-go-mqtt")
+}
+
+// outputFlags are the -out-* flags, by name without out-.
+var outputFlags = []struct{ name, usage string }{
+	{"c-header", "C handler header output path"},
+	{"c-source", "C handler source output path"},
+	{"py-handlers", "Python handlers output path"},
+	{"py-server", "Python peripheral GATT server output path (default: generated_server.py next to the Python handlers)"},
+	{"py-client", "Python client output path"},
+	{"py-typed", "PEP 561 py.typed marker path (default: py.typed in the package above the Python client)"},
+	{"py-capture", "Python traffic recorder and replay output path, with capture: true (default: capture.py next to the Python client)"},
+	{"py-faults", "Python fault-injection client output path, with fault_injection: true (default: faults.py next to the Python client)"},
+	{"kt-result-client", "Kotlin Result client output path, with kotlin_result_client: true (default: ResultClient.kt next to the Kotlin client)"},
+	{"py-sync-client", "Python synchronous client output path, with python_sync: true (default: sync_client.py next to the Python client)"},
+	{"py-resume", "Python resuming client wrapper output path"},
+	{"py-metrics", "Python interceptor and metrics wrapper output path (default: instrumented_client.py next to the Python client)"},
+	{"py-cache", "Python caching client wrapper output path (default: caching_client.py next to the Python client)"},
+	{"py-priority", "Python priority client wrapper output path (default: priority_client.py next to the Python client)"},
+	{"py-redaction", "Python sensitive field redaction helpers output path (default: redaction.py next to the Python client)"},
+	{"py-connmgr", "Python connection manager output path (default: connection_manager.py next to the Python client)"},
+	{"py-devices", "Python multi-device manager output path"},
+	{"py-scanner", "Python scan helper output path"},
+	{"py-mock", "Python mock client output path"},
+	{"py-bleak", "Python bleak transport client output path (default: bleak_client.py next to the Python client)"},
+	{"py-cli", "Python blerpc-cli command line tool output path (default: cli.py next to the Python client)"},
+	{"docs", "Markdown API reference output path (default: docs/api.md under -root)"},
+	{"openrpc", "OpenRPC description of the commands output path (default: openrpc.json next to the API reference)"},
+	{"docs-html", "HTML API explorer output path (default: api.html next to the API reference)"},
+	{"airtime", "airtime and energy budget report output path (default: airtime.md next to the API reference)"},
+	{"kt-client", "Kotlin client output path"},
+	{"kt-resume", "Kotlin resuming client wrapper output path"},
+	{"kt-metrics", "Kotlin interceptor and metrics wrapper output path (default: InstrumentedClient.kt next to the Kotlin client)"},
+	{"kt-cache", "Kotlin caching client wrapper output path (default: CachingClient.kt next to the Kotlin client)"},
+	{"kt-priority", "Kotlin priority client wrapper output path (default: PriorityClient.kt next to the Kotlin client)"},
+	{"kt-redaction", "Kotlin sensitive field redaction helpers output path (default: Redaction.kt next to the Kotlin client)"},
+	{"kt-connmgr", "Kotlin connection manager output path (default: ConnectionManager.kt next to the Kotlin client)"},
+	{"kt-queue", "Kotlin offline queue output path"},
+	{"kt-scanner", "Kotlin scan helper output path"},
+	{"kt-permissions", "Kotlin runtime permission helper output path"},
+	{"kt-mock", "Kotlin mock client output path"},
+	{"kt-gatt-client", "Kotlin Android GATT transport output path, with kotlin_gatt_client: true (default: GattClient.kt next to the Kotlin client)"},
+	{"swift-client", "Swift client output path"},
+	{"swift-resume", "Swift resuming client wrapper output path"},
+	{"swift-metrics", "Swift interceptor and metrics wrapper output path (default: InstrumentedClient.swift next to the Swift client)"},
+	{"swift-cache", "Swift caching client wrapper output path (default: CachingClient.swift next to the Swift client)"},
+	{"swift-priority", "Swift priority client wrapper output path (default: PriorityClient.swift next to the Swift client)"},
+	{"swift-redaction", "Swift sensitive field redaction helpers output path (default: Redaction.swift next to the Swift client)"},
+	{"swift-connmgr", "Swift connection manager output path (default: ConnectionManager.swift next to the Swift client)"},
+	{"swift-queue", "Swift offline queue output path"},
+	{"swift-scanner", "Swift scan helper output path"},
+	{"swift-authorization", "Swift Bluetooth authorization state helper output path"},
+	{"swift-mock", "Swift mock client output path"},
+	{"swift-objc-client", "Swift Objective-C client wrapper output path, with swift_objc_client: true (default: ObjCClient.swift next to the Swift client)"},
+	{"swift-package", "directory for a SwiftPM package of the Swift client, with a public API, a transport protocol and the protos (disabled if empty)"},
+	{"dart-client", "Dart client output path"},
+	{"ts-client", "TypeScript client output path"},
+	{"ts-web", "TypeScript Web Bluetooth client output path, next to -out-ts-client (disabled if empty)"},
+	{"ts-node", "TypeScript Node (noble) client output path, next to -out-ts-client (disabled if empty)"},
+	{"c-client-header", "C client header output path"},
+	{"c-client-source", "C client source output path"},
+	{"go-tui", "Go terminal UI client output path (disabled if empty)"},
+	{"cpp-header", "EmbeddedProto C++ handler header output path (disabled if empty)"},
+	{"cpp-source", "EmbeddedProto C++ handler source output path (default: generated_handlers.cpp next to -out-cpp-header)"},
+	{"cpp-client", "C++17 protobuf host client header output path (disabled if empty)"},
+	{"csharp-client", "C# .NET client output path (disabled if empty)"},
+	{"rs-handlers", "Rust no_std peripheral handler module output path (disabled if empty)"},
+	{"go-wire", "Go command table for the shared wire package output path (disabled if empty)"},
+	{"go-client", "typed Go client output path (disabled if empty)"},
+	{"go-capture", "Go traffic recorder and replay output path, with capture: true (default: capture.go next to -out-go-client)"},
+	{"go-faults", "Go fault-injection transport output path, with fault_injection: true (default: faults.go next to -out-go-client)"},
+	{"go-devices", "Go multi-device manager output path (default: device_manager.go next to -out-go-client)"},
+	{"go-errors", "Go client error types output path (default: errors.go next to -out-go-client, else disabled)"},
+	{"py-tests", "Python pytest suite calling every command of the Python client against the Python handlers over an in-process link output path (disabled if empty)"},
+	{"go-sim", "Go peripheral simulator serving the commands over TCP or a Unix socket output path (disabled if empty)"},
+	{"go-bench", "Go benchmark main package measuring latency and throughput per MTU against the simulator of -go-sim-import output path (disabled if empty)"},
+	{"go-gateway", "Go gRPC and JSON/HTTP gateway forwarding the commands through the Go client output path (disabled if empty)"},
+	{"go-mqtt", "Go MQTT bridge answering command requests on MQTT topics through the Go client and publishing events output path (disabled if empty)"},
+	{"go-files", "Go file_transfer helper output path, in the package of -out-go-errors (disabled if empty)"},
+	{"go-dfu", "Go dfu firmware update helper output path, in the package of -out-go-errors (disabled if empty)"},
+	{"conformance", "directory for cross-language conformance vectors and the C loopback; the other clients get a loopback client next to their mock client (disabled if empty)"},
+	{"fixtures", "directory for sample textproto request fixtures (disabled if empty)"},
+	{"c-user-handlers", "C user handler file path (-scaffold, user_files)"},
+	{"py-user-handlers", "Python user handler file path (-scaffold, user_files)"},
+	{"c-tests", "directory for Unity test skeletons of the C handlers (-scaffold; disabled if empty)"},
+	{"gatt-service-header", "RPC GATT service header output path (-platform; default: generated_gatt_service.h next to the C handlers)"},
+	{"gatt-service-source", "RPC GATT service source output path (-platform; default: generated_gatt_service.c next to the C handlers)"},
+	{"gatt-header", "characteristic-per-command GATT service header output path (-gatt per-command)"},
+	{"gatt-source", "characteristic-per-command GATT service source output path (-gatt per-command)"},
+	{"adv-c-header", "advertising encoder C header output path"},
+	{"adv-c-source", "advertising encoder C source output path"},
+	{"adv-py", "Python advertising parser output path"},
+	{"adv-kt", "Kotlin advertising parser output path"},
+	{"adv-swift", "Swift advertising parser output path"},
+	{"adv-go", "Go advertising parser output path (disabled if empty)"},
+	{"events-c-header", "event notify helper C header output path"},
+	{"events-c-source", "event notify helper C source output path"},
+	{"events-py", "Python event subscription output path"},
+	{"events-kt", "Kotlin event subscription output path"},
+	{"events-swift", "Swift event subscription output path"},
+	{"framing-c-header", "C framing layer header output path (framing in blerpc.yaml)"},
+	{"framing-c-source", "C framing layer source output path (framing in blerpc.yaml)"},
+	{"framing-py", "Python framing layer output path (framing in blerpc.yaml)"},
+	{"framing-kt", "Kotlin framing layer output path (framing in blerpc.yaml)"},
+	{"framing-swift", "Swift framing layer output path (framing in blerpc.yaml)"},
+	{"batch-c-source", "C batch handler output path (batch in blerpc.yaml)"},
+	{"batch-py", "Python batch builder output path (batch in blerpc.yaml)"},
+	{"batch-kt", "Kotlin batch builder output path (batch in blerpc.yaml)"},
+	{"batch-swift", "Swift batch builder output path (batch in blerpc.yaml)"},
+	{"compression-c-source", "C compression envelope handler output path (compressed commands)"},
+	{"uuids-py", "Python GATT UUID constants output path (default: generated_uuids.py next to the Python client)"},
+	{"uuids-kt", "Kotlin GATT UUID constants output path (default: GeneratedUuids.kt next to the Kotlin client)"},
+	{"uuids-swift", "Swift GATT UUID constants output path (default: GeneratedUUIDs.swift next to the Swift client)"},
+	{"uuids-dart", "Dart GATT UUID constants output path (default: generated_uuids.dart next to the Dart client)"},
+	{"uuids-ts", "TypeScript GATT UUID constants output path (default: GeneratedUuids.ts next to the TypeScript client)"},
+	{"uuids-c", "C client GATT UUID header output path (default: generated_uuids.h next to the C client header)"},
+	{"c-cmake", "Zephyr CMake fragment listing the generated peripheral sources; other build fragments go to the same directory"},
+	{"c-kconfig", "Zephyr Kconfig fragment with the command group options"},
+	{"c-client-cmake", "Zephyr CMake fragment listing the generated C client sources; other build fragments go to the same directory"},
+	{"c-client-kconfig", "Zephyr Kconfig fragment with the C client buffer size"},
+	{"builtin-proto", "proto of the built-in commands enabled in blerpc.yaml (default: blerpc_builtin.proto next to -proto)"},
+	{"fuzz", "directory for fuzz dictionary, corpus seeds and the libFuzzer target of the C handlers (disabled if empty)"},
+	{"c-services-header", "C header looking a command up in the handler tables of every proto, with protos and merge_handlers in blerpc.yaml (default: generated_services.h in peripheral_fw/src)"},
+}
+
+// isOutput reports whether name is an -out-* flag without out-.
+func isOutput(name string) bool {
+	return slices.ContainsFunc(outputFlags, func(f struct{ name, usage string }) bool { return f.name == name })
+}
+
+// out returns the path of the -out-<name> flag, "" when unset.
+func (o *Options) out(name string) string {
+	if !isOutput(name) {
+		panic("unknown output " + name)
+	}
+	return o.Outputs[name]
+}
+
+// clone returns a copy of o that a generation may change, such as by
+// applying the outputs: section of blerpc.yaml.
+func (o *Options) clone() *Options {
+	c := *o
+	c.ProtoPath = slices.Clone(o.ProtoPath)
+	c.Outputs = maps.Clone(o.Outputs)
+	if c.Outputs == nil {
+		c.Outputs = make(map[string]string)
+	}
+	return &c
+}
+
+// rootPath resolves path, relative to the root unless absolute.
+func (o *Options) rootPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(o.Root, path)
+}
+
+// defaultPath returns the default output path elem under the root. With a
+// namespace the file goes in a directory named after it, e.g.
+// peripheral_fw/src/sensor/generated_handlers.h.
+func (o *Options) defaultPath(elem ...string) string {
+	dir, file := elem[:len(elem)-1], elem[len(elem)-1]
+	return filepath.Join(o.Root, filepath.Join(dir...), o.Namespace, file)
+}
+
+// namespaced returns the file name prefixed with the namespace, e.g.
+// sensor_command_ids.lock, for files sharing a directory with those of the
+// other protos.
+func (o *Options) namespaced(file string) string {
+	if o.Namespace == "" {
+		return file
+	}
+	return o.Namespace + "_" + file
+}
+
+// applyOutputPaths sets the outputs of cfg that no -out-* flag sets,
+// resolving relative paths against the root.
+func (o *Options) applyOutputPaths(cfg *Config) {
+	for key, path := range cfg.Outputs {
+		if _, ok := o.Outputs[key]; !ok {
+			o.Outputs[key] = o.rootPath(path)
+		}
+	}
+}
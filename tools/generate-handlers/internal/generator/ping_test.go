@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func TestBuiltinPing(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n", "ping")
+	if len(commands) != 1 || commands[0].Snake != "ping" {
+		t.Fatalf("got %+v", commands)
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_PING_PAYLOAD_SIZE 64",
+		"uint32_t blerpc_ping_uptime_ms(void);",
+		"int8_t blerpc_ping_rssi(void);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"req.payload.funcs.decode = decode_ping_payload;",
+		"        ping_state.rssi = blerpc_ping_rssi();",
+		"resp.payload.funcs.encode = encode_ping_payload;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import time\n",
+			"async def measure_latency(self):",
+			"async def keepalive(self, interval=5.0, *, on_latency=None):",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import kotlinx.coroutines.delay\n",
+			"suspend fun keepalive(intervalMs: Long = 5000, onLatency: (Long) -> Unit = {}) {",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"func measureLatency() async throws -> TimeInterval {",
+			"try await Task.sleep(nanoseconds: UInt64(interval * 1_000_000_000))",
+		}},
+		{"dart", dart.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"Future<Duration> measureLatency() async {",
+		}},
+		{"ts", typescript.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"onLatency?.(await this.measureLatency());",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,483 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// isProtocPlugin reports whether the generator was started by protoc or buf:
+// as protoc-gen-<name>, or with the plugin argument.
+func isProtocPlugin(args []string) bool {
+	if strings.HasPrefix(filepath.Base(args[0]), "protoc-gen-") {
+		return true
+	}
+	return len(args) > 1 && args[1] == "plugin"
+}
+
+// runPlugin answers the CodeGeneratorRequest read from r on w.
+func runPlugin(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read request: %w", err)
+	}
+	req := &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("parse request: %w", err)
+	}
+	if err := flagsFromParameter(req.GetParameter()); err != nil {
+		return err
+	}
+	if cliOptions.scaffold {
+		return fmt.Errorf("-scaffold edits the user handlers in place and is not available as a plugin")
+	}
+	if *checkFlag {
+		return fmt.Errorf("-check reads the files on disk and is not available as a plugin")
+	}
+	if len(req.GetFileToGenerate()) != 1 {
+		return fmt.Errorf("want exactly one file to generate, got %d", len(req.GetFileToGenerate()))
+	}
+	protoFile, err := protoFileFromRequest(req)
+	if err != nil {
+		return err
+	}
+	protoPath := flagOrDefault(cliOptions.Proto, filepath.Join(cliOptions.Root, "proto", req.GetFileToGenerate()[0]))
+
+	resp := &pluginpb.CodeGeneratorResponse{
+		SupportedFeatures: proto.Uint64(uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)),
+	}
+	outputs, warnings, err := generate(context.Background(), cliOptions, protomodel.OS, protoFile, protoPath, io.Discard)
+	reportGeneration(warnings, err)
+	for _, out := range outputs {
+		rel, err := filepath.Rel(cliOptions.Root, out.Path)
+		if err != nil || !filepath.IsLocal(rel) {
+			return fmt.Errorf("output %s is outside the output directory", out.Path)
+		}
+		resp.File = append(resp.File, &pluginpb.CodeGeneratorResponse_File{
+			Name:    proto.String(filepath.ToSlash(rel)),
+			Content: proto.String(out.Content),
+		})
+	}
+	data, err = proto.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encode response: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// flagsFromParameter sets the flags named in a plugin parameter such as
+// "split=per-group,c-runtime=protobuf-c". A name without a value sets a
+// boolean flag.
+func flagsFromParameter(param string) error {
+	var args []string
+	for _, kv := range strings.Split(param, ",") {
+		if kv = strings.TrimSpace(kv); kv != "" {
+			args = append(args, "-"+kv)
+		}
+	}
+	if err := commandLine.Parse(args); err != nil {
+		return fmt.Errorf("parameter: %w", err)
+	}
+	if commandLine.NArg() > 0 {
+		return fmt.Errorf("parameter: unexpected %q", commandLine.Arg(0))
+	}
+	return nil
+}
+
+// protoFileFromRequest converts the file to generate and the files it
+// imports to one ProtoFile, as protomodel.ParseWithImports does for proto sources.
+func protoFileFromRequest(req *pluginpb.CodeGeneratorRequest) (*gen.ProtoFile, error) {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, fd := range req.GetProtoFile() {
+		byName[fd.GetName()] = fd
+	}
+	target, ok := byName[req.GetFileToGenerate()[0]]
+	if !ok {
+		return nil, fmt.Errorf("no descriptor for %s", req.GetFileToGenerate()[0])
+	}
+	pkg := target.GetPackage()
+
+	// The target file first, then its imports depth first, each once.
+	// Imported files that define types must share the package, as in
+	// protomodel.ParseWithImports; google/protobuf files only provide options and
+	// well-known types.
+	files := []*descriptorpb.FileDescriptorProto{target}
+	visited := map[string]bool{target.GetName(): true}
+	var visit func(fd *descriptorpb.FileDescriptorProto) error
+	visit = func(fd *descriptorpb.FileDescriptorProto) error {
+		for _, dep := range fd.GetDependency() {
+			imp, ok := byName[dep]
+			if !ok || visited[dep] || strings.HasPrefix(dep, "google/protobuf/") {
+				continue
+			}
+			visited[dep] = true
+			if imp.GetPackage() != pkg {
+				if len(imp.GetMessageType()) > 0 || len(imp.GetEnumType()) > 0 {
+					return fmt.Errorf("import %s: package %q differs from %q of %s", dep, imp.GetPackage(), pkg, target.GetName())
+				}
+				continue
+			}
+			files = append(files, imp)
+			if err := visit(imp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(target); err != nil {
+		return nil, err
+	}
+
+	d := &descriptorSchema{
+		pkg:        pkg,
+		options:    make(map[string]map[int32]extOption),
+		enumValues: make(map[string]map[int32]string),
+		msgFile:    make(map[string]string),
+		entries:    make(map[string]*descriptorpb.DescriptorProto),
+	}
+	for _, fd := range req.GetProtoFile() {
+		d.collectTypes(fd)
+	}
+	for i, fd := range files {
+		file := ""
+		if i > 0 {
+			file = strings.TrimSuffix(fd.GetName(), ".proto")
+		}
+		for _, msg := range fd.GetMessageType() {
+			d.collectMessages(msg, "", file)
+		}
+	}
+
+	pf := &gen.ProtoFile{Syntax: target.GetSyntax(), Package: pkg, Imports: target.GetDependency()}
+	if pf.Syntax == "" {
+		pf.Syntax = "proto2"
+	}
+	for _, fd := range files {
+		for _, e := range fd.GetEnumType() {
+			pf.Enums = append(pf.Enums, convertEnum(e))
+		}
+		for _, msg := range fd.GetMessageType() {
+			for _, e := range msg.GetEnumType() {
+				pf.Enums = append(pf.Enums, convertEnum(e))
+			}
+		}
+	}
+	for i, fd := range files {
+		file := ""
+		if i > 0 {
+			file = strings.TrimSuffix(fd.GetName(), ".proto")
+		}
+		for _, msg := range fd.GetMessageType() {
+			pf.Messages = append(pf.Messages, d.convertMessage(msg, file, fd.GetSyntax() == "proto3", pf.Enums))
+		}
+	}
+	for _, fd := range files {
+		for _, svc := range fd.GetService() {
+			s := gen.Service{Name: svc.GetName()}
+			for _, m := range svc.GetMethod() {
+				s.RPCs = append(s.RPCs, gen.ServiceRPC{
+					Name:         m.GetName(),
+					RequestType:  protomodel.LocalTypeName(m.GetInputType(), pkg),
+					ResponseType: protomodel.LocalTypeName(m.GetOutputType(), pkg),
+					ClientStream: m.GetClientStreaming(),
+					ServerStream: m.GetServerStreaming(),
+					Options:      d.methodOptions(m.GetOptions()),
+				})
+			}
+			pf.Services = append(pf.Services, s)
+		}
+	}
+	return pf, nil
+}
+
+// extOption is a custom option declared with extend.
+type extOption struct {
+	name     string // option name without parentheses, e.g. blerpc.wire_name
+	typ      descriptorpb.FieldDescriptorProto_Type
+	typeName string // enum of enum-typed options
+}
+
+// descriptorSchema holds what the conversion of descriptors looks up across
+// all files of a request.
+type descriptorSchema struct {
+	pkg        string
+	options    map[string]map[int32]extOption           // extendee -> field number -> option
+	enumValues map[string]map[int32]string              // fully-qualified enum -> number -> value name
+	msgFile    map[string]string                        // local message name -> imported file defining it
+	entries    map[string]*descriptorpb.DescriptorProto // fully-qualified map entry messages
+}
+
+// collectTypes records the custom options and enums of a file.
+func (d *descriptorSchema) collectTypes(fd *descriptorpb.FileDescriptorProto) {
+	prefix := "."
+	if fd.GetPackage() != "" {
+		prefix += fd.GetPackage() + "."
+	}
+	for _, ext := range fd.GetExtension() {
+		extendee := ext.GetExtendee()
+		if d.options[extendee] == nil {
+			d.options[extendee] = make(map[int32]extOption)
+		}
+		d.options[extendee][ext.GetNumber()] = extOption{
+			name:     strings.TrimPrefix(prefix, ".") + ext.GetName(),
+			typ:      ext.GetType(),
+			typeName: ext.GetTypeName(),
+		}
+	}
+	var enums func(scope string, list []*descriptorpb.EnumDescriptorProto, msgs []*descriptorpb.DescriptorProto)
+	enums = func(scope string, list []*descriptorpb.EnumDescriptorProto, msgs []*descriptorpb.DescriptorProto) {
+		for _, e := range list {
+			values := make(map[int32]string)
+			for _, v := range e.GetValue() {
+				values[v.GetNumber()] = v.GetName()
+			}
+			d.enumValues[scope+e.GetName()] = values
+		}
+		for _, m := range msgs {
+			enums(scope+m.GetName()+".", m.GetEnumType(), m.GetNestedType())
+		}
+	}
+	enums(prefix, fd.GetEnumType(), fd.GetMessageType())
+}
+
+// collectMessages records the file defining msg and its nested messages, and
+// the map entries among them.
+func (d *descriptorSchema) collectMessages(msg *descriptorpb.DescriptorProto, scope, file string) {
+	name := scope + msg.GetName()
+	if msg.GetOptions().GetMapEntry() {
+		d.entries["."+d.pkg+"."+name] = msg
+		return
+	}
+	d.msgFile[name] = file
+	for _, nested := range msg.GetNestedType() {
+		d.collectMessages(nested, name+".", file)
+	}
+}
+
+func convertEnum(e *descriptorpb.EnumDescriptorProto) gen.Enum {
+	en := gen.Enum{Name: e.GetName()}
+	for _, v := range e.GetValue() {
+		en.Values = append(en.Values, gen.EnumValue{Name: v.GetName(), Number: int(v.GetNumber())})
+	}
+	return en
+}
+
+// convertMessage converts a top-level message. proto3 fields are optional
+// only with the explicit label.
+func (d *descriptorSchema) convertMessage(msg *descriptorpb.DescriptorProto, file string, proto3 bool, enums []gen.Enum) gen.Message {
+	m := gen.Message{Name: msg.GetName(), File: file, Options: d.messageOptions(msg.GetOptions())}
+	oneofs := make([]*gen.OneofGroup, len(msg.GetOneofDecl()))
+	for _, f := range msg.GetField() {
+		if entry, ok := d.entries[f.GetTypeName()]; ok && f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+			key, value := entry.GetField()[0], entry.GetField()[1]
+			field := gen.Field{
+				Name:       f.GetName(),
+				Number:     int(f.GetNumber()),
+				IsMap:      true,
+				KeyType:    d.fieldType(key),
+				ValueType:  d.fieldType(value),
+				Deprecated: f.GetOptions().GetDeprecated(),
+				Sensitive:  d.fieldSensitive(f.GetOptions()),
+			}
+			if value.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+				field.ValueIsMessage = d.isLocalMessage(field.ValueType)
+				field.TypeFile = d.msgFile[field.ValueType]
+			}
+			m.Fields = append(m.Fields, field)
+			continue
+		}
+		typ := d.fieldType(f)
+		field := gen.Field{
+			Type:       typ,
+			Name:       f.GetName(),
+			Number:     int(f.GetNumber()),
+			IsEnum:     f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM,
+			IsRepeated: f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED,
+			IsMessage:  d.isLocalMessage(typ) || protomodel.IsWellKnownType(typ),
+			IsRequired: f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED,
+			Deprecated: f.GetOptions().GetDeprecated(),
+			Sensitive:  d.fieldSensitive(f.GetOptions()),
+			TypeFile:   d.msgFile[typ],
+		}
+		if f.OneofIndex != nil && !f.GetProto3Optional() {
+			field.Oneof = msg.GetOneofDecl()[f.GetOneofIndex()].GetName()
+			i := f.GetOneofIndex()
+			if oneofs[i] == nil {
+				oneofs[i] = &gen.OneofGroup{Name: field.Oneof}
+			}
+			oneofs[i].Fields = append(oneofs[i].Fields, field)
+		} else if f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL {
+			field.IsOptional = !proto3 || f.GetProto3Optional()
+			field.Default = descriptorDefault(f, typ, enums)
+		}
+		m.Fields = append(m.Fields, field)
+	}
+	for _, og := range oneofs {
+		if og != nil {
+			m.Oneofs = append(m.Oneofs, *og)
+		}
+	}
+	return m
+}
+
+// fieldType returns the type name a proto source would use for a field:
+// the scalar keyword, the message qualified from the package (Outer.Inner),
+// or the bare enum name.
+func (d *descriptorSchema) fieldType(f *descriptorpb.FieldDescriptorProto) string {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return protomodel.LocalTypeName(f.GetTypeName(), d.pkg)
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		name := f.GetTypeName()
+		return name[strings.LastIndex(name, ".")+1:]
+	}
+	return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+}
+
+func (d *descriptorSchema) isLocalMessage(name string) bool {
+	_, ok := d.msgFile[name]
+	return ok
+}
+
+// descriptorDefault returns a proto2 default in the form of a proto source
+// constant: quoted strings and bytes, and enum values by number.
+func descriptorDefault(f *descriptorpb.FieldDescriptorProto, typ string, enums []gen.Enum) string {
+	if f.DefaultValue == nil {
+		return ""
+	}
+	v := f.GetDefaultValue()
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return strconv.Quote(v)
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return `"` + v + `"` // protoc escapes bytes defaults C-style already
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		for _, en := range enums {
+			if en.Name != typ {
+				continue
+			}
+			for _, ev := range en.Values {
+				if ev.Name == v {
+					return strconv.Itoa(ev.Number)
+				}
+			}
+		}
+	}
+	return v
+}
+
+func (d *descriptorSchema) messageOptions(opts *descriptorpb.MessageOptions) map[string]string {
+	if opts == nil {
+		return nil
+	}
+	m := d.customOptions(".google.protobuf.MessageOptions", opts.ProtoReflect().GetUnknown())
+	if opts.GetDeprecated() {
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m["deprecated"] = "true"
+	}
+	return m
+}
+
+func (d *descriptorSchema) methodOptions(opts *descriptorpb.MethodOptions) map[string]string {
+	if opts == nil {
+		return nil
+	}
+	m := d.customOptions(".google.protobuf.MethodOptions", opts.ProtoReflect().GetUnknown())
+	if opts.IdempotencyLevel != nil {
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m["idempotency_level"] = opts.GetIdempotencyLevel().String()
+	}
+	if opts.GetDeprecated() {
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m["deprecated"] = "true"
+	}
+	return m
+}
+
+// fieldSensitive reports whether a field sets (blerpc.sensitive) = true.
+func (d *descriptorSchema) fieldSensitive(opts *descriptorpb.FieldOptions) bool {
+	if opts == nil {
+		return false
+	}
+	return d.customOptions(".google.protobuf.FieldOptions", opts.ProtoReflect().GetUnknown())["blerpc.sensitive"] == "true"
+}
+
+// customOptions returns the extension options encoded in raw, the unknown
+// fields of an options message, formatted like protomodel.OptionMap formats source
+// constants. Unknown fields that are not declared options are ignored.
+func (d *descriptorSchema) customOptions(extendee string, raw []byte) map[string]string {
+	var m map[string]string
+	set := func(name, value string) {
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[name] = value
+	}
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			break
+		}
+		raw = raw[n:]
+		opt, declared := d.options[extendee][int32(num)]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(raw)
+			if n < 0 {
+				return m
+			}
+			raw = raw[n:]
+			if !declared {
+				continue
+			}
+			switch opt.typ {
+			case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+				set(opt.name, strconv.FormatBool(v != 0))
+			case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+				set(opt.name, d.enumValues[opt.typeName][int32(v)])
+			case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_INT64:
+				set(opt.name, strconv.FormatInt(int64(v), 10))
+			case descriptorpb.FieldDescriptorProto_TYPE_SINT32, descriptorpb.FieldDescriptorProto_TYPE_SINT64:
+				set(opt.name, strconv.FormatInt(protowire.DecodeZigZag(v), 10))
+			default:
+				set(opt.name, strconv.FormatUint(v, 10))
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(raw)
+			if n < 0 {
+				return m
+			}
+			raw = raw[n:]
+			if declared && slices.Contains([]descriptorpb.FieldDescriptorProto_Type{
+				descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+			}, opt.typ) {
+				set(opt.name, string(v))
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, raw)
+			if n < 0 {
+				return m
+			}
+			raw = raw[n:]
+		}
+	}
+	return m
+}
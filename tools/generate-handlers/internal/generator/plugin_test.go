@@ -0,0 +1,274 @@
+package generator
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// pluginSource is the proto source pluginRequest describes.
+const pluginSource = `syntax = "proto3";
+
+package blerpc;
+
+import "blerpc_options.proto";
+
+message EchoRequest {
+  string message = 1;
+  optional uint32 repeat = 2;
+  map<string, Item> tags = 3;
+  oneof target {
+    uint32 slot = 4;
+    Item item = 5;
+  }
+}
+
+message EchoResponse {
+  option (blerpc.event) = false;
+  string message = 1;
+  repeated Item items = 2;
+  Level level = 3;
+}
+
+message Item {
+  string name = 1 [(blerpc.sensitive) = true];
+}
+
+enum Level {
+  LOW = 0;
+  HIGH = 1;
+}
+
+service Device {
+  rpc Echo(EchoRequest) returns (EchoResponse) {
+    option (blerpc.wire_name) = "e";
+    option idempotency_level = IDEMPOTENT;
+  }
+  rpc Watch(EchoRequest) returns (stream EchoResponse);
+}
+`
+
+// pluginRequest returns the CodeGeneratorRequest protoc sends for
+// pluginSource, built by hand as the tests do not run protoc.
+func pluginRequest(parameter string) *pluginpb.CodeGeneratorRequest {
+	typ := func(t descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto_Type { return &t }
+	label := func(l descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto_Label { return &l }
+	field := func(name string, num int32, t descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name: proto.String(name), Number: proto.Int32(num),
+			Type: typ(t), Label: label(descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		tString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		tUint32  = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+		tBool    = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+		tMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+		tEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+	)
+
+	options := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("blerpc_options.proto"),
+		Package:    proto.String("blerpc"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Syntax:     proto.String("proto3"),
+		Extension: []*descriptorpb.FieldDescriptorProto{
+			{Name: proto.String("wire_name"), Number: proto.Int32(50001), Type: typ(tString), Extendee: proto.String(".google.protobuf.MethodOptions")},
+			{Name: proto.String("event"), Number: proto.Int32(50104), Type: typ(tBool), Extendee: proto.String(".google.protobuf.MessageOptions")},
+			{Name: proto.String("sensitive"), Number: proto.Int32(50201), Type: typ(tBool), Extendee: proto.String(".google.protobuf.FieldOptions")},
+		},
+	}
+
+	repeat := field("repeat", 2, tUint32, "")
+	repeat.Proto3Optional = proto.Bool(true)
+	repeat.OneofIndex = proto.Int32(1)
+	tags := field("tags", 3, tMessage, ".blerpc.EchoRequest.TagsEntry")
+	tags.Label = label(descriptorpb.FieldDescriptorProto_LABEL_REPEATED)
+	slot, item := field("slot", 4, tUint32, ""), field("item", 5, tMessage, ".blerpc.Item")
+	slot.OneofIndex, item.OneofIndex = proto.Int32(0), proto.Int32(0)
+	items := field("items", 2, tMessage, ".blerpc.Item")
+	items.Label = label(descriptorpb.FieldDescriptorProto_LABEL_REPEATED)
+
+	var notEvent []byte
+	notEvent = protowire.AppendTag(notEvent, 50104, protowire.VarintType)
+	notEvent = protowire.AppendVarint(notEvent, 0)
+	responseOpts := &descriptorpb.MessageOptions{}
+	responseOpts.ProtoReflect().SetUnknown(notEvent)
+
+	var sensitive []byte
+	sensitive = protowire.AppendTag(sensitive, 50201, protowire.VarintType)
+	sensitive = protowire.AppendVarint(sensitive, 1)
+	itemName := field("name", 1, tString, "")
+	itemName.Options = &descriptorpb.FieldOptions{}
+	itemName.Options.ProtoReflect().SetUnknown(sensitive)
+
+	var wire []byte
+	wire = protowire.AppendTag(wire, 50001, protowire.BytesType)
+	wire = protowire.AppendString(wire, "e")
+	echoOpts := &descriptorpb.MethodOptions{IdempotencyLevel: descriptorpb.MethodOptions_IDEMPOTENT.Enum()}
+	echoOpts.ProtoReflect().SetUnknown(wire)
+
+	schema := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("blerpc.proto"),
+		Package:    proto.String("blerpc"),
+		Dependency: []string{"blerpc_options.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("EchoRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("message", 1, tString, ""), repeat, tags, slot, item},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name:    proto.String("TagsEntry"),
+					Field:   []*descriptorpb.FieldDescriptorProto{field("key", 1, tString, ""), field("value", 2, tMessage, ".blerpc.Item")},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("target")}, {Name: proto.String("_repeat")}},
+			},
+			{
+				Name:    proto.String("EchoResponse"),
+				Field:   []*descriptorpb.FieldDescriptorProto{field("message", 1, tString, ""), items, field("level", 3, tEnum, ".blerpc.Level")},
+				Options: responseOpts,
+			},
+			{Name: proto.String("Item"), Field: []*descriptorpb.FieldDescriptorProto{itemName}},
+		},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Level"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("LOW"), Number: proto.Int32(0)},
+				{Name: proto.String("HIGH"), Number: proto.Int32(1)},
+			},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Device"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Echo"), InputType: proto.String(".blerpc.EchoRequest"), OutputType: proto.String(".blerpc.EchoResponse"), Options: echoOpts},
+				{Name: proto.String("Watch"), InputType: proto.String(".blerpc.EchoRequest"), OutputType: proto.String(".blerpc.EchoResponse"), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}
+
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"blerpc.proto"},
+		Parameter:      proto.String(parameter),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			{Name: proto.String("google/protobuf/descriptor.proto"), Package: proto.String("google.protobuf")},
+			options,
+			schema,
+		},
+	}
+}
+
+func TestProtoFileFromRequest(t *testing.T) {
+	got, err := protoFileFromRequest(pluginRequest(""))
+	if err != nil {
+		t.Fatalf("protoFileFromRequest: %v", err)
+	}
+	want, err := protomodel.ParseReader(strings.NewReader(pluginSource))
+	if err != nil {
+		t.Fatalf("protomodel.ParseReader: %v", err)
+	}
+	// Both paths must yield the same schema model, except for the source
+	// positions descriptors do not carry.
+	for i := range want.Messages {
+		m := &want.Messages[i]
+		m.Pos = protomodel.Position{}
+		for j := range m.Fields {
+			m.Fields[j].Pos = protomodel.Position{}
+		}
+		for j := range m.Oneofs {
+			for k := range m.Oneofs[j].Fields {
+				m.Oneofs[j].Fields[k].Pos = protomodel.Position{}
+			}
+		}
+	}
+	for i := range want.Services {
+		for j := range want.Services[i].RPCs {
+			want.Services[i].RPCs[j].Pos = protomodel.Position{}
+		}
+	}
+	if !reflect.DeepEqual(got.Messages, want.Messages) {
+		t.Errorf("messages differ from the proto source:\ngot  %+v\nwant %+v", got.Messages, want.Messages)
+	}
+	if !reflect.DeepEqual(got.Services, want.Services) {
+		t.Errorf("services differ from the proto source:\ngot  %+v\nwant %+v", got.Services, want.Services)
+	}
+	if !reflect.DeepEqual(got.Enums, want.Enums) {
+		t.Errorf("enums differ from the proto source:\ngot  %+v\nwant %+v", got.Enums, want.Enums)
+	}
+	if got.Package != "blerpc" || got.Syntax != "proto3" {
+		t.Errorf("unexpected package/syntax %q/%q", got.Package, got.Syntax)
+	}
+
+	req := pluginRequest("")
+	req.ProtoFile[1].MessageType = []*descriptorpb.DescriptorProto{{Name: proto.String("Other")}}
+	req.ProtoFile[1].Package = proto.String("other")
+	if _, err := protoFileFromRequest(req); err == nil || !strings.Contains(err.Error(), `package "other" differs`) {
+		t.Errorf("expected a package mismatch error, got %v", err)
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	data, err := proto.Marshal(pluginRequest("root=" + t.TempDir() + ",python=,split=per-group"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := runPlugin(bytes.NewReader(data), &out); err != nil {
+		t.Fatalf("runPlugin: %v", err)
+	}
+	resp := &pluginpb.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(out.Bytes(), resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("plugin error: %s", resp.GetError())
+	}
+	files := make(map[string]string)
+	for _, f := range resp.GetFile() {
+		files[f.GetName()] = f.GetContent()
+	}
+	for _, name := range []string{
+		"peripheral_fw/src/generated_handlers.h",
+		"peripheral_fw/src/generated_handlers_device.c",
+		"central_py/blerpc/generated/generated_client/_base.py",
+		"central_android/app/src/main/java/com/blerpc/android/client/GeneratedClient.kt",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("response missing %s", name)
+		}
+	}
+	if !strings.Contains(files["peripheral_fw/src/generated_handlers.h"], "int handle_echo(") {
+		t.Error("generated header lacks handle_echo")
+	}
+	if resp.GetSupportedFeatures()&uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL) == 0 {
+		t.Error("proto3 optional support not declared")
+	}
+}
+
+func TestIsProtocPlugin(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"/usr/local/bin/protoc-gen-blerpc"}, true},
+		{[]string{"generate-handlers", "plugin"}, true},
+		{[]string{"generate-handlers", "-root", "."}, false},
+		{[]string{"generate-handlers"}, false},
+	}
+	for _, tt := range tests {
+		if got := isProtocPlugin(tt.args); got != tt.want {
+			t.Errorf("isProtocPlugin(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
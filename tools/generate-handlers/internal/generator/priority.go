@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Priority clients wrap a generated client and run the calls made through
+// it one at a time, highest priority first instead of in call order, so an
+// emergency stop does not wait behind a queue of bulk transfers. A call of
+// high priority also cancels the streaming transfer of lower priority in
+// progress, which fails with a preempted error; a unary call in progress
+// always runs to its end. The fixed code is in the *PriorityClient*.tmpl
+// templates.
+//
+// Commands get a priority with
+//
+//	option (blerpc.priority) = "high";
+//
+// or, for schemas discovered by message naming, an entry under priorities
+// in blerpc.yaml. Commands without one run at normal priority.
+
+// PriorityConfig sets the priority of a command in blerpc.yaml.
+type PriorityConfig struct {
+	Command  string `yaml:"command"`
+	Priority string `yaml:"priority"`
+}
+
+// applyPriorities records the priorities of blerpc.yaml and checks those of
+// the (blerpc.priority) options.
+func applyPriorities(commands []gen.Command, cfg *Config) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, p := range cfg.Priorities {
+		idx, ok := bySnake[p.Command]
+		if !ok {
+			return fmt.Errorf("priorities[%d]: unknown command %q", i, p.Command)
+		}
+		if !slices.Contains(gen.PriorityNames, p.Priority) {
+			return fmt.Errorf("priorities[%d]: %s has unknown priority %q (want %s)", i, p.Command, p.Priority, strings.Join(gen.PriorityNames, ", "))
+		}
+		commands[idx].Priority = p.Priority
+	}
+	for _, cmd := range commands {
+		if cmd.Priority != "" && !slices.Contains(gen.PriorityNames, cmd.Priority) {
+			return fmt.Errorf("%s: unknown priority %q (want %s)", cmd.Snake, cmd.Priority, strings.Join(gen.PriorityNames, ", "))
+		}
+	}
+	return nil
+}
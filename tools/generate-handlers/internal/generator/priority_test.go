@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestApplyPriorities(t *testing.T) {
+	optioned := gentest.EchoCommand()
+	optioned.Priority = "urgent"
+	tests := []struct {
+		name       string
+		cmd        gen.Command
+		priorities []PriorityConfig
+		want       string
+	}{
+		{"unknown", gentest.EchoCommand(), []PriorityConfig{{Command: "missing", Priority: "high"}}, `priorities[0]: unknown command "missing"`},
+		{"bad priority", gentest.EchoCommand(), []PriorityConfig{{Command: "echo", Priority: "urgent"}}, `echo has unknown priority "urgent" (want low, normal, high)`},
+		{"bad option", optioned, nil, `echo: unknown priority "urgent"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyPriorities([]gen.Command{tt.cmd}, &Config{Priorities: tt.priorities})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cmds := []gen.Command{gentest.EchoCommand()}
+	if err := applyPriorities(cmds, &Config{Priorities: []PriorityConfig{{Command: "echo", Priority: "high"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].Priority != "high" {
+		t.Errorf("Priority = %q, want high", cmds[0].Priority)
+	}
+}
+
+func TestGeneratePriorityTables(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.Priority = "high"
+	echo.ID = 3
+	normal := gentest.EchoCommand()
+	normal.Camel, normal.Snake, normal.Priority = "Plain", "plain", "normal"
+	stream := gentest.EchoCommand()
+	stream.Camel, stream.Snake, stream.Priority = "Bulk", "bulk", "low"
+	streaming := map[string]string{"bulk": "p2c"}
+
+	py := python.GeneratePriority(gctx, []gen.Command{echo, normal, stream}, streaming)
+	for _, want := range []string{
+		"from .generated_client import CommandId, GeneratedClientMixin, TransportError\n",
+		"    LOW = 0\n    NORMAL = 1\n    HIGH = 2\n",
+		"    \"echo\": Priority.HIGH,\n    CommandId.ECHO.wire_name: Priority.HIGH,\n    \"bulk\": Priority.LOW,\n}\n",
+		"class PriorityClient(GeneratedClientMixin):",
+		"    async def stream_cancel(self) -> None:\n",
+	} {
+		if !strings.Contains(py, want) {
+			t.Errorf("Python priority client missing %q", want)
+		}
+	}
+	if strings.Contains(py, "plain") {
+		t.Error("Python priority client lists a command of normal priority")
+	}
+
+	kt := kotlin.GeneratePriority(gctx, []gen.Command{echo}, "blerpc", nil)
+	if !strings.Contains(kt, "        \"echo\" to Priority.HIGH,\n        CommandId.ECHO.wireName to Priority.HIGH,\n") {
+		t.Errorf("Kotlin priority table wrong:\n%s", kt)
+	}
+	if strings.Contains(kt, "streamCancel") {
+		t.Error("Kotlin priority client forwards streamCancel without P2C streams")
+	}
+
+	swift := swiftgen.GeneratePriority(gctx, nil, nil)
+	for _, want := range []string{
+		"let commandPriorities: [String: Priority] = [:]\n",
+		"let callSerializer = CallSerializer(passThrough: true)\n",
+	} {
+		if !strings.Contains(swift, want) {
+			t.Errorf("Swift priority client missing %q", want)
+		}
+	}
+}
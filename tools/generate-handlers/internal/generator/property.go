@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// With property_accessors: true in blerpc.yaml, a pair of unary commands
+// GetFoo and SetFoo that read and write one scalar, e.g.
+// GetBrightnessResponse { uint32 level = 1; } and
+// SetBrightnessRequest { uint32 level = 1; }, also gets a property-like
+// accessor in the Python, Kotlin and Swift clients, so app code reads
+// await client.brightness instead of unpacking get_brightness. Python gets
+// an awaitable AsyncProperty whose set() writes, Kotlin the suspend
+// overloads brightness() and brightness(value), and Swift an async throwing
+// computed property and setBrightness(_:). The Get request must be empty,
+// and the value the only field of the Get response and of the Set request,
+// with the same type and the restrictions of unwrap_responses.
+
+// propertyField reports whether f can carry the value of a property.
+func propertyField(f gen.Field) bool {
+	return !f.IsRepeated && !f.IsMessage && !f.IsMap && !f.IsEnum && f.Oneof == "" && len(f.TypeOverrides) == 0
+}
+
+// propertyPair reports whether get and set read and write one value.
+func propertyPair(get, set gen.Command, streaming map[string]string) bool {
+	for _, cmd := range []gen.Command{get, set} {
+		if _, ok := streaming[cmd.Snake]; ok || cmd.Builtin != "" {
+			return false
+		}
+	}
+	if len(get.RequestFields) != 0 || len(get.ResponseFields) != 1 || len(set.RequestFields) != 1 {
+		return false
+	}
+	g, s := get.ResponseFields[0], set.RequestFields[0]
+	return propertyField(g) && propertyField(s) && g.Type == s.Type && g.Name != get.StatusField
+}
+
+// applyProperties marks the Get command of each property pair when
+// blerpc.yaml turns property_accessors on, and checks that the properties
+// do not take the name of a command.
+func applyProperties(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	if !cfg.Properties {
+		return nil
+	}
+	names := make(map[string]bool)
+	byCamel := make(map[string]gen.Command)
+	for _, cmd := range commands {
+		names[cmd.Snake] = true
+		byCamel[cmd.Camel] = cmd
+	}
+	for i, cmd := range commands {
+		rest, ok := strings.CutPrefix(cmd.Camel, "Get")
+		if !ok || rest == "" {
+			continue
+		}
+		set, ok := byCamel["Set"+rest]
+		if !ok || !propertyPair(cmd, set, streaming) {
+			continue
+		}
+		name := protomodel.CamelToSnake(rest)
+		if names[name] {
+			return fmt.Errorf("property_accessors: the %s property of %s and %s would take the name of a command", name, cmd.Snake, set.Snake)
+		}
+		commands[i].Property = name
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+// brightnessCommands returns the GetBrightness and SetBrightness commands of
+// a brightness property.
+func brightnessCommands() []gen.Command {
+	return []gen.Command{
+		{
+			Camel: "GetBrightness", Snake: "get_brightness",
+			RequestMsg: "GetBrightnessRequest", ResponseMsg: "GetBrightnessResponse",
+			ResponseFields: []gen.Field{{Type: "uint32", Name: "level", Number: 1}},
+		},
+		{
+			Camel: "SetBrightness", Snake: "set_brightness",
+			RequestMsg: "SetBrightnessRequest", ResponseMsg: "SetBrightnessResponse",
+			RequestFields: []gen.Field{{Type: "uint32", Name: "level", Number: 1}},
+		},
+	}
+}
+
+func TestApplyProperties(t *testing.T) {
+	commands := append(brightnessCommands(), gentest.EchoCommand())
+	if err := applyProperties(commands, &Config{Properties: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if commands[0].Property != "brightness" || commands[1].Property != "" || commands[2].Property != "" {
+		t.Errorf("Property = %q, %q, %q; want only get_brightness", commands[0].Property, commands[1].Property, commands[2].Property)
+	}
+
+	mismatch := brightnessCommands()
+	mismatch[1].RequestFields[0].Type = "string"
+	streamed := brightnessCommands()
+	for name, tc := range map[string]struct {
+		commands  []gen.Command
+		streaming map[string]string
+	}{
+		"type mismatch": {mismatch, nil},
+		"streamed":      {streamed, map[string]string{"get_brightness": "p2c"}},
+		"no setter":     {brightnessCommands()[:1], nil},
+	} {
+		if err := applyProperties(tc.commands, &Config{Properties: true}, tc.streaming); err != nil {
+			t.Fatal(err)
+		}
+		if tc.commands[0].Property != "" {
+			t.Errorf("%s: Property = %q, want none", name, tc.commands[0].Property)
+		}
+	}
+
+	clash := append(brightnessCommands(), gen.Command{Camel: "Brightness", Snake: "brightness"})
+	err := applyProperties(clash, &Config{Properties: true}, nil)
+	if err == nil || !strings.Contains(err.Error(), "brightness property") {
+		t.Errorf("err = %v, want a name clash", err)
+	}
+}
+
+func TestPropertyAccessors(t *testing.T) {
+	gctx := gen.NewContext()
+	commands := brightnessCommands()
+	commands[0].Property = "brightness"
+	for _, tc := range []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"from typing import Any, Generic, Protocol, TypeVar\n",
+			"class AsyncProperty(Generic[_T]):\n",
+			"    @property\n    def brightness(self) -> AsyncProperty[int]:\n",
+			"            return (await self.get_brightness()).level\n",
+			"            await self.set_brightness(level=value)\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    suspend fun brightness(): Int =\n        getBrightness().level\n",
+			"    suspend fun brightness(value: Int) {\n        setBrightness(level = value)\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    var brightness: UInt32 {\n        get async throws { try await getBrightness().level }\n",
+			"    func setBrightness(_ value: UInt32) async throws {\n        _ = try await setBrightness(level: value)\n",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.got, want) {
+				t.Errorf("%s: missing %q", tc.name, want)
+			}
+		}
+	}
+
+	// A target leaving the setter out has no property.
+	if got := python.GenerateClient(gctx, commands[:1], nil, "blerpc"); strings.Contains(got, "AsyncProperty") {
+		t.Error("python: property without its setter")
+	}
+}
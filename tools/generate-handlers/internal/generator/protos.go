@@ -0,0 +1,284 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// A project serving several protos lists them under protos: in blerpc.yaml.
+// Each is generated by a child process running the same command line with
+// -proto, -namespace and, when the entry has one, -config, so the protos
+// never share generator state. The namespace keeps their code apart: it
+// defaults the C prefixes and the Swift type prefix and puts the default
+// output paths in a directory of its own. With merge_handlers the peripheral
+// serves every proto on one RPC characteristic through
+// blerpc_services_lookup, which asks the handler table of each in turn, and
+// only the first proto writes the RPC GATT service; without it each proto
+// keeps its own RPC service.
+
+// ProtoConfig is one entry of protos: in blerpc.yaml.
+type ProtoConfig struct {
+	Proto     string `yaml:"proto"`     // path relative to -root
+	Namespace string `yaml:"namespace"` // default: the proto package
+	Config    string `yaml:"config"`    // generator config of this proto, relative to -root; default: the project's
+}
+
+// protoProject is a resolved protos: entry.
+type protoProject struct {
+	proto      string // path of the proto
+	namespace  string
+	configPath string // -config of the child, "" for the project's
+	pkg        string
+	cfg        *Config
+	commands   []string // wire names, nil if the schema has errors the child reports
+}
+
+// validateProtos checks the protos: section of cfg.
+func validateProtos(cfg *Config) error {
+	seen := make(map[string]bool)
+	for i, p := range cfg.Protos {
+		if p.Proto == "" {
+			return fmt.Errorf("protos[%d]: proto is required", i)
+		}
+		if seen[p.Proto] {
+			return fmt.Errorf("protos[%d]: %s is listed twice", i, p.Proto)
+		}
+		seen[p.Proto] = true
+		if p.Namespace != "" && !reIdentifier.MatchString(p.Namespace) {
+			return fmt.Errorf("protos[%d]: namespace %q is not an identifier", i, p.Namespace)
+		}
+	}
+	if cfg.MergeHandlers && len(cfg.Protos) == 0 {
+		return fmt.Errorf("merge_handlers: requires protos")
+	}
+	return nil
+}
+
+// resolveProtos parses each proto of cfg and loads its config.
+func resolveProtos(cfg *Config, importPaths []string) ([]protoProject, error) {
+	o := cliOptions
+	var projects []protoProject
+	for _, p := range cfg.Protos {
+		pf, err := protomodel.ParseWithImports(o.rootPath(p.Proto), importPaths)
+		if err != nil {
+			return nil, err
+		}
+		proj := protoProject{proto: o.rootPath(p.Proto), pkg: cmp.Or(pf.Package, "blerpc"), cfg: cfg}
+		proj.namespace = cmp.Or(p.Namespace, strings.ReplaceAll(proj.pkg, ".", "_"))
+		if p.Config != "" {
+			proj.configPath = o.rootPath(p.Config)
+			if proj.cfg, err = loadConfig(protomodel.OS, proj.configPath, true); err != nil {
+				return nil, fmt.Errorf("%s: %w", p.Config, err)
+			}
+		}
+		if err := mergeBuiltins(pf, proj.cfg); err == nil {
+			if commands, _, err := protomodel.Discover(pf); err == nil {
+				for _, c := range commands {
+					proj.commands = append(proj.commands, c.Wire())
+				}
+			}
+		}
+		projects = append(projects, proj)
+	}
+	return projects, nil
+}
+
+// protoClashes returns the names two protos of a project would both
+// define. merged is set when they share one RPC characteristic.
+func protoClashes(projects []protoProject, merged bool) []error {
+	var errs []error
+	for i, a := range projects {
+		for _, b := range projects[i+1:] {
+			pair := fmt.Sprintf("%s and %s", a.proto, b.proto)
+			if a.namespace == b.namespace {
+				errs = append(errs, fmt.Errorf("%s share namespace %q; set namespace on one of them", pair, a.namespace))
+			}
+			if a.pkg == b.pkg {
+				errs = append(errs, fmt.Errorf("%s share package %q, so their message types would clash", pair, a.pkg))
+			}
+			an, bn := projectNames(a), projectNames(b)
+			for _, n := range []struct{ key, a, b string }{
+				{"c_prefix", an.CPrefix, bn.CPrefix},
+				{"c_handler_prefix", an.CHandlerPrefix, bn.CHandlerPrefix},
+				{"swift_type_prefix", an.SwiftTypePrefix, bn.SwiftTypePrefix},
+			} {
+				if n.a == n.b {
+					errs = append(errs, fmt.Errorf("%s share names.%s %q", pair, n.key, n.a))
+				}
+			}
+			if merged {
+				for _, c := range a.commands {
+					if slices.Contains(b.commands, c) {
+						errs = append(errs, fmt.Errorf("%s both define command %s, which merge_handlers dispatches by name", pair, c))
+					}
+				}
+			} else if cliOptions.Platform != "none" && a.cfg.targetEnabled("c") && b.cfg.targetEnabled("c") {
+				au, bu := cmp.Or(a.cfg.GATT.ServiceUUID, gen.DefaultServiceUUID), cmp.Or(b.cfg.GATT.ServiceUUID, gen.DefaultServiceUUID)
+				if au == bu {
+					errs = append(errs, fmt.Errorf("%s share RPC service UUID %s; set gatt.service_uuid in the config of one, or merge_handlers", pair, au))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// projectNames returns the names: section a child generating p applies.
+func projectNames(p protoProject) gen.NamesConfig {
+	return namespaceNames(p.cfg.Names, p.namespace)
+}
+
+// namespaceNames fills the names n leaves unset from namespace ns: the C
+// prefixes ns_ and ns_handle_, and the Swift type prefix, e.g. Sensor.
+func namespaceNames(n gen.NamesConfig, ns string) gen.NamesConfig {
+	if ns == "" {
+		return n
+	}
+	n.CPrefix = cmp.Or(n.CPrefix, ns+"_")
+	n.CHandlerPrefix = cmp.Or(n.CHandlerPrefix, ns+"_handle_")
+	n.SwiftTypePrefix = cmp.Or(n.SwiftTypePrefix, gen.UpperCamel(ns))
+	return n
+}
+
+// protoChildArgs returns the command line of the child generating p.
+func protoChildArgs(args []string, p protoProject) []string {
+	out := append(slices.Clone(args), "-proto", p.proto, "-namespace", p.namespace)
+	if p.configPath != "" {
+		out = append(out, "-config", p.configPath)
+	}
+	return out
+}
+
+// runProtos generates each proto of cfg in a child process, then writes
+// the merged lookup of merge_handlers. args is the command line.
+func runProtos(cfg *Config, args []string, info io.Writer) {
+	o := cliOptions
+	if *watchFlag || o.scaffold {
+		fatalf("-watch and -scaffold need a single proto; pass -proto with one of protos")
+	}
+	if cfg.MergeHandlers && o.Platform == "esp-idf" {
+		fatalf("merge_handlers needs a BLE layer dispatching with blerpc_services_lookup and cannot be combined with -platform esp-idf")
+	}
+	projects, err := resolveProtos(cfg, o.ProtoPath)
+	if err != nil {
+		fatalf("Failed to parse proto: %v", err)
+	}
+	if errs := protoClashes(projects, cfg.MergeHandlers); len(errs) > 0 {
+		failProblems("Clashing protos", errs)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fatalf("Failed to find the generator executable: %v", err)
+	}
+	for i, p := range projects {
+		fmt.Fprintf(info, "[%s] %s\n", p.namespace, relPath(o.Root, p.proto))
+		childArgs := protoChildArgs(args, p)
+		if cfg.MergeHandlers && i > 0 {
+			// The RPC GATT service of the first proto serves them all.
+			childArgs = append(childArgs, "-platform", "none")
+		}
+		cmd := exec.Command(exe, childArgs...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			// The child has reported the problem.
+			os.Exit(1)
+		}
+	}
+	if !cfg.MergeHandlers || o.report || *stdoutFlag != "" {
+		return
+	}
+	o.applyOutputPaths(cfg)
+	gctx := gen.NewContext()
+	gctx.CHandlerCtx = o.CHandlerCtx
+	path := flagOrDefault(o.out("c-services-header"), filepath.Join(o.Root, "peripheral_fw", "src", "generated_services.h"))
+	var headers []string
+	var prefixes []string
+	for _, p := range projects {
+		if !p.cfg.targetEnabled("c") {
+			continue
+		}
+		header := filepath.Join(o.Root, "peripheral_fw", "src", p.namespace, "generated_handlers.h")
+		if out, ok := p.cfg.Outputs["c-header"]; ok {
+			header = o.rootPath(out)
+		}
+		rel, err := filepath.Rel(filepath.Dir(path), header)
+		if err != nil {
+			fatalf("Failed to include %s: %v", header, err)
+		}
+		headers = append(headers, filepath.ToSlash(rel))
+		prefixes = append(prefixes, projectNames(p).CPrefix)
+	}
+	if len(headers) == 0 {
+		return
+	}
+	outputs := []gen.Output{{Path: path, Content: generateServicesHeader(gctx, headers, prefixes)}}
+	switch {
+	case *dryRunFlag:
+		if err := dryRunOutputs(outputs, nil, o.Root, os.Stdout); err != nil {
+			fatalf("Failed to compare generated files: %v", err)
+		}
+	case *checkFlag:
+		stale, err := checkOutputs(outputs, o.Root, os.Stdout)
+		if err != nil {
+			fatalf("Failed to check generated files: %v", err)
+		}
+		if stale > 0 {
+			fatalf("%d generated file(s) out of date; rerun generate-handlers", stale)
+		}
+	default:
+		if err := writeFile(path, outputs[0].Content); err != nil {
+			fatalf("Failed to write %s: %v", path, err)
+		}
+		fmt.Fprintf(info, "  Generated %s\n", relPath(o.Root, path))
+	}
+}
+
+// generateServicesHeader returns generated_services.h, whose
+// blerpc_services_lookup finds a command in the handler tables of the
+// protos, included from headers, with C prefixes prefixes.
+func generateServicesHeader(gctx *gen.Context, headers, prefixes []string) string {
+	var b strings.Builder
+	b.WriteString("/* Auto-generated by generate-handlers — DO NOT EDIT */\n")
+	b.WriteString("#ifndef BLERPC_GENERATED_SERVICES_H\n")
+	b.WriteString("#define BLERPC_GENERATED_SERVICES_H\n\n")
+	for _, h := range headers {
+		fmt.Fprintf(&b, "#include \"%s\"\n", h)
+	}
+	b.WriteString("\n#ifdef __cplusplus\nextern \"C\" {\n#endif\n\n")
+	lookups := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		lookups[i] = p + "handlers_lookup"
+	}
+	fn := prefixes[0] + "command_handler_fn"
+	args := "name, name_len"
+	if gctx.CHandlerCtx {
+		args += ", ctx"
+	}
+	b.WriteString("/* Handler of a command of any proto of the project, for a dispatcher\n")
+	b.WriteString(" * serving them all on one RPC characteristic. Asks, in turn:\n")
+	for _, l := range lookups {
+		b.WriteString(" *   " + l + "\n")
+	}
+	b.WriteString(" * The handler types of the protos are the same. */\n")
+	fmt.Fprintf(&b, "static inline %s blerpc_services_lookup(const char *name, uint8_t name_len%s)\n", fn, gctx.CCtxSuffix())
+	b.WriteString("{\n")
+	fmt.Fprintf(&b, "    %s handler;\n", fn)
+	for _, l := range lookups[:len(lookups)-1] {
+		fmt.Fprintf(&b, "    if ((handler = %s(%s)) != NULL) return handler;\n", l, args)
+	}
+	fmt.Fprintf(&b, "    return %s(%s);\n", lookups[len(lookups)-1], args)
+	b.WriteString("}\n\n")
+	b.WriteString("#ifdef __cplusplus\n}\n#endif\n\n")
+	b.WriteString("#endif /* BLERPC_GENERATED_SERVICES_H */\n")
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestParseConfigProtos(t *testing.T) {
+	cfg, err := parseConfig([]byte("protos:\n  - proto: proto/device.proto\n  - proto: proto/sensor.proto\n    namespace: sensor\nmerge_handlers: true\n"))
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if len(cfg.Protos) != 2 || cfg.Protos[1].Namespace != "sensor" || !cfg.MergeHandlers {
+		t.Errorf("protos = %+v, merge_handlers = %v", cfg.Protos, cfg.MergeHandlers)
+	}
+	for _, tc := range []struct{ yaml, want string }{
+		{"protos:\n  - namespace: a\n", "proto is required"},
+		{"protos:\n  - proto: a.proto\n  - proto: a.proto\n", "listed twice"},
+		{"protos:\n  - proto: a.proto\n    namespace: 2fast\n", "not an identifier"},
+		{"merge_handlers: true\n", "requires protos"},
+	} {
+		if _, err := parseConfig([]byte(tc.yaml)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("parseConfig(%q) = %v, want %q", tc.yaml, err, tc.want)
+		}
+	}
+}
+
+func TestNamespaceNames(t *testing.T) {
+	n := namespaceNames(gen.NamesConfig{CPrefix: "dev_"}, "sensor_log")
+	if n.CPrefix != "dev_" || n.CHandlerPrefix != "sensor_log_handle_" || n.SwiftTypePrefix != "SensorLog" {
+		t.Errorf("namespaceNames = %+v", n)
+	}
+	if n := namespaceNames(gen.NamesConfig{}, ""); n != (gen.NamesConfig{}) {
+		t.Errorf("namespaceNames without namespace = %+v", n)
+	}
+}
+
+func TestProtoClashes(t *testing.T) {
+	cfg := &Config{}
+	device := protoProject{proto: "device.proto", namespace: "device", pkg: "device", cfg: cfg, commands: []string{"set_led", "ping"}}
+	sensor := protoProject{proto: "sensor.proto", namespace: "sensor", pkg: "sensor", cfg: cfg, commands: []string{"read_log", "ping"}}
+
+	errs := protoClashes([]protoProject{device, sensor}, true)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "both define command ping") {
+		t.Errorf("merged clashes = %v", errs)
+	}
+	errs = protoClashes([]protoProject{device, sensor}, false)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "share RPC service UUID") {
+		t.Errorf("separate clashes = %v", errs)
+	}
+	sensor.cfg = &Config{GATT: GATTConfig{ServiceUUID: "12350001-0000-1000-8000-00805f9b34fb"}}
+	if errs := protoClashes([]protoProject{device, sensor}, false); len(errs) != 0 {
+		t.Errorf("separate services clash: %v", errs)
+	}
+
+	sensor.namespace, sensor.pkg = "device", "device"
+	errs = protoClashes([]protoProject{device, sensor}, false)
+	for _, want := range []string{`share namespace "device"`, `share package "device"`, `share names.c_prefix "device_"`} {
+		if !strings.Contains(joinErrors(errs), want) {
+			t.Errorf("clashes %v missing %q", errs, want)
+		}
+	}
+}
+
+func joinErrors(errs []error) string {
+	var b strings.Builder
+	for _, err := range errs {
+		b.WriteString(err.Error() + "\n")
+	}
+	return b.String()
+}
+
+func TestProtoChildArgs(t *testing.T) {
+	args := []string{"-root", "."}
+	got := protoChildArgs(args, protoProject{proto: "proto/sensor.proto", namespace: "sensor", configPath: "sensor.yaml"})
+	want := "-root . -proto proto/sensor.proto -namespace sensor -config sensor.yaml"
+	if strings.Join(got, " ") != want {
+		t.Errorf("protoChildArgs = %q, want %q", got, want)
+	}
+	if len(args) != 2 {
+		t.Errorf("protoChildArgs changed args: %q", args)
+	}
+}
+
+func TestGenerateServicesHeader(t *testing.T) {
+	gctx := gen.NewContext()
+	out := generateServicesHeader(gctx, []string{"device/generated_handlers.h", "sensor/generated_handlers.h"}, []string{"device_", "sensor_"})
+	for _, want := range []string{
+		`#include "device/generated_handlers.h"`,
+		`#include "sensor/generated_handlers.h"`,
+		"static inline device_command_handler_fn blerpc_services_lookup(const char *name, uint8_t name_len)",
+		"    if ((handler = device_handlers_lookup(name, name_len)) != NULL) return handler;",
+		"    return sensor_handlers_lookup(name, name_len);",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("services header missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+)
+
+func TestPyCLIFlag(t *testing.T) {
+	tests := []struct {
+		field      gen.Field
+		conv, help string
+	}{
+		{gen.Field{Name: "addr", Type: "uint32"}, "_int", "uint32"},
+		{gen.Field{Name: "on", Type: "bool"}, "_bool", "true or false"},
+		{gen.Field{Name: "data", Type: "bytes"}, "bytes.fromhex", "hex bytes"},
+		{gen.Field{Name: "gain", Type: "float"}, "float", "float"},
+		{gen.Field{Name: "color", Type: "Color", IsEnum: true}, "_enum(blerpc_pb2.Color)", "Color name or number"},
+		{gen.Field{Name: "ids", Type: "uint32", IsRepeated: true}, "_json", "list[int] as JSON"},
+		{gen.Field{Name: "at", Type: ".google.protobuf.Timestamp", IsMessage: true, TypeOverrides: map[string]gen.TypeOverride{
+			"python": wellKnownTypeMappings[0].Languages["python"],
+		}}, "datetime.datetime.fromisoformat", "ISO 8601 time"},
+	}
+	for _, tt := range tests {
+		conv, help := python.CLIFlag(tt.field, "blerpc")
+		if conv != tt.conv || help != tt.help {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", tt.field.Name, conv, help, tt.conv, tt.help)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// The offline queue lets mobile apps issue fire-and-forget calls, such as
+// configuration pushes, while the device is out of reach. Queued calls are
+// persisted, sent in order by flush once a connection is up, and dropped
+// unsent when their time to live runs out. A flush stops at the first call
+// that fails without an answer from the peripheral and keeps it for the next
+// one; calls the peripheral rejected with a RemoteError are reported and
+// dropped.
+//
+// Unary commands become queueable with
+//
+//	option (blerpc.queue_ttl) = 86400;  // seconds
+//
+// or, for schemas discovered by message naming, an entry under queueable in
+// blerpc.yaml.
+
+// QueueableConfig makes a command queueable in blerpc.yaml.
+type QueueableConfig struct {
+	Command string `yaml:"command"`
+	TTL     int    `yaml:"ttl"` // seconds a queued call stays valid
+}
+
+// applyQueueable records the queueable commands of blerpc.yaml and checks
+// that every queueable command is unary.
+func applyQueueable(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, q := range cfg.Queueable {
+		idx, ok := bySnake[q.Command]
+		if !ok {
+			return fmt.Errorf("queueable[%d]: unknown command %q", i, q.Command)
+		}
+		if q.TTL <= 0 {
+			return fmt.Errorf("queueable[%d]: %s needs a positive ttl", i, q.Command)
+		}
+		commands[idx].QueueTTL = q.TTL
+	}
+	for _, cmd := range commands {
+		if cmd.QueueTTL > 0 && streaming[cmd.Snake] != "" {
+			return fmt.Errorf("%s: streaming commands cannot be queued", cmd.Snake)
+		}
+	}
+	return nil
+}
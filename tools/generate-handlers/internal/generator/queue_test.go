@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	kotlingen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestApplyQueueable(t *testing.T) {
+	tests := []struct {
+		name      string
+		queueable []QueueableConfig
+		want      string
+	}{
+		{"unknown", []QueueableConfig{{Command: "missing", TTL: 60}}, `unknown command "missing"`},
+		{"no ttl", []QueueableConfig{{Command: "echo"}}, "positive ttl"},
+		{"stream", []QueueableConfig{{Command: "counter_stream", TTL: 60}}, "streaming commands cannot be queued"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+			err := applyQueueable(cmds, &Config{Queueable: tt.queueable}, map[string]string{"counter_stream": "p2c"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cmds := []gen.Command{gentest.EchoCommand()}
+	if err := applyQueueable(cmds, &Config{Queueable: []QueueableConfig{{Command: "echo", TTL: 60}}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].QueueTTL != 60 {
+		t.Errorf("QueueTTL = %d", cmds[0].QueueTTL)
+	}
+}
+
+func TestGenerateQueue(t *testing.T) {
+	gctx := gen.NewContext()
+	write := gentest.CallbackCommand()
+	write.QueueTTL = 3600
+	cmds := []gen.Command{gentest.EchoCommand(), write}
+	kotlin := kotlingen.GenerateQueue(gctx, cmds, "blerpc")
+	for _, s := range []string{
+		"    mapOf(\n        \"data_write\" to 3600L,\n    )\n",
+		"    suspend fun enqueueDataWrite(request: blerpc.Blerpc.DataWriteRequest) {\n",
+		"                        } catch (e: RemoteException) {\n",
+		"client.exclusive { client.call(call.command, call.request) }",
+	} {
+		if !strings.Contains(kotlin, s) {
+			t.Errorf("kotlin: missing %q\nGot:\n%s", s, kotlin)
+		}
+	}
+	swift := swiftgen.GenerateQueue(gctx, cmds, "blerpc")
+	for _, s := range []string{
+		"let queueableCommands: [String: TimeInterval] = [\n    \"data_write\": 3600,\n]\n",
+		"    func enqueueDataWrite(_ request: Blerpc_DataWriteRequest) throws {\n",
+		"actor OfflineQueue {\n",
+		"                    let data = try await client.exclusive {\n",
+	} {
+		if !strings.Contains(swift, s) {
+			t.Errorf("swift: missing %q\nGot:\n%s", s, swift)
+		}
+	}
+	if strings.Contains(kotlin, "enqueueEcho") || strings.Contains(swift, "enqueueEcho") {
+		t.Error("non-queueable command got an enqueue method")
+	}
+}
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Rate limits protect slow handlers, such as flash writes, from apps that
+// call them in a tight loop. A command accepts at most its limit of calls per
+// one-second window; handlers_lookup answers further calls with a handler
+// that returns HANDLER_BUSY without running the real one, and the dispatcher
+// replies with a BUSY error the central can retry after.
+//
+// Commands get a limit with
+//
+//	option (blerpc.rate_limit) = 2;  // calls per second
+//
+// or, for schemas discovered by message naming, an entry under rate_limits in
+// blerpc.yaml.
+
+// RateLimitConfig sets the rate limit of a command in blerpc.yaml.
+type RateLimitConfig struct {
+	Command   string `yaml:"command"`
+	PerSecond int    `yaml:"per_second"`
+}
+
+// maxRateLimit is the largest limit the uint16_t window counter holds.
+const maxRateLimit = 0xffff
+
+// applyRateLimits records the rate limits of blerpc.yaml.
+func applyRateLimits(commands []gen.Command, cfg *Config) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, r := range cfg.RateLimits {
+		idx, ok := bySnake[r.Command]
+		if !ok {
+			return fmt.Errorf("rate_limits[%d]: unknown command %q", i, r.Command)
+		}
+		if r.PerSecond <= 0 || r.PerSecond > maxRateLimit {
+			return fmt.Errorf("rate_limits[%d]: %s needs a per_second between 1 and %d", i, r.Command, maxRateLimit)
+		}
+		commands[idx].RateLimit = r.PerSecond
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestApplyRateLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits []RateLimitConfig
+		want   string
+	}{
+		{"unknown", []RateLimitConfig{{Command: "missing", PerSecond: 2}}, `unknown command "missing"`},
+		{"zero", []RateLimitConfig{{Command: "echo"}}, "per_second between 1 and 65535"},
+		{"too large", []RateLimitConfig{{Command: "echo", PerSecond: 70000}}, "per_second between 1 and 65535"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyRateLimits([]gen.Command{gentest.EchoCommand()}, &Config{RateLimits: tt.limits})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cmds := []gen.Command{gentest.EchoCommand()}
+	if err := applyRateLimits(cmds, &Config{RateLimits: []RateLimitConfig{{Command: "echo", PerSecond: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].RateLimit != 2 {
+		t.Errorf("RateLimit = %d", cmds[0].RateLimit)
+	}
+}
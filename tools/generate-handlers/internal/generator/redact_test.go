@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateRedaction(t *testing.T) {
+	gctx := gen.NewContext()
+	msgs := gentest.RedactMessages()
+	login := gen.Command{Camel: "Login", Snake: "login", RequestMsg: "LoginRequest", ResponseMsg: "LoginResponse"}
+	goCapture := golang.GenerateCapture(gctx, []gen.Command{login}, msgs, "blerpc")
+	if _, err := parser.ParseFile(token.NewFileSet(), "capture.go", goCapture, 0); err != nil {
+		t.Fatalf("generated capture.go does not parse: %v\n%s", err, goCapture)
+	}
+	pyCapture := python.GenerateCapture(gctx, []gen.Command{login}, msgs)
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateRedaction(gctx, msgs, "blerpc"), []string{
+			"SENSITIVE_FIELDS: dict[str, frozenset[str]] = {\n    \"blerpc.Credentials\": frozenset({\"pin\"}),\n}\n",
+			"def redacted(message: Message) -> str:\n",
+			"class Redacted:\n",
+		}},
+		{"python capture", pyCapture, []string{
+			"    \"LoginRequest\": {1: \"Credentials\", 2: \"LoginRequest.BackupKeysEntry\"},\n",
+			"REDACTED_COMMANDS: dict[str, tuple[int, str | None, str | None] | None] = {\n    \"login\": (0, \"LoginRequest\", None),\n}\n",
+			"def strip_sensitive(data: bytes, message: str) -> bytes:\n",
+		}},
+		{"go capture", goCapture, []string{
+			"\t\"LoginRequest.BackupKeysEntry\": {2: \"Credentials\"},\n",
+			"\t\"login\": {prefix: 0, request: \"LoginRequest\", response: \"\"},\n",
+		}},
+		{"kotlin", kotlin.GenerateRedaction(gctx, msgs, "blerpc"), []string{
+			"        is blerpc.Blerpc.LoginRequest -> message.toRedactedString()\n",
+			"fun blerpc.Blerpc.Credentials.toRedactedString(): String =\n",
+			"        add(\"pin=$REDACTED\")\n",
+			"        if (hasCredentials()) add(\"credentials=${redacted(credentials)}\")\n",
+			"        add(\"backup_keys=${backupKeysMap.mapValues { redacted(it.value) }}\")\n",
+			"        if (hasNote()) add(\"note=$note\")\n",
+			"    }.joinToString(\", \", \"LoginRequest(\", \")\")\n",
+		}},
+		{"swift", swift.GenerateRedaction(gctx, msgs, "blerpc"), []string{
+			"    case let message as Blerpc_Credentials:\n",
+			"extension Blerpc_LoginRequest {\n",
+			"        fields.append(\"pin: \\(redactedPlaceholder)\")\n",
+			"        if hasCredentials { fields.append(\"credentials: \\(redacted(credentials))\") }\n",
+			"        fields.append(\"backup_keys: \\(backupKeys.mapValues { redacted($0) })\")\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+	if strings.Contains(kotlin.GenerateRedaction(gctx, msgs, "blerpc"), "LoginResponse") {
+		t.Error("kotlin: LoginResponse has no sensitive fields but is redacted")
+	}
+	for i, line := range strings.Split(pyCapture, "\n") {
+		if len([]rune(line)) > 88 {
+			t.Errorf("python line %d longer than 88 characters: %q", i+1, line)
+		}
+	}
+
+	// Without sensitive fields the helpers fall back on the messages' own
+	// formatting.
+	plain := map[string]gen.Message{"LoginResponse": msgs["LoginResponse"]}
+	if out := kotlin.GenerateRedaction(gctx, plain, "blerpc"); !strings.Contains(out, "fun redacted(message: MessageLite): String = message.toString()\n") {
+		t.Errorf("kotlin: missing the plain redacted():\n%s", out)
+	}
+	if out := python.GenerateRedaction(gctx, plain, "blerpc"); !strings.Contains(out, "SENSITIVE_FIELDS: dict[str, frozenset[str]] = {}\n") {
+		t.Errorf("python: missing the empty table:\n%s", out)
+	}
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// renderOutputs renders the queued outputs, on as many goroutines as there
+// are CPUs. Once ctx is done it starts no more renders and returns its
+// error after those running finish.
+func renderOutputs(ctx context.Context, outputs []gen.Output) error {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i := range outputs {
+		if outputs[i].Render == nil {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(out *gen.Output) {
+			defer wg.Done()
+			out.Content = out.Render()
+			out.Render = nil
+			<-sem
+		}(&outputs[i])
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// generatedMarker starts the header of every generated file but the Go
+// sources, whose header is goGeneratedMarker: "// Code generated by
+// generate-handlers. DO NOT EDIT.", the form go vet, gofmt and editors
+// recognize.
+const (
+	generatedMarker   = "Auto-generated by generate-handlers"
+	goGeneratedMarker = "Code generated by generate-handlers"
+)
+
+// stampOutputs adds the generator version and schema hash to the generated
+// header of each output, e.g. "Auto-generated by generate-handlers 0.1.0
+// (schema 1f0c9a2b3d4e5f60) — DO NOT EDIT", or "// Code generated by
+// generate-handlers 0.1.0 (schema 1f0c9a2b3d4e5f60). DO NOT EDIT." in Go.
+// Files without the header, such as JSON and fixtures, are left alone.
+func stampOutputs(outputs []gen.Output, hash string) {
+	suffix := " " + gen.GeneratorVersion + " (schema " + hash + ")"
+	for i, out := range outputs {
+		header := generatedHeader(out.Content)
+		for _, marker := range []string{generatedMarker, goGeneratedMarker} {
+			if strings.Contains(header, marker) {
+				outputs[i].Content = strings.Replace(out.Content, marker, marker+suffix, 1)
+				break
+			}
+		}
+	}
+}
+
+// generatedHeader returns the first two lines of content, which hold the
+// generated header: the first line, or the second in files whose first must
+// be a shebang, an HTML doctype or the Swift tools version.
+func generatedHeader(content string) string {
+	end := 0
+	for range 2 {
+		i := strings.IndexByte(content[end:], '\n')
+		if i < 0 {
+			return content
+		}
+		end += i + 1
+	}
+	return content[:end]
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestRenderOutputs(t *testing.T) {
+	var outputs []gen.Output
+	for i := range 50 {
+		outputs = append(outputs, gen.LazyOutput(fmt.Sprintf("f%d", i), func() string { return fmt.Sprint(i) }))
+	}
+	outputs = append(outputs, gen.Output{Path: "eager", Content: "e"})
+	renderOutputs(context.Background(), outputs)
+	for i, out := range outputs[:50] {
+		if out.Path != fmt.Sprintf("f%d", i) || out.Content != fmt.Sprint(i) || out.Render != nil {
+			t.Errorf("outputs[%d] = %q %q, want it rendered in place", i, out.Path, out.Content)
+		}
+	}
+	if outputs[50].Content != "e" {
+		t.Errorf("eager output changed to %q", outputs[50].Content)
+	}
+}
+
+func TestStampOutputs(t *testing.T) {
+	outputs := []gen.Output{
+		{Path: "a.h", Content: "/* Auto-generated by generate-handlers — DO NOT EDIT */\nint a;\n"},
+		{Path: "a.py", Content: "\"\"\"Auto-generated by generate-handlers — DO NOT EDIT.\"\"\"\n"},
+		{Path: "a.json", Content: "{\"commands\": []}\n"},
+		{Path: "b.c", Content: "int b;\nint c;\n/* Auto-generated by generate-handlers */\n"},
+		{Path: "a.html", Content: "<!DOCTYPE html>\n<!-- Auto-generated by generate-handlers — DO NOT EDIT. -->\n"},
+		{Path: "a.go", Content: "// Code generated by generate-handlers. DO NOT EDIT.\n\npackage a\n"},
+	}
+	stampOutputs(outputs, "0123456789abcdef")
+	want := []string{
+		"/* Auto-generated by generate-handlers " + gen.GeneratorVersion + " (schema 0123456789abcdef) — DO NOT EDIT */\nint a;\n",
+		"\"\"\"Auto-generated by generate-handlers " + gen.GeneratorVersion + " (schema 0123456789abcdef) — DO NOT EDIT.\"\"\"\n",
+		"{\"commands\": []}\n",
+		"int b;\nint c;\n/* Auto-generated by generate-handlers */\n",
+		"<!DOCTYPE html>\n<!-- Auto-generated by generate-handlers " + gen.GeneratorVersion + " (schema 0123456789abcdef) — DO NOT EDIT. -->\n",
+		"// Code generated by generate-handlers " + gen.GeneratorVersion + " (schema 0123456789abcdef). DO NOT EDIT.\n\npackage a\n",
+	}
+	for i, out := range outputs {
+		if out.Content != want[i] {
+			t.Errorf("%s = %q, want %q", out.Path, out.Content, want[i])
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// applyReplayProtected marks the commands listed under replay_protected in
+// blerpc.yaml and checks that every protected command is unary, sent only
+// once, as retries and queued calls would repeat or delay its counter, and
+// has the session key of the session built-in to authenticate it with.
+func applyReplayProtected(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for _, name := range cfg.ReplayProtected {
+		i, ok := bySnake[name]
+		if !ok {
+			return fmt.Errorf("replay_protected: unknown command %q", name)
+		}
+		commands[i].ReplayProtected = true
+	}
+	_, _, sessions := gen.SessionCommands(commands)
+	for _, cmd := range commands {
+		if !cmd.ReplayProtected {
+			continue
+		}
+		switch {
+		case !sessions:
+			return fmt.Errorf("%s: replay protection requires the session built-in, whose key authenticates the counter", cmd.Snake)
+		case cmd.Builtin == "session":
+			return fmt.Errorf("%s: the session commands cannot be replay protected", cmd.Snake)
+		case streaming[cmd.Snake] != "":
+			return fmt.Errorf("%s: streaming commands cannot be replay protected", cmd.Snake)
+		case cmd.Idempotent:
+			return fmt.Errorf("%s: replay-protected commands cannot be idempotent", cmd.Snake)
+		case cmd.QueueTTL > 0:
+			return fmt.Errorf("%s: replay-protected commands cannot be queued", cmd.Snake)
+		}
+	}
+	return nil
+}
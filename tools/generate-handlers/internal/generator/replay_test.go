@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func TestApplyReplayProtected(t *testing.T) {
+	commands, _ := builtinSchema(t, sessionSchema, "session")
+	idempotent := append([]gen.Command(nil), commands...)
+	idempotent[0].Idempotent = true
+	queued := append([]gen.Command(nil), commands...)
+	queued[0].QueueTTL = 60
+	tests := []struct {
+		name      string
+		commands  []gen.Command
+		cfg       []string
+		streaming map[string]string
+		want      string
+	}{
+		{"unknown", commands, []string{"missing"}, nil, `unknown command "missing"`},
+		{"no builtin", []gen.Command{gentest.EchoCommand()}, []string{"echo"}, nil, "requires the session built-in"},
+		{"session command", commands, []string{"start_session"}, nil, "cannot be replay protected"},
+		{"stream", commands, []string{"echo"}, map[string]string{"echo": "p2c"}, "streaming commands cannot be replay protected"},
+		{"idempotent", idempotent, []string{"echo"}, nil, "cannot be idempotent"},
+		{"queued", queued, []string{"echo"}, nil, "cannot be queued"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := append([]gen.Command(nil), tt.commands...)
+			err := applyReplayProtected(cmds, &Config{ReplayProtected: tt.cfg}, tt.streaming)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cmds := append([]gen.Command(nil), commands...)
+	if err := applyReplayProtected(cmds, &Config{ReplayProtected: []string{"echo"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !cmds[0].ReplayProtected {
+		t.Error("echo should be replay protected")
+	}
+}
+
+func TestGenerateReplayProtected(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, sessionSchema, "session")
+	if err := applyReplayProtected(commands, &Config{ReplayProtected: []string{"echo"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define HANDLER_REPLAYED (-4)",
+		"#define BLERPC_REPLAY_MAC_SIZE 16",
+		"int blerpc_replay_mac(const char *command, const uint8_t *counter,\n                      const uint8_t *payload, size_t payload_len, uint8_t *mac);",
+		"uint64_t blerpc_replay_counter_load(void);",
+		"void blerpc_replay_counter_store(uint64_t counter);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static bool replay_fresh(const char *command, const uint8_t *req_data, size_t req_len,",
+		"diff |= mac[i] ^ req_data[8 + i];",
+		"static int guarded_echo(const uint8_t *req_data, size_t req_len,\n                        pb_ostream_t *ostream)",
+		`if (!replay_fresh("echo", req_data, req_len, ostream->callback != NULL)) {`,
+		"return handle_echo(req_data + 24, req_len - 24, ostream);",
+		`{"echo", 4, guarded_echo},`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import hmac\n",
+			"def _with_replay_counter(client, command, req_data):",
+			`req_data = _with_replay_counter(self, "echo", req.SerializeToString())`,
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import java.nio.ByteOrder\n",
+			"internal object ReplayCounter {",
+			`call("echo", ReplayCounter.prefix(sessionKey, "echo", req.toByteArray()))`,
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"enum ReplayCounter {",
+			`ReplayCounter.prefix(key: session.key, command: "echo", try req.serializedData())`,
+		}},
+		{"dart", dart.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"Uint8List _withReplayCounter(\n",
+			"_withReplayCounter(sessionKey, 'echo', req.writeToBuffer())",
+		}},
+		{"ts", typescript.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"async function withReplayCounter(",
+			"this.call('echo', await withReplayCounter(this.sessionKey, 'echo', blerpc.EchoRequest.encode(req).finish()))",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+
+	if out := python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"); strings.Contains(out, "_with_replay_counter") {
+		t.Error("unprotected commands should not emit the replay counter")
+	}
+}
@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Resuming clients wrap a generated client and recover calls cut off by a
+// dropped connection. A call started while disconnected reconnects first.
+// When a call fails and the link is down afterwards, the peripheral may or may
+// not have run it: idempotent commands are retried after reconnecting, as the
+// policy allows, and the others fail with CallInterruptedError so the app can
+// decide what to do. Failures on a live link pass through unchanged.
+//
+// A command is idempotent when its RPC sets the standard option
+//
+//	option idempotency_level = IDEMPOTENT;  // or NO_SIDE_EFFECTS
+//
+// or, for schemas discovered by message naming, when blerpc.yaml lists it
+// under idempotent.
+
+// applyIdempotent marks the commands listed under idempotent in blerpc.yaml.
+func applyIdempotent(commands []gen.Command, cfg *Config) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for _, name := range cfg.Idempotent {
+		i, ok := bySnake[name]
+		if !ok {
+			return fmt.Errorf("idempotent: unknown command %q", name)
+		}
+		commands[i].Idempotent = true
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestApplyIdempotent(t *testing.T) {
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	if err := applyIdempotent(cmds, &Config{Idempotent: []string{"counter_stream"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].Idempotent || !cmds[1].Idempotent {
+		t.Errorf("unexpected idempotent flags: %v, %v", cmds[0].Idempotent, cmds[1].Idempotent)
+	}
+	err := applyIdempotent(cmds, &Config{Idempotent: []string{"missing"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown command "missing"`) {
+		t.Errorf("expected unknown command error, got %v", err)
+	}
+}
+
+func TestGenerateResume(t *testing.T) {
+	gctx := gen.NewContext()
+	echo := gentest.EchoCommand()
+	echo.Idempotent = true
+	cmds := []gen.Command{echo, gentest.CallbackCommand()}
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateResume(cmds), []string{
+			"IDEMPOTENT_COMMANDS = frozenset(\n    {\n        \"echo\",\n    }\n)\n",
+			"class CallInterruptedError(TransportError):\n",
+			"    async def _call(self, cmd_name, request_data):\n",
+			"                async with _rpc_lock(self._client):\n",
+		}},
+		{"kotlin", kotlin.GenerateResume(gctx, cmds, "blerpc"), []string{
+			"package com.blerpc.android.client\n",
+			"    setOf(\n        \"echo\",\n    )\n",
+			"class ResumingClient(\n",
+			"                return client.exclusive { block() }\n",
+		}},
+		{"swift", swift.GenerateResume(gctx, cmds), []string{
+			"let idempotentCommands: Set<String> = [\n    \"echo\",\n]\n",
+			"struct CallInterruptedError: BlerpcErrorProtocol {\n",
+			"    let callSerializer = CallSerializer()\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.want {
+			if !strings.Contains(tt.out, s) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, s, tt.out)
+			}
+		}
+		if strings.Contains(tt.out, "data_write") {
+			t.Errorf("%s: non-idempotent command listed", tt.name)
+		}
+	}
+	if !strings.Contains(python.GenerateResume(nil), "IDEMPOTENT_COMMANDS = frozenset()\n") {
+		t.Error("python: expected empty set")
+	}
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Roles keep privileged commands, such as factory calibration or installer
+// setup, away from the consumer app. Every command requires a role: user (the
+// default), installer or factory, each including the ones before it.
+// handlers_lookup compares it with the <pkg>_current_role() hook and treats a
+// command above the current role as unknown, so it cannot be invoked even by
+// an app that discovered its name. The clients get the roles as metadata to
+// hide what the connected role cannot use.
+//
+// Commands get a role with
+//
+//	option (blerpc.role) = "factory";
+//
+// or, for schemas discovered by message naming, an entry under roles in
+// blerpc.yaml.
+
+// RoleConfig sets the role a command requires in blerpc.yaml.
+type RoleConfig struct {
+	Command string `yaml:"command"`
+	Role    string `yaml:"role"`
+}
+
+// applyRoles records the roles of blerpc.yaml and checks those of the
+// (blerpc.role) options.
+func applyRoles(commands []gen.Command, cfg *Config) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for i, r := range cfg.Roles {
+		idx, ok := bySnake[r.Command]
+		if !ok {
+			return fmt.Errorf("roles[%d]: unknown command %q", i, r.Command)
+		}
+		if _, ok := gen.RoleLevel(r.Role); !ok || r.Role == "" {
+			return fmt.Errorf("roles[%d]: %s has unknown role %q (want %s)", i, r.Command, r.Role, strings.Join(gen.RoleNames, ", "))
+		}
+		commands[idx].Role = r.Role
+	}
+	for _, cmd := range commands {
+		if _, ok := gen.RoleLevel(cmd.Role); !ok {
+			return fmt.Errorf("%s: unknown role %q (want %s)", cmd.Snake, cmd.Role, strings.Join(gen.RoleNames, ", "))
+		}
+	}
+	return nil
+}
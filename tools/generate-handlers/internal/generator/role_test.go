@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func TestApplyRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []RoleConfig
+		want  string
+	}{
+		{"unknown command", []RoleConfig{{Command: "missing", Role: "factory"}}, `unknown command "missing"`},
+		{"unknown role", []RoleConfig{{Command: "echo", Role: "admin"}}, `unknown role "admin"`},
+		{"empty role", []RoleConfig{{Command: "echo"}}, `unknown role ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyRoles([]gen.Command{gentest.EchoCommand()}, &Config{Roles: tt.roles})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	option := gentest.EchoCommand()
+	option.Role = "root"
+	if err := applyRoles([]gen.Command{option}, &Config{}); err == nil || !strings.Contains(err.Error(), `unknown role "root"`) {
+		t.Errorf("expected option role error, got %v", err)
+	}
+
+	cmds := []gen.Command{gentest.EchoCommand()}
+	if err := applyRoles(cmds, &Config{Roles: []RoleConfig{{Command: "echo", Role: "installer"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].Role != "installer" {
+		t.Errorf("Role = %q", cmds[0].Role)
+	}
+}
+
+func TestGenerateRoles(t *testing.T) {
+	gctx := gen.NewContext()
+	factory := gentest.EchoCommand()
+	factory.Role = "factory"
+
+	header := c.GenerateHeader(gctx, []gen.Command{factory}, nil, nil, "blerpc")
+	for _, want := range []string{
+		"    BLERPC_ROLE_INSTALLER = 1,\n",
+		"enum blerpc_role blerpc_current_role(void);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := c.GenerateSource(gctx, []gen.Command{factory}, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static const uint8_t roles[] = {",
+		"    BLERPC_ROLE_FACTORY, /* echo */",
+		"__attribute__((weak))\nenum blerpc_role blerpc_current_role(void)",
+		"if (roles[i] > blerpc_current_role()) return NULL;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want string
+	}{
+		{"python", python.GenerateClient(gctx, []gen.Command{factory}, nil, "blerpc"), "    \"echo\": \"factory\",\n"},
+		{"kotlin", kotlin.GenerateClient(gctx, []gen.Command{factory}, nil, "blerpc"), "        \"echo\" to \"factory\",\n"},
+		{"swift", swift.GenerateClient(gctx, []gen.Command{factory}, nil, "blerpc"), "    \"echo\": \"factory\",\n"},
+		{"dart", dart.GenerateClient(gctx, []gen.Command{factory}, nil, "blerpc"), "  'echo': 'factory',\n"},
+		{"ts", typescript.GenerateClient(gctx, []gen.Command{factory}, nil, "blerpc"), "  echo: 'factory',\n"},
+	}
+	for _, tt := range clients {
+		if !strings.Contains(tt.out, tt.want) {
+			t.Errorf("%s: missing %q", tt.name, tt.want)
+		}
+	}
+
+	user := gentest.EchoCommand()
+	user.Role = "user"
+	if src := c.GenerateSource(gctx, []gen.Command{user}, nil, nil, "blerpc"); strings.Contains(src, "roles[]") {
+		t.Error("user-only commands should not emit role checks")
+	}
+	if out := python.GenerateClient(gctx, []gen.Command{user}, nil, "blerpc"); strings.Contains(out, "COMMAND_ROLES") {
+		t.Error("user-only commands should not emit COMMAND_ROLES")
+	}
+}
@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// Run generates in memory, for Go build tools that embed the generator
+// through pkg/blerpcgen instead of running the command. fsys stands in for
+// the working directory of the command: the paths in o are relative to it,
+// and the default Root of "." is its top. A nil o runs with DefaultOptions.
+// Run returns the files the command would write, keyed by slash-separated
+// path in fsys, and the warnings, as "file:line:col: message" when they
+// have a position; the error lists the problems the command would report.
+// Outputs outside fsys are an error. Plugins run through o.RunPlugin, and
+// are an error without it; each line they write to stderr is a warning.
+// Only ctx ending the run early returns ctx.Err().
+func Run(ctx context.Context, fsys fs.FS, o *Options) (files map[string][]byte, warnings []string, err error) {
+	if o == nil {
+		o = DefaultOptions()
+	}
+	fsys = slashFS{fsys}
+	if o.Proto == "" && o.Namespace == "" {
+		cfg, err := loadConfig(fsys, flagOrDefault(o.Config, filepath.Join(o.Root, "blerpc.yaml")), o.Config != "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("load config: %w", err)
+		}
+		if len(cfg.Protos) > 0 {
+			return nil, nil, errors.New("protos in blerpc.yaml generates each proto on its own; call Run with the Proto and Namespace of each")
+		}
+	}
+	protoPath := flagOrDefault(o.Proto, filepath.Join(o.Root, "proto", "blerpc.proto"))
+	outputs, diags, err := parseAndGenerate(ctx, o, fsys, protoPath, io.Discard)
+	for _, d := range diags {
+		warnings = append(warnings, d.String())
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, warnings, ctx.Err()
+		}
+		return nil, warnings, runError(err)
+	}
+
+	files = make(map[string][]byte, len(outputs))
+	for _, out := range outputs {
+		if !filepath.IsLocal(out.Path) {
+			return nil, warnings, fmt.Errorf("output %s is outside the root", out.Path)
+		}
+		files[filepath.ToSlash(filepath.Clean(out.Path))] = []byte(out.Content)
+	}
+	return files, warnings, nil
+}
+
+// Generate is Run with target alone on, as -targets with one name runs the
+// command: target is one of Targets. The outputs of -out-* flags o sets,
+// such as the Go client, are generated as well.
+func Generate(ctx context.Context, fsys fs.FS, target string, o *Options) (files map[string][]byte, warnings []string, err error) {
+	if !slices.Contains(targets, target) {
+		return nil, nil, fmt.Errorf("unknown target %q (want one of %s)", target, strings.Join(targets, ", "))
+	}
+	if o == nil {
+		o = DefaultOptions()
+	}
+	o = o.clone()
+	o.Targets = target
+	return Run(ctx, fsys, o)
+}
+
+// Targets returns the names of the targets: section of blerpc.yaml, e.g. c
+// for the C handlers and python for the Python client.
+func Targets() []string {
+	return slices.Clone(targets)
+}
+
+// runError returns err with each of its problems on a line of its own,
+// placed like the diagnostics of the command.
+func runError(err error) error {
+	var p *problemsError
+	if !errors.As(err, &p) {
+		return errors.New(newDiagnostic("error", err.Error(), err).String())
+	}
+	lines := make([]string, len(p.errs))
+	for i, e := range p.errs {
+		msg := e.Error()
+		var se *protomodel.SchemaError
+		if errors.As(e, &se) {
+			msg = se.Msg
+		}
+		lines[i] = newDiagnostic("error", msg, e).String()
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// slashFS opens the paths the generator joins with filepath in an fs.FS,
+// whose names are clean and slash-separated.
+type slashFS struct {
+	fsys fs.FS
+}
+
+func (s slashFS) Open(name string) (fs.File, error) {
+	return s.fsys.Open(filepath.ToSlash(filepath.Clean(name)))
+}
@@ -0,0 +1,185 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// TestRunGolden checks that Run generates the golden files of each case, as
+// the command writes them.
+func TestRunGolden(t *testing.T) {
+	cases, err := os.ReadDir(filepath.Join("testdata", "golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if !c.IsDir() {
+			continue
+		}
+		t.Run(c.Name(), func(t *testing.T) {
+			root := filepath.Join("testdata", "golden", c.Name())
+			files, _, err := Run(context.Background(), os.DirFS(root), caseOptions(t, root))
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			want := filepath.Join(root, "want")
+			n := 0
+			filepath.WalkDir(want, func(path string, d os.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				n++
+				name := filepath.ToSlash(relPath(want, path))
+				data, _ := os.ReadFile(path)
+				if got, ok := files[name]; !ok {
+					t.Errorf("%s not generated", name)
+				} else if string(got) != string(data) {
+					t.Errorf("%s differs from the golden file", name)
+				}
+				return nil
+			})
+			if len(files) != n {
+				t.Errorf("generated %d files, want %d", len(files), n)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		proto string
+		set   func(o *Options)
+		want  string
+	}{
+		{"invalid", echoProto, func(o *Options) { o.CDispatch = "tree" }, `invalid -c-dispatch "tree"`},
+		{"schema", strings.Replace(echoProto, "  string message = 1;\n", "  string message = 1;\n  int32 message = 2;\n", 1), nil, "proto/blerpc.proto:6:3: "},
+		{"outside", echoProto, func(o *Options) { o.Outputs["c-header"] = "../handlers.h" }, "outside the root"},
+		{"protos", echoProto, func(o *Options) { o.Config = "multi.yaml" }, "protos in blerpc.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fstest.MapFS{
+				"proto/blerpc.proto": {Data: []byte(tt.proto)},
+				"multi.yaml":         {Data: []byte("protos:\n  - proto: proto/blerpc.proto\n")},
+			}
+			o := DefaultOptions()
+			if tt.set != nil {
+				tt.set(o)
+			}
+			_, _, err := Run(context.Background(), root, o)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// A failed run leaves the next one its defaults.
+	o := DefaultOptions()
+	o.Targets = "c"
+	files, _, err := Run(context.Background(), fstest.MapFS{"proto/blerpc.proto": {Data: []byte(echoProto)}}, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["peripheral_fw/src/generated_handlers.h"]; !ok {
+		t.Errorf("missing the C header: %v", files)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := Run(ctx, fstest.MapFS{"proto/blerpc.proto": {Data: []byte(echoProto)}}, nil); err != context.Canceled {
+		t.Errorf("Run with a canceled context: %v", err)
+	}
+}
+
+// TestRunConcurrent checks that runs with different options, which share no
+// state, give the files of the same runs one at a time.
+func TestRunConcurrent(t *testing.T) {
+	root := fstest.MapFS{"proto/blerpc.proto": {Data: []byte(echoProto)}}
+	modes := []string{"linear", "binary", "hash"}
+	want := make([]map[string][]byte, len(modes))
+	for i, mode := range modes {
+		o := DefaultOptions()
+		o.Targets = "c"
+		o.CDispatch = mode
+		files, _, err := Run(context.Background(), root, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[i] = files
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		for i, mode := range modes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				o := DefaultOptions()
+				o.Targets = "c"
+				o.CDispatch = mode
+				files, _, err := Run(context.Background(), root, o)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for name, data := range want[i] {
+					if string(files[name]) != string(data) {
+						t.Errorf("-c-dispatch %s: %s differs from a run on its own", mode, name)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestRunPlugins(t *testing.T) {
+	root := fstest.MapFS{
+		"proto/blerpc.proto": {Data: []byte(echoProto)},
+		"blerpc.yaml":        {Data: []byte("plugins:\n  qnx_hmi: tools/gen-qnx\n")},
+	}
+	o := DefaultOptions()
+	o.Targets = "c"
+	if _, _, err := Run(context.Background(), root, o); err == nil || !strings.Contains(err.Error(), "Options.RunPlugin is not set") {
+		t.Errorf("expected Run to refuse the plugin, got %v", err)
+	}
+
+	o.RunPlugin = func(ctx context.Context, name, path string, request []byte, stderr io.Writer) ([]byte, error) {
+		if name != "qnx_hmi" || path != "tools/gen-qnx" {
+			t.Errorf("got plugin %s at %s", name, path)
+		}
+		fmt.Fprintln(stderr, "no screens yet")
+		return []byte(`{"files":[{"path":"hmi/screens.txt","content":"echo"}]}`), nil
+	}
+	files, warnings, err := Run(context.Background(), root, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(files["hmi/screens.txt"]) != "echo" {
+		t.Errorf("plugin file not returned: %q", files["hmi/screens.txt"])
+	}
+	if !slices.ContainsFunc(warnings, func(w string) bool { return strings.Contains(w, "plugin qnx_hmi: no screens yet") }) {
+		t.Errorf("plugin stderr not in the warnings: %q", warnings)
+	}
+}
+
+const echoProto = `syntax = "proto3";
+package blerpc;
+
+message EchoRequest {
+  string message = 1;
+}
+
+message EchoResponse {
+  string message = 1;
+}
+`
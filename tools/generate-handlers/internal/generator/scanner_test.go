@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateScanner(t *testing.T) {
+	gctx := gen.NewContext()
+	tests := []struct {
+		name    string
+		out     string
+		want    []string
+		advOnly string
+	}{
+		{"python", python.GenerateScanner(gctx, false), []string{
+			"SERVICE_UUID = \"" + gctx.ServiceUUID + "\"\n",
+			"class DiscoveredDevice:\n",
+			"    devices = await client.scan(timeout=timeout, service_uuid=SERVICE_UUID)\n",
+		}, "parse_manufacturer_data"},
+		{"kotlin", kotlin.GenerateScanner(gctx, false, "blerpc"), []string{
+			"import com.blerpc.android.ble.ScannedDevice\n",
+			"    fun discover(device: ScannedDevice): DiscoveredDevice? {\n",
+			"        devices.mapNotNull { discover(it) }.sortedByDescending { it.rssi }\n",
+		}, "GeneratedAdvertising"},
+		{"swift", swift.GenerateScanner(gctx, false), []string{
+			"    static let serviceUUID = CBUUID(string: \"" + gctx.ServiceUUID + "\")\n",
+			"        guard device.serviceUUIDs.contains(serviceUUID) else {\n",
+		}, "GeneratedAdvertisement"},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.want {
+			if !strings.Contains(tt.out, s) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, s, tt.out)
+			}
+		}
+		if strings.Contains(tt.out, tt.advOnly) {
+			t.Errorf("%s: references %s without advertisements", tt.name, tt.advOnly)
+		}
+	}
+}
+
+func TestGenerateScanner_Advertisements(t *testing.T) {
+	gctx := gen.NewContext()
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateScanner(gctx, true), []string{
+			"from .generated_advertising import parse_manufacturer_data\n",
+			"    if require_advertisement and advertisement is None:\n",
+		}},
+		{"kotlin", kotlin.GenerateScanner(gctx, true, "blerpc"), []string{
+			"    val advertisement: MessageLite?,\n",
+			"device.manufacturerData[GeneratedAdvertising.COMPANY_ID]",
+		}},
+		{"swift", swift.GenerateScanner(gctx, true), []string{
+			"    let advertisement: GeneratedAdvertisement?\n",
+			"device.manufacturerData.flatMap(GeneratedAdvertisement.init(manufacturerData:))",
+		}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.want {
+			if !strings.Contains(tt.out, s) {
+				t.Errorf("%s: missing %q\nGot:\n%s", tt.name, s, tt.out)
+			}
+		}
+	}
+}
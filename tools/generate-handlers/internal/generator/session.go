@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// applySessionProtected marks the commands listed under session_protected in
+// blerpc.yaml and checks that every protected command is unary, not queued
+// and has the session built-in to authenticate with.
+func applySessionProtected(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for _, name := range cfg.SessionProtected {
+		i, ok := bySnake[name]
+		if !ok {
+			return fmt.Errorf("session_protected: unknown command %q", name)
+		}
+		commands[i].SessionProtected = true
+	}
+	_, _, sessions := gen.SessionCommands(commands)
+	for _, cmd := range commands {
+		if !cmd.SessionProtected {
+			continue
+		}
+		switch {
+		case !sessions:
+			return fmt.Errorf("%s: session protection requires the session built-in", cmd.Snake)
+		case cmd.Builtin == "session":
+			return fmt.Errorf("%s: the session commands cannot be session protected", cmd.Snake)
+		case streaming[cmd.Snake] != "":
+			return fmt.Errorf("%s: streaming commands cannot be session protected", cmd.Snake)
+		case cmd.QueueTTL > 0:
+			return fmt.Errorf("%s: session-protected commands cannot be queued", cmd.Snake)
+		}
+	}
+	return nil
+}
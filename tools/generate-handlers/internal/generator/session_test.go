@@ -0,0 +1,163 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/cpp"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/csharp"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/golang"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+const sessionSchema = "syntax = \"proto3\";\npackage blerpc;\n" +
+	"message EchoRequest { string message = 1; }\n" +
+	"message EchoResponse { string message = 1; }\n"
+
+func TestApplySessionProtected(t *testing.T) {
+	commands, _ := builtinSchema(t, sessionSchema, "session")
+	if _, _, ok := gen.SessionCommands(commands); !ok {
+		t.Fatalf("got %+v", commands)
+	}
+	queued := append([]gen.Command(nil), commands...)
+	queued[0].QueueTTL = 60
+	tests := []struct {
+		name      string
+		commands  []gen.Command
+		cfg       []string
+		streaming map[string]string
+		want      string
+	}{
+		{"unknown", commands, []string{"missing"}, nil, `unknown command "missing"`},
+		{"no builtin", []gen.Command{gentest.EchoCommand()}, []string{"echo"}, nil, "requires the session built-in"},
+		{"session command", commands, []string{"start_session"}, nil, "cannot be session protected"},
+		{"stream", commands, []string{"echo"}, map[string]string{"echo": "p2c"}, "streaming commands cannot be session protected"},
+		{"queued", queued, []string{"echo"}, nil, "cannot be queued"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := append([]gen.Command(nil), tt.commands...)
+			err := applySessionProtected(cmds, &Config{SessionProtected: tt.cfg}, tt.streaming)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	cmds := append([]gen.Command(nil), commands...)
+	if err := applySessionProtected(cmds, &Config{SessionProtected: []string{"echo"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !cmds[0].SessionProtected {
+		t.Error("echo should be session protected")
+	}
+}
+
+func TestGenerateSession(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, sessionSchema, "session")
+	if err := applySessionProtected(commands, &Config{SessionProtected: []string{"echo"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"#define BLERPC_SESSION_TOKEN_SIZE 8",
+		"bool blerpc_session_verify(const uint8_t *challenge, const uint8_t *proof,",
+		"bool blerpc_session_check(const uint8_t *token);",
+		"BLERPC_STATUS_UNAUTHENTICATED = 11,",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"static int authed_echo(const uint8_t *req_data, size_t req_len,\n                       pb_ostream_t *ostream)",
+		"if (req_len < BLERPC_SESSION_TOKEN_SIZE || !blerpc_session_check(req_data)) {",
+		"return blerpc_return_error(ostream, BLERPC_STATUS_UNAUTHENTICATED);",
+		"return handle_echo(req_data + BLERPC_SESSION_TOKEN_SIZE,",
+		`{"echo", 4, authed_echo},`,
+		"session_open = session_status == BLERPC_STATUS_OK;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import hmac\n",
+			"async def _session_call(client, command, req_data):",
+			"resp_data = await _session_call(self, \"echo\", req.SerializeToString())",
+			"proof=hmac.digest(key, resp.challenge, \"sha256\")",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import javax.crypto.Mac\n",
+			"var sessionKey: ByteArray? = null",
+			"val respData = exclusive { sessionCall(\"echo\", req.toByteArray()) }",
+			"if (statusException(cmdName, respData) !is UnauthenticatedException) return respData",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import CryptoKit\n",
+			"final class BlerpcSession: @unchecked Sendable {",
+			"    var session: BlerpcSession { get }\n",
+			"try await exclusive { try await sessionCall(cmdName: \"echo\", requestData: try req.serializedData()) }",
+		}},
+		{"go", golang.GenerateClient(commands, nil, "blerpc", "example.com/pb"), []string{
+			"\t\"crypto/hmac\"\n",
+			"\tSessionKey []byte\n",
+			"respData, err := c.sessionCall(ctx, \"echo\", reqData)",
+			"if err != nil || !bytes.Equal(respData, unauthenticated) {",
+		}},
+		{"dart", dart.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import 'package:crypto/crypto.dart';\n",
+			"List<int>? sessionKey;",
+			"() => _sessionCall('echo', req.writeToBuffer()));",
+			"..proof = Hmac(sha256, key).convert(challenge).bytes;",
+		}},
+		{"typescript", typescript.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"sessionKey?: Uint8Array;",
+			"this.sessionCall('echo', blerpc.EchoRequest.encode(req).finish()),",
+			"await crypto.subtle.sign('HMAC', key, challenge)",
+		}},
+		{"cpp", cpp.GenerateClient(commands, nil, "blerpc", "blerpc.pb.h"), []string{
+			"virtual std::string sessionProof(const std::string &data) = 0;",
+			"std::string resp_data = sessionCall(\"echo\", req.SerializeAsString());",
+			"static const std::string unauthenticated = {'\\xF8', '\\xFF', '\\xFF', '\\xFF', '\\x0F', '\\x0B'};",
+		}},
+		{"csharp", csharp.GenerateClient(commands, nil, "blerpc"), []string{
+			"using System.Security.Cryptography;\n",
+			"public byte[]? SessionKey { get; set; }",
+			"await Exclusive(() => SessionCallAsync(\"echo\", req.ToByteArray(), cancellationToken), cancellationToken)",
+			"using var hmac = new HMACSHA256(key);",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+
+	// Without the built-in the clients carry none of the session code.
+	if py := python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"); strings.Contains(py, "_session_call") {
+		t.Error("python: session helpers emitted without the session built-in")
+	}
+	if goClient := golang.GenerateClient([]gen.Command{gentest.EchoCommand()}, nil, "blerpc", "example.com/pb"); strings.Contains(goClient, "sessionCall") {
+		t.Error("go: session helpers emitted without the session built-in")
+	}
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// A message annotated with
+//
+//	option (blerpc.settings) = true;
+//
+// holds the persistent settings of the device. Its fields are read and
+// written one at a time through the get_setting and set_setting commands of
+// the settings built-in, which the annotation enables: both carry the field
+// number and the settings message, encoded, with that field filled in. The
+// firmware stores each field through the <pkg>_setting_load/_store hooks and
+// the clients get a typed get_<field>_setting/set_<field>_setting accessor
+// pair per field, replacing a getter/setter command pair per setting.
+
+// discoverSettings returns the message annotated with (blerpc.settings), or
+// nil when there is none.
+func discoverSettings(messages []gen.Message) (*gen.Message, error) {
+	var settings *gen.Message
+	for i, m := range messages {
+		if m.Options["blerpc.settings"] != "true" {
+			continue
+		}
+		if settings != nil {
+			return nil, fmt.Errorf("messages %s and %s are both annotated with (blerpc.settings)", settings.Name, m.Name)
+		}
+		settings = &messages[i]
+	}
+	return settings, nil
+}
+
+// checkSettingsFields rejects settings fields the hooks cannot fill in a
+// nanopb struct: only scalars and strings or bytes with a max_size are
+// supported.
+func checkSettingsFields(settings *gen.Message, limits map[string]protomodel.FieldLimits) error {
+	for _, f := range settings.Fields {
+		switch {
+		case f.IsRepeated || f.IsMap || f.IsMessage || f.IsEnum:
+			return fmt.Errorf("field %s.%s: settings fields must be scalars, strings or bytes", settings.Name, f.Name)
+		case (f.Type == "string" || f.Type == "bytes") && limits[settings.Name+"."+f.Name].MaxSize == 0:
+			return fmt.Errorf("field %s.%s: set max_size in the .options file so the setting fits the message struct", settings.Name, f.Name)
+		}
+	}
+	return nil
+}
+
+// applySettings attaches the settings message to the commands of the
+// settings built-in.
+func applySettings(commands []gen.Command, settings *gen.Message) {
+	for i := range commands {
+		if commands[i].Builtin == "settings" {
+			commands[i].Settings = settings
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+const settingsProto = "syntax = \"proto3\";\npackage blerpc;\n" +
+	"message DeviceSettings {\n  option (blerpc.settings) = true;\n" +
+	"  uint32 brightness = 1;\n  string device_name = 2;\n  bool led_enabled = 3;\n}\n"
+
+func TestDiscoverSettings(t *testing.T) {
+	pf, err := protomodel.ParseReader(strings.NewReader(settingsProto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := discoverSettings(pf.Messages)
+	if err != nil || settings == nil || settings.Name != "DeviceSettings" {
+		t.Fatalf("got %v, %v", settings, err)
+	}
+
+	pf.Messages = append(pf.Messages, gen.Message{Name: "MoreSettings", Options: map[string]string{"blerpc.settings": "true"}})
+	if _, err := discoverSettings(pf.Messages); err == nil || !strings.Contains(err.Error(), "both annotated") {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+}
+
+func TestCheckSettingsFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		field gen.Field
+		want  string
+	}{
+		{"enum", gen.Field{Name: "mode", Type: "Mode", IsEnum: true}, "must be scalars"},
+		{"repeated", gen.Field{Name: "ids", Type: "uint32", IsRepeated: true}, "must be scalars"},
+		{"string", gen.Field{Name: "device_name", Type: "string"}, "set max_size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSettingsFields(&gen.Message{Name: "DeviceSettings", Fields: []gen.Field{tt.field}}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	settings := &gen.Message{Name: "DeviceSettings", Fields: []gen.Field{{Name: "device_name", Type: "string"}}}
+	limits := map[string]protomodel.FieldLimits{"DeviceSettings.device_name": {MaxSize: 32}}
+	if err := checkSettingsFields(settings, limits); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBuiltinSettings(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, pf := builtinSchema(t, settingsProto, "settings")
+	settings, err := discoverSettings(pf.Messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applySettings(commands, settings)
+	get, set := gen.SettingsCommands(commands)
+	if get == nil || set == nil || get.Snake != "get_setting" || set.Snake != "set_setting" {
+		t.Fatalf("settings commands: got %+v", commands)
+	}
+
+	header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"int blerpc_setting_load(uint32_t field, blerpc_DeviceSettings *settings);",
+		"int blerpc_setting_store(uint32_t field, const blerpc_DeviceSettings *settings);",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q", want)
+		}
+	}
+
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"case blerpc_DeviceSettings_led_enabled_tag:",
+		"return pb_encode_submessage(stream, blerpc_DeviceSettings_fields, *arg);",
+		"if (blerpc_setting_load(req.field, &settings) != 0) return -1;",
+		"req.value.funcs.decode = decode_setting_value;",
+		"if (ostream->callback == NULL && blerpc_setting_store(req.field, &settings) != 0) {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"async def get_brightness_setting(self):",
+			"settings = blerpc_pb2.DeviceSettings(device_name=value)",
+			"await self.set_setting(field=3, value=settings.SerializeToString())",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"suspend fun getBrightnessSetting(): Int {",
+			"suspend fun setDeviceNameSetting(value: String) {",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"func getLedEnabledSetting() async throws -> Bool {",
+			"settings.deviceName = value",
+		}},
+		{"dart", dart.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"Future<int> getBrightnessSetting() async {",
+			"final settings = DeviceSettings()..ledEnabled = value;",
+		}},
+		{"ts", typescript.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"async getDeviceNameSetting(): Promise<string> {",
+			"await this.setSetting({ field: 1, value: settings });",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
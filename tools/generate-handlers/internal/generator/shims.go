@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"cmp"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// -compat-shims keeps app code written against a previous version of the
+// schema compiling after commands are renamed or response fields removed.
+// It takes that version as a proto or a document saved by -emit-model, e.g.
+// the one of the last release, and compares it with the schema like "diff".
+// A command that is gone, when a new command of the same kind took over its
+// wire name or else its request and response, gets the deprecated alias of
+// (blerpc.renamed_from) in every client, delegating to the new method, unless
+// the schema names one already. A response field that is gone, when its type
+// still exists, comes back in the Kotlin and Swift clients as a deprecated
+// read-only extension property returning the default of its type, which is
+// what an unset field reads; Python messages have no place for it, so Python
+// only gets the aliases. The shims ease porting an app; unlike -compat-check,
+// they do nothing for apps already deployed.
+
+// applyCompatShims records on commands the renamed commands and removed
+// response fields of the schema since prev.
+func applyCompatShims(commands []gen.Command, streaming map[string]string, prev gen.ModelDocument, protoFile *gen.ProtoFile) {
+	prevNames := make(map[string]bool, len(prev.Commands))
+	for _, old := range prev.Commands {
+		prevNames[old.Name] = true
+	}
+	names := make(map[string]bool, len(commands))
+	for _, cmd := range commands {
+		names[cmd.Snake] = true
+	}
+	for _, old := range prev.Commands {
+		if names[old.Name] || old.Builtin != "" {
+			continue
+		}
+		if i := renamedCommand(commands, streaming, old, prevNames); i >= 0 && commands[i].RenamedFrom == "" {
+			commands[i].RenamedFrom = old.Camel
+		}
+	}
+
+	prevMsgs := make(map[string]gen.ModelMessage, len(prev.Messages))
+	for _, m := range prev.Messages {
+		prevMsgs[m.Name] = m
+	}
+	msgs := make(map[string]gen.Message, len(protoFile.Messages))
+	for _, m := range protoFile.Messages {
+		msgs[m.Name] = m
+	}
+	enums := make(map[string]bool, len(protoFile.Enums))
+	for _, e := range protoFile.Enums {
+		enums[e.Name] = true
+	}
+	for i, cmd := range commands {
+		old, ok := prevMsgs[cmd.ResponseMsg]
+		if !ok || cmd.Builtin != "" {
+			continue
+		}
+		have := make(map[string]bool, len(cmd.ResponseFields))
+		for _, f := range cmd.ResponseFields {
+			have[f.Name] = true
+		}
+		for _, mf := range old.Fields {
+			if have[mf.Name] {
+				continue
+			}
+			if f, ok := shimField(mf, msgs, enums); ok {
+				commands[i].RemovedFields = append(commands[i].RemovedFields, f)
+			}
+		}
+	}
+}
+
+// renamedCommand returns the index of the new command, one not in
+// prevNames, that took over old: the one with its wire name, or else the
+// only one with its request and response. It returns -1 if there is none.
+func renamedCommand(commands []gen.Command, streaming map[string]string, old gen.ModelCommand, prevNames map[string]bool) int {
+	byMsgs := -1
+	for i, cmd := range commands {
+		if prevNames[cmd.Snake] || cmp.Or(streaming[cmd.Snake], "unary") != old.Stream {
+			continue
+		}
+		if cmd.Wire() == old.WireName {
+			return i
+		}
+		if cmd.RequestMsg == old.Request && cmd.ResponseMsg == old.Response {
+			if byMsgs >= 0 {
+				return -1
+			}
+			byMsgs = i
+		}
+	}
+	return byMsgs
+}
+
+// shimField returns the field of a previous version of a message, if the
+// clients can still name its type.
+func shimField(mf gen.ModelField, msgs map[string]gen.Message, enums map[string]bool) (gen.Field, bool) {
+	f := gen.Field{Name: mf.Name, Number: mf.Number, Type: mf.Type, IsRepeated: mf.Repeated, IsOptional: mf.Optional, Default: mf.Default}
+	resolve := func(typ string) (isMessage, isEnum bool, file string, ok bool) {
+		if m, found := msgs[typ]; found {
+			return true, false, m.File, true
+		}
+		if enums[typ] {
+			return false, true, "", true
+		}
+		_, scalar := gen.PythonDefaults[typ]
+		return false, false, "", scalar
+	}
+	if mf.Type == "map" {
+		f.IsMap, f.KeyType, f.ValueType = true, mf.KeyType, mf.ValueType
+		var ok bool
+		f.ValueIsMessage, _, f.TypeFile, ok = resolve(mf.ValueType)
+		_, scalarKey := gen.PythonDefaults[mf.KeyType]
+		return f, ok && scalarKey
+	}
+	var ok bool
+	f.IsMessage, f.IsEnum, f.TypeFile, ok = resolve(mf.Type)
+	return f, ok
+}
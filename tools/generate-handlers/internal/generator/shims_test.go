@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+// shimsPrev is a previous version of the schema of gentest.EchoCommand,
+// where echo was called ping and its response had two more fields.
+func shimsPrev() gen.ModelDocument {
+	return gen.ModelDocument{
+		Version: gen.ModelVersion,
+		Commands: []gen.ModelCommand{
+			{Name: "ping", Camel: "Ping", WireName: "ping", Stream: "unary", Request: "EchoRequest", Response: "EchoResponse"},
+		},
+		Messages: []gen.ModelMessage{{Name: "EchoResponse", Fields: []gen.ModelField{
+			{Name: "message", Number: 1, Type: "string"},
+			{Name: "uptime_ms", Number: 2, Type: "uint32"},
+			{Name: "stats", Number: 3, Type: "Stats"},
+		}}},
+	}
+}
+
+func TestApplyCompatShims(t *testing.T) {
+	commands := []gen.Command{gentest.EchoCommand()}
+	applyCompatShims(commands, nil, shimsPrev(), &gen.ProtoFile{})
+	if commands[0].RenamedFrom != "Ping" {
+		t.Errorf("RenamedFrom = %q, want Ping", commands[0].RenamedFrom)
+	}
+	// Stats is gone from the schema, so the clients cannot name it.
+	if len(commands[0].RemovedFields) != 1 || commands[0].RemovedFields[0].Name != "uptime_ms" {
+		t.Errorf("RemovedFields = %+v, want uptime_ms", commands[0].RemovedFields)
+	}
+
+	named := []gen.Command{gentest.EchoCommand()}
+	named[0].RenamedFrom = "Loopback"
+	applyCompatShims(named, nil, shimsPrev(), &gen.ProtoFile{})
+	if named[0].RenamedFrom != "Loopback" {
+		t.Errorf("RenamedFrom = %q, want the schema's Loopback", named[0].RenamedFrom)
+	}
+
+	streamed := []gen.Command{gentest.EchoCommand()}
+	applyCompatShims(streamed, map[string]string{"echo": "p2c"}, shimsPrev(), &gen.ProtoFile{})
+	if streamed[0].RenamedFrom != "" {
+		t.Errorf("RenamedFrom = %q, want none for a command of another kind", streamed[0].RenamedFrom)
+	}
+
+	twins := []gen.Command{gentest.EchoCommand(), gentest.EchoCommand()}
+	twins[1].Camel, twins[1].Snake = "Echo2", "echo2"
+	applyCompatShims(twins, nil, shimsPrev(), &gen.ProtoFile{})
+	if twins[0].RenamedFrom != "" || twins[1].RenamedFrom != "" {
+		t.Errorf("RenamedFrom = %q, %q; want none when two commands match", twins[0].RenamedFrom, twins[1].RenamedFrom)
+	}
+}
+
+func TestRemovedFieldAccessors(t *testing.T) {
+	commands := []gen.Command{gentest.EchoCommand()}
+	applyCompatShims(commands, nil, shimsPrev(), &gen.ProtoFile{})
+
+	var kt strings.Builder
+	kotlin.WriteRemovedFields(&kt, commands, "blerpc", "Blerpc")
+	for _, want := range []string{
+		`@Deprecated("Field 2 was removed from the schema; reads the default")`,
+		"val blerpc.Blerpc.EchoResponse.uptimeMs: Int\n    get() = 0\n",
+	} {
+		if !strings.Contains(kt.String(), want) {
+			t.Errorf("Kotlin shims missing %q:\n%s", want, kt.String())
+		}
+	}
+
+	var swift strings.Builder
+	swiftgen.WriteRemovedFields(&swift, commands, "Blerpc_")
+	for _, want := range []string{
+		"extension Blerpc_EchoResponse {\n",
+		`@available(*, deprecated, message: "Field 2 was removed from the schema; reads the default")`,
+		"var uptimeMs: UInt32 { 0 }\n",
+	} {
+		if !strings.Contains(swift.String(), want) {
+			t.Errorf("Swift shims missing %q:\n%s", want, swift.String())
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package generator
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// Large schemas produce generated files of many thousand lines. With -split
+// the C handler source, the Python client and the Swift client are written as
+// one file per command (per-command) or per proto service (per-group), plus
+// an index file at the usual path that ties the pieces together, so the
+// public API is the same as in single-file mode. The other targets are
+// always written as a single file.
+
+// splitModes lists the accepted -split values.
+var splitModes = []string{"none", "per-command", "per-group"}
+
+// replaceOutput substitutes the output at path with the given split files.
+func replaceOutput(outputs []gen.Output, path string, files []gen.Output) []gen.Output {
+	for i, out := range outputs {
+		if out.Path == path {
+			rest := append(files, outputs[i+1:]...)
+			return append(outputs[:i], rest...)
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+)
+
+func outputsByPath(outputs []gen.Output) map[string]string {
+	byPath := make(map[string]string)
+	for _, out := range outputs {
+		byPath[out.Path] = out.Content
+	}
+	return byPath
+}
+
+func TestReplaceOutput(t *testing.T) {
+	outputs := []gen.Output{{Path: "a", Content: "1"}, {Path: "b", Content: "2"}, {Path: "c", Content: "3"}}
+	got := replaceOutput(outputs, "b", []gen.Output{{Path: "b", Content: "x"}, {Path: "b_1", Content: "y"}})
+	var paths []string
+	for _, out := range got {
+		paths = append(paths, out.Path)
+	}
+	if strings.Join(paths, ",") != "a,b,b_1,c" {
+		t.Errorf("paths = %v", paths)
+	}
+}
+
+func TestSplitPyClient(t *testing.T) {
+	gctx := gen.NewContext()
+	stream := gentest.StreamP2CCommand()
+	stream.RenamedFrom = "CountStream"
+	cmds := []gen.Command{gentest.EchoCommand(), checkedStatusCommand(t), stream}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	groups := gen.GroupCommands(cmds, "per-command", "blerpc")
+	dir := filepath.Join("blerpc", "generated", "generated_client")
+	files := outputsByPath(python.SplitClient(gctx, groups, cmds, streaming, "blerpc", dir+".py"))
+
+	if len(files) != 5 {
+		t.Fatalf("expected _base, __init__ and 3 command modules, got %d files", len(files))
+	}
+	base := files[filepath.Join(dir, "_base.py")]
+	for _, s := range []string{
+		"from google.protobuf import message\n",
+		"class TimeoutError(TransportError, builtins.TimeoutError):",
+		"class CommandStatusError(RemoteError):",
+		"def _decode(resp, data, command):",
+	} {
+		if !strings.Contains(base, s) {
+			t.Errorf("_base.py missing %q\nGot:\n%s", s, base)
+		}
+	}
+
+	flashWrite := files[filepath.Join(dir, "flash_write.py")]
+	for _, s := range []string{
+		"from .. import blerpc_pb2\nfrom ._base import CommandStatusError, RpcTransport, _decode, _rpc_lock\n",
+		"class FlashWriteMixin(RpcTransport):",
+		"    async def flash_write(self) -> blerpc_pb2.FlashWriteResponse:",
+		"raise CommandStatusError(",
+	} {
+		if !strings.Contains(flashWrite, s) {
+			t.Errorf("flash_write.py missing %q\nGot:\n%s", s, flashWrite)
+		}
+	}
+	counter := files[filepath.Join(dir, "counter_stream.py")]
+	if !strings.Contains(counter, "import warnings\n") || !strings.Contains(counter, "async def count_stream(self, *args, **kwargs):") {
+		t.Errorf("counter_stream.py missing deprecated alias:\n%s", counter)
+	}
+	if strings.Contains(files[filepath.Join(dir, "echo.py")], "warnings") {
+		t.Error("echo.py should not import warnings")
+	}
+
+	init := files[filepath.Join(dir, "__init__.py")]
+	for _, s := range []string{
+		"from .counter_stream import CounterStreamMixin\nfrom .echo import EchoMixin\nfrom .flash_write import FlashWriteMixin\n",
+		"class GeneratedClientMixin(EchoMixin, FlashWriteMixin, CounterStreamMixin):",
+		"    \"CommandStatusError\",",
+		"COMMAND_MESSAGES = {",
+		"def request_from_json(cmd_name, text):",
+	} {
+		if !strings.Contains(init, s) {
+			t.Errorf("__init__.py missing %q\nGot:\n%s", s, init)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// applyStatusChecks marks the commands listed in the status config with the
+// response field to check and the value that means success.
+func applyStatusChecks(commands []gen.Command, cfg *Config, enumByName map[string]gen.Enum) error {
+	st := cfg.Status
+	if st == nil {
+		return nil
+	}
+	en, ok := enumByName[st.Enum]
+	if !ok {
+		return fmt.Errorf("status: unknown enum %q", st.Enum)
+	}
+	okValue := 0
+	if st.OK != "" {
+		found := false
+		for _, v := range en.Values {
+			if v.Name == st.OK {
+				okValue, found = v.Number, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("status: enum %s has no value %q", st.Enum, st.OK)
+		}
+	}
+
+	bySnake := make(map[string]int)
+	for i, cmd := range commands {
+		bySnake[cmd.Snake] = i
+	}
+	for _, name := range st.Commands {
+		i, ok := bySnake[name]
+		if !ok {
+			return fmt.Errorf("status: unknown command %q", name)
+		}
+		field := ""
+		for _, f := range commands[i].ResponseFields {
+			if f.IsEnum && !f.IsRepeated && f.Type == st.Enum {
+				field = f.Name
+				break
+			}
+		}
+		if field == "" {
+			return fmt.Errorf("status: %s has no %s field", commands[i].ResponseMsg, st.Enum)
+		}
+		commands[i].StatusField = field
+		commands[i].StatusOK = okValue
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func statusEnums() map[string]gen.Enum {
+	return map[string]gen.Enum{
+		"Status": {Name: "Status", Values: []gen.EnumValue{
+			{Name: "STATUS_UNKNOWN", Number: 0},
+			{Name: "STATUS_OK", Number: 1},
+			{Name: "STATUS_BUSY", Number: 2},
+		}},
+	}
+}
+
+func statusCommand() gen.Command {
+	return gen.Command{
+		Camel:       "FlashWrite",
+		Snake:       "flash_write",
+		RequestMsg:  "FlashWriteRequest",
+		ResponseMsg: "FlashWriteResponse",
+		ResponseFields: []gen.Field{
+			{Type: "uint32", Name: "written", Number: 1},
+			{Type: "Status", Name: "status", Number: 2, IsEnum: true},
+		},
+	}
+}
+
+func checkedStatusCommand(t *testing.T) gen.Command {
+	t.Helper()
+	cmds := []gen.Command{statusCommand()}
+	cfg := &Config{Status: &StatusConfig{Enum: "Status", OK: "STATUS_OK", Commands: []string{"flash_write"}}}
+	if err := applyStatusChecks(cmds, cfg, statusEnums()); err != nil {
+		t.Fatalf("applyStatusChecks: %v", err)
+	}
+	return cmds[0]
+}
+
+func TestApplyStatusChecks(t *testing.T) {
+	cmd := checkedStatusCommand(t)
+	if cmd.StatusField != "status" || cmd.StatusOK != 1 {
+		t.Errorf("unexpected status check: field=%q ok=%d", cmd.StatusField, cmd.StatusOK)
+	}
+
+	// Without ok the zero value means success; unlisted commands stay unchecked.
+	cmds := []gen.Command{statusCommand(), gentest.EchoCommand()}
+	cfg := &Config{Status: &StatusConfig{Enum: "Status", Commands: []string{"flash_write"}}}
+	if err := applyStatusChecks(cmds, cfg, statusEnums()); err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].StatusOK != 0 || cmds[1].StatusField != "" {
+		t.Errorf("unexpected status checks: %+v", cmds)
+	}
+}
+
+func TestApplyStatusChecks_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		st   StatusConfig
+		want string
+	}{
+		{"unknown enum", StatusConfig{Enum: "Nope"}, `unknown enum "Nope"`},
+		{"unknown ok", StatusConfig{Enum: "Status", OK: "FINE"}, `has no value "FINE"`},
+		{"unknown command", StatusConfig{Enum: "Status", Commands: []string{"nope"}}, `unknown command "nope"`},
+		{"no status field", StatusConfig{Enum: "Status", Commands: []string{"echo"}}, "EchoResponse has no Status field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := []gen.Command{statusCommand(), gentest.EchoCommand()}
+			st := tt.st
+			err := applyStatusChecks(cmds, &Config{Status: &st}, statusEnums())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGeneratePyClient_StatusCheck(t *testing.T) {
+	gctx := gen.NewContext()
+	out := python.GenerateClient(gctx, []gen.Command{checkedStatusCommand(t), gentest.EchoCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"class CommandStatusError(RemoteError):",
+		"        if resp.status != 1:\n            raise CommandStatusError(\"flash_write\", \"status\", resp.status)\n        return resp\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Count(out, "raise CommandStatusError") != 1 {
+		t.Error("only opted-in commands should check the status")
+	}
+	if strings.Contains(python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"), "CommandStatusError") {
+		t.Error("CommandStatusError should only be generated when used")
+	}
+}
+
+func TestGenerateKotlinClient_StatusCheck(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := checkedStatusCommand(t)
+	stream := checkedStatusCommand(t)
+	stream.Snake, stream.Camel = "flash_dump", "FlashDump"
+	streaming := map[string]string{"flash_dump": "p2c"}
+	out := kotlin.GenerateClient(gctx, []gen.Command{cmd, stream}, streaming, "blerpc")
+
+	mustContain := []string{
+		"class CommandStatusException(",
+		") : RemoteException(command, status, \"$command failed: $field=$status\")",
+		"        val resp = decode(\"flash_write\", respData) { blerpc.Blerpc.FlashWriteResponse.parseFrom(it) }\n" +
+			"        if (resp.statusValue != 1) throw CommandStatusException(\"flash_write\", \"status\", resp.statusValue)\n" +
+			"        return resp\n",
+		"            if (resp.statusValue != 1) throw CommandStatusException(\"flash_dump\", \"status\", resp.statusValue)\n            resp\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_StatusCheck(t *testing.T) {
+	gctx := gen.NewContext()
+	out := swift.GenerateClient(gctx, []gen.Command{checkedStatusCommand(t)}, nil, "blerpc")
+
+	mustContain := []string{
+		"struct CommandStatusError: RemoteError {",
+		"        let resp = try decode(\"flash_write\", respData) { try Blerpc_FlashWriteResponse(serializedBytes: $0) }\n" +
+			"        if resp.status.rawValue != 1 {\n" +
+			"            throw CommandStatusError(command: \"flash_write\", field: \"status\", status: resp.status.rawValue)\n" +
+			"        }\n" +
+			"        return resp\n",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestParseConfig_Status(t *testing.T) {
+	cfg, err := parseConfig([]byte("status:\n  enum: Status\n  ok: STATUS_OK\n  commands: [flash_write]\n"))
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if cfg.Status == nil || cfg.Status.Enum != "Status" || len(cfg.Status.Commands) != 1 {
+		t.Errorf("unexpected status config: %+v", cfg.Status)
+	}
+	if _, err := parseConfig([]byte("status:\n  commands: [x]\n")); err == nil {
+		t.Error("status without enum should fail")
+	}
+}
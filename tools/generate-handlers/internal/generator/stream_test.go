@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestGenerateServerStreams(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	streaming := map[string]string{"counter_stream": "p2c"}
+
+	outputs := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c header", c.GenerateHeader(gctx, cmds, streaming, nil, "blerpc"), []string{
+			"int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len);",
+			"int blerpc_stream_end(void);",
+			"struct _blerpc_CounterStreamResponse;\nint blerpc_counter_stream_emit(const struct _blerpc_CounterStreamResponse *resp);",
+		}},
+		{"c source", c.GenerateSource(gctx, cmds, streaming, nil, "blerpc"), []string{
+			"__attribute__((weak))\nint blerpc_stream_finish(void)\n{\n    return -1;\n}",
+			"    return blerpc_stream_finish() == 0 ? -2 : -1;\n",
+			"int blerpc_counter_stream_emit(const blerpc_CounterStreamResponse *resp)\n{\n    uint8_t buf[blerpc_CounterStreamResponse_size];\n",
+			"    return blerpc_stream_write(\"counter_stream\", buf, out.bytes_written);\n",
+			"    /* Send each response with blerpc_counter_stream_emit() */\n    return blerpc_stream_end();\n",
+		}},
+		{"python", python.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"    async def iter_counter_stream(\n        self, *, start: int = 0\n    ) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:\n",
+			"                yield resp\n",
+			"        async for resp in self.iter_counter_stream(start=start):\n            results.append(resp)\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"open fun streamReceiveFlow(",
+			"    open fun counterStreamFlow(start: Int = 0): Flow<blerpc.Blerpc.CounterStreamResponse> {\n",
+			"streamReceiveFlow(\"counter_stream\", req.toByteArray()).collect {\n                        emit(decode(\"counter_stream\", it)",
+		}},
+		{"swift", swift.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"func streamReceiveStream(cmdName: String, requestData: Data) -> AsyncThrowingStream<Data, Error>\n",
+			"    func counterStreamResponses(start: UInt32 = 0) -> AsyncThrowingStream<Blerpc_CounterStreamResponse, Error> {\n",
+			"for try await data in responses {",
+			"continuation.onTermination = { _ in task.cancel() }",
+		}},
+	}
+	for _, tt := range outputs {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+
+	// Unbounded responses are encoded into a configurable buffer.
+	callbacks := map[string]bool{"CounterStreamResponse.count": true}
+	if src := c.GenerateSource(gctx, cmds, streaming, callbacks, "blerpc"); !strings.Contains(src, "#define BLERPC_STREAM_BUF_SIZE 256") ||
+		!strings.Contains(src, "uint8_t buf[BLERPC_STREAM_BUF_SIZE];") {
+		t.Errorf("unbounded response not sized by BLERPC_STREAM_BUF_SIZE:\n%s", src)
+	}
+	if src := c.GenerateSource(gctx, []gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc"); strings.Contains(src, "stream_write") {
+		t.Error("stream hooks emitted without streams")
+	}
+}
+
+func TestGenerateClientStreams(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamC2PCommand()}
+	streaming := map[string]string{"counter_upload": "c2p"}
+
+	src := c.GenerateSource(gctx, cmds, streaming, nil, "blerpc")
+	outputs := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"c header", c.GenerateHeader(gctx, cmds, streaming, nil, "blerpc"), []string{
+			"int blerpc_stream_end_c2p(void);",
+			"int blerpc_counter_upload_accumulate(const struct _blerpc_CounterUploadRequest *req);",
+			"int blerpc_counter_upload_finalize(struct _blerpc_CounterUploadResponse *resp);",
+		}},
+		{"c source", src, []string{
+			"static int (*stream_c2p_end)(void);",
+			"    if (blerpc_counter_upload_finalize(&resp) != 0) return -1;\n",
+			"    return blerpc_stream_write(\"counter_upload\", buf, out.bytes_written);\n",
+			"    if (blerpc_counter_upload_accumulate(&req) != 0) return -1;\n    stream_c2p_end = end_counter_upload;\n",
+			"    return end != NULL ? end() : -1;\n",
+		}},
+		{"python", python.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"from collections.abc import AsyncIterable, AsyncIterator, Iterable\n",
+			"def _serialize_each(messages):",
+			"        raw = _serialize_each(messages)\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"import kotlinx.coroutines.flow.map\n",
+			"    open suspend fun streamSendFlow(\n",
+			"    open suspend fun counterUpload(messages: Flow<blerpc.Blerpc.CounterUploadRequest>): blerpc.Blerpc.CounterUploadResponse {\n",
+			"exclusive { streamSendFlow(\"counter_upload\", raw, \"counter_upload\") }",
+		}},
+		{"swift", swift.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{
+			"    func streamSend<S: AsyncSequence>(cmdName: String, messages: S, finalCmdName: String) async throws -> Data\n        where S.Element == Data\n",
+			"    func counterUpload<S: AsyncSequence>(messages: S) async throws -> Blerpc_CounterUploadResponse where S.Element == Blerpc_CounterUploadRequest {\n",
+		}},
+	}
+	for _, tt := range outputs {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+	// The generated handler replaces the plain stub.
+	if n := strings.Count(src, "int handle_counter_upload("); n != 1 {
+		t.Errorf("handle_counter_upload defined %d times", n)
+	}
+}
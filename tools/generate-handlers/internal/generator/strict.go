@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// -strict fails on the schema constructs the generators otherwise fall back
+// on: a command field whose proto type a client language has no type for,
+// which that client declares as Any, dynamic, unknown or object, and, in a
+// schema with services, a <Name>Request message no RPC uses, which is not
+// generated at all. Mistakes such as a misspelled type then stop the
+// generator instead of the mobile builds.
+
+// strictLanguages maps the client targets to their scalar types and the type
+// a field without one falls back to.
+var strictLanguages = []struct {
+	target   string
+	types    map[string]string
+	lang     string
+	fallback string
+}{
+	{"python", gen.PythonTypes, "python", "object"},
+	{"kotlin", gen.KotlinTypes, "kotlin", "Any"},
+	{"swift", gen.SwiftTypes, "swift", "Any"},
+	{"dart", gen.DartTypes, "dart", "dynamic"},
+	{"typescript", gen.TsTypes, "ts", "unknown"},
+}
+
+// strictProblems returns the constructs -strict rejects among commands, as
+// generated for the targets cfg enables, and the messages of file.
+func strictProblems(commands []gen.Command, file *gen.ProtoFile, cfg *Config) []error {
+	var errs []error
+	type fieldKey struct{ msg, field string }
+	var order []fieldKey
+	unsupported := make(map[fieldKey][]string)
+	fieldOf := make(map[fieldKey]gen.Field)
+	for _, l := range strictLanguages {
+		if !cfg.targetEnabled(l.target) {
+			continue
+		}
+		for _, cmd := range targetCommands(commands, l.target) {
+			for _, part := range []struct {
+				msg    string
+				fields []gen.Field
+			}{{cmd.RequestMsg, cmd.RequestFields}, {cmd.ResponseMsg, cmd.ResponseFields}} {
+				for _, f := range part.fields {
+					if _, ok := gen.FieldTypeOverride(f, l.lang); ok || strictTypeSupported(f, l.types) {
+						continue
+					}
+					key := fieldKey{part.msg, f.Name}
+					if _, seen := unsupported[key]; !seen {
+						order = append(order, key)
+						fieldOf[key] = f
+					}
+					desc := l.target + " (" + l.fallback + ")"
+					if !slices.Contains(unsupported[key], desc) {
+						unsupported[key] = append(unsupported[key], desc)
+					}
+				}
+			}
+		}
+	}
+	for _, key := range order {
+		f := fieldOf[key]
+		errs = append(errs, &protomodel.SchemaError{Pos: f.Pos, Msg: fmt.Sprintf("%s.%s: %s has no type in %s; map it under type_mappings in blerpc.yaml or use a supported type", key.msg, key.field, fieldTypeName(f), strings.Join(unsupported[key], ", "))})
+	}
+
+	if len(file.Services) > 0 {
+		used := make(map[string]bool, len(commands))
+		for _, cmd := range commands {
+			used[cmd.RequestMsg] = true
+		}
+		for _, m := range file.Messages {
+			if m.File == "" && !strings.Contains(m.Name, ".") && strings.HasSuffix(m.Name, "Request") && !used[m.Name] {
+				errs = append(errs, &protomodel.SchemaError{Pos: m.Pos, Msg: fmt.Sprintf("%s is not the request of any RPC, so no command is generated for it", m.Name)})
+			}
+		}
+	}
+	return errs
+}
+
+// strictTypeSupported reports whether a client with the scalar types types
+// has a type for f. Enums and messages always have one.
+func strictTypeSupported(f gen.Field, types map[string]string) bool {
+	if f.IsMap {
+		_, key := types[f.KeyType]
+		_, value := types[f.ValueType]
+		return key && (value || f.ValueIsMessage)
+	}
+	if f.IsEnum || f.IsMessage {
+		return true
+	}
+	_, ok := types[f.Type]
+	return ok
+}
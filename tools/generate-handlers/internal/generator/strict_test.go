@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestStrictProblems(t *testing.T) {
+	cmd := gentest.EchoCommand()
+	cmd.RequestFields = append(cmd.RequestFields,
+		gen.Field{Name: "offset", Number: 2, Type: "sint32"},
+		gen.Field{Name: "labels", Number: 3, IsMap: true, KeyType: "string", ValueType: "Color"},
+		gen.Field{Name: "when", Number: 4, Type: "Timestamp", IsMessage: true},
+	)
+	file := &gen.ProtoFile{
+		Services: []gen.Service{{Name: "Device"}},
+		Messages: []gen.Message{{Name: "EchoRequest"}, {Name: "ResetRequest"}, {Name: "Point"}, {Name: "Outer.InnerRequest"}},
+	}
+
+	errs := strictProblems([]gen.Command{cmd}, file, &Config{})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"EchoRequest.labels: map<string, Color> has no type in python (object), kotlin (Any), swift (Any), dart (dynamic), typescript (unknown)",
+		"ResetRequest is not the request of any RPC",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("problem %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// A type mapping, or a disabled target, settles the field for that client.
+	cmd.RequestFields = cmd.RequestFields[:3]
+	cmd.RequestFields[2].TypeOverrides = map[string]gen.TypeOverride{"python": {Type: "dict"}, "kotlin": {Type: "Map<String, Int>"}}
+	cfg := &Config{Targets: map[string]bool{"swift": false, "dart": false, "typescript": false}}
+	if errs := strictProblems([]gen.Command{cmd}, &gen.ProtoFile{}, cfg); len(errs) != 0 {
+		t.Errorf("expected no problems, got %v", errs)
+	}
+}
@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// A string field with a max_size in the .options file is a char array in
+// the nanopb struct, its max_size counting the terminating NUL. A longer
+// string fails to decode on the peripheral with no hint of which field was
+// too long, and a name that fits in characters may not fit in bytes once a
+// user types it in another script. The Python, Kotlin and Swift clients
+// check the UTF-8 length of such arguments and raise StringTooLongError
+// (StringTooLongException) before sending.
+
+// setStringMaxBytes sets the MaxBytes of the bounded string fields of every
+// command.
+func setStringMaxBytes(commands []gen.Command, limits map[string]protomodel.FieldLimits, callbacks map[string]bool) {
+	set := func(msg string, fields []gen.Field) {
+		for i, f := range fields {
+			key := msg + "." + f.Name
+			if f.Type == "string" && !f.IsRepeated && !f.IsMap && !callbacks[key] && limits[key].MaxSize > 0 {
+				fields[i].MaxBytes = limits[key].MaxSize - 1
+			}
+		}
+	}
+	for i := range commands {
+		set(commands[i].RequestMsg, commands[i].RequestFields)
+		set(commands[i].ResponseMsg, commands[i].ResponseFields)
+	}
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// boundedStringCommand is gentest.EchoCommand with a max_size of 32 on its
+// message.
+func boundedStringCommand() gen.Command {
+	commands := []gen.Command{gentest.EchoCommand()}
+	limits := map[string]protomodel.FieldLimits{
+		"EchoRequest.message":  {MaxSize: 32},
+		"EchoResponse.message": {MaxSize: 32},
+	}
+	setStringMaxBytes(commands, limits, nil)
+	return commands[0]
+}
+
+func TestSetStringMaxBytes(t *testing.T) {
+	cmd := boundedStringCommand()
+	if cmd.RequestFields[0].MaxBytes != 31 || cmd.ResponseFields[0].MaxBytes != 31 {
+		t.Errorf("MaxBytes = %d, %d; want 31, 31", cmd.RequestFields[0].MaxBytes, cmd.ResponseFields[0].MaxBytes)
+	}
+
+	// FT_CALLBACK strings have no char array to bound.
+	commands := []gen.Command{gentest.EchoCommand()}
+	limits := map[string]protomodel.FieldLimits{"EchoRequest.message": {MaxSize: 32}}
+	setStringMaxBytes(commands, limits, map[string]bool{"EchoRequest.message": true})
+	if commands[0].RequestFields[0].MaxBytes != 0 {
+		t.Errorf("MaxBytes of callback field = %d, want 0", commands[0].RequestFields[0].MaxBytes)
+	}
+}
+
+func TestBoundedStringClients(t *testing.T) {
+	gctx := gen.NewContext()
+	cmds := []gen.Command{boundedStringCommand()}
+
+	py := python.GenerateClient(gctx, cmds, nil, "blerpc")
+	for _, s := range []string{
+		"class StringTooLongError(BlerpcError, ValueError):",
+		"        if len(message.encode()) > 31:\n            raise StringTooLongError(\"echo\", \"message\", len(message.encode()), 31)\n        req = ",
+	} {
+		if !strings.Contains(py, s) {
+			t.Errorf("Python client missing %q\nGot:\n%s", s, py)
+		}
+	}
+
+	kt := kotlin.GenerateClient(gctx, cmds, nil, "blerpc")
+	for _, s := range []string{
+		") : BlerpcException(\"$command: $field is $size bytes in UTF-8, over the $maxBytes its max_size allows\")",
+		"        if (message.encodeToByteArray().size > 31) {\n            throw StringTooLongException(\"echo\", \"message\", message.encodeToByteArray().size, 31)\n        }\n",
+	} {
+		if !strings.Contains(kt, s) {
+			t.Errorf("Kotlin client missing %q\nGot:\n%s", s, kt)
+		}
+	}
+
+	swift := swiftgen.GenerateClient(gctx, cmds, nil, "blerpc")
+	for _, s := range []string{
+		"struct StringTooLongError: BlerpcErrorProtocol {",
+		"        if message.utf8.count > 31 {\n            throw StringTooLongError(command: \"echo\", field: \"message\", size: message.utf8.count, maxBytes: 31)\n        }\n",
+	} {
+		if !strings.Contains(swift, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, swift)
+		}
+	}
+
+	for name, out := range map[string]string{
+		"python": python.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"),
+		"kotlin": kotlin.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"),
+		"swift":  swiftgen.GenerateClient(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc"),
+	} {
+		if strings.Contains(out, "StringTooLong") {
+			t.Errorf("%s client without bounded string fields should not declare StringTooLong", name)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// blerpc.yaml can turn targets off, move any output and rename the
+// per-language packages, e.g.
+//
+//	targets:
+//	  dart: false
+//	  typescript: false
+//	outputs:
+//	  kt-client: app/src/main/java/com/example/ble/GeneratedClient.kt
+//	names:
+//	  kotlin_package: com.example.ble
+//	  swift_prefix: Ble_
+//	  python_pb2_module: myapp.proto.blerpc_pb2
+//	  c_handler_prefix: sensor_handle_
+//	  c_prefix: sensor_
+//	  python_method_style: camel
+//	  kotlin_client_class: SensorClient
+//	  swift_type_prefix: Sensor
+//
+// Output keys are the -out-* flag names without the out- prefix and paths
+// are relative to -root; flags given on the command line take precedence.
+
+// targets lists the targets blerpc.yaml can turn off; each is on by default.
+// Optional outputs such as -out-go-tui stay off until their flag is set.
+var targets = []string{"c", "c_client", "python", "python_handlers", "kotlin", "swift", "dart", "typescript", "docs", "docs_html"}
+
+// reIdentifier matches the prefixes and type names of names:, which are
+// valid identifiers in every target language.
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateTargets(cfg *Config) error {
+	for _, name := range slices.Sorted(maps.Keys(cfg.Targets)) {
+		if !slices.Contains(targets, name) {
+			return fmt.Errorf("targets: unknown target %q (want one of %s)", name, strings.Join(targets, ", "))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(cfg.Outputs)) {
+		if isOutput(key) {
+			continue
+		}
+		return fmt.Errorf("outputs: unknown output %q (use an -out-* flag name without out-)", key)
+	}
+	if m := cfg.Names.PythonPb2Module; m != "" && (strings.HasPrefix(m, ".") || strings.HasSuffix(m, ".")) {
+		return fmt.Errorf("names: python_pb2_module %q must be an absolute module name", m)
+	}
+	for _, n := range []struct{ key, value string }{
+		{"c_handler_prefix", cfg.Names.CHandlerPrefix},
+		{"c_prefix", cfg.Names.CPrefix},
+		{"kotlin_client_class", cfg.Names.KotlinClientClass},
+		{"swift_type_prefix", cfg.Names.SwiftTypePrefix},
+	} {
+		if n.value != "" && !reIdentifier.MatchString(n.value) {
+			return fmt.Errorf("names: %s %q is not an identifier", n.key, n.value)
+		}
+	}
+	if s := cfg.Names.PythonMethodStyle; s != "" && s != "snake" && s != "camel" {
+		return fmt.Errorf("names: python_method_style %q (want snake or camel)", s)
+	}
+	return nil
+}
+
+// applyTargetsFlag limits generation to the comma-separated targets of
+// -targets, overriding the targets: section of blerpc.yaml. Names may use
+// dashes for underscores, e.g. python-handlers.
+func applyTargetsFlag(cfg *Config, value string) error {
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ReplaceAll(strings.TrimSpace(name), "-", "_")
+		if !slices.Contains(targets, name) {
+			return fmt.Errorf("unknown target %q (want one of %s)", name, strings.Join(targets, ", "))
+		}
+		selected[name] = true
+	}
+	cfg.Targets = make(map[string]bool)
+	for _, name := range targets {
+		cfg.Targets[name] = selected[name]
+	}
+	return nil
+}
+
+// targetEnabled reports whether blerpc.yaml leaves target on.
+func (c *Config) targetEnabled(target string) bool {
+	on, ok := c.Targets[target]
+	return !ok || on
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	cgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestTargetEnabled(t *testing.T) {
+	cfg, err := parseConfig([]byte("targets: {dart: false, swift: true}\n"))
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	for target, want := range map[string]bool{"dart": false, "swift": true, "kotlin": true} {
+		if got := cfg.targetEnabled(target); got != want {
+			t.Errorf("targetEnabled(%q) = %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestApplyTargetsFlag(t *testing.T) {
+	cfg, err := parseConfig([]byte("targets: {c: false}\n"))
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if err := applyTargetsFlag(cfg, "c, python-handlers"); err != nil {
+		t.Fatalf("applyTargetsFlag: %v", err)
+	}
+	for target, want := range map[string]bool{"c": true, "python_handlers": true, "python": false, "swift": false} {
+		if got := cfg.targetEnabled(target); got != want {
+			t.Errorf("targetEnabled(%q) = %v, want %v", target, got, want)
+		}
+	}
+
+	if err := applyTargetsFlag(&Config{}, "c,java"); err == nil || !strings.Contains(err.Error(), `unknown target "java"`) {
+		t.Errorf("expected unknown target error, got %v", err)
+	}
+}
+
+func TestApplyOutputPaths(t *testing.T) {
+	o := DefaultOptions()
+	o.Root = "root"
+	o.Outputs["dart-client"] = "lib/client.dart"
+	abs := filepath.Join(t.TempDir(), "Client.swift")
+	cfg := &Config{Outputs: map[string]string{"kt-client": "app/Client.kt", "swift-client": abs, "dart-client": "other.dart"}}
+	o.applyOutputPaths(cfg)
+	if want := filepath.Join("root", "app", "Client.kt"); o.out("kt-client") != want {
+		t.Errorf("kt-client = %q, want %q", o.out("kt-client"), want)
+	}
+	if o.out("swift-client") != abs {
+		t.Errorf("absolute swift-client path changed to %q", o.out("swift-client"))
+	}
+	if o.out("dart-client") != "lib/client.dart" {
+		t.Errorf("dart-client = %q, want the path of the option", o.out("dart-client"))
+	}
+}
+
+func TestNamesOverride(t *testing.T) {
+	gctx := gen.NewContext()
+	if got := gctx.KotlinPackage("blerpc"); got != "com.blerpc.android.client" {
+		t.Errorf("default kotlin package %q", got)
+	}
+	if got := gctx.SwiftPrefix("blerpc"); got != "Blerpc_" {
+		t.Errorf("default swift prefix %q", got)
+	}
+	if got := python.Pb2Import(gctx, "blerpc", "."); got != "from . import blerpc_pb2" {
+		t.Errorf("default pb2 import %q", got)
+	}
+
+	gctx.Names = gen.NamesConfig{KotlinPackage: "com.example.ble", SwiftPrefix: "Ble_", PythonPb2Module: "myapp.proto.schema_pb2"}
+	cmd := gentest.EchoCommand()
+	checks := []struct {
+		name, out, want string
+	}{
+		{"kotlin", kotlin.GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc"), "package com.example.ble\n"},
+		{"swift", swift.GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc"), "Ble_EchoRequest"},
+		{"python", python.GenerateClient(gctx, []gen.Command{cmd}, nil, "blerpc"), "from myapp.proto import schema_pb2 as blerpc_pb2\n"},
+	}
+	for _, c := range checks {
+		if !strings.Contains(c.out, c.want) {
+			t.Errorf("%s client lacks %q", c.name, c.want)
+		}
+	}
+	if got := python.Pb2Import(gctx, "blerpc", "."); got != "from myapp.proto import schema_pb2 as blerpc_pb2" {
+		t.Errorf("pb2 import %q", got)
+	}
+	gctx.Names.PythonPb2Module = "blerpc_pb2"
+	if got := python.Pb2Import(gctx, "blerpc", "."); got != "import blerpc_pb2" {
+		t.Errorf("top-level pb2 import %q", got)
+	}
+}
+
+func TestNamesPrefixes(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.Names = gen.NamesConfig{CHandlerPrefix: "sensor_handle_", CPrefix: "sensor_", PythonMethodStyle: "camel", KotlinClientClass: "SensorClient", SwiftTypePrefix: "Sensor"}
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand()}
+	cmds[0].Snake, cmds[0].Camel = "echo_back", "EchoBack"
+	streaming := map[string]string{"counter_stream": "p2c"}
+	checks := []struct {
+		name, out string
+		want      []string
+	}{
+		{"c header", cgen.GenerateHeader(gctx, cmds, streaming, nil, "blerpc"), []string{
+			"int sensor_handle_echo_back(const uint8_t *req_data, size_t req_len,\n",
+			"sensor_command_handler_fn sensor_handlers_lookup(const char *name, uint8_t name_len);\n",
+		}},
+		{"c source", cgen.GenerateSource(gctx, cmds, streaming, nil, "blerpc"), []string{"{\"echo_back\", 9, sensor_handle_echo_back},\n"}},
+		{"python", python.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{"    async def echoBack(", "    async def iterCounterStream("}},
+		{"python sync", python.GenerateSyncClient(gctx, cmds, streaming, "blerpc"), []string{"self.async_client.echoBack("}},
+		{"kotlin", kotlin.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{"abstract class SensorClient {\n"}},
+		{"kotlin mock", kotlin.GenerateMock(gctx, cmds, "blerpc"), []string{"class MockSensorClient : SensorClient() {\n"}},
+		{"swift", swift.GenerateClient(gctx, cmds, streaming, "blerpc"), []string{"protocol SensorGeneratedClientProtocol: Sendable {\n"}},
+		{"swift template", swift.GenerateInstrumented(gctx, cmds), []string{"final class InstrumentedClient: SensorGeneratedClientProtocol,"}},
+	}
+	for _, c := range checks {
+		for _, want := range c.want {
+			if !strings.Contains(c.out, want) {
+				t.Errorf("%s lacks %q", c.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,13 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import java.util.UUID
+
+/** GATT UUIDs of the blerpc service, from blerpc.yaml. */
+object GeneratedUuids {
+    /** Service advertised by blerpc peripherals. */
+    val SERVICE_UUID: UUID = UUID.fromString("12340001-0000-1000-8000-00805f9b34fb")
+
+    /** The multiplexed RPC characteristic. */
+    val CHAR_UUID: UUID = UUID.fromString("12340002-0000-1000-8000-00805f9b34fb")
+}
@@ -0,0 +1,15 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.google.protobuf.MessageLite
+
+/** Stands in for the value of a field marked (blerpc.sensitive). */
+const val REDACTED = "<redacted>"
+
+/**
+ * Returns [message] like a data class's toString, with the fields marked
+ * (blerpc.sensitive) shown as [REDACTED], e.g.
+ * `LoginRequest(user=ann, password=<redacted>)`. Messages without such
+ * fields, directly or in a message field, keep their own toString.
+ */
+fun redacted(message: MessageLite): String = message.toString()
@@ -0,0 +1,13 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_UUIDS_H
+#define BLERPC_GENERATED_UUIDS_H
+
+/* blerpc Service UUID: 12340001-0000-1000-8000-00805f9b34fb */
+#define BLERPC_SERVICE_UUID BT_UUID_128_ENCODE(0x12340001, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
+#define BLERPC_SERVICE_UUID_STR "12340001-0000-1000-8000-00805f9b34fb"
+
+/* blerpc Characteristic UUID: 12340002-0000-1000-8000-00805f9b34fb */
+#define BLERPC_CHAR_UUID BT_UUID_128_ENCODE(0x12340002, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
+#define BLERPC_CHAR_UUID_STR "12340002-0000-1000-8000-00805f9b34fb"
+
+#endif /* BLERPC_GENERATED_UUIDS_H */
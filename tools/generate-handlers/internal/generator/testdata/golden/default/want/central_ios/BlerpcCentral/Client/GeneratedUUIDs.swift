@@ -0,0 +1,11 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+import CoreBluetooth
+
+/// GATT UUIDs of the blerpc service, from blerpc.yaml.
+enum GeneratedUUIDs {
+    /// Service advertised by blerpc peripherals.
+    static let service = CBUUID(string: "12340001-0000-1000-8000-00805f9b34fb")
+
+    /// The multiplexed RPC characteristic.
+    static let characteristic = CBUUID(string: "12340002-0000-1000-8000-00805f9b34fb")
+}
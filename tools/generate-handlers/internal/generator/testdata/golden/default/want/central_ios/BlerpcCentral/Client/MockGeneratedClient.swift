@@ -0,0 +1,98 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+/// A call recorded by MockGeneratedClient: the command and its decoded
+/// requests, one per message of a C→P stream.
+struct MockCall {
+    let command: String
+    let requests: [any SwiftProtobuf.Message]
+}
+
+/// Stand-in for BlerpcClient in app unit tests, with no peripheral. Records
+/// every call in `calls` and answers it with the responses set for its
+/// command. Commands without any get an empty response, and P→C streams no
+/// responses.
+final class MockGeneratedClient: GeneratedClientProtocol, @unchecked Sendable {
+    typealias Answer = ([any SwiftProtobuf.Message]) throws -> [any SwiftProtobuf.Message]
+
+    let callSerializer = CallSerializer()
+    private var answers: [String: Answer] = [:]
+    private var recorded: [MockCall] = []
+    private let lock = NSLock()
+
+    init() {}
+
+    /// The calls made so far, in order.
+    var calls: [MockCall] {
+        lock.lock()
+        defer { lock.unlock() }
+        return recorded
+    }
+
+    /// Answers `command` with `responses`: a unary or C→P call gets the first,
+    /// a P→C stream each of them.
+    func respond(_ command: String, with responses: any SwiftProtobuf.Message...) {
+        respond(command) { _ in responses }
+    }
+
+    /// Answers `command` with the responses `answer` returns for its requests.
+    func respond(_ command: String, answer: @escaping Answer) {
+        lock.lock()
+        defer { lock.unlock() }
+        answers[command] = answer
+    }
+
+    /// Fails calls of `command` with `error`, e.g. a NotFoundError.
+    func fail(_ command: String, with error: Error) {
+        respond(command) { _ in throw error }
+    }
+
+    func call(cmdName: String, requestData: Data) async throws -> Data {
+        let (command, responses) = try answer(cmdName, [requestData])
+        return try (responses?.first ?? emptyResponse(command)).serializedData()
+    }
+
+    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        let (_, responses) = try answer(cmdName, [requestData])
+        return try (responses ?? []).map { try $0.serializedData() }
+    }
+
+    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        let (command, responses) = try answer(cmdName, messages)
+        return try (responses?.first ?? emptyResponse(command)).serializedData()
+    }
+
+    /// Records a call and returns its command with the responses set for it.
+    private func answer(_ cmdName: String, _ requests: [Data]) throws -> (String, [any SwiftProtobuf.Message]?) {
+        let command = cmdName
+        let decoded = try requests.map { try decodeRequest(command, $0) }
+        lock.lock()
+        recorded.append(MockCall(command: command, requests: decoded))
+        let answer = answers[command]
+        lock.unlock()
+        return (command, try answer?(decoded))
+    }
+
+    private func decodeRequest(_ command: String, _ data: Data) throws -> any SwiftProtobuf.Message {
+        switch command {
+        case "echo": return try Blerpc_EchoRequest(serializedBytes: data)
+        case "flash_read": return try Blerpc_FlashReadRequest(serializedBytes: data)
+        case "data_write": return try Blerpc_DataWriteRequest(serializedBytes: data)
+        case "counter_stream": return try Blerpc_CounterStreamRequest(serializedBytes: data)
+        case "counter_upload": return try Blerpc_CounterUploadRequest(serializedBytes: data)
+        default: throw TransportError(message: "unknown command \(command)")
+        }
+    }
+
+    private func emptyResponse(_ command: String) -> any SwiftProtobuf.Message {
+        switch command {
+        case "echo": return Blerpc_EchoResponse()
+        case "flash_read": return Blerpc_FlashReadResponse()
+        case "data_write": return Blerpc_DataWriteResponse()
+        case "counter_stream": return Blerpc_CounterStreamResponse()
+        case "counter_upload": return Blerpc_CounterUploadResponse()
+        default: preconditionFailure("unknown command \(command)")
+        }
+    }
+}
@@ -0,0 +1,14 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+/// Stands in for the value of a field marked (blerpc.sensitive).
+let redactedPlaceholder = "<redacted>"
+
+/// Returns `message` with the fields marked (blerpc.sensitive) shown as
+/// <redacted>, e.g. `LoginRequest(user: ann, password: <redacted>)`.
+/// Messages without such fields, directly or in a message field, keep
+/// their own description.
+func redacted(_ message: any SwiftProtobuf.Message) -> String {
+    String(describing: message)
+}
@@ -0,0 +1,96 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+import Foundation
+
+/// Commands that are safe to run twice; retried after a reconnect.
+let idempotentCommands: Set<String> = [
+    "echo",
+    "flash_read",
+]
+
+/// The connection dropped while a non-idempotent call was in flight. The
+/// peripheral may or may not have run the command, so it is not retried.
+struct CallInterruptedError: BlerpcErrorProtocol {
+    let command: String
+    let underlying: Error
+}
+
+/// App-level policy of ResumingClient. Subclass to override.
+class ResumePolicy {
+    let maxRetries: Int
+
+    init(maxRetries: Int = 1) {
+        self.maxRetries = maxRetries
+    }
+
+    /// Whether to reconnect and retry `command` after its `attempt`-th failure.
+    func shouldRetry(command: String, attempt: Int, error: Error) -> Bool {
+        idempotentCommands.contains(command) && attempt <= maxRetries
+    }
+}
+
+/// Recovers calls of `client` cut off by a dropped connection.
+///
+/// Calls made while disconnected run `reconnect` first. Interrupted idempotent
+/// calls are retried as `policy` allows; other interrupted calls throw
+/// CallInterruptedError. A failure while `isConnected` still holds is thrown
+/// unchanged.
+final class ResumingClient: GeneratedClientProtocol, @unchecked Sendable {
+    private let client: any GeneratedClientProtocol
+    private let isConnected: () -> Bool
+    private let reconnect: () async throws -> Void
+    private let policy: ResumePolicy
+    let callSerializer = CallSerializer()
+
+    init(
+        client: any GeneratedClientProtocol,
+        isConnected: @escaping () -> Bool,
+        reconnect: @escaping () async throws -> Void,
+        policy: ResumePolicy = ResumePolicy()
+    ) {
+        self.client = client
+        self.isConnected = isConnected
+        self.reconnect = reconnect
+        self.policy = policy
+    }
+
+    func call(cmdName: String, requestData: Data) async throws -> Data {
+        try await resume(cmdName) { try await self.client.call(cmdName: cmdName, requestData: requestData) }
+    }
+
+    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        try await resume(cmdName) {
+            try await self.client.streamReceive(cmdName: cmdName, requestData: requestData)
+        }
+    }
+
+    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        try await resume(cmdName) {
+            try await self.client.streamSend(cmdName: cmdName, messages: messages, finalCmdName: finalCmdName)
+        }
+    }
+
+    var capabilityFlags: Int { client.capabilityFlags }
+
+    private func resume<T>(_ command: String, _ body: () async throws -> T) async throws -> T {
+        var attempt = 0
+        while true {
+            if !isConnected() {
+                try await reconnect()
+            }
+            do {
+                return try await client.exclusive(body)
+            } catch {
+                if error is CancellationError || isConnected() {
+                    throw error
+                }
+                attempt += 1
+                if !policy.shouldRetry(command: command, attempt: attempt, error: error) {
+                    if idempotentCommands.contains(command) {
+                        throw error
+                    }
+                    throw CallInterruptedError(command: command, underlying: error)
+                }
+            }
+        }
+    }
+}
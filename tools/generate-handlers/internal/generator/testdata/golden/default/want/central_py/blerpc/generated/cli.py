@@ -0,0 +1,158 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT.
+
+blerpc-cli: calls the commands of a peripheral from the command line, a
+subcommand per command with a flag per request field, and prints the decoded
+responses as protojson:
+
+    python -m blerpc.generated.cli flash-read --address=0x1000 --length=16
+
+Unset flags leave their field at its default. Bytes are given in hex,
+repeated, map and message fields as JSON, and C2P streams read one JSON
+request per line from --input.
+"""
+
+from __future__ import annotations
+
+import argparse
+import asyncio
+import datetime
+import json
+import sys
+from collections.abc import Callable
+
+from google.protobuf import json_format
+
+from ..client import BlerpcClient
+from . import blerpc_pb2
+from .generated_client import BlerpcError, to_json
+from .generated_scanner import scan
+
+
+def _bool(text: str) -> bool:
+    if text.lower() in ("1", "true", "yes", "on"):
+        return True
+    if text.lower() in ("0", "false", "no", "off"):
+        return False
+    raise argparse.ArgumentTypeError(f"not a boolean: {text!r}")
+
+
+def _int(text: str) -> int:
+    # Accepts 0x, 0o and 0b prefixes too.
+    return int(text, 0)
+
+
+def _seconds(text: str) -> datetime.timedelta:
+    return datetime.timedelta(seconds=float(text))
+
+
+def _json(text: str) -> object:
+    return json.loads(text)
+
+
+def _enum(enum_type) -> Callable[[str], int]:
+    # Takes an enum value by name or number.
+    def parse(text: str) -> int:
+        try:
+            return enum_type.Value(text)
+        except ValueError:
+            return int(text, 0)
+
+    return parse
+
+
+def _build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(
+        prog="blerpc-cli", description="Call the commands of a blerpc peripheral."
+    )
+    parser.add_argument(
+        "--device", help="name or address of the peripheral (default: the nearest)"
+    )
+    parser.add_argument("--scan-timeout", type=float, default=5.0, help="seconds")
+    parser.add_argument("--known-keys", help="known peripheral keys file")
+    parser.add_argument(
+        "--no-encryption", action="store_true", help="allow unencrypted calls"
+    )
+    commands = parser.add_subparsers(dest="command", metavar="COMMAND", required=True)
+
+    cmd = commands.add_parser("echo", help="call echo")
+    cmd.add_argument("--message", dest="field_message", type=str, help="string")
+    cmd.set_defaults(method="echo", stream="", fields=("message",))
+
+    cmd = commands.add_parser("flash-read", help="call flash_read")
+    cmd.add_argument("--address", dest="field_address", type=_int, help="uint32")
+    cmd.add_argument("--length", dest="field_length", type=_int, help="uint32")
+    cmd.set_defaults(method="flash_read", stream="", fields=("address", "length"))
+
+    cmd = commands.add_parser("data-write", help="call data_write")
+    cmd.add_argument("--data", dest="field_data", type=bytes.fromhex, help="hex bytes")
+    cmd.set_defaults(method="data_write", stream="", fields=("data",))
+
+    cmd = commands.add_parser("counter-stream", help="P2C stream counter_stream")
+    cmd.add_argument("--count", dest="field_count", type=_int, help="uint32")
+    cmd.set_defaults(method="iter_counter_stream", stream="p2c", fields=("count",))
+
+    cmd = commands.add_parser("counter-upload", help="C2P stream counter_upload")
+    cmd.add_argument(
+        "--input",
+        type=argparse.FileType(),
+        default=sys.stdin,
+        help="CounterUploadRequest per line as JSON (default: stdin)",
+    )
+    cmd.set_defaults(
+        method="counter_upload", stream="c2p", request=blerpc_pb2.CounterUploadRequest
+    )
+
+    return parser
+
+
+async def _connect(args: argparse.Namespace) -> BlerpcClient:
+    client = BlerpcClient(
+        known_keys_path=args.known_keys, require_encryption=not args.no_encryption
+    )
+    devices = await scan(client, timeout=args.scan_timeout)
+    if args.device is not None:
+        devices = [d for d in devices if args.device in (d.name, d.address)]
+    if not devices:
+        raise BlerpcError("no blerpc peripheral found")
+    await client.connect(devices[0].device)
+    return client
+
+
+async def _run(args: argparse.Namespace) -> None:
+    client = await _connect(args)
+    try:
+        method = getattr(client, args.method)
+        if args.stream == "c2p":
+            lines = (line for line in args.input if line.strip())
+            requests = (json_format.Parse(line, args.request()) for line in lines)
+            print(to_json(await method(requests)))
+            return
+        kwargs = {}
+        for name in args.fields:
+            value = getattr(args, "field_" + name)
+            if value is not None:
+                kwargs[name] = value
+        if args.stream == "p2c":
+            async for resp in method(**kwargs):
+                print(to_json(resp), flush=True)
+            return
+        print(to_json(await method(**kwargs)))
+    finally:
+        await client.disconnect()
+
+
+def main(argv: list[str] | None = None) -> int:
+    """Entry point of blerpc-cli."""
+    args = _build_parser().parse_args(argv)
+    try:
+        asyncio.run(_run(args))
+    except (BlerpcError, json_format.ParseError) as e:
+        print(f"error: {e}", file=sys.stderr)
+        return 1
+    except KeyboardInterrupt:
+        return 130
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
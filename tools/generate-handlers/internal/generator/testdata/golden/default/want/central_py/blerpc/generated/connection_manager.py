@@ -0,0 +1,224 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+import enum
+import logging
+import random
+from collections.abc import AsyncIterable
+
+from .generated_client import (
+    DecodeError,
+    GeneratedClientMixin,
+    RemoteError,
+    TransportError,
+    _rpc_lock,
+)
+from .resuming_client import IDEMPOTENT_COMMANDS, CallInterruptedError
+
+logger = logging.getLogger(__name__)
+
+
+class LinkState(enum.Enum):
+    """State of the link a ConnectionManager maintains.
+
+    DISCONNECTED -> CONNECTING -> READY, and back to DISCONNECTED when the
+    link drops or every connect attempt fails. A call failing on a live link,
+    e.g. with a timeout, moves READY to DEGRADED; the next call that succeeds
+    moves it back.
+    """
+
+    DISCONNECTED = "disconnected"
+    CONNECTING = "connecting"
+    READY = "ready"
+    DEGRADED = "degraded"
+
+
+class ReconnectPolicy:
+    """Backoff and replay policy of ConnectionManager. Subclass to override."""
+
+    initial_delay = 0.5  # seconds before the second connect attempt
+    max_delay = 30.0
+    multiplier = 2.0
+    jitter = 0.2  # up to this fraction of each delay is dropped at random
+    max_attempts = 5  # connect attempts per reconnect; 0 tries forever
+    max_replays = 1  # replays of an interrupted idempotent call
+
+    def delay(self, attempt):
+        """Seconds to wait after the attempt-th failed connect attempt."""
+        d = min(self.max_delay, self.initial_delay * self.multiplier ** (attempt - 1))
+        return d * (1 - self.jitter * random.random())
+
+    def should_replay(self, command, attempt, error):
+        """Whether to replay command after its attempt-th interrupted try."""
+        return command in IDEMPOTENT_COMMANDS and attempt <= self.max_replays
+
+
+class ConnectionManager(GeneratedClientMixin):
+    """Owns the link of client: connects, reconnects and replays calls.
+
+    connect is an async callable opening the link, e.g.
+    lambda: client.connect(device). Calls made while disconnected connect
+    first, retrying with backoff as the policy allows. A call cut off by a
+    dropped link is replayed after reconnecting if the command is idempotent
+    and the policy allows; other interrupted calls raise
+    CallInterruptedError. Report a dropped link with link_lost() to
+    reconnect in the background right away. Other attributes are forwarded
+    to client.
+    """
+
+    def __init__(self, client, connect, policy=None):
+        self._client = client
+        self._connect = connect
+        self._policy = policy or ReconnectPolicy()
+        self._state = LinkState.DISCONNECTED
+        self._listeners = []
+        self._connect_lock = asyncio.Lock()
+        self._reconnect_task = None
+
+    def __getattr__(self, name):
+        return getattr(self._client, name)
+
+    @property
+    def state(self):
+        return self._state
+
+    def add_listener(self, listener):
+        """Call listener(old, new) on every state change; returns a remover."""
+        self._listeners.append(listener)
+        return lambda: self._listeners.remove(listener)
+
+    def _set_state(self, state):
+        if state is self._state:
+            return
+        old, self._state = self._state, state
+        logger.debug("Link %s -> %s", old.value, state.value)
+        for listener in list(self._listeners):
+            listener(old, state)
+
+    async def connect(self):
+        """Open the link, retrying with backoff; no-op when connected."""
+        async with self._connect_lock:
+            if self._client.is_connected:
+                if self._state is LinkState.DISCONNECTED:
+                    self._set_state(LinkState.READY)
+                return
+            self._set_state(LinkState.CONNECTING)
+            attempt = 0
+            while True:
+                try:
+                    await self._connect()
+                except Exception as e:
+                    attempt += 1
+                    limit = self._policy.max_attempts
+                    if limit and attempt >= limit:
+                        self._set_state(LinkState.DISCONNECTED)
+                        raise TransportError(
+                            f"connect failed after {attempt} attempts: {e}"
+                        ) from e
+                    delay = self._policy.delay(attempt)
+                    logger.debug(
+                        "Connect attempt %d failed, retry in %.1fs", attempt, delay
+                    )
+                    await asyncio.sleep(delay)
+                    continue
+                self._set_state(LinkState.READY)
+                return
+
+    def link_lost(self):
+        """Report that the link dropped and reconnect in the background."""
+        self._set_state(LinkState.DISCONNECTED)
+        if self._reconnect_task is None or self._reconnect_task.done():
+            self._reconnect_task = asyncio.ensure_future(self._reconnect())
+
+    async def _reconnect(self):
+        try:
+            await self.connect()
+        except TransportError as e:
+            logger.warning("Reconnect failed: %s", e)
+
+    async def disconnect(self):
+        """Close the link; the next call connects again."""
+        if self._reconnect_task is not None:
+            self._reconnect_task.cancel()
+        await self._client.disconnect()
+        self._set_state(LinkState.DISCONNECTED)
+
+    async def _ensure_connected(self):
+        if not self._client.is_connected:
+            self._set_state(LinkState.DISCONNECTED)
+            await self.connect()
+
+    def _failure(self, command, attempt, error, replayable=True):
+        """Return the error to raise for a failed try, or None to replay."""
+        if self._client.is_connected:
+            # Error and undecodable responses show the link works.
+            if not isinstance(error, (RemoteError, DecodeError)):
+                self._set_state(LinkState.DEGRADED)
+            return error
+        self._set_state(LinkState.DISCONNECTED)
+        if replayable and self._policy.should_replay(command, attempt, error):
+            return None
+        if command in IDEMPOTENT_COMMANDS and replayable:
+            return error
+        return CallInterruptedError(command, error)
+
+    async def _run(self, command, attempt_call):
+        attempt = 0
+        while True:
+            await self._ensure_connected()
+            try:
+                async with _rpc_lock(self._client):
+                    result = await attempt_call()
+            except Exception as e:
+                attempt += 1
+                err = self._failure(command, attempt, e)
+                if err is e:
+                    raise
+                if err is not None:
+                    raise err from e
+                continue
+            self._set_state(LinkState.READY)
+            return result
+
+    async def _call(self, cmd_name, request_data):
+        return await self._run(
+            cmd_name, lambda: self._client._call(cmd_name, request_data)
+        )
+
+    async def stream_send(self, cmd_name, messages, final_cmd_name):
+        # A replay sends the stream again, so it is collected first.
+        if isinstance(messages, AsyncIterable):
+            messages = [m async for m in messages]
+        else:
+            messages = list(messages)
+        return await self._run(
+            cmd_name,
+            lambda: self._client.stream_send(cmd_name, messages, final_cmd_name),
+        )
+
+    async def stream_receive(self, cmd_name, request_data):
+        # Responses already handed to the caller cannot be taken back, so a
+        # stream is only replayed if it broke before the first response.
+        attempt = 0
+        while True:
+            await self._ensure_connected()
+            received = False
+            try:
+                async with _rpc_lock(self._client):
+                    async for data in self._client.stream_receive(
+                        cmd_name, request_data
+                    ):
+                        received = True
+                        yield data
+            except Exception as e:
+                attempt += 1
+                err = self._failure(cmd_name, attempt, e, replayable=not received)
+                if err is e:
+                    raise
+                if err is not None:
+                    raise err from e
+                continue
+            self._set_state(LinkState.READY)
+            return
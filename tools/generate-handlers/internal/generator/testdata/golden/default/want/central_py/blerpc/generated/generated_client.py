@@ -0,0 +1,321 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+import builtins
+import enum
+from collections.abc import AsyncIterable, AsyncIterator, Iterable
+from typing import Protocol
+
+from google.protobuf import json_format, message
+
+from . import blerpc_pb2
+
+
+class BlerpcError(Exception):
+    """Base class of errors raised by generated client methods."""
+
+
+class TransportError(BlerpcError):
+    """The request could not be delivered or the response was lost."""
+
+
+class TimeoutError(TransportError, builtins.TimeoutError):
+    """The peripheral did not respond in time."""
+
+
+class DecodeError(BlerpcError):
+    """The response payload is not a valid message."""
+
+    def __init__(self, command, cause):
+        super().__init__(f"{command}: invalid response: {cause}")
+        self.command = command
+
+
+class RemoteError(BlerpcError):
+    """The peripheral reported a non-OK status."""
+
+    def __init__(self, command, status, message=None):
+        super().__init__(message or f"{command} failed: status={status}")
+        self.command = command
+        self.status = status
+
+
+class StatusCode(enum.IntEnum):
+    """Status codes of error responses; 128 and up are application codes."""
+
+    OK = 0
+    INVALID_ARGUMENT = 1
+    NOT_FOUND = 2
+    ALREADY_EXISTS = 3
+    PERMISSION_DENIED = 4
+    RESOURCE_EXHAUSTED = 5
+    FAILED_PRECONDITION = 6
+    OUT_OF_RANGE = 7
+    UNIMPLEMENTED = 8
+    INTERNAL = 9
+    UNAVAILABLE = 10
+    UNAUTHENTICATED = 11
+
+
+class InvalidArgumentError(RemoteError):
+    """The request is malformed or a field is out of bounds."""
+
+
+class NotFoundError(RemoteError):
+    """The requested entity does not exist."""
+
+
+class AlreadyExistsError(RemoteError):
+    """The entity to create exists already."""
+
+
+class PermissionDeniedError(RemoteError):
+    """The caller may not run the command."""
+
+
+class ResourceExhaustedError(RemoteError):
+    """Memory, storage or another resource ran out."""
+
+
+class FailedPreconditionError(RemoteError):
+    """The device is not in a state to run the command."""
+
+
+class OutOfRangeError(RemoteError):
+    """An offset or value lies past the valid range."""
+
+
+class UnimplementedError(RemoteError):
+    """The command is not supported by this firmware."""
+
+
+class InternalError(RemoteError):
+    """The firmware hit an unexpected error."""
+
+
+class UnavailableError(RemoteError):
+    """The device cannot run the command now; retry later."""
+
+
+class UnauthenticatedError(RemoteError):
+    """The command needs an authenticated session."""
+
+
+# Error responses hold only a status, in a field no message uses.
+_STATUS_TAG = b"\xf8\xff\xff\xff\x0f"
+
+_STATUS_ERRORS = {
+    StatusCode.INVALID_ARGUMENT: InvalidArgumentError,
+    StatusCode.NOT_FOUND: NotFoundError,
+    StatusCode.ALREADY_EXISTS: AlreadyExistsError,
+    StatusCode.PERMISSION_DENIED: PermissionDeniedError,
+    StatusCode.RESOURCE_EXHAUSTED: ResourceExhaustedError,
+    StatusCode.FAILED_PRECONDITION: FailedPreconditionError,
+    StatusCode.OUT_OF_RANGE: OutOfRangeError,
+    StatusCode.UNIMPLEMENTED: UnimplementedError,
+    StatusCode.INTERNAL: InternalError,
+    StatusCode.UNAVAILABLE: UnavailableError,
+    StatusCode.UNAUTHENTICATED: UnauthenticatedError,
+}
+
+
+def _check_status(data, command):
+    if not data.startswith(_STATUS_TAG):
+        return
+    status = shift = 0
+    for byte in data[len(_STATUS_TAG) :]:
+        status |= (byte & 0x7F) << shift
+        shift += 7
+        if byte < 0x80:
+            break
+    error = _STATUS_ERRORS.get(status)
+    if error is None:
+        raise RemoteError(command, status)
+    raise error(command, status, f"{command} failed: {StatusCode(status).name}")
+
+
+class StringTooLongError(BlerpcError, ValueError):
+    """A string argument is longer in UTF-8 than its max_size allows."""
+
+    def __init__(self, command, field, size, max_bytes):
+        super().__init__(
+            f"{command}: {field} is {size} bytes in UTF-8, over the {max_bytes}"
+            " its max_size allows"
+        )
+        self.command = command
+        self.field = field
+        self.size = size
+        self.max_bytes = max_bytes
+
+
+def _decode(resp, data, command):
+    _check_status(data, command)
+    try:
+        resp.ParseFromString(data)
+    except message.DecodeError as e:
+        raise DecodeError(command, e) from e
+    return resp
+
+
+def _rpc_lock(client):
+    # The peripheral handles one RPC at a time, so concurrent calls on one
+    # client would interleave their packets. Created on first use, as the
+    # mixin has no __init__.
+    return vars(client).setdefault("_rpc_call_lock", asyncio.Lock())
+
+
+def _serialize_each(messages):
+    # Encode lazily, so a generator can produce the stream as it is sent.
+    if isinstance(messages, AsyncIterable):
+        return (m.SerializeToString() async for m in messages)
+    return (m.SerializeToString() for m in messages)
+
+
+# Control command of the Cancel container, which stops the P2C stream in
+# progress on the peripheral. blerpc_protocol has no constant for it.
+CONTROL_CMD_CANCEL = 0x7
+
+
+def make_cancel(transaction_id):
+    """Serialize a Cancel control container, which carries no payload."""
+    return bytes([transaction_id & 0xFF, 0, 0xC0 | CONTROL_CMD_CANCEL << 2, 0])
+
+
+class RpcTransport(Protocol):
+    """Client primitives the generated methods call; BlerpcClient implements them."""
+
+    async def _call(self, cmd_name: str, request_data: bytes) -> bytes: ...
+
+    def stream_receive(
+        self, cmd_name: str, request_data: bytes
+    ) -> AsyncIterator[bytes]: ...
+
+    async def stream_send(
+        self,
+        cmd_name: str,
+        messages: Iterable[bytes] | AsyncIterable[bytes],
+        final_cmd_name: str,
+    ) -> bytes: ...
+
+
+class GeneratedClientMixin(RpcTransport):
+    """Auto-generated RPC methods (unary and streaming).
+
+    Requires the RpcTransport primitives, which BlerpcClient provides.
+    """
+
+    async def echo(self, *, message: str = "") -> blerpc_pb2.EchoResponse:
+        """Call the echo command."""
+        if len(message.encode()) > 256:
+            raise StringTooLongError("echo", "message", len(message.encode()), 256)
+        req = blerpc_pb2.EchoRequest(message=message)
+        async with _rpc_lock(self):
+            resp_data = await self._call("echo", req.SerializeToString())
+        resp = _decode(blerpc_pb2.EchoResponse(), resp_data, "echo")
+        return resp
+
+    async def flash_read(
+        self, *, address: int = 0, length: int = 0
+    ) -> blerpc_pb2.FlashReadResponse:
+        """Call the flash_read command."""
+        req = blerpc_pb2.FlashReadRequest(address=address, length=length)
+        async with _rpc_lock(self):
+            resp_data = await self._call("flash_read", req.SerializeToString())
+        resp = _decode(blerpc_pb2.FlashReadResponse(), resp_data, "flash_read")
+        return resp
+
+    async def data_write(self, *, data: bytes = b"") -> blerpc_pb2.DataWriteResponse:
+        """Call the data_write command."""
+        req = blerpc_pb2.DataWriteRequest(data=data)
+        async with _rpc_lock(self):
+            resp_data = await self._call("data_write", req.SerializeToString())
+        resp = _decode(blerpc_pb2.DataWriteResponse(), resp_data, "data_write")
+        return resp
+
+    async def iter_counter_stream(
+        self, *, count: int = 0
+    ) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:
+        """P2C stream: counter_stream, yielding each response as it arrives."""
+        req = blerpc_pb2.CounterStreamRequest(count=count)
+        async with _rpc_lock(self):
+            try:
+                async for data in self.stream_receive(
+                    "counter_stream", req.SerializeToString()
+                ):
+                    resp = _decode(
+                        blerpc_pb2.CounterStreamResponse(), data, "counter_stream"
+                    )
+                    yield resp
+            except (asyncio.CancelledError, GeneratorExit):
+                # The consumer stopped early: stop the peripheral too.
+                await self.stream_cancel()
+                raise
+
+    async def counter_stream(
+        self, *, count: int = 0
+    ) -> list[blerpc_pb2.CounterStreamResponse]:
+        """P2C stream: counter_stream."""
+        results = []
+        async for resp in self.iter_counter_stream(count=count):
+            results.append(resp)
+        return results
+
+    async def counter_upload(
+        self,
+        messages: (
+            Iterable[blerpc_pb2.CounterUploadRequest]
+            | AsyncIterable[blerpc_pb2.CounterUploadRequest]
+        ),
+    ) -> blerpc_pb2.CounterUploadResponse:
+        """C2P stream: counter_upload.
+
+        messages is an iterable or async iterable of CounterUploadRequest,
+        each sent as it is produced.
+        """
+        raw = _serialize_each(messages)
+        async with _rpc_lock(self):
+            resp_data = await self.stream_send("counter_upload", raw, "counter_upload")
+        resp = _decode(blerpc_pb2.CounterUploadResponse(), resp_data, "counter_upload")
+        return resp
+
+    async def stream_cancel(self) -> None:
+        """Stop the P2C stream in progress, whose consumer stopped early.
+
+        The default does nothing and the peripheral runs the stream to its
+        end; BlerpcClient sends make_cancel() and drains the stream.
+        """
+
+
+# Request/response message classes per command, for JSON conversion.
+COMMAND_MESSAGES = {
+    "echo": (blerpc_pb2.EchoRequest, blerpc_pb2.EchoResponse),
+    "flash_read": (blerpc_pb2.FlashReadRequest, blerpc_pb2.FlashReadResponse),
+    "data_write": (blerpc_pb2.DataWriteRequest, blerpc_pb2.DataWriteResponse),
+    "counter_stream": (
+        blerpc_pb2.CounterStreamRequest,
+        blerpc_pb2.CounterStreamResponse,
+    ),
+    "counter_upload": (
+        blerpc_pb2.CounterUploadRequest,
+        blerpc_pb2.CounterUploadResponse,
+    ),
+}
+
+
+def to_json(message):
+    """Serialize a request or response message to protojson text."""
+    return json_format.MessageToJson(message, preserving_proto_field_name=True)
+
+
+def request_from_json(cmd_name, text):
+    """Parse protojson text into the request message of cmd_name."""
+    req_cls, _ = COMMAND_MESSAGES[cmd_name]
+    return json_format.Parse(text, req_cls())
+
+
+def response_from_json(cmd_name, text):
+    """Parse protojson text into the response message of cmd_name."""
+    _, resp_cls = COMMAND_MESSAGES[cmd_name]
+    return json_format.Parse(text, resp_cls())
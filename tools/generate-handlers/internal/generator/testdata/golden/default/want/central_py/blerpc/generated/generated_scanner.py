@@ -0,0 +1,44 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from dataclasses import dataclass
+
+# Service UUID advertised by blerpc peripherals.
+SERVICE_UUID = "12340001-0000-1000-8000-00805f9b34fb"
+
+
+@dataclass
+class DiscoveredDevice:
+    """A blerpc peripheral found by a scan; pass device to connect()."""
+
+    name: str | None
+    address: str
+    rssi: int
+    device: object
+
+
+def discover(device):
+    """Return a scan result as a DiscoveredDevice, or None if it is not blerpc."""
+    if SERVICE_UUID not in (u.lower() for u in device.service_uuids):
+        return None
+    return DiscoveredDevice(
+        name=device.name,
+        address=device.address,
+        rssi=device.rssi,
+        device=device,
+    )
+
+
+def discover_all(devices):
+    """Return the blerpc peripherals among scan results, strongest first."""
+    found = (discover(d) for d in devices)
+    return sorted(
+        (d for d in found if d is not None), key=lambda d: d.rssi, reverse=True
+    )
+
+
+async def scan(client, timeout=5.0):
+    """Scan with client, e.g. BlerpcClient, and return the blerpc peripherals."""
+    devices = await client.scan(timeout=timeout, service_uuid=SERVICE_UUID)
+    return discover_all(devices)
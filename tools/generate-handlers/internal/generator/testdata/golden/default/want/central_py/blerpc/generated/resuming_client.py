@@ -0,0 +1,123 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from collections.abc import AsyncIterable
+
+from .generated_client import GeneratedClientMixin, TransportError, _rpc_lock
+
+# Commands that are safe to run twice; retried after a reconnect.
+IDEMPOTENT_COMMANDS = frozenset(
+    {
+        "echo",
+        "flash_read",
+    }
+)
+
+
+class CallInterruptedError(TransportError):
+    """The connection dropped while a non-idempotent call was in flight.
+
+    The peripheral may or may not have run the command, so it is not retried.
+    """
+
+    def __init__(self, command, cause):
+        super().__init__(f"{command}: interrupted by disconnect: {cause}")
+        self.command = command
+
+
+class ResumePolicy:
+    """App-level policy of ResumingClient. Subclass to override."""
+
+    max_retries = 1
+
+    def should_retry(self, command, attempt, error):
+        """Whether to reconnect and retry command after its attempt-th failure."""
+        return command in IDEMPOTENT_COMMANDS and attempt <= self.max_retries
+
+
+class ResumingClient(GeneratedClientMixin):
+    """Recovers calls cut off by a dropped connection.
+
+    Calls made while disconnected reconnect first. Interrupted idempotent calls
+    are retried as the policy allows; other interrupted calls raise
+    CallInterruptedError. reconnect is an async callable restoring the
+    connection, e.g. lambda: client.connect(device). Other attributes are
+    forwarded to client.
+    """
+
+    def __init__(self, client, reconnect, policy=None):
+        self._client = client
+        self._reconnect = reconnect
+        self._policy = policy or ResumePolicy()
+
+    def __getattr__(self, name):
+        return getattr(self._client, name)
+
+    async def _ensure_connected(self):
+        if not self._client.is_connected:
+            await self._reconnect()
+
+    def _failure(self, command, attempt, error, replayable=True):
+        """Return the error to raise for a failed attempt, or None to retry."""
+        if self._client.is_connected:
+            return error
+        if replayable and self._policy.should_retry(command, attempt, error):
+            return None
+        if command in IDEMPOTENT_COMMANDS and replayable:
+            return error
+        return CallInterruptedError(command, error)
+
+    async def _resume(self, command, attempt_call):
+        attempt = 0
+        while True:
+            await self._ensure_connected()
+            try:
+                async with _rpc_lock(self._client):
+                    return await attempt_call()
+            except Exception as e:
+                attempt += 1
+                err = self._failure(command, attempt, e)
+                if err is e:
+                    raise
+                if err is not None:
+                    raise err from e
+
+    async def _call(self, cmd_name, request_data):
+        return await self._resume(
+            cmd_name, lambda: self._client._call(cmd_name, request_data)
+        )
+
+    async def stream_send(self, cmd_name, messages, final_cmd_name):
+        # A retry sends the stream again, so it is collected first.
+        if isinstance(messages, AsyncIterable):
+            messages = [m async for m in messages]
+        else:
+            messages = list(messages)
+        return await self._resume(
+            cmd_name,
+            lambda: self._client.stream_send(cmd_name, messages, final_cmd_name),
+        )
+
+    async def stream_receive(self, cmd_name, request_data):
+        # Responses already handed to the caller cannot be taken back, so a
+        # stream is only retried if it broke before the first response.
+        attempt = 0
+        while True:
+            await self._ensure_connected()
+            received = False
+            try:
+                async with _rpc_lock(self._client):
+                    async for data in self._client.stream_receive(
+                        cmd_name, request_data
+                    ):
+                        received = True
+                        yield data
+                return
+            except Exception as e:
+                attempt += 1
+                err = self._failure(cmd_name, attempt, e, replayable=not received)
+                if err is e:
+                    raise
+                if err is not None:
+                    raise err from e
@@ -0,0 +1,113 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT */
+#include "generated_gatt_service.h"
+#include <errno.h>
+
+static struct bt_uuid_128 rpc_svc_uuid = BT_UUID_INIT_128(BLERPC_SERVICE_UUID);
+static struct bt_uuid_128 rpc_char_uuid = BT_UUID_INIT_128(BLERPC_CHAR_UUID);
+
+static bool notify_enabled;
+
+__attribute__((weak))
+void blerpc_gatt_on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx)
+{
+    (void)conn;
+    (void)tx;
+    (void)rx;
+}
+
+__attribute__((weak))
+void blerpc_gatt_on_notify_changed(bool enabled)
+{
+    (void)enabled;
+}
+
+static ssize_t on_write(struct bt_conn *conn, const struct bt_gatt_attr *attr, const void *buf,
+                        uint16_t len, uint16_t offset, uint8_t flags)
+{
+    (void)attr;
+    (void)offset;
+    (void)flags;
+    blerpc_gatt_on_write(conn, buf, len);
+    return len;
+}
+
+static void on_ccc_changed(const struct bt_gatt_attr *attr, uint16_t value)
+{
+    (void)attr;
+    notify_enabled = value == BT_GATT_CCC_NOTIFY;
+    blerpc_gatt_on_notify_changed(notify_enabled);
+}
+
+/* Attributes: primary service, characteristic declaration, value, CCC */
+BT_GATT_SERVICE_DEFINE(blerpc_svc, BT_GATT_PRIMARY_SERVICE(&rpc_svc_uuid),
+                       BT_GATT_CHARACTERISTIC(&rpc_char_uuid.uuid,
+                                              BT_GATT_CHRC_WRITE_WITHOUT_RESP | BT_GATT_CHRC_NOTIFY,
+                                              BT_GATT_PERM_WRITE, NULL, on_write, NULL),
+                       BT_GATT_CCC(on_ccc_changed, BT_GATT_PERM_READ | BT_GATT_PERM_WRITE), );
+
+static void on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx)
+{
+    blerpc_gatt_on_mtu_updated(conn, tx, rx);
+}
+
+static struct bt_gatt_cb gatt_callbacks = {
+    .att_mtu_updated = on_mtu_updated,
+};
+
+void blerpc_gatt_init(void)
+{
+    bt_gatt_cb_register(&gatt_callbacks);
+}
+
+bool blerpc_gatt_notify_enabled(void)
+{
+    return notify_enabled;
+}
+
+uint16_t blerpc_gatt_get_mtu(struct bt_conn *conn)
+{
+    if (!conn) {
+        return BLERPC_GATT_DEFAULT_MTU;
+    }
+    return bt_gatt_get_mtu(conn);
+}
+
+#ifdef CONFIG_BT_GATT_CLIENT
+static struct bt_gatt_exchange_params mtu_exchange_params;
+
+static void on_mtu_exchanged(struct bt_conn *conn, uint8_t err,
+                             struct bt_gatt_exchange_params *params)
+{
+    /* The new MTU is reported through att_mtu_updated */
+    (void)conn;
+    (void)err;
+    (void)params;
+}
+
+int blerpc_gatt_exchange_mtu(struct bt_conn *conn)
+{
+    mtu_exchange_params.func = on_mtu_exchanged;
+    return bt_gatt_exchange_mtu(conn, &mtu_exchange_params);
+}
+#else
+int blerpc_gatt_exchange_mtu(struct bt_conn *conn)
+{
+    (void)conn;
+    return -ENOTSUP;
+}
+#endif /* CONFIG_BT_GATT_CLIENT */
+
+int blerpc_gatt_notify(struct bt_conn *conn, const uint8_t *data, size_t len)
+{
+    if (!conn) {
+        return -ENOTCONN;
+    }
+
+    struct bt_gatt_notify_params params = {
+        .attr = &blerpc_svc.attrs[2],
+        .data = data,
+        .len = len,
+    };
+
+    return bt_gatt_notify_cb(conn, &params);
+}
@@ -0,0 +1,137 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema 42e65eeb2ca0ef87) — DO NOT EDIT.
+
+Subclass BlerpcHandlers and override the methods of the commands the
+peripheral implements, or register functions in their place with the
+HandlerRegistry decorators; the registry dispatches requests by command name
+or ID.
+"""
+
+import os
+import sys
+from collections.abc import AsyncIterator, Callable
+
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), "..", "central_py"))
+from blerpc.generated import blerpc_pb2
+
+
+class BlerpcHandlers:
+    """Peripheral handlers: one async method per command.
+
+    The defaults answer with empty responses. The method of a
+    peripheral-to-central stream yields its responses; the method of a
+    central-to-peripheral stream is called per request, and its finish_
+    method makes the response when the central ends the stream.
+    """
+
+    async def echo(self, req: blerpc_pb2.EchoRequest) -> blerpc_pb2.EchoResponse:
+        return blerpc_pb2.EchoResponse()
+
+    async def flash_read(
+        self, req: blerpc_pb2.FlashReadRequest
+    ) -> blerpc_pb2.FlashReadResponse:
+        return blerpc_pb2.FlashReadResponse()
+
+    async def data_write(
+        self, req: blerpc_pb2.DataWriteRequest
+    ) -> blerpc_pb2.DataWriteResponse:
+        return blerpc_pb2.DataWriteResponse()
+
+    async def counter_stream(
+        self, req: blerpc_pb2.CounterStreamRequest
+    ) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:
+        yield blerpc_pb2.CounterStreamResponse()
+
+    async def counter_upload(self, req: blerpc_pb2.CounterUploadRequest) -> None:
+        pass
+
+    async def finish_counter_upload(self) -> blerpc_pb2.CounterUploadResponse:
+        return blerpc_pb2.CounterUploadResponse()
+
+
+# Method name, request class and streaming direction ("", "p2c" or "c2p")
+# by command name.
+COMMANDS = {
+    "echo": ("echo", blerpc_pb2.EchoRequest, ""),
+    "flash_read": ("flash_read", blerpc_pb2.FlashReadRequest, ""),
+    "data_write": ("data_write", blerpc_pb2.DataWriteRequest, ""),
+    "counter_stream": ("counter_stream", blerpc_pb2.CounterStreamRequest, "p2c"),
+    "counter_upload": ("counter_upload", blerpc_pb2.CounterUploadRequest, "c2p"),
+}
+
+# One-character names carrying numeric command IDs, mapped to the command
+# names COMMANDS is keyed by.
+COMMAND_IDS = {}
+
+
+class HandlerRegistry:
+    """Dispatches encoded requests to handlers by command name or ID.
+
+    A function registered with the handler or finisher decorator, by method
+    name, takes the place of that BlerpcHandlers method:
+
+        registry = HandlerRegistry()
+
+        @registry.handler("my_command")
+        async def my_command(req):
+            ...
+    """
+
+    def __init__(self, handlers: BlerpcHandlers | None = None):
+        self.handlers = BlerpcHandlers() if handlers is None else handlers
+        self._handlers: dict[str, Callable] = {}
+        self._finishers: dict[str, Callable] = {}
+
+    def handler(self, method: str) -> Callable[[Callable], Callable]:
+        """Decorator registering an async function as the handler of method."""
+        return self._register(self._handlers, method)
+
+    def finisher(self, method: str) -> Callable[[Callable], Callable]:
+        """Decorator registering an async function as the finish_ method of a
+        central-to-peripheral stream."""
+        return self._register(self._finishers, method)
+
+    def _register(self, table: dict, method: str) -> Callable[[Callable], Callable]:
+        if not any(m == method for m, _, _ in COMMANDS.values()):
+            raise KeyError(f"unknown command: {method!r}")
+
+        def register(fn: Callable) -> Callable:
+            table[method] = fn
+            return fn
+
+        return register
+
+    def resolve(self, name: str) -> str:
+        """Return the command name of name, which may carry a command ID."""
+        return COMMAND_IDS.get(name, name)
+
+    def __contains__(self, name: str) -> bool:
+        return self.resolve(name) in COMMANDS
+
+    def direction(self, name: str) -> str:
+        """Return the streaming direction of a command: "", "p2c" or "c2p"."""
+        return COMMANDS[self.resolve(name)][2]
+
+    def _call(self, name: str, req_data: bytes):
+        method, request_cls, _ = COMMANDS[self.resolve(name)]
+        req = request_cls()
+        req.ParseFromString(req_data)
+        fn = self._handlers.get(method) or getattr(self.handlers, method)
+        return fn(req)
+
+    async def dispatch(self, name: str, req_data: bytes) -> bytes | None:
+        """Run a unary handler, or a central-to-peripheral stream handler
+        (which has no response), on an encoded request."""
+        resp = await self._call(name, req_data)
+        return None if resp is None else resp.SerializeToString()
+
+    async def stream(self, name: str, req_data: bytes) -> AsyncIterator[bytes]:
+        """Yield the encoded responses of a peripheral-to-central stream."""
+        async for resp in self._call(name, req_data):
+            yield resp.SerializeToString()
+
+    async def finish(self, name: str) -> bytes:
+        """Return the encoded response of a central-to-peripheral stream."""
+        method = COMMANDS[self.resolve(name)][0]
+        fn = self._finishers.get(method) or getattr(self.handlers, "finish_" + method)
+        resp = await fn()
+        return resp.SerializeToString()
@@ -0,0 +1,99 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import android.Manifest
+import android.content.Context
+import android.content.pm.PackageManager
+import android.os.Build
+import androidx.activity.result.ActivityResultCaller
+import androidx.activity.result.ActivityResultLauncher
+import androidx.activity.result.contract.ActivityResultContracts
+import androidx.core.content.ContextCompat
+
+/** Thrown when the app lacks runtime permissions the BLE transport needs. */
+class MissingPermissionsException(val permissions: List<String>) :
+    SecurityException("Missing Bluetooth permissions: ${permissions.joinToString()}")
+
+/**
+ * Runtime permissions required before the BLE transport can scan and connect.
+ *
+ * Android 12 (API 31) and later grant BLUETOOTH_SCAN and BLUETOOTH_CONNECT at
+ * runtime; earlier releases need location access for scans instead. Apps whose
+ * BLUETOOTH_SCAN declaration lacks usesPermissionFlags="neverForLocation" only
+ * receive scan results with location access, so they pass includeLocation = true.
+ */
+object BlePermissions {
+    /** Permissions to request on this device. */
+    fun required(includeLocation: Boolean = false): List<String> =
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.S) {
+            listOfNotNull(
+                Manifest.permission.BLUETOOTH_SCAN,
+                Manifest.permission.BLUETOOTH_CONNECT,
+                Manifest.permission.ACCESS_FINE_LOCATION.takeIf { includeLocation },
+            )
+        } else {
+            listOf(Manifest.permission.ACCESS_FINE_LOCATION)
+        }
+
+    /** Required permissions that are not granted yet. */
+    fun missing(
+        context: Context,
+        includeLocation: Boolean = false,
+    ): List<String> =
+        required(includeLocation).filter {
+            ContextCompat.checkSelfPermission(context, it) != PackageManager.PERMISSION_GRANTED
+        }
+
+    fun hasAll(
+        context: Context,
+        includeLocation: Boolean = false,
+    ): Boolean = missing(context, includeLocation).isEmpty()
+
+    /** Throws [MissingPermissionsException] unless every required permission is granted. */
+    fun check(
+        context: Context,
+        includeLocation: Boolean = false,
+    ) {
+        val missing = missing(context, includeLocation)
+        if (missing.isNotEmpty()) {
+            throw MissingPermissionsException(missing)
+        }
+    }
+
+    /**
+     * Registers a permission request on [caller]. Like any activity result, it
+     * must be registered before the activity or fragment is started. [onResult]
+     * receives whether every required permission is granted, which is also
+     * false when the user dismisses the dialog.
+     */
+    fun register(
+        caller: ActivityResultCaller,
+        context: Context,
+        includeLocation: Boolean = false,
+        onResult: (Boolean) -> Unit,
+    ): Request {
+        val launcher =
+            caller.registerForActivityResult(
+                ActivityResultContracts.RequestMultiplePermissions(),
+            ) { onResult(hasAll(context, includeLocation)) }
+        return Request(context, includeLocation, launcher, onResult)
+    }
+
+    /** A permission request registered with [register]. */
+    class Request internal constructor(
+        private val context: Context,
+        private val includeLocation: Boolean,
+        private val launcher: ActivityResultLauncher<Array<String>>,
+        private val onResult: (Boolean) -> Unit,
+    ) {
+        /** Asks for the missing permissions; reports success at once if none are missing. */
+        fun launch() {
+            val missing = missing(context, includeLocation)
+            if (missing.isEmpty()) {
+                onResult(true)
+            } else {
+                launcher.launch(missing.toTypedArray())
+            }
+        }
+    }
+}
@@ -0,0 +1,405 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import android.annotation.SuppressLint
+import android.bluetooth.BluetoothDevice
+import android.bluetooth.BluetoothGatt
+import android.bluetooth.BluetoothGattCallback
+import android.bluetooth.BluetoothGattCharacteristic
+import android.bluetooth.BluetoothGattDescriptor
+import android.bluetooth.BluetoothProfile
+import android.bluetooth.BluetoothStatusCodes
+import android.content.Context
+import android.os.Build
+import com.blerpc.protocol.BLERPC_ERROR_RESPONSE_TOO_LARGE
+import com.blerpc.protocol.BlerpcCryptoSession
+import com.blerpc.protocol.CAPABILITY_FLAG_ENCRYPTION_SUPPORTED
+import com.blerpc.protocol.CommandPacket
+import com.blerpc.protocol.CommandType
+import com.blerpc.protocol.Container
+import com.blerpc.protocol.ContainerAssembler
+import com.blerpc.protocol.ContainerSplitter
+import com.blerpc.protocol.ContainerType
+import com.blerpc.protocol.ControlCmd
+import com.blerpc.protocol.centralPerformKeyExchange
+import com.blerpc.protocol.makeCapabilitiesRequest
+import com.blerpc.protocol.makeKeyExchange
+import com.blerpc.protocol.makeStreamEndC2P
+import com.blerpc.protocol.makeTimeoutRequest
+import kotlinx.coroutines.CancellableContinuation
+import kotlinx.coroutines.TimeoutCancellationException
+import kotlinx.coroutines.channels.Channel
+import kotlinx.coroutines.suspendCancellableCoroutine
+import kotlinx.coroutines.withTimeout
+import java.util.UUID
+import kotlin.coroutines.resume
+import kotlin.coroutines.resumeWithException
+
+private val CCCD_UUID = UUID.fromString("00002902-0000-1000-8000-00805f9b34fb")
+
+/** The MTU requested on connect; Android caps it at 517. */
+private const val REQUESTED_MTU = 517
+
+/**
+ * Reference transport of [GeneratedClient] over the Android GATT API.
+ *
+ * [connect] opens a GATT connection, requests a larger MTU, discovers the
+ * blerpc service and subscribes to the RPC characteristic. Commands are
+ * framed with the protocol library, chunked into containers that fit the
+ * MTU and written with response, so each container is acknowledged before
+ * the next; responses are reassembled from the notifications.
+ *
+ *     val client = GattClient(context)
+ *     client.connect(device)
+ *     val resp = client.echo(message = "hello")
+ *     client.disconnect()
+ *
+ * The app must hold the runtime permissions of [BlePermissions].
+ */
+@SuppressLint("MissingPermission")
+class GattClient(
+    private val context: Context,
+    private val requireEncryption: Boolean = true,
+) : GeneratedClient() {
+    private var gatt: BluetoothGatt? = null
+    private var char: BluetoothGattCharacteristic? = null
+    private val notifications = Channel<ByteArray>(Channel.UNLIMITED)
+
+    @Volatile private var pending: CancellableContinuation<Int>? = null
+
+    private var splitter: ContainerSplitter? = null
+    private val assembler = ContainerAssembler()
+    private var timeoutMs: Long = 100
+    private var maxRequestPayloadSize: Int? = null
+    private var peripheralFlags = 0
+    private var session: BlerpcCryptoSession? = null
+
+    /** ATT MTU of the link; 23 until connected. */
+    var mtu: Int = 23
+        private set
+
+    val isConnected: Boolean get() = char != null
+    val isEncrypted: Boolean get() = session != null
+    override val capabilityFlags: Int get() = peripheralFlags
+
+    private val callback =
+        object : BluetoothGattCallback() {
+            override fun onConnectionStateChange(
+                g: BluetoothGatt,
+                status: Int,
+                newState: Int,
+            ) {
+                if (newState == BluetoothProfile.STATE_CONNECTED && status == BluetoothGatt.GATT_SUCCESS) {
+                    g.discoverServices()
+                } else if (newState == BluetoothProfile.STATE_DISCONNECTED) {
+                    reset()
+                    complete(BluetoothGatt.GATT_FAILURE)
+                }
+            }
+
+            override fun onServicesDiscovered(
+                g: BluetoothGatt,
+                status: Int,
+            ) = complete(status)
+
+            override fun onMtuChanged(
+                g: BluetoothGatt,
+                newMtu: Int,
+                status: Int,
+            ) {
+                if (status == BluetoothGatt.GATT_SUCCESS) mtu = newMtu
+                complete(status)
+            }
+
+            override fun onDescriptorWrite(
+                g: BluetoothGatt,
+                descriptor: BluetoothGattDescriptor,
+                status: Int,
+            ) = complete(status)
+
+            override fun onCharacteristicWrite(
+                g: BluetoothGatt,
+                characteristic: BluetoothGattCharacteristic,
+                status: Int,
+            ) = complete(status)
+
+            @Suppress("DEPRECATION")
+            override fun onCharacteristicChanged(
+                g: BluetoothGatt,
+                characteristic: BluetoothGattCharacteristic,
+            ) {
+                // Called below API 33; later releases call the overload with the value.
+                notifications.trySend(characteristic.value)
+            }
+
+            override fun onCharacteristicChanged(
+                g: BluetoothGatt,
+                characteristic: BluetoothGattCharacteristic,
+                value: ByteArray,
+            ) {
+                notifications.trySend(value)
+            }
+        }
+
+    /** Resumes the GATT operation in flight with its status. */
+    private fun complete(status: Int) {
+        pending?.let {
+            pending = null
+            it.resume(status)
+        }
+    }
+
+    /**
+     * Starts a GATT operation and suspends until its callback reports the
+     * status. The Android stack runs one operation at a time.
+     */
+    private suspend fun gattOp(
+        what: String,
+        start: () -> Boolean,
+    ) {
+        val status =
+            suspendCancellableCoroutine { cont ->
+                pending = cont
+                if (!start()) {
+                    pending = null
+                    cont.resumeWithException(TransportException("$what failed to start"))
+                }
+            }
+        if (status != BluetoothGatt.GATT_SUCCESS) {
+            throw TransportException("$what failed: status $status")
+        }
+    }
+
+    /** Connects to [device] and sets up the RPC channel. */
+    suspend fun connect(device: BluetoothDevice) {
+        BlePermissions.check(context)
+        while (notifications.tryReceive().isSuccess) { /* discard */ }
+        try {
+            gattOp("Connect") {
+                gatt = device.connectGatt(context, false, callback, BluetoothDevice.TRANSPORT_LE)
+                gatt != null
+            }
+            val g = gatt!!
+            try {
+                gattOp("MTU request") { g.requestMtu(REQUESTED_MTU) }
+            } catch (_: TransportException) {
+                // Keep the default MTU.
+            }
+            val c =
+                g.getService(GeneratedUuids.SERVICE_UUID)?.getCharacteristic(GeneratedUuids.CHAR_UUID)
+                    ?: throw TransportException("blerpc service not found")
+            g.setCharacteristicNotification(c, true)
+            val cccd = c.getDescriptor(CCCD_UUID) ?: throw TransportException("Notification descriptor not found")
+            gattOp("Notification subscription") {
+                if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.TIRAMISU) {
+                    g.writeDescriptor(cccd, BluetoothGattDescriptor.ENABLE_NOTIFICATION_VALUE) == BluetoothStatusCodes.SUCCESS
+                } else {
+                    @Suppress("DEPRECATION")
+                    cccd.value = BluetoothGattDescriptor.ENABLE_NOTIFICATION_VALUE
+                    @Suppress("DEPRECATION")
+                    g.writeDescriptor(cccd)
+                }
+            }
+            char = c
+        } catch (e: Exception) {
+            disconnect()
+            throw e
+        }
+        splitter = ContainerSplitter(mtu = mtu)
+
+        try {
+            requestTimeout()
+        } catch (_: TimeoutException) {
+            // Keep the default timeout.
+        }
+        try {
+            requestCapabilities()
+        } catch (_: TimeoutException) {
+            // The peripheral predates capabilities.
+        }
+        if (requireEncryption && session == null) {
+            disconnect()
+            throw TransportException("Encryption required but key exchange was not completed")
+        }
+    }
+
+    fun disconnect() {
+        gatt?.close()
+        gatt = null
+        reset()
+    }
+
+    private fun reset() {
+        char = null
+        splitter = null
+        session = null
+        maxRequestPayloadSize = null
+        peripheralFlags = 0
+    }
+
+    private suspend fun write(data: ByteArray) {
+        val g = gatt ?: throw TransportException("Not connected")
+        val c = char ?: throw TransportException("Not connected")
+        gattOp("Write") {
+            if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.TIRAMISU) {
+                g.writeCharacteristic(c, data, BluetoothGattCharacteristic.WRITE_TYPE_DEFAULT) == BluetoothStatusCodes.SUCCESS
+            } else {
+                @Suppress("DEPRECATION")
+                c.value = data
+                c.writeType = BluetoothGattCharacteristic.WRITE_TYPE_DEFAULT
+                @Suppress("DEPRECATION")
+                g.writeCharacteristic(c)
+            }
+        }
+    }
+
+    private suspend fun readNotify(timeoutMs: Long): ByteArray =
+        try {
+            withTimeout(timeoutMs) { notifications.receive() }
+        } catch (e: TimeoutCancellationException) {
+            throw TimeoutException("Timeout waiting for notification", e)
+        }
+
+    private suspend fun control(
+        request: Container,
+        cmd: ControlCmd,
+        timeoutMs: Long,
+    ): ByteArray {
+        write(request.serialize())
+        val resp = Container.deserialize(readNotify(timeoutMs))
+        if (resp.containerType != ContainerType.CONTROL || resp.controlCmd != cmd) {
+            throw TransportException("Expected control command $cmd")
+        }
+        return resp.payload
+    }
+
+    private suspend fun requestTimeout() {
+        val s = splitter ?: throw TransportException("Not connected")
+        val payload = control(makeTimeoutRequest(transactionId = s.nextTransactionId()), ControlCmd.TIMEOUT, 1000)
+        if (payload.size == 2) {
+            timeoutMs = ((payload[0].toInt() and 0xFF) or ((payload[1].toInt() and 0xFF) shl 8)).toLong()
+        }
+    }
+
+    private suspend fun requestCapabilities() {
+        val s = splitter ?: throw TransportException("Not connected")
+        val payload = control(makeCapabilitiesRequest(transactionId = s.nextTransactionId()), ControlCmd.CAPABILITIES, 1000)
+        if (payload.size < 6) return
+        maxRequestPayloadSize = (payload[0].toInt() and 0xFF) or ((payload[1].toInt() and 0xFF) shl 8)
+        val flags = (payload[4].toInt() and 0xFF) or ((payload[5].toInt() and 0xFF) shl 8)
+        peripheralFlags = flags
+        if (flags and CAPABILITY_FLAG_ENCRYPTION_SUPPORTED == 0) return
+        try {
+            session =
+                centralPerformKeyExchange(
+                    send = { data -> write(makeKeyExchange(transactionId = s.nextTransactionId(), payload = data).serialize()) },
+                    receive = {
+                        val resp = Container.deserialize(readNotify(2000))
+                        if (resp.containerType != ContainerType.CONTROL || resp.controlCmd != ControlCmd.KEY_EXCHANGE) {
+                            throw TransportException("Expected KEY_EXCHANGE response")
+                        }
+                        resp.payload
+                    },
+                )
+        } catch (e: Exception) {
+            if (requireEncryption) throw e
+        }
+    }
+
+    private fun encrypt(payload: ByteArray): ByteArray {
+        session?.let { return it.encrypt(payload) }
+        if (requireEncryption) throw TransportException("Encryption required but no session established")
+        return payload
+    }
+
+    private fun decrypt(payload: ByteArray): ByteArray {
+        session?.let { return it.decrypt(payload) }
+        if (requireEncryption) throw TransportException("Encryption required but no session established")
+        return payload
+    }
+
+    private suspend fun send(
+        cmdName: String,
+        requestData: ByteArray,
+    ) {
+        val s = splitter ?: throw TransportException("Not connected")
+        val payload = CommandPacket(cmdType = CommandType.REQUEST, cmdName = cmdName, data = requestData).serialize()
+        maxRequestPayloadSize?.let { limit ->
+            if (payload.size > limit) {
+                throw TransportException("Request payload (${payload.size} bytes) exceeds peripheral limit ($limit bytes)")
+            }
+        }
+        for (c in s.split(encrypt(payload))) {
+            write(c.serialize())
+        }
+    }
+
+    /** Reads the next response; null when a P→C stream ends. */
+    private suspend fun receive(
+        cmdName: String?,
+        firstRead: Boolean,
+    ): ByteArray? {
+        assembler.reset()
+        var first = firstRead
+        while (true) {
+            // The first read also covers the time the peripheral runs the command.
+            val container = Container.deserialize(readNotify(if (first) maxOf(timeoutMs, 2000) else timeoutMs))
+            first = false
+            if (container.containerType == ContainerType.CONTROL) {
+                if (container.controlCmd == ControlCmd.STREAM_END_P2C && cmdName == null) return null
+                if (container.controlCmd == ControlCmd.ERROR && container.payload.isNotEmpty()) {
+                    val code = container.payload[0]
+                    if (code == BLERPC_ERROR_RESPONSE_TOO_LARGE) {
+                        throw TransportException("Response exceeds peripheral's max_response_payload_size")
+                    }
+                    throw TransportException("Peripheral error: 0x${(code.toInt() and 0xFF).toString(16).padStart(2, '0')}")
+                }
+                continue
+            }
+            val result = assembler.feed(container) ?: continue
+            val resp = CommandPacket.deserialize(decrypt(result))
+            if (resp.cmdType != CommandType.RESPONSE) {
+                throw TransportException("Expected response, got type=${resp.cmdType}")
+            }
+            if (cmdName != null && resp.cmdName != cmdName) {
+                throw TransportException("Command name mismatch: expected '$cmdName', got '${resp.cmdName}'")
+            }
+            return resp.data
+        }
+    }
+
+    override suspend fun call(
+        cmdName: String,
+        requestData: ByteArray,
+    ): ByteArray {
+        send(cmdName, requestData)
+        return receive(cmdName, true)!!
+    }
+
+    override suspend fun streamReceive(
+        cmdName: String,
+        requestData: ByteArray,
+    ): List<ByteArray> {
+        send(cmdName, requestData)
+        val responses = mutableListOf<ByteArray>()
+        var data = receive(null, true)
+        while (data != null) {
+            responses.add(data)
+            data = receive(null, false)
+        }
+        return responses
+    }
+
+    override suspend fun streamSend(
+        cmdName: String,
+        messages: List<ByteArray>,
+        finalCmdName: String,
+    ): ByteArray {
+        for (message in messages) {
+            send(cmdName, message)
+        }
+        val s = splitter ?: throw TransportException("Not connected")
+        write(makeStreamEndC2P(transactionId = s.nextTransactionId()).serialize())
+        return receive(finalCmdName, true)!!
+    }
+}
@@ -0,0 +1,32 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import android.bluetooth.le.ScanRecord
+import com.google.protobuf.InvalidProtocolBufferException
+import com.google.protobuf.MessageLite
+
+/** Decodes advertisements broadcast as manufacturer specific data. */
+object GeneratedAdvertising {
+    /** Bluetooth SIG company identifier of the manufacturer data. */
+    const val COMPANY_ID = 0xFFFF
+
+    const val SENSOR_STATE_TYPE = 1
+
+    /** Returns the advertisement in [record], or null if it holds none of this schema. */
+    fun parse(record: ScanRecord): MessageLite? =
+        record.getManufacturerSpecificData(COMPANY_ID)?.let { parse(it) }
+
+    /** Decodes manufacturer data without the company identifier. */
+    fun parse(data: ByteArray): MessageLite? {
+        if (data.isEmpty()) return null
+        val payload = data.copyOfRange(1, data.size)
+        return try {
+            when (data[0].toInt() and 0xFF) {
+                SENSOR_STATE_TYPE -> blerpc.Blerpc.SensorState.parseFrom(payload)
+                else -> null
+            }
+        } catch (e: InvalidProtocolBufferException) {
+            null
+        }
+    }
+}
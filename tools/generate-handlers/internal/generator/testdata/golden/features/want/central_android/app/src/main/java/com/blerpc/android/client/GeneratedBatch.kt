@@ -0,0 +1,268 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.google.protobuf.ByteString
+import com.google.protobuf.InvalidProtocolBufferException
+import java.io.ByteArrayOutputStream
+
+/** Command name of the batch envelope. */
+const val BATCH_COMMAND = "_batch"
+
+/** The batch response holds no complete response for a call. */
+class BatchException(message: String) : BlerpcException(message)
+
+/** The response of a call added to a [Batch], once the batch is sent. */
+class BatchCall<T> internal constructor(
+    val command: String,
+    private val parse: (ByteArray) -> T,
+) {
+    private var result: Result<T>? = null
+
+    internal val error: Throwable? get() = result?.exceptionOrNull()
+
+    /** Returns the response, or throws the error of the call. */
+    fun get(): T = checkNotNull(result) { "$command: the batch was not sent" }.getOrThrow()
+
+    internal fun complete(data: ByteArray) {
+        result =
+            try {
+                statusException(command, data)?.let { throw it }
+                Result.success(parse(data))
+            } catch (e: InvalidProtocolBufferException) {
+                Result.failure(DecodeException(command, e))
+            } catch (e: BlerpcException) {
+                Result.failure(e)
+            }
+    }
+
+    internal fun fail(error: Throwable) {
+        result = Result.failure(error)
+    }
+}
+
+/**
+ * Unary calls sent to the peripheral in one request. The peripheral runs
+ * them in order and answers them in one response, so a sequence of small
+ * calls, such as provisioning, costs one round trip instead of one per call:
+ *
+ *     val batch = Batch(client)
+ *     val echo = batch.echo(message = "hi")
+ *     batch.send()
+ *     println(echo.get().message)
+ *
+ * Streaming commands, and commands with a replay counter or a session
+ * token, cannot be batched.
+ */
+class Batch(private val client: GeneratedClient) {
+    private val entries = ByteArrayOutputStream()
+    private val calls = mutableListOf<BatchCall<*>>()
+
+    fun echo(message: String = ""): BatchCall<blerpc.Blerpc.EchoResponse> {
+        if (message.encodeToByteArray().size > 256) {
+            throw StringTooLongException("echo", "message", message.encodeToByteArray().size, 256)
+        }
+        val req = blerpc.Blerpc.EchoRequest.newBuilder()
+            .setMessage(message)
+            .build()
+        val call = BatchCall("echo") { blerpc.Blerpc.EchoResponse.parseFrom(it) }
+        return add(CommandId.ECHO.wireName, req.toByteArray(), call)
+    }
+
+    @Deprecated("data_write is deprecated in the schema")
+    @Suppress("DEPRECATION")
+    fun dataWrite(data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): BatchCall<blerpc.Blerpc.DataWriteResponse> {
+        val req = blerpc.Blerpc.DataWriteRequest.newBuilder()
+            .setData(data)
+            .build()
+        val call = BatchCall("data_write") { blerpc.Blerpc.DataWriteResponse.parseFrom(it) }
+        return add(CommandId.DATA_WRITE.wireName, req.toByteArray(), call)
+    }
+
+    fun getBlerpcInfo(): BatchCall<blerpc.Blerpc.GetBlerpcInfoResponse> {
+        val req = blerpc.Blerpc.GetBlerpcInfoRequest.newBuilder()
+            .build()
+        val call = BatchCall("get_blerpc_info") { blerpc.Blerpc.GetBlerpcInfoResponse.parseFrom(it) }
+        return add(CommandId.GET_BLERPC_INFO.wireName, req.toByteArray(), call)
+    }
+
+    fun dfuBegin(size: Int = 0, crc32: Int = 0): BatchCall<blerpc.Blerpc.DfuBeginResponse> {
+        val req = blerpc.Blerpc.DfuBeginRequest.newBuilder()
+            .setSize(size)
+            .setCrc32(crc32)
+            .build()
+        val call = BatchCall("dfu_begin") { blerpc.Blerpc.DfuBeginResponse.parseFrom(it) }
+        return add(CommandId.DFU_BEGIN.wireName, req.toByteArray(), call)
+    }
+
+    fun dfuChunk(offset: Int = 0, data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): BatchCall<blerpc.Blerpc.DfuChunkResponse> {
+        val req = blerpc.Blerpc.DfuChunkRequest.newBuilder()
+            .setOffset(offset)
+            .setData(data)
+            .build()
+        val call = BatchCall("dfu_chunk") { blerpc.Blerpc.DfuChunkResponse.parseFrom(it) }
+        return add(CommandId.DFU_CHUNK.wireName, req.toByteArray(), call)
+    }
+
+    fun dfuFinalize(reboot: Boolean = false): BatchCall<blerpc.Blerpc.DfuFinalizeResponse> {
+        val req = blerpc.Blerpc.DfuFinalizeRequest.newBuilder()
+            .setReboot(reboot)
+            .build()
+        val call = BatchCall("dfu_finalize") { blerpc.Blerpc.DfuFinalizeResponse.parseFrom(it) }
+        return add(CommandId.DFU_FINALIZE.wireName, req.toByteArray(), call)
+    }
+
+    fun fileOpen(path: String = "", write: Boolean = false, resume: Boolean = false): BatchCall<blerpc.Blerpc.FileOpenResponse> {
+        val req = blerpc.Blerpc.FileOpenRequest.newBuilder()
+            .setPath(path)
+            .setWrite(write)
+            .setResume(resume)
+            .build()
+        val call = BatchCall("file_open") { blerpc.Blerpc.FileOpenResponse.parseFrom(it) }
+        return add(CommandId.FILE_OPEN.wireName, req.toByteArray(), call)
+    }
+
+    fun fileRead(handle: Int = 0, offset: Int = 0, length: Int = 0): BatchCall<blerpc.Blerpc.FileReadResponse> {
+        val req = blerpc.Blerpc.FileReadRequest.newBuilder()
+            .setHandle(handle)
+            .setOffset(offset)
+            .setLength(length)
+            .build()
+        val call = BatchCall("file_read") { blerpc.Blerpc.FileReadResponse.parseFrom(it) }
+        return add(CommandId.FILE_READ.wireName, req.toByteArray(), call)
+    }
+
+    fun fileWrite(handle: Int = 0, offset: Int = 0, data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): BatchCall<blerpc.Blerpc.FileWriteResponse> {
+        val req = blerpc.Blerpc.FileWriteRequest.newBuilder()
+            .setHandle(handle)
+            .setOffset(offset)
+            .setData(data)
+            .build()
+        val call = BatchCall("file_write") { blerpc.Blerpc.FileWriteResponse.parseFrom(it) }
+        return add(CommandId.FILE_WRITE.wireName, req.toByteArray(), call)
+    }
+
+    fun fileClose(handle: Int = 0, verify: Boolean = false): BatchCall<blerpc.Blerpc.FileCloseResponse> {
+        val req = blerpc.Blerpc.FileCloseRequest.newBuilder()
+            .setHandle(handle)
+            .setVerify(verify)
+            .build()
+        val call = BatchCall("file_close") { blerpc.Blerpc.FileCloseResponse.parseFrom(it) }
+        return add(CommandId.FILE_CLOSE.wireName, req.toByteArray(), call)
+    }
+
+    fun getRpcStats(reset: Boolean = false): BatchCall<blerpc.Blerpc.GetRpcStatsResponse> {
+        val req = blerpc.Blerpc.GetRpcStatsRequest.newBuilder()
+            .setReset(reset)
+            .build()
+        val call = BatchCall("get_rpc_stats") { blerpc.Blerpc.GetRpcStatsResponse.parseFrom(it) }
+        return add(CommandId.GET_RPC_STATS.wireName, req.toByteArray(), call)
+    }
+
+    fun startSession(): BatchCall<blerpc.Blerpc.StartSessionResponse> {
+        val req = blerpc.Blerpc.StartSessionRequest.newBuilder()
+            .build()
+        val call = BatchCall("start_session") { blerpc.Blerpc.StartSessionResponse.parseFrom(it) }
+        return add(CommandId.START_SESSION.wireName, req.toByteArray(), call)
+    }
+
+    fun authenticateSession(proof: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): BatchCall<blerpc.Blerpc.AuthenticateSessionResponse> {
+        val req = blerpc.Blerpc.AuthenticateSessionRequest.newBuilder()
+            .setProof(proof)
+            .build()
+        val call = BatchCall("authenticate_session") { blerpc.Blerpc.AuthenticateSessionResponse.parseFrom(it) }
+        return add(CommandId.AUTHENTICATE_SESSION.wireName, req.toByteArray(), call)
+    }
+
+    fun timeSync(unix_time_us: Long = 0L, offset_us: Int = 0): BatchCall<blerpc.Blerpc.TimeSyncResponse> {
+        val req = blerpc.Blerpc.TimeSyncRequest.newBuilder()
+            .setUnixTimeUs(unix_time_us)
+            .setOffsetUs(offset_us)
+            .build()
+        val call = BatchCall("time_sync") { blerpc.Blerpc.TimeSyncResponse.parseFrom(it) }
+        return add(CommandId.TIME_SYNC.wireName, req.toByteArray(), call)
+    }
+
+    fun ping(payload: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): BatchCall<blerpc.Blerpc.PingResponse> {
+        val req = blerpc.Blerpc.PingRequest.newBuilder()
+            .setPayload(payload)
+            .build()
+        val call = BatchCall("ping") { blerpc.Blerpc.PingResponse.parseFrom(it) }
+        return add(CommandId.PING.wireName, req.toByteArray(), call)
+    }
+
+    fun getCapabilities(): BatchCall<blerpc.Blerpc.GetCapabilitiesResponse> {
+        val req = blerpc.Blerpc.GetCapabilitiesRequest.newBuilder()
+            .build()
+        val call = BatchCall("get_capabilities") { blerpc.Blerpc.GetCapabilitiesResponse.parseFrom(it) }
+        return add(CommandId.GET_CAPABILITIES.wireName, req.toByteArray(), call)
+    }
+
+    fun getSetting(field: Int = 0): BatchCall<blerpc.Blerpc.GetSettingResponse> {
+        val req = blerpc.Blerpc.GetSettingRequest.newBuilder()
+            .setField(field)
+            .build()
+        val call = BatchCall("get_setting") { blerpc.Blerpc.GetSettingResponse.parseFrom(it) }
+        return add(CommandId.GET_SETTING.wireName, req.toByteArray(), call)
+    }
+
+    fun setSetting(field: Int = 0, value: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): BatchCall<blerpc.Blerpc.SetSettingResponse> {
+        val req = blerpc.Blerpc.SetSettingRequest.newBuilder()
+            .setField(field)
+            .setValue(value)
+            .build()
+        val call = BatchCall("set_setting") { blerpc.Blerpc.SetSettingResponse.parseFrom(it) }
+        return add(CommandId.SET_SETTING.wireName, req.toByteArray(), call)
+    }
+
+    private fun <T> add(
+        cmdName: String,
+        requestData: ByteArray,
+        call: BatchCall<T>,
+    ): BatchCall<T> {
+        val name = cmdName.toByteArray()
+        entries.write(name.size)
+        entries.write(name)
+        entries.write(requestData.size and 0xFF)
+        entries.write(requestData.size shr 8)
+        entries.write(requestData)
+        calls.add(call)
+        return call
+    }
+
+    /**
+     * Sends the calls added since the last send and records their results.
+     * Throws the error of the first failed call once every result is
+     * recorded, or the transport error, which every call then throws too.
+     */
+    suspend fun send() {
+        val sent = calls.toList()
+        val payload = entries.toByteArray()
+        calls.clear()
+        entries.reset()
+        if (sent.isEmpty()) return
+        val respData =
+            try {
+                client.exclusive { client.call(BATCH_COMMAND, payload) }
+            } catch (e: Exception) {
+                sent.forEach { it.fail(e) }
+                throw e
+            }
+        var pos = 0
+        for (call in sent) {
+            val size =
+                if (respData.size - pos < 2) {
+                    0
+                } else {
+                    (respData[pos].toInt() and 0xFF) or ((respData[pos + 1].toInt() and 0xFF) shl 8)
+                }
+            if (respData.size - pos < 2 + size) {
+                call.fail(BatchException("${call.command}: no response in the batch"))
+                pos = respData.size
+                continue
+            }
+            call.complete(respData.copyOfRange(pos + 2, pos + 2 + size))
+            pos += 2 + size
+        }
+        sent.firstNotNullOfOrNull { it.error }?.let { throw it }
+    }
+}
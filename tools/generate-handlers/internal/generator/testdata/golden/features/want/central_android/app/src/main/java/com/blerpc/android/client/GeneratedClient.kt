@@ -0,0 +1,962 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.google.protobuf.ByteString
+import com.google.protobuf.InvalidProtocolBufferException
+import kotlinx.coroutines.CancellationException
+import kotlinx.coroutines.NonCancellable
+import kotlinx.coroutines.TimeoutCancellationException
+import kotlinx.coroutines.delay
+import kotlinx.coroutines.flow.Flow
+import kotlinx.coroutines.flow.flow
+import kotlinx.coroutines.flow.map
+import kotlinx.coroutines.flow.toList
+import kotlinx.coroutines.sync.Mutex
+import kotlinx.coroutines.sync.withLock
+import kotlinx.coroutines.withContext
+import kotlinx.coroutines.withTimeout
+import java.io.ByteArrayOutputStream
+import java.nio.ByteBuffer
+import java.nio.ByteOrder
+import java.util.concurrent.ConcurrentHashMap
+import java.util.zip.DataFormatException
+import java.util.zip.Deflater
+import java.util.zip.Inflater
+import javax.crypto.Mac
+import javax.crypto.spec.SecretKeySpec
+
+/** Base class of errors thrown by generated client methods. */
+open class BlerpcException(message: String, cause: Throwable? = null) : Exception(message, cause)
+
+/** The request could not be delivered or the response was lost. */
+open class TransportException(message: String, cause: Throwable? = null) : BlerpcException(message, cause)
+
+/** The peripheral did not respond in time. */
+class TimeoutException(message: String, cause: Throwable? = null) : TransportException(message, cause)
+
+/** The response payload is not a valid message. */
+class DecodeException(val command: String, cause: Throwable) :
+    BlerpcException("$command: invalid response", cause)
+
+/** The peripheral reported a non-OK status. */
+open class RemoteException(
+    val command: String,
+    val status: Int,
+    message: String = "$command failed: status=$status",
+) : BlerpcException(message)
+
+/** Status codes of error responses; 128 and up are application codes. */
+enum class StatusCode(val code: Int) {
+    OK(0),
+    INVALID_ARGUMENT(1),
+    NOT_FOUND(2),
+    ALREADY_EXISTS(3),
+    PERMISSION_DENIED(4),
+    RESOURCE_EXHAUSTED(5),
+    FAILED_PRECONDITION(6),
+    OUT_OF_RANGE(7),
+    UNIMPLEMENTED(8),
+    INTERNAL(9),
+    UNAVAILABLE(10),
+    UNAUTHENTICATED(11),
+}
+
+/** The request is malformed or a field is out of bounds. */
+class InvalidArgumentException(command: String) :
+    RemoteException(command, StatusCode.INVALID_ARGUMENT.code, "$command failed: INVALID_ARGUMENT")
+
+/** The requested entity does not exist. */
+class NotFoundException(command: String) :
+    RemoteException(command, StatusCode.NOT_FOUND.code, "$command failed: NOT_FOUND")
+
+/** The entity to create exists already. */
+class AlreadyExistsException(command: String) :
+    RemoteException(command, StatusCode.ALREADY_EXISTS.code, "$command failed: ALREADY_EXISTS")
+
+/** The caller may not run the command. */
+class PermissionDeniedException(command: String) :
+    RemoteException(command, StatusCode.PERMISSION_DENIED.code, "$command failed: PERMISSION_DENIED")
+
+/** Memory, storage or another resource ran out. */
+class ResourceExhaustedException(command: String) :
+    RemoteException(command, StatusCode.RESOURCE_EXHAUSTED.code, "$command failed: RESOURCE_EXHAUSTED")
+
+/** The device is not in a state to run the command. */
+class FailedPreconditionException(command: String) :
+    RemoteException(command, StatusCode.FAILED_PRECONDITION.code, "$command failed: FAILED_PRECONDITION")
+
+/** An offset or value lies past the valid range. */
+class OutOfRangeException(command: String) :
+    RemoteException(command, StatusCode.OUT_OF_RANGE.code, "$command failed: OUT_OF_RANGE")
+
+/** The command is not supported by this firmware. */
+class UnimplementedException(command: String) :
+    RemoteException(command, StatusCode.UNIMPLEMENTED.code, "$command failed: UNIMPLEMENTED")
+
+/** The firmware hit an unexpected error. */
+class InternalException(command: String) :
+    RemoteException(command, StatusCode.INTERNAL.code, "$command failed: INTERNAL")
+
+/** The device cannot run the command now; retry later. */
+class UnavailableException(command: String) :
+    RemoteException(command, StatusCode.UNAVAILABLE.code, "$command failed: UNAVAILABLE")
+
+/** The command needs an authenticated session. */
+class UnauthenticatedException(command: String) :
+    RemoteException(command, StatusCode.UNAUTHENTICATED.code, "$command failed: UNAUTHENTICATED")
+
+// Error responses hold only a status, in a field no message uses.
+private val STATUS_TAG = byteArrayOf(0xF8.toByte(), 0xFF.toByte(), 0xFF.toByte(), 0xFF.toByte(), 0x0F.toByte())
+
+/** Returns the error an error response reports, or null for other responses. */
+fun statusException(command: String, data: ByteArray): RemoteException? {
+    if (data.size <= STATUS_TAG.size || STATUS_TAG.indices.any { data[it] != STATUS_TAG[it] }) return null
+    var status = 0
+    var shift = 0
+    for (i in STATUS_TAG.size until data.size) {
+        val byte = data[i].toInt() and 0xFF
+        status = status or ((byte and 0x7F) shl shift)
+        shift += 7
+        if (byte < 0x80) break
+    }
+    return when (status) {
+        StatusCode.INVALID_ARGUMENT.code -> InvalidArgumentException(command)
+        StatusCode.NOT_FOUND.code -> NotFoundException(command)
+        StatusCode.ALREADY_EXISTS.code -> AlreadyExistsException(command)
+        StatusCode.PERMISSION_DENIED.code -> PermissionDeniedException(command)
+        StatusCode.RESOURCE_EXHAUSTED.code -> ResourceExhaustedException(command)
+        StatusCode.FAILED_PRECONDITION.code -> FailedPreconditionException(command)
+        StatusCode.OUT_OF_RANGE.code -> OutOfRangeException(command)
+        StatusCode.UNIMPLEMENTED.code -> UnimplementedException(command)
+        StatusCode.INTERNAL.code -> InternalException(command)
+        StatusCode.UNAVAILABLE.code -> UnavailableException(command)
+        StatusCode.UNAUTHENTICATED.code -> UnauthenticatedException(command)
+        else -> RemoteException(command, status)
+    }
+}
+
+/**
+ * The schema this client was generated from, which
+ * [GeneratedClient.verifySchema] compares with the peripheral's.
+ */
+const val SCHEMA_HASH = "fb27bddde9c1f6b7"
+const val GENERATOR_VERSION = "0.1.0"
+
+/** The peripheral was built from a different schema than this client. */
+class SchemaMismatchException(
+    val expected: String,
+    val actual: String,
+    val generatorVersion: String,
+) : BlerpcException(
+    "peripheral schema $actual (generator $generatorVersion) does not match " +
+        "this client's $expected (generator $GENERATOR_VERSION)",
+)
+
+/** A string argument is longer in UTF-8 than its max_size allows. */
+class StringTooLongException(
+    val command: String,
+    val field: String,
+    val size: Int,
+    val maxBytes: Int,
+) : BlerpcException("$command: $field is $size bytes in UTF-8, over the $maxBytes its max_size allows")
+
+/**
+ * Leads replay-protected requests with a counter, 8 bytes little endian,
+ * and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of
+ * the command name, a NUL, the counter and the request. The peripheral drops
+ * requests whose MAC is wrong or whose counter is not above the last one it
+ * accepted; microseconds since the epoch keep it increasing across restarts.
+ */
+internal object ReplayCounter {
+    private var last = 0L
+
+    @Synchronized
+    fun prefix(key: ByteArray?, command: String, requestData: ByteArray): ByteArray {
+        key ?: throw BlerpcException("sessionKey is not set")
+        last = maxOf(last + 1, System.currentTimeMillis() * 1000)
+        val counter = ByteBuffer.allocate(8).order(ByteOrder.LITTLE_ENDIAN).putLong(last).array()
+        val mac = Mac.getInstance("HmacSHA256").apply { init(SecretKeySpec(key, "HmacSHA256")) }
+        mac.update(command.toByteArray())
+        mac.update(0.toByte())
+        mac.update(counter)
+        return counter + mac.doFinal(requestData).copyOf(16) + requestData
+    }
+}
+
+/** Numeric command IDs, sent as one-character command names. */
+enum class CommandId(val id: Int) {
+    ECHO(1),
+    FLASH_READ(2),
+    DATA_WRITE(3),
+    COUNTER_STREAM(4),
+    COUNTER_UPLOAD(5),
+    GET_BLERPC_INFO(6),
+    DFU_BEGIN(8),
+    DFU_CHUNK(9),
+    DFU_FINALIZE(10),
+    FILE_OPEN(11),
+    FILE_READ(12),
+    FILE_WRITE(13),
+    FILE_CLOSE(14),
+    LOG_STREAM(15),
+    GET_RPC_STATS(16),
+    START_SESSION(17),
+    AUTHENTICATE_SESSION(18),
+    TIME_SYNC(19),
+    PING(20),
+    GET_CAPABILITIES(21),
+    GET_SETTING(22),
+    SET_SETTING(23);
+
+    /** The command name carrying this ID. */
+    val wireName: String get() = Char(id).toString()
+}
+
+/** Timeout of each attempt of a command and how often it is retried. */
+class CallPolicy(val timeoutMs: Long, val retries: Int)
+
+/**
+ * Call policies by command. Commands without one wait as long as the
+ * transport does and are not retried.
+ */
+val CALL_POLICIES: Map<String, CallPolicy> = mapOf(
+    "echo" to CallPolicy(500, 2),
+    "flash_read" to CallPolicy(30000, 0),
+)
+
+/** CAPABILITIES flag of a peripheral answering compressed calls. */
+const val CAPABILITY_FLAG_COMPRESSION = 0x0002
+
+private const val COMPRESSED_COMMAND = "_compressed"
+private const val FLAG_COMPRESSED = 0x01
+private const val FLAG_ACCEPT_COMPRESSED = 0x02
+
+/** Compresses and decompresses the data of compressed calls. */
+interface CompressionCodec {
+    fun compress(data: ByteArray): ByteArray
+
+    fun decompress(data: ByteArray): ByteArray
+}
+
+/** Raw DEFLATE (RFC 1951). */
+object DeflateCodec : CompressionCodec {
+    override fun compress(data: ByteArray): ByteArray {
+        val deflater = Deflater(Deflater.BEST_COMPRESSION, true)
+        try {
+            deflater.setInput(data)
+            deflater.finish()
+            val out = ByteArrayOutputStream()
+            val buf = ByteArray(512)
+            while (!deflater.finished()) {
+                out.write(buf, 0, deflater.deflate(buf))
+            }
+            return out.toByteArray()
+        } finally {
+            deflater.end()
+        }
+    }
+
+    override fun decompress(data: ByteArray): ByteArray {
+        val inflater = Inflater(true)
+        try {
+            inflater.setInput(data)
+            val out = ByteArrayOutputStream()
+            val buf = ByteArray(512)
+            while (!inflater.finished()) {
+                val n = inflater.inflate(buf)
+                if (n == 0 && inflater.needsInput()) throw DataFormatException("truncated data")
+                out.write(buf, 0, n)
+            }
+            return out.toByteArray()
+        } finally {
+            inflater.end()
+        }
+    }
+}
+
+/**
+ * Codecs by algorithm. DEFLATE is built in; register a heatshrink codec
+ * (window 8, lookahead 4 bits) to compress the heatshrink commands too.
+ * Calls of a command whose algorithm has no codec are sent uncompressed.
+ */
+object CompressionCodecs {
+    val byAlgorithm: MutableMap<String, CompressionCodec> = ConcurrentHashMap(mapOf("deflate" to DeflateCodec))
+}
+
+/**
+ * Control command of the Cancel container, which stops the P→C stream in
+ * progress on the peripheral. The protocol library has no constant for it.
+ */
+const val CONTROL_CMD_CANCEL = 0x7
+
+/** Serializes a Cancel control container, which carries no payload. */
+fun cancelContainer(transactionId: Int): ByteArray =
+    byteArrayOf(transactionId.toByte(), 0, (0xC0 or (CONTROL_CMD_CANCEL shl 2)).toByte(), 0)
+
+/**
+ * Auto-generated RPC methods.
+ * Subclass and override for custom behavior.
+ */
+abstract class GeneratedClient {
+    private val rpcLock = Mutex()
+
+    abstract suspend fun call(cmdName: String, requestData: ByteArray): ByteArray
+    abstract suspend fun streamReceive(cmdName: String, requestData: ByteArray): List<ByteArray>
+    abstract suspend fun streamSend(cmdName: String, messages: List<ByteArray>, finalCmdName: String): ByteArray
+
+    /**
+     * Runs [block] with no other RPC of this client in flight. The peripheral
+     * handles one RPC at a time; the generated methods call through here, and
+     * so should direct uses of [call], [streamReceive] and [streamSend].
+     * Wrappers that order the calls themselves, such as PriorityClient,
+     * override it to run [block] at once.
+     */
+    open suspend fun <T> exclusive(block: suspend () -> T): T = rpcLock.withLock { block() }
+
+    /** Flags of the peripheral's CAPABILITIES response; 0 until it is received. */
+    open val capabilityFlags: Int get() = 0
+
+    /**
+     * Runs [block] under the call policy of [command]: each attempt fails with
+     * a [TimeoutException] after the timeout, and attempts that time out or
+     * fail with a [TransportException] are made again up to the retries.
+     */
+    protected suspend fun <T> withCallPolicy(command: String, block: suspend () -> T): T {
+        val policy = CALL_POLICIES.getValue(command)
+        var attempt = 0
+        while (true) {
+            try {
+                return try {
+                    withTimeout(policy.timeoutMs) { block() }
+                } catch (e: TimeoutCancellationException) {
+                    throw TimeoutException("$command: no response within ${policy.timeoutMs} ms", e)
+                }
+            } catch (e: TransportException) {
+                if (attempt++ == policy.retries) throw e
+            }
+        }
+    }
+
+    /**
+     * Sends a call of a compressed command in the compression envelope when
+     * the peripheral advertises [CAPABILITY_FLAG_COMPRESSION] and
+     * [CompressionCodecs] has a codec for [algorithm], compressing the request
+     * when that makes it smaller, and returns the response decompressed;
+     * sends a plain call otherwise.
+     */
+    protected suspend fun compressedCall(algorithm: String, cmdName: String, requestData: ByteArray): ByteArray {
+        val codec = CompressionCodecs.byAlgorithm[algorithm]
+        if (codec == null || capabilityFlags and CAPABILITY_FLAG_COMPRESSION == 0) {
+            return call(cmdName, requestData)
+        }
+        val packed = codec.compress(requestData)
+        val compressed = packed.size < requestData.size
+        val flags = if (compressed) FLAG_COMPRESSED or FLAG_ACCEPT_COMPRESSED else FLAG_ACCEPT_COMPRESSED
+        val name = cmdName.toByteArray()
+        val envelope =
+            byteArrayOf(name.size.toByte()) + name + byteArrayOf(flags.toByte()) +
+                if (compressed) packed else requestData
+        val respData = call(COMPRESSED_COMMAND, envelope)
+        if (respData.isEmpty()) throw DecodeException(cmdName, DataFormatException("empty response"))
+        val data = respData.copyOfRange(1, respData.size)
+        if (respData[0].toInt() and FLAG_COMPRESSED == 0) return data
+        return try {
+            codec.decompress(data)
+        } catch (e: Exception) {
+            throw DecodeException(cmdName, e)
+        }
+    }
+
+    /**
+     * Receives the responses of a P→C stream as they arrive. The default emits
+     * the list [streamReceive] returns; override to emit each response as it
+     * is received.
+     */
+    open fun streamReceiveFlow(
+        cmdName: String,
+        requestData: ByteArray,
+    ): Flow<ByteArray> = flow { streamReceive(cmdName, requestData).forEach { emit(it) } }
+
+    /**
+     * Stops the P→C stream in progress, whose collector stopped early. The
+     * default does nothing and the peripheral runs the stream to its end;
+     * override to send a [cancelContainer] and drain the stream.
+     */
+    open suspend fun streamCancel() {}
+
+    /**
+     * Sends a C→P stream whose messages are produced as it is sent. The
+     * default collects [messages] and calls [streamSend]; override to send
+     * each message as it is emitted.
+     */
+    open suspend fun streamSendFlow(
+        cmdName: String,
+        messages: Flow<ByteArray>,
+        finalCmdName: String,
+    ): ByteArray = streamSend(cmdName, messages.toList(), finalCmdName)
+
+    protected inline fun <T> decode(command: String, data: ByteArray, parse: (ByteArray) -> T): T {
+        statusException(command, data)?.let { throw it }
+        return try {
+            parse(data)
+        } catch (e: InvalidProtocolBufferException) {
+            throw DecodeException(command, e)
+        }
+    }
+
+    open suspend fun echo(message: String = ""): blerpc.Blerpc.EchoResponse {
+        if (message.encodeToByteArray().size > 256) {
+            throw StringTooLongException("echo", "message", message.encodeToByteArray().size, 256)
+        }
+        val req = blerpc.Blerpc.EchoRequest.newBuilder()
+            .setMessage(message)
+            .build()
+        val respData = exclusive { withCallPolicy("echo") { compressedCall("deflate", CommandId.ECHO.wireName, req.toByteArray()) } }
+        return decode("echo", respData) { blerpc.Blerpc.EchoResponse.parseFrom(it) }
+    }
+
+    open suspend fun flashRead(address: Int = 0, length: Int = 0): blerpc.Blerpc.FlashReadResponse {
+        val req = blerpc.Blerpc.FlashReadRequest.newBuilder()
+            .setAddress(address)
+            .setLength(length)
+            .build()
+        val respData = exclusive { withCallPolicy("flash_read") { sessionCall(CommandId.FLASH_READ.wireName, ReplayCounter.prefix(sessionKey, "flash_read", req.toByteArray())) } }
+        return decode("flash_read", respData) { blerpc.Blerpc.FlashReadResponse.parseFrom(it) }
+    }
+
+    @Deprecated("data_write is deprecated in the schema")
+    @Suppress("DEPRECATION")
+    open suspend fun dataWrite(data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): blerpc.Blerpc.DataWriteResponse {
+        val req = blerpc.Blerpc.DataWriteRequest.newBuilder()
+            .setData(data)
+            .build()
+        val respData = exclusive { call(CommandId.DATA_WRITE.wireName, req.toByteArray()) }
+        return decode("data_write", respData) { blerpc.Blerpc.DataWriteResponse.parseFrom(it) }
+    }
+
+    open suspend fun getBlerpcInfo(): blerpc.Blerpc.GetBlerpcInfoResponse {
+        val req = blerpc.Blerpc.GetBlerpcInfoRequest.newBuilder()
+            .build()
+        val respData = exclusive { call(CommandId.GET_BLERPC_INFO.wireName, req.toByteArray()) }
+        return decode("get_blerpc_info", respData) { blerpc.Blerpc.GetBlerpcInfoResponse.parseFrom(it) }
+    }
+
+    open suspend fun dfuBegin(size: Int = 0, crc32: Int = 0): blerpc.Blerpc.DfuBeginResponse {
+        val req = blerpc.Blerpc.DfuBeginRequest.newBuilder()
+            .setSize(size)
+            .setCrc32(crc32)
+            .build()
+        val respData = exclusive { call(CommandId.DFU_BEGIN.wireName, req.toByteArray()) }
+        return decode("dfu_begin", respData) { blerpc.Blerpc.DfuBeginResponse.parseFrom(it) }
+    }
+
+    open suspend fun dfuChunk(offset: Int = 0, data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): blerpc.Blerpc.DfuChunkResponse {
+        val req = blerpc.Blerpc.DfuChunkRequest.newBuilder()
+            .setOffset(offset)
+            .setData(data)
+            .build()
+        val respData = exclusive { call(CommandId.DFU_CHUNK.wireName, req.toByteArray()) }
+        return decode("dfu_chunk", respData) { blerpc.Blerpc.DfuChunkResponse.parseFrom(it) }
+    }
+
+    open suspend fun dfuFinalize(reboot: Boolean = false): blerpc.Blerpc.DfuFinalizeResponse {
+        val req = blerpc.Blerpc.DfuFinalizeRequest.newBuilder()
+            .setReboot(reboot)
+            .build()
+        val respData = exclusive { call(CommandId.DFU_FINALIZE.wireName, req.toByteArray()) }
+        return decode("dfu_finalize", respData) { blerpc.Blerpc.DfuFinalizeResponse.parseFrom(it) }
+    }
+
+    open suspend fun fileOpen(path: String = "", write: Boolean = false, resume: Boolean = false): blerpc.Blerpc.FileOpenResponse {
+        val req = blerpc.Blerpc.FileOpenRequest.newBuilder()
+            .setPath(path)
+            .setWrite(write)
+            .setResume(resume)
+            .build()
+        val respData = exclusive { call(CommandId.FILE_OPEN.wireName, req.toByteArray()) }
+        return decode("file_open", respData) { blerpc.Blerpc.FileOpenResponse.parseFrom(it) }
+    }
+
+    open suspend fun fileRead(handle: Int = 0, offset: Int = 0, length: Int = 0): blerpc.Blerpc.FileReadResponse {
+        val req = blerpc.Blerpc.FileReadRequest.newBuilder()
+            .setHandle(handle)
+            .setOffset(offset)
+            .setLength(length)
+            .build()
+        val respData = exclusive { compressedCall("heatshrink", CommandId.FILE_READ.wireName, req.toByteArray()) }
+        return decode("file_read", respData) { blerpc.Blerpc.FileReadResponse.parseFrom(it) }
+    }
+
+    open suspend fun fileWrite(handle: Int = 0, offset: Int = 0, data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): blerpc.Blerpc.FileWriteResponse {
+        val req = blerpc.Blerpc.FileWriteRequest.newBuilder()
+            .setHandle(handle)
+            .setOffset(offset)
+            .setData(data)
+            .build()
+        val respData = exclusive { call(CommandId.FILE_WRITE.wireName, req.toByteArray()) }
+        return decode("file_write", respData) { blerpc.Blerpc.FileWriteResponse.parseFrom(it) }
+    }
+
+    open suspend fun fileClose(handle: Int = 0, verify: Boolean = false): blerpc.Blerpc.FileCloseResponse {
+        val req = blerpc.Blerpc.FileCloseRequest.newBuilder()
+            .setHandle(handle)
+            .setVerify(verify)
+            .build()
+        val respData = exclusive { call(CommandId.FILE_CLOSE.wireName, req.toByteArray()) }
+        return decode("file_close", respData) { blerpc.Blerpc.FileCloseResponse.parseFrom(it) }
+    }
+
+    open suspend fun getRpcStats(reset: Boolean = false): blerpc.Blerpc.GetRpcStatsResponse {
+        val req = blerpc.Blerpc.GetRpcStatsRequest.newBuilder()
+            .setReset(reset)
+            .build()
+        val respData = exclusive { call(CommandId.GET_RPC_STATS.wireName, req.toByteArray()) }
+        return decode("get_rpc_stats", respData) { blerpc.Blerpc.GetRpcStatsResponse.parseFrom(it) }
+    }
+
+    open suspend fun startSession(): blerpc.Blerpc.StartSessionResponse {
+        val req = blerpc.Blerpc.StartSessionRequest.newBuilder()
+            .build()
+        val respData = exclusive { call(CommandId.START_SESSION.wireName, req.toByteArray()) }
+        return decode("start_session", respData) { blerpc.Blerpc.StartSessionResponse.parseFrom(it) }
+    }
+
+    open suspend fun authenticateSession(proof: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): blerpc.Blerpc.AuthenticateSessionResponse {
+        val req = blerpc.Blerpc.AuthenticateSessionRequest.newBuilder()
+            .setProof(proof)
+            .build()
+        val respData = exclusive { call(CommandId.AUTHENTICATE_SESSION.wireName, req.toByteArray()) }
+        return decode("authenticate_session", respData) { blerpc.Blerpc.AuthenticateSessionResponse.parseFrom(it) }
+    }
+
+    open suspend fun timeSync(unix_time_us: Long = 0L, offset_us: Int = 0): blerpc.Blerpc.TimeSyncResponse {
+        val req = blerpc.Blerpc.TimeSyncRequest.newBuilder()
+            .setUnixTimeUs(unix_time_us)
+            .setOffsetUs(offset_us)
+            .build()
+        val respData = exclusive { call(CommandId.TIME_SYNC.wireName, req.toByteArray()) }
+        return decode("time_sync", respData) { blerpc.Blerpc.TimeSyncResponse.parseFrom(it) }
+    }
+
+    open suspend fun ping(payload: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): blerpc.Blerpc.PingResponse {
+        val req = blerpc.Blerpc.PingRequest.newBuilder()
+            .setPayload(payload)
+            .build()
+        val respData = exclusive { call(CommandId.PING.wireName, req.toByteArray()) }
+        return decode("ping", respData) { blerpc.Blerpc.PingResponse.parseFrom(it) }
+    }
+
+    open suspend fun getCapabilities(): blerpc.Blerpc.GetCapabilitiesResponse {
+        val req = blerpc.Blerpc.GetCapabilitiesRequest.newBuilder()
+            .build()
+        val respData = exclusive { call(CommandId.GET_CAPABILITIES.wireName, req.toByteArray()) }
+        return decode("get_capabilities", respData) { blerpc.Blerpc.GetCapabilitiesResponse.parseFrom(it) }
+    }
+
+    open suspend fun getSetting(field: Int = 0): blerpc.Blerpc.GetSettingResponse {
+        val req = blerpc.Blerpc.GetSettingRequest.newBuilder()
+            .setField(field)
+            .build()
+        val respData = exclusive { call(CommandId.GET_SETTING.wireName, req.toByteArray()) }
+        return decode("get_setting", respData) { blerpc.Blerpc.GetSettingResponse.parseFrom(it) }
+    }
+
+    open suspend fun setSetting(field: Int = 0, value: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): blerpc.Blerpc.SetSettingResponse {
+        val req = blerpc.Blerpc.SetSettingRequest.newBuilder()
+            .setField(field)
+            .setValue(value)
+            .build()
+        val respData = exclusive { call(CommandId.SET_SETTING.wireName, req.toByteArray()) }
+        return decode("set_setting", respData) { blerpc.Blerpc.SetSettingResponse.parseFrom(it) }
+    }
+
+    open suspend fun counterStream(count: Int = 0): List<blerpc.Blerpc.CounterStreamResponse> {
+        val req = blerpc.Blerpc.CounterStreamRequest.newBuilder()
+            .setCount(count)
+            .build()
+        val responses = exclusive { streamReceive(CommandId.COUNTER_STREAM.wireName, req.toByteArray()) }
+        return responses.map { decode("counter_stream", it) { data -> blerpc.Blerpc.CounterStreamResponse.parseFrom(data) } }
+    }
+
+    open fun counterStreamFlow(count: Int = 0): Flow<blerpc.Blerpc.CounterStreamResponse> {
+        val req = blerpc.Blerpc.CounterStreamRequest.newBuilder()
+            .setCount(count)
+            .build()
+        return flow {
+            exclusive {
+                try {
+                    streamReceiveFlow(CommandId.COUNTER_STREAM.wireName, req.toByteArray()).collect {
+                        emit(decode("counter_stream", it) { data -> blerpc.Blerpc.CounterStreamResponse.parseFrom(data) })
+                    }
+                } catch (e: CancellationException) {
+                    // The collector stopped early: stop the peripheral too.
+                    withContext(NonCancellable) { streamCancel() }
+                    throw e
+                }
+            }
+        }
+    }
+
+    open suspend fun counterUpload(messages: List<blerpc.Blerpc.CounterUploadRequest>): blerpc.Blerpc.CounterUploadResponse {
+        val raw = messages.map { it.toByteArray() }
+        val respData = exclusive { streamSend(CommandId.COUNTER_UPLOAD.wireName, raw, CommandId.COUNTER_UPLOAD.wireName) }
+        return decode("counter_upload", respData) { blerpc.Blerpc.CounterUploadResponse.parseFrom(it) }
+    }
+
+    open suspend fun counterUpload(messages: Flow<blerpc.Blerpc.CounterUploadRequest>): blerpc.Blerpc.CounterUploadResponse {
+        val raw = messages.map { it.toByteArray() }
+        val respData = exclusive { streamSendFlow(CommandId.COUNTER_UPLOAD.wireName, raw, CommandId.COUNTER_UPLOAD.wireName) }
+        return decode("counter_upload", respData) { blerpc.Blerpc.CounterUploadResponse.parseFrom(it) }
+    }
+
+    open suspend fun logStream(min_level: Int = 0, max_entries: Int = 0): List<blerpc.Blerpc.LogStreamResponse> {
+        val req = blerpc.Blerpc.LogStreamRequest.newBuilder()
+            .setMinLevelValue(min_level)
+            .setMaxEntries(max_entries)
+            .build()
+        val responses = exclusive { streamReceive(CommandId.LOG_STREAM.wireName, req.toByteArray()) }
+        return responses.map { decode("log_stream", it) { data -> blerpc.Blerpc.LogStreamResponse.parseFrom(data) } }
+    }
+
+    open fun logStreamFlow(min_level: Int = 0, max_entries: Int = 0): Flow<blerpc.Blerpc.LogStreamResponse> {
+        val req = blerpc.Blerpc.LogStreamRequest.newBuilder()
+            .setMinLevelValue(min_level)
+            .setMaxEntries(max_entries)
+            .build()
+        return flow {
+            exclusive {
+                try {
+                    streamReceiveFlow(CommandId.LOG_STREAM.wireName, req.toByteArray()).collect {
+                        emit(decode("log_stream", it) { data -> blerpc.Blerpc.LogStreamResponse.parseFrom(data) })
+                    }
+                } catch (e: CancellationException) {
+                    // The collector stopped early: stop the peripheral too.
+                    withContext(NonCancellable) { streamCancel() }
+                    throw e
+                }
+            }
+        }
+    }
+
+    @Deprecated("Renamed to flashRead", ReplaceWith("flashRead(address = address, length = length)"))
+    suspend fun flashDump(address: Int = 0, length: Int = 0): blerpc.Blerpc.FlashReadResponse = flashRead(address = address, length = length)
+
+    /** Calls [echo] and returns the message of its response. */
+    suspend fun echoMessage(message: String = ""): String =
+        echo(message = message).message
+
+    /** Calls [dataWrite] and returns the length of its response. */
+    @Deprecated("data_write is deprecated in the schema")
+    @Suppress("DEPRECATION")
+    suspend fun dataWriteLength(data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): Int =
+        dataWrite(data = data).length
+
+    /**
+     * Checks that the peripheral was built from this client's schema. Call
+     * once on connect; throws [SchemaMismatchException] when the schema
+     * hashes differ.
+     */
+    suspend fun verifySchema(): blerpc.Blerpc.GetBlerpcInfoResponse {
+        val info = getBlerpcInfo()
+        if (info.schemaHash != SCHEMA_HASH) {
+            throw SchemaMismatchException(SCHEMA_HASH, info.schemaHash, info.generatorVersion)
+        }
+        return info
+    }
+
+    /**
+     * Returns the names of the commands the firmware implements; commands it
+     * lacks, or leaves on their stubs, are missing. Query it once on connect
+     * and check the set before calling commands some firmware versions lack.
+     */
+    suspend fun supportedCommands(): Set<String> {
+        val resp = getCapabilities()
+        val bitmap = resp.commandIds.toByteArray()
+        val ids = mapOf(
+            "echo" to 1,
+            "flash_read" to 2,
+            "data_write" to 3,
+            "counter_stream" to 4,
+            "counter_upload" to 5,
+            "get_blerpc_info" to 6,
+            "dfu_begin" to 8,
+            "dfu_chunk" to 9,
+            "dfu_finalize" to 10,
+            "file_open" to 11,
+            "file_read" to 12,
+            "file_write" to 13,
+            "file_close" to 14,
+            "log_stream" to 15,
+            "get_rpc_stats" to 16,
+            "start_session" to 17,
+            "authenticate_session" to 18,
+            "time_sync" to 19,
+            "ping" to 20,
+            "get_capabilities" to 21,
+            "get_setting" to 22,
+            "set_setting" to 23,
+        )
+        return ids.filterValues { it / 8 < bitmap.size && (bitmap[it / 8].toInt() shr (it % 8)) and 1 != 0 }.keys
+    }
+
+    /** Reports whether the firmware implements [command], e.g. "echo". */
+    suspend fun supports(command: String): Boolean = command in supportedCommands()
+
+    /**
+     * Writes the firmware image at [path] to the peripheral and applies it.
+     * An update interrupted before it was applied continues after the bytes
+     * the peripheral already has when called again with the same image.
+     * [progress] is called with the bytes sent and the total after each
+     * chunk. With [reboot], the peripheral restarts into the image once it is
+     * verified.
+     */
+    suspend fun updateFirmware(
+        path: String,
+        progress: ((Int, Int) -> Unit)? = null,
+        reboot: Boolean = true,
+    ) {
+        val image = java.io.File(path).readBytes()
+        val crc = java.util.zip.CRC32().apply { update(image) }.value.toInt()
+        val begun = dfuBegin(size = image.size, crc32 = crc)
+        var offset = begun.offset
+        val chunkSize = if (begun.maxChunk > 0) begun.maxChunk else 128
+        while (offset < image.size) {
+            val end = minOf(offset + chunkSize, image.size)
+            val resp = dfuChunk(offset = offset, data = ByteString.copyFrom(image, offset, end - offset))
+            if (resp.offset == offset) throw BlerpcException("$path: update stalled at byte $offset")
+            offset = resp.offset
+            progress?.invoke(offset, image.size)
+        }
+        val done = dfuFinalize(reboot = reboot)
+        if (done.crc32 != crc) throw BlerpcException("$path: update failed verification")
+    }
+
+    /**
+     * Writes [data] to [path] on the peripheral and verifies it by CRC-32. With
+     * [resume], an interrupted upload continues after the bytes already
+     * written, if they match the start of [data]. [progress] is called with
+     * the bytes sent and the total after each chunk.
+     */
+    suspend fun uploadFile(
+        path: String,
+        data: ByteArray,
+        resume: Boolean = false,
+        chunkSize: Int = 128,
+        progress: ((Int, Int) -> Unit)? = null,
+    ) {
+        var opened = fileOpen(path = path, write = true, resume = resume)
+        var offset = opened.size
+        if (offset != 0 && (offset > data.size || fileCrc32(data, offset) != opened.crc32)) {
+            // The partial file is not a prefix of data: start over.
+            fileClose(handle = opened.handle)
+            opened = fileOpen(path = path, write = true)
+            offset = 0
+        }
+        try {
+            while (offset < data.size) {
+                val end = minOf(offset + chunkSize, data.size)
+                fileWrite(
+                    handle = opened.handle,
+                    offset = offset,
+                    data = ByteString.copyFrom(data, offset, end - offset),
+                )
+                offset = end
+                progress?.invoke(offset, data.size)
+            }
+        } catch (e: Throwable) {
+            runCatching { fileClose(handle = opened.handle) }
+            throw e
+        }
+        val closed = fileClose(handle = opened.handle, verify = true)
+        if (closed.size != data.size || closed.crc32 != fileCrc32(data, data.size)) {
+            throw BlerpcException("$path: upload failed verification")
+        }
+    }
+
+    /**
+     * Reads [path] from the peripheral and verifies it by CRC-32. Pass the
+     * bytes received before an interruption as [partial] to continue after
+     * them. [progress] is called with the bytes received and the total after
+     * each chunk.
+     */
+    suspend fun downloadFile(
+        path: String,
+        partial: ByteArray = ByteArray(0),
+        chunkSize: Int = 128,
+        progress: ((Int, Int) -> Unit)? = null,
+    ): ByteArray {
+        val opened = fileOpen(path = path)
+        val data = java.io.ByteArrayOutputStream()
+        data.write(partial, 0, minOf(partial.size, opened.size))
+        try {
+            while (data.size() < opened.size) {
+                val resp = fileRead(handle = opened.handle, offset = data.size(), length = chunkSize)
+                if (resp.data.isEmpty) break
+                resp.data.writeTo(data)
+                progress?.invoke(data.size(), opened.size)
+            }
+        } catch (e: Throwable) {
+            runCatching { fileClose(handle = opened.handle) }
+            throw e
+        }
+        fileClose(handle = opened.handle)
+        val bytes = data.toByteArray()
+        if (bytes.size != opened.size || fileCrc32(bytes, bytes.size) != opened.crc32) {
+            throw BlerpcException("$path: download failed verification")
+        }
+        return bytes
+    }
+
+    private fun fileCrc32(data: ByteArray, length: Int): Int =
+        java.util.zip.CRC32().apply { update(data, 0, length) }.value.toInt()
+
+    /**
+     * Drains the firmware log into (timestamp, entry) pairs, oldest first. The
+     * message of each entry joins the fragments of a long message, and the
+     * timestamp is when it was logged.
+     */
+    suspend fun readLogs(minLevel: Int = 0, maxEntries: Int = 0): List<Pair<java.time.Instant, blerpc.Blerpc.LogStreamResponse>> {
+        val responses = logStream(minLevel, maxEntries)
+        val received = System.currentTimeMillis()
+        val logs = mutableListOf<Pair<java.time.Instant, blerpc.Blerpc.LogStreamResponse>>()
+        val message = StringBuilder()
+        var first: blerpc.Blerpc.LogStreamResponse? = null
+        for (resp in responses) {
+            if (first == null) first = resp
+            message.append(resp.message)
+            if (resp.more) continue
+            val ageMs = (first.nowMs - first.uptimeMs).toLong() and 0xFFFFFFFFL
+            val entry = first.toBuilder().setMessage(message.toString()).build()
+            logs.add(java.time.Instant.ofEpochMilli(received - ageMs) to entry)
+            message.clear()
+            first = null
+        }
+        return logs
+    }
+
+    /** Pings the peripheral and returns the round trip in milliseconds. */
+    suspend fun measureLatency(): Long {
+        val start = System.nanoTime()
+        ping()
+        return (System.nanoTime() - start) / 1_000_000
+    }
+
+    /**
+     * Pings the peripheral every [intervalMs] until cancelled, passing each
+     * round trip to [onLatency]. Launch it next to the calls: it throws as
+     * soon as a ping fails, so a dead connection shows before a long call
+     * times out.
+     */
+    suspend fun keepalive(intervalMs: Long = 5000, onLatency: (Long) -> Unit = {}) {
+        while (true) {
+            onLatency(measureLatency())
+            delay(intervalMs)
+        }
+    }
+
+    /** Returns the firmware's per-command counters keyed by command name. */
+    suspend fun rpcStatsByCommand(reset: Boolean = false): Map<String, blerpc.Blerpc.RpcStat> =
+        getRpcStats(reset = reset).statsList.associateBy { it.name }
+
+    /**
+     * Key shared with the peripheral, which the session handshake proves
+     * knowledge of. Set it before calling a session- or replay-protected
+     * command.
+     */
+    var sessionKey: ByteArray? = null
+
+    private var sessionToken: ByteArray? = null
+
+    /**
+     * Authenticates to the peripheral, unlocking its session-protected
+     * commands. Protected calls open a session as needed; call this to
+     * authenticate up front.
+     */
+    suspend fun openSession() {
+        exclusive { handshake() }
+    }
+
+    /**
+     * Runs the session handshake inside the caller's [exclusive] block and
+     * keeps the token: the proof is an HMAC-SHA256 of the challenge under
+     * [sessionKey].
+     */
+    private suspend fun handshake(): ByteArray {
+        val key = sessionKey ?: throw BlerpcException("sessionKey is not set")
+        val startData = call(CommandId.START_SESSION.wireName, blerpc.Blerpc.StartSessionRequest.getDefaultInstance().toByteArray())
+        val challenge = decode("start_session", startData) { blerpc.Blerpc.StartSessionResponse.parseFrom(it) }.challenge
+        val mac = Mac.getInstance("HmacSHA256").apply { init(SecretKeySpec(key, "HmacSHA256")) }
+        val req = blerpc.Blerpc.AuthenticateSessionRequest.newBuilder()
+            .setProof(ByteString.copyFrom(mac.doFinal(challenge.toByteArray())))
+            .build()
+        val authData = call(CommandId.AUTHENTICATE_SESSION.wireName, req.toByteArray())
+        val token = decode("authenticate_session", authData) { blerpc.Blerpc.AuthenticateSessionResponse.parseFrom(it) }.token.toByteArray()
+        sessionToken = token
+        return token
+    }
+
+    /**
+     * Sends a session-protected request led by the session token, opening a
+     * session first when there is none. A peripheral that lost the session,
+     * e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent
+     * once more in a new session.
+     */
+    protected suspend fun sessionCall(cmdName: String, requestData: ByteArray): ByteArray {
+        val respData = call(cmdName, (sessionToken ?: handshake()) + requestData)
+        if (statusException(cmdName, respData) !is UnauthenticatedException) return respData
+        return call(cmdName, handshake() + requestData)
+    }
+
+    /** Reads the sample_interval_ms setting. */
+    suspend fun getSampleIntervalMsSetting(): Int {
+        val resp = getSetting(field = 1)
+        return decode("get_setting", resp.value.toByteArray()) { blerpc.Blerpc.DeviceSettings.parseFrom(it) }.sampleIntervalMs
+    }
+
+    /** Reads the leds_enabled setting. */
+    suspend fun getLedsEnabledSetting(): Boolean {
+        val resp = getSetting(field = 2)
+        return decode("get_setting", resp.value.toByteArray()) { blerpc.Blerpc.DeviceSettings.parseFrom(it) }.ledsEnabled
+    }
+
+    /** Writes the sample_interval_ms setting. */
+    suspend fun setSampleIntervalMsSetting(value: Int) {
+        val settings = blerpc.Blerpc.DeviceSettings.newBuilder().setSampleIntervalMs(value).build()
+        setSetting(field = 1, value = settings.toByteString())
+    }
+
+    /** Writes the leds_enabled setting. */
+    suspend fun setLedsEnabledSetting(value: Boolean) {
+        val settings = blerpc.Blerpc.DeviceSettings.newBuilder().setLedsEnabled(value).build()
+        setSetting(field = 2, value = settings.toByteString())
+    }
+
+    /**
+     * Sets the peripheral's wall clock to this device's. With [compensate], a
+     * first exchange measures the round trip and the second adds half of it,
+     * the estimated delivery delay.
+     */
+    suspend fun syncTime(compensate: Boolean = false): blerpc.Blerpc.TimeSyncResponse {
+        var offsetUs = 0
+        if (compensate) {
+            val start = System.nanoTime()
+            timeSync(unix_time_us = System.currentTimeMillis() * 1000)
+            offsetUs = ((System.nanoTime() - start) / 2000).toInt()
+        }
+        return timeSync(unix_time_us = System.currentTimeMillis() * 1000, offset_us = offsetUs)
+    }
+}
+
+@Deprecated("Field 2 was removed from the schema; reads the default")
+val blerpc.Blerpc.EchoResponse.uptimeMs: Int
+    get() = 0
+
+@Deprecated("Field 3 was removed from the schema; reads the default")
+val blerpc.Blerpc.EchoResponse.tags: List<String>
+    get() = emptyList()
+
+/** Role each command requires on the peripheral, keyed by wire name; others need "user". */
+val COMMAND_ROLES: Map<String, String> =
+    mapOf(
+        "flash_read" to "factory",
+    )
@@ -0,0 +1,238 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import java.io.ByteArrayOutputStream
+import java.util.zip.CRC32
+import kotlinx.coroutines.channels.Channel
+import kotlinx.coroutines.flow.Flow
+import kotlinx.coroutines.flow.flow
+import kotlinx.coroutines.sync.Semaphore
+import kotlinx.coroutines.sync.withPermit
+
+/** A frame is malformed or out of sequence, or a message too large. */
+open class FramingException(message: String) : TransportException(message)
+
+/** A message failed its CRC-32 check: it was corrupted on the way. */
+class IntegrityException(message: String) : FramingException(message)
+
+/**
+ * Frames carry a message larger than the ATT MTU:
+ *
+ *     flags | id | seq | [length, uint16 LE, FIRST only] | data
+ *
+ * id is the correlation ID the client picked for a request, which its
+ * responses carry back, so up to [MAX_IN_FLIGHT] requests can be in flight
+ * and their frames interleaved. seq counts the frames of a message from 0. A
+ * frame with FIRST starts a new message under its ID, dropping one partly
+ * reassembled. MORE marks a message followed by another under the same ID,
+ * as the responses of a stream but the last.
+ */
+object Framing {
+    const val FRAME_FIRST = 0x01
+    const val FRAME_LAST = 0x02
+    const val FRAME_MORE = 0x04
+    const val FRAME_HEADER_SIZE = 3
+    const val FRAME_FIRST_HEADER_SIZE = 5
+
+    /** Messages the peripheral reassembles at once; no more calls are in flight. */
+    const val MAX_IN_FLIGHT = 4
+
+    /**
+     * Each message travels with its CRC-32 (IEEE 802.3, little endian) after
+     * its data; length leaves it out. The reassembler checks and strips it.
+     */
+    const val CRC_SIZE = 4
+
+    /** ATT opcode and handle ahead of each notification or write. */
+    const val ATT_OVERHEAD = 3
+
+    /** Largest message a frame header can announce. */
+    const val MAX_MESSAGE_SIZE = 0xFFFF
+
+    /** Splits [message] into frames fitting the ATT MTU [mtu]. */
+    fun fragment(message: ByteArray, mtu: Int, correlationId: Int): List<ByteArray> {
+        if (message.isEmpty() || message.size > MAX_MESSAGE_SIZE) {
+            throw FramingException("cannot frame a message of ${message.size} bytes")
+        }
+        val frameSize = mtu - ATT_OVERHEAD
+        if (frameSize <= FRAME_FIRST_HEADER_SIZE) {
+            throw FramingException("MTU $mtu leaves no room for data")
+        }
+        val crc = crc32(message, message.size)
+        val payload = message + ByteArray(CRC_SIZE) { (crc shr (8 * it)).toByte() }
+        val frames = mutableListOf<ByteArray>()
+        var offset = 0
+        while (offset < payload.size) {
+            val first = offset == 0
+            val headerSize = if (first) FRAME_FIRST_HEADER_SIZE else FRAME_HEADER_SIZE
+            val n = minOf(payload.size - offset, frameSize - headerSize)
+            var flags = if (first) FRAME_FIRST else 0
+            if (offset + n == payload.size) flags = flags or FRAME_LAST
+            val frame = ByteArray(headerSize + n)
+            frame[0] = flags.toByte()
+            frame[1] = correlationId.toByte()
+            frame[2] = frames.size.toByte()
+            if (first) {
+                frame[3] = (message.size and 0xFF).toByte()
+                frame[4] = (message.size shr 8).toByte()
+            }
+            payload.copyInto(frame, headerSize, offset, offset + n)
+            frames.add(frame)
+            offset += n
+        }
+        return frames
+    }
+
+    /** Returns the message ahead of the CRC-32 ending [payload], once checked. */
+    fun stripCrc(payload: ByteArray): ByteArray {
+        val n = payload.size - CRC_SIZE
+        var crc = 0
+        for (i in 0 until CRC_SIZE) crc = crc or ((payload[n + i].toInt() and 0xFF) shl (8 * i))
+        if (crc32(payload, n) != crc) throw IntegrityException("message failed its CRC-32 check")
+        return payload.copyOf(n)
+    }
+
+    private fun crc32(data: ByteArray, length: Int): Int =
+        CRC32().apply { update(data, 0, length) }.value.toInt()
+}
+
+/** A reassembled message and the correlation ID it arrived under. */
+class FrameMessage(
+    val correlationId: Int,
+    val data: ByteArray,
+    /** Another message follows under the same ID. */
+    val more: Boolean,
+)
+
+/**
+ * Puts frames back together into messages, one per correlation ID. [feed]
+ * returns the message once its last frame arrived. After a
+ * [FramingException] the ID of the frame waits for the next FIRST frame.
+ */
+class FrameReassembler(private val maxSize: Int = Framing.MAX_MESSAGE_SIZE) {
+    private class Partial(val total: Int) {
+        val buf = ByteArrayOutputStream(total)
+        var nextSeq = 0
+    }
+
+    private val partial = HashMap<Int, Partial>()
+
+    /** Drops every partly reassembled message. */
+    fun reset() {
+        partial.clear()
+    }
+
+    /** Adds a received frame; returns the message once complete, else null. */
+    fun feed(frame: ByteArray): FrameMessage? {
+        if (frame.size < Framing.FRAME_HEADER_SIZE) throw FramingException("short frame")
+        return try {
+            feedFrame(frame)
+        } catch (e: FramingException) {
+            partial.remove(frame[1].toInt() and 0xFF)
+            throw e
+        }
+    }
+
+    private fun feedFrame(frame: ByteArray): FrameMessage? {
+        val flags = frame[0].toInt() and 0xFF
+        val id = frame[1].toInt() and 0xFF
+        val seq = frame[2].toInt() and 0xFF
+        val p: Partial
+        val headerSize: Int
+        if (flags and Framing.FRAME_FIRST != 0) {
+            if (frame.size < Framing.FRAME_FIRST_HEADER_SIZE || seq != 0) {
+                throw FramingException("malformed first frame")
+            }
+            val length = (frame[3].toInt() and 0xFF) or ((frame[4].toInt() and 0xFF) shl 8)
+            if (length == 0) throw FramingException("empty message")
+            if (length > maxSize) throw FramingException("message of $length bytes exceeds $maxSize")
+            p = Partial(length + Framing.CRC_SIZE)
+            partial[id] = p
+            headerSize = Framing.FRAME_FIRST_HEADER_SIZE
+        } else {
+            p = partial[id]?.takeIf { it.nextSeq == seq }
+                ?: throw FramingException("frame $seq of call $id out of sequence")
+            headerSize = Framing.FRAME_HEADER_SIZE
+        }
+        val n = frame.size - headerSize
+        if (p.buf.size() + n > p.total) throw FramingException("frames exceed the message length")
+        p.buf.write(frame, headerSize, n)
+        p.nextSeq = (p.nextSeq + 1) and 0xFF
+        if (flags and Framing.FRAME_LAST == 0) return null
+        if (p.buf.size() != p.total) throw FramingException("message ends short of its length")
+        partial.remove(id)
+        return FrameMessage(id, Framing.stripCrc(p.buf.toByteArray()), flags and Framing.FRAME_MORE != 0)
+    }
+}
+
+/**
+ * Runs calls concurrently over one connection. Each call gets a free
+ * correlation ID and its responses are routed back by it, so a slow call or
+ * stream does not hold up the others. [write] sends one frame to the
+ * peripheral; pass every notified frame to [onFrame] and call [failAll] when
+ * the connection drops.
+ */
+class FramePipeline(
+    private val write: suspend (ByteArray) -> Unit,
+    @Volatile var mtu: Int,
+    maxInFlight: Int = Framing.MAX_IN_FLIGHT,
+) {
+    private val lock = Any()
+    private val slots = Semaphore(maxInFlight)
+    private val calls = HashMap<Int, Channel<Result<FrameMessage>>>()
+    private val reassembler = FrameReassembler()
+    private var nextId = 0
+
+    private fun allocate(): Int {
+        while (nextId in calls) nextId = (nextId + 1) and 0xFF
+        val id = nextId
+        nextId = (id + 1) and 0xFF
+        return id
+    }
+
+    /** Sends a request and returns its response. */
+    suspend fun call(message: ByteArray): ByteArray {
+        var response = ByteArray(0)
+        stream(message).collect { response = it }
+        return response
+    }
+
+    /** Sends a request and emits its responses until the last. */
+    fun stream(message: ByteArray): Flow<ByteArray> = flow {
+        slots.withPermit {
+            val channel = Channel<Result<FrameMessage>>(Channel.UNLIMITED)
+            val id = synchronized(lock) { allocate().also { calls[it] = channel } }
+            try {
+                for (frame in Framing.fragment(message, mtu, id)) write(frame)
+                while (true) {
+                    val msg = channel.receive().getOrThrow()
+                    emit(msg.data)
+                    if (!msg.more) break
+                }
+            } finally {
+                synchronized(lock) { calls.remove(id) }
+            }
+        }
+    }
+
+    /** Routes a notified frame to the call it belongs to. */
+    fun onFrame(frame: ByteArray) {
+        synchronized(lock) {
+            val msg = try {
+                reassembler.feed(frame)
+            } catch (e: FramingException) {
+                if (frame.size > 1) calls[frame[1].toInt() and 0xFF]?.trySend(Result.failure(e))
+                return
+            }
+            if (msg != null) calls[msg.correlationId]?.trySend(Result.success(msg))
+        }
+    }
+
+    /** Fails every call in flight, e.g. with a [TransportException] on disconnect. */
+    fun failAll(e: Throwable) {
+        synchronized(lock) {
+            reassembler.reset()
+            for (channel in calls.values) channel.trySend(Result.failure(e))
+        }
+    }
+}
@@ -0,0 +1,40 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.blerpc.android.ble.ScannedDevice
+import com.google.protobuf.MessageLite
+
+/** A blerpc peripheral found by a scan; pass [device] to connect. */
+data class DiscoveredDevice(
+    val device: ScannedDevice,
+    /** The decoded (blerpc.advertising) message, if the device sent one. */
+    val advertisement: MessageLite?,
+) {
+    val name: String? get() = device.name
+    val address: String get() = device.address
+    val rssi: Int get() = device.rssi
+}
+
+/** Picks the blerpc peripherals out of scan results. */
+object GeneratedScanner {
+    /** Service UUID advertised by blerpc peripherals. */
+    const val SERVICE_UUID = "6e400001-b5a3-f393-e0a9-e50e24dcca9e"
+
+    /** Returns [device] as a [DiscoveredDevice], or null if it is not a blerpc peripheral. */
+    fun discover(
+        device: ScannedDevice,
+        requireAdvertisement: Boolean = false,
+    ): DiscoveredDevice? {
+        if (device.serviceUuids.none { it.equals(SERVICE_UUID, ignoreCase = true) }) return null
+        val advertisement = device.manufacturerData[GeneratedAdvertising.COMPANY_ID]?.let { GeneratedAdvertising.parse(it) }
+        if (requireAdvertisement && advertisement == null) return null
+        return DiscoveredDevice(device, advertisement)
+    }
+
+    /** Returns the blerpc peripherals among [devices], strongest first. */
+    fun discoverAll(
+        devices: List<ScannedDevice>,
+        requireAdvertisement: Boolean = false,
+    ): List<DiscoveredDevice> =
+        devices.mapNotNull { discover(it, requireAdvertisement) }.sortedByDescending { it.rssi }
+}
@@ -0,0 +1,13 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import java.util.UUID
+
+/** GATT UUIDs of the blerpc service, from blerpc.yaml. */
+object GeneratedUuids {
+    /** Service advertised by blerpc peripherals. */
+    val SERVICE_UUID: UUID = UUID.fromString("6e400001-b5a3-f393-e0a9-e50e24dcca9e")
+
+    /** The multiplexed RPC characteristic. */
+    val CHAR_UUID: UUID = UUID.fromString("6e400002-b5a3-f393-e0a9-e50e24dcca9e")
+}
@@ -0,0 +1,190 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+/** The schema name of the command the generated methods send as [cmdName]. */
+private fun interceptedCommand(cmdName: String): String =
+    when (cmdName) {
+        CommandId.ECHO.wireName -> "echo"
+        CommandId.FLASH_READ.wireName -> "flash_read"
+        CommandId.DATA_WRITE.wireName -> "data_write"
+        CommandId.COUNTER_STREAM.wireName -> "counter_stream"
+        CommandId.COUNTER_UPLOAD.wireName -> "counter_upload"
+        CommandId.GET_BLERPC_INFO.wireName -> "get_blerpc_info"
+        CommandId.DFU_BEGIN.wireName -> "dfu_begin"
+        CommandId.DFU_CHUNK.wireName -> "dfu_chunk"
+        CommandId.DFU_FINALIZE.wireName -> "dfu_finalize"
+        CommandId.FILE_OPEN.wireName -> "file_open"
+        CommandId.FILE_READ.wireName -> "file_read"
+        CommandId.FILE_WRITE.wireName -> "file_write"
+        CommandId.FILE_CLOSE.wireName -> "file_close"
+        CommandId.LOG_STREAM.wireName -> "log_stream"
+        CommandId.GET_RPC_STATS.wireName -> "get_rpc_stats"
+        CommandId.START_SESSION.wireName -> "start_session"
+        CommandId.AUTHENTICATE_SESSION.wireName -> "authenticate_session"
+        CommandId.TIME_SYNC.wireName -> "time_sync"
+        CommandId.PING.wireName -> "ping"
+        CommandId.GET_CAPABILITIES.wireName -> "get_capabilities"
+        CommandId.GET_SETTING.wireName -> "get_setting"
+        CommandId.SET_SETTING.wireName -> "set_setting"
+        else -> cmdName
+    }
+
+/**
+ * Observes the calls of an [InstrumentedClient]. Override what you need.
+ *
+ * `command` is the command's name as in the schema. Sizes are payload bytes,
+ * summed over the messages of a stream. An exception an interceptor throws is
+ * dropped and does not fail the call.
+ */
+interface CallInterceptor {
+    /** Called before the request is sent. */
+    fun onRequest(
+        command: String,
+        requestSize: Int,
+    ) {}
+
+    /** Called when the peripheral answered the call. */
+    fun onResponse(
+        command: String,
+        requestSize: Int,
+        responseSize: Int,
+        durationNanos: Long,
+    ) {}
+
+    /** Called when the call failed, with the exception it threw. */
+    fun onError(
+        command: String,
+        requestSize: Int,
+        error: Throwable,
+        durationNanos: Long,
+    ) {}
+}
+
+/** Counters of one command, as [MetricsCollector] keeps them. */
+data class CommandMetrics(
+    val calls: Int = 0,
+    val errors: Int = 0,
+    val requestBytes: Long = 0,
+    val responseBytes: Long = 0,
+    val totalDurationNanos: Long = 0,
+    val maxDurationNanos: Long = 0,
+) {
+    val meanDurationNanos: Long get() = if (calls > 0) totalDurationNanos / calls else 0
+}
+
+/**
+ * Counts calls, errors, bytes and durations per command. Read the counters
+ * with [snapshot], e.g. periodically for a dashboard.
+ */
+class MetricsCollector : CallInterceptor {
+    private val metrics = mutableMapOf<String, CommandMetrics>()
+
+    override fun onResponse(
+        command: String,
+        requestSize: Int,
+        responseSize: Int,
+        durationNanos: Long,
+    ) = record(command, requestSize, responseSize, durationNanos, failed = false)
+
+    override fun onError(
+        command: String,
+        requestSize: Int,
+        error: Throwable,
+        durationNanos: Long,
+    ) = record(command, requestSize, 0, durationNanos, failed = true)
+
+    /** Returns a copy of the counters by command. */
+    @Synchronized
+    fun snapshot(): Map<String, CommandMetrics> = metrics.toMap()
+
+    /** Clears the counters. */
+    @Synchronized
+    fun reset() = metrics.clear()
+
+    @Synchronized
+    private fun record(
+        command: String,
+        requestSize: Int,
+        responseSize: Int,
+        durationNanos: Long,
+        failed: Boolean,
+    ) {
+        val m = metrics[command] ?: CommandMetrics()
+        metrics[command] =
+            m.copy(
+                calls = m.calls + 1,
+                errors = if (failed) m.errors + 1 else m.errors,
+                requestBytes = m.requestBytes + requestSize,
+                responseBytes = m.responseBytes + responseSize,
+                totalDurationNanos = m.totalDurationNanos + durationNanos,
+                maxDurationNanos = maxOf(m.maxDurationNanos, durationNanos),
+            )
+    }
+}
+
+/**
+ * Reports every call made through it to [interceptors].
+ *
+ * Interceptors see the exchanges with the peripheral: a response with an
+ * error status, or one that fails to decode, counts as a response, as the
+ * generated method throws after the exchange. Calls made on [client]
+ * directly are not reported.
+ */
+class InstrumentedClient(
+    private val client: GeneratedClient,
+    private val interceptors: List<CallInterceptor>,
+) : GeneratedClient() {
+    override suspend fun call(
+        cmdName: String,
+        requestData: ByteArray,
+    ): ByteArray = observe(cmdName, requestData.size, { it.size }) { client.call(cmdName, requestData) }
+
+    override suspend fun streamReceive(
+        cmdName: String,
+        requestData: ByteArray,
+    ): List<ByteArray> =
+        observe(cmdName, requestData.size, { r -> r.sumOf { it.size } }) {
+            client.streamReceive(cmdName, requestData)
+        }
+
+    override suspend fun streamSend(
+        cmdName: String,
+        messages: List<ByteArray>,
+        finalCmdName: String,
+    ): ByteArray =
+        observe(cmdName, messages.sumOf { it.size }, { it.size }) {
+            client.streamSend(cmdName, messages, finalCmdName)
+        }
+
+    override val capabilityFlags: Int get() = client.capabilityFlags
+
+    private suspend fun <T> observe(
+        cmdName: String,
+        requestSize: Int,
+        responseSize: (T) -> Int,
+        block: suspend () -> T,
+    ): T {
+        val command = interceptedCommand(cmdName)
+        notify { it.onRequest(command, requestSize) }
+        val start = System.nanoTime()
+        val result =
+            try {
+                block()
+            } catch (e: Exception) {
+                notify { it.onError(command, requestSize, e, System.nanoTime() - start) }
+                throw e
+            }
+        notify { it.onResponse(command, requestSize, responseSize(result), System.nanoTime() - start) }
+        return result
+    }
+
+    private inline fun notify(event: (CallInterceptor) -> Unit) {
+        for (interceptor in interceptors) {
+            try {
+                event(interceptor)
+            } catch (_: Exception) {
+                // An interceptor must not fail the call.
+            }
+        }
+    }
+}
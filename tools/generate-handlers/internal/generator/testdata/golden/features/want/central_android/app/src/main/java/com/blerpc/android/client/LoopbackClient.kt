@@ -0,0 +1,473 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.blerpc.protocol.CommandPacket
+import com.blerpc.protocol.CommandType
+import com.blerpc.protocol.Container
+import com.blerpc.protocol.ContainerAssembler
+import com.blerpc.protocol.ContainerSplitter
+import com.blerpc.protocol.ContainerType
+import com.blerpc.protocol.makeStreamEndC2P
+import com.google.protobuf.MessageLite
+
+/** A vector of conformance/vectors.json. Bytes are lowercase hex. */
+data class ConformanceVector(
+    val command: String,
+    val wireName: String,
+    val stream: String,
+    val mtu: Int,
+    val request: String,
+    val response: String,
+    val requestContainers: List<String>,
+    val responseContainers: List<String>,
+)
+
+/**
+ * Client whose peripheral is a conformance vector. Frames calls with the
+ * protocol library as BlerpcClient does, records the containers it would
+ * write in [sent] and answers from the response containers of [vector].
+ */
+class LoopbackClient(private val vector: ConformanceVector) : GeneratedClient() {
+    private val splitter = ContainerSplitter(mtu = vector.mtu)
+    private val recorded = mutableListOf<ByteArray>()
+
+    /** The containers written so far, in order. */
+    val sent: List<ByteArray>
+        get() = recorded.toList()
+
+    override suspend fun call(cmdName: String, requestData: ByteArray): ByteArray {
+        send(cmdName, requestData)
+        return receive().first()
+    }
+
+    override suspend fun streamReceive(cmdName: String, requestData: ByteArray): List<ByteArray> {
+        send(cmdName, requestData)
+        return receive()
+    }
+
+    override suspend fun streamSend(cmdName: String, messages: List<ByteArray>, finalCmdName: String): ByteArray {
+        messages.forEach { send(cmdName, it) }
+        recorded.add(makeStreamEndC2P(transactionId = splitter.nextTransactionId()).serialize())
+        return receive().first()
+    }
+
+    private fun send(cmdName: String, data: ByteArray) {
+        val packet = CommandPacket(cmdType = CommandType.REQUEST, cmdName = cmdName, data = data)
+        splitter.split(packet.serialize()).forEach { recorded.add(it.serialize()) }
+    }
+
+    /** Returns the data of every response in the answers of the vector. */
+    private fun receive(): List<ByteArray> {
+        val assembler = ContainerAssembler()
+        return vector.responseContainers.mapNotNull { hex ->
+            val container = Container.deserialize(hexToBytes(hex))
+            if (container.containerType == ContainerType.CONTROL) {
+                null
+            } else {
+                assembler.feed(container)?.let { CommandPacket.deserialize(it).data }
+            }
+        }
+    }
+}
+
+/**
+ * Runs every vector through a [LoopbackClient] and returns the mismatches.
+ * The sample request is decoded and encoded again with the generated
+ * messages, sent, and every response decoded and encoded again.
+ */
+suspend fun checkConformance(vectors: List<ConformanceVector> = conformanceVectors): List<String> {
+    val mismatches = mutableListOf<String>()
+    for (v in vectors) {
+        val name = "${v.command} at MTU ${v.mtu}"
+        val request = conformanceRequest(v.command, hexToBytes(v.request)).toByteArray()
+        if (bytesToHex(request) != v.request) {
+            mismatches.add("$name: request encodes as ${bytesToHex(request)}")
+        }
+        val client = LoopbackClient(v)
+        val responses = when (v.stream) {
+            "p2c" -> client.streamReceive(v.wireName, request)
+            "c2p" -> listOf(client.streamSend(v.wireName, listOf(request), v.wireName))
+            else -> listOf(client.call(v.wireName, request))
+        }
+        val sent = client.sent.map { bytesToHex(it) }
+        if (sent != v.requestContainers) {
+            mismatches.add("$name: sent $sent")
+        }
+        for (data in responses) {
+            val response = bytesToHex(conformanceResponse(v.command, data).toByteArray())
+            if (response != v.response) {
+                mismatches.add("$name: response encodes as $response")
+            }
+        }
+    }
+    return mismatches
+}
+
+private fun hexToBytes(hex: String): ByteArray =
+    ByteArray(hex.length / 2) { hex.substring(it * 2, it * 2 + 2).toInt(16).toByte() }
+
+private fun bytesToHex(data: ByteArray): String = data.joinToString("") { "%02x".format(it) }
+
+/** The vectors of conformance/vectors.json. */
+val conformanceVectors = listOf(
+    ConformanceVector(
+        "echo", "\u0001", "unary", 23,
+        request = "0a076d657373616765",
+        response = "0a076d657373616765",
+        requestContainers = listOf("0000000e000e00010109000a076d657373616765"),
+        responseContainers = listOf("0000000e000e80010109000a076d657373616765"),
+    ),
+    ConformanceVector(
+        "echo", "\u0001", "unary", 247,
+        request = "0a076d657373616765",
+        response = "0a076d657373616765",
+        requestContainers = listOf("0000000e000e00010109000a076d657373616765"),
+        responseContainers = listOf("0000000e000e80010109000a076d657373616765"),
+    ),
+    ConformanceVector(
+        "data_write", "\u0003", "unary", 23,
+        request = "0a0401020304",
+        response = "0801",
+        requestContainers = listOf("0000000b000b00010306000a0401020304"),
+        responseContainers = listOf("00000007000780010302000801"),
+    ),
+    ConformanceVector(
+        "data_write", "\u0003", "unary", 247,
+        request = "0a0401020304",
+        response = "0801",
+        requestContainers = listOf("0000000b000b00010306000a0401020304"),
+        responseContainers = listOf("00000007000780010302000801"),
+    ),
+    ConformanceVector(
+        "counter_stream", "\u0004", "p2c", 23,
+        request = "0801",
+        response = "080110ffffffffffffffffff01",
+        requestContainers = listOf("00000007000700010402000801"),
+        responseContainers = listOf("00000012000e8001040d00080110ffffffffffff", "00014004ffffff01", "0100cc00"),
+    ),
+    ConformanceVector(
+        "counter_stream", "\u0004", "p2c", 247,
+        request = "0801",
+        response = "080110ffffffffffffffffff01",
+        requestContainers = listOf("00000007000700010402000801"),
+        responseContainers = listOf("0000001200128001040d00080110ffffffffffffffffff01", "0100cc00"),
+    ),
+    ConformanceVector(
+        "counter_upload", "\u0005", "c2p", 23,
+        request = "080110ffffffffffffffffff01",
+        response = "0801",
+        requestContainers = listOf("00000012000e0001050d00080110ffffffffffff", "00014004ffffff01", "0100c800"),
+        responseContainers = listOf("00000007000780010502000801"),
+    ),
+    ConformanceVector(
+        "counter_upload", "\u0005", "c2p", 247,
+        request = "080110ffffffffffffffffff01",
+        response = "0801",
+        requestContainers = listOf("0000001200120001050d00080110ffffffffffffffffff01", "0100c800"),
+        responseContainers = listOf("00000007000780010502000801"),
+    ),
+    ConformanceVector(
+        "get_blerpc_info", "\u0006", "unary", 23,
+        request = "",
+        response = "0a0b736368656d615f68617368121167656e657261746f725f76657273696f6e",
+        requestContainers = listOf("0000000500050001060000"),
+        responseContainers = listOf("00000025000e80010620000a0b736368656d615f", "0001401068617368121167656e657261746f725f", "0002400776657273696f6e"),
+    ),
+    ConformanceVector(
+        "get_blerpc_info", "\u0006", "unary", 247,
+        request = "",
+        response = "0a0b736368656d615f68617368121167656e657261746f725f76657273696f6e",
+        requestContainers = listOf("0000000500050001060000"),
+        responseContainers = listOf("00000025002580010620000a0b736368656d615f68617368121167656e657261746f725f76657273696f6e"),
+    ),
+    ConformanceVector(
+        "conn_params", "\u0007", "unary", 23,
+        request = "0801",
+        response = "0801100118012001",
+        requestContainers = listOf("00000007000700010702000801"),
+        responseContainers = listOf("0000000d000d80010708000801100118012001"),
+    ),
+    ConformanceVector(
+        "conn_params", "\u0007", "unary", 247,
+        request = "0801",
+        response = "0801100118012001",
+        requestContainers = listOf("00000007000700010702000801"),
+        responseContainers = listOf("0000000d000d80010708000801100118012001"),
+    ),
+    ConformanceVector(
+        "dfu_begin", "\u0008", "unary", 23,
+        request = "08011001",
+        response = "08011001",
+        requestContainers = listOf("000000090009000108040008011001"),
+        responseContainers = listOf("000000090009800108040008011001"),
+    ),
+    ConformanceVector(
+        "dfu_begin", "\u0008", "unary", 247,
+        request = "08011001",
+        response = "08011001",
+        requestContainers = listOf("000000090009000108040008011001"),
+        responseContainers = listOf("000000090009800108040008011001"),
+    ),
+    ConformanceVector(
+        "dfu_chunk", "\u0009", "unary", 23,
+        request = "0801120401020304",
+        response = "0801",
+        requestContainers = listOf("0000000d000d00010908000801120401020304"),
+        responseContainers = listOf("00000007000780010902000801"),
+    ),
+    ConformanceVector(
+        "dfu_chunk", "\u0009", "unary", 247,
+        request = "0801120401020304",
+        response = "0801",
+        requestContainers = listOf("0000000d000d00010908000801120401020304"),
+        responseContainers = listOf("00000007000780010902000801"),
+    ),
+    ConformanceVector(
+        "dfu_finalize", "\u000a", "unary", 23,
+        request = "0801",
+        response = "0801",
+        requestContainers = listOf("00000007000700010a02000801"),
+        responseContainers = listOf("00000007000780010a02000801"),
+    ),
+    ConformanceVector(
+        "dfu_finalize", "\u000a", "unary", 247,
+        request = "0801",
+        response = "0801",
+        requestContainers = listOf("00000007000700010a02000801"),
+        responseContainers = listOf("00000007000780010a02000801"),
+    ),
+    ConformanceVector(
+        "file_open", "\u000b", "unary", 23,
+        request = "0a047061746810011801",
+        response = "080110011801",
+        requestContainers = listOf("0000000f000e00010b0a000a0470617468100118", "0001400101"),
+        responseContainers = listOf("0000000b000b80010b0600080110011801"),
+    ),
+    ConformanceVector(
+        "file_open", "\u000b", "unary", 247,
+        request = "0a047061746810011801",
+        response = "080110011801",
+        requestContainers = listOf("0000000f000f00010b0a000a047061746810011801"),
+        responseContainers = listOf("0000000b000b80010b0600080110011801"),
+    ),
+    ConformanceVector(
+        "file_read", "\u000c", "unary", 23,
+        request = "080110011801",
+        response = "0a0401020304",
+        requestContainers = listOf("0000000b000b00010c0600080110011801"),
+        responseContainers = listOf("0000000b000b80010c06000a0401020304"),
+    ),
+    ConformanceVector(
+        "file_read", "\u000c", "unary", 247,
+        request = "080110011801",
+        response = "0a0401020304",
+        requestContainers = listOf("0000000b000b00010c0600080110011801"),
+        responseContainers = listOf("0000000b000b80010c06000a0401020304"),
+    ),
+    ConformanceVector(
+        "file_write", "\u000d", "unary", 23,
+        request = "080110011a0401020304",
+        response = "",
+        requestContainers = listOf("0000000f000e00010d0a00080110011a04010203", "0001400104"),
+        responseContainers = listOf("00000005000580010d0000"),
+    ),
+    ConformanceVector(
+        "file_write", "\u000d", "unary", 247,
+        request = "080110011a0401020304",
+        response = "",
+        requestContainers = listOf("0000000f000f00010d0a00080110011a0401020304"),
+        responseContainers = listOf("00000005000580010d0000"),
+    ),
+    ConformanceVector(
+        "file_close", "\u000e", "unary", 23,
+        request = "08011001",
+        response = "08011001",
+        requestContainers = listOf("00000009000900010e040008011001"),
+        responseContainers = listOf("00000009000980010e040008011001"),
+    ),
+    ConformanceVector(
+        "file_close", "\u000e", "unary", 247,
+        request = "08011001",
+        response = "08011001",
+        requestContainers = listOf("00000009000900010e040008011001"),
+        responseContainers = listOf("00000009000980010e040008011001"),
+    ),
+    ConformanceVector(
+        "log_stream", "\u000f", "p2c", 23,
+        request = "08011001",
+        response = "08011001180120012a066d6f64756c6532076d6573736167653801",
+        requestContainers = listOf("00000009000900010f040008011001"),
+        responseContainers = listOf("00000020000e80010f1b0008011001180120012a", "00014010066d6f64756c6532076d657373616765", "000240023801", "0100cc00"),
+    ),
+    ConformanceVector(
+        "log_stream", "\u000f", "p2c", 247,
+        request = "08011001",
+        response = "08011001180120012a066d6f64756c6532076d6573736167653801",
+        requestContainers = listOf("00000009000900010f040008011001"),
+        responseContainers = listOf("00000020002080010f1b0008011001180120012a066d6f64756c6532076d6573736167653801", "0100cc00"),
+    ),
+    ConformanceVector(
+        "get_rpc_stats", "\u0010", "unary", 23,
+        request = "0801",
+        response = "0a0c0a046e616d65100118012001",
+        requestContainers = listOf("00000007000700011002000801"),
+        responseContainers = listOf("00000013000e8001100e000a0c0a046e616d6510", "000140050118012001"),
+    ),
+    ConformanceVector(
+        "get_rpc_stats", "\u0010", "unary", 247,
+        request = "0801",
+        response = "0a0c0a046e616d65100118012001",
+        requestContainers = listOf("00000007000700011002000801"),
+        responseContainers = listOf("0000001300138001100e000a0c0a046e616d65100118012001"),
+    ),
+    ConformanceVector(
+        "start_session", "\u0011", "unary", 23,
+        request = "",
+        response = "0a0401020304",
+        requestContainers = listOf("0000000500050001110000"),
+        responseContainers = listOf("0000000b000b80011106000a0401020304"),
+    ),
+    ConformanceVector(
+        "start_session", "\u0011", "unary", 247,
+        request = "",
+        response = "0a0401020304",
+        requestContainers = listOf("0000000500050001110000"),
+        responseContainers = listOf("0000000b000b80011106000a0401020304"),
+    ),
+    ConformanceVector(
+        "authenticate_session", "\u0012", "unary", 23,
+        request = "0a0401020304",
+        response = "0a0401020304",
+        requestContainers = listOf("0000000b000b00011206000a0401020304"),
+        responseContainers = listOf("0000000b000b80011206000a0401020304"),
+    ),
+    ConformanceVector(
+        "authenticate_session", "\u0012", "unary", 247,
+        request = "0a0401020304",
+        response = "0a0401020304",
+        requestContainers = listOf("0000000b000b00011206000a0401020304"),
+        responseContainers = listOf("0000000b000b80011206000a0401020304"),
+    ),
+    ConformanceVector(
+        "time_sync", "\u0013", "unary", 23,
+        request = "08ffffffffffffffffff011001",
+        response = "08ffffffffffffffffff01",
+        requestContainers = listOf("00000012000e0001130d0008ffffffffffffffff", "00014004ff011001"),
+        responseContainers = listOf("00000010000e8001130b0008ffffffffffffffff", "00014002ff01"),
+    ),
+    ConformanceVector(
+        "time_sync", "\u0013", "unary", 247,
+        request = "08ffffffffffffffffff011001",
+        response = "08ffffffffffffffffff01",
+        requestContainers = listOf("0000001200120001130d0008ffffffffffffffffff011001"),
+        responseContainers = listOf("0000001000108001130b0008ffffffffffffffffff01"),
+    ),
+    ConformanceVector(
+        "ping", "\u0014", "unary", 23,
+        request = "0a0401020304",
+        response = "0a040102030410011801",
+        requestContainers = listOf("0000000b000b00011406000a0401020304"),
+        responseContainers = listOf("0000000f000e8001140a000a0401020304100118", "0001400101"),
+    ),
+    ConformanceVector(
+        "ping", "\u0014", "unary", 247,
+        request = "0a0401020304",
+        response = "0a040102030410011801",
+        requestContainers = listOf("0000000b000b00011406000a0401020304"),
+        responseContainers = listOf("0000000f000f8001140a000a040102030410011801"),
+    ),
+    ConformanceVector(
+        "get_capabilities", "\u0015", "unary", 23,
+        request = "",
+        response = "0a040102030412056e616d6573",
+        requestContainers = listOf("0000000500050001150000"),
+        responseContainers = listOf("00000012000e8001150d000a040102030412056e", "00014004616d6573"),
+    ),
+    ConformanceVector(
+        "get_capabilities", "\u0015", "unary", 247,
+        request = "",
+        response = "0a040102030412056e616d6573",
+        requestContainers = listOf("0000000500050001150000"),
+        responseContainers = listOf("0000001200128001150d000a040102030412056e616d6573"),
+    ),
+    ConformanceVector(
+        "get_setting", "\u0016", "unary", 23,
+        request = "0801",
+        response = "0a0401020304",
+        requestContainers = listOf("00000007000700011602000801"),
+        responseContainers = listOf("0000000b000b80011606000a0401020304"),
+    ),
+    ConformanceVector(
+        "get_setting", "\u0016", "unary", 247,
+        request = "0801",
+        response = "0a0401020304",
+        requestContainers = listOf("00000007000700011602000801"),
+        responseContainers = listOf("0000000b000b80011606000a0401020304"),
+    ),
+    ConformanceVector(
+        "set_setting", "\u0017", "unary", 23,
+        request = "0801120401020304",
+        response = "",
+        requestContainers = listOf("0000000d000d00011708000801120401020304"),
+        responseContainers = listOf("0000000500058001170000"),
+    ),
+    ConformanceVector(
+        "set_setting", "\u0017", "unary", 247,
+        request = "0801120401020304",
+        response = "",
+        requestContainers = listOf("0000000d000d00011708000801120401020304"),
+        responseContainers = listOf("0000000500058001170000"),
+    ),
+)
+
+private fun conformanceRequest(command: String, data: ByteArray): MessageLite = when (command) {
+    "echo" -> blerpc.Blerpc.EchoRequest.parseFrom(data)
+    "data_write" -> blerpc.Blerpc.DataWriteRequest.parseFrom(data)
+    "counter_stream" -> blerpc.Blerpc.CounterStreamRequest.parseFrom(data)
+    "counter_upload" -> blerpc.Blerpc.CounterUploadRequest.parseFrom(data)
+    "get_blerpc_info" -> blerpc.Blerpc.GetBlerpcInfoRequest.parseFrom(data)
+    "conn_params" -> blerpc.Blerpc.ConnParamsRequest.parseFrom(data)
+    "dfu_begin" -> blerpc.Blerpc.DfuBeginRequest.parseFrom(data)
+    "dfu_chunk" -> blerpc.Blerpc.DfuChunkRequest.parseFrom(data)
+    "dfu_finalize" -> blerpc.Blerpc.DfuFinalizeRequest.parseFrom(data)
+    "file_open" -> blerpc.Blerpc.FileOpenRequest.parseFrom(data)
+    "file_read" -> blerpc.Blerpc.FileReadRequest.parseFrom(data)
+    "file_write" -> blerpc.Blerpc.FileWriteRequest.parseFrom(data)
+    "file_close" -> blerpc.Blerpc.FileCloseRequest.parseFrom(data)
+    "log_stream" -> blerpc.Blerpc.LogStreamRequest.parseFrom(data)
+    "get_rpc_stats" -> blerpc.Blerpc.GetRpcStatsRequest.parseFrom(data)
+    "start_session" -> blerpc.Blerpc.StartSessionRequest.parseFrom(data)
+    "authenticate_session" -> blerpc.Blerpc.AuthenticateSessionRequest.parseFrom(data)
+    "time_sync" -> blerpc.Blerpc.TimeSyncRequest.parseFrom(data)
+    "ping" -> blerpc.Blerpc.PingRequest.parseFrom(data)
+    "get_capabilities" -> blerpc.Blerpc.GetCapabilitiesRequest.parseFrom(data)
+    "get_setting" -> blerpc.Blerpc.GetSettingRequest.parseFrom(data)
+    "set_setting" -> blerpc.Blerpc.SetSettingRequest.parseFrom(data)
+    else -> throw IllegalArgumentException("unknown command $command")
+}
+
+private fun conformanceResponse(command: String, data: ByteArray): MessageLite = when (command) {
+    "echo" -> blerpc.Blerpc.EchoResponse.parseFrom(data)
+    "data_write" -> blerpc.Blerpc.DataWriteResponse.parseFrom(data)
+    "counter_stream" -> blerpc.Blerpc.CounterStreamResponse.parseFrom(data)
+    "counter_upload" -> blerpc.Blerpc.CounterUploadResponse.parseFrom(data)
+    "get_blerpc_info" -> blerpc.Blerpc.GetBlerpcInfoResponse.parseFrom(data)
+    "conn_params" -> blerpc.Blerpc.ConnParamsResponse.parseFrom(data)
+    "dfu_begin" -> blerpc.Blerpc.DfuBeginResponse.parseFrom(data)
+    "dfu_chunk" -> blerpc.Blerpc.DfuChunkResponse.parseFrom(data)
+    "dfu_finalize" -> blerpc.Blerpc.DfuFinalizeResponse.parseFrom(data)
+    "file_open" -> blerpc.Blerpc.FileOpenResponse.parseFrom(data)
+    "file_read" -> blerpc.Blerpc.FileReadResponse.parseFrom(data)
+    "file_write" -> blerpc.Blerpc.FileWriteResponse.parseFrom(data)
+    "file_close" -> blerpc.Blerpc.FileCloseResponse.parseFrom(data)
+    "log_stream" -> blerpc.Blerpc.LogStreamResponse.parseFrom(data)
+    "get_rpc_stats" -> blerpc.Blerpc.GetRpcStatsResponse.parseFrom(data)
+    "start_session" -> blerpc.Blerpc.StartSessionResponse.parseFrom(data)
+    "authenticate_session" -> blerpc.Blerpc.AuthenticateSessionResponse.parseFrom(data)
+    "time_sync" -> blerpc.Blerpc.TimeSyncResponse.parseFrom(data)
+    "ping" -> blerpc.Blerpc.PingResponse.parseFrom(data)
+    "get_capabilities" -> blerpc.Blerpc.GetCapabilitiesResponse.parseFrom(data)
+    "get_setting" -> blerpc.Blerpc.GetSettingResponse.parseFrom(data)
+    "set_setting" -> blerpc.Blerpc.SetSettingResponse.parseFrom(data)
+    else -> throw IllegalArgumentException("unknown command $command")
+}
@@ -0,0 +1,149 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.google.protobuf.ByteString
+import com.google.protobuf.MessageLite
+
+/**
+ * A call recorded by [MockGeneratedClient]: the command and its decoded
+ * requests, one per message of a C→P stream.
+ */
+data class MockCall(val command: String, val requests: List<MessageLite>)
+
+/**
+ * Stand-in for BlerpcClient in app unit tests, with no peripheral. Records
+ * every call in [calls] and answers it with the responses set for its
+ * command. Commands without any get an empty response, and P→C streams no
+ * responses.
+ */
+class MockGeneratedClient : GeneratedClient() {
+    private val answers = mutableMapOf<String, (List<MessageLite>) -> List<MessageLite>>()
+    private val recorded = mutableListOf<MockCall>()
+
+    init {
+        // Any key does: the mock answers the session handshake itself.
+        sessionKey = ByteArray(32)
+    }
+
+    /** The calls made so far, in order. */
+    val calls: List<MockCall>
+        get() = synchronized(recorded) { recorded.toList() }
+
+    /**
+     * Answers [command] with [responses]: a unary or C→P call gets the first,
+     * a P→C stream each of them.
+     */
+    fun respond(command: String, vararg responses: MessageLite) {
+        respond(command) { responses.toList() }
+    }
+
+    /** Answers [command] with the responses [answer] returns for its requests. */
+    fun respond(command: String, answer: (List<MessageLite>) -> List<MessageLite>) {
+        synchronized(answers) { answers[command] = answer }
+    }
+
+    /** Fails calls of [command] with [error], e.g. a [NotFoundException]. */
+    fun fail(command: String, error: Throwable) {
+        respond(command) { throw error }
+    }
+
+    override suspend fun call(cmdName: String, requestData: ByteArray): ByteArray {
+        val (command, responses) = answer(cmdName, listOf(requestData))
+        return (responses?.firstOrNull() ?: emptyResponse(command)).toByteArray()
+    }
+
+    override suspend fun streamReceive(cmdName: String, requestData: ByteArray): List<ByteArray> =
+        answer(cmdName, listOf(requestData)).second.orEmpty().map { it.toByteArray() }
+
+    override suspend fun streamSend(cmdName: String, messages: List<ByteArray>, finalCmdName: String): ByteArray {
+        val (command, responses) = answer(cmdName, messages)
+        return (responses?.firstOrNull() ?: emptyResponse(command)).toByteArray()
+    }
+
+    /** Records a call and returns its command with the responses set for it. */
+    private fun answer(cmdName: String, requests: List<ByteArray>): Pair<String, List<MessageLite>?> {
+        val command = when (cmdName) {
+            CommandId.ECHO.wireName -> "echo"
+            CommandId.FLASH_READ.wireName -> "flash_read"
+            CommandId.DATA_WRITE.wireName -> "data_write"
+            CommandId.COUNTER_STREAM.wireName -> "counter_stream"
+            CommandId.COUNTER_UPLOAD.wireName -> "counter_upload"
+            CommandId.GET_BLERPC_INFO.wireName -> "get_blerpc_info"
+            CommandId.DFU_BEGIN.wireName -> "dfu_begin"
+            CommandId.DFU_CHUNK.wireName -> "dfu_chunk"
+            CommandId.DFU_FINALIZE.wireName -> "dfu_finalize"
+            CommandId.FILE_OPEN.wireName -> "file_open"
+            CommandId.FILE_READ.wireName -> "file_read"
+            CommandId.FILE_WRITE.wireName -> "file_write"
+            CommandId.FILE_CLOSE.wireName -> "file_close"
+            CommandId.LOG_STREAM.wireName -> "log_stream"
+            CommandId.GET_RPC_STATS.wireName -> "get_rpc_stats"
+            CommandId.START_SESSION.wireName -> "start_session"
+            CommandId.AUTHENTICATE_SESSION.wireName -> "authenticate_session"
+            CommandId.TIME_SYNC.wireName -> "time_sync"
+            CommandId.PING.wireName -> "ping"
+            CommandId.GET_CAPABILITIES.wireName -> "get_capabilities"
+            CommandId.GET_SETTING.wireName -> "get_setting"
+            CommandId.SET_SETTING.wireName -> "set_setting"
+            else -> cmdName
+        }
+        val decoded = requests.map { decodeRequest(command, it) }
+        synchronized(recorded) { recorded.add(MockCall(command, decoded)) }
+        return command to synchronized(answers) { answers[command] }?.invoke(decoded)
+    }
+
+    private fun decodeRequest(command: String, data: ByteArray): MessageLite = when (command) {
+        "echo" -> blerpc.Blerpc.EchoRequest.parseFrom(data)
+        "flash_read" -> blerpc.Blerpc.FlashReadRequest.parseFrom(data.copyOfRange(32, data.size))
+        "data_write" -> blerpc.Blerpc.DataWriteRequest.parseFrom(data)
+        "counter_stream" -> blerpc.Blerpc.CounterStreamRequest.parseFrom(data)
+        "counter_upload" -> blerpc.Blerpc.CounterUploadRequest.parseFrom(data)
+        "get_blerpc_info" -> blerpc.Blerpc.GetBlerpcInfoRequest.parseFrom(data)
+        "dfu_begin" -> blerpc.Blerpc.DfuBeginRequest.parseFrom(data)
+        "dfu_chunk" -> blerpc.Blerpc.DfuChunkRequest.parseFrom(data)
+        "dfu_finalize" -> blerpc.Blerpc.DfuFinalizeRequest.parseFrom(data)
+        "file_open" -> blerpc.Blerpc.FileOpenRequest.parseFrom(data)
+        "file_read" -> blerpc.Blerpc.FileReadRequest.parseFrom(data)
+        "file_write" -> blerpc.Blerpc.FileWriteRequest.parseFrom(data)
+        "file_close" -> blerpc.Blerpc.FileCloseRequest.parseFrom(data)
+        "log_stream" -> blerpc.Blerpc.LogStreamRequest.parseFrom(data)
+        "get_rpc_stats" -> blerpc.Blerpc.GetRpcStatsRequest.parseFrom(data)
+        "start_session" -> blerpc.Blerpc.StartSessionRequest.parseFrom(data)
+        "authenticate_session" -> blerpc.Blerpc.AuthenticateSessionRequest.parseFrom(data)
+        "time_sync" -> blerpc.Blerpc.TimeSyncRequest.parseFrom(data)
+        "ping" -> blerpc.Blerpc.PingRequest.parseFrom(data)
+        "get_capabilities" -> blerpc.Blerpc.GetCapabilitiesRequest.parseFrom(data)
+        "get_setting" -> blerpc.Blerpc.GetSettingRequest.parseFrom(data)
+        "set_setting" -> blerpc.Blerpc.SetSettingRequest.parseFrom(data)
+        else -> throw IllegalArgumentException("unknown command $command")
+    }
+
+    private fun emptyResponse(command: String): MessageLite = when (command) {
+        "echo" -> blerpc.Blerpc.EchoResponse.getDefaultInstance()
+        "flash_read" -> blerpc.Blerpc.FlashReadResponse.getDefaultInstance()
+        "data_write" -> blerpc.Blerpc.DataWriteResponse.getDefaultInstance()
+        "counter_stream" -> blerpc.Blerpc.CounterStreamResponse.getDefaultInstance()
+        "counter_upload" -> blerpc.Blerpc.CounterUploadResponse.getDefaultInstance()
+        "get_blerpc_info" -> blerpc.Blerpc.GetBlerpcInfoResponse.getDefaultInstance()
+        "dfu_begin" -> blerpc.Blerpc.DfuBeginResponse.getDefaultInstance()
+        "dfu_chunk" -> blerpc.Blerpc.DfuChunkResponse.getDefaultInstance()
+        "dfu_finalize" -> blerpc.Blerpc.DfuFinalizeResponse.getDefaultInstance()
+        "file_open" -> blerpc.Blerpc.FileOpenResponse.getDefaultInstance()
+        "file_read" -> blerpc.Blerpc.FileReadResponse.getDefaultInstance()
+        "file_write" -> blerpc.Blerpc.FileWriteResponse.getDefaultInstance()
+        "file_close" -> blerpc.Blerpc.FileCloseResponse.getDefaultInstance()
+        "log_stream" -> blerpc.Blerpc.LogStreamResponse.getDefaultInstance()
+        "get_rpc_stats" -> blerpc.Blerpc.GetRpcStatsResponse.getDefaultInstance()
+        "start_session" -> blerpc.Blerpc.StartSessionResponse.getDefaultInstance()
+        // A token of the size peripherals issue opens the session.
+        "authenticate_session" -> blerpc.Blerpc.AuthenticateSessionResponse.newBuilder()
+            .setToken(ByteString.copyFrom(ByteArray(8)))
+            .build()
+        "time_sync" -> blerpc.Blerpc.TimeSyncResponse.getDefaultInstance()
+        "ping" -> blerpc.Blerpc.PingResponse.getDefaultInstance()
+        "get_capabilities" -> blerpc.Blerpc.GetCapabilitiesResponse.getDefaultInstance()
+        "get_setting" -> blerpc.Blerpc.GetSettingResponse.getDefaultInstance()
+        "set_setting" -> blerpc.Blerpc.SetSettingResponse.getDefaultInstance()
+        else -> throw IllegalArgumentException("unknown command $command")
+    }
+}
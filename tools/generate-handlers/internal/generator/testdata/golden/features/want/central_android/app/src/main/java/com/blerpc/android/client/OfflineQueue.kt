@@ -0,0 +1,138 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import kotlinx.coroutines.CancellationException
+import kotlinx.coroutines.TimeoutCancellationException
+import kotlinx.coroutines.sync.Mutex
+import kotlinx.coroutines.sync.withLock
+import java.io.DataInputStream
+import java.io.DataOutputStream
+import java.io.File
+import java.io.IOException
+
+/** Time to live in seconds of each queueable command. */
+val QUEUEABLE_COMMANDS: Map<String, Long> =
+    mapOf(
+        "data_write" to 3600L,
+    )
+
+/** A call waiting in an [OfflineQueue]. */
+class QueuedCall(
+    val command: String,
+    val request: ByteArray,
+    val expiresAtMs: Long,
+)
+
+/** The queued call expired before a connection came up. */
+class QueuedCallExpiredException(val command: String) :
+    BlerpcException("$command: expired in the offline queue")
+
+/** Persists the calls of an [OfflineQueue]. */
+interface QueueStore {
+    fun load(): List<QueuedCall>
+
+    fun save(calls: List<QueuedCall>)
+}
+
+/** Keeps the queue in [file], e.g. File(context.filesDir, "blerpc_queue.bin"). */
+class FileQueueStore(private val file: File) : QueueStore {
+    override fun load(): List<QueuedCall> {
+        if (!file.exists()) return emptyList()
+        DataInputStream(file.inputStream().buffered()).use { input ->
+            return List(input.readInt()) {
+                val command = input.readUTF()
+                val expiresAtMs = input.readLong()
+                val request = ByteArray(input.readInt()).also { input.readFully(it) }
+                QueuedCall(command, request, expiresAtMs)
+            }
+        }
+    }
+
+    override fun save(calls: List<QueuedCall>) {
+        // Write a new file and rename it so a crash never leaves a torn queue.
+        val tmp = File(file.path + ".tmp")
+        DataOutputStream(tmp.outputStream().buffered()).use { out ->
+            out.writeInt(calls.size)
+            for (call in calls) {
+                out.writeUTF(call.command)
+                out.writeLong(call.expiresAtMs)
+                out.writeInt(call.request.size)
+                out.write(call.request)
+            }
+        }
+        if (!tmp.renameTo(file)) {
+            throw IOException("Cannot replace ${file.path}")
+        }
+    }
+}
+
+/**
+ * Persistent queue of calls made while the device is out of reach.
+ *
+ * Call [flush] after connecting. Calls are sent in the order they were queued
+ * and never after they expire. A call failing without an answer from the
+ * peripheral stops the flush and stays queued; one rejected with a
+ * [RemoteException] is reported and dropped.
+ */
+class OfflineQueue(
+    private val store: QueueStore,
+    private val clock: () -> Long = System::currentTimeMillis,
+) {
+    private val lock = Mutex()
+    private val flushLock = Mutex()
+    private val calls = ArrayDeque(store.load())
+
+    suspend fun size(): Int = lock.withLock { calls.size }
+
+    suspend fun enqueueDataWrite(request: blerpc.Blerpc.DataWriteRequest) {
+        enqueue("data_write", request.toByteArray())
+    }
+
+    /**
+     * Sends the queued calls through [client] and passes each outcome to
+     * [onResult]; expired calls fail with [QueuedCallExpiredException]. Returns
+     * the number of calls left, right away if another flush is running.
+     */
+    suspend fun flush(
+        client: GeneratedClient,
+        onResult: (QueuedCall, Result<ByteArray>) -> Unit = { _, _ -> },
+    ): Int {
+        if (!flushLock.tryLock()) return size()
+        try {
+            while (true) {
+                val call = lock.withLock { calls.firstOrNull() } ?: return 0
+                val result: Result<ByteArray> =
+                    if (clock() >= call.expiresAtMs) {
+                        Result.failure(QueuedCallExpiredException(call.command))
+                    } else {
+                        try {
+                            Result.success(client.exclusive { client.call(call.command, call.request) })
+                        } catch (e: RemoteException) {
+                            Result.failure(e)
+                        } catch (e: Exception) {
+                            if (e is CancellationException && e !is TimeoutCancellationException) throw e
+                            return size()
+                        }
+                    }
+                lock.withLock {
+                    calls.removeFirst()
+                    store.save(calls)
+                }
+                onResult(call, result)
+            }
+        } finally {
+            flushLock.unlock()
+        }
+    }
+
+    private suspend fun enqueue(
+        command: String,
+        request: ByteArray,
+    ) {
+        val ttlMs = QUEUEABLE_COMMANDS.getValue(command) * 1000
+        lock.withLock {
+            calls.addLast(QueuedCall(command, request, clock() + ttlMs))
+            store.save(calls)
+        }
+    }
+}
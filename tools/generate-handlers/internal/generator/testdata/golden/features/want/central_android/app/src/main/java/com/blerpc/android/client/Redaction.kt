@@ -0,0 +1,26 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.google.protobuf.MessageLite
+
+/** Stands in for the value of a field marked (blerpc.sensitive). */
+const val REDACTED = "<redacted>"
+
+/**
+ * Returns [message] like a data class's toString, with the fields marked
+ * (blerpc.sensitive) shown as [REDACTED], e.g.
+ * `LoginRequest(user=ann, password=<redacted>)`. Messages without such
+ * fields, directly or in a message field, keep their own toString.
+ */
+fun redacted(message: MessageLite): String =
+    when (message) {
+        is blerpc.Blerpc.FlashReadResponse -> message.toRedactedString()
+        else -> message.toString()
+    }
+
+/** This FlashReadResponse with its sensitive fields redacted. */
+fun blerpc.Blerpc.FlashReadResponse.toRedactedString(): String =
+    buildList {
+        add("address=$address")
+        add("data=$REDACTED")
+    }.joinToString(", ", "FlashReadResponse(", ")")
@@ -0,0 +1,141 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import kotlinx.coroutines.CancellationException
+import kotlinx.coroutines.TimeoutCancellationException
+import kotlinx.coroutines.flow.MutableStateFlow
+import kotlinx.coroutines.flow.StateFlow
+import kotlinx.coroutines.flow.asStateFlow
+import kotlinx.coroutines.withTimeout
+
+/** Connection state of the link of a [ResultClient]. */
+enum class ConnectionState { DISCONNECTED, CONNECTING, CONNECTED }
+
+/**
+ * Timeouts of the unary commands with a call policy, covering all of its
+ * attempts. Other unary commands get the default of the [ResultClient].
+ */
+val RESULT_TIMEOUTS_MS: Map<String, Long> =
+    mapOf(
+        "echo" to 1500L,
+        "flash_read" to 30000L,
+    )
+
+/**
+ * The commands of [client], returning a [Result] instead of throwing. A
+ * failure is a [BlerpcException]: a [TimeoutException] once the timeout of
+ * a unary call passes, and a [TransportException] wrapping any error that
+ * is not a BlerpcException. Cancellation is not caught.
+ *
+ *     client.echo(message = "hi")
+ *         .onSuccess { println(it.message) }
+ *         .onFailure { log(it) }
+ */
+class ResultClient(
+    private val client: GeneratedClient,
+    private val defaultTimeoutMs: Long = 5000,
+) {
+    private val state = MutableStateFlow(ConnectionState.DISCONNECTED)
+
+    /** The state the link's owner last reported with [setConnectionState]. */
+    val connectionState: StateFlow<ConnectionState> = state.asStateFlow()
+
+    fun setConnectionState(value: ConnectionState) {
+        state.value = value
+    }
+
+    suspend fun echo(message: String = ""): Result<blerpc.Blerpc.EchoResponse> =
+        timed("echo") { it.echo(message = message) }
+
+    suspend fun flashRead(address: Int = 0, length: Int = 0): Result<blerpc.Blerpc.FlashReadResponse> =
+        timed("flash_read") { it.flashRead(address = address, length = length) }
+
+    @Deprecated("data_write is deprecated in the schema")
+    @Suppress("DEPRECATION")
+    suspend fun dataWrite(data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): Result<blerpc.Blerpc.DataWriteResponse> =
+        timed("data_write") { it.dataWrite(data = data) }
+
+    suspend fun counterStream(count: Int = 0): Result<List<blerpc.Blerpc.CounterStreamResponse>> =
+        untimed("counter_stream") { it.counterStream(count = count) }
+
+    suspend fun counterUpload(messages: List<blerpc.Blerpc.CounterUploadRequest>): Result<blerpc.Blerpc.CounterUploadResponse> =
+        untimed("counter_upload") { it.counterUpload(messages) }
+
+    suspend fun getBlerpcInfo(): Result<blerpc.Blerpc.GetBlerpcInfoResponse> =
+        timed("get_blerpc_info") { it.getBlerpcInfo() }
+
+    suspend fun dfuBegin(size: Int = 0, crc32: Int = 0): Result<blerpc.Blerpc.DfuBeginResponse> =
+        timed("dfu_begin") { it.dfuBegin(size = size, crc32 = crc32) }
+
+    suspend fun dfuChunk(offset: Int = 0, data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): Result<blerpc.Blerpc.DfuChunkResponse> =
+        timed("dfu_chunk") { it.dfuChunk(offset = offset, data = data) }
+
+    suspend fun dfuFinalize(reboot: Boolean = false): Result<blerpc.Blerpc.DfuFinalizeResponse> =
+        timed("dfu_finalize") { it.dfuFinalize(reboot = reboot) }
+
+    suspend fun fileOpen(path: String = "", write: Boolean = false, resume: Boolean = false): Result<blerpc.Blerpc.FileOpenResponse> =
+        timed("file_open") { it.fileOpen(path = path, write = write, resume = resume) }
+
+    suspend fun fileRead(handle: Int = 0, offset: Int = 0, length: Int = 0): Result<blerpc.Blerpc.FileReadResponse> =
+        timed("file_read") { it.fileRead(handle = handle, offset = offset, length = length) }
+
+    suspend fun fileWrite(handle: Int = 0, offset: Int = 0, data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): Result<blerpc.Blerpc.FileWriteResponse> =
+        timed("file_write") { it.fileWrite(handle = handle, offset = offset, data = data) }
+
+    suspend fun fileClose(handle: Int = 0, verify: Boolean = false): Result<blerpc.Blerpc.FileCloseResponse> =
+        timed("file_close") { it.fileClose(handle = handle, verify = verify) }
+
+    suspend fun logStream(min_level: Int = 0, max_entries: Int = 0): Result<List<blerpc.Blerpc.LogStreamResponse>> =
+        untimed("log_stream") { it.logStream(min_level = min_level, max_entries = max_entries) }
+
+    suspend fun getRpcStats(reset: Boolean = false): Result<blerpc.Blerpc.GetRpcStatsResponse> =
+        timed("get_rpc_stats") { it.getRpcStats(reset = reset) }
+
+    suspend fun startSession(): Result<blerpc.Blerpc.StartSessionResponse> =
+        timed("start_session") { it.startSession() }
+
+    suspend fun authenticateSession(proof: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): Result<blerpc.Blerpc.AuthenticateSessionResponse> =
+        timed("authenticate_session") { it.authenticateSession(proof = proof) }
+
+    suspend fun timeSync(unix_time_us: Long = 0L, offset_us: Int = 0): Result<blerpc.Blerpc.TimeSyncResponse> =
+        timed("time_sync") { it.timeSync(unix_time_us = unix_time_us, offset_us = offset_us) }
+
+    suspend fun ping(payload: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): Result<blerpc.Blerpc.PingResponse> =
+        timed("ping") { it.ping(payload = payload) }
+
+    suspend fun getCapabilities(): Result<blerpc.Blerpc.GetCapabilitiesResponse> =
+        timed("get_capabilities") { it.getCapabilities() }
+
+    suspend fun getSetting(field: Int = 0): Result<blerpc.Blerpc.GetSettingResponse> =
+        timed("get_setting") { it.getSetting(field = field) }
+
+    suspend fun setSetting(field: Int = 0, value: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): Result<blerpc.Blerpc.SetSettingResponse> =
+        timed("set_setting") { it.setSetting(field = field, value = value) }
+
+    private suspend fun <T> timed(
+        command: String,
+        block: suspend (GeneratedClient) -> T,
+    ): Result<T> {
+        val timeoutMs = RESULT_TIMEOUTS_MS[command] ?: defaultTimeoutMs
+        return try {
+            untimed(command) { withTimeout(timeoutMs) { block(it) } }
+        } catch (e: TimeoutCancellationException) {
+            Result.failure(TimeoutException("$command: no response within $timeoutMs ms", e))
+        }
+    }
+
+    private suspend fun <T> untimed(
+        command: String,
+        block: suspend (GeneratedClient) -> T,
+    ): Result<T> =
+        try {
+            Result.success(block(client))
+        } catch (e: BlerpcException) {
+            Result.failure(e)
+        } catch (e: CancellationException) {
+            // withTimeout of timed() expires as a TimeoutCancellationException.
+            throw e
+        } catch (e: Exception) {
+            Result.failure(TransportException("$command: ${e.message}", e))
+        }
+}
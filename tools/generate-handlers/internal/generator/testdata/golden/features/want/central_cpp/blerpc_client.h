@@ -0,0 +1,389 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_CLIENT_HPP
+#define BLERPC_GENERATED_CLIENT_HPP
+
+#include <algorithm>
+#include <chrono>
+#include <cstdint>
+#include <mutex>
+#include <stdexcept>
+#include <string>
+#include <utility>
+#include <vector>
+
+#include "blerpc.pb.h"
+
+namespace blerpc_client {
+
+namespace pb = ::blerpc;
+
+/** Base of every exception thrown by generated client methods. */
+class BlerpcError : public std::runtime_error {
+public:
+    using std::runtime_error::runtime_error;
+};
+
+/** The request could not be delivered or the response was lost. */
+class TransportError : public BlerpcError {
+public:
+    using BlerpcError::BlerpcError;
+};
+
+/** The peripheral did not respond in time. */
+class TimeoutError : public BlerpcError {
+public:
+    explicit TimeoutError(const std::string &command) : BlerpcError(command + ": timed out"), command(command) {}
+    std::string command;
+};
+
+/** The response payload is not a valid message. */
+class DecodeError : public BlerpcError {
+public:
+    explicit DecodeError(const std::string &command) : BlerpcError(command + ": invalid response"), command(command) {}
+    std::string command;
+};
+
+/** Status codes of error responses; 128 and up are application codes. */
+enum class StatusCode : int {
+    Ok = 0,
+    InvalidArgument = 1,
+    NotFound = 2,
+    AlreadyExists = 3,
+    PermissionDenied = 4,
+    ResourceExhausted = 5,
+    FailedPrecondition = 6,
+    OutOfRange = 7,
+    Unimplemented = 8,
+    Internal = 9,
+    Unavailable = 10,
+    Unauthenticated = 11,
+};
+
+/** The peripheral reported a non-OK status. */
+class RemoteError : public BlerpcError {
+public:
+    RemoteError(const std::string &command, int status)
+        : BlerpcError(command + " failed: status " + std::to_string(status)), command(command), status(status)
+    {
+    }
+    std::string command;
+    int status;
+};
+
+/**
+ * Throw the RemoteError an error response reports; return for other
+ * responses. Error responses hold only a status, in a field no message uses.
+ */
+inline void throwStatusError(const char *command, const std::string &data)
+{
+    static const std::string tag = {'\xF8', '\xFF', '\xFF', '\xFF', '\x0F'};
+    if (data.size() <= tag.size() || data.compare(0, tag.size(), tag) != 0) {
+        return;
+    }
+    int status = 0;
+    int shift = 0;
+    for (size_t i = tag.size(); i < data.size(); i++) {
+        auto byte = static_cast<uint8_t>(data[i]);
+        status |= (byte & 0x7F) << shift;
+        shift += 7;
+        if (byte < 0x80) {
+            break;
+        }
+    }
+    throw RemoteError(command, status);
+}
+
+/**
+ * Auto-generated RPC client. Derive from it and implement
+ * call/streamReceive/streamSend over the transport, e.g. BlueZ on D-Bus.
+ * The session handshake also needs sessionProof, the HMAC of the key.
+ * The command methods run one RPC at a time; the transport methods are
+ * called with the client's lock held.
+ */
+class GeneratedClient {
+public:
+    virtual ~GeneratedClient() = default;
+
+    /** Send one request and return the response payload. */
+    virtual std::string call(const std::string &cmd_name, const std::string &request_data) = 0;
+
+    /** Send one request and return every response of a P→C stream. */
+    virtual std::vector<std::string> streamReceive(const std::string &cmd_name,
+                                                   const std::string &request_data) = 0;
+
+    /** Send the messages of a C→P stream and return the final response payload. */
+    virtual std::string streamSend(const std::string &cmd_name, const std::vector<std::string> &messages,
+                                   const std::string &final_cmd_name) = 0;
+
+    /**
+     * Return the HMAC-SHA256 of data under the key shared with the
+     * peripheral, e.g. with OpenSSL's HMAC(). The session handshake sends it
+     * over the challenge to prove knowledge of the key, and replay-protected
+     * requests carry it over their counter.
+     */
+    virtual std::string sessionProof(const std::string &data) = 0;
+
+    /**
+     * Authenticate to the peripheral, unlocking its session-protected
+     * commands. Protected calls open a session as needed; call this to
+     * authenticate up front.
+     */
+    void openSession()
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        handshake();
+    }
+
+    pb::EchoResponse echo(const pb::EchoRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x01", req.SerializeAsString());
+        return decode<pb::EchoResponse>("echo", resp_data);
+    }
+
+    pb::FlashReadResponse flashRead(const pb::FlashReadRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = sessionCall("\x02", withReplayCounter("flash_read", req.SerializeAsString()));
+        return decode<pb::FlashReadResponse>("flash_read", resp_data);
+    }
+
+    pb::DataWriteResponse dataWrite(const pb::DataWriteRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x03", req.SerializeAsString());
+        return decode<pb::DataWriteResponse>("data_write", resp_data);
+    }
+
+    std::vector<pb::CounterStreamResponse> counterStream(const pb::CounterStreamRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::vector<pb::CounterStreamResponse> responses;
+        for (const std::string &data : streamReceive("\x04", req.SerializeAsString())) {
+            responses.push_back(decode<pb::CounterStreamResponse>("counter_stream", data));
+        }
+        return responses;
+    }
+
+    pb::CounterUploadResponse counterUpload(const std::vector<pb::CounterUploadRequest> &messages)
+    {
+        std::vector<std::string> raw;
+        raw.reserve(messages.size());
+        for (const pb::CounterUploadRequest &msg : messages) {
+            raw.push_back(msg.SerializeAsString());
+        }
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = streamSend("\x05", raw, "\x05");
+        return decode<pb::CounterUploadResponse>("counter_upload", resp_data);
+    }
+
+    pb::GetBlerpcInfoResponse getBlerpcInfo(const pb::GetBlerpcInfoRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x06", req.SerializeAsString());
+        return decode<pb::GetBlerpcInfoResponse>("get_blerpc_info", resp_data);
+    }
+
+    pb::ConnParamsResponse connParams(const pb::ConnParamsRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x07", req.SerializeAsString());
+        return decode<pb::ConnParamsResponse>("conn_params", resp_data);
+    }
+
+    pb::DfuBeginResponse dfuBegin(const pb::DfuBeginRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x08", req.SerializeAsString());
+        return decode<pb::DfuBeginResponse>("dfu_begin", resp_data);
+    }
+
+    pb::DfuChunkResponse dfuChunk(const pb::DfuChunkRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x09", req.SerializeAsString());
+        return decode<pb::DfuChunkResponse>("dfu_chunk", resp_data);
+    }
+
+    pb::DfuFinalizeResponse dfuFinalize(const pb::DfuFinalizeRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x0a", req.SerializeAsString());
+        return decode<pb::DfuFinalizeResponse>("dfu_finalize", resp_data);
+    }
+
+    pb::FileOpenResponse fileOpen(const pb::FileOpenRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x0b", req.SerializeAsString());
+        return decode<pb::FileOpenResponse>("file_open", resp_data);
+    }
+
+    pb::FileReadResponse fileRead(const pb::FileReadRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x0c", req.SerializeAsString());
+        return decode<pb::FileReadResponse>("file_read", resp_data);
+    }
+
+    pb::FileWriteResponse fileWrite(const pb::FileWriteRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x0d", req.SerializeAsString());
+        return decode<pb::FileWriteResponse>("file_write", resp_data);
+    }
+
+    pb::FileCloseResponse fileClose(const pb::FileCloseRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x0e", req.SerializeAsString());
+        return decode<pb::FileCloseResponse>("file_close", resp_data);
+    }
+
+    std::vector<pb::LogStreamResponse> logStream(const pb::LogStreamRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::vector<pb::LogStreamResponse> responses;
+        for (const std::string &data : streamReceive("\x0f", req.SerializeAsString())) {
+            responses.push_back(decode<pb::LogStreamResponse>("log_stream", data));
+        }
+        return responses;
+    }
+
+    pb::GetRpcStatsResponse getRpcStats(const pb::GetRpcStatsRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x10", req.SerializeAsString());
+        return decode<pb::GetRpcStatsResponse>("get_rpc_stats", resp_data);
+    }
+
+    pb::StartSessionResponse startSession(const pb::StartSessionRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x11", req.SerializeAsString());
+        return decode<pb::StartSessionResponse>("start_session", resp_data);
+    }
+
+    pb::AuthenticateSessionResponse authenticateSession(const pb::AuthenticateSessionRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x12", req.SerializeAsString());
+        return decode<pb::AuthenticateSessionResponse>("authenticate_session", resp_data);
+    }
+
+    pb::TimeSyncResponse timeSync(const pb::TimeSyncRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x13", req.SerializeAsString());
+        return decode<pb::TimeSyncResponse>("time_sync", resp_data);
+    }
+
+    pb::PingResponse ping(const pb::PingRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x14", req.SerializeAsString());
+        return decode<pb::PingResponse>("ping", resp_data);
+    }
+
+    pb::GetCapabilitiesResponse getCapabilities(const pb::GetCapabilitiesRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x15", req.SerializeAsString());
+        return decode<pb::GetCapabilitiesResponse>("get_capabilities", resp_data);
+    }
+
+    pb::GetSettingResponse getSetting(const pb::GetSettingRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x16", req.SerializeAsString());
+        return decode<pb::GetSettingResponse>("get_setting", resp_data);
+    }
+
+    pb::SetSettingResponse setSetting(const pb::SetSettingRequest &req)
+    {
+        std::lock_guard<std::mutex> lock(call_mutex_);
+        std::string resp_data = call("\x17", req.SerializeAsString());
+        return decode<pb::SetSettingResponse>("set_setting", resp_data);
+    }
+
+    [[deprecated("use flashRead")]]
+    pb::FlashReadResponse flashDump(const pb::FlashReadRequest &req)
+    {
+        return flashRead(req);
+    }
+
+protected:
+    /** Decode a response, throwing the error an error response reports. */
+    template <typename T>
+    static T decode(const char *command, const std::string &data)
+    {
+        throwStatusError(command, data);
+        T resp;
+        if (!resp.ParseFromString(data)) {
+            throw DecodeError(command);
+        }
+        return resp;
+    }
+
+    /**
+     * Lead a replay-protected request with a counter, 8 bytes little endian,
+     * and a MAC: the first 16 bytes of sessionProof() of the command name,
+     * a NUL, the counter and the request. The peripheral drops requests whose
+     * MAC is wrong or whose counter is not above the last one it accepted;
+     * microseconds since the epoch keep it increasing across restarts. Call
+     * with call_mutex_ held.
+     */
+    std::string withReplayCounter(const std::string &command, const std::string &request_data)
+    {
+        auto now = std::chrono::duration_cast<std::chrono::microseconds>(
+            std::chrono::system_clock::now().time_since_epoch());
+        last_replay_counter_ = std::max(last_replay_counter_ + 1, static_cast<uint64_t>(now.count()));
+        std::string counter;
+        for (int i = 0; i < 8; i++) {
+            counter.push_back(static_cast<char>(last_replay_counter_ >> (8 * i)));
+        }
+        std::string mac = sessionProof(command + std::string(1, '\0') + counter + request_data);
+        return counter + mac.substr(0, 16) + request_data;
+    }
+
+    /**
+     * Run the session handshake with call_mutex_ held and keep the token:
+     * the proof is sessionProof() of the challenge.
+     */
+    std::string handshake()
+    {
+        std::string start_data = call("\x11", pb::StartSessionRequest().SerializeAsString());
+        pb::AuthenticateSessionRequest req;
+        req.set_proof(sessionProof(decode<pb::StartSessionResponse>("start_session", start_data).challenge()));
+        std::string auth_data = call("\x12", req.SerializeAsString());
+        session_token_ = decode<pb::AuthenticateSessionResponse>("authenticate_session", auth_data).token();
+        return session_token_;
+    }
+
+    /**
+     * Send a session-protected request led by the session token, opening a
+     * session first when there is none. A peripheral that lost the session,
+     * e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent
+     * once more in a new session. Call with call_mutex_ held.
+     */
+    std::string sessionCall(const std::string &cmd_name, const std::string &request_data)
+    {
+        static const std::string unauthenticated = {'\xF8', '\xFF', '\xFF', '\xFF', '\x0F', '\x0B'};
+        if (session_token_.empty()) {
+            handshake();
+        }
+        std::string resp_data = call(cmd_name, session_token_ + request_data);
+        if (resp_data != unauthenticated) {
+            return resp_data;
+        }
+        return call(cmd_name, handshake() + request_data);
+    }
+
+    std::mutex call_mutex_;
+    uint64_t last_replay_counter_ = 0;
+    std::string session_token_;
+};
+
+} // namespace blerpc_client
+
+#endif /* BLERPC_GENERATED_CLIENT_HPP */
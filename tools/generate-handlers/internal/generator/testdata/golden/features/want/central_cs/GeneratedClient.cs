@@ -0,0 +1,400 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#nullable enable
+
+using System;
+using System.Collections.Generic;
+using System.Security.Cryptography;
+using System.Text;
+using System.Threading;
+using System.Threading.Tasks;
+using Google.Protobuf;
+using Pb = global::Blerpc;
+
+namespace Blerpc.Client
+{
+    /// <summary>Base of every exception thrown by generated client methods.</summary>
+    public class BlerpcException : Exception
+    {
+        public BlerpcException(string message, Exception? inner = null) : base(message, inner) { }
+    }
+
+    /// <summary>The request could not be delivered or the response was lost.</summary>
+    public class TransportException : BlerpcException
+    {
+        public TransportException(string message, Exception? inner = null) : base(message, inner) { }
+    }
+
+    /// <summary>The peripheral did not respond in time.</summary>
+    public class BlerpcTimeoutException : BlerpcException
+    {
+        public BlerpcTimeoutException(string command) : base(command + ": timed out") => Command = command;
+
+        public string Command { get; }
+    }
+
+    /// <summary>The response payload is not a valid message.</summary>
+    public class DecodeException : BlerpcException
+    {
+        public DecodeException(string command, Exception? inner = null) : base(command + ": invalid response", inner) => Command = command;
+
+        public string Command { get; }
+    }
+
+    /// <summary>Status codes of error responses; 128 and up are application codes.</summary>
+    public enum StatusCode
+    {
+        Ok = 0,
+        InvalidArgument = 1,
+        NotFound = 2,
+        AlreadyExists = 3,
+        PermissionDenied = 4,
+        ResourceExhausted = 5,
+        FailedPrecondition = 6,
+        OutOfRange = 7,
+        Unimplemented = 8,
+        Internal = 9,
+        Unavailable = 10,
+        Unauthenticated = 11,
+    }
+
+    /// <summary>The peripheral reported a non-OK status.</summary>
+    public class RemoteException : BlerpcException
+    {
+        public RemoteException(string command, int status) : base(command + " failed: status " + status)
+        {
+            Command = command;
+            Status = status;
+        }
+
+        public string Command { get; }
+
+        public int Status { get; }
+    }
+
+    internal static class StatusErrors
+    {
+        private static readonly byte[] Tag = { 0xF8, 0xFF, 0xFF, 0xFF, 0x0F };
+
+        /// <summary>
+        /// Throw the RemoteException an error response reports; return for other
+        /// responses. Error responses hold only a status, in a field no message uses.
+        /// </summary>
+        internal static void ThrowIfError(string command, byte[] data)
+        {
+            if (data.Length <= Tag.Length || !data.AsSpan(0, Tag.Length).SequenceEqual(Tag))
+            {
+                return;
+            }
+            int status = 0;
+            int shift = 0;
+            for (int i = Tag.Length; i < data.Length; i++)
+            {
+                status |= (data[i] & 0x7F) << shift;
+                shift += 7;
+                if (data[i] < 0x80)
+                {
+                    break;
+                }
+            }
+            throw new RemoteException(command, status);
+        }
+    }
+
+    /// <summary>
+    /// Auto-generated RPC client. Derive from it and implement CallAsync,
+    /// StreamReceiveAsync and StreamSendAsync over the transport, e.g.
+    /// Windows.Devices.Bluetooth. The command methods run one RPC at a time;
+    /// the transport methods are called with the client's lock held.
+    /// </summary>
+    public abstract class GeneratedClient
+    {
+        private readonly SemaphoreSlim callLock = new SemaphoreSlim(1, 1);
+        private ulong lastReplayCounter;
+        private byte[]? sessionToken;
+
+        /// <summary>Send one request and return the response payload.</summary>
+        protected abstract Task<byte[]> CallAsync(string cmdName, byte[] requestData, CancellationToken cancellationToken);
+
+        /// <summary>Send one request and return every response of a P→C stream.</summary>
+        protected abstract Task<IReadOnlyList<byte[]>> StreamReceiveAsync(string cmdName, byte[] requestData, CancellationToken cancellationToken);
+
+        /// <summary>Send the messages of a C→P stream and return the final response payload.</summary>
+        protected abstract Task<byte[]> StreamSendAsync(string cmdName, IReadOnlyList<byte[]> messages, string finalCmdName, CancellationToken cancellationToken);
+
+        /// <summary>
+        /// Key shared with the peripheral, which the session handshake proves
+        /// knowledge of. Set it before calling a session- or replay-protected
+        /// command.
+        /// </summary>
+        public byte[]? SessionKey { get; set; }
+
+        /// <summary>
+        /// Authenticate to the peripheral, unlocking its session-protected
+        /// commands. Protected calls open a session as needed; call this to
+        /// authenticate up front.
+        /// </summary>
+        public Task OpenSessionAsync(CancellationToken cancellationToken = default) =>
+            Exclusive(() => HandshakeAsync(cancellationToken), cancellationToken);
+
+        public async Task<Pb.EchoResponse> EchoAsync(Pb.EchoRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x01", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("echo", respData, Pb.EchoResponse.Parser);
+        }
+
+        public async Task<Pb.FlashReadResponse> FlashReadAsync(Pb.FlashReadRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => SessionCallAsync("\x02", WithReplayCounter("flash_read", req.ToByteArray()), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("flash_read", respData, Pb.FlashReadResponse.Parser);
+        }
+
+        public async Task<Pb.DataWriteResponse> DataWriteAsync(Pb.DataWriteRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x03", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("data_write", respData, Pb.DataWriteResponse.Parser);
+        }
+
+        public async Task<IReadOnlyList<Pb.CounterStreamResponse>> CounterStreamAsync(Pb.CounterStreamRequest req, CancellationToken cancellationToken = default)
+        {
+            var raw = await Exclusive(() => StreamReceiveAsync("\x04", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            var responses = new List<Pb.CounterStreamResponse>(raw.Count);
+            foreach (byte[] data in raw)
+            {
+                responses.Add(Decode("counter_stream", data, Pb.CounterStreamResponse.Parser));
+            }
+            return responses;
+        }
+
+        public async Task<Pb.CounterUploadResponse> CounterUploadAsync(IEnumerable<Pb.CounterUploadRequest> messages, CancellationToken cancellationToken = default)
+        {
+            var raw = new List<byte[]>();
+            foreach (Pb.CounterUploadRequest msg in messages)
+            {
+                raw.Add(msg.ToByteArray());
+            }
+            byte[] respData = await Exclusive(() => StreamSendAsync("\x05", raw, "\x05", cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("counter_upload", respData, Pb.CounterUploadResponse.Parser);
+        }
+
+        public async Task<Pb.GetBlerpcInfoResponse> GetBlerpcInfoAsync(Pb.GetBlerpcInfoRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x06", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("get_blerpc_info", respData, Pb.GetBlerpcInfoResponse.Parser);
+        }
+
+        public async Task<Pb.ConnParamsResponse> ConnParamsAsync(Pb.ConnParamsRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x07", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("conn_params", respData, Pb.ConnParamsResponse.Parser);
+        }
+
+        public async Task<Pb.DfuBeginResponse> DfuBeginAsync(Pb.DfuBeginRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x08", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("dfu_begin", respData, Pb.DfuBeginResponse.Parser);
+        }
+
+        public async Task<Pb.DfuChunkResponse> DfuChunkAsync(Pb.DfuChunkRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x09", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("dfu_chunk", respData, Pb.DfuChunkResponse.Parser);
+        }
+
+        public async Task<Pb.DfuFinalizeResponse> DfuFinalizeAsync(Pb.DfuFinalizeRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x0a", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("dfu_finalize", respData, Pb.DfuFinalizeResponse.Parser);
+        }
+
+        public async Task<Pb.FileOpenResponse> FileOpenAsync(Pb.FileOpenRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x0b", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("file_open", respData, Pb.FileOpenResponse.Parser);
+        }
+
+        public async Task<Pb.FileReadResponse> FileReadAsync(Pb.FileReadRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x0c", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("file_read", respData, Pb.FileReadResponse.Parser);
+        }
+
+        public async Task<Pb.FileWriteResponse> FileWriteAsync(Pb.FileWriteRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x0d", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("file_write", respData, Pb.FileWriteResponse.Parser);
+        }
+
+        public async Task<Pb.FileCloseResponse> FileCloseAsync(Pb.FileCloseRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x0e", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("file_close", respData, Pb.FileCloseResponse.Parser);
+        }
+
+        public async Task<IReadOnlyList<Pb.LogStreamResponse>> LogStreamAsync(Pb.LogStreamRequest req, CancellationToken cancellationToken = default)
+        {
+            var raw = await Exclusive(() => StreamReceiveAsync("\x0f", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            var responses = new List<Pb.LogStreamResponse>(raw.Count);
+            foreach (byte[] data in raw)
+            {
+                responses.Add(Decode("log_stream", data, Pb.LogStreamResponse.Parser));
+            }
+            return responses;
+        }
+
+        public async Task<Pb.GetRpcStatsResponse> GetRpcStatsAsync(Pb.GetRpcStatsRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x10", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("get_rpc_stats", respData, Pb.GetRpcStatsResponse.Parser);
+        }
+
+        public async Task<Pb.StartSessionResponse> StartSessionAsync(Pb.StartSessionRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x11", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("start_session", respData, Pb.StartSessionResponse.Parser);
+        }
+
+        public async Task<Pb.AuthenticateSessionResponse> AuthenticateSessionAsync(Pb.AuthenticateSessionRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x12", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("authenticate_session", respData, Pb.AuthenticateSessionResponse.Parser);
+        }
+
+        public async Task<Pb.TimeSyncResponse> TimeSyncAsync(Pb.TimeSyncRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x13", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("time_sync", respData, Pb.TimeSyncResponse.Parser);
+        }
+
+        public async Task<Pb.PingResponse> PingAsync(Pb.PingRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x14", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("ping", respData, Pb.PingResponse.Parser);
+        }
+
+        public async Task<Pb.GetCapabilitiesResponse> GetCapabilitiesAsync(Pb.GetCapabilitiesRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x15", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("get_capabilities", respData, Pb.GetCapabilitiesResponse.Parser);
+        }
+
+        public async Task<Pb.GetSettingResponse> GetSettingAsync(Pb.GetSettingRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x16", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("get_setting", respData, Pb.GetSettingResponse.Parser);
+        }
+
+        public async Task<Pb.SetSettingResponse> SetSettingAsync(Pb.SetSettingRequest req, CancellationToken cancellationToken = default)
+        {
+            byte[] respData = await Exclusive(() => CallAsync("\x17", req.ToByteArray(), cancellationToken), cancellationToken).ConfigureAwait(false);
+            return Decode("set_setting", respData, Pb.SetSettingResponse.Parser);
+        }
+
+        [Obsolete("use FlashReadAsync")]
+        public Task<Pb.FlashReadResponse> FlashDumpAsync(Pb.FlashReadRequest req, CancellationToken cancellationToken = default) =>
+            FlashReadAsync(req, cancellationToken);
+
+        /// <summary>Run a transport call with the client's lock held.</summary>
+        private async Task<T> Exclusive<T>(Func<Task<T>> call, CancellationToken cancellationToken)
+        {
+            await callLock.WaitAsync(cancellationToken).ConfigureAwait(false);
+            try
+            {
+                return await call().ConfigureAwait(false);
+            }
+            finally
+            {
+                callLock.Release();
+            }
+        }
+
+        /// <summary>Decode a response, throwing the error an error response reports.</summary>
+        protected static T Decode<T>(string command, byte[] data, MessageParser<T> parser) where T : IMessage<T>
+        {
+            StatusErrors.ThrowIfError(command, data);
+            try
+            {
+                return parser.ParseFrom(data);
+            }
+            catch (InvalidProtocolBufferException e)
+            {
+                throw new DecodeException(command, e);
+            }
+        }
+
+        /// <summary>
+        /// Lead a replay-protected request with a counter, 8 bytes little endian,
+        /// and a MAC: the first 16 bytes of an HMAC-SHA256 under SessionKey of
+        /// the command name, a NUL, the counter and the request. The peripheral
+        /// drops requests whose MAC is wrong or whose counter is not above the
+        /// last one it accepted; microseconds since the epoch keep it increasing
+        /// across restarts. Call with the client's lock held.
+        /// </summary>
+        private byte[] WithReplayCounter(string command, byte[] requestData)
+        {
+            byte[] key = SessionKey ?? throw new InvalidOperationException("SessionKey is not set");
+            ulong now = (ulong)(DateTimeOffset.UtcNow.ToUnixTimeMilliseconds() * 1000);
+            lastReplayCounter = Math.Max(lastReplayCounter + 1, now);
+            byte[] name = Encoding.UTF8.GetBytes(command);
+            var signed = new byte[name.Length + 1 + 8 + requestData.Length];
+            name.CopyTo(signed, 0);
+            for (int i = 0; i < 8; i++)
+            {
+                signed[name.Length + 1 + i] = (byte)(lastReplayCounter >> (8 * i));
+            }
+            requestData.CopyTo(signed, name.Length + 9);
+            using var hmac = new HMACSHA256(key);
+            byte[] mac = hmac.ComputeHash(signed);
+            var output = new byte[24 + requestData.Length];
+            Array.Copy(signed, name.Length + 1, output, 0, 8);
+            Array.Copy(mac, 0, output, 8, 16);
+            requestData.CopyTo(output, 24);
+            return output;
+        }
+
+        /// <summary>Error response of a peripheral that has no session with the token.</summary>
+        private static readonly byte[] Unauthenticated = { 0xF8, 0xFF, 0xFF, 0xFF, 0x0F, 0x0B };
+
+        /// <summary>
+        /// Run the session handshake with the client's lock held and keep the
+        /// token: the proof is an HMAC-SHA256 of the challenge under SessionKey.
+        /// </summary>
+        private async Task<byte[]> HandshakeAsync(CancellationToken cancellationToken)
+        {
+            byte[] key = SessionKey ?? throw new InvalidOperationException("SessionKey is not set");
+            byte[] startData = await CallAsync("\x11", new Pb.StartSessionRequest().ToByteArray(), cancellationToken).ConfigureAwait(false);
+            var challenge = Decode("start_session", startData, Pb.StartSessionResponse.Parser).Challenge;
+            using var hmac = new HMACSHA256(key);
+            var req = new Pb.AuthenticateSessionRequest { Proof = ByteString.CopyFrom(hmac.ComputeHash(challenge.ToByteArray())) };
+            byte[] authData = await CallAsync("\x12", req.ToByteArray(), cancellationToken).ConfigureAwait(false);
+            byte[] token = Decode("authenticate_session", authData, Pb.AuthenticateSessionResponse.Parser).Token.ToByteArray();
+            sessionToken = token;
+            return token;
+        }
+
+        /// <summary>
+        /// Send a session-protected request led by the session token, opening a
+        /// session first when there is none. A peripheral that lost the session,
+        /// e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent
+        /// once more in a new session. Call with the client's lock held.
+        /// </summary>
+        private async Task<byte[]> SessionCallAsync(string cmdName, byte[] requestData, CancellationToken cancellationToken)
+        {
+            byte[] token = sessionToken ?? await HandshakeAsync(cancellationToken).ConfigureAwait(false);
+            byte[] respData = await CallAsync(cmdName, WithToken(token, requestData), cancellationToken).ConfigureAwait(false);
+            if (!respData.AsSpan().SequenceEqual(Unauthenticated))
+            {
+                return respData;
+            }
+            token = await HandshakeAsync(cancellationToken).ConfigureAwait(false);
+            return await CallAsync(cmdName, WithToken(token, requestData), cancellationToken).ConfigureAwait(false);
+        }
+
+        private static byte[] WithToken(byte[] token, byte[] requestData)
+        {
+            var output = new byte[token.Length + requestData.Length];
+            token.CopyTo(output, 0);
+            requestData.CopyTo(output, token.Length);
+            return output;
+        }
+    }
+}
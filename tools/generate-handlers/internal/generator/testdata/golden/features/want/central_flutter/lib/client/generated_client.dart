@@ -0,0 +1,407 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import 'dart:convert';
+import 'dart:typed_data';
+
+import 'package:blerpc_central/proto/blerpc.pb.dart';
+import 'package:crypto/crypto.dart';
+
+int _lastReplayCounter = 0;
+
+/// Leads replay-protected requests with a counter, 8 bytes little endian,
+/// and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of
+/// the command name, a NUL, the counter and the request. The peripheral drops
+/// requests whose MAC is wrong or whose counter is not above the last one it
+/// accepted; microseconds since the epoch keep it increasing across restarts.
+Uint8List _withReplayCounter(
+    List<int>? key, String command, List<int> requestData) {
+  if (key == null) throw StateError('sessionKey is not set');
+  final now = DateTime.now().microsecondsSinceEpoch;
+  _lastReplayCounter = now > _lastReplayCounter ? now : _lastReplayCounter + 1;
+  final counter = (ByteData(8)..setUint64(0, _lastReplayCounter, Endian.little))
+      .buffer
+      .asUint8List();
+  final mac = Hmac(sha256, key)
+      .convert([...utf8.encode(command), 0, ...counter, ...requestData]).bytes;
+  return Uint8List.fromList([...counter, ...mac.sublist(0, 16), ...requestData]);
+}
+
+const _unauthenticated = [0xf8, 0xff, 0xff, 0xff, 0x0f, 11];
+
+bool _isUnauthenticated(List<int> data) {
+  if (data.length != _unauthenticated.length) return false;
+  for (var i = 0; i < data.length; i++) {
+    if (data[i] != _unauthenticated[i]) return false;
+  }
+  return true;
+}
+
+/// Auto-generated RPC method wrappers.
+mixin GeneratedClientMixin {
+  Future<Uint8List> call(String cmdName, Uint8List requestData);
+  Future<List<Uint8List>> streamReceive(String cmdName, Uint8List requestData);
+  Future<Uint8List> streamSend(
+      String cmdName, List<Uint8List> messages, String finalCmdName);
+
+  // The peripheral handles one RPC at a time, so calls are chained.
+  Future<void> _rpcTail = Future.value();
+
+  /// Runs [body] once every earlier RPC of this client has finished. The
+  /// generated methods call through here, and so should direct uses of
+  /// [call], [streamReceive] and [streamSend].
+  Future<T> exclusive<T>(Future<T> Function() body) {
+    final result = _rpcTail.then((_) => body());
+    _rpcTail = result.then((_) {}, onError: (_) {});
+    return result;
+  }
+
+  /// Key shared with the peripheral, which the session handshake proves
+  /// knowledge of. Set it before calling a session- or replay-protected
+  /// command.
+  List<int>? sessionKey;
+
+  List<int>? _sessionToken;
+
+  /// Authenticates to the peripheral, unlocking its session-protected
+  /// commands. Protected calls open a session as needed; call this to
+  /// authenticate up front.
+  Future<void> openSession() => exclusive(_handshake);
+
+  /// Runs the session handshake inside the caller's [exclusive] call and
+  /// keeps the token: the proof is an HMAC-SHA256 of the challenge under
+  /// [sessionKey].
+  Future<List<int>> _handshake() async {
+    final key = sessionKey;
+    if (key == null) throw StateError('sessionKey is not set');
+    final startData = await call(
+        'start_session', Uint8List.fromList(StartSessionRequest().writeToBuffer()));
+    final challenge = StartSessionResponse.fromBuffer(startData).challenge;
+    final req = AuthenticateSessionRequest()
+      ..proof = Hmac(sha256, key).convert(challenge).bytes;
+    final authData =
+        await call('authenticate_session', Uint8List.fromList(req.writeToBuffer()));
+    final token = AuthenticateSessionResponse.fromBuffer(authData).token;
+    _sessionToken = token;
+    return token;
+  }
+
+  /// Sends a session-protected request led by the session token, opening a
+  /// session first when there is none. A peripheral that lost the session,
+  /// e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent
+  /// once more in a new session.
+  Future<Uint8List> _sessionCall(String cmdName, List<int> requestData) async {
+    final token = _sessionToken ?? await _handshake();
+    final respData =
+        await call(cmdName, Uint8List.fromList([...token, ...requestData]));
+    if (!_isUnauthenticated(respData)) return respData;
+    final fresh = await _handshake();
+    return call(cmdName, Uint8List.fromList([...fresh, ...requestData]));
+  }
+
+  Future<EchoResponse> echo({String message = ''}) async {
+    final req = EchoRequest()..message = message;
+    final respData = await exclusive(
+        () => call('echo', Uint8List.fromList(req.writeToBuffer())));
+    return EchoResponse.fromBuffer(respData);
+  }
+
+  Future<FlashReadResponse> flashRead({int address = 0, int length = 0}) async {
+    final req = FlashReadRequest()
+      ..address = address
+      ..length = length;
+    final respData = await exclusive(
+        () => _sessionCall('flash_read',
+            _withReplayCounter(sessionKey, 'flash_read', req.writeToBuffer())));
+    return FlashReadResponse.fromBuffer(respData);
+  }
+
+  Future<DataWriteResponse> dataWrite({List<int> data = const <int>[]}) async {
+    final req = DataWriteRequest()..data = data;
+    final respData = await exclusive(
+        () => call('data_write', Uint8List.fromList(req.writeToBuffer())));
+    return DataWriteResponse.fromBuffer(respData);
+  }
+
+  Future<GetBlerpcInfoResponse> getBlerpcInfo() async {
+    final req = GetBlerpcInfoRequest();
+    final respData = await exclusive(
+        () => call('get_blerpc_info', Uint8List.fromList(req.writeToBuffer())));
+    return GetBlerpcInfoResponse.fromBuffer(respData);
+  }
+
+  Future<ConnParamsResponse> connParams({int profile = 0}) async {
+    final req = ConnParamsRequest()..profile = profile;
+    final respData = await exclusive(
+        () => call('conn_params', Uint8List.fromList(req.writeToBuffer())));
+    return ConnParamsResponse.fromBuffer(respData);
+  }
+
+  Future<DfuBeginResponse> dfuBegin({int size = 0, int crc32 = 0}) async {
+    final req = DfuBeginRequest()
+      ..size = size
+      ..crc32 = crc32;
+    final respData = await exclusive(
+        () => call('dfu_begin', Uint8List.fromList(req.writeToBuffer())));
+    return DfuBeginResponse.fromBuffer(respData);
+  }
+
+  Future<DfuChunkResponse> dfuChunk({int offset = 0, List<int> data = const <int>[]}) async {
+    final req = DfuChunkRequest()
+      ..offset = offset
+      ..data = data;
+    final respData = await exclusive(
+        () => call('dfu_chunk', Uint8List.fromList(req.writeToBuffer())));
+    return DfuChunkResponse.fromBuffer(respData);
+  }
+
+  Future<DfuFinalizeResponse> dfuFinalize({bool reboot = false}) async {
+    final req = DfuFinalizeRequest()..reboot = reboot;
+    final respData = await exclusive(
+        () => call('dfu_finalize', Uint8List.fromList(req.writeToBuffer())));
+    return DfuFinalizeResponse.fromBuffer(respData);
+  }
+
+  Future<FileOpenResponse> fileOpen({String path = '', bool write = false, bool resume = false}) async {
+    final req = FileOpenRequest()
+      ..path = path
+      ..write = write
+      ..resume = resume;
+    final respData = await exclusive(
+        () => call('file_open', Uint8List.fromList(req.writeToBuffer())));
+    return FileOpenResponse.fromBuffer(respData);
+  }
+
+  Future<FileReadResponse> fileRead({int handle = 0, int offset = 0, int length = 0}) async {
+    final req = FileReadRequest()
+      ..handle = handle
+      ..offset = offset
+      ..length = length;
+    final respData = await exclusive(
+        () => call('file_read', Uint8List.fromList(req.writeToBuffer())));
+    return FileReadResponse.fromBuffer(respData);
+  }
+
+  Future<FileWriteResponse> fileWrite({int handle = 0, int offset = 0, List<int> data = const <int>[]}) async {
+    final req = FileWriteRequest()
+      ..handle = handle
+      ..offset = offset
+      ..data = data;
+    final respData = await exclusive(
+        () => call('file_write', Uint8List.fromList(req.writeToBuffer())));
+    return FileWriteResponse.fromBuffer(respData);
+  }
+
+  Future<FileCloseResponse> fileClose({int handle = 0, bool verify = false}) async {
+    final req = FileCloseRequest()
+      ..handle = handle
+      ..verify = verify;
+    final respData = await exclusive(
+        () => call('file_close', Uint8List.fromList(req.writeToBuffer())));
+    return FileCloseResponse.fromBuffer(respData);
+  }
+
+  Future<GetRpcStatsResponse> getRpcStats({bool reset = false}) async {
+    final req = GetRpcStatsRequest()..reset = reset;
+    final respData = await exclusive(
+        () => call('get_rpc_stats', Uint8List.fromList(req.writeToBuffer())));
+    return GetRpcStatsResponse.fromBuffer(respData);
+  }
+
+  Future<StartSessionResponse> startSession() async {
+    final req = StartSessionRequest();
+    final respData = await exclusive(
+        () => call('start_session', Uint8List.fromList(req.writeToBuffer())));
+    return StartSessionResponse.fromBuffer(respData);
+  }
+
+  Future<AuthenticateSessionResponse> authenticateSession({List<int> proof = const <int>[]}) async {
+    final req = AuthenticateSessionRequest()..proof = proof;
+    final respData = await exclusive(
+        () => call('authenticate_session', Uint8List.fromList(req.writeToBuffer())));
+    return AuthenticateSessionResponse.fromBuffer(respData);
+  }
+
+  Future<TimeSyncResponse> timeSync({int unixTimeUs = 0, int offsetUs = 0}) async {
+    final req = TimeSyncRequest()
+      ..unixTimeUs = unixTimeUs
+      ..offsetUs = offsetUs;
+    final respData = await exclusive(
+        () => call('time_sync', Uint8List.fromList(req.writeToBuffer())));
+    return TimeSyncResponse.fromBuffer(respData);
+  }
+
+  Future<PingResponse> ping({List<int> payload = const <int>[]}) async {
+    final req = PingRequest()..payload = payload;
+    final respData = await exclusive(
+        () => call('ping', Uint8List.fromList(req.writeToBuffer())));
+    return PingResponse.fromBuffer(respData);
+  }
+
+  Future<GetCapabilitiesResponse> getCapabilities() async {
+    final req = GetCapabilitiesRequest();
+    final respData = await exclusive(
+        () => call('get_capabilities', Uint8List.fromList(req.writeToBuffer())));
+    return GetCapabilitiesResponse.fromBuffer(respData);
+  }
+
+  Future<GetSettingResponse> getSetting({int field = 0}) async {
+    final req = GetSettingRequest()..field = field;
+    final respData = await exclusive(
+        () => call('get_setting', Uint8List.fromList(req.writeToBuffer())));
+    return GetSettingResponse.fromBuffer(respData);
+  }
+
+  Future<SetSettingResponse> setSetting({int field = 0, List<int> value = const <int>[]}) async {
+    final req = SetSettingRequest()
+      ..field = field
+      ..value = value;
+    final respData = await exclusive(
+        () => call('set_setting', Uint8List.fromList(req.writeToBuffer())));
+    return SetSettingResponse.fromBuffer(respData);
+  }
+
+  Future<List<CounterStreamResponse>> counterStream({int count = 0}) async {
+    final req = CounterStreamRequest()..count = count;
+    final responses = await exclusive(() => streamReceive(
+        'counter_stream', Uint8List.fromList(req.writeToBuffer())));
+    return responses
+        .map((data) => CounterStreamResponse.fromBuffer(data))
+        .toList();
+  }
+
+  Future<CounterUploadResponse> counterUpload(
+      List<CounterUploadRequest> messages) async {
+    final raw =
+        messages.map((m) => Uint8List.fromList(m.writeToBuffer())).toList();
+    final respData = await exclusive(
+        () => streamSend('counter_upload', raw, 'counter_upload'));
+    return CounterUploadResponse.fromBuffer(respData);
+  }
+
+  Future<List<LogStreamResponse>> logStream({int minLevel = 0, int maxEntries = 0}) async {
+    final req = LogStreamRequest()
+      ..minLevel = minLevel
+      ..maxEntries = maxEntries;
+    final responses = await exclusive(() => streamReceive(
+        'log_stream', Uint8List.fromList(req.writeToBuffer())));
+    return responses
+        .map((data) => LogStreamResponse.fromBuffer(data))
+        .toList();
+  }
+
+  /// Checks that the peripheral was built from this client's schema. Call
+  /// once on connect; throws [SchemaMismatchError] when the schema hashes
+  /// differ.
+  Future<GetBlerpcInfoResponse> verifySchema() async {
+    final info = await getBlerpcInfo();
+    if (info.schemaHash != blerpcSchemaHash) {
+      throw SchemaMismatchError(
+          blerpcSchemaHash, info.schemaHash, info.generatorVersion);
+    }
+    return info;
+  }
+
+  /// Drains the firmware log into (timestamp, entry) pairs, oldest first. The
+  /// message of each entry joins the fragments of a long message, and the
+  /// timestamp is when it was logged.
+  Future<List<(DateTime, LogStreamResponse)>> readLogs({int minLevel = 0, int maxEntries = 0}) async {
+    final responses = await logStream(minLevel: minLevel, maxEntries: maxEntries);
+    final received = DateTime.now();
+    final logs = <(DateTime, LogStreamResponse)>[];
+    LogStreamResponse? first;
+    for (final resp in responses) {
+      if (first == null) {
+        first = resp;
+      } else {
+        first.message += resp.message;
+      }
+      if (resp.more) continue;
+      final ageMs = (first.nowMs - first.uptimeMs) & 0xFFFFFFFF;
+      logs.add((received.subtract(Duration(milliseconds: ageMs)), first));
+      first = null;
+    }
+    return logs;
+  }
+
+  /// Pings the peripheral and returns the round trip.
+  Future<Duration> measureLatency() async {
+    final roundTrip = Stopwatch()..start();
+    await ping();
+    return roundTrip.elapsed;
+  }
+
+  /// Pings the peripheral every [interval], passing each round trip to
+  /// [onLatency], until [isActive] returns false. It throws as soon as a ping
+  /// fails, so a dead connection shows before a long call times out.
+  Future<void> keepalive({
+    Duration interval = const Duration(seconds: 5),
+    void Function(Duration latency)? onLatency,
+    bool Function()? isActive,
+  }) async {
+    while (isActive?.call() ?? true) {
+      final latency = await measureLatency();
+      onLatency?.call(latency);
+      await Future<void>.delayed(interval);
+    }
+  }
+
+  /// Reads the sample_interval_ms setting.
+  Future<int> getSampleIntervalMsSetting() async {
+    final resp = await getSetting(field: 1);
+    return DeviceSettings.fromBuffer(resp.value).sampleIntervalMs;
+  }
+
+  /// Reads the leds_enabled setting.
+  Future<bool> getLedsEnabledSetting() async {
+    final resp = await getSetting(field: 2);
+    return DeviceSettings.fromBuffer(resp.value).ledsEnabled;
+  }
+
+  /// Writes the sample_interval_ms setting.
+  Future<void> setSampleIntervalMsSetting(int value) async {
+    final settings = DeviceSettings()..sampleIntervalMs = value;
+    await setSetting(field: 1, value: settings.writeToBuffer());
+  }
+
+  /// Writes the leds_enabled setting.
+  Future<void> setLedsEnabledSetting(bool value) async {
+    final settings = DeviceSettings()..ledsEnabled = value;
+    await setSetting(field: 2, value: settings.writeToBuffer());
+  }
+
+  /// Sets the peripheral's wall clock to this device's. With [compensate], a
+  /// first exchange measures the round trip and the second adds half of it,
+  /// the estimated delivery delay.
+  Future<TimeSyncResponse> syncTime({bool compensate = false}) async {
+    var offsetUs = 0;
+    if (compensate) {
+      final roundTrip = Stopwatch()..start();
+      await timeSync(unixTimeUs: DateTime.now().microsecondsSinceEpoch);
+      offsetUs = roundTrip.elapsedMicroseconds ~/ 2;
+    }
+    return timeSync(unixTimeUs: DateTime.now().microsecondsSinceEpoch, offsetUs: offsetUs);
+  }
+}
+
+/// Role each command requires on the peripheral, keyed by wire name; others need 'user'.
+const commandRoles = <String, String>{
+  'flash_read': 'factory',
+};
+
+/// The schema this client was generated from, which verifySchema compares
+/// with the peripheral's.
+const blerpcSchemaHash = 'fb27bddde9c1f6b7';
+const blerpcGeneratorVersion = '0.1.0';
+
+/// The peripheral was built from a different schema than this client.
+class SchemaMismatchError implements Exception {
+  final String expected;
+  final String actual;
+  final String generatorVersion;
+
+  SchemaMismatchError(this.expected, this.actual, this.generatorVersion);
+
+  @override
+  String toString() =>
+      'SchemaMismatchError: peripheral schema $actual (generator $generatorVersion) '
+      "does not match this client's $expected (generator $blerpcGeneratorVersion)";
+}
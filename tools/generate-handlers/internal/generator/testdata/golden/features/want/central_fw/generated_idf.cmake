@@ -0,0 +1,16 @@
+# Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT
+#
+# Generated C sources for an ESP-IDF component. Include it from the
+# component CMakeLists.txt before registering the component:
+#
+#   include(path/to/generated_idf.cmake)
+#   idf_component_register(SRCS main.c ${BLERPC_CLIENT_SRCS}
+#                          INCLUDE_DIRS . ${BLERPC_CLIENT_INCLUDE_DIRS})
+
+set(BLERPC_CLIENT_SRCS
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_client.c
+)
+
+set(BLERPC_CLIENT_INCLUDE_DIRS
+    ${CMAKE_CURRENT_LIST_DIR}/src
+)
@@ -0,0 +1,541 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_client.h"
+
+/* Encode context for FT_CALLBACK bytes fields */
+struct _blerpc_bytes_encode_ctx {
+    const uint8_t *data;
+    size_t data_len;
+};
+
+static bool _blerpc_encode_bytes_cb(pb_ostream_t *stream,
+                                     const pb_field_t *field,
+                                     void *const *arg)
+{
+    const struct _blerpc_bytes_encode_ctx *ctx =
+        *(const struct _blerpc_bytes_encode_ctx **)arg;
+    if (!pb_encode_tag_for_field(stream, field)) return false;
+    if (!pb_encode_varint(stream, ctx->data_len)) return false;
+    return pb_write(stream, ctx->data, ctx->data_len);
+}
+
+int blerpc_echo(const char *message, blerpc_EchoResponse *resp)
+{
+    blerpc_EchoRequest req = blerpc_EchoRequest_init_zero;
+    strncpy(req.message, message, sizeof(req.message) - 1);
+
+    uint8_t req_buf[blerpc_EchoRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_EchoRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_EchoResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x01", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_EchoResponse)blerpc_EchoResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_EchoResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_data_write(const uint8_t *data, size_t data_len, uint8_t *work_buf, size_t work_buf_size, blerpc_DataWriteResponse *resp)
+{
+    struct _blerpc_bytes_encode_ctx _data_ctx = {
+        .data = data, .data_len = data_len
+    };
+    blerpc_DataWriteRequest req = blerpc_DataWriteRequest_init_zero;
+    req.data.funcs.encode = _blerpc_encode_bytes_cb;
+    req.data.arg = &_data_ctx;
+
+    pb_ostream_t sizing = PB_OSTREAM_SIZING;
+    if (!pb_encode(&sizing, blerpc_DataWriteRequest_fields, &req)) return -1;
+    if (sizing.bytes_written > work_buf_size) return -1;
+
+    pb_ostream_t ostream = pb_ostream_from_buffer(work_buf, work_buf_size);
+    if (!pb_encode(&ostream, blerpc_DataWriteRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_DataWriteResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x03", work_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_DataWriteResponse)blerpc_DataWriteResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_DataWriteResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+struct _blerpc_counter_stream_ctx {
+    blerpc_CounterStreamResponse *results;
+    size_t max_results;
+    size_t count;
+};
+
+static int _blerpc_counter_stream_on_resp(const uint8_t *data, size_t len,
+                                            void *ctx)
+{
+    struct _blerpc_counter_stream_ctx *c = (struct _blerpc_counter_stream_ctx *)ctx;
+    if (c->count >= c->max_results) return -1;
+    c->results[c->count] = (blerpc_CounterStreamResponse)blerpc_CounterStreamResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(data, len);
+    if (!pb_decode(&istream, blerpc_CounterStreamResponse_fields, &c->results[c->count])) return -1;
+    c->count++;
+    return 0;
+}
+
+int blerpc_counter_stream(uint32_t count, blerpc_CounterStreamResponse *results, size_t max_results, size_t *result_count)
+{
+    blerpc_CounterStreamRequest req = blerpc_CounterStreamRequest_init_zero;
+    req.count = count;
+
+    uint8_t req_buf[blerpc_CounterStreamRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_CounterStreamRequest_fields, &req)) return -1;
+
+    struct _blerpc_counter_stream_ctx ctx = {
+        .results = results, .max_results = max_results, .count = 0
+    };
+    if (blerpc_stream_receive("\x04", req_buf, ostream.bytes_written,
+                              _blerpc_counter_stream_on_resp, &ctx) != 0) return -1;
+
+    *result_count = ctx.count;
+    return 0;
+}
+
+struct _blerpc_counter_upload_ctx {
+    const blerpc_CounterUploadRequest *messages;
+};
+
+static int _blerpc_counter_upload_next(size_t index, uint8_t *buf,
+                                         size_t buf_size, size_t *len, void *ctx)
+{
+    struct _blerpc_counter_upload_ctx *c = (struct _blerpc_counter_upload_ctx *)ctx;
+    pb_ostream_t ostream = pb_ostream_from_buffer(buf, buf_size);
+    if (!pb_encode(&ostream, blerpc_CounterUploadRequest_fields, &c->messages[index])) return -1;
+    *len = ostream.bytes_written;
+    return 0;
+}
+
+int blerpc_counter_upload(const blerpc_CounterUploadRequest *messages, size_t msg_count, blerpc_CounterUploadResponse *resp)
+{
+    struct _blerpc_counter_upload_ctx ctx = { .messages = messages };
+
+    uint8_t resp_buf[blerpc_CounterUploadResponse_size];
+    size_t resp_len;
+    if (blerpc_stream_send("\x05", msg_count,
+                           _blerpc_counter_upload_next, &ctx,
+                           "\x05", resp_buf, sizeof(resp_buf),
+                           &resp_len) != 0) return -1;
+
+    *resp = (blerpc_CounterUploadResponse)blerpc_CounterUploadResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_CounterUploadResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_get_blerpc_info(blerpc_GetBlerpcInfoResponse *resp)
+{
+    blerpc_GetBlerpcInfoRequest req = blerpc_GetBlerpcInfoRequest_init_zero;
+
+    uint8_t req_buf[blerpc_GetBlerpcInfoRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_GetBlerpcInfoRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_GetBlerpcInfoResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x06", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_GetBlerpcInfoResponse)blerpc_GetBlerpcInfoResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_GetBlerpcInfoResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_conn_params(int32_t profile, blerpc_ConnParamsResponse *resp)
+{
+    blerpc_ConnParamsRequest req = blerpc_ConnParamsRequest_init_zero;
+    req.profile = profile;
+
+    uint8_t req_buf[blerpc_ConnParamsRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_ConnParamsRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_ConnParamsResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x07", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_ConnParamsResponse)blerpc_ConnParamsResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_ConnParamsResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_dfu_begin(uint32_t size, uint32_t crc32, blerpc_DfuBeginResponse *resp)
+{
+    blerpc_DfuBeginRequest req = blerpc_DfuBeginRequest_init_zero;
+    req.size = size;
+    req.crc32 = crc32;
+
+    uint8_t req_buf[blerpc_DfuBeginRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_DfuBeginRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_DfuBeginResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x08", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_DfuBeginResponse)blerpc_DfuBeginResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_DfuBeginResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_dfu_chunk(uint32_t offset, const uint8_t *data, blerpc_DfuChunkResponse *resp)
+{
+    blerpc_DfuChunkRequest req = blerpc_DfuChunkRequest_init_zero;
+    req.offset = offset;
+    req.data = data;
+
+    uint8_t req_buf[blerpc_DfuChunkRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_DfuChunkRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_DfuChunkResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x09", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_DfuChunkResponse)blerpc_DfuChunkResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_DfuChunkResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_dfu_finalize(bool reboot, blerpc_DfuFinalizeResponse *resp)
+{
+    blerpc_DfuFinalizeRequest req = blerpc_DfuFinalizeRequest_init_zero;
+    req.reboot = reboot;
+
+    uint8_t req_buf[blerpc_DfuFinalizeRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_DfuFinalizeRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_DfuFinalizeResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x0a", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_DfuFinalizeResponse)blerpc_DfuFinalizeResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_DfuFinalizeResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_file_open(const char *path, bool write, bool resume, blerpc_FileOpenResponse *resp)
+{
+    blerpc_FileOpenRequest req = blerpc_FileOpenRequest_init_zero;
+    strncpy(req.path, path, sizeof(req.path) - 1);
+    req.write = write;
+    req.resume = resume;
+
+    uint8_t req_buf[blerpc_FileOpenRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_FileOpenRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_FileOpenResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x0b", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_FileOpenResponse)blerpc_FileOpenResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_FileOpenResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_file_read(uint32_t handle, uint32_t offset, uint32_t length, blerpc_FileReadResponse *resp)
+{
+    blerpc_FileReadRequest req = blerpc_FileReadRequest_init_zero;
+    req.handle = handle;
+    req.offset = offset;
+    req.length = length;
+
+    uint8_t req_buf[blerpc_FileReadRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_FileReadRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_FileReadResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x0c", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_FileReadResponse)blerpc_FileReadResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_FileReadResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_file_write(uint32_t handle, uint32_t offset, const uint8_t *data, blerpc_FileWriteResponse *resp)
+{
+    blerpc_FileWriteRequest req = blerpc_FileWriteRequest_init_zero;
+    req.handle = handle;
+    req.offset = offset;
+    req.data = data;
+
+    uint8_t req_buf[blerpc_FileWriteRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_FileWriteRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_FileWriteResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x0d", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_FileWriteResponse)blerpc_FileWriteResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_FileWriteResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_file_close(uint32_t handle, bool verify, blerpc_FileCloseResponse *resp)
+{
+    blerpc_FileCloseRequest req = blerpc_FileCloseRequest_init_zero;
+    req.handle = handle;
+    req.verify = verify;
+
+    uint8_t req_buf[blerpc_FileCloseRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_FileCloseRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_FileCloseResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x0e", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_FileCloseResponse)blerpc_FileCloseResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_FileCloseResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+struct _blerpc_log_stream_ctx {
+    blerpc_LogStreamResponse *results;
+    size_t max_results;
+    size_t count;
+};
+
+static int _blerpc_log_stream_on_resp(const uint8_t *data, size_t len,
+                                        void *ctx)
+{
+    struct _blerpc_log_stream_ctx *c = (struct _blerpc_log_stream_ctx *)ctx;
+    if (c->count >= c->max_results) return -1;
+    c->results[c->count] = (blerpc_LogStreamResponse)blerpc_LogStreamResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(data, len);
+    if (!pb_decode(&istream, blerpc_LogStreamResponse_fields, &c->results[c->count])) return -1;
+    c->count++;
+    return 0;
+}
+
+int blerpc_log_stream(int32_t min_level, uint32_t max_entries, blerpc_LogStreamResponse *results, size_t max_results, size_t *result_count)
+{
+    blerpc_LogStreamRequest req = blerpc_LogStreamRequest_init_zero;
+    req.min_level = min_level;
+    req.max_entries = max_entries;
+
+    uint8_t req_buf[blerpc_LogStreamRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_LogStreamRequest_fields, &req)) return -1;
+
+    struct _blerpc_log_stream_ctx ctx = {
+        .results = results, .max_results = max_results, .count = 0
+    };
+    if (blerpc_stream_receive("\x0f", req_buf, ostream.bytes_written,
+                              _blerpc_log_stream_on_resp, &ctx) != 0) return -1;
+
+    *result_count = ctx.count;
+    return 0;
+}
+
+int blerpc_get_rpc_stats(bool reset, blerpc_GetRpcStatsResponse *resp)
+{
+    blerpc_GetRpcStatsRequest req = blerpc_GetRpcStatsRequest_init_zero;
+    req.reset = reset;
+
+    uint8_t req_buf[blerpc_GetRpcStatsRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_GetRpcStatsRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_GetRpcStatsResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x10", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_GetRpcStatsResponse)blerpc_GetRpcStatsResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_GetRpcStatsResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_start_session(blerpc_StartSessionResponse *resp)
+{
+    blerpc_StartSessionRequest req = blerpc_StartSessionRequest_init_zero;
+
+    uint8_t req_buf[blerpc_StartSessionRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_StartSessionRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_StartSessionResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x11", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_StartSessionResponse)blerpc_StartSessionResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_StartSessionResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_authenticate_session(const uint8_t *proof, blerpc_AuthenticateSessionResponse *resp)
+{
+    blerpc_AuthenticateSessionRequest req = blerpc_AuthenticateSessionRequest_init_zero;
+    req.proof = proof;
+
+    uint8_t req_buf[blerpc_AuthenticateSessionRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_AuthenticateSessionRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_AuthenticateSessionResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x12", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_AuthenticateSessionResponse)blerpc_AuthenticateSessionResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_AuthenticateSessionResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_time_sync(int64_t unix_time_us, uint32_t offset_us, blerpc_TimeSyncResponse *resp)
+{
+    blerpc_TimeSyncRequest req = blerpc_TimeSyncRequest_init_zero;
+    req.unix_time_us = unix_time_us;
+    req.offset_us = offset_us;
+
+    uint8_t req_buf[blerpc_TimeSyncRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_TimeSyncRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_TimeSyncResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x13", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_TimeSyncResponse)blerpc_TimeSyncResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_TimeSyncResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_ping(const uint8_t *payload, blerpc_PingResponse *resp)
+{
+    blerpc_PingRequest req = blerpc_PingRequest_init_zero;
+    req.payload = payload;
+
+    uint8_t req_buf[blerpc_PingRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_PingRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_PingResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x14", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_PingResponse)blerpc_PingResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_PingResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_get_capabilities(blerpc_GetCapabilitiesResponse *resp)
+{
+    blerpc_GetCapabilitiesRequest req = blerpc_GetCapabilitiesRequest_init_zero;
+
+    uint8_t req_buf[blerpc_GetCapabilitiesRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_GetCapabilitiesRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_GetCapabilitiesResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x15", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_GetCapabilitiesResponse)blerpc_GetCapabilitiesResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_GetCapabilitiesResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_get_setting(uint32_t field, blerpc_GetSettingResponse *resp)
+{
+    blerpc_GetSettingRequest req = blerpc_GetSettingRequest_init_zero;
+    req.field = field;
+
+    uint8_t req_buf[blerpc_GetSettingRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_GetSettingRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_GetSettingResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x16", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_GetSettingResponse)blerpc_GetSettingResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_GetSettingResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_set_setting(uint32_t field, const uint8_t *value, blerpc_SetSettingResponse *resp)
+{
+    blerpc_SetSettingRequest req = blerpc_SetSettingRequest_init_zero;
+    req.field = field;
+    req.value = value;
+
+    uint8_t req_buf[blerpc_SetSettingRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_SetSettingRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_SetSettingResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("\x17", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_SetSettingResponse)blerpc_SetSettingResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_SetSettingResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
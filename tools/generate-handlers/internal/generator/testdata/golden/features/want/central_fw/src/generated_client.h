@@ -0,0 +1,94 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_CLIENT_H
+#define BLERPC_GENERATED_CLIENT_H
+
+#include "blerpc.pb.h"
+#include <pb_encode.h>
+#include <pb_decode.h>
+#include <stdint.h>
+#include <stddef.h>
+#include <stdbool.h>
+#include <string.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Callback for P2C streaming response payloads */
+typedef int (*blerpc_on_stream_resp_t)(const uint8_t *data, size_t len, void *ctx);
+
+/* Callback for C2P streaming message serialization */
+typedef int (*blerpc_next_msg_t)(size_t index, uint8_t *buf, size_t buf_size,
+                                 size_t *len, void *ctx);
+
+/* User-provided RPC transport functions */
+extern int blerpc_rpc_call(const char *cmd_name,
+                           const uint8_t *req_data, size_t req_len,
+                           uint8_t *resp_data, size_t resp_size, size_t *resp_len);
+
+extern int blerpc_stream_receive(const char *cmd_name,
+                                 const uint8_t *req_data, size_t req_len,
+                                 blerpc_on_stream_resp_t on_resp, void *ctx);
+
+extern int blerpc_stream_send(const char *cmd_name, size_t msg_count,
+                              blerpc_next_msg_t next_msg, void *msg_ctx,
+                              const char *final_cmd_name,
+                              uint8_t *resp_data, size_t resp_size, size_t *resp_len);
+
+/* Numeric command IDs. Sent as a one-byte command name, an ID dispatches
+ * like the full name. */
+enum blerpc_command_id {
+    BLERPC_CMD_ID_ECHO = 1,
+    BLERPC_CMD_ID_DATA_WRITE = 3,
+    BLERPC_CMD_ID_COUNTER_STREAM = 4,
+    BLERPC_CMD_ID_COUNTER_UPLOAD = 5,
+    BLERPC_CMD_ID_GET_BLERPC_INFO = 6,
+    BLERPC_CMD_ID_CONN_PARAMS = 7,
+    BLERPC_CMD_ID_DFU_BEGIN = 8,
+    BLERPC_CMD_ID_DFU_CHUNK = 9,
+    BLERPC_CMD_ID_DFU_FINALIZE = 10,
+    BLERPC_CMD_ID_FILE_OPEN = 11,
+    BLERPC_CMD_ID_FILE_READ = 12,
+    BLERPC_CMD_ID_FILE_WRITE = 13,
+    BLERPC_CMD_ID_FILE_CLOSE = 14,
+    BLERPC_CMD_ID_LOG_STREAM = 15,
+    BLERPC_CMD_ID_GET_RPC_STATS = 16,
+    BLERPC_CMD_ID_START_SESSION = 17,
+    BLERPC_CMD_ID_AUTHENTICATE_SESSION = 18,
+    BLERPC_CMD_ID_TIME_SYNC = 19,
+    BLERPC_CMD_ID_PING = 20,
+    BLERPC_CMD_ID_GET_CAPABILITIES = 21,
+    BLERPC_CMD_ID_GET_SETTING = 22,
+    BLERPC_CMD_ID_SET_SETTING = 23,
+};
+
+/* Generated typed RPC functions */
+int blerpc_echo(const char *message, blerpc_EchoResponse *resp);
+/* Deprecated. */
+int blerpc_data_write(const uint8_t *data, size_t data_len, uint8_t *work_buf, size_t work_buf_size, blerpc_DataWriteResponse *resp);
+int blerpc_counter_stream(uint32_t count, blerpc_CounterStreamResponse *results, size_t max_results, size_t *result_count);
+int blerpc_counter_upload(const blerpc_CounterUploadRequest *messages, size_t msg_count, blerpc_CounterUploadResponse *resp);
+int blerpc_get_blerpc_info(blerpc_GetBlerpcInfoResponse *resp);
+int blerpc_conn_params(int32_t profile, blerpc_ConnParamsResponse *resp);
+int blerpc_dfu_begin(uint32_t size, uint32_t crc32, blerpc_DfuBeginResponse *resp);
+int blerpc_dfu_chunk(uint32_t offset, const uint8_t *data, blerpc_DfuChunkResponse *resp);
+int blerpc_dfu_finalize(bool reboot, blerpc_DfuFinalizeResponse *resp);
+int blerpc_file_open(const char *path, bool write, bool resume, blerpc_FileOpenResponse *resp);
+int blerpc_file_read(uint32_t handle, uint32_t offset, uint32_t length, blerpc_FileReadResponse *resp);
+int blerpc_file_write(uint32_t handle, uint32_t offset, const uint8_t *data, blerpc_FileWriteResponse *resp);
+int blerpc_file_close(uint32_t handle, bool verify, blerpc_FileCloseResponse *resp);
+int blerpc_log_stream(int32_t min_level, uint32_t max_entries, blerpc_LogStreamResponse *results, size_t max_results, size_t *result_count);
+int blerpc_get_rpc_stats(bool reset, blerpc_GetRpcStatsResponse *resp);
+int blerpc_start_session(blerpc_StartSessionResponse *resp);
+int blerpc_authenticate_session(const uint8_t *proof, blerpc_AuthenticateSessionResponse *resp);
+int blerpc_time_sync(int64_t unix_time_us, uint32_t offset_us, blerpc_TimeSyncResponse *resp);
+int blerpc_ping(const uint8_t *payload, blerpc_PingResponse *resp);
+int blerpc_get_capabilities(blerpc_GetCapabilitiesResponse *resp);
+int blerpc_get_setting(uint32_t field, blerpc_GetSettingResponse *resp);
+int blerpc_set_setting(uint32_t field, const uint8_t *value, blerpc_SetSettingResponse *resp);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_CLIENT_H */
@@ -0,0 +1,13 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_UUIDS_H
+#define BLERPC_GENERATED_UUIDS_H
+
+/* blerpc Service UUID: 6e400001-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_SERVICE_UUID BT_UUID_128_ENCODE(0x6e400001, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+#define BLERPC_SERVICE_UUID_STR "6e400001-b5a3-f393-e0a9-e50e24dcca9e"
+
+/* blerpc Characteristic UUID: 6e400002-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_CHAR_UUID BT_UUID_128_ENCODE(0x6e400002, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+#define BLERPC_CHAR_UUID_STR "6e400002-b5a3-f393-e0a9-e50e24dcca9e"
+
+#endif /* BLERPC_GENERATED_UUIDS_H */
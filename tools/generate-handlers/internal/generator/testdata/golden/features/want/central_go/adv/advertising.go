@@ -0,0 +1,38 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+// Package blerpcadv decodes blerpc advertisements from scan results.
+package blerpcadv
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/tdaira/blerpc/central_go/proto"
+)
+
+// CompanyID is the Bluetooth SIG company identifier of the manufacturer data.
+const CompanyID = 0xffff
+
+// Advertisement types.
+const (
+	TypeSensorState = 1
+)
+
+// Parse decodes manufacturer data without the company identifier, as
+// scanners report it per company. It returns false when the data holds no
+// valid advertisement of this schema.
+func Parse(data []byte) (proto.Message, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+	var msg proto.Message
+	switch data[0] {
+	case TypeSensorState:
+		msg = &pb.SensorState{}
+	default:
+		return nil, false
+	}
+	if err := proto.Unmarshal(data[1:], msg); err != nil {
+		return nil, false
+	}
+	return msg, true
+}
@@ -0,0 +1,283 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+// Command bench measures the round-trip latency and throughput of the
+// commands for each ATT MTU against the simulator:
+//
+//	go run ./bench -mtu 23,247 -commands echo -interval 7.5ms
+//
+// Each command is called -n times per MTU with a sample request and the
+// p50, p90, p99 and maximum latency of the calls are printed, with the
+// calls and the request and response data moved per second.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tdaira/blerpc/go/wire"
+	sim "github.com/tdaira/blerpc/peripheral_go/sim"
+)
+
+// commands lists the commands with their wire name and sample request.
+var commands = []benchCommand{
+	{"echo", "\x01", wire.Unary, []byte("\n\amessage")},
+	{"flash_read", "\x02", wire.Unary, []byte("\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\b\x01\x10\x01")},
+	{"data_write", "\x03", wire.Unary, []byte("\n\x04\x01\x02\x03\x04")},
+	{"counter_stream", "\x04", wire.StreamP2C, []byte("\b\x01")},
+	{"counter_upload", "\x05", wire.StreamC2P, []byte("\b\x01\x10\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01")},
+	{"get_blerpc_info", "\x06", wire.Unary, nil},
+	{"conn_params", "\x07", wire.Unary, []byte("\b\x01")},
+	{"dfu_begin", "\x08", wire.Unary, []byte("\b\x01\x10\x01")},
+	{"dfu_chunk", "\x09", wire.Unary, []byte("\b\x01\x12\x04\x01\x02\x03\x04")},
+	{"dfu_finalize", "\x0a", wire.Unary, []byte("\b\x01")},
+	{"file_open", "\x0b", wire.Unary, []byte("\n\x04path\x10\x01\x18\x01")},
+	{"file_read", "\x0c", wire.Unary, []byte("\b\x01\x10\x01\x18\x01")},
+	{"file_write", "\x0d", wire.Unary, []byte("\b\x01\x10\x01\x1a\x04\x01\x02\x03\x04")},
+	{"file_close", "\x0e", wire.Unary, []byte("\b\x01\x10\x01")},
+	{"log_stream", "\x0f", wire.StreamP2C, []byte("\b\x01\x10\x01")},
+	{"get_rpc_stats", "\x10", wire.Unary, []byte("\b\x01")},
+	{"start_session", "\x11", wire.Unary, nil},
+	{"authenticate_session", "\x12", wire.Unary, []byte("\n\x04\x01\x02\x03\x04")},
+	{"time_sync", "\x13", wire.Unary, []byte("\b\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01\x10\x01")},
+	{"ping", "\x14", wire.Unary, []byte("\n\x04\x01\x02\x03\x04")},
+	{"get_capabilities", "\x15", wire.Unary, nil},
+	{"get_setting", "\x16", wire.Unary, []byte("\b\x01")},
+	{"set_setting", "\x17", wire.Unary, []byte("\b\x01\x12\x04\x01\x02\x03\x04")},
+}
+
+// benchCommand is a command the benchmark can call, with the sample
+// request it sends.
+type benchCommand struct {
+	name    string
+	wire    string
+	stream  wire.StreamKind
+	request []byte
+}
+
+func main() {
+	addr := flag.String("addr", "", "simulator socket to call, tcp host:port or unix:path (default: an in-process simulator per MTU)")
+	mtus := flag.String("mtu", "23,185,247", "comma-separated ATT MTUs to measure")
+	names := flag.String("commands", "", "comma-separated commands to call (default: all)")
+	n := flag.Int("n", 200, "calls per command and MTU")
+	warmup := flag.Int("warmup", 10, "calls per command and MTU before measuring")
+	interval := flag.Duration("interval", 0, "delay per container sent or received, emulating BLE connection events (e.g. 7.5ms)")
+	flag.Parse()
+
+	selected, err := selectCommands(*names)
+	if err != nil {
+		fail(err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "MTU\tCOMMAND\tCALLS\tERRORS\tP50\tP90\tP99\tMAX\tCALLS/S\tKB/S\t")
+	for _, field := range strings.Split(*mtus, ",") {
+		mtu, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || mtu < 23 {
+			fail(fmt.Errorf("invalid MTU %q", field))
+		}
+		conn, stop, err := dial(*addr, mtu)
+		if err != nil {
+			fail(err)
+		}
+		t := &transport{conn: conn, splitter: wire.NewSplitter(mtu), assembler: wire.NewAssembler(), interval: *interval}
+		for _, cmd := range selected {
+			for range *warmup {
+				t.roundTrip(cmd)
+			}
+			r := measure(t, cmd, *n)
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%.1f\t%.1f\t\n", mtu, cmd.name, r.calls, r.errors,
+				r.percentile(50), r.percentile(90), r.percentile(99), r.percentile(100), r.callsPerSecond(), r.bytesPerSecond()/1000)
+		}
+		stop()
+	}
+	w.Flush()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "bench:", err)
+	os.Exit(1)
+}
+
+// selectCommands returns the commands of the comma-separated names, or
+// all of them for "".
+func selectCommands(names string) ([]benchCommand, error) {
+	if names == "" {
+		return commands, nil
+	}
+	var out []benchCommand
+	for _, name := range strings.Split(names, ",") {
+		i := slices.IndexFunc(commands, func(c benchCommand) bool { return c.name == strings.TrimSpace(name) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown command %q", name)
+		}
+		out = append(out, commands[i])
+	}
+	return out, nil
+}
+
+// dial connects to the simulator at addr or, for "", to a new in-process
+// simulator splitting its responses for mtu. stop closes the connection
+// and stops the in-process simulator.
+func dial(addr string, mtu int) (net.Conn, func(), error) {
+	if addr != "" {
+		network := "tcp"
+		if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+			network, addr = "unix", path
+		}
+		conn, err := net.Dial(network, addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, func() { conn.Close() }, nil
+	}
+	s := sim.New(sim.DefaultHandler{})
+	s.MTU = mtu
+	central, peripheral := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.ServeConn(ctx, peripheral)
+	}()
+	return central, func() {
+		central.Close()
+		cancel()
+		<-done
+	}, nil
+}
+
+// transport calls commands over a simulator socket, framed as a central
+// does for its ATT MTU.
+type transport struct {
+	conn      net.Conn
+	splitter  *wire.Splitter
+	assembler *wire.Assembler
+	interval  time.Duration
+}
+
+func (t *transport) write(ct *wire.Container) error {
+	time.Sleep(t.interval)
+	return sim.WritePacket(t.conn, ct)
+}
+
+func (t *transport) send(cmd benchCommand) (int, error) {
+	packet, err := (&wire.Command{Type: wire.CommandRequest, Name: cmd.wire, Data: cmd.request}).MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	cts, err := t.splitter.Split(packet)
+	if err != nil {
+		return 0, err
+	}
+	for i := range cts {
+		if err := t.write(&cts[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(cmd.request), nil
+}
+
+// receive reads responses until one completes or, for a stream to the
+// central, until it ends, and returns the bytes of response data.
+func (t *transport) receive(stream wire.StreamKind) (int, error) {
+	n := 0
+	for {
+		data, err := sim.ReadPacket(t.conn)
+		if err != nil {
+			return n, err
+		}
+		time.Sleep(t.interval)
+		ct, err := wire.ParseContainer(data)
+		if err != nil {
+			return n, err
+		}
+		switch {
+		case ct.Type == wire.TypeControl && ct.ControlCmd == wire.ControlStreamEndP2C:
+			return n, nil
+		case ct.Type == wire.TypeControl && ct.ControlCmd == wire.ControlError:
+			return n, fmt.Errorf("error response 0x%02x", ct.Payload)
+		case ct.Type == wire.TypeControl:
+			continue
+		}
+		payload, ok := t.assembler.Feed(ct)
+		if !ok {
+			continue
+		}
+		resp, err := wire.ParseCommand(payload)
+		if err != nil {
+			return n, err
+		}
+		n += len(resp.Data)
+		if stream != wire.StreamP2C {
+			return n, nil
+		}
+	}
+}
+
+// roundTrip calls cmd once and returns the bytes of request and response
+// data it moved.
+func (t *transport) roundTrip(cmd benchCommand) (int, error) {
+	sent, err := t.send(cmd)
+	if err != nil {
+		return 0, err
+	}
+	if cmd.stream == wire.StreamC2P {
+		if err := t.write(wire.StreamEndC2P(t.splitter.NextTransactionID())); err != nil {
+			return sent, err
+		}
+	}
+	received, err := t.receive(cmd.stream)
+	return sent + received, err
+}
+
+// result holds the measured calls of a command.
+type result struct {
+	calls     int
+	errors    int
+	bytes     int
+	elapsed   time.Duration
+	latencies []time.Duration // sorted
+}
+
+func measure(t *transport, cmd benchCommand, n int) result {
+	var r result
+	start := time.Now()
+	for range n {
+		callStart := time.Now()
+		bytes, err := t.roundTrip(cmd)
+		r.calls++
+		if err != nil {
+			r.errors++
+			continue
+		}
+		r.bytes += bytes
+		r.latencies = append(r.latencies, time.Since(callStart))
+	}
+	r.elapsed = time.Since(start)
+	slices.Sort(r.latencies)
+	return r
+}
+
+// percentile returns the p-th percentile latency, by the nearest rank.
+func (r result) percentile(p int) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	rank := (p*len(r.latencies) + 99) / 100
+	return r.latencies[max(rank, 1)-1].Round(time.Microsecond)
+}
+
+func (r result) callsPerSecond() float64 {
+	return float64(len(r.latencies)) / r.elapsed.Seconds()
+}
+
+func (r result) bytesPerSecond() float64 {
+	return float64(r.bytes) / r.elapsed.Seconds()
+}
@@ -0,0 +1,352 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+package blerpcclient
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// captureCommands maps the names the methods send commands by, where they
+// are not their own, to the commands.
+var captureCommands = map[string]string{
+	"\x01": "echo",
+	"\x02": "flash_read",
+	"\x03": "data_write",
+	"\x04": "counter_stream",
+	"\x05": "counter_upload",
+	"\x06": "get_blerpc_info",
+	"\x07": "conn_params",
+	"\x08": "dfu_begin",
+	"\x09": "dfu_chunk",
+	"\x0a": "dfu_finalize",
+	"\x0b": "file_open",
+	"\x0c": "file_read",
+	"\x0d": "file_write",
+	"\x0e": "file_close",
+	"\x0f": "log_stream",
+	"\x10": "get_rpc_stats",
+	"\x11": "start_session",
+	"\x12": "authenticate_session",
+	"\x13": "time_sync",
+	"\x14": "ping",
+	"\x15": "get_capabilities",
+	"\x16": "get_setting",
+	"\x17": "set_setting",
+}
+
+// unreplayable holds the commands whose requests lead with a session
+// token or replay counter, which the peripheral accepts once.
+var unreplayable = map[string]bool{
+	"flash_read": true,
+}
+
+// redactedMessages holds the sensitive fields stripped from the recorded
+// payloads: by message, the numbers of the fields to drop, mapped to "",
+// and of the message fields holding some, mapped to their message.
+var redactedMessages = map[string]map[uint64]string{
+	"FlashReadResponse": {2: ""},
+}
+
+// redactedCommands holds the commands with sensitive fields.
+var redactedCommands = map[string]redactedCall{
+	"flash_read": {prefix: 32, request: "", response: "FlashReadResponse"},
+}
+
+// CaptureEntry is a call of a capture file, one JSON object per line.
+// Payloads are the raw request and response data, in hex.
+type CaptureEntry struct {
+	Time       time.Time  `json:"time"`
+	DurationUS int64      `json:"duration_us"`
+	Command    string     `json:"command"`
+	CmdName    string     `json:"cmd_name"`
+	Stream     string     `json:"stream"` // "", "p2c" or "c2p"
+	Requests   []HexBytes `json:"requests"`
+	Responses  []HexBytes `json:"responses"`
+	Error      string     `json:"error,omitempty"`
+	// Redacted marks an entry whose payloads lost their sensitive fields.
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// HexBytes is a payload of a capture entry, marshaled as hex.
+type HexBytes []byte
+
+func (h HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h)), nil
+}
+
+func (h *HexBytes) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	*h = b
+	return err
+}
+
+// redactedCall is how the recorder strips the payloads of a command with
+// sensitive fields.
+type redactedCall struct {
+	prefix   int    // bytes leading each request, kept as they are
+	request  string // message of redactedMessages the requests are, or ""
+	response string // likewise for the responses
+	opaque   bool   // payloads are not recorded at all
+}
+
+// StripSensitive returns data, an encoded message of redactedMessages,
+// without its sensitive fields.
+func StripSensitive(data []byte, message string) ([]byte, error) {
+	plan := redactedMessages[message]
+	var out []byte
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("truncated varint")
+		}
+		size, body := 0, n
+		switch key & 7 {
+		case 0:
+			_, m := binary.Uvarint(data[n:])
+			if m <= 0 {
+				return nil, errors.New("truncated varint")
+			}
+			size = m
+		case 1:
+			size = 8
+		case 2:
+			l, m := binary.Uvarint(data[n:])
+			if m <= 0 || l > uint64(len(data)) {
+				return nil, errors.New("truncated field")
+			}
+			body += m
+			size = m + int(l)
+		case 5:
+			size = 4
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", key&7)
+		}
+		if n+size > len(data) {
+			return nil, errors.New("truncated field")
+		}
+		field := data[:n+size]
+		data = data[n+size:]
+		inner, ok := plan[key>>3]
+		switch {
+		case !ok:
+			out = append(out, field...)
+		case inner != "" && key&7 == 2:
+			stripped, err := StripSensitive(field[body:], inner)
+			if err != nil {
+				return nil, err
+			}
+			out = binary.AppendUvarint(out, key)
+			out = binary.AppendUvarint(out, uint64(len(stripped)))
+			out = append(out, stripped...)
+		}
+	}
+	return out, nil
+}
+
+// redact strips the sensitive fields of message from payloads, keeping the
+// prefix bytes leading each; undecodable payloads are dropped whole.
+func redact(payloads [][]byte, prefix int, message string) [][]byte {
+	if message == "" {
+		return payloads
+	}
+	out := make([][]byte, len(payloads))
+	for i, p := range payloads {
+		if len(p) < prefix {
+			continue
+		}
+		if stripped, err := StripSensitive(p[prefix:], message); err == nil {
+			out[i] = append(slices.Clip(p[:prefix]), stripped...)
+		}
+	}
+	return out
+}
+
+// Recorder is a Transport that records every call it forwards to Transport
+// as a line of a capture file, stripping the sensitive fields of
+// redactedCommands from the payloads. Pass it to New to record the calls of
+// a client. It is safe for concurrent use.
+type Recorder struct {
+	Transport Transport
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder returns a recorder forwarding calls to t and writing their
+// entries to w.
+func NewRecorder(t Transport, w io.Writer) *Recorder {
+	return &Recorder{Transport: t, enc: json.NewEncoder(w)}
+}
+
+func (r *Recorder) record(cmdName, stream string, start time.Time, reqs, resps [][]byte, err error) {
+	command := cmp.Or(captureCommands[cmdName], cmdName)
+	rc, redacted := redactedCommands[command]
+	switch {
+	case rc.opaque:
+		reqs, resps = nil, nil
+	case redacted:
+		reqs, resps = redact(reqs, rc.prefix, rc.request), redact(resps, 0, rc.response)
+	}
+	e := CaptureEntry{
+		Time:       start.UTC(),
+		DurationUS: time.Since(start).Microseconds(),
+		Command:    command,
+		CmdName:    cmdName,
+		Stream:     stream,
+		Requests:   hexAll(reqs),
+		Responses:  hexAll(resps),
+		Redacted:   redacted,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enc.Encode(e)
+}
+
+func hexAll(payloads [][]byte) []HexBytes {
+	out := make([]HexBytes, len(payloads))
+	for i, p := range payloads {
+		out[i] = p
+	}
+	return out
+}
+
+func (r *Recorder) Call(ctx context.Context, cmdName string, requestData []byte) ([]byte, error) {
+	start := time.Now()
+	resp, err := r.Transport.Call(ctx, cmdName, requestData)
+	var resps [][]byte
+	if err == nil {
+		resps = [][]byte{resp}
+	}
+	r.record(cmdName, "", start, [][]byte{requestData}, resps, err)
+	return resp, err
+}
+
+func (r *Recorder) StreamReceive(ctx context.Context, cmdName string, requestData []byte) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		start := time.Now()
+		var resps [][]byte
+		var streamErr error
+		defer func() { r.record(cmdName, "p2c", start, [][]byte{requestData}, resps, streamErr) }()
+		for data, err := range r.Transport.StreamReceive(ctx, cmdName, requestData) {
+			if err != nil {
+				streamErr = err
+			} else {
+				resps = append(resps, data)
+			}
+			if !yield(data, err) {
+				return
+			}
+		}
+	}
+}
+
+func (r *Recorder) StreamSend(ctx context.Context, cmdName string, messages iter.Seq[[]byte], finalCmdName string) ([]byte, error) {
+	start := time.Now()
+	var reqs [][]byte
+	tee := func(yield func([]byte) bool) {
+		for data := range messages {
+			reqs = append(reqs, data)
+			if !yield(data) {
+				return
+			}
+		}
+	}
+	resp, err := r.Transport.StreamSend(ctx, cmdName, tee, finalCmdName)
+	var resps [][]byte
+	if err == nil {
+		resps = [][]byte{resp}
+	}
+	r.record(cmdName, "c2p", start, reqs, resps, err)
+	return resp, err
+}
+
+// ReadCapture returns the entries of the capture file read from r.
+func ReadCapture(r io.Reader) ([]CaptureEntry, error) {
+	var entries []CaptureEntry
+	dec := json.NewDecoder(r)
+	for {
+		var e CaptureEntry
+		if err := dec.Decode(&e); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return entries, fmt.Errorf("capture entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+// Replay sends the requests of every entry through t again, e.g. a
+// transport to a new firmware release or to the simulator, and returns a
+// line per entry whose responses differ byte for byte from the captured
+// ones, or that fails where the capture succeeded or the other way round.
+// Entries of session- and replay-protected commands are skipped: their
+// requests lead with a token or counter that is only valid once. So are
+// entries whose requests lost sensitive fields; responses are compared
+// without theirs.
+func Replay(ctx context.Context, t Transport, entries []CaptureEntry) []string {
+	var mismatches []string
+	for i, e := range entries {
+		rc := redactedCommands[e.Command]
+		if unreplayable[e.Command] || rc.opaque || rc.request != "" {
+			continue
+		}
+		name := fmt.Sprintf("entry %d (%s)", i+1, e.Command)
+		if len(e.Requests) == 0 && e.Stream != "c2p" {
+			mismatches = append(mismatches, name+": no request")
+			continue
+		}
+		var resps [][]byte
+		var err error
+		switch e.Stream {
+		case "p2c":
+			for data, streamErr := range t.StreamReceive(ctx, e.CmdName, e.Requests[0]) {
+				if err = streamErr; err != nil {
+					break
+				}
+				resps = append(resps, data)
+			}
+		case "c2p":
+			messages := func(yield func([]byte) bool) {
+				for _, req := range e.Requests {
+					if !yield(req) {
+						return
+					}
+				}
+			}
+			var resp []byte
+			if resp, err = t.StreamSend(ctx, e.CmdName, messages, e.CmdName); err == nil {
+				resps = append(resps, resp)
+			}
+		default:
+			var resp []byte
+			if resp, err = t.Call(ctx, e.CmdName, e.Requests[0]); err == nil {
+				resps = append(resps, resp)
+			}
+		}
+		resps = redact(resps, 0, rc.response)
+		switch {
+		case err != nil && e.Error == "":
+			mismatches = append(mismatches, fmt.Sprintf("%s: failed: %v", name, err))
+		case err == nil && e.Error != "":
+			mismatches = append(mismatches, fmt.Sprintf("%s: succeeded, captured %s", name, e.Error))
+		case err == nil && !slices.EqualFunc(resps, e.Responses, func(a []byte, b HexBytes) bool { return bytes.Equal(a, b) }):
+			mismatches = append(mismatches, fmt.Sprintf("%s: responses %x, captured %x", name, resps, e.Responses))
+		}
+	}
+	return mismatches
+}
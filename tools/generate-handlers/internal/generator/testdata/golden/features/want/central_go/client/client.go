@@ -0,0 +1,697 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+// Package blerpcclient is a typed client of the blerpc commands.
+package blerpcclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/tdaira/blerpc/central_go/proto"
+)
+
+// Transport carries raw command payloads to the peripheral, e.g. over a
+// BLE link framed with the wire package. Errors it returns are wrapped in
+// TransportError, or TimeoutError for context.DeadlineExceeded, unless they
+// match ErrBlerpc already.
+type Transport interface {
+	Caller
+	// StreamReceive sends a peripheral-to-central stream request and yields
+	// each response until the stream ends, an error is yielded or the
+	// consumer stops.
+	StreamReceive(ctx context.Context, cmdName string, requestData []byte) iter.Seq2[[]byte, error]
+	// StreamSend sends each request of a central-to-peripheral stream as it
+	// is produced, ends the stream with finalCmdName and returns the
+	// response.
+	StreamSend(ctx context.Context, cmdName string, messages iter.Seq[[]byte], finalCmdName string) ([]byte, error)
+}
+
+// Status codes of error responses, found in RemoteError.Status. Codes
+// from 128 up are application defined.
+const (
+	StatusOk                 = 0  // Success; never sent in an error response.
+	StatusInvalidArgument    = 1  // The request is malformed or a field is out of bounds.
+	StatusNotFound           = 2  // The requested entity does not exist.
+	StatusAlreadyExists      = 3  // The entity to create exists already.
+	StatusPermissionDenied   = 4  // The caller may not run the command.
+	StatusResourceExhausted  = 5  // Memory, storage or another resource ran out.
+	StatusFailedPrecondition = 6  // The device is not in a state to run the command.
+	StatusOutOfRange         = 7  // An offset or value lies past the valid range.
+	StatusUnimplemented      = 8  // The command is not supported by this firmware.
+	StatusInternal           = 9  // The firmware hit an unexpected error.
+	StatusUnavailable        = 10 // The device cannot run the command now; retry later.
+	StatusUnauthenticated    = 11 // The command needs an authenticated session.
+)
+
+// Client calls the commands over a Transport, one at a time: the
+// peripheral runs a single command at once. It is safe for concurrent use.
+type Client struct {
+	Transport Transport
+	// SessionKey is the key shared with the peripheral, which the session
+	// handshake proves knowledge of. Set it before calling a session- or
+	// replay-protected command.
+	SessionKey []byte
+
+	mu           sync.Mutex
+	lastReplay   uint64
+	sessionToken []byte
+}
+
+// New returns a client calling the commands over t.
+func New(t Transport) *Client {
+	return &Client{Transport: t}
+}
+
+// Call sends a raw request under the client's lock, so helpers taking a
+// Caller, such as FileTransfer, do not interleave with typed calls.
+func (c *Client) Call(ctx context.Context, cmdName string, requestData []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Transport.Call(ctx, cmdName, requestData)
+}
+
+// transportError wraps an error returned by the transport.
+func transportError(cmd string, err error) error {
+	switch {
+	case errors.Is(err, ErrBlerpc):
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		return &TimeoutError{Command: cmd}
+	}
+	return &TransportError{Command: cmd, Err: err}
+}
+
+// decode checks data for an error response, which carries only a status in
+// field 536870911, and otherwise unmarshals it into resp.
+func decode(cmd string, data []byte, resp proto.Message) error {
+	if num, typ, n := protowire.ConsumeTag(data); n > 0 && num == 536870911 && typ == protowire.VarintType {
+		status, m := protowire.ConsumeVarint(data[n:])
+		if m < 0 {
+			return &DecodeError{Command: cmd, Err: protowire.ParseError(m)}
+		}
+		return &RemoteError{Command: cmd, Status: int(status)}
+	}
+	if err := proto.Unmarshal(data, resp); err != nil {
+		return &DecodeError{Command: cmd, Err: err}
+	}
+	return nil
+}
+
+// withReplayCounter leads the request of a replay-protected command with a
+// counter, 8 bytes little endian, and a MAC: the first 16 bytes of an
+// HMAC-SHA256 under c.SessionKey of the command name, a NUL, the counter and
+// the request. The peripheral drops requests whose MAC is wrong or whose
+// counter is not above the last one it accepted; microseconds since the epoch
+// keep it increasing across restarts. Call it under c.mu so requests go out
+// in counter order.
+func (c *Client) withReplayCounter(cmdName string, reqData []byte) ([]byte, error) {
+	if c.SessionKey == nil {
+		return nil, ErrNoSessionKey
+	}
+	c.lastReplay = max(c.lastReplay+1, uint64(time.Now().UnixMicro()))
+	counter := binary.LittleEndian.AppendUint64(nil, c.lastReplay)
+	mac := hmac.New(sha256.New, c.SessionKey)
+	mac.Write(slices.Concat([]byte(cmdName), []byte{0}, counter, reqData))
+	return slices.Concat(counter, mac.Sum(nil)[:16], reqData), nil
+}
+
+// ErrNoSessionKey is returned for a session- or replay-protected call on a
+// client without a SessionKey.
+var ErrNoSessionKey = fmt.Errorf("%w: SessionKey is not set", ErrBlerpc)
+
+// unauthenticated is the error response of a peripheral that has no
+// session with the token a request led with.
+var unauthenticated = protowire.AppendVarint(protowire.AppendTag(nil, 536870911, protowire.VarintType), StatusUnauthenticated)
+
+// OpenSession authenticates to the peripheral, unlocking its
+// session-protected commands. Protected calls open a session as needed;
+// call it to authenticate up front.
+func (c *Client) OpenSession(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.handshake(ctx)
+	return err
+}
+
+// handshake runs the session handshake under c.mu and keeps the token:
+// the proof is an HMAC-SHA256 of the challenge under c.SessionKey.
+func (c *Client) handshake(ctx context.Context) ([]byte, error) {
+	if c.SessionKey == nil {
+		return nil, ErrNoSessionKey
+	}
+	startData, err := c.Transport.Call(ctx, "\x11", nil)
+	if err != nil {
+		return nil, transportError("start_session", err)
+	}
+	challenge := &pb.StartSessionResponse{}
+	if err := decode("start_session", startData, challenge); err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, c.SessionKey)
+	mac.Write(challenge.GetChallenge())
+	reqData, err := proto.Marshal(&pb.AuthenticateSessionRequest{Proof: mac.Sum(nil)})
+	if err != nil {
+		return nil, err
+	}
+	authData, err := c.Transport.Call(ctx, "\x12", reqData)
+	if err != nil {
+		return nil, transportError("authenticate_session", err)
+	}
+	session := &pb.AuthenticateSessionResponse{}
+	if err := decode("authenticate_session", authData, session); err != nil {
+		return nil, err
+	}
+	c.sessionToken = session.GetToken()
+	return c.sessionToken, nil
+}
+
+// sessionCall sends a session-protected request led by the session token,
+// opening a session first when there is none. A peripheral that lost the
+// session, e.g. on a reboot, answers UNAUTHENTICATED; the request is then
+// sent once more in a new session. Call it under c.mu.
+func (c *Client) sessionCall(ctx context.Context, cmdName string, requestData []byte) ([]byte, error) {
+	token := c.sessionToken
+	if token == nil {
+		var err error
+		if token, err = c.handshake(ctx); err != nil {
+			return nil, err
+		}
+	}
+	respData, err := c.Transport.Call(ctx, cmdName, slices.Concat(token, requestData))
+	if err != nil || !bytes.Equal(respData, unauthenticated) {
+		return respData, err
+	}
+	if token, err = c.handshake(ctx); err != nil {
+		return nil, err
+	}
+	return c.Transport.Call(ctx, cmdName, slices.Concat(token, requestData))
+}
+
+// Echo calls the echo command.
+func (c *Client) Echo(ctx context.Context, req *pb.EchoRequest) (*pb.EchoResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x01", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("echo", err)
+	}
+	resp := &pb.EchoResponse{}
+	if err := decode("echo", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FlashRead calls the flash_read command.
+func (c *Client) FlashRead(ctx context.Context, req *pb.FlashReadRequest) (*pb.FlashReadResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	reqData, err = c.withReplayCounter("flash_read", reqData)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	respData, err := c.sessionCall(ctx, "\x02", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("flash_read", err)
+	}
+	resp := &pb.FlashReadResponse{}
+	if err := decode("flash_read", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DataWrite calls the data_write command.
+func (c *Client) DataWrite(ctx context.Context, req *pb.DataWriteRequest) (*pb.DataWriteResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x03", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("data_write", err)
+	}
+	resp := &pb.DataWriteResponse{}
+	if err := decode("data_write", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CounterStreamSeq calls the counter_stream stream and yields each response as it arrives.
+// The client stays locked until the stream ends or the loop stops.
+func (c *Client) CounterStreamSeq(ctx context.Context, req *pb.CounterStreamRequest) iter.Seq2[*pb.CounterStreamResponse, error] {
+	return func(yield func(*pb.CounterStreamResponse, error) bool) {
+		reqData, err := proto.Marshal(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for data, err := range c.Transport.StreamReceive(ctx, "\x04", reqData) {
+			if err != nil {
+				yield(nil, transportError("counter_stream", err))
+				return
+			}
+			resp := &pb.CounterStreamResponse{}
+			if err := decode("counter_stream", data, resp); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+	}
+}
+
+// CounterStream calls the counter_stream stream and returns all responses.
+func (c *Client) CounterStream(ctx context.Context, req *pb.CounterStreamRequest) ([]*pb.CounterStreamResponse, error) {
+	var out []*pb.CounterStreamResponse
+	for resp, err := range c.CounterStreamSeq(ctx, req) {
+		if err != nil {
+			return out, err
+		}
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// CounterUpload sends each request of the counter_upload stream as reqs produces it and
+// returns the response; pass slices.Values(list) to send a slice.
+func (c *Client) CounterUpload(ctx context.Context, reqs iter.Seq[*pb.CounterUploadRequest]) (*pb.CounterUploadResponse, error) {
+	var marshalErr error
+	messages := func(yield func([]byte) bool) {
+		for req := range reqs {
+			data, err := proto.Marshal(req)
+			if err != nil {
+				marshalErr = err
+				return
+			}
+			if !yield(data) {
+				return
+			}
+		}
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.StreamSend(ctx, "\x05", messages, "\x05")
+	c.mu.Unlock()
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+	if err != nil {
+		return nil, transportError("counter_upload", err)
+	}
+	resp := &pb.CounterUploadResponse{}
+	if err := decode("counter_upload", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetBlerpcInfo calls the get_blerpc_info command.
+func (c *Client) GetBlerpcInfo(ctx context.Context, req *pb.GetBlerpcInfoRequest) (*pb.GetBlerpcInfoResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x06", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("get_blerpc_info", err)
+	}
+	resp := &pb.GetBlerpcInfoResponse{}
+	if err := decode("get_blerpc_info", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ConnParams calls the conn_params command.
+func (c *Client) ConnParams(ctx context.Context, req *pb.ConnParamsRequest) (*pb.ConnParamsResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x07", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("conn_params", err)
+	}
+	resp := &pb.ConnParamsResponse{}
+	if err := decode("conn_params", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DfuBegin calls the dfu_begin command.
+func (c *Client) DfuBegin(ctx context.Context, req *pb.DfuBeginRequest) (*pb.DfuBeginResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x08", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("dfu_begin", err)
+	}
+	resp := &pb.DfuBeginResponse{}
+	if err := decode("dfu_begin", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DfuChunk calls the dfu_chunk command.
+func (c *Client) DfuChunk(ctx context.Context, req *pb.DfuChunkRequest) (*pb.DfuChunkResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x09", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("dfu_chunk", err)
+	}
+	resp := &pb.DfuChunkResponse{}
+	if err := decode("dfu_chunk", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DfuFinalize calls the dfu_finalize command.
+func (c *Client) DfuFinalize(ctx context.Context, req *pb.DfuFinalizeRequest) (*pb.DfuFinalizeResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x0a", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("dfu_finalize", err)
+	}
+	resp := &pb.DfuFinalizeResponse{}
+	if err := decode("dfu_finalize", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FileOpen calls the file_open command.
+func (c *Client) FileOpen(ctx context.Context, req *pb.FileOpenRequest) (*pb.FileOpenResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x0b", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("file_open", err)
+	}
+	resp := &pb.FileOpenResponse{}
+	if err := decode("file_open", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FileRead calls the file_read command.
+func (c *Client) FileRead(ctx context.Context, req *pb.FileReadRequest) (*pb.FileReadResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x0c", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("file_read", err)
+	}
+	resp := &pb.FileReadResponse{}
+	if err := decode("file_read", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FileWrite calls the file_write command.
+func (c *Client) FileWrite(ctx context.Context, req *pb.FileWriteRequest) (*pb.FileWriteResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x0d", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("file_write", err)
+	}
+	resp := &pb.FileWriteResponse{}
+	if err := decode("file_write", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FileClose calls the file_close command.
+func (c *Client) FileClose(ctx context.Context, req *pb.FileCloseRequest) (*pb.FileCloseResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x0e", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("file_close", err)
+	}
+	resp := &pb.FileCloseResponse{}
+	if err := decode("file_close", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// LogStreamSeq calls the log_stream stream and yields each response as it arrives.
+// The client stays locked until the stream ends or the loop stops.
+func (c *Client) LogStreamSeq(ctx context.Context, req *pb.LogStreamRequest) iter.Seq2[*pb.LogStreamResponse, error] {
+	return func(yield func(*pb.LogStreamResponse, error) bool) {
+		reqData, err := proto.Marshal(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for data, err := range c.Transport.StreamReceive(ctx, "\x0f", reqData) {
+			if err != nil {
+				yield(nil, transportError("log_stream", err))
+				return
+			}
+			resp := &pb.LogStreamResponse{}
+			if err := decode("log_stream", data, resp); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+	}
+}
+
+// LogStream calls the log_stream stream and returns all responses.
+func (c *Client) LogStream(ctx context.Context, req *pb.LogStreamRequest) ([]*pb.LogStreamResponse, error) {
+	var out []*pb.LogStreamResponse
+	for resp, err := range c.LogStreamSeq(ctx, req) {
+		if err != nil {
+			return out, err
+		}
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// GetRpcStats calls the get_rpc_stats command.
+func (c *Client) GetRpcStats(ctx context.Context, req *pb.GetRpcStatsRequest) (*pb.GetRpcStatsResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x10", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("get_rpc_stats", err)
+	}
+	resp := &pb.GetRpcStatsResponse{}
+	if err := decode("get_rpc_stats", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StartSession calls the start_session command.
+func (c *Client) StartSession(ctx context.Context, req *pb.StartSessionRequest) (*pb.StartSessionResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x11", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("start_session", err)
+	}
+	resp := &pb.StartSessionResponse{}
+	if err := decode("start_session", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AuthenticateSession calls the authenticate_session command.
+func (c *Client) AuthenticateSession(ctx context.Context, req *pb.AuthenticateSessionRequest) (*pb.AuthenticateSessionResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x12", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("authenticate_session", err)
+	}
+	resp := &pb.AuthenticateSessionResponse{}
+	if err := decode("authenticate_session", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// TimeSync calls the time_sync command.
+func (c *Client) TimeSync(ctx context.Context, req *pb.TimeSyncRequest) (*pb.TimeSyncResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x13", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("time_sync", err)
+	}
+	resp := &pb.TimeSyncResponse{}
+	if err := decode("time_sync", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Ping calls the ping command.
+func (c *Client) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x14", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("ping", err)
+	}
+	resp := &pb.PingResponse{}
+	if err := decode("ping", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetCapabilities calls the get_capabilities command.
+func (c *Client) GetCapabilities(ctx context.Context, req *pb.GetCapabilitiesRequest) (*pb.GetCapabilitiesResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x15", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("get_capabilities", err)
+	}
+	resp := &pb.GetCapabilitiesResponse{}
+	if err := decode("get_capabilities", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetSetting calls the get_setting command.
+func (c *Client) GetSetting(ctx context.Context, req *pb.GetSettingRequest) (*pb.GetSettingResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x16", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("get_setting", err)
+	}
+	resp := &pb.GetSettingResponse{}
+	if err := decode("get_setting", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetSetting calls the set_setting command.
+func (c *Client) SetSetting(ctx context.Context, req *pb.SetSettingRequest) (*pb.SetSettingResponse, error) {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	respData, err := c.Transport.Call(ctx, "\x17", reqData)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, transportError("set_setting", err)
+	}
+	resp := &pb.SetSettingResponse{}
+	if err := decode("set_setting", respData, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
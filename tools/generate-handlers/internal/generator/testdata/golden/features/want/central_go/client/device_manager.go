@@ -0,0 +1,333 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+package blerpcclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"sync"
+
+	pb "github.com/tdaira/blerpc/central_go/proto"
+)
+
+// Result is the outcome of a call on one device.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// DeviceManager holds clients of several peripherals, keyed by address.
+// Every device gets a client of its own, so calls to different devices run
+// concurrently while each client still runs one RPC at a time.
+type DeviceManager struct {
+	// Dial connects to address and returns its transport. A transport
+	// implementing io.Closer is closed on Disconnect.
+	Dial func(ctx context.Context, address string) (Transport, error)
+
+	mu         sync.Mutex
+	clients    map[string]*Client
+	order      []string
+	connecting map[string]*dialing
+}
+
+type dialing struct {
+	done   chan struct{}
+	client *Client
+	err    error
+}
+
+// Connect returns the client of address, dialing it first if it is not
+// managed yet. Concurrent calls for one address share a single dial.
+func (m *DeviceManager) Connect(ctx context.Context, address string) (*Client, error) {
+	m.mu.Lock()
+	if c, ok := m.clients[address]; ok {
+		m.mu.Unlock()
+		return c, nil
+	}
+	d, ok := m.connecting[address]
+	if !ok {
+		d = &dialing{done: make(chan struct{})}
+		if m.connecting == nil {
+			m.connecting = make(map[string]*dialing)
+		}
+		m.connecting[address] = d
+		go m.dial(d, address)
+	}
+	m.mu.Unlock()
+	select {
+	case <-d.done:
+		return d.client, d.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dial runs without the caller's context, so a caller giving up does not
+// fail the dial other callers wait for.
+func (m *DeviceManager) dial(d *dialing, address string) {
+	t, err := m.Dial(context.Background(), address)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.connecting, address)
+	if err != nil {
+		d.err = &TransportError{Command: "connect", Err: err}
+	} else {
+		d.client = New(t)
+		if m.clients == nil {
+			m.clients = make(map[string]*Client)
+		}
+		m.clients[address] = d.client
+		m.order = append(m.order, address)
+	}
+	close(d.done)
+}
+
+// ConnectAll connects to every address concurrently.
+func (m *DeviceManager) ConnectAll(ctx context.Context, addresses ...string) map[string]Result[*Client] {
+	return broadcast(ctx, addresses, func(ctx context.Context, address string) (*Client, error) {
+		return m.Connect(ctx, address)
+	})
+}
+
+// Client returns the client of address, if it is managed.
+func (m *DeviceManager) Client(address string) (*Client, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.clients[address]
+	return c, ok
+}
+
+// Addresses returns the managed addresses in connection order.
+func (m *DeviceManager) Addresses() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.order...)
+}
+
+// Disconnect stops managing address and closes its transport.
+func (m *DeviceManager) Disconnect(address string) error {
+	m.mu.Lock()
+	c, ok := m.clients[address]
+	delete(m.clients, address)
+	for i, a := range m.order {
+		if a == address {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	if closer, ok := c.Transport.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// DisconnectAll disconnects every managed device.
+func (m *DeviceManager) DisconnectAll() error {
+	var errs []error
+	for _, address := range m.Addresses() {
+		errs = append(errs, m.Disconnect(address))
+	}
+	return errors.Join(errs...)
+}
+
+// Broadcast calls fn with the client of every managed device concurrently,
+// or of the given addresses. A device whose call failed maps to its error,
+// so one bad device does not hide the results of the others.
+func Broadcast[T any](ctx context.Context, m *DeviceManager, fn func(context.Context, *Client) (T, error), addresses ...string) map[string]Result[T] {
+	if len(addresses) == 0 {
+		addresses = m.Addresses()
+	}
+	return broadcast(ctx, addresses, func(ctx context.Context, address string) (T, error) {
+		c, ok := m.Client(address)
+		if !ok {
+			var zero T
+			return zero, &TransportError{Command: "broadcast", Err: errors.New(address + " is not connected")}
+		}
+		return fn(ctx, c)
+	})
+}
+
+func broadcast[T any](ctx context.Context, addresses []string, fn func(context.Context, string) (T, error)) map[string]Result[T] {
+	results := make([]Result[T], len(addresses))
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].Value, results[i].Err = fn(ctx, address)
+		}()
+	}
+	wg.Wait()
+	out := make(map[string]Result[T], len(addresses))
+	for i, address := range addresses {
+		out[address] = results[i]
+	}
+	return out
+}
+
+// EchoAll calls Echo on every managed device, or on the given addresses.
+func (m *DeviceManager) EchoAll(ctx context.Context, req *pb.EchoRequest, addresses ...string) map[string]Result[*pb.EchoResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.EchoResponse, error) {
+		return c.Echo(ctx, req)
+	}, addresses...)
+}
+
+// FlashReadAll calls FlashRead on every managed device, or on the given addresses.
+func (m *DeviceManager) FlashReadAll(ctx context.Context, req *pb.FlashReadRequest, addresses ...string) map[string]Result[*pb.FlashReadResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.FlashReadResponse, error) {
+		return c.FlashRead(ctx, req)
+	}, addresses...)
+}
+
+// DataWriteAll calls DataWrite on every managed device, or on the given addresses.
+func (m *DeviceManager) DataWriteAll(ctx context.Context, req *pb.DataWriteRequest, addresses ...string) map[string]Result[*pb.DataWriteResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.DataWriteResponse, error) {
+		return c.DataWrite(ctx, req)
+	}, addresses...)
+}
+
+// CounterStreamAll calls CounterStream on every managed device, or on the given addresses.
+func (m *DeviceManager) CounterStreamAll(ctx context.Context, req *pb.CounterStreamRequest, addresses ...string) map[string]Result[[]*pb.CounterStreamResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) ([]*pb.CounterStreamResponse, error) {
+		return c.CounterStream(ctx, req)
+	}, addresses...)
+}
+
+// CounterUploadAll calls CounterUpload on every managed device, or on the given addresses.
+func (m *DeviceManager) CounterUploadAll(ctx context.Context, reqs iter.Seq[*pb.CounterUploadRequest], addresses ...string) map[string]Result[*pb.CounterUploadResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.CounterUploadResponse, error) {
+		return c.CounterUpload(ctx, reqs)
+	}, addresses...)
+}
+
+// GetBlerpcInfoAll calls GetBlerpcInfo on every managed device, or on the given addresses.
+func (m *DeviceManager) GetBlerpcInfoAll(ctx context.Context, req *pb.GetBlerpcInfoRequest, addresses ...string) map[string]Result[*pb.GetBlerpcInfoResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.GetBlerpcInfoResponse, error) {
+		return c.GetBlerpcInfo(ctx, req)
+	}, addresses...)
+}
+
+// ConnParamsAll calls ConnParams on every managed device, or on the given addresses.
+func (m *DeviceManager) ConnParamsAll(ctx context.Context, req *pb.ConnParamsRequest, addresses ...string) map[string]Result[*pb.ConnParamsResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.ConnParamsResponse, error) {
+		return c.ConnParams(ctx, req)
+	}, addresses...)
+}
+
+// DfuBeginAll calls DfuBegin on every managed device, or on the given addresses.
+func (m *DeviceManager) DfuBeginAll(ctx context.Context, req *pb.DfuBeginRequest, addresses ...string) map[string]Result[*pb.DfuBeginResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.DfuBeginResponse, error) {
+		return c.DfuBegin(ctx, req)
+	}, addresses...)
+}
+
+// DfuChunkAll calls DfuChunk on every managed device, or on the given addresses.
+func (m *DeviceManager) DfuChunkAll(ctx context.Context, req *pb.DfuChunkRequest, addresses ...string) map[string]Result[*pb.DfuChunkResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.DfuChunkResponse, error) {
+		return c.DfuChunk(ctx, req)
+	}, addresses...)
+}
+
+// DfuFinalizeAll calls DfuFinalize on every managed device, or on the given addresses.
+func (m *DeviceManager) DfuFinalizeAll(ctx context.Context, req *pb.DfuFinalizeRequest, addresses ...string) map[string]Result[*pb.DfuFinalizeResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.DfuFinalizeResponse, error) {
+		return c.DfuFinalize(ctx, req)
+	}, addresses...)
+}
+
+// FileOpenAll calls FileOpen on every managed device, or on the given addresses.
+func (m *DeviceManager) FileOpenAll(ctx context.Context, req *pb.FileOpenRequest, addresses ...string) map[string]Result[*pb.FileOpenResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.FileOpenResponse, error) {
+		return c.FileOpen(ctx, req)
+	}, addresses...)
+}
+
+// FileReadAll calls FileRead on every managed device, or on the given addresses.
+func (m *DeviceManager) FileReadAll(ctx context.Context, req *pb.FileReadRequest, addresses ...string) map[string]Result[*pb.FileReadResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.FileReadResponse, error) {
+		return c.FileRead(ctx, req)
+	}, addresses...)
+}
+
+// FileWriteAll calls FileWrite on every managed device, or on the given addresses.
+func (m *DeviceManager) FileWriteAll(ctx context.Context, req *pb.FileWriteRequest, addresses ...string) map[string]Result[*pb.FileWriteResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.FileWriteResponse, error) {
+		return c.FileWrite(ctx, req)
+	}, addresses...)
+}
+
+// FileCloseAll calls FileClose on every managed device, or on the given addresses.
+func (m *DeviceManager) FileCloseAll(ctx context.Context, req *pb.FileCloseRequest, addresses ...string) map[string]Result[*pb.FileCloseResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.FileCloseResponse, error) {
+		return c.FileClose(ctx, req)
+	}, addresses...)
+}
+
+// LogStreamAll calls LogStream on every managed device, or on the given addresses.
+func (m *DeviceManager) LogStreamAll(ctx context.Context, req *pb.LogStreamRequest, addresses ...string) map[string]Result[[]*pb.LogStreamResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) ([]*pb.LogStreamResponse, error) {
+		return c.LogStream(ctx, req)
+	}, addresses...)
+}
+
+// GetRpcStatsAll calls GetRpcStats on every managed device, or on the given addresses.
+func (m *DeviceManager) GetRpcStatsAll(ctx context.Context, req *pb.GetRpcStatsRequest, addresses ...string) map[string]Result[*pb.GetRpcStatsResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.GetRpcStatsResponse, error) {
+		return c.GetRpcStats(ctx, req)
+	}, addresses...)
+}
+
+// StartSessionAll calls StartSession on every managed device, or on the given addresses.
+func (m *DeviceManager) StartSessionAll(ctx context.Context, req *pb.StartSessionRequest, addresses ...string) map[string]Result[*pb.StartSessionResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.StartSessionResponse, error) {
+		return c.StartSession(ctx, req)
+	}, addresses...)
+}
+
+// AuthenticateSessionAll calls AuthenticateSession on every managed device, or on the given addresses.
+func (m *DeviceManager) AuthenticateSessionAll(ctx context.Context, req *pb.AuthenticateSessionRequest, addresses ...string) map[string]Result[*pb.AuthenticateSessionResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.AuthenticateSessionResponse, error) {
+		return c.AuthenticateSession(ctx, req)
+	}, addresses...)
+}
+
+// TimeSyncAll calls TimeSync on every managed device, or on the given addresses.
+func (m *DeviceManager) TimeSyncAll(ctx context.Context, req *pb.TimeSyncRequest, addresses ...string) map[string]Result[*pb.TimeSyncResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.TimeSyncResponse, error) {
+		return c.TimeSync(ctx, req)
+	}, addresses...)
+}
+
+// PingAll calls Ping on every managed device, or on the given addresses.
+func (m *DeviceManager) PingAll(ctx context.Context, req *pb.PingRequest, addresses ...string) map[string]Result[*pb.PingResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.PingResponse, error) {
+		return c.Ping(ctx, req)
+	}, addresses...)
+}
+
+// GetCapabilitiesAll calls GetCapabilities on every managed device, or on the given addresses.
+func (m *DeviceManager) GetCapabilitiesAll(ctx context.Context, req *pb.GetCapabilitiesRequest, addresses ...string) map[string]Result[*pb.GetCapabilitiesResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.GetCapabilitiesResponse, error) {
+		return c.GetCapabilities(ctx, req)
+	}, addresses...)
+}
+
+// GetSettingAll calls GetSetting on every managed device, or on the given addresses.
+func (m *DeviceManager) GetSettingAll(ctx context.Context, req *pb.GetSettingRequest, addresses ...string) map[string]Result[*pb.GetSettingResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.GetSettingResponse, error) {
+		return c.GetSetting(ctx, req)
+	}, addresses...)
+}
+
+// SetSettingAll calls SetSetting on every managed device, or on the given addresses.
+func (m *DeviceManager) SetSettingAll(ctx context.Context, req *pb.SetSettingRequest, addresses ...string) map[string]Result[*pb.SetSettingResponse] {
+	return Broadcast(ctx, m, func(ctx context.Context, c *Client) (*pb.SetSettingResponse, error) {
+		return c.SetSetting(ctx, req)
+	}, addresses...)
+}
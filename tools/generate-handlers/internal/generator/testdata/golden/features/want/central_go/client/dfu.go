@@ -0,0 +1,88 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+package blerpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/tdaira/blerpc/central_go/proto"
+)
+
+// FirmwareUpdate writes firmware images over the dfu built-in, verifying
+// each by CRC-32.
+type FirmwareUpdate struct {
+	Caller Caller
+	// NoReboot leaves the peripheral on its running image after the update
+	// is applied, until it restarts for another reason.
+	NoReboot bool
+	// Progress, if set, is called after each chunk with the bytes sent so far
+	// and the total.
+	Progress func(sent, total int)
+}
+
+func (u *FirmwareUpdate) call(ctx context.Context, cmd string, req, resp proto.Message) error {
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	respData, err := u.Caller.Call(ctx, cmd, reqData)
+	if err != nil {
+		if errors.Is(err, ErrBlerpc) {
+			return err
+		}
+		return &TransportError{Command: cmd, Err: err}
+	}
+	if err := proto.Unmarshal(respData, resp); err != nil {
+		return &DecodeError{Command: cmd, Err: err}
+	}
+	return nil
+}
+
+// Update writes the firmware image at path to the peripheral and applies
+// it. An update interrupted before it was applied continues after the bytes
+// the peripheral already has when Update is called again with the same
+// image.
+func (u *FirmwareUpdate) Update(ctx context.Context, path string) error {
+	image, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	crc := crc32.ChecksumIEEE(image)
+	begun := &pb.DfuBeginResponse{}
+	if err := u.call(ctx, "dfu_begin", &pb.DfuBeginRequest{Size: uint32(len(image)), Crc32: crc}, begun); err != nil {
+		return err
+	}
+	offset := int(begun.GetOffset())
+	chunkSize := int(begun.GetMaxChunk())
+	if chunkSize == 0 {
+		chunkSize = 128
+	}
+	for offset < len(image) {
+		end := min(offset+chunkSize, len(image))
+		resp := &pb.DfuChunkResponse{}
+		if err := u.call(ctx, "dfu_chunk", &pb.DfuChunkRequest{Offset: uint32(offset), Data: image[offset:end]}, resp); err != nil {
+			return err
+		}
+		if int(resp.GetOffset()) == offset {
+			return fmt.Errorf("%s: update stalled at byte %d: %w", path, offset, ErrBlerpc)
+		}
+		offset = int(resp.GetOffset())
+		if u.Progress != nil {
+			u.Progress(offset, len(image))
+		}
+	}
+	done := &pb.DfuFinalizeResponse{}
+	if err := u.call(ctx, "dfu_finalize", &pb.DfuFinalizeRequest{Reboot: !u.NoReboot}, done); err != nil {
+		return err
+	}
+	if done.GetCrc32() != crc {
+		return fmt.Errorf("%s: update failed verification: %w", path, ErrBlerpc)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+package blerpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Caller carries raw command payloads to the peripheral.
+type Caller interface {
+	Call(ctx context.Context, cmdName string, requestData []byte) ([]byte, error)
+}
+
+// ErrBlerpc matches every error returned by client methods with errors.Is.
+var ErrBlerpc = errors.New("blerpc")
+
+// TransportError reports that the request could not be delivered or the
+// response was lost.
+type TransportError struct {
+	Command string
+	Err     error
+}
+
+func (e *TransportError) Error() string   { return fmt.Sprintf("%s: transport: %v", e.Command, e.Err) }
+func (e *TransportError) Unwrap() []error { return []error{ErrBlerpc, e.Err} }
+
+// TimeoutError reports that the peripheral did not respond in time. It
+// matches context.DeadlineExceeded.
+type TimeoutError struct {
+	Command string
+}
+
+func (e *TimeoutError) Error() string { return e.Command + ": timed out" }
+func (e *TimeoutError) Unwrap() []error {
+	return []error{&TransportError{Command: e.Command, Err: context.DeadlineExceeded}}
+}
+
+// DecodeError reports that the response payload is not a valid message.
+type DecodeError struct {
+	Command string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: invalid response: %v", e.Command, e.Err)
+}
+func (e *DecodeError) Unwrap() []error { return []error{ErrBlerpc, e.Err} }
+
+// RemoteError reports that the peripheral answered with a non-OK status.
+type RemoteError struct {
+	Command string
+	Field   string // status field name, if the status came from the response
+	Status  int
+}
+
+func (e *RemoteError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s failed: %s=%d", e.Command, e.Field, e.Status)
+	}
+	return fmt.Sprintf("%s failed: status=%d", e.Command, e.Status)
+}
+func (e *RemoteError) Unwrap() error { return ErrBlerpc }
@@ -0,0 +1,417 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+// Package blerpcgateway serves the blerpc commands to the network,
+// forwarding every call to a peripheral through a blerpcclient.Client:
+//
+//	gw := blerpcgateway.New(blerpcclient.New(bleTransport))
+//	s := grpc.NewServer()
+//	gw.Register(s)
+//	go s.Serve(grpcListener)
+//	go http.Serve(httpListener, gw.HTTPHandler())
+//
+// The gRPC service blerpc.Gateway has a method per command, named after
+// it in upper camel case; streams to the central are server streams and
+// streams from the central are client streams. Over HTTP each command is
+// POST /<command> with the protojson request as body, an empty body being
+// the empty request. Streams to the central answer newline-delimited JSON
+// responses; streams from the central take their requests as a sequence
+// of JSON objects in the body.
+package blerpcgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	client "github.com/tdaira/blerpc/central_go/client"
+)
+
+// ServiceDesc describes the blerpc.Gateway gRPC service. Register adds it
+// to a server.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Echo", (*client.Client).Echo),
+		unaryMethod("FlashRead", (*client.Client).FlashRead),
+		unaryMethod("DataWrite", (*client.Client).DataWrite),
+		unaryMethod("GetBlerpcInfo", (*client.Client).GetBlerpcInfo),
+		unaryMethod("ConnParams", (*client.Client).ConnParams),
+		unaryMethod("DfuBegin", (*client.Client).DfuBegin),
+		unaryMethod("DfuChunk", (*client.Client).DfuChunk),
+		unaryMethod("DfuFinalize", (*client.Client).DfuFinalize),
+		unaryMethod("FileOpen", (*client.Client).FileOpen),
+		unaryMethod("FileRead", (*client.Client).FileRead),
+		unaryMethod("FileWrite", (*client.Client).FileWrite),
+		unaryMethod("FileClose", (*client.Client).FileClose),
+		unaryMethod("GetRpcStats", (*client.Client).GetRpcStats),
+		unaryMethod("StartSession", (*client.Client).StartSession),
+		unaryMethod("AuthenticateSession", (*client.Client).AuthenticateSession),
+		unaryMethod("TimeSync", (*client.Client).TimeSync),
+		unaryMethod("Ping", (*client.Client).Ping),
+		unaryMethod("GetCapabilities", (*client.Client).GetCapabilities),
+		unaryMethod("GetSetting", (*client.Client).GetSetting),
+		unaryMethod("SetSetting", (*client.Client).SetSetting),
+	},
+	Streams: []grpc.StreamDesc{
+		serverStream("CounterStream", (*client.Client).CounterStreamSeq),
+		clientStream("CounterUpload", (*client.Client).CounterUpload),
+		serverStream("LogStream", (*client.Client).LogStreamSeq),
+	},
+}
+
+// HTTPHandler returns the JSON/HTTP endpoints of the commands.
+func (s *Server) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /echo", unaryHTTP(s, (*client.Client).Echo))
+	mux.Handle("POST /flash_read", unaryHTTP(s, (*client.Client).FlashRead))
+	mux.Handle("POST /data_write", unaryHTTP(s, (*client.Client).DataWrite))
+	mux.Handle("POST /counter_stream", serverStreamHTTP(s, (*client.Client).CounterStreamSeq))
+	mux.Handle("POST /counter_upload", clientStreamHTTP(s, (*client.Client).CounterUpload))
+	mux.Handle("POST /get_blerpc_info", unaryHTTP(s, (*client.Client).GetBlerpcInfo))
+	mux.Handle("POST /conn_params", unaryHTTP(s, (*client.Client).ConnParams))
+	mux.Handle("POST /dfu_begin", unaryHTTP(s, (*client.Client).DfuBegin))
+	mux.Handle("POST /dfu_chunk", unaryHTTP(s, (*client.Client).DfuChunk))
+	mux.Handle("POST /dfu_finalize", unaryHTTP(s, (*client.Client).DfuFinalize))
+	mux.Handle("POST /file_open", unaryHTTP(s, (*client.Client).FileOpen))
+	mux.Handle("POST /file_read", unaryHTTP(s, (*client.Client).FileRead))
+	mux.Handle("POST /file_write", unaryHTTP(s, (*client.Client).FileWrite))
+	mux.Handle("POST /file_close", unaryHTTP(s, (*client.Client).FileClose))
+	mux.Handle("POST /log_stream", serverStreamHTTP(s, (*client.Client).LogStreamSeq))
+	mux.Handle("POST /get_rpc_stats", unaryHTTP(s, (*client.Client).GetRpcStats))
+	mux.Handle("POST /start_session", unaryHTTP(s, (*client.Client).StartSession))
+	mux.Handle("POST /authenticate_session", unaryHTTP(s, (*client.Client).AuthenticateSession))
+	mux.Handle("POST /time_sync", unaryHTTP(s, (*client.Client).TimeSync))
+	mux.Handle("POST /ping", unaryHTTP(s, (*client.Client).Ping))
+	mux.Handle("POST /get_capabilities", unaryHTTP(s, (*client.Client).GetCapabilities))
+	mux.Handle("POST /get_setting", unaryHTTP(s, (*client.Client).GetSetting))
+	mux.Handle("POST /set_setting", unaryHTTP(s, (*client.Client).SetSetting))
+	return mux
+}
+
+// statusCodes maps the status codes of error responses to gRPC codes.
+var statusCodes = map[int]codes.Code{
+	client.StatusInvalidArgument:    codes.InvalidArgument,
+	client.StatusNotFound:           codes.NotFound,
+	client.StatusAlreadyExists:      codes.AlreadyExists,
+	client.StatusPermissionDenied:   codes.PermissionDenied,
+	client.StatusResourceExhausted:  codes.ResourceExhausted,
+	client.StatusFailedPrecondition: codes.FailedPrecondition,
+	client.StatusOutOfRange:         codes.OutOfRange,
+	client.StatusUnimplemented:      codes.Unimplemented,
+	client.StatusInternal:           codes.Internal,
+	client.StatusUnavailable:        codes.Unavailable,
+	client.StatusUnauthenticated:    codes.Unauthenticated,
+}
+
+// ServiceName is the full name of the gRPC service.
+const ServiceName = "blerpc.Gateway"
+
+// maxBodyBytes bounds the body of an HTTP request.
+const maxBodyBytes = 1 << 20
+
+// Server forwards the calls it serves to the peripheral behind Client.
+type Server struct {
+	Client *client.Client
+}
+
+// New returns a server forwarding to c.
+func New(c *client.Client) *Server {
+	return &Server{Client: c}
+}
+
+// Register adds the gRPC service to r.
+func (s *Server) Register(r grpc.ServiceRegistrar) {
+	r.RegisterService(&ServiceDesc, s)
+}
+
+// message constrains PReq to the pointer type of the protoc-gen-go message
+// Req, so the builders below can allocate requests.
+type message[Req any] interface {
+	*Req
+	proto.Message
+}
+
+func unaryMethod[Req any, PReq message[Req], Resp proto.Message](name string, call func(*client.Client, context.Context, PReq) (Resp, error)) grpc.MethodDesc {
+	handle := func(srv any, ctx context.Context, req any) (any, error) {
+		resp, err := call(srv.(*Server).Client, ctx, req.(PReq))
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		return resp, nil
+	}
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := PReq(new(Req))
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return handle(srv, ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + name}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return handle(srv, ctx, req)
+			})
+		},
+	}
+}
+
+func serverStream[Req any, PReq message[Req], Resp proto.Message](name string, call func(*client.Client, context.Context, PReq) iter.Seq2[Resp, error]) grpc.StreamDesc {
+	return grpc.StreamDesc{
+		StreamName:    name,
+		ServerStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			req := PReq(new(Req))
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			for resp, err := range call(srv.(*Server).Client, stream.Context(), req) {
+				if err != nil {
+					return grpcError(err)
+				}
+				if err := stream.SendMsg(resp); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func clientStream[Req any, PReq message[Req], Resp proto.Message](name string, call func(*client.Client, context.Context, iter.Seq[PReq]) (Resp, error)) grpc.StreamDesc {
+	return grpc.StreamDesc{
+		StreamName:    name,
+		ClientStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			var recvErr error
+			reqs := func(yield func(PReq) bool) {
+				for {
+					req := PReq(new(Req))
+					if err := stream.RecvMsg(req); err != nil {
+						if err != io.EOF {
+							recvErr = err
+						}
+						return
+					}
+					if !yield(req) {
+						return
+					}
+				}
+			}
+			resp, err := call(srv.(*Server).Client, stream.Context(), reqs)
+			if recvErr != nil {
+				return recvErr
+			}
+			if err != nil {
+				return grpcError(err)
+			}
+			return stream.SendMsg(resp)
+		},
+	}
+}
+
+func unaryHTTP[Req any, PReq message[Req], Resp proto.Message](s *Server, call func(*client.Client, context.Context, PReq) (Resp, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := PReq(new(Req))
+		if err := readRequest(w, r, req); err != nil {
+			writeError(w, err)
+			return
+		}
+		resp, err := call(s.Client, r.Context(), req)
+		if err != nil {
+			writeError(w, grpcError(err))
+			return
+		}
+		data, err := protojson.Marshal(resp)
+		if err != nil {
+			writeError(w, status.Error(codes.Internal, err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(data, '\n'))
+	}
+}
+
+func serverStreamHTTP[Req any, PReq message[Req], Resp proto.Message](s *Server, call func(*client.Client, context.Context, PReq) iter.Seq2[Resp, error]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := PReq(new(Req))
+		if err := readRequest(w, r, req); err != nil {
+			writeError(w, err)
+			return
+		}
+		rc := http.NewResponseController(w)
+		started := false
+		for resp, err := range call(s.Client, r.Context(), req) {
+			var data []byte
+			if err != nil {
+				err = grpcError(err)
+			} else if data, err = protojson.Marshal(resp); err != nil {
+				err = status.Error(codes.Internal, err.Error())
+			}
+			switch {
+			case err != nil && !started:
+				writeError(w, err)
+				return
+			case err != nil:
+				// The status line is sent, so the error ends the body instead.
+				writeErrorBody(w, err)
+				return
+			case !started:
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				started = true
+			}
+			w.Write(append(data, '\n'))
+			rc.Flush()
+		}
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+	}
+}
+
+func clientStreamHTTP[Req any, PReq message[Req], Resp proto.Message](s *Server, call func(*client.Client, context.Context, iter.Seq[PReq]) (Resp, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		var readErr error
+		reqs := func(yield func(PReq) bool) {
+			for {
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					if err != io.EOF {
+						readErr = status.Error(codes.InvalidArgument, err.Error())
+					}
+					return
+				}
+				req := PReq(new(Req))
+				if err := protojson.Unmarshal(raw, req); err != nil {
+					readErr = status.Error(codes.InvalidArgument, err.Error())
+					return
+				}
+				if !yield(req) {
+					return
+				}
+			}
+		}
+		resp, err := call(s.Client, r.Context(), reqs)
+		if readErr != nil {
+			writeError(w, readErr)
+			return
+		}
+		if err != nil {
+			writeError(w, grpcError(err))
+			return
+		}
+		data, err := protojson.Marshal(resp)
+		if err != nil {
+			writeError(w, status.Error(codes.Internal, err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(data, '\n'))
+	}
+}
+
+// readRequest decodes the protojson body of r into req; an empty body
+// leaves req empty.
+func readRequest(w http.ResponseWriter, r *http.Request, req proto.Message) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if err := protojson.Unmarshal(body, req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// grpcError returns the gRPC status error of an error of the client. Error
+// responses keep their status code; status fields of responses other than
+// OK become Unknown.
+func grpcError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var remote *client.RemoteError
+	var timeout *client.TimeoutError
+	var transport *client.TransportError
+	var decode *client.DecodeError
+	code := codes.Internal
+	switch {
+	case errors.As(err, &remote):
+		code = codes.Unknown
+		if c, ok := statusCodes[remote.Status]; ok && remote.Field == "" {
+			code = c
+		}
+	case errors.As(err, &timeout), errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.As(err, &transport):
+		code = codes.Unavailable
+	case errors.As(err, &decode):
+		code = codes.DataLoss
+	}
+	return status.Error(code, err.Error())
+}
+
+// httpStatus returns the HTTP status of a gRPC code, as grpc-gateway maps
+// them.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers err, a gRPC status error, with its HTTP status.
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(status.Code(err)))
+	writeErrorBody(w, err)
+}
+
+// writeErrorBody writes err as the JSON object {"code": ..., "message": ...}
+// of google.rpc.Status.
+func writeErrorBody(w io.Writer, err error) {
+	s := status.Convert(err)
+	json.NewEncoder(w).Encode(struct {
+		Code    codes.Code `json:"code"`
+		Message string     `json:"message"`
+	}{s.Code(), s.Message()})
+}
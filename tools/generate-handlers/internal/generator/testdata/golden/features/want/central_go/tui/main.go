@@ -0,0 +1,513 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+// Package blerpctui is an interactive terminal client for the blerpc commands.
+package blerpctui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/tdaira/blerpc/central_go/proto"
+)
+
+// Transport carries raw command payloads to the peripheral.
+type Transport interface {
+	Call(ctx context.Context, cmdName string, requestData []byte) ([]byte, error)
+	StreamReceive(ctx context.Context, cmdName string, requestData []byte) ([][]byte, error)
+}
+
+// Notification is an unsolicited payload pushed by the peripheral.
+type Notification struct {
+	Source string
+	Data   []byte
+}
+
+// Notifier is optionally implemented by transports that deliver notifications.
+// They are shown in the notification pane as they arrive.
+type Notifier interface {
+	Notifications() <-chan Notification
+}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindBytes
+	kindBool
+	kindNumber
+	kindEnum
+	kindJSON
+)
+
+type fieldSpec struct {
+	name string
+	kind fieldKind
+}
+
+type commandSpec struct {
+	name        string
+	stream      bool
+	fields      []fieldSpec
+	newRequest  func() proto.Message
+	newResponse func() proto.Message
+}
+
+var commands = []commandSpec{
+	{
+		name: "echo",
+		fields: []fieldSpec{
+			{"message", kindString},
+		},
+		newRequest:  func() proto.Message { return &pb.EchoRequest{} },
+		newResponse: func() proto.Message { return &pb.EchoResponse{} },
+	},
+	{
+		name: "flash_read",
+		fields: []fieldSpec{
+			{"address", kindNumber},
+			{"length", kindNumber},
+		},
+		newRequest:  func() proto.Message { return &pb.FlashReadRequest{} },
+		newResponse: func() proto.Message { return &pb.FlashReadResponse{} },
+	},
+	{
+		name: "data_write",
+		fields: []fieldSpec{
+			{"data", kindBytes},
+		},
+		newRequest:  func() proto.Message { return &pb.DataWriteRequest{} },
+		newResponse: func() proto.Message { return &pb.DataWriteResponse{} },
+	},
+	{
+		name:   "counter_stream",
+		stream: true,
+		fields: []fieldSpec{
+			{"count", kindNumber},
+		},
+		newRequest:  func() proto.Message { return &pb.CounterStreamRequest{} },
+		newResponse: func() proto.Message { return &pb.CounterStreamResponse{} },
+	},
+	{
+		name:        "get_blerpc_info",
+		newRequest:  func() proto.Message { return &pb.GetBlerpcInfoRequest{} },
+		newResponse: func() proto.Message { return &pb.GetBlerpcInfoResponse{} },
+	},
+	{
+		name: "conn_params",
+		fields: []fieldSpec{
+			{"profile", kindEnum},
+		},
+		newRequest:  func() proto.Message { return &pb.ConnParamsRequest{} },
+		newResponse: func() proto.Message { return &pb.ConnParamsResponse{} },
+	},
+	{
+		name: "dfu_begin",
+		fields: []fieldSpec{
+			{"size", kindNumber},
+			{"crc32", kindNumber},
+		},
+		newRequest:  func() proto.Message { return &pb.DfuBeginRequest{} },
+		newResponse: func() proto.Message { return &pb.DfuBeginResponse{} },
+	},
+	{
+		name: "dfu_chunk",
+		fields: []fieldSpec{
+			{"offset", kindNumber},
+			{"data", kindBytes},
+		},
+		newRequest:  func() proto.Message { return &pb.DfuChunkRequest{} },
+		newResponse: func() proto.Message { return &pb.DfuChunkResponse{} },
+	},
+	{
+		name: "dfu_finalize",
+		fields: []fieldSpec{
+			{"reboot", kindBool},
+		},
+		newRequest:  func() proto.Message { return &pb.DfuFinalizeRequest{} },
+		newResponse: func() proto.Message { return &pb.DfuFinalizeResponse{} },
+	},
+	{
+		name: "file_open",
+		fields: []fieldSpec{
+			{"path", kindString},
+			{"write", kindBool},
+			{"resume", kindBool},
+		},
+		newRequest:  func() proto.Message { return &pb.FileOpenRequest{} },
+		newResponse: func() proto.Message { return &pb.FileOpenResponse{} },
+	},
+	{
+		name: "file_read",
+		fields: []fieldSpec{
+			{"handle", kindNumber},
+			{"offset", kindNumber},
+			{"length", kindNumber},
+		},
+		newRequest:  func() proto.Message { return &pb.FileReadRequest{} },
+		newResponse: func() proto.Message { return &pb.FileReadResponse{} },
+	},
+	{
+		name: "file_write",
+		fields: []fieldSpec{
+			{"handle", kindNumber},
+			{"offset", kindNumber},
+			{"data", kindBytes},
+		},
+		newRequest:  func() proto.Message { return &pb.FileWriteRequest{} },
+		newResponse: func() proto.Message { return &pb.FileWriteResponse{} },
+	},
+	{
+		name: "file_close",
+		fields: []fieldSpec{
+			{"handle", kindNumber},
+			{"verify", kindBool},
+		},
+		newRequest:  func() proto.Message { return &pb.FileCloseRequest{} },
+		newResponse: func() proto.Message { return &pb.FileCloseResponse{} },
+	},
+	{
+		name:   "log_stream",
+		stream: true,
+		fields: []fieldSpec{
+			{"min_level", kindEnum},
+			{"max_entries", kindNumber},
+		},
+		newRequest:  func() proto.Message { return &pb.LogStreamRequest{} },
+		newResponse: func() proto.Message { return &pb.LogStreamResponse{} },
+	},
+	{
+		name: "get_rpc_stats",
+		fields: []fieldSpec{
+			{"reset", kindBool},
+		},
+		newRequest:  func() proto.Message { return &pb.GetRpcStatsRequest{} },
+		newResponse: func() proto.Message { return &pb.GetRpcStatsResponse{} },
+	},
+	{
+		name:        "start_session",
+		newRequest:  func() proto.Message { return &pb.StartSessionRequest{} },
+		newResponse: func() proto.Message { return &pb.StartSessionResponse{} },
+	},
+	{
+		name: "authenticate_session",
+		fields: []fieldSpec{
+			{"proof", kindBytes},
+		},
+		newRequest:  func() proto.Message { return &pb.AuthenticateSessionRequest{} },
+		newResponse: func() proto.Message { return &pb.AuthenticateSessionResponse{} },
+	},
+	{
+		name: "time_sync",
+		fields: []fieldSpec{
+			{"unix_time_us", kindNumber},
+			{"offset_us", kindNumber},
+		},
+		newRequest:  func() proto.Message { return &pb.TimeSyncRequest{} },
+		newResponse: func() proto.Message { return &pb.TimeSyncResponse{} },
+	},
+	{
+		name: "ping",
+		fields: []fieldSpec{
+			{"payload", kindBytes},
+		},
+		newRequest:  func() proto.Message { return &pb.PingRequest{} },
+		newResponse: func() proto.Message { return &pb.PingResponse{} },
+	},
+	{
+		name:        "get_capabilities",
+		newRequest:  func() proto.Message { return &pb.GetCapabilitiesRequest{} },
+		newResponse: func() proto.Message { return &pb.GetCapabilitiesResponse{} },
+	},
+	{
+		name: "get_setting",
+		fields: []fieldSpec{
+			{"field", kindNumber},
+		},
+		newRequest:  func() proto.Message { return &pb.GetSettingRequest{} },
+		newResponse: func() proto.Message { return &pb.GetSettingResponse{} },
+	},
+	{
+		name: "set_setting",
+		fields: []fieldSpec{
+			{"field", kindNumber},
+			{"value", kindBytes},
+		},
+		newRequest:  func() proto.Message { return &pb.SetSettingRequest{} },
+		newResponse: func() proto.Message { return &pb.SetSettingResponse{} },
+	},
+}
+
+// fieldValue converts the text typed into a form input to a protojson value.
+func fieldValue(kind fieldKind, text string) (any, error) {
+	switch kind {
+	case kindString, kindBytes, kindNumber:
+		// protojson accepts quoted numbers and base64 strings for bytes.
+		return text, nil
+	case kindBool:
+		return strconv.ParseBool(text)
+	case kindEnum:
+		if n, err := strconv.ParseInt(text, 10, 32); err == nil {
+			return n, nil
+		}
+		return text, nil
+	default:
+		var v any
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+func buildRequest(spec commandSpec, values []string) (proto.Message, error) {
+	obj := make(map[string]any)
+	for i, f := range spec.fields {
+		if values[i] == "" {
+			continue
+		}
+		v, err := fieldValue(f.kind, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		obj[f.name] = v
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	req := spec.newRequest()
+	if err := protojson.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func formatResponse(spec commandSpec, data []byte) string {
+	resp := spec.newResponse()
+	if err := proto.Unmarshal(data, resp); err != nil {
+		return fmt.Sprintf("decode error: %v", err)
+	}
+	return protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Format(resp)
+}
+
+type mode int
+
+const (
+	modeList mode = iota
+	modeForm
+)
+
+const maxNotes = 8
+
+type resultMsg string
+
+type noteMsg Notification
+
+type model struct {
+	transport Transport
+	timeout   time.Duration
+	notify    <-chan Notification
+
+	mode   mode
+	cursor int
+	inputs []textinput.Model
+	focus  int
+	busy   bool
+	output string
+	notes  []string
+}
+
+// Run starts the interactive client on the terminal and blocks until the user quits.
+func Run(t Transport, timeout time.Duration) error {
+	m := model{transport: t, timeout: timeout}
+	if n, ok := t.(Notifier); ok {
+		m.notify = n.Notifications()
+	}
+	_, err := tea.NewProgram(m).Run()
+	return err
+}
+
+func (m model) Init() tea.Cmd {
+	return m.waitNote()
+}
+
+func (m model) waitNote() tea.Cmd {
+	if m.notify == nil {
+		return nil
+	}
+	ch := m.notify
+	return func() tea.Msg {
+		n, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return noteMsg(n)
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case resultMsg:
+		m.busy = false
+		m.output = string(msg)
+		return m, nil
+	case noteMsg:
+		line := fmt.Sprintf("%s %s (%d bytes)", time.Now().Format("15:04:05"), msg.Source, len(msg.Data))
+		m.notes = append(m.notes, line)
+		if len(m.notes) > maxNotes {
+			m.notes = m.notes[len(m.notes)-maxNotes:]
+		}
+		return m, m.waitNote()
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.mode == modeList {
+			return m.updateList(msg)
+		}
+		return m.updateForm(msg)
+	}
+	return m, nil
+}
+
+func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(commands)-1 {
+			m.cursor++
+		}
+	case "enter":
+		spec := commands[m.cursor]
+		if len(spec.fields) == 0 {
+			return m.submit()
+		}
+		m.inputs = make([]textinput.Model, len(spec.fields))
+		for i, f := range spec.fields {
+			in := textinput.New()
+			in.Prompt = f.name + ": "
+			m.inputs[i] = in
+		}
+		m.focus = 0
+		m.inputs[0].Focus()
+		m.mode = modeForm
+	}
+	return m, nil
+}
+
+func (m model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		return m, nil
+	case "tab", "down":
+		return m.moveFocus(1), nil
+	case "shift+tab", "up":
+		return m.moveFocus(-1), nil
+	case "enter":
+		if m.focus < len(m.inputs)-1 {
+			return m.moveFocus(1), nil
+		}
+		return m.submit()
+	}
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) moveFocus(delta int) model {
+	m.inputs[m.focus].Blur()
+	m.focus = (m.focus + delta + len(m.inputs)) % len(m.inputs)
+	m.inputs[m.focus].Focus()
+	return m
+}
+
+func (m model) submit() (tea.Model, tea.Cmd) {
+	if m.busy {
+		return m, nil
+	}
+	spec := commands[m.cursor]
+	values := make([]string, len(m.inputs))
+	for i, in := range m.inputs {
+		values[i] = strings.TrimSpace(in.Value())
+	}
+	req, err := buildRequest(spec, values)
+	if err != nil {
+		m.output = fmt.Sprintf("invalid request: %v", err)
+		return m, nil
+	}
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		m.output = fmt.Sprintf("encode error: %v", err)
+		return m, nil
+	}
+	m.busy = true
+	m.mode = modeList
+	m.output = "calling " + spec.name + "..."
+	t, timeout := m.transport, m.timeout
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if !spec.stream {
+			respData, err := t.Call(ctx, spec.name, reqData)
+			if err != nil {
+				return resultMsg(fmt.Sprintf("%s failed: %v", spec.name, err))
+			}
+			return resultMsg(formatResponse(spec, respData))
+		}
+		responses, err := t.StreamReceive(ctx, spec.name, reqData)
+		if err != nil {
+			return resultMsg(fmt.Sprintf("%s failed: %v", spec.name, err))
+		}
+		parts := make([]string, len(responses))
+		for i, data := range responses {
+			parts[i] = formatResponse(spec, data)
+		}
+		return resultMsg(strings.Join(parts, "\n"))
+	}
+}
+
+func (m model) View() string {
+	var b strings.Builder
+	if m.mode == modeList {
+		b.WriteString("Commands\n\n")
+		for i, spec := range commands {
+			cursor := "  "
+			if i == m.cursor {
+				cursor = "> "
+			}
+			b.WriteString(cursor + spec.name + "\n")
+		}
+	} else {
+		b.WriteString(commands[m.cursor].name + "\n\n")
+		for _, in := range m.inputs {
+			b.WriteString(in.View() + "\n")
+		}
+	}
+	b.WriteString("\n── Response ──\n")
+	b.WriteString(m.output + "\n")
+	b.WriteString("\n── Notifications ──\n")
+	for _, n := range m.notes {
+		b.WriteString(n + "\n")
+	}
+	if m.mode == modeList {
+		b.WriteString("\n↑/↓ select • enter open • q quit\n")
+	} else {
+		b.WriteString("\ntab next field • enter send • esc back\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+/// An advertisement broadcast as manufacturer specific data.
+enum GeneratedAdvertisement {
+    case sensorState(Blerpc_SensorState)
+
+    /// Bluetooth SIG company identifier of the manufacturer data.
+    static let companyID: UInt16 = 0xFFFF
+
+    /// Decodes the CBAdvertisementDataManufacturerDataKey value, which starts
+    /// with the company identifier. Fails when the data holds no valid
+    /// advertisement of this schema.
+    init?(manufacturerData data: Data) {
+        let bytes = [UInt8](data)
+        guard bytes.count >= 3, UInt16(bytes[0]) | UInt16(bytes[1]) << 8 == Self.companyID else {
+            return nil
+        }
+        let payload = Data(bytes[3...])
+        do {
+            switch bytes[2] {
+            case 1:
+                self = .sensorState(try Blerpc_SensorState(serializedBytes: payload))
+            default:
+                return nil
+            }
+        } catch {
+            return nil
+        }
+    }
+}
@@ -0,0 +1,228 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+/// Command name of the batch envelope.
+let batchCommand = "_batch"
+
+/// The batch response holds no complete response for a call.
+struct BatchError: BlerpcErrorProtocol {
+    let message: String
+}
+
+/// The response of a call added to a Batch, once the batch is sent.
+final class BatchCall<T> {
+    let command: String
+    fileprivate var result: Result<T, Error>?
+
+    fileprivate init(command: String) {
+        self.command = command
+    }
+
+    /// Returns the response, or throws the error of the call.
+    func get() throws -> T {
+        guard let result else { throw BatchError(message: "\(command): the batch was not sent") }
+        return try result.get()
+    }
+}
+
+/// Unary calls sent to the peripheral in one request. The peripheral runs
+/// them in order and answers them in one response, so a sequence of small
+/// calls, such as provisioning, costs one round trip instead of one per call:
+///
+///     let batch = Batch(client: client)
+///     let echo = try batch.echo(message: "hi")
+///     try await batch.send()
+///     print(try echo.get().message)
+///
+/// Streaming commands, and commands with a replay counter or a session
+/// token, cannot be batched.
+final class Batch<Client: GeneratedClientProtocol> {
+    private let client: Client
+    private var payload = Data()
+    /// Records the response data of each call, or its error, and returns
+    /// the error of the call.
+    private var pending: [(command: String, complete: (Result<Data, Error>) -> Error?)] = []
+
+    init(client: Client) {
+        self.client = client
+    }
+
+    func echo(message: String = "") throws -> BatchCall<Blerpc_EchoResponse> {
+        if message.utf8.count > 256 {
+            throw StringTooLongError(command: "echo", field: "message", size: message.utf8.count, maxBytes: 256)
+        }
+        var req = Blerpc_EchoRequest()
+        req.message = message
+        return add("echo", cmdName: CommandId.echo.wireName, requestData: try req.serializedData()) { try Blerpc_EchoResponse(serializedBytes: $0) }
+    }
+
+    @available(*, deprecated, message: "data_write is deprecated in the schema")
+    func dataWrite(data: Data = Data()) throws -> BatchCall<Blerpc_DataWriteResponse> {
+        var req = Blerpc_DataWriteRequest()
+        req.data = data
+        return add("data_write", cmdName: CommandId.dataWrite.wireName, requestData: try req.serializedData()) { try Blerpc_DataWriteResponse(serializedBytes: $0) }
+    }
+
+    func getBlerpcInfo() throws -> BatchCall<Blerpc_GetBlerpcInfoResponse> {
+        var req = Blerpc_GetBlerpcInfoRequest()
+        return add("get_blerpc_info", cmdName: CommandId.getBlerpcInfo.wireName, requestData: try req.serializedData()) { try Blerpc_GetBlerpcInfoResponse(serializedBytes: $0) }
+    }
+
+    func dfuBegin(size: UInt32 = 0, crc32: UInt32 = 0) throws -> BatchCall<Blerpc_DfuBeginResponse> {
+        var req = Blerpc_DfuBeginRequest()
+        req.size = size
+        req.crc32 = crc32
+        return add("dfu_begin", cmdName: CommandId.dfuBegin.wireName, requestData: try req.serializedData()) { try Blerpc_DfuBeginResponse(serializedBytes: $0) }
+    }
+
+    func dfuChunk(offset: UInt32 = 0, data: Data = Data()) throws -> BatchCall<Blerpc_DfuChunkResponse> {
+        var req = Blerpc_DfuChunkRequest()
+        req.offset = offset
+        req.data = data
+        return add("dfu_chunk", cmdName: CommandId.dfuChunk.wireName, requestData: try req.serializedData()) { try Blerpc_DfuChunkResponse(serializedBytes: $0) }
+    }
+
+    func dfuFinalize(reboot: Bool = false) throws -> BatchCall<Blerpc_DfuFinalizeResponse> {
+        var req = Blerpc_DfuFinalizeRequest()
+        req.reboot = reboot
+        return add("dfu_finalize", cmdName: CommandId.dfuFinalize.wireName, requestData: try req.serializedData()) { try Blerpc_DfuFinalizeResponse(serializedBytes: $0) }
+    }
+
+    func fileOpen(path: String = "", write: Bool = false, resume: Bool = false) throws -> BatchCall<Blerpc_FileOpenResponse> {
+        var req = Blerpc_FileOpenRequest()
+        req.path = path
+        req.write = write
+        req.resume = resume
+        return add("file_open", cmdName: CommandId.fileOpen.wireName, requestData: try req.serializedData()) { try Blerpc_FileOpenResponse(serializedBytes: $0) }
+    }
+
+    func fileRead(handle: UInt32 = 0, offset: UInt32 = 0, length: UInt32 = 0) throws -> BatchCall<Blerpc_FileReadResponse> {
+        var req = Blerpc_FileReadRequest()
+        req.handle = handle
+        req.offset = offset
+        req.length = length
+        return add("file_read", cmdName: CommandId.fileRead.wireName, requestData: try req.serializedData()) { try Blerpc_FileReadResponse(serializedBytes: $0) }
+    }
+
+    func fileWrite(handle: UInt32 = 0, offset: UInt32 = 0, data: Data = Data()) throws -> BatchCall<Blerpc_FileWriteResponse> {
+        var req = Blerpc_FileWriteRequest()
+        req.handle = handle
+        req.offset = offset
+        req.data = data
+        return add("file_write", cmdName: CommandId.fileWrite.wireName, requestData: try req.serializedData()) { try Blerpc_FileWriteResponse(serializedBytes: $0) }
+    }
+
+    func fileClose(handle: UInt32 = 0, verify: Bool = false) throws -> BatchCall<Blerpc_FileCloseResponse> {
+        var req = Blerpc_FileCloseRequest()
+        req.handle = handle
+        req.verify = verify
+        return add("file_close", cmdName: CommandId.fileClose.wireName, requestData: try req.serializedData()) { try Blerpc_FileCloseResponse(serializedBytes: $0) }
+    }
+
+    func getRpcStats(reset: Bool = false) throws -> BatchCall<Blerpc_GetRpcStatsResponse> {
+        var req = Blerpc_GetRpcStatsRequest()
+        req.reset = reset
+        return add("get_rpc_stats", cmdName: CommandId.getRpcStats.wireName, requestData: try req.serializedData()) { try Blerpc_GetRpcStatsResponse(serializedBytes: $0) }
+    }
+
+    func startSession() throws -> BatchCall<Blerpc_StartSessionResponse> {
+        var req = Blerpc_StartSessionRequest()
+        return add("start_session", cmdName: CommandId.startSession.wireName, requestData: try req.serializedData()) { try Blerpc_StartSessionResponse(serializedBytes: $0) }
+    }
+
+    func authenticateSession(proof: Data = Data()) throws -> BatchCall<Blerpc_AuthenticateSessionResponse> {
+        var req = Blerpc_AuthenticateSessionRequest()
+        req.proof = proof
+        return add("authenticate_session", cmdName: CommandId.authenticateSession.wireName, requestData: try req.serializedData()) { try Blerpc_AuthenticateSessionResponse(serializedBytes: $0) }
+    }
+
+    func timeSync(unixTimeUs: Int64 = 0, offsetUs: UInt32 = 0) throws -> BatchCall<Blerpc_TimeSyncResponse> {
+        var req = Blerpc_TimeSyncRequest()
+        req.unixTimeUs = unixTimeUs
+        req.offsetUs = offsetUs
+        return add("time_sync", cmdName: CommandId.timeSync.wireName, requestData: try req.serializedData()) { try Blerpc_TimeSyncResponse(serializedBytes: $0) }
+    }
+
+    func ping(payload: Data = Data()) throws -> BatchCall<Blerpc_PingResponse> {
+        var req = Blerpc_PingRequest()
+        req.payload = payload
+        return add("ping", cmdName: CommandId.ping.wireName, requestData: try req.serializedData()) { try Blerpc_PingResponse(serializedBytes: $0) }
+    }
+
+    func getCapabilities() throws -> BatchCall<Blerpc_GetCapabilitiesResponse> {
+        var req = Blerpc_GetCapabilitiesRequest()
+        return add("get_capabilities", cmdName: CommandId.getCapabilities.wireName, requestData: try req.serializedData()) { try Blerpc_GetCapabilitiesResponse(serializedBytes: $0) }
+    }
+
+    func getSetting(field: UInt32 = 0) throws -> BatchCall<Blerpc_GetSettingResponse> {
+        var req = Blerpc_GetSettingRequest()
+        req.field = field
+        return add("get_setting", cmdName: CommandId.getSetting.wireName, requestData: try req.serializedData()) { try Blerpc_GetSettingResponse(serializedBytes: $0) }
+    }
+
+    func setSetting(field: UInt32 = 0, value: Data = Data()) throws -> BatchCall<Blerpc_SetSettingResponse> {
+        var req = Blerpc_SetSettingRequest()
+        req.field = field
+        req.value = value
+        return add("set_setting", cmdName: CommandId.setSetting.wireName, requestData: try req.serializedData()) { try Blerpc_SetSettingResponse(serializedBytes: $0) }
+    }
+
+    private func add<T>(
+        _ command: String, cmdName: String, requestData: Data, parse: @escaping (Data) throws -> T
+    ) -> BatchCall<T> {
+        let call = BatchCall<T>(command: command)
+        let name = Data(cmdName.utf8)
+        payload.append(UInt8(name.count))
+        payload.append(name)
+        payload.append(UInt8(requestData.count & 0xFF))
+        payload.append(UInt8(requestData.count >> 8))
+        payload.append(requestData)
+        let client = self.client
+        pending.append((command, { data in
+            let result = Result { try client.decode(command, try data.get(), parse) }
+            call.result = result
+            if case .failure(let error) = result { return error }
+            return nil
+        }))
+        return call
+    }
+
+    /// Sends the calls added since the last send and records their results.
+    /// Throws the error of the first failed call once every result is
+    /// recorded, or the transport error, which every call then throws too.
+    func send() async throws {
+        let sent = pending
+        let requestData = payload
+        pending = []
+        payload = Data()
+        guard !sent.isEmpty else { return }
+        let respData: Data
+        do {
+            respData = try await client.exclusive { try await client.call(cmdName: batchCommand, requestData: requestData) }
+        } catch {
+            for entry in sent {
+                _ = entry.complete(.failure(error))
+            }
+            throw error
+        }
+        let bytes = [UInt8](respData)
+        var pos = 0
+        var firstError: Error?
+        for entry in sent {
+            let size = bytes.count - pos < 2 ? 0 : Int(bytes[pos]) | Int(bytes[pos + 1]) << 8
+            let data: Result<Data, Error>
+            if bytes.count - pos < 2 + size {
+                data = .failure(BatchError(message: "\(entry.command): no response in the batch"))
+                pos = bytes.count
+            } else {
+                data = .success(Data(bytes[(pos + 2)..<(pos + 2 + size)]))
+                pos += 2 + size
+            }
+            if let error = entry.complete(data), firstError == nil {
+                firstError = error
+            }
+        }
+        if let firstError { throw firstError }
+    }
+}
@@ -0,0 +1,1215 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import BlerpcProtocol
+import CryptoKit
+import Foundation
+import SwiftProtobuf
+
+/// Implemented by every error thrown by generated client methods.
+protocol BlerpcErrorProtocol: Error {}
+
+/// An error of a generated client method by kind, for exhaustive handling:
+///
+///     do { ... } catch { switch BlerpcError(error) { ... } }
+///
+/// Each case carries the error as thrown. Errors of the transport that are
+/// not blerpc errors, e.g. a lost BLE link, are transport errors.
+enum BlerpcError: Error {
+    case transport(any Error)
+    case timeout(TimeoutError)
+    case decode(DecodeError)
+    case remote(any RemoteError)
+    /// Any other error of the generated code, e.g. a failed verification.
+    case other(any BlerpcErrorProtocol)
+
+    init(_ error: any Error) {
+        switch error {
+        case let error as BlerpcError: self = error
+        case let error as TimeoutError: self = .timeout(error)
+        case let error as DecodeError: self = .decode(error)
+        case let error as any RemoteError: self = .remote(error)
+        case let error as TransportError: self = .transport(error)
+        case let error as any BlerpcErrorProtocol: self = .other(error)
+        default: self = .transport(error)
+        }
+    }
+}
+
+/// The request could not be delivered or the response was lost.
+struct TransportError: BlerpcErrorProtocol {
+    let message: String
+}
+
+/// The peripheral did not respond in time.
+struct TimeoutError: BlerpcErrorProtocol {
+    let command: String
+}
+
+/// The response payload is not a valid message.
+struct DecodeError: BlerpcErrorProtocol {
+    let command: String
+    let underlying: Error
+}
+
+/// The peripheral reported a non-OK status.
+protocol RemoteError: BlerpcErrorProtocol {
+    var command: String { get }
+    var status: Int { get }
+}
+
+/// Status codes of error responses; 128 and up are application codes.
+enum StatusCode: Int {
+    case ok = 0
+    case invalidArgument = 1
+    case notFound = 2
+    case alreadyExists = 3
+    case permissionDenied = 4
+    case resourceExhausted = 5
+    case failedPrecondition = 6
+    case outOfRange = 7
+    case unimplemented = 8
+    case internal = 9
+    case unavailable = 10
+    case unauthenticated = 11
+}
+
+/// The request is malformed or a field is out of bounds.
+struct InvalidArgumentError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.invalidArgument.rawValue }
+}
+
+/// The requested entity does not exist.
+struct NotFoundError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.notFound.rawValue }
+}
+
+/// The entity to create exists already.
+struct AlreadyExistsError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.alreadyExists.rawValue }
+}
+
+/// The caller may not run the command.
+struct PermissionDeniedError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.permissionDenied.rawValue }
+}
+
+/// Memory, storage or another resource ran out.
+struct ResourceExhaustedError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.resourceExhausted.rawValue }
+}
+
+/// The device is not in a state to run the command.
+struct FailedPreconditionError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.failedPrecondition.rawValue }
+}
+
+/// An offset or value lies past the valid range.
+struct OutOfRangeError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.outOfRange.rawValue }
+}
+
+/// The command is not supported by this firmware.
+struct UnimplementedError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.unimplemented.rawValue }
+}
+
+/// The firmware hit an unexpected error.
+struct InternalError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.internal.rawValue }
+}
+
+/// The device cannot run the command now; retry later.
+struct UnavailableError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.unavailable.rawValue }
+}
+
+/// The command needs an authenticated session.
+struct UnauthenticatedError: RemoteError {
+    let command: String
+    var status: Int { StatusCode.unauthenticated.rawValue }
+}
+
+/// An error response with an application or unknown status code.
+struct UnknownStatusError: RemoteError {
+    let command: String
+    let status: Int
+}
+
+/// Returns the error an error response reports, or nil for other responses.
+/// Error responses hold only a status, in a field no message uses.
+func statusError(_ command: String, _ data: Data) -> RemoteError? {
+    let tag: [UInt8] = [0xF8, 0xFF, 0xFF, 0xFF, 0x0F]
+    let bytes = [UInt8](data)
+    guard bytes.count > tag.count, Array(bytes[..<tag.count]) == tag else { return nil }
+    var status = 0
+    var shift = 0
+    for byte in bytes[tag.count...] {
+        status |= Int(byte & 0x7F) << shift
+        shift += 7
+        if byte < 0x80 { break }
+    }
+    switch StatusCode(rawValue: status) {
+    case .invalidArgument: return InvalidArgumentError(command: command)
+    case .notFound: return NotFoundError(command: command)
+    case .alreadyExists: return AlreadyExistsError(command: command)
+    case .permissionDenied: return PermissionDeniedError(command: command)
+    case .resourceExhausted: return ResourceExhaustedError(command: command)
+    case .failedPrecondition: return FailedPreconditionError(command: command)
+    case .outOfRange: return OutOfRangeError(command: command)
+    case .unimplemented: return UnimplementedError(command: command)
+    case .internal: return InternalError(command: command)
+    case .unavailable: return UnavailableError(command: command)
+    case .unauthenticated: return UnauthenticatedError(command: command)
+    default: return UnknownStatusError(command: command, status: status)
+    }
+}
+
+/// updateFirmware could not write or verify the image at path.
+struct FirmwareUpdateError: BlerpcErrorProtocol {
+    let path: String
+    let reason: String
+}
+
+/// A file moved by uploadFile or downloadFile failed its size or CRC-32 check.
+struct FileVerificationError: BlerpcErrorProtocol {
+    let path: String
+}
+
+/// The schema this client was generated from, which verifySchema compares
+/// with the peripheral's.
+let blerpcSchemaHash = "fb27bddde9c1f6b7"
+let blerpcGeneratorVersion = "0.1.0"
+
+/// The peripheral was built from a different schema than this client.
+struct SchemaMismatchError: BlerpcErrorProtocol {
+    let expected: String
+    let actual: String
+    let generatorVersion: String
+}
+
+/// A string argument is longer in UTF-8 than its max_size allows.
+struct StringTooLongError: BlerpcErrorProtocol {
+    let command: String
+    let field: String
+    let size: Int
+    let maxBytes: Int
+}
+
+/// Lets one RPC of a client run at a time, in call order. The peripheral
+/// handles one RPC at a time, so concurrent calls would interleave packets.
+actor CallSerializer {
+    private let passThrough: Bool
+    private var busy = false
+    private var waiters: [CheckedContinuation<Void, Never>] = []
+
+    /// A pass-through serializer lets every call run at once, for clients
+    /// that order the calls themselves, such as PriorityClient.
+    init(passThrough: Bool = false) {
+        self.passThrough = passThrough
+    }
+
+    func acquire() async {
+        if passThrough {
+            return
+        }
+        if busy {
+            await withCheckedContinuation { waiters.append($0) }
+        } else {
+            busy = true
+        }
+    }
+
+    func release() {
+        if waiters.isEmpty {
+            busy = false
+        } else {
+            waiters.removeFirst().resume()
+        }
+    }
+}
+
+/// Leads replay-protected requests with a counter, 8 bytes little endian,
+/// and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of
+/// the command name, a NUL, the counter and the request. The peripheral drops
+/// requests whose MAC is wrong or whose counter is not above the last one it
+/// accepted; microseconds since the epoch keep it increasing across restarts.
+enum ReplayCounter {
+    private static var last: UInt64 = 0
+    private static let lock = NSLock()
+
+    static func prefix(key: Data, command: String, _ requestData: Data) -> Data {
+        lock.lock()
+        defer { lock.unlock() }
+        last = max(last + 1, UInt64(Date().timeIntervalSince1970 * 1_000_000))
+        var value = last.littleEndian
+        let counter = Data(bytes: &value, count: 8)
+        let mac = HMAC<SHA256>.authenticationCode(
+            for: Data(command.utf8) + [0] + counter + requestData, using: SymmetricKey(data: key))
+        return counter + Data(mac).prefix(16) + requestData
+    }
+}
+
+/// Session state of a client: the key shared with the peripheral, which the
+/// session handshake proves knowledge of, and the token of the open session.
+final class BlerpcSession: @unchecked Sendable {
+    let key: Data
+    private var openToken: Data?
+    private let lock = NSLock()
+
+    init(key: Data) {
+        self.key = key
+    }
+
+    var token: Data? {
+        get {
+            lock.lock()
+            defer { lock.unlock() }
+            return openToken
+        }
+        set {
+            lock.lock()
+            defer { lock.unlock() }
+            openToken = newValue
+        }
+    }
+}
+
+/// Numeric command IDs, sent as one-character command names.
+enum CommandId: UInt8 {
+    case echo = 1
+    case flashRead = 2
+    case dataWrite = 3
+    case counterStream = 4
+    case counterUpload = 5
+    case getBlerpcInfo = 6
+    case dfuBegin = 8
+    case dfuChunk = 9
+    case dfuFinalize = 10
+    case fileOpen = 11
+    case fileRead = 12
+    case fileWrite = 13
+    case fileClose = 14
+    case logStream = 15
+    case getRpcStats = 16
+    case startSession = 17
+    case authenticateSession = 18
+    case timeSync = 19
+    case ping = 20
+    case getCapabilities = 21
+    case getSetting = 22
+    case setSetting = 23
+
+    /// The command name carrying this ID.
+    var wireName: String { String(UnicodeScalar(rawValue)) }
+}
+
+/// Timeout of each attempt of a command and how often it is retried.
+struct CallPolicy: Sendable {
+    let timeoutMs: UInt64
+    let retries: Int
+}
+
+/// Call policies by command. Commands without one wait as long as the
+/// transport does and are not retried.
+let callPolicies: [String: CallPolicy] = [
+    "echo": CallPolicy(timeoutMs: 500, retries: 2),
+    "flash_read": CallPolicy(timeoutMs: 30000, retries: 0),
+]
+
+/// CAPABILITIES flag of a peripheral answering compressed calls.
+let capabilityFlagCompression = 0x0002
+
+private let compressedCommand = "_compressed"
+private let flagCompressed: UInt8 = 0x01
+private let flagAcceptCompressed: UInt8 = 0x02
+
+/// Compresses and decompresses the data of compressed calls.
+struct CompressionCodec: Sendable {
+    let compress: @Sendable (Data) throws -> Data
+    let decompress: @Sendable (Data) throws -> Data
+
+    /// Raw DEFLATE (RFC 1951).
+    static let deflate = CompressionCodec(
+        compress: { try ($0 as NSData).compressed(using: .zlib) as Data },
+        decompress: { try ($0 as NSData).decompressed(using: .zlib) as Data }
+    )
+}
+
+/// Codecs by algorithm. DEFLATE is built in; register a heatshrink codec
+/// (window 8, lookahead 4 bits) to compress the heatshrink commands too.
+/// Calls of a command whose algorithm has no codec are sent uncompressed.
+enum CompressionCodecs {
+    private static let lock = NSLock()
+    private static var codecs: [String: CompressionCodec] = ["deflate": .deflate]
+
+    static subscript(algorithm: String) -> CompressionCodec? {
+        get {
+            lock.lock()
+            defer { lock.unlock() }
+            return codecs[algorithm]
+        }
+        set {
+            lock.lock()
+            defer { lock.unlock() }
+            codecs[algorithm] = newValue
+        }
+    }
+}
+
+/// Control command of the Cancel container, which stops the P→C stream in
+/// progress on the peripheral. The protocol library has no constant for it.
+let controlCmdCancel: UInt8 = 0x7
+
+/// Serializes a Cancel control container, which carries no payload.
+func cancelContainer(transactionId: UInt8) -> Data {
+    Data([transactionId, 0, 0xC0 | controlCmdCancel << 2, 0])
+}
+
+/// Auto-generated RPC method protocol.
+/// Conform to this protocol and implement call/streamReceive/streamSend.
+/// Clients are Sendable so they can be shared between tasks under Swift 6
+/// strict concurrency; classes that guard their state themselves conform
+/// with @unchecked Sendable.
+protocol GeneratedClientProtocol: Sendable {
+    /// One per client instance.
+    var callSerializer: CallSerializer { get }
+    func call(cmdName: String, requestData: Data) async throws -> Data
+    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data]
+    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data
+    /// Receives the responses of a P→C stream as they arrive. The default
+    /// yields the array streamReceive returns.
+    func streamReceiveStream(cmdName: String, requestData: Data) -> AsyncThrowingStream<Data, Error>
+    /// Sends a C→P stream whose messages are produced as it is sent. The
+    /// default collects them and calls the array variant.
+    func streamSend<S: AsyncSequence>(cmdName: String, messages: S, finalCmdName: String) async throws -> Data
+        where S.Element == Data
+    /// Flags of the peripheral's CAPABILITIES response; the default is 0.
+    var capabilityFlags: Int { get }
+    /// Stops the P→C stream in progress, whose consumer stopped early. The
+    /// default does nothing and the peripheral runs the stream to its end;
+    /// implement it to send a cancelContainer and drain the stream.
+    func streamCancel() async
+    /// Key and token of the session handshake; one per client instance.
+    var session: BlerpcSession { get }
+}
+
+extension GeneratedClientProtocol {
+    func decode<T>(_ command: String, _ data: Data, _ parse: (Data) throws -> T) throws -> T {
+        if let error = statusError(command, data) { throw error }
+        do {
+            return try parse(data)
+        } catch {
+            throw DecodeError(command: command, underlying: error)
+        }
+    }
+
+    func streamReceiveStream(cmdName: String, requestData: Data) -> AsyncThrowingStream<Data, Error> {
+        AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    for data in try await self.streamReceive(cmdName: cmdName, requestData: requestData) {
+                        continuation.yield(data)
+                    }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+
+    func streamSend<S: AsyncSequence>(cmdName: String, messages: S, finalCmdName: String) async throws -> Data
+        where S.Element == Data
+    {
+        var collected: [Data] = []
+        for try await data in messages {
+            collected.append(data)
+        }
+        return try await streamSend(cmdName: cmdName, messages: collected, finalCmdName: finalCmdName)
+    }
+
+    func streamCancel() async {}
+
+    var capabilityFlags: Int { 0 }
+
+    /// Runs `body` with no other RPC of this client in flight. The generated
+    /// methods call through here, and so should direct uses of call,
+    /// streamReceive and streamSend.
+    func exclusive<T>(_ body: () async throws -> T) async throws -> T {
+        await callSerializer.acquire()
+        do {
+            let result = try await body()
+            await callSerializer.release()
+            return result
+        } catch {
+            await callSerializer.release()
+            throw error
+        }
+    }
+
+    /// Runs `body` under the call policy of `command`: each attempt fails with
+    /// a TimeoutError after the timeout, and attempts that time out or fail
+    /// with a TransportError are made again up to the retries. A timed-out
+    /// attempt is cancelled, so call should honor cancellation.
+    func withCallPolicy(_ command: String, _ body: @escaping @Sendable () async throws -> Data) async throws -> Data {
+        let policy = callPolicies[command]!
+        var attempt = 0
+        while true {
+            do {
+                return try await withThrowingTaskGroup(of: Data.self) { group in
+                    group.addTask { try await body() }
+                    group.addTask {
+                        try await Task.sleep(nanoseconds: policy.timeoutMs * 1_000_000)
+                        throw TimeoutError(command: command)
+                    }
+                    defer { group.cancelAll() }
+                    return try await group.next()!
+                }
+            } catch let error where attempt < policy.retries && (error is TimeoutError || error is TransportError) {
+                attempt += 1
+            }
+        }
+    }
+
+    /// Sends a call of a compressed command in the compression envelope when
+    /// the peripheral advertises capabilityFlagCompression and
+    /// CompressionCodecs has a codec for the algorithm, compressing the request
+    /// when that makes it smaller, and returns the response decompressed;
+    /// sends a plain call otherwise.
+    func compressedCall(_ algorithm: String, cmdName: String, requestData: Data) async throws -> Data {
+        guard let codec = CompressionCodecs[algorithm],
+              capabilityFlags & capabilityFlagCompression != 0 else {
+            return try await call(cmdName: cmdName, requestData: requestData)
+        }
+        var flags = flagAcceptCompressed
+        var data = requestData
+        if let packed = try? codec.compress(requestData), packed.count < requestData.count {
+            flags |= flagCompressed
+            data = packed
+        }
+        let name = Data(cmdName.utf8)
+        var envelope = Data([UInt8(name.count)])
+        envelope.append(name)
+        envelope.append(flags)
+        envelope.append(data)
+        let respData = try await call(cmdName: compressedCommand, requestData: envelope)
+        guard let respFlags = respData.first else {
+            throw DecodeError(command: cmdName, underlying: CocoaError(.coderReadCorrupt))
+        }
+        let body = Data(respData.dropFirst())
+        guard respFlags & flagCompressed != 0 else { return body }
+        do {
+            return try codec.decompress(body)
+        } catch {
+            throw DecodeError(command: cmdName, underlying: error)
+        }
+    }
+
+    func echo(message: String = "") async throws -> Blerpc_EchoResponse {
+        if message.utf8.count > 256 {
+            throw StringTooLongError(command: "echo", field: "message", size: message.utf8.count, maxBytes: 256)
+        }
+        var req = Blerpc_EchoRequest()
+        req.message = message
+        let reqData = try req.serializedData()
+        let respData = try await exclusive {
+            try await withCallPolicy("echo") { try await self.compressedCall("deflate", cmdName: CommandId.echo.wireName, requestData: reqData) }
+        }
+        return try decode("echo", respData) { try Blerpc_EchoResponse(serializedBytes: $0) }
+    }
+
+    func flashRead(address: UInt32 = 0, length: UInt32 = 0) async throws -> Blerpc_FlashReadResponse {
+        var req = Blerpc_FlashReadRequest()
+        req.address = address
+        req.length = length
+        let reqData = ReplayCounter.prefix(key: session.key, command: "flash_read", try req.serializedData())
+        let respData = try await exclusive {
+            try await withCallPolicy("flash_read") { try await self.sessionCall(cmdName: CommandId.flashRead.wireName, requestData: reqData) }
+        }
+        return try decode("flash_read", respData) { try Blerpc_FlashReadResponse(serializedBytes: $0) }
+    }
+
+    @available(*, deprecated, message: "data_write is deprecated in the schema")
+    func dataWrite(data: Data = Data()) async throws -> Blerpc_DataWriteResponse {
+        var req = Blerpc_DataWriteRequest()
+        req.data = data
+        let respData = try await exclusive { try await call(cmdName: CommandId.dataWrite.wireName, requestData: try req.serializedData()) }
+        return try decode("data_write", respData) { try Blerpc_DataWriteResponse(serializedBytes: $0) }
+    }
+
+    func getBlerpcInfo() async throws -> Blerpc_GetBlerpcInfoResponse {
+        var req = Blerpc_GetBlerpcInfoRequest()
+        let respData = try await exclusive { try await call(cmdName: CommandId.getBlerpcInfo.wireName, requestData: try req.serializedData()) }
+        return try decode("get_blerpc_info", respData) { try Blerpc_GetBlerpcInfoResponse(serializedBytes: $0) }
+    }
+
+    func dfuBegin(size: UInt32 = 0, crc32: UInt32 = 0) async throws -> Blerpc_DfuBeginResponse {
+        var req = Blerpc_DfuBeginRequest()
+        req.size = size
+        req.crc32 = crc32
+        let respData = try await exclusive { try await call(cmdName: CommandId.dfuBegin.wireName, requestData: try req.serializedData()) }
+        return try decode("dfu_begin", respData) { try Blerpc_DfuBeginResponse(serializedBytes: $0) }
+    }
+
+    func dfuChunk(offset: UInt32 = 0, data: Data = Data()) async throws -> Blerpc_DfuChunkResponse {
+        var req = Blerpc_DfuChunkRequest()
+        req.offset = offset
+        req.data = data
+        let respData = try await exclusive { try await call(cmdName: CommandId.dfuChunk.wireName, requestData: try req.serializedData()) }
+        return try decode("dfu_chunk", respData) { try Blerpc_DfuChunkResponse(serializedBytes: $0) }
+    }
+
+    func dfuFinalize(reboot: Bool = false) async throws -> Blerpc_DfuFinalizeResponse {
+        var req = Blerpc_DfuFinalizeRequest()
+        req.reboot = reboot
+        let respData = try await exclusive { try await call(cmdName: CommandId.dfuFinalize.wireName, requestData: try req.serializedData()) }
+        return try decode("dfu_finalize", respData) { try Blerpc_DfuFinalizeResponse(serializedBytes: $0) }
+    }
+
+    func fileOpen(path: String = "", write: Bool = false, resume: Bool = false) async throws -> Blerpc_FileOpenResponse {
+        var req = Blerpc_FileOpenRequest()
+        req.path = path
+        req.write = write
+        req.resume = resume
+        let respData = try await exclusive { try await call(cmdName: CommandId.fileOpen.wireName, requestData: try req.serializedData()) }
+        return try decode("file_open", respData) { try Blerpc_FileOpenResponse(serializedBytes: $0) }
+    }
+
+    func fileRead(handle: UInt32 = 0, offset: UInt32 = 0, length: UInt32 = 0) async throws -> Blerpc_FileReadResponse {
+        var req = Blerpc_FileReadRequest()
+        req.handle = handle
+        req.offset = offset
+        req.length = length
+        let respData = try await exclusive { try await compressedCall("heatshrink", cmdName: CommandId.fileRead.wireName, requestData: try req.serializedData()) }
+        return try decode("file_read", respData) { try Blerpc_FileReadResponse(serializedBytes: $0) }
+    }
+
+    func fileWrite(handle: UInt32 = 0, offset: UInt32 = 0, data: Data = Data()) async throws -> Blerpc_FileWriteResponse {
+        var req = Blerpc_FileWriteRequest()
+        req.handle = handle
+        req.offset = offset
+        req.data = data
+        let respData = try await exclusive { try await call(cmdName: CommandId.fileWrite.wireName, requestData: try req.serializedData()) }
+        return try decode("file_write", respData) { try Blerpc_FileWriteResponse(serializedBytes: $0) }
+    }
+
+    func fileClose(handle: UInt32 = 0, verify: Bool = false) async throws -> Blerpc_FileCloseResponse {
+        var req = Blerpc_FileCloseRequest()
+        req.handle = handle
+        req.verify = verify
+        let respData = try await exclusive { try await call(cmdName: CommandId.fileClose.wireName, requestData: try req.serializedData()) }
+        return try decode("file_close", respData) { try Blerpc_FileCloseResponse(serializedBytes: $0) }
+    }
+
+    func getRpcStats(reset: Bool = false) async throws -> Blerpc_GetRpcStatsResponse {
+        var req = Blerpc_GetRpcStatsRequest()
+        req.reset = reset
+        let respData = try await exclusive { try await call(cmdName: CommandId.getRpcStats.wireName, requestData: try req.serializedData()) }
+        return try decode("get_rpc_stats", respData) { try Blerpc_GetRpcStatsResponse(serializedBytes: $0) }
+    }
+
+    func startSession() async throws -> Blerpc_StartSessionResponse {
+        var req = Blerpc_StartSessionRequest()
+        let respData = try await exclusive { try await call(cmdName: CommandId.startSession.wireName, requestData: try req.serializedData()) }
+        return try decode("start_session", respData) { try Blerpc_StartSessionResponse(serializedBytes: $0) }
+    }
+
+    func authenticateSession(proof: Data = Data()) async throws -> Blerpc_AuthenticateSessionResponse {
+        var req = Blerpc_AuthenticateSessionRequest()
+        req.proof = proof
+        let respData = try await exclusive { try await call(cmdName: CommandId.authenticateSession.wireName, requestData: try req.serializedData()) }
+        return try decode("authenticate_session", respData) { try Blerpc_AuthenticateSessionResponse(serializedBytes: $0) }
+    }
+
+    func timeSync(unixTimeUs: Int64 = 0, offsetUs: UInt32 = 0) async throws -> Blerpc_TimeSyncResponse {
+        var req = Blerpc_TimeSyncRequest()
+        req.unixTimeUs = unixTimeUs
+        req.offsetUs = offsetUs
+        let respData = try await exclusive { try await call(cmdName: CommandId.timeSync.wireName, requestData: try req.serializedData()) }
+        return try decode("time_sync", respData) { try Blerpc_TimeSyncResponse(serializedBytes: $0) }
+    }
+
+    func ping(payload: Data = Data()) async throws -> Blerpc_PingResponse {
+        var req = Blerpc_PingRequest()
+        req.payload = payload
+        let respData = try await exclusive { try await call(cmdName: CommandId.ping.wireName, requestData: try req.serializedData()) }
+        return try decode("ping", respData) { try Blerpc_PingResponse(serializedBytes: $0) }
+    }
+
+    func getCapabilities() async throws -> Blerpc_GetCapabilitiesResponse {
+        var req = Blerpc_GetCapabilitiesRequest()
+        let respData = try await exclusive { try await call(cmdName: CommandId.getCapabilities.wireName, requestData: try req.serializedData()) }
+        return try decode("get_capabilities", respData) { try Blerpc_GetCapabilitiesResponse(serializedBytes: $0) }
+    }
+
+    func getSetting(field: UInt32 = 0) async throws -> Blerpc_GetSettingResponse {
+        var req = Blerpc_GetSettingRequest()
+        req.field = field
+        let respData = try await exclusive { try await call(cmdName: CommandId.getSetting.wireName, requestData: try req.serializedData()) }
+        return try decode("get_setting", respData) { try Blerpc_GetSettingResponse(serializedBytes: $0) }
+    }
+
+    func setSetting(field: UInt32 = 0, value: Data = Data()) async throws -> Blerpc_SetSettingResponse {
+        var req = Blerpc_SetSettingRequest()
+        req.field = field
+        req.value = value
+        let respData = try await exclusive { try await call(cmdName: CommandId.setSetting.wireName, requestData: try req.serializedData()) }
+        return try decode("set_setting", respData) { try Blerpc_SetSettingResponse(serializedBytes: $0) }
+    }
+
+    func counterStream(count: UInt32 = 0) async throws -> [Blerpc_CounterStreamResponse] {
+        var req = Blerpc_CounterStreamRequest()
+        req.count = count
+        let responses = try await exclusive {
+            try await streamReceive(cmdName: CommandId.counterStream.wireName, requestData: try req.serializedData())
+        }
+        return try responses.map { data in try decode("counter_stream", data) { try Blerpc_CounterStreamResponse(serializedBytes: $0) } }
+    }
+
+    func counterStreamResponses(count: UInt32 = 0) -> AsyncThrowingStream<Blerpc_CounterStreamResponse, Error> {
+        var req = Blerpc_CounterStreamRequest()
+        req.count = count
+        let request = req
+        return AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    try await self.exclusive {
+                        let responses = self.streamReceiveStream(cmdName: CommandId.counterStream.wireName, requestData: try request.serializedData())
+                        do {
+                            for try await data in responses {
+                                let resp = try self.decode("counter_stream", data) { try Blerpc_CounterStreamResponse(serializedBytes: $0) }
+                                continuation.yield(resp)
+                            }
+                        } catch {
+                            if !Task.isCancelled { throw error }
+                        }
+                        if Task.isCancelled {
+                            // The consumer stopped early: stop the peripheral too, in a
+                            // task of its own as this one is cancelled.
+                            await Task { await self.streamCancel() }.value
+                        }
+                    }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+
+    func counterUpload(messages: [Blerpc_CounterUploadRequest]) async throws -> Blerpc_CounterUploadResponse {
+        let raw = try messages.map { try $0.serializedData() }
+        let respData = try await exclusive {
+            try await streamSend(cmdName: CommandId.counterUpload.wireName, messages: raw, finalCmdName: CommandId.counterUpload.wireName)
+        }
+        return try decode("counter_upload", respData) { try Blerpc_CounterUploadResponse(serializedBytes: $0) }
+    }
+
+    func counterUpload<S: AsyncSequence>(messages: S) async throws -> Blerpc_CounterUploadResponse where S.Element == Blerpc_CounterUploadRequest {
+        let raw = messages.map { try $0.serializedData() }
+        let respData = try await exclusive {
+            try await streamSend(cmdName: CommandId.counterUpload.wireName, messages: raw, finalCmdName: CommandId.counterUpload.wireName)
+        }
+        return try decode("counter_upload", respData) { try Blerpc_CounterUploadResponse(serializedBytes: $0) }
+    }
+
+    func logStream(minLevel: Int32 = 0, maxEntries: UInt32 = 0) async throws -> [Blerpc_LogStreamResponse] {
+        var req = Blerpc_LogStreamRequest()
+        req.minLevel = minLevel
+        req.maxEntries = maxEntries
+        let responses = try await exclusive {
+            try await streamReceive(cmdName: CommandId.logStream.wireName, requestData: try req.serializedData())
+        }
+        return try responses.map { data in try decode("log_stream", data) { try Blerpc_LogStreamResponse(serializedBytes: $0) } }
+    }
+
+    func logStreamResponses(minLevel: Int32 = 0, maxEntries: UInt32 = 0) -> AsyncThrowingStream<Blerpc_LogStreamResponse, Error> {
+        var req = Blerpc_LogStreamRequest()
+        req.minLevel = minLevel
+        req.maxEntries = maxEntries
+        let request = req
+        return AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    try await self.exclusive {
+                        let responses = self.streamReceiveStream(cmdName: CommandId.logStream.wireName, requestData: try request.serializedData())
+                        do {
+                            for try await data in responses {
+                                let resp = try self.decode("log_stream", data) { try Blerpc_LogStreamResponse(serializedBytes: $0) }
+                                continuation.yield(resp)
+                            }
+                        } catch {
+                            if !Task.isCancelled { throw error }
+                        }
+                        if Task.isCancelled {
+                            // The consumer stopped early: stop the peripheral too, in a
+                            // task of its own as this one is cancelled.
+                            await Task { await self.streamCancel() }.value
+                        }
+                    }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+
+    @available(*, deprecated, renamed: "flashRead")
+    func flashDump(address: UInt32 = 0, length: UInt32 = 0) async throws -> Blerpc_FlashReadResponse {
+        try await flashRead(address: address, length: length)
+    }
+
+    /// Calls `echo` and returns the message of its response.
+    func echoMessage(message: String = "") async throws -> String {
+        try await echo(message: message).message
+    }
+
+    /// Calls `dataWrite` and returns the length of its response.
+    @available(*, deprecated, message: "data_write is deprecated in the schema")
+    func dataWriteLength(data: Data = Data()) async throws -> UInt32 {
+        try await dataWrite(data: data).length
+    }
+
+    /// Checks that the peripheral was built from this client's schema. Call
+    /// once on connect; throws SchemaMismatchError when the schema hashes
+    /// differ.
+    @discardableResult
+    func verifySchema() async throws -> Blerpc_GetBlerpcInfoResponse {
+        let info = try await getBlerpcInfo()
+        guard info.schemaHash == blerpcSchemaHash else {
+            throw SchemaMismatchError(
+                expected: blerpcSchemaHash, actual: info.schemaHash, generatorVersion: info.generatorVersion)
+        }
+        return info
+    }
+
+    /// Returns the names of the commands the firmware implements; commands it
+    /// lacks, or leaves on their stubs, are missing. Query it once on connect
+    /// and check the set before calling commands some firmware versions lack.
+    func supportedCommands() async throws -> Set<String> {
+        let resp = try await getCapabilities()
+        let bitmap = [UInt8](resp.commandIds)
+        let ids: [String: Int] = [
+            "echo": 1,
+            "flash_read": 2,
+            "data_write": 3,
+            "counter_stream": 4,
+            "counter_upload": 5,
+            "get_blerpc_info": 6,
+            "dfu_begin": 8,
+            "dfu_chunk": 9,
+            "dfu_finalize": 10,
+            "file_open": 11,
+            "file_read": 12,
+            "file_write": 13,
+            "file_close": 14,
+            "log_stream": 15,
+            "get_rpc_stats": 16,
+            "start_session": 17,
+            "authenticate_session": 18,
+            "time_sync": 19,
+            "ping": 20,
+            "get_capabilities": 21,
+            "get_setting": 22,
+            "set_setting": 23,
+        ]
+        return Set(ids.filter { $0.value / 8 < bitmap.count && (bitmap[$0.value / 8] >> ($0.value % 8)) & 1 != 0 }.keys)
+    }
+
+    /// Reports whether the firmware implements `command`, e.g. "echo".
+    func supports(_ command: String) async throws -> Bool {
+        try await supportedCommands().contains(command)
+    }
+
+    /// Writes the firmware image at `path` to the peripheral and applies it.
+    /// An update interrupted before it was applied continues after the bytes
+    /// the peripheral already has when called again with the same image.
+    /// `progress` is called with the bytes sent and the total after each
+    /// chunk. With `reboot`, the peripheral restarts into the image once it is
+    /// verified.
+    func updateFirmware(
+        path: String,
+        reboot: Bool = true,
+        progress: ((Int, Int) -> Void)? = nil
+    ) async throws {
+        let image = [UInt8](try Data(contentsOf: URL(fileURLWithPath: path)))
+        let crc = firmwareCRC32(image)
+        let begun = try await dfuBegin(size: UInt32(image.count), crc32: crc)
+        var offset = Int(begun.offset)
+        let chunkSize = begun.maxChunk > 0 ? Int(begun.maxChunk) : 128
+        while offset < image.count {
+            let end = min(offset + chunkSize, image.count)
+            let resp = try await dfuChunk(offset: UInt32(offset), data: Data(image[offset..<end]))
+            if Int(resp.offset) == offset {
+                throw FirmwareUpdateError(path: path, reason: "stalled at byte \(offset)")
+            }
+            offset = Int(resp.offset)
+            progress?(offset, image.count)
+        }
+        let done = try await dfuFinalize(reboot: reboot)
+        if done.crc32 != crc {
+            throw FirmwareUpdateError(path: path, reason: "failed verification")
+        }
+    }
+
+    private func firmwareCRC32(_ bytes: [UInt8]) -> UInt32 {
+        var crc: UInt32 = 0xFFFF_FFFF
+        for byte in bytes {
+            crc ^= UInt32(byte)
+            for _ in 0..<8 {
+                crc = (crc >> 1) ^ (crc & 1 == 0 ? 0 : 0xEDB8_8320)
+            }
+        }
+        return ~crc
+    }
+
+    /// Writes `data` to `path` on the peripheral and verifies it by CRC-32.
+    /// With `resume`, an interrupted upload continues after the bytes already
+    /// written, if they match the start of `data`. `progress` is called with
+    /// the bytes sent and the total after each chunk.
+    func uploadFile(
+        path: String,
+        data: Data,
+        resume: Bool = false,
+        chunkSize: Int = 128,
+        progress: ((Int, Int) -> Void)? = nil
+    ) async throws {
+        let bytes = [UInt8](data)
+        var opened = try await fileOpen(path: path, write: true, resume: resume)
+        var offset = Int(opened.size)
+        if offset != 0 && (offset > bytes.count || fileCRC32(bytes[..<offset]) != opened.crc32) {
+            // The partial file is not a prefix of data: start over.
+            _ = try await fileClose(handle: opened.handle)
+            opened = try await fileOpen(path: path, write: true)
+            offset = 0
+        }
+        do {
+            while offset < bytes.count {
+                let end = min(offset + chunkSize, bytes.count)
+                _ = try await fileWrite(
+                    handle: opened.handle, offset: UInt32(offset), data: Data(bytes[offset..<end]))
+                offset = end
+                progress?(offset, bytes.count)
+            }
+        } catch {
+            _ = try? await fileClose(handle: opened.handle)
+            throw error
+        }
+        let closed = try await fileClose(handle: opened.handle, verify: true)
+        if Int(closed.size) != bytes.count || closed.crc32 != fileCRC32(bytes[...]) {
+            throw FileVerificationError(path: path)
+        }
+    }
+
+    /// Reads `path` from the peripheral and verifies it by CRC-32. Pass the
+    /// bytes received before an interruption as `partial` to continue after
+    /// them. `progress` is called with the bytes received and the total after
+    /// each chunk.
+    func downloadFile(
+        path: String,
+        partial: Data = Data(),
+        chunkSize: Int = 128,
+        progress: ((Int, Int) -> Void)? = nil
+    ) async throws -> Data {
+        let opened = try await fileOpen(path: path)
+        let size = Int(opened.size)
+        var bytes = [UInt8](partial.prefix(size))
+        do {
+            while bytes.count < size {
+                let resp = try await fileRead(
+                    handle: opened.handle, offset: UInt32(bytes.count), length: UInt32(chunkSize))
+                if resp.data.isEmpty { break }
+                bytes += resp.data
+                progress?(bytes.count, size)
+            }
+        } catch {
+            _ = try? await fileClose(handle: opened.handle)
+            throw error
+        }
+        _ = try await fileClose(handle: opened.handle)
+        if bytes.count != size || fileCRC32(bytes[...]) != opened.crc32 {
+            throw FileVerificationError(path: path)
+        }
+        return Data(bytes)
+    }
+
+    private func fileCRC32(_ bytes: ArraySlice<UInt8>) -> UInt32 {
+        var crc: UInt32 = 0xFFFF_FFFF
+        for byte in bytes {
+            crc ^= UInt32(byte)
+            for _ in 0..<8 {
+                crc = (crc >> 1) ^ (crc & 1 == 0 ? 0 : 0xEDB8_8320)
+            }
+        }
+        return ~crc
+    }
+
+    /// Drains the firmware log into (timestamp, entry) pairs, oldest first. The
+    /// message of each entry joins the fragments of a long message, and the
+    /// timestamp is when it was logged.
+    func readLogs(minLevel: Int32 = 0, maxEntries: UInt32 = 0) async throws -> [(timestamp: Date, entry: Blerpc_LogStreamResponse)] {
+        let responses = try await logStream(minLevel: minLevel, maxEntries: maxEntries)
+        let received = Date()
+        var logs: [(timestamp: Date, entry: Blerpc_LogStreamResponse)] = []
+        var first: Blerpc_LogStreamResponse?
+        for resp in responses {
+            if first == nil {
+                first = resp
+            } else {
+                first!.message += resp.message
+            }
+            guard !resp.more, let entry = first else { continue }
+            let ageMs = entry.nowMs &- entry.uptimeMs
+            logs.append((received.addingTimeInterval(-Double(ageMs) / 1000), entry))
+            first = nil
+        }
+        return logs
+    }
+
+    /// Pings the peripheral and returns the round trip in seconds.
+    func measureLatency() async throws -> TimeInterval {
+        let start = DispatchTime.now().uptimeNanoseconds
+        _ = try await ping()
+        return TimeInterval(DispatchTime.now().uptimeNanoseconds - start) / 1_000_000_000
+    }
+
+    /// Pings the peripheral every `interval` seconds until the task is
+    /// cancelled, passing each round trip to `onLatency`. Run it in a task next
+    /// to the calls: it throws as soon as a ping fails, so a dead connection
+    /// shows before a long call times out.
+    func keepalive(interval: TimeInterval = 5, onLatency: (TimeInterval) -> Void = { _ in }) async throws {
+        while true {
+            onLatency(try await measureLatency())
+            try await Task.sleep(nanoseconds: UInt64(interval * 1_000_000_000))
+        }
+    }
+
+    /// Returns the firmware's per-command counters keyed by command name.
+    func rpcStatsByCommand(reset: Bool = false) async throws -> [String: Blerpc_RpcStat] {
+        let resp = try await getRpcStats(reset: reset)
+        return Dictionary(resp.stats.map { ($0.name, $0) }, uniquingKeysWith: { _, last in last })
+    }
+
+    /// Authenticates to the peripheral, unlocking its session-protected
+    /// commands. Protected calls open a session as needed; call this to
+    /// authenticate up front.
+    func openSession() async throws {
+        _ = try await exclusive { try await handshake() }
+    }
+
+    /// Runs the session handshake inside the caller's exclusive call and keeps
+    /// the token: the proof is an HMAC-SHA256 of the challenge under the
+    /// session key.
+    private func handshake() async throws -> Data {
+        let startData = try await call(cmdName: CommandId.startSession.wireName, requestData: try Blerpc_StartSessionRequest().serializedData())
+        let challenge = try decode("start_session", startData) { try Blerpc_StartSessionResponse(serializedBytes: $0) }.challenge
+        var req = Blerpc_AuthenticateSessionRequest()
+        req.proof = Data(HMAC<SHA256>.authenticationCode(for: challenge, using: SymmetricKey(data: session.key)))
+        let authData = try await call(cmdName: CommandId.authenticateSession.wireName, requestData: try req.serializedData())
+        let token = try decode("authenticate_session", authData) { try Blerpc_AuthenticateSessionResponse(serializedBytes: $0) }.token
+        session.token = token
+        return token
+    }
+
+    /// Sends a session-protected request led by the session token, opening a
+    /// session first when there is none. A peripheral that lost the session,
+    /// e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent
+    /// once more in a new session.
+    func sessionCall(cmdName: String, requestData: Data) async throws -> Data {
+        let token: Data
+        if let open = session.token {
+            token = open
+        } else {
+            token = try await handshake()
+        }
+        let respData = try await call(cmdName: cmdName, requestData: token + requestData)
+        guard statusError(cmdName, respData) is UnauthenticatedError else { return respData }
+        return try await call(cmdName: cmdName, requestData: handshake() + requestData)
+    }
+
+    /// Reads the sample_interval_ms setting.
+    func getSampleIntervalMsSetting() async throws -> UInt32 {
+        let resp = try await getSetting(field: 1)
+        return try decode("get_setting", resp.value) { try Blerpc_DeviceSettings(serializedBytes: $0) }.sampleIntervalMs
+    }
+
+    /// Reads the leds_enabled setting.
+    func getLedsEnabledSetting() async throws -> Bool {
+        let resp = try await getSetting(field: 2)
+        return try decode("get_setting", resp.value) { try Blerpc_DeviceSettings(serializedBytes: $0) }.ledsEnabled
+    }
+
+    /// Writes the sample_interval_ms setting.
+    func setSampleIntervalMsSetting(_ value: UInt32) async throws {
+        var settings = Blerpc_DeviceSettings()
+        settings.sampleIntervalMs = value
+        _ = try await setSetting(field: 1, value: try settings.serializedData())
+    }
+
+    /// Writes the leds_enabled setting.
+    func setLedsEnabledSetting(_ value: Bool) async throws {
+        var settings = Blerpc_DeviceSettings()
+        settings.ledsEnabled = value
+        _ = try await setSetting(field: 2, value: try settings.serializedData())
+    }
+
+    /// Sets the peripheral's wall clock to this device's. With `compensate`, a
+    /// first exchange measures the round trip and the second adds half of it,
+    /// the estimated delivery delay.
+    @discardableResult
+    func syncTime(compensate: Bool = false) async throws -> Blerpc_TimeSyncResponse {
+        var offsetUs: UInt32 = 0
+        if compensate {
+            let start = DispatchTime.now().uptimeNanoseconds
+            _ = try await timeSync(unixTimeUs: Int64(Date().timeIntervalSince1970 * 1_000_000))
+            offsetUs = UInt32((DispatchTime.now().uptimeNanoseconds - start) / 2000)
+        }
+        return try await timeSync(unixTimeUs: Int64(Date().timeIntervalSince1970 * 1_000_000), offsetUs: offsetUs)
+    }
+}
+
+extension Blerpc_EchoResponse {
+    @available(*, deprecated, message: "Field 2 was removed from the schema; reads the default")
+    var uptimeMs: UInt32 { 0 }
+    @available(*, deprecated, message: "Field 3 was removed from the schema; reads the default")
+    var tags: [String] { [] }
+}
+
+/// Role each command requires on the peripheral, keyed by wire name; others need "user".
+let commandRoles: [String: String] = [
+    "flash_read": "factory",
+]
+
+/// The link an ActorClient sends containers over, e.g. the write and notify
+/// characteristics of a BLE connection.
+protocol PacketLink: Sendable {
+    /// Payload bytes of one write, which containers are split to.
+    var mtu: Int { get }
+    func write(_ data: Data) async throws
+    /// The next notification; throws when none arrives in `timeoutMs`.
+    func read(timeoutMs: Int) async throws -> Data
+}
+
+/// Sends the generated commands over a PacketLink. The actor isolates the
+/// splitter and assembler; callSerializer keeps the RPCs from interleaving
+/// across the suspension points of the link. Encryption and the
+/// CAPABILITIES exchange are left to the link's owner.
+actor ActorClient: GeneratedClientProtocol {
+    nonisolated let callSerializer = CallSerializer()
+    nonisolated let session: BlerpcSession
+    private let link: any PacketLink
+    private let splitter: ContainerSplitter
+    private let assembler = ContainerAssembler()
+    private let timeoutMs: Int
+
+    init(link: any PacketLink, sessionKey: Data, timeoutMs: Int = 2000) {
+        self.session = BlerpcSession(key: sessionKey)
+        self.link = link
+        self.splitter = ContainerSplitter(mtu: link.mtu)
+        self.timeoutMs = timeoutMs
+    }
+
+    func call(cmdName: String, requestData: Data) async throws -> Data {
+        try await send(cmdName: cmdName, data: requestData)
+        return try await receive(cmdName: cmdName)
+    }
+
+    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        try await send(cmdName: cmdName, data: requestData)
+        var responses: [Data] = []
+        while let data = try await receiveStreamed() {
+            responses.append(data)
+        }
+        return responses
+    }
+
+    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        for message in messages {
+            try await send(cmdName: cmdName, data: message)
+        }
+        try await link.write(makeStreamEndC2P(transactionId: splitter.nextTransactionId()).serialize())
+        return try await receive(cmdName: finalCmdName)
+    }
+
+    /// Stops the P→C stream in progress and drains it up to its end.
+    func streamCancel() async {
+        do {
+            try await link.write(cancelContainer(transactionId: splitter.nextTransactionId()))
+            while try await receiveStreamed() != nil {}
+        } catch {
+            // The stream ended some other way; the next call starts afresh.
+        }
+        assembler.reset()
+    }
+
+    private func send(cmdName: String, data: Data) async throws {
+        let payload = try CommandPacket(cmdType: .request, cmdName: cmdName, data: data).serialize()
+        for container in try splitter.split(payload) {
+            try await link.write(container.serialize())
+        }
+        assembler.reset()
+    }
+
+    /// The response to `cmdName`.
+    private func receive(cmdName: String) async throws -> Data {
+        while true {
+            let container = try Container.deserialize(try await link.read(timeoutMs: timeoutMs))
+            if container.containerType == .control {
+                try checkControl(container)
+                continue
+            }
+            if let packet = try assembled(container) {
+                guard packet.cmdName == cmdName else {
+                    throw TransportError(message: "expected a response to \(cmdName), got \(packet.cmdName)")
+                }
+                return packet.data
+            }
+        }
+    }
+
+    /// The next response of a P→C stream, or nil at its end.
+    private func receiveStreamed() async throws -> Data? {
+        while true {
+            let container = try Container.deserialize(try await link.read(timeoutMs: timeoutMs))
+            if container.containerType == .control {
+                if container.controlCmd == .streamEndP2C {
+                    return nil
+                }
+                try checkControl(container)
+                continue
+            }
+            if let packet = try assembled(container) {
+                return packet.data
+            }
+        }
+    }
+
+    /// Feeds `container` to the assembler; the response once complete.
+    private func assembled(_ container: Container) throws -> CommandPacket? {
+        guard let payload = assembler.feed(container) else { return nil }
+        let packet = try CommandPacket.deserialize(payload)
+        guard packet.cmdType == .response else {
+            throw TransportError(message: "unexpected packet type \(packet.cmdType.rawValue)")
+        }
+        return packet
+    }
+
+    private func checkControl(_ container: Container) throws {
+        if container.controlCmd == .error, let code = container.payload.first {
+            throw TransportError(message: "peripheral error \(code)")
+        }
+    }
+}
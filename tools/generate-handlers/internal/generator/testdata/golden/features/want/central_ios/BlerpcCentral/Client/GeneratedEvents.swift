@@ -0,0 +1,24 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+extension BlerpcClient {
+    /// The ButtonEvent events the peripheral notifies; iterate to subscribe.
+    func subscribeButtonEvent() -> AsyncThrowingStream<Blerpc_ButtonEvent, Error> {
+        let events = self.events(eventName: "button_event")
+        return AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    for try await data in events {
+                        let event = try self.decode("button_event", data) { try Blerpc_ButtonEvent(serializedBytes: $0) }
+                        continuation.yield(event)
+                    }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+}
@@ -0,0 +1,286 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+
+/// A frame is malformed or out of sequence, or a message too large.
+struct FramingError: BlerpcErrorProtocol {
+    let message: String
+}
+
+/// A message failed its CRC-32 check: it was corrupted on the way.
+struct IntegrityError: BlerpcErrorProtocol {
+    let message: String
+}
+
+/// Frames carry a message larger than the ATT MTU:
+///
+///     flags | id | seq | [length, uint16 LE, FIRST only] | data
+///
+/// id is the correlation ID the client picked for a request, which its
+/// responses carry back, so up to maxInFlight requests can be in flight and
+/// their frames interleaved. seq counts the frames of a message from 0. A
+/// frame with FIRST starts a new message under its ID, dropping one partly
+/// reassembled. MORE marks a message followed by another under the same ID,
+/// as the responses of a stream but the last.
+enum Framing {
+    static let frameFirst: UInt8 = 0x01
+    static let frameLast: UInt8 = 0x02
+    static let frameMore: UInt8 = 0x04
+    static let frameHeaderSize = 3
+    static let frameFirstHeaderSize = 5
+
+    /// Messages the peripheral reassembles at once; no more calls are in flight.
+    static let maxInFlight = 4
+
+    /// Each message travels with its CRC-32 (IEEE 802.3, little endian) after
+    /// its data; length leaves it out. The reassembler checks and strips it.
+    static let crcSize = 4
+
+    /// ATT opcode and handle ahead of each notification or write.
+    static let attOverhead = 3
+
+    /// Largest message a frame header can announce.
+    static let maxMessageSize = 0xFFFF
+
+    /// Splits message into frames fitting the ATT MTU mtu.
+    static func fragment(_ message: Data, mtu: Int, correlationId: UInt8) throws -> [Data] {
+        if message.isEmpty || message.count > maxMessageSize {
+            throw FramingError(message: "cannot frame a message of \(message.count) bytes")
+        }
+        let frameSize = mtu - attOverhead
+        if frameSize <= frameFirstHeaderSize {
+            throw FramingError(message: "MTU \(mtu) leaves no room for data")
+        }
+        let crc = crc32([UInt8](message)[...])
+        let bytes = [UInt8](message) + (0..<crcSize).map { UInt8(truncatingIfNeeded: crc >> (8 * $0)) }
+        var frames: [Data] = []
+        var offset = 0
+        while offset < bytes.count {
+            let first = offset == 0
+            let headerSize = first ? frameFirstHeaderSize : frameHeaderSize
+            let n = min(bytes.count - offset, frameSize - headerSize)
+            var flags: UInt8 = first ? frameFirst : 0
+            if offset + n == bytes.count { flags |= frameLast }
+            var frame: [UInt8] = [flags, correlationId, UInt8(truncatingIfNeeded: frames.count)]
+            if first {
+                frame.append(UInt8(message.count & 0xFF))
+                frame.append(UInt8(message.count >> 8))
+            }
+            frame.append(contentsOf: bytes[offset..<offset + n])
+            frames.append(Data(frame))
+            offset += n
+        }
+        return frames
+    }
+
+    /// Returns the message ahead of the CRC-32 ending payload, once checked.
+    static func stripCRC(_ payload: [UInt8]) throws -> Data {
+        let n = payload.count - crcSize
+        let crc = (0..<crcSize).reduce(UInt32(0)) { $0 | UInt32(payload[n + $1]) << (8 * $1) }
+        guard crc32(payload[..<n]) == crc else {
+            throw IntegrityError(message: "message failed its CRC-32 check")
+        }
+        return Data(payload[..<n])
+    }
+
+    private static func crc32(_ bytes: ArraySlice<UInt8>) -> UInt32 {
+        var crc: UInt32 = 0xFFFF_FFFF
+        for byte in bytes {
+            crc ^= UInt32(byte)
+            for _ in 0..<8 {
+                crc = (crc >> 1) ^ (crc & 1 == 0 ? 0 : 0xEDB8_8320)
+            }
+        }
+        return ~crc
+    }
+}
+
+/// A reassembled message and the correlation ID it arrived under.
+struct FrameMessage {
+    let correlationId: UInt8
+    let data: Data
+    /// Another message follows under the same ID.
+    let more: Bool
+}
+
+/// Puts frames back together into messages, one per correlation ID. feed
+/// returns the message once its last frame arrived. After a FramingError the
+/// ID of the frame waits for the next FIRST frame.
+struct FrameReassembler {
+    private struct Partial {
+        var buf: [UInt8] = []
+        let total: Int
+        var nextSeq: UInt8 = 0
+    }
+
+    private let maxSize: Int
+    private var partial: [UInt8: Partial] = [:]
+
+    init(maxSize: Int = Framing.maxMessageSize) {
+        self.maxSize = maxSize
+    }
+
+    /// Drops every partly reassembled message.
+    mutating func reset() {
+        partial.removeAll()
+    }
+
+    /// Adds a received frame; returns the message once complete, else nil.
+    mutating func feed(_ frame: Data) throws -> FrameMessage? {
+        let bytes = [UInt8](frame)
+        guard bytes.count >= Framing.frameHeaderSize else {
+            throw FramingError(message: "short frame")
+        }
+        do {
+            return try feedFrame(bytes)
+        } catch {
+            partial[bytes[1]] = nil
+            throw error
+        }
+    }
+
+    private mutating func feedFrame(_ frame: [UInt8]) throws -> FrameMessage? {
+        let flags = frame[0]
+        let id = frame[1]
+        let seq = frame[2]
+        var p: Partial
+        let headerSize: Int
+        if flags & Framing.frameFirst != 0 {
+            guard frame.count >= Framing.frameFirstHeaderSize, seq == 0 else {
+                throw FramingError(message: "malformed first frame")
+            }
+            let length = Int(frame[3]) | Int(frame[4]) << 8
+            guard length != 0 else { throw FramingError(message: "empty message") }
+            guard length <= maxSize else {
+                throw FramingError(message: "message of \(length) bytes exceeds \(maxSize)")
+            }
+            p = Partial(total: length + Framing.crcSize)
+            headerSize = Framing.frameFirstHeaderSize
+        } else {
+            guard let existing = partial[id], existing.nextSeq == seq else {
+                throw FramingError(message: "frame \(seq) of call \(id) out of sequence")
+            }
+            p = existing
+            headerSize = Framing.frameHeaderSize
+        }
+        let data = frame[headerSize...]
+        guard p.buf.count + data.count <= p.total else {
+            throw FramingError(message: "frames exceed the message length")
+        }
+        p.buf.append(contentsOf: data)
+        p.nextSeq &+= 1
+        if flags & Framing.frameLast == 0 {
+            partial[id] = p
+            return nil
+        }
+        guard p.buf.count == p.total else {
+            throw FramingError(message: "message ends short of its length")
+        }
+        partial[id] = nil
+        let message = try Framing.stripCRC(p.buf)
+        return FrameMessage(correlationId: id, data: message, more: flags & Framing.frameMore != 0)
+    }
+}
+
+/// Runs calls concurrently over one connection. Each call gets a free
+/// correlation ID and its responses are routed back by it, so a slow call or
+/// stream does not hold up the others. write sends one frame to the
+/// peripheral; pass every notified frame to onFrame and call failAll when the
+/// connection drops.
+actor FramePipeline {
+    private let write: @Sendable (Data) async throws -> Void
+    private let maxInFlight: Int
+    private var calls: [UInt8: AsyncThrowingStream<FrameMessage, Error>.Continuation] = [:]
+    private var waiting: [CheckedContinuation<Void, Never>] = []
+    private var reassembler = FrameReassembler()
+    private var nextId: UInt8 = 0
+    var mtu: Int
+
+    init(mtu: Int, maxInFlight: Int = Framing.maxInFlight,
+         write: @escaping @Sendable (Data) async throws -> Void) {
+        self.mtu = mtu
+        self.maxInFlight = maxInFlight
+        self.write = write
+    }
+
+    func setMtu(_ mtu: Int) {
+        self.mtu = mtu
+    }
+
+    /// Sends a request and returns its response.
+    func call(_ message: Data) async throws -> Data {
+        var response = Data()
+        for try await data in stream(message) {
+            response = data
+        }
+        return response
+    }
+
+    /// Sends a request and yields its responses until the last.
+    nonisolated func stream(_ message: Data) -> AsyncThrowingStream<Data, Error> {
+        AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    try await self.run(message) { continuation.yield($0) }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+
+    private func run(_ message: Data, yield: @Sendable (Data) -> Void) async throws {
+        while calls.count >= maxInFlight {
+            await withCheckedContinuation { waiting.append($0) }
+        }
+        let id = allocate()
+        let (responses, continuation) = AsyncThrowingStream<FrameMessage, Error>.makeStream()
+        calls[id] = continuation
+        defer { release(id) }
+        for frame in try Framing.fragment(message, mtu: mtu, correlationId: id) {
+            try await write(frame)
+        }
+        for try await msg in responses {
+            yield(msg.data)
+            if !msg.more { return }
+        }
+    }
+
+    private func allocate() -> UInt8 {
+        while calls[nextId] != nil {
+            nextId &+= 1
+        }
+        let id = nextId
+        nextId &+= 1
+        return id
+    }
+
+    private func release(_ id: UInt8) {
+        calls[id] = nil
+        if !waiting.isEmpty {
+            waiting.removeFirst().resume()
+        }
+    }
+
+    /// Routes a notified frame to the call it belongs to.
+    func onFrame(_ frame: Data) {
+        do {
+            if let msg = try reassembler.feed(frame) {
+                calls[msg.correlationId]?.yield(msg)
+            }
+        } catch {
+            if frame.count > 1 {
+                calls[frame[frame.startIndex + 1]]?.finish(throwing: error)
+            }
+        }
+    }
+
+    /// Fails every call in flight, e.g. with a TransportError on disconnect.
+    func failAll(_ error: Error) {
+        reassembler.reset()
+        for continuation in calls.values {
+            continuation.finish(throwing: error)
+        }
+    }
+}
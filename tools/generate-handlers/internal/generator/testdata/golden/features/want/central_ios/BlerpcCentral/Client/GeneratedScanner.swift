@@ -0,0 +1,36 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import CoreBluetooth
+
+/// A blerpc peripheral found by a scan; pass `device` to connect.
+struct DiscoveredDevice: Identifiable {
+    let device: ScannedDevice
+    /// The decoded (blerpc.advertising) message, if the device sent one.
+    let advertisement: GeneratedAdvertisement?
+
+    var id: UUID { device.id }
+    var name: String? { device.name }
+    var rssi: Int { device.rssi }
+}
+
+/// Picks the blerpc peripherals out of scan results.
+enum GeneratedScanner {
+    /// Service UUID advertised by blerpc peripherals.
+    static let serviceUUID = CBUUID(string: "6e400001-b5a3-f393-e0a9-e50e24dcca9e")
+
+    /// Returns `device` as a DiscoveredDevice, or nil if it is not a blerpc peripheral.
+    static func discover(_ device: ScannedDevice, requireAdvertisement: Bool = false) -> DiscoveredDevice? {
+        guard device.serviceUUIDs.contains(serviceUUID) else {
+            return nil
+        }
+        let advertisement = device.manufacturerData.flatMap(GeneratedAdvertisement.init(manufacturerData:))
+        if requireAdvertisement && advertisement == nil {
+            return nil
+        }
+        return DiscoveredDevice(device: device, advertisement: advertisement)
+    }
+
+    /// Returns the blerpc peripherals among `devices`, strongest first.
+    static func discoverAll(_ devices: [ScannedDevice], requireAdvertisement: Bool = false) -> [DiscoveredDevice] {
+        devices.compactMap { discover($0, requireAdvertisement: requireAdvertisement) }.sorted { $0.rssi > $1.rssi }
+    }
+}
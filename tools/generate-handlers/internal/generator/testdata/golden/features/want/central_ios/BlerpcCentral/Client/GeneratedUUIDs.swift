@@ -0,0 +1,11 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import CoreBluetooth
+
+/// GATT UUIDs of the blerpc service, from blerpc.yaml.
+enum GeneratedUUIDs {
+    /// Service advertised by blerpc peripherals.
+    static let service = CBUUID(string: "6e400001-b5a3-f393-e0a9-e50e24dcca9e")
+
+    /// The multiplexed RPC characteristic.
+    static let characteristic = CBUUID(string: "6e400002-b5a3-f393-e0a9-e50e24dcca9e")
+}
@@ -0,0 +1,179 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+
+/// The schema name of the command the generated methods send as `cmdName`.
+private func interceptedCommand(_ cmdName: String) -> String {
+    switch cmdName {
+    case CommandId.echo.wireName: return "echo"
+    case CommandId.flashRead.wireName: return "flash_read"
+    case CommandId.dataWrite.wireName: return "data_write"
+    case CommandId.counterStream.wireName: return "counter_stream"
+    case CommandId.counterUpload.wireName: return "counter_upload"
+    case CommandId.getBlerpcInfo.wireName: return "get_blerpc_info"
+    case CommandId.dfuBegin.wireName: return "dfu_begin"
+    case CommandId.dfuChunk.wireName: return "dfu_chunk"
+    case CommandId.dfuFinalize.wireName: return "dfu_finalize"
+    case CommandId.fileOpen.wireName: return "file_open"
+    case CommandId.fileRead.wireName: return "file_read"
+    case CommandId.fileWrite.wireName: return "file_write"
+    case CommandId.fileClose.wireName: return "file_close"
+    case CommandId.logStream.wireName: return "log_stream"
+    case CommandId.getRpcStats.wireName: return "get_rpc_stats"
+    case CommandId.startSession.wireName: return "start_session"
+    case CommandId.authenticateSession.wireName: return "authenticate_session"
+    case CommandId.timeSync.wireName: return "time_sync"
+    case CommandId.ping.wireName: return "ping"
+    case CommandId.getCapabilities.wireName: return "get_capabilities"
+    case CommandId.getSetting.wireName: return "get_setting"
+    case CommandId.setSetting.wireName: return "set_setting"
+    default: return cmdName
+    }
+}
+
+/// Observes the calls of an InstrumentedClient. The methods default to doing
+/// nothing; implement what you need.
+///
+/// `command` is the command's name as in the schema. Sizes are payload bytes,
+/// summed over the messages of a stream; durations are in seconds.
+protocol CallInterceptor: AnyObject {
+    /// Called before the request is sent.
+    func onRequest(command: String, requestSize: Int)
+    /// Called when the peripheral answered the call.
+    func onResponse(command: String, requestSize: Int, responseSize: Int, duration: TimeInterval)
+    /// Called when the call failed, with the error it threw.
+    func onError(command: String, requestSize: Int, error: Error, duration: TimeInterval)
+}
+
+extension CallInterceptor {
+    func onRequest(command: String, requestSize: Int) {}
+    func onResponse(command: String, requestSize: Int, responseSize: Int, duration: TimeInterval) {}
+    func onError(command: String, requestSize: Int, error: Error, duration: TimeInterval) {}
+}
+
+/// Counters of one command, as MetricsCollector keeps them.
+struct CommandMetrics: Sendable {
+    var calls = 0
+    var errors = 0
+    var requestBytes = 0
+    var responseBytes = 0
+    var totalDuration: TimeInterval = 0
+    var maxDuration: TimeInterval = 0
+
+    var meanDuration: TimeInterval { calls > 0 ? totalDuration / Double(calls) : 0 }
+}
+
+/// Counts calls, errors, bytes and durations per command. Read the counters
+/// with snapshot(), e.g. periodically for a dashboard.
+final class MetricsCollector: CallInterceptor, @unchecked Sendable {
+    private let lock = NSLock()
+    private var metrics: [String: CommandMetrics] = [:]
+
+    init() {}
+
+    func onResponse(command: String, requestSize: Int, responseSize: Int, duration: TimeInterval) {
+        record(command, requestSize, responseSize, duration, failed: false)
+    }
+
+    func onError(command: String, requestSize: Int, error: Error, duration: TimeInterval) {
+        record(command, requestSize, 0, duration, failed: true)
+    }
+
+    /// Returns a copy of the counters by command.
+    func snapshot() -> [String: CommandMetrics] {
+        lock.lock()
+        defer { lock.unlock() }
+        return metrics
+    }
+
+    /// Clears the counters.
+    func reset() {
+        lock.lock()
+        defer { lock.unlock() }
+        metrics.removeAll()
+    }
+
+    private func record(
+        _ command: String, _ requestSize: Int, _ responseSize: Int, _ duration: TimeInterval, failed: Bool
+    ) {
+        lock.lock()
+        defer { lock.unlock() }
+        var m = metrics[command] ?? CommandMetrics()
+        m.calls += 1
+        if failed {
+            m.errors += 1
+        }
+        m.requestBytes += requestSize
+        m.responseBytes += responseSize
+        m.totalDuration += duration
+        m.maxDuration = max(m.maxDuration, duration)
+        metrics[command] = m
+    }
+}
+
+/// Reports every call made through it to `interceptors`.
+///
+/// Interceptors see the exchanges with the peripheral: a response with an
+/// error status, or one that fails to decode, counts as a response, as the
+/// generated method throws after the exchange. Calls made on `client`
+/// directly are not reported.
+final class InstrumentedClient: GeneratedClientProtocol, @unchecked Sendable {
+    private let client: any GeneratedClientProtocol
+    private let interceptors: [any CallInterceptor]
+    let callSerializer = CallSerializer()
+
+    init(client: any GeneratedClientProtocol, interceptors: [any CallInterceptor]) {
+        self.client = client
+        self.interceptors = interceptors
+    }
+
+    var capabilityFlags: Int { client.capabilityFlags }
+
+    func call(cmdName: String, requestData: Data) async throws -> Data {
+        try await observe(cmdName, requestSize: requestData.count, responseSize: { $0.count }) {
+            try await self.client.call(cmdName: cmdName, requestData: requestData)
+        }
+    }
+
+    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        try await observe(cmdName, requestSize: requestData.count, responseSize: { $0.reduce(0) { $0 + $1.count } }) {
+            try await self.client.streamReceive(cmdName: cmdName, requestData: requestData)
+        }
+    }
+
+    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        let size = messages.reduce(0) { $0 + $1.count }
+        return try await observe(cmdName, requestSize: size, responseSize: { $0.count }) {
+            try await self.client.streamSend(cmdName: cmdName, messages: messages, finalCmdName: finalCmdName)
+        }
+    }
+
+    private func observe<T>(
+        _ cmdName: String,
+        requestSize: Int,
+        responseSize: (T) -> Int,
+        _ body: () async throws -> T
+    ) async throws -> T {
+        let command = interceptedCommand(cmdName)
+        interceptors.forEach { $0.onRequest(command: command, requestSize: requestSize) }
+        let start = DispatchTime.now().uptimeNanoseconds
+        func elapsed() -> TimeInterval {
+            TimeInterval(DispatchTime.now().uptimeNanoseconds - start) / 1_000_000_000
+        }
+        let result: T
+        do {
+            result = try await body()
+        } catch {
+            let duration = elapsed()
+            interceptors.forEach {
+                $0.onError(command: command, requestSize: requestSize, error: error, duration: duration)
+            }
+            throw error
+        }
+        let duration = elapsed()
+        let size = responseSize(result)
+        interceptors.forEach {
+            $0.onResponse(command: command, requestSize: requestSize, responseSize: size, duration: duration)
+        }
+        return result
+    }
+}
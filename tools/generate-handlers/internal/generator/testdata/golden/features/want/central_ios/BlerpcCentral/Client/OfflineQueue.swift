@@ -0,0 +1,107 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+
+/// Time to live in seconds of each queueable command.
+let queueableCommands: [String: TimeInterval] = [
+    "data_write": 3600,
+]
+
+/// A call waiting in an OfflineQueue.
+struct QueuedCall: Codable, Equatable {
+    let command: String
+    let request: Data
+    let expiresAt: Date
+}
+
+/// The queued call expired before a connection came up.
+struct QueuedCallExpiredError: BlerpcErrorProtocol {
+    let command: String
+}
+
+/// Persists the calls of an OfflineQueue.
+protocol QueueStore {
+    func load() throws -> [QueuedCall]
+    func save(_ calls: [QueuedCall]) throws
+}
+
+/// Keeps the queue in a file, e.g. in the application support directory.
+struct FileQueueStore: QueueStore {
+    let url: URL
+
+    func load() throws -> [QueuedCall] {
+        guard FileManager.default.fileExists(atPath: url.path) else { return [] }
+        return try JSONDecoder().decode([QueuedCall].self, from: Data(contentsOf: url))
+    }
+
+    func save(_ calls: [QueuedCall]) throws {
+        try JSONEncoder().encode(calls).write(to: url, options: .atomic)
+    }
+}
+
+/// Persistent queue of calls made while the device is out of reach.
+///
+/// Call flush after connecting. Calls are sent in the order they were queued
+/// and never after they expire. A call failing without an answer from the
+/// peripheral stops the flush and stays queued; one rejected with a
+/// RemoteError is reported and dropped.
+actor OfflineQueue {
+    private let store: any QueueStore
+    private let now: () -> Date
+    private var calls: [QueuedCall]
+    private var flushing = false
+
+    init(store: any QueueStore, now: @escaping () -> Date = Date.init) throws {
+        self.store = store
+        self.now = now
+        calls = try store.load()
+    }
+
+    var count: Int { calls.count }
+
+    func enqueueDataWrite(_ request: Blerpc_DataWriteRequest) throws {
+        try enqueue(command: "data_write", request: request.serializedData())
+    }
+
+    /// Sends the queued calls through `client` and passes each outcome to
+    /// `onResult`; expired calls fail with QueuedCallExpiredError. Returns the
+    /// number of calls left, right away if another flush is running.
+    @discardableResult
+    func flush(
+        client: any GeneratedClientProtocol,
+        onResult: (QueuedCall, Result<Data, Error>) -> Void = { _, _ in }
+    ) async throws -> Int {
+        guard !flushing else { return calls.count }
+        flushing = true
+        defer { flushing = false }
+        while let call = calls.first {
+            let result: Result<Data, Error>
+            if now() >= call.expiresAt {
+                result = .failure(QueuedCallExpiredError(command: call.command))
+            } else {
+                do {
+                    let data = try await client.exclusive {
+                        try await client.call(cmdName: call.command, requestData: call.request)
+                    }
+                    result = .success(data)
+                } catch let error as any RemoteError {
+                    result = .failure(error)
+                } catch {
+                    if error is CancellationError {
+                        throw error
+                    }
+                    return calls.count
+                }
+            }
+            calls.removeFirst()
+            try store.save(calls)
+            onResult(call, result)
+        }
+        return 0
+    }
+
+    private func enqueue(command: String, request: Data) throws {
+        let ttl = queueableCommands[command] ?? 0
+        calls.append(QueuedCall(command: command, request: request, expiresAt: now().addingTimeInterval(ttl)))
+        try store.save(calls)
+    }
+}
@@ -0,0 +1,214 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+
+/// State of the link a ConnectionManager maintains.
+///
+/// disconnected → connecting → ready, and back to disconnected when the link
+/// drops or every connect attempt fails. A call failing on a live link, e.g.
+/// with a timeout, moves ready to degraded; the next call that succeeds moves
+/// it back.
+public enum LinkState: Sendable {
+    case disconnected
+    case connecting
+    case ready
+    case degraded
+}
+
+/// Backoff and replay policy of ConnectionManager. Subclass to override.
+open class ReconnectPolicy: @unchecked Sendable {
+    public let initialDelay: TimeInterval
+    public let maxDelay: TimeInterval
+    public let multiplier: Double
+    /// Up to this fraction of each delay is dropped at random.
+    public let jitter: Double
+    /// Connect attempts per reconnect; 0 tries forever.
+    public let maxAttempts: Int
+    /// Replays of an interrupted idempotent call.
+    public let maxReplays: Int
+
+    public init(
+        initialDelay: TimeInterval = 0.5,
+        maxDelay: TimeInterval = 30,
+        multiplier: Double = 2,
+        jitter: Double = 0.2,
+        maxAttempts: Int = 5,
+        maxReplays: Int = 1
+    ) {
+        self.initialDelay = initialDelay
+        self.maxDelay = maxDelay
+        self.multiplier = multiplier
+        self.jitter = jitter
+        self.maxAttempts = maxAttempts
+        self.maxReplays = maxReplays
+    }
+
+    /// Seconds to wait after the `attempt`-th failed connect attempt.
+    open func delay(attempt: Int) -> TimeInterval {
+        let d = min(maxDelay, initialDelay * pow(multiplier, Double(attempt - 1)))
+        return d * (1 - jitter * Double.random(in: 0..<1))
+    }
+
+    /// Whether to replay `command` after its `attempt`-th interrupted try.
+    open func shouldReplay(command: String, attempt: Int, error: Error) -> Bool {
+        idempotentCommands.contains(command) && attempt <= maxReplays
+    }
+}
+
+/// Owns the link of `client`: connects, reconnects and replays calls.
+///
+/// `connectLink` opens the link, e.g. `{ try await client.connect(to: peripheral) }`.
+/// Calls made while disconnected connect first, retrying with backoff as
+/// `policy` allows. A call cut off by a dropped link is replayed after
+/// reconnecting if the command is idempotent and the policy allows; other
+/// interrupted calls throw CallInterruptedError. Report a dropped link with
+/// linkLost() to reconnect right away. `onStateChange` is called, on no
+/// particular thread, with every new state.
+public final class ConnectionManager: GeneratedClientProtocol, @unchecked Sendable {
+    private let client: any GeneratedClientProtocol
+    private let isConnected: () -> Bool
+    private let connectLink: () async throws -> Void
+    private let policy: ReconnectPolicy
+    public let callSerializer = CallSerializer()
+
+    private let lock = NSLock()
+    private var currentState = LinkState.disconnected
+    private var connecting: Task<Void, Error>?
+    public var onStateChange: (@Sendable (LinkState) -> Void)?
+
+    public init(
+        client: any GeneratedClientProtocol,
+        isConnected: @escaping () -> Bool,
+        connectLink: @escaping () async throws -> Void,
+        policy: ReconnectPolicy = ReconnectPolicy()
+    ) {
+        self.client = client
+        self.isConnected = isConnected
+        self.connectLink = connectLink
+        self.policy = policy
+    }
+
+    public var state: LinkState {
+        lock.lock()
+        defer { lock.unlock() }
+        return currentState
+    }
+
+    public var capabilityFlags: Int { client.capabilityFlags }
+
+    private func setState(_ state: LinkState) {
+        lock.lock()
+        let changed = currentState != state
+        currentState = state
+        lock.unlock()
+        if changed {
+            onStateChange?(state)
+        }
+    }
+
+    /// Opens the link, retrying with backoff; returns at once when connected.
+    /// Concurrent callers share one attempt.
+    public func connect() async throws {
+        lock.lock()
+        let task: Task<Void, Error>
+        if let connecting {
+            task = connecting
+        } else {
+            task = Task { try await self.runConnect() }
+            connecting = task
+        }
+        lock.unlock()
+        defer {
+            lock.lock()
+            if connecting == task {
+                connecting = nil
+            }
+            lock.unlock()
+        }
+        try await task.value
+    }
+
+    private func runConnect() async throws {
+        if isConnected() {
+            if state == .disconnected {
+                setState(.ready)
+            }
+            return
+        }
+        setState(.connecting)
+        var attempt = 0
+        while true {
+            do {
+                try await connectLink()
+                setState(.ready)
+                return
+            } catch {
+                attempt += 1
+                if error is CancellationError || (policy.maxAttempts > 0 && attempt >= policy.maxAttempts) {
+                    setState(.disconnected)
+                    if error is CancellationError {
+                        throw error
+                    }
+                    throw TransportError(message: "Connect failed after \(attempt) attempts: \(error)")
+                }
+                try await Task.sleep(nanoseconds: UInt64(policy.delay(attempt: attempt) * 1_000_000_000))
+            }
+        }
+    }
+
+    /// Reports that the link dropped and reconnects in the background.
+    public func linkLost() {
+        setState(.disconnected)
+        Task { try? await self.connect() }
+    }
+
+    public func call(cmdName: String, requestData: Data) async throws -> Data {
+        try await run(cmdName) { try await self.client.call(cmdName: cmdName, requestData: requestData) }
+    }
+
+    public func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        try await run(cmdName) {
+            try await self.client.streamReceive(cmdName: cmdName, requestData: requestData)
+        }
+    }
+
+    public func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        try await run(cmdName) {
+            try await self.client.streamSend(cmdName: cmdName, messages: messages, finalCmdName: finalCmdName)
+        }
+    }
+
+    private func run<T>(_ command: String, _ body: () async throws -> T) async throws -> T {
+        var attempt = 0
+        while true {
+            if !isConnected() {
+                setState(.disconnected)
+                try await connect()
+            }
+            do {
+                let result = try await client.exclusive(body)
+                setState(.ready)
+                return result
+            } catch {
+                if error is CancellationError {
+                    throw error
+                }
+                if isConnected() {
+                    // Error and undecodable responses show the link works.
+                    switch BlerpcError(error) {
+                    case .remote, .decode: break
+                    default: setState(.degraded)
+                    }
+                    throw error
+                }
+                setState(.disconnected)
+                attempt += 1
+                if !policy.shouldReplay(command: command, attempt: attempt, error: error) {
+                    if idempotentCommands.contains(command) {
+                        throw error
+                    }
+                    throw CallInterruptedError(command: command, underlying: error)
+                }
+            }
+        }
+    }
+}
@@ -0,0 +1,501 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import BlerpcProtocol
+import Foundation
+import SwiftProtobuf
+
+/// A vector of conformance/vectors.json. Bytes are lowercase hex.
+public struct ConformanceVector {
+    public let command: String
+    public let wireName: String
+    public let stream: String
+    public let mtu: Int
+    public let request: String
+    public let response: String
+    public let requestContainers: [String]
+    public let responseContainers: [String]
+}
+
+public enum ConformanceError: Error {
+    case unknownCommand(String)
+    case noResponse
+}
+
+/// Client whose peripheral is a conformance vector. Frames calls with
+/// BlerpcProtocol as BlerpcClient does, records the containers it would
+/// write in `sent` and answers from the response containers of the vector.
+public final class LoopbackClient: GeneratedClientProtocol, @unchecked Sendable {
+    public let callSerializer = CallSerializer()
+    /// Unused: the vectors leave out session-protected commands.
+    public let session = BlerpcSession(key: Data(count: 32))
+    private let vector: ConformanceVector
+    private let splitter: ContainerSplitter
+    /// The containers written so far, in order.
+    public private(set) var sent: [Data] = []
+
+    public init(vector: ConformanceVector) {
+        self.vector = vector
+        splitter = ContainerSplitter(mtu: vector.mtu)
+    }
+
+    public func call(cmdName: String, requestData: Data) async throws -> Data {
+        try send(cmdName, requestData)
+        return try first(receive())
+    }
+
+    public func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        try send(cmdName, requestData)
+        return try receive()
+    }
+
+    public func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        for data in messages {
+            try send(cmdName, data)
+        }
+        sent.append(try makeStreamEndC2P(transactionId: splitter.nextTransactionId()).serialize())
+        return try first(receive())
+    }
+
+    private func send(_ cmdName: String, _ data: Data) throws {
+        let packet = CommandPacket(cmdType: .request, cmdName: cmdName, data: data)
+        for c in try splitter.split(try packet.serialize()) {
+            sent.append(try c.serialize())
+        }
+    }
+
+    /// Returns the data of every response in the answers of the vector.
+    private func receive() throws -> [Data] {
+        let assembler = ContainerAssembler()
+        var responses: [Data] = []
+        for hex in vector.responseContainers {
+            let container = try Container.deserialize(dataFromHex(hex))
+            if container.containerType == .control { continue }
+            if let payload = assembler.feed(container) {
+                responses.append(try CommandPacket.deserialize(payload).data)
+            }
+        }
+        return responses
+    }
+
+    private func first(_ responses: [Data]) throws -> Data {
+        guard let data = responses.first else { throw ConformanceError.noResponse }
+        return data
+    }
+}
+
+/// Runs every vector through a LoopbackClient and returns the mismatches.
+/// The sample request is decoded and encoded again with the generated
+/// messages, sent, and every response decoded and encoded again.
+public func checkConformance(_ vectors: [ConformanceVector] = conformanceVectors) async throws -> [String] {
+    var mismatches: [String] = []
+    for v in vectors {
+        let name = "\(v.command) at MTU \(v.mtu)"
+        let request: Data = try conformanceRequest(v.command, dataFromHex(v.request)).serializedData()
+        if hexFromData(request) != v.request {
+            mismatches.append("\(name): request encodes as \(hexFromData(request))")
+        }
+        let client = LoopbackClient(vector: v)
+        let responses: [Data]
+        switch v.stream {
+        case "p2c":
+            responses = try await client.streamReceive(cmdName: v.wireName, requestData: request)
+        case "c2p":
+            responses = [try await client.streamSend(cmdName: v.wireName, messages: [request], finalCmdName: v.wireName)]
+        default:
+            responses = [try await client.call(cmdName: v.wireName, requestData: request)]
+        }
+        let sent = client.sent.map(hexFromData)
+        if sent != v.requestContainers {
+            mismatches.append("\(name): sent \(sent)")
+        }
+        for data in responses {
+            let response: Data = try conformanceResponse(v.command, data).serializedData()
+            if hexFromData(response) != v.response {
+                mismatches.append("\(name): response encodes as \(hexFromData(response))")
+            }
+        }
+    }
+    return mismatches
+}
+
+private func dataFromHex(_ hex: String) -> Data {
+    var data = Data()
+    var index = hex.startIndex
+    while index < hex.endIndex {
+        let next = hex.index(index, offsetBy: 2)
+        data.append(UInt8(hex[index..<next], radix: 16)!)
+        index = next
+    }
+    return data
+}
+
+private func hexFromData(_ data: Data) -> String {
+    data.map { String(format: "%02x", $0) }.joined()
+}
+
+/// The vectors of conformance/vectors.json.
+public let conformanceVectors: [ConformanceVector] = [
+    ConformanceVector(
+        command: "echo", wireName: "\u{01}", stream: "unary", mtu: 23,
+        request: "0a076d657373616765",
+        response: "0a076d657373616765",
+        requestContainers: ["0000000e000e00010109000a076d657373616765"],
+        responseContainers: ["0000000e000e80010109000a076d657373616765"]
+    ),
+    ConformanceVector(
+        command: "echo", wireName: "\u{01}", stream: "unary", mtu: 247,
+        request: "0a076d657373616765",
+        response: "0a076d657373616765",
+        requestContainers: ["0000000e000e00010109000a076d657373616765"],
+        responseContainers: ["0000000e000e80010109000a076d657373616765"]
+    ),
+    ConformanceVector(
+        command: "data_write", wireName: "\u{03}", stream: "unary", mtu: 23,
+        request: "0a0401020304",
+        response: "0801",
+        requestContainers: ["0000000b000b00010306000a0401020304"],
+        responseContainers: ["00000007000780010302000801"]
+    ),
+    ConformanceVector(
+        command: "data_write", wireName: "\u{03}", stream: "unary", mtu: 247,
+        request: "0a0401020304",
+        response: "0801",
+        requestContainers: ["0000000b000b00010306000a0401020304"],
+        responseContainers: ["00000007000780010302000801"]
+    ),
+    ConformanceVector(
+        command: "counter_stream", wireName: "\u{04}", stream: "p2c", mtu: 23,
+        request: "0801",
+        response: "080110ffffffffffffffffff01",
+        requestContainers: ["00000007000700010402000801"],
+        responseContainers: ["00000012000e8001040d00080110ffffffffffff", "00014004ffffff01", "0100cc00"]
+    ),
+    ConformanceVector(
+        command: "counter_stream", wireName: "\u{04}", stream: "p2c", mtu: 247,
+        request: "0801",
+        response: "080110ffffffffffffffffff01",
+        requestContainers: ["00000007000700010402000801"],
+        responseContainers: ["0000001200128001040d00080110ffffffffffffffffff01", "0100cc00"]
+    ),
+    ConformanceVector(
+        command: "counter_upload", wireName: "\u{05}", stream: "c2p", mtu: 23,
+        request: "080110ffffffffffffffffff01",
+        response: "0801",
+        requestContainers: ["00000012000e0001050d00080110ffffffffffff", "00014004ffffff01", "0100c800"],
+        responseContainers: ["00000007000780010502000801"]
+    ),
+    ConformanceVector(
+        command: "counter_upload", wireName: "\u{05}", stream: "c2p", mtu: 247,
+        request: "080110ffffffffffffffffff01",
+        response: "0801",
+        requestContainers: ["0000001200120001050d00080110ffffffffffffffffff01", "0100c800"],
+        responseContainers: ["00000007000780010502000801"]
+    ),
+    ConformanceVector(
+        command: "get_blerpc_info", wireName: "\u{06}", stream: "unary", mtu: 23,
+        request: "",
+        response: "0a0b736368656d615f68617368121167656e657261746f725f76657273696f6e",
+        requestContainers: ["0000000500050001060000"],
+        responseContainers: ["00000025000e80010620000a0b736368656d615f", "0001401068617368121167656e657261746f725f", "0002400776657273696f6e"]
+    ),
+    ConformanceVector(
+        command: "get_blerpc_info", wireName: "\u{06}", stream: "unary", mtu: 247,
+        request: "",
+        response: "0a0b736368656d615f68617368121167656e657261746f725f76657273696f6e",
+        requestContainers: ["0000000500050001060000"],
+        responseContainers: ["00000025002580010620000a0b736368656d615f68617368121167656e657261746f725f76657273696f6e"]
+    ),
+    ConformanceVector(
+        command: "conn_params", wireName: "\u{07}", stream: "unary", mtu: 23,
+        request: "0801",
+        response: "0801100118012001",
+        requestContainers: ["00000007000700010702000801"],
+        responseContainers: ["0000000d000d80010708000801100118012001"]
+    ),
+    ConformanceVector(
+        command: "conn_params", wireName: "\u{07}", stream: "unary", mtu: 247,
+        request: "0801",
+        response: "0801100118012001",
+        requestContainers: ["00000007000700010702000801"],
+        responseContainers: ["0000000d000d80010708000801100118012001"]
+    ),
+    ConformanceVector(
+        command: "dfu_begin", wireName: "\u{08}", stream: "unary", mtu: 23,
+        request: "08011001",
+        response: "08011001",
+        requestContainers: ["000000090009000108040008011001"],
+        responseContainers: ["000000090009800108040008011001"]
+    ),
+    ConformanceVector(
+        command: "dfu_begin", wireName: "\u{08}", stream: "unary", mtu: 247,
+        request: "08011001",
+        response: "08011001",
+        requestContainers: ["000000090009000108040008011001"],
+        responseContainers: ["000000090009800108040008011001"]
+    ),
+    ConformanceVector(
+        command: "dfu_chunk", wireName: "\u{09}", stream: "unary", mtu: 23,
+        request: "0801120401020304",
+        response: "0801",
+        requestContainers: ["0000000d000d00010908000801120401020304"],
+        responseContainers: ["00000007000780010902000801"]
+    ),
+    ConformanceVector(
+        command: "dfu_chunk", wireName: "\u{09}", stream: "unary", mtu: 247,
+        request: "0801120401020304",
+        response: "0801",
+        requestContainers: ["0000000d000d00010908000801120401020304"],
+        responseContainers: ["00000007000780010902000801"]
+    ),
+    ConformanceVector(
+        command: "dfu_finalize", wireName: "\u{0a}", stream: "unary", mtu: 23,
+        request: "0801",
+        response: "0801",
+        requestContainers: ["00000007000700010a02000801"],
+        responseContainers: ["00000007000780010a02000801"]
+    ),
+    ConformanceVector(
+        command: "dfu_finalize", wireName: "\u{0a}", stream: "unary", mtu: 247,
+        request: "0801",
+        response: "0801",
+        requestContainers: ["00000007000700010a02000801"],
+        responseContainers: ["00000007000780010a02000801"]
+    ),
+    ConformanceVector(
+        command: "file_open", wireName: "\u{0b}", stream: "unary", mtu: 23,
+        request: "0a047061746810011801",
+        response: "080110011801",
+        requestContainers: ["0000000f000e00010b0a000a0470617468100118", "0001400101"],
+        responseContainers: ["0000000b000b80010b0600080110011801"]
+    ),
+    ConformanceVector(
+        command: "file_open", wireName: "\u{0b}", stream: "unary", mtu: 247,
+        request: "0a047061746810011801",
+        response: "080110011801",
+        requestContainers: ["0000000f000f00010b0a000a047061746810011801"],
+        responseContainers: ["0000000b000b80010b0600080110011801"]
+    ),
+    ConformanceVector(
+        command: "file_read", wireName: "\u{0c}", stream: "unary", mtu: 23,
+        request: "080110011801",
+        response: "0a0401020304",
+        requestContainers: ["0000000b000b00010c0600080110011801"],
+        responseContainers: ["0000000b000b80010c06000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "file_read", wireName: "\u{0c}", stream: "unary", mtu: 247,
+        request: "080110011801",
+        response: "0a0401020304",
+        requestContainers: ["0000000b000b00010c0600080110011801"],
+        responseContainers: ["0000000b000b80010c06000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "file_write", wireName: "\u{0d}", stream: "unary", mtu: 23,
+        request: "080110011a0401020304",
+        response: "",
+        requestContainers: ["0000000f000e00010d0a00080110011a04010203", "0001400104"],
+        responseContainers: ["00000005000580010d0000"]
+    ),
+    ConformanceVector(
+        command: "file_write", wireName: "\u{0d}", stream: "unary", mtu: 247,
+        request: "080110011a0401020304",
+        response: "",
+        requestContainers: ["0000000f000f00010d0a00080110011a0401020304"],
+        responseContainers: ["00000005000580010d0000"]
+    ),
+    ConformanceVector(
+        command: "file_close", wireName: "\u{0e}", stream: "unary", mtu: 23,
+        request: "08011001",
+        response: "08011001",
+        requestContainers: ["00000009000900010e040008011001"],
+        responseContainers: ["00000009000980010e040008011001"]
+    ),
+    ConformanceVector(
+        command: "file_close", wireName: "\u{0e}", stream: "unary", mtu: 247,
+        request: "08011001",
+        response: "08011001",
+        requestContainers: ["00000009000900010e040008011001"],
+        responseContainers: ["00000009000980010e040008011001"]
+    ),
+    ConformanceVector(
+        command: "log_stream", wireName: "\u{0f}", stream: "p2c", mtu: 23,
+        request: "08011001",
+        response: "08011001180120012a066d6f64756c6532076d6573736167653801",
+        requestContainers: ["00000009000900010f040008011001"],
+        responseContainers: ["00000020000e80010f1b0008011001180120012a", "00014010066d6f64756c6532076d657373616765", "000240023801", "0100cc00"]
+    ),
+    ConformanceVector(
+        command: "log_stream", wireName: "\u{0f}", stream: "p2c", mtu: 247,
+        request: "08011001",
+        response: "08011001180120012a066d6f64756c6532076d6573736167653801",
+        requestContainers: ["00000009000900010f040008011001"],
+        responseContainers: ["00000020002080010f1b0008011001180120012a066d6f64756c6532076d6573736167653801", "0100cc00"]
+    ),
+    ConformanceVector(
+        command: "get_rpc_stats", wireName: "\u{10}", stream: "unary", mtu: 23,
+        request: "0801",
+        response: "0a0c0a046e616d65100118012001",
+        requestContainers: ["00000007000700011002000801"],
+        responseContainers: ["00000013000e8001100e000a0c0a046e616d6510", "000140050118012001"]
+    ),
+    ConformanceVector(
+        command: "get_rpc_stats", wireName: "\u{10}", stream: "unary", mtu: 247,
+        request: "0801",
+        response: "0a0c0a046e616d65100118012001",
+        requestContainers: ["00000007000700011002000801"],
+        responseContainers: ["0000001300138001100e000a0c0a046e616d65100118012001"]
+    ),
+    ConformanceVector(
+        command: "start_session", wireName: "\u{11}", stream: "unary", mtu: 23,
+        request: "",
+        response: "0a0401020304",
+        requestContainers: ["0000000500050001110000"],
+        responseContainers: ["0000000b000b80011106000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "start_session", wireName: "\u{11}", stream: "unary", mtu: 247,
+        request: "",
+        response: "0a0401020304",
+        requestContainers: ["0000000500050001110000"],
+        responseContainers: ["0000000b000b80011106000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "authenticate_session", wireName: "\u{12}", stream: "unary", mtu: 23,
+        request: "0a0401020304",
+        response: "0a0401020304",
+        requestContainers: ["0000000b000b00011206000a0401020304"],
+        responseContainers: ["0000000b000b80011206000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "authenticate_session", wireName: "\u{12}", stream: "unary", mtu: 247,
+        request: "0a0401020304",
+        response: "0a0401020304",
+        requestContainers: ["0000000b000b00011206000a0401020304"],
+        responseContainers: ["0000000b000b80011206000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "time_sync", wireName: "\u{13}", stream: "unary", mtu: 23,
+        request: "08ffffffffffffffffff011001",
+        response: "08ffffffffffffffffff01",
+        requestContainers: ["00000012000e0001130d0008ffffffffffffffff", "00014004ff011001"],
+        responseContainers: ["00000010000e8001130b0008ffffffffffffffff", "00014002ff01"]
+    ),
+    ConformanceVector(
+        command: "time_sync", wireName: "\u{13}", stream: "unary", mtu: 247,
+        request: "08ffffffffffffffffff011001",
+        response: "08ffffffffffffffffff01",
+        requestContainers: ["0000001200120001130d0008ffffffffffffffffff011001"],
+        responseContainers: ["0000001000108001130b0008ffffffffffffffffff01"]
+    ),
+    ConformanceVector(
+        command: "ping", wireName: "\u{14}", stream: "unary", mtu: 23,
+        request: "0a0401020304",
+        response: "0a040102030410011801",
+        requestContainers: ["0000000b000b00011406000a0401020304"],
+        responseContainers: ["0000000f000e8001140a000a0401020304100118", "0001400101"]
+    ),
+    ConformanceVector(
+        command: "ping", wireName: "\u{14}", stream: "unary", mtu: 247,
+        request: "0a0401020304",
+        response: "0a040102030410011801",
+        requestContainers: ["0000000b000b00011406000a0401020304"],
+        responseContainers: ["0000000f000f8001140a000a040102030410011801"]
+    ),
+    ConformanceVector(
+        command: "get_capabilities", wireName: "\u{15}", stream: "unary", mtu: 23,
+        request: "",
+        response: "0a040102030412056e616d6573",
+        requestContainers: ["0000000500050001150000"],
+        responseContainers: ["00000012000e8001150d000a040102030412056e", "00014004616d6573"]
+    ),
+    ConformanceVector(
+        command: "get_capabilities", wireName: "\u{15}", stream: "unary", mtu: 247,
+        request: "",
+        response: "0a040102030412056e616d6573",
+        requestContainers: ["0000000500050001150000"],
+        responseContainers: ["0000001200128001150d000a040102030412056e616d6573"]
+    ),
+    ConformanceVector(
+        command: "get_setting", wireName: "\u{16}", stream: "unary", mtu: 23,
+        request: "0801",
+        response: "0a0401020304",
+        requestContainers: ["00000007000700011602000801"],
+        responseContainers: ["0000000b000b80011606000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "get_setting", wireName: "\u{16}", stream: "unary", mtu: 247,
+        request: "0801",
+        response: "0a0401020304",
+        requestContainers: ["00000007000700011602000801"],
+        responseContainers: ["0000000b000b80011606000a0401020304"]
+    ),
+    ConformanceVector(
+        command: "set_setting", wireName: "\u{17}", stream: "unary", mtu: 23,
+        request: "0801120401020304",
+        response: "",
+        requestContainers: ["0000000d000d00011708000801120401020304"],
+        responseContainers: ["0000000500058001170000"]
+    ),
+    ConformanceVector(
+        command: "set_setting", wireName: "\u{17}", stream: "unary", mtu: 247,
+        request: "0801120401020304",
+        response: "",
+        requestContainers: ["0000000d000d00011708000801120401020304"],
+        responseContainers: ["0000000500058001170000"]
+    ),
+]
+
+private func conformanceRequest(_ command: String, _ data: Data) throws -> any SwiftProtobuf.Message {
+    switch command {
+    case "echo": return try Blerpc_EchoRequest(serializedBytes: data)
+    case "data_write": return try Blerpc_DataWriteRequest(serializedBytes: data)
+    case "counter_stream": return try Blerpc_CounterStreamRequest(serializedBytes: data)
+    case "counter_upload": return try Blerpc_CounterUploadRequest(serializedBytes: data)
+    case "get_blerpc_info": return try Blerpc_GetBlerpcInfoRequest(serializedBytes: data)
+    case "conn_params": return try Blerpc_ConnParamsRequest(serializedBytes: data)
+    case "dfu_begin": return try Blerpc_DfuBeginRequest(serializedBytes: data)
+    case "dfu_chunk": return try Blerpc_DfuChunkRequest(serializedBytes: data)
+    case "dfu_finalize": return try Blerpc_DfuFinalizeRequest(serializedBytes: data)
+    case "file_open": return try Blerpc_FileOpenRequest(serializedBytes: data)
+    case "file_read": return try Blerpc_FileReadRequest(serializedBytes: data)
+    case "file_write": return try Blerpc_FileWriteRequest(serializedBytes: data)
+    case "file_close": return try Blerpc_FileCloseRequest(serializedBytes: data)
+    case "log_stream": return try Blerpc_LogStreamRequest(serializedBytes: data)
+    case "get_rpc_stats": return try Blerpc_GetRpcStatsRequest(serializedBytes: data)
+    case "start_session": return try Blerpc_StartSessionRequest(serializedBytes: data)
+    case "authenticate_session": return try Blerpc_AuthenticateSessionRequest(serializedBytes: data)
+    case "time_sync": return try Blerpc_TimeSyncRequest(serializedBytes: data)
+    case "ping": return try Blerpc_PingRequest(serializedBytes: data)
+    case "get_capabilities": return try Blerpc_GetCapabilitiesRequest(serializedBytes: data)
+    case "get_setting": return try Blerpc_GetSettingRequest(serializedBytes: data)
+    case "set_setting": return try Blerpc_SetSettingRequest(serializedBytes: data)
+    default: throw ConformanceError.unknownCommand(command)
+    }
+}
+
+private func conformanceResponse(_ command: String, _ data: Data) throws -> any SwiftProtobuf.Message {
+    switch command {
+    case "echo": return try Blerpc_EchoResponse(serializedBytes: data)
+    case "data_write": return try Blerpc_DataWriteResponse(serializedBytes: data)
+    case "counter_stream": return try Blerpc_CounterStreamResponse(serializedBytes: data)
+    case "counter_upload": return try Blerpc_CounterUploadResponse(serializedBytes: data)
+    case "get_blerpc_info": return try Blerpc_GetBlerpcInfoResponse(serializedBytes: data)
+    case "conn_params": return try Blerpc_ConnParamsResponse(serializedBytes: data)
+    case "dfu_begin": return try Blerpc_DfuBeginResponse(serializedBytes: data)
+    case "dfu_chunk": return try Blerpc_DfuChunkResponse(serializedBytes: data)
+    case "dfu_finalize": return try Blerpc_DfuFinalizeResponse(serializedBytes: data)
+    case "file_open": return try Blerpc_FileOpenResponse(serializedBytes: data)
+    case "file_read": return try Blerpc_FileReadResponse(serializedBytes: data)
+    case "file_write": return try Blerpc_FileWriteResponse(serializedBytes: data)
+    case "file_close": return try Blerpc_FileCloseResponse(serializedBytes: data)
+    case "log_stream": return try Blerpc_LogStreamResponse(serializedBytes: data)
+    case "get_rpc_stats": return try Blerpc_GetRpcStatsResponse(serializedBytes: data)
+    case "start_session": return try Blerpc_StartSessionResponse(serializedBytes: data)
+    case "authenticate_session": return try Blerpc_AuthenticateSessionResponse(serializedBytes: data)
+    case "time_sync": return try Blerpc_TimeSyncResponse(serializedBytes: data)
+    case "ping": return try Blerpc_PingResponse(serializedBytes: data)
+    case "get_capabilities": return try Blerpc_GetCapabilitiesResponse(serializedBytes: data)
+    case "get_setting": return try Blerpc_GetSettingResponse(serializedBytes: data)
+    case "set_setting": return try Blerpc_SetSettingResponse(serializedBytes: data)
+    default: throw ConformanceError.unknownCommand(command)
+    }
+}
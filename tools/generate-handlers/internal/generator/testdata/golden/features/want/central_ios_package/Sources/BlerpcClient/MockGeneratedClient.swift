@@ -0,0 +1,163 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+/// A call recorded by MockGeneratedClient: the command and its decoded
+/// requests, one per message of a C→P stream.
+public struct MockCall {
+    public let command: String
+    public let requests: [any SwiftProtobuf.Message]
+}
+
+/// Stand-in for BlerpcClient in app unit tests, with no peripheral. Records
+/// every call in `calls` and answers it with the responses set for its
+/// command. Commands without any get an empty response, and P→C streams no
+/// responses.
+public final class MockGeneratedClient: GeneratedClientProtocol, @unchecked Sendable {
+    public typealias Answer = ([any SwiftProtobuf.Message]) throws -> [any SwiftProtobuf.Message]
+
+    public let callSerializer = CallSerializer()
+    /// Any key does: the mock answers the session handshake itself.
+    public let session = BlerpcSession(key: Data(count: 32))
+    private var answers: [String: Answer] = [:]
+    private var recorded: [MockCall] = []
+    private let lock = NSLock()
+
+    public init() {}
+
+    /// The calls made so far, in order.
+    public var calls: [MockCall] {
+        lock.lock()
+        defer { lock.unlock() }
+        return recorded
+    }
+
+    /// Answers `command` with `responses`: a unary or C→P call gets the first,
+    /// a P→C stream each of them.
+    public func respond(_ command: String, with responses: any SwiftProtobuf.Message...) {
+        respond(command) { _ in responses }
+    }
+
+    /// Answers `command` with the responses `answer` returns for its requests.
+    public func respond(_ command: String, answer: @escaping Answer) {
+        lock.lock()
+        defer { lock.unlock() }
+        answers[command] = answer
+    }
+
+    /// Fails calls of `command` with `error`, e.g. a NotFoundError.
+    public func fail(_ command: String, with error: Error) {
+        respond(command) { _ in throw error }
+    }
+
+    public func call(cmdName: String, requestData: Data) async throws -> Data {
+        let (command, responses) = try answer(cmdName, [requestData])
+        return try (responses?.first ?? emptyResponse(command)).serializedData()
+    }
+
+    public func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        let (_, responses) = try answer(cmdName, [requestData])
+        return try (responses ?? []).map { try $0.serializedData() }
+    }
+
+    public func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        let (command, responses) = try answer(cmdName, messages)
+        return try (responses?.first ?? emptyResponse(command)).serializedData()
+    }
+
+    /// Records a call and returns its command with the responses set for it.
+    private func answer(_ cmdName: String, _ requests: [Data]) throws -> (String, [any SwiftProtobuf.Message]?) {
+        let command: String
+        switch cmdName {
+        case CommandId.echo.wireName: command = "echo"
+        case CommandId.flashRead.wireName: command = "flash_read"
+        case CommandId.dataWrite.wireName: command = "data_write"
+        case CommandId.counterStream.wireName: command = "counter_stream"
+        case CommandId.counterUpload.wireName: command = "counter_upload"
+        case CommandId.getBlerpcInfo.wireName: command = "get_blerpc_info"
+        case CommandId.dfuBegin.wireName: command = "dfu_begin"
+        case CommandId.dfuChunk.wireName: command = "dfu_chunk"
+        case CommandId.dfuFinalize.wireName: command = "dfu_finalize"
+        case CommandId.fileOpen.wireName: command = "file_open"
+        case CommandId.fileRead.wireName: command = "file_read"
+        case CommandId.fileWrite.wireName: command = "file_write"
+        case CommandId.fileClose.wireName: command = "file_close"
+        case CommandId.logStream.wireName: command = "log_stream"
+        case CommandId.getRpcStats.wireName: command = "get_rpc_stats"
+        case CommandId.startSession.wireName: command = "start_session"
+        case CommandId.authenticateSession.wireName: command = "authenticate_session"
+        case CommandId.timeSync.wireName: command = "time_sync"
+        case CommandId.ping.wireName: command = "ping"
+        case CommandId.getCapabilities.wireName: command = "get_capabilities"
+        case CommandId.getSetting.wireName: command = "get_setting"
+        case CommandId.setSetting.wireName: command = "set_setting"
+        default: command = cmdName
+        }
+        let decoded = try requests.map { try decodeRequest(command, $0) }
+        lock.lock()
+        recorded.append(MockCall(command: command, requests: decoded))
+        let answer = answers[command]
+        lock.unlock()
+        return (command, try answer?(decoded))
+    }
+
+    private func decodeRequest(_ command: String, _ data: Data) throws -> any SwiftProtobuf.Message {
+        switch command {
+        case "echo": return try Blerpc_EchoRequest(serializedBytes: data)
+        case "flash_read": return try Blerpc_FlashReadRequest(serializedBytes: data.dropFirst(32))
+        case "data_write": return try Blerpc_DataWriteRequest(serializedBytes: data)
+        case "counter_stream": return try Blerpc_CounterStreamRequest(serializedBytes: data)
+        case "counter_upload": return try Blerpc_CounterUploadRequest(serializedBytes: data)
+        case "get_blerpc_info": return try Blerpc_GetBlerpcInfoRequest(serializedBytes: data)
+        case "dfu_begin": return try Blerpc_DfuBeginRequest(serializedBytes: data)
+        case "dfu_chunk": return try Blerpc_DfuChunkRequest(serializedBytes: data)
+        case "dfu_finalize": return try Blerpc_DfuFinalizeRequest(serializedBytes: data)
+        case "file_open": return try Blerpc_FileOpenRequest(serializedBytes: data)
+        case "file_read": return try Blerpc_FileReadRequest(serializedBytes: data)
+        case "file_write": return try Blerpc_FileWriteRequest(serializedBytes: data)
+        case "file_close": return try Blerpc_FileCloseRequest(serializedBytes: data)
+        case "log_stream": return try Blerpc_LogStreamRequest(serializedBytes: data)
+        case "get_rpc_stats": return try Blerpc_GetRpcStatsRequest(serializedBytes: data)
+        case "start_session": return try Blerpc_StartSessionRequest(serializedBytes: data)
+        case "authenticate_session": return try Blerpc_AuthenticateSessionRequest(serializedBytes: data)
+        case "time_sync": return try Blerpc_TimeSyncRequest(serializedBytes: data)
+        case "ping": return try Blerpc_PingRequest(serializedBytes: data)
+        case "get_capabilities": return try Blerpc_GetCapabilitiesRequest(serializedBytes: data)
+        case "get_setting": return try Blerpc_GetSettingRequest(serializedBytes: data)
+        case "set_setting": return try Blerpc_SetSettingRequest(serializedBytes: data)
+        default: throw TransportError(message: "unknown command \(command)")
+        }
+    }
+
+    private func emptyResponse(_ command: String) -> any SwiftProtobuf.Message {
+        switch command {
+        case "echo": return Blerpc_EchoResponse()
+        case "flash_read": return Blerpc_FlashReadResponse()
+        case "data_write": return Blerpc_DataWriteResponse()
+        case "counter_stream": return Blerpc_CounterStreamResponse()
+        case "counter_upload": return Blerpc_CounterUploadResponse()
+        case "get_blerpc_info": return Blerpc_GetBlerpcInfoResponse()
+        case "dfu_begin": return Blerpc_DfuBeginResponse()
+        case "dfu_chunk": return Blerpc_DfuChunkResponse()
+        case "dfu_finalize": return Blerpc_DfuFinalizeResponse()
+        case "file_open": return Blerpc_FileOpenResponse()
+        case "file_read": return Blerpc_FileReadResponse()
+        case "file_write": return Blerpc_FileWriteResponse()
+        case "file_close": return Blerpc_FileCloseResponse()
+        case "log_stream": return Blerpc_LogStreamResponse()
+        case "get_rpc_stats": return Blerpc_GetRpcStatsResponse()
+        case "start_session": return Blerpc_StartSessionResponse()
+        case "authenticate_session":
+            // A token of the size peripherals issue opens the session.
+            var resp = Blerpc_AuthenticateSessionResponse()
+            resp.token = Data(count: 8)
+            return resp
+        case "time_sync": return Blerpc_TimeSyncResponse()
+        case "ping": return Blerpc_PingResponse()
+        case "get_capabilities": return Blerpc_GetCapabilitiesResponse()
+        case "get_setting": return Blerpc_GetSettingResponse()
+        case "set_setting": return Blerpc_SetSettingResponse()
+        default: preconditionFailure("unknown command \(command)")
+        }
+    }
+}
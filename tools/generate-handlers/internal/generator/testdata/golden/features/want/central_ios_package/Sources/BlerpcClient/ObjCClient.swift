@@ -0,0 +1,187 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+/// The commands of a generated client for Objective-C. Each takes the
+/// serialized request message and calls `completion`, on no particular
+/// queue, with the serialized response or the error. Create it in Swift
+/// around the client and hand it to Objective-C code, e.g.
+///
+///     [objcClient echo:[request data] completion:^(NSData *data, NSError *error) { ... }];
+@objc(BlerpcObjCClient)
+public final class ObjCClient: NSObject {
+    private let client: any GeneratedClientProtocol
+
+    public init(client: any GeneratedClientProtocol) {
+        self.client = client
+    }
+
+    @objc public func echo(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_EchoRequest(serializedBytes: request)
+            return try await client.echo(message: req.message).serializedData()
+        }
+    }
+
+    @objc public func flashRead(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_FlashReadRequest(serializedBytes: request)
+            return try await client.flashRead(address: req.address, length: req.length).serializedData()
+        }
+    }
+
+    @available(*, deprecated, message: "data_write is deprecated in the schema")
+    @objc public func dataWrite(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_DataWriteRequest(serializedBytes: request)
+            return try await client.dataWrite(data: req.data).serializedData()
+        }
+    }
+
+    @objc public func counterStream(_ request: Data, completion: @escaping @Sendable ([Data]?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_CounterStreamRequest(serializedBytes: request)
+            return try await client.counterStream(count: req.count).map { try $0.serializedData() }
+        }
+    }
+
+    @objc public func counterUpload(_ requests: [Data], completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let messages = try requests.map { try Blerpc_CounterUploadRequest(serializedBytes: $0) }
+            return try await client.counterUpload(messages: messages).serializedData()
+        }
+    }
+
+    @objc public func getBlerpcInfo(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            _ = try Blerpc_GetBlerpcInfoRequest(serializedBytes: request)
+            return try await client.getBlerpcInfo().serializedData()
+        }
+    }
+
+    @objc public func dfuBegin(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_DfuBeginRequest(serializedBytes: request)
+            return try await client.dfuBegin(size: req.size, crc32: req.crc32).serializedData()
+        }
+    }
+
+    @objc public func dfuChunk(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_DfuChunkRequest(serializedBytes: request)
+            return try await client.dfuChunk(offset: req.offset, data: req.data).serializedData()
+        }
+    }
+
+    @objc public func dfuFinalize(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_DfuFinalizeRequest(serializedBytes: request)
+            return try await client.dfuFinalize(reboot: req.reboot).serializedData()
+        }
+    }
+
+    @objc public func fileOpen(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_FileOpenRequest(serializedBytes: request)
+            return try await client.fileOpen(path: req.path, write: req.write, resume: req.resume).serializedData()
+        }
+    }
+
+    @objc public func fileRead(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_FileReadRequest(serializedBytes: request)
+            return try await client.fileRead(handle: req.handle, offset: req.offset, length: req.length).serializedData()
+        }
+    }
+
+    @objc public func fileWrite(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_FileWriteRequest(serializedBytes: request)
+            return try await client.fileWrite(handle: req.handle, offset: req.offset, data: req.data).serializedData()
+        }
+    }
+
+    @objc public func fileClose(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_FileCloseRequest(serializedBytes: request)
+            return try await client.fileClose(handle: req.handle, verify: req.verify).serializedData()
+        }
+    }
+
+    @objc public func logStream(_ request: Data, completion: @escaping @Sendable ([Data]?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_LogStreamRequest(serializedBytes: request)
+            return try await client.logStream(minLevel: req.minLevel, maxEntries: req.maxEntries).map { try $0.serializedData() }
+        }
+    }
+
+    @objc public func getRpcStats(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_GetRpcStatsRequest(serializedBytes: request)
+            return try await client.getRpcStats(reset: req.reset).serializedData()
+        }
+    }
+
+    @objc public func startSession(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            _ = try Blerpc_StartSessionRequest(serializedBytes: request)
+            return try await client.startSession().serializedData()
+        }
+    }
+
+    @objc public func authenticateSession(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_AuthenticateSessionRequest(serializedBytes: request)
+            return try await client.authenticateSession(proof: req.proof).serializedData()
+        }
+    }
+
+    @objc public func timeSync(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_TimeSyncRequest(serializedBytes: request)
+            return try await client.timeSync(unixTimeUs: req.unixTimeUs, offsetUs: req.offsetUs).serializedData()
+        }
+    }
+
+    @objc public func ping(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_PingRequest(serializedBytes: request)
+            return try await client.ping(payload: req.payload).serializedData()
+        }
+    }
+
+    @objc public func getCapabilities(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            _ = try Blerpc_GetCapabilitiesRequest(serializedBytes: request)
+            return try await client.getCapabilities().serializedData()
+        }
+    }
+
+    @objc public func getSetting(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_GetSettingRequest(serializedBytes: request)
+            return try await client.getSetting(field: req.field).serializedData()
+        }
+    }
+
+    @objc public func setSetting(_ request: Data, completion: @escaping @Sendable (Data?, Error?) -> Void) {
+        run(completion) { [client] in
+            let req = try Blerpc_SetSettingRequest(serializedBytes: request)
+            return try await client.setSetting(field: req.field, value: req.value).serializedData()
+        }
+    }
+
+    /// Runs `body` in a task and completes with its result or error.
+    private func run<T>(
+        _ completion: @escaping @Sendable (T?, Error?) -> Void,
+        _ body: @escaping @Sendable () async throws -> T
+    ) {
+        Task {
+            do {
+                completion(try await body(), nil)
+            } catch {
+                completion(nil, error)
+            }
+        }
+    }
+}
@@ -0,0 +1,29 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+import SwiftProtobuf
+
+/// Stands in for the value of a field marked (blerpc.sensitive).
+public let redactedPlaceholder = "<redacted>"
+
+/// Returns `message` with the fields marked (blerpc.sensitive) shown as
+/// <redacted>, e.g. `LoginRequest(user: ann, password: <redacted>)`.
+/// Messages without such fields, directly or in a message field, keep
+/// their own description.
+public func redacted(_ message: any SwiftProtobuf.Message) -> String {
+    switch message {
+    case let message as Blerpc_FlashReadResponse:
+        return message.redactedDescription
+    default:
+        return String(describing: message)
+    }
+}
+
+public extension Blerpc_FlashReadResponse {
+    /// This FlashReadResponse with its sensitive fields redacted.
+    var redactedDescription: String {
+        var fields: [String] = []
+        fields.append("address: \(address)")
+        fields.append("data: \(redactedPlaceholder)")
+        return "FlashReadResponse(" + fields.joined(separator: ", ") + ")"
+    }
+}
@@ -0,0 +1,43 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import Foundation
+
+/// Carries the commands of a TransportClient to a peripheral: the container
+/// layer, encryption and BLE link, as the BlerpcClient of the example app
+/// implements them over CoreBluetooth.
+public protocol BlerpcTransport: AnyObject, Sendable {
+    func call(cmdName: String, requestData: Data) async throws -> Data
+    func streamReceive(cmdName: String, requestData: Data) async throws -> [Data]
+    func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data
+    /// Stops the P→C stream in progress. The default does nothing.
+    func streamCancel() async
+}
+
+public extension BlerpcTransport {
+    func streamCancel() async {}
+}
+
+/// The generated commands, sent over a BlerpcTransport.
+public final class TransportClient: GeneratedClientProtocol, Sendable {
+    public let transport: BlerpcTransport
+    public let callSerializer = CallSerializer()
+
+    public init(transport: BlerpcTransport) {
+        self.transport = transport
+    }
+
+    public func call(cmdName: String, requestData: Data) async throws -> Data {
+        try await transport.call(cmdName: cmdName, requestData: requestData)
+    }
+
+    public func streamReceive(cmdName: String, requestData: Data) async throws -> [Data] {
+        try await transport.streamReceive(cmdName: cmdName, requestData: requestData)
+    }
+
+    public func streamSend(cmdName: String, messages: [Data], finalCmdName: String) async throws -> Data {
+        try await transport.streamSend(cmdName: cmdName, messages: messages, finalCmdName: finalCmdName)
+    }
+
+    public func streamCancel() async {
+        await transport.streamCancel()
+    }
+}
@@ -0,0 +1,342 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import argparse
+import asyncio
+import datetime
+import json
+import sys
+import time
+from collections.abc import AsyncIterable, AsyncIterator, Iterable
+from typing import IO, Any
+
+from ..client import BlerpcClient
+from .generated_client import BlerpcError, CommandId, GeneratedClientMixin
+
+# Commands by the name the generated methods send for them, where it is
+# not their own.
+CAPTURE_COMMANDS = {
+    CommandId.ECHO.wire_name: "echo",
+    CommandId.FLASH_READ.wire_name: "flash_read",
+    CommandId.DATA_WRITE.wire_name: "data_write",
+    CommandId.COUNTER_STREAM.wire_name: "counter_stream",
+    CommandId.COUNTER_UPLOAD.wire_name: "counter_upload",
+    CommandId.GET_BLERPC_INFO.wire_name: "get_blerpc_info",
+    CommandId.CONN_PARAMS.wire_name: "conn_params",
+    CommandId.DFU_BEGIN.wire_name: "dfu_begin",
+    CommandId.DFU_CHUNK.wire_name: "dfu_chunk",
+    CommandId.DFU_FINALIZE.wire_name: "dfu_finalize",
+    CommandId.FILE_OPEN.wire_name: "file_open",
+    CommandId.FILE_READ.wire_name: "file_read",
+    CommandId.FILE_WRITE.wire_name: "file_write",
+    CommandId.FILE_CLOSE.wire_name: "file_close",
+    CommandId.LOG_STREAM.wire_name: "log_stream",
+    CommandId.GET_RPC_STATS.wire_name: "get_rpc_stats",
+    CommandId.START_SESSION.wire_name: "start_session",
+    CommandId.AUTHENTICATE_SESSION.wire_name: "authenticate_session",
+    CommandId.TIME_SYNC.wire_name: "time_sync",
+    CommandId.PING.wire_name: "ping",
+    CommandId.GET_CAPABILITIES.wire_name: "get_capabilities",
+    CommandId.GET_SETTING.wire_name: "get_setting",
+    CommandId.SET_SETTING.wire_name: "set_setting",
+}
+
+# Commands whose requests lead with a session token or replay counter,
+# which the peripheral accepts once; replay skips them.
+UNREPLAYABLE_COMMANDS = frozenset(
+    {
+        "flash_read",
+    }
+)
+
+# Sensitive fields, stripped from the recorded payloads: by message, the
+# numbers of the fields to drop, mapped to None, and of the message
+# fields holding some, mapped to their message.
+REDACTED_MESSAGES: dict[str, dict[int, str | None]] = {
+    "FlashReadResponse": {2: None},
+}
+
+# Commands with sensitive fields: the bytes leading each request, and the
+# messages of REDACTED_MESSAGES the requests and responses are, or None.
+# Payloads of commands mapped to None are not recorded at all.
+REDACTED_COMMANDS: dict[str, tuple[int, str | None, str | None] | None] = {
+    "flash_read": (32, None, "FlashReadResponse"),
+}
+
+
+def _varint(data: bytes, pos: int) -> tuple[int, int]:
+    value = shift = 0
+    while True:
+        if pos >= len(data):
+            raise ValueError("truncated varint")
+        byte = data[pos]
+        pos += 1
+        value |= (byte & 0x7F) << shift
+        if not byte & 0x80:
+            return value, pos
+        shift += 7
+
+
+def _put_varint(out: bytearray, value: int) -> None:
+    while value > 0x7F:
+        out.append(value & 0x7F | 0x80)
+        value >>= 7
+    out.append(value)
+
+
+def strip_sensitive(data: bytes, message: str) -> bytes:
+    """Return data, an encoded message of REDACTED_MESSAGES, without its
+    sensitive fields. Raises ValueError if data is not a valid encoding."""
+    plan = REDACTED_MESSAGES[message]
+    out = bytearray()
+    pos = 0
+    while pos < len(data):
+        start = pos
+        key, pos = _varint(data, pos)
+        number, wire_type = key >> 3, key & 7
+        size = 0
+        if wire_type == 0:
+            _, pos = _varint(data, pos)
+        elif wire_type == 1:
+            pos += 8
+        elif wire_type == 2:
+            size, pos = _varint(data, pos)
+            pos += size
+        elif wire_type == 5:
+            pos += 4
+        else:
+            raise ValueError(f"unsupported wire type {wire_type}")
+        if pos > len(data):
+            raise ValueError("truncated field")
+        inner = plan.get(number)
+        if number not in plan:
+            out += data[start:pos]
+        elif inner is not None and wire_type == 2:
+            stripped = strip_sensitive(data[pos - size : pos], inner)
+            _put_varint(out, key)
+            _put_varint(out, len(stripped))
+            out += stripped
+    return bytes(out)
+
+
+def _redact(payloads: list[bytes], prefix: int, message: str | None) -> list[bytes]:
+    # Strips the sensitive fields of message from payloads, keeping the
+    # prefix bytes leading each; undecodable payloads are dropped whole.
+    if message is None:
+        return payloads
+    out = []
+    for p in payloads:
+        try:
+            out.append(p[:prefix] + strip_sensitive(p[prefix:], message))
+        except ValueError:
+            out.append(b"")
+    return out
+
+
+def _now() -> tuple[str, int]:
+    # The wall clock time, for the capture, and a monotonic one, for the
+    # duration.
+    now = datetime.datetime.now(datetime.timezone.utc)
+    return now.isoformat().replace("+00:00", "Z"), time.monotonic_ns()
+
+
+class RecordingClient(GeneratedClientMixin):
+    """Records every call made through it to a JSONL capture file.
+
+    Each line holds the command, the name it was sent by, the stream kind,
+    the start time, the duration in microseconds, the raw request and
+    response payloads in hex, and the error the call raised, if any.
+    Payloads of REDACTED_COMMANDS are recorded without their sensitive
+    fields, and the entry is marked redacted. Make every call through the
+    recorder: it forwards other attributes to client, but calls made on
+    client directly are not recorded.
+    """
+
+    def __init__(self, client: Any, file: IO[str]) -> None:
+        self._client = client
+        self._file = file
+
+    def __getattr__(self, name: str) -> Any:
+        return getattr(self._client, name)
+
+    def _record(
+        self,
+        cmd_name: str,
+        stream: str,
+        start: tuple[str, int],
+        requests: list[bytes],
+        responses: list[bytes],
+        error: BaseException | None,
+    ) -> None:
+        command = CAPTURE_COMMANDS.get(cmd_name, cmd_name)
+        redacted = command in REDACTED_COMMANDS
+        if redacted:
+            plan = REDACTED_COMMANDS[command]
+            if plan is None:
+                requests, responses = [], []
+            else:
+                requests = _redact(requests, plan[0], plan[1])
+                responses = _redact(responses, 0, plan[2])
+        entry = {
+            "time": start[0],
+            "duration_us": (time.monotonic_ns() - start[1]) // 1000,
+            "command": command,
+            "cmd_name": cmd_name,
+            "stream": stream,
+            "requests": [r.hex() for r in requests],
+            "responses": [r.hex() for r in responses],
+        }
+        if redacted:
+            entry["redacted"] = True
+        if error is not None:
+            entry["error"] = f"{type(error).__name__}: {error}"
+        self._file.write(json.dumps(entry) + "\n")
+        self._file.flush()
+
+    async def _call(self, cmd_name: str, request_data: bytes) -> bytes:
+        start = _now()
+        try:
+            resp = await self._client._call(cmd_name, request_data)
+        except Exception as e:
+            self._record(cmd_name, "", start, [request_data], [], e)
+            raise
+        self._record(cmd_name, "", start, [request_data], [resp], None)
+        return resp
+
+    async def stream_receive(
+        self, cmd_name: str, request_data: bytes
+    ) -> AsyncIterator[bytes]:
+        start = _now()
+        responses: list[bytes] = []
+        error = None
+        try:
+            async for data in self._client.stream_receive(cmd_name, request_data):
+                responses.append(data)
+                yield data
+        except Exception as e:
+            error = e
+            raise
+        finally:
+            self._record(cmd_name, "p2c", start, [request_data], responses, error)
+
+    async def stream_send(
+        self,
+        cmd_name: str,
+        messages: Iterable[bytes] | AsyncIterable[bytes],
+        final_cmd_name: str,
+    ) -> bytes:
+        start = _now()
+        requests: list[bytes] = []
+
+        async def tee() -> AsyncIterator[bytes]:
+            if isinstance(messages, AsyncIterable):
+                async for data in messages:
+                    requests.append(data)
+                    yield data
+            else:
+                for data in messages:
+                    requests.append(data)
+                    yield data
+
+        try:
+            resp = await self._client.stream_send(cmd_name, tee(), final_cmd_name)
+        except Exception as e:
+            self._record(cmd_name, "c2p", start, requests, [], e)
+            raise
+        self._record(cmd_name, "c2p", start, requests, [resp], None)
+        return resp
+
+
+def read_capture(path: str) -> list[dict[str, Any]]:
+    """Return the entries of the capture file at path."""
+    with open(path) as f:
+        return [json.loads(line) for line in f if line.strip()]
+
+
+async def replay(client: Any, entries: Iterable[dict[str, Any]]) -> list[str]:
+    """Send the requests of every entry through client again.
+
+    client is any generated client, e.g. a BlerpcClient connected to a new
+    firmware release or a client of the simulator. Returns a line per entry
+    whose responses differ byte for byte from the captured ones, or that
+    fails where the capture succeeded or the other way round. Entries of
+    UNREPLAYABLE_COMMANDS, and of REDACTED_COMMANDS whose requests lost
+    sensitive fields, are skipped; responses are compared without theirs.
+    """
+    mismatches = []
+    for i, entry in enumerate(entries, 1):
+        if entry["command"] in UNREPLAYABLE_COMMANDS:
+            continue
+        plan = REDACTED_COMMANDS.get(entry["command"], (0, None, None))
+        if plan is None or plan[1] is not None:
+            continue
+        name = f"entry {i} ({entry['command']})"
+        cmd_name = entry["cmd_name"]
+        requests = [bytes.fromhex(r) for r in entry["requests"]]
+        responses = []
+        try:
+            if entry["stream"] == "p2c":
+                async for data in client.stream_receive(cmd_name, requests[0]):
+                    responses.append(data)
+            elif entry["stream"] == "c2p":
+                responses.append(await client.stream_send(cmd_name, requests, cmd_name))
+            else:
+                responses.append(await client._call(cmd_name, requests[0]))
+        except Exception as e:
+            if "error" not in entry:
+                mismatches.append(f"{name}: failed: {e}")
+            continue
+        if "error" in entry:
+            mismatches.append(f"{name}: succeeded, captured {entry['error']}")
+            continue
+        got = [r.hex() for r in _redact(responses, 0, plan[2])]
+        if got != entry["responses"]:
+            mismatches.append(f"{name}: responses {got}, captured {entry['responses']}")
+    return mismatches
+
+
+async def _replay_on_device(args: argparse.Namespace) -> list[str]:
+    client = BlerpcClient(
+        known_keys_path=args.known_keys, require_encryption=not args.no_encryption
+    )
+    devices = await client.scan(timeout=args.scan_timeout)
+    if args.device is not None:
+        devices = [d for d in devices if args.device in (d.name, d.address)]
+    if not devices:
+        raise BlerpcError("no blerpc peripheral found")
+    await client.connect(devices[0])
+    try:
+        return await replay(client, read_capture(args.capture))
+    finally:
+        await client.disconnect()
+
+
+def main(argv: list[str] | None = None) -> int:
+    """python -m <package>.capture capture.jsonl: replay on a peripheral."""
+    parser = argparse.ArgumentParser(
+        description="Replay a capture on a blerpc peripheral and report the "
+        "responses that differ."
+    )
+    parser.add_argument("capture", help="JSONL capture file of a RecordingClient")
+    parser.add_argument(
+        "--device", help="name or address of the peripheral (default: the nearest)"
+    )
+    parser.add_argument("--scan-timeout", type=float, default=5.0, help="seconds")
+    parser.add_argument("--known-keys", help="known peripheral keys file")
+    parser.add_argument(
+        "--no-encryption", action="store_true", help="allow unencrypted calls"
+    )
+    args = parser.parse_args(argv)
+    try:
+        mismatches = asyncio.run(_replay_on_device(args))
+    except BlerpcError as e:
+        print(f"error: {e}", file=sys.stderr)
+        return 1
+    for m in mismatches:
+        print(m)
+    return 1 if mismatches else 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -0,0 +1,273 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT.
+
+blerpc-cli: calls the commands of a peripheral from the command line, a
+subcommand per command with a flag per request field, and prints the decoded
+responses as protojson:
+
+    python -m blerpc.generated.cli flash-read --address=0x1000 --length=16
+
+Unset flags leave their field at its default. Bytes are given in hex,
+repeated, map and message fields as JSON, and C2P streams read one JSON
+request per line from --input.
+"""
+
+from __future__ import annotations
+
+import argparse
+import asyncio
+import datetime
+import json
+import sys
+from collections.abc import Callable
+
+from google.protobuf import json_format
+
+from ..client import BlerpcClient
+from . import blerpc_pb2
+from .generated_client import BlerpcError, to_json
+from .generated_scanner import scan
+
+
+def _bool(text: str) -> bool:
+    if text.lower() in ("1", "true", "yes", "on"):
+        return True
+    if text.lower() in ("0", "false", "no", "off"):
+        return False
+    raise argparse.ArgumentTypeError(f"not a boolean: {text!r}")
+
+
+def _int(text: str) -> int:
+    # Accepts 0x, 0o and 0b prefixes too.
+    return int(text, 0)
+
+
+def _seconds(text: str) -> datetime.timedelta:
+    return datetime.timedelta(seconds=float(text))
+
+
+def _json(text: str) -> object:
+    return json.loads(text)
+
+
+def _enum(enum_type) -> Callable[[str], int]:
+    # Takes an enum value by name or number.
+    def parse(text: str) -> int:
+        try:
+            return enum_type.Value(text)
+        except ValueError:
+            return int(text, 0)
+
+    return parse
+
+
+def _build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(
+        prog="blerpc-cli", description="Call the commands of a blerpc peripheral."
+    )
+    parser.add_argument(
+        "--device", help="name or address of the peripheral (default: the nearest)"
+    )
+    parser.add_argument("--scan-timeout", type=float, default=5.0, help="seconds")
+    parser.add_argument("--known-keys", help="known peripheral keys file")
+    parser.add_argument(
+        "--no-encryption", action="store_true", help="allow unencrypted calls"
+    )
+    parser.add_argument(
+        "--session-key",
+        type=bytes.fromhex,
+        help="session key shared with the peripheral, in hex",
+    )
+    commands = parser.add_subparsers(dest="command", metavar="COMMAND", required=True)
+
+    cmd = commands.add_parser("echo", help="call echo")
+    cmd.add_argument("--message", dest="field_message", type=str, help="string")
+    cmd.set_defaults(method="echo", stream="", fields=("message",))
+
+    cmd = commands.add_parser("flash-read", help="call flash_read")
+    cmd.add_argument("--address", dest="field_address", type=_int, help="uint32")
+    cmd.add_argument("--length", dest="field_length", type=_int, help="uint32")
+    cmd.set_defaults(method="flash_read", stream="", fields=("address", "length"))
+
+    cmd = commands.add_parser("data-write", help="call data_write")
+    cmd.add_argument("--data", dest="field_data", type=bytes.fromhex, help="hex bytes")
+    cmd.set_defaults(method="data_write", stream="", fields=("data",))
+
+    cmd = commands.add_parser("counter-stream", help="P2C stream counter_stream")
+    cmd.add_argument("--count", dest="field_count", type=_int, help="uint32")
+    cmd.set_defaults(method="iter_counter_stream", stream="p2c", fields=("count",))
+
+    cmd = commands.add_parser("counter-upload", help="C2P stream counter_upload")
+    cmd.add_argument(
+        "--input",
+        type=argparse.FileType(),
+        default=sys.stdin,
+        help="CounterUploadRequest per line as JSON (default: stdin)",
+    )
+    cmd.set_defaults(
+        method="counter_upload", stream="c2p", request=blerpc_pb2.CounterUploadRequest
+    )
+
+    cmd = commands.add_parser("get-blerpc-info", help="call get_blerpc_info")
+    cmd.set_defaults(method="get_blerpc_info", stream="", fields=())
+
+    cmd = commands.add_parser("conn-params", help="call conn_params")
+    cmd.add_argument(
+        "--profile",
+        dest="field_profile",
+        type=_enum(blerpc_pb2.ConnProfile),
+        help="ConnProfile name or number",
+    )
+    cmd.set_defaults(method="conn_params", stream="", fields=("profile",))
+
+    cmd = commands.add_parser("dfu-begin", help="call dfu_begin")
+    cmd.add_argument("--size", dest="field_size", type=_int, help="uint32")
+    cmd.add_argument("--crc32", dest="field_crc32", type=_int, help="uint32")
+    cmd.set_defaults(method="dfu_begin", stream="", fields=("size", "crc32"))
+
+    cmd = commands.add_parser("dfu-chunk", help="call dfu_chunk")
+    cmd.add_argument("--offset", dest="field_offset", type=_int, help="uint32")
+    cmd.add_argument("--data", dest="field_data", type=bytes.fromhex, help="hex bytes")
+    cmd.set_defaults(method="dfu_chunk", stream="", fields=("offset", "data"))
+
+    cmd = commands.add_parser("dfu-finalize", help="call dfu_finalize")
+    cmd.add_argument("--reboot", dest="field_reboot", type=_bool, help="true or false")
+    cmd.set_defaults(method="dfu_finalize", stream="", fields=("reboot",))
+
+    cmd = commands.add_parser("file-open", help="call file_open")
+    cmd.add_argument("--path", dest="field_path", type=str, help="string")
+    cmd.add_argument("--write", dest="field_write", type=_bool, help="true or false")
+    cmd.add_argument("--resume", dest="field_resume", type=_bool, help="true or false")
+    cmd.set_defaults(method="file_open", stream="", fields=("path", "write", "resume"))
+
+    cmd = commands.add_parser("file-read", help="call file_read")
+    cmd.add_argument("--handle", dest="field_handle", type=_int, help="uint32")
+    cmd.add_argument("--offset", dest="field_offset", type=_int, help="uint32")
+    cmd.add_argument("--length", dest="field_length", type=_int, help="uint32")
+    cmd.set_defaults(
+        method="file_read", stream="", fields=("handle", "offset", "length")
+    )
+
+    cmd = commands.add_parser("file-write", help="call file_write")
+    cmd.add_argument("--handle", dest="field_handle", type=_int, help="uint32")
+    cmd.add_argument("--offset", dest="field_offset", type=_int, help="uint32")
+    cmd.add_argument("--data", dest="field_data", type=bytes.fromhex, help="hex bytes")
+    cmd.set_defaults(
+        method="file_write", stream="", fields=("handle", "offset", "data")
+    )
+
+    cmd = commands.add_parser("file-close", help="call file_close")
+    cmd.add_argument("--handle", dest="field_handle", type=_int, help="uint32")
+    cmd.add_argument("--verify", dest="field_verify", type=_bool, help="true or false")
+    cmd.set_defaults(method="file_close", stream="", fields=("handle", "verify"))
+
+    cmd = commands.add_parser("log-stream", help="P2C stream log_stream")
+    cmd.add_argument(
+        "--min-level",
+        dest="field_min_level",
+        type=_enum(blerpc_pb2.LogLevel),
+        help="LogLevel name or number",
+    )
+    cmd.add_argument(
+        "--max-entries", dest="field_max_entries", type=_int, help="uint32"
+    )
+    cmd.set_defaults(
+        method="iter_log_stream", stream="p2c", fields=("min_level", "max_entries")
+    )
+
+    cmd = commands.add_parser("get-rpc-stats", help="call get_rpc_stats")
+    cmd.add_argument("--reset", dest="field_reset", type=_bool, help="true or false")
+    cmd.set_defaults(method="get_rpc_stats", stream="", fields=("reset",))
+
+    cmd = commands.add_parser("start-session", help="call start_session")
+    cmd.set_defaults(method="start_session", stream="", fields=())
+
+    cmd = commands.add_parser("authenticate-session", help="call authenticate_session")
+    cmd.add_argument(
+        "--proof", dest="field_proof", type=bytes.fromhex, help="hex bytes"
+    )
+    cmd.set_defaults(method="authenticate_session", stream="", fields=("proof",))
+
+    cmd = commands.add_parser("time-sync", help="call time_sync")
+    cmd.add_argument(
+        "--unix-time-us", dest="field_unix_time_us", type=_int, help="int64"
+    )
+    cmd.add_argument("--offset-us", dest="field_offset_us", type=_int, help="uint32")
+    cmd.set_defaults(
+        method="time_sync", stream="", fields=("unix_time_us", "offset_us")
+    )
+
+    cmd = commands.add_parser("ping", help="call ping")
+    cmd.add_argument(
+        "--payload", dest="field_payload", type=bytes.fromhex, help="hex bytes"
+    )
+    cmd.set_defaults(method="ping", stream="", fields=("payload",))
+
+    cmd = commands.add_parser("get-capabilities", help="call get_capabilities")
+    cmd.set_defaults(method="get_capabilities", stream="", fields=())
+
+    cmd = commands.add_parser("get-setting", help="call get_setting")
+    cmd.add_argument("--field", dest="field_field", type=_int, help="uint32")
+    cmd.set_defaults(method="get_setting", stream="", fields=("field",))
+
+    cmd = commands.add_parser("set-setting", help="call set_setting")
+    cmd.add_argument("--field", dest="field_field", type=_int, help="uint32")
+    cmd.add_argument(
+        "--value", dest="field_value", type=bytes.fromhex, help="hex bytes"
+    )
+    cmd.set_defaults(method="set_setting", stream="", fields=("field", "value"))
+
+    return parser
+
+
+async def _connect(args: argparse.Namespace) -> BlerpcClient:
+    client = BlerpcClient(
+        known_keys_path=args.known_keys, require_encryption=not args.no_encryption
+    )
+    client.session_key = args.session_key
+    devices = await scan(client, timeout=args.scan_timeout)
+    if args.device is not None:
+        devices = [d for d in devices if args.device in (d.name, d.address)]
+    if not devices:
+        raise BlerpcError("no blerpc peripheral found")
+    await client.connect(devices[0].device)
+    return client
+
+
+async def _run(args: argparse.Namespace) -> None:
+    client = await _connect(args)
+    try:
+        method = getattr(client, args.method)
+        if args.stream == "c2p":
+            lines = (line for line in args.input if line.strip())
+            requests = (json_format.Parse(line, args.request()) for line in lines)
+            print(to_json(await method(requests)))
+            return
+        kwargs = {}
+        for name in args.fields:
+            value = getattr(args, "field_" + name)
+            if value is not None:
+                kwargs[name] = value
+        if args.stream == "p2c":
+            async for resp in method(**kwargs):
+                print(to_json(resp), flush=True)
+            return
+        print(to_json(await method(**kwargs)))
+    finally:
+        await client.disconnect()
+
+
+def main(argv: list[str] | None = None) -> int:
+    """Entry point of blerpc-cli."""
+    args = _build_parser().parse_args(argv)
+    try:
+        asyncio.run(_run(args))
+    except (BlerpcError, json_format.ParseError) as e:
+        print(f"error: {e}", file=sys.stderr)
+        return 1
+    except KeyboardInterrupt:
+        return 130
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -0,0 +1,224 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+
+
+class DeviceManager:
+    """Connections to several peripherals, keyed by address.
+
+    factory returns a new, unconnected client, e.g. BlerpcClient. Every device
+    gets a client of its own, so calls to different devices run concurrently
+    while each client still runs one RPC at a time.
+    """
+
+    def __init__(self, factory):
+        self._factory = factory
+        self._clients = {}
+        self._connecting = {}
+
+    def __contains__(self, address):
+        return address in self._clients
+
+    def __getitem__(self, address):
+        """The client of address; KeyError if it was never connected."""
+        return self._clients[address]
+
+    def __len__(self):
+        return len(self._clients)
+
+    @property
+    def addresses(self):
+        """Addresses of the managed devices, in connection order."""
+        return list(self._clients)
+
+    async def __aenter__(self):
+        return self
+
+    async def __aexit__(self, *exc):
+        await self.disconnect_all()
+
+    async def connect(self, device):
+        """Connect to a scanned device and return its client.
+
+        A live connection to the same address is reused; concurrent calls for
+        one address share a single connection attempt.
+        """
+        address = device.address
+        client = self._clients.get(address)
+        if client is not None and client.is_connected:
+            return client
+        task = self._connecting.get(address)
+        if task is None:
+            task = asyncio.ensure_future(self._connect(device))
+            self._connecting[address] = task
+            task.add_done_callback(lambda _: self._connecting.pop(address, None))
+        return await asyncio.shield(task)
+
+    async def _connect(self, device):
+        client = self._factory()
+        await client.connect(device)
+        self._clients[device.address] = client
+        return client
+
+    async def connect_all(self, devices):
+        """Connect to every device concurrently.
+
+        Returns {address: client}, with the exception in place of the client
+        for devices that could not be connected.
+        """
+        devices = list(devices)
+        results = await asyncio.gather(
+            *(self.connect(d) for d in devices), return_exceptions=True
+        )
+        return {d.address: r for d, r in zip(devices, results)}
+
+    async def disconnect(self, address):
+        """Disconnect address and stop managing it."""
+        client = self._clients.pop(address, None)
+        if client is not None:
+            await client.disconnect()
+
+    async def disconnect_all(self):
+        clients = list(self._clients.values())
+        self._clients.clear()
+        await asyncio.gather(
+            *(c.disconnect() for c in clients), return_exceptions=True
+        )
+
+    async def broadcast(self, fn, addresses=None):
+        """Await fn(client) for every connected device concurrently.
+
+        addresses limits the call to those devices. Returns {address: result};
+        a device whose call failed maps to the exception, so one bad device
+        does not hide the results of the others.
+        """
+        if addresses is None:
+            addresses = [a for a, c in self._clients.items() if c.is_connected]
+        addresses = list(addresses)
+        results = await asyncio.gather(
+            *(fn(self._clients[a]) for a in addresses), return_exceptions=True
+        )
+        return dict(zip(addresses, results))
+
+    async def echo_all(self, *, message="", addresses=None):
+        """Call echo on every connected device."""
+        return await self.broadcast(lambda c: c.echo(message=message), addresses)
+
+    async def flash_read_all(self, *, address=0, length=0, addresses=None):
+        """Call flash_read on every connected device."""
+        return await self.broadcast(
+            lambda c: c.flash_read(address=address, length=length), addresses
+        )
+
+    async def data_write_all(self, *, data=b"", addresses=None):
+        """Call data_write on every connected device."""
+        return await self.broadcast(lambda c: c.data_write(data=data), addresses)
+
+    async def counter_stream_all(self, *, count=0, addresses=None):
+        """Call counter_stream on every connected device."""
+        return await self.broadcast(lambda c: c.counter_stream(count=count), addresses)
+
+    async def counter_upload_all(self, messages, *, addresses=None):
+        """Call counter_upload on every connected device."""
+        return await self.broadcast(lambda c: c.counter_upload(messages), addresses)
+
+    async def get_blerpc_info_all(self, *, addresses=None):
+        """Call get_blerpc_info on every connected device."""
+        return await self.broadcast(lambda c: c.get_blerpc_info(), addresses)
+
+    async def conn_params_all(self, *, profile=0, addresses=None):
+        """Call conn_params on every connected device."""
+        return await self.broadcast(lambda c: c.conn_params(profile=profile), addresses)
+
+    async def dfu_begin_all(self, *, size=0, crc32=0, addresses=None):
+        """Call dfu_begin on every connected device."""
+        return await self.broadcast(
+            lambda c: c.dfu_begin(size=size, crc32=crc32), addresses
+        )
+
+    async def dfu_chunk_all(self, *, offset=0, data=b"", addresses=None):
+        """Call dfu_chunk on every connected device."""
+        return await self.broadcast(
+            lambda c: c.dfu_chunk(offset=offset, data=data), addresses
+        )
+
+    async def dfu_finalize_all(self, *, reboot=False, addresses=None):
+        """Call dfu_finalize on every connected device."""
+        return await self.broadcast(lambda c: c.dfu_finalize(reboot=reboot), addresses)
+
+    async def file_open_all(
+        self,
+        *,
+        path="",
+        write=False,
+        resume=False,
+        addresses=None,
+    ):
+        """Call file_open on every connected device."""
+        return await self.broadcast(
+            lambda c: c.file_open(path=path, write=write, resume=resume), addresses
+        )
+
+    async def file_read_all(self, *, handle=0, offset=0, length=0, addresses=None):
+        """Call file_read on every connected device."""
+        return await self.broadcast(
+            lambda c: c.file_read(handle=handle, offset=offset, length=length), addresses
+        )
+
+    async def file_write_all(self, *, handle=0, offset=0, data=b"", addresses=None):
+        """Call file_write on every connected device."""
+        return await self.broadcast(
+            lambda c: c.file_write(handle=handle, offset=offset, data=data), addresses
+        )
+
+    async def file_close_all(self, *, handle=0, verify=False, addresses=None):
+        """Call file_close on every connected device."""
+        return await self.broadcast(
+            lambda c: c.file_close(handle=handle, verify=verify), addresses
+        )
+
+    async def log_stream_all(self, *, min_level=0, max_entries=0, addresses=None):
+        """Call log_stream on every connected device."""
+        return await self.broadcast(
+            lambda c: c.log_stream(min_level=min_level, max_entries=max_entries), addresses
+        )
+
+    async def get_rpc_stats_all(self, *, reset=False, addresses=None):
+        """Call get_rpc_stats on every connected device."""
+        return await self.broadcast(lambda c: c.get_rpc_stats(reset=reset), addresses)
+
+    async def start_session_all(self, *, addresses=None):
+        """Call start_session on every connected device."""
+        return await self.broadcast(lambda c: c.start_session(), addresses)
+
+    async def authenticate_session_all(self, *, proof=b"", addresses=None):
+        """Call authenticate_session on every connected device."""
+        return await self.broadcast(
+            lambda c: c.authenticate_session(proof=proof), addresses
+        )
+
+    async def time_sync_all(self, *, unix_time_us=0, offset_us=0, addresses=None):
+        """Call time_sync on every connected device."""
+        return await self.broadcast(
+            lambda c: c.time_sync(unix_time_us=unix_time_us, offset_us=offset_us), addresses
+        )
+
+    async def ping_all(self, *, payload=b"", addresses=None):
+        """Call ping on every connected device."""
+        return await self.broadcast(lambda c: c.ping(payload=payload), addresses)
+
+    async def get_capabilities_all(self, *, addresses=None):
+        """Call get_capabilities on every connected device."""
+        return await self.broadcast(lambda c: c.get_capabilities(), addresses)
+
+    async def get_setting_all(self, *, field=0, addresses=None):
+        """Call get_setting on every connected device."""
+        return await self.broadcast(lambda c: c.get_setting(field=field), addresses)
+
+    async def set_setting_all(self, *, field=0, value=b"", addresses=None):
+        """Call set_setting on every connected device."""
+        return await self.broadcast(
+            lambda c: c.set_setting(field=field, value=value), addresses
+        )
@@ -0,0 +1,36 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from google.protobuf import message
+
+from . import blerpc_pb2
+
+# Bluetooth SIG company identifier of the manufacturer data.
+COMPANY_ID = 0xFFFF
+
+# Message class per advertisement type.
+ADVERTISEMENT_TYPES = {
+    1: blerpc_pb2.SensorState,
+}
+
+
+def parse_manufacturer_data(manufacturer_data):
+    """Decode the advertisement in the manufacturer data of a scan result.
+
+    manufacturer_data maps company identifiers to data without the
+    identifier, like bleak's AdvertisementData.manufacturer_data. Returns
+    None when it holds no valid advertisement of this schema.
+    """
+    data = manufacturer_data.get(COMPANY_ID)
+    if not data:
+        return None
+    cls = ADVERTISEMENT_TYPES.get(data[0])
+    if cls is None:
+        return None
+    msg = cls()
+    try:
+        msg.ParseFromString(bytes(data[1:]))
+    except message.DecodeError:
+        return None
+    return msg
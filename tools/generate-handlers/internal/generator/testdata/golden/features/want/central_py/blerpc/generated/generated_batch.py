@@ -0,0 +1,422 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import warnings
+from collections.abc import Callable
+from typing import Generic, TypeVar, cast
+
+from . import blerpc_pb2
+from .generated_client import (
+    BlerpcError,
+    CommandId,
+    GeneratedClientMixin,
+    StringTooLongError,
+    _decode,
+    _rpc_lock,
+)
+
+# Command name of the batch envelope.
+BATCH_COMMAND = "_batch"
+
+T = TypeVar("T")
+
+
+class BatchError(BlerpcError):
+    """The batch response holds no complete response for a call."""
+
+
+class BatchCall(Generic[T]):
+    """The response of a call added to a Batch, once the batch is sent."""
+
+    def __init__(self, command: str, decode: Callable[[bytes], T]):
+        self.command = command
+        self._decode = decode
+        self._result: T | None = None
+        self._error: BaseException | None = None
+        self._done = False
+
+    def result(self) -> T:
+        """Return the response, or raise the error of the call."""
+        if not self._done:
+            raise RuntimeError(f"{self.command}: the batch was not sent")
+        if self._error is not None:
+            raise self._error
+        return cast(T, self._result)
+
+    def _set(self, data: bytes) -> None:
+        try:
+            self._result = self._decode(data)
+        except BlerpcError as e:
+            self._error = e
+        self._done = True
+
+    def _fail(self, error: BaseException) -> None:
+        self._error = error
+        self._done = True
+
+
+class Batch:
+    """Unary calls sent to the peripheral in one request.
+
+    The peripheral runs them in order and answers them in one response, so a
+    sequence of small calls, such as provisioning, costs one round trip
+    instead of one per call:
+
+        batch = Batch(client)
+        echo = batch.echo(message="hi")
+        await batch.send()
+        print(echo.result().message)
+
+    Streaming commands, and commands with a replay counter or a session
+    token, cannot be batched.
+    """
+
+    def __init__(self, client: GeneratedClientMixin):
+        self._client = client
+        self._entries: list[bytes] = []
+        self._calls: list[BatchCall] = []
+
+    def _add(self, name: str, data: bytes, call: BatchCall[T]) -> BatchCall[T]:
+        encoded = name.encode()
+        header = bytes([len(encoded)]) + encoded + len(data).to_bytes(2, "little")
+        self._entries.append(header + data)
+        self._calls.append(call)
+        return call
+
+    async def send(self) -> None:
+        """Send the calls added since the last send and record their results.
+
+        Raises the error of the first failed call once every result is
+        recorded, or the transport error, which every call then raises too.
+        """
+        calls, self._calls = self._calls, []
+        payload, self._entries = b"".join(self._entries), []
+        if not calls:
+            return
+        try:
+            async with _rpc_lock(self._client):
+                resp_data = await self._client._call(BATCH_COMMAND, payload)
+        except Exception as e:
+            for call in calls:
+                call._fail(e)
+            raise
+        pos = 0
+        for call in calls:
+            size = int.from_bytes(resp_data[pos : pos + 2], "little")
+            if len(resp_data) < pos + 2 + size:
+                call._fail(BatchError(f"{call.command}: no response in the batch"))
+                pos = len(resp_data)
+                continue
+            call._set(resp_data[pos + 2 : pos + 2 + size])
+            pos += 2 + size
+        for call in calls:
+            if call._error is not None:
+                raise call._error
+
+    def echo(self, *, message: str = "") -> BatchCall[blerpc_pb2.EchoResponse]:
+        """Add a call of the echo command."""
+        if len(message.encode()) > 256:
+            raise StringTooLongError("echo", "message", len(message.encode()), 256)
+        req = blerpc_pb2.EchoRequest(message=message)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.EchoResponse:
+            resp = _decode(blerpc_pb2.EchoResponse(), resp_data, "echo")
+            return resp
+
+        return self._add(
+            CommandId.ECHO.wire_name, req.SerializeToString(), BatchCall("echo", decode)
+        )
+
+    def data_write(
+        self, *, data: bytes = b""
+    ) -> BatchCall[blerpc_pb2.DataWriteResponse]:
+        """Add a call of the data_write command."""
+        warnings.warn(
+            "data_write() is deprecated",
+            DeprecationWarning,
+            stacklevel=2,
+        )
+        req = blerpc_pb2.DataWriteRequest(data=data)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.DataWriteResponse:
+            resp = _decode(blerpc_pb2.DataWriteResponse(), resp_data, "data_write")
+            return resp
+
+        return self._add(
+            CommandId.DATA_WRITE.wire_name,
+            req.SerializeToString(),
+            BatchCall("data_write", decode),
+        )
+
+    def get_blerpc_info(self) -> BatchCall[blerpc_pb2.GetBlerpcInfoResponse]:
+        """Add a call of the get_blerpc_info command."""
+        req = blerpc_pb2.GetBlerpcInfoRequest()
+
+        def decode(resp_data: bytes) -> blerpc_pb2.GetBlerpcInfoResponse:
+            resp = _decode(
+                blerpc_pb2.GetBlerpcInfoResponse(), resp_data, "get_blerpc_info"
+            )
+            return resp
+
+        return self._add(
+            CommandId.GET_BLERPC_INFO.wire_name,
+            req.SerializeToString(),
+            BatchCall("get_blerpc_info", decode),
+        )
+
+    def conn_params(
+        self, *, profile: int = 0
+    ) -> BatchCall[blerpc_pb2.ConnParamsResponse]:
+        """Add a call of the conn_params command."""
+        req = blerpc_pb2.ConnParamsRequest(profile=profile)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.ConnParamsResponse:
+            resp = _decode(blerpc_pb2.ConnParamsResponse(), resp_data, "conn_params")
+            return resp
+
+        return self._add(
+            CommandId.CONN_PARAMS.wire_name,
+            req.SerializeToString(),
+            BatchCall("conn_params", decode),
+        )
+
+    def dfu_begin(
+        self, *, size: int = 0, crc32: int = 0
+    ) -> BatchCall[blerpc_pb2.DfuBeginResponse]:
+        """Add a call of the dfu_begin command."""
+        req = blerpc_pb2.DfuBeginRequest(size=size, crc32=crc32)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.DfuBeginResponse:
+            resp = _decode(blerpc_pb2.DfuBeginResponse(), resp_data, "dfu_begin")
+            return resp
+
+        return self._add(
+            CommandId.DFU_BEGIN.wire_name,
+            req.SerializeToString(),
+            BatchCall("dfu_begin", decode),
+        )
+
+    def dfu_chunk(
+        self, *, offset: int = 0, data: bytes = b""
+    ) -> BatchCall[blerpc_pb2.DfuChunkResponse]:
+        """Add a call of the dfu_chunk command."""
+        req = blerpc_pb2.DfuChunkRequest(offset=offset, data=data)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.DfuChunkResponse:
+            resp = _decode(blerpc_pb2.DfuChunkResponse(), resp_data, "dfu_chunk")
+            return resp
+
+        return self._add(
+            CommandId.DFU_CHUNK.wire_name,
+            req.SerializeToString(),
+            BatchCall("dfu_chunk", decode),
+        )
+
+    def dfu_finalize(
+        self, *, reboot: bool = False
+    ) -> BatchCall[blerpc_pb2.DfuFinalizeResponse]:
+        """Add a call of the dfu_finalize command."""
+        req = blerpc_pb2.DfuFinalizeRequest(reboot=reboot)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.DfuFinalizeResponse:
+            resp = _decode(blerpc_pb2.DfuFinalizeResponse(), resp_data, "dfu_finalize")
+            return resp
+
+        return self._add(
+            CommandId.DFU_FINALIZE.wire_name,
+            req.SerializeToString(),
+            BatchCall("dfu_finalize", decode),
+        )
+
+    def file_open(
+        self, *, path: str = "", write: bool = False, resume: bool = False
+    ) -> BatchCall[blerpc_pb2.FileOpenResponse]:
+        """Add a call of the file_open command."""
+        req = blerpc_pb2.FileOpenRequest(path=path, write=write, resume=resume)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.FileOpenResponse:
+            resp = _decode(blerpc_pb2.FileOpenResponse(), resp_data, "file_open")
+            return resp
+
+        return self._add(
+            CommandId.FILE_OPEN.wire_name,
+            req.SerializeToString(),
+            BatchCall("file_open", decode),
+        )
+
+    def file_read(
+        self, *, handle: int = 0, offset: int = 0, length: int = 0
+    ) -> BatchCall[blerpc_pb2.FileReadResponse]:
+        """Add a call of the file_read command."""
+        req = blerpc_pb2.FileReadRequest(handle=handle, offset=offset, length=length)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.FileReadResponse:
+            resp = _decode(blerpc_pb2.FileReadResponse(), resp_data, "file_read")
+            return resp
+
+        return self._add(
+            CommandId.FILE_READ.wire_name,
+            req.SerializeToString(),
+            BatchCall("file_read", decode),
+        )
+
+    def file_write(
+        self, *, handle: int = 0, offset: int = 0, data: bytes = b""
+    ) -> BatchCall[blerpc_pb2.FileWriteResponse]:
+        """Add a call of the file_write command."""
+        req = blerpc_pb2.FileWriteRequest(handle=handle, offset=offset, data=data)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.FileWriteResponse:
+            resp = _decode(blerpc_pb2.FileWriteResponse(), resp_data, "file_write")
+            return resp
+
+        return self._add(
+            CommandId.FILE_WRITE.wire_name,
+            req.SerializeToString(),
+            BatchCall("file_write", decode),
+        )
+
+    def file_close(
+        self, *, handle: int = 0, verify: bool = False
+    ) -> BatchCall[blerpc_pb2.FileCloseResponse]:
+        """Add a call of the file_close command."""
+        req = blerpc_pb2.FileCloseRequest(handle=handle, verify=verify)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.FileCloseResponse:
+            resp = _decode(blerpc_pb2.FileCloseResponse(), resp_data, "file_close")
+            return resp
+
+        return self._add(
+            CommandId.FILE_CLOSE.wire_name,
+            req.SerializeToString(),
+            BatchCall("file_close", decode),
+        )
+
+    def get_rpc_stats(
+        self, *, reset: bool = False
+    ) -> BatchCall[blerpc_pb2.GetRpcStatsResponse]:
+        """Add a call of the get_rpc_stats command."""
+        req = blerpc_pb2.GetRpcStatsRequest(reset=reset)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.GetRpcStatsResponse:
+            resp = _decode(blerpc_pb2.GetRpcStatsResponse(), resp_data, "get_rpc_stats")
+            return resp
+
+        return self._add(
+            CommandId.GET_RPC_STATS.wire_name,
+            req.SerializeToString(),
+            BatchCall("get_rpc_stats", decode),
+        )
+
+    def start_session(self) -> BatchCall[blerpc_pb2.StartSessionResponse]:
+        """Add a call of the start_session command."""
+        req = blerpc_pb2.StartSessionRequest()
+
+        def decode(resp_data: bytes) -> blerpc_pb2.StartSessionResponse:
+            resp = _decode(
+                blerpc_pb2.StartSessionResponse(), resp_data, "start_session"
+            )
+            return resp
+
+        return self._add(
+            CommandId.START_SESSION.wire_name,
+            req.SerializeToString(),
+            BatchCall("start_session", decode),
+        )
+
+    def authenticate_session(
+        self, *, proof: bytes = b""
+    ) -> BatchCall[blerpc_pb2.AuthenticateSessionResponse]:
+        """Add a call of the authenticate_session command."""
+        req = blerpc_pb2.AuthenticateSessionRequest(proof=proof)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.AuthenticateSessionResponse:
+            resp = _decode(
+                blerpc_pb2.AuthenticateSessionResponse(),
+                resp_data,
+                "authenticate_session",
+            )
+            return resp
+
+        return self._add(
+            CommandId.AUTHENTICATE_SESSION.wire_name,
+            req.SerializeToString(),
+            BatchCall("authenticate_session", decode),
+        )
+
+    def time_sync(
+        self, *, unix_time_us: int = 0, offset_us: int = 0
+    ) -> BatchCall[blerpc_pb2.TimeSyncResponse]:
+        """Add a call of the time_sync command."""
+        req = blerpc_pb2.TimeSyncRequest(unix_time_us=unix_time_us, offset_us=offset_us)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.TimeSyncResponse:
+            resp = _decode(blerpc_pb2.TimeSyncResponse(), resp_data, "time_sync")
+            return resp
+
+        return self._add(
+            CommandId.TIME_SYNC.wire_name,
+            req.SerializeToString(),
+            BatchCall("time_sync", decode),
+        )
+
+    def ping(self, *, payload: bytes = b"") -> BatchCall[blerpc_pb2.PingResponse]:
+        """Add a call of the ping command."""
+        req = blerpc_pb2.PingRequest(payload=payload)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.PingResponse:
+            resp = _decode(blerpc_pb2.PingResponse(), resp_data, "ping")
+            return resp
+
+        return self._add(
+            CommandId.PING.wire_name, req.SerializeToString(), BatchCall("ping", decode)
+        )
+
+    def get_capabilities(self) -> BatchCall[blerpc_pb2.GetCapabilitiesResponse]:
+        """Add a call of the get_capabilities command."""
+        req = blerpc_pb2.GetCapabilitiesRequest()
+
+        def decode(resp_data: bytes) -> blerpc_pb2.GetCapabilitiesResponse:
+            resp = _decode(
+                blerpc_pb2.GetCapabilitiesResponse(), resp_data, "get_capabilities"
+            )
+            return resp
+
+        return self._add(
+            CommandId.GET_CAPABILITIES.wire_name,
+            req.SerializeToString(),
+            BatchCall("get_capabilities", decode),
+        )
+
+    def get_setting(
+        self, *, field: int = 0
+    ) -> BatchCall[blerpc_pb2.GetSettingResponse]:
+        """Add a call of the get_setting command."""
+        req = blerpc_pb2.GetSettingRequest(field=field)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.GetSettingResponse:
+            resp = _decode(blerpc_pb2.GetSettingResponse(), resp_data, "get_setting")
+            return resp
+
+        return self._add(
+            CommandId.GET_SETTING.wire_name,
+            req.SerializeToString(),
+            BatchCall("get_setting", decode),
+        )
+
+    def set_setting(
+        self, *, field: int = 0, value: bytes = b""
+    ) -> BatchCall[blerpc_pb2.SetSettingResponse]:
+        """Add a call of the set_setting command."""
+        req = blerpc_pb2.SetSettingRequest(field=field, value=value)
+
+        def decode(resp_data: bytes) -> blerpc_pb2.SetSettingResponse:
+            resp = _decode(blerpc_pb2.SetSettingResponse(), resp_data, "set_setting")
+            return resp
+
+        return self._add(
+            CommandId.SET_SETTING.wire_name,
+            req.SerializeToString(),
+            BatchCall("set_setting", decode),
+        )
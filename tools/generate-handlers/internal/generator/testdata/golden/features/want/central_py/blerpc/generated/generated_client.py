@@ -0,0 +1,1087 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+import builtins
+import contextlib
+import enum
+import pathlib
+import time
+import hmac
+import warnings
+import zlib
+from collections.abc import AsyncIterable, AsyncIterator, Iterable
+from typing import NamedTuple, Protocol
+
+from google.protobuf import json_format, message
+
+try:
+    import heatshrink2
+except ImportError:
+    heatshrink2 = None
+
+from . import blerpc_pb2
+
+
+class BlerpcError(Exception):
+    """Base class of errors raised by generated client methods."""
+
+
+class TransportError(BlerpcError):
+    """The request could not be delivered or the response was lost."""
+
+
+class TimeoutError(TransportError, builtins.TimeoutError):
+    """The peripheral did not respond in time."""
+
+
+class DecodeError(BlerpcError):
+    """The response payload is not a valid message."""
+
+    def __init__(self, command, cause):
+        super().__init__(f"{command}: invalid response: {cause}")
+        self.command = command
+
+
+class RemoteError(BlerpcError):
+    """The peripheral reported a non-OK status."""
+
+    def __init__(self, command, status, message=None):
+        super().__init__(message or f"{command} failed: status={status}")
+        self.command = command
+        self.status = status
+
+
+class StatusCode(enum.IntEnum):
+    """Status codes of error responses; 128 and up are application codes."""
+
+    OK = 0
+    INVALID_ARGUMENT = 1
+    NOT_FOUND = 2
+    ALREADY_EXISTS = 3
+    PERMISSION_DENIED = 4
+    RESOURCE_EXHAUSTED = 5
+    FAILED_PRECONDITION = 6
+    OUT_OF_RANGE = 7
+    UNIMPLEMENTED = 8
+    INTERNAL = 9
+    UNAVAILABLE = 10
+    UNAUTHENTICATED = 11
+
+
+class InvalidArgumentError(RemoteError):
+    """The request is malformed or a field is out of bounds."""
+
+
+class NotFoundError(RemoteError):
+    """The requested entity does not exist."""
+
+
+class AlreadyExistsError(RemoteError):
+    """The entity to create exists already."""
+
+
+class PermissionDeniedError(RemoteError):
+    """The caller may not run the command."""
+
+
+class ResourceExhaustedError(RemoteError):
+    """Memory, storage or another resource ran out."""
+
+
+class FailedPreconditionError(RemoteError):
+    """The device is not in a state to run the command."""
+
+
+class OutOfRangeError(RemoteError):
+    """An offset or value lies past the valid range."""
+
+
+class UnimplementedError(RemoteError):
+    """The command is not supported by this firmware."""
+
+
+class InternalError(RemoteError):
+    """The firmware hit an unexpected error."""
+
+
+class UnavailableError(RemoteError):
+    """The device cannot run the command now; retry later."""
+
+
+class UnauthenticatedError(RemoteError):
+    """The command needs an authenticated session."""
+
+
+# Error responses hold only a status, in a field no message uses.
+_STATUS_TAG = b"\xf8\xff\xff\xff\x0f"
+
+_STATUS_ERRORS = {
+    StatusCode.INVALID_ARGUMENT: InvalidArgumentError,
+    StatusCode.NOT_FOUND: NotFoundError,
+    StatusCode.ALREADY_EXISTS: AlreadyExistsError,
+    StatusCode.PERMISSION_DENIED: PermissionDeniedError,
+    StatusCode.RESOURCE_EXHAUSTED: ResourceExhaustedError,
+    StatusCode.FAILED_PRECONDITION: FailedPreconditionError,
+    StatusCode.OUT_OF_RANGE: OutOfRangeError,
+    StatusCode.UNIMPLEMENTED: UnimplementedError,
+    StatusCode.INTERNAL: InternalError,
+    StatusCode.UNAVAILABLE: UnavailableError,
+    StatusCode.UNAUTHENTICATED: UnauthenticatedError,
+}
+
+
+def _check_status(data, command):
+    if not data.startswith(_STATUS_TAG):
+        return
+    status = shift = 0
+    for byte in data[len(_STATUS_TAG) :]:
+        status |= (byte & 0x7F) << shift
+        shift += 7
+        if byte < 0x80:
+            break
+    error = _STATUS_ERRORS.get(status)
+    if error is None:
+        raise RemoteError(command, status)
+    raise error(command, status, f"{command} failed: {StatusCode(status).name}")
+
+
+# The schema this client was generated from, which verify_schema compares
+# with the peripheral's.
+SCHEMA_HASH = "fb27bddde9c1f6b7"
+GENERATOR_VERSION = "0.1.0"
+
+
+class SchemaMismatchError(BlerpcError):
+    """The peripheral was built from a different schema than this client."""
+
+    def __init__(self, expected, actual, generator_version):
+        super().__init__(
+            f"peripheral schema {actual} (generator {generator_version}) does not "
+            f"match this client's {expected} (generator {GENERATOR_VERSION})"
+        )
+        self.expected = expected
+        self.actual = actual
+        self.generator_version = generator_version
+
+
+class StringTooLongError(BlerpcError, ValueError):
+    """A string argument is longer in UTF-8 than its max_size allows."""
+
+    def __init__(self, command, field, size, max_bytes):
+        super().__init__(
+            f"{command}: {field} is {size} bytes in UTF-8, over the {max_bytes}"
+            " its max_size allows"
+        )
+        self.command = command
+        self.field = field
+        self.size = size
+        self.max_bytes = max_bytes
+
+
+def _decode(resp, data, command):
+    _check_status(data, command)
+    try:
+        resp.ParseFromString(data)
+    except message.DecodeError as e:
+        raise DecodeError(command, e) from e
+    return resp
+
+
+def _rpc_lock(client):
+    # The peripheral handles one RPC at a time, so concurrent calls on one
+    # client would interleave their packets. Created on first use, as the
+    # mixin has no __init__.
+    return vars(client).setdefault("_rpc_call_lock", asyncio.Lock())
+
+
+class CallPolicy(NamedTuple):
+    """Timeout of each attempt of a command and how often it is retried."""
+
+    timeout_s: float
+    retries: int
+
+
+# Call policies by command. Commands without one wait as long as the
+# transport does and are not retried.
+CALL_POLICIES = {
+    "echo": CallPolicy(timeout_s=0.5, retries=2),
+    "flash_read": CallPolicy(timeout_s=30.0, retries=0),
+}
+
+
+async def _call_with_policy(command, call):
+    # Awaits call() under the policy of command: each attempt fails with
+    # TimeoutError after the timeout, and attempts that time out or fail with
+    # a TransportError are made again up to the retries.
+    policy = CALL_POLICIES[command]
+    for attempt in range(policy.retries + 1):
+        try:
+            return await asyncio.wait_for(call(), policy.timeout_s)
+        except TransportError:
+            if attempt == policy.retries:
+                raise
+        except builtins.TimeoutError as e:
+            if attempt == policy.retries:
+                msg = f"{command}: no response within {policy.timeout_s} s"
+                raise TimeoutError(msg) from e
+
+
+def _serialize_each(messages):
+    # Encode lazily, so a generator can produce the stream as it is sent.
+    if isinstance(messages, AsyncIterable):
+        return (m.SerializeToString() async for m in messages)
+    return (m.SerializeToString() for m in messages)
+
+
+_last_replay_counter = 0
+
+
+def _with_replay_counter(client, command, req_data):
+    # Leads a replay-protected request with a counter, 8 bytes little endian,
+    # and a MAC: the first 16 bytes of an HMAC-SHA256 under session_key of
+    # the command name, a NUL, the counter and the request. The peripheral
+    # drops requests whose MAC is wrong or whose counter is not above the last
+    # one it accepted; microseconds since the epoch keep it increasing across
+    # restarts. Call under _rpc_lock so requests go out in counter order.
+    global _last_replay_counter
+    key = getattr(client, "session_key", None)
+    if key is None:
+        msg = "set session_key before calling a replay-protected command"
+        raise BlerpcError(msg)
+    _last_replay_counter = max(_last_replay_counter + 1, time.time_ns() // 1000)
+    counter = _last_replay_counter.to_bytes(8, "little")
+    mac = hmac.digest(key, command.encode() + b"\0" + counter + req_data, "sha256")
+    return counter + mac[:16] + req_data
+
+
+async def _session_call(client, command, req_data):
+    # Sends a session-protected request led by the session token, opening a
+    # session first when the client has none. A peripheral that lost the
+    # session, e.g. on a reboot, answers UNAUTHENTICATED; the request is then
+    # sent once more in a new session. Call under _rpc_lock.
+    token = vars(client).get("_session_token") or await client._open_session()
+    resp_data = await client._call(command, token + req_data)
+    if resp_data == _STATUS_TAG + bytes([StatusCode.UNAUTHENTICATED]):
+        token = await client._open_session()
+        resp_data = await client._call(command, token + req_data)
+    return resp_data
+
+
+# CAPABILITIES flag of a peripheral answering calls in the _compressed
+# envelope.
+CAPABILITY_FLAG_COMPRESSION = 0x0002
+_COMPRESSED_COMMAND = "_compressed"
+# Flags of the envelope: the data is compressed; the response may be.
+_FLAG_COMPRESSED = 0x01
+_FLAG_ACCEPT_COMPRESSED = 0x02
+
+
+def _deflate(data):
+    compressor = zlib.compressobj(wbits=-15)
+    return compressor.compress(data) + compressor.flush()
+
+
+# Compress and decompress functions by algorithm: raw DEFLATE (RFC 1951),
+# and heatshrink (window 8, lookahead 4 bits) when heatshrink2 is installed.
+# Calls of a command whose algorithm has no codec are sent uncompressed.
+COMPRESSION_CODECS = {
+    "deflate": (_deflate, lambda data: zlib.decompress(data, wbits=-15)),
+}
+if heatshrink2 is not None:
+    COMPRESSION_CODECS["heatshrink"] = (
+        lambda data: heatshrink2.compress(data, window_sz2=8, lookahead_sz2=4),
+        lambda data: heatshrink2.decompress(data, window_sz2=8, lookahead_sz2=4),
+    )
+
+
+async def _compressed_call(client, algorithm, command, req_data):
+    # Sends a call of a compressed command in the _compressed envelope when
+    # the peripheral advertises CAPABILITY_FLAG_COMPRESSION and the algorithm
+    # has a codec, compressing the request when that makes it smaller, and
+    # returns the response decompressed; sends a plain call otherwise. Call
+    # under _rpc_lock.
+    codec = COMPRESSION_CODECS.get(algorithm)
+    capabilities = getattr(client, "capability_flags", 0)
+    if codec is None or not capabilities & CAPABILITY_FLAG_COMPRESSION:
+        return await client._call(command, req_data)
+    compress, decompress = codec
+    flags = _FLAG_ACCEPT_COMPRESSED
+    packed = compress(req_data)
+    if len(packed) < len(req_data):
+        flags |= _FLAG_COMPRESSED
+        req_data = packed
+    name = command.encode()
+    envelope = bytes([len(name)]) + name + bytes([flags]) + req_data
+    resp_data = await client._call(_COMPRESSED_COMMAND, envelope)
+    if not resp_data:
+        raise DecodeError(command, "empty response")
+    if not resp_data[0] & _FLAG_COMPRESSED:
+        return resp_data[1:]
+    try:
+        return decompress(resp_data[1:])
+    except Exception as e:
+        raise DecodeError(command, e) from e
+
+
+# Control command of the Cancel container, which stops the P2C stream in
+# progress on the peripheral. blerpc_protocol has no constant for it.
+CONTROL_CMD_CANCEL = 0x7
+
+
+def make_cancel(transaction_id):
+    """Serialize a Cancel control container, which carries no payload."""
+    return bytes([transaction_id & 0xFF, 0, 0xC0 | CONTROL_CMD_CANCEL << 2, 0])
+
+
+class CommandId(enum.IntEnum):
+    """Numeric command IDs, sent as one-character command names."""
+
+    ECHO = 1
+    FLASH_READ = 2
+    DATA_WRITE = 3
+    COUNTER_STREAM = 4
+    COUNTER_UPLOAD = 5
+    GET_BLERPC_INFO = 6
+    CONN_PARAMS = 7
+    DFU_BEGIN = 8
+    DFU_CHUNK = 9
+    DFU_FINALIZE = 10
+    FILE_OPEN = 11
+    FILE_READ = 12
+    FILE_WRITE = 13
+    FILE_CLOSE = 14
+    LOG_STREAM = 15
+    GET_RPC_STATS = 16
+    START_SESSION = 17
+    AUTHENTICATE_SESSION = 18
+    TIME_SYNC = 19
+    PING = 20
+    GET_CAPABILITIES = 21
+    GET_SETTING = 22
+    SET_SETTING = 23
+
+    @property
+    def wire_name(self) -> str:
+        """The command name carrying this ID."""
+        return chr(self)
+
+
+class RpcTransport(Protocol):
+    """Client primitives the generated methods call; BlerpcClient implements them."""
+
+    async def _call(self, cmd_name: str, request_data: bytes) -> bytes: ...
+
+    def stream_receive(
+        self, cmd_name: str, request_data: bytes
+    ) -> AsyncIterator[bytes]: ...
+
+    async def stream_send(
+        self,
+        cmd_name: str,
+        messages: Iterable[bytes] | AsyncIterable[bytes],
+        final_cmd_name: str,
+    ) -> bytes: ...
+
+
+class GeneratedClientMixin(RpcTransport):
+    """Auto-generated RPC methods (unary and streaming).
+
+    Requires the RpcTransport primitives, which BlerpcClient provides.
+    """
+
+    async def echo(self, *, message: str = "") -> blerpc_pb2.EchoResponse:
+        """Call the echo command."""
+        if len(message.encode()) > 256:
+            raise StringTooLongError("echo", "message", len(message.encode()), 256)
+        req = blerpc_pb2.EchoRequest(message=message)
+        async with _rpc_lock(self):
+            resp_data = await _call_with_policy(
+                "echo",
+                lambda: _compressed_call(self, "deflate", CommandId.ECHO.wire_name, req.SerializeToString()),
+            )
+        resp = _decode(blerpc_pb2.EchoResponse(), resp_data, "echo")
+        return resp
+
+    async def flash_read(
+        self, *, address: int = 0, length: int = 0
+    ) -> blerpc_pb2.FlashReadResponse:
+        """Call the flash_read command."""
+        req = blerpc_pb2.FlashReadRequest(address=address, length=length)
+        async with _rpc_lock(self):
+            req_data = _with_replay_counter(self, "flash_read", req.SerializeToString())
+            resp_data = await _call_with_policy(
+                "flash_read",
+                lambda: _session_call(self, CommandId.FLASH_READ.wire_name, req_data),
+            )
+        resp = _decode(blerpc_pb2.FlashReadResponse(), resp_data, "flash_read")
+        return resp
+
+    async def data_write(self, *, data: bytes = b"") -> blerpc_pb2.DataWriteResponse:
+        """Call the data_write command."""
+        warnings.warn(
+            "data_write() is deprecated",
+            DeprecationWarning,
+            stacklevel=2,
+        )
+        req = blerpc_pb2.DataWriteRequest(data=data)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.DATA_WRITE.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.DataWriteResponse(), resp_data, "data_write")
+        return resp
+
+    async def get_blerpc_info(self) -> blerpc_pb2.GetBlerpcInfoResponse:
+        """Call the get_blerpc_info command."""
+        req = blerpc_pb2.GetBlerpcInfoRequest()
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.GET_BLERPC_INFO.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.GetBlerpcInfoResponse(), resp_data, "get_blerpc_info")
+        return resp
+
+    async def conn_params(self, *, profile: int = 0) -> blerpc_pb2.ConnParamsResponse:
+        """Call the conn_params command."""
+        req = blerpc_pb2.ConnParamsRequest(profile=profile)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.CONN_PARAMS.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.ConnParamsResponse(), resp_data, "conn_params")
+        return resp
+
+    async def dfu_begin(
+        self, *, size: int = 0, crc32: int = 0
+    ) -> blerpc_pb2.DfuBeginResponse:
+        """Call the dfu_begin command."""
+        req = blerpc_pb2.DfuBeginRequest(size=size, crc32=crc32)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.DFU_BEGIN.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.DfuBeginResponse(), resp_data, "dfu_begin")
+        return resp
+
+    async def dfu_chunk(
+        self, *, offset: int = 0, data: bytes = b""
+    ) -> blerpc_pb2.DfuChunkResponse:
+        """Call the dfu_chunk command."""
+        req = blerpc_pb2.DfuChunkRequest(offset=offset, data=data)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.DFU_CHUNK.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.DfuChunkResponse(), resp_data, "dfu_chunk")
+        return resp
+
+    async def dfu_finalize(
+        self, *, reboot: bool = False
+    ) -> blerpc_pb2.DfuFinalizeResponse:
+        """Call the dfu_finalize command."""
+        req = blerpc_pb2.DfuFinalizeRequest(reboot=reboot)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.DFU_FINALIZE.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.DfuFinalizeResponse(), resp_data, "dfu_finalize")
+        return resp
+
+    async def file_open(
+        self, *, path: str = "", write: bool = False, resume: bool = False
+    ) -> blerpc_pb2.FileOpenResponse:
+        """Call the file_open command."""
+        req = blerpc_pb2.FileOpenRequest(path=path, write=write, resume=resume)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.FILE_OPEN.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.FileOpenResponse(), resp_data, "file_open")
+        return resp
+
+    async def file_read(
+        self, *, handle: int = 0, offset: int = 0, length: int = 0
+    ) -> blerpc_pb2.FileReadResponse:
+        """Call the file_read command."""
+        req = blerpc_pb2.FileReadRequest(handle=handle, offset=offset, length=length)
+        async with _rpc_lock(self):
+            resp_data = await _compressed_call(
+                self,
+                "heatshrink",
+                CommandId.FILE_READ.wire_name,
+                req.SerializeToString(),
+            )
+        resp = _decode(blerpc_pb2.FileReadResponse(), resp_data, "file_read")
+        return resp
+
+    async def file_write(
+        self, *, handle: int = 0, offset: int = 0, data: bytes = b""
+    ) -> blerpc_pb2.FileWriteResponse:
+        """Call the file_write command."""
+        req = blerpc_pb2.FileWriteRequest(handle=handle, offset=offset, data=data)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.FILE_WRITE.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.FileWriteResponse(), resp_data, "file_write")
+        return resp
+
+    async def file_close(
+        self, *, handle: int = 0, verify: bool = False
+    ) -> blerpc_pb2.FileCloseResponse:
+        """Call the file_close command."""
+        req = blerpc_pb2.FileCloseRequest(handle=handle, verify=verify)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.FILE_CLOSE.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.FileCloseResponse(), resp_data, "file_close")
+        return resp
+
+    async def get_rpc_stats(
+        self, *, reset: bool = False
+    ) -> blerpc_pb2.GetRpcStatsResponse:
+        """Call the get_rpc_stats command."""
+        req = blerpc_pb2.GetRpcStatsRequest(reset=reset)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.GET_RPC_STATS.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.GetRpcStatsResponse(), resp_data, "get_rpc_stats")
+        return resp
+
+    async def start_session(self) -> blerpc_pb2.StartSessionResponse:
+        """Call the start_session command."""
+        req = blerpc_pb2.StartSessionRequest()
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.START_SESSION.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.StartSessionResponse(), resp_data, "start_session")
+        return resp
+
+    async def authenticate_session(
+        self, *, proof: bytes = b""
+    ) -> blerpc_pb2.AuthenticateSessionResponse:
+        """Call the authenticate_session command."""
+        req = blerpc_pb2.AuthenticateSessionRequest(proof=proof)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.AUTHENTICATE_SESSION.wire_name, req.SerializeToString()
+            )
+        resp = _decode(
+            blerpc_pb2.AuthenticateSessionResponse(), resp_data, "authenticate_session"
+        )
+        return resp
+
+    async def time_sync(
+        self, *, unix_time_us: int = 0, offset_us: int = 0
+    ) -> blerpc_pb2.TimeSyncResponse:
+        """Call the time_sync command."""
+        req = blerpc_pb2.TimeSyncRequest(unix_time_us=unix_time_us, offset_us=offset_us)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.TIME_SYNC.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.TimeSyncResponse(), resp_data, "time_sync")
+        return resp
+
+    async def ping(self, *, payload: bytes = b"") -> blerpc_pb2.PingResponse:
+        """Call the ping command."""
+        req = blerpc_pb2.PingRequest(payload=payload)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.PING.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.PingResponse(), resp_data, "ping")
+        return resp
+
+    async def get_capabilities(self) -> blerpc_pb2.GetCapabilitiesResponse:
+        """Call the get_capabilities command."""
+        req = blerpc_pb2.GetCapabilitiesRequest()
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.GET_CAPABILITIES.wire_name, req.SerializeToString()
+            )
+        resp = _decode(
+            blerpc_pb2.GetCapabilitiesResponse(), resp_data, "get_capabilities"
+        )
+        return resp
+
+    async def get_setting(self, *, field: int = 0) -> blerpc_pb2.GetSettingResponse:
+        """Call the get_setting command."""
+        req = blerpc_pb2.GetSettingRequest(field=field)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.GET_SETTING.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.GetSettingResponse(), resp_data, "get_setting")
+        return resp
+
+    async def set_setting(
+        self, *, field: int = 0, value: bytes = b""
+    ) -> blerpc_pb2.SetSettingResponse:
+        """Call the set_setting command."""
+        req = blerpc_pb2.SetSettingRequest(field=field, value=value)
+        async with _rpc_lock(self):
+            resp_data = await self._call(
+                CommandId.SET_SETTING.wire_name, req.SerializeToString()
+            )
+        resp = _decode(blerpc_pb2.SetSettingResponse(), resp_data, "set_setting")
+        return resp
+
+    async def iter_counter_stream(
+        self, *, count: int = 0
+    ) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:
+        """P2C stream: counter_stream, yielding each response as it arrives."""
+        req = blerpc_pb2.CounterStreamRequest(count=count)
+        async with _rpc_lock(self):
+            try:
+                async for data in self.stream_receive(
+                    CommandId.COUNTER_STREAM.wire_name, req.SerializeToString()
+                ):
+                    resp = _decode(
+                        blerpc_pb2.CounterStreamResponse(), data, "counter_stream"
+                    )
+                    yield resp
+            except (asyncio.CancelledError, GeneratorExit):
+                # The consumer stopped early: stop the peripheral too.
+                await self.stream_cancel()
+                raise
+
+    async def counter_stream(
+        self, *, count: int = 0
+    ) -> list[blerpc_pb2.CounterStreamResponse]:
+        """P2C stream: counter_stream."""
+        results = []
+        async for resp in self.iter_counter_stream(count=count):
+            results.append(resp)
+        return results
+
+    async def counter_upload(
+        self,
+        messages: (
+            Iterable[blerpc_pb2.CounterUploadRequest]
+            | AsyncIterable[blerpc_pb2.CounterUploadRequest]
+        ),
+    ) -> blerpc_pb2.CounterUploadResponse:
+        """C2P stream: counter_upload.
+
+        messages is an iterable or async iterable of CounterUploadRequest,
+        each sent as it is produced.
+        """
+        raw = _serialize_each(messages)
+        async with _rpc_lock(self):
+            resp_data = await self.stream_send(
+                CommandId.COUNTER_UPLOAD.wire_name,
+                raw,
+                CommandId.COUNTER_UPLOAD.wire_name,
+            )
+        resp = _decode(blerpc_pb2.CounterUploadResponse(), resp_data, "counter_upload")
+        return resp
+
+    async def iter_log_stream(
+        self, *, min_level: int = 0, max_entries: int = 0
+    ) -> AsyncIterator[blerpc_pb2.LogStreamResponse]:
+        """P2C stream: log_stream, yielding each response as it arrives."""
+        req = blerpc_pb2.LogStreamRequest(min_level=min_level, max_entries=max_entries)
+        async with _rpc_lock(self):
+            try:
+                async for data in self.stream_receive(
+                    CommandId.LOG_STREAM.wire_name, req.SerializeToString()
+                ):
+                    resp = _decode(blerpc_pb2.LogStreamResponse(), data, "log_stream")
+                    yield resp
+            except (asyncio.CancelledError, GeneratorExit):
+                # The consumer stopped early: stop the peripheral too.
+                await self.stream_cancel()
+                raise
+
+    async def log_stream(
+        self, *, min_level: int = 0, max_entries: int = 0
+    ) -> list[blerpc_pb2.LogStreamResponse]:
+        """P2C stream: log_stream."""
+        results = []
+        async for resp in self.iter_log_stream(
+            min_level=min_level, max_entries=max_entries
+        ):
+            results.append(resp)
+        return results
+
+    async def flash_dump(self, *args, **kwargs):
+        """Deprecated: renamed to flash_read."""
+        warnings.warn(
+            "flash_dump() is deprecated, use flash_read()",
+            DeprecationWarning,
+            stacklevel=2,
+        )
+        return await self.flash_read(*args, **kwargs)
+
+    async def echo_message(self, *, message: str = "") -> str:
+        """Return the message of the echo response."""
+        resp = await self.echo(message=message)
+        return resp.message
+
+    async def data_write_length(self, *, data: bytes = b"") -> int:
+        """Return the length of the data_write response."""
+        resp = await self.data_write(data=data)
+        return resp.length
+
+    async def verify_schema(self):
+        """Check that the peripheral was built from this client's schema.
+
+        Call once on connect. Raises SchemaMismatchError when the schema
+        hashes differ; returns the peripheral's info otherwise.
+        """
+        info = await self.get_blerpc_info()
+        if info.schema_hash != SCHEMA_HASH:
+            raise SchemaMismatchError(
+                SCHEMA_HASH, info.schema_hash, info.generator_version
+            )
+        return info
+
+    async def supported_commands(self):
+        """Return the names of the commands the firmware implements.
+
+        Commands the firmware lacks, or leaves on their stubs, are missing.
+        Query it once on connect and check the set before calling commands
+        some firmware versions lack.
+        """
+        resp = await self.get_capabilities()
+        ids = {
+            "echo": 1,
+            "flash_read": 2,
+            "data_write": 3,
+            "counter_stream": 4,
+            "counter_upload": 5,
+            "get_blerpc_info": 6,
+            "conn_params": 7,
+            "dfu_begin": 8,
+            "dfu_chunk": 9,
+            "dfu_finalize": 10,
+            "file_open": 11,
+            "file_read": 12,
+            "file_write": 13,
+            "file_close": 14,
+            "log_stream": 15,
+            "get_rpc_stats": 16,
+            "start_session": 17,
+            "authenticate_session": 18,
+            "time_sync": 19,
+            "ping": 20,
+            "get_capabilities": 21,
+            "get_setting": 22,
+            "set_setting": 23,
+        }
+        bitmap = resp.command_ids
+        return {
+            name
+            for name, i in ids.items()
+            if i // 8 < len(bitmap) and bitmap[i // 8] >> (i % 8) & 1
+        }
+
+    async def supports(self, command):
+        """Report whether the firmware implements command, e.g. "echo"."""
+        return command in await self.supported_commands()
+
+    @contextlib.asynccontextmanager
+    async def fast_connection(self):
+        """Run the block on the fast connection profile, e.g. for a DFU.
+
+        The balanced profile is requested again when the block exits.
+        """
+        await self.conn_params(profile=blerpc_pb2.CONN_PROFILE_FAST)
+        try:
+            yield
+        finally:
+            await self.conn_params(profile=blerpc_pb2.CONN_PROFILE_BALANCED)
+
+    async def use_idle_connection(self):
+        """Request the low-power profile for a connection that stays idle."""
+        return await self.conn_params(profile=blerpc_pb2.CONN_PROFILE_LOW_POWER)
+
+    async def update_firmware(self, path, progress=None, *, reboot=True):
+        """Write the firmware image at path to the peripheral and apply it.
+
+        An update interrupted before it was applied continues after the
+        bytes the peripheral already has when called again with the same
+        image. progress is called with (sent, total) after each chunk. With
+        reboot, the peripheral restarts into the image once it is verified.
+        """
+        image = pathlib.Path(path).read_bytes()
+        crc = zlib.crc32(image)
+        begun = await self.dfu_begin(size=len(image), crc32=crc)
+        offset = begun.offset
+        chunk_size = begun.max_chunk or 128
+        while offset < len(image):
+            chunk = image[offset : offset + chunk_size]
+            resp = await self.dfu_chunk(offset=offset, data=chunk)
+            if resp.offset == offset:
+                raise BlerpcError(f"{path}: update stalled at byte {offset}")
+            offset = resp.offset
+            if progress:
+                progress(offset, len(image))
+        done = await self.dfu_finalize(reboot=reboot)
+        if done.crc32 != crc:
+            raise BlerpcError(f"{path}: update failed verification")
+
+    async def upload_file(
+        self, path, data, *, resume=False, chunk_size=128, progress=None
+    ):
+        """Write data to path on the peripheral and verify it by CRC-32.
+
+        With resume, an interrupted upload continues after the bytes already
+        written, if they match the start of data. progress is called with
+        (sent, total) after each chunk.
+        """
+        opened = await self.file_open(path=path, write=True, resume=resume)
+        offset = opened.size
+        if offset and (
+            offset > len(data) or zlib.crc32(data[:offset]) != opened.crc32
+        ):
+            # The partial file is not a prefix of data: start over.
+            await self.file_close(handle=opened.handle)
+            opened = await self.file_open(path=path, write=True)
+            offset = 0
+        try:
+            while offset < len(data):
+                chunk = data[offset : offset + chunk_size]
+                await self.file_write(
+                    handle=opened.handle, offset=offset, data=chunk
+                )
+                offset += len(chunk)
+                if progress:
+                    progress(offset, len(data))
+        except BaseException:
+            try:
+                await self.file_close(handle=opened.handle)
+            except BlerpcError:
+                pass
+            raise
+        closed = await self.file_close(handle=opened.handle, verify=True)
+        if closed.size != len(data) or closed.crc32 != zlib.crc32(data):
+            raise BlerpcError(f"{path}: upload failed verification")
+
+    async def download_file(
+        self, path, *, partial=b"", chunk_size=128, progress=None
+    ):
+        """Read path from the peripheral and verify it by CRC-32.
+
+        Pass the bytes received before an interruption as partial to
+        continue after them. progress is called with (received, total)
+        after each chunk.
+        """
+        opened = await self.file_open(path=path)
+        data = bytearray(partial[: opened.size])
+        try:
+            while len(data) < opened.size:
+                resp = await self.file_read(
+                    handle=opened.handle, offset=len(data), length=chunk_size
+                )
+                if not resp.data:
+                    break
+                data += resp.data
+                if progress:
+                    progress(len(data), opened.size)
+        except BaseException:
+            try:
+                await self.file_close(handle=opened.handle)
+            except BlerpcError:
+                pass
+            raise
+        await self.file_close(handle=opened.handle)
+        if len(data) != opened.size or zlib.crc32(data) != opened.crc32:
+            raise BlerpcError(f"{path}: download failed verification")
+        return bytes(data)
+
+    async def read_logs(self, *, min_level=0, max_entries=0):
+        """Drain the firmware log into (timestamp, entry) pairs, oldest first.
+
+        The message of each entry joins the fragments of a long message, and
+        the timestamp (seconds since the epoch) is when it was logged.
+        """
+        responses = await self.log_stream(min_level=min_level, max_entries=max_entries)
+        received = time.time()
+        logs = []
+        first = None
+        for resp in responses:
+            if first is None:
+                first = resp
+            else:
+                first.message += resp.message
+            if resp.more:
+                continue
+            age_ms = (first.now_ms - first.uptime_ms) & 0xFFFFFFFF
+            logs.append((received - age_ms / 1000, first))
+            first = None
+        return logs
+
+    async def measure_latency(self):
+        """Ping the peripheral and return the round trip in seconds."""
+        start = time.monotonic()
+        await self.ping()
+        return time.monotonic() - start
+
+    async def keepalive(self, interval=5.0, *, on_latency=None):
+        """Ping the peripheral every interval seconds until cancelled.
+
+        Run it as a task next to the calls: it raises as soon as a ping
+        fails, so a dead connection shows before a long call times out.
+        on_latency, if given, is called with each round trip in seconds.
+        """
+        while True:
+            latency = await self.measure_latency()
+            if on_latency is not None:
+                on_latency(latency)
+            await asyncio.sleep(interval)
+
+    async def rpc_stats_by_command(self, *, reset=False):
+        """Return the firmware's per-command counters keyed by command name."""
+        resp = await self.get_rpc_stats(reset=reset)
+        return {s.name: s for s in resp.stats}
+
+    async def open_session(self):
+        """Authenticate to the peripheral, unlocking its protected commands.
+
+        Protected calls open a session as needed; call it to authenticate up
+        front. Set session_key to the key shared with the peripheral first.
+        """
+        async with _rpc_lock(self):
+            await self._open_session()
+
+    async def _open_session(self):
+        # Runs the handshake under the caller's _rpc_lock and keeps the token:
+        # the proof is an HMAC-SHA256 of the challenge under session_key.
+        key = getattr(self, "session_key", None)
+        if key is None:
+            msg = "set session_key before opening a session"
+            raise BlerpcError(msg)
+        req = blerpc_pb2.StartSessionRequest()
+        resp_data = await self._call(
+            CommandId.START_SESSION.wire_name, req.SerializeToString()
+        )
+        resp = _decode(blerpc_pb2.StartSessionResponse(), resp_data, "start_session")
+        req = blerpc_pb2.AuthenticateSessionRequest(
+            proof=hmac.digest(key, resp.challenge, "sha256")
+        )
+        resp_data = await self._call(
+            CommandId.AUTHENTICATE_SESSION.wire_name, req.SerializeToString()
+        )
+        resp = _decode(
+            blerpc_pb2.AuthenticateSessionResponse(), resp_data, "authenticate_session"
+        )
+        vars(self)["_session_token"] = resp.token
+        return resp.token
+
+    async def get_sample_interval_ms_setting(self):
+        """Read the sample_interval_ms setting."""
+        resp = await self.get_setting(field=1)
+        settings = _decode(blerpc_pb2.DeviceSettings(), resp.value, "get_setting")
+        return settings.sample_interval_ms
+
+    async def get_leds_enabled_setting(self):
+        """Read the leds_enabled setting."""
+        resp = await self.get_setting(field=2)
+        settings = _decode(blerpc_pb2.DeviceSettings(), resp.value, "get_setting")
+        return settings.leds_enabled
+
+    async def set_sample_interval_ms_setting(self, value):
+        """Write the sample_interval_ms setting."""
+        settings = blerpc_pb2.DeviceSettings(sample_interval_ms=value)
+        await self.set_setting(field=1, value=settings.SerializeToString())
+
+    async def set_leds_enabled_setting(self, value):
+        """Write the leds_enabled setting."""
+        settings = blerpc_pb2.DeviceSettings(leds_enabled=value)
+        await self.set_setting(field=2, value=settings.SerializeToString())
+
+    async def sync_time(self, *, compensate=False):
+        """Set the peripheral's wall clock to this host's.
+
+        With compensate, a first exchange measures the round trip and the
+        second adds half of it, the estimated delivery delay.
+        """
+        offset_us = 0
+        if compensate:
+            start = time.monotonic_ns()
+            await self.time_sync(unix_time_us=time.time_ns() // 1000)
+            offset_us = (time.monotonic_ns() - start) // 2000
+        now_us = time.time_ns() // 1000
+        return await self.time_sync(unix_time_us=now_us, offset_us=offset_us)
+
+    async def stream_cancel(self) -> None:
+        """Stop the P2C stream in progress, whose consumer stopped early.
+
+        The default does nothing and the peripheral runs the stream to its
+        end; BlerpcClient sends make_cancel() and drains the stream.
+        """
+
+
+# Request/response message classes per command, for JSON conversion.
+COMMAND_MESSAGES = {
+    "echo": (blerpc_pb2.EchoRequest, blerpc_pb2.EchoResponse),
+    "flash_read": (blerpc_pb2.FlashReadRequest, blerpc_pb2.FlashReadResponse),
+    "data_write": (blerpc_pb2.DataWriteRequest, blerpc_pb2.DataWriteResponse),
+    "counter_stream": (
+        blerpc_pb2.CounterStreamRequest,
+        blerpc_pb2.CounterStreamResponse,
+    ),
+    "counter_upload": (
+        blerpc_pb2.CounterUploadRequest,
+        blerpc_pb2.CounterUploadResponse,
+    ),
+    "get_blerpc_info": (
+        blerpc_pb2.GetBlerpcInfoRequest,
+        blerpc_pb2.GetBlerpcInfoResponse,
+    ),
+    "conn_params": (blerpc_pb2.ConnParamsRequest, blerpc_pb2.ConnParamsResponse),
+    "dfu_begin": (blerpc_pb2.DfuBeginRequest, blerpc_pb2.DfuBeginResponse),
+    "dfu_chunk": (blerpc_pb2.DfuChunkRequest, blerpc_pb2.DfuChunkResponse),
+    "dfu_finalize": (blerpc_pb2.DfuFinalizeRequest, blerpc_pb2.DfuFinalizeResponse),
+    "file_open": (blerpc_pb2.FileOpenRequest, blerpc_pb2.FileOpenResponse),
+    "file_read": (blerpc_pb2.FileReadRequest, blerpc_pb2.FileReadResponse),
+    "file_write": (blerpc_pb2.FileWriteRequest, blerpc_pb2.FileWriteResponse),
+    "file_close": (blerpc_pb2.FileCloseRequest, blerpc_pb2.FileCloseResponse),
+    "log_stream": (blerpc_pb2.LogStreamRequest, blerpc_pb2.LogStreamResponse),
+    "get_rpc_stats": (blerpc_pb2.GetRpcStatsRequest, blerpc_pb2.GetRpcStatsResponse),
+    "start_session": (blerpc_pb2.StartSessionRequest, blerpc_pb2.StartSessionResponse),
+    "authenticate_session": (
+        blerpc_pb2.AuthenticateSessionRequest,
+        blerpc_pb2.AuthenticateSessionResponse,
+    ),
+    "time_sync": (blerpc_pb2.TimeSyncRequest, blerpc_pb2.TimeSyncResponse),
+    "ping": (blerpc_pb2.PingRequest, blerpc_pb2.PingResponse),
+    "get_capabilities": (
+        blerpc_pb2.GetCapabilitiesRequest,
+        blerpc_pb2.GetCapabilitiesResponse,
+    ),
+    "get_setting": (blerpc_pb2.GetSettingRequest, blerpc_pb2.GetSettingResponse),
+    "set_setting": (blerpc_pb2.SetSettingRequest, blerpc_pb2.SetSettingResponse),
+}
+
+
+def to_json(message):
+    """Serialize a request or response message to protojson text."""
+    return json_format.MessageToJson(message, preserving_proto_field_name=True)
+
+
+def request_from_json(cmd_name, text):
+    """Parse protojson text into the request message of cmd_name."""
+    req_cls, _ = COMMAND_MESSAGES[cmd_name]
+    return json_format.Parse(text, req_cls())
+
+
+def response_from_json(cmd_name, text):
+    """Parse protojson text into the response message of cmd_name."""
+    _, resp_cls = COMMAND_MESSAGES[cmd_name]
+    return json_format.Parse(text, resp_cls())
+
+
+# Role each command requires on the peripheral, keyed by wire name; commands
+# not listed need only "user". The peripheral treats commands above its
+# current role as unknown.
+COMMAND_ROLES = {
+    "flash_read": "factory",
+}
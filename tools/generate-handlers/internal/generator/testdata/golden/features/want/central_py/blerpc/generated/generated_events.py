@@ -0,0 +1,15 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from . import blerpc_pb2
+from .generated_client import _decode
+
+
+async def subscribe_button_event(client):
+    """Yield each ButtonEvent the peripheral notifies.
+
+    client is a connected BlerpcClient; leave the loop to unsubscribe.
+    """
+    async for data in client.events("button_event"):
+        yield _decode(blerpc_pb2.ButtonEvent(), data, "button_event")
@@ -0,0 +1,228 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+import zlib
+from collections.abc import AsyncIterator, Awaitable, Callable
+from typing import NamedTuple
+
+from .generated_client import TransportError
+
+# Frames carry a message larger than the ATT MTU:
+#
+#   flags | id | seq | [length, uint16 LE, FIRST only] | data
+#
+# id is the correlation ID the client picked for a request, which its
+# responses carry back, so up to MAX_IN_FLIGHT requests can be in flight and
+# their frames interleaved. seq counts the frames of a message from 0. A frame
+# with FIRST starts a new message under its ID, dropping one partly
+# reassembled. MORE marks a message followed by another under the same ID, as
+# the responses of a stream but the last.
+FRAME_FIRST = 0x01
+FRAME_LAST = 0x02
+FRAME_MORE = 0x04
+FRAME_HEADER_SIZE = 3
+FRAME_FIRST_HEADER_SIZE = 5
+
+# Messages the peripheral reassembles at once; no more calls are in flight.
+MAX_IN_FLIGHT = 4
+
+# Each message travels with its CRC-32 (IEEE 802.3, little endian) after its
+# data; length leaves it out. The reassembler checks and strips it.
+CRC_SIZE = 4
+
+# ATT opcode and handle ahead of each notification or write.
+ATT_OVERHEAD = 3
+
+# Largest message a frame header can announce.
+MAX_MESSAGE_SIZE = 0xFFFF
+
+
+class FramingError(TransportError):
+    """A frame is malformed or out of sequence, or a message too large."""
+
+
+class IntegrityError(FramingError):
+    """A message failed its CRC-32 check: it was corrupted on the way."""
+
+
+class FrameMessage(NamedTuple):
+    """A reassembled message and the correlation ID it arrived under."""
+
+    correlation_id: int
+    data: bytes
+    more: bool  # another message follows under the same ID
+
+
+def fragment(message: bytes, mtu: int, correlation_id: int) -> list[bytes]:
+    """Split message into frames fitting the ATT MTU mtu."""
+    if not message or len(message) > MAX_MESSAGE_SIZE:
+        raise FramingError(f"cannot frame a message of {len(message)} bytes")
+    frame_size = mtu - ATT_OVERHEAD
+    if frame_size <= FRAME_FIRST_HEADER_SIZE:
+        raise FramingError(f"MTU {mtu} leaves no room for data")
+    payload = message + zlib.crc32(message).to_bytes(CRC_SIZE, "little")
+    frames = []
+    offset = 0
+    while offset < len(payload):
+        first = offset == 0
+        header_size = FRAME_FIRST_HEADER_SIZE if first else FRAME_HEADER_SIZE
+        n = min(len(payload) - offset, frame_size - header_size)
+        flags = FRAME_FIRST if first else 0
+        if offset + n == len(payload):
+            flags |= FRAME_LAST
+        header = bytes([flags, correlation_id, len(frames) & 0xFF])
+        if first:
+            header += len(message).to_bytes(2, "little")
+        frames.append(header + payload[offset : offset + n])
+        offset += n
+    return frames
+
+
+def _strip_crc(payload: bytes | bytearray) -> bytes:
+    """Return the message ahead of the CRC-32 ending payload, once checked."""
+    message = bytes(payload[:-CRC_SIZE])
+    if zlib.crc32(message) != int.from_bytes(payload[-CRC_SIZE:], "little"):
+        raise IntegrityError("message failed its CRC-32 check")
+    return message
+
+
+class _Partial:
+    """A message being reassembled under one correlation ID."""
+
+    def __init__(self, total: int) -> None:
+        self.buf = bytearray()
+        self.total = total
+        self.next_seq = 0
+
+
+class Reassembler:
+    """Puts frames back together into messages, one per correlation ID.
+
+    feed returns the message once its last frame arrived. After a
+    FramingError the ID of the frame waits for the next FIRST frame.
+    """
+
+    def __init__(self, max_size: int = MAX_MESSAGE_SIZE) -> None:
+        self._max_size = max_size
+        self._partial: dict[int, _Partial] = {}
+
+    def reset(self) -> None:
+        """Drop every partly reassembled message."""
+        self._partial.clear()
+
+    def feed(self, frame: bytes) -> FrameMessage | None:
+        """Add a received frame; return the message once complete, else None."""
+        if len(frame) < FRAME_HEADER_SIZE:
+            raise FramingError("short frame")
+        try:
+            return self._feed(frame)
+        except FramingError:
+            self._partial.pop(frame[1], None)
+            raise
+
+    def _feed(self, frame: bytes) -> FrameMessage | None:
+        flags, correlation_id, seq = frame[0], frame[1], frame[2]
+        if flags & FRAME_FIRST:
+            if len(frame) < FRAME_FIRST_HEADER_SIZE or seq != 0:
+                raise FramingError("malformed first frame")
+            total = int.from_bytes(frame[3:5], "little")
+            if total == 0:
+                raise FramingError("empty message")
+            if total > self._max_size:
+                raise FramingError(f"message of {total} bytes exceeds {self._max_size}")
+            partial = _Partial(total + CRC_SIZE)
+            self._partial[correlation_id] = partial
+            data = frame[FRAME_FIRST_HEADER_SIZE:]
+        else:
+            partial = self._partial.get(correlation_id)
+            if partial is None or seq != partial.next_seq:
+                msg = f"frame {seq} of call {correlation_id} out of sequence"
+                raise FramingError(msg)
+            data = frame[FRAME_HEADER_SIZE:]
+        if len(partial.buf) + len(data) > partial.total:
+            raise FramingError("frames exceed the message length")
+        partial.buf += data
+        partial.next_seq = (partial.next_seq + 1) & 0xFF
+        if not flags & FRAME_LAST:
+            return None
+        if len(partial.buf) != partial.total:
+            raise FramingError("message ends short of its length")
+        del self._partial[correlation_id]
+        message = _strip_crc(partial.buf)
+        return FrameMessage(correlation_id, message, bool(flags & FRAME_MORE))
+
+
+class Pipeline:
+    """Runs calls concurrently over one connection.
+
+    Each call gets a free correlation ID and its responses are routed back by
+    it, so a slow call or stream does not hold up the others. write sends one
+    frame to the peripheral; pass every notified frame to on_frame and call
+    fail_all when the connection drops.
+    """
+
+    def __init__(
+        self,
+        write: Callable[[bytes], Awaitable[None]],
+        mtu: int,
+        max_in_flight: int = MAX_IN_FLIGHT,
+    ) -> None:
+        self.mtu = mtu
+        self._write = write
+        self._slots = asyncio.Semaphore(max_in_flight)
+        self._calls: dict[int, asyncio.Queue] = {}
+        self._reassembler = Reassembler()
+        self._next_id = 0
+
+    def _allocate(self) -> int:
+        while self._next_id in self._calls:
+            self._next_id = (self._next_id + 1) & 0xFF
+        correlation_id = self._next_id
+        self._next_id = (correlation_id + 1) & 0xFF
+        return correlation_id
+
+    async def call(self, message: bytes) -> bytes:
+        """Send a request and return its response."""
+        response = b""
+        async for response in self.stream(message):
+            pass
+        return response
+
+    async def stream(self, message: bytes) -> AsyncIterator[bytes]:
+        """Send a request and yield its responses until the last."""
+        async with self._slots:
+            correlation_id = self._allocate()
+            queue: asyncio.Queue = asyncio.Queue()
+            self._calls[correlation_id] = queue
+            try:
+                for frame in fragment(message, self.mtu, correlation_id):
+                    await self._write(frame)
+                while True:
+                    item = await queue.get()
+                    if isinstance(item, Exception):
+                        raise item
+                    yield item.data
+                    if not item.more:
+                        return
+            finally:
+                del self._calls[correlation_id]
+
+    def on_frame(self, frame: bytes) -> None:
+        """Route a notified frame to the call it belongs to."""
+        try:
+            msg = self._reassembler.feed(frame)
+        except FramingError as e:
+            queue = self._calls.get(frame[1]) if len(frame) > 1 else None
+            if queue is not None:
+                queue.put_nowait(e)
+            return
+        if msg is not None and msg.correlation_id in self._calls:
+            self._calls[msg.correlation_id].put_nowait(msg)
+
+    def fail_all(self, exc: Exception) -> None:
+        """Fail every call in flight, e.g. with a TransportError on disconnect."""
+        self._reassembler.reset()
+        for queue in self._calls.values():
+            queue.put_nowait(exc)
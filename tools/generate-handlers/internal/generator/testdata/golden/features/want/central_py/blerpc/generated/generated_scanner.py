@@ -0,0 +1,52 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from dataclasses import dataclass
+
+from .generated_advertising import parse_manufacturer_data
+
+# Service UUID advertised by blerpc peripherals.
+SERVICE_UUID = "6e400001-b5a3-f393-e0a9-e50e24dcca9e"
+
+
+@dataclass
+class DiscoveredDevice:
+    """A blerpc peripheral found by a scan; pass device to connect()."""
+
+    name: str | None
+    address: str
+    rssi: int
+    device: object
+    # The decoded (blerpc.advertising) message, if the device sent one.
+    advertisement: object | None = None
+
+
+def discover(device, require_advertisement=False):
+    """Return a scan result as a DiscoveredDevice, or None if it is not blerpc."""
+    if SERVICE_UUID not in (u.lower() for u in device.service_uuids):
+        return None
+    advertisement = parse_manufacturer_data(device.manufacturer_data)
+    if require_advertisement and advertisement is None:
+        return None
+    return DiscoveredDevice(
+        name=device.name,
+        address=device.address,
+        rssi=device.rssi,
+        device=device,
+        advertisement=advertisement,
+    )
+
+
+def discover_all(devices, require_advertisement=False):
+    """Return the blerpc peripherals among scan results, strongest first."""
+    found = (discover(d, require_advertisement) for d in devices)
+    return sorted(
+        (d for d in found if d is not None), key=lambda d: d.rssi, reverse=True
+    )
+
+
+async def scan(client, timeout=5.0, require_advertisement=False):
+    """Scan with client, e.g. BlerpcClient, and return the blerpc peripherals."""
+    devices = await client.scan(timeout=timeout, service_uuid=SERVICE_UUID)
+    return discover_all(devices, require_advertisement)
@@ -0,0 +1,119 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from collections.abc import AsyncIterable
+from typing import NamedTuple
+
+from .generated_client import COMMAND_MESSAGES, CommandId, GeneratedClientMixin
+
+# Commands by the name the generated methods send for them.
+_COMMAND_NAMES = {
+    CommandId.ECHO.wire_name: "echo",
+    CommandId.FLASH_READ.wire_name: "flash_read",
+    CommandId.DATA_WRITE.wire_name: "data_write",
+    CommandId.COUNTER_STREAM.wire_name: "counter_stream",
+    CommandId.COUNTER_UPLOAD.wire_name: "counter_upload",
+    CommandId.GET_BLERPC_INFO.wire_name: "get_blerpc_info",
+    CommandId.CONN_PARAMS.wire_name: "conn_params",
+    CommandId.DFU_BEGIN.wire_name: "dfu_begin",
+    CommandId.DFU_CHUNK.wire_name: "dfu_chunk",
+    CommandId.DFU_FINALIZE.wire_name: "dfu_finalize",
+    CommandId.FILE_OPEN.wire_name: "file_open",
+    CommandId.FILE_READ.wire_name: "file_read",
+    CommandId.FILE_WRITE.wire_name: "file_write",
+    CommandId.FILE_CLOSE.wire_name: "file_close",
+    CommandId.LOG_STREAM.wire_name: "log_stream",
+    CommandId.GET_RPC_STATS.wire_name: "get_rpc_stats",
+    CommandId.START_SESSION.wire_name: "start_session",
+    CommandId.AUTHENTICATE_SESSION.wire_name: "authenticate_session",
+    CommandId.TIME_SYNC.wire_name: "time_sync",
+    CommandId.PING.wire_name: "ping",
+    CommandId.GET_CAPABILITIES.wire_name: "get_capabilities",
+    CommandId.GET_SETTING.wire_name: "get_setting",
+    CommandId.SET_SETTING.wire_name: "set_setting",
+}
+
+# Bytes of session token and replay prefix leading protected requests.
+_REQUEST_PREFIXES = {
+    "flash_read": 32,
+}
+
+
+class MockCall(NamedTuple):
+    """A call recorded by MockGeneratedClient.
+
+    requests holds the decoded request, or each message of a C2P stream.
+    """
+
+    command: str
+    requests: list
+
+
+class MockGeneratedClient(GeneratedClientMixin):
+    """Stand-in for BlerpcClient in app unit tests, with no peripheral.
+
+    Records every call in calls and answers it with the responses set for its
+    command. Commands without any get an empty response, and P2C streams no
+    responses.
+    """
+
+    is_connected = True
+    # Any key does: the mock answers the session handshake itself.
+    session_key = bytes(32)
+
+    def __init__(self):
+        self.calls = []
+        self._answers = {}
+
+    def respond(self, command, *responses):
+        """Answer command with responses.
+
+        A unary or C2P call gets the first, a P2C stream each of them.
+        """
+        self._answers[command] = lambda requests: list(responses)
+
+    def respond_with(self, command, answer):
+        """Answer command with the responses answer(requests) returns."""
+        self._answers[command] = answer
+
+    def fail(self, command, error):
+        """Raise error, e.g. a NotFoundError, from calls of command."""
+
+        def answer(requests):
+            raise error
+
+        self._answers[command] = answer
+
+    def _answer(self, cmd_name, requests):
+        # Records the call and returns its command with the responses set
+        # for it, None if there are none.
+        command = _COMMAND_NAMES.get(cmd_name, cmd_name)
+        req_cls, _ = COMMAND_MESSAGES[command]
+        skip = _REQUEST_PREFIXES.get(command, 0)
+        decoded = [req_cls.FromString(data[skip:]) for data in requests]
+        self.calls.append(MockCall(command, decoded))
+        answer = self._answers.get(command)
+        return command, None if answer is None else answer(decoded)
+
+    def _first(self, command, responses):
+        if responses:
+            return responses[0].SerializeToString()
+        _, resp_cls = COMMAND_MESSAGES[command]
+        if command == "authenticate_session":
+            # A token of the size peripherals issue opens the session.
+            return resp_cls(token=bytes(8)).SerializeToString()
+        return resp_cls().SerializeToString()
+
+    async def _call(self, cmd_name, request_data):
+        return self._first(*self._answer(cmd_name, [request_data]))
+
+    async def stream_receive(self, cmd_name, request_data):
+        _, responses = self._answer(cmd_name, [request_data])
+        for resp in responses or []:
+            yield resp.SerializeToString()
+
+    async def stream_send(self, cmd_name, messages, final_cmd_name):
+        if isinstance(messages, AsyncIterable):
+            messages = [m async for m in messages]
+        return self._first(*self._answer(cmd_name, list(messages)))
@@ -0,0 +1,264 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+import threading
+import warnings
+from collections.abc import AsyncIterator, Awaitable, Iterable, Iterator
+from typing import Protocol, TypeVar
+
+from . import blerpc_pb2
+from .generated_client import GeneratedClientMixin
+
+T = TypeVar("T")
+
+
+class SyncRpcTransport(Protocol):
+    """Blocking hooks the generated sync methods call; SyncClient implements them."""
+
+    async_client: GeneratedClientMixin
+
+    def _call_sync(self, call: Awaitable[T]) -> T: ...
+
+    def _iter_sync(self, stream: AsyncIterator[T]) -> Iterator[T]: ...
+
+
+class GeneratedSyncClientMixin(SyncRpcTransport):
+    """Blocking variants of the GeneratedClientMixin methods, by the same names.
+
+    Each runs the method of async_client through _call_sync and returns its
+    result, or raises its error.
+    """
+
+    def echo(self, *, message: str = "") -> blerpc_pb2.EchoResponse:
+        """Call the echo command, blocking until it responds."""
+        return self._call_sync(self.async_client.echo(message=message))
+
+    def flash_read(
+        self, *, address: int = 0, length: int = 0
+    ) -> blerpc_pb2.FlashReadResponse:
+        """Call the flash_read command, blocking until it responds."""
+        return self._call_sync(
+            self.async_client.flash_read(address=address, length=length)
+        )
+
+    def data_write(self, *, data: bytes = b"") -> blerpc_pb2.DataWriteResponse:
+        """Call the data_write command, blocking until it responds."""
+        return self._call_sync(self.async_client.data_write(data=data))
+
+    def get_blerpc_info(self) -> blerpc_pb2.GetBlerpcInfoResponse:
+        """Call the get_blerpc_info command, blocking until it responds."""
+        return self._call_sync(self.async_client.get_blerpc_info())
+
+    def conn_params(self, *, profile: int = 0) -> blerpc_pb2.ConnParamsResponse:
+        """Call the conn_params command, blocking until it responds."""
+        return self._call_sync(self.async_client.conn_params(profile=profile))
+
+    def dfu_begin(
+        self, *, size: int = 0, crc32: int = 0
+    ) -> blerpc_pb2.DfuBeginResponse:
+        """Call the dfu_begin command, blocking until it responds."""
+        return self._call_sync(self.async_client.dfu_begin(size=size, crc32=crc32))
+
+    def dfu_chunk(
+        self, *, offset: int = 0, data: bytes = b""
+    ) -> blerpc_pb2.DfuChunkResponse:
+        """Call the dfu_chunk command, blocking until it responds."""
+        return self._call_sync(self.async_client.dfu_chunk(offset=offset, data=data))
+
+    def dfu_finalize(self, *, reboot: bool = False) -> blerpc_pb2.DfuFinalizeResponse:
+        """Call the dfu_finalize command, blocking until it responds."""
+        return self._call_sync(self.async_client.dfu_finalize(reboot=reboot))
+
+    def file_open(
+        self, *, path: str = "", write: bool = False, resume: bool = False
+    ) -> blerpc_pb2.FileOpenResponse:
+        """Call the file_open command, blocking until it responds."""
+        return self._call_sync(
+            self.async_client.file_open(path=path, write=write, resume=resume)
+        )
+
+    def file_read(
+        self, *, handle: int = 0, offset: int = 0, length: int = 0
+    ) -> blerpc_pb2.FileReadResponse:
+        """Call the file_read command, blocking until it responds."""
+        return self._call_sync(
+            self.async_client.file_read(handle=handle, offset=offset, length=length)
+        )
+
+    def file_write(
+        self, *, handle: int = 0, offset: int = 0, data: bytes = b""
+    ) -> blerpc_pb2.FileWriteResponse:
+        """Call the file_write command, blocking until it responds."""
+        return self._call_sync(
+            self.async_client.file_write(handle=handle, offset=offset, data=data)
+        )
+
+    def file_close(
+        self, *, handle: int = 0, verify: bool = False
+    ) -> blerpc_pb2.FileCloseResponse:
+        """Call the file_close command, blocking until it responds."""
+        return self._call_sync(
+            self.async_client.file_close(handle=handle, verify=verify)
+        )
+
+    def get_rpc_stats(self, *, reset: bool = False) -> blerpc_pb2.GetRpcStatsResponse:
+        """Call the get_rpc_stats command, blocking until it responds."""
+        return self._call_sync(self.async_client.get_rpc_stats(reset=reset))
+
+    def start_session(self) -> blerpc_pb2.StartSessionResponse:
+        """Call the start_session command, blocking until it responds."""
+        return self._call_sync(self.async_client.start_session())
+
+    def authenticate_session(
+        self, *, proof: bytes = b""
+    ) -> blerpc_pb2.AuthenticateSessionResponse:
+        """Call the authenticate_session command, blocking until it responds."""
+        return self._call_sync(self.async_client.authenticate_session(proof=proof))
+
+    def time_sync(
+        self, *, unix_time_us: int = 0, offset_us: int = 0
+    ) -> blerpc_pb2.TimeSyncResponse:
+        """Call the time_sync command, blocking until it responds."""
+        return self._call_sync(
+            self.async_client.time_sync(unix_time_us=unix_time_us, offset_us=offset_us)
+        )
+
+    def ping(self, *, payload: bytes = b"") -> blerpc_pb2.PingResponse:
+        """Call the ping command, blocking until it responds."""
+        return self._call_sync(self.async_client.ping(payload=payload))
+
+    def get_capabilities(self) -> blerpc_pb2.GetCapabilitiesResponse:
+        """Call the get_capabilities command, blocking until it responds."""
+        return self._call_sync(self.async_client.get_capabilities())
+
+    def get_setting(self, *, field: int = 0) -> blerpc_pb2.GetSettingResponse:
+        """Call the get_setting command, blocking until it responds."""
+        return self._call_sync(self.async_client.get_setting(field=field))
+
+    def set_setting(
+        self, *, field: int = 0, value: bytes = b""
+    ) -> blerpc_pb2.SetSettingResponse:
+        """Call the set_setting command, blocking until it responds."""
+        return self._call_sync(self.async_client.set_setting(field=field, value=value))
+
+    def iter_counter_stream(
+        self, *, count: int = 0
+    ) -> Iterator[blerpc_pb2.CounterStreamResponse]:
+        """P2C stream: counter_stream, yielding each response as it arrives.
+
+        Closing the iterator before the stream ends cancels the stream.
+        """
+        return self._iter_sync(self.async_client.iter_counter_stream(count=count))
+
+    def counter_stream(
+        self, *, count: int = 0
+    ) -> list[blerpc_pb2.CounterStreamResponse]:
+        """P2C stream: counter_stream."""
+        return self._call_sync(self.async_client.counter_stream(count=count))
+
+    def counter_upload(
+        self, messages: Iterable[blerpc_pb2.CounterUploadRequest]
+    ) -> blerpc_pb2.CounterUploadResponse:
+        """C2P stream: counter_upload.
+
+        messages is an iterable of CounterUploadRequest, each sent as it is
+        produced (on the event loop of async_client).
+        """
+        return self._call_sync(self.async_client.counter_upload(messages))
+
+    def iter_log_stream(
+        self, *, min_level: int = 0, max_entries: int = 0
+    ) -> Iterator[blerpc_pb2.LogStreamResponse]:
+        """P2C stream: log_stream, yielding each response as it arrives.
+
+        Closing the iterator before the stream ends cancels the stream.
+        """
+        return self._iter_sync(
+            self.async_client.iter_log_stream(
+                min_level=min_level, max_entries=max_entries
+            )
+        )
+
+    def log_stream(
+        self, *, min_level: int = 0, max_entries: int = 0
+    ) -> list[blerpc_pb2.LogStreamResponse]:
+        """P2C stream: log_stream."""
+        return self._call_sync(
+            self.async_client.log_stream(min_level=min_level, max_entries=max_entries)
+        )
+
+    def echo_message(self, *, message: str = "") -> str:
+        """Return the message of the echo response."""
+        return self._call_sync(self.async_client.echo_message(message=message))
+
+    def data_write_length(self, *, data: bytes = b"") -> int:
+        """Return the length of the data_write response."""
+        return self._call_sync(self.async_client.data_write_length(data=data))
+
+    def flash_dump(self, *args, **kwargs):
+        """Deprecated: renamed to flash_read."""
+        warnings.warn(
+            "flash_dump() is deprecated, use flash_read()",
+            DeprecationWarning,
+            stacklevel=2,
+        )
+        return self.flash_read(*args, **kwargs)
+
+
+async def _await(call: Awaitable[T]) -> T:
+    return await call
+
+
+class SyncClient(GeneratedSyncClientMixin):
+    """Blocking client for scripts without an event loop.
+
+    Runs an event loop in a daemon thread and async_client, e.g. a
+    BlerpcClient, on it; every call blocks until it completes. Client methods
+    without a blocking variant run with run():
+
+        with SyncClient(BlerpcClient()) as client:
+            devices = client.run(client.async_client.scan())
+            client.run(client.async_client.connect(devices[0]))
+            print(client.echo(message="hello").message)
+            client.run(client.async_client.disconnect())
+    """
+
+    def __init__(self, async_client: GeneratedClientMixin):
+        self.async_client = async_client
+        self._loop = asyncio.new_event_loop()
+        self._thread = threading.Thread(
+            target=self._loop.run_forever, name="blerpc-sync-client", daemon=True
+        )
+        self._thread.start()
+
+    def run(self, call: Awaitable[T]) -> T:
+        """Run call on the event loop of async_client, blocking until it completes."""
+        return asyncio.run_coroutine_threadsafe(_await(call), self._loop).result()
+
+    def _call_sync(self, call: Awaitable[T]) -> T:
+        return self.run(call)
+
+    def _iter_sync(self, stream: AsyncIterator[T]) -> Iterator[T]:
+        try:
+            while True:
+                try:
+                    yield self.run(anext(stream))
+                except StopAsyncIteration:
+                    return
+        finally:
+            # Closing the iterator early closes the stream, which cancels it.
+            self.run(stream.aclose())
+
+    def close(self) -> None:
+        """Stop the event loop thread; disconnect async_client first."""
+        self._loop.call_soon_threadsafe(self._loop.stop)
+        self._thread.join()
+        self._loop.close()
+
+    def __enter__(self) -> SyncClient:
+        return self
+
+    def __exit__(self, *exc_info: object) -> None:
+        self.close()
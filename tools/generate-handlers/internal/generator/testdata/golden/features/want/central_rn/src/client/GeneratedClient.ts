@@ -0,0 +1,501 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import { blerpc } from '../proto/blerpc';
+
+let lastReplayCounter = 0n;
+
+/**
+ * Leads replay-protected requests with a counter, 8 bytes little endian,
+ * and a MAC: the first 16 bytes of an HMAC-SHA256 under the session key of
+ * the command name, a NUL, the counter and the request. The peripheral drops
+ * requests whose MAC is wrong or whose counter is not above the last one it
+ * accepted; microseconds since the epoch keep it increasing across restarts.
+ */
+async function withReplayCounter(
+  key: Uint8Array | undefined,
+  command: string,
+  requestData: Uint8Array,
+): Promise<Uint8Array> {
+  if (key === undefined) {
+    throw new Error('sessionKey is not set');
+  }
+  const now = BigInt(Date.now()) * 1000n;
+  lastReplayCounter = now > lastReplayCounter ? now : lastReplayCounter + 1n;
+  const name = new TextEncoder().encode(command);
+  const signed = new Uint8Array(name.length + 1 + 8 + requestData.length);
+  signed.set(name);
+  new DataView(signed.buffer).setBigUint64(name.length + 1, lastReplayCounter, true);
+  signed.set(requestData, name.length + 9);
+  const hmacKey = await crypto.subtle.importKey(
+    'raw',
+    key,
+    { name: 'HMAC', hash: 'SHA-256' },
+    false,
+    ['sign'],
+  );
+  const mac = new Uint8Array(await crypto.subtle.sign('HMAC', hmacKey, signed));
+  const out = new Uint8Array(24 + requestData.length);
+  out.set(signed.subarray(name.length + 1, name.length + 9));
+  out.set(mac.subarray(0, 16), 8);
+  out.set(requestData, 24);
+  return out;
+}
+
+// Error response of a peripheral that has no session with the token.
+const UNAUTHENTICATED = Uint8Array.of(0xf8, 0xff, 0xff, 0xff, 0x0f, 11);
+
+function isUnauthenticated(data: Uint8Array): boolean {
+  return data.length === UNAUTHENTICATED.length && data.every((b, i) => b === UNAUTHENTICATED[i]);
+}
+
+function withToken(token: Uint8Array, requestData: Uint8Array): Uint8Array {
+  const out = new Uint8Array(token.length + requestData.length);
+  out.set(token);
+  out.set(requestData, token.length);
+  return out;
+}
+
+export abstract class GeneratedClient {
+  protected abstract call(cmdName: string, requestData: Uint8Array): Promise<Uint8Array>;
+  protected abstract streamReceive(cmdName: string, requestData: Uint8Array): Promise<Uint8Array[]>;
+  protected abstract streamSend(
+    cmdName: string,
+    messages: Uint8Array[],
+    finalCmdName: string,
+  ): Promise<Uint8Array>;
+
+  // The peripheral handles one RPC at a time, so calls are chained.
+  private rpcTail: Promise<unknown> = Promise.resolve();
+
+  /**
+   * Runs `body` once every earlier RPC of this client has settled. The
+   * generated methods call through here, and so should direct uses of
+   * call, streamReceive and streamSend.
+   */
+  protected exclusive<T>(body: () => Promise<T>): Promise<T> {
+    const result = this.rpcTail.then(body, body);
+    this.rpcTail = result.catch(() => undefined);
+    return result;
+  }
+
+  /**
+   * Key shared with the peripheral, which the session handshake proves
+   * knowledge of. Set it before calling a session- or replay-protected
+   * command. The proof is signed with Web Crypto; React Native needs a
+   * polyfill of crypto.subtle.
+   */
+  sessionKey?: Uint8Array;
+
+  private sessionToken?: Uint8Array;
+
+  /**
+   * Authenticates to the peripheral, unlocking its session-protected
+   * commands. Protected calls open a session as needed; call this to
+   * authenticate up front.
+   */
+  async openSession(): Promise<void> {
+    await this.exclusive(() => this.handshake());
+  }
+
+  /**
+   * Runs the session handshake inside the caller's exclusive call and keeps
+   * the token: the proof is an HMAC-SHA256 of the challenge under
+   * sessionKey.
+   */
+  private async handshake(): Promise<Uint8Array> {
+    if (this.sessionKey === undefined) {
+      throw new Error('sessionKey is not set');
+    }
+    const startData = await this.call(
+      'start_session',
+      blerpc.StartSessionRequest.encode(blerpc.StartSessionRequest.create({})).finish(),
+    );
+    const { challenge } = blerpc.StartSessionResponse.decode(startData);
+    const key = await crypto.subtle.importKey(
+      'raw',
+      this.sessionKey,
+      { name: 'HMAC', hash: 'SHA-256' },
+      false,
+      ['sign'],
+    );
+    const proof = new Uint8Array(await crypto.subtle.sign('HMAC', key, challenge));
+    const authData = await this.call(
+      'authenticate_session',
+      blerpc.AuthenticateSessionRequest.encode(blerpc.AuthenticateSessionRequest.create({ proof })).finish(),
+    );
+    const { token } = blerpc.AuthenticateSessionResponse.decode(authData);
+    this.sessionToken = token;
+    return token;
+  }
+
+  /**
+   * Sends a session-protected request led by the session token, opening a
+   * session first when there is none. A peripheral that lost the session,
+   * e.g. on a reboot, answers UNAUTHENTICATED; the request is then sent
+   * once more in a new session.
+   */
+  private async sessionCall(cmdName: string, requestData: Uint8Array): Promise<Uint8Array> {
+    const token = this.sessionToken ?? (await this.handshake());
+    const respData = await this.call(cmdName, withToken(token, requestData));
+    if (!isUnauthenticated(respData)) {
+      return respData;
+    }
+    return this.call(cmdName, withToken(await this.handshake(), requestData));
+  }
+
+  async echo({ message = '' }: { message?: string } = {}): Promise<blerpc.EchoResponse> {
+    const req = blerpc.EchoRequest.create({ message });
+    const respData = await this.exclusive(() =>
+      this.call('echo', blerpc.EchoRequest.encode(req).finish()),
+    );
+    return blerpc.EchoResponse.decode(respData);
+  }
+
+  async flashRead({
+    address = 0,
+    length = 0,
+  }: { address?: number; length?: number } = {}): Promise<blerpc.FlashReadResponse> {
+    const req = blerpc.FlashReadRequest.create({ address, length });
+    const respData = await this.exclusive(async () =>
+      this.sessionCall('flash_read', await withReplayCounter(this.sessionKey, 'flash_read', blerpc.FlashReadRequest.encode(req).finish())),
+    );
+    return blerpc.FlashReadResponse.decode(respData);
+  }
+
+  async dataWrite({
+    data = new Uint8Array(0),
+  }: { data?: Uint8Array } = {}): Promise<blerpc.DataWriteResponse> {
+    const req = blerpc.DataWriteRequest.create({ data });
+    const respData = await this.exclusive(() =>
+      this.call('data_write', blerpc.DataWriteRequest.encode(req).finish()),
+    );
+    return blerpc.DataWriteResponse.decode(respData);
+  }
+
+  async getBlerpcInfo(): Promise<blerpc.GetBlerpcInfoResponse> {
+    const req = blerpc.GetBlerpcInfoRequest.create({});
+    const respData = await this.exclusive(() =>
+      this.call('get_blerpc_info', blerpc.GetBlerpcInfoRequest.encode(req).finish()),
+    );
+    return blerpc.GetBlerpcInfoResponse.decode(respData);
+  }
+
+  async connParams({ profile = 0 }: { profile?: number } = {}): Promise<blerpc.ConnParamsResponse> {
+    const req = blerpc.ConnParamsRequest.create({ profile });
+    const respData = await this.exclusive(() =>
+      this.call('conn_params', blerpc.ConnParamsRequest.encode(req).finish()),
+    );
+    return blerpc.ConnParamsResponse.decode(respData);
+  }
+
+  async dfuBegin({
+    size = 0,
+    crc32 = 0,
+  }: { size?: number; crc32?: number } = {}): Promise<blerpc.DfuBeginResponse> {
+    const req = blerpc.DfuBeginRequest.create({ size, crc32 });
+    const respData = await this.exclusive(() =>
+      this.call('dfu_begin', blerpc.DfuBeginRequest.encode(req).finish()),
+    );
+    return blerpc.DfuBeginResponse.decode(respData);
+  }
+
+  async dfuChunk({
+    offset = 0,
+    data = new Uint8Array(0),
+  }: { offset?: number; data?: Uint8Array } = {}): Promise<blerpc.DfuChunkResponse> {
+    const req = blerpc.DfuChunkRequest.create({ offset, data });
+    const respData = await this.exclusive(() =>
+      this.call('dfu_chunk', blerpc.DfuChunkRequest.encode(req).finish()),
+    );
+    return blerpc.DfuChunkResponse.decode(respData);
+  }
+
+  async dfuFinalize({
+    reboot = false,
+  }: { reboot?: boolean } = {}): Promise<blerpc.DfuFinalizeResponse> {
+    const req = blerpc.DfuFinalizeRequest.create({ reboot });
+    const respData = await this.exclusive(() =>
+      this.call('dfu_finalize', blerpc.DfuFinalizeRequest.encode(req).finish()),
+    );
+    return blerpc.DfuFinalizeResponse.decode(respData);
+  }
+
+  async fileOpen({
+    path = '',
+    write = false,
+    resume = false,
+  }: { path?: string; write?: boolean; resume?: boolean } = {}): Promise<blerpc.FileOpenResponse> {
+    const req = blerpc.FileOpenRequest.create({ path, write, resume });
+    const respData = await this.exclusive(() =>
+      this.call('file_open', blerpc.FileOpenRequest.encode(req).finish()),
+    );
+    return blerpc.FileOpenResponse.decode(respData);
+  }
+
+  async fileRead({
+    handle = 0,
+    offset = 0,
+    length = 0,
+  }: { handle?: number; offset?: number; length?: number } = {}): Promise<blerpc.FileReadResponse> {
+    const req = blerpc.FileReadRequest.create({ handle, offset, length });
+    const respData = await this.exclusive(() =>
+      this.call('file_read', blerpc.FileReadRequest.encode(req).finish()),
+    );
+    return blerpc.FileReadResponse.decode(respData);
+  }
+
+  async fileWrite({
+    handle = 0,
+    offset = 0,
+    data = new Uint8Array(0),
+  }: { handle?: number; offset?: number; data?: Uint8Array } = {}): Promise<blerpc.FileWriteResponse> {
+    const req = blerpc.FileWriteRequest.create({ handle, offset, data });
+    const respData = await this.exclusive(() =>
+      this.call('file_write', blerpc.FileWriteRequest.encode(req).finish()),
+    );
+    return blerpc.FileWriteResponse.decode(respData);
+  }
+
+  async fileClose({
+    handle = 0,
+    verify = false,
+  }: { handle?: number; verify?: boolean } = {}): Promise<blerpc.FileCloseResponse> {
+    const req = blerpc.FileCloseRequest.create({ handle, verify });
+    const respData = await this.exclusive(() =>
+      this.call('file_close', blerpc.FileCloseRequest.encode(req).finish()),
+    );
+    return blerpc.FileCloseResponse.decode(respData);
+  }
+
+  async getRpcStats({
+    reset = false,
+  }: { reset?: boolean } = {}): Promise<blerpc.GetRpcStatsResponse> {
+    const req = blerpc.GetRpcStatsRequest.create({ reset });
+    const respData = await this.exclusive(() =>
+      this.call('get_rpc_stats', blerpc.GetRpcStatsRequest.encode(req).finish()),
+    );
+    return blerpc.GetRpcStatsResponse.decode(respData);
+  }
+
+  async startSession(): Promise<blerpc.StartSessionResponse> {
+    const req = blerpc.StartSessionRequest.create({});
+    const respData = await this.exclusive(() =>
+      this.call('start_session', blerpc.StartSessionRequest.encode(req).finish()),
+    );
+    return blerpc.StartSessionResponse.decode(respData);
+  }
+
+  async authenticateSession({
+    proof = new Uint8Array(0),
+  }: { proof?: Uint8Array } = {}): Promise<blerpc.AuthenticateSessionResponse> {
+    const req = blerpc.AuthenticateSessionRequest.create({ proof });
+    const respData = await this.exclusive(() =>
+      this.call('authenticate_session', blerpc.AuthenticateSessionRequest.encode(req).finish()),
+    );
+    return blerpc.AuthenticateSessionResponse.decode(respData);
+  }
+
+  async timeSync({
+    unixTimeUs = 0,
+    offsetUs = 0,
+  }: { unixTimeUs?: number; offsetUs?: number } = {}): Promise<blerpc.TimeSyncResponse> {
+    const req = blerpc.TimeSyncRequest.create({ unixTimeUs, offsetUs });
+    const respData = await this.exclusive(() =>
+      this.call('time_sync', blerpc.TimeSyncRequest.encode(req).finish()),
+    );
+    return blerpc.TimeSyncResponse.decode(respData);
+  }
+
+  async ping({
+    payload = new Uint8Array(0),
+  }: { payload?: Uint8Array } = {}): Promise<blerpc.PingResponse> {
+    const req = blerpc.PingRequest.create({ payload });
+    const respData = await this.exclusive(() =>
+      this.call('ping', blerpc.PingRequest.encode(req).finish()),
+    );
+    return blerpc.PingResponse.decode(respData);
+  }
+
+  async getCapabilities(): Promise<blerpc.GetCapabilitiesResponse> {
+    const req = blerpc.GetCapabilitiesRequest.create({});
+    const respData = await this.exclusive(() =>
+      this.call('get_capabilities', blerpc.GetCapabilitiesRequest.encode(req).finish()),
+    );
+    return blerpc.GetCapabilitiesResponse.decode(respData);
+  }
+
+  async getSetting({ field = 0 }: { field?: number } = {}): Promise<blerpc.GetSettingResponse> {
+    const req = blerpc.GetSettingRequest.create({ field });
+    const respData = await this.exclusive(() =>
+      this.call('get_setting', blerpc.GetSettingRequest.encode(req).finish()),
+    );
+    return blerpc.GetSettingResponse.decode(respData);
+  }
+
+  async setSetting({
+    field = 0,
+    value = new Uint8Array(0),
+  }: { field?: number; value?: Uint8Array } = {}): Promise<blerpc.SetSettingResponse> {
+    const req = blerpc.SetSettingRequest.create({ field, value });
+    const respData = await this.exclusive(() =>
+      this.call('set_setting', blerpc.SetSettingRequest.encode(req).finish()),
+    );
+    return blerpc.SetSettingResponse.decode(respData);
+  }
+
+  async counterStream({ count = 0 }: { count?: number } = {}): Promise<
+    blerpc.CounterStreamResponse[]
+  > {
+    const req = blerpc.CounterStreamRequest.create({ count });
+    const responses = await this.exclusive(() =>
+      this.streamReceive('counter_stream', blerpc.CounterStreamRequest.encode(req).finish()),
+    );
+    return responses.map((data) => blerpc.CounterStreamResponse.decode(data));
+  }
+
+  async counterUpload(
+    messages: blerpc.ICounterUploadRequest[],
+  ): Promise<blerpc.CounterUploadResponse> {
+    const raw = messages.map((m) =>
+      blerpc.CounterUploadRequest.encode(blerpc.CounterUploadRequest.create(m)).finish(),
+    );
+    const respData = await this.exclusive(() =>
+      this.streamSend('counter_upload', raw, 'counter_upload'),
+    );
+    return blerpc.CounterUploadResponse.decode(respData);
+  }
+
+  async logStream({ minLevel = 0, maxEntries = 0 }: { minLevel?: number; maxEntries?: number } = {}): Promise<
+    blerpc.LogStreamResponse[]
+  > {
+    const req = blerpc.LogStreamRequest.create({ minLevel, maxEntries });
+    const responses = await this.exclusive(() =>
+      this.streamReceive('log_stream', blerpc.LogStreamRequest.encode(req).finish()),
+    );
+    return responses.map((data) => blerpc.LogStreamResponse.decode(data));
+  }
+
+  /**
+   * Checks that the peripheral was built from this client's schema. Call
+   * once on connect; throws `SchemaMismatchError` when the schema hashes
+   * differ.
+   */
+  async verifySchema(): Promise<blerpc.GetBlerpcInfoResponse> {
+    const info = await this.getBlerpcInfo();
+    if (info.schemaHash !== SCHEMA_HASH) {
+      throw new SchemaMismatchError(SCHEMA_HASH, info.schemaHash, info.generatorVersion);
+    }
+    return info;
+  }
+
+  /**
+   * Drains the firmware log into timestamped entries, oldest first. The
+   * message of each entry joins the fragments of a long message, and the
+   * timestamp is when it was logged.
+   */
+  async readLogs({ minLevel = 0, maxEntries = 0 }: { minLevel?: number; maxEntries?: number } = {}): Promise<
+    { timestamp: Date; entry: blerpc.LogStreamResponse }[]
+  > {
+    const responses = await this.logStream({ minLevel, maxEntries });
+    const received = Date.now();
+    const logs: { timestamp: Date; entry: blerpc.LogStreamResponse }[] = [];
+    let first: blerpc.LogStreamResponse | undefined;
+    for (const resp of responses) {
+      if (first === undefined) {
+        first = resp;
+      } else {
+        first.message += resp.message;
+      }
+      if (resp.more) continue;
+      const ageMs = (first.nowMs - first.uptimeMs) >>> 0;
+      logs.push({ timestamp: new Date(received - ageMs), entry: first });
+      first = undefined;
+    }
+    return logs;
+  }
+
+  /** Pings the peripheral and returns the round trip in milliseconds. */
+  async measureLatency(): Promise<number> {
+    const start = Date.now();
+    await this.ping();
+    return Date.now() - start;
+  }
+
+  /**
+   * Pings the peripheral every `intervalMs`, passing each round trip to
+   * `onLatency`, until `signal` is aborted. It rejects as soon as a ping
+   * fails, so a dead connection shows before a long call times out.
+   */
+  async keepalive({
+    intervalMs = 5000,
+    onLatency,
+    signal,
+  }: { intervalMs?: number; onLatency?: (latencyMs: number) => void; signal?: AbortSignal } = {}): Promise<void> {
+    while (!signal?.aborted) {
+      onLatency?.(await this.measureLatency());
+      await new Promise((resolve) => setTimeout(resolve, intervalMs));
+    }
+  }
+
+  /** Reads the sample_interval_ms setting. */
+  async getSampleIntervalMsSetting(): Promise<number> {
+    const resp = await this.getSetting({ field: 1 });
+    return blerpc.DeviceSettings.decode(resp.value).sampleIntervalMs;
+  }
+
+  /** Reads the leds_enabled setting. */
+  async getLedsEnabledSetting(): Promise<boolean> {
+    const resp = await this.getSetting({ field: 2 });
+    return blerpc.DeviceSettings.decode(resp.value).ledsEnabled;
+  }
+
+  /** Writes the sample_interval_ms setting. */
+  async setSampleIntervalMsSetting(value: number): Promise<void> {
+    const settings = blerpc.DeviceSettings.encode({ sampleIntervalMs: value }).finish();
+    await this.setSetting({ field: 1, value: settings });
+  }
+
+  /** Writes the leds_enabled setting. */
+  async setLedsEnabledSetting(value: boolean): Promise<void> {
+    const settings = blerpc.DeviceSettings.encode({ ledsEnabled: value }).finish();
+    await this.setSetting({ field: 2, value: settings });
+  }
+
+  /**
+   * Sets the peripheral's wall clock to this device's. With `compensate`, a
+   * first exchange measures the round trip and the second adds half of it,
+   * the estimated delivery delay.
+   */
+  async syncTime({ compensate = false }: { compensate?: boolean } = {}): Promise<blerpc.TimeSyncResponse> {
+    let offsetUs = 0;
+    if (compensate) {
+      const start = Date.now();
+      await this.timeSync({ unixTimeUs: Date.now() * 1000 });
+      offsetUs = (Date.now() - start) * 500;
+    }
+    return this.timeSync({ unixTimeUs: Date.now() * 1000, offsetUs });
+  }
+}
+
+/** Role each command requires on the peripheral, keyed by wire name; others need 'user'. */
+export const COMMAND_ROLES: Readonly<Record<string, string>> = {
+  flash_read: 'factory',
+};
+
+/** The schema this client was generated from, which verifySchema compares with the peripheral's. */
+export const SCHEMA_HASH = 'fb27bddde9c1f6b7';
+export const GENERATOR_VERSION = '0.1.0';
+
+/** The peripheral was built from a different schema than this client. */
+export class SchemaMismatchError extends Error {
+  constructor(
+    readonly expected: string,
+    readonly actual: string,
+    readonly generatorVersion: string,
+  ) {
+    super(
+      `peripheral schema ${actual} (generator ${generatorVersion}) does not ` +
+        `match this client's ${expected} (generator ${GENERATOR_VERSION})`,
+    );
+    this.name = 'SchemaMismatchError';
+  }
+}
@@ -0,0 +1,346 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import noble, { Characteristic, Peripheral } from '@abandonware/noble';
+import {
+  ContainerSplitter,
+  ContainerAssembler,
+  Container,
+  ContainerType,
+  ControlCmd,
+  CommandPacket,
+  CommandType,
+  makeTimeoutRequest,
+  makeCapabilitiesRequest,
+  makeStreamEndC2P,
+  makeKeyExchange,
+  centralPerformKeyExchange,
+  BlerpcCryptoSession,
+  CAPABILITY_FLAG_ENCRYPTION_SUPPORTED,
+  BLERPC_ERROR_RESPONSE_TOO_LARGE,
+} from '@blerpc/protocol-rn';
+import { GeneratedClient } from './GeneratedClient';
+
+export const SERVICE_UUID = '6e400001-b5a3-f393-e0a9-e50e24dcca9e';
+export const CHAR_UUID = '6e400002-b5a3-f393-e0a9-e50e24dcca9e';
+
+/** noble reports UUIDs in lowercase without dashes. */
+const nobleUuid = (uuid: string): string => uuid.replace(/-/g, '').toLowerCase();
+
+function poweredOn(): Promise<void> {
+  if (noble.state === 'poweredOn') return Promise.resolve();
+  return new Promise<void>((resolve) => {
+    const onStateChange = (state: string): void => {
+      if (state !== 'poweredOn') return;
+      noble.removeListener('stateChange', onStateChange);
+      resolve();
+    };
+    noble.on('stateChange', onStateChange);
+  });
+}
+
+export interface NodeBleClientOptions {
+  /** Fail connect() unless the peripheral completes the key exchange. */
+  requireEncryption?: boolean;
+  /** Milliseconds connect() scans for a peripheral when none is given. */
+  scanTimeout?: number;
+}
+
+/**
+ * Calls the commands from Node over noble, e.g. from hardware-in-the-loop
+ * rig scripts.
+ */
+export class NodeBleClient extends GeneratedClient {
+  private readonly requireEncryption: boolean;
+  private readonly scanTimeout: number;
+
+  private peripheral: Peripheral | null = null;
+  private char: Characteristic | null = null;
+  private notifyQueue: Uint8Array[] = [];
+  private notifyWaiter: ((data: Uint8Array) => void) | null = null;
+
+  private splitter: ContainerSplitter | null = null;
+  private readonly assembler = new ContainerAssembler();
+  private timeout = 100;
+  private maxRequestPayloadSize: number | null = null;
+  private session: BlerpcCryptoSession | null = null;
+
+  constructor({ requireEncryption = true, scanTimeout = 10000 }: NodeBleClientOptions = {}) {
+    super();
+    this.requireEncryption = requireEncryption;
+    this.scanTimeout = scanTimeout;
+  }
+
+  get isConnected(): boolean {
+    return this.char !== null && this.peripheral?.state === 'connected';
+  }
+
+  get isEncrypted(): boolean {
+    return this.session !== null;
+  }
+
+  /**
+   * Scans for the first peripheral advertising the blerpc service, or the
+   * one with the given local name.
+   */
+  static async scan(name?: string, timeout = 10000): Promise<Peripheral> {
+    await poweredOn();
+    return new Promise<Peripheral>((resolve, reject) => {
+      const finish = (): void => {
+        clearTimeout(timer);
+        noble.removeListener('discover', onDiscover);
+        void noble.stopScanningAsync();
+      };
+      const onDiscover = (peripheral: Peripheral): void => {
+        if (name !== undefined && peripheral.advertisement.localName !== name) return;
+        finish();
+        resolve(peripheral);
+      };
+      const timer = setTimeout(() => {
+        finish();
+        reject(new Error('No blerpc peripheral found'));
+      }, timeout);
+      noble.on('discover', onDiscover);
+      noble.startScanningAsync([nobleUuid(SERVICE_UUID)], false).catch((e: unknown) => {
+        finish();
+        reject(e);
+      });
+    });
+  }
+
+  async connect(peripheral?: Peripheral): Promise<void> {
+    this.peripheral = peripheral ?? (await NodeBleClient.scan(undefined, this.scanTimeout));
+    this.peripheral.once('disconnect', this.onDisconnected);
+    await this.peripheral.connectAsync();
+    const { characteristics } = await this.peripheral.discoverSomeServicesAndCharacteristicsAsync(
+      [nobleUuid(SERVICE_UUID)],
+      [nobleUuid(CHAR_UUID)],
+    );
+    if (characteristics.length === 0) {
+      await this.disconnect();
+      throw new Error('blerpc characteristic not found');
+    }
+    this.char = characteristics[0];
+    this.notifyQueue = [];
+    this.notifyWaiter = null;
+    this.char.on('data', this.onNotify);
+    await this.char.subscribeAsync();
+    this.splitter = new ContainerSplitter(this.peripheral.mtu ?? 23);
+
+    try {
+      await this.requestTimeout();
+    } catch {
+      console.log('Peripheral did not respond to timeout request, using default');
+    }
+    try {
+      await this.requestCapabilities();
+    } catch {
+      console.log('Peripheral did not respond to capabilities request');
+    }
+    if (this.requireEncryption && this.session === null) {
+      await this.disconnect();
+      throw new Error('Encryption required but key exchange was not completed');
+    }
+  }
+
+  async disconnect(): Promise<void> {
+    this.char?.removeListener('data', this.onNotify);
+    const peripheral = this.peripheral;
+    peripheral?.removeListener('disconnect', this.onDisconnected);
+    this.peripheral = null;
+    this.reset();
+    if (peripheral?.state === 'connected') await peripheral.disconnectAsync();
+  }
+
+  private reset(): void {
+    this.char = null;
+    this.splitter = null;
+    this.session = null;
+    this.notifyQueue = [];
+    this.notifyWaiter = null;
+  }
+
+  private readonly onDisconnected = (): void => {
+    this.reset();
+  };
+
+  private readonly onNotify = (data: Buffer): void => {
+    const copy = new Uint8Array(data);
+    if (this.notifyWaiter) {
+      const waiter = this.notifyWaiter;
+      this.notifyWaiter = null;
+      waiter(copy);
+    } else {
+      this.notifyQueue.push(copy);
+    }
+  };
+
+  private async write(data: Uint8Array): Promise<void> {
+    if (!this.char) throw new Error('Not connected');
+    await this.char.writeAsync(Buffer.from(data), true);
+  }
+
+  private readNotify(timeout: number): Promise<Uint8Array> {
+    if (!this.char) return Promise.reject(new Error('Not connected'));
+    const queued = this.notifyQueue.shift();
+    if (queued) return Promise.resolve(queued);
+    return new Promise<Uint8Array>((resolve, reject) => {
+      const timer = setTimeout(() => {
+        this.notifyWaiter = null;
+        reject(new Error('Timeout waiting for notification'));
+      }, timeout);
+      this.notifyWaiter = (data) => {
+        clearTimeout(timer);
+        resolve(data);
+      };
+    });
+  }
+
+  private readTimeout(firstRead: boolean): number {
+    return firstRead ? Math.max(this.timeout, 2000) : this.timeout;
+  }
+
+  private async control(
+    request: Container,
+    cmd: ControlCmd,
+    timeout: number,
+  ): Promise<Uint8Array> {
+    await this.write(request.serialize());
+    const resp = Container.deserialize(await this.readNotify(timeout));
+    if (resp.containerType !== ContainerType.CONTROL || resp.controlCmd !== cmd) {
+      throw new Error(`Expected control command ${cmd}`);
+    }
+    return resp.payload;
+  }
+
+  private async requestTimeout(): Promise<void> {
+    const s = this.splitter!;
+    const payload = await this.control(
+      makeTimeoutRequest(s.nextTransactionId()),
+      ControlCmd.TIMEOUT,
+      1000,
+    );
+    if (payload.length === 2) {
+      this.timeout = new DataView(payload.buffer, payload.byteOffset, 2).getUint16(0, true);
+    }
+  }
+
+  private async requestCapabilities(): Promise<void> {
+    const s = this.splitter!;
+    const payload = await this.control(
+      makeCapabilitiesRequest(s.nextTransactionId()),
+      ControlCmd.CAPABILITIES,
+      1000,
+    );
+    if (payload.length < 6) return;
+    const view = new DataView(payload.buffer, payload.byteOffset, payload.byteLength);
+    this.maxRequestPayloadSize = view.getUint16(0, true);
+    if (view.getUint16(4, true) & CAPABILITY_FLAG_ENCRYPTION_SUPPORTED) {
+      try {
+        this.session = await centralPerformKeyExchange({
+          send: async (data: Uint8Array) => {
+            await this.write(makeKeyExchange(s.nextTransactionId(), data).serialize());
+          },
+          receive: async () => {
+            const resp = Container.deserialize(await this.readNotify(2000));
+            if (
+              resp.containerType !== ContainerType.CONTROL ||
+              resp.controlCmd !== ControlCmd.KEY_EXCHANGE
+            ) {
+              throw new Error('Expected KEY_EXCHANGE response');
+            }
+            return resp.payload;
+          },
+        });
+      } catch (e) {
+        if (this.requireEncryption) throw e;
+      }
+    }
+  }
+
+  private encrypt(payload: Uint8Array): Uint8Array {
+    if (this.session) return this.session.encrypt(payload);
+    if (this.requireEncryption) throw new Error('Encryption required but no session established');
+    return payload;
+  }
+
+  private decrypt(payload: Uint8Array): Uint8Array {
+    if (this.session) return this.session.decrypt(payload);
+    if (this.requireEncryption) throw new Error('Encryption required but no session established');
+    return payload;
+  }
+
+  private async send(cmdName: string, requestData: Uint8Array): Promise<void> {
+    if (!this.splitter) throw new Error('Not connected');
+    const payload = new CommandPacket({
+      cmdType: CommandType.REQUEST,
+      cmdName,
+      data: requestData,
+    }).serialize();
+    if (this.maxRequestPayloadSize !== null && payload.length > this.maxRequestPayloadSize) {
+      throw new Error(
+        `Request payload (${payload.length} bytes) exceeds peripheral limit (${this.maxRequestPayloadSize} bytes)`,
+      );
+    }
+    for (const c of this.splitter.split(this.encrypt(payload))) {
+      await this.write(c.serialize());
+    }
+  }
+
+  /** Reads the next response; null when a peripheral-to-central stream ends. */
+  private async receive(cmdName: string | null, firstRead: boolean): Promise<Uint8Array | null> {
+    this.assembler.reset();
+    for (;;) {
+      const container = Container.deserialize(await this.readNotify(this.readTimeout(firstRead)));
+      firstRead = false;
+      if (container.containerType === ContainerType.CONTROL) {
+        if (container.controlCmd === ControlCmd.STREAM_END_P2C && cmdName === null) return null;
+        if (container.controlCmd === ControlCmd.ERROR && container.payload.length > 0) {
+          const code = container.payload[0];
+          if (code === BLERPC_ERROR_RESPONSE_TOO_LARGE) {
+            throw new Error("Response exceeds peripheral's max_response_payload_size");
+          }
+          throw new Error(`Peripheral error 0x${code.toString(16).padStart(2, '0')}`);
+        }
+        continue;
+      }
+      const result = this.assembler.feed(container);
+      if (result === null) continue;
+      const resp = CommandPacket.deserialize(this.decrypt(result));
+      if (resp.cmdType !== CommandType.RESPONSE) {
+        throw new Error(`Expected response, got type=${resp.cmdType}`);
+      }
+      if (cmdName !== null && resp.cmdName !== cmdName) {
+        throw new Error(
+          `Command name mismatch: expected '${cmdName}', got '${resp.cmdName}'`,
+        );
+      }
+      return resp.data;
+    }
+  }
+
+  protected async call(cmdName: string, requestData: Uint8Array): Promise<Uint8Array> {
+    await this.send(cmdName, requestData);
+    return (await this.receive(cmdName, true))!;
+  }
+
+  protected async streamReceive(cmdName: string, requestData: Uint8Array): Promise<Uint8Array[]> {
+    await this.send(cmdName, requestData);
+    const results: Uint8Array[] = [];
+    for (let data = await this.receive(null, true); data !== null; ) {
+      results.push(data);
+      data = await this.receive(null, false);
+    }
+    return results;
+  }
+
+  protected async streamSend(
+    cmdName: string,
+    messages: Uint8Array[],
+    finalCmdName: string,
+  ): Promise<Uint8Array> {
+    for (const data of messages) {
+      await this.send(cmdName, data);
+    }
+    await this.write(makeStreamEndC2P(this.splitter!.nextTransactionId()).serialize());
+    return (await this.receive(finalCmdName, true))!;
+  }
+}
@@ -0,0 +1,305 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+import {
+  ContainerSplitter,
+  ContainerAssembler,
+  Container,
+  ContainerType,
+  ControlCmd,
+  CommandPacket,
+  CommandType,
+  makeTimeoutRequest,
+  makeCapabilitiesRequest,
+  makeStreamEndC2P,
+  makeKeyExchange,
+  centralPerformKeyExchange,
+  BlerpcCryptoSession,
+  CAPABILITY_FLAG_ENCRYPTION_SUPPORTED,
+  BLERPC_ERROR_RESPONSE_TOO_LARGE,
+} from '@blerpc/protocol-rn';
+import { GeneratedClient } from './GeneratedClient';
+
+export const SERVICE_UUID = '6e400001-b5a3-f393-e0a9-e50e24dcca9e';
+export const CHAR_UUID = '6e400002-b5a3-f393-e0a9-e50e24dcca9e';
+
+export interface WebBluetoothClientOptions {
+  /** Fail connect() unless the peripheral completes the key exchange. */
+  requireEncryption?: boolean;
+  /**
+   * ATT MTU of the link. Web Bluetooth does not report the negotiated MTU,
+   * so containers are sized for this value; raise it only for peripherals
+   * known to negotiate a larger one.
+   */
+  mtu?: number;
+}
+
+/**
+ * Calls the commands from a browser over Web Bluetooth. connect() must run
+ * from a user gesture, e.g. a click handler, as requestDevice() shows the
+ * browser's device chooser.
+ */
+export class WebBluetoothClient extends GeneratedClient {
+  private readonly requireEncryption: boolean;
+  private readonly mtu: number;
+
+  private device: BluetoothDevice | null = null;
+  private char: BluetoothRemoteGATTCharacteristic | null = null;
+  private notifyQueue: Uint8Array[] = [];
+  private notifyWaiter: ((data: Uint8Array) => void) | null = null;
+
+  private splitter: ContainerSplitter | null = null;
+  private readonly assembler = new ContainerAssembler();
+  private timeout = 100;
+  private maxRequestPayloadSize: number | null = null;
+  private session: BlerpcCryptoSession | null = null;
+
+  constructor({ requireEncryption = true, mtu = 23 }: WebBluetoothClientOptions = {}) {
+    super();
+    this.requireEncryption = requireEncryption;
+    this.mtu = mtu;
+  }
+
+  get isConnected(): boolean {
+    return this.char !== null && (this.device?.gatt?.connected ?? false);
+  }
+
+  get isEncrypted(): boolean {
+    return this.session !== null;
+  }
+
+  /** Asks the user to pick a peripheral advertising the blerpc service. */
+  static requestDevice(): Promise<BluetoothDevice> {
+    return navigator.bluetooth.requestDevice({ filters: [{ services: [SERVICE_UUID] }] });
+  }
+
+  async connect(device?: BluetoothDevice): Promise<void> {
+    this.device = device ?? (await WebBluetoothClient.requestDevice());
+    this.device.addEventListener('gattserverdisconnected', this.onDisconnected);
+    const server = await this.device.gatt!.connect();
+    const service = await server.getPrimaryService(SERVICE_UUID);
+    this.char = await service.getCharacteristic(CHAR_UUID);
+    this.notifyQueue = [];
+    this.notifyWaiter = null;
+    this.char.addEventListener('characteristicvaluechanged', this.onNotify);
+    await this.char.startNotifications();
+    this.splitter = new ContainerSplitter(this.mtu);
+
+    try {
+      await this.requestTimeout();
+    } catch {
+      console.log('Peripheral did not respond to timeout request, using default');
+    }
+    try {
+      await this.requestCapabilities();
+    } catch {
+      console.log('Peripheral did not respond to capabilities request');
+    }
+    if (this.requireEncryption && this.session === null) {
+      this.disconnect();
+      throw new Error('Encryption required but key exchange was not completed');
+    }
+  }
+
+  disconnect(): void {
+    this.char?.removeEventListener('characteristicvaluechanged', this.onNotify);
+    this.device?.removeEventListener('gattserverdisconnected', this.onDisconnected);
+    if (this.device?.gatt?.connected) this.device.gatt.disconnect();
+    this.device = null;
+    this.reset();
+  }
+
+  private reset(): void {
+    this.char = null;
+    this.splitter = null;
+    this.session = null;
+    this.notifyQueue = [];
+    this.notifyWaiter = null;
+  }
+
+  private readonly onDisconnected = (): void => {
+    this.reset();
+  };
+
+  private readonly onNotify = (event: Event): void => {
+    const value = (event.target as BluetoothRemoteGATTCharacteristic).value;
+    if (!value) return;
+    const data = new Uint8Array(value.buffer, value.byteOffset, value.byteLength).slice();
+    if (this.notifyWaiter) {
+      const waiter = this.notifyWaiter;
+      this.notifyWaiter = null;
+      waiter(data);
+    } else {
+      this.notifyQueue.push(data);
+    }
+  };
+
+  private async write(data: Uint8Array): Promise<void> {
+    if (!this.char) throw new Error('Not connected');
+    await this.char.writeValueWithoutResponse(data);
+  }
+
+  private readNotify(timeout: number): Promise<Uint8Array> {
+    if (!this.char) return Promise.reject(new Error('Not connected'));
+    const queued = this.notifyQueue.shift();
+    if (queued) return Promise.resolve(queued);
+    return new Promise<Uint8Array>((resolve, reject) => {
+      const timer = setTimeout(() => {
+        this.notifyWaiter = null;
+        reject(new Error('Timeout waiting for notification'));
+      }, timeout);
+      this.notifyWaiter = (data) => {
+        clearTimeout(timer);
+        resolve(data);
+      };
+    });
+  }
+
+  private readTimeout(firstRead: boolean): number {
+    return firstRead ? Math.max(this.timeout, 2000) : this.timeout;
+  }
+
+  private async control(
+    request: Container,
+    cmd: ControlCmd,
+    timeout: number,
+  ): Promise<Uint8Array> {
+    await this.write(request.serialize());
+    const resp = Container.deserialize(await this.readNotify(timeout));
+    if (resp.containerType !== ContainerType.CONTROL || resp.controlCmd !== cmd) {
+      throw new Error(`Expected control command ${cmd}`);
+    }
+    return resp.payload;
+  }
+
+  private async requestTimeout(): Promise<void> {
+    const s = this.splitter!;
+    const payload = await this.control(
+      makeTimeoutRequest(s.nextTransactionId()),
+      ControlCmd.TIMEOUT,
+      1000,
+    );
+    if (payload.length === 2) {
+      this.timeout = new DataView(payload.buffer, payload.byteOffset, 2).getUint16(0, true);
+    }
+  }
+
+  private async requestCapabilities(): Promise<void> {
+    const s = this.splitter!;
+    const payload = await this.control(
+      makeCapabilitiesRequest(s.nextTransactionId()),
+      ControlCmd.CAPABILITIES,
+      1000,
+    );
+    if (payload.length < 6) return;
+    const view = new DataView(payload.buffer, payload.byteOffset, payload.byteLength);
+    this.maxRequestPayloadSize = view.getUint16(0, true);
+    if (view.getUint16(4, true) & CAPABILITY_FLAG_ENCRYPTION_SUPPORTED) {
+      try {
+        this.session = await centralPerformKeyExchange({
+          send: async (data: Uint8Array) => {
+            await this.write(makeKeyExchange(s.nextTransactionId(), data).serialize());
+          },
+          receive: async () => {
+            const resp = Container.deserialize(await this.readNotify(2000));
+            if (
+              resp.containerType !== ContainerType.CONTROL ||
+              resp.controlCmd !== ControlCmd.KEY_EXCHANGE
+            ) {
+              throw new Error('Expected KEY_EXCHANGE response');
+            }
+            return resp.payload;
+          },
+        });
+      } catch (e) {
+        if (this.requireEncryption) throw e;
+      }
+    }
+  }
+
+  private encrypt(payload: Uint8Array): Uint8Array {
+    if (this.session) return this.session.encrypt(payload);
+    if (this.requireEncryption) throw new Error('Encryption required but no session established');
+    return payload;
+  }
+
+  private decrypt(payload: Uint8Array): Uint8Array {
+    if (this.session) return this.session.decrypt(payload);
+    if (this.requireEncryption) throw new Error('Encryption required but no session established');
+    return payload;
+  }
+
+  private async send(cmdName: string, requestData: Uint8Array): Promise<void> {
+    if (!this.splitter) throw new Error('Not connected');
+    const payload = new CommandPacket({
+      cmdType: CommandType.REQUEST,
+      cmdName,
+      data: requestData,
+    }).serialize();
+    if (this.maxRequestPayloadSize !== null && payload.length > this.maxRequestPayloadSize) {
+      throw new Error(
+        `Request payload (${payload.length} bytes) exceeds peripheral limit (${this.maxRequestPayloadSize} bytes)`,
+      );
+    }
+    for (const c of this.splitter.split(this.encrypt(payload))) {
+      await this.write(c.serialize());
+    }
+  }
+
+  /** Reads the next response; null when a peripheral-to-central stream ends. */
+  private async receive(cmdName: string | null, firstRead: boolean): Promise<Uint8Array | null> {
+    this.assembler.reset();
+    for (;;) {
+      const container = Container.deserialize(await this.readNotify(this.readTimeout(firstRead)));
+      firstRead = false;
+      if (container.containerType === ContainerType.CONTROL) {
+        if (container.controlCmd === ControlCmd.STREAM_END_P2C && cmdName === null) return null;
+        if (container.controlCmd === ControlCmd.ERROR && container.payload.length > 0) {
+          const code = container.payload[0];
+          if (code === BLERPC_ERROR_RESPONSE_TOO_LARGE) {
+            throw new Error("Response exceeds peripheral's max_response_payload_size");
+          }
+          throw new Error(`Peripheral error 0x${code.toString(16).padStart(2, '0')}`);
+        }
+        continue;
+      }
+      const result = this.assembler.feed(container);
+      if (result === null) continue;
+      const resp = CommandPacket.deserialize(this.decrypt(result));
+      if (resp.cmdType !== CommandType.RESPONSE) {
+        throw new Error(`Expected response, got type=${resp.cmdType}`);
+      }
+      if (cmdName !== null && resp.cmdName !== cmdName) {
+        throw new Error(
+          `Command name mismatch: expected '${cmdName}', got '${resp.cmdName}'`,
+        );
+      }
+      return resp.data;
+    }
+  }
+
+  protected async call(cmdName: string, requestData: Uint8Array): Promise<Uint8Array> {
+    await this.send(cmdName, requestData);
+    return (await this.receive(cmdName, true))!;
+  }
+
+  protected async streamReceive(cmdName: string, requestData: Uint8Array): Promise<Uint8Array[]> {
+    await this.send(cmdName, requestData);
+    const results: Uint8Array[] = [];
+    for (let data = await this.receive(null, true); data !== null; ) {
+      results.push(data);
+      data = await this.receive(null, false);
+    }
+    return results;
+  }
+
+  protected async streamSend(
+    cmdName: string,
+    messages: Uint8Array[],
+    finalCmdName: string,
+  ): Promise<Uint8Array> {
+    for (const data of messages) {
+      await this.send(cmdName, data);
+    }
+    await this.write(makeStreamEndC2P(this.splitter!.nextTransactionId()).serialize());
+    return (await this.receive(finalCmdName, true))!;
+  }
+}
@@ -0,0 +1,913 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+/*
+ * Conformance loopback of the C client. blerpc_rpc_call,
+ * blerpc_stream_receive and blerpc_stream_send, the transport functions
+ * generated_client.h expects, frame calls with blerpc_protocol as
+ * central_fw/src/main.c does, but record the containers instead of writing
+ * them and answer from the response containers of the current vector. main
+ * calls them with the sample request of every vector and compares the
+ * containers and the response data with it, decoding the response with
+ * nanopb. Build it on the host in place of main.c, with blerpc_protocol,
+ * nanopb and blerpc.pb.c; it exits non-zero on a mismatch.
+ */
+#include <stdio.h>
+#include <string.h>
+
+#include <blerpc_protocol/command.h>
+#include <blerpc_protocol/container.h>
+#include "generated_client.h"
+
+#ifndef CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE
+#define CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE 4096
+#endif
+
+enum conformance_stream {
+    STREAM_NONE,
+    STREAM_P2C,
+    STREAM_C2P,
+};
+
+struct conformance_bytes {
+    const uint8_t *data;
+    size_t len;
+};
+
+struct conformance_vector {
+    const char *command;
+    const char *wire_name;
+    enum conformance_stream stream;
+    uint16_t mtu;
+    const uint8_t *request;
+    size_t request_len;
+    const uint8_t *response;
+    size_t response_len;
+    /* The request containers, concatenated. */
+    const uint8_t *sent;
+    size_t sent_len;
+    /* The response containers. */
+    const struct conformance_bytes *answers;
+    size_t answer_count;
+    bool (*decode)(const uint8_t *data, size_t len);
+};
+
+static const struct conformance_vector *current;
+static uint8_t transaction_counter;
+static uint8_t sent[CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE * 2];
+static size_t sent_len;
+static uint8_t cmd_buf[CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE];
+static uint8_t work_buf[CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE];
+
+/* Send container callback for container_split_and_send: records the
+ * container. */
+static int record(const uint8_t *data, size_t len, void *ctx)
+{
+    (void)ctx;
+    if (len > sizeof(sent) - sent_len) {
+        return -1;
+    }
+    memcpy(sent + sent_len, data, len);
+    sent_len += len;
+    return 0;
+}
+
+static int send_request(const char *cmd_name, const uint8_t *data, size_t len)
+{
+    int cmd_len = command_serialize(COMMAND_TYPE_REQUEST, cmd_name, (uint8_t)strlen(cmd_name),
+                                    data, (uint16_t)len, cmd_buf, sizeof(cmd_buf));
+    if (cmd_len < 0) {
+        return -1;
+    }
+    return container_split_and_send(transaction_counter++, cmd_buf, (size_t)cmd_len,
+                                    current->mtu, record, NULL) < 0 ? -1 : 0;
+}
+
+/* Passes the data of every response in the answers of the vector to
+ * on_resp. */
+static int receive(blerpc_on_stream_resp_t on_resp, void *ctx)
+{
+    struct container_assembler assembler;
+    container_assembler_init(&assembler);
+    for (size_t i = 0; i < current->answer_count; i++) {
+        struct container_header hdr;
+        if (container_parse_header(current->answers[i].data, current->answers[i].len, &hdr) != 0) {
+            return -1;
+        }
+        if (hdr.type == CONTAINER_TYPE_CONTROL || container_assembler_feed(&assembler, &hdr) != 1) {
+            continue;
+        }
+        struct command_packet resp;
+        if (command_parse(assembler.buf, assembler.total_length, &resp) != 0 ||
+            resp.cmd_type != COMMAND_TYPE_RESPONSE) {
+            return -1;
+        }
+        if (on_resp(resp.data, resp.data_len, ctx) != 0) {
+            return -1;
+        }
+        container_assembler_init(&assembler);
+    }
+    return 0;
+}
+
+struct response_buf {
+    uint8_t *data;
+    size_t size;
+    size_t *len;
+};
+
+static int copy_response(const uint8_t *data, size_t len, void *ctx)
+{
+    struct response_buf *out = ctx;
+    if (len > out->size) {
+        return -1;
+    }
+    memcpy(out->data, data, len);
+    *out->len = len;
+    return 0;
+}
+
+int blerpc_rpc_call(const char *cmd_name, const uint8_t *req_data, size_t req_len,
+                    uint8_t *resp_data, size_t resp_size, size_t *resp_len)
+{
+    struct response_buf out = {resp_data, resp_size, resp_len};
+    if (send_request(cmd_name, req_data, req_len) != 0) {
+        return -1;
+    }
+    return receive(copy_response, &out);
+}
+
+int blerpc_stream_receive(const char *cmd_name, const uint8_t *req_data, size_t req_len,
+                          blerpc_on_stream_resp_t on_resp, void *ctx)
+{
+    if (send_request(cmd_name, req_data, req_len) != 0) {
+        return -1;
+    }
+    return receive(on_resp, ctx);
+}
+
+int blerpc_stream_send(const char *cmd_name, size_t msg_count, blerpc_next_msg_t next_msg,
+                       void *msg_ctx, const char *final_cmd_name, uint8_t *resp_data,
+                       size_t resp_size, size_t *resp_len)
+{
+    (void)final_cmd_name;
+    for (size_t i = 0; i < msg_count; i++) {
+        size_t msg_len;
+        if (next_msg(i, work_buf, sizeof(work_buf), &msg_len, msg_ctx) != 0 ||
+            send_request(cmd_name, work_buf, msg_len) != 0) {
+            return -1;
+        }
+    }
+
+    /* STREAM_END_C2P, under the next transaction like the other clients */
+    uint8_t end[8];
+    struct container_header ctrl = {
+        .transaction_id = transaction_counter++,
+        .sequence_number = 0,
+        .type = CONTAINER_TYPE_CONTROL,
+        .control_cmd = CONTROL_CMD_STREAM_END_C2P,
+        .payload_len = 0,
+    };
+    ctrl.payload = NULL;
+    int n = container_serialize(&ctrl, end, sizeof(end));
+    if (n < 0 || record(end, (size_t)n, NULL) != 0) {
+        return -1;
+    }
+
+    struct response_buf out = {resp_data, resp_size, resp_len};
+    return receive(copy_response, &out);
+}
+
+/* next_msg callback sending the sample request once. */
+static int next_request(size_t index, uint8_t *buf, size_t buf_size, size_t *len, void *ctx)
+{
+    (void)index;
+    const struct conformance_vector *v = ctx;
+    if (v->request_len > buf_size) {
+        return -1;
+    }
+    if (v->request_len > 0) {
+        memcpy(buf, v->request, v->request_len);
+    }
+    *len = v->request_len;
+    return 0;
+}
+
+/* on_resp callback checking every response against the vector. */
+static int check_response(const uint8_t *data, size_t len, void *ctx)
+{
+    const struct conformance_vector *v = ctx;
+    if (len != v->response_len || (len > 0 && memcmp(data, v->response, len) != 0)) {
+        printf("%s at MTU %u: response data differs\n", v->command, (unsigned)v->mtu);
+        return -1;
+    }
+    if (!v->decode(data, len)) {
+        printf("%s at MTU %u: response does not decode\n", v->command, (unsigned)v->mtu);
+        return -1;
+    }
+    return 0;
+}
+
+/* Runs v and returns 1 on a mismatch. */
+static int check_vector(const struct conformance_vector *v)
+{
+    static uint8_t resp[CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE];
+    size_t resp_len = 0;
+    int rc;
+
+    current = v;
+    transaction_counter = 0;
+    sent_len = 0;
+    switch (v->stream) {
+    case STREAM_P2C:
+        rc = blerpc_stream_receive(v->wire_name, v->request, v->request_len, check_response,
+                                   (void *)v);
+        break;
+    case STREAM_C2P:
+        rc = blerpc_stream_send(v->wire_name, 1, next_request, (void *)v, v->wire_name, resp,
+                                sizeof(resp), &resp_len);
+        if (rc == 0) {
+            rc = check_response(resp, resp_len, (void *)v);
+        }
+        break;
+    default:
+        rc = blerpc_rpc_call(v->wire_name, v->request, v->request_len, resp, sizeof(resp),
+                             &resp_len);
+        if (rc == 0) {
+            rc = check_response(resp, resp_len, (void *)v);
+        }
+        break;
+    }
+    if (rc != 0) {
+        printf("%s at MTU %u: call failed\n", v->command, (unsigned)v->mtu);
+        return 1;
+    }
+    if (sent_len != v->sent_len || (sent_len > 0 && memcmp(sent, v->sent, sent_len) != 0)) {
+        printf("%s at MTU %u: sent containers differ\n", v->command, (unsigned)v->mtu);
+        return 1;
+    }
+    return 0;
+}
+
+static bool decode_echo(const uint8_t *data, size_t len)
+{
+    blerpc_EchoResponse msg = blerpc_EchoResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_EchoResponse_fields, &msg);
+}
+
+static bool decode_data_write(const uint8_t *data, size_t len)
+{
+    blerpc_DataWriteResponse msg = blerpc_DataWriteResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_DataWriteResponse_fields, &msg);
+}
+
+static bool decode_counter_stream(const uint8_t *data, size_t len)
+{
+    blerpc_CounterStreamResponse msg = blerpc_CounterStreamResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_CounterStreamResponse_fields, &msg);
+}
+
+static bool decode_counter_upload(const uint8_t *data, size_t len)
+{
+    blerpc_CounterUploadResponse msg = blerpc_CounterUploadResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_CounterUploadResponse_fields, &msg);
+}
+
+static bool decode_get_blerpc_info(const uint8_t *data, size_t len)
+{
+    blerpc_GetBlerpcInfoResponse msg = blerpc_GetBlerpcInfoResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_GetBlerpcInfoResponse_fields, &msg);
+}
+
+static bool decode_conn_params(const uint8_t *data, size_t len)
+{
+    blerpc_ConnParamsResponse msg = blerpc_ConnParamsResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_ConnParamsResponse_fields, &msg);
+}
+
+static bool decode_dfu_begin(const uint8_t *data, size_t len)
+{
+    blerpc_DfuBeginResponse msg = blerpc_DfuBeginResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_DfuBeginResponse_fields, &msg);
+}
+
+static bool decode_dfu_chunk(const uint8_t *data, size_t len)
+{
+    blerpc_DfuChunkResponse msg = blerpc_DfuChunkResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_DfuChunkResponse_fields, &msg);
+}
+
+static bool decode_dfu_finalize(const uint8_t *data, size_t len)
+{
+    blerpc_DfuFinalizeResponse msg = blerpc_DfuFinalizeResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_DfuFinalizeResponse_fields, &msg);
+}
+
+static bool decode_file_open(const uint8_t *data, size_t len)
+{
+    blerpc_FileOpenResponse msg = blerpc_FileOpenResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_FileOpenResponse_fields, &msg);
+}
+
+static bool decode_file_read(const uint8_t *data, size_t len)
+{
+    blerpc_FileReadResponse msg = blerpc_FileReadResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_FileReadResponse_fields, &msg);
+}
+
+static bool decode_file_write(const uint8_t *data, size_t len)
+{
+    blerpc_FileWriteResponse msg = blerpc_FileWriteResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_FileWriteResponse_fields, &msg);
+}
+
+static bool decode_file_close(const uint8_t *data, size_t len)
+{
+    blerpc_FileCloseResponse msg = blerpc_FileCloseResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_FileCloseResponse_fields, &msg);
+}
+
+static bool decode_log_stream(const uint8_t *data, size_t len)
+{
+    blerpc_LogStreamResponse msg = blerpc_LogStreamResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_LogStreamResponse_fields, &msg);
+}
+
+static bool decode_get_rpc_stats(const uint8_t *data, size_t len)
+{
+    blerpc_GetRpcStatsResponse msg = blerpc_GetRpcStatsResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_GetRpcStatsResponse_fields, &msg);
+}
+
+static bool decode_start_session(const uint8_t *data, size_t len)
+{
+    blerpc_StartSessionResponse msg = blerpc_StartSessionResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_StartSessionResponse_fields, &msg);
+}
+
+static bool decode_authenticate_session(const uint8_t *data, size_t len)
+{
+    blerpc_AuthenticateSessionResponse msg = blerpc_AuthenticateSessionResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_AuthenticateSessionResponse_fields, &msg);
+}
+
+static bool decode_time_sync(const uint8_t *data, size_t len)
+{
+    blerpc_TimeSyncResponse msg = blerpc_TimeSyncResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_TimeSyncResponse_fields, &msg);
+}
+
+static bool decode_ping(const uint8_t *data, size_t len)
+{
+    blerpc_PingResponse msg = blerpc_PingResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_PingResponse_fields, &msg);
+}
+
+static bool decode_get_capabilities(const uint8_t *data, size_t len)
+{
+    blerpc_GetCapabilitiesResponse msg = blerpc_GetCapabilitiesResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_GetCapabilitiesResponse_fields, &msg);
+}
+
+static bool decode_get_setting(const uint8_t *data, size_t len)
+{
+    blerpc_GetSettingResponse msg = blerpc_GetSettingResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_GetSettingResponse_fields, &msg);
+}
+
+static bool decode_set_setting(const uint8_t *data, size_t len)
+{
+    blerpc_SetSettingResponse msg = blerpc_SetSettingResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(data, len);
+    return pb_decode(&stream, blerpc_SetSettingResponse_fields, &msg);
+}
+
+/* The vectors of conformance/vectors.json. */
+static const struct conformance_vector vectors[] = {
+    {
+        "echo", "\x01", STREAM_NONE, 23,
+        (const uint8_t[]){0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 9,
+        (const uint8_t[]){0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 9,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x00, 0x01, 0x01, 0x09, 0x00, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 20,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x80, 0x01, 0x01, 0x09, 0x00, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 20},
+        },
+        1,
+        decode_echo,
+    },
+    {
+        "echo", "\x01", STREAM_NONE, 247,
+        (const uint8_t[]){0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 9,
+        (const uint8_t[]){0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 9,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x00, 0x01, 0x01, 0x09, 0x00, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 20,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x80, 0x01, 0x01, 0x09, 0x00, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 20},
+        },
+        1,
+        decode_echo,
+    },
+    {
+        "data_write", "\x03", STREAM_NONE, 23,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x03, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x03, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_data_write,
+    },
+    {
+        "data_write", "\x03", STREAM_NONE, 247,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x03, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x03, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_data_write,
+    },
+    {
+        "counter_stream", "\x04", STREAM_P2C, 23,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 13,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x04, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x0e, 0x80, 0x01, 0x04, 0x0d, 0x00, 0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 20},
+            {(const uint8_t[]){0x00, 0x01, 0x40, 0x04, 0xff, 0xff, 0xff, 0x01}, 8},
+            {(const uint8_t[]){0x01, 0x00, 0xcc, 0x00}, 4},
+        },
+        3,
+        decode_counter_stream,
+    },
+    {
+        "counter_stream", "\x04", STREAM_P2C, 247,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 13,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x04, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x80, 0x01, 0x04, 0x0d, 0x00, 0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 24},
+            {(const uint8_t[]){0x01, 0x00, 0xcc, 0x00}, 4},
+        },
+        2,
+        decode_counter_stream,
+    },
+    {
+        "counter_upload", "\x05", STREAM_C2P, 23,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 13,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x0e, 0x00, 0x01, 0x05, 0x0d, 0x00, 0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x01, 0x40, 0x04, 0xff, 0xff, 0xff, 0x01, 0x01, 0x00, 0xc8, 0x00}, 32,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x05, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_counter_upload,
+    },
+    {
+        "counter_upload", "\x05", STREAM_C2P, 247,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 13,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x00, 0x01, 0x05, 0x0d, 0x00, 0x08, 0x01, 0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x01, 0x00, 0xc8, 0x00}, 28,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x05, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_counter_upload,
+    },
+    {
+        "get_blerpc_info", "\x06", STREAM_NONE, 23,
+        NULL, 0,
+        (const uint8_t[]){0x0a, 0x0b, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x12, 0x11, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e}, 32,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x01, 0x06, 0x00, 0x00}, 11,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x25, 0x00, 0x0e, 0x80, 0x01, 0x06, 0x20, 0x00, 0x0a, 0x0b, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f}, 20},
+            {(const uint8_t[]){0x00, 0x01, 0x40, 0x10, 0x68, 0x61, 0x73, 0x68, 0x12, 0x11, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f}, 20},
+            {(const uint8_t[]){0x00, 0x02, 0x40, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e}, 11},
+        },
+        3,
+        decode_get_blerpc_info,
+    },
+    {
+        "get_blerpc_info", "\x06", STREAM_NONE, 247,
+        NULL, 0,
+        (const uint8_t[]){0x0a, 0x0b, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x12, 0x11, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e}, 32,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x01, 0x06, 0x00, 0x00}, 11,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x25, 0x00, 0x25, 0x80, 0x01, 0x06, 0x20, 0x00, 0x0a, 0x0b, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x12, 0x11, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e}, 43},
+        },
+        1,
+        decode_get_blerpc_info,
+    },
+    {
+        "conn_params", "\x07", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01}, 8,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x07, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x80, 0x01, 0x07, 0x08, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01}, 19},
+        },
+        1,
+        decode_conn_params,
+    },
+    {
+        "conn_params", "\x07", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01}, 8,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x07, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x80, 0x01, 0x07, 0x08, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01}, 19},
+        },
+        1,
+        decode_conn_params,
+    },
+    {
+        "dfu_begin", "\x08", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x00, 0x01, 0x08, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x80, 0x01, 0x08, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15},
+        },
+        1,
+        decode_dfu_begin,
+    },
+    {
+        "dfu_begin", "\x08", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x00, 0x01, 0x08, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x80, 0x01, 0x08, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15},
+        },
+        1,
+        decode_dfu_begin,
+    },
+    {
+        "dfu_chunk", "\x09", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 8,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x00, 0x01, 0x09, 0x08, 0x00, 0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 19,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x09, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_dfu_chunk,
+    },
+    {
+        "dfu_chunk", "\x09", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 8,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x00, 0x01, 0x09, 0x08, 0x00, 0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 19,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x09, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_dfu_chunk,
+    },
+    {
+        "dfu_finalize", "\x0a", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x0a, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x0a, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_dfu_finalize,
+    },
+    {
+        "dfu_finalize", "\x0a", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x0a, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x80, 0x01, 0x0a, 0x02, 0x00, 0x08, 0x01}, 13},
+        },
+        1,
+        decode_dfu_finalize,
+    },
+    {
+        "file_open", "\x0b", STREAM_NONE, 23,
+        (const uint8_t[]){0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x10, 0x01, 0x18, 0x01}, 10,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0f, 0x00, 0x0e, 0x00, 0x01, 0x0b, 0x0a, 0x00, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x10, 0x01, 0x18, 0x00, 0x01, 0x40, 0x01, 0x01}, 25,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x0b, 0x06, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 17},
+        },
+        1,
+        decode_file_open,
+    },
+    {
+        "file_open", "\x0b", STREAM_NONE, 247,
+        (const uint8_t[]){0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x10, 0x01, 0x18, 0x01}, 10,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x00, 0x01, 0x0b, 0x0a, 0x00, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x10, 0x01, 0x18, 0x01}, 21,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x0b, 0x06, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 17},
+        },
+        1,
+        decode_file_open,
+    },
+    {
+        "file_read", "\x0c", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 6,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x0c, 0x06, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x0c, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_file_read,
+    },
+    {
+        "file_read", "\x0c", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 6,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x0c, 0x06, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x0c, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_file_read,
+    },
+    {
+        "file_write", "\x0d", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x04}, 10,
+        NULL, 0,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0f, 0x00, 0x0e, 0x00, 0x01, 0x0d, 0x0a, 0x00, 0x08, 0x01, 0x10, 0x01, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x00, 0x01, 0x40, 0x01, 0x04}, 25,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x80, 0x01, 0x0d, 0x00, 0x00}, 11},
+        },
+        1,
+        decode_file_write,
+    },
+    {
+        "file_write", "\x0d", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x04}, 10,
+        NULL, 0,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x00, 0x01, 0x0d, 0x0a, 0x00, 0x08, 0x01, 0x10, 0x01, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x04}, 21,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x80, 0x01, 0x0d, 0x00, 0x00}, 11},
+        },
+        1,
+        decode_file_write,
+    },
+    {
+        "file_close", "\x0e", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x00, 0x01, 0x0e, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x80, 0x01, 0x0e, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15},
+        },
+        1,
+        decode_file_close,
+    },
+    {
+        "file_close", "\x0e", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x00, 0x01, 0x0e, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x80, 0x01, 0x0e, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15},
+        },
+        1,
+        decode_file_close,
+    },
+    {
+        "log_stream", "\x0f", STREAM_P2C, 23,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01, 0x2a, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x32, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x38, 0x01}, 27,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x00, 0x01, 0x0f, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x20, 0x00, 0x0e, 0x80, 0x01, 0x0f, 0x1b, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01, 0x2a}, 20},
+            {(const uint8_t[]){0x00, 0x01, 0x40, 0x10, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x32, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65}, 20},
+            {(const uint8_t[]){0x00, 0x02, 0x40, 0x02, 0x38, 0x01}, 6},
+            {(const uint8_t[]){0x01, 0x00, 0xcc, 0x00}, 4},
+        },
+        4,
+        decode_log_stream,
+    },
+    {
+        "log_stream", "\x0f", STREAM_P2C, 247,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01}, 4,
+        (const uint8_t[]){0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01, 0x2a, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x32, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x38, 0x01}, 27,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x00, 0x01, 0x0f, 0x04, 0x00, 0x08, 0x01, 0x10, 0x01}, 15,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x20, 0x00, 0x20, 0x80, 0x01, 0x0f, 0x1b, 0x00, 0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01, 0x2a, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x32, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x38, 0x01}, 38},
+            {(const uint8_t[]){0x01, 0x00, 0xcc, 0x00}, 4},
+        },
+        2,
+        decode_log_stream,
+    },
+    {
+        "get_rpc_stats", "\x10", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x0a, 0x0c, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01}, 14,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x10, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x13, 0x00, 0x0e, 0x80, 0x01, 0x10, 0x0e, 0x00, 0x0a, 0x0c, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x10}, 20},
+            {(const uint8_t[]){0x00, 0x01, 0x40, 0x05, 0x01, 0x18, 0x01, 0x20, 0x01}, 9},
+        },
+        2,
+        decode_get_rpc_stats,
+    },
+    {
+        "get_rpc_stats", "\x10", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x0a, 0x0c, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01}, 14,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x10, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x80, 0x01, 0x10, 0x0e, 0x00, 0x0a, 0x0c, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x10, 0x01, 0x18, 0x01, 0x20, 0x01}, 25},
+        },
+        1,
+        decode_get_rpc_stats,
+    },
+    {
+        "start_session", "\x11", STREAM_NONE, 23,
+        NULL, 0,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x01, 0x11, 0x00, 0x00}, 11,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x11, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_start_session,
+    },
+    {
+        "start_session", "\x11", STREAM_NONE, 247,
+        NULL, 0,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x01, 0x11, 0x00, 0x00}, 11,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x11, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_start_session,
+    },
+    {
+        "authenticate_session", "\x12", STREAM_NONE, 23,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x12, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x12, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_authenticate_session,
+    },
+    {
+        "authenticate_session", "\x12", STREAM_NONE, 247,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x12, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x12, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_authenticate_session,
+    },
+    {
+        "time_sync", "\x13", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x10, 0x01}, 13,
+        (const uint8_t[]){0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 11,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x0e, 0x00, 0x01, 0x13, 0x0d, 0x00, 0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x01, 0x40, 0x04, 0xff, 0x01, 0x10, 0x01}, 28,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x10, 0x00, 0x0e, 0x80, 0x01, 0x13, 0x0b, 0x00, 0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 20},
+            {(const uint8_t[]){0x00, 0x01, 0x40, 0x02, 0xff, 0x01}, 6},
+        },
+        2,
+        decode_time_sync,
+    },
+    {
+        "time_sync", "\x13", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x10, 0x01}, 13,
+        (const uint8_t[]){0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 11,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x00, 0x01, 0x13, 0x0d, 0x00, 0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x10, 0x01}, 24,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x80, 0x01, 0x13, 0x0b, 0x00, 0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 22},
+        },
+        1,
+        decode_time_sync,
+    },
+    {
+        "ping", "\x14", STREAM_NONE, 23,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x10, 0x01, 0x18, 0x01}, 10,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x14, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0f, 0x00, 0x0e, 0x80, 0x01, 0x14, 0x0a, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x10, 0x01, 0x18}, 20},
+            {(const uint8_t[]){0x00, 0x01, 0x40, 0x01, 0x01}, 5},
+        },
+        2,
+        decode_ping,
+    },
+    {
+        "ping", "\x14", STREAM_NONE, 247,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x10, 0x01, 0x18, 0x01}, 10,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x00, 0x01, 0x14, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x80, 0x01, 0x14, 0x0a, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x10, 0x01, 0x18, 0x01}, 21},
+        },
+        1,
+        decode_ping,
+    },
+    {
+        "get_capabilities", "\x15", STREAM_NONE, 23,
+        NULL, 0,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x12, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73}, 13,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x01, 0x15, 0x00, 0x00}, 11,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x0e, 0x80, 0x01, 0x15, 0x0d, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x12, 0x05, 0x6e}, 20},
+            {(const uint8_t[]){0x00, 0x01, 0x40, 0x04, 0x61, 0x6d, 0x65, 0x73}, 8},
+        },
+        2,
+        decode_get_capabilities,
+    },
+    {
+        "get_capabilities", "\x15", STREAM_NONE, 247,
+        NULL, 0,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x12, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73}, 13,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x01, 0x15, 0x00, 0x00}, 11,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x80, 0x01, 0x15, 0x0d, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04, 0x12, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73}, 24},
+        },
+        1,
+        decode_get_capabilities,
+    },
+    {
+        "get_setting", "\x16", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x16, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x16, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_get_setting,
+    },
+    {
+        "get_setting", "\x16", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01}, 2,
+        (const uint8_t[]){0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 6,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x00, 0x01, 0x16, 0x02, 0x00, 0x08, 0x01}, 13,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x80, 0x01, 0x16, 0x06, 0x00, 0x0a, 0x04, 0x01, 0x02, 0x03, 0x04}, 17},
+        },
+        1,
+        decode_get_setting,
+    },
+    {
+        "set_setting", "\x17", STREAM_NONE, 23,
+        (const uint8_t[]){0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 8,
+        NULL, 0,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x00, 0x01, 0x17, 0x08, 0x00, 0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 19,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x80, 0x01, 0x17, 0x00, 0x00}, 11},
+        },
+        1,
+        decode_set_setting,
+    },
+    {
+        "set_setting", "\x17", STREAM_NONE, 247,
+        (const uint8_t[]){0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 8,
+        NULL, 0,
+        (const uint8_t[]){0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x00, 0x01, 0x17, 0x08, 0x00, 0x08, 0x01, 0x12, 0x04, 0x01, 0x02, 0x03, 0x04}, 19,
+        (const struct conformance_bytes[]){
+            {(const uint8_t[]){0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x80, 0x01, 0x17, 0x00, 0x00}, 11},
+        },
+        1,
+        decode_set_setting,
+    },
+};
+
+int main(void)
+{
+    int failed = 0;
+    for (size_t i = 0; i < sizeof(vectors) / sizeof(vectors[0]); i++) {
+        failed += check_vector(&vectors[i]);
+    }
+    printf("%d of %zu vectors failed\n", failed, sizeof(vectors) / sizeof(vectors[0]));
+    return failed != 0;
+}
@@ -0,0 +1,126 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+/*
+ * libFuzzer target of the request path: build it with
+ * -fsanitize=fuzzer,address, generated_handlers.c, the handler
+ * implementations, generated_framing.c, nanopb and blerpc_protocol, and run
+ * with -dict=blerpc.dict on the corpus directory.
+ */
+#include "generated_handlers.h"
+#include "blerpc.pb.h"
+#include "generated_framing.h"
+#include <blerpc_protocol/command.h>
+#include <pb_decode.h>
+#include <pb_encode.h>
+#include <string.h>
+
+#ifndef FUZZ_RESP_BUF_SIZE
+#define FUZZ_RESP_BUF_SIZE 1024
+#endif
+
+/* Room for the decoded request of any command. */
+union fuzz_request {
+    blerpc_EchoRequest echo;
+    blerpc_FlashReadRequest flash_read;
+    blerpc_DataWriteRequest data_write;
+    blerpc_CounterStreamRequest counter_stream;
+    blerpc_CounterUploadRequest counter_upload;
+    blerpc_GetBlerpcInfoRequest get_blerpc_info;
+    blerpc_ConnParamsRequest conn_params;
+    blerpc_DfuBeginRequest dfu_begin;
+    blerpc_DfuChunkRequest dfu_chunk;
+    blerpc_DfuFinalizeRequest dfu_finalize;
+    blerpc_FileOpenRequest file_open;
+    blerpc_FileReadRequest file_read;
+    blerpc_FileWriteRequest file_write;
+    blerpc_FileCloseRequest file_close;
+    blerpc_LogStreamRequest log_stream;
+    blerpc_GetRpcStatsRequest get_rpc_stats;
+    blerpc_StartSessionRequest start_session;
+    blerpc_AuthenticateSessionRequest authenticate_session;
+    blerpc_TimeSyncRequest time_sync;
+    blerpc_PingRequest ping;
+    blerpc_GetCapabilitiesRequest get_capabilities;
+    blerpc_GetSettingRequest get_setting;
+    blerpc_SetSettingRequest set_setting;
+};
+
+static const struct {
+    const char *name;
+    uint8_t name_len;
+    const pb_msgdesc_t *fields;
+} requests[] = {
+    {"echo", 4, blerpc_EchoRequest_fields},
+    {"flash_read", 10, blerpc_FlashReadRequest_fields},
+    {"data_write", 10, blerpc_DataWriteRequest_fields},
+    {"counter_stream", 14, blerpc_CounterStreamRequest_fields},
+    {"counter_upload", 14, blerpc_CounterUploadRequest_fields},
+    {"get_blerpc_info", 15, blerpc_GetBlerpcInfoRequest_fields},
+    {"conn_params", 11, blerpc_ConnParamsRequest_fields},
+    {"dfu_begin", 9, blerpc_DfuBeginRequest_fields},
+    {"dfu_chunk", 9, blerpc_DfuChunkRequest_fields},
+    {"dfu_finalize", 12, blerpc_DfuFinalizeRequest_fields},
+    {"file_open", 9, blerpc_FileOpenRequest_fields},
+    {"file_read", 9, blerpc_FileReadRequest_fields},
+    {"file_write", 10, blerpc_FileWriteRequest_fields},
+    {"file_close", 10, blerpc_FileCloseRequest_fields},
+    {"log_stream", 10, blerpc_LogStreamRequest_fields},
+    {"get_rpc_stats", 13, blerpc_GetRpcStatsRequest_fields},
+    {"start_session", 13, blerpc_StartSessionRequest_fields},
+    {"authenticate_session", 20, blerpc_AuthenticateSessionRequest_fields},
+    {"time_sync", 9, blerpc_TimeSyncRequest_fields},
+    {"ping", 4, blerpc_PingRequest_fields},
+    {"get_capabilities", 16, blerpc_GetCapabilitiesRequest_fields},
+    {"get_setting", 11, blerpc_GetSettingRequest_fields},
+    {"set_setting", 11, blerpc_SetSettingRequest_fields},
+};
+
+static void dispatch(const uint8_t *data, size_t len)
+{
+    static uint8_t resp_buf[FUZZ_RESP_BUF_SIZE];
+    struct command_packet cmd;
+    if (command_parse(data, len, &cmd) != 0 || cmd.cmd_type != COMMAND_TYPE_REQUEST) {
+        return;
+    }
+
+    for (size_t i = 0; i < sizeof(requests) / sizeof(requests[0]); i++) {
+        if (requests[i].name_len != cmd.cmd_name_len ||
+            memcmp(requests[i].name, cmd.cmd_name, cmd.cmd_name_len) != 0) {
+            continue;
+        }
+        union fuzz_request req;
+        memset(&req, 0, sizeof(req));
+        pb_istream_t stream = pb_istream_from_buffer(cmd.data, cmd.data_len);
+        pb_decode(&stream, requests[i].fields, &req);
+#ifdef PB_ENABLE_MALLOC
+        pb_release(requests[i].fields, &req);
+#endif
+    }
+
+    command_handler_fn handler = handlers_lookup(cmd.cmd_name, cmd.cmd_name_len);
+    if (handler == NULL) return;
+    pb_ostream_t sizing = PB_OSTREAM_SIZING;
+    if (handler(cmd.data, cmd.data_len, &sizing) != 0 ||
+        sizing.bytes_written > sizeof(resp_buf)) {
+        return;
+    }
+    pb_ostream_t out = pb_ostream_from_buffer(resp_buf, sizeof(resp_buf));
+    handler(cmd.data, cmd.data_len, &out);
+}
+
+int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size)
+{
+    dispatch(data, size);
+
+    static struct blerpc_reassembler r;
+    blerpc_reassembler_reset(&r);
+    while (size > 0) {
+        size_t n = data[0] < size - 1 ? data[0] : size - 1;
+        struct blerpc_frame_message msg;
+        if (blerpc_reassembler_feed(&r, data + 1, n, &msg) > 0) {
+            dispatch(msg.data, msg.len);
+        }
+        data += 1 + n;
+        size -= 1 + n;
+    }
+    return 0;
+}
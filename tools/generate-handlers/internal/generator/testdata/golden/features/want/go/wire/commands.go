@@ -0,0 +1,38 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+// Package blerpcwire lists the blerpc commands for the shared wire package.
+package blerpcwire
+
+import "github.com/tdaira/blerpc/go/wire"
+
+// Commands describes every command in schema order.
+var Commands = []wire.CommandSpec{
+	{Name: "echo", Request: "EchoRequest", Response: "EchoResponse", Stream: wire.Unary},
+	{Name: "flash_read", Request: "FlashReadRequest", Response: "FlashReadResponse", Stream: wire.Unary},
+	{Name: "data_write", Request: "DataWriteRequest", Response: "DataWriteResponse", Stream: wire.Unary},
+	{Name: "counter_stream", Request: "CounterStreamRequest", Response: "CounterStreamResponse", Stream: wire.StreamP2C},
+	{Name: "counter_upload", Request: "CounterUploadRequest", Response: "CounterUploadResponse", Stream: wire.StreamC2P},
+	{Name: "get_blerpc_info", Request: "GetBlerpcInfoRequest", Response: "GetBlerpcInfoResponse", Stream: wire.Unary},
+	{Name: "conn_params", Request: "ConnParamsRequest", Response: "ConnParamsResponse", Stream: wire.Unary},
+	{Name: "dfu_begin", Request: "DfuBeginRequest", Response: "DfuBeginResponse", Stream: wire.Unary},
+	{Name: "dfu_chunk", Request: "DfuChunkRequest", Response: "DfuChunkResponse", Stream: wire.Unary},
+	{Name: "dfu_finalize", Request: "DfuFinalizeRequest", Response: "DfuFinalizeResponse", Stream: wire.Unary},
+	{Name: "file_open", Request: "FileOpenRequest", Response: "FileOpenResponse", Stream: wire.Unary},
+	{Name: "file_read", Request: "FileReadRequest", Response: "FileReadResponse", Stream: wire.Unary},
+	{Name: "file_write", Request: "FileWriteRequest", Response: "FileWriteResponse", Stream: wire.Unary},
+	{Name: "file_close", Request: "FileCloseRequest", Response: "FileCloseResponse", Stream: wire.Unary},
+	{Name: "log_stream", Request: "LogStreamRequest", Response: "LogStreamResponse", Stream: wire.StreamP2C},
+	{Name: "get_rpc_stats", Request: "GetRpcStatsRequest", Response: "GetRpcStatsResponse", Stream: wire.Unary},
+	{Name: "start_session", Request: "StartSessionRequest", Response: "StartSessionResponse", Stream: wire.Unary},
+	{Name: "authenticate_session", Request: "AuthenticateSessionRequest", Response: "AuthenticateSessionResponse", Stream: wire.Unary},
+	{Name: "time_sync", Request: "TimeSyncRequest", Response: "TimeSyncResponse", Stream: wire.Unary},
+	{Name: "ping", Request: "PingRequest", Response: "PingResponse", Stream: wire.Unary},
+	{Name: "get_capabilities", Request: "GetCapabilitiesRequest", Response: "GetCapabilitiesResponse", Stream: wire.Unary},
+	{Name: "get_setting", Request: "GetSettingRequest", Response: "GetSettingResponse", Stream: wire.Unary},
+	{Name: "set_setting", Request: "SetSettingRequest", Response: "SetSettingResponse", Stream: wire.Unary},
+}
+
+// NewRegistry returns a registry of Commands.
+func NewRegistry() (*wire.Registry, error) {
+	return wire.NewRegistry(Commands)
+}
@@ -0,0 +1,349 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_handlers.hpp"
+#include <cstring>
+
+namespace blerpc_handlers {
+
+__attribute__((weak))
+int handle_echo(::EmbeddedProto::ReadBufferInterface &req_buf,
+                ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    EchoRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    EchoResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_flash_read(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    FlashReadRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    FlashReadResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_data_write(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    DataWriteRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    DataWriteResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_counter_stream(::EmbeddedProto::ReadBufferInterface &req_buf,
+                          ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    CounterStreamRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    CounterStreamResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_counter_upload(::EmbeddedProto::ReadBufferInterface &req_buf,
+                          ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    CounterUploadRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    CounterUploadResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_get_blerpc_info(::EmbeddedProto::ReadBufferInterface &req_buf,
+                           ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    GetBlerpcInfoRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    GetBlerpcInfoResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_conn_params(::EmbeddedProto::ReadBufferInterface &req_buf,
+                       ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    ConnParamsRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    ConnParamsResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_dfu_begin(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    DfuBeginRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    DfuBeginResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_dfu_chunk(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    DfuChunkRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    DfuChunkResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_dfu_finalize(::EmbeddedProto::ReadBufferInterface &req_buf,
+                        ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    DfuFinalizeRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    DfuFinalizeResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_open(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    FileOpenRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    FileOpenResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_read(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    FileReadRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    FileReadResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_write(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    FileWriteRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    FileWriteResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_close(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    FileCloseRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    FileCloseResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_log_stream(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    LogStreamRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    LogStreamResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_get_rpc_stats(::EmbeddedProto::ReadBufferInterface &req_buf,
+                         ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    GetRpcStatsRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    GetRpcStatsResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_start_session(::EmbeddedProto::ReadBufferInterface &req_buf,
+                         ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    StartSessionRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    StartSessionResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_authenticate_session(::EmbeddedProto::ReadBufferInterface &req_buf,
+                                ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    AuthenticateSessionRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    AuthenticateSessionResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_time_sync(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    TimeSyncRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    TimeSyncResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_ping(::EmbeddedProto::ReadBufferInterface &req_buf,
+                ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    PingRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    PingResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_get_capabilities(::EmbeddedProto::ReadBufferInterface &req_buf,
+                            ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    GetCapabilitiesRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    GetCapabilitiesResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_get_setting(::EmbeddedProto::ReadBufferInterface &req_buf,
+                       ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    GetSettingRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    GetSettingResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_set_setting(::EmbeddedProto::ReadBufferInterface &req_buf,
+                       ::EmbeddedProto::WriteBufferInterface &resp_buf)
+{
+    SetSettingRequest req;
+    if (req.deserialize(req_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+
+    SetSettingResponse resp;
+    if (resp.serialize(resp_buf) != ::EmbeddedProto::Error::NO_ERRORS) return -1;
+    return 0;
+}
+
+static const handler_entry handler_table[] = {
+    {"echo", 4, handle_echo},
+    {"flash_read", 10, handle_flash_read},
+    {"data_write", 10, handle_data_write},
+    {"counter_stream", 14, handle_counter_stream},
+    {"counter_upload", 14, handle_counter_upload},
+    {"get_blerpc_info", 15, handle_get_blerpc_info},
+    {"conn_params", 11, handle_conn_params},
+    {"dfu_begin", 9, handle_dfu_begin},
+    {"dfu_chunk", 9, handle_dfu_chunk},
+    {"dfu_finalize", 12, handle_dfu_finalize},
+    {"file_open", 9, handle_file_open},
+    {"file_read", 9, handle_file_read},
+    {"file_write", 10, handle_file_write},
+    {"file_close", 10, handle_file_close},
+    {"log_stream", 10, handle_log_stream},
+    {"get_rpc_stats", 13, handle_get_rpc_stats},
+    {"start_session", 13, handle_start_session},
+    {"authenticate_session", 20, handle_authenticate_session},
+    {"time_sync", 9, handle_time_sync},
+    {"ping", 4, handle_ping},
+    {"get_capabilities", 16, handle_get_capabilities},
+    {"get_setting", 11, handle_get_setting},
+    {"set_setting", 11, handle_set_setting},
+};
+
+/* ID of each command, in handler table order. */
+static const uint8_t command_ids[] = {
+    1, /* echo */
+    2, /* flash_read */
+    3, /* data_write */
+    4, /* counter_stream */
+    5, /* counter_upload */
+    6, /* get_blerpc_info */
+    7, /* conn_params */
+    8, /* dfu_begin */
+    9, /* dfu_chunk */
+    10, /* dfu_finalize */
+    11, /* file_open */
+    12, /* file_read */
+    13, /* file_write */
+    14, /* file_close */
+    15, /* log_stream */
+    16, /* get_rpc_stats */
+    17, /* start_session */
+    18, /* authenticate_session */
+    19, /* time_sync */
+    20, /* ping */
+    21, /* get_capabilities */
+    22, /* get_setting */
+    23, /* set_setting */
+};
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len)
+{
+    for (size_t i = 0; i < sizeof(handler_table) / sizeof(handler_table[0]); i++) {
+        const handler_entry &entry = handler_table[i];
+        /* A one-byte name carries the command ID. */
+        if ((name_len == 1 && static_cast<uint8_t>(name[0]) == command_ids[i]) ||
+            (entry.name_len == name_len && std::memcmp(entry.name, name, name_len) == 0)) {
+            return entry.handler;
+        }
+    }
+    return nullptr;
+}
+
+} // namespace blerpc_handlers
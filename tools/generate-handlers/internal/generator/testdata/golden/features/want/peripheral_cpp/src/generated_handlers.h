@@ -0,0 +1,150 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_HANDLERS_HPP
+#define BLERPC_GENERATED_HANDLERS_HPP
+
+#include <cstddef>
+#include <cstdint>
+#include <ReadBufferInterface.h>
+#include <WriteBufferInterface.h>
+#include "blerpc.h"
+
+/* Lengths for string, bytes and repeated fields without nanopb size options */
+#ifndef BLERPC_EP_DEFAULT_LENGTH
+#define BLERPC_EP_DEFAULT_LENGTH 64
+#endif
+#ifndef BLERPC_EP_DEFAULT_REP_LENGTH
+#define BLERPC_EP_DEFAULT_REP_LENGTH 8
+#endif
+
+namespace blerpc_handlers {
+
+using EchoRequest = ::blerpc::EchoRequest<257>;
+using EchoResponse = ::blerpc::EchoResponse<257>;
+using FlashReadRequest = ::blerpc::FlashReadRequest;
+using FlashReadResponse = ::blerpc::FlashReadResponse<BLERPC_EP_DEFAULT_LENGTH>;
+using DataWriteRequest = ::blerpc::DataWriteRequest<BLERPC_EP_DEFAULT_LENGTH>;
+using DataWriteResponse = ::blerpc::DataWriteResponse;
+using CounterStreamRequest = ::blerpc::CounterStreamRequest;
+using CounterStreamResponse = ::blerpc::CounterStreamResponse;
+using CounterUploadRequest = ::blerpc::CounterUploadRequest;
+using CounterUploadResponse = ::blerpc::CounterUploadResponse;
+using GetBlerpcInfoRequest = ::blerpc::GetBlerpcInfoRequest;
+using GetBlerpcInfoResponse = ::blerpc::GetBlerpcInfoResponse<BLERPC_EP_DEFAULT_LENGTH, BLERPC_EP_DEFAULT_LENGTH>;
+using ConnParamsRequest = ::blerpc::ConnParamsRequest;
+using ConnParamsResponse = ::blerpc::ConnParamsResponse;
+using DfuBeginRequest = ::blerpc::DfuBeginRequest;
+using DfuBeginResponse = ::blerpc::DfuBeginResponse;
+using DfuChunkRequest = ::blerpc::DfuChunkRequest<BLERPC_EP_DEFAULT_LENGTH>;
+using DfuChunkResponse = ::blerpc::DfuChunkResponse;
+using DfuFinalizeRequest = ::blerpc::DfuFinalizeRequest;
+using DfuFinalizeResponse = ::blerpc::DfuFinalizeResponse;
+using FileOpenRequest = ::blerpc::FileOpenRequest<BLERPC_EP_DEFAULT_LENGTH>;
+using FileOpenResponse = ::blerpc::FileOpenResponse;
+using FileReadRequest = ::blerpc::FileReadRequest;
+using FileReadResponse = ::blerpc::FileReadResponse<BLERPC_EP_DEFAULT_LENGTH>;
+using FileWriteRequest = ::blerpc::FileWriteRequest<BLERPC_EP_DEFAULT_LENGTH>;
+using FileWriteResponse = ::blerpc::FileWriteResponse;
+using FileCloseRequest = ::blerpc::FileCloseRequest;
+using FileCloseResponse = ::blerpc::FileCloseResponse;
+using LogStreamRequest = ::blerpc::LogStreamRequest;
+using LogStreamResponse = ::blerpc::LogStreamResponse<BLERPC_EP_DEFAULT_LENGTH, BLERPC_EP_DEFAULT_LENGTH>;
+using GetRpcStatsRequest = ::blerpc::GetRpcStatsRequest;
+using GetRpcStatsResponse = ::blerpc::GetRpcStatsResponse<BLERPC_EP_DEFAULT_REP_LENGTH, BLERPC_EP_DEFAULT_LENGTH>;
+using StartSessionRequest = ::blerpc::StartSessionRequest;
+using StartSessionResponse = ::blerpc::StartSessionResponse<BLERPC_EP_DEFAULT_LENGTH>;
+using AuthenticateSessionRequest = ::blerpc::AuthenticateSessionRequest<BLERPC_EP_DEFAULT_LENGTH>;
+using AuthenticateSessionResponse = ::blerpc::AuthenticateSessionResponse<BLERPC_EP_DEFAULT_LENGTH>;
+using TimeSyncRequest = ::blerpc::TimeSyncRequest;
+using TimeSyncResponse = ::blerpc::TimeSyncResponse;
+using PingRequest = ::blerpc::PingRequest<BLERPC_EP_DEFAULT_LENGTH>;
+using PingResponse = ::blerpc::PingResponse<BLERPC_EP_DEFAULT_LENGTH>;
+using GetCapabilitiesRequest = ::blerpc::GetCapabilitiesRequest;
+using GetCapabilitiesResponse = ::blerpc::GetCapabilitiesResponse<BLERPC_EP_DEFAULT_LENGTH, BLERPC_EP_DEFAULT_REP_LENGTH, BLERPC_EP_DEFAULT_LENGTH>;
+using GetSettingRequest = ::blerpc::GetSettingRequest;
+using GetSettingResponse = ::blerpc::GetSettingResponse<BLERPC_EP_DEFAULT_LENGTH>;
+using SetSettingRequest = ::blerpc::SetSettingRequest<BLERPC_EP_DEFAULT_LENGTH>;
+using SetSettingResponse = ::blerpc::SetSettingResponse;
+
+using command_handler_fn = int (*)(::EmbeddedProto::ReadBufferInterface &req_buf,
+                                   ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+struct handler_entry {
+    const char *name;
+    uint8_t name_len;
+    command_handler_fn handler;
+};
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len);
+
+int handle_echo(::EmbeddedProto::ReadBufferInterface &req_buf,
+                ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_flash_read(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_data_write(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_counter_stream(::EmbeddedProto::ReadBufferInterface &req_buf,
+                          ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_counter_upload(::EmbeddedProto::ReadBufferInterface &req_buf,
+                          ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_get_blerpc_info(::EmbeddedProto::ReadBufferInterface &req_buf,
+                           ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_conn_params(::EmbeddedProto::ReadBufferInterface &req_buf,
+                       ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_dfu_begin(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_dfu_chunk(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_dfu_finalize(::EmbeddedProto::ReadBufferInterface &req_buf,
+                        ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_file_open(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_file_read(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_file_write(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_file_close(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_log_stream(::EmbeddedProto::ReadBufferInterface &req_buf,
+                      ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_get_rpc_stats(::EmbeddedProto::ReadBufferInterface &req_buf,
+                         ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_start_session(::EmbeddedProto::ReadBufferInterface &req_buf,
+                         ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_authenticate_session(::EmbeddedProto::ReadBufferInterface &req_buf,
+                                ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_time_sync(::EmbeddedProto::ReadBufferInterface &req_buf,
+                     ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_ping(::EmbeddedProto::ReadBufferInterface &req_buf,
+                ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_get_capabilities(::EmbeddedProto::ReadBufferInterface &req_buf,
+                            ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_get_setting(::EmbeddedProto::ReadBufferInterface &req_buf,
+                       ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+int handle_set_setting(::EmbeddedProto::ReadBufferInterface &req_buf,
+                       ::EmbeddedProto::WriteBufferInterface &resp_buf);
+
+} // namespace blerpc_handlers
+
+#endif /* BLERPC_GENERATED_HANDLERS_HPP */
@@ -0,0 +1,22 @@
+# Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT
+#
+# Generated C sources for Make builds. Include it and add the variables to
+# the build:
+#
+#   include path/to/generated.mk
+#   SRCS += $(BLERPC_HANDLERS_SRCS)
+#   CFLAGS += $(addprefix -I,$(BLERPC_HANDLERS_INC_DIRS))
+
+BLERPC_HANDLERS_DIR := $(patsubst %/,%,$(dir $(lastword $(MAKEFILE_LIST))))
+
+BLERPC_HANDLERS_SRCS := \
+	$(BLERPC_HANDLERS_DIR)/src/generated_handlers.c \
+	$(BLERPC_HANDLERS_DIR)/src/generated_gatt_service.c \
+	$(BLERPC_HANDLERS_DIR)/src/generated_advertising.c \
+	$(BLERPC_HANDLERS_DIR)/src/generated_events.c \
+	$(BLERPC_HANDLERS_DIR)/src/generated_framing.c \
+	$(BLERPC_HANDLERS_DIR)/src/generated_batch.c \
+	$(BLERPC_HANDLERS_DIR)/src/generated_compression.c
+
+BLERPC_HANDLERS_INC_DIRS := \
+	$(BLERPC_HANDLERS_DIR)/src
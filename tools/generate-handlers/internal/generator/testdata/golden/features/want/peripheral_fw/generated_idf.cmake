@@ -0,0 +1,22 @@
+# Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT
+#
+# Generated C sources for an ESP-IDF component. Include it from the
+# component CMakeLists.txt before registering the component:
+#
+#   include(path/to/generated_idf.cmake)
+#   idf_component_register(SRCS main.c ${BLERPC_HANDLERS_SRCS}
+#                          INCLUDE_DIRS . ${BLERPC_HANDLERS_INCLUDE_DIRS})
+
+set(BLERPC_HANDLERS_SRCS
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_handlers.c
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_gatt_service.c
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_advertising.c
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_events.c
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_framing.c
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_batch.c
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_compression.c
+)
+
+set(BLERPC_HANDLERS_INCLUDE_DIRS
+    ${CMAKE_CURRENT_LIST_DIR}/src
+)
@@ -0,0 +1,20 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_advertising.h"
+#include <pb_encode.h>
+
+static int adv_encode(uint8_t type, const pb_msgdesc_t *fields, const void *msg,
+                      uint8_t *buf, size_t buf_size)
+{
+    if (buf_size < 3) return -1;
+    buf[0] = BLERPC_ADV_COMPANY_ID & 0xff;
+    buf[1] = BLERPC_ADV_COMPANY_ID >> 8;
+    buf[2] = type;
+    pb_ostream_t stream = pb_ostream_from_buffer(buf + 3, buf_size - 3);
+    if (!pb_encode(&stream, fields, msg)) return -1;
+    return (int)(3 + stream.bytes_written);
+}
+
+int blerpc_adv_encode_sensor_state(const blerpc_SensorState *msg, uint8_t *buf, size_t buf_size)
+{
+    return adv_encode(BLERPC_ADV_SENSOR_STATE_TYPE, blerpc_SensorState_fields, msg, buf, buf_size);
+}
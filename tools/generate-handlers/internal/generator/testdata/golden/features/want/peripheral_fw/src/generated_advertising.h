@@ -0,0 +1,33 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_ADVERTISING_H
+#define BLERPC_GENERATED_ADVERTISING_H
+
+#include <stddef.h>
+#include <stdint.h>
+#include "blerpc.pb.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Bluetooth SIG company identifier leading the manufacturer data. */
+#define BLERPC_ADV_COMPANY_ID 0xffff
+
+/* Advertisement types, and the largest manufacturer data of each,
+ * company identifier included. */
+#define BLERPC_ADV_SENSOR_STATE_TYPE 1
+#define BLERPC_ADV_SENSOR_STATE_MAX_SIZE 20
+
+/*
+ * Encode msg as manufacturer specific data: company identifier (little
+ * endian), advertisement type, protobuf-encoded message. Return the data
+ * length, or -1 if buf is too small. Pass the data to
+ * BT_DATA(BT_DATA_MANUFACTURER_DATA, buf, len).
+ */
+int blerpc_adv_encode_sensor_state(const blerpc_SensorState *msg, uint8_t *buf, size_t buf_size);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_ADVERTISING_H */
@@ -0,0 +1,147 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_handlers.h"
+#include <pb_encode.h>
+#include <stdbool.h>
+#include <string.h>
+
+/* Commands a batch may carry: the unary commands whose requests carry no
+ * replay counter or session token. */
+static bool batchable(const char *name, uint8_t name_len)
+{
+    static const struct handler_entry commands[] = {
+        {"echo", 4, NULL},
+        {"data_write", 10, NULL},
+        {"get_blerpc_info", 15, NULL},
+        {"conn_params", 11, NULL},
+        {"dfu_begin", 9, NULL},
+        {"dfu_chunk", 9, NULL},
+        {"dfu_finalize", 12, NULL},
+        {"file_open", 9, NULL},
+        {"file_read", 9, NULL},
+        {"file_write", 10, NULL},
+        {"file_close", 10, NULL},
+        {"get_rpc_stats", 13, NULL},
+        {"start_session", 13, NULL},
+        {"authenticate_session", 20, NULL},
+        {"time_sync", 9, NULL},
+        {"ping", 4, NULL},
+        {"get_capabilities", 16, NULL},
+        {"get_setting", 11, NULL},
+        {"set_setting", 11, NULL},
+    };
+    static const uint8_t command_ids[] = {
+        BLERPC_CMD_ID_ECHO,
+        BLERPC_CMD_ID_DATA_WRITE,
+        BLERPC_CMD_ID_GET_BLERPC_INFO,
+        BLERPC_CMD_ID_CONN_PARAMS,
+        BLERPC_CMD_ID_DFU_BEGIN,
+        BLERPC_CMD_ID_DFU_CHUNK,
+        BLERPC_CMD_ID_DFU_FINALIZE,
+        BLERPC_CMD_ID_FILE_OPEN,
+        BLERPC_CMD_ID_FILE_READ,
+        BLERPC_CMD_ID_FILE_WRITE,
+        BLERPC_CMD_ID_FILE_CLOSE,
+        BLERPC_CMD_ID_GET_RPC_STATS,
+        BLERPC_CMD_ID_START_SESSION,
+        BLERPC_CMD_ID_AUTHENTICATE_SESSION,
+        BLERPC_CMD_ID_TIME_SYNC,
+        BLERPC_CMD_ID_PING,
+        BLERPC_CMD_ID_GET_CAPABILITIES,
+        BLERPC_CMD_ID_GET_SETTING,
+        BLERPC_CMD_ID_SET_SETTING,
+    };
+    size_t i;
+    for (i = 0; i < sizeof(commands) / sizeof(commands[0]); i++) {
+        /* A one-byte name carries the command ID. */
+        if ((name_len == 1 && (uint8_t)name[0] == command_ids[i]) ||
+            (commands[i].name_len == name_len &&
+             memcmp(commands[i].name, name, name_len) == 0)) {
+            return true;
+        }
+    }
+    return false;
+}
+
+/* Responses of the last batch run, and its request. */
+static uint8_t resp_buf[BLERPC_BATCH_BUF_SIZE];
+static size_t resp_len;
+static const uint8_t *resp_req;
+static size_t resp_req_len;
+
+/* Appends a response of len bytes, written after its length. */
+static void put_response(size_t len)
+{
+    resp_buf[resp_len] = (uint8_t)(len & 0xFF);
+    resp_buf[resp_len + 1] = (uint8_t)(len >> 8);
+    resp_len += 2 + len;
+}
+
+/* Appends an error response; false when it does not fit. */
+static bool put_error(uint32_t status)
+{
+    if (sizeof(resp_buf) - resp_len < 2) return false;
+    pb_ostream_t out = pb_ostream_from_buffer(resp_buf + resp_len + 2,
+                                              sizeof(resp_buf) - resp_len - 2);
+    if (blerpc_return_error(&out, status) != 0) return false;
+    put_response(out.bytes_written);
+    return true;
+}
+
+/* Runs the calls of a batch request into resp_buf. Like the dispatcher,
+ * sizes each response before encoding it. Returns -1 for a malformed
+ * request; calls left over when resp_buf is full get no response. */
+static int run_batch(const uint8_t *req_data, size_t req_len)
+{
+    size_t pos = 0;
+    resp_len = 0;
+    while (pos < req_len) {
+        uint8_t name_len = req_data[pos];
+        if (req_len - pos < 3u + name_len) return -1;
+        const char *name = (const char *)req_data + pos + 1;
+        size_t data_len = req_data[pos + 1 + name_len] |
+                          (size_t)req_data[pos + 2 + name_len] << 8;
+        pos += 3u + name_len;
+        if (req_len - pos < data_len) return -1;
+        const uint8_t *data = req_data + pos;
+        pos += data_len;
+
+        command_handler_fn handler = NULL;
+        if (batchable(name, name_len)) {
+            handler = handlers_lookup(name, name_len);
+        }
+        uint32_t status = BLERPC_STATUS_UNIMPLEMENTED;
+        if (handler != NULL) {
+            pb_ostream_t sizing = PB_OSTREAM_SIZING;
+            int rc = handler(data, data_len, &sizing);
+            if (rc == 0 && sizeof(resp_buf) - resp_len >= 2 &&
+                sizing.bytes_written <= sizeof(resp_buf) - resp_len - 2) {
+                pb_ostream_t out = pb_ostream_from_buffer(resp_buf + resp_len + 2,
+                                                          sizing.bytes_written);
+                rc = handler(data, data_len, &out);
+                if (rc == 0) {
+                    put_response(out.bytes_written);
+                    continue;
+                }
+            }
+            status = rc == 0 || rc == HANDLER_BUSY ? BLERPC_STATUS_RESOURCE_EXHAUSTED
+                                                   : BLERPC_STATUS_INTERNAL;
+        }
+        if (!put_error(status)) break;
+    }
+    return 0;
+}
+
+int blerpc_batch_handler(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream)
+{
+    /* The dispatcher calls a handler twice, to size the response and to
+     * encode it. The calls run on the first, sizing pass, and the second
+     * writes the responses collected then. */
+    bool sizing = ostream->callback == NULL;
+    if (sizing || req_data != resp_req || req_len != resp_req_len) {
+        if (run_batch(req_data, req_len) != 0) return -1;
+    }
+    resp_req = sizing ? req_data : NULL;
+    resp_req_len = sizing ? req_len : 0;
+    return pb_write(ostream, resp_buf, resp_len) ? 0 : -1;
+}
@@ -0,0 +1,127 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_handlers.h"
+#include <pb_encode.h>
+#include <stdbool.h>
+#include <string.h>
+
+/* Flags of the envelope: the data is compressed; the response may be. */
+#define FLAG_COMPRESSED 0x01
+#define FLAG_ACCEPT_COMPRESSED 0x02
+
+/* Algorithm of a compressed command; NONE for any other. */
+static enum blerpc_compression compression_of(const char *name, uint8_t name_len)
+{
+    static const struct handler_entry commands[] = {
+        {"echo", 4, NULL},
+        {"file_read", 9, NULL},
+    };
+    static const enum blerpc_compression algorithms[] = {
+        BLERPC_COMPRESSION_DEFLATE,
+        BLERPC_COMPRESSION_HEATSHRINK,
+    };
+    static const uint8_t command_ids[] = {
+        BLERPC_CMD_ID_ECHO,
+        BLERPC_CMD_ID_FILE_READ,
+    };
+    size_t i;
+    for (i = 0; i < sizeof(commands) / sizeof(commands[0]); i++) {
+        /* A one-byte name carries the command ID. */
+        if ((name_len == 1 && (uint8_t)name[0] == command_ids[i]) ||
+            (commands[i].name_len == name_len &&
+             memcmp(commands[i].name, name, name_len) == 0)) {
+            return algorithms[i];
+        }
+    }
+    return BLERPC_COMPRESSION_NONE;
+}
+
+static uint8_t req_buf[BLERPC_COMPRESSION_BUF_SIZE];
+static uint8_t plain_buf[BLERPC_COMPRESSION_BUF_SIZE];
+static uint8_t packed_buf[BLERPC_COMPRESSION_BUF_SIZE];
+
+/* Response of the last call run, and its request. */
+static uint8_t resp_flags;
+static const uint8_t *resp_data;
+static size_t resp_len;
+static const uint8_t *resp_req;
+static size_t resp_req_len;
+
+/* Sets the response to an error carrying status. */
+static int set_error(uint32_t status)
+{
+    pb_ostream_t out = pb_ostream_from_buffer(plain_buf, sizeof(plain_buf));
+    if (blerpc_return_error(&out, status) != 0) return -1;
+    resp_flags = 0;
+    resp_data = plain_buf;
+    resp_len = out.bytes_written;
+    return 0;
+}
+
+/* Runs the call of an envelope into resp_data. Returns -1 for a malformed
+ * envelope, or what the handler returns when it fails, for the dispatcher
+ * to answer as it would a plain call. */
+static int run_compressed(const uint8_t *req_data, size_t req_len)
+{
+    if (req_len < 2 || req_len - 2 < req_data[0]) return -1;
+    uint8_t name_len = req_data[0];
+    const char *name = (const char *)req_data + 1;
+    uint8_t flags = req_data[1 + name_len];
+    const uint8_t *data = req_data + 2 + name_len;
+    size_t data_len = req_len - 2 - name_len;
+
+    enum blerpc_compression algorithm = compression_of(name, name_len);
+    command_handler_fn handler = NULL;
+    if (algorithm != BLERPC_COMPRESSION_NONE) {
+        handler = handlers_lookup(name, name_len);
+    }
+    if (handler == NULL) return set_error(BLERPC_STATUS_UNIMPLEMENTED);
+    if (flags & FLAG_COMPRESSED) {
+        if (blerpc_decompress(algorithm, data, data_len, req_buf, sizeof(req_buf),
+                               &data_len) != 0) {
+            return set_error(BLERPC_STATUS_INVALID_ARGUMENT);
+        }
+        data = req_buf;
+    }
+
+    pb_ostream_t sizing = PB_OSTREAM_SIZING;
+    int rc = handler(data, data_len, &sizing);
+    if (rc != 0) return rc;
+    if (sizing.bytes_written > sizeof(plain_buf)) return set_error(BLERPC_STATUS_RESOURCE_EXHAUSTED);
+    pb_ostream_t out = pb_ostream_from_buffer(plain_buf, sizing.bytes_written);
+    rc = handler(data, data_len, &out);
+    if (rc != 0) return rc;
+    resp_flags = 0;
+    resp_data = plain_buf;
+    resp_len = out.bytes_written;
+
+    size_t packed_len;
+    if ((flags & FLAG_ACCEPT_COMPRESSED) &&
+        blerpc_compress(algorithm, plain_buf, resp_len, packed_buf, sizeof(packed_buf),
+                         &packed_len) == 0 &&
+        packed_len < resp_len) {
+        resp_flags = FLAG_COMPRESSED;
+        resp_data = packed_buf;
+        resp_len = packed_len;
+    }
+    return 0;
+}
+
+int blerpc_compressed_handler(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    /* The dispatcher calls a handler twice, to size the response and to
+     * encode it. The call runs on the first, sizing pass, and the second
+     * writes the response kept then. */
+    bool sizing = ostream->callback == NULL;
+    if (sizing || req_data != resp_req || req_len != resp_req_len) {
+        int rc = run_compressed(req_data, req_len);
+        if (rc != 0) {
+            resp_req = NULL;
+            return rc;
+        }
+    }
+    resp_req = sizing ? req_data : NULL;
+    resp_req_len = sizing ? req_len : 0;
+    if (!pb_write(ostream, &resp_flags, 1)) return -1;
+    return pb_write(ostream, resp_data, resp_len) ? 0 : -1;
+}
@@ -0,0 +1,20 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_events.h"
+#include <pb_encode.h>
+
+__attribute__((weak))
+int blerpc_event_write(const char *name, const uint8_t *data, size_t len)
+{
+    (void)name;
+    (void)data;
+    (void)len;
+    return -1;
+}
+
+int blerpc_notify_button_event(const blerpc_ButtonEvent *event)
+{
+    uint8_t buf[blerpc_ButtonEvent_size];
+    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+    if (!pb_encode(&out, blerpc_ButtonEvent_fields, event)) return -1;
+    return blerpc_event_write("button_event", buf, out.bytes_written);
+}
@@ -0,0 +1,28 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_EVENTS_H
+#define BLERPC_GENERATED_EVENTS_H
+
+#include <stddef.h>
+#include <stdint.h>
+#include "blerpc.pb.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/*
+ * Notify an encoded event to the central as a response named name. The
+ * weak default returns -1; the firmware provides it, writing to the notify
+ * characteristic like a stream response. Return 0 on success.
+ */
+int blerpc_event_write(const char *name, const uint8_t *data, size_t len);
+
+/* Encode an event and notify it. Return 0, or -1 if encoding or the write
+ * fails. */
+int blerpc_notify_button_event(const blerpc_ButtonEvent *event);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_EVENTS_H */
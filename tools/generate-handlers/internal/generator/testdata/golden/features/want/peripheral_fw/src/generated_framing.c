@@ -0,0 +1,192 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_framing.h"
+#include <string.h>
+
+_Static_assert(BLERPC_FRAMING_REASSEMBLY_SIZE <= UINT16_MAX,
+               "a frame header counts at most 65535 message bytes");
+_Static_assert(BLERPC_FRAMING_MAX_MTU > BLERPC_FRAME_ATT_OVERHEAD + BLERPC_FRAME_FIRST_HEADER_SIZE,
+               "BLERPC_FRAMING_MAX_MTU leaves no room for data");
+
+/* CRC-32 (IEEE 802.3) of len bytes of data. */
+static uint32_t frame_crc32(const uint8_t *data, size_t len)
+{
+    uint32_t c = 0xFFFFFFFF;
+    for (size_t i = 0; i < len; i++) {
+        c ^= data[i];
+        for (int k = 0; k < 8; k++) {
+            c = (c >> 1) ^ (0xEDB88320 & (0 - (c & 1)));
+        }
+    }
+    return ~c;
+}
+
+/* Whether the CRC-32 ending the len bytes of buf matches the bytes ahead. */
+static bool crc_matches(const uint8_t *buf, size_t len)
+{
+    size_t n = len - BLERPC_FRAME_CRC_SIZE;
+    uint32_t crc = (uint32_t)buf[n] | ((uint32_t)buf[n + 1] << 8) | ((uint32_t)buf[n + 2] << 16) |
+                   ((uint32_t)buf[n + 3] << 24);
+    return frame_crc32(buf, n) == crc;
+}
+
+static void slot_reset(struct blerpc_reassembly_slot *s)
+{
+    s->total = 0;
+    s->len = 0;
+    s->next_seq = 0;
+    s->active = false;
+}
+
+void blerpc_reassembler_reset(struct blerpc_reassembler *r)
+{
+    size_t i;
+    for (i = 0; i < BLERPC_FRAMING_MAX_IN_FLIGHT; i++) {
+        slot_reset(&r->slots[i]);
+    }
+}
+
+static int reassembly_fail(struct blerpc_reassembly_slot *s, int err)
+{
+    slot_reset(s);
+    return err;
+}
+
+/* The slot reassembling a message under id, or with active false a free
+ * one, or NULL. */
+static struct blerpc_reassembly_slot *find_slot(struct blerpc_reassembler *r, uint8_t id,
+                                                bool active)
+{
+    size_t i;
+    for (i = 0; i < BLERPC_FRAMING_MAX_IN_FLIGHT; i++) {
+        struct blerpc_reassembly_slot *s = &r->slots[i];
+        if (s->active == active && (!active || s->id == id)) {
+            return s;
+        }
+    }
+    return NULL;
+}
+
+int blerpc_reassembler_feed(struct blerpc_reassembler *r, const uint8_t *frame,
+                            size_t len, struct blerpc_frame_message *msg)
+{
+    if (len < BLERPC_FRAME_HEADER_SIZE) {
+        return BLERPC_FRAMING_ERR_MALFORMED;
+    }
+    uint8_t flags = frame[0];
+    uint8_t id = frame[1];
+    uint8_t seq = frame[2];
+    size_t header = BLERPC_FRAME_HEADER_SIZE;
+    struct blerpc_reassembly_slot *s = find_slot(r, id, true);
+
+    if (flags & BLERPC_FRAME_FIRST) {
+        if (s == NULL) {
+            s = find_slot(r, id, false);
+        }
+        if (s == NULL) {
+            return BLERPC_FRAMING_ERR_BUSY;
+        }
+        if (len < BLERPC_FRAME_FIRST_HEADER_SIZE || seq != 0) {
+            return reassembly_fail(s, BLERPC_FRAMING_ERR_MALFORMED);
+        }
+        size_t total = (size_t)frame[3] | ((size_t)frame[4] << 8);
+        if (total == 0) {
+            return reassembly_fail(s, BLERPC_FRAMING_ERR_MALFORMED);
+        }
+        if (total > BLERPC_FRAMING_REASSEMBLY_SIZE) {
+            return reassembly_fail(s, BLERPC_FRAMING_ERR_TOO_LARGE);
+        }
+        slot_reset(s);
+        s->id = id;
+        s->total = total + BLERPC_FRAME_CRC_SIZE;
+        s->active = true;
+        header = BLERPC_FRAME_FIRST_HEADER_SIZE;
+    } else if (s == NULL) {
+        return BLERPC_FRAMING_ERR_SEQUENCE;
+    } else if (seq != s->next_seq) {
+        return reassembly_fail(s, BLERPC_FRAMING_ERR_SEQUENCE);
+    }
+
+    size_t n = len - header;
+    if (n > s->total - s->len) {
+        return reassembly_fail(s, BLERPC_FRAMING_ERR_MALFORMED);
+    }
+    memcpy(s->buf + s->len, frame + header, n);
+    s->len += n;
+    s->next_seq++;
+
+    if (!(flags & BLERPC_FRAME_LAST)) {
+        return 0;
+    }
+    if (s->len != s->total) {
+        return reassembly_fail(s, BLERPC_FRAMING_ERR_MALFORMED);
+    }
+    if (!crc_matches(s->buf, s->total)) {
+        return reassembly_fail(s, BLERPC_FRAMING_ERR_INTEGRITY);
+    }
+    s->total -= BLERPC_FRAME_CRC_SIZE;
+    msg->id = id;
+    msg->more = (flags & BLERPC_FRAME_MORE) != 0;
+    msg->data = s->buf;
+    msg->len = s->total;
+    s->active = false;
+    return (int)s->total;
+}
+
+int blerpc_fragment(const uint8_t *msg, size_t len, uint16_t mtu, uint8_t id, bool more,
+                    blerpc_frame_write_fn write, void *user)
+{
+    uint8_t frame[BLERPC_FRAMING_MAX_MTU - BLERPC_FRAME_ATT_OVERHEAD];
+    uint8_t crc[BLERPC_FRAME_CRC_SIZE];
+
+    if (mtu > BLERPC_FRAMING_MAX_MTU) {
+        mtu = BLERPC_FRAMING_MAX_MTU;
+    }
+    if (len == 0 || len > UINT16_MAX ||
+        mtu <= BLERPC_FRAME_ATT_OVERHEAD + BLERPC_FRAME_FIRST_HEADER_SIZE) {
+        return BLERPC_FRAMING_ERR_MALFORMED;
+    }
+    size_t frame_size = (size_t)mtu - BLERPC_FRAME_ATT_OVERHEAD;
+    size_t offset = 0;
+    uint8_t seq = 0;
+    uint32_t c = frame_crc32(msg, len);
+    size_t total = len + BLERPC_FRAME_CRC_SIZE;
+
+    crc[0] = (uint8_t)(c & 0xff);
+    crc[1] = (uint8_t)(c >> 8);
+    crc[2] = (uint8_t)(c >> 16);
+    crc[3] = (uint8_t)(c >> 24);
+    while (offset < total) {
+        size_t header = BLERPC_FRAME_HEADER_SIZE;
+        frame[0] = more ? BLERPC_FRAME_MORE : 0;
+        if (offset == 0) {
+            frame[0] |= BLERPC_FRAME_FIRST;
+            frame[3] = (uint8_t)(len & 0xff);
+            frame[4] = (uint8_t)(len >> 8);
+            header = BLERPC_FRAME_FIRST_HEADER_SIZE;
+        }
+        size_t n = total - offset;
+        if (n > frame_size - header) {
+            n = frame_size - header;
+        } else {
+            frame[0] |= BLERPC_FRAME_LAST;
+        }
+        frame[1] = id;
+        frame[2] = seq++;
+        /* The CRC follows the message, in the last frame or straddling two. */
+        size_t from_msg = offset < len ? len - offset : 0;
+        if (from_msg > n) {
+            from_msg = n;
+        }
+        if (from_msg > 0) {
+            memcpy(frame + header, msg + offset, from_msg);
+        }
+        if (n > from_msg) {
+            memcpy(frame + header + from_msg, crc + (offset + from_msg - len), n - from_msg);
+        }
+        if (write(frame, header + n, user) != 0) {
+            return BLERPC_FRAMING_ERR_WRITE;
+        }
+        offset += n;
+    }
+    return 0;
+}
@@ -0,0 +1,111 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_FRAMING_H
+#define BLERPC_GENERATED_FRAMING_H
+
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/*
+ * Frames carry a message larger than the ATT MTU:
+ *
+ *   flags | id | seq | [length, uint16 LE, FIRST only] | data
+ *
+ * id is the correlation ID the client picked for a request, which its
+ * responses carry back, so up to BLERPC_FRAMING_MAX_IN_FLIGHT requests can be
+ * in flight and their frames interleaved. seq counts the frames of a message
+ * from 0. A frame with FIRST starts a new message under its ID, dropping one
+ * partly reassembled. MORE marks a message followed by another under the
+ * same ID, as the responses of a stream but the last.
+ */
+#define BLERPC_FRAME_FIRST 0x01
+#define BLERPC_FRAME_LAST 0x02
+#define BLERPC_FRAME_MORE 0x04
+#define BLERPC_FRAME_HEADER_SIZE 3
+#define BLERPC_FRAME_FIRST_HEADER_SIZE 5
+
+/* Messages reassembled at once, one per correlation ID; the clients start no
+ * more calls than this. Set with max_in_flight in blerpc.yaml. */
+#define BLERPC_FRAMING_MAX_IN_FLIGHT 4
+
+/* Each message travels with its CRC-32 (IEEE 802.3, little endian) after its
+ * data; length leaves it out. The reassembler checks and strips it. */
+#define BLERPC_FRAME_CRC_SIZE 4
+
+/* ATT opcode and handle ahead of each notification or write. */
+#define BLERPC_FRAME_ATT_OVERHEAD 3
+
+/* Largest ATT MTU frames are sent with; a larger MTU is clamped. */
+#ifndef BLERPC_FRAMING_MAX_MTU
+#define BLERPC_FRAMING_MAX_MTU 247
+#endif
+
+/* Largest message the reassembler holds. A request has no bound in
+ * the .options file, so the default is a guess: size it for the largest
+ * request the firmware accepts. */
+#ifndef BLERPC_FRAMING_REASSEMBLY_SIZE
+#define BLERPC_FRAMING_REASSEMBLY_SIZE 1024
+#endif
+
+/* Errors of blerpc_reassembler_feed and blerpc_fragment. */
+enum blerpc_framing_error {
+    BLERPC_FRAMING_ERR_MALFORMED = -1, /* short frame, or bad MTU or length */
+    BLERPC_FRAMING_ERR_SEQUENCE = -2,  /* frame missing, repeated or without FIRST */
+    BLERPC_FRAMING_ERR_TOO_LARGE = -3, /* message over the reassembly buffer */
+    BLERPC_FRAMING_ERR_WRITE = -4,     /* the write callback failed */
+    BLERPC_FRAMING_ERR_BUSY = -5,      /* more than MAX_IN_FLIGHT messages at once */
+    BLERPC_FRAMING_ERR_INTEGRITY = -6, /* CRC-32 mismatch: the message was corrupted */
+};
+
+struct blerpc_reassembly_slot {
+    uint8_t buf[BLERPC_FRAMING_REASSEMBLY_SIZE + BLERPC_FRAME_CRC_SIZE];
+    size_t total;
+    size_t len;
+    uint8_t next_seq;
+    uint8_t id;
+    bool active;
+};
+
+struct blerpc_reassembler {
+    struct blerpc_reassembly_slot slots[BLERPC_FRAMING_MAX_IN_FLIGHT];
+};
+
+/* A reassembled message; data stays valid until the next feed. */
+struct blerpc_frame_message {
+    uint8_t id;
+    bool more;
+    const uint8_t *data;
+    size_t len;
+};
+
+void blerpc_reassembler_reset(struct blerpc_reassembler *r);
+
+/*
+ * Add a received frame. Return the length of the message, filling msg, once
+ * its last frame arrived, 0 while more are due, or a negative
+ * blerpc_framing_error, after which the ID of the frame waits for a FIRST
+ * frame.
+ */
+int blerpc_reassembler_feed(struct blerpc_reassembler *r, const uint8_t *frame,
+                            size_t len, struct blerpc_frame_message *msg);
+
+/* Send one frame; return 0 on success. */
+typedef int (*blerpc_frame_write_fn)(const uint8_t *frame, size_t len, void *user);
+
+/*
+ * Split msg into frames fitting mtu under correlation ID id, with MORE if
+ * more is set, and pass each to write. Return 0, or a negative
+ * blerpc_framing_error.
+ */
+int blerpc_fragment(const uint8_t *msg, size_t len, uint16_t mtu, uint8_t id, bool more,
+                    blerpc_frame_write_fn write, void *user);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_FRAMING_H */
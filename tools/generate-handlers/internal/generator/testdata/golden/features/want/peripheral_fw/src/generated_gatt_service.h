@@ -0,0 +1,73 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_GATT_SERVICE_H
+#define BLERPC_GENERATED_GATT_SERVICE_H
+
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#include <zephyr/bluetooth/conn.h>
+#include <zephyr/bluetooth/gatt.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* blerpc Service UUID: 6e400001-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_SERVICE_UUID BT_UUID_128_ENCODE(0x6e400001, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+
+/* blerpc Characteristic UUID: 6e400002-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_CHAR_UUID BT_UUID_128_ENCODE(0x6e400002, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+
+/* ATT MTU before the MTU exchange, and without a connection. */
+#define BLERPC_GATT_DEFAULT_MTU 23
+
+/**
+ * Register the GATT callbacks (MTU updates).
+ * Call once after bt_enable().
+ */
+void blerpc_gatt_init(void);
+
+/**
+ * Implemented by the BLE layer: a container was written to the RPC
+ * characteristic. Called on the Bluetooth RX thread.
+ */
+void blerpc_gatt_on_write(struct bt_conn *conn, const uint8_t *data, uint16_t len);
+
+/**
+ * Called when the ATT MTU of conn changes. Weak; the default does nothing.
+ */
+void blerpc_gatt_on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx);
+
+/**
+ * Called when the Central enables or disables notifications. Weak; the
+ * default does nothing.
+ */
+void blerpc_gatt_on_notify_changed(bool enabled);
+
+/**
+ * Whether the Central has enabled notifications on the RPC characteristic.
+ */
+bool blerpc_gatt_notify_enabled(void);
+
+/**
+ * Get the ATT MTU of conn, or BLERPC_GATT_DEFAULT_MTU when conn is NULL.
+ */
+uint16_t blerpc_gatt_get_mtu(struct bt_conn *conn);
+
+/**
+ * Ask the Central for the largest ATT MTU the stack supports.
+ * Needs CONFIG_BT_GATT_CLIENT; returns -ENOTSUP without it.
+ */
+int blerpc_gatt_exchange_mtu(struct bt_conn *conn);
+
+/**
+ * Send a notification on the RPC characteristic.
+ * @return 0 on success, negative on error
+ */
+int blerpc_gatt_notify(struct bt_conn *conn, const uint8_t *data, size_t len);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_GATT_SERVICE_H */
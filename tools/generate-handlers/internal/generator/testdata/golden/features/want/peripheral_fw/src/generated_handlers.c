@@ -0,0 +1,2088 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#include "generated_handlers.h"
+#include "blerpc.pb.h"
+#include <pb_encode.h>
+#include <pb_decode.h>
+#include <string.h>
+
+/* Each command, header and name included, must fit the transport
+ * buffers at its largest. */
+#ifdef CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "echo requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(38 + BLERPC_FLASH_READ_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "flash_read requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_STREAM_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "counter_stream requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_UPLOAD_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "counter_upload requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(19 + BLERPC_GET_BLERPC_INFO_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "get_blerpc_info requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(15 + BLERPC_CONN_PARAMS_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "conn_params requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(13 + BLERPC_DFU_BEGIN_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "dfu_begin requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(16 + BLERPC_DFU_FINALIZE_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "dfu_finalize requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(13 + BLERPC_FILE_READ_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "file_read requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(14 + BLERPC_FILE_CLOSE_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "file_close requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(14 + BLERPC_LOG_STREAM_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "log_stream requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(17 + BLERPC_GET_RPC_STATS_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "get_rpc_stats requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(17 + BLERPC_START_SESSION_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "start_session requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(13 + BLERPC_TIME_SYNC_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "time_sync requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(20 + BLERPC_GET_CAPABILITIES_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "get_capabilities requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(15 + BLERPC_GET_SETTING_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "get_setting requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+#endif
+#ifdef CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "echo responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(14 + BLERPC_DATA_WRITE_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "data_write responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_STREAM_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "counter_stream responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_UPLOAD_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "counter_upload responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(15 + BLERPC_CONN_PARAMS_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "conn_params responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(13 + BLERPC_DFU_BEGIN_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "dfu_begin responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(13 + BLERPC_DFU_CHUNK_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "dfu_chunk responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(16 + BLERPC_DFU_FINALIZE_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "dfu_finalize responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(13 + BLERPC_FILE_OPEN_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "file_open responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(14 + BLERPC_FILE_WRITE_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "file_write responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(14 + BLERPC_FILE_CLOSE_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "file_close responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(13 + BLERPC_TIME_SYNC_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "time_sync responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(15 + BLERPC_SET_SETTING_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "set_setting responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+#endif
+
+/* Discard callback for FT_CALLBACK fields during decode */
+static bool discard_bytes_cb(pb_istream_t *stream, const pb_field_t *field,
+                             void **arg)
+{
+    (void)field;
+    (void)arg;
+    uint8_t buf[64];
+    size_t left = stream->bytes_left;
+    while (left > 0) {
+        size_t n = left < sizeof(buf) ? left : sizeof(buf);
+        if (!pb_read(stream, buf, n)) return false;
+        left -= n;
+    }
+    return true;
+}
+
+__attribute__((weak))
+int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len)
+{
+    (void)cmd_name;
+    (void)data;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_stream_finish(void)
+{
+    return -1;
+}
+
+/* Set by a Cancel container, cleared when the stream ends. */
+static volatile bool stream_cancelled;
+
+void blerpc_stream_cancel(void)
+{
+    stream_cancelled = true;
+}
+
+bool blerpc_stream_should_stop(void)
+{
+    return stream_cancelled;
+}
+
+int blerpc_stream_end(void)
+{
+    stream_cancelled = false;
+    return blerpc_stream_finish() == 0 ? -2 : -1;
+}
+
+int blerpc_counter_stream_emit(const blerpc_CounterStreamResponse *resp)
+{
+    uint8_t buf[blerpc_CounterStreamResponse_size];
+    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+    if (!pb_encode(&out, blerpc_CounterStreamResponse_fields, resp)) return -1;
+    return blerpc_stream_write("counter_stream", buf, out.bytes_written);
+}
+
+/* Finalizes the client stream in progress; set by its requests. */
+static int (*stream_c2p_end)(void);
+
+__attribute__((weak))
+int blerpc_counter_upload_accumulate(const blerpc_CounterUploadRequest *req)
+{
+    (void)req;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_counter_upload_finalize(blerpc_CounterUploadResponse *resp)
+{
+    (void)resp;
+    return 0;
+}
+
+static int end_counter_upload(void)
+{
+    blerpc_CounterUploadResponse resp = blerpc_CounterUploadResponse_init_zero;
+    if (blerpc_counter_upload_finalize(&resp) != 0) return -1;
+    uint8_t buf[blerpc_CounterUploadResponse_size];
+    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+    if (!pb_encode(&out, blerpc_CounterUploadResponse_fields, &resp)) return -1;
+    return blerpc_stream_write("counter_upload", buf, out.bytes_written);
+}
+
+__attribute__((weak))
+int handle_counter_upload(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    (void)ostream; /* Not used — the response goes out from blerpc_stream_end_c2p */
+    blerpc_CounterUploadRequest req = blerpc_CounterUploadRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_CounterUploadRequest_fields, &req)) return -1;
+    if (blerpc_counter_upload_accumulate(&req) != 0) return -1;
+    stream_c2p_end = end_counter_upload;
+
+    /* Return -2: the requests of a stream get no response */
+    return -2;
+}
+
+int blerpc_stream_end_c2p(void)
+{
+    int (*end)(void) = stream_c2p_end;
+    stream_c2p_end = NULL;
+    return end != NULL ? end() : -1;
+}
+
+__attribute__((weak))
+int blerpc_dfu_begin(uint32_t size)
+{
+    (void)size;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_dfu_write(uint32_t offset, const uint8_t *data, size_t len)
+{
+    (void)offset;
+    (void)data;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_dfu_finalize(bool reboot)
+{
+    (void)reboot;
+    return -1;
+}
+
+/* dfu_begin moves to DFU_RECEIVING and dfu_finalize back to DFU_IDLE. An
+ * update left in DFU_RECEIVING, e.g. by a dropped link, continues from
+ * offset when dfu_begin comes again with the same size and CRC-32. The
+ * handlers change the state on the sizing pass, which comes first. */
+enum dfu_state {
+    DFU_IDLE,
+    DFU_RECEIVING,
+};
+
+static struct {
+    enum dfu_state state;
+    uint32_t size;
+    uint32_t crc32;  /* of the whole image, from dfu_begin */
+    uint32_t offset; /* bytes written */
+    uint32_t crc;    /* running CRC-32 of the bytes written, uninverted */
+} dfu;
+
+/* CRC-32 (IEEE 802.3) of data, continued from c. */
+static uint32_t dfu_crc_update(uint32_t c, const uint8_t *data, size_t len)
+{
+    for (size_t i = 0; i < len; i++) {
+        c ^= data[i];
+        for (int k = 0; k < 8; k++) {
+            c = (c >> 1) ^ (0xEDB88320 & (0 - (c & 1)));
+        }
+    }
+    return c;
+}
+
+/* The data of the dfu_chunk request being handled. */
+static struct {
+    uint8_t data[BLERPC_DFU_CHUNK_SIZE];
+    size_t len;
+} dfu_chunk;
+
+static bool decode_dfu_data(pb_istream_t *stream, const pb_field_t *field,
+                            void **arg)
+{
+    (void)field;
+    (void)arg;
+    size_t len = stream->bytes_left;
+    if (len > sizeof(dfu_chunk.data)) return false;
+    if (!pb_read(stream, dfu_chunk.data, len)) return false;
+    dfu_chunk.len = len;
+    return true;
+}
+
+__attribute__((weak))
+int blerpc_file_open(const char *path, bool write, bool resume)
+{
+    (void)path;
+    (void)write;
+    (void)resume;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_file_read(uint32_t handle, uint32_t offset, uint8_t *buf, size_t len)
+{
+    (void)handle;
+    (void)offset;
+    (void)buf;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_file_write(uint32_t handle, uint32_t offset, const uint8_t *data,
+                     size_t len)
+{
+    (void)handle;
+    (void)offset;
+    (void)data;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_file_close(uint32_t handle)
+{
+    (void)handle;
+    return -1;
+}
+
+/* Size and CRC-32 (IEEE 802.3) of the file behind handle, read back one
+ * chunk at a time. */
+static int file_checksum(uint32_t handle, uint32_t *size, uint32_t *crc)
+{
+    uint8_t buf[BLERPC_FILE_CHUNK_SIZE];
+    uint32_t c = 0xFFFFFFFF;
+    *size = 0;
+    for (;;) {
+        int n = blerpc_file_read(handle, *size, buf, sizeof(buf));
+        if (n < 0) return -1;
+        if (n == 0) break;
+        for (size_t i = 0; i < (size_t)n; i++) {
+            c ^= buf[i];
+            for (int k = 0; k < 8; k++) {
+                c = (c >> 1) ^ (0xEDB88320 & (0 - (c & 1)));
+            }
+        }
+        *size += (uint32_t)n;
+    }
+    *crc = ~c;
+    return 0;
+}
+
+/* file_open and file_close act on the sizing pass, which comes first, and
+ * keep the result here for the writing pass. */
+static int file_handle;
+static uint32_t file_size;
+static uint32_t file_crc;
+
+/* The chunk file_read read on the sizing pass. */
+static struct {
+    uint8_t data[BLERPC_FILE_CHUNK_SIZE];
+    size_t len;
+} file_chunk;
+
+static bool decode_file_path(pb_istream_t *stream, const pb_field_t *field,
+                             void **arg)
+{
+    (void)field;
+    char *path = *arg;
+    size_t len = stream->bytes_left;
+    if (len >= BLERPC_FILE_PATH_SIZE) return false;
+    if (!pb_read(stream, (pb_byte_t *)path, len)) return false;
+    path[len] = '\0';
+    return true;
+}
+
+static bool encode_file_chunk(pb_ostream_t *stream, const pb_field_t *field,
+                              void *const *arg)
+{
+    (void)arg;
+    return pb_encode_tag_for_field(stream, field) &&
+           pb_encode_string(stream, file_chunk.data, file_chunk.len);
+}
+
+/* Where a file_write request's data goes. Encoders emit fields in number
+ * order, so handle and offset are decoded by the time data is. */
+struct file_write_target {
+    const uint32_t *handle;
+    const uint32_t *offset;
+    bool write;
+};
+
+static bool decode_file_data(pb_istream_t *stream, const pb_field_t *field,
+                             void **arg)
+{
+    (void)field;
+    const struct file_write_target *target = *arg;
+    uint32_t offset = *target->offset;
+    uint8_t buf[64];
+    while (stream->bytes_left > 0) {
+        size_t n = stream->bytes_left < sizeof(buf) ? stream->bytes_left : sizeof(buf);
+        if (!pb_read(stream, buf, n)) return false;
+        if (target->write &&
+            blerpc_file_write(*target->handle, offset, buf, n) != 0) {
+            return false;
+        }
+        offset += (uint32_t)n;
+    }
+    return true;
+}
+
+__attribute__((weak))
+int blerpc_session_random(uint8_t *buf, size_t len)
+{
+    (void)buf;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+bool blerpc_session_verify(const uint8_t *challenge, const uint8_t *proof,
+                            size_t proof_len)
+{
+    (void)challenge;
+    (void)proof;
+    (void)proof_len;
+    return false;
+}
+
+static uint8_t session_challenge[BLERPC_SESSION_CHALLENGE_SIZE];
+static bool session_challenge_valid;
+static uint8_t session_token[BLERPC_SESSION_TOKEN_SIZE];
+static bool session_open;
+/* Outcome of the authenticate_session request in progress. */
+static enum blerpc_status session_status;
+
+bool blerpc_session_check(const uint8_t *token)
+{
+    uint8_t diff = 0;
+    size_t i;
+    if (!session_open) return false;
+    /* Look at every byte, so the time taken tells nothing of the token. */
+    for (i = 0; i < BLERPC_SESSION_TOKEN_SIZE; i++) {
+        diff |= token[i] ^ session_token[i];
+    }
+    return diff == 0;
+}
+
+void blerpc_session_end(void)
+{
+    session_open = false;
+    session_challenge_valid = false;
+}
+
+struct session_bytes {
+    const uint8_t *data;
+    size_t len;
+};
+
+static bool encode_session_bytes(pb_ostream_t *stream, const pb_field_t *field,
+                                 void *const *arg)
+{
+    const struct session_bytes *bytes = *arg;
+    return pb_encode_tag_for_field(stream, field) &&
+           pb_encode_string(stream, bytes->data, bytes->len);
+}
+
+struct session_proof {
+    uint8_t data[BLERPC_SESSION_PROOF_SIZE];
+    size_t len;
+};
+
+static bool decode_session_proof(pb_istream_t *stream, const pb_field_t *field,
+                                 void **arg)
+{
+    struct session_proof *proof = *arg;
+    (void)field;
+    if (stream->bytes_left > sizeof(proof->data)) return false;
+    proof->len = stream->bytes_left;
+    return pb_read(stream, proof->data, proof->len);
+}
+
+__attribute__((weak))
+int handle_echo(const uint8_t *req_data, size_t req_len,
+                    pb_ostream_t *ostream)
+{
+    blerpc_EchoRequest req = blerpc_EchoRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_EchoRequest_fields, &req)) return -1;
+
+    blerpc_EchoResponse resp = blerpc_EchoResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_EchoResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+size_t blerpc_flash_read_length_data(const blerpc_FlashReadRequest *req)
+{
+    (void)req;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_flash_read_source_data(const blerpc_FlashReadRequest *req, uint8_t *chunk,
+                                  size_t len, size_t offset)
+{
+    (void)req;
+    (void)chunk;
+    (void)len;
+    (void)offset;
+    return -1;
+}
+
+static bool write_flash_read_data(pb_ostream_t *stream, const pb_field_t *field,
+                                  void *const *arg)
+{
+    const blerpc_FlashReadRequest *req = *arg;
+    size_t len = blerpc_flash_read_length_data(req);
+    if (len == 0) return true;
+    if (!pb_encode_tag_for_field(stream, field)) return false;
+    if (!pb_encode_varint(stream, len)) return false;
+    /* The sizing pass counts the bytes without reading them. */
+    if (stream->callback == NULL) return pb_write(stream, NULL, len);
+    uint8_t buf[64];
+    size_t offset = 0;
+    while (offset < len) {
+        size_t n = len - offset < sizeof(buf) ? len - offset : sizeof(buf);
+        if (blerpc_flash_read_source_data(req, buf, n, offset) != 0) return false;
+        if (!pb_write(stream, buf, n)) return false;
+        offset += n;
+    }
+    return true;
+}
+
+__attribute__((weak))
+int handle_flash_read(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream)
+{
+    blerpc_FlashReadRequest req = blerpc_FlashReadRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_FlashReadRequest_fields, &req)) return -1;
+
+    blerpc_FlashReadResponse resp = blerpc_FlashReadResponse_init_zero;
+    resp.data.funcs.encode = write_flash_read_data;
+    resp.data.arg = &req;
+    if (!pb_encode(ostream, blerpc_FlashReadResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_data_write_read_data(const blerpc_DataWriteRequest *req, const uint8_t *chunk,
+                                size_t len, size_t offset)
+{
+    (void)req;
+    (void)chunk;
+    (void)len;
+    (void)offset;
+    return 0;
+}
+
+static bool read_data_write_data(pb_istream_t *stream, const pb_field_t *field,
+                                 void **arg)
+{
+    (void)field;
+    const blerpc_DataWriteRequest *req = *arg;
+    uint8_t buf[64];
+    size_t offset = 0;
+    while (stream->bytes_left > 0) {
+        size_t n = stream->bytes_left < sizeof(buf) ? stream->bytes_left : sizeof(buf);
+        if (!pb_read(stream, buf, n)) return false;
+        if (blerpc_data_write_read_data(req, buf, n, offset) != 0) return false;
+        offset += n;
+    }
+    return true;
+}
+
+__attribute__((weak))
+int handle_data_write(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream)
+{
+    blerpc_DataWriteRequest req = blerpc_DataWriteRequest_init_zero;
+    /* Read the request's FT_CALLBACK fields once, on the sizing pass. */
+    if (ostream->callback == NULL) {
+        req.data.funcs.decode = read_data_write_data;
+        req.data.arg = &req;
+    } else {
+        req.data.funcs.decode = discard_bytes_cb;
+    }
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_DataWriteRequest_fields, &req)) return -1;
+
+    blerpc_DataWriteResponse resp = blerpc_DataWriteResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_DataWriteResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_counter_stream(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    (void)ostream; /* Not used — responses go out through blerpc_counter_stream_emit */
+    blerpc_CounterStreamRequest req = blerpc_CounterStreamRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_CounterStreamRequest_fields, &req)) return -1;
+
+    /* Send each response with blerpc_counter_stream_emit() */
+    return blerpc_stream_end();
+}
+
+static bool encode_info_string(pb_ostream_t *stream, const pb_field_t *field,
+                               void *const *arg)
+{
+    const char *s = *arg;
+    return pb_encode_tag_for_field(stream, field) &&
+           pb_encode_string(stream, (const pb_byte_t *)s, strlen(s));
+}
+
+__attribute__((weak))
+int handle_get_blerpc_info(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream)
+{
+    (void)req_data; /* The request has no fields */
+    (void)req_len;
+    blerpc_GetBlerpcInfoResponse resp = blerpc_GetBlerpcInfoResponse_init_zero;
+    resp.schema_hash.funcs.encode = encode_info_string;
+    resp.schema_hash.arg = (void *)BLERPC_SCHEMA_HASH;
+    resp.generator_version.funcs.encode = encode_info_string;
+    resp.generator_version.arg = (void *)BLERPC_GENERATOR_VERSION;
+    if (!pb_encode(ostream, blerpc_GetBlerpcInfoResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_conn_params_apply(uint8_t profile, struct blerpc_conn_params *params)
+{
+    (void)profile;
+    (void)params;
+    return -1;
+}
+
+__attribute__((weak))
+int handle_conn_params(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream)
+{
+    blerpc_ConnParamsRequest req = blerpc_ConnParamsRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_ConnParamsRequest_fields, &req)) return -1;
+
+    struct blerpc_conn_params params;
+    switch (req.profile) {
+    case blerpc_ConnProfile_CONN_PROFILE_FAST:
+        params = (struct blerpc_conn_params){6, 12, 0, 400};
+        break;
+    case blerpc_ConnProfile_CONN_PROFILE_LOW_POWER:
+        params = (struct blerpc_conn_params){80, 160, 4, 600};
+        break;
+    default:
+        params = (struct blerpc_conn_params){24, 40, 0, 400};
+        break;
+    }
+    if (blerpc_conn_params_apply((uint8_t)req.profile, &params) != 0) return -1;
+
+    blerpc_ConnParamsResponse resp = blerpc_ConnParamsResponse_init_zero;
+    resp.interval_min = params.interval_min;
+    resp.interval_max = params.interval_max;
+    resp.latency = params.latency;
+    resp.timeout = params.timeout;
+    if (!pb_encode(ostream, blerpc_ConnParamsResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_dfu_begin(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream)
+{
+    blerpc_DfuBeginRequest req = blerpc_DfuBeginRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_DfuBeginRequest_fields, &req)) return -1;
+
+    if (ostream->callback == NULL) {
+        bool resume = dfu.state == DFU_RECEIVING && dfu.size == req.size &&
+                      dfu.crc32 == req.crc32;
+        if (!resume) {
+            dfu.state = DFU_IDLE;
+            if (blerpc_dfu_begin(req.size) != 0) return -1;
+            dfu.state = DFU_RECEIVING;
+            dfu.size = req.size;
+            dfu.crc32 = req.crc32;
+            dfu.offset = 0;
+            dfu.crc = 0xFFFFFFFF;
+        }
+    }
+    blerpc_DfuBeginResponse resp = blerpc_DfuBeginResponse_init_zero;
+    resp.offset = dfu.offset;
+    resp.max_chunk = BLERPC_DFU_CHUNK_SIZE;
+    if (!pb_encode(ostream, blerpc_DfuBeginResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_dfu_chunk(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream)
+{
+    blerpc_DfuChunkRequest req = blerpc_DfuChunkRequest_init_zero;
+    dfu_chunk.len = 0;
+    req.data.funcs.decode = decode_dfu_data;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_DfuChunkRequest_fields, &req)) return -1;
+
+    if (ostream->callback == NULL) {
+        if (dfu.state != DFU_RECEIVING) return -1;
+        /* A chunk at another offset, e.g. one resent after its response was
+         * lost, is not written; the response says where to continue. */
+        if (req.offset == dfu.offset && dfu_chunk.len > 0) {
+            if (dfu_chunk.len > dfu.size - dfu.offset) return -1;
+            if (blerpc_dfu_write(dfu.offset, dfu_chunk.data, dfu_chunk.len) != 0) {
+                return -1;
+            }
+            dfu.crc = dfu_crc_update(dfu.crc, dfu_chunk.data, dfu_chunk.len);
+            dfu.offset += (uint32_t)dfu_chunk.len;
+        }
+    }
+    blerpc_DfuChunkResponse resp = blerpc_DfuChunkResponse_init_zero;
+    resp.offset = dfu.offset;
+    if (!pb_encode(ostream, blerpc_DfuChunkResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_dfu_finalize(const uint8_t *req_data, size_t req_len,
+                            pb_ostream_t *ostream)
+{
+    blerpc_DfuFinalizeRequest req = blerpc_DfuFinalizeRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_DfuFinalizeRequest_fields, &req)) return -1;
+
+    if (ostream->callback == NULL) {
+        if (dfu.state != DFU_RECEIVING) return -1;
+        dfu.state = DFU_IDLE;
+        if (dfu.offset != dfu.size || ~dfu.crc != dfu.crc32) return -1;
+        if (blerpc_dfu_finalize(req.reboot) != 0) return -1;
+    }
+    blerpc_DfuFinalizeResponse resp = blerpc_DfuFinalizeResponse_init_zero;
+    resp.crc32 = ~dfu.crc;
+    if (!pb_encode(ostream, blerpc_DfuFinalizeResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_open(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream)
+{
+    blerpc_FileOpenRequest req = blerpc_FileOpenRequest_init_zero;
+    char path[BLERPC_FILE_PATH_SIZE] = "";
+    req.path.funcs.decode = decode_file_path;
+    req.path.arg = path;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_FileOpenRequest_fields, &req)) return -1;
+
+    blerpc_FileOpenResponse resp = blerpc_FileOpenResponse_init_zero;
+    if (ostream->callback == NULL) {
+        file_handle = blerpc_file_open(path, req.write, req.resume);
+        if (file_handle < 0) return -1;
+        if (file_checksum((uint32_t)file_handle, &file_size, &file_crc) != 0) {
+            blerpc_file_close((uint32_t)file_handle);
+            return -1;
+        }
+    }
+    resp.handle = (uint32_t)file_handle;
+    resp.size = file_size;
+    resp.crc32 = file_crc;
+    if (!pb_encode(ostream, blerpc_FileOpenResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_read(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream)
+{
+    blerpc_FileReadRequest req = blerpc_FileReadRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_FileReadRequest_fields, &req)) return -1;
+
+    blerpc_FileReadResponse resp = blerpc_FileReadResponse_init_zero;
+    if (ostream->callback == NULL) {
+        size_t len = req.length < sizeof(file_chunk.data) ? req.length
+                                                          : sizeof(file_chunk.data);
+        int n = blerpc_file_read(req.handle, req.offset, file_chunk.data, len);
+        if (n < 0) return -1;
+        file_chunk.len = (size_t)n;
+    }
+    resp.data.funcs.encode = encode_file_chunk;
+    if (!pb_encode(ostream, blerpc_FileReadResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_write(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream)
+{
+    blerpc_FileWriteRequest req = blerpc_FileWriteRequest_init_zero;
+    struct file_write_target target = {
+        &req.handle, &req.offset, ostream->callback == NULL,
+    };
+    /* Write the data once, on the sizing pass. */
+    req.data.funcs.decode = decode_file_data;
+    req.data.arg = &target;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_FileWriteRequest_fields, &req)) return -1;
+
+    blerpc_FileWriteResponse resp = blerpc_FileWriteResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_FileWriteResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_file_close(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream)
+{
+    blerpc_FileCloseRequest req = blerpc_FileCloseRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_FileCloseRequest_fields, &req)) return -1;
+
+    blerpc_FileCloseResponse resp = blerpc_FileCloseResponse_init_zero;
+    if (ostream->callback == NULL) {
+        file_size = 0;
+        file_crc = 0;
+        if (req.verify && file_checksum(req.handle, &file_size, &file_crc) != 0) {
+            blerpc_file_close(req.handle);
+            return -1;
+        }
+        if (blerpc_file_close(req.handle) != 0) return -1;
+    }
+    resp.size = file_size;
+    resp.crc32 = file_crc;
+    if (!pb_encode(ostream, blerpc_FileCloseResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+struct log_entry {
+    uint32_t seq;
+    uint32_t uptime_ms;
+    uint8_t level;
+    bool more;
+    char module[BLERPC_LOG_MODULE_SIZE];
+    char message[BLERPC_LOG_MESSAGE_SIZE];
+};
+
+static struct log_entry log_ring[BLERPC_LOG_ENTRIES];
+static uint32_t log_head; /* seq of the next entry */
+static uint32_t log_tail; /* seq of the oldest entry kept */
+
+#ifdef __ZEPHYR__
+#include <zephyr/kernel.h>
+
+static struct k_spinlock log_lock;
+#endif
+
+__attribute__((weak))
+uint32_t blerpc_log_now_ms(void)
+{
+#ifdef __ZEPHYR__
+    return k_uptime_get_32();
+#else
+    return 0;
+#endif
+}
+
+void blerpc_log_write(uint8_t level, const char *module, const char *message)
+{
+    uint32_t now = blerpc_log_now_ms();
+    size_t len = strlen(message);
+#ifdef __ZEPHYR__
+    k_spinlock_key_t key = k_spin_lock(&log_lock);
+#endif
+    do {
+        struct log_entry *e = &log_ring[log_head % BLERPC_LOG_ENTRIES];
+        size_t n = len < BLERPC_LOG_MESSAGE_SIZE - 1 ? len : BLERPC_LOG_MESSAGE_SIZE - 1;
+        size_t cut = n;
+        /* Split between UTF-8 characters where there is one to split at. */
+        while (cut > 0 && cut < len && ((uint8_t)message[cut] & 0xC0) == 0x80) cut--;
+        if (cut > 0) n = cut;
+        e->seq = log_head++;
+        e->uptime_ms = now;
+        e->level = level;
+        strncpy(e->module, module, sizeof(e->module) - 1);
+        e->module[sizeof(e->module) - 1] = '\0';
+        memcpy(e->message, message, n);
+        e->message[n] = '\0';
+        message += n;
+        len -= n;
+        e->more = len > 0;
+    } while (len > 0);
+    if (log_head - log_tail > BLERPC_LOG_ENTRIES) {
+        log_tail = log_head - BLERPC_LOG_ENTRIES;
+    }
+#ifdef __ZEPHYR__
+    k_spin_unlock(&log_lock, key);
+#endif
+}
+
+/* Removes the oldest entry from the buffer into out. */
+static bool log_pop(struct log_entry *out)
+{
+#ifdef __ZEPHYR__
+    k_spinlock_key_t key = k_spin_lock(&log_lock);
+#endif
+    bool ok = log_tail != log_head;
+    if (ok) {
+        *out = log_ring[log_tail % BLERPC_LOG_ENTRIES];
+        log_tail++;
+    }
+#ifdef __ZEPHYR__
+    k_spin_unlock(&log_lock, key);
+#endif
+    return ok;
+}
+
+static bool encode_log_string(pb_ostream_t *stream, const pb_field_t *field,
+                              void *const *arg)
+{
+    const char *s = *arg;
+    return pb_encode_tag_for_field(stream, field) &&
+           pb_encode_string(stream, (const pb_byte_t *)s, strlen(s));
+}
+
+__attribute__((weak))
+int blerpc_log_stream_send(const uint8_t *data, size_t len)
+{
+    (void)data;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_log_stream_end(void)
+{
+    return -1;
+}
+
+__attribute__((weak))
+int handle_log_stream(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream)
+{
+    (void)ostream; /* Not used — entries go out through blerpc_log_stream_send */
+    blerpc_LogStreamRequest req = blerpc_LogStreamRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_LogStreamRequest_fields, &req)) return -1;
+
+    struct log_entry entry;
+    uint32_t sent = 0;
+    while ((req.max_entries == 0 || sent < req.max_entries) && log_pop(&entry)) {
+        if (entry.level < (uint8_t)req.min_level) continue;
+        blerpc_LogStreamResponse resp = blerpc_LogStreamResponse_init_zero;
+        resp.seq = entry.seq;
+        resp.level = (blerpc_LogLevel)entry.level;
+        resp.uptime_ms = entry.uptime_ms;
+        resp.now_ms = blerpc_log_now_ms();
+        resp.more = entry.more;
+        resp.module.funcs.encode = encode_log_string;
+        resp.module.arg = entry.module;
+        resp.message.funcs.encode = encode_log_string;
+        resp.message.arg = entry.message;
+
+        uint8_t buf[BLERPC_LOG_MODULE_SIZE + BLERPC_LOG_MESSAGE_SIZE + 32];
+        pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+        if (!pb_encode(&out, blerpc_LogStreamResponse_fields, &resp)) return -1;
+        if (blerpc_log_stream_send(buf, out.bytes_written) != 0) return -1;
+        /* max_entries counts messages, not their fragments. */
+        if (!entry.more) sent++;
+    }
+    if (blerpc_log_stream_end() != 0) return -1;
+
+    /* Return -2: the dispatcher sends no response */
+    return -2;
+}
+
+static bool encode_rpc_stat_name(pb_ostream_t *stream, const pb_field_t *field,
+                                 void *const *arg)
+{
+    const struct blerpc_rpc_stat *stat = *arg;
+    return pb_encode_tag_for_field(stream, field) &&
+           pb_encode_string(stream, (const pb_byte_t *)stat->name, stat->name_len);
+}
+
+static bool encode_rpc_stats(pb_ostream_t *stream, const pb_field_t *field,
+                             void *const *arg)
+{
+    (void)arg;
+    size_t count, i;
+    struct blerpc_rpc_stat *stats = blerpc_rpc_stats(&count);
+    for (i = 0; i < count; i++) {
+        blerpc_RpcStat msg = blerpc_RpcStat_init_zero;
+        msg.name.funcs.encode = encode_rpc_stat_name;
+        msg.name.arg = &stats[i];
+        msg.calls = stats[i].calls;
+        msg.errors = stats[i].errors;
+        msg.max_duration_us = stats[i].max_duration_us;
+        if (!pb_encode_tag_for_field(stream, field) ||
+            !pb_encode_submessage(stream, blerpc_RpcStat_fields, &msg)) {
+            return false;
+        }
+    }
+    return true;
+}
+
+__attribute__((weak))
+int handle_get_rpc_stats(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    blerpc_GetRpcStatsRequest req = blerpc_GetRpcStatsRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_GetRpcStatsRequest_fields, &req)) return -1;
+
+    blerpc_GetRpcStatsResponse resp = blerpc_GetRpcStatsResponse_init_zero;
+    resp.stats.funcs.encode = encode_rpc_stats;
+    if (!pb_encode(ostream, blerpc_GetRpcStatsResponse_fields, &resp)) return -1;
+
+    /* Clear after the writing pass, so both passes encode the same counts. */
+    if (req.reset && ostream->callback != NULL) {
+        size_t count, i;
+        struct blerpc_rpc_stat *stats = blerpc_rpc_stats(&count);
+        for (i = 0; i < count; i++) {
+            stats[i].calls = 0;
+            stats[i].errors = 0;
+            stats[i].max_duration_us = 0;
+        }
+    }
+    return 0;
+}
+
+__attribute__((weak))
+int handle_start_session(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    (void)req_data; /* The request has no fields */
+    (void)req_len;
+    if (ostream->callback == NULL) {
+        blerpc_session_end();
+        session_challenge_valid =
+            blerpc_session_random(session_challenge, sizeof(session_challenge)) == 0;
+    }
+    if (!session_challenge_valid) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_UNAVAILABLE);
+    }
+
+    struct session_bytes challenge = {session_challenge, sizeof(session_challenge)};
+    blerpc_StartSessionResponse resp = blerpc_StartSessionResponse_init_zero;
+    resp.challenge.funcs.encode = encode_session_bytes;
+    resp.challenge.arg = &challenge;
+    if (!pb_encode(ostream, blerpc_StartSessionResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_authenticate_session(const uint8_t *req_data, size_t req_len,
+                                    pb_ostream_t *ostream)
+{
+    if (ostream->callback == NULL) {
+        struct session_proof proof = {{0}, 0};
+        blerpc_AuthenticateSessionRequest req = blerpc_AuthenticateSessionRequest_init_zero;
+        req.proof.funcs.decode = decode_session_proof;
+        req.proof.arg = &proof;
+        pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+        if (!pb_decode(&stream, blerpc_AuthenticateSessionRequest_fields, &req)) return -1;
+        if (!session_challenge_valid ||
+            !blerpc_session_verify(session_challenge, proof.data, proof.len)) {
+            session_status = BLERPC_STATUS_UNAUTHENTICATED;
+        } else if (blerpc_session_random(session_token, sizeof(session_token)) != 0) {
+            session_status = BLERPC_STATUS_UNAVAILABLE;
+        } else {
+            session_status = BLERPC_STATUS_OK;
+        }
+    } else {
+        session_challenge_valid = false;
+        session_open = session_status == BLERPC_STATUS_OK;
+    }
+    if (session_status != BLERPC_STATUS_OK) {
+        return blerpc_return_error(ostream, session_status);
+    }
+
+    struct session_bytes token = {session_token, sizeof(session_token)};
+    blerpc_AuthenticateSessionResponse resp = blerpc_AuthenticateSessionResponse_init_zero;
+    resp.token.funcs.encode = encode_session_bytes;
+    resp.token.arg = &token;
+    if (!pb_encode(ostream, blerpc_AuthenticateSessionResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_time_set(int64_t unix_time_us)
+{
+    (void)unix_time_us;
+    return -1;
+}
+
+__attribute__((weak))
+int handle_time_sync(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream)
+{
+    blerpc_TimeSyncRequest req = blerpc_TimeSyncRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_TimeSyncRequest_fields, &req)) return -1;
+
+    blerpc_TimeSyncResponse resp = blerpc_TimeSyncResponse_init_zero;
+    resp.applied_time_us = req.unix_time_us + (int64_t)req.offset_us;
+    /* Set the clock once, on the sizing pass, which comes first. */
+    if (ostream->callback == NULL && blerpc_time_set(resp.applied_time_us) != 0) {
+        return -1;
+    }
+    if (!pb_encode(ostream, blerpc_TimeSyncResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+#ifdef __ZEPHYR__
+#include <zephyr/kernel.h>
+#endif
+
+__attribute__((weak))
+uint32_t blerpc_ping_uptime_ms(void)
+{
+#ifdef __ZEPHYR__
+    return k_uptime_get_32();
+#else
+    return 0;
+#endif
+}
+
+__attribute__((weak))
+int8_t blerpc_ping_rssi(void)
+{
+    return 0;
+}
+
+/* The payload of the ping being answered, and what the sizing pass read. */
+static struct {
+    uint8_t data[BLERPC_PING_PAYLOAD_SIZE];
+    size_t len;
+    uint32_t uptime_ms;
+    int8_t rssi;
+} ping_state;
+
+static bool decode_ping_payload(pb_istream_t *stream, const pb_field_t *field,
+                                void **arg)
+{
+    (void)field;
+    (void)arg;
+    size_t len = stream->bytes_left;
+    if (len > sizeof(ping_state.data)) return false;
+    ping_state.len = len;
+    return pb_read(stream, ping_state.data, len);
+}
+
+static bool encode_ping_payload(pb_ostream_t *stream, const pb_field_t *field,
+                                void *const *arg)
+{
+    (void)arg;
+    if (ping_state.len == 0) return true;
+    return pb_encode_tag_for_field(stream, field) &&
+           pb_encode_string(stream, ping_state.data, ping_state.len);
+}
+
+__attribute__((weak))
+int handle_ping(const uint8_t *req_data, size_t req_len,
+                    pb_ostream_t *ostream)
+{
+    blerpc_PingRequest req = blerpc_PingRequest_init_zero;
+    req.payload.funcs.decode = decode_ping_payload;
+    ping_state.len = 0;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_PingRequest_fields, &req)) return -1;
+    if (ostream->callback == NULL) {
+        ping_state.uptime_ms = blerpc_ping_uptime_ms();
+        ping_state.rssi = blerpc_ping_rssi();
+    }
+
+    blerpc_PingResponse resp = blerpc_PingResponse_init_zero;
+    resp.payload.funcs.encode = encode_ping_payload;
+    resp.uptime_ms = ping_state.uptime_ms;
+    resp.rssi = ping_state.rssi;
+    if (!pb_encode(ostream, blerpc_PingResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+static bool encode_capability_ids(pb_ostream_t *stream, const pb_field_t *field,
+                                  void *const *arg)
+{
+    (void)arg;
+    uint8_t bitmap[127 / 8 + 1] = {0};
+    size_t count, i, len = 0;
+    const struct blerpc_capability *caps = blerpc_capabilities(&count);
+    for (i = 0; i < count; i++) {
+        if (!*caps[i].implemented) continue;
+        bitmap[caps[i].id / 8] |= (uint8_t)(1u << (caps[i].id % 8));
+        if ((size_t)caps[i].id / 8 + 1 > len) len = (size_t)caps[i].id / 8 + 1;
+    }
+    return pb_encode_tag_for_field(stream, field) &&
+           pb_encode_string(stream, bitmap, len);
+}
+
+__attribute__((weak))
+int handle_get_capabilities(const uint8_t *req_data, size_t req_len,
+                                pb_ostream_t *ostream)
+{
+    blerpc_GetCapabilitiesRequest req = blerpc_GetCapabilitiesRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_GetCapabilitiesRequest_fields, &req)) return -1;
+
+    blerpc_GetCapabilitiesResponse resp = blerpc_GetCapabilitiesResponse_init_zero;
+    resp.command_ids.funcs.encode = encode_capability_ids;
+    if (!pb_encode(ostream, blerpc_GetCapabilitiesResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_setting_load(uint32_t field, blerpc_DeviceSettings *settings)
+{
+    (void)field;
+    (void)settings;
+    return -1;
+}
+
+static bool encode_setting_value(pb_ostream_t *stream, const pb_field_t *field,
+                                 void *const *arg)
+{
+    if (!pb_encode_tag_for_field(stream, field)) return false;
+    return pb_encode_submessage(stream, blerpc_DeviceSettings_fields, *arg);
+}
+
+__attribute__((weak))
+int handle_get_setting(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream)
+{
+    blerpc_GetSettingRequest req = blerpc_GetSettingRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_GetSettingRequest_fields, &req)) return -1;
+    switch (req.field) {
+    case blerpc_DeviceSettings_sample_interval_ms_tag:
+    case blerpc_DeviceSettings_leds_enabled_tag:
+        break;
+    default:
+        return -1;
+    }
+
+    blerpc_DeviceSettings settings = blerpc_DeviceSettings_init_zero;
+    if (blerpc_setting_load(req.field, &settings) != 0) return -1;
+
+    blerpc_GetSettingResponse resp = blerpc_GetSettingResponse_init_zero;
+    resp.value.funcs.encode = encode_setting_value;
+    resp.value.arg = &settings;
+    if (!pb_encode(ostream, blerpc_GetSettingResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_setting_store(uint32_t field, const blerpc_DeviceSettings *settings)
+{
+    (void)field;
+    (void)settings;
+    return -1;
+}
+
+static bool decode_setting_value(pb_istream_t *stream, const pb_field_t *field,
+                                 void **arg)
+{
+    (void)field;
+    return pb_decode(stream, blerpc_DeviceSettings_fields, *arg);
+}
+
+__attribute__((weak))
+int handle_set_setting(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream)
+{
+    blerpc_DeviceSettings settings = blerpc_DeviceSettings_init_zero;
+    blerpc_SetSettingRequest req = blerpc_SetSettingRequest_init_zero;
+    req.value.funcs.decode = decode_setting_value;
+    req.value.arg = &settings;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_SetSettingRequest_fields, &req)) return -1;
+    switch (req.field) {
+    case blerpc_DeviceSettings_sample_interval_ms_tag:
+    case blerpc_DeviceSettings_leds_enabled_tag:
+        break;
+    default:
+        return -1;
+    }
+    if (ostream->callback == NULL && blerpc_setting_store(req.field, &settings) != 0) {
+        return -1;
+    }
+
+    blerpc_SetSettingResponse resp = blerpc_SetSettingResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_SetSettingResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+/* get_capabilities flags, set by BLERPC_IMPLEMENTS in the handler sources. */
+__attribute__((weak)) const bool blerpc_implements_echo = false;
+__attribute__((weak)) const bool blerpc_implements_flash_read = false;
+__attribute__((weak)) const bool blerpc_implements_data_write = false;
+__attribute__((weak)) const bool blerpc_implements_counter_stream = false;
+__attribute__((weak)) const bool blerpc_implements_counter_upload = false;
+static const bool builtin_implemented = true;
+
+static const struct blerpc_capability capabilities[] = {
+#if BLERPC_CMDS_BLERPC
+    {"echo", 4, BLERPC_CMD_ID_ECHO, &blerpc_implements_echo},
+    {"flash_read", 10, BLERPC_CMD_ID_FLASH_READ, &blerpc_implements_flash_read},
+    {"data_write", 10, BLERPC_CMD_ID_DATA_WRITE, &blerpc_implements_data_write},
+    {"counter_stream", 14, BLERPC_CMD_ID_COUNTER_STREAM, &blerpc_implements_counter_stream},
+    {"counter_upload", 14, BLERPC_CMD_ID_COUNTER_UPLOAD, &blerpc_implements_counter_upload},
+    {"get_blerpc_info", 15, BLERPC_CMD_ID_GET_BLERPC_INFO, &builtin_implemented},
+    {"conn_params", 11, BLERPC_CMD_ID_CONN_PARAMS, &builtin_implemented},
+    {"dfu_begin", 9, BLERPC_CMD_ID_DFU_BEGIN, &builtin_implemented},
+    {"dfu_chunk", 9, BLERPC_CMD_ID_DFU_CHUNK, &builtin_implemented},
+    {"dfu_finalize", 12, BLERPC_CMD_ID_DFU_FINALIZE, &builtin_implemented},
+    {"file_open", 9, BLERPC_CMD_ID_FILE_OPEN, &builtin_implemented},
+    {"file_read", 9, BLERPC_CMD_ID_FILE_READ, &builtin_implemented},
+    {"file_write", 10, BLERPC_CMD_ID_FILE_WRITE, &builtin_implemented},
+    {"file_close", 10, BLERPC_CMD_ID_FILE_CLOSE, &builtin_implemented},
+    {"log_stream", 10, BLERPC_CMD_ID_LOG_STREAM, &builtin_implemented},
+    {"get_rpc_stats", 13, BLERPC_CMD_ID_GET_RPC_STATS, &builtin_implemented},
+    {"start_session", 13, BLERPC_CMD_ID_START_SESSION, &builtin_implemented},
+    {"authenticate_session", 20, BLERPC_CMD_ID_AUTHENTICATE_SESSION, &builtin_implemented},
+    {"time_sync", 9, BLERPC_CMD_ID_TIME_SYNC, &builtin_implemented},
+    {"ping", 4, BLERPC_CMD_ID_PING, &builtin_implemented},
+    {"get_capabilities", 16, BLERPC_CMD_ID_GET_CAPABILITIES, &builtin_implemented},
+    {"get_setting", 11, BLERPC_CMD_ID_GET_SETTING, &builtin_implemented},
+    {"set_setting", 11, BLERPC_CMD_ID_SET_SETTING, &builtin_implemented},
+#endif
+};
+
+const struct blerpc_capability *blerpc_capabilities(size_t *count)
+{
+    *count = sizeof(capabilities) / sizeof(capabilities[0]);
+    return capabilities;
+}
+
+/* get_rpc_stats counters, in handler table order. */
+enum {
+#if BLERPC_CMDS_BLERPC
+    RPC_STAT_ECHO,
+    RPC_STAT_FLASH_READ,
+    RPC_STAT_DATA_WRITE,
+    RPC_STAT_COUNTER_STREAM,
+    RPC_STAT_COUNTER_UPLOAD,
+    RPC_STAT_GET_BLERPC_INFO,
+    RPC_STAT_CONN_PARAMS,
+    RPC_STAT_DFU_BEGIN,
+    RPC_STAT_DFU_CHUNK,
+    RPC_STAT_DFU_FINALIZE,
+    RPC_STAT_FILE_OPEN,
+    RPC_STAT_FILE_READ,
+    RPC_STAT_FILE_WRITE,
+    RPC_STAT_FILE_CLOSE,
+    RPC_STAT_LOG_STREAM,
+    RPC_STAT_GET_RPC_STATS,
+    RPC_STAT_START_SESSION,
+    RPC_STAT_AUTHENTICATE_SESSION,
+    RPC_STAT_TIME_SYNC,
+    RPC_STAT_PING,
+    RPC_STAT_GET_CAPABILITIES,
+    RPC_STAT_GET_SETTING,
+    RPC_STAT_SET_SETTING,
+#endif
+    RPC_STAT_COUNT
+};
+
+static struct blerpc_rpc_stat rpc_stats[RPC_STAT_COUNT] = {
+#if BLERPC_CMDS_BLERPC
+    {"echo", 4, 0, 0, 0},
+    {"flash_read", 10, 0, 0, 0},
+    {"data_write", 10, 0, 0, 0},
+    {"counter_stream", 14, 0, 0, 0},
+    {"counter_upload", 14, 0, 0, 0},
+    {"get_blerpc_info", 15, 0, 0, 0},
+    {"conn_params", 11, 0, 0, 0},
+    {"dfu_begin", 9, 0, 0, 0},
+    {"dfu_chunk", 9, 0, 0, 0},
+    {"dfu_finalize", 12, 0, 0, 0},
+    {"file_open", 9, 0, 0, 0},
+    {"file_read", 9, 0, 0, 0},
+    {"file_write", 10, 0, 0, 0},
+    {"file_close", 10, 0, 0, 0},
+    {"log_stream", 10, 0, 0, 0},
+    {"get_rpc_stats", 13, 0, 0, 0},
+    {"start_session", 13, 0, 0, 0},
+    {"authenticate_session", 20, 0, 0, 0},
+    {"time_sync", 9, 0, 0, 0},
+    {"ping", 4, 0, 0, 0},
+    {"get_capabilities", 16, 0, 0, 0},
+    {"get_setting", 11, 0, 0, 0},
+    {"set_setting", 11, 0, 0, 0},
+#endif
+};
+
+struct blerpc_rpc_stat *blerpc_rpc_stats(size_t *count)
+{
+    *count = RPC_STAT_COUNT;
+    return rpc_stats;
+}
+
+#ifdef __ZEPHYR__
+#include <zephyr/kernel.h>
+#endif
+
+__attribute__((weak))
+uint32_t blerpc_rpc_stats_now_us(void)
+{
+#ifdef __ZEPHYR__
+    return k_ticks_to_us_floor32(k_uptime_ticks());
+#else
+    return 0;
+#endif
+}
+
+static int run_counted(size_t index, command_handler_fn handler,
+                       const uint8_t *req_data, size_t req_len,
+                       pb_ostream_t *ostream)
+{
+    uint32_t start = blerpc_rpc_stats_now_us();
+    int rc = handler(req_data, req_len, ostream);
+    /* The sizing pass has no callback; count it only if it ends the request. */
+    if (ostream->callback != NULL || rc != 0) {
+        struct blerpc_rpc_stat *stat = &rpc_stats[index];
+        uint32_t elapsed = blerpc_rpc_stats_now_us() - start;
+        stat->calls++;
+        if (rc != 0 && rc != -2) {
+            stat->errors++;
+        }
+        if (elapsed > stat->max_duration_us) {
+            stat->max_duration_us = elapsed;
+        }
+    }
+    return rc;
+}
+
+#if BLERPC_CMDS_BLERPC
+static int counted_echo(const uint8_t *req_data, size_t req_len,
+                        pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_ECHO, handle_echo,
+                       req_data, req_len, ostream);
+}
+
+static int counted_flash_read(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_FLASH_READ, handle_flash_read,
+                       req_data, req_len, ostream);
+}
+
+static int counted_data_write(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_DATA_WRITE, handle_data_write,
+                       req_data, req_len, ostream);
+}
+
+static int counted_counter_stream(const uint8_t *req_data, size_t req_len,
+                                  pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_COUNTER_STREAM, handle_counter_stream,
+                       req_data, req_len, ostream);
+}
+
+static int counted_counter_upload(const uint8_t *req_data, size_t req_len,
+                                  pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_COUNTER_UPLOAD, handle_counter_upload,
+                       req_data, req_len, ostream);
+}
+
+static int counted_get_blerpc_info(const uint8_t *req_data, size_t req_len,
+                                   pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_GET_BLERPC_INFO, handle_get_blerpc_info,
+                       req_data, req_len, ostream);
+}
+
+static int counted_conn_params(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_CONN_PARAMS, handle_conn_params,
+                       req_data, req_len, ostream);
+}
+
+static int counted_dfu_begin(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_DFU_BEGIN, handle_dfu_begin,
+                       req_data, req_len, ostream);
+}
+
+static int counted_dfu_chunk(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_DFU_CHUNK, handle_dfu_chunk,
+                       req_data, req_len, ostream);
+}
+
+static int counted_dfu_finalize(const uint8_t *req_data, size_t req_len,
+                                pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_DFU_FINALIZE, handle_dfu_finalize,
+                       req_data, req_len, ostream);
+}
+
+static int counted_file_open(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_FILE_OPEN, handle_file_open,
+                       req_data, req_len, ostream);
+}
+
+static int counted_file_read(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_FILE_READ, handle_file_read,
+                       req_data, req_len, ostream);
+}
+
+static int counted_file_write(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_FILE_WRITE, handle_file_write,
+                       req_data, req_len, ostream);
+}
+
+static int counted_file_close(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_FILE_CLOSE, handle_file_close,
+                       req_data, req_len, ostream);
+}
+
+static int counted_log_stream(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_LOG_STREAM, handle_log_stream,
+                       req_data, req_len, ostream);
+}
+
+static int counted_get_rpc_stats(const uint8_t *req_data, size_t req_len,
+                                 pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_GET_RPC_STATS, handle_get_rpc_stats,
+                       req_data, req_len, ostream);
+}
+
+static int counted_start_session(const uint8_t *req_data, size_t req_len,
+                                 pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_START_SESSION, handle_start_session,
+                       req_data, req_len, ostream);
+}
+
+static int counted_authenticate_session(const uint8_t *req_data, size_t req_len,
+                                        pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_AUTHENTICATE_SESSION, handle_authenticate_session,
+                       req_data, req_len, ostream);
+}
+
+static int counted_time_sync(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_TIME_SYNC, handle_time_sync,
+                       req_data, req_len, ostream);
+}
+
+static int counted_ping(const uint8_t *req_data, size_t req_len,
+                        pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_PING, handle_ping,
+                       req_data, req_len, ostream);
+}
+
+static int counted_get_capabilities(const uint8_t *req_data, size_t req_len,
+                                    pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_GET_CAPABILITIES, handle_get_capabilities,
+                       req_data, req_len, ostream);
+}
+
+static int counted_get_setting(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_GET_SETTING, handle_get_setting,
+                       req_data, req_len, ostream);
+}
+
+static int counted_set_setting(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream)
+{
+    return run_counted(RPC_STAT_SET_SETTING, handle_set_setting,
+                       req_data, req_len, ostream);
+}
+#endif
+
+__attribute__((weak))
+int blerpc_replay_mac(const char *command, const uint8_t *counter,
+                      const uint8_t *payload, size_t payload_len, uint8_t *mac)
+{
+    (void)command;
+    (void)counter;
+    (void)payload;
+    (void)payload_len;
+    (void)mac;
+    return -1;
+}
+
+__attribute__((weak))
+uint64_t blerpc_replay_counter_load(void)
+{
+    return 0;
+}
+
+__attribute__((weak))
+void blerpc_replay_counter_store(uint64_t counter)
+{
+    (void)counter;
+}
+
+static uint64_t replay_last;
+static bool replay_loaded;
+
+/* Checks the MAC of a replay-protected request to command and its counter
+ * against the last one accepted. The sizing pass only checks; the writing
+ * pass accepts the counter. */
+static bool replay_fresh(const char *command, const uint8_t *req_data, size_t req_len,
+                         bool accept)
+{
+    uint64_t counter = 0;
+    uint8_t mac[32];
+    uint8_t diff = 0;
+    size_t i;
+    if (req_len < 8 + BLERPC_REPLAY_MAC_SIZE) return false;
+    if (!replay_loaded) {
+        replay_last = blerpc_replay_counter_load();
+        replay_loaded = true;
+    }
+    for (i = 0; i < 8; i++) {
+        counter |= (uint64_t)req_data[i] << (8 * i);
+    }
+    if (counter <= replay_last) return false;
+    if (blerpc_replay_mac(command, req_data, req_data + 8 + BLERPC_REPLAY_MAC_SIZE,
+                          req_len - 8 - BLERPC_REPLAY_MAC_SIZE, mac) != 0) {
+        return false;
+    }
+    /* Look at every byte, so the time taken tells nothing of the MAC. */
+    for (i = 0; i < BLERPC_REPLAY_MAC_SIZE; i++) {
+        diff |= mac[i] ^ req_data[8 + i];
+    }
+    if (diff != 0) return false;
+    if (accept) {
+        replay_last = counter;
+        blerpc_replay_counter_store(counter);
+    }
+    return true;
+}
+
+#if BLERPC_CMDS_BLERPC
+static int guarded_flash_read(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    if (!replay_fresh("flash_read", req_data, req_len, ostream->callback != NULL)) {
+        return HANDLER_REPLAYED;
+    }
+    return counted_flash_read(req_data + 24, req_len - 24, ostream);
+}
+#endif
+
+#if BLERPC_CMDS_BLERPC
+static int authed_flash_read(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    if (req_len < BLERPC_SESSION_TOKEN_SIZE || !blerpc_session_check(req_data)) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_UNAUTHENTICATED);
+    }
+    return guarded_flash_read(req_data + BLERPC_SESSION_TOKEN_SIZE,
+                              req_len - BLERPC_SESSION_TOKEN_SIZE, ostream);
+}
+#endif
+
+#if BLERPC_CMDS_BLERPC
+static int sized_echo(const uint8_t *req_data, size_t req_len,
+                      pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_ECHO_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_echo(req_data, req_len, ostream);
+}
+
+static int sized_flash_read(const uint8_t *req_data, size_t req_len,
+                            pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_FLASH_READ_MAX_REQ_SIZE + BLERPC_SESSION_TOKEN_SIZE + 24) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return authed_flash_read(req_data, req_len, ostream);
+}
+
+static int sized_counter_stream(const uint8_t *req_data, size_t req_len,
+                                pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_COUNTER_STREAM_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_counter_stream(req_data, req_len, ostream);
+}
+
+static int sized_counter_upload(const uint8_t *req_data, size_t req_len,
+                                pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_COUNTER_UPLOAD_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_counter_upload(req_data, req_len, ostream);
+}
+
+static int sized_get_blerpc_info(const uint8_t *req_data, size_t req_len,
+                                 pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_GET_BLERPC_INFO_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_get_blerpc_info(req_data, req_len, ostream);
+}
+
+static int sized_conn_params(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_CONN_PARAMS_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_conn_params(req_data, req_len, ostream);
+}
+
+static int sized_dfu_begin(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_DFU_BEGIN_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_dfu_begin(req_data, req_len, ostream);
+}
+
+static int sized_dfu_finalize(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_DFU_FINALIZE_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_dfu_finalize(req_data, req_len, ostream);
+}
+
+static int sized_file_read(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_FILE_READ_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_file_read(req_data, req_len, ostream);
+}
+
+static int sized_file_close(const uint8_t *req_data, size_t req_len,
+                            pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_FILE_CLOSE_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_file_close(req_data, req_len, ostream);
+}
+
+static int sized_log_stream(const uint8_t *req_data, size_t req_len,
+                            pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_LOG_STREAM_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_log_stream(req_data, req_len, ostream);
+}
+
+static int sized_get_rpc_stats(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_GET_RPC_STATS_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_get_rpc_stats(req_data, req_len, ostream);
+}
+
+static int sized_start_session(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_START_SESSION_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_start_session(req_data, req_len, ostream);
+}
+
+static int sized_time_sync(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_TIME_SYNC_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_time_sync(req_data, req_len, ostream);
+}
+
+static int sized_get_capabilities(const uint8_t *req_data, size_t req_len,
+                                  pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_GET_CAPABILITIES_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_get_capabilities(req_data, req_len, ostream);
+}
+
+static int sized_get_setting(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream)
+{
+    if (req_len > BLERPC_GET_SETTING_MAX_REQ_SIZE) {
+        return blerpc_return_error(ostream, BLERPC_STATUS_INVALID_ARGUMENT);
+    }
+    return counted_get_setting(req_data, req_len, ostream);
+}
+#endif
+
+/* Role each command requires, in handler table order. */
+static const uint8_t roles[] = {
+#if BLERPC_CMDS_BLERPC
+    BLERPC_ROLE_USER, /* echo */
+    BLERPC_ROLE_FACTORY, /* flash_read */
+    BLERPC_ROLE_USER, /* data_write */
+    BLERPC_ROLE_USER, /* counter_stream */
+    BLERPC_ROLE_USER, /* counter_upload */
+    BLERPC_ROLE_USER, /* get_blerpc_info */
+    BLERPC_ROLE_USER, /* conn_params */
+    BLERPC_ROLE_USER, /* dfu_begin */
+    BLERPC_ROLE_USER, /* dfu_chunk */
+    BLERPC_ROLE_USER, /* dfu_finalize */
+    BLERPC_ROLE_USER, /* file_open */
+    BLERPC_ROLE_USER, /* file_read */
+    BLERPC_ROLE_USER, /* file_write */
+    BLERPC_ROLE_USER, /* file_close */
+    BLERPC_ROLE_USER, /* log_stream */
+    BLERPC_ROLE_USER, /* get_rpc_stats */
+    BLERPC_ROLE_USER, /* start_session */
+    BLERPC_ROLE_USER, /* authenticate_session */
+    BLERPC_ROLE_USER, /* time_sync */
+    BLERPC_ROLE_USER, /* ping */
+    BLERPC_ROLE_USER, /* get_capabilities */
+    BLERPC_ROLE_USER, /* get_setting */
+    BLERPC_ROLE_USER, /* set_setting */
+#endif
+};
+
+__attribute__((weak))
+enum blerpc_role blerpc_current_role(void)
+{
+    return BLERPC_ROLE_USER;
+}
+
+__attribute__((weak))
+void blerpc_on_rate_limited(const char *name, uint8_t name_len)
+{
+    (void)name;
+    (void)name_len;
+}
+
+#ifdef __ZEPHYR__
+#include <zephyr/kernel.h>
+
+__attribute__((weak))
+uint32_t blerpc_rate_limit_now_ms(void)
+{
+    return k_uptime_get_32();
+}
+#endif
+
+/* Calls per second accepted by each command (0: unlimited), in handler
+ * table order. */
+static const uint16_t rate_limits[] = {
+#if BLERPC_CMDS_BLERPC
+    0, /* echo */
+    0, /* flash_read */
+    2, /* data_write */
+    0, /* counter_stream */
+    0, /* counter_upload */
+    0, /* get_blerpc_info */
+    0, /* conn_params */
+    0, /* dfu_begin */
+    0, /* dfu_chunk */
+    0, /* dfu_finalize */
+    0, /* file_open */
+    0, /* file_read */
+    0, /* file_write */
+    0, /* file_close */
+    0, /* log_stream */
+    0, /* get_rpc_stats */
+    0, /* start_session */
+    0, /* authenticate_session */
+    0, /* time_sync */
+    0, /* ping */
+    0, /* get_capabilities */
+    0, /* get_setting */
+    0, /* set_setting */
+#endif
+};
+
+static struct {
+    uint32_t start_ms;
+    uint16_t calls;
+} rate_windows[sizeof(rate_limits) / sizeof(rate_limits[0])];
+
+/* Counts a call against the current one-second window of command i and
+ * reports whether it is within the limit. */
+static int rate_limit_take(size_t i)
+{
+    if (rate_limits[i] == 0) return 1;
+    uint32_t now = blerpc_rate_limit_now_ms();
+    if (now - rate_windows[i].start_ms >= 1000 || rate_windows[i].calls == 0) {
+        rate_windows[i].start_ms = now;
+        rate_windows[i].calls = 0;
+    }
+    if (rate_windows[i].calls >= rate_limits[i]) return 0;
+    rate_windows[i].calls++;
+    return 1;
+}
+
+#define BUCKET_FULL ((uint32_t)BLERPC_RATE_LIMIT_BURST * 1000u)
+
+static uint32_t bucket_tokens = BUCKET_FULL;
+static uint32_t bucket_ms;
+
+/* Refills the token bucket for the time since the last call and takes a
+ * token from it, reporting whether there was one. */
+static int bucket_take(void)
+{
+    uint32_t now = blerpc_rate_limit_now_ms();
+    uint64_t tokens = bucket_tokens + (uint64_t)(now - bucket_ms) * BLERPC_RATE_LIMIT_PER_SECOND;
+    bucket_ms = now;
+    if (tokens > BUCKET_FULL) tokens = BUCKET_FULL;
+    if (tokens < 1000) {
+        bucket_tokens = (uint32_t)tokens;
+        return 0;
+    }
+    bucket_tokens = (uint32_t)(tokens - 1000);
+    return 1;
+}
+
+static int reject_rate_limited(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream)
+{
+    (void)req_data;
+    (void)req_len;
+    (void)ostream;
+    return HANDLER_BUSY;
+}
+
+/* Positions in the handler table. */
+enum {
+#if BLERPC_CMDS_BLERPC
+    TABLE_ECHO,
+    TABLE_FLASH_READ,
+    TABLE_DATA_WRITE,
+    TABLE_COUNTER_STREAM,
+    TABLE_COUNTER_UPLOAD,
+    TABLE_GET_BLERPC_INFO,
+    TABLE_CONN_PARAMS,
+    TABLE_DFU_BEGIN,
+    TABLE_DFU_CHUNK,
+    TABLE_DFU_FINALIZE,
+    TABLE_FILE_OPEN,
+    TABLE_FILE_READ,
+    TABLE_FILE_WRITE,
+    TABLE_FILE_CLOSE,
+    TABLE_LOG_STREAM,
+    TABLE_GET_RPC_STATS,
+    TABLE_START_SESSION,
+    TABLE_AUTHENTICATE_SESSION,
+    TABLE_TIME_SYNC,
+    TABLE_PING,
+    TABLE_GET_CAPABILITIES,
+    TABLE_GET_SETTING,
+    TABLE_SET_SETTING,
+#endif
+    TABLE_COUNT
+};
+
+/* Table position + 1 of each command ID; 0 for unused IDs. */
+static const uint8_t id_slots[128] = {
+#if BLERPC_CMDS_BLERPC
+    [BLERPC_CMD_ID_ECHO] = TABLE_ECHO + 1,
+    [BLERPC_CMD_ID_FLASH_READ] = TABLE_FLASH_READ + 1,
+    [BLERPC_CMD_ID_DATA_WRITE] = TABLE_DATA_WRITE + 1,
+    [BLERPC_CMD_ID_COUNTER_STREAM] = TABLE_COUNTER_STREAM + 1,
+    [BLERPC_CMD_ID_COUNTER_UPLOAD] = TABLE_COUNTER_UPLOAD + 1,
+    [BLERPC_CMD_ID_GET_BLERPC_INFO] = TABLE_GET_BLERPC_INFO + 1,
+    [BLERPC_CMD_ID_CONN_PARAMS] = TABLE_CONN_PARAMS + 1,
+    [BLERPC_CMD_ID_DFU_BEGIN] = TABLE_DFU_BEGIN + 1,
+    [BLERPC_CMD_ID_DFU_CHUNK] = TABLE_DFU_CHUNK + 1,
+    [BLERPC_CMD_ID_DFU_FINALIZE] = TABLE_DFU_FINALIZE + 1,
+    [BLERPC_CMD_ID_FILE_OPEN] = TABLE_FILE_OPEN + 1,
+    [BLERPC_CMD_ID_FILE_READ] = TABLE_FILE_READ + 1,
+    [BLERPC_CMD_ID_FILE_WRITE] = TABLE_FILE_WRITE + 1,
+    [BLERPC_CMD_ID_FILE_CLOSE] = TABLE_FILE_CLOSE + 1,
+    [BLERPC_CMD_ID_LOG_STREAM] = TABLE_LOG_STREAM + 1,
+    [BLERPC_CMD_ID_GET_RPC_STATS] = TABLE_GET_RPC_STATS + 1,
+    [BLERPC_CMD_ID_START_SESSION] = TABLE_START_SESSION + 1,
+    [BLERPC_CMD_ID_AUTHENTICATE_SESSION] = TABLE_AUTHENTICATE_SESSION + 1,
+    [BLERPC_CMD_ID_TIME_SYNC] = TABLE_TIME_SYNC + 1,
+    [BLERPC_CMD_ID_PING] = TABLE_PING + 1,
+    [BLERPC_CMD_ID_GET_CAPABILITIES] = TABLE_GET_CAPABILITIES + 1,
+    [BLERPC_CMD_ID_GET_SETTING] = TABLE_GET_SETTING + 1,
+    [BLERPC_CMD_ID_SET_SETTING] = TABLE_SET_SETTING + 1,
+#endif
+};
+
+/* Perfect hash of the command names: the seed of a name's bucket sends
+ * it to its own slot, which holds its table position + 1. */
+#define HASH_BUCKETS 12
+#define HASH_SLOTS 28
+
+static const uint8_t hash_seeds[HASH_BUCKETS] = {
+    1, 0, 4, 2, 1, 2, 0, 0, 4, 0, 0, 9,
+};
+
+static const uint8_t hash_slots[HASH_SLOTS] = {
+#if BLERPC_CMDS_BLERPC
+    [20] = TABLE_ECHO + 1,
+    [22] = TABLE_FLASH_READ + 1,
+    [15] = TABLE_DATA_WRITE + 1,
+    [5] = TABLE_COUNTER_STREAM + 1,
+    [23] = TABLE_COUNTER_UPLOAD + 1,
+    [9] = TABLE_GET_BLERPC_INFO + 1,
+    [10] = TABLE_CONN_PARAMS + 1,
+    [4] = TABLE_DFU_BEGIN + 1,
+    [12] = TABLE_DFU_CHUNK + 1,
+    [21] = TABLE_DFU_FINALIZE + 1,
+    [27] = TABLE_FILE_OPEN + 1,
+    [3] = TABLE_FILE_READ + 1,
+    [14] = TABLE_FILE_WRITE + 1,
+    [1] = TABLE_FILE_CLOSE + 1,
+    [26] = TABLE_LOG_STREAM + 1,
+    [24] = TABLE_GET_RPC_STATS + 1,
+    [8] = TABLE_START_SESSION + 1,
+    [13] = TABLE_AUTHENTICATE_SESSION + 1,
+    [18] = TABLE_TIME_SYNC + 1,
+    [17] = TABLE_PING + 1,
+    [2] = TABLE_GET_CAPABILITIES + 1,
+    [16] = TABLE_GET_SETTING + 1,
+    [7] = TABLE_SET_SETTING + 1,
+#endif
+};
+
+static uint32_t name_hash(uint32_t seed, const char *name, uint8_t name_len)
+{
+    uint32_t h = 2166136261u ^ seed;
+    uint8_t i;
+    for (i = 0; i < name_len; i++) {
+        h = (h ^ (uint8_t)name[i]) * 16777619u;
+    }
+    return h;
+}
+
+static const struct handler_entry handler_table[] = {
+#if BLERPC_CMDS_BLERPC
+    {"echo", 4, sized_echo},
+    {"flash_read", 10, sized_flash_read},
+    {"data_write", 10, counted_data_write},
+    {"counter_stream", 14, sized_counter_stream},
+    {"counter_upload", 14, sized_counter_upload},
+    {"get_blerpc_info", 15, sized_get_blerpc_info},
+    {"conn_params", 11, sized_conn_params},
+    {"dfu_begin", 9, sized_dfu_begin},
+    {"dfu_chunk", 9, counted_dfu_chunk},
+    {"dfu_finalize", 12, sized_dfu_finalize},
+    {"file_open", 9, counted_file_open},
+    {"file_read", 9, sized_file_read},
+    {"file_write", 10, counted_file_write},
+    {"file_close", 10, sized_file_close},
+    {"log_stream", 10, sized_log_stream},
+    {"get_rpc_stats", 13, sized_get_rpc_stats},
+    {"start_session", 13, sized_start_session},
+    {"authenticate_session", 20, counted_authenticate_session},
+    {"time_sync", 9, sized_time_sync},
+    {"ping", 4, counted_ping},
+    {"get_capabilities", 16, sized_get_capabilities},
+    {"get_setting", 11, sized_get_setting},
+    {"set_setting", 11, counted_set_setting},
+#endif
+};
+
+static int handler_index(const char *name, uint8_t name_len)
+{
+    /* A one-byte name carries the command ID. */
+    if (name_len == 1 && (uint8_t)name[0] < 128 && id_slots[(uint8_t)name[0]] != 0) {
+        return id_slots[(uint8_t)name[0]] - 1;
+    }
+    uint8_t seed = hash_seeds[name_hash(0, name, name_len) % HASH_BUCKETS];
+    int i = (int)hash_slots[name_hash(seed, name, name_len) % HASH_SLOTS] - 1;
+    if (i < 0 || handler_table[i].name_len != name_len ||
+        memcmp(handler_table[i].name, name, name_len) != 0) {
+        return -1;
+    }
+    return i;
+}
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len)
+{
+    if (name_len == sizeof(BLERPC_BATCH_CMD_NAME) - 1 &&
+        memcmp(name, BLERPC_BATCH_CMD_NAME, name_len) == 0) {
+        return blerpc_batch_handler;
+    }
+    if (name_len == sizeof(BLERPC_COMPRESSED_CMD_NAME) - 1 &&
+        memcmp(name, BLERPC_COMPRESSED_CMD_NAME, name_len) == 0) {
+        return blerpc_compressed_handler;
+    }
+    int i = handler_index(name, name_len);
+    if (i < 0) return NULL;
+    /* Commands above the current role look unknown. */
+    if (roles[i] > blerpc_current_role()) return NULL;
+    if (!rate_limit_take((size_t)i) || !bucket_take()) {
+        blerpc_on_rate_limited(name, name_len);
+        return reject_rate_limited;
+    }
+    return handler_table[i].handler;
+}
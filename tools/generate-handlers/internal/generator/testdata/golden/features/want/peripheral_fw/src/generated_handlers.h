@@ -0,0 +1,548 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_HANDLERS_H
+#define BLERPC_GENERATED_HANDLERS_H
+
+#include <stdint.h>
+#include <stddef.h>
+#include <pb_encode.h>
+#include "blerpc.pb.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+typedef int (*command_handler_fn)(const uint8_t *req_data, size_t req_len,
+                                  pb_ostream_t *ostream);
+
+struct handler_entry {
+    const char *name;
+    uint8_t name_len;
+    command_handler_fn handler;
+};
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len);
+
+/* Returned for a request over its command's rate limit; the dispatcher
+ * answers it with a BUSY error. */
+#define HANDLER_BUSY (-3)
+
+/* Returned for a replay-protected request whose MAC is wrong or whose
+ * counter is not newer than the last one accepted; the dispatcher drops
+ * it. */
+#define HANDLER_REPLAYED (-4)
+
+/* Status codes of error responses. Codes from 128 up are free for
+ * application use. */
+enum blerpc_status {
+    BLERPC_STATUS_OK = 0,
+    BLERPC_STATUS_INVALID_ARGUMENT = 1,
+    BLERPC_STATUS_NOT_FOUND = 2,
+    BLERPC_STATUS_ALREADY_EXISTS = 3,
+    BLERPC_STATUS_PERMISSION_DENIED = 4,
+    BLERPC_STATUS_RESOURCE_EXHAUSTED = 5,
+    BLERPC_STATUS_FAILED_PRECONDITION = 6,
+    BLERPC_STATUS_OUT_OF_RANGE = 7,
+    BLERPC_STATUS_UNIMPLEMENTED = 8,
+    BLERPC_STATUS_INTERNAL = 9,
+    BLERPC_STATUS_UNAVAILABLE = 10,
+    BLERPC_STATUS_UNAUTHENTICATED = 11,
+};
+
+/* Field number of the status in an error response; no message uses it. */
+#define BLERPC_STATUS_FIELD 536870911
+
+/*
+ * Write an error response carrying status in place of the response
+ * message, e.g.
+ *
+ *     return blerpc_return_error(ostream, BLERPC_STATUS_NOT_FOUND);
+ *
+ * Return 0, or -1 if the status does not fit.
+ */
+static inline int blerpc_return_error(pb_ostream_t *ostream, uint32_t status)
+{
+    if (!pb_encode_tag(ostream, PB_WT_VARINT, BLERPC_STATUS_FIELD)) return -1;
+    return pb_encode_varint(ostream, status) ? 0 : -1;
+}
+
+/* Millisecond clock of the rate limit windows; only differences are used,
+ * so it may wrap. Zephyr builds get a weak default reading the uptime;
+ * other platforms must define it. */
+uint32_t blerpc_rate_limit_now_ms(void);
+
+/* Called for each call rejected over a rate limit, with the command name
+ * as received, before it is answered with a BUSY error. The weak default
+ * does nothing. */
+void blerpc_on_rate_limited(const char *name, uint8_t name_len);
+
+/* Calls per second the token bucket of all commands refills with, and the
+ * calls it holds; define them to override blerpc.yaml. */
+#ifndef BLERPC_RATE_LIMIT_PER_SECOND
+#define BLERPC_RATE_LIMIT_PER_SECOND 20
+#endif
+#ifndef BLERPC_RATE_LIMIT_BURST
+#define BLERPC_RATE_LIMIT_BURST 40
+#endif
+
+/* Roles a command may require; each includes the ones before it. */
+enum blerpc_role {
+    BLERPC_ROLE_USER = 0,
+    BLERPC_ROLE_INSTALLER = 1,
+    BLERPC_ROLE_FACTORY = 2,
+};
+
+/* Role of the current connection, e.g. raised by an unlock command or a
+ * factory jumper. The weak default returns BLERPC_ROLE_USER. */
+enum blerpc_role blerpc_current_role(void);
+
+/* Length of the MAC after the replay counter: the first bytes of an
+ * HMAC-SHA256 under the key shared with the central. */
+#define BLERPC_REPLAY_MAC_SIZE 16
+
+/* Writes to mac, 32 bytes, the HMAC-SHA256 under the key shared with the
+ * central of command with its NUL, the 8 counter bytes leading a
+ * replay-protected request, then its payload. Returns 0 on success; the weak
+ * default returns -1, so every replay-protected request is dropped until it
+ * is overridden. */
+int blerpc_replay_mac(const char *command, const uint8_t *counter,
+                      const uint8_t *payload, size_t payload_len, uint8_t *mac);
+
+/* Last replay counter accepted, kept across reboots so a recorded request
+ * cannot be replayed after one. The weak defaults keep it in RAM only. */
+uint64_t blerpc_replay_counter_load(void);
+void blerpc_replay_counter_store(uint64_t counter);
+
+/* Numeric command IDs. Sent as a one-byte command name, an ID dispatches
+ * like the full name. */
+enum blerpc_command_id {
+    BLERPC_CMD_ID_ECHO = 1,
+    BLERPC_CMD_ID_FLASH_READ = 2,
+    BLERPC_CMD_ID_DATA_WRITE = 3,
+    BLERPC_CMD_ID_COUNTER_STREAM = 4,
+    BLERPC_CMD_ID_COUNTER_UPLOAD = 5,
+    BLERPC_CMD_ID_GET_BLERPC_INFO = 6,
+    BLERPC_CMD_ID_CONN_PARAMS = 7,
+    BLERPC_CMD_ID_DFU_BEGIN = 8,
+    BLERPC_CMD_ID_DFU_CHUNK = 9,
+    BLERPC_CMD_ID_DFU_FINALIZE = 10,
+    BLERPC_CMD_ID_FILE_OPEN = 11,
+    BLERPC_CMD_ID_FILE_READ = 12,
+    BLERPC_CMD_ID_FILE_WRITE = 13,
+    BLERPC_CMD_ID_FILE_CLOSE = 14,
+    BLERPC_CMD_ID_LOG_STREAM = 15,
+    BLERPC_CMD_ID_GET_RPC_STATS = 16,
+    BLERPC_CMD_ID_START_SESSION = 17,
+    BLERPC_CMD_ID_AUTHENTICATE_SESSION = 18,
+    BLERPC_CMD_ID_TIME_SYNC = 19,
+    BLERPC_CMD_ID_PING = 20,
+    BLERPC_CMD_ID_GET_CAPABILITIES = 21,
+    BLERPC_CMD_ID_GET_SETTING = 22,
+    BLERPC_CMD_ID_SET_SETTING = 23,
+};
+
+/* Command groups in the handler table; define one to 0 to leave its
+ * commands out. Zephyr builds set them from Kconfig.generated. */
+#ifndef BLERPC_CMDS_BLERPC
+#define BLERPC_CMDS_BLERPC 1
+#endif
+
+/* Largest encoded request and response of each command, from the
+ * max_size and max_count bounds in the .options file. Messages with an
+ * unbounded field (FT_CALLBACK, or no bound) have no constant. */
+#define BLERPC_ECHO_MAX_REQ_SIZE 259
+#define BLERPC_ECHO_MAX_RESP_SIZE 259
+#define BLERPC_FLASH_READ_MAX_REQ_SIZE 12
+#define BLERPC_DATA_WRITE_MAX_RESP_SIZE 6
+#define BLERPC_COUNTER_STREAM_MAX_REQ_SIZE 6
+#define BLERPC_COUNTER_STREAM_MAX_RESP_SIZE 17
+#define BLERPC_COUNTER_UPLOAD_MAX_REQ_SIZE 17
+#define BLERPC_COUNTER_UPLOAD_MAX_RESP_SIZE 6
+#define BLERPC_GET_BLERPC_INFO_MAX_REQ_SIZE 0
+#define BLERPC_CONN_PARAMS_MAX_REQ_SIZE 11
+#define BLERPC_CONN_PARAMS_MAX_RESP_SIZE 24
+#define BLERPC_DFU_BEGIN_MAX_REQ_SIZE 12
+#define BLERPC_DFU_BEGIN_MAX_RESP_SIZE 12
+#define BLERPC_DFU_CHUNK_MAX_RESP_SIZE 6
+#define BLERPC_DFU_FINALIZE_MAX_REQ_SIZE 2
+#define BLERPC_DFU_FINALIZE_MAX_RESP_SIZE 6
+#define BLERPC_FILE_OPEN_MAX_RESP_SIZE 18
+#define BLERPC_FILE_READ_MAX_REQ_SIZE 18
+#define BLERPC_FILE_WRITE_MAX_RESP_SIZE 0
+#define BLERPC_FILE_CLOSE_MAX_REQ_SIZE 8
+#define BLERPC_FILE_CLOSE_MAX_RESP_SIZE 12
+#define BLERPC_LOG_STREAM_MAX_REQ_SIZE 17
+#define BLERPC_GET_RPC_STATS_MAX_REQ_SIZE 2
+#define BLERPC_START_SESSION_MAX_REQ_SIZE 0
+#define BLERPC_TIME_SYNC_MAX_REQ_SIZE 17
+#define BLERPC_TIME_SYNC_MAX_RESP_SIZE 11
+#define BLERPC_GET_CAPABILITIES_MAX_REQ_SIZE 0
+#define BLERPC_GET_SETTING_MAX_REQ_SIZE 6
+#define BLERPC_SET_SETTING_MAX_RESP_SIZE 0
+
+/* Read hooks of the FT_CALLBACK bytes fields of the requests, called with
+ * each chunk of the field as the request is decoded, once per request. The
+ * fields of req numbered before it are decoded already; offset is the
+ * position of chunk in the field. Return 0 to go on, or a negative value
+ * to fail the request. The weak defaults discard the data. */
+int blerpc_data_write_read_data(const blerpc_DataWriteRequest *req, const uint8_t *chunk,
+                                size_t len, size_t offset);
+
+/* Source hooks of the FT_CALLBACK bytes fields of the responses. The
+ * length hook returns the length of the field for req, 0 to leave it out;
+ * the source hook fills chunk with the len bytes of the field at offset
+ * and returns 0, or a negative value to fail the request. The chunks go
+ * out as they are filled, so the field may exceed the RAM of the device.
+ * The weak length hooks return 0 and the weak source hooks fail. */
+size_t blerpc_flash_read_length_data(const blerpc_FlashReadRequest *req);
+int blerpc_flash_read_source_data(const blerpc_FlashReadRequest *req, uint8_t *chunk,
+                                  size_t len, size_t offset);
+
+/* Command name of the batch envelope, a request carrying several unary
+ * requests that blerpc_batch_handler runs in order, answered by one
+ * response carrying their responses. handlers_lookup returns the handler
+ * for it. */
+#define BLERPC_BATCH_CMD_NAME "_batch"
+
+/* Bytes of responses one batch collects; a response that does not fit is
+ * answered with RESOURCE_EXHAUSTED. Define it to override. */
+#ifndef BLERPC_BATCH_BUF_SIZE
+#define BLERPC_BATCH_BUF_SIZE 1024
+#endif
+
+int blerpc_batch_handler(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream);
+
+/* Algorithms of compressed commands. */
+enum blerpc_compression {
+    BLERPC_COMPRESSION_NONE = 0,
+    BLERPC_COMPRESSION_DEFLATE = 1,
+    BLERPC_COMPRESSION_HEATSHRINK = 2,
+};
+
+/* CAPABILITIES flag telling centrals they may call compressed commands in
+ * the envelope named BLERPC_COMPRESSED_CMD_NAME, which blerpc_compressed_handler
+ * answers; handlers_lookup returns the handler for it. */
+#define BLERPC_CAPABILITY_FLAG_COMPRESSION 0x0002
+#define BLERPC_COMPRESSED_CMD_NAME "_compressed"
+
+/* Bytes of a decompressed request, and of a response before and after
+ * compression. Define it to override. */
+#ifndef BLERPC_COMPRESSION_BUF_SIZE
+#define BLERPC_COMPRESSION_BUF_SIZE 1024
+#endif
+
+/* Codecs of the compressed commands, which the firmware must define, e.g.
+ * with miniz for raw DEFLATE (RFC 1951) or heatshrink with a window of 8
+ * and a lookahead of 4 bits. Each writes at most out_size bytes to out and
+ * sets *out_len; return 0, or -1 on an error or when the result does not
+ * fit. */
+int blerpc_compress(enum blerpc_compression algorithm,
+                    const uint8_t *in, size_t in_len,
+                    uint8_t *out, size_t out_size, size_t *out_len);
+int blerpc_decompress(enum blerpc_compression algorithm,
+                      const uint8_t *in, size_t in_len,
+                      uint8_t *out, size_t out_size, size_t *out_len);
+
+int blerpc_compressed_handler(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream);
+
+int handle_echo(const uint8_t *req_data, size_t req_len,
+                    pb_ostream_t *ostream);
+
+int handle_flash_read(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream);
+
+/* Deprecated. */
+int handle_data_write(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream);
+
+int handle_counter_stream(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream);
+
+int handle_counter_upload(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream);
+
+int handle_get_blerpc_info(const uint8_t *req_data, size_t req_len,
+                               pb_ostream_t *ostream);
+
+int handle_conn_params(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream);
+
+int handle_dfu_begin(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream);
+
+int handle_dfu_chunk(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream);
+
+int handle_dfu_finalize(const uint8_t *req_data, size_t req_len,
+                            pb_ostream_t *ostream);
+
+int handle_file_open(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream);
+
+int handle_file_read(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream);
+
+int handle_file_write(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream);
+
+int handle_file_close(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream);
+
+int handle_log_stream(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream);
+
+int handle_get_rpc_stats(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream);
+
+int handle_start_session(const uint8_t *req_data, size_t req_len,
+                             pb_ostream_t *ostream);
+
+int handle_authenticate_session(const uint8_t *req_data, size_t req_len,
+                                    pb_ostream_t *ostream);
+
+int handle_time_sync(const uint8_t *req_data, size_t req_len,
+                         pb_ostream_t *ostream);
+
+int handle_ping(const uint8_t *req_data, size_t req_len,
+                    pb_ostream_t *ostream);
+
+int handle_get_capabilities(const uint8_t *req_data, size_t req_len,
+                                pb_ostream_t *ostream);
+
+int handle_get_setting(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream);
+
+int handle_set_setting(const uint8_t *req_data, size_t req_len,
+                           pb_ostream_t *ostream);
+
+/* Server-streaming handlers send each response with <pkg>_<cmd>_emit() and
+ * finish with return <pkg>_stream_end(), so the dispatcher sends no response
+ * of its own. The responses leave through two hooks returning 0 on success,
+ * whose weak defaults return -1: stream_write sends one encoded response of
+ * cmd_name, e.g. with command_serialize() and
+ * ble_service_send_command_response(), and stream_finish ends the stream,
+ * e.g. with ble_service_send_stream_end_p2c(). */
+int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len);
+int blerpc_stream_finish(void);
+
+/* Ends the stream; returns -2, or -1 when it could not be ended. */
+int blerpc_stream_end(void);
+
+/* Control command of the Cancel container a central sends to stop the
+ * server stream in progress; it carries no payload. */
+#define BLERPC_CONTROL_CMD_CANCEL 0x7
+
+/* Stops the server stream in progress at its handler's next
+ * blerpc_stream_should_stop() check. The transport calls it when a Cancel
+ * container arrives while a request runs; safe from any thread. */
+void blerpc_stream_cancel(void);
+
+/* Reports whether the central cancelled the server stream in progress.
+ * Streaming handlers check it between responses and, once set, stop
+ * emitting and return blerpc_stream_end(), which clears it. */
+bool blerpc_stream_should_stop(void);
+
+struct _blerpc_CounterStreamResponse;
+int blerpc_counter_stream_emit(const struct _blerpc_CounterStreamResponse *resp);
+
+/* Client-streaming handlers pass each request of the stream to
+ * <pkg>_<cmd>_accumulate(). When the central ends the stream, call
+ * <pkg>_stream_end_c2p(), e.g. from a work item submitted by the
+ * STREAM_END_C2P callback: it has <pkg>_<cmd>_finalize() fill the response
+ * and sends it through <pkg>_stream_write(). The weak defaults discard the
+ * requests and leave the response empty. All return 0 on success. */
+int blerpc_stream_end_c2p(void);
+
+struct _blerpc_CounterUploadRequest;
+struct _blerpc_CounterUploadResponse;
+int blerpc_counter_upload_accumulate(const struct _blerpc_CounterUploadRequest *req);
+int blerpc_counter_upload_finalize(struct _blerpc_CounterUploadResponse *resp);
+
+/* Reported by get_blerpc_info; the clients compare the schema hash with
+ * their own on connect. */
+#define BLERPC_SCHEMA_HASH "fb27bddde9c1f6b7"
+#define BLERPC_GENERATOR_VERSION "0.1.0"
+
+/* Marks a command implemented for get_capabilities: put BLERPC_IMPLEMENTS(echo);
+ * next to the handle_echo overriding the weak stub. Commands left on their
+ * stubs report as missing; built-ins always report as implemented. */
+#define BLERPC_IMPLEMENTS(cmd) const bool blerpc_implements_##cmd = true
+
+extern const bool blerpc_implements_echo;
+extern const bool blerpc_implements_flash_read;
+extern const bool blerpc_implements_data_write;
+extern const bool blerpc_implements_counter_stream;
+extern const bool blerpc_implements_counter_upload;
+
+struct blerpc_capability {
+    const char *name;
+    uint8_t name_len;
+    uint8_t id;
+    const bool *implemented;
+};
+
+/* Returns every command in the handler table and stores their number in
+ * count. */
+const struct blerpc_capability *blerpc_capabilities(size_t *count);
+
+/* Connection parameters requested by conn_params: intervals in 1.25 ms
+ * units, supervision timeout in 10 ms units. */
+struct blerpc_conn_params {
+    uint16_t interval_min;
+    uint16_t interval_max;
+    uint16_t latency;
+    uint16_t timeout;
+};
+
+/* Requests params for profile (a ConnProfile value) from the BLE stack,
+ * e.g. with bt_conn_le_param_update(). params holds the profile defaults
+ * and may be adjusted to what was requested. Returns 0 on success; the
+ * weak default returns -1, so conn_params fails until it is overridden. */
+int blerpc_conn_params_apply(uint8_t profile, struct blerpc_conn_params *params);
+
+/* Most bytes dfu_chunk accepts at once; dfu_begin reports it to the
+ * clients. */
+#ifndef BLERPC_DFU_CHUNK_SIZE
+#define BLERPC_DFU_CHUNK_SIZE 128
+#endif
+
+/* Update slot of dfu, e.g. MCUboot's secondary slot through Zephyr's
+ * flash_img_*() API. begin prepares the slot for an image of size bytes,
+ * e.g. by erasing it. write stores len bytes at offset; the image arrives in
+ * order, from 0 or from where an interrupted update stopped. finalize marks
+ * the image, whose CRC-32 has been checked, to boot, and with reboot
+ * restarts into it once the response has gone out, e.g. from a delayed
+ * work item. Each returns 0, or a negative value on error; the weak
+ * defaults return -1, so dfu fails until they are overridden. */
+int blerpc_dfu_begin(uint32_t size);
+int blerpc_dfu_write(uint32_t offset, const uint8_t *data, size_t len);
+int blerpc_dfu_finalize(bool reboot);
+
+/* Longest path file_open accepts, and most bytes file_read returns at once;
+ * the chunk is also the buffer of the CRC-32 read-back. */
+#ifndef BLERPC_FILE_PATH_SIZE
+#define BLERPC_FILE_PATH_SIZE 64
+#endif
+#ifndef BLERPC_FILE_CHUNK_SIZE
+#define BLERPC_FILE_CHUNK_SIZE 128
+#endif
+
+/* File system of file_transfer, e.g. over Zephyr's fs_*() API. open returns
+ * a handle >= 0 for path, opened for writing, truncated unless resume is set,
+ * or for reading. read returns the bytes read at offset, 0 at the end of the
+ * file, and must work on handles opened for writing too, so uploads can be
+ * verified. write and close return 0. Each returns a negative value on
+ * error; the weak defaults return -1, so file_transfer fails until they are
+ * overridden. */
+int blerpc_file_open(const char *path, bool write, bool resume);
+int blerpc_file_read(uint32_t handle, uint32_t offset, uint8_t *buf, size_t len);
+int blerpc_file_write(uint32_t handle, uint32_t offset, const uint8_t *data,
+                     size_t len);
+int blerpc_file_close(uint32_t handle);
+
+/* Entries kept by the log_stream ring buffer, and the bytes of a module
+ * name and of a message fragment in each, including the terminator. */
+#ifndef BLERPC_LOG_ENTRIES
+#define BLERPC_LOG_ENTRIES 32
+#endif
+#ifndef BLERPC_LOG_MODULE_SIZE
+#define BLERPC_LOG_MODULE_SIZE 16
+#endif
+#ifndef BLERPC_LOG_MESSAGE_SIZE
+#define BLERPC_LOG_MESSAGE_SIZE 64
+#endif
+
+/* Appends message to the buffer drained by log_stream, split over several
+ * entries when longer than a fragment; the oldest entries are overwritten
+ * when it is full. level is a LogLevel value. On Zephyr it may be called
+ * from any thread or ISR. */
+void blerpc_log_write(uint8_t level, const char *module, const char *message);
+
+/* Millisecond clock stamping the entries; only differences are used, so it
+ * may wrap. The weak default reads the Zephyr uptime and returns 0
+ * elsewhere. */
+uint32_t blerpc_log_now_ms(void);
+
+/* Send one encoded LogStreamResponse of log_stream, and end the stream,
+ * e.g. with command_serialize(), ble_service_send_command_response() and
+ * ble_service_send_stream_end_p2c(). Return 0 on success; the weak defaults
+ * return -1. */
+int blerpc_log_stream_send(const uint8_t *data, size_t len);
+int blerpc_log_stream_end(void);
+
+/* Bytes of payload ping echoes; a longer payload fails the call. Define
+ * it to override. */
+#ifndef BLERPC_PING_PAYLOAD_SIZE
+#define BLERPC_PING_PAYLOAD_SIZE 64
+#endif
+
+/* Uptime and link RSSI, in dBm, reported by ping. The weak uptime reads
+ * the Zephyr uptime and returns 0 elsewhere; the weak RSSI returns 0,
+ * unknown, until it is overridden, e.g. with an HCI Read RSSI command. */
+uint32_t blerpc_ping_uptime_ms(void);
+int8_t blerpc_ping_rssi(void);
+
+/* Per-command counters kept by the handler table for get_rpc_stats. */
+struct blerpc_rpc_stat {
+    const char *name;
+    uint8_t name_len;
+    uint32_t calls;
+    uint32_t errors;
+    uint32_t max_duration_us;
+};
+
+/* Returns the counters of every command in the handler table and stores
+ * their number in count. */
+struct blerpc_rpc_stat *blerpc_rpc_stats(size_t *count);
+
+/* Microsecond clock that times the handlers; only differences are used, so
+ * it may wrap. The weak default reads the Zephyr uptime and returns 0 (no
+ * durations) elsewhere. */
+uint32_t blerpc_rpc_stats_now_us(void);
+
+/* Lengths of the session challenge and token, and of the proof: an
+ * HMAC-SHA256 of the challenge under the key shared with the central. */
+#define BLERPC_SESSION_CHALLENGE_SIZE 16
+#define BLERPC_SESSION_TOKEN_SIZE 8
+#define BLERPC_SESSION_PROOF_SIZE 32
+
+/* Fills buf with len bytes from a cryptographic random source, e.g.
+ * sys_csrand_get() or psa_generate_random(). Returns 0 on success; the weak
+ * default returns -1, so no session starts until it is overridden. */
+int blerpc_session_random(uint8_t *buf, size_t len);
+
+/* Reports whether proof is the HMAC-SHA256 of challenge under the key shared
+ * with the central; compare in constant time. The weak default rejects
+ * every proof. */
+bool blerpc_session_verify(const uint8_t *challenge, const uint8_t *proof,
+                            size_t proof_len);
+
+/* Reports whether token, the first SESSION_TOKEN_SIZE bytes of a request,
+ * is the token of the open session. The guards of session-protected
+ * commands call it. */
+bool blerpc_session_check(const uint8_t *token);
+
+/* Ends the open session, e.g. on disconnect; protected commands fail with
+ * BLERPC_STATUS_UNAUTHENTICATED until the central authenticates again. */
+void blerpc_session_end(void);
+
+#include "blerpc.pb.h"
+
+/* Storage of the DeviceSettings fields read by get_setting and written by
+ * set_setting. field is a blerpc_DeviceSettings_<field>_tag value; load
+ * fills in that field of settings, store persists it, e.g. with the Zephyr
+ * settings subsystem. Return 0 on success; the weak defaults return -1. */
+int blerpc_setting_load(uint32_t field, blerpc_DeviceSettings *settings);
+int blerpc_setting_store(uint32_t field, const blerpc_DeviceSettings *settings);
+
+/* Sets the wall clock to unix_time_us, microseconds since the Unix epoch,
+ * for time_sync, e.g. with clock_settime(). Returns 0 on success; the weak
+ * default returns -1, so time_sync fails until it is overridden. */
+int blerpc_time_set(int64_t unix_time_us);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_HANDLERS_H */
@@ -0,0 +1,818 @@
+// Code generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7). DO NOT EDIT.
+
+// Package blerpcsim is a simulated blerpc peripheral for integration
+// tests. It serves the commands over TCP or a Unix socket, sending every
+// container of the wire package as one packet led by its length (uint16
+// LE), so a test transport only has to replace the GATT characteristic:
+//
+//	sim := blerpcsim.New(myHandler{})
+//	go sim.ListenAndServe(ctx, "tcp", "127.0.0.1:7001")
+//
+// Requests of session- and replay-protected commands are accepted without
+// checking their token, counter or MAC, and encryption is not simulated.
+package blerpcsim
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/tdaira/blerpc/central_go/proto"
+	"github.com/tdaira/blerpc/go/wire"
+)
+
+// Handler implements the commands of the simulated peripheral. Embed
+// DefaultHandler to implement only the commands a test needs. A returned
+// *StatusError becomes an error response of its status; other errors
+// become StatusInternal.
+type Handler interface {
+	// Echo answers the echo command.
+	Echo(ctx context.Context, req *pb.EchoRequest) (*pb.EchoResponse, error)
+	// FlashRead answers the flash_read command.
+	FlashRead(ctx context.Context, req *pb.FlashReadRequest) (*pb.FlashReadResponse, error)
+	// DataWrite answers the data_write command.
+	DataWrite(ctx context.Context, req *pb.DataWriteRequest) (*pb.DataWriteResponse, error)
+	// CounterStream answers the counter_stream stream, calling send for each response.
+	CounterStream(ctx context.Context, req *pb.CounterStreamRequest, send func(*pb.CounterStreamResponse) error) error
+	// CounterUpload answers the counter_upload stream once the central ends it.
+	CounterUpload(ctx context.Context, reqs []*pb.CounterUploadRequest) (*pb.CounterUploadResponse, error)
+	// GetBlerpcInfo answers the get_blerpc_info command.
+	GetBlerpcInfo(ctx context.Context, req *pb.GetBlerpcInfoRequest) (*pb.GetBlerpcInfoResponse, error)
+	// ConnParams answers the conn_params command.
+	ConnParams(ctx context.Context, req *pb.ConnParamsRequest) (*pb.ConnParamsResponse, error)
+	// DfuBegin answers the dfu_begin command.
+	DfuBegin(ctx context.Context, req *pb.DfuBeginRequest) (*pb.DfuBeginResponse, error)
+	// DfuChunk answers the dfu_chunk command.
+	DfuChunk(ctx context.Context, req *pb.DfuChunkRequest) (*pb.DfuChunkResponse, error)
+	// DfuFinalize answers the dfu_finalize command.
+	DfuFinalize(ctx context.Context, req *pb.DfuFinalizeRequest) (*pb.DfuFinalizeResponse, error)
+	// FileOpen answers the file_open command.
+	FileOpen(ctx context.Context, req *pb.FileOpenRequest) (*pb.FileOpenResponse, error)
+	// FileRead answers the file_read command.
+	FileRead(ctx context.Context, req *pb.FileReadRequest) (*pb.FileReadResponse, error)
+	// FileWrite answers the file_write command.
+	FileWrite(ctx context.Context, req *pb.FileWriteRequest) (*pb.FileWriteResponse, error)
+	// FileClose answers the file_close command.
+	FileClose(ctx context.Context, req *pb.FileCloseRequest) (*pb.FileCloseResponse, error)
+	// LogStream answers the log_stream stream, calling send for each response.
+	LogStream(ctx context.Context, req *pb.LogStreamRequest, send func(*pb.LogStreamResponse) error) error
+	// GetRpcStats answers the get_rpc_stats command.
+	GetRpcStats(ctx context.Context, req *pb.GetRpcStatsRequest) (*pb.GetRpcStatsResponse, error)
+	// StartSession answers the start_session command.
+	StartSession(ctx context.Context, req *pb.StartSessionRequest) (*pb.StartSessionResponse, error)
+	// AuthenticateSession answers the authenticate_session command.
+	AuthenticateSession(ctx context.Context, req *pb.AuthenticateSessionRequest) (*pb.AuthenticateSessionResponse, error)
+	// TimeSync answers the time_sync command.
+	TimeSync(ctx context.Context, req *pb.TimeSyncRequest) (*pb.TimeSyncResponse, error)
+	// Ping answers the ping command.
+	Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error)
+	// GetCapabilities answers the get_capabilities command.
+	GetCapabilities(ctx context.Context, req *pb.GetCapabilitiesRequest) (*pb.GetCapabilitiesResponse, error)
+	// GetSetting answers the get_setting command.
+	GetSetting(ctx context.Context, req *pb.GetSettingRequest) (*pb.GetSettingResponse, error)
+	// SetSetting answers the set_setting command.
+	SetSetting(ctx context.Context, req *pb.SetSettingRequest) (*pb.SetSettingResponse, error)
+}
+
+// DefaultHandler answers every command with its request echoed into the
+// response: the fields sharing a number and wire type are copied. Streams
+// to the central send one such response; streams from the central echo
+// their last request.
+type DefaultHandler struct{}
+
+func (DefaultHandler) Echo(ctx context.Context, req *pb.EchoRequest) (*pb.EchoResponse, error) {
+	return echo(req, &pb.EchoResponse{}), nil
+}
+
+func (DefaultHandler) FlashRead(ctx context.Context, req *pb.FlashReadRequest) (*pb.FlashReadResponse, error) {
+	return echo(req, &pb.FlashReadResponse{}), nil
+}
+
+func (DefaultHandler) DataWrite(ctx context.Context, req *pb.DataWriteRequest) (*pb.DataWriteResponse, error) {
+	return echo(req, &pb.DataWriteResponse{}), nil
+}
+
+func (DefaultHandler) CounterStream(ctx context.Context, req *pb.CounterStreamRequest, send func(*pb.CounterStreamResponse) error) error {
+	return send(echo(req, &pb.CounterStreamResponse{}))
+}
+
+func (DefaultHandler) CounterUpload(ctx context.Context, reqs []*pb.CounterUploadRequest) (*pb.CounterUploadResponse, error) {
+	resp := &pb.CounterUploadResponse{}
+	if len(reqs) > 0 {
+		echo(reqs[len(reqs)-1], resp)
+	}
+	return resp, nil
+}
+
+func (DefaultHandler) GetBlerpcInfo(ctx context.Context, req *pb.GetBlerpcInfoRequest) (*pb.GetBlerpcInfoResponse, error) {
+	return echo(req, &pb.GetBlerpcInfoResponse{}), nil
+}
+
+func (DefaultHandler) ConnParams(ctx context.Context, req *pb.ConnParamsRequest) (*pb.ConnParamsResponse, error) {
+	return echo(req, &pb.ConnParamsResponse{}), nil
+}
+
+func (DefaultHandler) DfuBegin(ctx context.Context, req *pb.DfuBeginRequest) (*pb.DfuBeginResponse, error) {
+	return echo(req, &pb.DfuBeginResponse{}), nil
+}
+
+func (DefaultHandler) DfuChunk(ctx context.Context, req *pb.DfuChunkRequest) (*pb.DfuChunkResponse, error) {
+	return echo(req, &pb.DfuChunkResponse{}), nil
+}
+
+func (DefaultHandler) DfuFinalize(ctx context.Context, req *pb.DfuFinalizeRequest) (*pb.DfuFinalizeResponse, error) {
+	return echo(req, &pb.DfuFinalizeResponse{}), nil
+}
+
+func (DefaultHandler) FileOpen(ctx context.Context, req *pb.FileOpenRequest) (*pb.FileOpenResponse, error) {
+	return echo(req, &pb.FileOpenResponse{}), nil
+}
+
+func (DefaultHandler) FileRead(ctx context.Context, req *pb.FileReadRequest) (*pb.FileReadResponse, error) {
+	return echo(req, &pb.FileReadResponse{}), nil
+}
+
+func (DefaultHandler) FileWrite(ctx context.Context, req *pb.FileWriteRequest) (*pb.FileWriteResponse, error) {
+	return echo(req, &pb.FileWriteResponse{}), nil
+}
+
+func (DefaultHandler) FileClose(ctx context.Context, req *pb.FileCloseRequest) (*pb.FileCloseResponse, error) {
+	return echo(req, &pb.FileCloseResponse{}), nil
+}
+
+func (DefaultHandler) LogStream(ctx context.Context, req *pb.LogStreamRequest, send func(*pb.LogStreamResponse) error) error {
+	return send(echo(req, &pb.LogStreamResponse{}))
+}
+
+func (DefaultHandler) GetRpcStats(ctx context.Context, req *pb.GetRpcStatsRequest) (*pb.GetRpcStatsResponse, error) {
+	return echo(req, &pb.GetRpcStatsResponse{}), nil
+}
+
+func (DefaultHandler) StartSession(ctx context.Context, req *pb.StartSessionRequest) (*pb.StartSessionResponse, error) {
+	return echo(req, &pb.StartSessionResponse{}), nil
+}
+
+func (DefaultHandler) AuthenticateSession(ctx context.Context, req *pb.AuthenticateSessionRequest) (*pb.AuthenticateSessionResponse, error) {
+	return echo(req, &pb.AuthenticateSessionResponse{}), nil
+}
+
+func (DefaultHandler) TimeSync(ctx context.Context, req *pb.TimeSyncRequest) (*pb.TimeSyncResponse, error) {
+	return echo(req, &pb.TimeSyncResponse{}), nil
+}
+
+func (DefaultHandler) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	return echo(req, &pb.PingResponse{}), nil
+}
+
+func (DefaultHandler) GetCapabilities(ctx context.Context, req *pb.GetCapabilitiesRequest) (*pb.GetCapabilitiesResponse, error) {
+	return echo(req, &pb.GetCapabilitiesResponse{}), nil
+}
+
+func (DefaultHandler) GetSetting(ctx context.Context, req *pb.GetSettingRequest) (*pb.GetSettingResponse, error) {
+	return echo(req, &pb.GetSettingResponse{}), nil
+}
+
+func (DefaultHandler) SetSetting(ctx context.Context, req *pb.SetSettingRequest) (*pb.SetSettingResponse, error) {
+	return echo(req, &pb.SetSettingResponse{}), nil
+}
+
+// Status codes of error responses. Codes from 128 up are application
+// defined.
+const (
+	StatusOk                 = 0  // Success; never sent in an error response.
+	StatusInvalidArgument    = 1  // The request is malformed or a field is out of bounds.
+	StatusNotFound           = 2  // The requested entity does not exist.
+	StatusAlreadyExists      = 3  // The entity to create exists already.
+	StatusPermissionDenied   = 4  // The caller may not run the command.
+	StatusResourceExhausted  = 5  // Memory, storage or another resource ran out.
+	StatusFailedPrecondition = 6  // The device is not in a state to run the command.
+	StatusOutOfRange         = 7  // An offset or value lies past the valid range.
+	StatusUnimplemented      = 8  // The command is not supported by this firmware.
+	StatusInternal           = 9  // The firmware hit an unexpected error.
+	StatusUnavailable        = 10 // The device cannot run the command now; retry later.
+	StatusUnauthenticated    = 11 // The command needs an authenticated session.
+)
+
+func runEcho(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.EchoRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.Echo(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runFlashRead(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.FlashReadRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.FlashRead(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runDataWrite(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.DataWriteRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.DataWrite(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runCounterStream(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.CounterStreamRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	return h.CounterStream(ctx, req, func(resp *pb.CounterStreamResponse) error { return send(resp) })
+}
+
+func runCounterUpload(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	msgs := make([]*pb.CounterUploadRequest, len(reqs))
+	for i, data := range reqs {
+		msgs[i] = &pb.CounterUploadRequest{}
+		if err := proto.Unmarshal(data, msgs[i]); err != nil {
+			return invalidRequest(err)
+		}
+	}
+	resp, err := h.CounterUpload(ctx, msgs)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runGetBlerpcInfo(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.GetBlerpcInfoRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.GetBlerpcInfo(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runConnParams(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.ConnParamsRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.ConnParams(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runDfuBegin(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.DfuBeginRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.DfuBegin(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runDfuChunk(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.DfuChunkRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.DfuChunk(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runDfuFinalize(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.DfuFinalizeRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.DfuFinalize(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runFileOpen(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.FileOpenRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.FileOpen(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runFileRead(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.FileReadRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.FileRead(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runFileWrite(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.FileWriteRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.FileWrite(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runFileClose(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.FileCloseRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.FileClose(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runLogStream(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.LogStreamRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	return h.LogStream(ctx, req, func(resp *pb.LogStreamResponse) error { return send(resp) })
+}
+
+func runGetRpcStats(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.GetRpcStatsRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.GetRpcStats(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runStartSession(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.StartSessionRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.StartSession(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runAuthenticateSession(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.AuthenticateSessionRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.AuthenticateSession(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runTimeSync(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.TimeSyncRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.TimeSync(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runPing(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.PingRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.Ping(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runGetCapabilities(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.GetCapabilitiesRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.GetCapabilities(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runGetSetting(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.GetSettingRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.GetSetting(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+func runSetSetting(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error {
+	req := &pb.SetSettingRequest{}
+	if err := proto.Unmarshal(reqs[0], req); err != nil {
+		return invalidRequest(err)
+	}
+	resp, err := h.SetSetting(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+// commands maps the wire names, and the IDs, of the commands to their
+// stream kind, the bytes leading their requests and their run function.
+var commands = map[string]command{
+	"echo":                 {wire.Unary, 0, runEcho},
+	"\x01":                 {wire.Unary, 0, runEcho},
+	"flash_read":           {wire.Unary, 32, runFlashRead},
+	"\x02":                 {wire.Unary, 32, runFlashRead},
+	"data_write":           {wire.Unary, 0, runDataWrite},
+	"\x03":                 {wire.Unary, 0, runDataWrite},
+	"counter_stream":       {wire.StreamP2C, 0, runCounterStream},
+	"\x04":                 {wire.StreamP2C, 0, runCounterStream},
+	"counter_upload":       {wire.StreamC2P, 0, runCounterUpload},
+	"\x05":                 {wire.StreamC2P, 0, runCounterUpload},
+	"get_blerpc_info":      {wire.Unary, 0, runGetBlerpcInfo},
+	"\x06":                 {wire.Unary, 0, runGetBlerpcInfo},
+	"conn_params":          {wire.Unary, 0, runConnParams},
+	"\x07":                 {wire.Unary, 0, runConnParams},
+	"dfu_begin":            {wire.Unary, 0, runDfuBegin},
+	"\x08":                 {wire.Unary, 0, runDfuBegin},
+	"dfu_chunk":            {wire.Unary, 0, runDfuChunk},
+	"\x09":                 {wire.Unary, 0, runDfuChunk},
+	"dfu_finalize":         {wire.Unary, 0, runDfuFinalize},
+	"\x0a":                 {wire.Unary, 0, runDfuFinalize},
+	"file_open":            {wire.Unary, 0, runFileOpen},
+	"\x0b":                 {wire.Unary, 0, runFileOpen},
+	"file_read":            {wire.Unary, 0, runFileRead},
+	"\x0c":                 {wire.Unary, 0, runFileRead},
+	"file_write":           {wire.Unary, 0, runFileWrite},
+	"\x0d":                 {wire.Unary, 0, runFileWrite},
+	"file_close":           {wire.Unary, 0, runFileClose},
+	"\x0e":                 {wire.Unary, 0, runFileClose},
+	"log_stream":           {wire.StreamP2C, 0, runLogStream},
+	"\x0f":                 {wire.StreamP2C, 0, runLogStream},
+	"get_rpc_stats":        {wire.Unary, 0, runGetRpcStats},
+	"\x10":                 {wire.Unary, 0, runGetRpcStats},
+	"start_session":        {wire.Unary, 0, runStartSession},
+	"\x11":                 {wire.Unary, 0, runStartSession},
+	"authenticate_session": {wire.Unary, 0, runAuthenticateSession},
+	"\x12":                 {wire.Unary, 0, runAuthenticateSession},
+	"time_sync":            {wire.Unary, 0, runTimeSync},
+	"\x13":                 {wire.Unary, 0, runTimeSync},
+	"ping":                 {wire.Unary, 0, runPing},
+	"\x14":                 {wire.Unary, 0, runPing},
+	"get_capabilities":     {wire.Unary, 0, runGetCapabilities},
+	"\x15":                 {wire.Unary, 0, runGetCapabilities},
+	"get_setting":          {wire.Unary, 0, runGetSetting},
+	"\x16":                 {wire.Unary, 0, runGetSetting},
+	"set_setting":          {wire.Unary, 0, runSetSetting},
+	"\x17":                 {wire.Unary, 0, runSetSetting},
+}
+
+type command struct {
+	stream wire.StreamKind
+	prefix int // bytes of session token and replay counter leading requests
+	run    func(ctx context.Context, h Handler, reqs [][]byte, send func(proto.Message) error) error
+}
+
+// StatusError is a handler error answered with an error response of Status.
+type StatusError struct {
+	Status  int
+	Message string // logged by the simulator, not sent
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("status %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("status %d", e.Status)
+}
+
+func invalidRequest(err error) error {
+	return &StatusError{Status: StatusInvalidArgument, Message: err.Error()}
+}
+
+// echo fills resp with the fields of req that share a number and wire type
+// with it and returns resp.
+func echo[T proto.Message](req proto.Message, resp T) T {
+	data, err := proto.Marshal(req)
+	if err == nil {
+		err = proto.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, resp)
+	}
+	if err != nil {
+		// A string field echoing bytes that are not UTF-8.
+		proto.Reset(resp)
+	}
+	return resp
+}
+
+// statusField is the reserved field number of the error envelope.
+const statusField = 536870911
+
+// errorResponse encodes the error envelope of status: the status alone, as
+// a varint in statusField.
+func errorResponse(status int) []byte {
+	b := protowire.AppendTag(nil, statusField, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(status))
+}
+
+// Defaults of the Simulator fields.
+const (
+	DefaultMTU       = 247
+	DefaultTimeoutMs = 100
+)
+
+// Simulator serves the commands to centrals connecting over a socket. Each
+// connection is one BLE connection: it runs one command at a time, and
+// Handler methods may run concurrently for different connections.
+type Simulator struct {
+	Handler   Handler
+	MTU       int                              // ATT MTU responses are split for; 0 means DefaultMTU
+	TimeoutMs uint16                           // answer to TIMEOUT requests; 0 means DefaultTimeoutMs
+	Logf      func(format string, args ...any) // reports dropped packets and handler errors; nil discards them
+}
+
+// New returns a simulator answering with h.
+func New(h Handler) *Simulator {
+	return &Simulator{Handler: h}
+}
+
+func (s *Simulator) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+// ListenAndServe listens on network ("tcp" or "unix") and address and
+// serves every connection until ctx is done.
+func (s *Simulator) ListenAndServe(ctx context.Context, network, address string) error {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve accepts connections on l until ctx is done or l fails, then closes
+// l and waits for the connections to end. It returns ctx.Err() when ctx
+// stopped it.
+func (s *Simulator) Serve(ctx context.Context, l net.Listener) error {
+	defer l.Close()
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer stop()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.ServeConn(ctx, conn); err != nil {
+				s.logf("%s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeConn serves one central on conn until it disconnects or ctx is done,
+// then closes conn.
+func (s *Simulator) ServeConn(ctx context.Context, conn net.Conn) error {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	c := &simConn{
+		sim:       s,
+		conn:      conn,
+		splitter:  wire.NewSplitter(cmp.Or(s.MTU, DefaultMTU)),
+		assembler: wire.NewAssembler(),
+	}
+	for {
+		data, err := ReadPacket(conn)
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		ct, err := wire.ParseContainer(data)
+		if err != nil {
+			s.logf("dropped packet: %v", err)
+			continue
+		}
+		if ct.Type == wire.TypeControl {
+			err = c.control(ctx, ct)
+		} else if payload, ok := c.assembler.Feed(ct); ok {
+			err = c.request(ctx, payload, ct.TransactionID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadPacket reads one container sent over a simulator socket.
+func ReadPacket(r io.Reader) ([]byte, error) {
+	var n [2]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.LittleEndian.Uint16(n[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// WritePacket sends one container over a simulator socket.
+func WritePacket(w io.Writer, ct *wire.Container) error {
+	data, err := ct.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(binary.LittleEndian.AppendUint16(nil, uint16(len(data))), data...))
+	return err
+}
+
+// simConn is the state of one connection.
+type simConn struct {
+	sim       *Simulator
+	conn      net.Conn
+	splitter  *wire.Splitter
+	assembler *wire.Assembler
+	upload    string   // wire name of the open central-to-peripheral stream
+	uploads   [][]byte // its requests so far
+}
+
+func (c *simConn) control(ctx context.Context, ct *wire.Container) error {
+	switch ct.ControlCmd {
+	case wire.ControlTimeout:
+		return WritePacket(c.conn, wire.TimeoutResponse(ct.TransactionID, cmp.Or(c.sim.TimeoutMs, DefaultTimeoutMs)))
+	case wire.ControlCapabilities:
+		caps := wire.Capabilities{MaxRequestPayloadSize: 0xFFFF, MaxResponsePayloadSize: 0xFFFF}
+		return WritePacket(c.conn, wire.CapabilitiesResponse(ct.TransactionID, caps))
+	case wire.ControlStreamEndC2P:
+		name, reqs := c.upload, c.uploads
+		c.upload, c.uploads = "", nil
+		if name == "" {
+			c.sim.logf("STREAM_END_C2P without a stream")
+			return nil
+		}
+		return c.run(ctx, name, commands[name], reqs, -1)
+	case wire.ControlKeyExchange:
+		c.sim.logf("KEY_EXCHANGE ignored: encryption is not simulated")
+	}
+	return nil
+}
+
+func (c *simConn) request(ctx context.Context, payload []byte, txn uint8) error {
+	cmd, err := wire.ParseCommand(payload)
+	if err != nil {
+		c.sim.logf("dropped request: %v", err)
+		return nil
+	}
+	if cmd.Type != wire.CommandRequest {
+		c.sim.logf("dropped %s packet of %q", cmd.Type, cmd.Name)
+		return nil
+	}
+	spec, ok := commands[cmd.Name]
+	if !ok {
+		c.sim.logf("unknown command %q", cmd.Name)
+		return c.respond(cmd.Name, int(txn), errorResponse(StatusUnimplemented))
+	}
+	if len(cmd.Data) < spec.prefix {
+		return c.respond(cmd.Name, int(txn), errorResponse(StatusInvalidArgument))
+	}
+	data := cmd.Data[spec.prefix:]
+	switch spec.stream {
+	case wire.StreamC2P:
+		if c.upload != cmd.Name {
+			c.upload, c.uploads = cmd.Name, nil
+		}
+		c.uploads = append(c.uploads, data)
+		return nil
+	case wire.StreamP2C:
+		return c.run(ctx, cmd.Name, spec, [][]byte{data}, -1)
+	}
+	return c.run(ctx, cmd.Name, spec, [][]byte{data}, int(txn))
+}
+
+// run calls the handler of a command and sends its responses, under txn or,
+// when it is -1, under a new transaction each, and the end of a stream to
+// the central.
+func (c *simConn) run(ctx context.Context, name string, spec command, reqs [][]byte, txn int) error {
+	var sendErr error
+	send := func(resp proto.Message) error {
+		data, err := proto.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		sendErr = c.respond(name, txn, data)
+		return sendErr
+	}
+	err := spec.run(ctx, c.sim.Handler, reqs, send)
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		status := StatusInternal
+		var se *StatusError
+		if errors.As(err, &se) {
+			status = se.Status
+		}
+		c.sim.logf("%s: %v", name, err)
+		if err := c.respond(name, txn, errorResponse(status)); err != nil {
+			return err
+		}
+	}
+	if spec.stream == wire.StreamP2C {
+		return WritePacket(c.conn, wire.StreamEndP2C(c.splitter.NextTransactionID()))
+	}
+	return nil
+}
+
+// respond sends a response packet split for the MTU, or a
+// RESPONSE_TOO_LARGE error when it does not fit a transaction.
+func (c *simConn) respond(name string, txn int, data []byte) error {
+	id := uint8(txn)
+	if txn < 0 {
+		id = c.splitter.NextTransactionID()
+	}
+	packet, err := (&wire.Command{Type: wire.CommandResponse, Name: name, Data: data}).MarshalBinary()
+	var cts []wire.Container
+	if err == nil {
+		cts, err = c.splitter.SplitTransaction(packet, id)
+	}
+	if err != nil {
+		c.sim.logf("%s: %v", name, err)
+		return WritePacket(c.conn, wire.ErrorResponse(id, wire.ErrorResponseTooLarge))
+	}
+	for i := range cts {
+		if err := WritePacket(c.conn, &cts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
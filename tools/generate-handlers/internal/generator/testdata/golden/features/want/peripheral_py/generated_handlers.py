@@ -0,0 +1,271 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT.
+
+Subclass BlerpcHandlers and override the methods of the commands the
+peripheral implements, or register functions in their place with the
+HandlerRegistry decorators; the registry dispatches requests by command name
+or ID.
+"""
+
+import os
+import sys
+from collections.abc import AsyncIterator, Callable
+
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), "..", "central_py"))
+from blerpc.generated import blerpc_pb2
+
+
+class BlerpcHandlers:
+    """Peripheral handlers: one async method per command.
+
+    The defaults answer with empty responses. The method of a
+    peripheral-to-central stream yields its responses; the method of a
+    central-to-peripheral stream is called per request, and its finish_
+    method makes the response when the central ends the stream.
+    """
+
+    async def echo(self, req: blerpc_pb2.EchoRequest) -> blerpc_pb2.EchoResponse:
+        return blerpc_pb2.EchoResponse()
+
+    async def flash_read(
+        self, req: blerpc_pb2.FlashReadRequest
+    ) -> blerpc_pb2.FlashReadResponse:
+        return blerpc_pb2.FlashReadResponse()
+
+    async def data_write(
+        self, req: blerpc_pb2.DataWriteRequest
+    ) -> blerpc_pb2.DataWriteResponse:
+        return blerpc_pb2.DataWriteResponse()
+
+    async def counter_stream(
+        self, req: blerpc_pb2.CounterStreamRequest
+    ) -> AsyncIterator[blerpc_pb2.CounterStreamResponse]:
+        yield blerpc_pb2.CounterStreamResponse()
+
+    async def counter_upload(self, req: blerpc_pb2.CounterUploadRequest) -> None:
+        pass
+
+    async def finish_counter_upload(self) -> blerpc_pb2.CounterUploadResponse:
+        return blerpc_pb2.CounterUploadResponse()
+
+    async def get_blerpc_info(
+        self, req: blerpc_pb2.GetBlerpcInfoRequest
+    ) -> blerpc_pb2.GetBlerpcInfoResponse:
+        return blerpc_pb2.GetBlerpcInfoResponse()
+
+    async def conn_params(
+        self, req: blerpc_pb2.ConnParamsRequest
+    ) -> blerpc_pb2.ConnParamsResponse:
+        return blerpc_pb2.ConnParamsResponse()
+
+    async def dfu_begin(
+        self, req: blerpc_pb2.DfuBeginRequest
+    ) -> blerpc_pb2.DfuBeginResponse:
+        return blerpc_pb2.DfuBeginResponse()
+
+    async def dfu_chunk(
+        self, req: blerpc_pb2.DfuChunkRequest
+    ) -> blerpc_pb2.DfuChunkResponse:
+        return blerpc_pb2.DfuChunkResponse()
+
+    async def dfu_finalize(
+        self, req: blerpc_pb2.DfuFinalizeRequest
+    ) -> blerpc_pb2.DfuFinalizeResponse:
+        return blerpc_pb2.DfuFinalizeResponse()
+
+    async def file_open(
+        self, req: blerpc_pb2.FileOpenRequest
+    ) -> blerpc_pb2.FileOpenResponse:
+        return blerpc_pb2.FileOpenResponse()
+
+    async def file_read(
+        self, req: blerpc_pb2.FileReadRequest
+    ) -> blerpc_pb2.FileReadResponse:
+        return blerpc_pb2.FileReadResponse()
+
+    async def file_write(
+        self, req: blerpc_pb2.FileWriteRequest
+    ) -> blerpc_pb2.FileWriteResponse:
+        return blerpc_pb2.FileWriteResponse()
+
+    async def file_close(
+        self, req: blerpc_pb2.FileCloseRequest
+    ) -> blerpc_pb2.FileCloseResponse:
+        return blerpc_pb2.FileCloseResponse()
+
+    async def log_stream(
+        self, req: blerpc_pb2.LogStreamRequest
+    ) -> AsyncIterator[blerpc_pb2.LogStreamResponse]:
+        yield blerpc_pb2.LogStreamResponse()
+
+    async def get_rpc_stats(
+        self, req: blerpc_pb2.GetRpcStatsRequest
+    ) -> blerpc_pb2.GetRpcStatsResponse:
+        return blerpc_pb2.GetRpcStatsResponse()
+
+    async def start_session(
+        self, req: blerpc_pb2.StartSessionRequest
+    ) -> blerpc_pb2.StartSessionResponse:
+        return blerpc_pb2.StartSessionResponse()
+
+    async def authenticate_session(
+        self, req: blerpc_pb2.AuthenticateSessionRequest
+    ) -> blerpc_pb2.AuthenticateSessionResponse:
+        return blerpc_pb2.AuthenticateSessionResponse()
+
+    async def time_sync(
+        self, req: blerpc_pb2.TimeSyncRequest
+    ) -> blerpc_pb2.TimeSyncResponse:
+        return blerpc_pb2.TimeSyncResponse()
+
+    async def ping(self, req: blerpc_pb2.PingRequest) -> blerpc_pb2.PingResponse:
+        return blerpc_pb2.PingResponse()
+
+    async def get_capabilities(
+        self, req: blerpc_pb2.GetCapabilitiesRequest
+    ) -> blerpc_pb2.GetCapabilitiesResponse:
+        return blerpc_pb2.GetCapabilitiesResponse()
+
+    async def get_setting(
+        self, req: blerpc_pb2.GetSettingRequest
+    ) -> blerpc_pb2.GetSettingResponse:
+        return blerpc_pb2.GetSettingResponse()
+
+    async def set_setting(
+        self, req: blerpc_pb2.SetSettingRequest
+    ) -> blerpc_pb2.SetSettingResponse:
+        return blerpc_pb2.SetSettingResponse()
+
+
+# Method name, request class and streaming direction ("", "p2c" or "c2p")
+# by command name.
+COMMANDS = {
+    "echo": ("echo", blerpc_pb2.EchoRequest, ""),
+    "flash_read": ("flash_read", blerpc_pb2.FlashReadRequest, ""),
+    "data_write": ("data_write", blerpc_pb2.DataWriteRequest, ""),
+    "counter_stream": ("counter_stream", blerpc_pb2.CounterStreamRequest, "p2c"),
+    "counter_upload": ("counter_upload", blerpc_pb2.CounterUploadRequest, "c2p"),
+    "get_blerpc_info": ("get_blerpc_info", blerpc_pb2.GetBlerpcInfoRequest, ""),
+    "conn_params": ("conn_params", blerpc_pb2.ConnParamsRequest, ""),
+    "dfu_begin": ("dfu_begin", blerpc_pb2.DfuBeginRequest, ""),
+    "dfu_chunk": ("dfu_chunk", blerpc_pb2.DfuChunkRequest, ""),
+    "dfu_finalize": ("dfu_finalize", blerpc_pb2.DfuFinalizeRequest, ""),
+    "file_open": ("file_open", blerpc_pb2.FileOpenRequest, ""),
+    "file_read": ("file_read", blerpc_pb2.FileReadRequest, ""),
+    "file_write": ("file_write", blerpc_pb2.FileWriteRequest, ""),
+    "file_close": ("file_close", blerpc_pb2.FileCloseRequest, ""),
+    "log_stream": ("log_stream", blerpc_pb2.LogStreamRequest, "p2c"),
+    "get_rpc_stats": ("get_rpc_stats", blerpc_pb2.GetRpcStatsRequest, ""),
+    "start_session": ("start_session", blerpc_pb2.StartSessionRequest, ""),
+    "authenticate_session": (
+        "authenticate_session",
+        blerpc_pb2.AuthenticateSessionRequest,
+        "",
+    ),
+    "time_sync": ("time_sync", blerpc_pb2.TimeSyncRequest, ""),
+    "ping": ("ping", blerpc_pb2.PingRequest, ""),
+    "get_capabilities": ("get_capabilities", blerpc_pb2.GetCapabilitiesRequest, ""),
+    "get_setting": ("get_setting", blerpc_pb2.GetSettingRequest, ""),
+    "set_setting": ("set_setting", blerpc_pb2.SetSettingRequest, ""),
+}
+
+# One-character names carrying numeric command IDs, mapped to the command
+# names COMMANDS is keyed by.
+COMMAND_IDS = {
+    "\x01": "echo",
+    "\x02": "flash_read",
+    "\x03": "data_write",
+    "\x04": "counter_stream",
+    "\x05": "counter_upload",
+    "\x06": "get_blerpc_info",
+    "\a": "conn_params",
+    "\b": "dfu_begin",
+    "\t": "dfu_chunk",
+    "\n": "dfu_finalize",
+    "\v": "file_open",
+    "\f": "file_read",
+    "\r": "file_write",
+    "\x0e": "file_close",
+    "\x0f": "log_stream",
+    "\x10": "get_rpc_stats",
+    "\x11": "start_session",
+    "\x12": "authenticate_session",
+    "\x13": "time_sync",
+    "\x14": "ping",
+    "\x15": "get_capabilities",
+    "\x16": "get_setting",
+    "\x17": "set_setting",
+}
+
+
+class HandlerRegistry:
+    """Dispatches encoded requests to handlers by command name or ID.
+
+    A function registered with the handler or finisher decorator, by method
+    name, takes the place of that BlerpcHandlers method:
+
+        registry = HandlerRegistry()
+
+        @registry.handler("my_command")
+        async def my_command(req):
+            ...
+    """
+
+    def __init__(self, handlers: BlerpcHandlers | None = None):
+        self.handlers = BlerpcHandlers() if handlers is None else handlers
+        self._handlers: dict[str, Callable] = {}
+        self._finishers: dict[str, Callable] = {}
+
+    def handler(self, method: str) -> Callable[[Callable], Callable]:
+        """Decorator registering an async function as the handler of method."""
+        return self._register(self._handlers, method)
+
+    def finisher(self, method: str) -> Callable[[Callable], Callable]:
+        """Decorator registering an async function as the finish_ method of a
+        central-to-peripheral stream."""
+        return self._register(self._finishers, method)
+
+    def _register(self, table: dict, method: str) -> Callable[[Callable], Callable]:
+        if not any(m == method for m, _, _ in COMMANDS.values()):
+            raise KeyError(f"unknown command: {method!r}")
+
+        def register(fn: Callable) -> Callable:
+            table[method] = fn
+            return fn
+
+        return register
+
+    def resolve(self, name: str) -> str:
+        """Return the command name of name, which may carry a command ID."""
+        return COMMAND_IDS.get(name, name)
+
+    def __contains__(self, name: str) -> bool:
+        return self.resolve(name) in COMMANDS
+
+    def direction(self, name: str) -> str:
+        """Return the streaming direction of a command: "", "p2c" or "c2p"."""
+        return COMMANDS[self.resolve(name)][2]
+
+    def _call(self, name: str, req_data: bytes):
+        method, request_cls, _ = COMMANDS[self.resolve(name)]
+        req = request_cls()
+        req.ParseFromString(req_data)
+        fn = self._handlers.get(method) or getattr(self.handlers, method)
+        return fn(req)
+
+    async def dispatch(self, name: str, req_data: bytes) -> bytes | None:
+        """Run a unary handler, or a central-to-peripheral stream handler
+        (which has no response), on an encoded request."""
+        resp = await self._call(name, req_data)
+        return None if resp is None else resp.SerializeToString()
+
+    async def stream(self, name: str, req_data: bytes) -> AsyncIterator[bytes]:
+        """Yield the encoded responses of a peripheral-to-central stream."""
+        async for resp in self._call(name, req_data):
+            yield resp.SerializeToString()
+
+    async def finish(self, name: str) -> bytes:
+        """Return the encoded response of a central-to-peripheral stream."""
+        method = COMMANDS[self.resolve(name)][0]
+        fn = self._finishers.get(method) or getattr(self.handlers, "finish_" + method)
+        resp = await fn()
+        return resp.SerializeToString()
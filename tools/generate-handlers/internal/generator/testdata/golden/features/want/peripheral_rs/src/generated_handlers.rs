@@ -0,0 +1,550 @@
+// Auto-generated by generate-handlers 0.1.0 (schema fb27bddde9c1f6b7) — DO NOT EDIT
+//! Command handlers for no_std peripherals. Requests and responses are
+//! the prost messages of the schema; only `alloc` is needed.
+
+use prost::Message;
+
+use crate::blerpc as pb;
+
+/// Why a command produced no response. The dispatcher answers with an
+/// error container instead.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum HandlerError {
+    /// The request did not decode.
+    Decode,
+    /// The response did not fit in the response buffer.
+    Encode,
+    /// The handler rejected the request.
+    Failed,
+}
+
+/// One method per command. The defaults answer with an empty response;
+/// override the commands the peripheral implements.
+pub trait Handlers {
+    fn echo(&mut self, req: pb::EchoRequest) -> Result<pb::EchoResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::EchoResponse::default())
+    }
+
+    fn flash_read(
+        &mut self,
+        req: pb::FlashReadRequest,
+    ) -> Result<pb::FlashReadResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::FlashReadResponse::default())
+    }
+
+    fn data_write(
+        &mut self,
+        req: pb::DataWriteRequest,
+    ) -> Result<pb::DataWriteResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::DataWriteResponse::default())
+    }
+
+    fn counter_stream(
+        &mut self,
+        req: pb::CounterStreamRequest,
+    ) -> Result<pb::CounterStreamResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::CounterStreamResponse::default())
+    }
+
+    fn counter_upload(
+        &mut self,
+        req: pb::CounterUploadRequest,
+    ) -> Result<pb::CounterUploadResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::CounterUploadResponse::default())
+    }
+
+    fn get_blerpc_info(
+        &mut self,
+        req: pb::GetBlerpcInfoRequest,
+    ) -> Result<pb::GetBlerpcInfoResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::GetBlerpcInfoResponse::default())
+    }
+
+    fn conn_params(
+        &mut self,
+        req: pb::ConnParamsRequest,
+    ) -> Result<pb::ConnParamsResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::ConnParamsResponse::default())
+    }
+
+    fn dfu_begin(
+        &mut self,
+        req: pb::DfuBeginRequest,
+    ) -> Result<pb::DfuBeginResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::DfuBeginResponse::default())
+    }
+
+    fn dfu_chunk(
+        &mut self,
+        req: pb::DfuChunkRequest,
+    ) -> Result<pb::DfuChunkResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::DfuChunkResponse::default())
+    }
+
+    fn dfu_finalize(
+        &mut self,
+        req: pb::DfuFinalizeRequest,
+    ) -> Result<pb::DfuFinalizeResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::DfuFinalizeResponse::default())
+    }
+
+    fn file_open(
+        &mut self,
+        req: pb::FileOpenRequest,
+    ) -> Result<pb::FileOpenResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::FileOpenResponse::default())
+    }
+
+    fn file_read(
+        &mut self,
+        req: pb::FileReadRequest,
+    ) -> Result<pb::FileReadResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::FileReadResponse::default())
+    }
+
+    fn file_write(
+        &mut self,
+        req: pb::FileWriteRequest,
+    ) -> Result<pb::FileWriteResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::FileWriteResponse::default())
+    }
+
+    fn file_close(
+        &mut self,
+        req: pb::FileCloseRequest,
+    ) -> Result<pb::FileCloseResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::FileCloseResponse::default())
+    }
+
+    fn log_stream(
+        &mut self,
+        req: pb::LogStreamRequest,
+    ) -> Result<pb::LogStreamResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::LogStreamResponse::default())
+    }
+
+    fn get_rpc_stats(
+        &mut self,
+        req: pb::GetRpcStatsRequest,
+    ) -> Result<pb::GetRpcStatsResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::GetRpcStatsResponse::default())
+    }
+
+    fn start_session(
+        &mut self,
+        req: pb::StartSessionRequest,
+    ) -> Result<pb::StartSessionResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::StartSessionResponse::default())
+    }
+
+    fn authenticate_session(
+        &mut self,
+        req: pb::AuthenticateSessionRequest,
+    ) -> Result<pb::AuthenticateSessionResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::AuthenticateSessionResponse::default())
+    }
+
+    fn time_sync(
+        &mut self,
+        req: pb::TimeSyncRequest,
+    ) -> Result<pb::TimeSyncResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::TimeSyncResponse::default())
+    }
+
+    fn ping(&mut self, req: pb::PingRequest) -> Result<pb::PingResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::PingResponse::default())
+    }
+
+    fn get_capabilities(
+        &mut self,
+        req: pb::GetCapabilitiesRequest,
+    ) -> Result<pb::GetCapabilitiesResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::GetCapabilitiesResponse::default())
+    }
+
+    fn get_setting(
+        &mut self,
+        req: pb::GetSettingRequest,
+    ) -> Result<pb::GetSettingResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::GetSettingResponse::default())
+    }
+
+    fn set_setting(
+        &mut self,
+        req: pb::SetSettingRequest,
+    ) -> Result<pb::SetSettingResponse, HandlerError> {
+        let _ = req;
+        Ok(pb::SetSettingResponse::default())
+    }
+}
+
+/// Decodes a request, runs its handler and encodes the response into the
+/// buffer, returning the response length.
+pub type CommandHandler<H> = fn(&mut H, &[u8], &mut [u8]) -> Result<usize, HandlerError>;
+
+pub struct HandlerEntry<H> {
+    pub name: &'static [u8],
+    /// Command ID from the lock file.
+    pub id: u8,
+    pub handler: CommandHandler<H>,
+}
+
+fn encode<M: Message>(resp: &M, mut out: &mut [u8]) -> Result<usize, HandlerError> {
+    let len = resp.encoded_len();
+    resp.encode(&mut out).map_err(|_| HandlerError::Encode)?;
+    Ok(len)
+}
+
+fn handle_echo<H: Handlers>(h: &mut H, req: &[u8], out: &mut [u8]) -> Result<usize, HandlerError> {
+    let req = pb::EchoRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.echo(req)?, out)
+}
+
+fn handle_flash_read<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::FlashReadRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.flash_read(req)?, out)
+}
+
+fn handle_data_write<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::DataWriteRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.data_write(req)?, out)
+}
+
+fn handle_counter_stream<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::CounterStreamRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.counter_stream(req)?, out)
+}
+
+fn handle_counter_upload<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::CounterUploadRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.counter_upload(req)?, out)
+}
+
+fn handle_get_blerpc_info<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::GetBlerpcInfoRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.get_blerpc_info(req)?, out)
+}
+
+fn handle_conn_params<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::ConnParamsRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.conn_params(req)?, out)
+}
+
+fn handle_dfu_begin<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::DfuBeginRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.dfu_begin(req)?, out)
+}
+
+fn handle_dfu_chunk<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::DfuChunkRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.dfu_chunk(req)?, out)
+}
+
+fn handle_dfu_finalize<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::DfuFinalizeRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.dfu_finalize(req)?, out)
+}
+
+fn handle_file_open<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::FileOpenRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.file_open(req)?, out)
+}
+
+fn handle_file_read<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::FileReadRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.file_read(req)?, out)
+}
+
+fn handle_file_write<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::FileWriteRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.file_write(req)?, out)
+}
+
+fn handle_file_close<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::FileCloseRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.file_close(req)?, out)
+}
+
+fn handle_log_stream<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::LogStreamRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.log_stream(req)?, out)
+}
+
+fn handle_get_rpc_stats<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::GetRpcStatsRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.get_rpc_stats(req)?, out)
+}
+
+fn handle_start_session<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::StartSessionRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.start_session(req)?, out)
+}
+
+fn handle_authenticate_session<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::AuthenticateSessionRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.authenticate_session(req)?, out)
+}
+
+fn handle_time_sync<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::TimeSyncRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.time_sync(req)?, out)
+}
+
+fn handle_ping<H: Handlers>(h: &mut H, req: &[u8], out: &mut [u8]) -> Result<usize, HandlerError> {
+    let req = pb::PingRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.ping(req)?, out)
+}
+
+fn handle_get_capabilities<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::GetCapabilitiesRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.get_capabilities(req)?, out)
+}
+
+fn handle_get_setting<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::GetSettingRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.get_setting(req)?, out)
+}
+
+fn handle_set_setting<H: Handlers>(
+    h: &mut H,
+    req: &[u8],
+    out: &mut [u8],
+) -> Result<usize, HandlerError> {
+    let req = pb::SetSettingRequest::decode(req).map_err(|_| HandlerError::Decode)?;
+    encode(&h.set_setting(req)?, out)
+}
+
+/// The handler table, in schema order.
+pub trait HandlerTable: Handlers + Sized + 'static {
+    const TABLE: &'static [HandlerEntry<Self>];
+}
+
+impl<H: Handlers + 'static> HandlerTable for H {
+    const TABLE: &'static [HandlerEntry<H>] = &[
+        HandlerEntry {
+            name: b"echo",
+            id: 1,
+            handler: handle_echo::<H>,
+        },
+        HandlerEntry {
+            name: b"flash_read",
+            id: 2,
+            handler: handle_flash_read::<H>,
+        },
+        HandlerEntry {
+            name: b"data_write",
+            id: 3,
+            handler: handle_data_write::<H>,
+        },
+        HandlerEntry {
+            name: b"counter_stream",
+            id: 4,
+            handler: handle_counter_stream::<H>,
+        },
+        HandlerEntry {
+            name: b"counter_upload",
+            id: 5,
+            handler: handle_counter_upload::<H>,
+        },
+        HandlerEntry {
+            name: b"get_blerpc_info",
+            id: 6,
+            handler: handle_get_blerpc_info::<H>,
+        },
+        HandlerEntry {
+            name: b"conn_params",
+            id: 7,
+            handler: handle_conn_params::<H>,
+        },
+        HandlerEntry {
+            name: b"dfu_begin",
+            id: 8,
+            handler: handle_dfu_begin::<H>,
+        },
+        HandlerEntry {
+            name: b"dfu_chunk",
+            id: 9,
+            handler: handle_dfu_chunk::<H>,
+        },
+        HandlerEntry {
+            name: b"dfu_finalize",
+            id: 10,
+            handler: handle_dfu_finalize::<H>,
+        },
+        HandlerEntry {
+            name: b"file_open",
+            id: 11,
+            handler: handle_file_open::<H>,
+        },
+        HandlerEntry {
+            name: b"file_read",
+            id: 12,
+            handler: handle_file_read::<H>,
+        },
+        HandlerEntry {
+            name: b"file_write",
+            id: 13,
+            handler: handle_file_write::<H>,
+        },
+        HandlerEntry {
+            name: b"file_close",
+            id: 14,
+            handler: handle_file_close::<H>,
+        },
+        HandlerEntry {
+            name: b"log_stream",
+            id: 15,
+            handler: handle_log_stream::<H>,
+        },
+        HandlerEntry {
+            name: b"get_rpc_stats",
+            id: 16,
+            handler: handle_get_rpc_stats::<H>,
+        },
+        HandlerEntry {
+            name: b"start_session",
+            id: 17,
+            handler: handle_start_session::<H>,
+        },
+        HandlerEntry {
+            name: b"authenticate_session",
+            id: 18,
+            handler: handle_authenticate_session::<H>,
+        },
+        HandlerEntry {
+            name: b"time_sync",
+            id: 19,
+            handler: handle_time_sync::<H>,
+        },
+        HandlerEntry {
+            name: b"ping",
+            id: 20,
+            handler: handle_ping::<H>,
+        },
+        HandlerEntry {
+            name: b"get_capabilities",
+            id: 21,
+            handler: handle_get_capabilities::<H>,
+        },
+        HandlerEntry {
+            name: b"get_setting",
+            id: 22,
+            handler: handle_get_setting::<H>,
+        },
+        HandlerEntry {
+            name: b"set_setting",
+            id: 23,
+            handler: handle_set_setting::<H>,
+        },
+    ];
+}
+
+pub fn handlers_lookup<H: HandlerTable>(name: &[u8]) -> Option<CommandHandler<H>> {
+    // A one-byte name carries the command ID.
+    H::TABLE
+        .iter()
+        .find(|e| e.name == name || (name.len() == 1 && name[0] == e.id))
+        .map(|e| e.handler)
+}
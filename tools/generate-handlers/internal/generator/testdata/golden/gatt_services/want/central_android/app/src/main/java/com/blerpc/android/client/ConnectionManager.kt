@@ -0,0 +1,177 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import kotlinx.coroutines.CancellationException
+import kotlinx.coroutines.CoroutineScope
+import kotlinx.coroutines.Job
+import kotlinx.coroutines.TimeoutCancellationException
+import kotlinx.coroutines.delay
+import kotlinx.coroutines.flow.MutableStateFlow
+import kotlinx.coroutines.flow.StateFlow
+import kotlinx.coroutines.flow.asStateFlow
+import kotlinx.coroutines.launch
+import kotlinx.coroutines.sync.Mutex
+import kotlinx.coroutines.sync.withLock
+import kotlin.math.min
+import kotlin.math.pow
+import kotlin.random.Random
+
+/**
+ * State of the link a [ConnectionManager] maintains.
+ *
+ * DISCONNECTED → CONNECTING → READY, and back to DISCONNECTED when the link
+ * drops or every connect attempt fails. A call failing on a live link, e.g.
+ * with a timeout, moves READY to DEGRADED; the next call that succeeds moves
+ * it back.
+ */
+enum class LinkState { DISCONNECTED, CONNECTING, READY, DEGRADED }
+
+/** Backoff and replay policy of [ConnectionManager]. Subclass to override. */
+open class ReconnectPolicy(
+    val initialDelayMs: Long = 500,
+    val maxDelayMs: Long = 30_000,
+    val multiplier: Double = 2.0,
+    /** Up to this fraction of each delay is dropped at random. */
+    val jitter: Double = 0.2,
+    /** Connect attempts per reconnect; 0 tries forever. */
+    val maxAttempts: Int = 5,
+    /** Replays of an interrupted idempotent call. */
+    val maxReplays: Int = 1,
+) {
+    /** Milliseconds to wait after the [attempt]-th failed connect attempt. */
+    open fun delayMs(attempt: Int): Long {
+        val d = min(maxDelayMs.toDouble(), initialDelayMs * multiplier.pow(attempt - 1))
+        return (d * (1 - jitter * Random.nextDouble())).toLong()
+    }
+
+    /** Whether to replay [command] after its [attempt]-th interrupted try. */
+    open fun shouldReplay(
+        command: String,
+        attempt: Int,
+        error: Throwable,
+    ): Boolean = command in IDEMPOTENT_COMMANDS && attempt <= maxReplays
+}
+
+/**
+ * Owns the link of [client]: connects, reconnects and replays calls.
+ *
+ * [connectLink] opens the link, e.g. `{ client.connect(device) }`. Calls made
+ * while disconnected connect first, retrying with backoff as [policy]
+ * allows. A call cut off by a dropped link is replayed after reconnecting if
+ * the command is idempotent and the policy allows; other interrupted calls
+ * throw [CallInterruptedException]. Report a dropped link with [linkLost] to
+ * reconnect in [scope] right away.
+ */
+class ConnectionManager(
+    private val client: GeneratedClient,
+    private val isConnected: () -> Boolean,
+    private val connectLink: suspend () -> Unit,
+    private val scope: CoroutineScope,
+    private val policy: ReconnectPolicy = ReconnectPolicy(),
+) : GeneratedClient() {
+    private val mutableState = MutableStateFlow(LinkState.DISCONNECTED)
+    private val connectLock = Mutex()
+    private var reconnectJob: Job? = null
+
+    val state: StateFlow<LinkState> = mutableState.asStateFlow()
+
+    override val capabilityFlags: Int get() = client.capabilityFlags
+
+    /** Opens the link, retrying with backoff; returns at once when connected. */
+    suspend fun connect() {
+        connectLock.withLock {
+            if (isConnected()) {
+                if (mutableState.value == LinkState.DISCONNECTED) mutableState.value = LinkState.READY
+                return
+            }
+            mutableState.value = LinkState.CONNECTING
+            var attempt = 0
+            while (true) {
+                try {
+                    connectLink()
+                    mutableState.value = LinkState.READY
+                    return
+                } catch (e: CancellationException) {
+                    mutableState.value = LinkState.DISCONNECTED
+                    throw e
+                } catch (e: Exception) {
+                    attempt++
+                    if (policy.maxAttempts in 1..attempt) {
+                        mutableState.value = LinkState.DISCONNECTED
+                        throw TransportException("Connect failed after $attempt attempts", e)
+                    }
+                    delay(policy.delayMs(attempt))
+                }
+            }
+        }
+    }
+
+    /** Reports that the link dropped and reconnects in [scope]. */
+    fun linkLost() {
+        mutableState.value = LinkState.DISCONNECTED
+        if (reconnectJob?.isActive == true) return
+        reconnectJob =
+            scope.launch {
+                try {
+                    connect()
+                } catch (_: TransportException) {
+                    // The next call tries again.
+                }
+            }
+    }
+
+    /** Closes the link with [disconnectLink]; the next call connects again. */
+    fun disconnect(disconnectLink: () -> Unit) {
+        reconnectJob?.cancel()
+        disconnectLink()
+        mutableState.value = LinkState.DISCONNECTED
+    }
+
+    override suspend fun call(
+        cmdName: String,
+        requestData: ByteArray,
+    ): ByteArray = run(cmdName) { client.call(cmdName, requestData) }
+
+    override suspend fun streamReceive(
+        cmdName: String,
+        requestData: ByteArray,
+    ): List<ByteArray> = run(cmdName) { client.streamReceive(cmdName, requestData) }
+
+    override suspend fun streamSend(
+        cmdName: String,
+        messages: List<ByteArray>,
+        finalCmdName: String,
+    ): ByteArray = run(cmdName) { client.streamSend(cmdName, messages, finalCmdName) }
+
+    private suspend fun <T> run(
+        command: String,
+        block: suspend () -> T,
+    ): T {
+        var attempt = 0
+        while (true) {
+            if (!isConnected()) {
+                mutableState.value = LinkState.DISCONNECTED
+                connect()
+            }
+            try {
+                val result = client.exclusive { block() }
+                mutableState.value = LinkState.READY
+                return result
+            } catch (e: Exception) {
+                // A read timeout is a TimeoutCancellationException; only real
+                // cancellation ends the call right away.
+                if (e is CancellationException && e !is TimeoutCancellationException) throw e
+                if (isConnected()) {
+                    // Error and undecodable responses show the link works.
+                    if (e !is RemoteException && e !is DecodeException) mutableState.value = LinkState.DEGRADED
+                    throw e
+                }
+                mutableState.value = LinkState.DISCONNECTED
+                attempt++
+                if (!policy.shouldReplay(command, attempt, e)) {
+                    throw if (command in IDEMPOTENT_COMMANDS) e else CallInterruptedException(command, e)
+                }
+            }
+        }
+    }
+}
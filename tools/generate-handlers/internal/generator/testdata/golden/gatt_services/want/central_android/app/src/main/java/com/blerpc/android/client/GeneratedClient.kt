@@ -0,0 +1,221 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.google.protobuf.ByteString
+import com.google.protobuf.InvalidProtocolBufferException
+import kotlinx.coroutines.flow.Flow
+import kotlinx.coroutines.flow.flow
+import kotlinx.coroutines.flow.toList
+import kotlinx.coroutines.sync.Mutex
+import kotlinx.coroutines.sync.withLock
+
+/** Base class of errors thrown by generated client methods. */
+open class BlerpcException(message: String, cause: Throwable? = null) : Exception(message, cause)
+
+/** The request could not be delivered or the response was lost. */
+open class TransportException(message: String, cause: Throwable? = null) : BlerpcException(message, cause)
+
+/** The peripheral did not respond in time. */
+class TimeoutException(message: String, cause: Throwable? = null) : TransportException(message, cause)
+
+/** The response payload is not a valid message. */
+class DecodeException(val command: String, cause: Throwable) :
+    BlerpcException("$command: invalid response", cause)
+
+/** The peripheral reported a non-OK status. */
+open class RemoteException(
+    val command: String,
+    val status: Int,
+    message: String = "$command failed: status=$status",
+) : BlerpcException(message)
+
+/** Status codes of error responses; 128 and up are application codes. */
+enum class StatusCode(val code: Int) {
+    OK(0),
+    INVALID_ARGUMENT(1),
+    NOT_FOUND(2),
+    ALREADY_EXISTS(3),
+    PERMISSION_DENIED(4),
+    RESOURCE_EXHAUSTED(5),
+    FAILED_PRECONDITION(6),
+    OUT_OF_RANGE(7),
+    UNIMPLEMENTED(8),
+    INTERNAL(9),
+    UNAVAILABLE(10),
+    UNAUTHENTICATED(11),
+}
+
+/** The request is malformed or a field is out of bounds. */
+class InvalidArgumentException(command: String) :
+    RemoteException(command, StatusCode.INVALID_ARGUMENT.code, "$command failed: INVALID_ARGUMENT")
+
+/** The requested entity does not exist. */
+class NotFoundException(command: String) :
+    RemoteException(command, StatusCode.NOT_FOUND.code, "$command failed: NOT_FOUND")
+
+/** The entity to create exists already. */
+class AlreadyExistsException(command: String) :
+    RemoteException(command, StatusCode.ALREADY_EXISTS.code, "$command failed: ALREADY_EXISTS")
+
+/** The caller may not run the command. */
+class PermissionDeniedException(command: String) :
+    RemoteException(command, StatusCode.PERMISSION_DENIED.code, "$command failed: PERMISSION_DENIED")
+
+/** Memory, storage or another resource ran out. */
+class ResourceExhaustedException(command: String) :
+    RemoteException(command, StatusCode.RESOURCE_EXHAUSTED.code, "$command failed: RESOURCE_EXHAUSTED")
+
+/** The device is not in a state to run the command. */
+class FailedPreconditionException(command: String) :
+    RemoteException(command, StatusCode.FAILED_PRECONDITION.code, "$command failed: FAILED_PRECONDITION")
+
+/** An offset or value lies past the valid range. */
+class OutOfRangeException(command: String) :
+    RemoteException(command, StatusCode.OUT_OF_RANGE.code, "$command failed: OUT_OF_RANGE")
+
+/** The command is not supported by this firmware. */
+class UnimplementedException(command: String) :
+    RemoteException(command, StatusCode.UNIMPLEMENTED.code, "$command failed: UNIMPLEMENTED")
+
+/** The firmware hit an unexpected error. */
+class InternalException(command: String) :
+    RemoteException(command, StatusCode.INTERNAL.code, "$command failed: INTERNAL")
+
+/** The device cannot run the command now; retry later. */
+class UnavailableException(command: String) :
+    RemoteException(command, StatusCode.UNAVAILABLE.code, "$command failed: UNAVAILABLE")
+
+/** The command needs an authenticated session. */
+class UnauthenticatedException(command: String) :
+    RemoteException(command, StatusCode.UNAUTHENTICATED.code, "$command failed: UNAUTHENTICATED")
+
+// Error responses hold only a status, in a field no message uses.
+private val STATUS_TAG = byteArrayOf(0xF8.toByte(), 0xFF.toByte(), 0xFF.toByte(), 0xFF.toByte(), 0x0F.toByte())
+
+/** Returns the error an error response reports, or null for other responses. */
+fun statusException(command: String, data: ByteArray): RemoteException? {
+    if (data.size <= STATUS_TAG.size || STATUS_TAG.indices.any { data[it] != STATUS_TAG[it] }) return null
+    var status = 0
+    var shift = 0
+    for (i in STATUS_TAG.size until data.size) {
+        val byte = data[i].toInt() and 0xFF
+        status = status or ((byte and 0x7F) shl shift)
+        shift += 7
+        if (byte < 0x80) break
+    }
+    return when (status) {
+        StatusCode.INVALID_ARGUMENT.code -> InvalidArgumentException(command)
+        StatusCode.NOT_FOUND.code -> NotFoundException(command)
+        StatusCode.ALREADY_EXISTS.code -> AlreadyExistsException(command)
+        StatusCode.PERMISSION_DENIED.code -> PermissionDeniedException(command)
+        StatusCode.RESOURCE_EXHAUSTED.code -> ResourceExhaustedException(command)
+        StatusCode.FAILED_PRECONDITION.code -> FailedPreconditionException(command)
+        StatusCode.OUT_OF_RANGE.code -> OutOfRangeException(command)
+        StatusCode.UNIMPLEMENTED.code -> UnimplementedException(command)
+        StatusCode.INTERNAL.code -> InternalException(command)
+        StatusCode.UNAVAILABLE.code -> UnavailableException(command)
+        StatusCode.UNAUTHENTICATED.code -> UnauthenticatedException(command)
+        else -> RemoteException(command, status)
+    }
+}
+
+/** A bytes argument exceeds the max_size the peripheral accepts. */
+class PayloadTooLargeException(
+    val command: String,
+    val field: String,
+    val size: Int,
+    val maxSize: Int,
+) : BlerpcException("$command: $field is $size bytes, over its max_size of $maxSize")
+
+/** A string argument is longer in UTF-8 than its max_size allows. */
+class StringTooLongException(
+    val command: String,
+    val field: String,
+    val size: Int,
+    val maxBytes: Int,
+) : BlerpcException("$command: $field is $size bytes in UTF-8, over the $maxBytes its max_size allows")
+
+/**
+ * Auto-generated RPC methods.
+ * Subclass and override for custom behavior.
+ */
+abstract class GeneratedClient {
+    private val rpcLock = Mutex()
+
+    abstract suspend fun call(cmdName: String, requestData: ByteArray): ByteArray
+    abstract suspend fun streamReceive(cmdName: String, requestData: ByteArray): List<ByteArray>
+    abstract suspend fun streamSend(cmdName: String, messages: List<ByteArray>, finalCmdName: String): ByteArray
+
+    /**
+     * Runs [block] with no other RPC of this client in flight. The peripheral
+     * handles one RPC at a time; the generated methods call through here, and
+     * so should direct uses of [call], [streamReceive] and [streamSend].
+     * Wrappers that order the calls themselves, such as PriorityClient,
+     * override it to run [block] at once.
+     */
+    open suspend fun <T> exclusive(block: suspend () -> T): T = rpcLock.withLock { block() }
+
+    /** Flags of the peripheral's CAPABILITIES response; 0 until it is received. */
+    open val capabilityFlags: Int get() = 0
+
+    /**
+     * Receives the responses of a P→C stream as they arrive. The default emits
+     * the list [streamReceive] returns; override to emit each response as it
+     * is received.
+     */
+    open fun streamReceiveFlow(
+        cmdName: String,
+        requestData: ByteArray,
+    ): Flow<ByteArray> = flow { streamReceive(cmdName, requestData).forEach { emit(it) } }
+
+    /**
+     * Sends a C→P stream whose messages are produced as it is sent. The
+     * default collects [messages] and calls [streamSend]; override to send
+     * each message as it is emitted.
+     */
+    open suspend fun streamSendFlow(
+        cmdName: String,
+        messages: Flow<ByteArray>,
+        finalCmdName: String,
+    ): ByteArray = streamSend(cmdName, messages.toList(), finalCmdName)
+
+    protected inline fun <T> decode(command: String, data: ByteArray, parse: (ByteArray) -> T): T {
+        statusException(command, data)?.let { throw it }
+        return try {
+            parse(data)
+        } catch (e: InvalidProtocolBufferException) {
+            throw DecodeException(command, e)
+        }
+    }
+
+    open suspend fun echo(message: String = ""): blerpc.Blerpc.EchoResponse {
+        if (message.encodeToByteArray().size > 256) {
+            throw StringTooLongException("echo", "message", message.encodeToByteArray().size, 256)
+        }
+        val req = blerpc.Blerpc.EchoRequest.newBuilder()
+            .setMessage(message)
+            .build()
+        val respData = exclusive { call("echo", req.toByteArray()) }
+        return decode("echo", respData) { blerpc.Blerpc.EchoResponse.parseFrom(it) }
+    }
+
+    open suspend fun flashRead(address: Int = 0, length: Int = 0): blerpc.Blerpc.FlashReadResponse {
+        val req = blerpc.Blerpc.FlashReadRequest.newBuilder()
+            .setAddress(address)
+            .setLength(length)
+            .build()
+        val respData = exclusive { call("flash_read", req.toByteArray()) }
+        return decode("flash_read", respData) { blerpc.Blerpc.FlashReadResponse.parseFrom(it) }
+    }
+
+    open suspend fun dataWrite(data: com.google.protobuf.ByteString = com.google.protobuf.ByteString.EMPTY): blerpc.Blerpc.DataWriteResponse {
+        if (data.size() > 256) {
+            throw PayloadTooLargeException("data_write", "data", data.size(), 256)
+        }
+        val req = blerpc.Blerpc.DataWriteRequest.newBuilder()
+            .setData(data)
+            .build()
+        val respData = exclusive { call("data_write", req.toByteArray()) }
+        return decode("data_write", respData) { blerpc.Blerpc.DataWriteResponse.parseFrom(it) }
+    }
+}
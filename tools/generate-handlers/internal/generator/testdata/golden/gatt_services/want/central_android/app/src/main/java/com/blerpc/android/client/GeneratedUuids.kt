@@ -0,0 +1,39 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import java.util.UUID
+
+/** GATT UUIDs of the blerpc service, from blerpc.yaml. */
+object GeneratedUuids {
+    /** Service advertised by blerpc peripherals. */
+    val SERVICE_UUID: UUID = UUID.fromString("12340001-0000-1000-8000-00805f9b34fb")
+
+    /** The multiplexed RPC characteristic. */
+    val CHAR_UUID: UUID = UUID.fromString("12340002-0000-1000-8000-00805f9b34fb")
+
+    /** Services grouping commands apart from the RPC service: service and characteristic by name. */
+    val SERVICES: Map<String, Pair<UUID, UUID>> =
+        mapOf(
+            "diagnostics" to
+                Pair(
+                    UUID.fromString("6e400101-b5a3-f393-e0a9-e50e24dcca9e"),
+                    UUID.fromString("6e400102-b5a3-f393-e0a9-e50e24dcca9e"),
+                ),
+            "bulk" to
+                Pair(
+                    UUID.fromString("6e400201-b5a3-f393-e0a9-e50e24dcca9e"),
+                    UUID.fromString("6e400202-b5a3-f393-e0a9-e50e24dcca9e"),
+                ),
+        )
+
+    /** Service of each grouped command, by wire name; the others use [SERVICE_UUID]. */
+    val COMMAND_SERVICES: Map<String, String> =
+        mapOf(
+            "flash_read" to "diagnostics",
+            "data_write" to "bulk",
+        )
+
+    /** The characteristic [command] is written to. */
+    fun characteristicFor(command: String): UUID =
+        COMMAND_SERVICES[command]?.let { SERVICES.getValue(it).second } ?: CHAR_UUID
+}
@@ -0,0 +1,78 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+package com.blerpc.android.client
+
+import com.google.protobuf.MessageLite
+
+/**
+ * A call recorded by [MockGeneratedClient]: the command and its decoded
+ * requests, one per message of a C→P stream.
+ */
+data class MockCall(val command: String, val requests: List<MessageLite>)
+
+/**
+ * Stand-in for BlerpcClient in app unit tests, with no peripheral. Records
+ * every call in [calls] and answers it with the responses set for its
+ * command. Commands without any get an empty response, and P→C streams no
+ * responses.
+ */
+class MockGeneratedClient : GeneratedClient() {
+    private val answers = mutableMapOf<String, (List<MessageLite>) -> List<MessageLite>>()
+    private val recorded = mutableListOf<MockCall>()
+
+    /** The calls made so far, in order. */
+    val calls: List<MockCall>
+        get() = synchronized(recorded) { recorded.toList() }
+
+    /**
+     * Answers [command] with [responses]: a unary or C→P call gets the first,
+     * a P→C stream each of them.
+     */
+    fun respond(command: String, vararg responses: MessageLite) {
+        respond(command) { responses.toList() }
+    }
+
+    /** Answers [command] with the responses [answer] returns for its requests. */
+    fun respond(command: String, answer: (List<MessageLite>) -> List<MessageLite>) {
+        synchronized(answers) { answers[command] = answer }
+    }
+
+    /** Fails calls of [command] with [error], e.g. a [NotFoundException]. */
+    fun fail(command: String, error: Throwable) {
+        respond(command) { throw error }
+    }
+
+    override suspend fun call(cmdName: String, requestData: ByteArray): ByteArray {
+        val (command, responses) = answer(cmdName, listOf(requestData))
+        return (responses?.firstOrNull() ?: emptyResponse(command)).toByteArray()
+    }
+
+    override suspend fun streamReceive(cmdName: String, requestData: ByteArray): List<ByteArray> =
+        answer(cmdName, listOf(requestData)).second.orEmpty().map { it.toByteArray() }
+
+    override suspend fun streamSend(cmdName: String, messages: List<ByteArray>, finalCmdName: String): ByteArray {
+        val (command, responses) = answer(cmdName, messages)
+        return (responses?.firstOrNull() ?: emptyResponse(command)).toByteArray()
+    }
+
+    /** Records a call and returns its command with the responses set for it. */
+    private fun answer(cmdName: String, requests: List<ByteArray>): Pair<String, List<MessageLite>?> {
+        val command = cmdName
+        val decoded = requests.map { decodeRequest(command, it) }
+        synchronized(recorded) { recorded.add(MockCall(command, decoded)) }
+        return command to synchronized(answers) { answers[command] }?.invoke(decoded)
+    }
+
+    private fun decodeRequest(command: String, data: ByteArray): MessageLite = when (command) {
+        "echo" -> blerpc.Blerpc.EchoRequest.parseFrom(data)
+        "flash_read" -> blerpc.Blerpc.FlashReadRequest.parseFrom(data)
+        "data_write" -> blerpc.Blerpc.DataWriteRequest.parseFrom(data)
+        else -> throw IllegalArgumentException("unknown command $command")
+    }
+
+    private fun emptyResponse(command: String): MessageLite = when (command) {
+        "echo" -> blerpc.Blerpc.EchoResponse.getDefaultInstance()
+        "flash_read" -> blerpc.Blerpc.FlashReadResponse.getDefaultInstance()
+        "data_write" -> blerpc.Blerpc.DataWriteResponse.getDefaultInstance()
+        else -> throw IllegalArgumentException("unknown command $command")
+    }
+}
@@ -0,0 +1,30 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+
+// GATT UUIDs of the blerpc service, from blerpc.yaml.
+const String serviceUuid = '12340001-0000-1000-8000-00805f9b34fb';
+const String charUuid = '12340002-0000-1000-8000-00805f9b34fb';
+
+/// Services grouping commands apart from the RPC service, by name.
+const gattServices = <String, ({String service, String characteristic})>{
+  'diagnostics': (
+    service: '6e400101-b5a3-f393-e0a9-e50e24dcca9e',
+    characteristic: '6e400102-b5a3-f393-e0a9-e50e24dcca9e',
+  ),
+  'bulk': (
+    service: '6e400201-b5a3-f393-e0a9-e50e24dcca9e',
+    characteristic: '6e400202-b5a3-f393-e0a9-e50e24dcca9e',
+  ),
+};
+
+/// Service of each grouped command, by wire name; the others use
+/// [serviceUuid].
+const commandServices = <String, String>{
+  'flash_read': 'diagnostics',
+  'data_write': 'bulk',
+};
+
+/// The characteristic [command] is written to.
+String characteristicFor(String command) {
+  final service = commandServices[command];
+  return service == null ? charUuid : gattServices[service]!.characteristic;
+}
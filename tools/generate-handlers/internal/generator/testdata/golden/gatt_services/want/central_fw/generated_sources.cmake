@@ -0,0 +1,19 @@
+# Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT
+#
+# Generated client sources, include directories and Kconfig-driven
+# settings. Include it from the application CMakeLists.txt after
+# find_package(Zephyr):
+#
+#   include(${CMAKE_CURRENT_SOURCE_DIR}/generated_sources.cmake)
+
+target_sources(app PRIVATE
+    ${CMAKE_CURRENT_LIST_DIR}/src/generated_client.c
+)
+
+target_include_directories(app PRIVATE
+    ${CMAKE_CURRENT_LIST_DIR}/src
+)
+
+target_compile_definitions(app PRIVATE
+    BLERPC_GENERATED_RESP_BUF_SIZE=${CONFIG_BLERPC_GENERATED_RESP_BUF_SIZE}
+)
@@ -0,0 +1,101 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+#include "generated_client.h"
+
+#ifndef BLERPC_GENERATED_RESP_BUF_SIZE
+#define BLERPC_GENERATED_RESP_BUF_SIZE 4096
+#endif
+static uint8_t _blerpc_resp_buf[BLERPC_GENERATED_RESP_BUF_SIZE];
+
+/* Decode context for FT_CALLBACK bytes fields */
+struct _blerpc_bytes_decode_ctx {
+    uint8_t *buf;
+    size_t buf_size;
+    size_t decoded_len;
+};
+
+static bool _blerpc_decode_bytes_cb(pb_istream_t *stream,
+                                     const pb_field_t *field, void **arg)
+{
+    (void)field;
+    struct _blerpc_bytes_decode_ctx *ctx =
+        (struct _blerpc_bytes_decode_ctx *)*arg;
+    size_t len = stream->bytes_left;
+    if (len > ctx->buf_size - ctx->decoded_len) return false;
+    if (!pb_read(stream, ctx->buf + ctx->decoded_len, len)) return false;
+    ctx->decoded_len += len;
+    return true;
+}
+
+int blerpc_echo(const char *message, blerpc_EchoResponse *resp)
+{
+    blerpc_EchoRequest req = blerpc_EchoRequest_init_zero;
+    strncpy(req.message, message, sizeof(req.message) - 1);
+
+    uint8_t req_buf[blerpc_EchoRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_EchoRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_EchoResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("echo", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_EchoResponse)blerpc_EchoResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_EchoResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
+int blerpc_flash_read(uint32_t address, uint32_t length, blerpc_FlashReadResponse *resp, uint8_t *data_buf, size_t data_buf_size, size_t *data_len)
+{
+    blerpc_FlashReadRequest req = blerpc_FlashReadRequest_init_zero;
+    req.address = address;
+    req.length = length;
+
+    uint8_t req_buf[blerpc_FlashReadRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_FlashReadRequest_fields, &req)) return -1;
+
+    size_t resp_len;
+    if (blerpc_rpc_call("flash_read", req_buf, ostream.bytes_written,
+                        _blerpc_resp_buf, sizeof(_blerpc_resp_buf),
+                        &resp_len) != 0) return -1;
+
+    struct _blerpc_bytes_decode_ctx _data_ctx = {
+        .buf = data_buf, .buf_size = data_buf_size, .decoded_len = 0
+    };
+    *resp = (blerpc_FlashReadResponse)blerpc_FlashReadResponse_init_zero;
+    resp->data.funcs.decode = _blerpc_decode_bytes_cb;
+    resp->data.arg = &_data_ctx;
+    pb_istream_t istream = pb_istream_from_buffer(_blerpc_resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_FlashReadResponse_fields, resp)) return -1;
+
+    *data_len = _data_ctx.decoded_len;
+
+    return 0;
+}
+
+int blerpc_data_write(const uint8_t *data, size_t data_len, blerpc_DataWriteResponse *resp)
+{
+    blerpc_DataWriteRequest req = blerpc_DataWriteRequest_init_zero;
+    if (data_len > sizeof(req.data.bytes)) return -1;
+    memcpy(req.data.bytes, data, data_len);
+    req.data.size = (pb_size_t)data_len;
+
+    uint8_t req_buf[blerpc_DataWriteRequest_size];
+    pb_ostream_t ostream = pb_ostream_from_buffer(req_buf, sizeof(req_buf));
+    if (!pb_encode(&ostream, blerpc_DataWriteRequest_fields, &req)) return -1;
+
+    uint8_t resp_buf[blerpc_DataWriteResponse_size];
+    size_t resp_len;
+    if (blerpc_rpc_call("data_write", req_buf, ostream.bytes_written,
+                        resp_buf, sizeof(resp_buf), &resp_len) != 0) return -1;
+
+    *resp = (blerpc_DataWriteResponse)blerpc_DataWriteResponse_init_zero;
+    pb_istream_t istream = pb_istream_from_buffer(resp_buf, resp_len);
+    if (!pb_decode(&istream, blerpc_DataWriteResponse_fields, resp)) return -1;
+
+    return 0;
+}
+
@@ -0,0 +1,33 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_UUIDS_H
+#define BLERPC_GENERATED_UUIDS_H
+
+/* blerpc Service UUID: 12340001-0000-1000-8000-00805f9b34fb */
+#define BLERPC_SERVICE_UUID BT_UUID_128_ENCODE(0x12340001, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
+#define BLERPC_SERVICE_UUID_STR "12340001-0000-1000-8000-00805f9b34fb"
+
+/* blerpc Characteristic UUID: 12340002-0000-1000-8000-00805f9b34fb */
+#define BLERPC_CHAR_UUID BT_UUID_128_ENCODE(0x12340002, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
+#define BLERPC_CHAR_UUID_STR "12340002-0000-1000-8000-00805f9b34fb"
+
+/* diagnostics Service UUID: 6e400101-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_DIAGNOSTICS_SERVICE_UUID BT_UUID_128_ENCODE(0x6e400101, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+#define BLERPC_DIAGNOSTICS_SERVICE_UUID_STR "6e400101-b5a3-f393-e0a9-e50e24dcca9e"
+
+/* diagnostics Characteristic UUID: 6e400102-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_DIAGNOSTICS_CHAR_UUID BT_UUID_128_ENCODE(0x6e400102, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+#define BLERPC_DIAGNOSTICS_CHAR_UUID_STR "6e400102-b5a3-f393-e0a9-e50e24dcca9e"
+
+/* bulk Service UUID: 6e400201-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_BULK_SERVICE_UUID BT_UUID_128_ENCODE(0x6e400201, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+#define BLERPC_BULK_SERVICE_UUID_STR "6e400201-b5a3-f393-e0a9-e50e24dcca9e"
+
+/* bulk Characteristic UUID: 6e400202-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_BULK_CHAR_UUID BT_UUID_128_ENCODE(0x6e400202, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+#define BLERPC_BULK_CHAR_UUID_STR "6e400202-b5a3-f393-e0a9-e50e24dcca9e"
+
+/* Characteristic each grouped command is written to. */
+#define BLERPC_CMD_FLASH_READ_CHAR_UUID BLERPC_DIAGNOSTICS_CHAR_UUID
+#define BLERPC_CMD_DATA_WRITE_CHAR_UUID BLERPC_BULK_CHAR_UUID
+
+#endif /* BLERPC_GENERATED_UUIDS_H */
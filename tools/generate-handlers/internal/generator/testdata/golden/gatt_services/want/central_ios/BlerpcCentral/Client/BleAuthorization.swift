@@ -0,0 +1,117 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+import CoreBluetooth
+import Foundation
+
+/// Why Bluetooth cannot be used.
+enum BluetoothUnavailableError: Error, Equatable {
+    /// The user denied Bluetooth access; it can only be granted again in Settings.
+    case unauthorized
+    /// Bluetooth access is restricted, e.g. by parental controls or a device profile.
+    case restricted
+    case poweredOff
+    /// The device has no Bluetooth LE support.
+    case unsupported
+}
+
+/// Bluetooth availability derived from CBManager authorization and state.
+enum BluetoothAvailability: Equatable {
+    /// Not known yet, e.g. while the permission prompt is shown or Bluetooth resets.
+    case unknown
+    case available
+    case unavailable(BluetoothUnavailableError)
+
+    init(state: CBManagerState, authorization: CBManagerAuthorization = CBManager.authorization) {
+        switch authorization {
+        case .denied:
+            self = .unavailable(.unauthorized)
+            return
+        case .restricted:
+            self = .unavailable(.restricted)
+            return
+        default:
+            break
+        }
+        switch state {
+        case .poweredOn:
+            self = .available
+        case .poweredOff:
+            self = .unavailable(.poweredOff)
+        case .unauthorized:
+            self = .unavailable(.unauthorized)
+        case .unsupported:
+            self = .unavailable(.unsupported)
+        default:
+            self = .unknown
+        }
+    }
+}
+
+/// Follows Bluetooth availability with a central manager of its own, leaving the
+/// transport's manager and delegate alone. Creating the monitor shows the
+/// permission prompt if the user has not answered it yet.
+final class BleAuthorization: NSObject, CBCentralManagerDelegate {
+    static let shared = BleAuthorization()
+
+    private let queue = DispatchQueue(label: "com.blerpc.ble.authorization")
+    private var manager: CBCentralManager?
+    private var state: BluetoothAvailability = .unknown
+    private var continuations: [UUID: AsyncStream<BluetoothAvailability>.Continuation] = [:]
+
+    override init() {
+        super.init()
+        manager = CBCentralManager(
+            delegate: self,
+            queue: queue,
+            options: [CBCentralManagerOptionShowPowerAlertKey: false]
+        )
+    }
+
+    /// The latest availability.
+    var current: BluetoothAvailability {
+        queue.sync { state }
+    }
+
+    /// Yields the current availability, then every change.
+    var updates: AsyncStream<BluetoothAvailability> {
+        AsyncStream { continuation in
+            let id = UUID()
+            queue.sync {
+                continuations[id] = continuation
+                continuation.yield(state)
+            }
+            continuation.onTermination = { [weak self] _ in
+                self?.queue.async { self?.continuations[id] = nil }
+            }
+        }
+    }
+
+    /// Throws if Bluetooth is known to be unavailable.
+    func check() throws {
+        if case .unavailable(let error) = current {
+            throw error
+        }
+    }
+
+    /// Waits while the availability is unknown, then returns once Bluetooth is
+    /// available or throws the reason it is not.
+    func waitUntilAvailable() async throws {
+        for await availability in updates {
+            switch availability {
+            case .available:
+                return
+            case .unavailable(let error):
+                throw error
+            case .unknown:
+                continue
+            }
+        }
+        throw CancellationError()
+    }
+
+    func centralManagerDidUpdateState(_ central: CBCentralManager) {
+        state = BluetoothAvailability(state: central.state)
+        for continuation in continuations.values {
+            continuation.yield(state)
+        }
+    }
+}
@@ -0,0 +1,30 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+import CoreBluetooth
+
+/// A blerpc peripheral found by a scan; pass `device` to connect.
+struct DiscoveredDevice: Identifiable {
+    let device: ScannedDevice
+
+    var id: UUID { device.id }
+    var name: String? { device.name }
+    var rssi: Int { device.rssi }
+}
+
+/// Picks the blerpc peripherals out of scan results.
+enum GeneratedScanner {
+    /// Service UUID advertised by blerpc peripherals.
+    static let serviceUUID = CBUUID(string: "12340001-0000-1000-8000-00805f9b34fb")
+
+    /// Returns `device` as a DiscoveredDevice, or nil if it is not a blerpc peripheral.
+    static func discover(_ device: ScannedDevice) -> DiscoveredDevice? {
+        guard device.serviceUUIDs.contains(serviceUUID) else {
+            return nil
+        }
+        return DiscoveredDevice(device: device)
+    }
+
+    /// Returns the blerpc peripherals among `devices`, strongest first.
+    static func discoverAll(_ devices: [ScannedDevice]) -> [DiscoveredDevice] {
+        devices.compactMap { discover($0) }.sorted { $0.rssi > $1.rssi }
+    }
+}
@@ -0,0 +1,34 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+import CoreBluetooth
+
+/// GATT UUIDs of the blerpc service, from blerpc.yaml.
+enum GeneratedUUIDs {
+    /// Service advertised by blerpc peripherals.
+    static let service = CBUUID(string: "12340001-0000-1000-8000-00805f9b34fb")
+
+    /// The multiplexed RPC characteristic.
+    static let characteristic = CBUUID(string: "12340002-0000-1000-8000-00805f9b34fb")
+
+    /// Services grouping commands apart from the RPC service, by name.
+    static let services: [String: (service: CBUUID, characteristic: CBUUID)] = [
+        "diagnostics": (
+            CBUUID(string: "6e400101-b5a3-f393-e0a9-e50e24dcca9e"),
+            CBUUID(string: "6e400102-b5a3-f393-e0a9-e50e24dcca9e")
+        ),
+        "bulk": (
+            CBUUID(string: "6e400201-b5a3-f393-e0a9-e50e24dcca9e"),
+            CBUUID(string: "6e400202-b5a3-f393-e0a9-e50e24dcca9e")
+        ),
+    ]
+
+    /// Service of each grouped command, by wire name; the others use `service`.
+    static let commandServices: [String: String] = [
+        "flash_read": "diagnostics",
+        "data_write": "bulk",
+    ]
+
+    /// The characteristic `command` is written to.
+    static func routedCharacteristic(for command: String) -> CBUUID {
+        commandServices[command].flatMap { services[$0]?.characteristic } ?? characteristic
+    }
+}
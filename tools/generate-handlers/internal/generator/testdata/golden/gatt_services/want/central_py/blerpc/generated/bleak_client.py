@@ -0,0 +1,322 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+import logging
+from collections.abc import AsyncIterable, AsyncIterator, Iterable
+
+from bleak import BleakClient, BleakScanner
+from bleak.backends.device import BLEDevice
+from blerpc_protocol.command import CommandPacket, CommandType
+from blerpc_protocol.container import (
+    BLERPC_ERROR_RESPONSE_TOO_LARGE,
+    CAPABILITY_FLAG_ENCRYPTION_SUPPORTED,
+    Container,
+    ContainerAssembler,
+    ContainerSplitter,
+    ContainerType,
+    ControlCmd,
+    make_capabilities_request,
+    make_key_exchange,
+    make_stream_end_c2p,
+    make_timeout_request,
+)
+from blerpc_protocol.crypto import BlerpcCryptoSession, central_perform_key_exchange
+
+from .generated_client import (
+    GeneratedClientMixin,
+    TimeoutError,
+    TransportError,
+    make_cancel,
+)
+
+logger = logging.getLogger(__name__)
+
+SERVICE_UUID = "12340001-0000-1000-8000-00805f9b34fb"
+CHAR_UUID = "12340002-0000-1000-8000-00805f9b34fb"
+
+# Reads of the first response wait at least this long, as the peripheral's
+# timeout covers the gap between containers, not the time to run a command.
+_FIRST_READ_TIMEOUT_S = 2.0
+
+
+class BleakRpcClient(GeneratedClientMixin):
+    """Calls the commands over BLE with bleak.
+
+    Frames each call with blerpc_protocol, writes the containers to the RPC
+    characteristic and reassembles the notified responses:
+
+        client = BleakRpcClient()
+        device = await BleakRpcClient.find_device()
+        await client.connect(device)
+        print((await client.echo(message="hello")).message)
+        await client.disconnect()
+    """
+
+    def __init__(self, require_encryption: bool = True):
+        self._require_encryption = require_encryption
+        self._client: BleakClient | None = None
+        self._notify_queue: asyncio.Queue[bytes] = asyncio.Queue()
+        self._splitter: ContainerSplitter | None = None
+        self._assembler = ContainerAssembler()
+        self._timeout_s = 0.1
+        self._max_request_payload_size: int | None = None
+        self._capability_flags = 0
+        self._session: BlerpcCryptoSession | None = None
+
+    @property
+    def is_connected(self) -> bool:
+        return self._client is not None and self._client.is_connected
+
+    @property
+    def is_encrypted(self) -> bool:
+        return self._session is not None
+
+    @property
+    def capability_flags(self) -> int:
+        """Flags of the peripheral's CAPABILITIES response; 0 until received."""
+        return self._capability_flags
+
+    @property
+    def mtu(self) -> int:
+        """ATT MTU of the link; 23 until connected."""
+        return self._client.mtu_size if self._client is not None else 23
+
+    @staticmethod
+    async def find_device(timeout: float = 5.0) -> BLEDevice:
+        """Return the first peripheral advertising the blerpc service."""
+        device = await BleakScanner.find_device_by_filter(
+            lambda _d, adv: SERVICE_UUID in (u.lower() for u in adv.service_uuids),
+            timeout=timeout,
+        )
+        if device is None:
+            raise TransportError("No blerpc peripheral found")
+        return device
+
+    async def connect(self, device: BLEDevice | str) -> None:
+        """Connect to a device or address and set up the RPC channel.
+
+        Subscribes to the RPC characteristic, sizes containers for the
+        negotiated MTU and asks the peripheral for its timeout and
+        capabilities, running the key exchange when it supports encryption.
+        """
+        self._client = BleakClient(device, disconnected_callback=self._on_disconnect)
+        await self._client.connect()
+        self._notify_queue = asyncio.Queue()
+        await self._client.start_notify(CHAR_UUID, self._on_notify)
+        self._splitter = ContainerSplitter(mtu=self._client.mtu_size)
+        logger.info("Connected. MTU=%d", self._client.mtu_size)
+
+        try:
+            await self._request_timeout()
+        except TimeoutError:
+            logger.debug("Peripheral did not respond to timeout request, using default")
+        try:
+            await self._request_capabilities()
+        except TimeoutError:
+            logger.debug("Peripheral did not respond to capabilities request")
+        if self._require_encryption and self._session is None:
+            await self.disconnect()
+            raise TransportError(
+                "Encryption required but key exchange was not completed"
+            )
+
+    async def disconnect(self) -> None:
+        """Disconnect from the peripheral."""
+        client, self._client = self._client, None
+        if client is not None and client.is_connected:
+            try:
+                await client.stop_notify(CHAR_UUID)
+            except (OSError, EOFError) as e:
+                logger.debug("stop_notify during disconnect: %s", e)
+            await client.disconnect()
+        self._reset()
+
+    def _reset(self) -> None:
+        self._splitter = None
+        self._session = None
+        self._max_request_payload_size = None
+        self._capability_flags = 0
+
+    def _on_disconnect(self, _client: BleakClient) -> None:
+        self._reset()
+
+    def _on_notify(self, _sender: object, data: bytearray) -> None:
+        self._notify_queue.put_nowait(bytes(data))
+
+    async def _write(self, data: bytes) -> None:
+        if not self.is_connected:
+            raise TransportError("Not connected: call connect() first")
+        await self._client.write_gatt_char(CHAR_UUID, data, response=False)
+
+    async def _read_notify(self, timeout: float) -> bytes:
+        try:
+            return await asyncio.wait_for(self._notify_queue.get(), timeout=timeout)
+        except asyncio.TimeoutError as e:
+            raise TimeoutError("Timeout waiting for notification") from e
+
+    async def _control(
+        self, request: Container, cmd: ControlCmd, timeout: float
+    ) -> bytes:
+        await self._write(request.serialize())
+        resp = Container.deserialize(await self._read_notify(timeout))
+        if resp.container_type != ContainerType.CONTROL or resp.control_cmd != cmd:
+            raise TransportError(f"Expected control command {cmd!r}")
+        return resp.payload
+
+    async def _request_timeout(self) -> None:
+        tid = self._splitter.next_transaction_id()
+        payload = await self._control(
+            make_timeout_request(transaction_id=tid), ControlCmd.TIMEOUT, 1.0
+        )
+        if len(payload) == 2:
+            self._timeout_s = int.from_bytes(payload, "little") / 1000.0
+
+    async def _request_capabilities(self) -> None:
+        tid = self._splitter.next_transaction_id()
+        payload = await self._control(
+            make_capabilities_request(transaction_id=tid), ControlCmd.CAPABILITIES, 1.0
+        )
+        if len(payload) < 6:
+            return
+        self._max_request_payload_size = int.from_bytes(payload[0:2], "little")
+        flags = int.from_bytes(payload[4:6], "little")
+        self._capability_flags = flags
+        if flags & CAPABILITY_FLAG_ENCRYPTION_SUPPORTED:
+            await self._perform_key_exchange()
+
+    async def _perform_key_exchange(self) -> None:
+        async def send(payload: bytes) -> None:
+            tid = self._splitter.next_transaction_id()
+            req = make_key_exchange(transaction_id=tid, payload=payload)
+            await self._write(req.serialize())
+
+        async def receive() -> bytes:
+            resp = Container.deserialize(await self._read_notify(2.0))
+            if (
+                resp.container_type != ContainerType.CONTROL
+                or resp.control_cmd != ControlCmd.KEY_EXCHANGE
+            ):
+                raise ValueError("Expected KEY_EXCHANGE response")
+            return resp.payload
+
+        try:
+            self._session = await central_perform_key_exchange(send, receive)
+        except ValueError as e:
+            logger.error("Key exchange failed: %s", e)
+            if self._require_encryption:
+                raise
+
+    def _encrypt(self, payload: bytes) -> bytes:
+        if self._session is not None:
+            return self._session.encrypt(payload)
+        if self._require_encryption:
+            raise TransportError("Encryption required but no session established")
+        return payload
+
+    def _decrypt(self, payload: bytes) -> bytes:
+        if self._session is not None:
+            return self._session.decrypt(payload)
+        if self._require_encryption:
+            raise TransportError("Encryption required but no session established")
+        return payload
+
+    async def _send(self, cmd_name: str, request_data: bytes) -> None:
+        if self._splitter is None:
+            raise TransportError("Not connected: call connect() first")
+        payload = CommandPacket(
+            cmd_type=CommandType.REQUEST, cmd_name=cmd_name, data=request_data
+        ).serialize()
+        limit = self._max_request_payload_size
+        if limit is not None and len(payload) > limit:
+            raise TransportError(
+                f"Request payload ({len(payload)} bytes) exceeds peripheral limit"
+                f" ({limit} bytes)"
+            )
+        for c in self._splitter.split(self._encrypt(payload)):
+            await self._write(c.serialize())
+
+    async def _receive(self, cmd_name: str | None, first_read: bool) -> bytes | None:
+        """Read the next response; None when a P->C stream ends."""
+        self._assembler.reset()
+        while True:
+            timeout = self._timeout_s
+            if first_read:
+                timeout = max(timeout, _FIRST_READ_TIMEOUT_S)
+                first_read = False
+            container = Container.deserialize(await self._read_notify(timeout))
+            if container.container_type == ContainerType.CONTROL:
+                end = container.control_cmd == ControlCmd.STREAM_END_P2C
+                if end and cmd_name is None:
+                    return None
+                if container.control_cmd == ControlCmd.ERROR and container.payload:
+                    code = container.payload[0]
+                    if code == BLERPC_ERROR_RESPONSE_TOO_LARGE:
+                        raise TransportError(
+                            "Response exceeds peripheral's max_response_payload_size"
+                        )
+                    raise TransportError(f"Peripheral error: 0x{code:02x}")
+                continue
+            result = self._assembler.feed(container)
+            if result is None:
+                continue
+            resp = CommandPacket.deserialize(self._decrypt(result))
+            if resp.cmd_type != CommandType.RESPONSE:
+                raise TransportError(f"Expected response, got type={resp.cmd_type}")
+            if cmd_name is not None and resp.cmd_name != cmd_name:
+                raise TransportError(
+                    f"Command name mismatch: expected '{cmd_name}',"
+                    f" got '{resp.cmd_name}'"
+                )
+            return resp.data
+
+    async def _call(self, cmd_name: str, request_data: bytes) -> bytes:
+        await self._send(cmd_name, request_data)
+        return await self._receive(cmd_name, True)
+
+    async def stream_receive(
+        self, cmd_name: str, request_data: bytes
+    ) -> AsyncIterator[bytes]:
+        await self._send(cmd_name, request_data)
+        data = await self._receive(None, True)
+        while data is not None:
+            yield data
+            data = await self._receive(None, False)
+
+    async def stream_cancel(self) -> None:
+        """Stop the P->C stream in progress and drain it up to its end."""
+        if self._splitter is None or not self.is_connected:
+            return
+        await self._write(make_cancel(self._splitter.next_transaction_id()))
+        while True:
+            try:
+                container = Container.deserialize(
+                    await self._read_notify(self._timeout_s)
+                )
+            except TimeoutError:
+                logger.warning("Cancelled stream did not end")
+                return
+            if container.container_type == ContainerType.CONTROL and (
+                container.control_cmd in (ControlCmd.STREAM_END_P2C, ControlCmd.ERROR)
+            ):
+                self._assembler.reset()
+                return
+
+    async def stream_send(
+        self,
+        cmd_name: str,
+        messages: Iterable[bytes] | AsyncIterable[bytes],
+        final_cmd_name: str,
+    ) -> bytes:
+        if isinstance(messages, AsyncIterable):
+            async for data in messages:
+                await self._send(cmd_name, data)
+        else:
+            for data in messages:
+                await self._send(cmd_name, data)
+        if self._splitter is None:
+            raise TransportError("Not connected: call connect() first")
+        tid = self._splitter.next_transaction_id()
+        await self._write(make_stream_end_c2p(transaction_id=tid).serialize())
+        return await self._receive(final_cmd_name, True)
@@ -0,0 +1,117 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+import asyncio
+
+
+class DeviceManager:
+    """Connections to several peripherals, keyed by address.
+
+    factory returns a new, unconnected client, e.g. BlerpcClient. Every device
+    gets a client of its own, so calls to different devices run concurrently
+    while each client still runs one RPC at a time.
+    """
+
+    def __init__(self, factory):
+        self._factory = factory
+        self._clients = {}
+        self._connecting = {}
+
+    def __contains__(self, address):
+        return address in self._clients
+
+    def __getitem__(self, address):
+        """The client of address; KeyError if it was never connected."""
+        return self._clients[address]
+
+    def __len__(self):
+        return len(self._clients)
+
+    @property
+    def addresses(self):
+        """Addresses of the managed devices, in connection order."""
+        return list(self._clients)
+
+    async def __aenter__(self):
+        return self
+
+    async def __aexit__(self, *exc):
+        await self.disconnect_all()
+
+    async def connect(self, device):
+        """Connect to a scanned device and return its client.
+
+        A live connection to the same address is reused; concurrent calls for
+        one address share a single connection attempt.
+        """
+        address = device.address
+        client = self._clients.get(address)
+        if client is not None and client.is_connected:
+            return client
+        task = self._connecting.get(address)
+        if task is None:
+            task = asyncio.ensure_future(self._connect(device))
+            self._connecting[address] = task
+            task.add_done_callback(lambda _: self._connecting.pop(address, None))
+        return await asyncio.shield(task)
+
+    async def _connect(self, device):
+        client = self._factory()
+        await client.connect(device)
+        self._clients[device.address] = client
+        return client
+
+    async def connect_all(self, devices):
+        """Connect to every device concurrently.
+
+        Returns {address: client}, with the exception in place of the client
+        for devices that could not be connected.
+        """
+        devices = list(devices)
+        results = await asyncio.gather(
+            *(self.connect(d) for d in devices), return_exceptions=True
+        )
+        return {d.address: r for d, r in zip(devices, results)}
+
+    async def disconnect(self, address):
+        """Disconnect address and stop managing it."""
+        client = self._clients.pop(address, None)
+        if client is not None:
+            await client.disconnect()
+
+    async def disconnect_all(self):
+        clients = list(self._clients.values())
+        self._clients.clear()
+        await asyncio.gather(
+            *(c.disconnect() for c in clients), return_exceptions=True
+        )
+
+    async def broadcast(self, fn, addresses=None):
+        """Await fn(client) for every connected device concurrently.
+
+        addresses limits the call to those devices. Returns {address: result};
+        a device whose call failed maps to the exception, so one bad device
+        does not hide the results of the others.
+        """
+        if addresses is None:
+            addresses = [a for a, c in self._clients.items() if c.is_connected]
+        addresses = list(addresses)
+        results = await asyncio.gather(
+            *(fn(self._clients[a]) for a in addresses), return_exceptions=True
+        )
+        return dict(zip(addresses, results))
+
+    async def echo_all(self, *, message="", addresses=None):
+        """Call echo on every connected device."""
+        return await self.broadcast(lambda c: c.echo(message=message), addresses)
+
+    async def flash_read_all(self, *, address=0, length=0, addresses=None):
+        """Call flash_read on every connected device."""
+        return await self.broadcast(
+            lambda c: c.flash_read(address=address, length=length), addresses
+        )
+
+    async def data_write_all(self, *, data=b"", addresses=None):
+        """Call data_write on every connected device."""
+        return await self.broadcast(lambda c: c.data_write(data=data), addresses)
@@ -0,0 +1,31 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT."""
+
+# GATT UUIDs of the blerpc service, from blerpc.yaml.
+SERVICE_UUID = "12340001-0000-1000-8000-00805f9b34fb"
+CHAR_UUID = "12340002-0000-1000-8000-00805f9b34fb"
+
+# GATT services grouping commands apart from the RPC service, from
+# blerpc.yaml: (service UUID, characteristic UUID) by name.
+SERVICES = {
+    "diagnostics": (
+        "6e400101-b5a3-f393-e0a9-e50e24dcca9e",
+        "6e400102-b5a3-f393-e0a9-e50e24dcca9e",
+    ),
+    "bulk": (
+        "6e400201-b5a3-f393-e0a9-e50e24dcca9e",
+        "6e400202-b5a3-f393-e0a9-e50e24dcca9e",
+    ),
+}
+
+# Service of each grouped command, by wire name; the others use
+# SERVICE_UUID and CHAR_UUID.
+COMMAND_SERVICES = {
+    "flash_read": "diagnostics",
+    "data_write": "bulk",
+}
+
+
+def characteristic_for(command: str) -> str:
+    """Return the UUID of the characteristic command is written to."""
+    service = COMMAND_SERVICES.get(command)
+    return SERVICES[service][1] if service else CHAR_UUID
@@ -0,0 +1,61 @@
+"""Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT."""
+
+from __future__ import annotations
+
+from typing import Any
+
+from google.protobuf.descriptor import FieldDescriptor
+from google.protobuf.message import Message
+
+REDACTED = "<redacted>"
+
+# Fields marked (blerpc.sensitive), by the full name of their message.
+SENSITIVE_FIELDS: dict[str, frozenset[str]] = {}
+
+
+def redacted(message: Message) -> str:
+    """Return message like a dataclass repr, its sensitive fields masked.
+
+    Only the fields that are set are shown, e.g.
+    LoginRequest(user='ann', password=<redacted>); messages in fields are
+    redacted too. Use it, or Redacted, wherever requests and responses are
+    logged.
+    """
+    hidden = SENSITIVE_FIELDS.get(message.DESCRIPTOR.full_name, frozenset())
+    parts = []
+    for field, value in message.ListFields():
+        entry = field.message_type
+        if field.name in hidden:
+            text = REDACTED
+        elif entry is not None and entry.GetOptions().map_entry:
+            items = (f"{k!r}: {_value(v)}" for k, v in value.items())
+            text = "{" + ", ".join(items) + "}"
+        elif field.label == FieldDescriptor.LABEL_REPEATED:
+            text = "[" + ", ".join(_value(v) for v in value) + "]"
+        else:
+            text = _value(value)
+        parts.append(f"{field.name}={text}")
+    return f"{message.DESCRIPTOR.name}({', '.join(parts)})"
+
+
+def _value(value: Any) -> str:
+    if isinstance(value, Message):
+        return redacted(value)
+    return repr(value)
+
+
+class Redacted:
+    """Formats message with redacted() once a log record is emitted, e.g.
+
+    logger.debug("sent %s", Redacted(request))
+    """
+
+    __slots__ = ("message",)
+
+    def __init__(self, message: Message) -> None:
+        self.message = message
+
+    def __str__(self) -> str:
+        return redacted(self.message)
+
+    __repr__ = __str__
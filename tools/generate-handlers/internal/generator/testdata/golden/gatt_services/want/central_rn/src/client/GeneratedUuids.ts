@@ -0,0 +1,31 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+
+// GATT UUIDs of the blerpc service, from blerpc.yaml.
+export const SERVICE_UUID = '12340001-0000-1000-8000-00805f9b34fb';
+export const CHAR_UUID = '12340002-0000-1000-8000-00805f9b34fb';
+
+/** Services grouping commands apart from the RPC service, by name. */
+export const SERVICES: Readonly<
+  Record<string, { service: string; characteristic: string }>
+> = {
+  diagnostics: {
+    service: '6e400101-b5a3-f393-e0a9-e50e24dcca9e',
+    characteristic: '6e400102-b5a3-f393-e0a9-e50e24dcca9e',
+  },
+  bulk: {
+    service: '6e400201-b5a3-f393-e0a9-e50e24dcca9e',
+    characteristic: '6e400202-b5a3-f393-e0a9-e50e24dcca9e',
+  },
+};
+
+/** Service of each grouped command, by wire name; the others use SERVICE_UUID. */
+export const COMMAND_SERVICES: Readonly<Record<string, string>> = {
+  flash_read: 'diagnostics',
+  data_write: 'bulk',
+};
+
+/** The characteristic `command` is written to. */
+export function characteristicFor(command: string): string {
+  const service = COMMAND_SERVICES[command];
+  return service ? SERVICES[service].characteristic : CHAR_UUID;
+}
@@ -0,0 +1,104 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_GATT_SERVICE_H
+#define BLERPC_GENERATED_GATT_SERVICE_H
+
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#include <zephyr/bluetooth/conn.h>
+#include <zephyr/bluetooth/gatt.h>
+#include "generated_handlers.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* blerpc Service UUID: 12340001-0000-1000-8000-00805f9b34fb */
+#define BLERPC_SERVICE_UUID BT_UUID_128_ENCODE(0x12340001, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
+
+/* blerpc Characteristic UUID: 12340002-0000-1000-8000-00805f9b34fb */
+#define BLERPC_CHAR_UUID BT_UUID_128_ENCODE(0x12340002, 0x0000, 0x1000, 0x8000, 0x00805f9b34fb)
+
+/* ATT MTU before the MTU exchange, and without a connection. */
+#define BLERPC_GATT_DEFAULT_MTU 23
+
+/**
+ * Register the GATT callbacks (MTU updates).
+ * Call once after bt_enable().
+ */
+void blerpc_gatt_init(void);
+
+/**
+ * Implemented by the BLE layer: a container was written to the RPC
+ * characteristic. Called on the Bluetooth RX thread.
+ */
+void blerpc_gatt_on_write(struct bt_conn *conn, const uint8_t *data, uint16_t len);
+
+/**
+ * Called when the ATT MTU of conn changes. Weak; the default does nothing.
+ */
+void blerpc_gatt_on_mtu_updated(struct bt_conn *conn, uint16_t tx, uint16_t rx);
+
+/**
+ * Called when the Central enables or disables notifications. Weak; the
+ * default does nothing.
+ */
+void blerpc_gatt_on_notify_changed(bool enabled);
+
+/**
+ * Whether the Central has enabled notifications on the RPC characteristic.
+ */
+bool blerpc_gatt_notify_enabled(void);
+
+/**
+ * Get the ATT MTU of conn, or BLERPC_GATT_DEFAULT_MTU when conn is NULL.
+ */
+uint16_t blerpc_gatt_get_mtu(struct bt_conn *conn);
+
+/**
+ * Ask the Central for the largest ATT MTU the stack supports.
+ * Needs CONFIG_BT_GATT_CLIENT; returns -ENOTSUP without it.
+ */
+int blerpc_gatt_exchange_mtu(struct bt_conn *conn);
+
+/**
+ * Send a notification on the RPC characteristic.
+ * @return 0 on success, negative on error
+ */
+int blerpc_gatt_notify(struct bt_conn *conn, const uint8_t *data, size_t len);
+
+/* GATT services grouping commands apart from the RPC service
+ * (gatt.services in blerpc.yaml), each with one characteristic. */
+/* diagnostics Service UUID: 6e400101-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_DIAGNOSTICS_SERVICE_UUID BT_UUID_128_ENCODE(0x6e400101, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+/* diagnostics Characteristic UUID: 6e400102-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_DIAGNOSTICS_CHAR_UUID BT_UUID_128_ENCODE(0x6e400102, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+
+/* bulk Service UUID: 6e400201-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_BULK_SERVICE_UUID BT_UUID_128_ENCODE(0x6e400201, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+/* bulk Characteristic UUID: 6e400202-b5a3-f393-e0a9-e50e24dcca9e */
+#define BLERPC_BULK_CHAR_UUID BT_UUID_128_ENCODE(0x6e400202, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)
+
+/**
+ * Implemented by the BLE layer: a container was written to the
+ * characteristic of a grouped service. Look its command up with
+ * blerpc_service_handlers_lookup(service, ...), and that of a
+ * container written to the RPC characteristic with BLERPC_GATT_SERVICE_RPC,
+ * so each characteristic only runs the commands of its service.
+ */
+void blerpc_gatt_on_service_write(enum blerpc_gatt_service service, struct bt_conn *conn,
+                                  const uint8_t *data, uint16_t len);
+
+/**
+ * Send a notification on the characteristic of service; BLERPC_GATT_SERVICE_RPC
+ * is the RPC characteristic.
+ * @return 0 on success, negative on error
+ */
+int blerpc_gatt_service_notify(enum blerpc_gatt_service service, struct bt_conn *conn,
+                               const uint8_t *data, size_t len);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_GATT_SERVICE_H */
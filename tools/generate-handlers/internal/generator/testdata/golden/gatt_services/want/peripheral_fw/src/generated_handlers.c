@@ -0,0 +1,180 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+#include "generated_handlers.h"
+#include "blerpc.pb.h"
+#include <pb_encode.h>
+#include <pb_decode.h>
+#include <string.h>
+
+/* Each command, header and name included, must fit the transport
+ * buffers at its largest. */
+#ifdef CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "echo requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(14 + BLERPC_FLASH_READ_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "flash_read requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(14 + BLERPC_DATA_WRITE_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "data_write requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+#endif
+#ifdef CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "echo responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(14 + BLERPC_DATA_WRITE_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "data_write responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+#endif
+
+/* Discard callback for FT_CALLBACK fields during decode */
+static bool discard_bytes_cb(pb_istream_t *stream, const pb_field_t *field,
+                             void **arg)
+{
+    (void)field;
+    (void)arg;
+    uint8_t buf[64];
+    size_t left = stream->bytes_left;
+    while (left > 0) {
+        size_t n = left < sizeof(buf) ? left : sizeof(buf);
+        if (!pb_read(stream, buf, n)) return false;
+        left -= n;
+    }
+    return true;
+}
+
+__attribute__((weak))
+int handle_echo(const uint8_t *req_data, size_t req_len,
+                    pb_ostream_t *ostream)
+{
+    blerpc_EchoRequest req = blerpc_EchoRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_EchoRequest_fields, &req)) return -1;
+
+    blerpc_EchoResponse resp = blerpc_EchoResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_EchoResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+size_t blerpc_flash_read_length_data(const blerpc_FlashReadRequest *req)
+{
+    (void)req;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_flash_read_source_data(const blerpc_FlashReadRequest *req, uint8_t *chunk,
+                                  size_t len, size_t offset)
+{
+    (void)req;
+    (void)chunk;
+    (void)len;
+    (void)offset;
+    return -1;
+}
+
+static bool write_flash_read_data(pb_ostream_t *stream, const pb_field_t *field,
+                                  void *const *arg)
+{
+    const blerpc_FlashReadRequest *req = *arg;
+    size_t len = blerpc_flash_read_length_data(req);
+    if (len == 0) return true;
+    if (!pb_encode_tag_for_field(stream, field)) return false;
+    if (!pb_encode_varint(stream, len)) return false;
+    /* The sizing pass counts the bytes without reading them. */
+    if (stream->callback == NULL) return pb_write(stream, NULL, len);
+    uint8_t buf[64];
+    size_t offset = 0;
+    while (offset < len) {
+        size_t n = len - offset < sizeof(buf) ? len - offset : sizeof(buf);
+        if (blerpc_flash_read_source_data(req, buf, n, offset) != 0) return false;
+        if (!pb_write(stream, buf, n)) return false;
+        offset += n;
+    }
+    return true;
+}
+
+__attribute__((weak))
+int handle_flash_read(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream)
+{
+    blerpc_FlashReadRequest req = blerpc_FlashReadRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_FlashReadRequest_fields, &req)) return -1;
+
+    blerpc_FlashReadResponse resp = blerpc_FlashReadResponse_init_zero;
+    resp.data.funcs.encode = write_flash_read_data;
+    resp.data.arg = &req;
+    if (!pb_encode(ostream, blerpc_FlashReadResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_data_write(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream)
+{
+    blerpc_DataWriteRequest req = blerpc_DataWriteRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_DataWriteRequest_fields, &req)) return -1;
+
+    blerpc_DataWriteResponse resp = blerpc_DataWriteResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_DataWriteResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+static const struct handler_entry handler_table[] = {
+#if BLERPC_CMDS_BLERPC
+    {"echo", 4, handle_echo},
+    {"flash_read", 10, handle_flash_read},
+    {"data_write", 10, handle_data_write},
+#endif
+};
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len)
+{
+    size_t i;
+    for (i = 0; i < sizeof(handler_table) / sizeof(handler_table[0]); i++) {
+        if (handler_table[i].name_len == name_len &&
+            memcmp(handler_table[i].name, name, name_len) == 0) {
+            return handler_table[i].handler;
+        }
+    }
+    return NULL;
+}
+
+/* Commands of each GATT service, for blerpc_service_handlers_lookup. */
+struct service_command {
+    const char *name;
+    uint8_t name_len;
+};
+
+static const struct service_command rpc_service_commands[] = {
+    {"echo", 4},
+};
+
+static const struct service_command diagnostics_service_commands[] = {
+    {"flash_read", 10},
+};
+
+static const struct service_command bulk_service_commands[] = {
+    {"data_write", 10},
+};
+
+static const struct {
+    const struct service_command *commands;
+    size_t count;
+} service_tables[BLERPC_GATT_SERVICE_COUNT] = {
+    {rpc_service_commands, sizeof(rpc_service_commands) / sizeof(rpc_service_commands[0])},
+    {diagnostics_service_commands, sizeof(diagnostics_service_commands) / sizeof(diagnostics_service_commands[0])},
+    {bulk_service_commands, sizeof(bulk_service_commands) / sizeof(bulk_service_commands[0])},
+};
+
+command_handler_fn blerpc_service_handlers_lookup(enum blerpc_gatt_service service, const char *name,
+                                                  uint8_t name_len)
+{
+    size_t i;
+    if ((unsigned)service >= BLERPC_GATT_SERVICE_COUNT) return NULL;
+    for (i = 0; i < service_tables[service].count; i++) {
+        const struct service_command *cmd = &service_tables[service].commands[i];
+        if (cmd->name_len == name_len && memcmp(cmd->name, name, name_len) == 0) {
+            return handlers_lookup(name, name_len);
+        }
+    }
+    return NULL;
+}
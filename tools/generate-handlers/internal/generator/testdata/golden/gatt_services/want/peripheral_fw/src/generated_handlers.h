@@ -0,0 +1,121 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema f1234630e4fe626a) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_HANDLERS_H
+#define BLERPC_GENERATED_HANDLERS_H
+
+#include <stdint.h>
+#include <stddef.h>
+#include <pb_encode.h>
+#include "blerpc.pb.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+typedef int (*command_handler_fn)(const uint8_t *req_data, size_t req_len,
+                                  pb_ostream_t *ostream);
+
+struct handler_entry {
+    const char *name;
+    uint8_t name_len;
+    command_handler_fn handler;
+};
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len);
+
+/* Returned for a request over its command's rate limit; the dispatcher
+ * answers it with a BUSY error. */
+#define HANDLER_BUSY (-3)
+
+/* Returned for a replay-protected request whose MAC is wrong or whose
+ * counter is not newer than the last one accepted; the dispatcher drops
+ * it. */
+#define HANDLER_REPLAYED (-4)
+
+/* Status codes of error responses. Codes from 128 up are free for
+ * application use. */
+enum blerpc_status {
+    BLERPC_STATUS_OK = 0,
+    BLERPC_STATUS_INVALID_ARGUMENT = 1,
+    BLERPC_STATUS_NOT_FOUND = 2,
+    BLERPC_STATUS_ALREADY_EXISTS = 3,
+    BLERPC_STATUS_PERMISSION_DENIED = 4,
+    BLERPC_STATUS_RESOURCE_EXHAUSTED = 5,
+    BLERPC_STATUS_FAILED_PRECONDITION = 6,
+    BLERPC_STATUS_OUT_OF_RANGE = 7,
+    BLERPC_STATUS_UNIMPLEMENTED = 8,
+    BLERPC_STATUS_INTERNAL = 9,
+    BLERPC_STATUS_UNAVAILABLE = 10,
+    BLERPC_STATUS_UNAUTHENTICATED = 11,
+};
+
+/* Field number of the status in an error response; no message uses it. */
+#define BLERPC_STATUS_FIELD 536870911
+
+/*
+ * Write an error response carrying status in place of the response
+ * message, e.g.
+ *
+ *     return blerpc_return_error(ostream, BLERPC_STATUS_NOT_FOUND);
+ *
+ * Return 0, or -1 if the status does not fit.
+ */
+static inline int blerpc_return_error(pb_ostream_t *ostream, uint32_t status)
+{
+    if (!pb_encode_tag(ostream, PB_WT_VARINT, BLERPC_STATUS_FIELD)) return -1;
+    return pb_encode_varint(ostream, status) ? 0 : -1;
+}
+
+/* GATT services the commands are grouped into (gatt.services in
+ * blerpc.yaml), each with a characteristic of its own. */
+enum blerpc_gatt_service {
+    BLERPC_GATT_SERVICE_RPC = 0, /* the multiplexed RPC service */
+    BLERPC_GATT_SERVICE_DIAGNOSTICS = 1,
+    BLERPC_GATT_SERVICE_BULK = 2,
+};
+
+#define BLERPC_GATT_SERVICE_COUNT 3
+
+/* handlers_lookup for a container written to the characteristic of
+ * service: the commands of the other services look unknown. */
+command_handler_fn blerpc_service_handlers_lookup(enum blerpc_gatt_service service, const char *name,
+                                                  uint8_t name_len);
+
+/* Command groups in the handler table; define one to 0 to leave its
+ * commands out. Zephyr builds set them from Kconfig.generated. */
+#ifndef BLERPC_CMDS_BLERPC
+#define BLERPC_CMDS_BLERPC 1
+#endif
+
+/* Largest encoded request and response of each command, from the
+ * max_size and max_count bounds in the .options file. Messages with an
+ * unbounded field (FT_CALLBACK, or no bound) have no constant. */
+#define BLERPC_ECHO_MAX_REQ_SIZE 259
+#define BLERPC_ECHO_MAX_RESP_SIZE 259
+#define BLERPC_FLASH_READ_MAX_REQ_SIZE 12
+#define BLERPC_DATA_WRITE_MAX_REQ_SIZE 259
+#define BLERPC_DATA_WRITE_MAX_RESP_SIZE 6
+
+/* Source hooks of the FT_CALLBACK bytes fields of the responses. The
+ * length hook returns the length of the field for req, 0 to leave it out;
+ * the source hook fills chunk with the len bytes of the field at offset
+ * and returns 0, or a negative value to fail the request. The chunks go
+ * out as they are filled, so the field may exceed the RAM of the device.
+ * The weak length hooks return 0 and the weak source hooks fail. */
+size_t blerpc_flash_read_length_data(const blerpc_FlashReadRequest *req);
+int blerpc_flash_read_source_data(const blerpc_FlashReadRequest *req, uint8_t *chunk,
+                                  size_t len, size_t offset);
+
+int handle_echo(const uint8_t *req_data, size_t req_len,
+                    pb_ostream_t *ostream);
+
+int handle_flash_read(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream);
+
+int handle_data_write(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_HANDLERS_H */
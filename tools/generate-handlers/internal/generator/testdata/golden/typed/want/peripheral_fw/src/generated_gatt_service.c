@@ -0,0 +1,379 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT */
+#include "generated_gatt_service.h"
+#include "generated_handlers.h"
+#include <blerpc_protocol/command.h>
+#include <blerpc_protocol/container.h>
+#include <errno.h>
+#include <pb_encode.h>
+#include <stdbool.h>
+#include <string.h>
+#include "esp_log.h"
+#include "freertos/FreeRTOS.h"
+#include "freertos/task.h"
+#include "host/ble_hs.h"
+
+static const char *TAG = "blerpc_gatt";
+
+static const ble_uuid128_t rpc_svc_uuid = BLERPC_SERVICE_UUID;
+static const ble_uuid128_t rpc_char_uuid = BLERPC_CHAR_UUID;
+
+static uint16_t rpc_val_handle;
+static uint16_t conn_handle = BLE_HS_CONN_HANDLE_NONE;
+static struct container_assembler assembler;
+static blerpc_gatt_stream_end_cb_t stream_end_cb;
+static uint8_t transaction_counter;
+
+/* Assembled request, handed from the NimBLE host task to the request task */
+static TaskHandle_t request_task_handle;
+static volatile bool request_busy;
+static uint8_t request_transaction_id;
+static size_t request_len;
+static uint8_t request_buf[CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE];
+static uint8_t response_buf[CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE];
+
+__attribute__((weak))
+void blerpc_gatt_on_mtu_updated(uint16_t conn_handle, uint16_t mtu)
+{
+    (void)conn_handle;
+    (void)mtu;
+}
+
+__attribute__((weak))
+void *blerpc_gatt_handler_ctx(uint16_t conn_handle)
+{
+    (void)conn_handle;
+    return NULL;
+}
+
+static int send_with_retry(const uint8_t *data, size_t len)
+{
+    int rc;
+    for (int retries = 0; retries < 10; retries++) {
+        rc = blerpc_gatt_notify(data, len);
+        if (rc != -ENOMEM) {
+            return rc;
+        }
+        vTaskDelay(pdMS_TO_TICKS(5));
+    }
+    ESP_LOGE(TAG, "Notify failed after retries: %d", rc);
+    return rc;
+}
+
+static int container_send_cb(const uint8_t *data, size_t len, void *ctx)
+{
+    (void)ctx;
+    return send_with_retry(data, len);
+}
+
+static void send_control(uint8_t transaction_id, uint8_t control_cmd, uint8_t *payload,
+                         uint8_t payload_len)
+{
+    uint8_t ctrl_buf[16];
+    struct container_header ctrl = {
+        .transaction_id = transaction_id,
+        .sequence_number = 0,
+        .type = CONTAINER_TYPE_CONTROL,
+        .control_cmd = control_cmd,
+        .payload_len = payload_len,
+        .payload = payload,
+    };
+    int n = container_serialize(&ctrl, ctrl_buf, sizeof(ctrl_buf));
+    if (n > 0) {
+        send_with_retry(ctrl_buf, (size_t)n);
+    }
+}
+
+static void send_error(uint8_t transaction_id, uint8_t code)
+{
+    uint8_t err_payload[1] = {code};
+    send_control(transaction_id, CONTROL_CMD_ERROR, err_payload, sizeof(err_payload));
+}
+
+/* ── Request processing ──────────────────────────────────────────────── */
+
+static void process_request(const uint8_t *data, size_t len, uint8_t transaction_id)
+{
+    struct command_packet cmd;
+    if (command_parse(data, len, &cmd) != 0) {
+        ESP_LOGE(TAG, "Command parse failed");
+        return;
+    }
+    if (cmd.cmd_type != COMMAND_TYPE_REQUEST) {
+        ESP_LOGE(TAG, "Expected request, got type %d", cmd.cmd_type);
+        return;
+    }
+    void *ctx = blerpc_gatt_handler_ctx(conn_handle);
+
+    command_handler_fn handler = handlers_lookup(cmd.cmd_name, cmd.cmd_name_len, ctx);
+    if (!handler) {
+        ESP_LOGE(TAG, "Unknown command: %.*s", cmd.cmd_name_len, cmd.cmd_name);
+        return;
+    }
+
+    /* Pass 1: Calculate protobuf encoded size (sizing stream, no I/O) */
+    pb_ostream_t sizing = PB_OSTREAM_SIZING;
+    int handler_rc = handler(cmd.data, cmd.data_len, &sizing, ctx);
+    if (handler_rc == HANDLER_BUSY) {
+        ESP_LOGW(TAG, "Rate limited: %.*s", cmd.cmd_name_len, cmd.cmd_name);
+        send_error(transaction_id, BLERPC_ERROR_BUSY);
+        return;
+    }
+    if (handler_rc == HANDLER_REPLAYED) {
+        ESP_LOGW(TAG, "Replayed request dropped: %.*s", cmd.cmd_name_len, cmd.cmd_name);
+        return;
+    }
+    if (handler_rc == -2) {
+        /* Handler manages its own response (e.g. stream handlers) */
+        return;
+    }
+    if (handler_rc != 0) {
+        ESP_LOGE(TAG, "Handler sizing pass failed");
+        return;
+    }
+
+    size_t cmd_hdr_size = 2 + cmd.cmd_name_len + 2;
+    size_t pb_size = sizing.bytes_written;
+    size_t total_length = cmd_hdr_size + pb_size;
+    if (total_length > CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE ||
+        total_length > sizeof(response_buf)) {
+        ESP_LOGW(TAG, "Response too large: %u", (unsigned)total_length);
+        send_error(transaction_id, BLERPC_ERROR_RESPONSE_TOO_LARGE);
+        return;
+    }
+
+    response_buf[0] = (COMMAND_TYPE_RESPONSE & 0x01) << 7;
+    response_buf[1] = cmd.cmd_name_len;
+    memcpy(response_buf + 2, cmd.cmd_name, cmd.cmd_name_len);
+    response_buf[2 + cmd.cmd_name_len] = (uint8_t)(pb_size & 0xFF);
+    response_buf[3 + cmd.cmd_name_len] = (uint8_t)((pb_size >> 8) & 0xFF);
+
+    /* Pass 2: Encode protobuf after the command header */
+    pb_ostream_t ostream = pb_ostream_from_buffer(response_buf + cmd_hdr_size, pb_size);
+    if (handler(cmd.data, cmd.data_len, &ostream, ctx) != 0) {
+        ESP_LOGE(TAG, "Handler encode pass failed");
+        return;
+    }
+    if (blerpc_gatt_send_command_response(transaction_id, response_buf, total_length) < 0) {
+        ESP_LOGE(TAG, "Response send failed");
+    }
+}
+
+static void request_task(void *arg)
+{
+    (void)arg;
+    for (;;) {
+        ulTaskNotifyTake(pdTRUE, portMAX_DELAY);
+        process_request(request_buf, request_len, request_transaction_id);
+        request_busy = false;
+    }
+}
+
+/* ── Characteristic writes ───────────────────────────────────────────── */
+
+static void on_control(const struct container_header *hdr)
+{
+    if (hdr->control_cmd == CONTROL_CMD_TIMEOUT) {
+        uint8_t timeout_payload[2] = {
+            (uint8_t)(CONFIG_BLERPC_TIMEOUT_MS & 0xFF),
+            (uint8_t)(CONFIG_BLERPC_TIMEOUT_MS >> 8),
+        };
+        send_control(hdr->transaction_id, CONTROL_CMD_TIMEOUT, timeout_payload,
+                     sizeof(timeout_payload));
+    } else if (hdr->control_cmd == CONTROL_CMD_STREAM_END_C2P) {
+        if (stream_end_cb) {
+            stream_end_cb(hdr->transaction_id);
+        }
+    } else if (hdr->control_cmd == CONTROL_CMD_CAPABILITIES) {
+        uint16_t max_req = CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE;
+        uint16_t max_resp = CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE;
+        uint8_t caps_payload[6] = {
+            (uint8_t)(max_req & 0xFF), (uint8_t)(max_req >> 8),
+            (uint8_t)(max_resp & 0xFF), (uint8_t)(max_resp >> 8),
+            0, 0, /* no encryption */
+        };
+        send_control(hdr->transaction_id, CONTROL_CMD_CAPABILITIES, caps_payload,
+                     sizeof(caps_payload));
+    }
+}
+
+static void on_container(const uint8_t *buf, uint16_t len)
+{
+    struct container_header hdr;
+    if (container_parse_header(buf, len, &hdr) != 0) {
+        ESP_LOGE(TAG, "Container parse failed");
+        return;
+    }
+    if (hdr.type == CONTAINER_TYPE_CONTROL) {
+        on_control(&hdr);
+        return;
+    }
+
+    int rc = container_assembler_feed(&assembler, &hdr);
+    if (rc == 1) {
+        /* Assembly complete — process on the request task to free the host task */
+        if (request_busy) {
+            ESP_LOGW(TAG, "Request task busy, sending BUSY error");
+            send_error(hdr.transaction_id, BLERPC_ERROR_BUSY);
+        } else {
+            request_busy = true;
+            request_transaction_id = hdr.transaction_id;
+            request_len = assembler.total_length;
+            memcpy(request_buf, assembler.buf, assembler.total_length);
+            xTaskNotifyGive(request_task_handle);
+        }
+        container_assembler_init(&assembler);
+    } else if (rc < 0) {
+        container_assembler_init(&assembler);
+    }
+}
+
+static int rpc_access(uint16_t conn, uint16_t attr_handle, struct ble_gatt_access_ctxt *ctxt,
+                      void *arg)
+{
+    (void)conn;
+    (void)attr_handle;
+    (void)arg;
+
+    if (ctxt->op != BLE_GATT_ACCESS_OP_WRITE_CHR) {
+        return BLE_ATT_ERR_UNLIKELY;
+    }
+    static uint8_t write_buf[BLE_ATT_ATTR_MAX_LEN];
+    uint16_t len;
+    if (ble_hs_mbuf_to_flat(ctxt->om, write_buf, sizeof(write_buf), &len) != 0) {
+        return BLE_ATT_ERR_INVALID_ATTR_VALUE_LEN;
+    }
+    on_container(write_buf, len);
+    return 0;
+}
+
+static const struct ble_gatt_svc_def rpc_svcs[] = {
+    {
+        .type = BLE_GATT_SVC_TYPE_PRIMARY,
+        .uuid = &rpc_svc_uuid.u,
+        .characteristics =
+            (struct ble_gatt_chr_def[]){
+                {
+                    .uuid = &rpc_char_uuid.u,
+                    .access_cb = rpc_access,
+                    .flags = BLE_GATT_CHR_F_WRITE_NO_RSP | BLE_GATT_CHR_F_NOTIFY,
+                    .val_handle = &rpc_val_handle,
+                },
+                {0},
+            },
+    },
+    {0},
+};
+
+/* ── Public API ──────────────────────────────────────────────────────── */
+
+int blerpc_gatt_init(void)
+{
+    container_assembler_init(&assembler);
+
+    int rc = ble_gatts_count_cfg(rpc_svcs);
+    if (rc != 0) {
+        return rc;
+    }
+    rc = ble_gatts_add_svcs(rpc_svcs);
+    if (rc != 0) {
+        return rc;
+    }
+    rc = ble_att_set_preferred_mtu(CONFIG_BLERPC_PREFERRED_MTU);
+    if (rc != 0) {
+        return rc;
+    }
+    if (xTaskCreate(request_task, "blerpc_req", CONFIG_BLERPC_WORK_STACK_SIZE, NULL, 5,
+                    &request_task_handle) != pdPASS) {
+        return -ENOMEM;
+    }
+    return 0;
+}
+
+void blerpc_gatt_on_gap_event(const struct ble_gap_event *event)
+{
+    switch (event->type) {
+    case BLE_GAP_EVENT_CONNECT:
+        if (event->connect.status == 0) {
+            conn_handle = event->connect.conn_handle;
+            transaction_counter = 0;
+            container_assembler_init(&assembler);
+        }
+        break;
+    case BLE_GAP_EVENT_DISCONNECT:
+        conn_handle = BLE_HS_CONN_HANDLE_NONE;
+        container_assembler_init(&assembler);
+        break;
+    case BLE_GAP_EVENT_MTU:
+        blerpc_gatt_on_mtu_updated(event->mtu.conn_handle, event->mtu.value);
+        break;
+    default:
+        break;
+    }
+}
+
+uint16_t blerpc_gatt_get_mtu(void)
+{
+    if (conn_handle == BLE_HS_CONN_HANDLE_NONE) {
+        return BLE_ATT_MTU_DFLT;
+    }
+    return ble_att_mtu(conn_handle);
+}
+
+int blerpc_gatt_exchange_mtu(void)
+{
+    if (conn_handle == BLE_HS_CONN_HANDLE_NONE) {
+        return -ENOTCONN;
+    }
+    return ble_gattc_exchange_mtu(conn_handle, NULL, NULL);
+}
+
+int blerpc_gatt_notify(const uint8_t *data, size_t len)
+{
+    if (conn_handle == BLE_HS_CONN_HANDLE_NONE) {
+        return -ENOTCONN;
+    }
+    struct os_mbuf *om = ble_hs_mbuf_from_flat(data, len);
+    if (!om) {
+        return -ENOMEM;
+    }
+    int rc = ble_gatts_notify_custom(conn_handle, rpc_val_handle, om);
+    if (rc == BLE_HS_ENOMEM) {
+        return -ENOMEM;
+    }
+    return rc == 0 ? 0 : -EIO;
+}
+
+int blerpc_gatt_send_stream_end_p2c(uint8_t transaction_id)
+{
+    uint8_t ctrl_buf[8];
+    struct container_header ctrl = {
+        .transaction_id = transaction_id,
+        .sequence_number = 0,
+        .type = CONTAINER_TYPE_CONTROL,
+        .control_cmd = CONTROL_CMD_STREAM_END_P2C,
+        .payload_len = 0,
+        .payload = NULL,
+    };
+    int n = container_serialize(&ctrl, ctrl_buf, sizeof(ctrl_buf));
+    if (n < 0) {
+        return -1;
+    }
+    return send_with_retry(ctrl_buf, (size_t)n);
+}
+
+void blerpc_gatt_set_stream_end_cb(blerpc_gatt_stream_end_cb_t cb)
+{
+    stream_end_cb = cb;
+}
+
+uint8_t blerpc_gatt_next_transaction_id(void)
+{
+    return transaction_counter++;
+}
+
+int blerpc_gatt_send_command_response(uint8_t transaction_id, const uint8_t *cmd_data,
+                                      size_t cmd_len)
+{
+    return container_split_and_send(transaction_id, cmd_data, cmd_len, blerpc_gatt_get_mtu(),
+                                    container_send_cb, NULL);
+}
@@ -0,0 +1,109 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_GATT_SERVICE_H
+#define BLERPC_GENERATED_GATT_SERVICE_H
+
+#include <stddef.h>
+#include <stdint.h>
+#include "host/ble_gap.h"
+#include "host/ble_uuid.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* blerpc Service UUID: 12340001-0000-1000-8000-00805f9b34fb */
+#define BLERPC_SERVICE_UUID BLE_UUID128_INIT(0xfb, 0x34, 0x9b, 0x5f, 0x80, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00, 0x00, 0x01, 0x00, 0x34, 0x12)
+
+/* blerpc Characteristic UUID: 12340002-0000-1000-8000-00805f9b34fb */
+#define BLERPC_CHAR_UUID BLE_UUID128_INIT(0xfb, 0x34, 0x9b, 0x5f, 0x80, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00, 0x00, 0x02, 0x00, 0x34, 0x12)
+
+/* Settings shared with the Zephyr Kconfig options; override with -D. */
+#ifndef CONFIG_BLERPC_TIMEOUT_MS
+#define CONFIG_BLERPC_TIMEOUT_MS 100
+#endif
+#ifndef CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE
+#define CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE 65535
+#endif
+#ifndef CONFIG_BLERPC_WORK_STACK_SIZE
+#define CONFIG_BLERPC_WORK_STACK_SIZE 4096
+#endif
+#ifndef CONFIG_BLERPC_PREFERRED_MTU
+#define CONFIG_BLERPC_PREFERRED_MTU 247
+#endif
+
+/**
+ * Register the RPC service and start the request task.
+ * Call after nimble_port_init() and before starting the host task.
+ * @return 0 on success, non-zero on error
+ */
+int blerpc_gatt_init(void);
+
+/**
+ * Feed every GAP event of the application's event handler, so the
+ * service tracks the connection and its MTU.
+ */
+void blerpc_gatt_on_gap_event(const struct ble_gap_event *event);
+
+/**
+ * Called when the ATT MTU of the connection changes. Weak; the default
+ * does nothing.
+ */
+void blerpc_gatt_on_mtu_updated(uint16_t conn_handle, uint16_t mtu);
+
+/**
+ * Context passed to the handlers of requests from conn_handle. Weak;
+ * the default returns NULL.
+ */
+void *blerpc_gatt_handler_ctx(uint16_t conn_handle);
+
+/**
+ * Get the current connection's MTU.
+ */
+uint16_t blerpc_gatt_get_mtu(void);
+
+/**
+ * Ask the Central for CONFIG_BLERPC_PREFERRED_MTU.
+ * @return 0 on success, non-zero on error
+ */
+int blerpc_gatt_exchange_mtu(void);
+
+/**
+ * Send a notification to the connected Central.
+ * @return 0 on success, negative on error
+ */
+int blerpc_gatt_notify(const uint8_t *data, size_t len);
+
+/**
+ * Send a STREAM_END_P2C control container.
+ * @return 0 on success, negative on error
+ */
+int blerpc_gatt_send_stream_end_p2c(uint8_t transaction_id);
+
+/**
+ * Callback type for stream end notification (C->P).
+ * Called when STREAM_END_C2P is received from Central.
+ */
+typedef void (*blerpc_gatt_stream_end_cb_t)(uint8_t transaction_id);
+
+/**
+ * Register a callback for STREAM_END_C2P reception.
+ */
+void blerpc_gatt_set_stream_end_cb(blerpc_gatt_stream_end_cb_t cb);
+
+/**
+ * Get the next transaction ID (incrementing counter).
+ */
+uint8_t blerpc_gatt_next_transaction_id(void);
+
+/**
+ * Split a serialized command payload into containers and send them.
+ * @return 0 on success, negative on error
+ */
+int blerpc_gatt_send_command_response(uint8_t transaction_id, const uint8_t *cmd_data,
+                                      size_t cmd_len);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_GATT_SERVICE_H */
@@ -0,0 +1,274 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT */
+#include "generated_handlers.h"
+#include "blerpc.pb.h"
+#include <pb_encode.h>
+#include <pb_decode.h>
+#include <string.h>
+
+/* Each command, header and name included, must fit the transport
+ * buffers at its largest. */
+#ifdef CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "echo requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(14 + BLERPC_FLASH_READ_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "flash_read requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(14 + BLERPC_DATA_WRITE_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "data_write requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_STREAM_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "counter_stream requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_UPLOAD_MAX_REQ_SIZE <= CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE,
+               "counter_upload requests can exceed CONFIG_BLERPC_PROTOCOL_ASSEMBLER_BUF_SIZE");
+#endif
+#ifdef CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE
+_Static_assert(8 + BLERPC_ECHO_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "echo responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(14 + BLERPC_DATA_WRITE_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "data_write responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_STREAM_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "counter_stream responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+_Static_assert(18 + BLERPC_COUNTER_UPLOAD_MAX_RESP_SIZE <= CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE,
+               "counter_upload responses can exceed CONFIG_BLERPC_MAX_RESPONSE_PAYLOAD_SIZE");
+#endif
+
+/* Discard callback for FT_CALLBACK fields during decode */
+static bool discard_bytes_cb(pb_istream_t *stream, const pb_field_t *field,
+                             void **arg)
+{
+    (void)field;
+    (void)arg;
+    uint8_t buf[64];
+    size_t left = stream->bytes_left;
+    while (left > 0) {
+        size_t n = left < sizeof(buf) ? left : sizeof(buf);
+        if (!pb_read(stream, buf, n)) return false;
+        left -= n;
+    }
+    return true;
+}
+
+__attribute__((weak))
+int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len)
+{
+    (void)cmd_name;
+    (void)data;
+    (void)len;
+    return -1;
+}
+
+__attribute__((weak))
+int blerpc_stream_finish(void)
+{
+    return -1;
+}
+
+/* Set by a Cancel container, cleared when the stream ends. */
+static volatile bool stream_cancelled;
+
+void blerpc_stream_cancel(void)
+{
+    stream_cancelled = true;
+}
+
+bool blerpc_stream_should_stop(void)
+{
+    return stream_cancelled;
+}
+
+int blerpc_stream_end(void)
+{
+    stream_cancelled = false;
+    return blerpc_stream_finish() == 0 ? -2 : -1;
+}
+
+int blerpc_counter_stream_emit(const blerpc_CounterStreamResponse *resp)
+{
+    uint8_t buf[blerpc_CounterStreamResponse_size];
+    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+    if (!pb_encode(&out, blerpc_CounterStreamResponse_fields, resp)) return -1;
+    return blerpc_stream_write("counter_stream", buf, out.bytes_written);
+}
+
+/* Finalizes the client stream in progress; set by its requests. */
+static int (*stream_c2p_end)(void);
+
+__attribute__((weak))
+int blerpc_counter_upload_accumulate(const blerpc_CounterUploadRequest *req)
+{
+    (void)req;
+    return 0;
+}
+
+__attribute__((weak))
+int blerpc_counter_upload_finalize(blerpc_CounterUploadResponse *resp)
+{
+    (void)resp;
+    return 0;
+}
+
+static int end_counter_upload(void)
+{
+    blerpc_CounterUploadResponse resp = blerpc_CounterUploadResponse_init_zero;
+    if (blerpc_counter_upload_finalize(&resp) != 0) return -1;
+    uint8_t buf[blerpc_CounterUploadResponse_size];
+    pb_ostream_t out = pb_ostream_from_buffer(buf, sizeof(buf));
+    if (!pb_encode(&out, blerpc_CounterUploadResponse_fields, &resp)) return -1;
+    return blerpc_stream_write("counter_upload", buf, out.bytes_written);
+}
+
+__attribute__((weak))
+int handle_counter_upload(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream, void *ctx)
+{
+    (void)ostream; /* Not used — the response goes out from blerpc_stream_end_c2p */
+    blerpc_CounterUploadRequest req = blerpc_CounterUploadRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_CounterUploadRequest_fields, &req)) return -1;
+    if (blerpc_counter_upload_accumulate(&req) != 0) return -1;
+    stream_c2p_end = end_counter_upload;
+
+    /* Return -2: the requests of a stream get no response */
+    return -2;
+}
+
+int blerpc_stream_end_c2p(void)
+{
+    int (*end)(void) = stream_c2p_end;
+    stream_c2p_end = NULL;
+    return end != NULL ? end() : -1;
+}
+
+__attribute__((weak))
+int handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp, void *ctx)
+{
+    (void)req;
+    (void)resp;
+    (void)ctx;
+    return 0;
+}
+
+int dispatch_echo(const uint8_t *req_data, size_t req_len,
+                  pb_ostream_t *ostream, void *ctx)
+{
+    blerpc_EchoRequest req = blerpc_EchoRequest_init_zero;
+    blerpc_EchoResponse resp = blerpc_EchoResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_EchoRequest_fields, &req)) return -1;
+
+    int rc = handle_echo(&req, &resp, ctx);
+    if (rc != 0) return rc;
+    if (!pb_encode(ostream, blerpc_EchoResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_flash_read(const blerpc_FlashReadRequest *req, blerpc_FlashReadResponse *resp, void *ctx)
+{
+    (void)req;
+    (void)resp;
+    (void)ctx;
+    return 0;
+}
+
+int dispatch_flash_read(const uint8_t *req_data, size_t req_len,
+                        pb_ostream_t *ostream, void *ctx)
+{
+    blerpc_FlashReadRequest req = blerpc_FlashReadRequest_init_zero;
+    blerpc_FlashReadResponse resp = blerpc_FlashReadResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_FlashReadRequest_fields, &req)) return -1;
+
+    int rc = handle_flash_read(&req, &resp, ctx);
+    if (rc != 0) return rc;
+    if (!pb_encode(ostream, blerpc_FlashReadResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_data_write(const blerpc_DataWriteRequest *req, blerpc_DataWriteResponse *resp, void *ctx)
+{
+    (void)req;
+    (void)resp;
+    (void)ctx;
+    return 0;
+}
+
+int dispatch_data_write(const uint8_t *req_data, size_t req_len,
+                        pb_ostream_t *ostream, void *ctx)
+{
+    blerpc_DataWriteRequest req = blerpc_DataWriteRequest_init_zero;
+    blerpc_DataWriteResponse resp = blerpc_DataWriteResponse_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_DataWriteRequest_fields, &req)) return -1;
+
+    int rc = handle_data_write(&req, &resp, ctx);
+    if (rc != 0) return rc;
+    if (!pb_encode(ostream, blerpc_DataWriteResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+__attribute__((weak))
+int handle_counter_stream(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream, void *ctx)
+{
+    (void)ostream; /* Not used — responses go out through blerpc_counter_stream_emit */
+    blerpc_CounterStreamRequest req = blerpc_CounterStreamRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_CounterStreamRequest_fields, &req)) return -1;
+
+    /* Send each response with blerpc_counter_stream_emit() */
+    return blerpc_stream_end();
+}
+
+/* Positions in the handler table. */
+enum {
+#if BLERPC_CMDS_BLERPC
+    TABLE_COUNTER_STREAM,
+    TABLE_COUNTER_UPLOAD,
+    TABLE_DATA_WRITE,
+    TABLE_ECHO,
+    TABLE_FLASH_READ,
+#endif
+    TABLE_COUNT
+};
+
+static const struct handler_entry handler_table[] = {
+#if BLERPC_CMDS_BLERPC
+    {"counter_stream", 14, handle_counter_stream},
+    {"counter_upload", 14, handle_counter_upload},
+    {"data_write", 10, dispatch_data_write},
+    {"echo", 4, dispatch_echo},
+    {"flash_read", 10, dispatch_flash_read},
+#endif
+};
+
+static int compare_name(const char *name, uint8_t name_len,
+                        const struct handler_entry *entry)
+{
+    uint8_t n = name_len < entry->name_len ? name_len : entry->name_len;
+    int c = memcmp(name, entry->name, n);
+    return c != 0 ? c : (int)name_len - (int)entry->name_len;
+}
+
+static int handler_index(const char *name, uint8_t name_len)
+{
+    size_t lo = 0;
+    size_t hi = sizeof(handler_table) / sizeof(handler_table[0]);
+    while (lo < hi) {
+        size_t mid = lo + (hi - lo) / 2;
+        int c = compare_name(name, name_len, &handler_table[mid]);
+        if (c == 0) return (int)mid;
+        if (c < 0) {
+            hi = mid;
+        } else {
+            lo = mid + 1;
+        }
+    }
+    return -1;
+}
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len, void *ctx)
+{
+    int i = handler_index(name, name_len);
+    if (i < 0) return NULL;
+    return handler_table[i].handler;
+}
@@ -0,0 +1,155 @@
+/* Auto-generated by generate-handlers 0.1.0 (schema 4262a5a433f0a4b8) — DO NOT EDIT */
+#ifndef BLERPC_GENERATED_HANDLERS_H
+#define BLERPC_GENERATED_HANDLERS_H
+
+#include <stdint.h>
+#include <stddef.h>
+#include <pb_encode.h>
+#include "blerpc.pb.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+typedef int (*command_handler_fn)(const uint8_t *req_data, size_t req_len,
+                                  pb_ostream_t *ostream, void *ctx);
+
+struct handler_entry {
+    const char *name;
+    uint8_t name_len;
+    command_handler_fn handler;
+};
+
+command_handler_fn handlers_lookup(const char *name, uint8_t name_len, void *ctx);
+
+/* Returned for a request over its command's rate limit; the dispatcher
+ * answers it with a BUSY error. */
+#define HANDLER_BUSY (-3)
+
+/* Returned for a replay-protected request whose MAC is wrong or whose
+ * counter is not newer than the last one accepted; the dispatcher drops
+ * it. */
+#define HANDLER_REPLAYED (-4)
+
+/* Handlers and handlers_lookup take a trailing void *ctx. */
+#define BLERPC_HANDLER_CTX 1
+
+/* Status codes of error responses. Codes from 128 up are free for
+ * application use. */
+enum blerpc_status {
+    BLERPC_STATUS_OK = 0,
+    BLERPC_STATUS_INVALID_ARGUMENT = 1,
+    BLERPC_STATUS_NOT_FOUND = 2,
+    BLERPC_STATUS_ALREADY_EXISTS = 3,
+    BLERPC_STATUS_PERMISSION_DENIED = 4,
+    BLERPC_STATUS_RESOURCE_EXHAUSTED = 5,
+    BLERPC_STATUS_FAILED_PRECONDITION = 6,
+    BLERPC_STATUS_OUT_OF_RANGE = 7,
+    BLERPC_STATUS_UNIMPLEMENTED = 8,
+    BLERPC_STATUS_INTERNAL = 9,
+    BLERPC_STATUS_UNAVAILABLE = 10,
+    BLERPC_STATUS_UNAUTHENTICATED = 11,
+};
+
+/* Field number of the status in an error response; no message uses it. */
+#define BLERPC_STATUS_FIELD 536870911
+
+/*
+ * Write an error response carrying status in place of the response
+ * message, e.g.
+ *
+ *     return blerpc_return_error(ostream, BLERPC_STATUS_NOT_FOUND);
+ *
+ * Return 0, or -1 if the status does not fit.
+ */
+static inline int blerpc_return_error(pb_ostream_t *ostream, uint32_t status)
+{
+    if (!pb_encode_tag(ostream, PB_WT_VARINT, BLERPC_STATUS_FIELD)) return -1;
+    return pb_encode_varint(ostream, status) ? 0 : -1;
+}
+
+/* Command groups in the handler table; define one to 0 to leave its
+ * commands out. Zephyr builds set them from Kconfig.generated. */
+#ifndef BLERPC_CMDS_BLERPC
+#define BLERPC_CMDS_BLERPC 1
+#endif
+
+/* Largest encoded request and response of each command, from the
+ * max_size and max_count bounds in the .options file. Messages with an
+ * unbounded field (FT_CALLBACK, or no bound) have no constant. */
+#define BLERPC_ECHO_MAX_REQ_SIZE 259
+#define BLERPC_ECHO_MAX_RESP_SIZE 259
+#define BLERPC_FLASH_READ_MAX_REQ_SIZE 12
+#define BLERPC_DATA_WRITE_MAX_REQ_SIZE 259
+#define BLERPC_DATA_WRITE_MAX_RESP_SIZE 6
+#define BLERPC_COUNTER_STREAM_MAX_REQ_SIZE 6
+#define BLERPC_COUNTER_STREAM_MAX_RESP_SIZE 17
+#define BLERPC_COUNTER_UPLOAD_MAX_REQ_SIZE 17
+#define BLERPC_COUNTER_UPLOAD_MAX_RESP_SIZE 6
+
+int handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp, void *ctx);
+int dispatch_echo(const uint8_t *req_data, size_t req_len,
+                  pb_ostream_t *ostream, void *ctx);
+
+int handle_flash_read(const blerpc_FlashReadRequest *req, blerpc_FlashReadResponse *resp, void *ctx);
+int dispatch_flash_read(const uint8_t *req_data, size_t req_len,
+                        pb_ostream_t *ostream, void *ctx);
+
+int handle_data_write(const blerpc_DataWriteRequest *req, blerpc_DataWriteResponse *resp, void *ctx);
+int dispatch_data_write(const uint8_t *req_data, size_t req_len,
+                        pb_ostream_t *ostream, void *ctx);
+
+int handle_counter_stream(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream, void *ctx);
+
+int handle_counter_upload(const uint8_t *req_data, size_t req_len,
+                              pb_ostream_t *ostream, void *ctx);
+
+/* Server-streaming handlers send each response with <pkg>_<cmd>_emit() and
+ * finish with return <pkg>_stream_end(), so the dispatcher sends no response
+ * of its own. The responses leave through two hooks returning 0 on success,
+ * whose weak defaults return -1: stream_write sends one encoded response of
+ * cmd_name, e.g. with command_serialize() and
+ * ble_service_send_command_response(), and stream_finish ends the stream,
+ * e.g. with ble_service_send_stream_end_p2c(). */
+int blerpc_stream_write(const char *cmd_name, const uint8_t *data, size_t len);
+int blerpc_stream_finish(void);
+
+/* Ends the stream; returns -2, or -1 when it could not be ended. */
+int blerpc_stream_end(void);
+
+/* Control command of the Cancel container a central sends to stop the
+ * server stream in progress; it carries no payload. */
+#define BLERPC_CONTROL_CMD_CANCEL 0x7
+
+/* Stops the server stream in progress at its handler's next
+ * blerpc_stream_should_stop() check. The transport calls it when a Cancel
+ * container arrives while a request runs; safe from any thread. */
+void blerpc_stream_cancel(void);
+
+/* Reports whether the central cancelled the server stream in progress.
+ * Streaming handlers check it between responses and, once set, stop
+ * emitting and return blerpc_stream_end(), which clears it. */
+bool blerpc_stream_should_stop(void);
+
+struct _blerpc_CounterStreamResponse;
+int blerpc_counter_stream_emit(const struct _blerpc_CounterStreamResponse *resp);
+
+/* Client-streaming handlers pass each request of the stream to
+ * <pkg>_<cmd>_accumulate(). When the central ends the stream, call
+ * <pkg>_stream_end_c2p(), e.g. from a work item submitted by the
+ * STREAM_END_C2P callback: it has <pkg>_<cmd>_finalize() fill the response
+ * and sends it through <pkg>_stream_write(). The weak defaults discard the
+ * requests and leave the response empty. All return 0 on success. */
+int blerpc_stream_end_c2p(void);
+
+struct _blerpc_CounterUploadRequest;
+struct _blerpc_CounterUploadResponse;
+int blerpc_counter_upload_accumulate(const struct _blerpc_CounterUploadRequest *req);
+int blerpc_counter_upload_finalize(struct _blerpc_CounterUploadResponse *resp);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BLERPC_GENERATED_HANDLERS_H */
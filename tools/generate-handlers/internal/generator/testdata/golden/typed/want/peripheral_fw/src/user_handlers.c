@@ -0,0 +1,64 @@
+/*
+ * User handler implementations.
+ *
+ * Created by generate-handlers. This file is yours to edit: the generator
+ * never rewrites it, -scaffold only appends stubs for new commands.
+ * These definitions override the weak defaults in generated_handlers.c.
+ */
+#include "generated_handlers.h"
+#include "blerpc.pb.h"
+#include <pb_encode.h>
+#include <pb_decode.h>
+
+int handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp, void *ctx)
+{
+    /* TODO: implement echo */
+    (void)req;
+    (void)resp;
+    (void)ctx;
+    return 0;
+}
+
+int handle_flash_read(const blerpc_FlashReadRequest *req, blerpc_FlashReadResponse *resp, void *ctx)
+{
+    /* TODO: implement flash_read */
+    (void)req;
+    (void)resp;
+    (void)ctx;
+    return 0;
+}
+
+int handle_data_write(const blerpc_DataWriteRequest *req, blerpc_DataWriteResponse *resp, void *ctx)
+{
+    /* TODO: implement data_write */
+    (void)req;
+    (void)resp;
+    (void)ctx;
+    return 0;
+}
+
+int handle_counter_stream(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream, void *ctx)
+{
+    blerpc_CounterStreamRequest req = blerpc_CounterStreamRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_CounterStreamRequest_fields, &req)) return -1;
+
+    /* TODO: implement counter_stream */
+    blerpc_CounterStreamResponse resp = blerpc_CounterStreamResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_CounterStreamResponse_fields, &resp)) return -1;
+    return 0;
+}
+
+int handle_counter_upload(const uint8_t *req_data, size_t req_len,
+                          pb_ostream_t *ostream, void *ctx)
+{
+    blerpc_CounterUploadRequest req = blerpc_CounterUploadRequest_init_zero;
+    pb_istream_t stream = pb_istream_from_buffer(req_data, req_len);
+    if (!pb_decode(&stream, blerpc_CounterUploadRequest_fields, &req)) return -1;
+
+    /* TODO: implement counter_upload */
+    blerpc_CounterUploadResponse resp = blerpc_CounterUploadResponse_init_zero;
+    if (!pb_encode(ostream, blerpc_CounterUploadResponse_fields, &resp)) return -1;
+    return 0;
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func TestBuiltinTimeSync(t *testing.T) {
+	gctx := gen.NewContext()
+	commands, _ := builtinSchema(t, "syntax = \"proto3\";\npackage blerpc;\n", "time_sync")
+	if len(commands) != 1 || commands[0].Snake != "time_sync" {
+		t.Fatalf("got %+v", commands)
+	}
+
+	if header := c.GenerateHeader(gctx, commands, nil, nil, "blerpc"); !strings.Contains(header, "int blerpc_time_set(int64_t unix_time_us);") {
+		t.Error("header missing the time_set hook")
+	}
+	src := c.GenerateSource(gctx, commands, nil, nil, "blerpc")
+	for _, want := range []string{
+		"resp.applied_time_us = req.unix_time_us + (int64_t)req.offset_us;",
+		"if (ostream->callback == NULL && blerpc_time_set(resp.applied_time_us) != 0) {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source missing %q", want)
+		}
+	}
+
+	clients := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"import time\n",
+			"async def sync_time(self, *, compensate=False):",
+			"return await self.time_sync(unix_time_us=now_us, offset_us=offset_us)",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"suspend fun syncTime(compensate: Boolean = false): blerpc.Blerpc.TimeSyncResponse {",
+			"return timeSync(unix_time_us = System.currentTimeMillis() * 1000, offset_us = offsetUs)",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"func syncTime(compensate: Bool = false) async throws -> Blerpc_TimeSyncResponse {",
+		}},
+		{"dart", dart.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"Future<TimeSyncResponse> syncTime({bool compensate = false}) async {",
+		}},
+		{"ts", typescript.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"return this.timeSync({ unixTimeUs: Date.now() * 1000, offsetUs });",
+		}},
+	}
+	for _, tt := range clients {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// With -c-handler-signature typed the nanopb handlers of unary schema
+// commands take the decoded request and the response to fill:
+//
+//	int handle_echo(const blerpc_EchoRequest *req, blerpc_EchoResponse *resp);
+//
+// A generated dispatch_<cmd> with the raw signature of the handler table
+// decodes the request, calls the handler and encodes the response, so the
+// firmware no longer repeats the pb_decode/pb_encode boilerplate. A non-zero
+// return of the handler is passed on without encoding a response. Like the
+// raw handlers, a typed handler runs once to size the response and once to
+// write it. Streaming and built-in commands keep the raw signature.
+
+// applyTypedHandlers marks the commands whose C handler takes typed structs
+// when signature is "typed". Their requests must decode into the struct, so
+// FT_CALLBACK request fields are rejected.
+func applyTypedHandlers(commands []gen.Command, signature string, streaming map[string]string, callbacks map[string]bool) error {
+	if signature != "typed" {
+		return nil
+	}
+	for i, cmd := range commands {
+		if cmd.Builtin != "" || streaming[cmd.Snake] != "" {
+			continue
+		}
+		for _, f := range cmd.RequestFields {
+			if callbacks[cmd.RequestMsg+"."+f.Name] {
+				return fmt.Errorf("%s: %s.%s is an FT_CALLBACK field a typed handler cannot receive; bound it with max_size or max_count in the .options file", cmd.Snake, cmd.RequestMsg, f.Name)
+			}
+		}
+		commands[i].TypedHandler = true
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestApplyTypedHandlers(t *testing.T) {
+	stats := gentest.EchoCommand()
+	stats.Snake = "get_rpc_stats"
+	stats.Builtin = "rpc_stats"
+	cmds := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), stats}
+	streaming := map[string]string{"counter_stream": "p2c"}
+	if err := applyTypedHandlers(cmds, "raw", streaming, nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.HasTypedHandlers(cmds) {
+		t.Fatal("raw should leave every handler raw")
+	}
+	if err := applyTypedHandlers(cmds, "typed", streaming, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false, false} {
+		if cmds[i].TypedHandler != want {
+			t.Errorf("%s: TypedHandler = %v, want %v", cmds[i].Snake, cmds[i].TypedHandler, want)
+		}
+	}
+
+	err := applyTypedHandlers([]gen.Command{gentest.EchoCommand()}, "typed", nil, map[string]bool{"EchoRequest.message": true})
+	if err == nil || !strings.Contains(err.Error(), "EchoRequest.message is an FT_CALLBACK field") {
+		t.Errorf("expected FT_CALLBACK error, got %v", err)
+	}
+}
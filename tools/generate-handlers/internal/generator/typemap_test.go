@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	cgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	dartgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	kotlingen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	pythongen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	swiftgen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func TestResolveDefault_Proto2(t *testing.T) {
+	f := gen.Field{Type: "uint32", Name: "retries", Default: "3"}
+	if got := pythongen.ResolveDefault(f); got != "3" {
+		t.Errorf("resolvePythonDefault = %q, want 3", got)
+	}
+	if got := kotlingen.ResolveDefault(f, "blerpc"); got != "3" {
+		t.Errorf("resolveKotlinDefault = %q, want 3", got)
+	}
+	if got := swiftgen.ResolveDefault(f, "Blerpc"); got != "3" {
+		t.Errorf("resolveSwiftDefault = %q, want 3", got)
+	}
+	if got := dartgen.ResolveDefault(f); got != "3" {
+		t.Errorf("resolveDartDefault = %q, want 3", got)
+	}
+	if got := typescript.ResolveDefault(f); got != "3" {
+		t.Errorf("resolveTsDefault = %q, want 3", got)
+	}
+}
+
+func TestResolveType_Scalars(t *testing.T) {
+	tests := []struct {
+		proto                              string
+		kotlin, swift, dart, ts, c, python string
+		kotlinDefault                      string
+	}{
+		{"sint32", "Int", "Int32", "int", "number", "int32_t", "int", "0"},
+		{"sint64", "Long", "Int64", "int", "number", "int64_t", "int", "0L"},
+		{"fixed32", "Int", "UInt32", "int", "number", "uint32_t", "int", "0"},
+		{"fixed64", "Long", "UInt64", "int", "number", "uint64_t", "int", "0L"},
+		{"sfixed32", "Int", "Int32", "int", "number", "int32_t", "int", "0"},
+		{"sfixed64", "Long", "Int64", "int", "number", "int64_t", "int", "0L"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proto, func(t *testing.T) {
+			f := gen.Field{Type: tt.proto, Name: "v"}
+			check := func(lang, got, want string) {
+				if got != want {
+					t.Errorf("%s %s = %q, want %q", lang, tt.proto, got, want)
+				}
+			}
+			check("kotlin type", kotlingen.ResolveType(f, "blerpc"), tt.kotlin)
+			check("swift type", swiftgen.ResolveType(f, "Blerpc"), tt.swift)
+			check("dart type", dartgen.ResolveType(f), tt.dart)
+			check("ts type", typescript.ResolveType(f, "blerpc"), tt.ts)
+			check("c type", cgen.ResolveType(f, "blerpc"), tt.c)
+			check("python type", pythongen.ResolveType(f, "blerpc"), tt.python)
+			check("kotlin default", kotlingen.ResolveDefault(f, "blerpc"), tt.kotlinDefault)
+			check("swift default", swiftgen.ResolveDefault(f, "Blerpc"), "0")
+			check("dart default", dartgen.ResolveDefault(f), "0")
+			check("ts default", typescript.ResolveDefault(f), "0")
+			check("python default", pythongen.ResolveDefault(f), "0")
+		})
+	}
+}
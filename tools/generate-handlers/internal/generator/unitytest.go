@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+)
+
+// scaffoldCUnityTests writes test_<cmd>.c in dir for every command without
+// one. Built-in commands have generated handlers and get no test.
+func scaffoldCUnityTests(gctx *gen.Context, commands []gen.Command, streaming map[string]string, pkg, dir string, w io.Writer) error {
+	var added []string
+	for _, cmd := range commands {
+		if cmd.Builtin != "" {
+			continue
+		}
+		path := filepath.Join(dir, "test_"+cmd.Snake+".c")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if err := writeFile(path, c.GenerateUnityTest(gctx, cmd, streaming[cmd.Snake], pkg)); err != nil {
+			return err
+		}
+		added = append(added, cmd.Snake)
+	}
+	if len(added) == 0 {
+		fmt.Fprintf(w, "  %s: all commands have tests, left unchanged\n", dir)
+		return nil
+	}
+	fmt.Fprintf(w, "  %s: added tests for %s\n", dir, strings.Join(added, ", "))
+	return nil
+}
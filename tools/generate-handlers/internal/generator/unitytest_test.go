@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+)
+
+func TestScaffoldCUnityTests_KeepsExisting(t *testing.T) {
+	gctx := gen.NewContext()
+	dir := t.TempDir()
+	mine := filepath.Join(dir, "test_echo.c")
+	if err := os.WriteFile(mine, []byte("/* mine */\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := scaffoldCUnityTests(gctx, []gen.Command{gentest.EchoCommand(), gentest.EnumCommand()}, nil, "blerpc", dir, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(mine); string(data) != "/* mine */\n" {
+		t.Errorf("existing test rewritten:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test_get_status.c")); err != nil {
+		t.Errorf("test_get_status.c not written: %v", err)
+	}
+}
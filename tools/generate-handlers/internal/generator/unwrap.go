@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// With unwrap_responses: true in blerpc.yaml, the Python, Kotlin and Swift
+// clients get a second method for each unary command whose response is one
+// scalar field, e.g. GetBatteryResponse { uint32 percent = 1; }. Named after
+// the command and the field, get_battery_percent, it calls the command and
+// returns the field, while get_battery still returns the message. Enums,
+// whose clients pass them as integers, fields in a oneof, fields with a type
+// mapping and status fields are not unwrapped, nor built-in commands, whose
+// helpers already return what callers need.
+
+// unwrappable reports whether the response of cmd is one scalar field.
+func unwrappable(cmd gen.Command, streaming map[string]string) bool {
+	if _, ok := streaming[cmd.Snake]; ok || cmd.Builtin != "" || len(cmd.ResponseFields) != 1 {
+		return false
+	}
+	f := cmd.ResponseFields[0]
+	return !f.IsRepeated && !f.IsMessage && !f.IsMap && !f.IsEnum && f.Oneof == "" &&
+		len(f.TypeOverrides) == 0 && f.Name != cmd.StatusField
+}
+
+// applyUnwrap marks the commands to unwrap when blerpc.yaml turns
+// unwrap_responses on, and checks that their methods do not take the name of
+// a command.
+func applyUnwrap(commands []gen.Command, cfg *Config, streaming map[string]string) error {
+	if !cfg.UnwrapResponses {
+		return nil
+	}
+	names := make(map[string]bool)
+	for _, cmd := range commands {
+		names[cmd.Snake] = true
+	}
+	for i, cmd := range commands {
+		if !unwrappable(cmd, streaming) {
+			continue
+		}
+		if name := gen.UnwrapName(cmd); names[name] {
+			return fmt.Errorf("unwrap_responses: %s would name the unwrapped method of %s after a command", name, cmd.Snake)
+		}
+		commands[i].Unwrap = true
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func TestApplyUnwrap(t *testing.T) {
+	streaming := map[string]string{"counter_stream": "p2c"}
+	two := gentest.EchoCommand()
+	two.Snake, two.ResponseFields = "two", append(two.ResponseFields, gen.Field{Name: "count", Type: "uint32"})
+	commands := []gen.Command{gentest.EchoCommand(), gentest.StreamP2CCommand(), two}
+	if err := applyUnwrap(commands, &Config{UnwrapResponses: true}, streaming); err != nil {
+		t.Fatal(err)
+	}
+	if !commands[0].Unwrap || commands[1].Unwrap || commands[2].Unwrap {
+		t.Errorf("Unwrap = %v, %v, %v; want only echo", commands[0].Unwrap, commands[1].Unwrap, commands[2].Unwrap)
+	}
+
+	clash := gentest.EchoCommand()
+	clash.Snake = "echo_message"
+	err := applyUnwrap([]gen.Command{gentest.EchoCommand(), clash}, &Config{UnwrapResponses: true}, nil)
+	if err == nil || !strings.Contains(err.Error(), "echo_message would name") {
+		t.Errorf("err = %v, want a name clash", err)
+	}
+}
+
+func TestUnwrappedMethods(t *testing.T) {
+	gctx := gen.NewContext()
+	cmd := gentest.EchoCommand()
+	cmd.Unwrap = true
+	commands := []gen.Command{cmd}
+	for _, tc := range []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"python", python.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    async def echo_message(self, *, message: str = \"\") -> str:\n",
+			"        resp = await self.echo(message=message)\n        return resp.message\n",
+		}},
+		{"kotlin", kotlin.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    suspend fun echoMessage(message: String = \"\"): String =\n        echo(message = message).message\n",
+		}},
+		{"swift", swift.GenerateClient(gctx, commands, nil, "blerpc"), []string{
+			"    func echoMessage(message: String = \"\") async throws -> String {\n        try await echo(message: message).message\n",
+		}},
+		{"python sync", python.GenerateSyncClient(gctx, commands, nil, "blerpc"), []string{
+			"    def echo_message(self, *, message: str = \"\") -> str:\n",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.got, want) {
+				t.Errorf("%s: missing %q", tc.name, want)
+			}
+		}
+	}
+}
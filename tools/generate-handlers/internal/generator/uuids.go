@@ -0,0 +1,27 @@
+package generator
+
+import (
+	"fmt"
+)
+
+// GATTConfig overrides the UUIDs of the RPC service and characteristic.
+type GATTConfig struct {
+	ServiceUUID        string              `yaml:"service_uuid"`
+	CharacteristicUUID string              `yaml:"characteristic_uuid"`
+	Services           []GATTServiceConfig `yaml:"services"` // command groups on a service of their own (see gatt_groups.go)
+}
+
+func validateGATT(c GATTConfig) error {
+	for _, f := range []struct{ name, uuid string }{
+		{"service_uuid", c.ServiceUUID},
+		{"characteristic_uuid", c.CharacteristicUUID},
+	} {
+		if f.uuid != "" && !reUUID.MatchString(f.uuid) {
+			return fmt.Errorf("gatt: %s %q is not a lowercase UUID", f.name, f.uuid)
+		}
+	}
+	if c.ServiceUUID != "" && c.ServiceUUID == c.CharacteristicUUID {
+		return fmt.Errorf("gatt: service_uuid and characteristic_uuid are both %s", c.ServiceUUID)
+	}
+	return validateGATTServices(c)
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/dart"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/typescript"
+)
+
+func TestGenerateUUIDs(t *testing.T) {
+	gctx := gen.NewContext()
+	gctx.ServiceUUID = "6e400001-b5a3-f393-e0a9-e50e24dcca9e"
+	gctx.CharUUID = "6e400002-b5a3-f393-e0a9-e50e24dcca9e"
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"python", python.GenerateUUIDs(gctx), []string{
+			"SERVICE_UUID = \"6e400001-b5a3-f393-e0a9-e50e24dcca9e\"\n",
+			"CHAR_UUID = \"6e400002-b5a3-f393-e0a9-e50e24dcca9e\"\n",
+		}},
+		{"kotlin", kotlin.GenerateUUIDs(gctx, "blerpc"), []string{
+			"package com.blerpc.android.client\n",
+			"val SERVICE_UUID: UUID = UUID.fromString(\"6e400001-b5a3-f393-e0a9-e50e24dcca9e\")\n",
+			"val CHAR_UUID: UUID = UUID.fromString(\"6e400002-b5a3-f393-e0a9-e50e24dcca9e\")\n",
+		}},
+		{"swift", swift.GenerateUUIDs(gctx), []string{
+			"static let service = CBUUID(string: \"6e400001-b5a3-f393-e0a9-e50e24dcca9e\")\n",
+			"static let characteristic = CBUUID(string: \"6e400002-b5a3-f393-e0a9-e50e24dcca9e\")\n",
+		}},
+		{"dart", dart.GenerateUUIDs(gctx), []string{
+			"const String serviceUuid = '6e400001-b5a3-f393-e0a9-e50e24dcca9e';\n",
+			"const String charUuid = '6e400002-b5a3-f393-e0a9-e50e24dcca9e';\n",
+		}},
+		{"typescript", typescript.GenerateUUIDs(gctx), []string{
+			"export const SERVICE_UUID = '6e400001-b5a3-f393-e0a9-e50e24dcca9e';\n",
+			"export const CHAR_UUID = '6e400002-b5a3-f393-e0a9-e50e24dcca9e';\n",
+		}},
+		{"c", c.GenerateUUIDsHeader(gctx, "blerpc"), []string{
+			"#define BLERPC_SERVICE_UUID BT_UUID_128_ENCODE(0x6e400001, 0xb5a3, 0xf393, 0xe0a9, 0xe50e24dcca9e)\n",
+			"#define BLERPC_CHAR_UUID_STR \"6e400002-b5a3-f393-e0a9-e50e24dcca9e\"\n",
+		}},
+		{"scanner", python.GenerateScanner(gctx, false), []string{
+			"SERVICE_UUID = \"6e400001-b5a3-f393-e0a9-e50e24dcca9e\"\n",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.out, want) {
+				t.Errorf("%s: missing %q", tt.name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package generator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	pythongen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+)
+
+// verifyCheck is one compile smoke test of a generated file.
+type verifyCheck struct {
+	target string // c, python, kotlin or swift
+	file   string // path relative to the project root
+	tool   string
+	args   []string // arguments before the file
+}
+
+// verifyOptions selects the toolchains used by the verify subcommand.
+type verifyOptions struct {
+	root            string
+	cc              string
+	cflags          []string
+	python          string
+	kotlinc         string
+	kotlinClasspath string
+	swiftc          string
+	only            map[string]bool
+	strict          bool
+}
+
+// verifyChecks lists the checks for the generated files at their default
+// locations under the project root.
+func verifyChecks(o verifyOptions) []verifyCheck {
+	cArgs := func(file string) []string {
+		args := []string{"-fsyntax-only", "-std=c11", "-I" + filepath.Join(o.root, filepath.Dir(file))}
+		return append(args, o.cflags...)
+	}
+	checks := []verifyCheck{
+		{"c", "peripheral_fw/src/generated_handlers.c", o.cc, cArgs("peripheral_fw/src/generated_handlers.c")},
+		{"c", "central_fw/src/generated_client.c", o.cc, cArgs("central_fw/src/generated_client.c")},
+		{"python", "peripheral_py/generated_handlers.py", o.python, []string{"-c", pythongen.CompileFile}},
+		{"python", "central_py/blerpc/generated/generated_client.py", o.python, []string{"-c", pythongen.CompileFile}},
+		{"swift", "central_ios/BlerpcCentral/Client/GeneratedClient.swift", o.swiftc, []string{"-parse"}},
+	}
+	// kotlinc has no parse-only mode, so the client is compiled against the
+	// protobuf-java classes and the generated message classes.
+	if o.kotlinClasspath != "" {
+		checks = append(checks, verifyCheck{
+			"kotlin", "central_android/app/src/main/java/com/blerpc/android/client/GeneratedClient.kt", o.kotlinc,
+			[]string{"-cp", o.kotlinClasspath, "-d", os.TempDir()},
+		})
+	}
+	var selected []verifyCheck
+	for _, c := range checks {
+		if len(o.only) == 0 || o.only[c.target] {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+// runVerify implements "generate-handlers verify": it compiles the
+// generated code with the configured toolchains and stops at the first
+// failure. Checks whose toolchain or file is missing are skipped unless
+// -strict is set.
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	root := fs.String("root", ".", "project root directory")
+	cc := fs.String("cc", flagOrDefault(os.Getenv("CC"), "cc"), "host C compiler")
+	cflags := fs.String("cflags", "", "extra C compiler flags, e.g. -I<nanopb dir>")
+	python := fs.String("python", "python3", "Python interpreter")
+	kotlinc := fs.String("kotlinc", "kotlinc", "Kotlin compiler")
+	kotlinClasspath := fs.String("kotlin-classpath", "", "classpath with protobuf-java and the generated message classes (Kotlin check is skipped if empty)")
+	swiftc := fs.String("swiftc", "swiftc", "Swift compiler")
+	only := fs.String("only", "", "comma-separated targets to check: c, python, kotlin, swift (default: all)")
+	strict := fs.Bool("strict", false, "fail instead of skipping when a toolchain or generated file is missing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	o := verifyOptions{
+		root:            *root,
+		cc:              *cc,
+		cflags:          strings.Fields(*cflags),
+		python:          *python,
+		kotlinc:         *kotlinc,
+		kotlinClasspath: *kotlinClasspath,
+		swiftc:          *swiftc,
+		strict:          *strict,
+	}
+	if *only != "" {
+		o.only = make(map[string]bool)
+		for _, t := range strings.Split(*only, ",") {
+			o.only[strings.TrimSpace(t)] = true
+		}
+	}
+	return verify(o)
+}
+
+func verify(o verifyOptions) error {
+	passed := 0
+	for _, c := range verifyChecks(o) {
+		path := filepath.Join(o.root, c.file)
+		if _, err := os.Stat(path); err != nil {
+			if o.strict {
+				return fmt.Errorf("%s: %w", c.file, err)
+			}
+			fmt.Printf("  skip  %-6s %s (not generated)\n", c.target, c.file)
+			continue
+		}
+		if _, err := exec.LookPath(c.tool); err != nil {
+			if o.strict {
+				return fmt.Errorf("%s: toolchain %q not found", c.target, c.tool)
+			}
+			fmt.Printf("  skip  %-6s %s (%s not found)\n", c.target, c.file, c.tool)
+			continue
+		}
+		cmd := exec.Command(c.tool, append(c.args, path)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s does not compile (%s): %v\n%s", c.file, cmd, err, out)
+		}
+		fmt.Printf("  ok    %-6s %s\n", c.target, c.file)
+		passed++
+	}
+	fmt.Printf("Verified %d generated files\n", passed)
+	return nil
+}
+
+// checkPythonOutputs syntax-checks the generated .py outputs. It is a no-op
+// when python is empty or not installed.
+func checkPythonOutputs(ctx context.Context, python string, outputs []gen.Output) error {
+	if python == "" {
+		return nil
+	}
+	if _, err := exec.LookPath(python); err != nil {
+		return nil
+	}
+	for _, out := range outputs {
+		if filepath.Ext(out.Path) != ".py" {
+			continue
+		}
+		if err := pythongen.CheckSyntax(ctx, python, out.Path, out.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
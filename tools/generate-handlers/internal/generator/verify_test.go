@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	pythongen "github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+)
+
+func TestVerifyChecks(t *testing.T) {
+	o := verifyOptions{root: "/proj", cc: "gcc", cflags: []string{"-I/nanopb"}, python: "python3", swiftc: "swiftc"}
+	checks := verifyChecks(o)
+
+	targets := make(map[string]int)
+	for _, c := range checks {
+		targets[c.target]++
+	}
+	if targets["c"] != 2 || targets["python"] != 2 || targets["swift"] != 1 || targets["kotlin"] != 0 {
+		t.Errorf("targets = %v", targets)
+	}
+	c := checks[0]
+	if c.tool != "gcc" || !strings.Contains(strings.Join(c.args, " "), "-fsyntax-only -std=c11 -I/proj/peripheral_fw/src -I/nanopb") {
+		t.Errorf("C check = %+v", c)
+	}
+
+	o.kotlinClasspath = "protobuf.jar"
+	o.only = map[string]bool{"kotlin": true}
+	checks = verifyChecks(o)
+	if len(checks) != 1 || checks[0].target != "kotlin" || checks[0].args[1] != "protobuf.jar" {
+		t.Errorf("kotlin only = %+v", checks)
+	}
+}
+
+func TestVerify_Python(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	root := t.TempDir()
+	path := filepath.Join(root, "peripheral_py", "generated_handlers.py")
+	if err := writeFile(path, "HANDLERS = {}\n"); err != nil {
+		t.Fatal(err)
+	}
+	o := verifyOptions{root: root, python: python, only: map[string]bool{"python": true}}
+	if err := verify(o); err != nil {
+		t.Fatalf("valid Python should verify: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("def broken(:\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = verify(o)
+	if err == nil || !strings.Contains(err.Error(), "peripheral_py/generated_handlers.py does not compile") {
+		t.Errorf("expected compile failure, got %v", err)
+	}
+}
+
+func TestVerify_MissingToolchain(t *testing.T) {
+	root := t.TempDir()
+	if err := writeFile(filepath.Join(root, "peripheral_py", "generated_handlers.py"), "x = 1\n"); err != nil {
+		t.Fatal(err)
+	}
+	o := verifyOptions{root: root, python: "no-such-python-binary", only: map[string]bool{"python": true}}
+	if err := verify(o); err != nil {
+		t.Errorf("missing toolchain should be skipped: %v", err)
+	}
+	o.strict = true
+	if err := verify(o); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected toolchain error with -strict, got %v", err)
+	}
+}
+
+func TestCheckPythonOutputs(t *testing.T) {
+	gctx := gen.NewContext()
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	good := []gen.Output{
+		{Path: "client.py", Content: pythongen.GenerateClient(gctx, []gen.Command{gentest.EchoCommand(), gentest.EnumCommand()}, nil, "blerpc")},
+		{Path: "handlers.py", Content: pythongen.GenerateHandlers(gctx, []gen.Command{gentest.EchoCommand()}, nil, "blerpc")},
+		{Path: "client.kt", Content: "not python"},
+	}
+	if err := checkPythonOutputs(context.Background(), python, good); err != nil {
+		t.Errorf("generated Python should compile: %v", err)
+	}
+
+	bad := []gen.Output{{Path: "bad.py", Content: "x = f\"{\"\n"}}
+	if err := checkPythonOutputs(context.Background(), python, bad); err == nil || !strings.Contains(err.Error(), "bad.py") {
+		t.Errorf("expected syntax error, got %v", err)
+	}
+	if err := checkPythonOutputs(context.Background(), "", bad); err != nil {
+		t.Errorf("empty interpreter should disable the check: %v", err)
+	}
+	if err := checkPythonOutputs(context.Background(), "no-such-python-binary", bad); err != nil {
+		t.Errorf("missing interpreter should skip the check: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+// -watch regenerates whenever an input changes: the proto, the .options
+// file, streaming.txt or blerpc.yaml. Each regeneration is a child process
+// running the same command line without -watch, so a broken schema is
+// reported and waited out instead of ending the watch. The child writes
+// only the files whose content changed, so builds watching the outputs
+// redo only what the change affects, and prints a line count per file
+// instead of a Generated line for every output. Inputs are polled and
+// compared by hash, so saving a file without changes does nothing.
+
+// watchInterval is how often -watch polls the inputs.
+const watchInterval = 500 * time.Millisecond
+
+// watchChildEnv is set in the environment of the runs -watch starts.
+const watchChildEnv = "BLERPC_WATCH_CHILD"
+
+// watchInputs returns the files -watch polls.
+func watchInputs(protoPath string) []string {
+	return []string{
+		protoPath,
+		flagOrDefault(cliOptions.OptionsFile, filepath.Join(cliOptions.Root, "proto", "blerpc.options")),
+		flagOrDefault(cliOptions.Streaming, filepath.Join(cliOptions.Root, "proto", "streaming.txt")),
+		flagOrDefault(cliOptions.Config, filepath.Join(cliOptions.Root, "blerpc.yaml")),
+	}
+}
+
+// watchArgs returns args without -watch.
+func watchArgs(args []string) []string {
+	var out []string
+	for _, a := range args {
+		name := strings.TrimLeft(a, "-")
+		if name != a && (name == "watch" || strings.HasPrefix(name, "watch=")) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// hashInputs returns the hash of each input; a missing input hashes to "".
+func hashInputs(paths []string) map[string]string {
+	hashes := make(map[string]string, len(paths))
+	for _, p := range paths {
+		if data, err := os.ReadFile(p); err == nil {
+			hashes[p] = fmt.Sprintf("%x", sha256.Sum256(data))
+		} else {
+			hashes[p] = ""
+		}
+	}
+	return hashes
+}
+
+// changedInputs returns the paths whose hash differs between prev and cur.
+func changedInputs(paths []string, prev, cur map[string]string) []string {
+	var changed []string
+	for _, p := range paths {
+		if prev[p] != cur[p] {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// runWatch calls regenerate once, then again whenever one of inputs
+// changes, until ctx is done.
+func runWatch(ctx context.Context, inputs []string, interval time.Duration, root string, regenerate func() error, w io.Writer) {
+	hashes := hashInputs(inputs)
+	run := func() {
+		start := time.Now()
+		if err := regenerate(); err != nil {
+			fmt.Fprintf(w, "Generation failed (%v); waiting for changes\n", err)
+			return
+		}
+		fmt.Fprintf(w, "Generated in %v; waiting for changes\n", time.Since(start).Round(time.Millisecond))
+	}
+	fmt.Fprintf(w, "Watching %d inputs (Ctrl-C to stop)\n", len(inputs))
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cur := hashInputs(inputs)
+		changed := changedInputs(inputs, hashes, cur)
+		if len(changed) == 0 {
+			continue
+		}
+		hashes = cur
+		for i, p := range changed {
+			changed[i] = relPath(root, p)
+		}
+		fmt.Fprintf(w, "\n%s changed\n", strings.Join(changed, ", "))
+		run()
+	}
+}
+
+// watchChild returns a regenerate func for runWatch running this program
+// with args.
+func watchChild(ctx context.Context, args []string) func() error {
+	return func() error {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, exe, args...)
+		cmd.Env = append(os.Environ(), watchChildEnv+"=1")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+}
+
+// writeChangedOutputs writes the outputs whose content differs from the
+// file on disk and reports each with the lines it adds and removes.
+func writeChangedOutputs(outputs []gen.Output, root string, w io.Writer) error {
+	written := 0
+	for _, out := range outputs {
+		data, err := os.ReadFile(out.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil && string(data) == out.Content {
+			continue
+		}
+		if err := writeFile(out.Path, out.Content); err != nil {
+			return err
+		}
+		written++
+		added, removed := lineChanges(string(data), out.Content)
+		fmt.Fprintf(w, "  %s +%d -%d\n", relPath(root, out.Path), added, removed)
+	}
+	fmt.Fprintf(w, "%d of %d files changed\n", written, len(outputs))
+	return nil
+}
+
+// lineChanges returns the number of lines turning old into new adds and
+// removes.
+func lineChanges(old, new string) (added, removed int) {
+	for _, op := range diffLines(splitLines(old), splitLines(new)) {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
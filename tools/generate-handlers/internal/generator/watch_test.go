@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+)
+
+func TestWatchArgs(t *testing.T) {
+	got := watchArgs([]string{"-root", ".", "-watch", "--watch=true", "-targets", "c", "watch"})
+	want := []string{"-root", ".", "-targets", "c", "watch"}
+	if !slices.Equal(got, want) {
+		t.Errorf("watchArgs = %q, want %q", got, want)
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	dir := t.TempDir()
+	proto := filepath.Join(dir, "blerpc.proto")
+	options := filepath.Join(dir, "blerpc.options")
+	os.WriteFile(proto, []byte("syntax = \"proto3\";\n"), 0o644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 3)
+	regenerate := func() error {
+		runs <- struct{}{}
+		return nil
+	}
+	var w bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		runWatch(ctx, []string{proto, options}, time.Millisecond, dir, regenerate, &w)
+		close(done)
+	}()
+	<-runs
+	// Saving without changes does not regenerate; creating the options
+	// file does.
+	os.WriteFile(proto, []byte("syntax = \"proto3\";\n"), 0o644)
+	os.WriteFile(options, []byte("*.name max_size:16\n"), 0o644)
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no regeneration after the options file changed")
+	}
+	cancel()
+	<-done
+	if len(runs) != 0 {
+		t.Error("regenerated more than once for one change")
+	}
+	if !strings.Contains(w.String(), "\nblerpc.options changed\n") {
+		t.Errorf("output:\n%s", w.String())
+	}
+}
+
+func TestWriteChangedOutputs(t *testing.T) {
+	root := t.TempDir()
+	same := filepath.Join(root, "same.c")
+	changed := filepath.Join(root, "changed.c")
+	os.WriteFile(same, []byte("int x;\n"), 0o644)
+	os.WriteFile(changed, []byte("int y;\nint z;\n"), 0o644)
+	before, _ := os.Stat(same)
+
+	var w bytes.Buffer
+	outputs := []gen.Output{
+		{Path: same, Content: "int x;\n"},
+		{Path: changed, Content: "int y;\nint w;\nint v;\n"},
+		{Path: filepath.Join(root, "src", "new.c"), Content: "int n;\n"},
+	}
+	if err := writeChangedOutputs(outputs, root, &w); err != nil {
+		t.Fatal(err)
+	}
+	want := "  changed.c +2 -1\n  src/new.c +1 -0\n2 of 3 files changed\n"
+	if w.String() != want {
+		t.Errorf("output = %q, want %q", w.String(), want)
+	}
+	if after, _ := os.Stat(same); !after.ModTime().Equal(before.ModTime()) {
+		t.Error("unchanged file was rewritten")
+	}
+	if data, _ := os.ReadFile(changed); string(data) != outputs[1].Content {
+		t.Errorf("changed.c = %q", data)
+	}
+}
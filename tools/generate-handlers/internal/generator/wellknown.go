@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+// wellKnownTypeMappings give the well-known types idiomatic client types.
+// They are applied like blerpc.yaml type mappings, so a config entry for the
+// same proto_type replaces the built-in mapping.
+var wellKnownTypeMappings = []TypeMapping{
+	{
+		ProtoType: protomodel.TimestampType,
+		Languages: map[string]gen.TypeOverride{
+			"python": {Type: "datetime.datetime", Encode: "_timestamp({})", Default: "None"},
+			"kotlin": {
+				Type:    "Instant",
+				Encode:  "com.google.protobuf.Timestamp.newBuilder().setSeconds({}.epochSecond).setNanos({}.nano).build()",
+				Decode:  "Instant.ofEpochSecond({}.seconds, {}.nanos.toLong())",
+				Default: "Instant.EPOCH",
+				Import:  "import java.time.Instant",
+			},
+			"swift": {
+				Type:    "Date",
+				Encode:  "Google_Protobuf_Timestamp(date: {})",
+				Decode:  "{}.date",
+				Default: "Date(timeIntervalSince1970: 0)",
+			},
+		},
+	},
+	{
+		ProtoType: protomodel.DurationType,
+		Languages: map[string]gen.TypeOverride{
+			"python": {Type: "datetime.timedelta", Encode: "_duration({})", Default: "None"},
+			"kotlin": {
+				Type:    "Duration",
+				Encode:  "com.google.protobuf.Duration.newBuilder().setSeconds({}.seconds).setNanos({}.nano).build()",
+				Decode:  "Duration.ofSeconds({}.seconds, {}.nanos.toLong())",
+				Default: "Duration.ZERO",
+				Import:  "import java.time.Duration",
+			},
+			"swift": {
+				Type:    "TimeInterval",
+				Encode:  "Google_Protobuf_Duration(timeInterval: {})",
+				Decode:  "{}.timeInterval",
+				Default: "0",
+			},
+		},
+	},
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/c"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/gentest"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/kotlin"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/python"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/gen/swift"
+)
+
+func telemetryCommand() gen.Command {
+	cmds := []gen.Command{{
+		Camel:       "Telemetry",
+		Snake:       "telemetry",
+		RequestMsg:  "TelemetryRequest",
+		ResponseMsg: "TelemetryResponse",
+		RequestFields: []gen.Field{
+			{Type: "google.protobuf.Timestamp", Name: "since", Number: 1, IsMessage: true},
+			{Type: "google.protobuf.Duration", Name: "window", Number: 2, IsMessage: true},
+		},
+		ResponseFields: []gen.Field{
+			{Type: "google.protobuf.Timestamp", Name: "sampled_at", Number: 1, IsMessage: true},
+		},
+	}}
+	applyTypeMappings(cmds, &Config{})
+	return cmds[0]
+}
+
+func TestApplyTypeMappings_WellKnownConfigWins(t *testing.T) {
+	cfg, err := parseConfig([]byte("type_mappings:\n  - proto_type: google.protobuf.Timestamp\n    kotlin: {type: Long, encode: \"ts({})\"}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := []gen.Command{telemetryCommand()}
+	applyTypeMappings(cmds, cfg)
+	o, _ := gen.FieldTypeOverride(cmds[0].RequestFields[0], "kotlin")
+	if o.Type != "Long" {
+		t.Errorf("config mapping should replace the built-in one, got %+v", o)
+	}
+}
+
+func TestGeneratePyClient_WellKnownTypes(t *testing.T) {
+	gctx := gen.NewContext()
+	out := python.GenerateClient(gctx, []gen.Command{telemetryCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"from google.protobuf import duration_pb2, json_format, message, timestamp_pb2\n",
+		"def _timestamp(value):\n",
+		"    msg.FromDatetime(value)\n",
+		"def _duration(value):\n",
+		"    msg.FromTimedelta(value)\n",
+		"import datetime\n",
+		"since: datetime.datetime | None = None,",
+		"window: datetime.timedelta | None = None,",
+		"blerpc_pb2.TelemetryRequest(since=_timestamp(since), window=_duration(window))",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Python client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateKotlinClient_WellKnownTypes(t *testing.T) {
+	gctx := gen.NewContext()
+	out := kotlin.GenerateClient(gctx, []gen.Command{telemetryCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"import java.time.Duration\nimport java.time.Instant\n",
+		"open suspend fun telemetry(since: Instant = Instant.EPOCH, window: Duration = Duration.ZERO)",
+		".setSince(com.google.protobuf.Timestamp.newBuilder().setSeconds(since.epochSecond).setNanos(since.nano).build())",
+		"val blerpc.Blerpc.TelemetryResponse.sampledAtTyped: Instant\n    get() = Instant.ofEpochSecond(sampledAt.seconds, sampledAt.nanos.toLong())",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Kotlin client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateSwiftClient_WellKnownTypes(t *testing.T) {
+	gctx := gen.NewContext()
+	out := swift.GenerateClient(gctx, []gen.Command{telemetryCommand()}, nil, "blerpc")
+
+	mustContain := []string{
+		"func telemetry(since: Date = Date(timeIntervalSince1970: 0), window: TimeInterval = 0)",
+		"req.since = Google_Protobuf_Timestamp(date: since)",
+		"req.window = Google_Protobuf_Duration(timeInterval: window)",
+		"var sampledAtTyped: Date { sampledAt.date }",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("Swift client missing %q\nGot:\n%s", s, out)
+		}
+	}
+}
+
+func TestGenerateCClientHeader_WellKnownTypes(t *testing.T) {
+	out := c.GenerateClientHeader([]gen.Command{telemetryCommand()}, nil, nil, "blerpc")
+
+	mustContain := []string{
+		"static inline google_protobuf_Timestamp blerpc_timestamp_from_epoch_ms(int64_t ms)",
+		"static inline int64_t blerpc_timestamp_to_epoch_ms(const google_protobuf_Timestamp *ts)",
+		"static inline google_protobuf_Duration blerpc_duration_from_ms(int64_t ms)",
+		"int blerpc_telemetry(google_protobuf_Timestamp since, google_protobuf_Duration window,",
+	}
+	for _, s := range mustContain {
+		if !strings.Contains(out, s) {
+			t.Errorf("C client header missing %q\nGot:\n%s", s, out)
+		}
+	}
+	if strings.Contains(c.GenerateClientHeader([]gen.Command{gentest.EchoCommand()}, nil, nil, "blerpc"), "epoch_ms") {
+		t.Error("well-known type helpers should only be emitted when used")
+	}
+}
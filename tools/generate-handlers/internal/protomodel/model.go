@@ -0,0 +1,183 @@
+package protomodel
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Position is where a declaration starts in its proto file. It is zero for
+// schemas converted from descriptors (the protoc plugin).
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+// String renders the position as file:line:column, or "" when unknown.
+func (p Position) String() string {
+	switch {
+	case p.Line == 0:
+		return p.Filename
+	case p.Filename == "":
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// EnumValue represents a single value in an enum.
+type EnumValue struct {
+	Name    string
+	Number  int
+	Comment string
+}
+
+// Enum represents a protobuf enum.
+type Enum struct {
+	Name    string
+	Values  []EnumValue
+	Comment string
+}
+
+// OneofGroup represents a protobuf oneof.
+type OneofGroup struct {
+	Name   string
+	Fields []Field
+}
+
+// Field represents a protobuf message field.
+type Field struct {
+	Type       string
+	Name       string
+	Number     int
+	IsEnum     bool
+	IsRepeated bool
+	IsMessage  bool
+	IsMap      bool
+	KeyType    string
+	ValueType  string
+	// ValueIsMessage marks map fields whose values are messages.
+	ValueIsMessage bool
+	// TypeFile names the imported proto file, without .proto, defining the
+	// message of a message-typed field or map value; empty for the main file.
+	TypeFile   string
+	Oneof      string // name of the enclosing oneof, empty if none
+	IsRequired bool   // proto2 required label
+	IsOptional bool   // explicit optional label; nanopb emits a has_ flag
+	Default    string // proto2 [default = ...] constant; enum defaults hold the value number
+	Deprecated bool   // [deprecated = true]
+	MaxSize    int    // nanopb max_size of a statically allocated bytes field; 0 when unbounded
+	MaxBytes   int    // UTF-8 bytes a string field with a max_size holds, its NUL excluded; 0 when unbounded
+	Sensitive  bool   // [(blerpc.sensitive) = true]; redacted from client logs and captures
+
+	TypeOverrides map[string]TypeOverride // per-language type mappings from blerpc.yaml
+
+	Comment string // proto comments on the field
+	Pos     Position
+}
+
+// Message represents a protobuf message.
+type Message struct {
+	Name    string
+	Fields  []Field
+	Oneofs  []OneofGroup
+	Options map[string]string // message options, e.g. "blerpc.advertising"
+	File    string            // imported proto file defining the message, without .proto; empty for the main file
+	Comment string            // proto comments on the message
+	Pos     Position
+}
+
+// Command represents a matched Request/Response pair.
+type Command struct {
+	Camel            string
+	Snake            string
+	WireName         string   // on-air name from (blerpc.wire_name); empty means Snake
+	RenamedFrom      string   // previous RPC name from (blerpc.renamed_from); clients keep a deprecated alias
+	StatusField      string   // response status enum field checked by clients (blerpc.yaml status)
+	StatusOK         int      // status value treated as success
+	Service          string   // enclosing proto service; empty when discovered by naming convention
+	CharUUID         string   // GATT characteristic in the characteristic-per-command mode (-gatt per-command)
+	GattService      string   // GATT service group from (blerpc.gatt_service) or blerpc.yaml; empty means the RPC service
+	Idempotent       bool     // safe to run twice; resuming clients retry it after a reconnect
+	QueueTTL         int      // seconds a call may wait in the offline queue; 0 means not queueable
+	RateLimit        int      // calls per second the peripheral accepts; 0 means unlimited
+	TimeoutMs        int      // milliseconds clients wait for each attempt of a call; 0 leaves it to the transport
+	Retries          int      // attempts clients make again after a timeout or transport error; idempotent commands only
+	CacheTTLMs       int      // milliseconds caching clients serve a response from memory; 0 means not cacheable
+	Priority         string   // order of the call in priority clients: "low", "normal" (or empty) or "high"
+	Role             string   // role required on the peripheral: "user" (or empty), "installer" or "factory"
+	ReplayProtected  bool     // requests lead with a counter the peripheral checks against replays
+	SessionProtected bool     // requests lead with the token of an authenticated session (session built-in)
+	Compression      string   // algorithm clients may compress calls with: "deflate", "heatshrink" or empty
+	Unwrap           bool     // clients also return the one scalar response field on its own (blerpc.yaml unwrap_responses)
+	Property         string   // snake_case property a Get command reads and its Set command writes (blerpc.yaml property_accessors)
+	RemovedFields    []Field  // response fields of the previous schema version, kept as deprecated defaults (-compat-shims)
+	ExcludeTargets   []string // client targets the command is left out of
+	Deprecated       bool     // deprecated = true on the RPC, or on the request message
+	ID               int      // numeric command ID from the lock file (blerpc.yaml command_ids); 0 when IDs are off
+	MaxRequestSize   int      // largest encoded request from the .options bounds; -1 when a field is unbounded
+	MaxResponseSize  int      // largest encoded response from the .options bounds; -1 when a field is unbounded
+	TypedHandler     bool     // C handler takes the decoded request and the response struct (-c-handler-signature typed)
+	Builtin          string   // built-in command set from blerpc.yaml builtins; empty for schema commands
+	Settings         *Message // (blerpc.settings) message read and written by the settings built-in
+	Comment          string   // proto comments on the RPC, or on the request message
+	RequestMsg       string
+	ResponseMsg      string
+	RequestFields    []Field
+	ResponseFields   []Field
+}
+
+// Wire returns the command name sent on the air. Generated code keeps using
+// Snake/Camel for identifiers so a command can be renamed in code without
+// breaking devices that already depend on the wire name.
+func (c Command) Wire() string {
+	if c.WireName != "" {
+		return c.WireName
+	}
+	return c.Snake
+}
+
+// Fields yields the request fields followed by the response fields without
+// copying them into a new slice.
+func (c Command) Fields() iter.Seq[Field] {
+	return func(yield func(Field) bool) {
+		for _, f := range c.RequestFields {
+			if !yield(f) {
+				return
+			}
+		}
+		for _, f := range c.ResponseFields {
+			if !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// ServiceRPC represents a single RPC method within a service.
+type ServiceRPC struct {
+	Name         string
+	RequestType  string
+	ResponseType string
+	ClientStream bool              // stream on request
+	ServerStream bool              // stream on response
+	Options      map[string]string // custom options keyed without parentheses, e.g. "blerpc.wire_name"
+	Comment      string            // proto comments on the RPC
+	Pos          Position
+}
+
+// Service represents a protobuf service definition.
+type Service struct {
+	Name string
+	RPCs []ServiceRPC
+}
+
+// TypeOverride is the mapping for one language. Encode and Decode are
+// expressions in which {} stands for the value being converted; an empty
+// converter passes the value through unchanged.
+type TypeOverride struct {
+	Type    string `yaml:"type"`    // language type used in the generated API
+	Encode  string `yaml:"encode"`  // custom value -> proto field value
+	Decode  string `yaml:"decode"`  // proto field value -> custom value
+	Default string `yaml:"default"` // parameter default; without one the parameter is required
+	Import  string `yaml:"import"`  // import line needed for Type, emitted verbatim
+}
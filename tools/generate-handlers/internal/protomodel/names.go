@@ -0,0 +1,113 @@
+package protomodel
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Well-known types the targets map to idiomatic client types; the parser
+// keeps fields of these types out of the message model.
+const (
+	TimestampType = "google.protobuf.Timestamp"
+	DurationType  = "google.protobuf.Duration"
+)
+
+var (
+	reSub1 = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
+	reSub2 = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// CamelToSnake converts a CamelCase name, e.g. FlashRead, to snake_case.
+func CamelToSnake(name string) string {
+	s := reSub1.ReplaceAllString(name, "${1}_${2}")
+	s = reSub2.ReplaceAllString(s, "${1}_${2}")
+	return strings.ToLower(s)
+}
+
+// UnquoteString strips the quotes of a proto string constant and
+// resolves its escape sequences.
+func UnquoteString(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		s = `"` + strings.ReplaceAll(s[1:len(s)-1], `"`, `\"`) + `"`
+	}
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return strings.Trim(s, `"'`)
+}
+
+// IsWellKnownType reports whether a proto type name is a supported
+// google.protobuf well-known type.
+func IsWellKnownType(protoType string) bool {
+	t := strings.TrimPrefix(protoType, ".")
+	return t == TimestampType || t == DurationType
+}
+
+// IsIdempotent reports whether an idempotency_level option value allows
+// running the RPC twice.
+func IsIdempotent(level string) bool {
+	return level == "IDEMPOTENT" || level == "NO_SIDE_EFFECTS"
+}
+
+// ParseQueueTTL parses a (blerpc.queue_ttl) option value; an absent or
+// malformed value leaves the command unqueueable.
+func ParseQueueTTL(v string) int {
+	ttl, err := strconv.ParseUint(v, 0, 32)
+	if err != nil {
+		return 0
+	}
+	return int(ttl)
+}
+
+// ParseTimeoutMs parses a (blerpc.timeout_ms) option value; an absent or
+// malformed value leaves the timeout to the transport.
+func ParseTimeoutMs(v string) int {
+	ms, err := strconv.ParseUint(v, 0, 32)
+	if err != nil {
+		return 0
+	}
+	return int(ms)
+}
+
+// ParseRetries parses a (blerpc.retries) option value; an absent or
+// malformed value means no retries.
+func ParseRetries(v string) int {
+	n, err := strconv.ParseUint(v, 0, 8)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// ParseCacheTTLMs parses a (blerpc.cache_ttl_ms) option value; an absent or
+// malformed value leaves the command uncached.
+func ParseCacheTTLMs(v string) int {
+	ms, err := strconv.ParseUint(v, 0, 32)
+	if err != nil {
+		return 0
+	}
+	return int(ms)
+}
+
+// ParseExcludeTargets parses a (blerpc.exclude_targets) option value, a
+// comma-separated list of target names.
+func ParseExcludeTargets(v string) []string {
+	var targets []string
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// ParseRateLimit parses a (blerpc.rate_limit) option value; an absent or
+// malformed value leaves the command unlimited.
+func ParseRateLimit(v string) int {
+	n, err := strconv.ParseUint(v, 0, 16)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
@@ -0,0 +1,74 @@
+package protomodel
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCamelToSnake(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Echo", "echo"},
+		{"FlashRead", "flash_read"},
+		{"DataWrite", "data_write"},
+		{"CounterStream", "counter_stream"},
+		{"CounterUpload", "counter_upload"},
+		{"HTMLParser", "html_parser"},
+		{"getHTTPResponse", "get_http_response"},
+		{"SimpleXML", "simple_xml"},
+		{"", ""},
+		{"a", "a"},
+		{"A", "a"},
+		{"already_snake", "already_snake"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := CamelToSnake(tt.input)
+			if got != tt.want {
+				t.Errorf("CamelToSnake(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueueTTL(t *testing.T) {
+	for v, want := range map[string]int{"": 0, "3600": 3600, "0x10": 16, "-1": 0} {
+		if got := ParseQueueTTL(v); got != want {
+			t.Errorf("ParseQueueTTL(%q) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestParseTimeoutMs(t *testing.T) {
+	for v, want := range map[string]int{"": 0, "500": 500, "0x10": 16, "-1": 0} {
+		if got := ParseTimeoutMs(v); got != want {
+			t.Errorf("ParseTimeoutMs(%q) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestParseRetries(t *testing.T) {
+	for v, want := range map[string]int{"": 0, "3": 3, "-1": 0, "300": 0} {
+		if got := ParseRetries(v); got != want {
+			t.Errorf("ParseRetries(%q) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestParseExcludeTargets(t *testing.T) {
+	for v, want := range map[string][]string{"": nil, "kotlin": {"kotlin"}, "kotlin, swift,": {"kotlin", "swift"}} {
+		if got := ParseExcludeTargets(v); !slices.Equal(got, want) {
+			t.Errorf("ParseExcludeTargets(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	for v, want := range map[string]int{"": 0, "2": 2, "0x10": 16, "-1": 0, "70000": 0} {
+		if got := ParseRateLimit(v); got != want {
+			t.Errorf("ParseRateLimit(%q) = %d, want %d", v, got, want)
+		}
+	}
+}
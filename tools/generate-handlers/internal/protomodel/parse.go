@@ -0,0 +1,888 @@
+package protomodel
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yoheimuta/go-protoparser/v4"
+	"github.com/yoheimuta/go-protoparser/v4/parser"
+	"github.com/yoheimuta/go-protoparser/v4/parser/meta"
+)
+
+// ProtoFile holds the parsed result of a proto file.
+type ProtoFile struct {
+	Syntax   string // "proto2" or "proto3"
+	Package  string
+	Messages []Message
+	Enums    []Enum
+	Services []Service
+	Imports  []string // import paths (for recursive resolution)
+}
+
+// collectMessageNames adds the name of msg and of every message nested in it,
+// qualified by the enclosing messages (Outer.Inner), to set.
+func collectMessageNames(msg *parser.Message, prefix string, set map[string]bool) {
+	name := prefix + msg.MessageName
+	set[name] = true
+	for _, body := range msg.MessageBody {
+		if nested, ok := body.(*parser.Message); ok {
+			collectMessageNames(nested, name+".", set)
+		}
+	}
+}
+
+// commentText returns the text of the comments leading a declaration and the
+// one trailing it on its line, without comment markers, a line per comment
+// line. Blank lines are dropped.
+func commentText(leading []*parser.Comment, inline *parser.Comment) string {
+	if inline != nil {
+		leading = append(leading[:len(leading):len(leading)], inline)
+	}
+	var lines []string
+	for _, c := range leading {
+		for _, line := range c.Lines() {
+			line = strings.TrimSpace(line)
+			if c.IsCStyle() {
+				line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collectEnums extracts enum definitions from parser enum body items.
+func collectEnums(e *parser.Enum) Enum {
+	en := Enum{Name: e.EnumName, Comment: commentText(e.Comments, e.InlineComment)}
+	for _, body := range e.EnumBody {
+		ef, ok := body.(*parser.EnumField)
+		if !ok {
+			continue
+		}
+		num := 0
+		_, _ = fmt.Sscanf(ef.Number, "%d", &num)
+		en.Values = append(en.Values, EnumValue{
+			Name:    ef.Ident,
+			Number:  num,
+			Comment: commentText(ef.Comments, ef.InlineComment),
+		})
+	}
+	return en
+}
+
+// fieldDefault returns the proto2 explicit default of a field. Enum defaults
+// are given by value name in the proto and resolved to the value number here,
+// since every target passes enums as integers.
+func fieldDefault(f *parser.Field, enums []Enum) string {
+	for _, opt := range f.FieldOptions {
+		if opt.OptionName != "default" {
+			continue
+		}
+		for _, en := range enums {
+			if en.Name != f.Type {
+				continue
+			}
+			for _, v := range en.Values {
+				if v.Name == opt.Constant {
+					return fmt.Sprintf("%d", v.Number)
+				}
+			}
+		}
+		return opt.Constant
+	}
+	return ""
+}
+
+// fieldDeprecated reports whether field options set deprecated = true.
+func fieldDeprecated(opts []*parser.FieldOption) bool {
+	for _, opt := range opts {
+		if opt.OptionName == "deprecated" && opt.Constant == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldSensitive reports whether field options set (blerpc.sensitive) = true.
+func fieldSensitive(opts []*parser.FieldOption) bool {
+	for _, opt := range opts {
+		if opt.OptionName == "(blerpc.sensitive)" && opt.Constant == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// OptionMap collects options by name, dropping the parentheses around custom
+// option names and unquoting string constants.
+func OptionMap(opts []*parser.Option) map[string]string {
+	if len(opts) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(opts))
+	for _, opt := range opts {
+		name := strings.TrimSuffix(strings.TrimPrefix(opt.OptionName, "("), ")")
+		val := opt.Constant
+		if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') {
+			val = UnquoteString(val)
+		}
+		m[name] = val
+	}
+	return m
+}
+
+// importedProto is a proto file reached through the imports of the main file.
+type importedProto struct {
+	name  string // import path without .proto, e.g. common/types
+	proto *parser.Proto
+}
+
+// protoPackage returns the package declared by a parsed proto file.
+func protoPackage(proto *parser.Proto) string {
+	for _, item := range proto.ProtoBody {
+		if pkg, ok := item.(*parser.Package); ok {
+			return pkg.Name
+		}
+	}
+	return ""
+}
+
+// protoImports returns the import paths of a parsed proto file.
+func protoImports(proto *parser.Proto) []string {
+	var imports []string
+	for _, item := range proto.ProtoBody {
+		if imp, ok := item.(*parser.Import); ok {
+			imports = append(imports, strings.Trim(imp.Location, "\""))
+		}
+	}
+	return imports
+}
+
+// definesTypes reports whether a parsed proto file defines messages or enums.
+func definesTypes(proto *parser.Proto) bool {
+	for _, item := range proto.ProtoBody {
+		switch item.(type) {
+		case *parser.Message, *parser.Enum:
+			return true
+		}
+	}
+	return false
+}
+
+func ParseReader(r io.Reader) (*ProtoFile, error) {
+	proto, err := protoparser.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse proto: %w", err)
+	}
+	return buildProtoFile(proto, nil), nil
+}
+
+// buildProtoFile converts the main proto file and the files it imports to
+// one ProtoFile. Field types resolve across all files, and the messages,
+// enums and services of imported files follow those of the main file.
+func buildProtoFile(proto *parser.Proto, imported []importedProto) *ProtoFile {
+	pkgName := protoPackage(proto)
+	files := append([]importedProto{{proto: proto}}, imported...)
+
+	// Collect all enums (top-level + nested inside messages)
+	enumSet := make(map[string]bool)
+	msgSet := make(map[string]bool)
+	msgFile := make(map[string]string)
+
+	var enums []Enum
+	for _, file := range files {
+		for _, item := range file.proto.ProtoBody {
+			if e, ok := item.(*parser.Enum); ok {
+				en := collectEnums(e)
+				enums = append(enums, en)
+				enumSet[en.Name] = true
+			}
+		}
+	}
+
+	// Collect message names and nested enums/messages
+	for _, file := range files {
+		fileMsgs := make(map[string]bool)
+		for _, item := range file.proto.ProtoBody {
+			msg, ok := item.(*parser.Message)
+			if !ok {
+				continue
+			}
+			collectMessageNames(msg, "", fileMsgs)
+			for _, body := range msg.MessageBody {
+				if e, ok := body.(*parser.Enum); ok {
+					en := collectEnums(e)
+					enums = append(enums, en)
+					enumSet[en.Name] = true
+				}
+			}
+		}
+		for name := range fileMsgs {
+			msgSet[name] = true
+			msgFile[name] = file.name
+		}
+	}
+	// messageType resolves a field type of the top-level message scope to the
+	// message it names, qualified as Outer.Inner when nested.
+	messageType := func(typ, scope string) (string, bool) {
+		typ = LocalTypeName(typ, pkgName)
+		if msgSet[scope+"."+typ] {
+			return scope + "." + typ, true
+		}
+		if msgSet[typ] {
+			return typ, true
+		}
+		return "", false
+	}
+
+	var messages []Message
+	for _, file := range files {
+		messages = append(messages, buildMessages(file, enumSet, enums, msgFile, messageType)...)
+	}
+
+	var services []Service
+	for _, file := range files {
+		for _, item := range file.proto.ProtoBody {
+			svc, ok := item.(*parser.Service)
+			if !ok {
+				continue
+			}
+			s := Service{Name: svc.ServiceName}
+			for _, body := range svc.ServiceBody {
+				rpc, ok := body.(*parser.RPC)
+				if !ok {
+					continue
+				}
+				sr := ServiceRPC{
+					Name:         rpc.RPCName,
+					RequestType:  LocalTypeName(rpc.RPCRequest.MessageType, pkgName),
+					ResponseType: LocalTypeName(rpc.RPCResponse.MessageType, pkgName),
+					ClientStream: rpc.RPCRequest.IsStream,
+					ServerStream: rpc.RPCResponse.IsStream,
+					Options:      OptionMap(rpc.Options),
+					Comment:      commentText(rpc.Comments, rpc.InlineComment),
+					Pos:          position(rpc.Meta),
+				}
+				s.RPCs = append(s.RPCs, sr)
+			}
+			services = append(services, s)
+		}
+	}
+
+	syntax := "proto3"
+	if proto.Syntax != nil && proto.Syntax.ProtobufVersion != "" {
+		syntax = proto.Syntax.ProtobufVersion
+	}
+
+	return &ProtoFile{Syntax: syntax, Package: pkgName, Messages: messages, Enums: enums, Services: services, Imports: protoImports(proto)}
+}
+
+// buildMessages converts the top-level messages of one file. msgFile maps
+// every message name to the imported file defining it.
+func buildMessages(file importedProto, enumSet map[string]bool, enums []Enum, msgFile map[string]string,
+	messageType func(typ, scope string) (string, bool)) []Message {
+	var messages []Message
+	for _, item := range file.proto.ProtoBody {
+		msg, ok := item.(*parser.Message)
+		if !ok {
+			continue
+		}
+		m := Message{Name: msg.MessageName, File: file.name, Comment: commentText(msg.Comments, msg.InlineComment), Pos: position(msg.Meta)}
+		var opts []*parser.Option
+		for _, body := range msg.MessageBody {
+			switch f := body.(type) {
+			case *parser.Option:
+				opts = append(opts, f)
+			case *parser.Field:
+				num := 0
+				_, _ = fmt.Sscanf(f.FieldNumber, "%d", &num)
+				typ, isMsg := messageType(f.Type, msg.MessageName)
+				if !isMsg {
+					typ = f.Type
+				}
+				m.Fields = append(m.Fields, Field{
+					Type:       typ,
+					Name:       f.FieldName,
+					Number:     num,
+					IsEnum:     enumSet[f.Type],
+					IsRepeated: f.IsRepeated,
+					IsMessage:  isMsg || IsWellKnownType(f.Type),
+					IsRequired: f.IsRequired,
+					IsOptional: f.IsOptional,
+					Default:    fieldDefault(f, enums),
+					Deprecated: fieldDeprecated(f.FieldOptions),
+					Sensitive:  fieldSensitive(f.FieldOptions),
+					TypeFile:   msgFile[typ],
+					Comment:    commentText(f.Comments, f.InlineComment),
+					Pos:        position(f.Meta),
+				})
+			case *parser.MapField:
+				num := 0
+				_, _ = fmt.Sscanf(f.FieldNumber, "%d", &num)
+				value, valueIsMsg := messageType(f.Type, msg.MessageName)
+				if !valueIsMsg {
+					value = f.Type
+				}
+				m.Fields = append(m.Fields, Field{
+					Name:           f.MapName,
+					Number:         num,
+					IsMap:          true,
+					KeyType:        f.KeyType,
+					ValueType:      value,
+					ValueIsMessage: valueIsMsg,
+					Deprecated:     fieldDeprecated(f.FieldOptions),
+					Sensitive:      fieldSensitive(f.FieldOptions),
+					TypeFile:       msgFile[value],
+					Comment:        commentText(f.Comments, f.InlineComment),
+					Pos:            position(f.Meta),
+				})
+			case *parser.Oneof:
+				og := OneofGroup{Name: f.OneofName}
+				for _, of := range f.OneofFields {
+					num := 0
+					_, _ = fmt.Sscanf(of.FieldNumber, "%d", &num)
+					typ, isMsg := messageType(of.Type, msg.MessageName)
+					if !isMsg {
+						typ = of.Type
+					}
+					field := Field{
+						Type:       typ,
+						Name:       of.FieldName,
+						Number:     num,
+						IsEnum:     enumSet[of.Type],
+						IsMessage:  isMsg || IsWellKnownType(of.Type),
+						Oneof:      f.OneofName,
+						Deprecated: fieldDeprecated(of.FieldOptions),
+						Sensitive:  fieldSensitive(of.FieldOptions),
+						TypeFile:   msgFile[typ],
+						Comment:    commentText(of.Comments, of.InlineComment),
+						Pos:        position(of.Meta),
+					}
+					og.Fields = append(og.Fields, field)
+					// Also add oneof fields to the message's flat field list
+					m.Fields = append(m.Fields, field)
+				}
+				m.Oneofs = append(m.Oneofs, og)
+			}
+		}
+		m.Options = OptionMap(opts)
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+// OS is the file system of the operating system. Unlike os.DirFS it takes
+// OS paths, relative to the working directory unless absolute, so the *FS
+// functions read the files of the other functions through it.
+var OS fs.FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) { return os.Open(name) }
+
+// ParseWithImports parses a proto file and recursively resolves imports.
+// protoPaths are additional directories to search for imported files. The
+// imported files are parsed before any field type is resolved, so fields may
+// use messages and enums of any of them. Imported files that define types
+// must declare the package of the main file, as the generated code addresses
+// every message through that package.
+func ParseWithImports(path string, protoPaths []string) (*ProtoFile, error) {
+	return ParseWithImportsFS(OS, path, protoPaths)
+}
+
+// ParseWithImportsFS is ParseWithImports reading the files from fsys, which
+// opens path and the import paths joined with protoPaths.
+func ParseWithImportsFS(fsys fs.FS, path string, protoPaths []string) (*ProtoFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("abs path: %w", err)
+	}
+	proto, err := parseProtoFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	var imported []importedProto
+	visited := map[string]bool{absPath: true}
+	roots := append([]string{filepath.Dir(path)}, protoPaths...)
+	if err := collectImports(fsys, proto, path, roots, visited, &imported); err != nil {
+		return nil, err
+	}
+	pkg := protoPackage(proto)
+	for _, imp := range imported {
+		if got := protoPackage(imp.proto); got != pkg && definesTypes(imp.proto) {
+			return nil, fmt.Errorf("import %s.proto: package %q differs from %q of %s",
+				imp.name, got, pkg, filepath.Base(path))
+		}
+	}
+	return buildProtoFile(proto, imported), nil
+}
+
+// parseProtoFile parses the proto file at path in fsys.
+func parseProtoFile(fsys fs.FS, path string) (*parser.Proto, error) {
+	reader, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open proto: %w", err)
+	}
+	defer reader.Close()
+	proto, err := protoparser.Parse(reader, protoparser.WithFilename(path))
+	if err != nil {
+		return nil, fmt.Errorf("parse proto: %w", err)
+	}
+	return proto, nil
+}
+
+// position converts the start of a parsed declaration.
+func position(m meta.Meta) Position {
+	return Position{Filename: m.Pos.Filename, Line: m.Pos.Line, Column: m.Pos.Column}
+}
+
+// collectImports appends the files imported by proto, which was read from
+// path, and their imports in turn to out, each file once. Like protoc it
+// resolves import paths against roots, the directory of the main file and
+// the proto paths, then falls back to the directory of the importing file.
+func collectImports(fsys fs.FS, proto *parser.Proto, path string, roots []string, visited map[string]bool, out *[]importedProto) error {
+	searchPaths := append(append([]string{}, roots...), filepath.Dir(path))
+	for _, imp := range protoImports(proto) {
+		impPath := resolveImportPath(fsys, imp, searchPaths)
+		if impPath == "" {
+			continue // skip unresolvable imports (e.g. google/protobuf/*)
+		}
+		absPath, err := filepath.Abs(impPath)
+		if err != nil {
+			return fmt.Errorf("abs path: %w", err)
+		}
+		if visited[absPath] {
+			continue
+		}
+		visited[absPath] = true
+		imported, err := parseProtoFile(fsys, impPath)
+		if err != nil {
+			return fmt.Errorf("import %q: %w", imp, err)
+		}
+		*out = append(*out, importedProto{name: strings.TrimSuffix(imp, ".proto"), proto: imported})
+		if err := collectImports(fsys, imported, impPath, roots, visited, out); err != nil {
+			return fmt.Errorf("import %q: %w", imp, err)
+		}
+	}
+	return nil
+}
+
+// resolveImportPath finds the file for an import path across search directories.
+func resolveImportPath(fsys fs.FS, importLoc string, searchPaths []string) string {
+	for _, dir := range searchPaths {
+		candidate := filepath.Join(dir, importLoc)
+		if _, err := fs.Stat(fsys, candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// ParseStreamingCommands reads the streaming.txt sidecar, which lists
+// "<command> <p2c|c2p>" lines. Deprecated: set (blerpc.streaming) on the
+// request message or use stream RPCs instead; the file is only consulted for
+// commands the proto leaves unary.
+func ParseStreamingCommands(path string) (map[string]string, error) {
+	return ParseStreamingCommandsFS(OS, path)
+}
+
+// ParseStreamingCommandsFS is ParseStreamingCommands reading path from fsys.
+func ParseStreamingCommandsFS(fsys fs.FS, path string) (map[string]string, error) {
+	streaming := make(map[string]string)
+	f, err := fsys.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return streaming, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid streaming line (expected 'name direction'): %q", line)
+		}
+		dir := parts[1]
+		if dir != "p2c" && dir != "c2p" {
+			return nil, fmt.Errorf("invalid direction %q (must be p2c or c2p)", dir)
+		}
+		streaming[parts[0]] = dir
+	}
+	return streaming, scanner.Err()
+}
+
+func ParseOptions(path string) (map[string]bool, error) {
+	return ParseOptionsFS(OS, path)
+}
+
+// ParseOptionsFS is ParseOptions reading path from fsys.
+func ParseOptionsFS(fsys fs.FS, path string) (map[string]bool, error) {
+	callbacks := make(map[string]bool)
+	f, err := fsys.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return callbacks, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "FT_CALLBACK") {
+			parts := strings.Fields(line)
+			if len(parts) > 0 {
+				qualified := strings.TrimPrefix(parts[0], "blerpc.")
+				callbacks[qualified] = true
+			}
+		}
+	}
+	return callbacks, scanner.Err()
+}
+
+// FieldLimits holds the nanopb size options of one field.
+type FieldLimits struct {
+	MaxSize  int // max_size: bytes of a string or bytes field
+	MaxCount int // max_count: elements of a repeated field
+}
+
+// ParseOptionLimits reads max_size and max_count from a nanopb .options file,
+// keyed like ParseOptions.
+func ParseOptionLimits(path string) (map[string]FieldLimits, error) {
+	return ParseOptionLimitsFS(OS, path)
+}
+
+// ParseOptionLimitsFS is ParseOptionLimits reading path from fsys.
+func ParseOptionLimitsFS(fsys fs.FS, path string) (map[string]FieldLimits, error) {
+	limits := make(map[string]FieldLimits)
+	f, err := fsys.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return limits, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 || strings.HasPrefix(parts[0], "#") {
+			continue
+		}
+		qualified := strings.TrimPrefix(parts[0], "blerpc.")
+		l := limits[qualified]
+		for _, opt := range parts[1:] {
+			key, value, ok := strings.Cut(opt, ":")
+			if !ok {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "max_size":
+				l.MaxSize = n
+			case "max_count":
+				l.MaxCount = n
+			}
+		}
+		limits[qualified] = l
+	}
+	return limits, scanner.Err()
+}
+
+// StreamingFromServices derives streaming directions from service RPC definitions.
+// server stream → p2c (peripheral-to-central), client stream → c2p (central-to-peripheral).
+func StreamingFromServices(services []Service) map[string]string {
+	streaming := make(map[string]string)
+	for _, svc := range services {
+		for _, rpc := range svc.RPCs {
+			snake := CamelToSnake(rpc.Name)
+			if rpc.ServerStream && !rpc.ClientStream {
+				streaming[snake] = "p2c"
+			} else if rpc.ClientStream && !rpc.ServerStream {
+				streaming[snake] = "c2p"
+			}
+			// bidirectional streaming not supported yet; unary has no entry
+		}
+	}
+	return streaming
+}
+
+// streamingDirections maps (blerpc.streaming) values to directions.
+var streamingDirections = map[string]string{"UNARY": "", "SERVER": "p2c", "CLIENT": "c2p"}
+
+// StreamingFromProto derives streaming directions from the proto itself: the
+// stream keywords of service RPCs and the (blerpc.streaming) option of
+// request messages. An option that contradicts its RPC is an error.
+func StreamingFromProto(commands []Command, messages []Message, services []Service) (map[string]string, error) {
+	streaming := StreamingFromServices(services)
+	for _, m := range messages {
+		value, ok := m.Options["blerpc.streaming"]
+		if !ok {
+			continue
+		}
+		dir, ok := streamingDirections[value]
+		if !ok {
+			return nil, fmt.Errorf("message %s: invalid (blerpc.streaming) %q (must be UNARY, SERVER or CLIENT)", m.Name, value)
+		}
+		found := false
+		for _, cmd := range commands {
+			if cmd.RequestMsg != m.Name {
+				continue
+			}
+			found = true
+			if cmd.Service != "" {
+				if streaming[cmd.Snake] != dir {
+					return nil, fmt.Errorf("message %s: (blerpc.streaming) = %s contradicts rpc %s", m.Name, value, cmd.Camel)
+				}
+				continue
+			}
+			if dir != "" {
+				streaming[cmd.Snake] = dir
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("message %s: (blerpc.streaming) is only allowed on command request messages", m.Name)
+		}
+	}
+	return streaming, nil
+}
+
+// LocalTypeName strips the package qualifier from an RPC message type of the
+// schema's own package, so .blerpc.EchoRequest and blerpc.EchoRequest name
+// the EchoRequest message.
+func LocalTypeName(name, pkg string) string {
+	name = strings.TrimPrefix(name, ".")
+	if pkg != "" {
+		name = strings.TrimPrefix(name, pkg+".")
+	}
+	return name
+}
+
+// ValidateServiceTypes rejects RPCs whose request or response is not a
+// message of the schema, instead of leaving the command out, or is defined in
+// an imported file, whose generated classes the clients do not address.
+func ValidateServiceTypes(services []Service, msgByName map[string]Message) error {
+	for _, svc := range services {
+		for _, rpc := range svc.RPCs {
+			for _, name := range []string{rpc.RequestType, rpc.ResponseType} {
+				msg, ok := msgByName[name]
+				if !ok {
+					return fmt.Errorf("rpc %s.%s: unknown message %s", svc.Name, rpc.Name, name)
+				}
+				if msg.File != "" {
+					return fmt.Errorf("rpc %s.%s: message %s is defined in imported %s.proto; define request and response messages in the main proto file",
+						svc.Name, rpc.Name, name, msg.File)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DiscoverCommandsFromServices builds commands from service RPC definitions.
+func DiscoverCommandsFromServices(services []Service, msgByName map[string]Message) []Command {
+	var commands []Command
+	for _, svc := range services {
+		for _, rpc := range svc.RPCs {
+			reqMsg, reqOk := msgByName[rpc.RequestType]
+			respMsg, respOk := msgByName[rpc.ResponseType]
+			if !reqOk || !respOk {
+				continue
+			}
+			commands = append(commands, Command{
+				Camel:            rpc.Name,
+				Snake:            CamelToSnake(rpc.Name),
+				WireName:         rpc.Options["blerpc.wire_name"],
+				RenamedFrom:      rpc.Options["blerpc.renamed_from"],
+				Idempotent:       IsIdempotent(rpc.Options["idempotency_level"]),
+				QueueTTL:         ParseQueueTTL(rpc.Options["blerpc.queue_ttl"]),
+				RateLimit:        ParseRateLimit(rpc.Options["blerpc.rate_limit"]),
+				TimeoutMs:        ParseTimeoutMs(rpc.Options["blerpc.timeout_ms"]),
+				Retries:          ParseRetries(rpc.Options["blerpc.retries"]),
+				CacheTTLMs:       ParseCacheTTLMs(rpc.Options["blerpc.cache_ttl_ms"]),
+				Priority:         rpc.Options["blerpc.priority"],
+				Role:             rpc.Options["blerpc.role"],
+				ExcludeTargets:   ParseExcludeTargets(rpc.Options["blerpc.exclude_targets"]),
+				ReplayProtected:  rpc.Options["blerpc.replay_protected"] == "true",
+				SessionProtected: rpc.Options["blerpc.session_protected"] == "true",
+				Compression:      rpc.Options["blerpc.compression"],
+				GattService:      rpc.Options["blerpc.gatt_service"],
+				Deprecated:       rpc.Options["deprecated"] == "true" || reqMsg.Options["deprecated"] == "true",
+				Service:          svc.Name,
+				RequestMsg:       rpc.RequestType,
+				ResponseMsg:      rpc.ResponseType,
+				RequestFields:    reqMsg.Fields,
+				ResponseFields:   respMsg.Fields,
+				Comment:          cmp.Or(rpc.Comment, reqMsg.Comment),
+			})
+		}
+	}
+	return commands
+}
+
+// UnpairedRequests returns the *Request messages DiscoverCommands leaves out
+// for lack of a matching *Response message.
+func UnpairedRequests(messages []Message) []string {
+	have := make(map[string]bool)
+	for _, m := range messages {
+		have[m.Name] = true
+	}
+	var names []string
+	for _, m := range messages {
+		camel, ok := strings.CutSuffix(m.Name, "Request")
+		if ok && m.File == "" && !have[camel+"Response"] {
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+// DiscoverCommands builds commands from the naming convention: every
+// <Name>Request message of the main file with a matching <Name>Response.
+func DiscoverCommands(messages []Message) []Command {
+	msgByName := make(map[string]Message)
+	for _, m := range messages {
+		msgByName[m.Name] = m
+	}
+
+	var commands []Command
+	for _, msg := range messages {
+		// Messages of imported files are field types, not commands.
+		if msg.File != "" || !strings.HasSuffix(msg.Name, "Request") {
+			continue
+		}
+		camel := msg.Name[:len(msg.Name)-len("Request")]
+		respName := camel + "Response"
+		resp, ok := msgByName[respName]
+		if !ok {
+			continue
+		}
+		commands = append(commands, Command{
+			Camel:          camel,
+			Snake:          CamelToSnake(camel),
+			RequestMsg:     msg.Name,
+			ResponseMsg:    respName,
+			RequestFields:  msg.Fields,
+			ResponseFields: resp.Fields,
+			Deprecated:     msg.Options["deprecated"] == "true",
+			Comment:        msg.Comment,
+		})
+	}
+	return commands
+}
+
+// maxWireNameLen is the largest command name the protocol header can carry
+// (the name length is a single byte).
+const maxWireNameLen = 255
+
+// ValidateWireNames rejects commands whose on-air names collide, either with
+// each other or with the default name of another command.
+func ValidateWireNames(commands []Command) error {
+	owner := make(map[string]string)
+	for _, cmd := range commands {
+		wire := cmd.Wire()
+		if wire == "" {
+			return fmt.Errorf("command %s: empty wire name", cmd.Camel)
+		}
+		if len(wire) > maxWireNameLen {
+			return fmt.Errorf("command %s: wire name %q exceeds %d bytes", cmd.Camel, wire, maxWireNameLen)
+		}
+		if other, ok := owner[wire]; ok {
+			return fmt.Errorf("wire name %q used by both %s and %s", wire, other, cmd.Camel)
+		}
+		owner[wire] = cmd.Camel
+	}
+	return nil
+}
+
+// DefaultMaxCommands is the default -max-commands limit. Every command adds
+// a linearly searched handler table entry and a name string to firmware
+// flash, so schemas past a few hundred commands are more likely a mistake
+// (e.g. a shared proto generated into one device) than intended.
+const DefaultMaxCommands = 255
+
+// ValidateCommandCount rejects schemas with more than limit commands, so an
+// oversized schema fails at generation time rather than producing firmware
+// that runs out of flash or dispatches slowly. A limit of 0 disables the check.
+func ValidateCommandCount(commands []Command, limit int) error {
+	if limit > 0 && len(commands) > limit {
+		return fmt.Errorf("%d commands exceed the limit of %d; split the schema into smaller services, "+
+			"enable command_ids in blerpc.yaml so requests carry a one-byte ID instead of the name, "+
+			"or raise -max-commands if the firmware can hold the larger handler table", len(commands), limit)
+	}
+	return nil
+}
+
+// ValidateAliases rejects renamed_from aliases that would clash with a
+// current command (or another alias) once turned into a client method name.
+func ValidateAliases(commands []Command) error {
+	owner := make(map[string]string)
+	for _, cmd := range commands {
+		owner[cmd.Snake] = cmd.Camel
+	}
+	for _, cmd := range commands {
+		if cmd.RenamedFrom == "" {
+			continue
+		}
+		old := CamelToSnake(cmd.RenamedFrom)
+		if other, ok := owner[old]; ok {
+			return fmt.Errorf("command %s: renamed_from %q clashes with %s", cmd.Camel, cmd.RenamedFrom, other)
+		}
+		owner[old] = cmd.Camel
+	}
+	return nil
+}
+
+// Discover returns the commands of a parsed schema and the directions of its
+// streaming commands, keyed by snake name. Commands come from the service
+// definitions, or without services from Request/Response message pairs.
+func Discover(file *ProtoFile) ([]Command, map[string]string, error) {
+	msgByName := make(map[string]Message)
+	for _, m := range file.Messages {
+		msgByName[m.Name] = m
+	}
+	var commands []Command
+	if len(file.Services) > 0 {
+		if err := ValidateServiceTypes(file.Services, msgByName); err != nil {
+			return nil, nil, fmt.Errorf("invalid services: %w", err)
+		}
+		commands = DiscoverCommandsFromServices(file.Services, msgByName)
+	} else {
+		commands = DiscoverCommands(file.Messages)
+	}
+	streaming, err := StreamingFromProto(commands, file.Messages, file.Services)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid streaming: %w", err)
+	}
+	if err := ValidateWireNames(commands); err != nil {
+		return nil, nil, fmt.Errorf("invalid commands: %w", err)
+	}
+	if err := ValidateAliases(commands); err != nil {
+		return nil, nil, fmt.Errorf("invalid commands: %w", err)
+	}
+	return commands, streaming, nil
+}
@@ -0,0 +1,1438 @@
+package protomodel
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const echoProto = `syntax = "proto3";
+package test;
+
+message EchoRequest {
+  string message = 1;
+}
+
+message EchoResponse {
+  string message = 1;
+}
+`
+
+const multiFieldProto = `syntax = "proto3";
+package test;
+
+message FlashReadRequest {
+  uint32 address = 1;
+  uint32 length = 2;
+}
+
+message FlashReadResponse {
+  uint32 address = 1;
+  bytes data = 2;
+}
+`
+
+const noMatchProto = `syntax = "proto3";
+package test;
+
+message Foo {
+  string name = 1;
+}
+
+message Bar {
+  string name = 1;
+}
+`
+
+const enumProto = `syntax = "proto3";
+package test;
+
+enum Status {
+  STATUS_UNKNOWN = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_INACTIVE = 2;
+}
+
+message GetStatusRequest {
+  string name = 1;
+}
+
+message GetStatusResponse {
+  Status status = 1;
+}
+`
+
+const nestedEnumProto = `syntax = "proto3";
+package test;
+
+message SetModeRequest {
+  enum Mode {
+    MODE_UNKNOWN = 0;
+    MODE_FAST = 1;
+    MODE_SLOW = 2;
+  }
+  Mode mode = 1;
+}
+
+message SetModeResponse {
+  bool ok = 1;
+}
+`
+
+const repeatedProto = `syntax = "proto3";
+package test;
+
+message BatchRequest {
+  repeated string names = 1;
+  repeated uint32 ids = 2;
+}
+
+message BatchResponse {
+  repeated string results = 1;
+}
+`
+
+const repeatedEnumProto = `syntax = "proto3";
+package test;
+
+enum Color {
+  COLOR_UNKNOWN = 0;
+  COLOR_RED = 1;
+  COLOR_BLUE = 2;
+}
+
+message PaintRequest {
+  repeated Color colors = 1;
+}
+
+message PaintResponse {
+  bool ok = 1;
+}
+`
+
+const messageFieldProto = `syntax = "proto3";
+package test;
+
+message Address {
+  string street = 1;
+  string city = 2;
+}
+
+message UpdateAddressRequest {
+  string user_id = 1;
+  Address address = 2;
+}
+
+message UpdateAddressResponse {
+  bool ok = 1;
+}
+`
+
+const oneofProto = `syntax = "proto3";
+package test;
+
+message SearchRequest {
+  oneof query {
+    string text = 1;
+    uint32 id = 2;
+  }
+}
+
+message SearchResponse {
+  string result = 1;
+}
+`
+
+const mapProto = `syntax = "proto3";
+package test;
+
+message SetLabelsRequest {
+  map<string, string> labels = 1;
+  map<string, uint32> counts = 2;
+}
+
+message SetLabelsResponse {
+  bool ok = 1;
+}
+`
+
+func TestParseProtoReader_Echo(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(echoProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgs := pf.Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].Name != "EchoRequest" {
+		t.Errorf("expected EchoRequest, got %s", msgs[0].Name)
+	}
+	if msgs[1].Name != "EchoResponse" {
+		t.Errorf("expected EchoResponse, got %s", msgs[1].Name)
+	}
+	if len(msgs[0].Fields) != 1 {
+		t.Fatalf("expected 1 field in EchoRequest, got %d", len(msgs[0].Fields))
+	}
+	f := msgs[0].Fields[0]
+	if f.Type != "string" || f.Name != "message" || f.Number != 1 {
+		t.Errorf("unexpected field: %+v", f)
+	}
+}
+
+func TestParseProtoReader_MultiField(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(multiFieldProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgs := pf.Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	req := msgs[0]
+	if len(req.Fields) != 2 {
+		t.Fatalf("expected 2 fields in FlashReadRequest, got %d", len(req.Fields))
+	}
+	if req.Fields[0].Type != "uint32" || req.Fields[0].Name != "address" {
+		t.Errorf("unexpected field[0]: %+v", req.Fields[0])
+	}
+	if req.Fields[1].Type != "uint32" || req.Fields[1].Name != "length" {
+		t.Errorf("unexpected field[1]: %+v", req.Fields[1])
+	}
+}
+
+func TestParseProtoReader_Enum(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(enumProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if len(pf.Enums) != 1 {
+		t.Fatalf("expected 1 enum, got %d", len(pf.Enums))
+	}
+	e := pf.Enums[0]
+	if e.Name != "Status" {
+		t.Errorf("expected enum name Status, got %s", e.Name)
+	}
+	if len(e.Values) != 3 {
+		t.Fatalf("expected 3 enum values, got %d", len(e.Values))
+	}
+	if e.Values[0].Name != "STATUS_UNKNOWN" || e.Values[0].Number != 0 {
+		t.Errorf("unexpected enum value[0]: %+v", e.Values[0])
+	}
+	if e.Values[1].Name != "STATUS_ACTIVE" || e.Values[1].Number != 1 {
+		t.Errorf("unexpected enum value[1]: %+v", e.Values[1])
+	}
+
+	// Check that the Status field is marked as enum
+	msgs := pf.Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	resp := msgs[1]
+	if len(resp.Fields) != 1 {
+		t.Fatalf("expected 1 field in GetStatusResponse, got %d", len(resp.Fields))
+	}
+	if !resp.Fields[0].IsEnum {
+		t.Error("expected Status field to be marked as enum")
+	}
+	if resp.Fields[0].Type != "Status" {
+		t.Errorf("expected field type Status, got %s", resp.Fields[0].Type)
+	}
+}
+
+func TestParseProtoReader_NestedEnum(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(nestedEnumProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if len(pf.Enums) != 1 {
+		t.Fatalf("expected 1 enum, got %d", len(pf.Enums))
+	}
+	e := pf.Enums[0]
+	if e.Name != "Mode" {
+		t.Errorf("expected enum name Mode, got %s", e.Name)
+	}
+	if len(e.Values) != 3 {
+		t.Fatalf("expected 3 enum values, got %d", len(e.Values))
+	}
+
+	// Check that the Mode field is marked as enum
+	msgs := pf.Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	req := msgs[0]
+	if len(req.Fields) != 1 {
+		t.Fatalf("expected 1 field in SetModeRequest, got %d", len(req.Fields))
+	}
+	if !req.Fields[0].IsEnum {
+		t.Error("expected Mode field to be marked as enum")
+	}
+}
+
+func TestDiscoverCommands_Echo(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(echoProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	cmds := DiscoverCommands(pf.Messages)
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+	cmd := cmds[0]
+	if cmd.Camel != "Echo" {
+		t.Errorf("expected Camel=Echo, got %s", cmd.Camel)
+	}
+	if cmd.Snake != "echo" {
+		t.Errorf("expected Snake=echo, got %s", cmd.Snake)
+	}
+	if cmd.RequestMsg != "EchoRequest" {
+		t.Errorf("expected RequestMsg=EchoRequest, got %s", cmd.RequestMsg)
+	}
+	if cmd.ResponseMsg != "EchoResponse" {
+		t.Errorf("expected ResponseMsg=EchoResponse, got %s", cmd.ResponseMsg)
+	}
+}
+
+func TestDiscoverCommands_NoMatch(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(noMatchProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	cmds := DiscoverCommands(pf.Messages)
+	if len(cmds) != 0 {
+		t.Fatalf("expected 0 commands, got %d", len(cmds))
+	}
+}
+
+func TestDiscoverCommands_MultiField(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(multiFieldProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	cmds := DiscoverCommands(pf.Messages)
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+	cmd := cmds[0]
+	if cmd.Camel != "FlashRead" {
+		t.Errorf("expected Camel=FlashRead, got %s", cmd.Camel)
+	}
+	if cmd.Snake != "flash_read" {
+		t.Errorf("expected Snake=flash_read, got %s", cmd.Snake)
+	}
+	if len(cmd.RequestFields) != 2 {
+		t.Errorf("expected 2 request fields, got %d", len(cmd.RequestFields))
+	}
+	if len(cmd.ResponseFields) != 2 {
+		t.Errorf("expected 2 response fields, got %d", len(cmd.ResponseFields))
+	}
+}
+
+func TestDiscoverCommands_Enum(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(enumProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	cmds := DiscoverCommands(pf.Messages)
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+	cmd := cmds[0]
+	if cmd.Camel != "GetStatus" {
+		t.Errorf("expected Camel=GetStatus, got %s", cmd.Camel)
+	}
+	if len(cmd.ResponseFields) != 1 {
+		t.Fatalf("expected 1 response field, got %d", len(cmd.ResponseFields))
+	}
+	if !cmd.ResponseFields[0].IsEnum {
+		t.Error("expected response field to be marked as enum")
+	}
+}
+
+func TestParseProtoReader_Repeated(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(repeatedProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgs := pf.Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	req := msgs[0]
+	if len(req.Fields) != 2 {
+		t.Fatalf("expected 2 fields, got %d", len(req.Fields))
+	}
+	if !req.Fields[0].IsRepeated {
+		t.Error("expected names to be repeated")
+	}
+	if req.Fields[0].Type != "string" {
+		t.Errorf("expected type string, got %s", req.Fields[0].Type)
+	}
+	if !req.Fields[1].IsRepeated {
+		t.Error("expected ids to be repeated")
+	}
+}
+
+func TestParseProtoReader_RepeatedEnum(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(repeatedEnumProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgs := pf.Messages
+	req := msgs[0]
+	if len(req.Fields) != 1 {
+		t.Fatalf("expected 1 field, got %d", len(req.Fields))
+	}
+	f := req.Fields[0]
+	if !f.IsRepeated {
+		t.Error("expected colors to be repeated")
+	}
+	if !f.IsEnum {
+		t.Error("expected colors to be an enum")
+	}
+}
+
+func TestParseProtoReader_MessageField(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(messageFieldProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgs := pf.Messages
+	// Should find Address, UpdateAddressRequest, UpdateAddressResponse
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+	// UpdateAddressRequest is the second message
+	req := msgs[1]
+	if req.Name != "UpdateAddressRequest" {
+		t.Fatalf("expected UpdateAddressRequest, got %s", req.Name)
+	}
+	if len(req.Fields) != 2 {
+		t.Fatalf("expected 2 fields, got %d", len(req.Fields))
+	}
+	// First field: user_id (string, not a message)
+	if req.Fields[0].IsMessage {
+		t.Error("expected user_id not to be a message type")
+	}
+	// Second field: address (Address, a message type)
+	if !req.Fields[1].IsMessage {
+		t.Error("expected address to be a message type")
+	}
+	if req.Fields[1].Type != "Address" {
+		t.Errorf("expected type Address, got %s", req.Fields[1].Type)
+	}
+}
+
+func TestParseProtoReader_NestedMessageField(t *testing.T) {
+	proto := `syntax = "proto3";
+package blerpc;
+message Point { int32 x = 1; }
+message Outer {
+  message Inner { string tag = 1; }
+  Inner inner = 1;
+}
+message DrawRequest {
+  Outer.Inner tag = 1;
+  repeated blerpc.Point path = 2;
+  map<string, Point> named = 3;
+}
+`
+	pf, err := ParseReader(strings.NewReader(proto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	outer, draw := pf.Messages[1], pf.Messages[2]
+	if f := outer.Fields[0]; !f.IsMessage || f.Type != "Outer.Inner" {
+		t.Errorf("Outer.inner = %+v, want message Outer.Inner", f)
+	}
+	if f := draw.Fields[0]; !f.IsMessage || f.Type != "Outer.Inner" {
+		t.Errorf("tag = %+v, want message Outer.Inner", f)
+	}
+	if f := draw.Fields[1]; !f.IsMessage || f.Type != "Point" {
+		t.Errorf("path = %+v, want message Point", f)
+	}
+	if f := draw.Fields[2]; !f.ValueIsMessage || f.ValueType != "Point" {
+		t.Errorf("named = %+v, want message values of Point", f)
+	}
+}
+
+func TestDiscoverCommands_MessageField(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(messageFieldProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	cmds := DiscoverCommands(pf.Messages)
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+	cmd := cmds[0]
+	if cmd.Camel != "UpdateAddress" {
+		t.Errorf("expected Camel=UpdateAddress, got %s", cmd.Camel)
+	}
+	if len(cmd.RequestFields) != 2 {
+		t.Fatalf("expected 2 request fields, got %d", len(cmd.RequestFields))
+	}
+	if !cmd.RequestFields[1].IsMessage {
+		t.Error("expected second request field to be a message type")
+	}
+}
+
+func TestParseProtoReader_Map(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(mapProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgs := pf.Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	req := msgs[0]
+	if len(req.Fields) != 2 {
+		t.Fatalf("expected 2 fields, got %d", len(req.Fields))
+	}
+	// First field: map<string, string> labels
+	f0 := req.Fields[0]
+	if !f0.IsMap {
+		t.Error("expected labels to be a map")
+	}
+	if f0.KeyType != "string" {
+		t.Errorf("expected KeyType=string, got %s", f0.KeyType)
+	}
+	if f0.ValueType != "string" {
+		t.Errorf("expected ValueType=string, got %s", f0.ValueType)
+	}
+	if f0.Name != "labels" {
+		t.Errorf("expected name=labels, got %s", f0.Name)
+	}
+	// Second field: map<string, uint32> counts
+	f1 := req.Fields[1]
+	if !f1.IsMap {
+		t.Error("expected counts to be a map")
+	}
+	if f1.KeyType != "string" {
+		t.Errorf("expected KeyType=string, got %s", f1.KeyType)
+	}
+	if f1.ValueType != "uint32" {
+		t.Errorf("expected ValueType=uint32, got %s", f1.ValueType)
+	}
+}
+
+func TestParseProtoReader_Oneof(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(oneofProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgs := pf.Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	req := msgs[0]
+	// Oneof fields should appear in the flat field list
+	if len(req.Fields) != 2 {
+		t.Fatalf("expected 2 fields, got %d", len(req.Fields))
+	}
+	if req.Fields[0].Name != "text" || req.Fields[0].Type != "string" {
+		t.Errorf("unexpected field[0]: %+v", req.Fields[0])
+	}
+	if req.Fields[1].Name != "id" || req.Fields[1].Type != "uint32" {
+		t.Errorf("unexpected field[1]: %+v", req.Fields[1])
+	}
+	if req.Fields[0].Oneof != "query" || req.Fields[1].Oneof != "query" {
+		t.Errorf("expected flat oneof fields to record oneof name, got %+v", req.Fields)
+	}
+	// Check oneof group
+	if len(req.Oneofs) != 1 {
+		t.Fatalf("expected 1 oneof group, got %d", len(req.Oneofs))
+	}
+	og := req.Oneofs[0]
+	if og.Name != "query" {
+		t.Errorf("expected oneof name query, got %s", og.Name)
+	}
+	if len(og.Fields) != 2 {
+		t.Fatalf("expected 2 oneof fields, got %d", len(og.Fields))
+	}
+}
+
+const serviceProto = `syntax = "proto3";
+package test;
+
+message EchoRequest {
+  string message = 1;
+}
+
+message EchoResponse {
+  string message = 1;
+}
+
+message CounterStreamRequest {
+  uint32 start = 1;
+}
+
+message CounterStreamResponse {
+  uint32 count = 1;
+}
+
+message CounterUploadRequest {
+  uint32 value = 1;
+}
+
+message CounterUploadResponse {
+  uint32 total = 1;
+}
+
+service TestService {
+  rpc Echo(EchoRequest) returns (EchoResponse);
+  rpc CounterStream(CounterStreamRequest) returns (stream CounterStreamResponse);
+  rpc CounterUpload(stream CounterUploadRequest) returns (CounterUploadResponse);
+}
+`
+
+func TestParseProtoReader_Service(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(serviceProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if len(pf.Services) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(pf.Services))
+	}
+	svc := pf.Services[0]
+	if svc.Name != "TestService" {
+		t.Errorf("expected service name TestService, got %s", svc.Name)
+	}
+	if len(svc.RPCs) != 3 {
+		t.Fatalf("expected 3 RPCs, got %d", len(svc.RPCs))
+	}
+
+	// Echo: unary
+	if svc.RPCs[0].Name != "Echo" {
+		t.Errorf("expected RPC name Echo, got %s", svc.RPCs[0].Name)
+	}
+	if svc.RPCs[0].ClientStream || svc.RPCs[0].ServerStream {
+		t.Error("Echo should not be streaming")
+	}
+
+	// CounterStream: server-side stream (p2c)
+	if svc.RPCs[1].Name != "CounterStream" {
+		t.Errorf("expected RPC name CounterStream, got %s", svc.RPCs[1].Name)
+	}
+	if svc.RPCs[1].ClientStream {
+		t.Error("CounterStream should not have client stream")
+	}
+	if !svc.RPCs[1].ServerStream {
+		t.Error("CounterStream should have server stream")
+	}
+
+	// CounterUpload: client-side stream (c2p)
+	if svc.RPCs[2].Name != "CounterUpload" {
+		t.Errorf("expected RPC name CounterUpload, got %s", svc.RPCs[2].Name)
+	}
+	if !svc.RPCs[2].ClientStream {
+		t.Error("CounterUpload should have client stream")
+	}
+	if svc.RPCs[2].ServerStream {
+		t.Error("CounterUpload should not have server stream")
+	}
+}
+
+func TestStreamingFromServices(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(serviceProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	streaming := StreamingFromServices(pf.Services)
+
+	if _, ok := streaming["echo"]; ok {
+		t.Error("echo should not be in streaming map")
+	}
+	if dir, ok := streaming["counter_stream"]; !ok || dir != "p2c" {
+		t.Errorf("expected counter_stream=p2c, got %q", dir)
+	}
+	if dir, ok := streaming["counter_upload"]; !ok || dir != "c2p" {
+		t.Errorf("expected counter_upload=c2p, got %q", dir)
+	}
+}
+
+func TestStreamingFromProto(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(`syntax = "proto3";
+package blerpc;
+import "blerpc_options.proto";
+message EchoRequest { string message = 1; }
+message EchoResponse { string message = 1; }
+message CounterStreamRequest {
+  option (blerpc.streaming) = SERVER;
+  uint32 count = 1;
+}
+message CounterStreamResponse { uint32 seq = 1; }
+message CounterUploadRequest {
+  option (blerpc.streaming) = CLIENT;
+  uint32 seq = 1;
+}
+message CounterUploadResponse { uint32 received = 1; }
+`))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	cmds := DiscoverCommands(pf.Messages)
+	streaming, err := StreamingFromProto(cmds, pf.Messages, nil)
+	if err != nil {
+		t.Fatalf("StreamingFromProto: %v", err)
+	}
+	want := map[string]string{"counter_stream": "p2c", "counter_upload": "c2p"}
+	if !reflect.DeepEqual(streaming, want) {
+		t.Errorf("got %v, want %v", streaming, want)
+	}
+
+	for _, tt := range []struct {
+		name string
+		msg  Message
+		want string
+	}{
+		{"invalid value", Message{Name: "EchoRequest", Options: map[string]string{"blerpc.streaming": "BIDI"}}, "invalid (blerpc.streaming)"},
+		{"not a request", Message{Name: "EchoResponse", Options: map[string]string{"blerpc.streaming": "SERVER"}}, "only allowed on command request messages"},
+	} {
+		_, err := StreamingFromProto(cmds, []Message{tt.msg}, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Service RPCs carry the direction already; the option must agree.
+	svc, err := ParseReader(strings.NewReader(serviceProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgByName := make(map[string]Message)
+	for _, m := range svc.Messages {
+		msgByName[m.Name] = m
+	}
+	svcCmds := DiscoverCommandsFromServices(svc.Services, msgByName)
+	agree := []Message{{Name: "CounterStreamRequest", Options: map[string]string{"blerpc.streaming": "SERVER"}}}
+	if _, err := StreamingFromProto(svcCmds, agree, svc.Services); err != nil {
+		t.Errorf("matching option: %v", err)
+	}
+	contradict := []Message{{Name: "EchoRequest", Options: map[string]string{"blerpc.streaming": "CLIENT"}}}
+	if _, err := StreamingFromProto(svcCmds, contradict, svc.Services); err == nil || !strings.Contains(err.Error(), "contradicts rpc Echo") {
+		t.Errorf("contradicting option: got %v", err)
+	}
+}
+
+func TestDiscoverCommandsFromServices(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(serviceProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgByName := make(map[string]Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	cmds := DiscoverCommandsFromServices(pf.Services, msgByName)
+	if len(cmds) != 3 {
+		t.Fatalf("expected 3 commands, got %d", len(cmds))
+	}
+	if cmds[0].Camel != "Echo" || cmds[0].Snake != "echo" || cmds[0].Service != "TestService" {
+		t.Errorf("unexpected cmd[0]: %+v", cmds[0])
+	}
+	if cmds[1].Camel != "CounterStream" || cmds[1].Snake != "counter_stream" {
+		t.Errorf("unexpected cmd[1]: %+v", cmds[1])
+	}
+	if cmds[2].Camel != "CounterUpload" || cmds[2].Snake != "counter_upload" {
+		t.Errorf("unexpected cmd[2]: %+v", cmds[2])
+	}
+	// Verify request/response fields are populated
+	if len(cmds[0].RequestFields) != 1 {
+		t.Errorf("expected 1 request field for Echo, got %d", len(cmds[0].RequestFields))
+	}
+}
+
+func TestDiscoverCommandsFromServices_ArbitraryNames(t *testing.T) {
+	proto := `syntax = "proto3";
+package test;
+
+message Ping {
+  uint32 seq = 1;
+}
+
+message Pong {
+  uint32 seq = 1;
+}
+
+message GetStatus {}
+
+service Device {
+  rpc Heartbeat(Ping) returns (test.Pong);
+  rpc Status(.test.GetStatus) returns (Pong);
+}
+`
+	pf, err := ParseReader(strings.NewReader(proto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgByName := make(map[string]Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	if err := ValidateServiceTypes(pf.Services, msgByName); err != nil {
+		t.Fatalf("ValidateServiceTypes: %v", err)
+	}
+	cmds := DiscoverCommandsFromServices(pf.Services, msgByName)
+	if len(cmds) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(cmds))
+	}
+	if cmds[0].Snake != "heartbeat" || cmds[0].RequestMsg != "Ping" || cmds[0].ResponseMsg != "Pong" {
+		t.Errorf("unexpected cmd[0]: %+v", cmds[0])
+	}
+	if cmds[1].Snake != "status" || cmds[1].RequestMsg != "GetStatus" {
+		t.Errorf("unexpected cmd[1]: %+v", cmds[1])
+	}
+}
+
+func TestDiscoverCommandsFromServices_Deprecated(t *testing.T) {
+	proto := `syntax = "proto3";
+package test;
+
+message PingRequest {
+  uint32 seq = 1;
+  string note = 2 [deprecated = true];
+}
+
+message PingResponse {
+  uint32 seq = 1;
+}
+
+service Device {
+  rpc Ping(PingRequest) returns (PingResponse) {
+    option deprecated = true;
+  }
+}
+`
+	pf, err := ParseReader(strings.NewReader(proto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgByName := make(map[string]Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	cmds := DiscoverCommandsFromServices(pf.Services, msgByName)
+	if len(cmds) != 1 || !cmds[0].Deprecated {
+		t.Fatalf("expected a deprecated command, got %+v", cmds)
+	}
+	if f := cmds[0].RequestFields; f[0].Deprecated || !f[1].Deprecated {
+		t.Errorf("expected only note deprecated, got %+v", f)
+	}
+}
+
+func TestParseSensitiveFields(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(`syntax = "proto3";
+package test;
+
+message LoginRequest {
+  string user = 1;
+  string password = 2 [(blerpc.sensitive) = true];
+  map<string, string> secrets = 3 [(blerpc.sensitive) = true];
+  oneof auth {
+    bytes pin = 4 [(blerpc.sensitive) = true];
+    bool guest = 5;
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	var got []string
+	for _, f := range pf.Messages[0].Fields {
+		if f.Sensitive {
+			got = append(got, f.Name)
+		}
+	}
+	if want := []string{"password", "secrets", "pin"}; !slices.Equal(got, want) {
+		t.Errorf("sensitive fields = %v, want %v", got, want)
+	}
+}
+
+func TestValidateServiceTypes(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(`syntax = "proto3";
+package test;
+
+message Ping {}
+
+service Device {
+  rpc Heartbeat(Ping) returns (google.protobuf.Empty);
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	msgByName := map[string]Message{"Ping": pf.Messages[0]}
+	err = ValidateServiceTypes(pf.Services, msgByName)
+	if err == nil || !strings.Contains(err.Error(), "Device.Heartbeat: unknown message google.protobuf.Empty") {
+		t.Errorf("got %v", err)
+	}
+
+	msgByName["google.protobuf.Empty"] = Message{Name: "Empty", File: "types"}
+	err = ValidateServiceTypes(pf.Services, msgByName)
+	if err == nil || !strings.Contains(err.Error(), "is defined in imported types.proto") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestUnpairedRequests(t *testing.T) {
+	messages := []Message{{Name: "EchoRequest"}, {Name: "EchoResponse"}, {Name: "ResetRequest"}, {Name: "Status"}}
+	got := UnpairedRequests(messages)
+	if len(got) != 1 || got[0] != "ResetRequest" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestParseProtoReader_Imports(t *testing.T) {
+	proto := `syntax = "proto3";
+package test;
+import "common.proto";
+import "other.proto";
+
+message EchoRequest {
+  string message = 1;
+}
+
+message EchoResponse {
+  string message = 1;
+}
+`
+	pf, err := ParseReader(strings.NewReader(proto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if len(pf.Imports) != 2 {
+		t.Fatalf("expected 2 imports, got %d", len(pf.Imports))
+	}
+	if pf.Imports[0] != "common.proto" {
+		t.Errorf("expected import[0]=common.proto, got %s", pf.Imports[0])
+	}
+	if pf.Imports[1] != "other.proto" {
+		t.Errorf("expected import[1]=other.proto, got %s", pf.Imports[1])
+	}
+}
+
+func TestParseProtoWithImports(t *testing.T) {
+	// Create temporary directory with proto files
+	tmpDir := t.TempDir()
+
+	// Write shared types proto
+	sharedProto := `syntax = "proto3";
+package test;
+
+enum Color {
+  COLOR_UNKNOWN = 0;
+  COLOR_RED = 1;
+}
+
+message Address {
+  string street = 1;
+  string city = 2;
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "shared.proto"), []byte(sharedProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write main proto that imports shared
+	mainProto := `syntax = "proto3";
+package test;
+import "shared.proto";
+
+message GetAddressRequest {
+  string user_id = 1;
+}
+
+message GetAddressResponse {
+  Address address = 1;
+  Color color = 2;
+}
+`
+	mainPath := filepath.Join(tmpDir, "main.proto")
+	if err := os.WriteFile(mainPath, []byte(mainProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := ParseWithImports(mainPath, nil)
+	if err != nil {
+		t.Fatalf("ParseWithImports: %v", err)
+	}
+
+	// Should have messages from both files
+	if len(pf.Messages) < 3 {
+		t.Fatalf("expected at least 3 messages (Address, GetAddressRequest, GetAddressResponse), got %d", len(pf.Messages))
+	}
+
+	// Should have enums from the imported file
+	if len(pf.Enums) < 1 {
+		t.Fatalf("expected at least 1 enum (Color), got %d", len(pf.Enums))
+	}
+
+	// The address field should be recognized as a message type
+	// since Address is defined in the imported file
+	cmds := DiscoverCommands(pf.Messages)
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+	address, color := cmds[0].ResponseFields[0], cmds[0].ResponseFields[1]
+	if !address.IsMessage || address.TypeFile != "shared" {
+		t.Errorf("address = %+v, want message of shared.proto", address)
+	}
+	if !color.IsEnum {
+		t.Errorf("color = %+v, want enum", color)
+	}
+}
+
+func TestParseProtoWithImports_Nested(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.proto": `syntax = "proto3";
+package test;
+import "common/types.proto";
+import "nanopb.proto";
+message PlaceRequest { Point at = 1; }
+message PlaceResponse {}
+`,
+		// Imported twice, parsed once.
+		"common/types.proto": `syntax = "proto3";
+package test;
+import "common/tag.proto";
+message Point { Tag tag = 1; }
+message MoveRequest {}
+message MoveResponse {}
+`,
+		"common/tag.proto": `syntax = "proto3";
+package test;
+message Tag {}
+`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pf, err := ParseWithImports(filepath.Join(dir, "main.proto"), nil)
+	if err != nil {
+		t.Fatalf("ParseWithImports: %v", err)
+	}
+	var names []string
+	for _, m := range pf.Messages {
+		names = append(names, m.Name+"@"+m.File)
+	}
+	want := "PlaceRequest@ PlaceResponse@ Point@common/types MoveRequest@common/types MoveResponse@common/types Tag@common/tag"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("messages = %s, want %s", got, want)
+	}
+	if f := pf.Messages[2].Fields[0]; !f.IsMessage || f.TypeFile != "common/tag" {
+		t.Errorf("Point.tag = %+v, want message of common/tag.proto", f)
+	}
+	// Request/Response pairs of imported files are types, not commands.
+	if cmds := DiscoverCommands(pf.Messages); len(cmds) != 1 || cmds[0].Snake != "place" {
+		t.Errorf("commands = %+v, want only place", cmds)
+	}
+}
+
+func TestParseProtoWithImports_PackageMismatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "other.proto"), []byte("syntax = \"proto3\";\npackage other;\nmessage Thing {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mainPath := filepath.Join(dir, "main.proto")
+	if err := os.WriteFile(mainPath, []byte("syntax = \"proto3\";\npackage test;\nimport \"other.proto\";\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ParseWithImports(mainPath, nil)
+	if err == nil || !strings.Contains(err.Error(), `import other.proto: package "other" differs from "test"`) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestParseProtoWithImports_ProtoPath(t *testing.T) {
+	// Create two directories: main dir and includes dir
+	mainDir := t.TempDir()
+	includesDir := t.TempDir()
+
+	// Write shared proto in includes dir
+	sharedProto := `syntax = "proto3";
+package test;
+
+message Shared {
+  string value = 1;
+}
+`
+	if err := os.WriteFile(filepath.Join(includesDir, "shared.proto"), []byte(sharedProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write main proto that imports shared
+	mainProto := `syntax = "proto3";
+package test;
+import "shared.proto";
+
+message UseSharedRequest {
+  Shared data = 1;
+}
+
+message UseSharedResponse {
+  bool ok = 1;
+}
+`
+	mainPath := filepath.Join(mainDir, "main.proto")
+	if err := os.WriteFile(mainPath, []byte(mainProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without proto-path, import should be skipped (not found in main dir)
+	pf, err := ParseWithImports(mainPath, nil)
+	if err != nil {
+		t.Fatalf("ParseWithImports: %v", err)
+	}
+	if len(pf.Messages) != 2 { // Only main file messages
+		t.Fatalf("without proto-path: expected 2 messages, got %d", len(pf.Messages))
+	}
+
+	// With proto-path, import should resolve
+	pf, err = ParseWithImports(mainPath, []string{includesDir})
+	if err != nil {
+		t.Fatalf("ParseWithImports: %v", err)
+	}
+	if len(pf.Messages) != 3 { // Main + imported
+		t.Fatalf("with proto-path: expected 3 messages, got %d", len(pf.Messages))
+	}
+}
+
+func TestParseProtoReader_Package(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(echoProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if pf.Package != "test" {
+		t.Errorf("expected package=test, got %s", pf.Package)
+	}
+}
+
+func TestDiscoverCommands_Oneof(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(oneofProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	cmds := DiscoverCommands(pf.Messages)
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+	cmd := cmds[0]
+	if cmd.Camel != "Search" {
+		t.Errorf("expected Camel=Search, got %s", cmd.Camel)
+	}
+	// Oneof fields show up as regular request fields
+	if len(cmd.RequestFields) != 2 {
+		t.Fatalf("expected 2 request fields, got %d", len(cmd.RequestFields))
+	}
+}
+
+const proto2Proto = `syntax = "proto2";
+package test;
+
+enum Mode {
+  MODE_OFF = 0;
+  MODE_ON = 1;
+}
+
+message ConfigureRequest {
+  required uint32 address = 1;
+  optional string label = 2 [default = "dev"];
+  optional Mode mode = 3 [default = MODE_ON];
+  optional uint32 retries = 4;
+}
+
+message ConfigureResponse {
+  optional bool ok = 1;
+}
+`
+
+func TestParseProtoReader_Proto2(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(proto2Proto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if pf.Syntax != "proto2" {
+		t.Errorf("expected syntax proto2, got %q", pf.Syntax)
+	}
+	fields := pf.Messages[0].Fields
+	if !fields[0].IsRequired {
+		t.Errorf("expected address to be required: %+v", fields[0])
+	}
+	if fields[1].IsRequired || !fields[1].IsOptional || fields[1].Default != `"dev"` {
+		t.Errorf("unexpected label field: %+v", fields[1])
+	}
+	if fields[2].Default != "1" {
+		t.Errorf("expected enum default resolved to 1, got %q", fields[2].Default)
+	}
+	if fields[3].Default != "" {
+		t.Errorf("expected no default for retries, got %q", fields[3].Default)
+	}
+
+	pf3, err := ParseReader(strings.NewReader(echoProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if pf3.Syntax != "proto3" {
+		t.Errorf("expected syntax proto3, got %q", pf3.Syntax)
+	}
+}
+
+func TestParseProtoReader_Proto3Optional(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(`syntax = "proto3";
+package test;
+
+message ListRequest {
+  optional uint32 limit = 1;
+  uint32 offset = 2;
+}
+`))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	fields := pf.Messages[0].Fields
+	if !fields[0].IsOptional || fields[0].IsRequired {
+		t.Errorf("expected limit to be optional: %+v", fields[0])
+	}
+	if fields[1].IsOptional {
+		t.Errorf("expected offset without a label not to be optional: %+v", fields[1])
+	}
+}
+
+const wireNameProto = `syntax = "proto3";
+package test;
+import "blerpc_options.proto";
+
+message GetBatteryRequest {}
+message GetBatteryResponse {
+  uint32 level = 1;
+}
+message EchoRequest {}
+message EchoResponse {}
+
+service TestService {
+  rpc GetBattery(GetBatteryRequest) returns (GetBatteryResponse) {
+    option (blerpc.wire_name) = "get_batt";
+    option (blerpc.renamed_from) = "ReadBattery";
+  }
+  rpc Echo(EchoRequest) returns (EchoResponse);
+}
+`
+
+func TestDiscoverCommandsFromServices_WireName(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(wireNameProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if got := pf.Services[0].RPCs[0].Options["blerpc.wire_name"]; got != "get_batt" {
+		t.Errorf("expected wire_name option get_batt, got %q", got)
+	}
+	msgByName := make(map[string]Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	cmds := DiscoverCommandsFromServices(pf.Services, msgByName)
+	if len(cmds) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(cmds))
+	}
+	if cmds[0].Snake != "get_battery" || cmds[0].Wire() != "get_batt" {
+		t.Errorf("unexpected cmd[0]: snake=%s wire=%s", cmds[0].Snake, cmds[0].Wire())
+	}
+	if cmds[0].RenamedFrom != "ReadBattery" {
+		t.Errorf("expected renamed_from ReadBattery, got %q", cmds[0].RenamedFrom)
+	}
+	if cmds[1].Wire() != "echo" {
+		t.Errorf("expected default wire name echo, got %s", cmds[1].Wire())
+	}
+	if err := ValidateWireNames(cmds); err != nil {
+		t.Errorf("ValidateWireNames: %v", err)
+	}
+}
+
+func TestValidateWireNames(t *testing.T) {
+	tests := []struct {
+		name string
+		cmds []Command
+		want string
+	}{
+		{
+			"override collides with default name",
+			[]Command{
+				{Camel: "Echo", Snake: "echo"},
+				{Camel: "Ping", Snake: "ping", WireName: "echo"},
+			},
+			`wire name "echo" used by both Echo and Ping`,
+		},
+		{
+			"two overrides collide",
+			[]Command{
+				{Camel: "A", Snake: "a", WireName: "x"},
+				{Camel: "B", Snake: "b", WireName: "x"},
+			},
+			`wire name "x" used by both A and B`,
+		},
+		{
+			"too long",
+			[]Command{{Camel: "A", Snake: "a", WireName: strings.Repeat("x", 256)}},
+			"exceeds 255 bytes",
+		},
+		{
+			"renamed without collision",
+			[]Command{
+				{Camel: "Echo", Snake: "echo", WireName: "ping"},
+				{Camel: "Ping", Snake: "ping", WireName: "echo"},
+			},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWireNames(tt.cmds)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateAliases(t *testing.T) {
+	ok := []Command{
+		{Camel: "BatteryLevel", Snake: "battery_level", RenamedFrom: "GetBattery"},
+		{Camel: "Echo", Snake: "echo"},
+	}
+	if err := ValidateAliases(ok); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	clash := []Command{
+		{Camel: "Ping", Snake: "ping", RenamedFrom: "Echo"},
+		{Camel: "Echo", Snake: "echo"},
+	}
+	if err := ValidateAliases(clash); err == nil || !strings.Contains(err.Error(), `renamed_from "Echo" clashes with Echo`) {
+		t.Errorf("expected clash error, got %v", err)
+	}
+
+	dup := []Command{
+		{Camel: "A", Snake: "a", RenamedFrom: "Old"},
+		{Camel: "B", Snake: "b", RenamedFrom: "Old"},
+	}
+	if err := ValidateAliases(dup); err == nil {
+		t.Error("expected error for duplicate aliases")
+	}
+}
+
+func TestValidateCommandCount(t *testing.T) {
+	cmds := make([]Command, 3)
+	if err := ValidateCommandCount(cmds, 3); err != nil {
+		t.Errorf("unexpected error at the limit: %v", err)
+	}
+	if err := ValidateCommandCount(cmds, 0); err != nil {
+		t.Errorf("0 should disable the check: %v", err)
+	}
+	err := ValidateCommandCount(cmds, 2)
+	if err == nil || !strings.Contains(err.Error(), "3 commands exceed the limit of 2") {
+		t.Errorf("expected limit error, got %v", err)
+	}
+}
+
+func TestParseOptionLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.options")
+	content := "# comment\n" +
+		"blerpc.EchoRequest.message        max_size:257\n" +
+		"blerpc.ListResponse.items         max_count:16 max_size:32\n" +
+		"blerpc.DataWriteRequest.data      type:FT_CALLBACK\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	limits, err := ParseOptionLimits(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := limits["EchoRequest.message"]; got != (FieldLimits{MaxSize: 257}) {
+		t.Errorf("EchoRequest.message = %+v", got)
+	}
+	if got := limits["ListResponse.items"]; got != (FieldLimits{MaxSize: 32, MaxCount: 16}) {
+		t.Errorf("ListResponse.items = %+v", got)
+	}
+	if got := limits["DataWriteRequest.data"]; got != (FieldLimits{}) {
+		t.Errorf("DataWriteRequest.data = %+v", got)
+	}
+
+	missing, err := ParseOptionLimits(filepath.Join(t.TempDir(), "missing.options"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("missing file: %v, %v", missing, err)
+	}
+}
+
+const commentProto = `
+syntax = "proto3";
+package test;
+
+// Log severity.
+enum Level {
+  LEVEL_DEBUG = 0; // verbose
+  LEVEL_ERROR = 1;
+}
+
+/*
+ * Echo — loopback test.
+ * Returns the message.
+ */
+message EchoRequest {
+  // Text to echo.
+  // Up to 256 bytes.
+  string message = 1;
+  Level level = 2; // severity to log at
+}
+message EchoResponse { string message = 1; }
+
+service Api {
+  // Call echo.
+  rpc Echo(EchoRequest) returns (EchoResponse);
+}
+`
+
+func TestParseReader_Comments(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(commentProto))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	level := pf.Enums[0]
+	if level.Comment != "Log severity." || level.Values[0].Comment != "verbose" || level.Values[1].Comment != "" {
+		t.Errorf("unexpected enum comments: %+v", level)
+	}
+	req := pf.Messages[0]
+	if req.Comment != "Echo — loopback test.\nReturns the message." {
+		t.Errorf("message comment = %q", req.Comment)
+	}
+	if req.Fields[0].Comment != "Text to echo.\nUp to 256 bytes." || req.Fields[1].Comment != "severity to log at" {
+		t.Errorf("unexpected field comments: %+v", req.Fields)
+	}
+	msgByName := make(map[string]Message)
+	for _, m := range pf.Messages {
+		msgByName[m.Name] = m
+	}
+	if cmds := DiscoverCommandsFromServices(pf.Services, msgByName); cmds[0].Comment != "Call echo." {
+		t.Errorf("rpc comment = %q", cmds[0].Comment)
+	}
+	if cmds := DiscoverCommands(pf.Messages); cmds[0].Comment != req.Comment {
+		t.Errorf("command comment = %q, want the request comment", cmds[0].Comment)
+	}
+}
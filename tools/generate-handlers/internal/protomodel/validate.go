@@ -0,0 +1,77 @@
+package protomodel
+
+import "fmt"
+
+// SchemaError is a mistake in a declaration of the schema.
+type SchemaError struct {
+	Pos Position
+	Msg string
+}
+
+func (e *SchemaError) Error() string {
+	if pos := e.Pos.String(); pos != "" {
+		return pos + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+// Validate returns every mistake in the schema that the generators would
+// otherwise turn into broken or surprising code: fields of a message sharing
+// a number or name, <Name>Request messages without a <Name>Response in a
+// schema without services, and commands whose names map to the same
+// snake_case handler name.
+func Validate(file *ProtoFile) []error {
+	var errs []error
+	msgPos := make(map[string]Position)
+	for _, m := range file.Messages {
+		msgPos[m.Name] = m.Pos
+		byNumber := make(map[int]Field)
+		byName := make(map[string]Field)
+		for _, f := range m.Fields {
+			if prev, ok := byNumber[f.Number]; ok {
+				errs = append(errs, &SchemaError{f.Pos, fmt.Sprintf("%s.%s: field number %d is already used by %s", m.Name, f.Name, f.Number, prev.Name)})
+			} else {
+				byNumber[f.Number] = f
+			}
+			if _, ok := byName[f.Name]; ok {
+				errs = append(errs, &SchemaError{f.Pos, fmt.Sprintf("%s.%s: field declared twice", m.Name, f.Name)})
+			} else {
+				byName[f.Name] = f
+			}
+		}
+	}
+
+	type named struct {
+		camel string
+		pos   Position
+	}
+	var commands []named
+	if len(file.Services) > 0 {
+		for _, svc := range file.Services {
+			for _, rpc := range svc.RPCs {
+				commands = append(commands, named{rpc.Name, rpc.Pos})
+			}
+		}
+	} else {
+		for _, name := range UnpairedRequests(file.Messages) {
+			errs = append(errs, &SchemaError{msgPos[name], fmt.Sprintf("%s has no matching Response message, so it is not a command; add the response, or define a service to use other message names", name)})
+		}
+		for _, cmd := range DiscoverCommands(file.Messages) {
+			commands = append(commands, named{cmd.Camel, msgPos[cmd.RequestMsg]})
+		}
+	}
+	bySnake := make(map[string]named)
+	for _, cmd := range commands {
+		snake := CamelToSnake(cmd.camel)
+		if prev, ok := bySnake[snake]; ok {
+			where := ""
+			if pos := prev.pos.String(); pos != "" {
+				where = " at " + pos
+			}
+			errs = append(errs, &SchemaError{cmd.pos, fmt.Sprintf("command %s and %s%s both map to the handler name %s", cmd.camel, prev.camel, where, snake)})
+			continue
+		}
+		bySnake[snake] = cmd
+	}
+	return errs
+}
@@ -0,0 +1,75 @@
+package protomodel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(`syntax = "proto3";
+package blerpc;
+
+message GetLEDRequest {
+  uint32 index = 1;
+  bool on = 1;
+  string index = 3;
+}
+message GetLEDResponse {}
+message GetLedRequest {}
+message GetLedResponse {}
+message ResetRequest {}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, err := range Validate(pf) {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"6:3: GetLEDRequest.on: field number 1 is already used by index",
+		"7:3: GetLEDRequest.index: field declared twice",
+		"12:1: ResetRequest has no matching Response message, so it is not a command; add the response, or define a service to use other message names",
+		"10:1: command GetLed and GetLED at 4:1 both map to the handler name get_led",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateServices(t *testing.T) {
+	pf, err := ParseReader(strings.NewReader(`syntax = "proto3";
+package blerpc;
+message Req {}
+message Resp {}
+message OrphanRequest {}
+service A {
+  rpc ReadURL(Req) returns (Resp);
+}
+service B {
+  rpc ReadUrl(Req) returns (Resp);
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := Validate(pf)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "command ReadUrl and ReadURL at 7:3 both map to the handler name read_url") {
+		t.Errorf("got %v", errs)
+	}
+}
+
+func TestPositionString(t *testing.T) {
+	for _, tc := range []struct {
+		pos  Position
+		want string
+	}{
+		{Position{}, ""},
+		{Position{Line: 3, Column: 5}, "3:5"},
+		{Position{Filename: "proto/blerpc.proto", Line: 3, Column: 5}, "proto/blerpc.proto:3:5"},
+	} {
+		if got := tc.pos.String(); got != tc.want {
+			t.Errorf("%+v.String() = %q, want %q", tc.pos, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package blerpcgen gives Go tools the schema model of generate-handlers:
+// parse a .proto file and discover its commands the way the generator
+// does, e.g. to write a custom target or lint a schema in CI.
+//
+//	file, err := blerpcgen.Parse("proto/blerpc.proto")
+//	if err != nil { ... }
+//	commands, streaming, err := blerpcgen.Discover(file)
+//
+// The commands are those of the schema alone; blerpc.yaml settings such as
+// built-ins, command IDs and type mappings are applied by the generator.
+//
+// Run is the generator itself, for build tools that embed it rather than
+// run the command, such as Bazel rules or mage targets. It reads the project
+// from an fs.FS and returns the files in memory:
+//
+//	opts := blerpcgen.DefaultOptions()
+//	opts.Targets, opts.Split = "c,python", "per-group"
+//	res, err := blerpcgen.Run(ctx, blerpcgen.Config{Root: os.DirFS("."), Options: opts})
+//	if err != nil { ... }
+//	for path, data := range res.Files { ... }
+//
+// Generate runs it for one target, e.g. to regenerate only the Python
+// client:
+//
+//	res, err := blerpcgen.Generate(ctx, "python", blerpcgen.Config{Root: os.DirFS(".")})
+package blerpcgen
+
+import (
+	"context"
+	"io"
+	"io/fs"
+
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/generator"
+	"github.com/tdaira/blerpc/tools/generate-handlers/internal/protomodel"
+)
+
+type (
+	// ProtoFile is a parsed schema with the files it imports.
+	ProtoFile = protomodel.ProtoFile
+	// Message is a protobuf message; nested messages are named Outer.Inner.
+	Message = protomodel.Message
+	// Field is a message field.
+	Field = protomodel.Field
+	// OneofGroup is a oneof of a message.
+	OneofGroup = protomodel.OneofGroup
+	// Enum is a protobuf enum.
+	Enum = protomodel.Enum
+	// EnumValue is a value of an enum.
+	EnumValue = protomodel.EnumValue
+	// Service is a protobuf service definition.
+	Service = protomodel.Service
+	// ServiceRPC is an RPC of a service.
+	ServiceRPC = protomodel.ServiceRPC
+	// Command is a request/response pair callable over BLE.
+	Command = protomodel.Command
+	// Position is where a declaration starts in its proto file.
+	Position = protomodel.Position
+	// SchemaError is a mistake found by Validate, at its position.
+	SchemaError = protomodel.SchemaError
+	// Options are the settings of Run: the flags of the command that shape
+	// the generated files, e.g. Split is -split. The paths of the -out-*
+	// flags are in Outputs, keyed by flag name without out-.
+	Options = generator.Options
+	// PluginRunner runs an external generator of the plugins: section of
+	// blerpc.yaml for Run; it is Options.RunPlugin.
+	PluginRunner = generator.PluginRunner
+)
+
+// DefaultOptions returns the options of the command run without flags, but
+// with an empty Python, leaving generated Python unchecked.
+func DefaultOptions() *Options {
+	return generator.DefaultOptions()
+}
+
+// ExecPlugin is the PluginRunner of the command, which executes the plugin
+// as a child process. Set it as Options.RunPlugin to run plugins as the
+// command does.
+func ExecPlugin(ctx context.Context, name, path string, request []byte, stderr io.Writer) ([]byte, error) {
+	return generator.ExecPlugin(ctx, name, path, request, stderr)
+}
+
+// Parse parses the proto file at path and the files it imports, searched
+// for next to it and in importPaths.
+func Parse(path string, importPaths ...string) (*ProtoFile, error) {
+	return protomodel.ParseWithImports(path, importPaths)
+}
+
+// Discover returns the commands of file and the direction ("p2c" or "c2p")
+// of each streaming command, keyed by snake name. Commands come from the
+// service definitions, or without services from Request/Response message
+// pairs.
+func Discover(file *ProtoFile) ([]Command, map[string]string, error) {
+	return protomodel.Discover(file)
+}
+
+// Validate returns every mistake in file the generator rejects before
+// writing any output, such as two fields sharing a number. Each error is a
+// *SchemaError.
+func Validate(file *ProtoFile) []error {
+	return protomodel.Validate(file)
+}
+
+// Config is the input of Run.
+type Config struct {
+	// Root is the project root the command takes with -root: it holds
+	// proto/blerpc.proto, blerpc.yaml and the other inputs at their default
+	// paths, and any user handler files already written.
+	Root fs.FS
+	// Options are the settings of the run; nil is DefaultOptions. Their
+	// paths are relative to Root, and so is Options.Root, "." by default.
+	// Like command-line flags, the Outputs they set win over blerpc.yaml.
+	Options *Options
+}
+
+// Result is the output of Run.
+type Result struct {
+	// Files are the generated files by slash-separated path relative to
+	// Root, as the command would write them.
+	Files map[string][]byte
+	// Warnings are the warnings the command prints, with their positions.
+	Warnings []string
+}
+
+// Run generates the project in cfg.Root, as the command does for a single
+// proto, without writing anything. The error lists the problems the command
+// would report, or is ctx.Err() when ctx ends the run early. Runs share no
+// state, so they may be concurrent. Run executes no programs: plugins fail
+// the run unless Options.RunPlugin is set, and what they write to stderr is
+// in Warnings.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	files, warnings, err := generator.Run(ctx, cfg.Root, cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Files: files, Warnings: warnings}, nil
+}
+
+// Targets returns the targets Generate takes, e.g. "c" for the C handlers
+// and "python" for the Python client.
+func Targets() []string {
+	return generator.Targets()
+}
+
+// Generate is Run with only target on, as the command runs with -targets
+// target; any Options.Targets is replaced. target is one of Targets. The
+// outputs only -out-* flags turn on, such as the Go client, are generated
+// when cfg.Options sets their paths.
+func Generate(ctx context.Context, target string, cfg Config) (*Result, error) {
+	files, warnings, err := generator.Generate(ctx, cfg.Root, target, cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Files: files, Warnings: warnings}, nil
+}
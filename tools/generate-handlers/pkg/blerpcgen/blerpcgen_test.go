@@ -0,0 +1,149 @@
+package blerpcgen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const sensorProto = `syntax = "proto3";
+package acme;
+
+message ReadRequest {
+  uint32 channel = 1;
+}
+
+message ReadResponse {
+  int32 value = 1;
+}
+
+message SubscribeRequest {
+  uint32 channel = 1;
+}
+
+message SubscribeResponse {
+  int32 value = 1;
+}
+
+service Sensor {
+  rpc Read(ReadRequest) returns (ReadResponse);
+  rpc Subscribe(SubscribeRequest) returns (stream SubscribeResponse);
+}
+`
+
+func TestParseAndDiscover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sensor.proto")
+	if err := os.WriteFile(path, []byte(sensorProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if file.Package != "acme" || len(file.Messages) != 4 {
+		t.Fatalf("unexpected file: package %q, %d messages", file.Package, len(file.Messages))
+	}
+
+	commands, streaming, err := Discover(file)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(commands) != 2 || commands[0].Snake != "read" || commands[1].ResponseMsg != "SubscribeResponse" {
+		t.Errorf("unexpected commands: %+v", commands)
+	}
+	if len(streaming) != 1 || streaming["subscribe"] != "p2c" {
+		t.Errorf("unexpected streaming: %v", streaming)
+	}
+}
+
+func TestDiscoverRejectsUnknownTypes(t *testing.T) {
+	file := &ProtoFile{
+		Services: []Service{{Name: "Sensor", RPCs: []ServiceRPC{{Name: "Read", RequestType: "ReadRequest", ResponseType: "ReadResponse"}}}},
+	}
+	if _, _, err := Discover(file); err == nil {
+		t.Error("expected an error for undefined messages")
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := fstest.MapFS{
+		"proto/blerpc.proto": {Data: []byte(sensorProto)},
+		"blerpc.yaml":        {Data: []byte("outputs:\n  py-client: client/acme.py\n")},
+	}
+	opts := DefaultOptions()
+	opts.Targets = "c,python"
+	res, err := Run(context.Background(), Config{Root: root, Options: opts})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	header := string(res.Files["peripheral_fw/src/generated_handlers.h"])
+	if !strings.Contains(header, "int handle_read(") {
+		t.Errorf("C header missing handle_read:\n%s", header)
+	}
+	if _, ok := res.Files["client/acme.py"]; !ok {
+		t.Errorf("Python client not at its blerpc.yaml path: %v", res.Files)
+	}
+	if _, ok := res.Files["central_android/app/src/main/java/com/blerpc/android/client/GeneratedClient.kt"]; ok {
+		t.Error("Kotlin client generated outside -targets")
+	}
+
+	// Options win over blerpc.yaml, as flags do on the command line.
+	opts = DefaultOptions()
+	opts.Targets = "python"
+	opts.Outputs["py-client"] = "gen/client.py"
+	res, err = Run(context.Background(), Config{Root: root, Options: opts})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, ok := res.Files["gen/client.py"]; !ok {
+		t.Errorf("Python client not at -out-py-client: %v", res.Files)
+	}
+
+	if _, err := Run(context.Background(), Config{Root: fstest.MapFS{}}); err == nil || !strings.Contains(err.Error(), "parse proto: ") {
+		t.Errorf("expected a parse error without a proto, got %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	root := fstest.MapFS{"proto/blerpc.proto": {Data: []byte(sensorProto)}}
+	if !slices.Contains(Targets(), "python") {
+		t.Fatalf("Targets() = %q", Targets())
+	}
+	res, err := Generate(context.Background(), "python", Config{Root: root})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, ok := res.Files["central_py/blerpc/generated/generated_client.py"]; !ok {
+		t.Errorf("Python client missing: %v", res.Files)
+	}
+	for path := range res.Files {
+		if !strings.HasPrefix(path, "central_py/") {
+			t.Errorf("%s generated for the python target", path)
+		}
+	}
+
+	// The target replaces Options.Targets.
+	opts := DefaultOptions()
+	opts.Targets = "python"
+	res, err = Generate(context.Background(), "c", Config{Root: root, Options: opts})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, ok := res.Files["peripheral_fw/src/generated_handlers.h"]; !ok {
+		t.Errorf("C header missing: %v", res.Files)
+	}
+	if _, ok := res.Files["central_py/blerpc/generated/generated_client.py"]; ok {
+		t.Error("Python client generated for the c target")
+	}
+	if opts.Targets != "python" {
+		t.Errorf("Generate changed the caller's Options.Targets to %q", opts.Targets)
+	}
+
+	if _, err := Generate(context.Background(), "c,python", Config{Root: root}); err == nil || !strings.Contains(err.Error(), `unknown target "c,python"`) {
+		t.Errorf("expected an unknown target error, got %v", err)
+	}
+}